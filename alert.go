@@ -5,7 +5,13 @@ import (
 	"encoding/json"
 	"fmt"
 	html "html/template"
+	"math"
+	"net/http"
 	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	text "text/template"
 	"time"
@@ -18,6 +24,7 @@ import (
 	"github.com/influxdata/kapacitor/pipeline"
 	alertservice "github.com/influxdata/kapacitor/services/alert"
 	"github.com/influxdata/kapacitor/services/hipchat"
+	"github.com/influxdata/kapacitor/services/httpd"
 	"github.com/influxdata/kapacitor/services/httppost"
 	"github.com/influxdata/kapacitor/services/kafka"
 	"github.com/influxdata/kapacitor/services/mqtt"
@@ -45,6 +52,13 @@ const (
 	statsWarnsTriggered  = "warns_triggered"
 	statsCritsTriggered  = "crits_triggered"
 	statsEventsDropped   = "events_dropped"
+
+	statsHandlerEventsDropped = "handler_events_dropped"
+
+	statsEventsRedelivered = "events_redelivered"
+	statsEventsExpired     = "events_expired"
+
+	statsAlertsAcknowledged = "alerts_acknowledged"
 )
 
 // The newest state change is weighted 'weightDiff' times more than oldest state change.
@@ -53,6 +67,29 @@ const weightDiff = 1.5
 // Maximum weight applied to newest state change.
 const maxWeight = 1.2
 
+// Name of the rate of change in the level expressions.
+const rocVar = "roc"
+
+// Parameters of the rolling percentiles estimator.
+const (
+	// Relative accuracy of the estimated percentiles.
+	percentilesAccuracy = 0.01
+	// Number of panes of the window, the values expire a pane at a time.
+	percentilesPanes = 10
+	// Maximum number of buckets of a pane for either sign, the buckets closest to zero are collapsed beyond it.
+	percentilesMaxBuckets = 2048
+)
+
+// Names of the aggregates of the batch context in the level expressions.
+const (
+	batchContextCountVar       = "count"
+	batchContextCountOverVar   = "count_over"
+	batchContextPercentOverVar = "percent_over"
+	batchContextMinVar         = "min"
+	batchContextMaxVar         = "max"
+	batchContextMeanVar        = "mean"
+)
+
 type AlertNode struct {
 	node
 	a           *pipeline.AlertNode
@@ -73,10 +110,41 @@ type AlertNode struct {
 	critsTriggered  *expvar.Int
 	eventsDropped   *expvar.Int
 
+	alertsAcknowledged *expvar.Int
+
+	limitedHandlers      []*alert.LimitedHandler
+	handlerEventsDropped *expvar.Int
+
+	// retries redelivers the events handlers failed to deliver, if durable retries are enabled.
+	retries           *alert.RetryQueue
+	eventsRedelivered *expvar.Int
+	eventsExpired     *expvar.Int
+
 	bufPool sync.Pool
 
+	acks *alertAcks
+
+	// summary collects events when events are summarized at an interval.
+	summary *alertSummary
+
+	// groupStates keeps the state of the groups for the task snapshots, if it is persisted.
+	groupStates *groupStates
+
+	// sorted tags the alert identity is computed from, nil if each group is an alert
+	idTags []string
+	// states of the alert identities, shared by the groups with the same identity
+	identities map[models.GroupID]*identityState
+
+	mu     sync.Mutex
+	routes []httpd.Route
+
 	levelResets  []stateful.Expression
 	lrScopePools []stateful.ScopePool
+
+	// names of the rolling percentiles in the level expressions
+	percentileVars []string
+	// number of values the window must have before the rolling percentiles are computed
+	percentilesMinCount int64
 }
 
 // Create a new  AlertNode which caches the most recent item and exposes it over the HTTP API.
@@ -88,8 +156,29 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, d NodeDiagnostic) (a
 	an = &AlertNode{
 		node: node{Node: n, et: et, diag: d},
 		a:    n,
+		acks: newAlertAcks(),
+
+		handlerEventsDropped: &expvar.Int{},
+		eventsRedelivered:    &expvar.Int{},
+		eventsExpired:        &expvar.Int{},
+	}
+	if n.SummaryInterval > 0 {
+		an.summary = newAlertSummary()
+	}
+	if n.DurableRetryMaxAge > 0 {
+		an.retries = alert.NewRetryQueue(n.DurableRetryMaxAge, n.DurableRetryInterval, et.saveSnapshot, an.eventsRedelivered, an.eventsExpired)
+	}
+	if n.PersistStateFlag {
+		an.groupStates = newGroupStates(alertGroupStateVersion, d)
+	}
+	if len(n.IDTags) > 0 {
+		an.idTags = make([]string, len(n.IDTags))
+		copy(an.idTags, n.IDTags)
+		sort.Strings(an.idTags)
+		an.identities = make(map[models.GroupID]*identityState)
 	}
 	an.node.runF = an.runAlert
+	an.node.stopF = an.stopAlert
 
 	an.topic = n.Topic
 	// Create anonymous topic name
@@ -136,20 +225,30 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, d NodeDiagnostic) (a
 			Address: tcp.Address,
 		}
 		h := alertservice.NewTCPHandler(c, an.diag)
-		an.handlers = append(an.handlers, h)
+		an.addHandler("tcp", tcp.LevelsList, h)
 	}
 
 	for _, email := range n.EmailHandlers {
 		c := smtp.HandlerConfig{
-			To: email.ToList,
+			To:         email.ToList,
+			ToTag:      email.ToTag,
+			Body:       email.Body,
+			Text:       email.Text,
+			AttachData: email.AttachDataFlag,
+		}
+		h, err := et.tm.SMTPService.Handler(c, ctx...)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create email handler")
 		}
-		h := et.tm.SMTPService.Handler(c, ctx...)
-		an.handlers = append(an.handlers, h)
+		an.addHandler("email", email.LevelsList, h)
 	}
 	if len(n.EmailHandlers) == 0 && (et.tm.SMTPService != nil && et.tm.SMTPService.Global()) {
 		c := smtp.HandlerConfig{}
-		h := et.tm.SMTPService.Handler(c, ctx...)
-		an.handlers = append(an.handlers, h)
+		h, err := et.tm.SMTPService.Handler(c, ctx...)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create email handler")
+		}
+		an.addHandler("email", nil, h)
 	}
 	// If email has been configured with state changes only set it.
 	if et.tm.SMTPService != nil &&
@@ -165,7 +264,7 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, d NodeDiagnostic) (a
 			Commander: et.tm.Commander,
 		}
 		h := alertservice.NewExecHandler(c, an.diag)
-		an.handlers = append(an.handlers, h)
+		an.addHandler("exec", e.LevelsList, h)
 	}
 
 	for _, log := range n.LogHandlers {
@@ -178,7 +277,7 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, d NodeDiagnostic) (a
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to create log alert handler")
 		}
-		an.handlers = append(an.handlers, h)
+		an.addHandler("log", log.LevelsList, h)
 	}
 
 	for _, vo := range n.VictorOpsHandlers {
@@ -186,12 +285,12 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, d NodeDiagnostic) (a
 			RoutingKey: vo.RoutingKey,
 		}
 		h := et.tm.VictorOpsService.Handler(c, ctx...)
-		an.handlers = append(an.handlers, h)
+		an.addHandler("victorOps", vo.LevelsList, h)
 	}
 	if len(n.VictorOpsHandlers) == 0 && (et.tm.VictorOpsService != nil && et.tm.VictorOpsService.Global()) {
 		c := victorops.HandlerConfig{}
 		h := et.tm.VictorOpsService.Handler(c, ctx...)
-		an.handlers = append(an.handlers, h)
+		an.addHandler("victorOps", nil, h)
 	}
 
 	for _, pd := range n.PagerDutyHandlers {
@@ -199,12 +298,12 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, d NodeDiagnostic) (a
 			ServiceKey: pd.ServiceKey,
 		}
 		h := et.tm.PagerDutyService.Handler(c, ctx...)
-		an.handlers = append(an.handlers, h)
+		an.addHandler("pagerDuty", pd.LevelsList, h)
 	}
 	if len(n.PagerDutyHandlers) == 0 && (et.tm.PagerDutyService != nil && et.tm.PagerDutyService.Global()) {
 		c := pagerduty.HandlerConfig{}
 		h := et.tm.PagerDutyService.Handler(c, ctx...)
-		an.handlers = append(an.handlers, h)
+		an.addHandler("pagerDuty", nil, h)
 	}
 
 	for _, pd := range n.PagerDuty2Handlers {
@@ -212,12 +311,12 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, d NodeDiagnostic) (a
 			RoutingKey: pd.ServiceKey,
 		}
 		h := et.tm.PagerDuty2Service.Handler(c, ctx...)
-		an.handlers = append(an.handlers, h)
+		an.addHandler("pagerDuty2", pd.LevelsList, h)
 	}
 	if len(n.PagerDuty2Handlers) == 0 && (et.tm.PagerDuty2Service != nil && et.tm.PagerDuty2Service.Global()) {
 		c := pagerduty2.HandlerConfig{}
 		h := et.tm.PagerDuty2Service.Handler(c, ctx...)
-		an.handlers = append(an.handlers, h)
+		an.addHandler("pagerDuty2", nil, h)
 	}
 
 	for _, s := range n.SensuHandlers {
@@ -229,7 +328,7 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, d NodeDiagnostic) (a
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to create sensu alert handler")
 		}
-		an.handlers = append(an.handlers, h)
+		an.addHandler("sensu", s.LevelsList, h)
 	}
 
 	for _, s := range n.SlackHandlers {
@@ -240,11 +339,11 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, d NodeDiagnostic) (a
 			IconEmoji: s.IconEmoji,
 		}
 		h := et.tm.SlackService.Handler(c, ctx...)
-		an.handlers = append(an.handlers, h)
+		an.addHandler("slack", s.LevelsList, h)
 	}
 	if len(n.SlackHandlers) == 0 && (et.tm.SlackService != nil && et.tm.SlackService.Global()) {
 		h := et.tm.SlackService.Handler(slack.HandlerConfig{}, ctx...)
-		an.handlers = append(an.handlers, h)
+		an.addHandler("slack", nil, h)
 	}
 	// If slack has been configured with state changes only set it.
 	if et.tm.SlackService != nil &&
@@ -261,7 +360,7 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, d NodeDiagnostic) (a
 			DisableNotification:   t.IsDisableNotification,
 		}
 		h := et.tm.TelegramService.Handler(c, ctx...)
-		an.handlers = append(an.handlers, h)
+		an.addHandler("telegram", t.LevelsList, h)
 	}
 
 	for _, s := range n.SNMPTrapHandlers {
@@ -281,13 +380,13 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, d NodeDiagnostic) (a
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to create SNMP handler")
 		}
-		an.handlers = append(an.handlers, h)
+		an.addHandler("snmpTrap", s.LevelsList, h)
 	}
 
 	if len(n.TelegramHandlers) == 0 && (et.tm.TelegramService != nil && et.tm.TelegramService.Global()) {
 		c := telegram.HandlerConfig{}
 		h := et.tm.TelegramService.Handler(c, ctx...)
-		an.handlers = append(an.handlers, h)
+		an.addHandler("telegram", nil, h)
 	}
 	// If telegram has been configured with state changes only set it.
 	if et.tm.TelegramService != nil &&
@@ -302,12 +401,12 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, d NodeDiagnostic) (a
 			Token: hc.Token,
 		}
 		h := et.tm.HipChatService.Handler(c, ctx...)
-		an.handlers = append(an.handlers, h)
+		an.addHandler("hipChat", hc.LevelsList, h)
 	}
 	if len(n.HipChatHandlers) == 0 && (et.tm.HipChatService != nil && et.tm.HipChatService.Global()) {
 		c := hipchat.HandlerConfig{}
 		h := et.tm.HipChatService.Handler(c, ctx...)
-		an.handlers = append(an.handlers, h)
+		an.addHandler("hipChat", nil, h)
 	}
 	// If HipChat has been configured with state changes only set it.
 	if et.tm.HipChatService != nil &&
@@ -326,7 +425,7 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, d NodeDiagnostic) (a
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to create kafka handler")
 		}
-		an.handlers = append(an.handlers, h)
+		an.addHandler("kafka", k.LevelsList, h)
 	}
 
 	for _, a := range n.AlertaHandlers {
@@ -362,7 +461,7 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, d NodeDiagnostic) (a
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to create Alerta handler")
 		}
-		an.handlers = append(an.handlers, h)
+		an.addHandler("alerta", a.LevelsList, h)
 	}
 
 	for _, p := range n.PushoverHandlers {
@@ -383,7 +482,7 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, d NodeDiagnostic) (a
 			c.Sound = p.Sound
 		}
 		h := et.tm.PushoverService.Handler(c, ctx...)
-		an.handlers = append(an.handlers, h)
+		an.addHandler("pushover", p.LevelsList, h)
 	}
 
 	for _, p := range n.HTTPPostHandlers {
@@ -395,7 +494,7 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, d NodeDiagnostic) (a
 			Timeout:         p.Timeout,
 		}
 		h := et.tm.HTTPPostService.Handler(c, ctx...)
-		an.handlers = append(an.handlers, h)
+		an.addHandler("post", p.LevelsList, h)
 	}
 
 	for _, og := range n.OpsGenieHandlers {
@@ -404,12 +503,12 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, d NodeDiagnostic) (a
 			RecipientsList: og.RecipientsList,
 		}
 		h := et.tm.OpsGenieService.Handler(c, ctx...)
-		an.handlers = append(an.handlers, h)
+		an.addHandler("opsGenie", og.LevelsList, h)
 	}
 	if len(n.OpsGenieHandlers) == 0 && (et.tm.OpsGenieService != nil && et.tm.OpsGenieService.Global()) {
 		c := opsgenie.HandlerConfig{}
 		h := et.tm.OpsGenieService.Handler(c, ctx...)
-		an.handlers = append(an.handlers, h)
+		an.addHandler("opsGenie", nil, h)
 	}
 	for _, og := range n.OpsGenie2Handlers {
 		c := opsgenie2.HandlerConfig{
@@ -417,17 +516,22 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, d NodeDiagnostic) (a
 			RecipientsList: og.RecipientsList,
 		}
 		h := et.tm.OpsGenie2Service.Handler(c, ctx...)
-		an.handlers = append(an.handlers, h)
+		an.addHandler("opsGenie2", og.LevelsList, h)
 	}
 	if len(n.OpsGenie2Handlers) == 0 && (et.tm.OpsGenie2Service != nil && et.tm.OpsGenie2Service.Global()) {
 		c := opsgenie2.HandlerConfig{}
 		h := et.tm.OpsGenie2Service.Handler(c, ctx...)
-		an.handlers = append(an.handlers, h)
+		an.addHandler("opsGenie2", nil, h)
 	}
 
-	for range n.TalkHandlers {
+	for _, t := range n.TalkHandlers {
 		h := et.tm.TalkService.Handler(ctx...)
-		an.handlers = append(an.handlers, h)
+		an.addHandler("talk", t.LevelsList, h)
+	}
+
+	for _, o := range n.OpenTelemetryHandlers {
+		h := et.tm.OpenTelemetryService.Handler(ctx...)
+		an.addHandler("openTelemetry", o.LevelsList, h)
 	}
 
 	for _, m := range n.MQTTHandlers {
@@ -438,8 +542,20 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, d NodeDiagnostic) (a
 			Retained:   m.Retained,
 		}
 		h := et.tm.MQTTService.Handler(c, ctx...)
-		an.handlers = append(an.handlers, h)
+		an.addHandler("mqtt", m.LevelsList, h)
 	}
+	if n.PercentilesField != "" {
+		an.percentileVars = make([]string, len(n.PercentilesRanks))
+		max := 0.0
+		for i, p := range n.PercentilesRanks {
+			an.percentileVars[i] = "p" + strings.Replace(strconv.FormatFloat(p, 'f', -1, 64), ".", "_", 1)
+			max = math.Max(max, p)
+		}
+		// The smallest number of values for which the highest percentile is not the maximum,
+		// rounded down slightly so that e.g. 99.9 needs 1000 values and not 1001 due to floating point errors.
+		an.percentilesMinCount = int64(math.Ceil(100/(100-max) - 1e-9))
+	}
+
 	// Parse level expressions
 	an.levels = make([]stateful.Expression, alert.Critical+1)
 	an.scopePools = make([]stateful.ScopePool, alert.Critical+1)
@@ -514,7 +630,55 @@ func newAlertNode(et *ExecutingTask, n *pipeline.AlertNode, d NodeDiagnostic) (a
 	return
 }
 
-func (n *AlertNode) runAlert([]byte) error {
+// addHandler adds a handler of the given kind, retrying failed deliveries and limiting its concurrency if configured.
+// If levels are given the handler is only notified of events of those levels and their recoveries.
+func (n *AlertNode) addHandler(kind string, levels []string, h alert.Handler) {
+	if n.retries != nil {
+		h = n.retries.Wrap(h)
+	}
+	for _, l := range n.a.HandlerLimits {
+		if l.Handler == kind {
+			lh := alert.NewLimitedHandler(h, int(l.Concurrency), int(l.QueueSize), n.handlerEventsDropped)
+			n.limitedHandlers = append(n.limitedHandlers, lh)
+			h = lh
+			break
+		}
+	}
+	if len(levels) > 0 {
+		ls := make([]alert.Level, len(levels))
+		for i, l := range levels {
+			// Levels have been validated by the pipeline.
+			ls[i], _ = alert.ParseLevel(l)
+		}
+		h = alert.NewLevelHandler(h, ls)
+	}
+	n.handlers = append(n.handlers, h)
+}
+
+func (n *AlertNode) runAlert(snapshot []byte) error {
+	s := n.decodeSnapshot(snapshot)
+	if n.groupStates != nil {
+		n.groupStates.load(s.Groups)
+	}
+	if n.retries != nil {
+		if err := n.retries.Restore(s.Retries); err != nil {
+			return errors.Wrap(err, "failed to restore undelivered alerts")
+		}
+		n.statMap.Set(statsEventsRedelivered, n.eventsRedelivered)
+		n.statMap.Set(statsEventsExpired, n.eventsExpired)
+		n.retries.Open()
+		defer n.retries.Close()
+	}
+
+	for _, h := range n.limitedHandlers {
+		h.Open()
+	}
+	defer func() {
+		for _, h := range n.limitedHandlers {
+			h.Close()
+		}
+	}()
+
 	// Register delete hook
 	if n.hasAnonTopic() {
 		n.et.tm.registerDeleteHookForTask(n.et.Task.ID, deleteAlertHook(n.anonTopic))
@@ -549,6 +713,34 @@ func (n *AlertNode) runAlert([]byte) error {
 	n.eventsDropped = &expvar.Int{}
 	n.statMap.Set(statsCritsTriggered, n.critsTriggered)
 
+	n.alertsAcknowledged = &expvar.Int{}
+	n.statMap.Set(statsAlertsAcknowledged, n.alertsAcknowledged)
+
+	n.statMap.Set(statsHandlerEventsDropped, n.handlerEventsDropped)
+
+	// Register ack endpoint
+	routes := []httpd.Route{{
+		Method:      "POST",
+		Pattern:     path.Join("/tasks/", n.et.Task.ID, n.Name(), "ack"),
+		HandlerFunc: n.handleAck,
+	}}
+	n.mu.Lock()
+	n.routes = routes
+	n.mu.Unlock()
+	if err := n.et.tm.HTTPDService.AddRoutes(routes); err != nil {
+		return err
+	}
+
+	// Start summary emitter
+	if n.summary != nil {
+		stop := n.summary.start(n.a.SummaryInterval, func(now time.Time) {
+			if event, ok := n.summary.summarize(n.summaryID(), n.et.Task.ID, n.a.Category, now, !n.a.NoRecoveriesFlag); ok {
+				n.collect(event)
+			}
+		})
+		defer stop()
+	}
+
 	// Setup consumer
 	consumer := edge.NewGroupedConsumer(
 		n.ins[0],
@@ -571,30 +763,141 @@ func (n *AlertNode) runAlert([]byte) error {
 	return nil
 }
 
+// alertSnapshot is the snapshot of an alert node persisting the state of its groups.
+// Without the state of the groups the snapshot only has the events waiting to be redelivered.
+type alertSnapshot struct {
+	Retries json.RawMessage `json:"retries,omitempty"`
+	Groups  json.RawMessage `json:"groups,omitempty"`
+}
+
+// snapshot returns the events waiting to be redelivered and the state of the groups if it is persisted.
+func (n *AlertNode) snapshot() ([]byte, error) {
+	var retries []byte
+	if n.retries != nil {
+		var err error
+		if retries, err = n.retries.Snapshot(); err != nil {
+			return nil, err
+		}
+	}
+	if n.groupStates == nil {
+		return retries, nil
+	}
+	groups, err := n.groupStates.snapshot()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(alertSnapshot{
+		Retries: retries,
+		Groups:  groups,
+	})
+}
+
+// decodeSnapshot decodes the data returned by snapshot,
+// with or without the state of the groups whatever the node persists now.
+func (n *AlertNode) decodeSnapshot(data []byte) alertSnapshot {
+	if len(data) == 0 {
+		return alertSnapshot{}
+	}
+	// The events waiting to be redelivered are a list.
+	if data[0] != '{' {
+		return alertSnapshot{Retries: data}
+	}
+	var s alertSnapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		n.diag.Error("discarding alert snapshot", err)
+		return alertSnapshot{}
+	}
+	return s
+}
+
+func (n *AlertNode) stopAlert() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.et.tm.HTTPDService.DelRoutes(n.routes)
+}
+
+type ackRequest struct {
+	Group string `json:"group"`
+	Level string `json:"level"`
+}
+
+// handleAck acknowledges the active alert of a group.
+func (n *AlertNode) handleAck(w http.ResponseWriter, r *http.Request) {
+	req := ackRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpd.HttpError(w, fmt.Sprintf("invalid ack request: %v", err), true, http.StatusBadRequest)
+		return
+	}
+	if req.Group == "" {
+		httpd.HttpError(w, "must provide the group of the alert", true, http.StatusBadRequest)
+		return
+	}
+	// The OK level matches any active level.
+	level := alert.OK
+	if req.Level != "" {
+		l, err := alert.ParseLevel(req.Level)
+		if err != nil {
+			httpd.HttpError(w, err.Error(), true, http.StatusBadRequest)
+			return
+		}
+		level = l
+	}
+	if err := n.acks.ack(models.GroupID(req.Group), level, time.Now()); err != nil {
+		code := http.StatusConflict
+		if err == errAlertNotActive {
+			code = http.StatusNotFound
+		}
+		httpd.HttpError(w, err.Error(), true, code)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
 func (n *AlertNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
-	id, err := n.renderID(first.Name(), first.GroupID(), first.Tags())
+	alertGroup := n.alertGroup(first.Name(), first.GroupID(), first.Dimensions(), first.Tags())
+	id, err := n.renderID(first.Name(), alertGroup, first.Tags())
 	if err != nil {
 		return nil, err
 	}
 	t := first.Time()
 
-	state := n.restoreEventState(id, t, group.Tags)
-
+	var state *alertState
+	var r edge.ForwardReceiver
+	if n.identities != nil {
+		identity, ok := n.identities[alertGroup]
+		if !ok {
+			identity = &identityState{alertState: n.restoreEventState(id, t, alertGroup, group.Tags)}
+			n.identities[alertGroup] = identity
+		}
+		identity.groups++
+		state = identity.alertState
+		r = &identityReceiver{
+			identityState: identity,
+			group:         alertGroup,
+		}
+	} else {
+		state = n.restoreEventState(id, t, alertGroup, group.Tags)
+		r = state
+	}
+	if n.groupStates != nil {
+		r = n.groupStates.add(group.ID, state, r)
+	}
 	return edge.NewReceiverFromForwardReceiverWithStats(
 		n.outs,
 		edge.NewTimedForwardReceiver(
 			n.timer,
-			state,
+			r,
 		),
 	), nil
 }
 
-func (n *AlertNode) restoreEventState(id string, t time.Time, tags models.Tags) *alertState {
+func (n *AlertNode) restoreEventState(id string, t time.Time, group models.GroupID, tags models.Tags) *alertState {
 	state := n.newAlertState(tags)
 	currentLevel, triggered := n.restoreEvent(id)
 	if currentLevel != alert.OK {
 		// Add initial event
 		state.addEvent(t, currentLevel)
+		n.acks.update(group, currentLevel)
 		// Record triggered time
 		state.triggered(triggered)
 	}
@@ -613,12 +916,22 @@ func (n *AlertNode) newAlertState(tags models.Tags) *alertState {
 		inhibitors[i] = inhibitor
 		n.et.tm.AlertService.AddInhibitor(inhibitor)
 	}
-	return &alertState{
+	state := &alertState{
 		history:    make([]alert.Level, n.a.History),
 		n:          n,
 		buffer:     new(edge.BatchBuffer),
 		inhibitors: inhibitors,
 	}
+	if n.a.ValueHistoryField != "" {
+		state.values = newValueHistory(int(n.a.ValueHistoryCount))
+	}
+	if n.a.RocField != "" {
+		state.roc = &rocHistory{lookback: n.a.RocLookback}
+	}
+	if n.a.PercentilesField != "" {
+		state.percentiles = &percentileHistory{window: n.a.PercentilesWindow}
+	}
+	return state
 }
 
 func (n *AlertNode) restoreEvent(id string) (alert.Level, time.Time) {
@@ -683,6 +996,12 @@ func (n *AlertNode) handleEvent(event alert.Event) {
 		return
 	}
 
+	// Check if alert is acknowledged, recoveries are always sent.
+	if event.State.Level != alert.OK && n.acks.acknowledged(models.GroupID(event.Data.Group), time.Now(), n.a.AckTimeout) {
+		n.alertsAcknowledged.Add(1)
+		return
+	}
+
 	n.alertsTriggered.Add(1)
 	switch event.State.Level {
 	case alert.OK:
@@ -696,6 +1015,16 @@ func (n *AlertNode) handleEvent(event alert.Event) {
 	}
 	n.diag.AlertTriggered(event.State.Level, event.State.ID, event.State.Message, event.Data.Result.Series[0])
 
+	// Events are sent with the next summary instead.
+	if n.summary != nil {
+		n.summary.add(event)
+		return
+	}
+	n.collect(event)
+}
+
+// collect emits the event to the topics of the node.
+func (n *AlertNode) collect(event alert.Event) {
 	// If we have anon handlers, emit event to the anonTopic
 	if n.hasAnonTopic() {
 		event.Topic = n.anonTopic
@@ -758,12 +1087,13 @@ func (n *AlertNode) event(
 	group models.GroupID,
 	tags models.Tags,
 	fields models.Fields,
+	history []interface{},
 	level alert.Level,
 	t time.Time,
 	d time.Duration,
 	result models.Result,
 ) (alert.Event, error) {
-	msg, details, err := n.renderMessageAndDetails(id, name, t, group, tags, fields, level, d)
+	msg, details, err := n.renderMessageAndDetails(id, name, t, group, tags, fields, history, level, d)
 	if err != nil {
 		return alert.Event{}, err
 	}
@@ -799,6 +1129,15 @@ type alertState struct {
 	history []alert.Level
 	idx     int
 
+	// recent values of the value history field, nil if not kept
+	values *valueHistory
+
+	// values of the rate of change field, nil if the rate of change is not computed
+	roc *rocHistory
+
+	// rolling percentiles of the percentiles field, nil if they are not computed
+	percentiles *percentileHistory
+
 	flapping bool
 
 	changed bool
@@ -826,44 +1165,68 @@ func (a *alertState) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
 
 func (a *alertState) BufferedBatch(b edge.BufferedBatchMessage) (edge.Message, error) {
 	begin := b.Begin()
-	id, err := a.n.renderID(begin.Name(), begin.GroupID(), begin.Tags())
+	group := a.n.alertGroup(begin.Name(), begin.GroupID(), begin.Dimensions(), begin.Tags())
+	id, err := a.n.renderID(begin.Name(), group, begin.Tags())
 	if err != nil {
 		return nil, err
 	}
 	if len(b.Points()) == 0 {
 		return nil, nil
 	}
-	// Keep track of lowest level for any point
-	lowestLevel := alert.Critical
-	// Keep track of highest level and point
-	highestLevel := alert.OK
-	var highestPoint edge.BatchPointMessage
-
-	currentLevel := a.currentLevel()
-	for _, bp := range b.Points() {
-		l := a.n.determineLevel(bp, currentLevel)
-		if l < lowestLevel {
-			lowestLevel = l
+	var l alert.Level
+	var t time.Time
+	var highestPoint edge.FieldsTagsTimeGetter
+	if a.n.a.BatchContextField != "" {
+		p, ok := a.batchContext(b)
+		if !ok {
+			// No point has a value of the field.
+			return nil, nil
 		}
-		if l > highestLevel || highestPoint == nil {
-			highestLevel = l
-			highestPoint = bp
+		l = a.n.determineLevel(p, a.currentLevel())
+		t = p.Time()
+		highestPoint = p
+	} else {
+		// Keep track of lowest level for any point
+		lowestLevel := alert.Critical
+		// Keep track of highest level and point
+		highestLevel := alert.OK
+
+		currentLevel := a.currentLevel()
+		for _, bp := range b.Points() {
+			a.recordValue(bp.Fields())
+			p, ok := a.levelContext(bp)
+			if !ok {
+				continue
+			}
+			l := a.n.determineLevel(p, currentLevel)
+			if l < lowestLevel {
+				lowestLevel = l
+			}
+			if l > highestLevel || highestPoint == nil {
+				highestLevel = l
+				highestPoint = p
+			}
+		}
+		if highestPoint == nil {
+			// No point has a rate of change or rolling percentiles.
+			return nil, nil
 		}
-	}
 
-	// Default the determined level to lowest.
-	l := lowestLevel
-	// Update determined level to highest if we don't care about all
-	if !a.n.a.AllFlag {
-		l = highestLevel
-	}
-	// Create alert Data
-	t := highestPoint.Time()
-	if a.n.a.AllFlag || l == alert.OK {
-		t = begin.Time()
+		// Default the determined level to lowest.
+		l = lowestLevel
+		// Update determined level to highest if we don't care about all
+		if !a.n.a.AllFlag {
+			l = highestLevel
+		}
+		// Create alert Data
+		t = highestPoint.Time()
+		if a.n.a.AllFlag || l == alert.OK {
+			t = begin.Time()
+		}
 	}
 
 	a.addEvent(t, l)
+	a.n.acks.update(group, l)
 
 	// Trigger alert only if:
 	//  l == OK and state.changed (aka recovery)
@@ -884,7 +1247,7 @@ func (a *alertState) BufferedBatch(b edge.BufferedBatchMessage) (edge.Message, e
 	}
 
 	duration := a.duration()
-	event, err := a.n.event(id, begin.Name(), begin.GroupID(), begin.Tags(), highestPoint.Fields(), l, t, duration, b.ToResult())
+	event, err := a.n.event(id, begin.Name(), group, begin.Tags(), highestPoint.Fields(), a.recentValues(), l, t, duration, b.ToResult())
 	if err != nil {
 		return nil, err
 	}
@@ -917,13 +1280,20 @@ func (a *alertState) BufferedBatch(b edge.BufferedBatchMessage) (edge.Message, e
 }
 
 func (a *alertState) Point(p edge.PointMessage) (edge.Message, error) {
-	id, err := a.n.renderID(p.Name(), p.GroupID(), p.Tags())
+	group := a.n.alertGroup(p.Name(), p.GroupID(), p.Dimensions(), p.Tags())
+	id, err := a.n.renderID(p.Name(), group, p.Tags())
 	if err != nil {
 		return nil, err
 	}
-	l := a.n.determineLevel(p, a.currentLevel())
+	a.recordValue(p.Fields())
+	rp, ok := a.levelContext(p)
+	if !ok {
+		return nil, nil
+	}
+	l := a.n.determineLevel(rp, a.currentLevel())
 
 	a.addEvent(p.Time(), l)
+	a.n.acks.update(group, l)
 
 	if (a.n.a.UseFlapping && a.flapping) || (a.n.a.IsStateChangesOnly && !a.changed && !a.expired) {
 		return nil, nil
@@ -940,9 +1310,10 @@ func (a *alertState) Point(p edge.PointMessage) (edge.Message, error) {
 		event, err := a.n.event(
 			id,
 			p.Name(),
-			p.GroupID(),
+			group,
 			p.Tags(),
-			p.Fields(),
+			rp.Fields(),
+			a.recentValues(),
 			l,
 			p.Time(),
 			duration,
@@ -963,6 +1334,142 @@ func (a *alertState) Point(p edge.PointMessage) (edge.Message, error) {
 	return nil, nil
 }
 
+// recordValue adds the value of the value history field to the recent values.
+func (a *alertState) recordValue(fields models.Fields) {
+	if a.values == nil {
+		return
+	}
+	if v, ok := fields[a.n.a.ValueHistoryField]; ok {
+		a.values.add(v)
+	}
+}
+
+// rateOfChange records the value of the rate of change field,
+// and returns the point with its rate of change added to the fields.
+// Returns false if the point has no rate of change, in which case it must not change the level.
+func (a *alertState) rateOfChange(p edge.FieldsTagsTimeGetter) (edge.FieldsTagsTimeGetter, bool) {
+	if a.roc == nil {
+		return p, true
+	}
+	var v float64
+	switch f := p.Fields()[a.n.a.RocField].(type) {
+	case float64:
+		v = f
+	case int64:
+		v = float64(f)
+	default:
+		return nil, false
+	}
+	roc, ok := a.roc.add(p.Time(), v)
+	if !ok {
+		return nil, false
+	}
+	fields := p.Fields().Copy()
+	fields[rocVar] = roc
+	return rocPoint{FieldsTagsTimeGetter: p, fields: fields}, true
+}
+
+// levelContext returns the point evaluated by the level expressions,
+// with the rate of change and the rolling percentiles added to its fields.
+// Returns false if the point must not change the level.
+func (a *alertState) levelContext(p edge.FieldsTagsTimeGetter) (edge.FieldsTagsTimeGetter, bool) {
+	// The values are recorded for both, even if the point has no value of the other.
+	percentiles, pOK := a.rollingPercentiles(p)
+	rp, ok := a.rateOfChange(p)
+	if !ok || !pOK {
+		return nil, false
+	}
+	if len(percentiles) == 0 {
+		return rp, true
+	}
+	fields := rp.Fields().Copy()
+	for k, v := range percentiles {
+		fields[k] = v
+	}
+	return rocPoint{FieldsTagsTimeGetter: p, fields: fields}, true
+}
+
+// rollingPercentiles returns the rolling percentiles of the percentiles field within the window before the point,
+// and records the value of the point.
+// Returns false if the point has no numeric value of the field or the window does not have enough values,
+// in which case it must not change the level.
+func (a *alertState) rollingPercentiles(p edge.FieldsTagsTimeGetter) (models.Fields, bool) {
+	if a.percentiles == nil {
+		return nil, true
+	}
+	var v float64
+	switch f := p.Fields()[a.n.a.PercentilesField].(type) {
+	case float64:
+		v = f
+	case int64:
+		v = float64(f)
+	default:
+		return nil, false
+	}
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return nil, false
+	}
+	values, ok := a.percentiles.percentiles(p.Time(), a.n.a.PercentilesRanks, a.n.percentilesMinCount)
+	a.percentiles.add(p.Time(), v)
+	if !ok {
+		return nil, false
+	}
+	fields := make(models.Fields, len(values))
+	for i, v := range values {
+		fields[a.n.percentileVars[i]] = v
+	}
+	return fields, true
+}
+
+// batchContext records the values of the batch and returns a point with the aggregates
+// of the batch context field over the batch, the tags and the time of the batch.
+// Returns false if no point of the batch has a numeric value of the field.
+func (a *alertState) batchContext(b edge.BufferedBatchMessage) (edge.FieldsTagsTimeGetter, bool) {
+	var count, over int64
+	var sum float64
+	min, max := math.Inf(1), math.Inf(-1)
+	for _, bp := range b.Points() {
+		a.recordValue(bp.Fields())
+		var v float64
+		switch f := bp.Fields()[a.n.a.BatchContextField].(type) {
+		case float64:
+			v = f
+		case int64:
+			v = float64(f)
+		default:
+			continue
+		}
+		count++
+		if v > a.n.a.BatchContextThreshold {
+			over++
+		}
+		sum += v
+		min = math.Min(min, v)
+		max = math.Max(max, v)
+	}
+	if count == 0 {
+		return nil, false
+	}
+	fields := models.Fields{
+		batchContextCountVar:       count,
+		batchContextCountOverVar:   over,
+		batchContextPercentOverVar: float64(over) / float64(count) * 100,
+		batchContextMinVar:         min,
+		batchContextMaxVar:         max,
+		batchContextMeanVar:        sum / float64(count),
+	}
+	begin := b.Begin()
+	return edge.NewBatchPointMessage(fields, begin.Tags(), begin.Time()), true
+}
+
+// recentValues returns the recent values of the value history field, oldest first.
+func (a *alertState) recentValues() []interface{} {
+	if a.values == nil {
+		return nil
+	}
+	return a.values.Values()
+}
+
 func (a *alertState) augmentTagsWithEventState(p edge.TagSetter, eventState alert.EventState) {
 	if a.n.a.LevelTag != "" || a.n.a.IdTag != "" {
 		tags := p.Tags().Copy()
@@ -1000,14 +1507,110 @@ func (a *alertState) Barrier(b edge.BarrierMessage) (edge.Message, error) {
 }
 
 func (a *alertState) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	a.release(d.GroupID())
 	return d, nil
 }
+
+// release the state of the alert of a deleted group.
+func (a *alertState) release(group models.GroupID) {
+	a.n.acks.delete(group)
+	if a.n.summary != nil {
+		a.n.summary.delete(group)
+	}
+	if a.percentiles != nil {
+		// Release the estimators of the group.
+		a.percentiles.panes = nil
+	}
+}
 func (a *alertState) Done() {
 	for _, inhibitor := range a.inhibitors {
 		a.n.et.tm.AlertService.RemoveInhibitor(inhibitor)
 	}
 }
 
+// identityState is the state of an alert identity shared by the groups with the same values of the id tags.
+type identityState struct {
+	*alertState
+
+	// number of groups with the identity
+	groups int
+	done   bool
+}
+
+// identityReceiver receives the messages of a group for the state of its alert identity.
+type identityReceiver struct {
+	*identityState
+
+	// group of the identity
+	group models.GroupID
+}
+
+func (r *identityReceiver) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	r.groups--
+	if r.groups == 0 {
+		// The last group with the identity was deleted.
+		delete(r.n.identities, r.group)
+		r.release(r.group)
+	}
+	return d, nil
+}
+
+func (r *identityReceiver) Done() {
+	// The state is shared, remove its inhibitors once.
+	if !r.done {
+		r.done = true
+		r.alertState.Done()
+	}
+}
+
+// alertGroupStateVersion is the version of the encoding of alertStateSnapshot.
+const alertGroupStateVersion = 1
+
+// alertStateSnapshot is the persisted state of an alert group.
+type alertStateSnapshot struct {
+	History        []alert.Level `json:"history"`
+	Index          int           `json:"index"`
+	Flapping       bool          `json:"flapping"`
+	Changed        bool          `json:"changed"`
+	Expired        bool          `json:"expired"`
+	FirstTriggered time.Time     `json:"firstTriggered"`
+	LastTriggered  time.Time     `json:"lastTriggered"`
+}
+
+func (a *alertState) snapshotState() ([]byte, error) {
+	return json.Marshal(alertStateSnapshot{
+		History:        a.history,
+		Index:          a.idx,
+		Flapping:       a.flapping,
+		Changed:        a.changed,
+		Expired:        a.expired,
+		FirstTriggered: a.firstTriggered,
+		LastTriggered:  a.lastTriggered,
+	})
+}
+
+func (a *alertState) restoreState(data []byte) error {
+	var s alertStateSnapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	// The history is only compatible if its size did not change.
+	if len(s.History) != len(a.history) {
+		return fmt.Errorf("history of %d levels, expected %d", len(s.History), len(a.history))
+	}
+	if s.Index < 0 || s.Index >= len(s.History) {
+		return fmt.Errorf("invalid history index %d", s.Index)
+	}
+	copy(a.history, s.History)
+	a.idx = s.Index
+	a.flapping = s.Flapping
+	a.changed = s.Changed
+	a.expired = s.Expired
+	a.firstTriggered = s.FirstTriggered
+	a.lastTriggered = s.LastTriggered
+	return nil
+}
+
 // Return the duration of the current alert state.
 func (a *alertState) duration() time.Duration {
 	return a.lastTriggered.Sub(a.firstTriggered)
@@ -1093,6 +1696,202 @@ func (a *alertState) updateExpired(t time.Time) {
 	a.expired = !a.changed && a.n.a.StateChangesOnlyDuration != 0 && t.Sub(a.lastTriggered) >= a.n.a.StateChangesOnlyDuration
 }
 
+var errAlertNotActive = errors.New("alert is not active")
+
+type alertAck struct {
+	// level of the alert when it was acknowledged
+	level alert.Level
+	time  time.Time
+}
+
+// alertAcks tracks the level and acknowledgement of the active alert of each group.
+// It is safe for concurrent use since acknowledgements are made via the HTTP API.
+type alertAcks struct {
+	mu sync.Mutex
+	// current level of each group with an active alert
+	levels map[models.GroupID]alert.Level
+	acks   map[models.GroupID]alertAck
+}
+
+func newAlertAcks() *alertAcks {
+	return &alertAcks{
+		levels: make(map[models.GroupID]alert.Level),
+		acks:   make(map[models.GroupID]alertAck),
+	}
+}
+
+// update records the current level of a group.
+// The acknowledgement of the group is cleared if the alert recovered or escalated.
+func (a *alertAcks) update(group models.GroupID, level alert.Level) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if level == alert.OK {
+		delete(a.levels, group)
+		delete(a.acks, group)
+		return
+	}
+	a.levels[group] = level
+	if ack, ok := a.acks[group]; ok && level > ack.level {
+		delete(a.acks, group)
+	}
+}
+
+// ack acknowledges the active alert of a group.
+// Unless level is OK the alert must currently be at the given level.
+func (a *alertAcks) ack(group models.GroupID, level alert.Level, now time.Time) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	current, ok := a.levels[group]
+	if !ok {
+		return errAlertNotActive
+	}
+	if level != alert.OK && level != current {
+		return fmt.Errorf("alert is %v not %v", current, level)
+	}
+	a.acks[group] = alertAck{
+		level: current,
+		time:  now,
+	}
+	return nil
+}
+
+// acknowledged reports whether the alert of a group is acknowledged.
+// Acknowledgements older than timeout are cleared, a zero timeout never expires.
+func (a *alertAcks) acknowledged(group models.GroupID, now time.Time, timeout time.Duration) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	ack, ok := a.acks[group]
+	if !ok {
+		return false
+	}
+	if timeout > 0 && now.Sub(ack.time) >= timeout {
+		delete(a.acks, group)
+		return false
+	}
+	return true
+}
+
+func (a *alertAcks) delete(group models.GroupID) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.levels, group)
+	delete(a.acks, group)
+}
+
+// summaryID returns the ID of the summary events of the node.
+func (n *AlertNode) summaryID() string {
+	return n.et.Task.ID + ":" + n.Name() + ":summary"
+}
+
+// alertSummary collects the latest event of each alerting group,
+// so that a single summary event can be sent at an interval.
+type alertSummary struct {
+	mu     sync.Mutex
+	events map[models.GroupID]alert.Event
+}
+
+func newAlertSummary() *alertSummary {
+	return &alertSummary{
+		events: make(map[models.GroupID]alert.Event),
+	}
+}
+
+// add records the latest event of a group.
+// Recoveries of groups that were not in a previous summary and are not alerting are ignored.
+func (s *alertSummary) add(event alert.Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	group := models.GroupID(event.Data.Group)
+	if _, ok := s.events[group]; !ok && event.State.Level == alert.OK {
+		return
+	}
+	s.events[group] = event
+}
+
+func (s *alertSummary) delete(group models.GroupID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.events, group)
+}
+
+// summarize returns a single event summarizing the events of all groups and whether there is anything to send.
+// Recovered groups are removed, so that they are only reported once.
+func (s *alertSummary) summarize(id, taskName, category string, now time.Time, recoverable bool) (alert.Event, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.events) == 0 {
+		return alert.Event{}, false
+	}
+	groups := make([]string, 0, len(s.events))
+	for group := range s.events {
+		groups = append(groups, string(group))
+	}
+	sort.Strings(groups)
+
+	level := alert.OK
+	alerting := 0
+	var name string
+	var series models.Rows
+	var lines []string
+	for _, group := range groups {
+		event := s.events[models.GroupID(group)]
+		if event.State.Level > level {
+			level = event.State.Level
+		}
+		if event.State.Level == alert.OK {
+			delete(s.events, models.GroupID(group))
+		} else {
+			alerting++
+		}
+		if name == "" {
+			name = event.Data.Name
+		}
+		series = append(series, event.Data.Result.Series...)
+		lines = append(lines, fmt.Sprintf("%s %s: %s", event.State.Level, event.State.ID, event.State.Message))
+	}
+	msg := fmt.Sprintf("%d of %d groups alerting", alerting, len(groups))
+	return alert.Event{
+		State: alert.EventState{
+			ID:      id,
+			Message: msg,
+			Details: msg + "\n" + strings.Join(lines, "\n"),
+			Time:    now,
+			Level:   level,
+		},
+		Data: alert.EventData{
+			Name:        name,
+			TaskName:    taskName,
+			Category:    category,
+			Result:      models.Result{Series: series},
+			Recoverable: recoverable,
+		},
+	}, true
+}
+
+// start calls emit every interval until the returned function is called.
+func (s *alertSummary) start(interval time.Duration, emit func(now time.Time)) func() {
+	ticker := time.NewTicker(interval)
+	stopC := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case now := <-ticker.C:
+				emit(now.UTC())
+			case <-stopC:
+				return
+			}
+		}
+	}()
+	return func() {
+		ticker.Stop()
+		close(stopC)
+		wg.Wait()
+	}
+}
+
 type serverInfo struct {
 	Hostname  string
 	ClusterID string
@@ -1134,6 +1933,9 @@ type messageInfo struct {
 
 	// Duration of the alert
 	Duration time.Duration
+
+	// Recent values of the value history field, oldest first.
+	History []interface{} `json:",omitempty"`
 }
 
 type detailsInfo struct {
@@ -1150,6 +1952,19 @@ func (n *AlertNode) serverInfo() serverInfo {
 	}
 
 }
+
+// alertGroup returns the group of the alert of a group,
+// the group computed from the id tags if they are set.
+func (n *AlertNode) alertGroup(name string, group models.GroupID, dims models.Dimensions, tags models.Tags) models.GroupID {
+	if n.idTags == nil {
+		return group
+	}
+	return models.ToGroupID(name, tags, models.Dimensions{
+		ByName:   dims.ByName,
+		TagNames: n.idTags,
+	})
+}
+
 func (n *AlertNode) renderID(name string, group models.GroupID, tags models.Tags) (string, error) {
 	g := string(group)
 	if group == models.NilGroup {
@@ -1175,7 +1990,7 @@ func (n *AlertNode) renderID(name string, group models.GroupID, tags models.Tags
 	return id.String(), nil
 }
 
-func (n *AlertNode) renderMessageAndDetails(id, name string, t time.Time, group models.GroupID, tags models.Tags, fields models.Fields, level alert.Level, d time.Duration) (string, string, error) {
+func (n *AlertNode) renderMessageAndDetails(id, name string, t time.Time, group models.GroupID, tags models.Tags, fields models.Fields, history []interface{}, level alert.Level, d time.Duration) (string, string, error) {
 	g := string(group)
 	if group == models.NilGroup {
 		g = "nil"
@@ -1193,6 +2008,7 @@ func (n *AlertNode) renderMessageAndDetails(id, name string, t time.Time, group
 		Level:    level.String(),
 		Time:     t,
 		Duration: d,
+		History:  history,
 	}
 
 	// Grab a buffer for the message template and the details template
@@ -1224,3 +2040,269 @@ func (n *AlertNode) renderMessageAndDetails(id, name string, t time.Time, group
 	details := tmpBuffer.String()
 	return msg, details, nil
 }
+
+// valueHistory is a ring buffer of the most recent values of a field.
+type valueHistory struct {
+	values []interface{}
+	// index of the next value to overwrite
+	next int
+	full bool
+}
+
+func newValueHistory(size int) *valueHistory {
+	return &valueHistory{
+		values: make([]interface{}, size),
+	}
+}
+
+func (h *valueHistory) add(v interface{}) {
+	h.values[h.next] = v
+	h.next++
+	if h.next == len(h.values) {
+		h.next = 0
+		h.full = true
+	}
+}
+
+// Values returns a copy of the values, oldest first.
+func (h *valueHistory) Values() []interface{} {
+	if !h.full {
+		values := make([]interface{}, h.next)
+		copy(values, h.values[:h.next])
+		return values
+	}
+	values := make([]interface{}, 0, len(h.values))
+	values = append(values, h.values[h.next:]...)
+	return append(values, h.values[:h.next]...)
+}
+
+// rocHistory keeps the values of a field needed to compute its rate of change.
+type rocHistory struct {
+	lookback time.Duration
+	// times and values of the field, oldest first.
+	// The first value is the reference once it is at least lookback older than the newest value.
+	times  []time.Time
+	values []float64
+}
+
+// add adds the value at time t and returns its rate of change relative to the reference value.
+// Returns false if there is no reference value or the reference value is zero.
+func (h *rocHistory) add(t time.Time, v float64) (float64, bool) {
+	cutoff := t.Add(-h.lookback)
+	// Drop the values older than the most recent value before the cutoff.
+	i := 0
+	for i+1 < len(h.times) && !h.times[i+1].After(cutoff) {
+		i++
+	}
+	h.times, h.values = h.times[i:], h.values[i:]
+
+	var ref float64
+	ok := len(h.times) > 0 && !h.times[0].After(cutoff)
+	if ok {
+		ref = h.values[0]
+	}
+	h.times = append(h.times, t)
+	h.values = append(h.values, v)
+
+	if !ok || ref == 0 {
+		return 0, false
+	}
+	return (v - ref) / math.Abs(ref), true
+}
+
+// rocPoint is a point with the rate of change, or other context of the level expressions, added to its fields.
+type rocPoint struct {
+	edge.FieldsTagsTimeGetter
+	fields models.Fields
+}
+
+func (p rocPoint) Fields() models.Fields {
+	return p.fields
+}
+
+// percentileHistory estimates the rolling percentiles of the values of a field within a window.
+// The window is split into panes with a sketch of the values each, so that values expire a pane at a time
+// and the memory is bounded regardless of the number of values.
+type percentileHistory struct {
+	window time.Duration
+	// panes of the window, oldest first
+	panes []percentilePane
+}
+
+type percentilePane struct {
+	start  time.Time
+	sketch *quantileSketch
+}
+
+func (h *percentileHistory) paneWidth() time.Duration {
+	return h.window / percentilesPanes
+}
+
+// add adds the value at time t.
+func (h *percentileHistory) add(t time.Time, v float64) {
+	h.expire(t)
+	start := t.Truncate(h.paneWidth())
+	// Values older than the newest pane are added to it.
+	if len(h.panes) == 0 || h.panes[len(h.panes)-1].start.Before(start) {
+		h.panes = append(h.panes, percentilePane{
+			start:  start,
+			sketch: newQuantileSketch(),
+		})
+	}
+	h.panes[len(h.panes)-1].sketch.add(v)
+}
+
+// expire drops the panes that ended before the window preceding t.
+func (h *percentileHistory) expire(t time.Time) {
+	cutoff := t.Add(-h.window)
+	i := 0
+	for i < len(h.panes) && !h.panes[i].start.Add(h.paneWidth()).After(cutoff) {
+		i++
+	}
+	if i > 0 {
+		h.panes = append(h.panes[:0], h.panes[i:]...)
+	}
+}
+
+// percentiles returns the estimates of the percentiles of the values within the window preceding t.
+// Returns false if the window has fewer than minCount values.
+func (h *percentileHistory) percentiles(t time.Time, percentiles []float64, minCount int64) ([]float64, bool) {
+	h.expire(t)
+	merged := newQuantileSketch()
+	for _, p := range h.panes {
+		merged.merge(p.sketch)
+	}
+	if merged.count < minCount {
+		return nil, false
+	}
+	values := make([]float64, len(percentiles))
+	for i, p := range percentiles {
+		values[i] = merged.quantile(p / 100)
+	}
+	return values, true
+}
+
+// percentilesGamma is the ratio of the bounds of a bucket of a quantileSketch.
+var percentilesGamma = (1 + percentilesAccuracy) / (1 - percentilesAccuracy)
+
+// quantileSketch estimates the quantiles of values within the relative accuracy,
+// by counting the values in buckets whose bounds grow exponentially with their magnitude.
+type quantileSketch struct {
+	// counts of the positive and negative values by the bucket of their magnitude
+	pos, neg map[int]int64
+	zero     int64
+	count    int64
+	// the exact extremes, which bound the estimates
+	min, max float64
+}
+
+func newQuantileSketch() *quantileSketch {
+	return &quantileSketch{
+		pos: make(map[int]int64),
+		neg: make(map[int]int64),
+		min: math.Inf(1),
+		max: math.Inf(-1),
+	}
+}
+
+// quantileBucket returns the bucket of a magnitude, bucket i contains the magnitudes in (gamma^(i-1), gamma^i].
+func quantileBucket(m float64) int {
+	return int(math.Ceil(math.Log(m) / math.Log(percentilesGamma)))
+}
+
+// quantileBucketValue returns the estimate of the magnitudes of a bucket,
+// which is within the relative accuracy of both bounds of the bucket.
+func quantileBucketValue(i int) float64 {
+	return 2 * math.Pow(percentilesGamma, float64(i)) / (percentilesGamma + 1)
+}
+
+func (s *quantileSketch) add(v float64) {
+	s.count++
+	s.min = math.Min(s.min, v)
+	s.max = math.Max(s.max, v)
+	switch {
+	case v > 0:
+		s.pos[quantileBucket(v)]++
+		collapseQuantileBuckets(s.pos)
+	case v < 0:
+		s.neg[quantileBucket(-v)]++
+		collapseQuantileBuckets(s.neg)
+	default:
+		s.zero++
+	}
+}
+
+// collapseQuantileBuckets merges the two buckets of the smallest magnitudes while there are too many buckets.
+func collapseQuantileBuckets(buckets map[int]int64) {
+	for len(buckets) > percentilesMaxBuckets {
+		first, second := math.MaxInt64, math.MaxInt64
+		for i := range buckets {
+			if i < first {
+				first, second = i, first
+			} else if i < second {
+				second = i
+			}
+		}
+		buckets[second] += buckets[first]
+		delete(buckets, first)
+	}
+}
+
+func (s *quantileSketch) merge(o *quantileSketch) {
+	for i, c := range o.pos {
+		s.pos[i] += c
+	}
+	for i, c := range o.neg {
+		s.neg[i] += c
+	}
+	s.zero += o.zero
+	s.count += o.count
+	s.min = math.Min(s.min, o.min)
+	s.max = math.Max(s.max, o.max)
+}
+
+// quantile returns the estimate of the value with the rank q*count, where q is between 0 and 1,
+// bounded by the extremes so that e.g. the estimates of constant values are exact.
+// The sketch must not be empty.
+func (s *quantileSketch) quantile(q float64) float64 {
+	return math.Max(s.min, math.Min(s.max, s.bucketQuantile(q)))
+}
+
+func (s *quantileSketch) bucketQuantile(q float64) float64 {
+	rank := int64(math.Ceil(q * float64(s.count)))
+	if rank < 1 {
+		rank = 1
+	}
+
+	// The negative values are ordered by descending magnitude.
+	neg := make([]int, 0, len(s.neg))
+	for i := range s.neg {
+		neg = append(neg, i)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(neg)))
+	var seen int64
+	for _, i := range neg {
+		seen += s.neg[i]
+		if seen >= rank {
+			return -quantileBucketValue(i)
+		}
+	}
+
+	seen += s.zero
+	if seen >= rank {
+		return 0
+	}
+
+	pos := make([]int, 0, len(s.pos))
+	for i := range s.pos {
+		pos = append(pos, i)
+	}
+	sort.Ints(pos)
+	for _, i := range pos {
+		seen += s.pos[i]
+		if seen >= rank {
+			return quantileBucketValue(i)
+		}
+	}
+	return quantileBucketValue(pos[len(pos)-1])
+}
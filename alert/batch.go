@@ -0,0 +1,161 @@
+package alert
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// BatchAttempts is the number of times a Batcher attempts to send an event before it is dropped.
+	BatchAttempts = 3
+	// BatchQueueSize is the maximum number of events waiting in a Batcher.
+	BatchQueueSize = 10000
+)
+
+// BatchEvent is an event waiting in a Batcher to be sent.
+type BatchEvent struct {
+	// Key identifies the alert of the event, the events of an alert are sent in order.
+	Key   string
+	Event Event
+	// Data is passed on to the sender with the event, e.g. the configuration of the handler of the event.
+	Data interface{}
+	// Attempts is the number of times sending the event failed.
+	Attempts int
+}
+
+// BatchSender sends a batch of events and returns the events that failed to be sent.
+type BatchSender func(batch []BatchEvent) (failed []BatchEvent)
+
+// Batcher accumulates events and sends them in batches every window,
+// so that the events of many alerts firing at once do not exceed the rate limits of an API.
+//
+// Every event is sent, so that no level change of an alert is lost.
+// A batch contains at most maxSize events and at most one event of each alert,
+// the remaining events wait for the next window, keeping the events of an alert in order.
+// The events of a batch that failed to be sent are retried with the next batch,
+// ahead of the later events of their alerts, unless they have been attempted BatchAttempts times.
+type Batcher struct {
+	send    BatchSender
+	window  time.Duration
+	maxSize int
+
+	mu sync.Mutex
+	// pending are the events waiting to be sent, oldest first.
+	pending []BatchEvent
+
+	closing chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewBatcher creates a Batcher sending batches of at most maxSize events with send every window.
+func NewBatcher(send BatchSender, window time.Duration, maxSize int) *Batcher {
+	return &Batcher{
+		send:    send,
+		window:  window,
+		maxSize: maxSize,
+	}
+}
+
+// Open starts sending batches.
+func (b *Batcher) Open() {
+	b.closing = make(chan struct{})
+	b.wg.Add(1)
+	go b.run()
+}
+
+// Close stops sending batches and sends the waiting events without retrying them.
+// The remaining events are dropped once a batch fails entirely.
+func (b *Batcher) Close() {
+	close(b.closing)
+	b.wg.Wait()
+	for b.Pending() > 0 {
+		if !b.Flush(true) {
+			return
+		}
+	}
+}
+
+func (b *Batcher) run() {
+	defer b.wg.Done()
+	ticker := time.NewTicker(b.window)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			b.Flush(false)
+		case <-b.closing:
+			return
+		}
+	}
+}
+
+// Add queues an event to be sent with a later batch.
+// Returns false if BatchQueueSize events are already waiting, in which case the event is not queued.
+func (b *Batcher) Add(e BatchEvent) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.pending) >= BatchQueueSize {
+		return false
+	}
+	b.pending = append(b.pending, e)
+	return true
+}
+
+// Pending returns the number of events waiting to be sent.
+func (b *Batcher) Pending() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.pending)
+}
+
+// Flush sends a batch of the waiting events and requeues the events that failed.
+// Failed events are dropped instead of requeued if final is set.
+// Returns false if no event of the batch was sent.
+func (b *Batcher) Flush(final bool) bool {
+	b.mu.Lock()
+	batch := b.next()
+	b.mu.Unlock()
+	if len(batch) == 0 {
+		return true
+	}
+
+	failed := b.send(batch)
+	if final {
+		return len(failed) < len(batch)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var retry []BatchEvent
+	for _, f := range failed {
+		f.Attempts++
+		if f.Attempts >= BatchAttempts {
+			continue
+		}
+		retry = append(retry, f)
+	}
+	// The failed events are retried before the events that arrived while the batch was sent.
+	b.pending = append(retry, b.pending...)
+	return len(failed) < len(batch)
+}
+
+// next removes the events of the next batch from the waiting events, the lock must be held.
+// The batch contains the oldest event of each alert, up to maxSize events.
+func (b *Batcher) next() []BatchEvent {
+	var batch, rest []BatchEvent
+	keys := make(map[string]bool)
+	for i, e := range b.pending {
+		if len(batch) == b.maxSize {
+			rest = append(rest, b.pending[i:]...)
+			break
+		}
+		if keys[e.Key] {
+			rest = append(rest, e)
+			continue
+		}
+		keys[e.Key] = true
+		batch = append(batch, e)
+	}
+	b.pending = rest
+	return batch
+}
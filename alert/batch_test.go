@@ -0,0 +1,190 @@
+package alert_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/alert"
+)
+
+// recordingSender records the keys of the batches it sends and fails the events of the keys in fail.
+type recordingSender struct {
+	batches [][]string
+	fail    map[string]int
+}
+
+func (s *recordingSender) send(batch []alert.BatchEvent) []alert.BatchEvent {
+	var keys []string
+	var failed []alert.BatchEvent
+	for _, e := range batch {
+		keys = append(keys, e.Key)
+		if s.fail[e.Key] > 0 {
+			s.fail[e.Key]--
+			failed = append(failed, e)
+		}
+	}
+	s.batches = append(s.batches, keys)
+	return failed
+}
+
+func batchEvent(key string, level alert.Level) alert.BatchEvent {
+	return alert.BatchEvent{
+		Key: key,
+		Event: alert.Event{
+			State: alert.EventState{ID: key, Level: level},
+		},
+	}
+}
+
+func TestBatcher_Batches(t *testing.T) {
+	s := &recordingSender{}
+	b := alert.NewBatcher(s.send, time.Hour, 2)
+
+	b.Add(batchEvent("a", alert.Warning))
+	b.Add(batchEvent("b", alert.Warning))
+	b.Add(batchEvent("c", alert.Warning))
+	b.Add(batchEvent("a", alert.Critical))
+
+	b.Flush(false)
+	b.Flush(false)
+	b.Flush(false)
+	if exp := [][]string{{"a", "b"}, {"c", "a"}}; !reflect.DeepEqual(s.batches, exp) {
+		t.Errorf("unexpected batches: got %v exp %v", s.batches, exp)
+	}
+	if b.Pending() != 0 {
+		t.Errorf("unexpected pending events: %d", b.Pending())
+	}
+}
+
+func TestBatcher_LevelChanges(t *testing.T) {
+	var got [][]alert.Level
+	b := alert.NewBatcher(func(batch []alert.BatchEvent) []alert.BatchEvent {
+		var levels []alert.Level
+		for _, e := range batch {
+			levels = append(levels, e.Event.State.Level)
+		}
+		got = append(got, levels)
+		return nil
+	}, time.Hour, 10)
+	b.Add(batchEvent("a", alert.Warning))
+	b.Add(batchEvent("a", alert.Critical))
+	b.Add(batchEvent("a", alert.OK))
+	for b.Pending() > 0 {
+		b.Flush(false)
+	}
+	// Every event of the alert is sent in order, one per batch.
+	if exp := [][]alert.Level{{alert.Warning}, {alert.Critical}, {alert.OK}}; !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected levels: got %v exp %v", got, exp)
+	}
+}
+
+func TestBatcher_PartialFailure(t *testing.T) {
+	s := &recordingSender{
+		fail: map[string]int{
+			"b": 1,
+			// Fails more often than it is attempted.
+			"c": alert.BatchAttempts + 1,
+		},
+	}
+	b := alert.NewBatcher(s.send, time.Hour, 3)
+	for _, k := range []string{"a", "b", "c"} {
+		b.Add(batchEvent(k, alert.Critical))
+	}
+
+	b.Flush(false)
+	// Events arriving while a batch is sent are sent after the failed events.
+	b.Add(batchEvent("d", alert.Critical))
+	for b.Pending() > 0 {
+		b.Flush(false)
+	}
+
+	// Only the failed events are retried, c is dropped after its last attempt.
+	exp := [][]string{
+		{"a", "b", "c"},
+		{"b", "c", "d"},
+		{"c"},
+	}
+	if !reflect.DeepEqual(s.batches, exp) {
+		t.Errorf("unexpected batches:\ngot %v\nexp %v", s.batches, exp)
+	}
+}
+
+func TestBatcher_FailedBeforeLater(t *testing.T) {
+	fail := 1
+	var got []alert.Level
+	var b *alert.Batcher
+	b = alert.NewBatcher(func(batch []alert.BatchEvent) []alert.BatchEvent {
+		// A newer event of the alert arrives while the first batch is sent.
+		if len(got) == 0 {
+			b.Add(batchEvent("a", alert.OK))
+		}
+		var failed []alert.BatchEvent
+		for _, e := range batch {
+			got = append(got, e.Event.State.Level)
+			if fail > 0 {
+				fail--
+				failed = append(failed, e)
+			}
+		}
+		return failed
+	}, time.Hour, 10)
+	b.Add(batchEvent("a", alert.Critical))
+	for b.Pending() > 0 {
+		b.Flush(false)
+	}
+	// The failed event is retried before the newer event of the alert.
+	if exp := []alert.Level{alert.Critical, alert.Critical, alert.OK}; !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected levels sent: got %v exp %v", got, exp)
+	}
+}
+
+func TestBatcher_QueueSize(t *testing.T) {
+	b := alert.NewBatcher(func(batch []alert.BatchEvent) []alert.BatchEvent { return nil }, time.Hour, 10)
+	for i := 0; i < alert.BatchQueueSize; i++ {
+		if !b.Add(batchEvent("a", alert.Critical)) {
+			t.Fatalf("event %d was not queued", i)
+		}
+	}
+	if b.Add(batchEvent("b", alert.Critical)) {
+		t.Error("expected event not to be queued when the queue is full")
+	}
+	if got, exp := b.Pending(), alert.BatchQueueSize; got != exp {
+		t.Errorf("unexpected pending events: got %d exp %d", got, exp)
+	}
+}
+
+func TestBatcher_Window(t *testing.T) {
+	sent := make(chan []alert.BatchEvent, 10)
+	b := alert.NewBatcher(func(batch []alert.BatchEvent) []alert.BatchEvent {
+		sent <- batch
+		return nil
+	}, 10*time.Millisecond, 10)
+	b.Open()
+	b.Add(batchEvent("a", alert.Critical))
+	b.Add(batchEvent("b", alert.Critical))
+	select {
+	case batch := <-sent:
+		if len(batch) != 2 {
+			t.Errorf("expected both events in one batch, got %d", len(batch))
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for the batch")
+	}
+
+	// The waiting events are sent on close.
+	b.Add(batchEvent("c", alert.Critical))
+	b.Close()
+	var keys []string
+	for len(sent) > 0 {
+		for _, e := range <-sent {
+			keys = append(keys, e.Key)
+		}
+	}
+	if !reflect.DeepEqual(keys, []string{"c"}) {
+		t.Errorf("unexpected events sent after the first batch: %v", keys)
+	}
+	if b.Pending() != 0 {
+		t.Errorf("unexpected pending events: %d", b.Pending())
+	}
+}
@@ -0,0 +1,49 @@
+package alert
+
+import "sync"
+
+// LevelHandler passes the events of a set of levels on to a handler.
+// OK events are passed on only for alerts whose previous event was passed on,
+// so that the handler receives the recoveries of the alerts it was notified of.
+type LevelHandler struct {
+	h      Handler
+	levels [maxLevel]bool
+
+	mu sync.Mutex
+	// IDs of the alerts the handler was notified of and has not received the recovery of.
+	notified map[string]bool
+}
+
+// NewLevelHandler creates a LevelHandler that passes events of the given levels on to h.
+func NewLevelHandler(h Handler, levels []Level) *LevelHandler {
+	lh := &LevelHandler{
+		h:        h,
+		notified: make(map[string]bool),
+	}
+	for _, l := range levels {
+		if l < maxLevel {
+			lh.levels[l] = true
+		}
+	}
+	return lh
+}
+
+// Handle passes the event on if it has one of the levels,
+// or if it is the recovery of an alert the handler was notified of.
+func (h *LevelHandler) Handle(event Event) {
+	id := event.State.ID
+	h.mu.Lock()
+	pass := false
+	switch {
+	case event.State.Level == OK:
+		pass = h.notified[id]
+		delete(h.notified, id)
+	case h.levels[event.State.Level]:
+		pass = true
+		h.notified[id] = true
+	}
+	h.mu.Unlock()
+	if pass {
+		h.h.Handle(event)
+	}
+}
@@ -0,0 +1,59 @@
+package alert_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/influxdata/kapacitor/alert"
+)
+
+type recordingHandler struct {
+	events []string
+}
+
+func (h *recordingHandler) Handle(event alert.Event) {
+	h.events = append(h.events, event.State.ID+":"+event.State.Level.String())
+}
+
+func TestLevelHandler(t *testing.T) {
+	slack := new(recordingHandler)
+	pagerDuty := new(recordingHandler)
+	handlers := []alert.Handler{
+		alert.NewLevelHandler(slack, []alert.Level{alert.Warning, alert.Critical}),
+		alert.NewLevelHandler(pagerDuty, []alert.Level{alert.Critical}),
+	}
+	events := []struct {
+		id    string
+		level alert.Level
+	}{
+		{"a", alert.Warning},
+		{"a", alert.OK},
+		{"b", alert.Info},
+		{"b", alert.OK},
+		{"a", alert.Critical},
+		{"a", alert.Warning},
+		{"a", alert.OK},
+		{"c", alert.Critical},
+		{"c", alert.OK},
+		{"c", alert.OK},
+	}
+	for _, e := range events {
+		for _, h := range handlers {
+			h.Handle(alert.Event{State: alert.EventState{ID: e.id, Level: e.level}})
+		}
+	}
+	if exp := []string{
+		"a:WARNING", "a:OK",
+		"a:CRITICAL", "a:WARNING", "a:OK",
+		"c:CRITICAL", "c:OK",
+	}; !reflect.DeepEqual(slack.events, exp) {
+		t.Errorf("unexpected slack events:\ngot %v\nexp %v", slack.events, exp)
+	}
+	// The recovery of a is sent even though the last event of a before it was a WARNING.
+	if exp := []string{
+		"a:CRITICAL", "a:OK",
+		"c:CRITICAL", "c:OK",
+	}; !reflect.DeepEqual(pagerDuty.events, exp) {
+		t.Errorf("unexpected pagerDuty events:\ngot %v\nexp %v", pagerDuty.events, exp)
+	}
+}
@@ -0,0 +1,57 @@
+package alert
+
+import (
+	"sync"
+
+	"github.com/influxdata/kapacitor/expvar"
+)
+
+// LimitedHandler wraps a Handler in order to limit the number of concurrent calls to Handle.
+// Events are queued while the limit is reached and dropped once the queue is full.
+type LimitedHandler struct {
+	h           Handler
+	concurrency int
+	events      chan Event
+	dropped     *expvar.Int
+	wg          sync.WaitGroup
+}
+
+// NewLimitedHandler creates a LimitedHandler that calls h at most concurrency times in parallel
+// and queues at most queueSize events.
+// Dropped events are counted in dropped.
+func NewLimitedHandler(h Handler, concurrency, queueSize int, dropped *expvar.Int) *LimitedHandler {
+	return &LimitedHandler{
+		h:           h,
+		concurrency: concurrency,
+		events:      make(chan Event, queueSize),
+		dropped:     dropped,
+	}
+}
+
+// Open starts handling queued events.
+func (h *LimitedHandler) Open() {
+	h.wg.Add(h.concurrency)
+	for i := 0; i < h.concurrency; i++ {
+		go func() {
+			defer h.wg.Done()
+			for event := range h.events {
+				h.h.Handle(event)
+			}
+		}()
+	}
+}
+
+// Close waits for all queued events to be handled.
+func (h *LimitedHandler) Close() {
+	close(h.events)
+	h.wg.Wait()
+}
+
+// Handle queues the event, dropping it if the queue is full.
+func (h *LimitedHandler) Handle(event Event) {
+	select {
+	case h.events <- event:
+	default:
+		h.dropped.Add(1)
+	}
+}
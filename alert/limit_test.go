@@ -0,0 +1,75 @@
+package alert_test
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/influxdata/kapacitor/alert"
+	"github.com/influxdata/kapacitor/expvar"
+)
+
+type blockingHandler struct {
+	release     chan struct{}
+	started     chan struct{}
+	inFlight    int32
+	maxInFlight int32
+	mu          sync.Mutex
+	handled     int
+}
+
+func (h *blockingHandler) Handle(event alert.Event) {
+	n := atomic.AddInt32(&h.inFlight, 1)
+	for {
+		max := atomic.LoadInt32(&h.maxInFlight)
+		if n <= max || atomic.CompareAndSwapInt32(&h.maxInFlight, max, n) {
+			break
+		}
+	}
+	h.started <- struct{}{}
+	<-h.release
+	atomic.AddInt32(&h.inFlight, -1)
+	h.mu.Lock()
+	h.handled++
+	h.mu.Unlock()
+}
+
+func TestLimitedHandler(t *testing.T) {
+	const (
+		concurrency = 3
+		queueSize   = 10
+		burst       = 20
+	)
+	h := &blockingHandler{
+		release: make(chan struct{}),
+		started: make(chan struct{}, burst),
+	}
+	dropped := new(expvar.Int)
+	lh := alert.NewLimitedHandler(h, concurrency, queueSize, dropped)
+	lh.Open()
+
+	// Wait for the first events to be in flight so the queue is drained deterministically.
+	for i := 0; i < concurrency; i++ {
+		lh.Handle(alert.Event{})
+	}
+	for i := 0; i < concurrency; i++ {
+		<-h.started
+	}
+	for i := concurrency; i < burst; i++ {
+		lh.Handle(alert.Event{})
+	}
+
+	if got, exp := dropped.IntValue(), int64(burst-concurrency-queueSize); got != exp {
+		t.Errorf("unexpected dropped events: got %d exp %d", got, exp)
+	}
+
+	close(h.release)
+	lh.Close()
+
+	if got := atomic.LoadInt32(&h.maxInFlight); got > concurrency {
+		t.Errorf("too many concurrent calls: got %d exp at most %d", got, concurrency)
+	}
+	if got, exp := h.handled, concurrency+queueSize; got != exp {
+		t.Errorf("unexpected handled events: got %d exp %d", got, exp)
+	}
+}
@@ -0,0 +1,222 @@
+package alert
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/influxdata/kapacitor/expvar"
+)
+
+// DeliveryHandler is a Handler that reports whether an event was delivered.
+type DeliveryHandler interface {
+	Handler
+	// Deliver handles the event, returning an error if the event was not delivered.
+	Deliver(event Event) error
+}
+
+// PendingEvent is an event waiting to be redelivered to a handler of a RetryQueue.
+type PendingEvent struct {
+	// Index of the handler in the order the handlers were wrapped.
+	Handler int   `json:"handler"`
+	Event   Event `json:"event"`
+	// Time the first delivery failed.
+	Failed   time.Time `json:"failed"`
+	Attempts int       `json:"attempts"`
+}
+
+// RetryQueue redelivers the events its handlers failed to deliver,
+// until they are delivered or older than the maximum age.
+// Events are delivered at least once, receivers can detect duplicates by the ID and time of the event.
+//
+// The pending events are persisted by calling persist from the retrying goroutine whenever they change,
+// and restored from the data returned by Snapshot.
+type RetryQueue struct {
+	maxAge   time.Duration
+	interval time.Duration
+	persist  func() error
+
+	mu       sync.Mutex
+	handlers []DeliveryHandler
+	pending  []PendingEvent
+	// events being retried, kept in snapshots until the retry completes
+	retrying []PendingEvent
+
+	redelivered *expvar.Int
+	expired     *expvar.Int
+
+	// changed signals the retrying goroutine to persist the pending events.
+	changed chan struct{}
+	closing chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewRetryQueue creates a RetryQueue retrying every interval.
+// Redelivered and expired events are counted in redelivered and expired.
+func NewRetryQueue(maxAge, interval time.Duration, persist func() error, redelivered, expired *expvar.Int) *RetryQueue {
+	return &RetryQueue{
+		maxAge:      maxAge,
+		interval:    interval,
+		persist:     persist,
+		redelivered: redelivered,
+		expired:     expired,
+		changed:     make(chan struct{}, 1),
+	}
+}
+
+// Wrap returns a handler that queues the events h fails to deliver.
+// Handlers that do not implement DeliveryHandler cannot report failures and are returned unchanged.
+// Handlers must be wrapped in the same order on each restart, pending events refer to them by index.
+func (q *RetryQueue) Wrap(h Handler) Handler {
+	dh, ok := h.(DeliveryHandler)
+	if !ok {
+		return h
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.handlers = append(q.handlers, dh)
+	return &retryHandler{q: q, i: len(q.handlers) - 1, h: dh}
+}
+
+// Snapshot returns the encoded pending events.
+func (q *RetryQueue) Snapshot() ([]byte, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.retrying)+len(q.pending) == 0 {
+		return nil, nil
+	}
+	all := make([]PendingEvent, 0, len(q.retrying)+len(q.pending))
+	all = append(all, q.retrying...)
+	all = append(all, q.pending...)
+	return json.Marshal(all)
+}
+
+// Restore restores the pending events from the data returned by Snapshot.
+// Events of unknown handlers are dropped.
+func (q *RetryQueue) Restore(data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+	var pending []PendingEvent
+	if err := json.Unmarshal(data, &pending); err != nil {
+		return err
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, p := range pending {
+		if p.Handler >= 0 && p.Handler < len(q.handlers) {
+			q.pending = append(q.pending, p)
+		}
+	}
+	return nil
+}
+
+// Pending returns the number of events waiting to be redelivered.
+func (q *RetryQueue) Pending() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.retrying) + len(q.pending)
+}
+
+// Open starts retrying, the restored events are retried immediately.
+func (q *RetryQueue) Open() {
+	q.closing = make(chan struct{})
+	q.wg.Add(1)
+	go q.run()
+}
+
+// Close stops retrying, the pending events are persisted and kept.
+func (q *RetryQueue) Close() {
+	close(q.closing)
+	q.wg.Wait()
+}
+
+func (q *RetryQueue) run() {
+	defer q.wg.Done()
+	ticker := time.NewTicker(q.interval)
+	defer ticker.Stop()
+	q.Retry(time.Now())
+	for {
+		select {
+		case <-ticker.C:
+			q.Retry(time.Now())
+		case <-q.changed:
+			q.save()
+		case <-q.closing:
+			select {
+			case <-q.changed:
+				q.save()
+			default:
+			}
+			return
+		}
+	}
+}
+
+// Retry attempts to redeliver each pending event once, dropping the events older than the maximum age.
+// It is called by the retrying goroutine, and must only be called directly when the queue is not open.
+func (q *RetryQueue) Retry(now time.Time) {
+	q.mu.Lock()
+	q.retrying, q.pending = q.pending, nil
+	retrying := q.retrying
+	q.mu.Unlock()
+	if len(retrying) == 0 {
+		return
+	}
+
+	var failed []PendingEvent
+	for _, p := range retrying {
+		if now.Sub(p.Failed) > q.maxAge {
+			q.expired.Add(1)
+			continue
+		}
+		p.Attempts++
+		if err := q.handlers[p.Handler].Deliver(p.Event); err != nil {
+			failed = append(failed, p)
+			continue
+		}
+		q.redelivered.Add(1)
+	}
+
+	q.mu.Lock()
+	// Keep the events that failed while retrying after the events still failing.
+	q.pending = append(failed, q.pending...)
+	q.retrying = nil
+	q.mu.Unlock()
+	q.save()
+}
+
+func (q *RetryQueue) add(handler int, event Event) {
+	q.mu.Lock()
+	q.pending = append(q.pending, PendingEvent{
+		Handler:  handler,
+		Event:    event,
+		Failed:   time.Now(),
+		Attempts: 1,
+	})
+	q.mu.Unlock()
+	select {
+	case q.changed <- struct{}{}:
+	default:
+	}
+}
+
+// save persists the pending events, the lock must not be held as persist calls Snapshot.
+func (q *RetryQueue) save() {
+	if q.persist != nil {
+		// Errors are reported by persist, the events are retried regardless.
+		_ = q.persist()
+	}
+}
+
+type retryHandler struct {
+	q *RetryQueue
+	i int
+	h DeliveryHandler
+}
+
+func (h *retryHandler) Handle(event Event) {
+	if err := h.h.Deliver(event); err != nil {
+		h.q.add(h.i, event)
+	}
+}
@@ -0,0 +1,146 @@
+package alert_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/alert"
+	"github.com/influxdata/kapacitor/expvar"
+)
+
+type failingHandler struct {
+	mu        sync.Mutex
+	fail      bool
+	delivered []string
+}
+
+func (h *failingHandler) Handle(event alert.Event) {
+	h.Deliver(event)
+}
+
+func (h *failingHandler) Deliver(event alert.Event) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.fail {
+		return errors.New("unavailable")
+	}
+	h.delivered = append(h.delivered, event.State.ID)
+	return nil
+}
+
+func (h *failingHandler) setFail(fail bool) {
+	h.mu.Lock()
+	h.fail = fail
+	h.mu.Unlock()
+}
+
+func (h *failingHandler) Delivered() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]string(nil), h.delivered...)
+}
+
+func event(id string) alert.Event {
+	return alert.Event{State: alert.EventState{ID: id, Level: alert.Critical}}
+}
+
+func TestRetryQueue_Retry(t *testing.T) {
+	h := &failingHandler{fail: true}
+	redelivered, expired := new(expvar.Int), new(expvar.Int)
+	q := alert.NewRetryQueue(time.Hour, time.Minute, nil, redelivered, expired)
+	wh := q.Wrap(h)
+
+	wh.Handle(event("a"))
+	wh.Handle(event("b"))
+	if got := q.Pending(); got != 2 {
+		t.Fatalf("unexpected pending events: got %d exp 2", got)
+	}
+
+	// Still failing, the events are kept.
+	q.Retry(time.Now())
+	if got := q.Pending(); got != 2 {
+		t.Fatalf("unexpected pending events after failed retry: got %d exp 2", got)
+	}
+
+	h.setFail(false)
+	q.Retry(time.Now())
+	if got := q.Pending(); got != 0 {
+		t.Fatalf("unexpected pending events after retry: got %d exp 0", got)
+	}
+	if got, exp := h.Delivered(), []string{"a", "b"}; len(got) != len(exp) || got[0] != exp[0] || got[1] != exp[1] {
+		t.Errorf("unexpected delivered events: got %v exp %v", got, exp)
+	}
+	if got := redelivered.IntValue(); got != 2 {
+		t.Errorf("unexpected redelivered count: got %d exp 2", got)
+	}
+}
+
+func TestRetryQueue_Expired(t *testing.T) {
+	h := &failingHandler{fail: true}
+	redelivered, expired := new(expvar.Int), new(expvar.Int)
+	q := alert.NewRetryQueue(time.Hour, time.Minute, nil, redelivered, expired)
+	q.Wrap(h).Handle(event("a"))
+
+	h.setFail(false)
+	q.Retry(time.Now().Add(2 * time.Hour))
+	if got := q.Pending(); got != 0 {
+		t.Fatalf("unexpected pending events: got %d exp 0", got)
+	}
+	if got := h.Delivered(); len(got) != 0 {
+		t.Errorf("unexpected delivered events: %v", got)
+	}
+	if got := expired.IntValue(); got != 1 {
+		t.Errorf("unexpected expired count: got %d exp 1", got)
+	}
+}
+
+func TestRetryQueue_SnapshotRestore(t *testing.T) {
+	h := &failingHandler{fail: true}
+	q := alert.NewRetryQueue(time.Hour, time.Minute, nil, new(expvar.Int), new(expvar.Int))
+	q.Wrap(h).Handle(event("a"))
+
+	data, err := q.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Restore into a new queue with the same handlers, as after a restart.
+	restarted := &failingHandler{}
+	persisted := make(chan struct{}, 10)
+	rq := alert.NewRetryQueue(time.Hour, time.Minute, func() error {
+		persisted <- struct{}{}
+		return nil
+	}, new(expvar.Int), new(expvar.Int))
+	rq.Wrap(restarted)
+	if err := rq.Restore(data); err != nil {
+		t.Fatal(err)
+	}
+	if got := rq.Pending(); got != 1 {
+		t.Fatalf("unexpected restored events: got %d exp 1", got)
+	}
+
+	// Restored events are retried on open and the emptied queue is persisted.
+	rq.Open()
+	select {
+	case <-persisted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the queue to be persisted")
+	}
+	rq.Close()
+	if got := restarted.Delivered(); len(got) != 1 || got[0] != "a" {
+		t.Errorf("unexpected delivered events: %v", got)
+	}
+	if data, err := rq.Snapshot(); err != nil || data != nil {
+		t.Errorf("unexpected snapshot of empty queue: %q %v", data, err)
+	}
+}
+
+func TestRetryQueue_NotDeliveryHandler(t *testing.T) {
+	h := &blockingHandler{}
+	q := alert.NewRetryQueue(time.Hour, time.Minute, nil, new(expvar.Int), new(expvar.Int))
+	if got := q.Wrap(h); got != alert.Handler(h) {
+		t.Error("expected handler without delivery errors to be returned unchanged")
+	}
+}
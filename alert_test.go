@@ -0,0 +1,390 @@
+package kapacitor
+
+import (
+	"math"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/alert"
+	"github.com/influxdata/kapacitor/models"
+)
+
+func TestAlertAcks(t *testing.T) {
+	group := models.GroupID("cpu,host=serverA")
+	now := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("not active", func(t *testing.T) {
+		acks := newAlertAcks()
+		if err := acks.ack(group, alert.OK, now); err != errAlertNotActive {
+			t.Errorf("unexpected error: got %v exp %v", err, errAlertNotActive)
+		}
+	})
+	t.Run("level mismatch", func(t *testing.T) {
+		acks := newAlertAcks()
+		acks.update(group, alert.Warning)
+		if err := acks.ack(group, alert.Critical, now); err == nil {
+			t.Error("expected error acknowledging alert at wrong level")
+		}
+		if acks.acknowledged(group, now, 0) {
+			t.Error("expected alert to not be acknowledged")
+		}
+	})
+	t.Run("ack suppresses", func(t *testing.T) {
+		acks := newAlertAcks()
+		acks.update(group, alert.Critical)
+		if err := acks.ack(group, alert.Critical, now); err != nil {
+			t.Fatal(err)
+		}
+		acks.update(group, alert.Critical)
+		if !acks.acknowledged(group, now.Add(time.Hour), 0) {
+			t.Error("expected alert to be acknowledged")
+		}
+		if acks.acknowledged(models.GroupID("cpu,host=serverB"), now, 0) {
+			t.Error("expected other group to not be acknowledged")
+		}
+		// Stepping down keeps the acknowledgement.
+		acks.update(group, alert.Warning)
+		if !acks.acknowledged(group, now, 0) {
+			t.Error("expected alert to be acknowledged after stepping down")
+		}
+	})
+	t.Run("resolution clears", func(t *testing.T) {
+		acks := newAlertAcks()
+		acks.update(group, alert.Warning)
+		if err := acks.ack(group, alert.OK, now); err != nil {
+			t.Fatal(err)
+		}
+		acks.update(group, alert.OK)
+		acks.update(group, alert.Warning)
+		if acks.acknowledged(group, now, 0) {
+			t.Error("expected acknowledgement to be cleared by recovery")
+		}
+	})
+	t.Run("escalation clears", func(t *testing.T) {
+		acks := newAlertAcks()
+		acks.update(group, alert.Warning)
+		if err := acks.ack(group, alert.Warning, now); err != nil {
+			t.Fatal(err)
+		}
+		acks.update(group, alert.Critical)
+		if acks.acknowledged(group, now, 0) {
+			t.Error("expected acknowledgement to be cleared by escalation")
+		}
+	})
+	t.Run("timeout re-enables", func(t *testing.T) {
+		acks := newAlertAcks()
+		acks.update(group, alert.Critical)
+		if err := acks.ack(group, alert.Critical, now); err != nil {
+			t.Fatal(err)
+		}
+		if !acks.acknowledged(group, now.Add(time.Minute), 5*time.Minute) {
+			t.Error("expected alert to be acknowledged before the timeout")
+		}
+		if acks.acknowledged(group, now.Add(5*time.Minute), 5*time.Minute) {
+			t.Error("expected acknowledgement to expire after the timeout")
+		}
+		if acks.acknowledged(group, now.Add(time.Minute), 5*time.Minute) {
+			t.Error("expected expired acknowledgement to be cleared")
+		}
+	})
+}
+
+func newTestAlertEvent(group string, level alert.Level) alert.Event {
+	return alert.Event{
+		State: alert.EventState{
+			ID:      "cpu:" + group,
+			Message: "cpu:" + group + " is " + level.String(),
+			Level:   level,
+		},
+		Data: alert.EventData{
+			Name:   "cpu",
+			Group:  group,
+			Result: models.Result{Series: models.Rows{{Name: "cpu", Tags: map[string]string{"host": group}}}},
+		},
+	}
+}
+
+func TestAlertSummary(t *testing.T) {
+	now := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := newAlertSummary()
+	if _, ok := s.summarize("summary", "task", "", now, true); ok {
+		t.Fatal("expected no summary without events")
+	}
+
+	// A recovery of a group not yet summarized is not reported.
+	s.add(newTestAlertEvent("serverC", alert.OK))
+	s.add(newTestAlertEvent("serverB", alert.Warning))
+	s.add(newTestAlertEvent("serverA", alert.Warning))
+	s.add(newTestAlertEvent("serverA", alert.Critical))
+
+	event, ok := s.summarize("summary", "task", "", now, true)
+	if !ok {
+		t.Fatal("expected summary")
+	}
+	if got, exp := event.State.ID, "summary"; got != exp {
+		t.Errorf("unexpected ID: got %s exp %s", got, exp)
+	}
+	if got, exp := event.State.Level, alert.Critical; got != exp {
+		t.Errorf("unexpected level: got %v exp %v", got, exp)
+	}
+	if got, exp := event.State.Message, "2 of 2 groups alerting"; got != exp {
+		t.Errorf("unexpected message: got %q exp %q", got, exp)
+	}
+	expDetails := "2 of 2 groups alerting\nCRITICAL cpu:serverA: cpu:serverA is CRITICAL\nWARNING cpu:serverB: cpu:serverB is WARNING"
+	if got := event.State.Details; got != expDetails {
+		t.Errorf("unexpected details: got %q exp %q", got, expDetails)
+	}
+	if got, exp := len(event.Data.Result.Series), 2; got != exp {
+		t.Fatalf("unexpected series count: got %d exp %d", got, exp)
+	}
+	if got, exp := event.Data.Result.Series[0].Tags["host"], "serverA"; got != exp {
+		t.Errorf("unexpected first series: got %s exp %s", got, exp)
+	}
+
+	// Alerting groups are reported again, recoveries once.
+	s.add(newTestAlertEvent("serverA", alert.OK))
+	event, ok = s.summarize("summary", "task", "", now, true)
+	if !ok {
+		t.Fatal("expected summary")
+	}
+	if got, exp := event.State.Message, "1 of 2 groups alerting"; got != exp {
+		t.Errorf("unexpected message: got %q exp %q", got, exp)
+	}
+	if got, exp := event.State.Level, alert.Warning; got != exp {
+		t.Errorf("unexpected level: got %v exp %v", got, exp)
+	}
+
+	s.add(newTestAlertEvent("serverB", alert.OK))
+	event, ok = s.summarize("summary", "task", "", now, true)
+	if !ok {
+		t.Fatal("expected summary")
+	}
+	if got, exp := event.State.Level, alert.OK; got != exp {
+		t.Errorf("unexpected level: got %v exp %v", got, exp)
+	}
+	if got, exp := event.State.Message, "0 of 1 groups alerting"; got != exp {
+		t.Errorf("unexpected message: got %q exp %q", got, exp)
+	}
+	if _, ok := s.summarize("summary", "task", "", now, true); ok {
+		t.Error("expected no summary once all groups recovered")
+	}
+}
+
+func TestAlertSummary_Interval(t *testing.T) {
+	s := newAlertSummary()
+	s.add(newTestAlertEvent("serverA", alert.Critical))
+
+	interval := 20 * time.Millisecond
+	var mu sync.Mutex
+	var times []time.Time
+	stop := s.start(interval, func(now time.Time) {
+		if _, ok := s.summarize("summary", "task", "", now, true); ok {
+			mu.Lock()
+			times = append(times, now)
+			mu.Unlock()
+		}
+	})
+	time.Sleep(5*interval + interval/2)
+	stop()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(times) < 3 || len(times) > 6 {
+		t.Fatalf("unexpected number of summaries: got %d exp about 5", len(times))
+	}
+	for i := 1; i < len(times); i++ {
+		if d := times[i].Sub(times[i-1]); d < interval/2 {
+			t.Errorf("summaries sent too often: %v apart", d)
+		}
+	}
+
+	// No summaries are sent once stopped.
+	n := len(times)
+	mu.Unlock()
+	time.Sleep(2 * interval)
+	mu.Lock()
+	if len(times) != n {
+		t.Errorf("summaries sent after stop")
+	}
+}
+
+func TestValueHistory(t *testing.T) {
+	h := newValueHistory(3)
+	if got := h.Values(); len(got) != 0 {
+		t.Errorf("unexpected values: %v", got)
+	}
+	testCases := []struct {
+		add interface{}
+		exp []interface{}
+	}{
+		{add: 1.0, exp: []interface{}{1.0}},
+		{add: 2.0, exp: []interface{}{1.0, 2.0}},
+		{add: 3.0, exp: []interface{}{1.0, 2.0, 3.0}},
+		{add: 4.0, exp: []interface{}{2.0, 3.0, 4.0}},
+		{add: 5.0, exp: []interface{}{3.0, 4.0, 5.0}},
+		{add: 6.0, exp: []interface{}{4.0, 5.0, 6.0}},
+		{add: 7.0, exp: []interface{}{5.0, 6.0, 7.0}},
+	}
+	for _, tc := range testCases {
+		h.add(tc.add)
+		if got := h.Values(); !reflect.DeepEqual(got, tc.exp) {
+			t.Errorf("unexpected values after adding %v:\ngot %v\nexp %v", tc.add, got, tc.exp)
+		}
+		if len(h.values) != 3 {
+			t.Fatalf("value history grew to %d values", len(h.values))
+		}
+	}
+}
+
+func TestRocHistory(t *testing.T) {
+	t0 := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	type add struct {
+		t     time.Duration
+		v     float64
+		exp   float64
+		expOK bool
+	}
+	testCases := []struct {
+		name     string
+		lookback time.Duration
+		adds     []add
+	}{
+		{
+			name: "previous point",
+			adds: []add{
+				{t: 0, v: 10},
+				{t: time.Second, v: 15, exp: 0.5, expOK: true},
+				{t: 5 * time.Second, v: 12, exp: -0.2, expOK: true},
+				{t: 6 * time.Second, v: 0, exp: -1, expOK: true},
+				// A zero reference has no rate of change.
+				{t: 7 * time.Second, v: 5},
+				{t: 8 * time.Second, v: -5, exp: -2, expOK: true},
+			},
+		},
+		{
+			name:     "lookback",
+			lookback: 10 * time.Second,
+			adds: []add{
+				{t: 0, v: 10},
+				{t: 5 * time.Second, v: 20},
+				{t: 10 * time.Second, v: 15, exp: 0.5, expOK: true},
+				{t: 12 * time.Second, v: 30, exp: 2, expOK: true},
+				{t: 15 * time.Second, v: 30, exp: 0.5, expOK: true},
+				// The reference is the most recent value at least the lookback older.
+				{t: 40 * time.Second, v: 60, exp: 1, expOK: true},
+				{t: 45 * time.Second, v: 60, exp: 1, expOK: true},
+				{t: 50 * time.Second, v: 90, exp: 0.5, expOK: true},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := &rocHistory{lookback: tc.lookback}
+			for i, a := range tc.adds {
+				got, ok := h.add(t0.Add(a.t), a.v)
+				if ok != a.expOK || got != a.exp {
+					t.Errorf("%d: unexpected rate of change: got %v %v exp %v %v", i, got, ok, a.exp, a.expOK)
+				}
+			}
+		})
+	}
+}
+
+func TestQuantileSketch(t *testing.T) {
+	testCases := []struct {
+		name   string
+		values []float64
+	}{
+		{
+			name: "positive",
+			values: func() []float64 {
+				values := make([]float64, 10000)
+				for i := range values {
+					values[i] = float64(i + 1)
+				}
+				return values
+			}(),
+		},
+		{
+			name: "mixed signs",
+			values: func() []float64 {
+				values := make([]float64, 2001)
+				for i := range values {
+					values[i] = float64(i-1000) * 0.37
+				}
+				return values
+			}(),
+		},
+		{
+			name:   "constant",
+			values: []float64{42, 42, 42},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newQuantileSketch()
+			for _, v := range tc.values {
+				s.add(v)
+			}
+			sorted := append([]float64(nil), tc.values...)
+			sort.Float64s(sorted)
+			for _, q := range []float64{0.01, 0.25, 0.5, 0.9, 0.99, 0.999} {
+				rank := int(math.Ceil(q * float64(len(sorted))))
+				exp := sorted[rank-1]
+				got := s.quantile(q)
+				if math.Abs(got-exp) > percentilesAccuracy*math.Abs(exp) {
+					t.Errorf("quantile %v: got %v exp %v within %v", q, got, exp, percentilesAccuracy)
+				}
+			}
+		})
+	}
+}
+
+func TestQuantileSketch_MaxBuckets(t *testing.T) {
+	s := newQuantileSketch()
+	for i := 0; i < 3*percentilesMaxBuckets; i++ {
+		s.add(math.Pow(percentilesGamma, float64(i)))
+	}
+	if got := len(s.pos); got != percentilesMaxBuckets {
+		t.Fatalf("unexpected number of buckets: got %d exp %d", got, percentilesMaxBuckets)
+	}
+	// The largest values keep their accuracy.
+	exp := math.Pow(percentilesGamma, float64(3*percentilesMaxBuckets-2))
+	if got := s.quantile(1 - 1.5/float64(3*percentilesMaxBuckets)); math.Abs(got-exp) > percentilesAccuracy*exp {
+		t.Errorf("unexpected quantile: got %v exp %v", got, exp)
+	}
+}
+
+func TestPercentileHistory(t *testing.T) {
+	t0 := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	h := &percentileHistory{window: 10 * time.Second}
+	for i := 0; i < 10; i++ {
+		h.add(t0.Add(time.Duration(i)*time.Second), 100)
+	}
+	if _, ok := h.percentiles(t0.Add(10*time.Second), []float64{50}, 11); ok {
+		t.Error("expected no percentiles with fewer values than the minimum")
+	}
+	got, ok := h.percentiles(t0.Add(10*time.Second), []float64{50}, 10)
+	if !ok || got[0] != 100 {
+		t.Errorf("unexpected percentiles: got %v %v exp [100]", got, ok)
+	}
+
+	// The values expire a pane at a time.
+	for i := 10; i < 15; i++ {
+		h.add(t0.Add(time.Duration(i)*time.Second), 1)
+	}
+	got, ok = h.percentiles(t0.Add(15*time.Second), []float64{40, 60}, 1)
+	if !ok || !reflect.DeepEqual(got, []float64{1, 100}) {
+		t.Errorf("unexpected percentiles: got %v %v exp [1 100]", got, ok)
+	}
+	if got, exp := len(h.panes), percentilesPanes; got != exp {
+		t.Errorf("unexpected number of panes: got %d exp %d", got, exp)
+	}
+	_, ok = h.percentiles(t0.Add(30*time.Second), []float64{50}, 1)
+	if ok || len(h.panes) != 0 {
+		t.Errorf("expected all values to expire, got %d panes", len(h.panes))
+	}
+}
@@ -0,0 +1,171 @@
+// Package avrotest provides a mock Confluent schema registry for tests.
+// It implements registering schemas as versions of subjects and getting their latest versions.
+package avrotest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+)
+
+// Registry is a mock schema registry.
+type Registry struct {
+	URL string
+
+	s *httptest.Server
+
+	mu sync.Mutex
+	// schemas by ID, IDs start at 1
+	schemas []string
+	// versions are the IDs of the versions of each subject, oldest first
+	versions map[string][]int32
+	requests int
+	// errorCode is returned for all requests if set
+	errorCode int
+}
+
+// NewRegistry starts a mock registry.
+func NewRegistry() *Registry {
+	r := &Registry{
+		versions: make(map[string][]int32),
+	}
+	r.s = httptest.NewServer(http.HandlerFunc(r.handle))
+	r.URL = r.s.URL
+	return r
+}
+
+// Close stops the registry.
+func (r *Registry) Close() {
+	r.s.Close()
+}
+
+// SetError makes the registry fail all requests with the error code, or succeed again if it is zero.
+func (r *Registry) SetError(code int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.errorCode = code
+}
+
+// Schema returns the schema with the ID.
+func (r *Registry) Schema(id int32) (string, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if id < 1 || int(id) > len(r.schemas) {
+		return "", false
+	}
+	return r.schemas[id-1], true
+}
+
+// Versions returns the IDs of the schemas registered as versions of the subject, oldest first.
+func (r *Registry) Versions(subject string) []int32 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]int32(nil), r.versions[subject]...)
+}
+
+// Requests returns the number of requests the registry received.
+func (r *Registry) Requests() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.requests
+}
+
+func (r *Registry) handle(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests++
+
+	w.Header().Set("Content-Type", "application/vnd.schemaregistry.v1+json")
+	if r.errorCode != 0 {
+		writeError(w, r.errorCode, "mock registry error")
+		return
+	}
+	parts := strings.Split(strings.TrimPrefix(req.URL.Path, "/"), "/")
+	if req.Method == "GET" && len(parts) == 4 && parts[0] == "subjects" && parts[2] == "versions" && parts[3] == "latest" {
+		r.latest(w, parts[1])
+		return
+	}
+	if req.Method != "POST" || len(parts) != 3 || parts[0] != "subjects" || parts[2] != "versions" {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	subject := parts[1]
+	var body struct {
+		Schema string `json:"schema"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil || body.Schema == "" {
+		writeError(w, http.StatusUnprocessableEntity, "invalid schema")
+		return
+	}
+
+	id := r.id(body.Schema)
+	registered := false
+	for _, v := range r.versions[subject] {
+		if v == id {
+			registered = true
+			break
+		}
+	}
+	if !registered {
+		r.versions[subject] = append(r.versions[subject], id)
+	}
+	json.NewEncoder(w).Encode(struct {
+		ID int32 `json:"id"`
+	}{
+		ID: id,
+	})
+}
+
+// latest writes the latest version of the subject.
+func (r *Registry) latest(w http.ResponseWriter, subject string) {
+	versions := r.versions[subject]
+	if len(versions) == 0 {
+		writeError(w, http.StatusNotFound, "subject not found")
+		return
+	}
+	id := versions[len(versions)-1]
+	json.NewEncoder(w).Encode(struct {
+		Subject string `json:"subject"`
+		ID      int32  `json:"id"`
+		Version int    `json:"version"`
+		Schema  string `json:"schema"`
+	}{
+		Subject: subject,
+		ID:      id,
+		Version: len(versions),
+		Schema:  r.schemas[id-1],
+	})
+}
+
+// Register registers the schema as a new version of the subject and returns its ID.
+func (r *Registry) Register(subject, schema string) int32 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	id := r.id(schema)
+	r.versions[subject] = append(r.versions[subject], id)
+	return id
+}
+
+// id returns the ID of a schema, adding it if it is new.
+func (r *Registry) id(schema string) int32 {
+	for i, s := range r.schemas {
+		if s == schema {
+			return int32(i + 1)
+		}
+	}
+	r.schemas = append(r.schemas, schema)
+	return int32(len(r.schemas))
+}
+
+func writeError(w http.ResponseWriter, code int, message string) {
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(struct {
+		ErrorCode int    `json:"error_code"`
+		Message   string `json:"message"`
+	}{
+		ErrorCode: code,
+		Message:   message,
+	})
+}
@@ -0,0 +1,142 @@
+package avro
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+)
+
+// magicByte is the first byte of a message in the Confluent wire format.
+const magicByte = 0
+
+// Encode encodes a record in the Avro binary encoding.
+// The values of the fields are looked up by their names, fields without a value are encoded as null.
+// A time.Time value can be encoded as a long, in the unit of its logical type or else in nanoseconds,
+// or as a string in RFC 3339 format.
+func (s *Schema) Encode(values map[string]interface{}) ([]byte, error) {
+	var b []byte
+	for _, f := range s.Fields {
+		v := values[f.Name]
+		var err error
+		b, err = appendField(b, f, v)
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %v", f.Name, err)
+		}
+	}
+	return b, nil
+}
+
+// Frame frames an encoded record in the Confluent wire format,
+// a zero magic byte followed by the schema ID as a 4 byte big endian integer and the record.
+func Frame(id int32, record []byte) []byte {
+	b := make([]byte, 5, 5+len(record))
+	b[0] = magicByte
+	binary.BigEndian.PutUint32(b[1:], uint32(id))
+	return append(b, record...)
+}
+
+func appendField(b []byte, f Field, v interface{}) ([]byte, error) {
+	if len(f.Types) == 1 {
+		return appendValue(b, f.Types[0], v)
+	}
+	// A union is encoded as the index of the first branch that accepts the value, followed by the value.
+	for i, t := range f.Types {
+		if vb, err := appendValue(nil, t, v); err == nil {
+			b = appendLong(b, int64(i))
+			return append(b, vb...), nil
+		}
+	}
+	if v == nil {
+		return nil, fmt.Errorf("missing value of non-nullable field")
+	}
+	return nil, fmt.Errorf("value %v of type %T does not match any type of the union", v, v)
+}
+
+func appendValue(b []byte, t Type, v interface{}) ([]byte, error) {
+	if v == nil {
+		if t.Name == Null {
+			return b, nil
+		}
+		return nil, fmt.Errorf("missing value of non-nullable field")
+	}
+	switch t.Name {
+	case Boolean:
+		if v, ok := v.(bool); ok {
+			if v {
+				return append(b, 1), nil
+			}
+			return append(b, 0), nil
+		}
+	case Int:
+		if v, ok := v.(int64); ok {
+			if v < math.MinInt32 || v > math.MaxInt32 {
+				return nil, fmt.Errorf("value %d out of range of int", v)
+			}
+			return appendLong(b, v), nil
+		}
+	case Long:
+		switch v := v.(type) {
+		case int64:
+			return appendLong(b, v), nil
+		case time.Time:
+			switch t.LogicalType {
+			case TimestampMillis:
+				return appendLong(b, v.UnixNano()/int64(time.Millisecond)), nil
+			case TimestampMicros:
+				return appendLong(b, v.UnixNano()/int64(time.Microsecond)), nil
+			default:
+				return appendLong(b, v.UnixNano()), nil
+			}
+		}
+	case Float:
+		switch v := v.(type) {
+		case float64:
+			return appendUint32(b, math.Float32bits(float32(v))), nil
+		case int64:
+			return appendUint32(b, math.Float32bits(float32(v))), nil
+		}
+	case Double:
+		switch v := v.(type) {
+		case float64:
+			return appendUint64(b, math.Float64bits(v)), nil
+		case int64:
+			return appendUint64(b, math.Float64bits(float64(v))), nil
+		}
+	case String:
+		switch v := v.(type) {
+		case string:
+			b = appendLong(b, int64(len(v)))
+			return append(b, v...), nil
+		case time.Time:
+			s := v.UTC().Format(time.RFC3339Nano)
+			b = appendLong(b, int64(len(s)))
+			return append(b, s...), nil
+		}
+	}
+	return nil, fmt.Errorf("cannot encode value %v of type %T as %s", v, v, t.Name)
+}
+
+// appendLong appends an int or long as a zig-zag encoded variable length integer.
+func appendLong(b []byte, v int64) []byte {
+	u := uint64((v << 1) ^ (v >> 63))
+	for u >= 0x80 {
+		b = append(b, byte(u)|0x80)
+		u >>= 7
+	}
+	return append(b, byte(u))
+}
+
+// appendUint32 appends a float in little endian byte order.
+func appendUint32(b []byte, v uint32) []byte {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], v)
+	return append(b, buf[:]...)
+}
+
+// appendUint64 appends a double in little endian byte order.
+func appendUint64(b []byte, v uint64) []byte {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], v)
+	return append(b, buf[:]...)
+}
@@ -0,0 +1,84 @@
+package avro_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/avro"
+)
+
+func TestSchema_Encode(t *testing.T) {
+	s, err := avro.ParseSchema(`{
+	"type": "record",
+	"name": "cpu",
+	"fields": [
+		{"name": "time", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+		{"name": "host", "type": "string"},
+		{"name": "value", "type": ["null", "double"]},
+		{"name": "count", "type": ["null", "int", "string"]},
+		{"name": "ratio", "type": "float"},
+		{"name": "up", "type": "boolean"},
+		{"name": "missing", "type": ["null", "long"]}
+	]
+}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.Encode(map[string]interface{}{
+		"time":  time.Unix(1, 0),
+		"host":  "ab",
+		"value": 1.0,
+		"count": int64(-2),
+		"ratio": 0.5,
+		"up":    true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := []byte{
+		// time: 1000 milliseconds zig-zag encoded
+		0xd0, 0x0f,
+		// host: length 2 and the bytes
+		0x04, 'a', 'b',
+		// value: union branch 1 and 1.0 as a little endian double
+		0x02, 0, 0, 0, 0, 0, 0, 0xf0, 0x3f,
+		// count: union branch 1 and -2 zig-zag encoded
+		0x02, 0x03,
+		// ratio: 0.5 as a little endian float
+		0, 0, 0, 0x3f,
+		// up
+		0x01,
+		// missing: union branch 0
+		0x00,
+	}
+	if !bytes.Equal(got, exp) {
+		t.Errorf("unexpected encoding:\ngot % x\nexp % x", got, exp)
+	}
+}
+
+func TestSchema_EncodeErrors(t *testing.T) {
+	s, err := avro.ParseSchema(`{"type": "record", "name": "r", "fields": [{"name": "a", "type": "int"}]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCases := map[string]map[string]interface{}{
+		"missing":      {},
+		"wrong type":   {"a": "1"},
+		"out of range": {"a": int64(1 << 40)},
+	}
+	for name, values := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := s.Encode(values); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestFrame(t *testing.T) {
+	got := avro.Frame(258, []byte{0x02})
+	if exp := []byte{0, 0, 0, 1, 2, 0x02}; !bytes.Equal(got, exp) {
+		t.Errorf("unexpected frame: got % x exp % x", got, exp)
+	}
+}
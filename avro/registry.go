@@ -0,0 +1,143 @@
+package avro
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// registryContentType is the content type of the requests and responses of the schema registry API.
+const registryContentType = "application/vnd.schemaregistry.v1+json"
+
+// Registry is a client of the Confluent schema registry.
+type Registry struct {
+	url    string
+	client *http.Client
+
+	mu sync.Mutex
+	// ids are the IDs of the registered schemas by subject and schema.
+	ids map[registryKey]int32
+}
+
+type registryKey struct {
+	subject string
+	schema  string
+}
+
+// NewRegistry creates a client of the schema registry at the URL.
+// User and password may be given in the URL for basic authentication.
+func NewRegistry(u string, timeout time.Duration) *Registry {
+	return &Registry{
+		url: strings.TrimSuffix(u, "/"),
+		client: &http.Client{
+			Timeout: timeout,
+		},
+		ids: make(map[registryKey]int32),
+	}
+}
+
+// SchemaID returns the ID of the schema under the subject, registering the schema as a new version
+// of the subject if it is not registered yet.
+// The IDs are cached, so the registry is asked once for each schema.
+func (r *Registry) SchemaID(subject string, s *Schema) (int32, error) {
+	key := registryKey{subject: subject, schema: s.String()}
+	r.mu.Lock()
+	id, ok := r.ids[key]
+	r.mu.Unlock()
+	if ok {
+		return id, nil
+	}
+
+	id, err := r.register(subject, s.String())
+	if err != nil {
+		return 0, err
+	}
+	r.mu.Lock()
+	r.ids[key] = id
+	r.mu.Unlock()
+	return id, nil
+}
+
+// register registers the schema under the subject, which returns the ID of the existing version
+// if the schema is already registered.
+func (r *Registry) register(subject, schema string) (int32, error) {
+	body, err := json.Marshal(struct {
+		Schema string `json:"schema"`
+	}{
+		Schema: schema,
+	})
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequest("POST", r.url+"/subjects/"+url.PathEscape(subject)+"/versions", bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", registryContentType)
+	var res struct {
+		ID int32 `json:"id"`
+	}
+	if err := r.do(req, &res); err != nil {
+		return 0, fmt.Errorf("failed to register schema for subject %q: %v", subject, err)
+	}
+	return res.ID, nil
+}
+
+// Latest returns the latest version of the schema of the subject and its ID.
+func (r *Registry) Latest(subject string) (*Schema, int32, error) {
+	req, err := http.NewRequest("GET", r.url+"/subjects/"+url.PathEscape(subject)+"/versions/latest", nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	var res struct {
+		ID         int32  `json:"id"`
+		Schema     string `json:"schema"`
+		SchemaType string `json:"schemaType"`
+	}
+	if err := r.do(req, &res); err != nil {
+		return nil, 0, fmt.Errorf("failed to get latest schema of subject %q: %v", subject, err)
+	}
+	// The schema type is omitted for Avro schemas.
+	if res.SchemaType != "" && res.SchemaType != "AVRO" {
+		return nil, 0, fmt.Errorf("schema of subject %q is of unsupported type %s", subject, res.SchemaType)
+	}
+	s, err := ParseSchema(res.Schema)
+	if err != nil {
+		return nil, 0, fmt.Errorf("schema of subject %q: %v", subject, err)
+	}
+	return s, res.ID, nil
+}
+
+// do sends a request to the registry and decodes the JSON response into res.
+func (r *Registry) do(req *http.Request, res interface{}) error {
+	req.Header.Set("Accept", registryContentType)
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode/100 != 2 {
+		var e struct {
+			ErrorCode int    `json:"error_code"`
+			Message   string `json:"message"`
+		}
+		if err := json.Unmarshal(data, &e); err != nil || e.Message == "" {
+			return fmt.Errorf("unexpected response code %d", resp.StatusCode)
+		}
+		return fmt.Errorf("error %d: %s", e.ErrorCode, e.Message)
+	}
+	if err := json.Unmarshal(data, res); err != nil {
+		return fmt.Errorf("failed to decode response of schema registry: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package avro_test
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/avro"
+	"github.com/influxdata/kapacitor/avro/avrotest"
+)
+
+func TestRegistry_SchemaID(t *testing.T) {
+	mr := avrotest.NewRegistry()
+	defer mr.Close()
+	r := avro.NewRegistry(mr.URL, time.Second)
+
+	v1, err := avro.GenerateSchema("cpu", nil, map[string]interface{}{"value": 1.0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v2, err := avro.GenerateSchema("cpu", nil, map[string]interface{}{"value": 1.0, "count": int64(1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, tc := range []struct {
+		subject string
+		schema  *avro.Schema
+		exp     int32
+	}{
+		{subject: "cpu-value", schema: v1, exp: 1},
+		{subject: "cpu-value", schema: v2, exp: 2},
+		// Cached
+		{subject: "cpu-value", schema: v1, exp: 1},
+		// Registered under another subject with the same ID.
+		{subject: "other-value", schema: v2, exp: 2},
+	} {
+		id, err := r.SchemaID(tc.subject, tc.schema)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != tc.exp {
+			t.Errorf("%d: unexpected ID: got %d exp %d", i, id, tc.exp)
+		}
+	}
+	if got, exp := mr.Requests(), 3; got != exp {
+		t.Errorf("unexpected number of requests: got %d exp %d", got, exp)
+	}
+	if got, exp := mr.Versions("cpu-value"), []int32{1, 2}; !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected versions: got %v exp %v", got, exp)
+	}
+	if got, _ := mr.Schema(2); got != v2.String() {
+		t.Errorf("unexpected schema: got %s exp %s", got, v2.String())
+	}
+}
+
+func TestRegistry_Error(t *testing.T) {
+	mr := avrotest.NewRegistry()
+	defer mr.Close()
+	mr.SetError(http.StatusConflict)
+	r := avro.NewRegistry(mr.URL, time.Second)
+
+	s, err := avro.GenerateSchema("cpu", nil, map[string]interface{}{"value": 1.0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.SchemaID("cpu-value", s); err == nil {
+		t.Fatal("expected error")
+	}
+	// Errors are not cached.
+	mr.SetError(0)
+	if id, err := r.SchemaID("cpu-value", s); err != nil || id != 1 {
+		t.Errorf("unexpected ID: got %d %v exp 1", id, err)
+	}
+}
+
+func TestRegistry_Latest(t *testing.T) {
+	mr := avrotest.NewRegistry()
+	defer mr.Close()
+	r := avro.NewRegistry(mr.URL, time.Second)
+
+	if _, _, err := r.Latest("cpu-value"); err == nil {
+		t.Fatal("expected error for unknown subject")
+	}
+	mr.Register("cpu-value", `{"type":"record","name":"cpu","fields":[{"name":"value","type":"double"}]}`)
+	v2 := `{"type":"record","name":"cpu","fields":[{"name":"value","type":["null","double"],"default":null}]}`
+	mr.Register("cpu-value", v2)
+
+	s, id, err := r.Latest("cpu-value")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != 2 {
+		t.Errorf("unexpected ID: got %d exp 2", id)
+	}
+	if s.String() != v2 {
+		t.Errorf("unexpected schema: got %s exp %s", s.String(), v2)
+	}
+}
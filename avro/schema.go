@@ -0,0 +1,324 @@
+// Package avro implements the subset of Apache Avro needed to publish points:
+// record schemas with fields of primitive types and unions of them, their binary encoding,
+// the Confluent wire format and a client of the Confluent schema registry.
+package avro
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// The supported primitive types.
+const (
+	Null    = "null"
+	Boolean = "boolean"
+	Int     = "int"
+	Long    = "long"
+	Float   = "float"
+	Double  = "double"
+	String  = "string"
+)
+
+// The supported logical types of longs.
+const (
+	TimestampMillis = "timestamp-millis"
+	TimestampMicros = "timestamp-micros"
+)
+
+// TimeField is the name of the field with the time of a point.
+const TimeField = "time"
+
+// Type is a primitive type, optionally annotated with a logical type.
+type Type struct {
+	Name        string
+	LogicalType string
+}
+
+// MarshalJSON encodes the type as its name, or as an object if it has a logical type.
+func (t Type) MarshalJSON() ([]byte, error) {
+	if t.LogicalType == "" {
+		return json.Marshal(t.Name)
+	}
+	return json.Marshal(struct {
+		Type        string `json:"type"`
+		LogicalType string `json:"logicalType"`
+	}{
+		Type:        t.Name,
+		LogicalType: t.LogicalType,
+	})
+}
+
+// Field is a field of a record.
+type Field struct {
+	Name string
+	// Types are the branches of the union of the field, or its only type if it is not a union.
+	Types []Type
+}
+
+// MarshalJSON encodes the field, with a null default if the first branch of its union is null.
+func (f Field) MarshalJSON() ([]byte, error) {
+	var typ interface{} = f.Types
+	if len(f.Types) == 1 {
+		typ = f.Types[0]
+	}
+	if len(f.Types) > 1 && f.Types[0].Name == Null {
+		return json.Marshal(struct {
+			Name    string      `json:"name"`
+			Type    interface{} `json:"type"`
+			Default interface{} `json:"default"`
+		}{
+			Name: f.Name,
+			Type: typ,
+		})
+	}
+	return json.Marshal(struct {
+		Name string      `json:"name"`
+		Type interface{} `json:"type"`
+	}{
+		Name: f.Name,
+		Type: typ,
+	})
+}
+
+// Schema is a record schema.
+type Schema struct {
+	Name   string
+	Fields []Field
+
+	// text is the JSON of the schema as registered.
+	text string
+}
+
+// String returns the JSON of the schema.
+func (s *Schema) String() string {
+	return s.text
+}
+
+// ParseSchema parses the JSON of a record schema.
+// Only fields of the primitive types null, boolean, int, long, float, double and string,
+// and unions of them, are supported.
+func ParseSchema(text string) (*Schema, error) {
+	var raw struct {
+		Type   string `json:"type"`
+		Name   string `json:"name"`
+		Fields []struct {
+			Name string          `json:"name"`
+			Type json.RawMessage `json:"type"`
+		} `json:"fields"`
+	}
+	if err := json.Unmarshal([]byte(text), &raw); err != nil {
+		return nil, fmt.Errorf("invalid schema: %v", err)
+	}
+	if raw.Type != "record" {
+		return nil, fmt.Errorf("schema must be a record, got %q", raw.Type)
+	}
+	if raw.Name == "" {
+		return nil, fmt.Errorf("schema must have a name")
+	}
+	s := &Schema{
+		Name:   raw.Name,
+		Fields: make([]Field, len(raw.Fields)),
+		text:   text,
+	}
+	names := make(map[string]bool, len(raw.Fields))
+	for i, rf := range raw.Fields {
+		if !validName(rf.Name) {
+			return nil, fmt.Errorf("invalid field name %q", rf.Name)
+		}
+		if names[rf.Name] {
+			return nil, fmt.Errorf("duplicate field %q", rf.Name)
+		}
+		names[rf.Name] = true
+		types, err := parseTypes(rf.Type)
+		if err != nil {
+			return nil, fmt.Errorf("invalid type of field %q: %v", rf.Name, err)
+		}
+		s.Fields[i] = Field{Name: rf.Name, Types: types}
+	}
+	return s, nil
+}
+
+// parseTypes parses the type of a field, either a type or a union of types.
+func parseTypes(data json.RawMessage) ([]Type, error) {
+	var union []json.RawMessage
+	if err := json.Unmarshal(data, &union); err != nil {
+		t, err := parseType(data)
+		if err != nil {
+			return nil, err
+		}
+		return []Type{t}, nil
+	}
+	if len(union) == 0 {
+		return nil, fmt.Errorf("empty union")
+	}
+	types := make([]Type, len(union))
+	seen := make(map[string]bool, len(union))
+	for i, u := range union {
+		t, err := parseType(u)
+		if err != nil {
+			return nil, err
+		}
+		if seen[t.Name] {
+			return nil, fmt.Errorf("duplicate type %q in union", t.Name)
+		}
+		seen[t.Name] = true
+		types[i] = t
+	}
+	return types, nil
+}
+
+func parseType(data json.RawMessage) (Type, error) {
+	var t Type
+	if err := json.Unmarshal(data, &t.Name); err != nil {
+		var raw struct {
+			Type        string `json:"type"`
+			LogicalType string `json:"logicalType"`
+		}
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return Type{}, fmt.Errorf("unsupported type %s", data)
+		}
+		t = Type{Name: raw.Type, LogicalType: raw.LogicalType}
+	}
+	switch t.Name {
+	case Null, Boolean, Int, Long, Float, Double, String:
+	default:
+		return Type{}, fmt.Errorf("unsupported type %q", t.Name)
+	}
+	switch t.LogicalType {
+	case "":
+	case TimestampMillis, TimestampMicros:
+		if t.Name != Long {
+			return Type{}, fmt.Errorf("logical type %q must annotate a long", t.LogicalType)
+		}
+	default:
+		return Type{}, fmt.Errorf("unsupported logical type %q", t.LogicalType)
+	}
+	return t, nil
+}
+
+// GenerateSchema generates a schema for a point.
+// The record has the name of the measurement and the fields:
+//
+//   - time -- a long with the time of the point in microseconds since the epoch
+//   - the tags -- nullable strings, in order of their names
+//   - the fields -- nullable values of their types, in order of their names
+//
+// Since all fields but the time are nullable with a null default, the schemas of points
+// with more or fewer tags and fields are compatible versions of each other.
+// The names are sanitized with Name, it is an error if two of them have the same name.
+func GenerateSchema(name string, tags map[string]string, fields map[string]interface{}) (*Schema, error) {
+	s := &Schema{
+		Name: Name(name),
+		Fields: []Field{{
+			Name:  TimeField,
+			Types: []Type{{Name: Long, LogicalType: TimestampMicros}},
+		}},
+	}
+	names := map[string]string{TimeField: TimeField}
+	add := func(source, name string, t string) error {
+		n := Name(name)
+		if other, ok := names[n]; ok {
+			return fmt.Errorf("%s %q has the same Avro name %q as %q", source, name, n, other)
+		}
+		names[n] = name
+		s.Fields = append(s.Fields, Field{
+			Name:  n,
+			Types: []Type{{Name: Null}, {Name: t}},
+		})
+		return nil
+	}
+
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if err := add("tag", k, String); err != nil {
+			return nil, err
+		}
+	}
+
+	keys = keys[:0]
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		var t string
+		switch fields[k].(type) {
+		case float64:
+			t = Double
+		case int64:
+			t = Long
+		case string:
+			t = String
+		case bool:
+			t = Boolean
+		default:
+			return nil, fmt.Errorf("field %q has unsupported type %T", k, fields[k])
+		}
+		if err := add("field", k, t); err != nil {
+			return nil, err
+		}
+	}
+
+	text, err := json.Marshal(struct {
+		Type   string  `json:"type"`
+		Name   string  `json:"name"`
+		Fields []Field `json:"fields"`
+	}{
+		Type:   "record",
+		Name:   s.Name,
+		Fields: s.Fields,
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.text = string(text)
+	return s, nil
+}
+
+// Name sanitizes a name to a valid Avro name,
+// replacing all characters other than letters, digits and underscores with underscores
+// and prefixing names starting with a digit with an underscore.
+func Name(name string) string {
+	if validName(name) {
+		return name
+	}
+	var b bytes.Buffer
+	for i, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '_':
+			b.WriteRune(r)
+		case r >= '0' && r <= '9':
+			if i == 0 {
+				b.WriteByte('_')
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	if b.Len() == 0 {
+		return "_"
+	}
+	return b.String()
+}
+
+func validName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '_':
+		case r >= '0' && r <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,94 @@
+package avro_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/influxdata/kapacitor/avro"
+)
+
+func TestParseSchema(t *testing.T) {
+	text := `{
+	"type": "record",
+	"name": "cpu",
+	"fields": [
+		{"name": "time", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+		{"name": "host", "type": "string"},
+		{"name": "value", "type": ["null", "double"], "default": null}
+	]
+}`
+	s, err := avro.ParseSchema(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := []avro.Field{
+		{Name: "time", Types: []avro.Type{{Name: avro.Long, LogicalType: avro.TimestampMillis}}},
+		{Name: "host", Types: []avro.Type{{Name: avro.String}}},
+		{Name: "value", Types: []avro.Type{{Name: avro.Null}, {Name: avro.Double}}},
+	}
+	if s.Name != "cpu" || !reflect.DeepEqual(s.Fields, exp) {
+		t.Errorf("unexpected schema:\ngot %s %+v\nexp cpu %+v", s.Name, s.Fields, exp)
+	}
+	if s.String() != text {
+		t.Errorf("unexpected schema text: %s", s.String())
+	}
+}
+
+func TestParseSchema_Invalid(t *testing.T) {
+	testCases := map[string]string{
+		"not json":             `{`,
+		"not a record":         `{"type": "enum", "name": "e", "symbols": ["A"]}`,
+		"no name":              `{"type": "record", "fields": []}`,
+		"invalid field name":   `{"type": "record", "name": "r", "fields": [{"name": "a-b", "type": "string"}]}`,
+		"duplicate field":      `{"type": "record", "name": "r", "fields": [{"name": "a", "type": "string"}, {"name": "a", "type": "long"}]}`,
+		"unsupported type":     `{"type": "record", "name": "r", "fields": [{"name": "a", "type": "bytes"}]}`,
+		"nested record":        `{"type": "record", "name": "r", "fields": [{"name": "a", "type": {"type": "record", "name": "n", "fields": []}}]}`,
+		"duplicate union type": `{"type": "record", "name": "r", "fields": [{"name": "a", "type": ["null", "null"]}]}`,
+		"logical type":         `{"type": "record", "name": "r", "fields": [{"name": "a", "type": {"type": "int", "logicalType": "timestamp-millis"}}]}`,
+	}
+	for name, text := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := avro.ParseSchema(text); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestGenerateSchema(t *testing.T) {
+	s, err := avro.GenerateSchema(
+		"cpu.usage",
+		map[string]string{"host": "a", "2nd-dc": "b"},
+		map[string]interface{}{"value": 1.0, "count": int64(1), "state": "ok", "up": true},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := `{"type":"record","name":"cpu_usage","fields":[` +
+		`{"name":"time","type":{"type":"long","logicalType":"timestamp-micros"}},` +
+		`{"name":"_2nd_dc","type":["null","string"],"default":null},` +
+		`{"name":"host","type":["null","string"],"default":null},` +
+		`{"name":"count","type":["null","long"],"default":null},` +
+		`{"name":"state","type":["null","string"],"default":null},` +
+		`{"name":"up","type":["null","boolean"],"default":null},` +
+		`{"name":"value","type":["null","double"],"default":null}]}`
+	if got := s.String(); got != exp {
+		t.Errorf("unexpected schema:\ngot %s\nexp %s", got, exp)
+	}
+	// The generated schema is valid.
+	if _, err := avro.ParseSchema(s.String()); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestGenerateSchema_Collision(t *testing.T) {
+	if _, err := avro.GenerateSchema("cpu", map[string]string{"host": "a"}, map[string]interface{}{"host": 1.0}); err == nil {
+		t.Error("expected error for a tag and field with the same name")
+	}
+	if _, err := avro.GenerateSchema("cpu", nil, map[string]interface{}{"a-b": 1.0, "a_b": 1.0}); err == nil {
+		t.Error("expected error for fields with the same sanitized name")
+	}
+	if _, err := avro.GenerateSchema("cpu", map[string]string{"time": "a"}, nil); err == nil {
+		t.Error("expected error for a tag named time")
+	}
+}
@@ -0,0 +1,48 @@
+package avro
+
+import "fmt"
+
+// Reasons a record fails validation.
+const (
+	// ReasonMissingField is the reason for a missing value of a field that is not nullable.
+	ReasonMissingField = "missing_field"
+	// ReasonTypeMismatch is the reason for a value that matches none of the types of its field.
+	ReasonTypeMismatch = "type_mismatch"
+)
+
+// ValidationError is the error of a record that does not conform to a schema.
+type ValidationError struct {
+	Field  string
+	Reason string
+	Err    error
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("field %q: %v", e.Field, e.Err)
+}
+
+// Validate checks that a record can be encoded with the schema,
+// returning a *ValidationError for the first field that does not conform.
+func (s *Schema) Validate(values map[string]interface{}) error {
+	for _, f := range s.Fields {
+		v := values[f.Name]
+		if _, err := appendField(nil, f, v); err != nil {
+			reason := ReasonTypeMismatch
+			if v == nil {
+				reason = ReasonMissingField
+			}
+			return &ValidationError{Field: f.Name, Reason: reason, Err: err}
+		}
+	}
+	return nil
+}
+
+// HasField reports whether the schema has a field with the name.
+func (s *Schema) HasField(name string) bool {
+	for _, f := range s.Fields {
+		if f.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+package avro_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/avro"
+)
+
+func TestSchema_Validate(t *testing.T) {
+	s, err := avro.ParseSchema(`{
+	"type": "record",
+	"name": "cpu",
+	"fields": [
+		{"name": "time", "type": {"type": "long", "logicalType": "timestamp-micros"}},
+		{"name": "host", "type": "string"},
+		{"name": "value", "type": ["null", "double"]},
+		{"name": "count", "type": ["null", "int"]}
+	]
+}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		name   string
+		values map[string]interface{}
+		field  string
+		reason string
+	}{
+		{
+			name:   "valid",
+			values: map[string]interface{}{"time": time.Unix(1, 0), "host": "a", "value": 1.0, "count": int64(1)},
+		},
+		{
+			name:   "valid nulls",
+			values: map[string]interface{}{"time": time.Unix(1, 0), "host": "a"},
+		},
+		{
+			name:   "missing field",
+			values: map[string]interface{}{"time": time.Unix(1, 0), "value": 1.0},
+			field:  "host",
+			reason: avro.ReasonMissingField,
+		},
+		{
+			name:   "type mismatch",
+			values: map[string]interface{}{"time": time.Unix(1, 0), "host": "a", "value": "high"},
+			field:  "value",
+			reason: avro.ReasonTypeMismatch,
+		},
+		{
+			name:   "out of range",
+			values: map[string]interface{}{"time": time.Unix(1, 0), "host": "a", "count": int64(1) << 40},
+			field:  "count",
+			reason: avro.ReasonTypeMismatch,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := s.Validate(tc.values)
+			if tc.reason == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			verr, ok := err.(*avro.ValidationError)
+			if !ok {
+				t.Fatalf("expected validation error, got %v", err)
+			}
+			if verr.Field != tc.field || verr.Reason != tc.reason {
+				t.Errorf("unexpected error: got %s %s exp %s %s", verr.Field, verr.Reason, tc.field, tc.reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package kapacitor
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/influxdata/kapacitor/avro"
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/keyvalue"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/services/kafka"
+)
+
+const (
+	statsAvroPublished           = "published"
+	statsAvroSerializationErrors = "serialization_errors"
+	statsAvroRegistryErrors      = "registry_errors"
+	statsAvroPublishErrors       = "publish_errors"
+)
+
+type AvroOutNode struct {
+	node
+	o *pipeline.AvroOutNode
+
+	cluster  *kafka.Cluster
+	registry *avro.Registry
+	subject  string
+	// schema is the configured schema, if any.
+	schema *avro.Schema
+	// schemas are the generated schemas by the signature of the points.
+	schemas map[string]*avro.Schema
+	// name of the current batch
+	batchName string
+
+	published           *expvar.Int
+	serializationErrors *expvar.Int
+	registryErrors      *expvar.Int
+	publishErrors       *expvar.Int
+}
+
+// Create a new AvroOutNode which publishes each point to a Kafka topic as an Avro record.
+func newAvroOutNode(et *ExecutingTask, n *pipeline.AvroOutNode, d NodeDiagnostic) (*AvroOutNode, error) {
+	if et.tm.KafkaService == nil {
+		return nil, fmt.Errorf("kafka service is not enabled")
+	}
+	cluster, ok := et.tm.KafkaService.Cluster(n.Cluster)
+	if !ok {
+		return nil, fmt.Errorf("unknown kafka cluster %q", n.Cluster)
+	}
+	an := &AvroOutNode{
+		node:                node{Node: n, et: et, diag: d},
+		o:                   n,
+		cluster:             cluster,
+		registry:            avro.NewRegistry(n.RegistryURL, n.Timeout),
+		subject:             n.Subject,
+		schemas:             make(map[string]*avro.Schema),
+		published:           new(expvar.Int),
+		serializationErrors: new(expvar.Int),
+		registryErrors:      new(expvar.Int),
+		publishErrors:       new(expvar.Int),
+	}
+	if an.subject == "" {
+		an.subject = n.Topic + "-value"
+	}
+	if n.Schema != "" {
+		s, err := avro.ParseSchema(n.Schema)
+		if err != nil {
+			return nil, err
+		}
+		an.schema = s
+	}
+	an.node.runF = an.runAvroOut
+	return an, nil
+}
+
+func (n *AvroOutNode) runAvroOut([]byte) error {
+	n.statMap.Set(statsAvroPublished, n.published)
+	n.statMap.Set(statsAvroSerializationErrors, n.serializationErrors)
+	n.statMap.Set(statsAvroRegistryErrors, n.registryErrors)
+	n.statMap.Set(statsAvroPublishErrors, n.publishErrors)
+
+	consumer := edge.NewConsumerWithReceiver(
+		n.ins[0],
+		edge.NewReceiverFromForwardReceiverWithStats(
+			n.outs,
+			edge.NewTimedForwardReceiver(n.timer, n),
+		),
+	)
+	return consumer.Consume()
+}
+
+// publish publishes a single point, errors are logged and the point is passed on regardless.
+func (n *AvroOutNode) publish(name string, t time.Time, tags models.Tags, fields models.Fields) {
+	s, err := n.schemaOf(name, tags, fields)
+	if err != nil {
+		n.serializationErrors.Add(1)
+		n.diag.Error("failed to generate schema", err)
+		return
+	}
+
+	record, err := s.Encode(avroValues(t, tags, fields))
+	if err != nil {
+		n.serializationErrors.Add(1)
+		n.diag.Error("failed to encode record", err)
+		return
+	}
+
+	id, err := n.registry.SchemaID(n.subject, s)
+	if err != nil {
+		n.registryErrors.Add(1)
+		n.diag.Error("failed to register schema", err, keyvalue.KV("subject", n.subject))
+		return
+	}
+
+	if err := n.cluster.WriteMessage(n.o.Topic, nil, avro.Frame(id, record)); err != nil {
+		n.publishErrors.Add(1)
+		n.diag.Error("failed to publish message", err, keyvalue.KV("topic", n.o.Topic))
+		return
+	}
+	n.published.Add(1)
+}
+
+// avroValues returns the values of the record of a point by their Avro names,
+// fields take precedence over tags with the same name.
+func avroValues(t time.Time, tags models.Tags, fields models.Fields) map[string]interface{} {
+	values := make(map[string]interface{}, len(tags)+len(fields)+1)
+	for k, v := range tags {
+		values[avro.Name(k)] = v
+	}
+	for k, v := range fields {
+		values[avro.Name(k)] = v
+	}
+	values[avro.TimeField] = t
+	return values
+}
+
+// schemaOf returns the configured schema, or else the schema generated for the point.
+// Generated schemas are cached by the name and the names and types of the tags and fields of the points.
+func (n *AvroOutNode) schemaOf(name string, tags models.Tags, fields models.Fields) (*avro.Schema, error) {
+	if n.schema != nil {
+		return n.schema, nil
+	}
+	sig := avroSignature(name, tags, fields)
+	if s, ok := n.schemas[sig]; ok {
+		return s, nil
+	}
+	s, err := avro.GenerateSchema(name, tags, fields)
+	if err != nil {
+		return nil, err
+	}
+	n.schemas[sig] = s
+	return s, nil
+}
+
+// avroSignature identifies the schema generated for a point.
+func avroSignature(name string, tags models.Tags, fields models.Fields) string {
+	var buf bytes.Buffer
+	buf.WriteString(name)
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		buf.WriteString("\x00t")
+		buf.WriteString(k)
+	}
+	keys = keys[:0]
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&buf, "\x00f%s\x00%T", k, fields[k])
+	}
+	return buf.String()
+}
+
+func (n *AvroOutNode) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	n.batchName = begin.Name()
+	return begin, nil
+}
+
+func (n *AvroOutNode) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	n.publish(n.batchName, bp.Time(), bp.Tags(), bp.Fields())
+	return bp, nil
+}
+
+func (n *AvroOutNode) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (n *AvroOutNode) Point(p edge.PointMessage) (edge.Message, error) {
+	n.publish(p.Name(), p.Time(), p.Tags(), p.Fields())
+	return p, nil
+}
+
+func (n *AvroOutNode) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+
+func (n *AvroOutNode) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+
+func (n *AvroOutNode) Done() {}
@@ -0,0 +1,217 @@
+package kapacitor
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/influxdb"
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+	pkgerrors "github.com/pkg/errors"
+)
+
+const (
+	statsPointsBackfilled   = "points_backfilled"
+	statsPointsDeduplicated = "points_deduplicated"
+)
+
+type BackfillNode struct {
+	node
+	b     *pipeline.BackfillNode
+	query *Query
+
+	// seam is the time of the first live point,
+	// all earlier points have been backfilled.
+	seam time.Time
+
+	mu sync.Mutex
+	// lastWritten is the time of the latest point emitted.
+	lastWritten time.Time
+
+	pointsBackfilled   *expvar.Int
+	pointsDeduplicated *expvar.Int
+}
+
+// backfillSnapshot is the state of a BackfillNode saved in the task snapshot.
+type backfillSnapshot struct {
+	LastWritten time.Time `json:"lastWritten"`
+}
+
+// Create a new BackfillNode which fills the gap in a stream by querying InfluxDB.
+func newBackfillNode(et *ExecutingTask, n *pipeline.BackfillNode, d NodeDiagnostic) (*BackfillNode, error) {
+	q, err := NewQuery(n.QueryStr)
+	if err != nil {
+		return nil, err
+	}
+	// Group by all tags so the queried points carry the same tags as the live points.
+	if err := q.Dimensions([]interface{}{&ast.StarNode{}}); err != nil {
+		return nil, err
+	}
+	bn := &BackfillNode{
+		node:               node{Node: n, et: et, diag: d},
+		b:                  n,
+		query:              q,
+		pointsBackfilled:   new(expvar.Int),
+		pointsDeduplicated: new(expvar.Int),
+	}
+	bn.node.runF = bn.runBackfill
+	return bn, nil
+}
+
+func (n *BackfillNode) runBackfill(snapshot []byte) error {
+	n.statMap.Set(statsPointsBackfilled, n.pointsBackfilled)
+	n.statMap.Set(statsPointsDeduplicated, n.pointsDeduplicated)
+	if len(snapshot) > 0 {
+		var s backfillSnapshot
+		if err := json.Unmarshal(snapshot, &s); err != nil {
+			return pkgerrors.Wrap(err, "failed to restore backfill snapshot")
+		}
+		n.lastWritten = s.LastWritten
+	}
+	consumer := edge.NewConsumerWithReceiver(
+		n.ins[0],
+		edge.NewReceiverFromForwardReceiverWithStats(
+			n.outs,
+			edge.NewTimedForwardReceiver(n.timer, n),
+		),
+	)
+	return consumer.Consume()
+}
+
+func (n *BackfillNode) snapshot() ([]byte, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.lastWritten.IsZero() {
+		return nil, nil
+	}
+	return json.Marshal(backfillSnapshot{LastWritten: n.lastWritten})
+}
+
+func (n *BackfillNode) written(t time.Time) {
+	n.mu.Lock()
+	if t.After(n.lastWritten) {
+		n.lastWritten = t
+	}
+	n.mu.Unlock()
+}
+
+// backfill queries the gap before the first live point p and emits the queried points.
+func (n *BackfillNode) backfill(p edge.PointMessage) error {
+	n.seam = p.Time()
+	start := n.seam.Add(-n.b.Lookback)
+	n.mu.Lock()
+	if n.lastWritten.After(start) {
+		// The last written point is included in the query, it is dropped below.
+		start = n.lastWritten
+	}
+	lastWritten := n.lastWritten
+	n.mu.Unlock()
+	if !start.Before(n.seam) {
+		return nil
+	}
+
+	points, err := n.queryGap(start, n.seam, p)
+	if err != nil {
+		n.diag.Error("failed to backfill", err)
+		return nil
+	}
+	for _, m := range points {
+		if !m.Time().After(lastWritten) {
+			continue
+		}
+		n.timer.Pause()
+		err := edge.Forward(n.outs, m)
+		n.timer.Resume()
+		if err != nil {
+			return err
+		}
+		n.pointsBackfilled.Add(1)
+		n.written(m.Time())
+	}
+	return nil
+}
+
+// queryGap queries InfluxDB for the points in [start, stop) sorted by time.
+// The points are given the database, retention policy and dimensions of the live point.
+func (n *BackfillNode) queryGap(start, stop time.Time, live edge.PointMessage) ([]edge.PointMessage, error) {
+	if n.et.tm.InfluxDBService == nil {
+		return nil, errors.New("InfluxDB not configured, cannot query InfluxDB for backfill")
+	}
+	con, err := n.et.tm.InfluxDBService.NewNamedClient(n.b.Cluster)
+	if err != nil {
+		return nil, pkgerrors.Wrap(err, "failed to get InfluxDB client")
+	}
+	n.query.SetStartTime(start)
+	n.query.SetStopTime(stop)
+	qStr := n.query.String()
+	n.diag.StartingBatchQuery(qStr)
+	resp, err := con.Query(influxdb.Query{
+		Command: qStr,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var points []edge.PointMessage
+	for _, res := range resp.Results {
+		batches, err := edge.ResultToBufferedBatches(res, false)
+		if err != nil {
+			return nil, err
+		}
+		for _, b := range batches {
+			for _, bp := range b.Points() {
+				points = append(points, edge.NewPointMessage(
+					b.Name(),
+					live.Database(),
+					live.RetentionPolicy(),
+					live.Dimensions(),
+					bp.Fields(),
+					bp.Tags(),
+					bp.Time(),
+				))
+			}
+		}
+	}
+	sort.SliceStable(points, func(i, j int) bool {
+		return points[i].Time().Before(points[j].Time())
+	})
+	return points, nil
+}
+
+func (n *BackfillNode) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	return nil, errors.New("backfill does not support batch data")
+}
+
+func (n *BackfillNode) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	return nil, errors.New("backfill does not support batch data")
+}
+
+func (n *BackfillNode) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return nil, errors.New("backfill does not support batch data")
+}
+
+func (n *BackfillNode) Point(p edge.PointMessage) (edge.Message, error) {
+	if n.seam.IsZero() {
+		if err := n.backfill(p); err != nil {
+			return nil, err
+		}
+	} else if p.Time().Before(n.seam) {
+		// The point falls within the backfilled range.
+		n.pointsDeduplicated.Add(1)
+		return nil, nil
+	}
+	n.written(p.Time())
+	return p, nil
+}
+
+func (n *BackfillNode) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (n *BackfillNode) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (n *BackfillNode) Done() {}
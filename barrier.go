@@ -1,21 +1,44 @@
 package kapacitor
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
+	"net/http"
+	"path"
 	"time"
 
 	"sync"
 	"sync/atomic"
 
+	"github.com/influxdata/influxdb/influxql"
 	"github.com/influxdata/kapacitor/edge"
 	"github.com/influxdata/kapacitor/models"
 	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/services/httpd"
 )
 
+// minBarrierPeriod is the smallest period of periodic barriers,
+// smaller periods would have the barriers flood the pipeline.
+const minBarrierPeriod = 10 * time.Millisecond
+
 type BarrierNode struct {
 	node
 	b              *pipeline.BarrierNode
 	barrierStopper map[models.GroupID]func()
+
+	// period is shared by the periodic barriers of all groups.
+	period *barrierPeriod
+	// scheduler emits the barriers of all groups when the timer is shared, nil otherwise.
+	scheduler *barrierScheduler
+
+	// dataOuts receive the data, barrierOuts the barriers.
+	// Both are all output edges unless the barriers have a separate output.
+	dataOuts    []edge.StatsEdge
+	barrierOuts []edge.StatsEdge
+
+	mu     sync.Mutex
+	routes []httpd.Route
 }
 
 // Create a new  BarrierNode, which emits a barrier if data traffic has been idle for the configured amount of time.
@@ -28,21 +51,113 @@ func newBarrierNode(et *ExecutingTask, n *pipeline.BarrierNode, d NodeDiagnostic
 		b:              n,
 		barrierStopper: map[models.GroupID]func(){},
 	}
+	if n.Period != 0 {
+		bn.period = newBarrierPeriod(n.Period)
+	}
 	bn.node.runF = bn.runBarrierEmitter
+	bn.node.stopF = bn.stopBarrier
 	return bn, nil
 }
 
 func (n *BarrierNode) runBarrierEmitter([]byte) error {
 	defer n.stopBarrierEmitter()
+	if n.period != nil {
+		// Register period endpoint
+		p := path.Join("/tasks/", n.et.Task.ID, n.Name(), "period")
+		routes := []httpd.Route{
+			{
+				Method:      "GET",
+				Pattern:     p,
+				HandlerFunc: n.handleGetPeriod,
+			},
+			{
+				Method:      "POST",
+				Pattern:     p,
+				HandlerFunc: n.handleSetPeriod,
+			},
+		}
+		n.mu.Lock()
+		n.routes = routes
+		n.mu.Unlock()
+		if err := n.et.tm.HTTPDService.AddRoutes(routes); err != nil {
+			return err
+		}
+	}
+	if n.b.SharedTimerFlag {
+		n.scheduler = newBarrierScheduler(n.period)
+	}
+	n.mapOuts()
 	consumer := edge.NewGroupedConsumer(n.ins[0], n)
 	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
 	return consumer.Consume()
 }
 
+// mapOuts separates the output edges of the barrier branch from the other output edges.
+func (n *BarrierNode) mapOuts() {
+	if !n.b.BarrierOutputFlag {
+		n.dataOuts, n.barrierOuts = n.outs, n.outs
+		return
+	}
+	branches := make(map[pipeline.ID]bool)
+	for _, c := range n.b.Children() {
+		if _, ok := c.(*pipeline.SplitBranchNode); ok {
+			branches[c.ID()] = true
+		}
+	}
+	n.dataOuts, n.barrierOuts = nil, nil
+	for i, child := range n.children {
+		if branches[child.ID()] {
+			n.barrierOuts = append(n.barrierOuts, n.outs[i])
+		} else {
+			n.dataOuts = append(n.dataOuts, n.outs[i])
+		}
+	}
+}
+
 func (n *BarrierNode) stopBarrierEmitter() {
 	for _, stopF := range n.barrierStopper {
 		stopF()
 	}
+	if n.scheduler != nil {
+		n.scheduler.Stop()
+	}
+}
+
+func (n *BarrierNode) stopBarrier() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.routes != nil {
+		n.et.tm.HTTPDService.DelRoutes(n.routes)
+	}
+}
+
+type periodRequest struct {
+	Period string `json:"period"`
+}
+
+// handleGetPeriod returns the current period of the periodic barriers.
+func (n *BarrierNode) handleGetPeriod(w http.ResponseWriter, r *http.Request) {
+	b, _ := json.Marshal(periodRequest{Period: influxql.FormatDuration(n.period.get())})
+	_, _ = w.Write(b)
+}
+
+// handleSetPeriod changes the period of the periodic barriers while the task runs.
+func (n *BarrierNode) handleSetPeriod(w http.ResponseWriter, r *http.Request) {
+	req := periodRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpd.HttpError(w, fmt.Sprintf("invalid period request: %v", err), true, http.StatusBadRequest)
+		return
+	}
+	period, err := influxql.ParseDuration(req.Period)
+	if err != nil {
+		httpd.HttpError(w, fmt.Sprintf("invalid period %q: %v", req.Period, err), true, http.StatusBadRequest)
+		return
+	}
+	if err := n.period.set(period); err != nil {
+		httpd.HttpError(w, err.Error(), true, http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
 }
 
 func (n *BarrierNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
@@ -51,29 +166,71 @@ func (n *BarrierNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge
 		return nil, err
 	}
 	n.barrierStopper[group.ID] = stopF
+	if n.b.BarrierOutputFlag {
+		r = barrierOutput{ForwardReceiver: r, outs: n.barrierOuts}
+	}
 	return edge.NewReceiverFromForwardReceiverWithStats(
-		n.outs,
+		n.dataOuts,
 		edge.NewTimedForwardReceiver(n.timer, r),
 	), nil
 }
 
+// barrierOutput forwards the barriers passed on by a group to the barrier outputs instead of the data outputs.
+type barrierOutput struct {
+	edge.ForwardReceiver
+	outs []edge.StatsEdge
+}
+
+func (r barrierOutput) Barrier(m edge.BarrierMessage) (edge.Message, error) {
+	b, err := r.ForwardReceiver.Barrier(m)
+	if err != nil || b == nil {
+		return nil, err
+	}
+	return nil, edge.Forward(r.outs, b)
+}
+
+// DeleteGroup forwards the delete to the barrier outputs as well,
+// so that the children of the barrier branch also release the group.
+func (r barrierOutput) DeleteGroup(m edge.DeleteGroupMessage) (edge.Message, error) {
+	d, err := r.ForwardReceiver.DeleteGroup(m)
+	if err != nil || d == nil {
+		return d, err
+	}
+	if err := edge.Forward(r.outs, d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
 func (n *BarrierNode) newBarrier(group edge.GroupInfo, first edge.PointMeta) (edge.ForwardReceiver, func(), error) {
 	switch {
 	case n.b.Idle != 0:
-		idleBarrier := newIdleBarrier(
+		idleBarrier := newScheduledIdleBarrier(
 			first.Name(),
 			group,
 			n.b.Idle,
-			n.outs,
+			n.barrierOuts,
+			n.scheduler,
+			n.b.MarkersFlag,
 		)
 		return idleBarrier, idleBarrier.Stop, nil
 	case n.b.Period != 0:
-		periodicBarrier := newPeriodicBarrier(
+		periodicBarrier := newScheduledPeriodicBarrier(
 			first.Name(),
 			group,
-			n.b.Period,
-			n.outs,
+			n.period,
+			n.b.SkipEmptyFlag,
+			n.barrierOuts,
+			n.scheduler,
+			n.b.MarkersFlag,
 		)
+		if n.b.EmitImmediatelyFlag {
+			// The first barrier has the time of the first data of the group, so that the data is not dropped.
+			if err := periodicBarrier.emitBarrierAt(first.Time(), edge.BarrierReasonPeriod); err != nil {
+				periodicBarrier.Stop()
+				return nil, nil, err
+			}
+		}
 		return periodicBarrier, periodicBarrier.Stop, nil
 	default:
 		return nil, nil, errors.New("unreachable code, barrier node should have non-zero idle or non-zero period")
@@ -90,10 +247,63 @@ type idleBarrier struct {
 	wg           sync.WaitGroup
 	outs         []edge.StatsEdge
 	stopC        chan struct{}
+	stopOnce     sync.Once
 	resetTimerC  chan struct{}
+
+	// scheduler emits the barriers instead of the idleHandler goroutine when it is not nil.
+	scheduler *barrierScheduler
+	scheduled *scheduledBarrier
+	// markers indicates a marker point is emitted with each barrier.
+	markers bool
+	// resetAt is the time in unix nanoseconds the idle time was last restarted, used with a scheduler.
+	resetAt int64
+
+	// batch prevents barriers from being emitted while a batch is in progress.
+	batch batchGuard
+	// time of the batch in progress
+	batchT time.Time
+}
+
+// batchGuard tracks whether a batch is in progress,
+// so that barriers are only emitted between batches.
+type batchGuard struct {
+	mu      sync.Mutex
+	inBatch bool
+	// batched indicates batch data has been received.
+	batched bool
+}
+
+func (g *batchGuard) begin() {
+	g.mu.Lock()
+	g.inBatch = true
+	g.batched = true
+	g.mu.Unlock()
+}
+
+func (g *batchGuard) end() {
+	g.mu.Lock()
+	g.inBatch = false
+	g.mu.Unlock()
+}
+
+// emit calls f unless a batch is in progress.
+// No batch can begin until f has returned.
+func (g *batchGuard) emit(f func() error) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.inBatch {
+		return nil
+	}
+	return f()
 }
 
 func newIdleBarrier(name string, group edge.GroupInfo, idle time.Duration, outs []edge.StatsEdge) *idleBarrier {
+	return newScheduledIdleBarrier(name, group, idle, outs, nil, false)
+}
+
+// newScheduledIdleBarrier creates an idle barrier emitted by the scheduler,
+// or by a goroutine of its own if the scheduler is nil.
+func newScheduledIdleBarrier(name string, group edge.GroupInfo, idle time.Duration, outs []edge.StatsEdge, scheduler *barrierScheduler, markers bool) *idleBarrier {
 	r := &idleBarrier{
 		name:         name,
 		group:        group,
@@ -102,8 +312,12 @@ func newIdleBarrier(name string, group edge.GroupInfo, idle time.Duration, outs
 		lastBarrierT: atomic.Value{},
 		wg:           sync.WaitGroup{},
 		outs:         outs,
-		stopC:        make(chan struct{}),
-		resetTimerC:  make(chan struct{}),
+		scheduler:    scheduler,
+		markers:      markers,
+	}
+	if scheduler == nil {
+		r.stopC = make(chan struct{})
+		r.resetTimerC = make(chan struct{})
 	}
 
 	r.Init()
@@ -114,28 +328,50 @@ func newIdleBarrier(name string, group edge.GroupInfo, idle time.Duration, outs
 func (n *idleBarrier) Init() {
 	n.lastPointT.Store(time.Now().UTC())
 	n.lastBarrierT.Store(time.Time{})
+	if n.scheduler != nil {
+		now := time.Now()
+		atomic.StoreInt64(&n.resetAt, now.UnixNano())
+		n.scheduled = n.scheduler.add(n.fireIdle, now.Add(n.idle))
+		return
+	}
 	n.wg.Add(1)
 
 	go n.idleHandler()
 }
 
+// Stop stops emitting barriers, it is safe to call more than once.
 func (n *idleBarrier) Stop() {
+	n.stopOnce.Do(n.stop)
+}
+
+func (n *idleBarrier) stop() {
+	if n.scheduler != nil {
+		n.scheduler.remove(n.scheduled)
+		return
+	}
 	close(n.stopC)
 	n.wg.Wait()
 }
 
 func (n *idleBarrier) BeginBatch(m edge.BeginBatchMessage) (edge.Message, error) {
+	n.batch.begin()
+	n.batchT = m.Time()
 	return m, nil
 }
 func (n *idleBarrier) BatchPoint(m edge.BatchPointMessage) (edge.Message, error) {
 	if !m.Time().Before(n.lastBarrierT.Load().(time.Time)) {
-		n.resetTimer()
-		n.lastPointT.Store(m.Time())
 		return m, nil
 	}
 	return nil, nil
 }
 func (n *idleBarrier) EndBatch(m edge.EndBatchMessage) (edge.Message, error) {
+	// The idle time of batch data is measured from the end of the batch.
+	if !n.batchT.Before(n.lastBarrierT.Load().(time.Time)) {
+		n.lastPointT.Store(n.batchT)
+	}
+	// Restart the idle time before allowing barriers again.
+	n.resetTimer()
+	n.batch.end()
 	return m, nil
 }
 func (n *idleBarrier) Barrier(m edge.BarrierMessage) (edge.Message, error) {
@@ -149,7 +385,17 @@ func (n *idleBarrier) Barrier(m edge.BarrierMessage) (edge.Message, error) {
 }
 func (n *idleBarrier) DeleteGroup(m edge.DeleteGroupMessage) (edge.Message, error) {
 	if m.GroupID() == n.group.ID {
-		n.Stop()
+		stopped := false
+		n.stopOnce.Do(func() {
+			n.stop()
+			stopped = true
+		})
+		// Emit a final barrier only for the first delete of the group.
+		if stopped {
+			if err := n.emitBarrier(edge.BarrierReasonDelete); err != nil {
+				return nil, err
+			}
+		}
 	}
 	return m, nil
 }
@@ -165,14 +411,29 @@ func (n *idleBarrier) Point(m edge.PointMessage) (edge.Message, error) {
 }
 
 func (n *idleBarrier) resetTimer() {
+	if n.scheduler != nil {
+		atomic.StoreInt64(&n.resetAt, time.Now().UnixNano())
+		return
+	}
 	n.resetTimerC <- struct{}{}
 }
 
-func (n *idleBarrier) emitBarrier() error {
-	newT := n.lastPointT.Load().(time.Time).Add(n.idle)
+func (n *idleBarrier) emitBarrier(reason string) error {
+	newT := n.lastPointT.Load().(time.Time)
+	if reason == edge.BarrierReasonIdle {
+		if n.batch.batched {
+			// The next batch starts where the last one ended,
+			// so a single barrier at the time of the last batch is emitted.
+			if !newT.After(n.lastBarrierT.Load().(time.Time)) {
+				return nil
+			}
+		} else {
+			newT = newT.Add(n.idle)
+		}
+	}
 	n.lastPointT.Store(newT)
 	n.lastBarrierT.Store(newT)
-	return edge.Forward(n.outs, edge.NewBarrierMessage(n.group, newT))
+	return forwardBarrier(n.outs, n.group, newT, reason, n.markers)
 }
 
 func (n *idleBarrier) idleHandler() {
@@ -186,7 +447,9 @@ func (n *idleBarrier) idleHandler() {
 			}
 			idleTimer.Reset(n.idle)
 		case <-idleTimer.C:
-			n.emitBarrier()
+			n.batch.emit(func() error {
+				return n.emitBarrier(edge.BarrierReasonIdle)
+			})
 			idleTimer.Reset(n.idle)
 		case <-n.stopC:
 			idleTimer.Stop()
@@ -195,26 +458,140 @@ func (n *idleBarrier) idleHandler() {
 	}
 }
 
+// fireIdle emits an idle barrier if the group has been idle since the idle time was last restarted.
+// It returns the time the group will have been idle again.
+func (n *idleBarrier) fireIdle(now time.Time) time.Time {
+	if idleT := time.Unix(0, atomic.LoadInt64(&n.resetAt)).Add(n.idle); now.Before(idleT) {
+		return idleT
+	}
+	n.batch.emit(func() error {
+		return n.emitBarrier(edge.BarrierReasonIdle)
+	})
+	return now.Add(n.idle)
+}
+
+// forwardBarrier forwards a barrier of the group with time t,
+// preceded by a marker point of the barrier if markers is set.
+func forwardBarrier(outs []edge.StatsEdge, group edge.GroupInfo, t time.Time, reason string, markers bool) error {
+	if markers {
+		tags := make(models.Tags, len(group.Tags)+1)
+		for k, v := range group.Tags {
+			tags[k] = v
+		}
+		tags[pipeline.BarrierMarkerReasonTag] = reason
+		marker := edge.NewPointMessage(
+			pipeline.BarrierMarkerMeasurement,
+			"",
+			"",
+			group.Dimensions,
+			models.Fields{pipeline.BarrierMarkerGroupField: string(group.ID)},
+			tags,
+			t,
+		)
+		if err := edge.Forward(outs, marker); err != nil {
+			return err
+		}
+	}
+	return edge.Forward(outs, edge.NewBarrierMessageWithReason(group, t, reason))
+}
+
+// barrierPeriod is the period of periodic barriers, it can be changed at any time.
+type barrierPeriod struct {
+	mu     sync.Mutex
+	period time.Duration
+	// changed is closed when the period changes.
+	changed chan struct{}
+}
+
+func newBarrierPeriod(period time.Duration) *barrierPeriod {
+	return &barrierPeriod{
+		period:  period,
+		changed: make(chan struct{}),
+	}
+}
+
+func (p *barrierPeriod) get() time.Duration {
+	period, _ := p.load()
+	return period
+}
+
+// load returns the current period and a channel that is closed when the period changes.
+func (p *barrierPeriod) load() (time.Duration, <-chan struct{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.period, p.changed
+}
+
+// after returns the time one current period after t.
+func (p *barrierPeriod) after(t time.Time) time.Time {
+	period := p.get()
+	if period < minBarrierPeriod {
+		period = minBarrierPeriod
+	}
+	return t.Add(period)
+}
+
+func (p *barrierPeriod) set(period time.Duration) error {
+	if period < minBarrierPeriod {
+		return fmt.Errorf("period must be at least %s", minBarrierPeriod)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if period == p.period {
+		return nil
+	}
+	p.period = period
+	close(p.changed)
+	p.changed = make(chan struct{})
+	return nil
+}
+
 type periodicBarrier struct {
 	name  string
 	group edge.GroupInfo
 
-	lastT  atomic.Value
-	ticker *time.Ticker
-	wg     sync.WaitGroup
-	outs   []edge.StatsEdge
-	stopC  chan struct{}
+	lastT    atomic.Value
+	period   *barrierPeriod
+	wg       sync.WaitGroup
+	outs     []edge.StatsEdge
+	stopC    chan struct{}
+	stopOnce sync.Once
+
+	// skipEmpty indicates barriers are only emitted if the group is dirty,
+	// i.e. data has been received since the last barrier.
+	skipEmpty bool
+	dirty     int32
+
+	// batch prevents barriers from being emitted while a batch is in progress.
+	batch batchGuard
+
+	// scheduler emits the barriers instead of the periodicEmitter goroutine when it is not nil.
+	scheduler *barrierScheduler
+	scheduled *scheduledBarrier
+	// markers indicates a marker point is emitted with each barrier.
+	markers bool
 }
 
-func newPeriodicBarrier(name string, group edge.GroupInfo, period time.Duration, outs []edge.StatsEdge) *periodicBarrier {
+func newPeriodicBarrier(name string, group edge.GroupInfo, period *barrierPeriod, skipEmpty bool, outs []edge.StatsEdge) *periodicBarrier {
+	return newScheduledPeriodicBarrier(name, group, period, skipEmpty, outs, nil, false)
+}
+
+// newScheduledPeriodicBarrier creates a periodic barrier emitted by the scheduler,
+// or by a goroutine of its own if the scheduler is nil.
+func newScheduledPeriodicBarrier(name string, group edge.GroupInfo, period *barrierPeriod, skipEmpty bool, outs []edge.StatsEdge, scheduler *barrierScheduler, markers bool) *periodicBarrier {
 	r := &periodicBarrier{
-		name:   name,
-		group:  group,
-		lastT:  atomic.Value{},
-		ticker: time.NewTicker(period),
-		wg:     sync.WaitGroup{},
-		outs:   outs,
-		stopC:  make(chan struct{}),
+		name:      name,
+		group:     group,
+		lastT:     atomic.Value{},
+		period:    period,
+		wg:        sync.WaitGroup{},
+		outs:      outs,
+		skipEmpty: skipEmpty,
+		scheduler: scheduler,
+		markers:   markers,
+	}
+	if scheduler == nil {
+		r.stopC = make(chan struct{})
 	}
 
 	r.Init()
@@ -224,27 +601,42 @@ func newPeriodicBarrier(name string, group edge.GroupInfo, period time.Duration,
 
 func (n *periodicBarrier) Init() {
 	n.lastT.Store(time.Time{})
+	if n.scheduler != nil {
+		n.scheduled = n.scheduler.add(n.firePeriodic, n.period.after(time.Now()))
+		return
+	}
 	n.wg.Add(1)
 
 	go n.periodicEmitter()
 }
 
+// Stop stops emitting barriers, it is safe to call more than once.
 func (n *periodicBarrier) Stop() {
+	n.stopOnce.Do(n.stop)
+}
+
+func (n *periodicBarrier) stop() {
+	if n.scheduler != nil {
+		n.scheduler.remove(n.scheduled)
+		return
+	}
 	close(n.stopC)
-	n.ticker.Stop()
 	n.wg.Wait()
 }
 
 func (n *periodicBarrier) BeginBatch(m edge.BeginBatchMessage) (edge.Message, error) {
+	n.batch.begin()
 	return m, nil
 }
 func (n *periodicBarrier) BatchPoint(m edge.BatchPointMessage) (edge.Message, error) {
 	if !m.Time().Before(n.lastT.Load().(time.Time)) {
+		n.markDirty()
 		return m, nil
 	}
 	return nil, nil
 }
 func (n *periodicBarrier) EndBatch(m edge.EndBatchMessage) (edge.Message, error) {
+	n.batch.end()
 	return m, nil
 }
 func (n *periodicBarrier) Barrier(m edge.BarrierMessage) (edge.Message, error) {
@@ -255,7 +647,17 @@ func (n *periodicBarrier) Barrier(m edge.BarrierMessage) (edge.Message, error) {
 }
 func (n *periodicBarrier) DeleteGroup(m edge.DeleteGroupMessage) (edge.Message, error) {
 	if m.GroupID() == n.group.ID {
-		n.Stop()
+		stopped := false
+		n.stopOnce.Do(func() {
+			n.stop()
+			stopped = true
+		})
+		// Emit a final barrier only for the first delete of the group.
+		if stopped {
+			if err := n.emitBarrier(edge.BarrierReasonDelete); err != nil {
+				return nil, err
+			}
+		}
 	}
 	return m, nil
 }
@@ -263,25 +665,68 @@ func (n *periodicBarrier) Done() {}
 
 func (n *periodicBarrier) Point(m edge.PointMessage) (edge.Message, error) {
 	if !m.Time().Before(n.lastT.Load().(time.Time)) {
+		n.markDirty()
 		return m, nil
 	}
 	return nil, nil
 }
 
-func (n *periodicBarrier) emitBarrier() error {
-	nowT := time.Now().UTC()
-	n.lastT.Store(nowT)
-	return edge.Forward(n.outs, edge.NewBarrierMessage(n.group, nowT))
+func (n *periodicBarrier) markDirty() {
+	atomic.StoreInt32(&n.dirty, 1)
+}
+
+func (n *periodicBarrier) emitBarrier(reason string) error {
+	// Clear the dirty flag in the same operation that checks it,
+	// so that a point arriving concurrently is accounted for by the next barrier.
+	// The final barrier of a deleted group is always emitted.
+	if n.skipEmpty && !atomic.CompareAndSwapInt32(&n.dirty, 1, 0) && reason != edge.BarrierReasonDelete {
+		return nil
+	}
+	return n.emitBarrierAt(time.Now(), reason)
+}
+
+// emitBarrierAt emits a barrier with the time t regardless of whether the group is dirty.
+func (n *periodicBarrier) emitBarrierAt(t time.Time, reason string) error {
+	t = t.UTC()
+	n.lastT.Store(t)
+	return forwardBarrier(n.outs, n.group, t, reason, n.markers)
 }
 
+// periodicEmitter emits a barrier every period.
+// The next barrier is scheduled one current period after the last one,
+// so a changed period applies as soon as it is set.
 func (n *periodicBarrier) periodicEmitter() {
 	defer n.wg.Done()
+	last := time.Now()
+	timer := time.NewTimer(0)
+	<-timer.C
 	for {
+		period, changed := n.period.load()
+		if period < minBarrierPeriod {
+			period = minBarrierPeriod
+		}
+		timer.Reset(time.Until(last.Add(period)))
 		select {
-		case <-n.ticker.C:
-			n.emitBarrier()
+		case <-timer.C:
+			n.batch.emit(func() error {
+				return n.emitBarrier(edge.BarrierReasonPeriod)
+			})
+			last = time.Now()
+		case <-changed:
+			if !timer.Stop() {
+				<-timer.C
+			}
 		case <-n.stopC:
+			timer.Stop()
 			return
 		}
 	}
 }
+
+// firePeriodic emits a periodic barrier and returns the time of the next one.
+func (n *periodicBarrier) firePeriodic(now time.Time) time.Time {
+	n.batch.emit(func() error {
+		return n.emitBarrier(edge.BarrierReasonPeriod)
+	})
+	return n.period.after(now)
+}
@@ -0,0 +1,208 @@
+package kapacitor
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+)
+
+// barrierScheduler emits the barriers of many groups from a single goroutine.
+// The groups are kept in a min-heap ordered by the time of their next barrier,
+// so a group costs one heap entry instead of a goroutine and a timer.
+type barrierScheduler struct {
+	// period is the period of periodic barriers, nil for idle barriers.
+	// The next barrier of each group is always one current period after its last barrier,
+	// so when it changes the barriers are rescheduled.
+	period *barrierPeriod
+	// changed is closed when the period changes.
+	changed <-chan struct{}
+
+	mu      sync.Mutex
+	pending barrierHeap
+
+	// wakeC wakes the scheduler when the earliest deadline changes.
+	wakeC chan struct{}
+	stopC chan struct{}
+	wg    sync.WaitGroup
+}
+
+func newBarrierScheduler(period *barrierPeriod) *barrierScheduler {
+	s := &barrierScheduler{
+		period: period,
+		wakeC:  make(chan struct{}, 1),
+		stopC:  make(chan struct{}),
+	}
+	// Load the channel before any barrier is scheduled, so that no change of the period is missed.
+	if period != nil {
+		_, s.changed = period.load()
+	}
+	s.wg.Add(1)
+	go s.run()
+	return s
+}
+
+// scheduledBarrier is the entry of a group in a barrierScheduler.
+type scheduledBarrier struct {
+	// fire emits the barrier of the group due at now, if any, and returns the time of the next barrier.
+	fire func(now time.Time) time.Time
+
+	// deadline is the time of the next barrier.
+	deadline time.Time
+	// last is the time the barrier was last fired or added.
+	last time.Time
+	// index in the heap, -1 if the entry is not in the heap.
+	index int
+
+	// mu is held while firing, so that removing the entry waits for a barrier being emitted.
+	mu      sync.Mutex
+	removed bool
+}
+
+// add schedules a barrier to be fired at the deadline.
+// Periodic barriers are fired one period after they are added, whatever the deadline.
+func (s *barrierScheduler) add(fire func(now time.Time) time.Time, deadline time.Time) *scheduledBarrier {
+	b := &scheduledBarrier{
+		fire:     fire,
+		deadline: deadline,
+		index:    -1,
+	}
+	s.mu.Lock()
+	b.last = time.Now()
+	s.push(b)
+	s.mu.Unlock()
+	return b
+}
+
+// push adds b to the heap, waking the scheduler if b is now the earliest deadline.
+// The lock must be held, it orders reading the period with rescheduling on a change.
+func (s *barrierScheduler) push(b *scheduledBarrier) {
+	if s.period != nil {
+		b.deadline = s.period.after(b.last)
+	}
+	heap.Push(&s.pending, b)
+	if b.index == 0 {
+		select {
+		case s.wakeC <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// remove stops firing b. If b is being fired it waits until it has been fired.
+func (s *barrierScheduler) remove(b *scheduledBarrier) {
+	// Once marked as removed under its lock b is not added back to the heap.
+	b.mu.Lock()
+	b.removed = true
+	b.mu.Unlock()
+	s.mu.Lock()
+	if b.index >= 0 {
+		heap.Remove(&s.pending, b.index)
+	}
+	s.mu.Unlock()
+}
+
+// Stop stops the scheduler, the barriers that are still scheduled are not fired.
+func (s *barrierScheduler) Stop() {
+	close(s.stopC)
+	s.wg.Wait()
+}
+
+func (s *barrierScheduler) run() {
+	defer s.wg.Done()
+	timer := time.NewTimer(0)
+	<-timer.C
+	for {
+		var timerC <-chan time.Time
+		s.mu.Lock()
+		if len(s.pending) > 0 {
+			timer.Reset(time.Until(s.pending[0].deadline))
+			timerC = timer.C
+		}
+		s.mu.Unlock()
+		select {
+		case <-timerC:
+			s.fireDue(time.Now())
+			continue
+		case <-s.wakeC:
+		case <-s.changed:
+			// Load the next channel before reading the period,
+			// so that a change while rescheduling is not missed.
+			_, s.changed = s.period.load()
+			s.reschedule()
+		case <-s.stopC:
+			stopTimer(timer, timerC)
+			return
+		}
+		stopTimer(timer, timerC)
+	}
+}
+
+// stopTimer stops a timer that was reset if timerC is not nil, draining its channel if it already fired.
+func stopTimer(timer *time.Timer, timerC <-chan time.Time) {
+	if timerC == nil {
+		return
+	}
+	if !timer.Stop() {
+		select {
+		case <-timer.C:
+		default:
+		}
+	}
+}
+
+// fireDue fires the barriers due at now and schedules their next barriers.
+func (s *barrierScheduler) fireDue(now time.Time) {
+	var due []*scheduledBarrier
+	s.mu.Lock()
+	for len(s.pending) > 0 && !s.pending[0].deadline.After(now) {
+		due = append(due, heap.Pop(&s.pending).(*scheduledBarrier))
+	}
+	s.mu.Unlock()
+	for _, b := range due {
+		b.mu.Lock()
+		if !b.removed {
+			next := b.fire(now)
+			s.mu.Lock()
+			b.deadline = next
+			b.last = now
+			s.push(b)
+			s.mu.Unlock()
+		}
+		b.mu.Unlock()
+	}
+}
+
+// reschedule moves the next barrier of each group to one current period after its last barrier.
+func (s *barrierScheduler) reschedule() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, b := range s.pending {
+		b.deadline = s.period.after(b.last)
+	}
+	heap.Init(&s.pending)
+}
+
+// barrierHeap is a min-heap of scheduled barriers ordered by deadline.
+type barrierHeap []*scheduledBarrier
+
+func (h barrierHeap) Len() int           { return len(h) }
+func (h barrierHeap) Less(i, j int) bool { return h[i].deadline.Before(h[j].deadline) }
+func (h barrierHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+func (h *barrierHeap) Push(x interface{}) {
+	b := x.(*scheduledBarrier)
+	b.index = len(*h)
+	*h = append(*h, b)
+}
+func (h *barrierHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	b := old[n-1]
+	old[n-1] = nil
+	b.index = -1
+	*h = old[:n-1]
+	return b
+}
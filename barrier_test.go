@@ -0,0 +1,639 @@
+package kapacitor
+
+import (
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/timer"
+)
+
+func TestPeriodicBarrier_SkipEmpty(t *testing.T) {
+	testCases := []struct {
+		name      string
+		skipEmpty bool
+		// points indicates whether a point is received before each emit
+		points []bool
+		exp    int
+	}{
+		{
+			name:      "idle group",
+			skipEmpty: true,
+			points:    []bool{false, false, false},
+			exp:       0,
+		},
+		{
+			name:      "active group",
+			skipEmpty: true,
+			points:    []bool{true, false, true, true, false},
+			exp:       3,
+		},
+		{
+			name:      "idle group without skip empty",
+			skipEmpty: false,
+			points:    []bool{false, false, false},
+			exp:       3,
+		},
+	}
+	group := edge.GroupInfo{
+		ID: models.GroupID("test"),
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			out := edge.NewChannelEdge(pipeline.StreamEdge, len(tc.points))
+			// Use a long period so that only explicit emits produce barriers.
+			b := newPeriodicBarrier("cpu", group, newBarrierPeriod(time.Hour), tc.skipEmpty, []edge.StatsEdge{edge.NewStatsEdge(out)})
+
+			for i, point := range tc.points {
+				if point {
+					p := edge.NewPointMessage(
+						"cpu", "db", "rp",
+						models.Dimensions{},
+						models.Fields{"value": 1.0},
+						models.Tags{},
+						time.Now().UTC(),
+					)
+					if _, err := b.Point(p); err != nil {
+						t.Fatal(err)
+					}
+				}
+				if err := b.emitBarrier(edge.BarrierReasonPeriod); err != nil {
+					t.Fatalf("unexpected error emitting barrier %d: %v", i, err)
+				}
+			}
+			b.Stop()
+			out.Close()
+
+			got := 0
+			for m, ok := out.Emit(); ok; m, ok = out.Emit() {
+				if m.Type() == edge.Barrier {
+					got++
+				}
+			}
+			if got != tc.exp {
+				t.Errorf("unexpected number of barriers: got %d exp %d", got, tc.exp)
+			}
+		})
+	}
+}
+
+func TestPeriodicBarrier_Markers(t *testing.T) {
+	group := edge.GroupInfo{
+		ID:         models.GroupID("cpu\nhost=serverA"),
+		Tags:       models.Tags{"host": "serverA"},
+		Dimensions: models.Dimensions{TagNames: []string{"host"}},
+	}
+	for _, markers := range []bool{false, true} {
+		t.Run(strconv.FormatBool(markers), func(t *testing.T) {
+			out := edge.NewChannelEdge(pipeline.StreamEdge, 4)
+			b := newScheduledPeriodicBarrier("cpu", group, newBarrierPeriod(time.Hour), false, []edge.StatsEdge{edge.NewStatsEdge(out)}, nil, markers)
+			if err := b.emitBarrier(edge.BarrierReasonPeriod); err != nil {
+				t.Fatal(err)
+			}
+			b.Stop()
+			out.Close()
+
+			var msgs []edge.Message
+			for m, ok := out.Emit(); ok; m, ok = out.Emit() {
+				msgs = append(msgs, m)
+			}
+			exp := 1
+			if markers {
+				exp = 2
+			}
+			if len(msgs) != exp {
+				t.Fatalf("unexpected number of messages: got %d exp %d", len(msgs), exp)
+			}
+			barrier, ok := msgs[len(msgs)-1].(edge.BarrierMessage)
+			if !ok {
+				t.Fatalf("expected barrier as last message, got %v", msgs[len(msgs)-1].Type())
+			}
+			if !markers {
+				return
+			}
+			marker, ok := msgs[0].(edge.PointMessage)
+			if !ok {
+				t.Fatalf("expected marker point, got %v", msgs[0].Type())
+			}
+			if got, exp := marker.Name(), "_barrier"; got != exp {
+				t.Errorf("unexpected measurement: got %q exp %q", got, exp)
+			}
+			if got, exp := marker.Tags(), (models.Tags{"host": "serverA", "reason": edge.BarrierReasonPeriod}); !reflect.DeepEqual(got, exp) {
+				t.Errorf("unexpected tags: got %v exp %v", got, exp)
+			}
+			if got, exp := marker.Fields(), (models.Fields{"group": string(group.ID)}); !reflect.DeepEqual(got, exp) {
+				t.Errorf("unexpected fields: got %v exp %v", got, exp)
+			}
+			if !marker.Time().Equal(barrier.Time()) {
+				t.Errorf("unexpected marker time: got %v exp %v", marker.Time(), barrier.Time())
+			}
+		})
+	}
+}
+
+func TestBarrier_DuplicateDeleteGroup(t *testing.T) {
+	group := edge.GroupInfo{
+		ID: models.GroupID("test"),
+	}
+	testCases := []struct {
+		name       string
+		newBarrier func(outs []edge.StatsEdge) (edge.ForwardReceiver, func())
+	}{
+		{
+			name: "idle",
+			newBarrier: func(outs []edge.StatsEdge) (edge.ForwardReceiver, func()) {
+				b := newIdleBarrier("cpu", group, time.Hour, outs)
+				return b, b.Stop
+			},
+		},
+		{
+			name: "periodic",
+			newBarrier: func(outs []edge.StatsEdge) (edge.ForwardReceiver, func()) {
+				b := newPeriodicBarrier("cpu", group, newBarrierPeriod(time.Hour), false, outs)
+				return b, b.Stop
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			out := edge.NewChannelEdge(pipeline.StreamEdge, 10)
+			b, stop := tc.newBarrier([]edge.StatsEdge{edge.NewStatsEdge(out)})
+
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				for i := 0; i < 2; i++ {
+					d := edge.NewDeleteGroupMessage(group.ID)
+					if m, err := b.DeleteGroup(d); err != nil {
+						t.Error(err)
+					} else if m != d {
+						t.Errorf("delete %d: expected delete group to be forwarded", i)
+					}
+				}
+				// The node stops all barriers when it finishes.
+				stop()
+			}()
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("timed out stopping barrier")
+			}
+			out.Close()
+			// Only the first delete emits a final barrier.
+			m, ok := out.Emit()
+			if !ok {
+				t.Fatal("expected final barrier")
+			}
+			if b, ok := m.(edge.BarrierMessage); !ok || b.Reason() != edge.BarrierReasonDelete {
+				t.Errorf("expected delete barrier, got %v", m)
+			}
+			if m, ok := out.Emit(); ok {
+				t.Errorf("unexpected message after shutdown: %v", m)
+			}
+		})
+	}
+}
+
+func TestBarrier_Reason(t *testing.T) {
+	group := edge.GroupInfo{
+		ID: models.GroupID("test"),
+	}
+	testCases := []struct {
+		name   string
+		reason string
+		emit   func(outs []edge.StatsEdge) func()
+	}{
+		{
+			name:   "idle",
+			reason: edge.BarrierReasonIdle,
+			emit: func(outs []edge.StatsEdge) func() {
+				b := newIdleBarrier("cpu", group, 10*time.Millisecond, outs)
+				return b.Stop
+			},
+		},
+		{
+			name:   "period",
+			reason: edge.BarrierReasonPeriod,
+			emit: func(outs []edge.StatsEdge) func() {
+				b := newPeriodicBarrier("cpu", group, newBarrierPeriod(10*time.Millisecond), false, outs)
+				return b.Stop
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			out := edge.NewChannelEdge(pipeline.StreamEdge, 10)
+			stop := tc.emit([]edge.StatsEdge{edge.NewStatsEdge(out)})
+			defer stop()
+
+			m, ok := out.Emit()
+			if !ok {
+				t.Fatal("expected barrier")
+			}
+			b, ok := m.(edge.BarrierMessage)
+			if !ok {
+				t.Fatalf("expected barrier, got %v", m)
+			}
+			if got := b.Reason(); got != tc.reason {
+				t.Errorf("unexpected reason got %q exp %q", got, tc.reason)
+			}
+			// The reason is preserved when the barrier is copied by downstream nodes.
+			if got := b.ShallowCopy().Reason(); got != tc.reason {
+				t.Errorf("unexpected reason of copy got %q exp %q", got, tc.reason)
+			}
+		})
+	}
+}
+
+func TestIdleBarrier_Batch(t *testing.T) {
+	group := edge.GroupInfo{
+		ID: models.GroupID("test"),
+	}
+	const idle = 20 * time.Millisecond
+	out := edge.NewChannelEdge(pipeline.BatchEdge, 100)
+	b := newIdleBarrier("cpu", group, idle, []edge.StatsEdge{edge.NewStatsEdge(out)})
+	defer b.Stop()
+
+	// forward passes messages returned by the barrier on, as the node would.
+	forward := func(m edge.Message, err error) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m != nil {
+			if err := out.Collect(m); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	t0 := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	batch := func(stop time.Time, slow bool) {
+		forward(b.BeginBatch(edge.NewBeginBatchMessage("cpu", models.Tags{}, false, stop, 2)))
+		forward(b.BatchPoint(edge.NewBatchPointMessage(models.Fields{"value": 1.0}, models.Tags{}, stop.Add(-2*time.Second))))
+		if slow {
+			// The batch takes longer than the idle duration to arrive.
+			time.Sleep(3 * idle)
+		}
+		forward(b.BatchPoint(edge.NewBatchPointMessage(models.Fields{"value": 2.0}, models.Tags{}, stop.Add(-time.Second))))
+		forward(b.EndBatch(edge.NewEndBatchMessage()))
+	}
+
+	batch(t0.Add(10*time.Second), true)
+	// The source goes idle.
+	time.Sleep(5 * idle)
+	batch(t0.Add(20*time.Second), false)
+	b.Stop()
+	out.Close()
+
+	var got []string
+	var barriers []time.Time
+	for m, ok := out.Emit(); ok; m, ok = out.Emit() {
+		got = append(got, m.Type().String())
+		if bm, ok := m.(edge.BarrierMessage); ok {
+			barriers = append(barriers, bm.Time())
+			if r := bm.Reason(); r != edge.BarrierReasonIdle {
+				t.Errorf("unexpected barrier reason %q", r)
+			}
+		}
+	}
+	// A single barrier is emitted between the batches and none within a batch.
+	exp := []string{
+		"begin_batch", "batch_point", "batch_point", "end_batch",
+		"barrier",
+		"begin_batch", "batch_point", "batch_point", "end_batch",
+	}
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected messages:\ngot %v\nexp %v", got, exp)
+	}
+	// The barrier has the time of the last batch, so no points of the next batch are dropped.
+	if exp := []time.Time{t0.Add(10 * time.Second)}; !reflect.DeepEqual(barriers, exp) {
+		t.Errorf("unexpected barrier times:\ngot %v\nexp %v", barriers, exp)
+	}
+}
+
+func TestPeriodicBarrier_ChangePeriod(t *testing.T) {
+	group := edge.GroupInfo{
+		ID: models.GroupID("test"),
+	}
+	out := edge.NewChannelEdge(pipeline.StreamEdge, 1000)
+	period := newBarrierPeriod(time.Hour)
+	b := newPeriodicBarrier("cpu", group, period, false, []edge.StatsEdge{edge.NewStatsEdge(out)})
+
+	// Record the times barriers are received.
+	var (
+		mu    sync.Mutex
+		times []time.Time
+	)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, ok := out.Emit(); ok; _, ok = out.Emit() {
+			mu.Lock()
+			times = append(times, time.Now())
+			mu.Unlock()
+		}
+	}()
+	received := func() []time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]time.Time(nil), times...)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if got := len(received()); got != 0 {
+		t.Fatalf("expected no barriers with a period of 1h, got %d", got)
+	}
+
+	// The shorter period applies without waiting for the hour to pass.
+	changed := time.Now()
+	if err := period.set(20 * time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	timeout := time.After(10 * time.Second)
+	for len(received()) < 5 {
+		select {
+		case <-timeout:
+			t.Fatal("timed out waiting for barriers")
+		case <-time.After(time.Millisecond):
+		}
+	}
+	// Five barriers every 20ms take at least 100ms.
+	if d := received()[4].Sub(changed); d < 80*time.Millisecond {
+		t.Errorf("expected barriers every 20ms, got 5 barriers within %v", d)
+	}
+
+	// The longer period applies to the next barrier.
+	if err := period.set(time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	// Allow for a barrier emitted while the period was changed.
+	time.Sleep(20 * time.Millisecond)
+	before := len(received())
+	time.Sleep(100 * time.Millisecond)
+	if got := len(received()) - before; got != 0 {
+		t.Errorf("expected no barriers after changing the period back to 1h, got %d", got)
+	}
+
+	b.Stop()
+	out.Close()
+	<-done
+}
+
+func TestBarrierPeriod_Set(t *testing.T) {
+	p := newBarrierPeriod(time.Minute)
+	_, changed := p.load()
+
+	if err := p.set(time.Millisecond); err == nil {
+		t.Error("expected error setting a period below the minimum")
+	}
+	if err := p.set(time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-changed:
+		t.Fatal("expected no change notification for the same period")
+	default:
+	}
+
+	if err := p.set(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-changed:
+	default:
+		t.Fatal("expected change notification")
+	}
+	if got, exp := p.get(), time.Second; got != exp {
+		t.Errorf("unexpected period got %v exp %v", got, exp)
+	}
+}
+
+func TestBarrierNode_BarrierOutput(t *testing.T) {
+	n, err := newBarrierNode(&ExecutingTask{}, &pipeline.BarrierNode{
+		Idle:              time.Hour,
+		BarrierOutputFlag: true,
+	}, &windowNodeDiagnostic{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := edge.NewChannelEdge(pipeline.StreamEdge, 10)
+	barriers := edge.NewChannelEdge(pipeline.StreamEdge, 10)
+	n.dataOuts = []edge.StatsEdge{edge.NewStatsEdge(data)}
+	n.barrierOuts = []edge.StatsEdge{edge.NewStatsEdge(barriers)}
+	n.outs = append(n.dataOuts, n.barrierOuts...)
+	n.timer = timer.NewNoOp()
+
+	group := edge.GroupInfo{
+		ID: models.GroupID("test"),
+	}
+	t0 := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	p := edge.NewPointMessage("cpu", "db", "rp", models.Dimensions{}, models.Fields{"value": 1.0}, models.Tags{}, t0)
+	r, err := n.NewGroup(group, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Point(p); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Barrier(edge.NewBarrierMessage(group, t0.Add(time.Second))); err != nil {
+		t.Fatal(err)
+	}
+	// The delete emits a final barrier.
+	if err := r.DeleteGroup(edge.NewDeleteGroupMessage(group.ID)); err != nil {
+		t.Fatal(err)
+	}
+	n.stopBarrierEmitter()
+	data.Close()
+	barriers.Close()
+
+	types := func(e edge.Edge) []edge.MessageType {
+		var got []edge.MessageType
+		for m, ok := e.Emit(); ok; m, ok = e.Emit() {
+			got = append(got, m.Type())
+		}
+		return got
+	}
+	if got, exp := types(data), []edge.MessageType{edge.Point, edge.DeleteGroup}; !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected data messages:\ngot %v\nexp %v", got, exp)
+	}
+	if got, exp := types(barriers), []edge.MessageType{edge.Barrier, edge.Barrier, edge.DeleteGroup}; !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected barrier messages:\ngot %v\nexp %v", got, exp)
+	}
+}
+
+func TestBarrierNode_EmitImmediately(t *testing.T) {
+	for _, shared := range []bool{false, true} {
+		t.Run("shared timer "+strconv.FormatBool(shared), func(t *testing.T) {
+			n, err := newBarrierNode(&ExecutingTask{}, &pipeline.BarrierNode{
+				Period:              200 * time.Millisecond,
+				SharedTimerFlag:     shared,
+				EmitImmediatelyFlag: true,
+			}, &windowNodeDiagnostic{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if shared {
+				n.scheduler = newBarrierScheduler(n.period)
+			}
+			out := edge.NewChannelEdge(pipeline.StreamEdge, 100)
+			n.outs = []edge.StatsEdge{edge.NewStatsEdge(out)}
+			n.mapOuts()
+			n.timer = timer.NewNoOp()
+
+			group := edge.GroupInfo{
+				ID: models.GroupID("test"),
+			}
+			start := time.Now()
+			p := edge.NewPointMessage("cpu", "db", "rp", models.Dimensions{}, models.Fields{"value": 1.0}, models.Tags{}, start.Add(-time.Second).UTC())
+			r, err := n.NewGroup(group, p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := r.Point(p); err != nil {
+				t.Fatal(err)
+			}
+
+			next := func() edge.Message {
+				m, ok := out.Emit()
+				if !ok {
+					t.Fatal("unexpected closed edge")
+				}
+				return m
+			}
+			// The first barrier is emitted at t≈0 with the time of the first point, before the point.
+			first, ok := next().(edge.BarrierMessage)
+			if !ok {
+				t.Fatal("expected an immediate barrier before the first point")
+			}
+			if d := time.Since(start); d > 100*time.Millisecond {
+				t.Errorf("expected an immediate barrier, got the first barrier after %v", d)
+			}
+			if !first.Time().Equal(p.Time()) {
+				t.Errorf("unexpected time of the first barrier: got %v exp %v", first.Time(), p.Time())
+			}
+			if m := next(); m.Type() != edge.Point {
+				t.Fatalf("expected the first point not to be dropped, got %v", m.Type())
+			}
+			// The regular cadence starts one period after the first barrier, without a second barrier at t≈0.
+			if m := next(); m.Type() != edge.Barrier {
+				t.Fatalf("expected a periodic barrier, got %v", m.Type())
+			}
+			if d := time.Since(start); d < 150*time.Millisecond {
+				t.Errorf("expected the second barrier one period after the first, got it after %v", d)
+			}
+
+			n.stopBarrierEmitter()
+			out.Close()
+		})
+	}
+}
+
+func TestBarrierScheduler(t *testing.T) {
+	out := edge.NewChannelEdge(pipeline.StreamEdge, 1000)
+	outs := []edge.StatsEdge{edge.NewStatsEdge(out)}
+	period := newBarrierPeriod(time.Hour)
+	idleScheduler := newBarrierScheduler(nil)
+	periodScheduler := newBarrierScheduler(period)
+
+	idle := newScheduledIdleBarrier("cpu", edge.GroupInfo{ID: "idle"}, 20*time.Millisecond, outs, idleScheduler, false)
+	periodic := newScheduledPeriodicBarrier("cpu", edge.GroupInfo{ID: "periodic"}, period, false, outs, periodScheduler, false)
+	// The shorter period applies to the scheduled barrier without waiting for the hour to pass.
+	if err := period.set(20 * time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+
+	counts := make(map[models.GroupID]int)
+	timeout := time.After(10 * time.Second)
+	for counts["idle"] < 3 || counts["periodic"] < 3 {
+		select {
+		case <-timeout:
+			t.Fatalf("timed out waiting for barriers, got %v", counts)
+		case <-time.After(time.Millisecond):
+		}
+		m, ok := out.Emit()
+		if !ok {
+			t.Fatal("unexpected closed edge")
+		}
+		b, ok := m.(edge.BarrierMessage)
+		if !ok {
+			t.Fatalf("unexpected message %T", m)
+		}
+		counts[b.GroupID()]++
+	}
+
+	// No barriers are emitted once the groups are deleted.
+	if _, err := idle.DeleteGroup(edge.NewDeleteGroupMessage("idle")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := periodic.DeleteGroup(edge.NewDeleteGroupMessage("periodic")); err != nil {
+		t.Fatal(err)
+	}
+	idleScheduler.Stop()
+	periodScheduler.Stop()
+	out.Close()
+	reasons := make(map[string]int)
+	for m, ok := out.Emit(); ok; m, ok = out.Emit() {
+		if b, ok := m.(edge.BarrierMessage); ok {
+			reasons[b.Reason()]++
+		}
+	}
+	if got := reasons[edge.BarrierReasonDelete]; got != 2 {
+		t.Errorf("expected a delete barrier for each group, got %d", got)
+	}
+}
+
+// BenchmarkBarrierGroups compares a goroutine and a timer per group with a shared scheduler.
+func BenchmarkBarrierGroups(b *testing.B) {
+	const groups = 100000
+	outs := []edge.StatsEdge{edge.NewStatsEdge(edge.NewChannelEdge(pipeline.StreamEdge, 0))}
+	groupInfos := make([]edge.GroupInfo, groups)
+	points := make([]edge.PointMessage, groups)
+	for i := range groupInfos {
+		groupInfos[i] = edge.GroupInfo{ID: models.GroupID(strconv.Itoa(i))}
+		points[i] = edge.NewPointMessage(
+			"cpu", "db", "rp",
+			models.Dimensions{},
+			models.Fields{"value": 1.0},
+			models.Tags{},
+			time.Now().UTC(),
+		)
+	}
+	benchmarks := []struct {
+		name   string
+		shared bool
+	}{
+		{name: "goroutine per group"},
+		{name: "shared timer", shared: true},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				var scheduler *barrierScheduler
+				if bm.shared {
+					scheduler = newBarrierScheduler(nil)
+				}
+				// The idle time is long enough that no barriers are emitted.
+				barriers := make([]*idleBarrier, groups)
+				for g := range barriers {
+					barriers[g] = newScheduledIdleBarrier("cpu", groupInfos[g], time.Hour, outs, scheduler, false)
+				}
+				for g, r := range barriers {
+					if _, err := r.Point(points[g]); err != nil {
+						b.Fatal(err)
+					}
+				}
+				for _, r := range barriers {
+					r.Stop()
+				}
+				if scheduler != nil {
+					scheduler.Stop()
+				}
+			}
+		})
+	}
+}
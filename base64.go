@@ -0,0 +1,184 @@
+package kapacitor
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/keyvalue"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+const (
+	statsEncodeErrors = "encode_errors"
+	statsDecodeErrors = "decode_errors"
+)
+
+// base64Func transforms the value of a string field.
+type base64Func func(value string) (string, error)
+
+// base64Field applies f to the field of p and stores the result in the field as.
+// Points without the field are left unchanged, it returns an error if the field could not be transformed.
+func base64Field(p edge.FieldsTagsTimeSetter, field, as string, f base64Func) error {
+	value, ok := p.Fields()[field]
+	if !ok {
+		return nil
+	}
+	s, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("field %q is not a string, got %T", field, value)
+	}
+	v, err := f(s)
+	if err != nil {
+		return fmt.Errorf("field %q: %v", field, err)
+	}
+	fields := p.Fields().Copy()
+	fields[as] = v
+	p.SetFields(fields)
+	return nil
+}
+
+func encodeBase64(value string) (string, error) {
+	return base64.StdEncoding.EncodeToString([]byte(value)), nil
+}
+
+func decodeBase64(value string) (string, error) {
+	b, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+type EncodeBase64Node struct {
+	node
+	e *pipeline.EncodeBase64Node
+
+	encodeErrors *expvar.Int
+}
+
+// Create a new EncodeBase64Node which encodes the value of a field with base64.
+func newEncodeBase64Node(et *ExecutingTask, n *pipeline.EncodeBase64Node, d NodeDiagnostic) (*EncodeBase64Node, error) {
+	en := &EncodeBase64Node{
+		node:         node{Node: n, et: et, diag: d},
+		e:            n,
+		encodeErrors: new(expvar.Int),
+	}
+	en.node.runF = en.runEncodeBase64
+	return en, nil
+}
+
+func (n *EncodeBase64Node) runEncodeBase64(snapshot []byte) error {
+	n.statMap.Set(statsEncodeErrors, n.encodeErrors)
+	consumer := edge.NewConsumerWithReceiver(
+		n.ins[0],
+		edge.NewReceiverFromForwardReceiverWithStats(
+			n.outs,
+			edge.NewTimedForwardReceiver(n.timer, n),
+		),
+	)
+	return consumer.Consume()
+}
+
+// doEncode encodes the field of p, points whose field cannot be encoded are left unchanged.
+func (n *EncodeBase64Node) doEncode(p edge.FieldsTagsTimeSetter) {
+	if err := base64Field(p, n.e.Field, n.e.As, encodeBase64); err != nil {
+		n.encodeErrors.Add(1)
+		n.diag.Error("failed to encode field", err, keyvalue.KV("time", p.Time().String()))
+	}
+}
+
+func (n *EncodeBase64Node) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	return begin, nil
+}
+
+func (n *EncodeBase64Node) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	bp = bp.ShallowCopy()
+	n.doEncode(bp)
+	return bp, nil
+}
+
+func (n *EncodeBase64Node) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (n *EncodeBase64Node) Point(p edge.PointMessage) (edge.Message, error) {
+	p = p.ShallowCopy()
+	n.doEncode(p)
+	return p, nil
+}
+
+func (n *EncodeBase64Node) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (n *EncodeBase64Node) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (n *EncodeBase64Node) Done() {}
+
+type DecodeBase64Node struct {
+	node
+	d *pipeline.DecodeBase64Node
+
+	decodeErrors *expvar.Int
+}
+
+// Create a new DecodeBase64Node which decodes the base64 value of a field.
+func newDecodeBase64Node(et *ExecutingTask, n *pipeline.DecodeBase64Node, d NodeDiagnostic) (*DecodeBase64Node, error) {
+	dn := &DecodeBase64Node{
+		node:         node{Node: n, et: et, diag: d},
+		d:            n,
+		decodeErrors: new(expvar.Int),
+	}
+	dn.node.runF = dn.runDecodeBase64
+	return dn, nil
+}
+
+func (n *DecodeBase64Node) runDecodeBase64(snapshot []byte) error {
+	n.statMap.Set(statsDecodeErrors, n.decodeErrors)
+	consumer := edge.NewConsumerWithReceiver(
+		n.ins[0],
+		edge.NewReceiverFromForwardReceiverWithStats(
+			n.outs,
+			edge.NewTimedForwardReceiver(n.timer, n),
+		),
+	)
+	return consumer.Consume()
+}
+
+// doDecode decodes the field of p, points whose field cannot be decoded are left unchanged.
+func (n *DecodeBase64Node) doDecode(p edge.FieldsTagsTimeSetter) {
+	if err := base64Field(p, n.d.Field, n.d.As, decodeBase64); err != nil {
+		n.decodeErrors.Add(1)
+		n.diag.Error("failed to decode field", err, keyvalue.KV("time", p.Time().String()))
+	}
+}
+
+func (n *DecodeBase64Node) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	return begin, nil
+}
+
+func (n *DecodeBase64Node) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	bp = bp.ShallowCopy()
+	n.doDecode(bp)
+	return bp, nil
+}
+
+func (n *DecodeBase64Node) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (n *DecodeBase64Node) Point(p edge.PointMessage) (edge.Message, error) {
+	p = p.ShallowCopy()
+	n.doDecode(p)
+	return p, nil
+}
+
+func (n *DecodeBase64Node) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (n *DecodeBase64Node) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (n *DecodeBase64Node) Done() {}
@@ -3,6 +3,7 @@ package kapacitor
 import (
 	"bytes"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -11,6 +12,7 @@ import (
 	"github.com/influxdata/kapacitor/edge"
 	"github.com/influxdata/kapacitor/expvar"
 	"github.com/influxdata/kapacitor/influxdb"
+	"github.com/influxdata/kapacitor/models"
 	"github.com/influxdata/kapacitor/pipeline"
 	"github.com/pkg/errors"
 )
@@ -151,38 +153,11 @@ func newQueryNode(et *ExecutingTask, n *pipeline.QueryNode, d NodeDiagnostic) (*
 	bn.node.runF = bn.runBatch
 	bn.node.stopF = bn.stopBatch
 
-	// Create query
-	q, err := NewQuery(n.QueryStr)
-	if err != nil {
-		return nil, err
-	}
-	bn.query = q
-	// Add in dimensions
-	err = bn.query.Dimensions(n.Dimensions)
-	if err != nil {
-		return nil, err
-	}
-	// Set offset alignment
-	if n.AlignGroupFlag {
-		bn.query.AlignGroup()
-	}
-	// Set fill
-	switch fill := n.Fill.(type) {
-	case string:
-		switch fill {
-		case "null":
-			bn.query.Fill(influxql.NullFill, nil)
-		case "none":
-			bn.query.Fill(influxql.NoFill, nil)
-		case "previous":
-			bn.query.Fill(influxql.PreviousFill, nil)
-		case "linear":
-			bn.query.Fill(influxql.LinearFill, nil)
-		default:
-			return nil, fmt.Errorf("unexpected fill option %s", fill)
+	// Flux queries are sent as is, only InfluxQL queries are parsed.
+	if !n.FluxFlag {
+		if err := bn.initQuery(); err != nil {
+			return nil, err
 		}
-	case int64, float64:
-		bn.query.Fill(influxql.NumberFill, fill)
 	}
 
 	// Determine schedule
@@ -205,13 +180,54 @@ func newQueryNode(et *ExecutingTask, n *pipeline.QueryNode, d NodeDiagnostic) (*
 	return bn, nil
 }
 
+// initQuery creates the InfluxQL query of the node.
+func (n *QueryNode) initQuery() error {
+	q, err := NewQuery(n.b.QueryStr)
+	if err != nil {
+		return err
+	}
+	n.query = q
+	// Add in dimensions
+	err = n.query.Dimensions(n.b.Dimensions)
+	if err != nil {
+		return err
+	}
+	// Set offset alignment
+	if n.b.AlignGroupFlag {
+		n.query.AlignGroup()
+	}
+	// Set fill
+	switch fill := n.b.Fill.(type) {
+	case string:
+		switch fill {
+		case "null":
+			n.query.Fill(influxql.NullFill, nil)
+		case "none":
+			n.query.Fill(influxql.NoFill, nil)
+		case "previous":
+			n.query.Fill(influxql.PreviousFill, nil)
+		case "linear":
+			n.query.Fill(influxql.LinearFill, nil)
+		default:
+			return fmt.Errorf("unexpected fill option %s", fill)
+		}
+	case int64, float64:
+		n.query.Fill(influxql.NumberFill, fill)
+	}
+	return nil
+}
+
 func (n *QueryNode) GroupByMeasurement() bool {
 	return n.byName
 }
 
 // Return list of databases and retention policies
 // the batcher will query.
+// Flux queries select buckets instead of databases and retention policies and return none.
 func (n *QueryNode) DBRPs() ([]DBRP, error) {
+	if n.b.FluxFlag {
+		return nil, nil
+	}
 	return n.query.DBRPs()
 }
 
@@ -233,6 +249,9 @@ func (n *QueryNode) Cluster() string {
 }
 
 func (n *QueryNode) Queries(start, stop time.Time) ([]*Query, error) {
+	if n.b.FluxFlag {
+		return nil, errors.New("cannot replay flux queries")
+	}
 	now := time.Now()
 	if stop.IsZero() {
 		stop = now
@@ -279,6 +298,18 @@ func (n *QueryNode) doQuery(in edge.Edge) error {
 	if err != nil {
 		return errors.Wrap(err, "failed to get InfluxDB client")
 	}
+
+	var spill *edge.SpillBatchBuffer
+	if n.b.MaxInMemoryPoints > 0 {
+		spill = edge.NewSpillBatchBuffer(int(n.b.MaxInMemoryPoints))
+		// Remove any spill file left by an interrupted batch.
+		defer spill.Close()
+	}
+
+	// The first query is skipped if its window started before the query node started.
+	started := time.Now()
+	skipFirst := n.b.SkipFirstFlag
+
 	tickC := n.ticker.Start()
 	for {
 		select {
@@ -290,17 +321,38 @@ func (n *QueryNode) doQuery(in edge.Edge) error {
 			n.timer.Start()
 			// Update times for query
 			stop := now.Add(-1 * n.b.Offset)
-			n.query.SetStartTime(stop.Add(-1 * n.b.Period))
-			n.query.SetStopTime(stop)
+			if skipFirst {
+				skipFirst = false
+				if n.partialWindow(started, stop) {
+					n.timer.Stop()
+					break
+				}
+			}
+			if n.b.ChunkInterval > 0 {
+				// Execute the query one chunk at a time
+				batches, err := n.executeChunked(con, stop)
+				if err != nil {
+					n.diag.Error("error executing query", err)
+					n.timer.Stop()
+					break
+				}
+				if err := n.collectBatches(in, batches, stop); err != nil {
+					return err
+				}
+				n.timer.Stop()
+				break
+			}
 
-			qStr := n.query.String()
-			n.diag.StartingBatchQuery(qStr)
+			if spill != nil {
+				if err := n.querySpilled(con, in, stop, spill); err != nil {
+					return err
+				}
+				n.timer.Stop()
+				break
+			}
 
 			// Execute query
-			q := influxdb.Query{
-				Command: qStr,
-			}
-			resp, err := con.Query(q)
+			resp, err := n.execute(con, stop)
 			if err != nil {
 				n.diag.Error("error executing query", err)
 				n.timer.Stop()
@@ -314,20 +366,8 @@ func (n *QueryNode) doQuery(in edge.Edge) error {
 					n.diag.Error("failed to understand query result", err)
 					continue
 				}
-				for _, bch := range batches {
-					// Set stop time based off query bounds
-					if bch.Begin().Time().IsZero() || !n.query.IsGroupedByTime() {
-						bch.Begin().SetTime(stop)
-					}
-
-					n.batchesQueried.Add(1)
-					n.pointsQueried.Add(int64(len(bch.Points())))
-
-					n.timer.Pause()
-					if err := in.Collect(bch); err != nil {
-						return err
-					}
-					n.timer.Resume()
+				if err := n.collectBatches(in, batches, stop); err != nil {
+					return err
 				}
 			}
 			n.timer.Stop()
@@ -335,6 +375,261 @@ func (n *QueryNode) doQuery(in edge.Edge) error {
 	}
 }
 
+// collectBatches collects the batches of the query of the window stopping at stop on in.
+func (n *QueryNode) collectBatches(in edge.Edge, batches []edge.BufferedBatchMessage, stop time.Time) error {
+	for _, bch := range batches {
+		// Set stop time based off query bounds
+		if bch.Begin().Time().IsZero() || !n.groupedByTime() {
+			bch.Begin().SetTime(stop)
+		}
+
+		n.batchesQueried.Add(1)
+		n.pointsQueried.Add(int64(len(bch.Points())))
+
+		n.timer.Pause()
+		if err := in.Collect(bch); err != nil {
+			return err
+		}
+		n.timer.Resume()
+	}
+	return nil
+}
+
+// execute runs the query of the window stopping at stop.
+func (n *QueryNode) execute(con influxdb.Client, stop time.Time) (*influxdb.Response, error) {
+	return n.executeRange(con, stop.Add(-1*n.b.Period), stop)
+}
+
+// executeChunked runs the query of the window stopping at stop one chunk after the other,
+// and assembles the points of the chunks into a batch per group.
+func (n *QueryNode) executeChunked(con influxdb.Client, stop time.Time) ([]edge.BufferedBatchMessage, error) {
+	start := stop.Add(-1 * n.b.Period)
+	chunks := newChunkedBatches(n.b.Overlap)
+	for chunkStart := start; chunkStart.Before(stop); chunkStart = chunkStart.Add(n.b.ChunkInterval) {
+		chunkStop := chunkStart.Add(n.b.ChunkInterval)
+		if chunkStop.After(stop) {
+			chunkStop = stop
+		}
+		// Chunks after the first also query the overlap with the previous chunk.
+		queryStart := chunkStart
+		if chunkStart.After(start) {
+			queryStart = chunkStart.Add(-1 * n.b.Overlap)
+		}
+		resp, err := n.executeRange(con, queryStart, chunkStop)
+		if err != nil {
+			return nil, err
+		}
+		for _, res := range resp.Results {
+			batches, err := edge.ResultToBufferedBatches(res, n.byName)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to understand query result")
+			}
+			chunks.add(chunkStart, chunkStop, batches)
+		}
+		chunks.endChunk()
+	}
+	return chunks.batches(), nil
+}
+
+// executeRange runs the query of the time range from start to stop.
+func (n *QueryNode) executeRange(con influxdb.Client, start, stop time.Time) (*influxdb.Response, error) {
+	if n.b.FluxFlag {
+		fc, ok := con.(influxdb.FluxClient)
+		if !ok {
+			return nil, errors.New("InfluxDB client does not support flux queries")
+		}
+		qStr := n.fluxQuery(start, stop)
+		n.diag.StartingBatchQuery(qStr)
+		return fc.QueryFlux(influxdb.FluxQuery{
+			Query: qStr,
+			Org:   n.b.Org,
+		})
+	}
+	n.query.SetStartTime(start)
+	n.query.SetStopTime(stop)
+
+	qStr := n.query.String()
+	n.diag.StartingBatchQuery(qStr)
+	return con.Query(influxdb.Query{
+		Command: qStr,
+	})
+}
+
+// fluxQuery returns the flux query preceded by the definition of the option v with the window and bucket of the query.
+func (n *QueryNode) fluxQuery(start, stop time.Time) string {
+	return fmt.Sprintf("option v = {timeRangeStart: %s, timeRangeStop: %s, bucket: %s}\n%s",
+		start.UTC().Format(time.RFC3339Nano),
+		stop.UTC().Format(time.RFC3339Nano),
+		influxdb.FluxString(n.b.Bucket),
+		n.b.QueryStr,
+	)
+}
+
+// groupedByTime reports whether the batches are grouped by time,
+// in which case the time of a batch is that of its last point instead of the stop time of the query.
+func (n *QueryNode) groupedByTime() bool {
+	return !n.b.FluxFlag && n.query.IsGroupedByTime()
+}
+
+// partialWindow reports whether the window of a query stopping at stop started before the node started.
+// The offset shifts the start time into the past like the window.
+func (n *QueryNode) partialWindow(started, stop time.Time) bool {
+	return stop.Add(-1 * n.b.Period).Before(started.Add(-1 * n.b.Offset))
+}
+
+// querySpilled runs the query of the window stopping at stop and collects its batches on in,
+// spilling points beyond the in memory limit to disk.
+// InfluxQL queries stream the response in chunks of the in memory limit, which are spilled as they are decoded.
+// Flux responses are decoded as a whole before their points are spilled.
+// Returns an error only if collecting on in fails.
+func (n *QueryNode) querySpilled(con influxdb.Client, in edge.Edge, stop time.Time, spill *edge.SpillBatchBuffer) error {
+	var collectErr error
+	spiller := edge.NewResultSpiller(n.byName, spill, func(m edge.Message) error {
+		if begin, ok := m.(edge.BeginBatchMessage); ok {
+			// Set stop time based off query bounds
+			if begin.Time().IsZero() || !n.groupedByTime() {
+				begin.SetTime(stop)
+			}
+			n.batchesQueried.Add(1)
+			n.pointsQueried.Add(int64(begin.SizeHint()))
+		}
+		n.timer.Pause()
+		defer n.timer.Resume()
+		if err := in.Collect(m); err != nil {
+			collectErr = err
+			return err
+		}
+		return nil
+	})
+
+	var err error
+	if cc, ok := con.(influxdb.ChunkedClient); ok && !n.b.FluxFlag {
+		err = n.streamRange(cc, stop.Add(-1*n.b.Period), stop, spiller.Result)
+	} else {
+		var resp *influxdb.Response
+		resp, err = n.execute(con, stop)
+		if err == nil {
+			for _, res := range resp.Results {
+				if err = spiller.Result(res); err != nil {
+					break
+				}
+			}
+		}
+	}
+	if err == nil {
+		err = spiller.Flush()
+	}
+	if collectErr != nil {
+		return collectErr
+	}
+	if err != nil {
+		spiller.Abort()
+		n.diag.Error("error executing query", err)
+	}
+	return nil
+}
+
+// streamRange runs the InfluxQL query of the time range from start to stop,
+// passing the response to f in chunks of at most the in memory limit of points.
+func (n *QueryNode) streamRange(con influxdb.ChunkedClient, start, stop time.Time, f func(influxdb.Result) error) error {
+	n.query.SetStartTime(start)
+	n.query.SetStopTime(stop)
+
+	qStr := n.query.String()
+	n.diag.StartingBatchQuery(qStr)
+	return con.QueryChunked(influxdb.Query{
+		Command: qStr,
+	}, int(n.b.MaxInMemoryPoints), f)
+}
+
+// chunkedBatches assembles the batches of the chunks of a query window into a single batch per group,
+// dropping the points of the overlap of a chunk with the previous chunk that the previous chunk already returned.
+type chunkedBatches struct {
+	overlap time.Duration
+	groups  map[models.GroupID]*chunkedBatch
+	// groups in the order they were first returned
+	order []*chunkedBatch
+}
+
+type chunkedBatch struct {
+	begin  edge.BeginBatchMessage
+	points []edge.BatchPointMessage
+	// keys of the points of the previous chunk within the overlap of the current chunk
+	seen map[string]bool
+	// keys of the points of the current chunk within the overlap of the next chunk
+	next map[string]bool
+}
+
+func newChunkedBatches(overlap time.Duration) *chunkedBatches {
+	return &chunkedBatches{
+		overlap: overlap,
+		groups:  make(map[models.GroupID]*chunkedBatch),
+	}
+}
+
+// add the batches returned by the query of the chunk from start to stop.
+func (c *chunkedBatches) add(start, stop time.Time, batches []edge.BufferedBatchMessage) {
+	nextOverlap := stop.Add(-1 * c.overlap)
+	for _, b := range batches {
+		begin := b.Begin()
+		// Batches of different measurements are different groups, even if the measurement is not part of the group ID.
+		key := models.GroupID(begin.Name() + "\n" + string(begin.GroupID()))
+		g, ok := c.groups[key]
+		if !ok {
+			g = &chunkedBatch{begin: begin.ShallowCopy()}
+			c.groups[key] = g
+			c.order = append(c.order, g)
+		} else if begin.Time().After(g.begin.Time()) {
+			g.begin.SetTime(begin.Time())
+		}
+		for _, bp := range b.Points() {
+			if c.overlap == 0 {
+				g.points = append(g.points, bp)
+				continue
+			}
+			k := chunkPointKey(bp)
+			if bp.Time().Before(start) && g.seen[k] {
+				// The previous chunk returned the point.
+				continue
+			}
+			if !bp.Time().Before(nextOverlap) {
+				if g.next == nil {
+					g.next = make(map[string]bool)
+				}
+				g.next[k] = true
+			}
+			g.points = append(g.points, bp)
+		}
+	}
+}
+
+// endChunk is called once all batches of a chunk were added.
+func (c *chunkedBatches) endChunk() {
+	for _, g := range c.groups {
+		g.seen, g.next = g.next, nil
+	}
+}
+
+// batches returns a batch per group with the points of all chunks in time order.
+func (c *chunkedBatches) batches() []edge.BufferedBatchMessage {
+	batches := make([]edge.BufferedBatchMessage, len(c.order))
+	for i, g := range c.order {
+		// Points of the overlap missed by the previous chunk follow the points of the previous chunk.
+		sort.SliceStable(g.points, func(i, j int) bool {
+			return g.points[i].Time().Before(g.points[j].Time())
+		})
+		g.begin.SetSizeHint(len(g.points))
+		batches[i] = edge.NewBufferedBatchMessage(g.begin, g.points, edge.NewEndBatchMessage())
+	}
+	return batches
+}
+
+// chunkPointKey identifies a point of a group by its time and tags.
+func chunkPointKey(bp edge.BatchPointMessage) string {
+	tags := bp.Tags()
+	return bp.Time().String() + "," + string(models.ToGroupID("", tags, models.Dimensions{TagNames: models.SortedKeys(tags)}))
+}
+
 func (n *QueryNode) runBatch([]byte) error {
 	errC := make(chan error, 1)
 	go func() {
@@ -0,0 +1,116 @@
+package kapacitor
+
+import (
+	"errors"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+const (
+	statsBatchesSplit = "batches_split"
+)
+
+type BatchSizeLimitNode struct {
+	node
+	b *pipeline.BatchSizeLimitNode
+
+	// begin is the begin message of the batch in progress.
+	begin edge.BeginBatchMessage
+	// count is the number of points in the current sub-batch.
+	count int64
+	// emitted is the number of points of the batch in progress emitted in previous sub-batches.
+	emitted int64
+	split   bool
+
+	batchesSplit *expvar.Int
+}
+
+// Create a new BatchSizeLimitNode which splits batches with more than a maximum number of points.
+func newBatchSizeLimitNode(et *ExecutingTask, n *pipeline.BatchSizeLimitNode, d NodeDiagnostic) (*BatchSizeLimitNode, error) {
+	bn := &BatchSizeLimitNode{
+		node:         node{Node: n, et: et, diag: d},
+		b:            n,
+		batchesSplit: new(expvar.Int),
+	}
+	bn.node.runF = bn.runBatchSizeLimit
+	return bn, nil
+}
+
+func (n *BatchSizeLimitNode) runBatchSizeLimit(snapshot []byte) error {
+	n.statMap.Set(statsBatchesSplit, n.batchesSplit)
+	consumer := edge.NewConsumerWithReceiver(
+		n.ins[0],
+		edge.NewReceiverFromForwardReceiverWithStats(
+			n.outs,
+			edge.NewTimedForwardReceiver(n.timer, n),
+		),
+	)
+	return consumer.Consume()
+}
+
+// subBatchBegin returns the begin message of the next sub-batch.
+// The size hint is the number of points the sub-batch is expected to have.
+func (n *BatchSizeLimitNode) subBatchBegin() edge.BeginBatchMessage {
+	begin := n.begin.ShallowCopy()
+	if hint := int64(n.begin.SizeHint()); hint > 0 {
+		remaining := hint - n.emitted
+		if remaining > n.b.MaxPoints {
+			remaining = n.b.MaxPoints
+		}
+		if remaining < 0 {
+			remaining = 0
+		}
+		begin.SetSizeHint(int(remaining))
+	}
+	return begin
+}
+
+func (n *BatchSizeLimitNode) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	n.begin = begin
+	n.count = 0
+	n.emitted = 0
+	n.split = false
+	return n.subBatchBegin(), nil
+}
+
+func (n *BatchSizeLimitNode) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	if n.count >= n.b.MaxPoints {
+		// End the full sub-batch and begin the next one before passing on the point.
+		n.emitted += n.count
+		n.count = 0
+		if !n.split {
+			n.split = true
+			n.batchesSplit.Add(1)
+		}
+		n.timer.Pause()
+		err := edge.Forward(n.outs, edge.NewEndBatchMessage())
+		if err == nil {
+			err = edge.Forward(n.outs, n.subBatchBegin())
+		}
+		n.timer.Resume()
+		if err != nil {
+			return nil, err
+		}
+	}
+	n.count++
+	return bp, nil
+}
+
+func (n *BatchSizeLimitNode) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	n.begin = nil
+	return end, nil
+}
+
+func (n *BatchSizeLimitNode) Point(p edge.PointMessage) (edge.Message, error) {
+	return nil, errors.New("batchSizeLimit does not support stream data")
+}
+
+func (n *BatchSizeLimitNode) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (n *BatchSizeLimitNode) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (n *BatchSizeLimitNode) Done() {}
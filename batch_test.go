@@ -0,0 +1,134 @@
+package kapacitor
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+func TestQueryNode_PartialWindow(t *testing.T) {
+	started := time.Date(2018, 1, 1, 12, 3, 0, 0, time.UTC)
+	testCases := []struct {
+		name   string
+		period time.Duration
+		offset time.Duration
+		stop   time.Time
+		exp    bool
+	}{
+		{
+			name:   "started mid window",
+			period: 5 * time.Minute,
+			stop:   time.Date(2018, 1, 1, 12, 5, 0, 0, time.UTC),
+			exp:    true,
+		},
+		{
+			name:   "window starts when started",
+			period: 2 * time.Minute,
+			stop:   time.Date(2018, 1, 1, 12, 5, 0, 0, time.UTC),
+		},
+		{
+			name:   "window after start",
+			period: time.Minute,
+			stop:   time.Date(2018, 1, 1, 12, 5, 0, 0, time.UTC),
+		},
+		{
+			name:   "offset window started mid window",
+			period: 5 * time.Minute,
+			offset: time.Hour,
+			stop:   time.Date(2018, 1, 1, 11, 5, 0, 0, time.UTC),
+			exp:    true,
+		},
+		{
+			name:   "offset window after start",
+			period: time.Minute,
+			offset: time.Hour,
+			stop:   time.Date(2018, 1, 1, 11, 5, 0, 0, time.UTC),
+		},
+	}
+	for _, tc := range testCases {
+		n := &QueryNode{
+			b: &pipeline.QueryNode{
+				Period: tc.period,
+				Offset: tc.offset,
+			},
+		}
+		if got := n.partialWindow(started, tc.stop); got != tc.exp {
+			t.Errorf("%s: unexpected partial window: got %t exp %t", tc.name, got, tc.exp)
+		}
+	}
+}
+
+func TestChunkedBatches(t *testing.T) {
+	t0 := time.Date(2018, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) time.Time { return t0.Add(d) }
+	// newBatch creates the batch of host returned by a chunk query with a point at each offset.
+	newBatch := func(host string, offsets ...time.Duration) edge.BufferedBatchMessage {
+		tags := models.Tags{"host": host}
+		points := make([]edge.BatchPointMessage, len(offsets))
+		for i, o := range offsets {
+			points[i] = edge.NewBatchPointMessage(models.Fields{"value": o.Seconds()}, tags, at(o))
+		}
+		begin := edge.NewBeginBatchMessage("cpu", tags, false, at(offsets[len(offsets)-1]), len(points))
+		return edge.NewBufferedBatchMessage(begin, points, edge.NewEndBatchMessage())
+	}
+
+	// Window of 30m queried in chunks of 10m with an overlap of 1m.
+	c := newChunkedBatches(time.Minute)
+	c.add(at(0), at(10*time.Minute), []edge.BufferedBatchMessage{
+		newBatch("serverA", 0, 5*time.Minute, 9*time.Minute+30*time.Second),
+		newBatch("serverB", 9*time.Minute+30*time.Second),
+	})
+	c.endChunk()
+	// The point at 9m45s of serverA was not yet written when the first chunk was queried.
+	c.add(at(10*time.Minute), at(20*time.Minute), []edge.BufferedBatchMessage{
+		newBatch("serverA", 9*time.Minute+30*time.Second, 9*time.Minute+45*time.Second, 15*time.Minute, 19*time.Minute+30*time.Second),
+		newBatch("serverB", 9*time.Minute+30*time.Second, 12*time.Minute),
+	})
+	c.endChunk()
+	c.add(at(20*time.Minute), at(30*time.Minute), []edge.BufferedBatchMessage{
+		newBatch("serverA", 19*time.Minute+30*time.Second, 25*time.Minute),
+	})
+	c.endChunk()
+
+	batches := c.batches()
+	if got, exp := len(batches), 2; got != exp {
+		t.Fatalf("unexpected number of batches: got %d exp %d", got, exp)
+	}
+	testCases := []struct {
+		host  string
+		times []time.Time
+	}{
+		{
+			host:  "serverA",
+			times: []time.Time{at(0), at(5 * time.Minute), at(9*time.Minute + 30*time.Second), at(9*time.Minute + 45*time.Second), at(15 * time.Minute), at(19*time.Minute + 30*time.Second), at(25 * time.Minute)},
+		},
+		{
+			host:  "serverB",
+			times: []time.Time{at(9*time.Minute + 30*time.Second), at(12 * time.Minute)},
+		},
+	}
+	for i, tc := range testCases {
+		b := batches[i]
+		if got := b.Begin().Tags()["host"]; got != tc.host {
+			t.Errorf("%d: unexpected host: got %s exp %s", i, got, tc.host)
+			continue
+		}
+		times := make([]time.Time, len(b.Points()))
+		for j, p := range b.Points() {
+			times[j] = p.Time()
+		}
+		if !reflect.DeepEqual(times, tc.times) {
+			t.Errorf("%s: unexpected point times:\ngot %v\nexp %v", tc.host, times, tc.times)
+		}
+		if got, exp := b.Begin().Time(), tc.times[len(tc.times)-1]; !got.Equal(exp) {
+			t.Errorf("%s: unexpected batch time: got %v exp %v", tc.host, got, exp)
+		}
+		if got, exp := b.Begin().SizeHint(), len(tc.times); got != exp {
+			t.Errorf("%s: unexpected size hint: got %d exp %d", tc.host, got, exp)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+// Package bloom implements Bloom filters,
+// testing whether a value was added to a set using a fixed amount of memory.
+//
+// A Bloom filter never reports an added value as absent,
+// but may report a value that was never added as present.
+// The probability of such a false positive grows with the number of added values,
+// a filter sized for n values with rate p has a false positive rate of about p once n values were added.
+package bloom
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+)
+
+// MaxBits is the largest supported size of a filter, using 512MB.
+const MaxBits = 1 << 32
+
+// Filter is a Bloom filter.
+type Filter struct {
+	bits []uint64
+	m    uint64
+	k    uint64
+}
+
+// New creates an empty filter sized for n values with a false positive rate of p.
+func New(n uint64, p float64) (*Filter, error) {
+	m, k, err := Size(n, p)
+	if err != nil {
+		return nil, err
+	}
+	return &Filter{
+		bits: make([]uint64, (m+63)/64),
+		m:    m,
+		k:    k,
+	}, nil
+}
+
+// Size returns the number of bits and hash functions of a filter for n values with a false positive rate of p.
+func Size(n uint64, p float64) (m, k uint64, err error) {
+	if n == 0 {
+		return 0, 0, errors.New("expected number of values must be greater than zero")
+	}
+	if p <= 0 || p >= 1 {
+		return 0, 0, fmt.Errorf("false positive rate must be between 0 and 1 exclusive, got %v", p)
+	}
+	bits := math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2))
+	if bits > MaxBits {
+		return 0, 0, fmt.Errorf("a filter for %d values with a false positive rate of %v needs %v bits, more than %d", n, p, bits, uint64(MaxBits))
+	}
+	m = uint64(bits)
+	k = uint64(math.Max(1, math.Round(float64(m)/float64(n)*math.Ln2)))
+	return m, k, nil
+}
+
+// FalsePositiveRate returns the expected false positive rate of a filter with m bits and k hash functions
+// once n values were added.
+func FalsePositiveRate(m, k, n uint64) float64 {
+	return math.Pow(1-math.Exp(-float64(k)*float64(n)/float64(m)), float64(k))
+}
+
+// Bits returns the number of bits of the filter.
+func (f *Filter) Bits() uint64 {
+	return f.m
+}
+
+// Hashes returns the number of hash functions of the filter.
+func (f *Filter) Hashes() uint64 {
+	return f.k
+}
+
+// Add adds a value to the filter.
+func (f *Filter) Add(v []byte) {
+	h1, h2 := hash(v)
+	for i := uint64(0); i < f.k; i++ {
+		b := (h1 + i*h2) % f.m
+		f.bits[b/64] |= 1 << (b % 64)
+	}
+}
+
+// Test reports whether the value was probably added to the filter.
+func (f *Filter) Test(v []byte) bool {
+	h1, h2 := hash(v)
+	for i := uint64(0); i < f.k; i++ {
+		b := (h1 + i*h2) % f.m
+		if f.bits[b/64]&(1<<(b%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// TestAndAdd reports whether the value was probably added to the filter before adding it.
+func (f *Filter) TestAndAdd(v []byte) bool {
+	h1, h2 := hash(v)
+	present := true
+	for i := uint64(0); i < f.k; i++ {
+		b := (h1 + i*h2) % f.m
+		mask := uint64(1) << (b % 64)
+		if f.bits[b/64]&mask == 0 {
+			present = false
+			f.bits[b/64] |= mask
+		}
+	}
+	return present
+}
+
+// Reset empties the filter, keeping its bits allocated.
+func (f *Filter) Reset() {
+	for i := range f.bits {
+		f.bits[i] = 0
+	}
+}
+
+// hash returns the two 64 bit hashes of v combined into the k hashes of the filter.
+// Both halves of FNV-1a 128 are finalized with the MurmurHash3 mixer to spread them over all bits,
+// the second hash is odd so that the combined hashes do not repeat early.
+func hash(v []byte) (uint64, uint64) {
+	h := fnv.New128a()
+	h.Write(v)
+	var sum [16]byte
+	s := h.Sum(sum[:0])
+	var h1, h2 uint64
+	for i := 0; i < 8; i++ {
+		h1 = h1<<8 | uint64(s[i])
+		h2 = h2<<8 | uint64(s[8+i])
+	}
+	return mix(h1), mix(h2) | 1
+}
+
+func mix(x uint64) uint64 {
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
@@ -0,0 +1,117 @@
+package bloom_test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/influxdata/kapacitor/bloom"
+)
+
+func TestFilter_NoFalseNegatives(t *testing.T) {
+	f, err := bloom.New(10000, 0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10000; i++ {
+		// A false positive may be reported, the value is added either way.
+		f.TestAndAdd([]byte("id" + strconv.Itoa(i)))
+	}
+	for i := 0; i < 10000; i++ {
+		v := []byte("id" + strconv.Itoa(i))
+		if !f.Test(v) || !f.TestAndAdd(v) {
+			t.Fatalf("added value %q reported as absent", v)
+		}
+	}
+}
+
+func TestFilter_FalsePositiveRate(t *testing.T) {
+	testCases := []struct {
+		n uint64
+		p float64
+	}{
+		{n: 1000, p: 0.1},
+		{n: 10000, p: 0.01},
+		{n: 10000, p: 0.001},
+	}
+	for _, tc := range testCases {
+		t.Run(strconv.FormatUint(tc.n, 10)+"/"+strconv.FormatFloat(tc.p, 'g', -1, 64), func(t *testing.T) {
+			f, err := bloom.New(tc.n, tc.p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := uint64(0); i < tc.n; i++ {
+				f.Add([]byte("member" + strconv.FormatUint(i, 10)))
+			}
+			if exp := bloom.FalsePositiveRate(f.Bits(), f.Hashes(), tc.n); exp > 1.1*tc.p {
+				t.Errorf("expected false positive rate %v of the sizing exceeds %v", exp, tc.p)
+			}
+			const trials = 100000
+			positives := 0
+			for i := 0; i < trials; i++ {
+				if f.Test([]byte("other" + strconv.Itoa(i))) {
+					positives++
+				}
+			}
+			// The observed rate is within twice the configured rate,
+			// the trials make a larger deviation vanishingly unlikely.
+			if got := float64(positives) / trials; got > 2*tc.p {
+				t.Errorf("false positive rate %v, more than twice %v", got, tc.p)
+			}
+		})
+	}
+}
+
+func TestFilter_Reset(t *testing.T) {
+	f, err := bloom.New(100, 0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Add([]byte("a"))
+	f.Reset()
+	if f.Test([]byte("a")) {
+		t.Error("value present after reset")
+	}
+}
+
+func TestNew_Invalid(t *testing.T) {
+	testCases := []struct {
+		name string
+		n    uint64
+		p    float64
+	}{
+		{name: "zero count", n: 0, p: 0.01},
+		{name: "zero rate", n: 100, p: 0},
+		{name: "rate of one", n: 100, p: 1},
+		{name: "too large", n: 1 << 40, p: 0.01},
+	}
+	for _, tc := range testCases {
+		if _, err := bloom.New(tc.n, tc.p); err == nil {
+			t.Errorf("%s: expected error", tc.name)
+		}
+	}
+}
+
+func TestRotating(t *testing.T) {
+	r, err := bloom.NewRotating(1000, 0.001)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := []byte("a")
+	if r.TestAndAdd(a) {
+		t.Fatal("a present before it was added")
+	}
+	r.Rotate()
+	// Testing a adds it to the current filter again.
+	if !r.TestAndAdd(a) {
+		t.Fatal("a absent after one rotation")
+	}
+	r.Rotate()
+	if !r.TestAndAdd(a) {
+		t.Fatal("a absent one rotation after it was added again")
+	}
+	r.Rotate()
+	r.Rotate()
+	if r.TestAndAdd(a) {
+		t.Error("a present two rotations after it was last added")
+	}
+}
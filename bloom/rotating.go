@@ -0,0 +1,44 @@
+package bloom
+
+// Rotating is a pair of Bloom filters testing whether a value was added since the previous rotation.
+// Values are added to the current filter, on rotation the current filter replaces the previous one
+// and the current filter starts empty.
+// Values are therefore remembered for at least one and at most two rotation periods.
+type Rotating struct {
+	current  *Filter
+	previous *Filter
+}
+
+// NewRotating creates an empty rotating filter,
+// each of its two filters is sized for n values with a false positive rate of p.
+func NewRotating(n uint64, p float64) (*Rotating, error) {
+	current, err := New(n, p)
+	if err != nil {
+		return nil, err
+	}
+	previous, _ := New(n, p)
+	return &Rotating{
+		current:  current,
+		previous: previous,
+	}, nil
+}
+
+// TestAndAdd reports whether the value was probably added since the previous rotation before adding it.
+func (r *Rotating) TestAndAdd(v []byte) bool {
+	if r.current.TestAndAdd(v) {
+		return true
+	}
+	return r.previous.Test(v)
+}
+
+// Rotate forgets the values added before the previous rotation.
+func (r *Rotating) Rotate() {
+	r.current, r.previous = r.previous, r.current
+	r.current.Reset()
+}
+
+// Reset empties both filters.
+func (r *Rotating) Reset() {
+	r.current.Reset()
+	r.previous.Reset()
+}
@@ -0,0 +1,174 @@
+package kapacitor
+
+import (
+	"time"
+
+	"github.com/influxdata/kapacitor/bloom"
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+const (
+	statsProbablySeen = "probably_seen"
+)
+
+type BloomFilterNode struct {
+	node
+	b *pipeline.BloomFilterNode
+
+	// global is the filter shared by all groups, nil unless the global property is set
+	global *bloomMembers
+
+	probablySeen *expvar.Int
+}
+
+// Create a new BloomFilterNode which marks points whose ID was probably seen before.
+func newBloomFilterNode(et *ExecutingTask, n *pipeline.BloomFilterNode, d NodeDiagnostic) (*BloomFilterNode, error) {
+	// Check the sizing up front, the filters of the groups are allocated on their first ID.
+	if _, _, err := bloom.Size(uint64(n.Expected), n.FalsePositiveRate); err != nil {
+		return nil, err
+	}
+	bn := &BloomFilterNode{
+		node:         node{Node: n, et: et, diag: d},
+		b:            n,
+		probablySeen: new(expvar.Int),
+	}
+	if n.GlobalFlag {
+		bn.global = new(bloomMembers)
+	}
+	bn.node.runF = bn.runBloomFilter
+	return bn, nil
+}
+
+func (n *BloomFilterNode) runBloomFilter([]byte) error {
+	n.statMap.Set(statsProbablySeen, n.probablySeen)
+	consumer := edge.NewGroupedConsumer(
+		n.ins[0],
+		n,
+	)
+	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
+	return consumer.Consume()
+}
+
+func (n *BloomFilterNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	members := n.global
+	if members == nil {
+		members = new(bloomMembers)
+	}
+	g := &bloomFilterGroup{
+		n:       n,
+		members: members,
+	}
+	return edge.NewReceiverFromForwardReceiverWithStats(
+		n.outs,
+		edge.NewTimedForwardReceiver(n.timer, g),
+	), nil
+}
+
+// bloomMembers holds the IDs seen by a group, or by all groups for a global filter.
+type bloomMembers struct {
+	// filter is used without rotation, rotating with it, both are allocated on the first ID
+	filter   *bloom.Filter
+	rotating *bloom.Rotating
+	// rotateAt is the time of the next rotation
+	rotateAt time.Time
+}
+
+// testAndAdd reports whether the ID was probably seen before and adds it,
+// first rotating the filter if the time t is past the end of the current rotation period.
+func (m *bloomMembers) testAndAdd(b *pipeline.BloomFilterNode, id []byte, t time.Time) bool {
+	n, p := uint64(b.Expected), b.FalsePositiveRate
+	if b.Rotate == 0 {
+		if m.filter == nil {
+			// The sizing was checked when creating the node.
+			m.filter, _ = bloom.New(n, p)
+		}
+		return m.filter.TestAndAdd(id)
+	}
+	if m.rotating == nil {
+		m.rotating, _ = bloom.NewRotating(n, p)
+	}
+	if !t.Before(m.rotateAt) {
+		if t.Before(m.rotateAt.Add(b.Rotate)) {
+			m.rotating.Rotate()
+		} else {
+			// No IDs were seen during the previous period.
+			m.rotating.Reset()
+		}
+		m.rotateAt = t.Truncate(b.Rotate).Add(b.Rotate)
+	}
+	return m.rotating.TestAndAdd(id)
+}
+
+type bloomFilterGroup struct {
+	n       *BloomFilterNode
+	members *bloomMembers
+
+	// buffer for encoding IDs
+	buf []byte
+}
+
+// fields returns a copy of the fields of p marking whether its ID was probably seen before.
+// Returns false if p has no ID.
+func (g *bloomFilterGroup) fields(p edge.FieldsTagsTimeGetter) (models.Fields, bool) {
+	var ok bool
+	var err error
+	g.buf, ok, err = appendFieldOrTag(g.buf[:0], p, g.n.b.Field)
+	if err != nil {
+		g.n.diag.Error("cannot test membership", err)
+		return nil, false
+	}
+	if !ok {
+		return nil, false
+	}
+	seen := g.members.testAndAdd(g.n.b, g.buf, p.Time())
+	if seen {
+		g.n.probablySeen.Add(1)
+	}
+	fields := p.Fields().Copy()
+	fields[g.n.b.As] = seen
+	return fields, true
+}
+
+func (g *bloomFilterGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	return begin, nil
+}
+
+func (g *bloomFilterGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	fields, ok := g.fields(bp)
+	if !ok {
+		return bp, nil
+	}
+	bp = bp.ShallowCopy()
+	bp.SetFields(fields)
+	return bp, nil
+}
+
+func (g *bloomFilterGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (g *bloomFilterGroup) Point(p edge.PointMessage) (edge.Message, error) {
+	fields, ok := g.fields(p)
+	if !ok {
+		return p, nil
+	}
+	p = p.ShallowCopy()
+	p.SetFields(fields)
+	return p, nil
+}
+
+func (g *bloomFilterGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+
+// DeleteGroup releases the filter of the group, a global filter is kept for the other groups.
+func (g *bloomFilterGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	g.members = nil
+	g.buf = nil
+	return d, nil
+}
+
+func (g *bloomFilterGroup) Done() {}
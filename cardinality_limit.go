@@ -0,0 +1,157 @@
+package kapacitor
+
+import (
+	"sort"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+const (
+	statsCardinalityBreaches = "breaches"
+
+	cardinalityField       = "cardinality"
+	topTagField            = "top_tag"
+	topTagCardinalityField = "top_tag_cardinality"
+)
+
+type CardinalityLimitNode struct {
+	node
+	c *pipeline.CardinalityLimitNode
+
+	windowStart time.Time
+	groups      map[models.GroupID]bool
+	// distinct values of each tag key, only tracked if the top tag is requested
+	tagValues map[string]map[string]bool
+	breached  bool
+
+	// name and group of the current batch
+	batchName  string
+	batchGroup models.GroupID
+
+	breaches *expvar.Int
+}
+
+// Create a new CardinalityLimitNode which emits a point when the number of distinct groups exceeds a limit.
+func newCardinalityLimitNode(et *ExecutingTask, n *pipeline.CardinalityLimitNode, d NodeDiagnostic) (*CardinalityLimitNode, error) {
+	cn := &CardinalityLimitNode{
+		node:     node{Node: n, et: et, diag: d},
+		c:        n,
+		breaches: new(expvar.Int),
+	}
+	cn.resetWindow(time.Time{})
+	cn.node.runF = cn.runCardinalityLimit
+	return cn, nil
+}
+
+func (n *CardinalityLimitNode) runCardinalityLimit([]byte) error {
+	n.statMap.Set(statsCardinalityBreaches, n.breaches)
+	consumer := edge.NewGroupedConsumer(n.ins[0], n)
+	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
+	return consumer.Consume()
+}
+
+func (n *CardinalityLimitNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	// All groups share the node state, since groups are counted across the whole stream.
+	return edge.NewReceiverFromForwardReceiverWithStats(
+		n.outs,
+		edge.NewTimedForwardReceiver(n.timer, n),
+	), nil
+}
+
+func (n *CardinalityLimitNode) resetWindow(start time.Time) {
+	n.windowStart = start
+	n.groups = make(map[models.GroupID]bool)
+	if n.c.TopTagFlag {
+		n.tagValues = make(map[string]map[string]bool)
+	}
+	n.breached = false
+}
+
+// observe counts the group and tags of a point and returns a point if the limit was exceeded.
+func (n *CardinalityLimitNode) observe(name string, group models.GroupID, tags models.Tags, t time.Time) edge.Message {
+	if start := t.Truncate(n.c.Window); start.After(n.windowStart) {
+		n.resetWindow(start)
+	}
+	n.groups[group] = true
+	if n.tagValues != nil {
+		for k, v := range tags {
+			values, ok := n.tagValues[k]
+			if !ok {
+				values = make(map[string]bool)
+				n.tagValues[k] = values
+			}
+			values[v] = true
+		}
+	}
+	if n.breached || int64(len(n.groups)) <= n.c.MaxCardinality {
+		return nil
+	}
+	n.breached = true
+	n.breaches.Add(1)
+
+	fields := models.Fields{
+		cardinalityField: int64(len(n.groups)),
+	}
+	if n.tagValues != nil {
+		if tag, count := n.topTag(); tag != "" {
+			fields[topTagField] = tag
+			fields[topTagCardinalityField] = int64(count)
+		}
+	}
+	return edge.NewPointMessage(
+		name,
+		"",
+		"",
+		models.Dimensions{},
+		fields,
+		models.Tags{},
+		t,
+	)
+}
+
+// topTag returns the tag key with the most distinct values in the window.
+// Ties are broken by tag key so the result is deterministic.
+func (n *CardinalityLimitNode) topTag() (string, int) {
+	keys := make([]string, 0, len(n.tagValues))
+	for k := range n.tagValues {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	top, count := "", 0
+	for _, k := range keys {
+		if c := len(n.tagValues[k]); c > count {
+			top, count = k, c
+		}
+	}
+	return top, count
+}
+
+func (n *CardinalityLimitNode) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	n.batchName = begin.Name()
+	n.batchGroup = begin.GroupID()
+	return nil, nil
+}
+
+func (n *CardinalityLimitNode) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	return n.observe(n.batchName, n.batchGroup, bp.Tags(), bp.Time()), nil
+}
+
+func (n *CardinalityLimitNode) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return nil, nil
+}
+
+func (n *CardinalityLimitNode) Point(p edge.PointMessage) (edge.Message, error) {
+	return n.observe(p.Name(), p.GroupID(), p.Tags(), p.Time()), nil
+}
+
+func (n *CardinalityLimitNode) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return nil, nil
+}
+func (n *CardinalityLimitNode) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return nil, nil
+}
+func (n *CardinalityLimitNode) Done() {}
@@ -0,0 +1,165 @@
+package kapacitor
+
+import (
+	"math"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+const (
+	statsClamped = "clamped"
+	statsDropped = "dropped"
+)
+
+type ClampNode struct {
+	node
+	c *pipeline.ClampNode
+
+	clamped *expvar.Int
+	dropped *expvar.Int
+}
+
+// Create a new ClampNode which bounds numeric fields into ranges.
+func newClampNode(et *ExecutingTask, n *pipeline.ClampNode, d NodeDiagnostic) (*ClampNode, error) {
+	cn := &ClampNode{
+		node:    node{Node: n, et: et, diag: d},
+		c:       n,
+		clamped: new(expvar.Int),
+		dropped: new(expvar.Int),
+	}
+	cn.node.runF = cn.runClamp
+	return cn, nil
+}
+
+func (n *ClampNode) runClamp([]byte) error {
+	n.statMap.Set(statsClamped, n.clamped)
+	n.statMap.Set(statsDropped, n.dropped)
+	consumer := edge.NewConsumerWithReceiver(
+		n.ins[0],
+		edge.NewReceiverFromForwardReceiverWithStats(
+			n.outs,
+			edge.NewTimedForwardReceiver(n.timer, n),
+		),
+	)
+	return consumer.Consume()
+}
+
+// clampFloat returns v bounded into r and whether v was in r.
+// NaN is never in r and is returned unchanged.
+func clampFloat(v float64, r *pipeline.ClampRange) (float64, bool) {
+	switch {
+	case math.IsNaN(v):
+		return v, false
+	case v < r.Min:
+		return r.Min, false
+	case v > r.Max:
+		return r.Max, false
+	}
+	return v, true
+}
+
+// clampInt returns v bounded into the integers of r and whether v was in r.
+func clampInt(v int64, r *pipeline.ClampRange) (int64, bool) {
+	switch {
+	case float64(v) < r.Min:
+		return floatToInt(math.Ceil(r.Min)), false
+	case float64(v) > r.Max:
+		return floatToInt(math.Floor(r.Max)), false
+	}
+	return v, true
+}
+
+// floatToInt converts an integral f to an int64, saturating at the limits of int64.
+func floatToInt(f float64) int64 {
+	switch {
+	case f >= math.MaxInt64:
+		return math.MaxInt64
+	case f <= math.MinInt64:
+		return math.MinInt64
+	}
+	return int64(f)
+}
+
+// apply bounds the fields into their ranges and returns whether the point should be kept.
+// The fields are copied before they are modified.
+func (n *ClampNode) apply(fields models.Fields) (models.Fields, bool) {
+	newFields := fields
+	copied := false
+	set := func(name string, v interface{}) {
+		if !copied {
+			newFields = newFields.Copy()
+			copied = true
+		}
+		if f, ok := v.(float64); ok && math.IsNaN(f) {
+			delete(newFields, name)
+			return
+		}
+		newFields[name] = v
+	}
+	for name, r := range n.c.Ranges {
+		var v interface{}
+		var ok bool
+		switch value := fields[name].(type) {
+		case float64:
+			v, ok = clampFloat(value, r)
+		case int64:
+			v, ok = clampInt(value, r)
+		default:
+			continue
+		}
+		if ok {
+			continue
+		}
+		n.clamped.Add(1)
+		if n.c.Action == pipeline.ClampDrop {
+			n.dropped.Add(1)
+			return nil, false
+		}
+		set(name, v)
+	}
+	return newFields, true
+}
+
+func (n *ClampNode) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	if n.c.Action == pipeline.ClampDrop {
+		// Points may be dropped, so the size of the batch is not known.
+		begin = begin.ShallowCopy()
+		begin.SetSizeHint(0)
+	}
+	return begin, nil
+}
+
+func (n *ClampNode) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	fields, ok := n.apply(bp.Fields())
+	if !ok {
+		return nil, nil
+	}
+	bp = bp.ShallowCopy()
+	bp.SetFields(fields)
+	return bp, nil
+}
+
+func (n *ClampNode) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (n *ClampNode) Point(p edge.PointMessage) (edge.Message, error) {
+	fields, ok := n.apply(p.Fields())
+	if !ok {
+		return nil, nil
+	}
+	p = p.ShallowCopy()
+	p.SetFields(fields)
+	return p, nil
+}
+
+func (n *ClampNode) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (n *ClampNode) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (n *ClampNode) Done() {}
@@ -104,8 +104,10 @@ func (m *Main) Run(args ...string) error {
 				break Loop
 
 			case syscall.SIGHUP.String():
-				m.Diag.Info("SIGHUP received, reloading tasks/templates/handlers directory...")
-				cmd.Server.Reload()
+				m.Diag.Info("SIGHUP received, reloading tasks/templates/handlers directory and configuration...")
+				if err := cmd.Reload(); err != nil {
+					m.Diag.Error("failed to reload", err)
+				}
 
 			default:
 				m.Diag.Info("signal received, initializing clean shutdown...")
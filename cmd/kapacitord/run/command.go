@@ -41,9 +41,10 @@ type Command struct {
 	Commit   string
 	Platform string
 
-	closing chan struct{}
-	pidfile string
-	Closed  chan struct{}
+	closing    chan struct{}
+	pidfile    string
+	configPath string
+	Closed     chan struct{}
 
 	Stdin  io.Reader
 	Stdout io.Writer
@@ -78,7 +79,8 @@ func (cmd *Command) Run(args ...string) error {
 	fmt.Print(logo)
 
 	// Parse config
-	config, err := cmd.ParseConfig(FindConfigPath(options.ConfigPath))
+	cmd.configPath = FindConfigPath(options.ConfigPath)
+	config, err := cmd.ParseConfig(cmd.configPath)
 	if err != nil {
 		return fmt.Errorf("parse config: %s", err)
 	}
@@ -156,6 +158,25 @@ func (cmd *Command) Close() error {
 	return nil
 }
 
+// Reload reloads the tasks/templates/handlers directory
+// and applies the configuration of all dynamic services from the configuration file,
+// without restarting running tasks.
+func (cmd *Command) Reload() error {
+	cmd.Server.Reload()
+
+	config, err := cmd.ParseConfig(cmd.configPath)
+	if err != nil {
+		return fmt.Errorf("parse config: %s", err)
+	}
+	if err := config.ApplyEnvOverrides(); err != nil {
+		return fmt.Errorf("apply env config: %v", err)
+	}
+	if err := config.Validate(); err != nil {
+		return fmt.Errorf("invalid config: %v", err)
+	}
+	return cmd.Server.ReloadConfig(config)
+}
+
 func (cmd *Command) monitorServerErrors() {
 	for {
 		select {
@@ -0,0 +1,157 @@
+package kapacitor
+
+import (
+	"math"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+const (
+	statsMissing = "missing"
+)
+
+type CoalesceNode struct {
+	node
+	c *pipeline.CoalesceNode
+
+	missing *expvar.Int
+}
+
+// Create a new CoalesceNode which sets a field to the value of the first of a list of fields that has a value.
+func newCoalesceNode(et *ExecutingTask, n *pipeline.CoalesceNode, d NodeDiagnostic) (*CoalesceNode, error) {
+	cn := &CoalesceNode{
+		node:    node{Node: n, et: et, diag: d},
+		c:       n,
+		missing: new(expvar.Int),
+	}
+	cn.node.runF = cn.runCoalesce
+	return cn, nil
+}
+
+func (n *CoalesceNode) runCoalesce([]byte) error {
+	n.statMap.Set(statsMissing, n.missing)
+	consumer := edge.NewConsumerWithReceiver(
+		n.ins[0],
+		edge.NewReceiverFromForwardReceiverWithStats(
+			n.outs,
+			edge.NewTimedForwardReceiver(n.timer, n),
+		),
+	)
+	return consumer.Consume()
+}
+
+// coalesceValue converts v to the type typ.
+// Returns false if v is NaN or cannot be converted.
+func coalesceValue(v interface{}, typ string) (interface{}, bool) {
+	if f, ok := v.(float64); ok && math.IsNaN(f) {
+		return nil, false
+	}
+	switch typ {
+	case pipeline.CoalesceAny:
+		switch v.(type) {
+		case float64, int64, string, bool:
+			return v, true
+		}
+	case pipeline.CoalesceFloat:
+		switch v := v.(type) {
+		case float64:
+			return v, true
+		case int64:
+			return float64(v), true
+		}
+	case pipeline.CoalesceInt:
+		switch v := v.(type) {
+		case int64:
+			return v, true
+		case float64:
+			if v == math.Trunc(v) && v >= math.MinInt64 && v < math.MaxInt64 {
+				return int64(v), true
+			}
+		}
+	case pipeline.CoalesceString:
+		if s, err := fieldTagValue(v); err == nil {
+			return s, true
+		}
+	case pipeline.CoalesceBool:
+		if b, ok := v.(bool); ok {
+			return b, true
+		}
+	}
+	return nil, false
+}
+
+// coalesce returns the fields with the field set to the first value of the fields.
+// Returns false if the point should be dropped.
+// The fields are copied before they are modified.
+func (n *CoalesceNode) coalesce(fields models.Fields) (models.Fields, bool) {
+	var value interface{}
+	found := false
+	for _, name := range n.c.Fields {
+		v, ok := fields[name]
+		if !ok {
+			continue
+		}
+		if value, found = coalesceValue(v, n.c.FieldType); found {
+			break
+		}
+	}
+	if !found {
+		n.missing.Add(1)
+		switch {
+		case n.c.DropMissingFlag:
+			return nil, false
+		case n.c.DefaultValue != nil:
+			value, found = coalesceValue(n.c.DefaultValue, n.c.FieldType)
+		}
+		if !found {
+			return fields, true
+		}
+	}
+	fields = fields.Copy()
+	fields[n.c.As] = value
+	return fields, true
+}
+
+func (n *CoalesceNode) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	if n.c.DropMissingFlag {
+		// Points may be dropped, so the size of the batch is not known.
+		begin = begin.ShallowCopy()
+		begin.SetSizeHint(0)
+	}
+	return begin, nil
+}
+
+func (n *CoalesceNode) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	fields, ok := n.coalesce(bp.Fields())
+	if !ok {
+		return nil, nil
+	}
+	bp = bp.ShallowCopy()
+	bp.SetFields(fields)
+	return bp, nil
+}
+
+func (n *CoalesceNode) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (n *CoalesceNode) Point(p edge.PointMessage) (edge.Message, error) {
+	fields, ok := n.coalesce(p.Fields())
+	if !ok {
+		return nil, nil
+	}
+	p = p.ShallowCopy()
+	p.SetFields(fields)
+	return p, nil
+}
+
+func (n *CoalesceNode) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (n *CoalesceNode) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (n *CoalesceNode) Done() {}
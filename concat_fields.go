@@ -0,0 +1,106 @@
+package kapacitor
+
+import (
+	"bytes"
+	"strconv"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+type ConcatFieldsNode struct {
+	node
+	c *pipeline.ConcatFieldsNode
+}
+
+// Create a new ConcatFieldsNode which concatenates fields, tags and literal strings into string fields.
+func newConcatFieldsNode(et *ExecutingTask, n *pipeline.ConcatFieldsNode, d NodeDiagnostic) (*ConcatFieldsNode, error) {
+	cn := &ConcatFieldsNode{
+		node: node{Node: n, et: et, diag: d},
+		c:    n,
+	}
+	cn.node.runF = cn.runConcatFields
+	return cn, nil
+}
+
+func (n *ConcatFieldsNode) runConcatFields([]byte) error {
+	consumer := edge.NewConsumerWithReceiver(
+		n.ins[0],
+		edge.NewReceiverFromForwardReceiverWithStats(
+			n.outs,
+			edge.NewTimedForwardReceiver(n.timer, n),
+		),
+	)
+	return consumer.Consume()
+}
+
+// concatFields returns a copy of fields with the concatenated fields of a point with the fields and tags.
+func (n *ConcatFieldsNode) concatFields(fields models.Fields, tags models.Tags) models.Fields {
+	// The concatenations refer to the fields of the point, not to the fields set before them.
+	concatenated := fields.Copy()
+	var b bytes.Buffer
+	for _, c := range n.c.Concats {
+		b.Reset()
+		for i, p := range c.Parts {
+			if i > 0 {
+				b.WriteString(c.Separator)
+			}
+			if p.Reference {
+				b.WriteString(n.resolve(p.Value, fields, tags))
+			} else {
+				b.WriteString(p.Value)
+			}
+		}
+		concatenated[c.As] = b.String()
+	}
+	return concatenated
+}
+
+// resolve returns the string value of the field name, or of the tag name if there is no such field.
+func (n *ConcatFieldsNode) resolve(name string, fields models.Fields, tags models.Tags) string {
+	if v, ok := fields[name]; ok {
+		switch v := v.(type) {
+		case string:
+			return v
+		case float64:
+			return strconv.FormatFloat(v, 'f', int(n.c.Precision), 64)
+		case int64:
+			return strconv.FormatInt(v, 10)
+		case bool:
+			return strconv.FormatBool(v)
+		}
+	}
+	if v, ok := tags[name]; ok {
+		return v
+	}
+	return n.c.Missing
+}
+
+func (n *ConcatFieldsNode) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	return begin, nil
+}
+
+func (n *ConcatFieldsNode) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	bp = bp.ShallowCopy()
+	bp.SetFields(n.concatFields(bp.Fields(), bp.Tags()))
+	return bp, nil
+}
+
+func (n *ConcatFieldsNode) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (n *ConcatFieldsNode) Point(p edge.PointMessage) (edge.Message, error) {
+	p = p.ShallowCopy()
+	p.SetFields(n.concatFields(p.Fields(), p.Tags()))
+	return p, nil
+}
+
+func (n *ConcatFieldsNode) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (n *ConcatFieldsNode) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (n *ConcatFieldsNode) Done() {}
@@ -0,0 +1,278 @@
+package kapacitor
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/keyvalue"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+type CorrelateNode struct {
+	node
+	c *pipeline.CorrelateNode
+}
+
+// Create a new CorrelateNode which computes the correlation between two fields over the last points of each group.
+func newCorrelateNode(et *ExecutingTask, n *pipeline.CorrelateNode, d NodeDiagnostic) (*CorrelateNode, error) {
+	cn := &CorrelateNode{
+		node: node{Node: n, et: et, diag: d},
+		c:    n,
+	}
+	cn.node.runF = cn.runCorrelate
+	return cn, nil
+}
+
+func (n *CorrelateNode) runCorrelate([]byte) error {
+	consumer := edge.NewGroupedConsumer(
+		n.ins[0],
+		n,
+	)
+	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
+	return consumer.Consume()
+}
+
+func (n *CorrelateNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	return edge.NewReceiverFromForwardReceiverWithStats(
+		n.outs,
+		edge.NewTimedForwardReceiver(n.timer, n.newGroup(group, first.Name())),
+	), nil
+}
+
+func (n *CorrelateNode) newGroup(group edge.GroupInfo, name string) *correlateGroup {
+	return &correlateGroup{
+		n:      n,
+		group:  group,
+		name:   name,
+		window: newCorrelationWindow(int(n.c.Size)),
+	}
+}
+
+type correlateGroup struct {
+	n      *CorrelateNode
+	group  edge.GroupInfo
+	name   string
+	window *correlationWindow
+
+	// number of points added since the last window was emitted by a stream
+	count int
+	// time of the current batch
+	batchTime time.Time
+}
+
+func (g *correlateGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	g.window.reset()
+	if g.n.c.PerWindowFlag {
+		g.name = begin.Name()
+		g.batchTime = begin.Time()
+		return nil, nil
+	}
+	begin = begin.ShallowCopy()
+	begin.SetSizeHint(0)
+	return begin, nil
+}
+
+func (g *correlateGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	if !g.add(bp) || g.n.c.PerWindowFlag {
+		return nil, nil
+	}
+	np := bp.ShallowCopy()
+	g.setCoefficient(np)
+	return np, nil
+}
+
+func (g *correlateGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	if !g.n.c.PerWindowFlag {
+		return end, nil
+	}
+	r, ok := g.window.coefficient()
+	if !ok {
+		return nil, nil
+	}
+	return edge.NewBufferedBatchMessage(
+		edge.NewBeginBatchMessage(
+			g.name,
+			g.group.Tags,
+			g.group.Dimensions.ByName,
+			g.batchTime,
+			1,
+		),
+		[]edge.BatchPointMessage{
+			edge.NewBatchPointMessage(
+				models.Fields{g.n.c.As: r},
+				g.group.Tags,
+				g.batchTime,
+			),
+		},
+		edge.NewEndBatchMessage(),
+	), nil
+}
+
+func (g *correlateGroup) Point(p edge.PointMessage) (edge.Message, error) {
+	if !g.add(p) {
+		return nil, nil
+	}
+	if !g.n.c.PerWindowFlag {
+		np := p.ShallowCopy()
+		g.setCoefficient(np)
+		return np, nil
+	}
+	g.count++
+	if g.count < int(g.n.c.Size) {
+		return nil, nil
+	}
+	g.count = 0
+	r, ok := g.window.coefficient()
+	if !ok {
+		return nil, nil
+	}
+	return edge.NewPointMessage(
+		p.Name(), "", "",
+		g.group.Dimensions,
+		models.Fields{g.n.c.As: r},
+		g.group.Tags,
+		p.Time(),
+	), nil
+}
+
+// add adds the values of the fields of p to the window.
+// Returns false if p does not have numeric values for both fields.
+func (g *correlateGroup) add(p edge.FieldsTagsTimeGetter) bool {
+	x, ok := g.value(p, g.n.c.X)
+	if !ok {
+		return false
+	}
+	y, ok := g.value(p, g.n.c.Y)
+	if !ok {
+		return false
+	}
+	g.window.add(x, y)
+	return true
+}
+
+func (g *correlateGroup) value(p edge.FieldsTagsTimeGetter, field string) (float64, bool) {
+	v, ok := numToFloat(p.Fields()[field])
+	if !ok {
+		g.n.diag.Error("cannot compute correlation",
+			errors.New("field is missing or the wrong type"),
+			keyvalue.KV("field", field),
+			keyvalue.KV("type", fmt.Sprintf("%T", p.Fields()[field])),
+		)
+	}
+	return v, ok
+}
+
+// setCoefficient sets the coefficient of the window on p, leaving the field unset if it is null.
+func (g *correlateGroup) setCoefficient(p edge.FieldsTagsTimeSetter) {
+	r, ok := g.window.coefficient()
+	if !ok {
+		return
+	}
+	fields := p.Fields().Copy()
+	fields[g.n.c.As] = r
+	p.SetFields(fields)
+}
+
+func (g *correlateGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (g *correlateGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	// Release the window, the group is no longer referenced by the consumer.
+	g.window = nil
+	return d, nil
+}
+func (g *correlateGroup) Done() {}
+
+// correlationWindow keeps the most recent pairs of values and the sums needed to compute their correlation.
+// The sums are of the values shifted by a pair of the window, which leaves the coefficient unchanged
+// but avoids the loss of precision of summing squares of large values with a small variance.
+type correlationWindow struct {
+	xs, ys []float64
+	// index of the oldest pair
+	start int
+	count int
+
+	shiftX, shiftY                  float64
+	sumX, sumY, sumXY, sumXX, sumYY float64
+}
+
+func newCorrelationWindow(size int) *correlationWindow {
+	return &correlationWindow{
+		xs: make([]float64, size),
+		ys: make([]float64, size),
+	}
+}
+
+// add adds a pair of values to the window, removing the oldest pair from the sums if the window is full.
+func (w *correlationWindow) add(x, y float64) {
+	if w.count == 0 {
+		w.shiftX, w.shiftY = x, y
+	}
+	if w.count < len(w.xs) {
+		i := (w.start + w.count) % len(w.xs)
+		w.xs[i], w.ys[i] = x, y
+		w.count++
+		w.addSums(x, y, 1)
+		return
+	}
+	w.addSums(w.xs[w.start], w.ys[w.start], -1)
+	w.xs[w.start], w.ys[w.start] = x, y
+	w.start = (w.start + 1) % len(w.xs)
+	w.addSums(x, y, 1)
+	if w.start == 0 {
+		// Recompute the sums once per rotation of the window so rounding errors do not accumulate,
+		// shifting them by a current pair in case the values drifted away from the previous one.
+		w.recompute()
+	}
+}
+
+func (w *correlationWindow) addSums(x, y, sign float64) {
+	x -= w.shiftX
+	y -= w.shiftY
+	w.sumX += sign * x
+	w.sumY += sign * y
+	w.sumXY += sign * x * y
+	w.sumXX += sign * x * x
+	w.sumYY += sign * y * y
+}
+
+func (w *correlationWindow) recompute() {
+	w.shiftX, w.shiftY = w.xs[w.start], w.ys[w.start]
+	w.sumX, w.sumY, w.sumXY, w.sumXX, w.sumYY = 0, 0, 0, 0, 0
+	for i := 0; i < w.count; i++ {
+		w.addSums(w.xs[i], w.ys[i], 1)
+	}
+}
+
+func (w *correlationWindow) reset() {
+	w.start = 0
+	w.count = 0
+	w.sumX, w.sumY, w.sumXY, w.sumXX, w.sumYY = 0, 0, 0, 0, 0
+}
+
+// correlationVarianceEpsilon is the relative variance below which a field is considered constant.
+const correlationVarianceEpsilon = 1e-12
+
+// coefficient returns the Pearson correlation coefficient of the pairs in the window.
+// Returns false if the window has fewer than two pairs or either field has no variance.
+func (w *correlationWindow) coefficient() (float64, bool) {
+	if w.count < 2 {
+		return 0, false
+	}
+	n := float64(w.count)
+	// n times the sums of squared deviations, and of the products of deviations, from the means.
+	varX := n*w.sumXX - w.sumX*w.sumX
+	varY := n*w.sumYY - w.sumY*w.sumY
+	cov := n*w.sumXY - w.sumX*w.sumY
+	// Rounding errors leave a small variance for constant values, compare it to the magnitude of the values.
+	if varX <= correlationVarianceEpsilon*n*w.sumXX || varY <= correlationVarianceEpsilon*n*w.sumYY {
+		return 0, false
+	}
+	r := cov / math.Sqrt(varX*varY)
+	// Rounding errors can push the coefficient of perfectly correlated values slightly out of range.
+	return math.Max(-1, math.Min(1, r)), true
+}
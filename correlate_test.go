@@ -0,0 +1,42 @@
+package kapacitor
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestCorrelationWindow_RunningSums(t *testing.T) {
+	// Compare the running sums of large values with the coefficient computed directly after many rotations of the window.
+	const size = 50
+	w := newCorrelationWindow(size)
+	rnd := rand.New(rand.NewSource(1))
+	var xs, ys []float64
+	for i := 0; i < 10*size+7; i++ {
+		x := 1e6 + rnd.Float64()
+		y := 2*x + rnd.NormFloat64()
+		w.add(x, y)
+		xs = append(xs, x)
+		ys = append(ys, y)
+	}
+	xs, ys = xs[len(xs)-size:], ys[len(ys)-size:]
+	var mx, my float64
+	for i := range xs {
+		mx += xs[i] / size
+		my += ys[i] / size
+	}
+	var cov, vx, vy float64
+	for i := range xs {
+		cov += (xs[i] - mx) * (ys[i] - my)
+		vx += (xs[i] - mx) * (xs[i] - mx)
+		vy += (ys[i] - my) * (ys[i] - my)
+	}
+	exp := cov / math.Sqrt(vx*vy)
+	got, ok := w.coefficient()
+	if !ok {
+		t.Fatal("expected coefficient")
+	}
+	if math.Abs(got-exp) > 1e-9 {
+		t.Errorf("unexpected coefficient: got %v exp %v", got, exp)
+	}
+}
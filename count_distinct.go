@@ -0,0 +1,188 @@
+package kapacitor
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/hll"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+type CountDistinctNode struct {
+	node
+	c *pipeline.CountDistinctNode
+}
+
+// Create a new CountDistinctNode which estimates the number of distinct values per window with HyperLogLog.
+func newCountDistinctNode(et *ExecutingTask, n *pipeline.CountDistinctNode, d NodeDiagnostic) (*CountDistinctNode, error) {
+	if _, err := hll.New(int(n.Precision)); err != nil {
+		return nil, err
+	}
+	cn := &CountDistinctNode{
+		node: node{Node: n, et: et, diag: d},
+		c:    n,
+	}
+	cn.node.runF = cn.runCountDistinct
+	return cn, nil
+}
+
+func (n *CountDistinctNode) runCountDistinct([]byte) error {
+	consumer := edge.NewGroupedConsumer(
+		n.ins[0],
+		n,
+	)
+	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
+	return consumer.Consume()
+}
+
+func (n *CountDistinctNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	g := &countDistinctGroup{
+		n:     n,
+		name:  first.Name(),
+		group: group,
+	}
+	return edge.NewReceiverFromForwardReceiverWithStats(
+		n.outs,
+		edge.NewTimedForwardReceiver(n.timer, g),
+	), nil
+}
+
+type countDistinctGroup struct {
+	n     *CountDistinctNode
+	name  string
+	group edge.GroupInfo
+
+	// sketch of the current window, allocated on the first value
+	sketch *hll.Sketch
+	// whether a value was added to the sketch since the last reset
+	added bool
+
+	// time of the current batch
+	batchTime time.Time
+	// end of the current interval window of a stream
+	windowEnd time.Time
+
+	// buffer for encoding values
+	buf []byte
+}
+
+// add adds the value of the counted field or tag to the sketch.
+func (g *countDistinctGroup) add(p edge.FieldsTagsTimeGetter) {
+	var ok bool
+	var err error
+	g.buf, ok, err = appendFieldOrTag(g.buf[:0], p, g.n.c.Field)
+	if err != nil {
+		g.n.diag.Error("cannot count distinct values", err)
+		return
+	}
+	if !ok {
+		return
+	}
+	if g.sketch == nil {
+		// The precision was validated when creating the node.
+		g.sketch, _ = hll.New(int(g.n.c.Precision))
+	}
+	g.sketch.Add(g.buf)
+	g.added = true
+}
+
+// appendFieldOrTag appends the value of the field with the given name to buf,
+// or the value of the tag with that name if p has no such field.
+// Values of different types are distinct.
+// Returns false if p has neither.
+func appendFieldOrTag(buf []byte, p edge.FieldsTagsTimeGetter, name string) ([]byte, bool, error) {
+	if v, ok := p.Fields()[name]; ok {
+		switch v := v.(type) {
+		case float64:
+			buf = strconv.AppendFloat(append(buf, 'f'), v, 'g', -1, 64)
+		case int64:
+			buf = strconv.AppendInt(append(buf, 'i'), v, 10)
+		case string:
+			buf = append(append(buf, 's'), v...)
+		case bool:
+			buf = strconv.AppendBool(append(buf, 'b'), v)
+		default:
+			return buf, false, fmt.Errorf("field %q has unsupported type %T", name, v)
+		}
+		return buf, true, nil
+	}
+	if v, ok := p.Tags()[name]; ok {
+		return append(append(buf, 's'), v...), true, nil
+	}
+	return buf, false, nil
+}
+
+// emit returns a point containing the estimate of the current window and resets the window.
+// A nil message is returned if no values were counted in the window.
+func (g *countDistinctGroup) emit(t time.Time) edge.Message {
+	if !g.added {
+		return nil
+	}
+	count := g.sketch.Count()
+	g.sketch.Reset()
+	g.added = false
+	return edge.NewPointMessage(
+		g.name, "", "",
+		g.group.Dimensions,
+		models.Fields{g.n.c.As: int64(count)},
+		g.group.Tags,
+		t,
+	)
+}
+
+func (g *countDistinctGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	g.name = begin.Name()
+	g.batchTime = begin.Time()
+	if g.sketch != nil {
+		g.sketch.Reset()
+	}
+	g.added = false
+	return nil, nil
+}
+
+func (g *countDistinctGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	g.add(bp)
+	return nil, nil
+}
+
+func (g *countDistinctGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return g.emit(g.batchTime), nil
+}
+
+// Point adds the point to the current window.
+// If an interval is set and the point is past the end of the current window,
+// the window is closed first and its estimate returned.
+func (g *countDistinctGroup) Point(p edge.PointMessage) (edge.Message, error) {
+	g.name = p.Name()
+	var m edge.Message
+	if interval := g.n.c.Interval; interval > 0 {
+		if !p.Time().Before(g.windowEnd) {
+			m = g.emit(g.windowEnd)
+			g.windowEnd = p.Time().Truncate(interval).Add(interval)
+		}
+	}
+	g.add(p)
+	return m, nil
+}
+
+// Barrier closes the current window, replacing the barrier with the estimate of the window if any.
+func (g *countDistinctGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	if m := g.emit(b.Time()); m != nil {
+		if err := edge.Forward(g.n.outs, m); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+func (g *countDistinctGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	g.sketch = nil
+	g.added = false
+	g.buf = nil
+	return d, nil
+}
+
+func (g *countDistinctGroup) Done() {}
@@ -0,0 +1,192 @@
+package kapacitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/influxdata/kapacitor/alert"
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/influxdb"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/pkg/errors"
+)
+
+const (
+	statDeadLetters = "dead_letters"
+)
+
+// DeadLetter is a point a node failed to process, annotated with the cause of the failure.
+type DeadLetter struct {
+	// Time the point was sent to the dead letter queue.
+	Time  time.Time `json:"time"`
+	Task  string    `json:"task"`
+	Node  string    `json:"node"`
+	Error string    `json:"error"`
+
+	// The point that failed to be processed.
+	Name      string        `json:"name"`
+	Tags      models.Tags   `json:"tags,omitempty"`
+	Fields    models.Fields `json:"fields"`
+	PointTime time.Time     `json:"pointTime"`
+}
+
+// DeadLetterQueue receives the points nodes failed to process.
+type DeadLetterQueue interface {
+	Add(DeadLetter) error
+	Close() error
+}
+
+// deadLetterQueue returns the dead letter queue of the task for the sink and target,
+// creating it if it does not exist yet.
+func (et *ExecutingTask) deadLetterQueue(sink, target string) (DeadLetterQueue, error) {
+	key := sink + ":" + target
+	if q, ok := et.deadLetters[key]; ok {
+		return q, nil
+	}
+	var q DeadLetterQueue
+	switch sink {
+	case pipeline.DeadLetterFile:
+		f, err := os.OpenFile(target, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0640)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to open dead letter file")
+		}
+		q = &fileDeadLetterQueue{f: f}
+	case pipeline.DeadLetterInfluxDB:
+		db, rp, measurement, err := pipeline.SplitDeadLetterMeasurement(target)
+		if err != nil {
+			return nil, err
+		}
+		if et.tm.InfluxDBService == nil {
+			return nil, errors.New("cannot use the influxdb dead letter queue, InfluxDB is not configured")
+		}
+		cli, err := et.tm.InfluxDBService.NewNamedClient("")
+		if err != nil {
+			return nil, err
+		}
+		q = &influxDBDeadLetterQueue{
+			cli:         cli,
+			database:    db,
+			rp:          rp,
+			measurement: measurement,
+		}
+	case pipeline.DeadLetterTopic:
+		q = &topicDeadLetterQueue{et: et, topic: target}
+	default:
+		return nil, fmt.Errorf("unknown dead letter sink %q", sink)
+	}
+	if et.deadLetters == nil {
+		et.deadLetters = make(map[string]DeadLetterQueue)
+	}
+	et.deadLetters[key] = q
+	return q, nil
+}
+
+// closeDeadLetterQueues closes the dead letter queues of the task.
+func (et *ExecutingTask) closeDeadLetterQueues() {
+	for key, q := range et.deadLetters {
+		if err := q.Close(); err != nil {
+			et.diag.Error("failed to close dead letter queue", err)
+		}
+		delete(et.deadLetters, key)
+	}
+}
+
+// fileDeadLetterQueue appends dead letters as JSON lines to a file.
+type fileDeadLetterQueue struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+func (q *fileDeadLetterQueue) Add(l DeadLetter) error {
+	b, err := json.Marshal(l)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode dead letter")
+	}
+	b = append(b, '\n')
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	_, err = q.f.Write(b)
+	return err
+}
+
+func (q *fileDeadLetterQueue) Close() error {
+	return q.f.Close()
+}
+
+// influxDBDeadLetterQueue writes dead letters to a measurement of the default InfluxDB cluster.
+// The point keeps its tags and fields,
+// the original measurement, the task and the node are added as tags and the error as a field.
+type influxDBDeadLetterQueue struct {
+	cli         influxdb.Client
+	database    string
+	rp          string
+	measurement string
+}
+
+func (q *influxDBDeadLetterQueue) Add(l DeadLetter) error {
+	tags := make(map[string]string, len(l.Tags)+3)
+	for k, v := range l.Tags {
+		tags[k] = v
+	}
+	tags["measurement"] = l.Name
+	tags["task"] = l.Task
+	tags["node"] = l.Node
+	fields := make(map[string]interface{}, len(l.Fields)+1)
+	for k, v := range l.Fields {
+		fields[k] = v
+	}
+	fields["error"] = l.Error
+
+	bp, err := influxdb.NewBatchPoints(influxdb.BatchPointsConfig{
+		Database:        q.database,
+		RetentionPolicy: q.rp,
+	})
+	if err != nil {
+		return err
+	}
+	bp.AddPoint(influxdb.Point{
+		Name:   q.measurement,
+		Tags:   tags,
+		Fields: fields,
+		Time:   l.PointTime,
+	})
+	return q.cli.Write(bp)
+}
+
+func (q *influxDBDeadLetterQueue) Close() error {
+	return nil
+}
+
+// topicDeadLetterQueue publishes dead letters as alert events to a topic.
+type topicDeadLetterQueue struct {
+	et    *ExecutingTask
+	topic string
+}
+
+func (q *topicDeadLetterQueue) Add(l DeadLetter) error {
+	p := edge.NewPointMessage(l.Name, "", "", models.Dimensions{}, l.Fields, l.Tags, l.PointTime)
+	return q.et.tm.AlertService.Collect(alert.Event{
+		Topic: q.topic,
+		State: alert.EventState{
+			ID:      l.Task + ":" + l.Node,
+			Message: l.Error,
+			Time:    l.Time,
+			Level:   alert.Warning,
+		},
+		Data: alert.EventData{
+			Name:     l.Name,
+			TaskName: l.Task,
+			Tags:     l.Tags,
+			Fields:   l.Fields,
+			Result:   p.ToResult(),
+		},
+	})
+}
+
+func (q *topicDeadLetterQueue) Close() error {
+	return nil
+}
@@ -0,0 +1,58 @@
+package kapacitor
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+func TestExecutingTask_DeadLetterQueue(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "TestExecutingTask_DeadLetterQueue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	path := filepath.Join(tmpDir, "dead_letters.log")
+
+	et := &ExecutingTask{Task: &Task{ID: "task"}}
+	q1, err := et.deadLetterQueue(pipeline.DeadLetterFile, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q2, err := et.deadLetterQueue(pipeline.DeadLetterFile, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q1 != q2 {
+		t.Error("expected the queue to be shared by the nodes of the task")
+	}
+
+	l := DeadLetter{
+		Time:      time.Date(2018, 1, 1, 0, 0, 1, 0, time.UTC),
+		Task:      "task",
+		Node:      "eval2",
+		Error:     "no field or tag exists for value",
+		Name:      "cpu",
+		Tags:      models.Tags{"host": "serverA"},
+		Fields:    models.Fields{"idle": 42.0},
+		PointTime: time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	if err := q1.Add(l); err != nil {
+		t.Fatal(err)
+	}
+	et.closeDeadLetterQueues()
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := `{"time":"2018-01-01T00:00:01Z","task":"task","node":"eval2","error":"no field or tag exists for value","name":"cpu","tags":{"host":"serverA"},"fields":{"idle":42},"pointTime":"2018-01-01T00:00:00Z"}` + "\n"
+	if got := string(data); got != exp {
+		t.Errorf("unexpected dead letter file:\ngot %s\nexp %s", got, exp)
+	}
+}
@@ -0,0 +1,131 @@
+package kapacitor
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/keyvalue"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+const (
+	statsSuppressed = "suppressed"
+)
+
+type DeadbandNode struct {
+	node
+	d *pipeline.DeadbandNode
+
+	suppressed *expvar.Int
+}
+
+// Create a new DeadbandNode which suppresses points within a deadband around the last emitted value.
+func newDeadbandNode(et *ExecutingTask, n *pipeline.DeadbandNode, d NodeDiagnostic) (*DeadbandNode, error) {
+	dn := &DeadbandNode{
+		node:       node{Node: n, et: et, diag: d},
+		d:          n,
+		suppressed: new(expvar.Int),
+	}
+	dn.node.runF = dn.runDeadband
+	return dn, nil
+}
+
+func (n *DeadbandNode) runDeadband([]byte) error {
+	n.statMap.Set(statsSuppressed, n.suppressed)
+	consumer := edge.NewGroupedConsumer(
+		n.ins[0],
+		n,
+	)
+	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
+	return consumer.Consume()
+}
+
+func (n *DeadbandNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	return edge.NewReceiverFromForwardReceiverWithStats(
+		n.outs,
+		edge.NewTimedForwardReceiver(n.timer, n.newGroup()),
+	), nil
+}
+
+func (n *DeadbandNode) newGroup() *deadbandGroup {
+	return &deadbandGroup{
+		n: n,
+	}
+}
+
+// width returns the width of the deadband on either side of the last emitted value.
+func (n *DeadbandNode) width(last float64) float64 {
+	if n.d.Percent > 0 {
+		return math.Abs(last) * n.d.Percent / 100
+	}
+	return n.d.Absolute
+}
+
+type deadbandGroup struct {
+	n *DeadbandNode
+
+	// last is the value of the last emitted point, valid if emitted is set.
+	last    float64
+	emitted bool
+}
+
+func (g *deadbandGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	if s := begin.SizeHint(); s > 0 {
+		begin = begin.ShallowCopy()
+		begin.SetSizeHint(0)
+	}
+	return begin, nil
+}
+
+func (g *deadbandGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	if g.emit(bp) {
+		return bp, nil
+	}
+	return nil, nil
+}
+
+func (g *deadbandGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (g *deadbandGroup) Point(p edge.PointMessage) (edge.Message, error) {
+	if g.emit(p) {
+		return p, nil
+	}
+	return nil, nil
+}
+
+// emit reports whether the point is outside of the deadband around the last emitted value,
+// in which case its value becomes the last emitted value.
+func (g *deadbandGroup) emit(p edge.FieldsTagsTimeGetter) bool {
+	var value float64
+	switch v := p.Fields()[g.n.d.Field].(type) {
+	case float64:
+		value = v
+	case int64:
+		value = float64(v)
+	default:
+		g.n.diag.Error("invalid field in deadband",
+			fmt.Errorf("expected numeric field %s, got %T", g.n.d.Field, v),
+			keyvalue.KV("field", g.n.d.Field))
+		return false
+	}
+	if g.emitted && !(math.Abs(value-g.last) > g.n.width(g.last)) {
+		g.n.suppressed.Add(1)
+		return false
+	}
+	g.last = value
+	g.emitted = true
+	return true
+}
+
+func (g *deadbandGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (g *deadbandGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	g.emitted = false
+	return d, nil
+}
+func (g *deadbandGroup) Done() {}
@@ -0,0 +1,104 @@
+package kapacitor
+
+import (
+	"math"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+type DecimalsNode struct {
+	node
+	d *pipeline.DecimalsNode
+
+	round func(float64) float64
+}
+
+// Create a new DecimalsNode which rounds or truncates float fields to a fixed number of decimals.
+func newDecimalsNode(et *ExecutingTask, n *pipeline.DecimalsNode, d NodeDiagnostic) (*DecimalsNode, error) {
+	dn := &DecimalsNode{
+		node:  node{Node: n, et: et, diag: d},
+		d:     n,
+		round: math.RoundToEven,
+	}
+	if n.HalfUpFlag {
+		dn.round = math.Round
+	}
+	dn.node.runF = dn.runDecimals
+	return dn, nil
+}
+
+func (n *DecimalsNode) runDecimals(snapshot []byte) error {
+	consumer := edge.NewConsumerWithReceiver(
+		n.ins[0],
+		edge.NewReceiverFromForwardReceiverWithStats(
+			n.outs,
+			edge.NewTimedForwardReceiver(n.timer, n),
+		),
+	)
+	return consumer.Consume()
+}
+
+// toDecimals applies f to v scaled by 10^decimals.
+// Values too large to be scaled are returned unchanged, they have no decimals to drop.
+func toDecimals(v float64, decimals int64, f func(float64) float64) float64 {
+	scale := math.Pow10(int(decimals))
+	scaled := v * scale
+	if math.IsInf(scaled, 0) || math.IsNaN(scaled) {
+		return v
+	}
+	return f(scaled) / scale
+}
+
+// apply rounds and truncates the float fields, the fields are copied before they are modified.
+func (n *DecimalsNode) apply(fields models.Fields) models.Fields {
+	newFields := fields
+	copied := false
+	set := func(name string, v float64) {
+		if !copied {
+			newFields = newFields.Copy()
+			copied = true
+		}
+		newFields[name] = v
+	}
+	for name, decimals := range n.d.RoundFields {
+		if v, ok := fields[name].(float64); ok {
+			set(name, toDecimals(v, decimals, n.round))
+		}
+	}
+	for name, decimals := range n.d.TruncateFields {
+		if v, ok := fields[name].(float64); ok {
+			set(name, toDecimals(v, decimals, math.Trunc))
+		}
+	}
+	return newFields
+}
+
+func (n *DecimalsNode) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	return begin, nil
+}
+
+func (n *DecimalsNode) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	bp = bp.ShallowCopy()
+	bp.SetFields(n.apply(bp.Fields()))
+	return bp, nil
+}
+
+func (n *DecimalsNode) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (n *DecimalsNode) Point(p edge.PointMessage) (edge.Message, error) {
+	p = p.ShallowCopy()
+	p.SetFields(n.apply(p.Fields()))
+	return p, nil
+}
+
+func (n *DecimalsNode) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (n *DecimalsNode) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (n *DecimalsNode) Done() {}
@@ -0,0 +1,40 @@
+package kapacitor
+
+import (
+	"math"
+	"testing"
+)
+
+func TestToDecimals(t *testing.T) {
+	testCases := []struct {
+		name     string
+		v        float64
+		decimals int64
+		f        func(float64) float64
+		exp      float64
+	}{
+		{name: "round", v: 3.14159, decimals: 2, f: math.RoundToEven, exp: 3.14},
+		{name: "round up", v: 2.71828, decimals: 3, f: math.RoundToEven, exp: 2.718},
+		{name: "round zero decimals", v: 2.6, decimals: 0, f: math.RoundToEven, exp: 3},
+		{name: "round negative", v: -3.14159, decimals: 2, f: math.RoundToEven, exp: -3.14},
+		{name: "half to even down", v: 0.125, decimals: 2, f: math.RoundToEven, exp: 0.12},
+		{name: "half to even up", v: 0.375, decimals: 2, f: math.RoundToEven, exp: 0.38},
+		{name: "half to even zero decimals", v: 2.5, decimals: 0, f: math.RoundToEven, exp: 2},
+		{name: "half to even negative", v: -0.125, decimals: 2, f: math.RoundToEven, exp: -0.12},
+		{name: "half to even negative zero decimals", v: -3.5, decimals: 0, f: math.RoundToEven, exp: -4},
+		{name: "half up", v: 0.125, decimals: 2, f: math.Round, exp: 0.13},
+		{name: "half up zero decimals", v: 2.5, decimals: 0, f: math.Round, exp: 3},
+		{name: "half up negative", v: -0.125, decimals: 2, f: math.Round, exp: -0.13},
+		{name: "truncate", v: 1.99, decimals: 1, f: math.Trunc, exp: 1.9},
+		{name: "truncate negative", v: -1.99, decimals: 1, f: math.Trunc, exp: -1.9},
+		{name: "truncate zero decimals", v: -7.9, decimals: 0, f: math.Trunc, exp: -7},
+		{name: "too large to scale", v: math.MaxFloat64, decimals: 2, f: math.RoundToEven, exp: math.MaxFloat64},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := toDecimals(tc.v, tc.decimals, tc.f); got != tc.exp {
+				t.Errorf("unexpected value: got %v exp %v", got, tc.exp)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package kapacitor
+
+import (
+	"sort"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/keyvalue"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+const (
+	statsUnmatched = "unmatched"
+)
+
+type DeriveTagNode struct {
+	node
+	d *pipeline.DeriveTagNode
+
+	// index of the capture group in the regex
+	capture int
+
+	// dimensions of the current batch
+	batchDims models.Dimensions
+
+	unmatched *expvar.Int
+}
+
+// Create a new DeriveTagNode which sets a tag from a capture group of a regex matched against another tag or field.
+func newDeriveTagNode(et *ExecutingTask, n *pipeline.DeriveTagNode, d NodeDiagnostic) (*DeriveTagNode, error) {
+	dn := &DeriveTagNode{
+		node:      node{Node: n, et: et, diag: d},
+		d:         n,
+		capture:   n.Pattern.SubexpIndex(n.Capture),
+		unmatched: new(expvar.Int),
+	}
+	dn.node.runF = dn.runDeriveTag
+	return dn, nil
+}
+
+func (n *DeriveTagNode) runDeriveTag(snapshot []byte) error {
+	n.statMap.Set(statsUnmatched, n.unmatched)
+	consumer := edge.NewConsumerWithReceiver(
+		n.ins[0],
+		edge.NewReceiverFromForwardReceiverWithStats(
+			n.outs,
+			edge.NewTimedForwardReceiver(n.timer, n),
+		),
+	)
+	return consumer.Consume()
+}
+
+func (n *DeriveTagNode) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	n.batchDims = begin.Dimensions()
+	return begin, nil
+}
+
+func (n *DeriveTagNode) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	tags, ok := n.derive(bp.Fields(), bp.Tags(), n.batchDims)
+	if !ok {
+		return bp, nil
+	}
+	bp = bp.ShallowCopy()
+	bp.SetTags(tags)
+	return bp, nil
+}
+
+func (n *DeriveTagNode) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (n *DeriveTagNode) Point(p edge.PointMessage) (edge.Message, error) {
+	dims := p.Dimensions()
+	tags, ok := n.derive(p.Fields(), p.Tags(), dims)
+	if !ok {
+		tags = p.Tags()
+	}
+	_, set := tags[n.d.Tag]
+	regroup := set && n.d.RegroupFlag && !isDimension(n.d.Tag, dims)
+	if !ok && !regroup {
+		return p, nil
+	}
+	p = p.ShallowCopy()
+	if regroup {
+		tagNames := make([]string, len(dims.TagNames), len(dims.TagNames)+1)
+		copy(tagNames, dims.TagNames)
+		tagNames = append(tagNames, n.d.Tag)
+		sort.Strings(tagNames)
+		dims = models.Dimensions{
+			TagNames: tagNames,
+			ByName:   dims.ByName,
+		}
+	}
+	p.SetTagsAndDimensions(tags, dims)
+	return p, nil
+}
+
+func (n *DeriveTagNode) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (n *DeriveTagNode) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (n *DeriveTagNode) Done() {}
+
+// derive returns a copy of tags with the derived tag set,
+// or removed if the source does not match and there is no missing value.
+// Returns false if the tags are unchanged.
+func (n *DeriveTagNode) derive(fields models.Fields, tags models.Tags, dims models.Dimensions) (models.Tags, bool) {
+	value, ok := n.match(fields, tags)
+	if !ok {
+		n.unmatched.Add(1)
+		value = n.d.Missing
+	}
+	if value == "" {
+		if _, exists := tags[n.d.Tag]; !exists || isDimension(n.d.Tag, dims) {
+			return nil, false
+		}
+		tags = tags.Copy()
+		delete(tags, n.d.Tag)
+		return tags, true
+	}
+	if tags[n.d.Tag] == value {
+		return nil, false
+	}
+	tags = tags.Copy()
+	tags[n.d.Tag] = value
+	return tags, true
+}
+
+// match returns the value of the capture group for the source tag or field.
+// Returns false if the source is missing or does not match.
+func (n *DeriveTagNode) match(fields models.Fields, tags models.Tags) (string, bool) {
+	var source string
+	if n.d.FromTag != "" {
+		v, ok := tags[n.d.FromTag]
+		if !ok {
+			return "", false
+		}
+		source = v
+	} else {
+		v, ok := fields[n.d.FromField]
+		if !ok {
+			return "", false
+		}
+		s, err := fieldTagValue(v)
+		if err != nil {
+			n.diag.Error("cannot match field", err,
+				keyvalue.KV("field", n.d.FromField),
+			)
+			return "", false
+		}
+		source = s
+	}
+	m := n.d.Pattern.FindStringSubmatchIndex(source)
+	if m == nil || m[2*n.capture] < 0 {
+		return "", false
+	}
+	return source[m[2*n.capture]:m[2*n.capture+1]], true
+}
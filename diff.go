@@ -0,0 +1,107 @@
+package kapacitor
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+type DiffNode struct {
+	node
+	d *pipeline.DiffNode
+}
+
+// Create a new DiffNode which computes the difference between two fields.
+func newDiffNode(et *ExecutingTask, n *pipeline.DiffNode, d NodeDiagnostic) (*DiffNode, error) {
+	dn := &DiffNode{
+		node: node{Node: n, et: et, diag: d},
+		d:    n,
+	}
+	dn.node.runF = dn.runDiff
+	return dn, nil
+}
+
+func (n *DiffNode) runDiff([]byte) error {
+	consumer := edge.NewConsumerWithReceiver(
+		n.ins[0],
+		edge.NewReceiverFromForwardReceiverWithStats(
+			n.outs,
+			edge.NewTimedForwardReceiver(n.timer, n),
+		),
+	)
+	return consumer.Consume()
+}
+
+// diff returns the fields with the difference, absolute difference and percent change of the compared fields added.
+func (n *DiffNode) diff(fields models.Fields) (models.Fields, error) {
+	value, ok := numToFloat(fields[n.d.Field])
+	if !ok {
+		return nil, fmt.Errorf("field %q is missing or not numeric", n.d.Field)
+	}
+	baseline, ok := numToFloat(fields[n.d.Baseline])
+	if !ok {
+		return nil, fmt.Errorf("field %q is missing or not numeric", n.d.Baseline)
+	}
+	diff := value - baseline
+	fields = fields.Copy()
+	fields[n.d.DifferenceAs] = diff
+	fields[n.d.AbsoluteAs] = math.Abs(diff)
+	switch {
+	case baseline != 0:
+		fields[n.d.PercentAs] = diff / math.Abs(baseline) * 100
+	case n.d.UseZeroBaseline:
+		fields[n.d.PercentAs] = n.d.ZeroBaselineValue
+	default:
+		// The percent change is undefined, remove any existing field of the same name.
+		delete(fields, n.d.PercentAs)
+	}
+	return fields, nil
+}
+
+func (n *DiffNode) doDiff(p edge.FieldsTagsTimeGetter, s edge.FieldSetter) bool {
+	fields, err := n.diff(p.Fields())
+	if err != nil {
+		n.diag.Error("cannot compute difference", err)
+		return false
+	}
+	s.SetFields(fields)
+	return true
+}
+
+func (n *DiffNode) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	// Points may be dropped, so the size of the batch is not known.
+	begin = begin.ShallowCopy()
+	begin.SetSizeHint(0)
+	return begin, nil
+}
+
+func (n *DiffNode) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	np := bp.ShallowCopy()
+	if n.doDiff(bp, np) {
+		return np, nil
+	}
+	return nil, nil
+}
+
+func (n *DiffNode) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (n *DiffNode) Point(p edge.PointMessage) (edge.Message, error) {
+	np := p.ShallowCopy()
+	if n.doDiff(p, np) {
+		return np, nil
+	}
+	return nil, nil
+}
+
+func (n *DiffNode) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (n *DiffNode) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (n *DiffNode) Done() {}
@@ -0,0 +1,249 @@
+package kapacitor
+
+import (
+	"path"
+	"regexp"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+const (
+	statsTagsDropped   = "tags_dropped"
+	statsFieldsDropped = "fields_dropped"
+)
+
+// nameMatcher matches names against a set of glob patterns and regular expressions.
+// Results are cached per name since the set of distinct names is typically small.
+type nameMatcher struct {
+	patterns []string
+	regexes  []*regexp.Regexp
+	cache    map[string]bool
+}
+
+func newNameMatcher(patterns []string, regexes []*regexp.Regexp) *nameMatcher {
+	return &nameMatcher{
+		patterns: patterns,
+		regexes:  regexes,
+		cache:    make(map[string]bool),
+	}
+}
+
+// Match reports whether name matches any of the patterns or regexes.
+func (m *nameMatcher) Match(name string) bool {
+	if matched, ok := m.cache[name]; ok {
+		return matched
+	}
+	matched := m.match(name)
+	m.cache[name] = matched
+	return matched
+}
+
+func (m *nameMatcher) match(name string) bool {
+	for _, p := range m.patterns {
+		// Patterns have been validated by the pipeline, so errors cannot occur.
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	for _, r := range m.regexes {
+		if r.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+type DropTagsNode struct {
+	node
+	d *pipeline.DropTagsNode
+
+	matcher *nameMatcher
+
+	// dimensions of the current batch
+	batchDims models.Dimensions
+
+	tagsDropped *expvar.Int
+}
+
+// Create a new DropTagsNode which removes matching tags from each point.
+func newDropTagsNode(et *ExecutingTask, n *pipeline.DropTagsNode, d NodeDiagnostic) (*DropTagsNode, error) {
+	dn := &DropTagsNode{
+		node:        node{Node: n, et: et, diag: d},
+		d:           n,
+		matcher:     newNameMatcher(n.Tags, n.Regexes),
+		tagsDropped: new(expvar.Int),
+	}
+	dn.node.runF = dn.runDropTags
+	return dn, nil
+}
+
+func (n *DropTagsNode) runDropTags(snapshot []byte) error {
+	n.statMap.Set(statsTagsDropped, n.tagsDropped)
+	consumer := edge.NewConsumerWithReceiver(
+		n.ins[0],
+		edge.NewReceiverFromForwardReceiverWithStats(
+			n.outs,
+			edge.NewTimedForwardReceiver(n.timer, n),
+		),
+	)
+	return consumer.Consume()
+}
+
+func (n *DropTagsNode) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	begin = begin.ShallowCopy()
+	n.batchDims = begin.Dimensions()
+	tags, dims := n.doDrops(begin.Tags(), n.batchDims)
+	begin.SetTagsAndDimensions(tags, dims)
+	return begin, nil
+}
+
+func (n *DropTagsNode) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	bp = bp.ShallowCopy()
+	tags, _ := n.doDrops(bp.Tags(), n.batchDims)
+	bp.SetTags(tags)
+	return bp, nil
+}
+
+func (n *DropTagsNode) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (n *DropTagsNode) Point(p edge.PointMessage) (edge.Message, error) {
+	p = p.ShallowCopy()
+	tags, dims := n.doDrops(p.Tags(), p.Dimensions())
+	p.SetTagsAndDimensions(tags, dims)
+	return p, nil
+}
+
+func (n *DropTagsNode) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (n *DropTagsNode) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (n *DropTagsNode) Done() {}
+
+// doDrops removes all matching tags.
+// Tags that are dimensions are only removed if regrouping,
+// in which case they are removed from the dimensions as well.
+func (n *DropTagsNode) doDrops(tags models.Tags, dims models.Dimensions) (models.Tags, models.Dimensions) {
+	newTags := tags
+	tagsCopied := false
+	for tag := range tags {
+		if !n.matcher.Match(tag) {
+			continue
+		}
+		if !n.d.RegroupFlag && isDimension(tag, dims) {
+			continue
+		}
+		if !tagsCopied {
+			newTags = newTags.Copy()
+			tagsCopied = true
+		}
+		n.tagsDropped.Add(1)
+		delete(newTags, tag)
+	}
+	if !n.d.RegroupFlag || !tagsCopied {
+		return newTags, dims
+	}
+	newTagNames := make([]string, 0, len(dims.TagNames))
+	for _, dim := range dims.TagNames {
+		if _, ok := newTags[dim]; ok {
+			newTagNames = append(newTagNames, dim)
+		}
+	}
+	return newTags, models.Dimensions{
+		TagNames: newTagNames,
+		ByName:   dims.ByName,
+	}
+}
+
+func isDimension(tag string, dims models.Dimensions) bool {
+	for _, dim := range dims.TagNames {
+		if dim == tag {
+			return true
+		}
+	}
+	return false
+}
+
+type DropFieldsNode struct {
+	node
+	d *pipeline.DropFieldsNode
+
+	matcher *nameMatcher
+
+	fieldsDropped *expvar.Int
+}
+
+// Create a new DropFieldsNode which removes matching fields from each point.
+func newDropFieldsNode(et *ExecutingTask, n *pipeline.DropFieldsNode, d NodeDiagnostic) (*DropFieldsNode, error) {
+	dn := &DropFieldsNode{
+		node:          node{Node: n, et: et, diag: d},
+		d:             n,
+		matcher:       newNameMatcher(n.Fields, n.Regexes),
+		fieldsDropped: new(expvar.Int),
+	}
+	dn.node.runF = dn.runDropFields
+	return dn, nil
+}
+
+func (n *DropFieldsNode) runDropFields(snapshot []byte) error {
+	n.statMap.Set(statsFieldsDropped, n.fieldsDropped)
+	consumer := edge.NewConsumerWithReceiver(
+		n.ins[0],
+		edge.NewReceiverFromForwardReceiverWithStats(
+			n.outs,
+			edge.NewTimedForwardReceiver(n.timer, n),
+		),
+	)
+	return consumer.Consume()
+}
+
+func (n *DropFieldsNode) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	return begin, nil
+}
+
+func (n *DropFieldsNode) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	bp = bp.ShallowCopy()
+	bp.SetFields(n.doDrops(bp.Fields()))
+	return bp, nil
+}
+
+func (n *DropFieldsNode) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (n *DropFieldsNode) Point(p edge.PointMessage) (edge.Message, error) {
+	p = p.ShallowCopy()
+	p.SetFields(n.doDrops(p.Fields()))
+	return p, nil
+}
+
+func (n *DropFieldsNode) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (n *DropFieldsNode) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (n *DropFieldsNode) Done() {}
+
+func (n *DropFieldsNode) doDrops(fields models.Fields) models.Fields {
+	newFields := fields
+	fieldsCopied := false
+	for field := range fields {
+		if !n.matcher.Match(field) {
+			continue
+		}
+		if !fieldsCopied {
+			newFields = newFields.Copy()
+			fieldsCopied = true
+		}
+		n.fieldsDropped.Add(1)
+		delete(newFields, field)
+	}
+	return newFields
+}
@@ -0,0 +1,152 @@
+package kapacitor
+
+import (
+	"bytes"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+// humanizeUnits are the units of a formatted duration, largest first.
+var humanizeUnits = []string{
+	pipeline.DurationDays,
+	pipeline.DurationHours,
+	pipeline.DurationMinutes,
+	pipeline.DurationSeconds,
+	pipeline.DurationMilliseconds,
+	pipeline.DurationMicroseconds,
+	pipeline.DurationNanoseconds,
+}
+
+type DurationFormatNode struct {
+	node
+	d *pipeline.DurationFormatNode
+
+	unit      time.Duration
+	precision time.Duration
+}
+
+// Create a new DurationFormatNode which formats a numeric duration field as a human readable string field.
+func newDurationFormatNode(et *ExecutingTask, n *pipeline.DurationFormatNode, d NodeDiagnostic) (*DurationFormatNode, error) {
+	dn := &DurationFormatNode{
+		node: node{Node: n, et: et, diag: d},
+		d:    n,
+	}
+	dn.unit, _ = pipeline.DurationFormatUnit(n.Unit)
+	dn.precision, _ = pipeline.DurationFormatUnit(n.Precision)
+	dn.node.runF = dn.runDurationFormat
+	return dn, nil
+}
+
+func (n *DurationFormatNode) runDurationFormat([]byte) error {
+	consumer := edge.NewConsumerWithReceiver(
+		n.ins[0],
+		edge.NewReceiverFromForwardReceiverWithStats(
+			n.outs,
+			edge.NewTimedForwardReceiver(n.timer, n),
+		),
+	)
+	return consumer.Consume()
+}
+
+// duration converts the value of the field to a duration.
+// Returns false if the value is not a number or out of range.
+func (n *DurationFormatNode) duration(v interface{}) (time.Duration, bool) {
+	var f float64
+	switch v := v.(type) {
+	case int64:
+		if n.unit == time.Nanosecond {
+			return time.Duration(v), true
+		}
+		f = float64(v)
+	case float64:
+		f = v
+	default:
+		return 0, false
+	}
+	f *= float64(n.unit)
+	if math.IsNaN(f) || f >= math.MaxInt64 || f < math.MinInt64 {
+		return 0, false
+	}
+	return time.Duration(f), true
+}
+
+// humanizeDuration formats d rounded to the precision, leaving out the units that are zero, e.g. 2h15m.
+func humanizeDuration(d time.Duration, precision string) string {
+	p, _ := pipeline.DurationFormatUnit(precision)
+	d = d.Round(p)
+	if d == 0 {
+		return "0" + precision
+	}
+	var buf bytes.Buffer
+	// The absolute value as unsigned, so that the minimum duration does not overflow.
+	u := uint64(d)
+	if d < 0 {
+		buf.WriteByte('-')
+		u = -u
+	}
+	for _, unit := range humanizeUnits {
+		ud, _ := pipeline.DurationFormatUnit(unit)
+		if c := u / uint64(ud); c > 0 {
+			buf.WriteString(strconv.FormatUint(c, 10))
+			buf.WriteString(unit)
+			u -= c * uint64(ud)
+		}
+		if unit == precision {
+			break
+		}
+	}
+	return buf.String()
+}
+
+// format returns the fields with the formatted duration.
+// Returns false if the point has no duration.
+func (n *DurationFormatNode) format(fields models.Fields) (models.Fields, bool) {
+	d, ok := n.duration(fields[n.d.Field])
+	if !ok {
+		return nil, false
+	}
+	fields = fields.Copy()
+	fields[n.d.As] = humanizeDuration(d, n.d.Precision)
+	return fields, true
+}
+
+func (n *DurationFormatNode) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	return begin, nil
+}
+
+func (n *DurationFormatNode) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	fields, ok := n.format(bp.Fields())
+	if !ok {
+		return bp, nil
+	}
+	bp = bp.ShallowCopy()
+	bp.SetFields(fields)
+	return bp, nil
+}
+
+func (n *DurationFormatNode) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (n *DurationFormatNode) Point(p edge.PointMessage) (edge.Message, error) {
+	fields, ok := n.format(p.Fields())
+	if !ok {
+		return p, nil
+	}
+	p = p.ShallowCopy()
+	p.SetFields(fields)
+	return p, nil
+}
+
+func (n *DurationFormatNode) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (n *DurationFormatNode) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (n *DurationFormatNode) Done() {}
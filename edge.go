@@ -3,16 +3,21 @@ package kapacitor
 import (
 	"errors"
 	"sync"
+	"time"
 
 	"github.com/influxdata/kapacitor/edge"
 	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/models"
 	"github.com/influxdata/kapacitor/pipeline"
 	"github.com/influxdata/kapacitor/server/vars"
 )
 
 const (
-	statCollected = "collected"
-	statEmitted   = "emitted"
+	statCollected           = "collected"
+	statEmitted             = "emitted"
+	statReorderDropped      = "reorder_dropped"
+	statBackpressureDropped = "backpressure_dropped"
+	statOrderViolations     = "order_violations"
 
 	defaultEdgeBufferSize = 1000
 )
@@ -21,6 +26,7 @@ var ErrAborted = errors.New("edged aborted")
 
 type EdgeDiagnostic interface {
 	ClosingEdge(collected, emitted int64)
+	OutOfOrder(group models.GroupID, previous, current time.Time)
 }
 
 type Edge struct {
@@ -34,8 +40,9 @@ type Edge struct {
 	diag     EdgeDiagnostic
 }
 
-func newEdge(taskName, parentName, childName string, t pipeline.EdgeType, size int, d EdgeDiagnostic) edge.StatsEdge {
-	e := edge.NewStatsEdge(edge.NewChannelEdge(t, defaultEdgeBufferSize))
+func newEdge(taskName, parentName, childName string, t pipeline.EdgeType, size int, policy edge.BackpressurePolicy, d EdgeDiagnostic) edge.StatsEdge {
+	dropped := new(expvar.Int)
+	e := edge.NewStatsEdge(edge.NewBackpressureEdge(t, defaultEdgeBufferSize, policy, dropped))
 	tags := map[string]string{
 		"task":   taskName,
 		"parent": parentName,
@@ -45,6 +52,9 @@ func newEdge(taskName, parentName, childName string, t pipeline.EdgeType, size i
 	key, sm := vars.NewStatistic("edges", tags)
 	sm.Set(statCollected, e.CollectedVar())
 	sm.Set(statEmitted, e.EmittedVar())
+	if policy != edge.BackpressureBlock {
+		sm.Set(statBackpressureDropped, dropped)
+	}
 	return &Edge{
 		StatsEdge: e,
 		statsKey:  key,
@@ -53,6 +63,38 @@ func newEdge(taskName, parentName, childName string, t pipeline.EdgeType, size i
 	}
 }
 
+// backpressurePolicy returns the edge policy for the backpressure policy of a pipeline node.
+func backpressurePolicy(policy string) edge.BackpressurePolicy {
+	switch policy {
+	case pipeline.BackpressureDropNewest:
+		return edge.BackpressureDropNewest
+	case pipeline.BackpressureDropOldest:
+		return edge.BackpressureDropOldest
+	default:
+		return edge.BackpressureBlock
+	}
+}
+
+// newReorderEdge wraps the child side of e in a reorder buffer.
+// Points dropped by the buffer are counted in the statistics of e.
+func newReorderEdge(e edge.StatsEdge, lateness time.Duration, size int) edge.StatsEdge {
+	dropped := new(expvar.Int)
+	if ke, ok := e.(*Edge); ok {
+		ke.statMap.Set(statReorderDropped, dropped)
+	}
+	return edge.NewReorderEdge(e, lateness, size, dropped)
+}
+
+// newOrderCheckEdge wraps in, the child side of e, in a check of the order of the points.
+// Violations are counted in the statistics of e and logged to d.
+func newOrderCheckEdge(e, in edge.StatsEdge, d EdgeDiagnostic) edge.StatsEdge {
+	violations := new(expvar.Int)
+	if ke, ok := e.(*Edge); ok {
+		ke.statMap.Set(statOrderViolations, violations)
+	}
+	return edge.NewOrderCheckEdge(in, violations, d.OutOfOrder)
+}
+
 func (e *Edge) Close() error {
 	e.mu.Lock()
 	defer e.mu.Unlock()
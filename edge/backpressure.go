@@ -0,0 +1,150 @@
+package edge
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+// BackpressurePolicy determines what an edge does with a point collected while its buffer is full.
+type BackpressurePolicy int
+
+const (
+	// BackpressureBlock blocks the collecting node until the buffer has room for the point.
+	BackpressureBlock BackpressurePolicy = iota
+	// BackpressureDropNewest drops the collected point.
+	BackpressureDropNewest
+	// BackpressureDropOldest drops the oldest buffered point to make room for the collected point.
+	BackpressureDropOldest
+)
+
+// dropEdge is a stream edge that drops points instead of blocking when its buffer is full.
+// Messages other than points are never dropped, collecting them blocks while the buffer is full.
+type dropEdge struct {
+	typ     pipeline.EdgeType
+	size    int
+	policy  BackpressurePolicy
+	dropped *expvar.Int
+
+	mu sync.Mutex
+	// cond signals changes of the buffer and the state.
+	cond  *sync.Cond
+	buf   []Message
+	state edgeState
+}
+
+// NewBackpressureEdge returns a new edge buffering up to size messages
+// that handles points collected while the buffer is full according to the policy.
+// Dropped points are counted in dropped.
+// With the block policy the edge is the same as a channel edge.
+func NewBackpressureEdge(typ pipeline.EdgeType, size int, policy BackpressurePolicy, dropped *expvar.Int) Edge {
+	if policy == BackpressureBlock {
+		return NewChannelEdge(typ, size)
+	}
+	if size < 1 {
+		size = 1
+	}
+	e := &dropEdge{
+		typ:     typ,
+		size:    size,
+		policy:  policy,
+		dropped: dropped,
+		buf:     make([]Message, 0, size),
+		state:   edgeOpen,
+	}
+	e.cond = sync.NewCond(&e.mu)
+	return e
+}
+
+func (e *dropEdge) Collect(m Message) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for {
+		switch e.state {
+		case edgeAborted:
+			return ErrAborted
+		case edgeClosed:
+			panic("collect on closed edge")
+		}
+		if len(e.buf) < e.size {
+			e.buf = append(e.buf, m)
+			e.cond.Broadcast()
+			return nil
+		}
+		if _, ok := m.(PointMessage); ok {
+			switch e.policy {
+			case BackpressureDropNewest:
+				e.dropped.Add(1)
+				return nil
+			case BackpressureDropOldest:
+				if e.dropOldestPoint() {
+					e.buf = append(e.buf, m)
+					e.cond.Broadcast()
+					return nil
+				}
+			}
+		}
+		e.cond.Wait()
+	}
+}
+
+// dropOldestPoint removes the oldest point from the buffer,
+// returns false if the buffer holds no points.
+func (e *dropEdge) dropOldestPoint() bool {
+	for i, m := range e.buf {
+		if _, ok := m.(PointMessage); ok {
+			copy(e.buf[i:], e.buf[i+1:])
+			e.buf[len(e.buf)-1] = nil
+			e.buf = e.buf[:len(e.buf)-1]
+			e.dropped.Add(1)
+			return true
+		}
+	}
+	return false
+}
+
+func (e *dropEdge) Emit() (Message, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for len(e.buf) == 0 && e.state == edgeOpen {
+		e.cond.Wait()
+	}
+	if e.state == edgeAborted || len(e.buf) == 0 {
+		return nil, false
+	}
+	m := e.buf[0]
+	copy(e.buf, e.buf[1:])
+	e.buf[len(e.buf)-1] = nil
+	e.buf = e.buf[:len(e.buf)-1]
+	e.cond.Broadcast()
+	return m, true
+}
+
+func (e *dropEdge) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.state != edgeOpen {
+		return errors.New("edge not open cannot close")
+	}
+	e.state = edgeClosed
+	e.cond.Broadcast()
+	return nil
+}
+
+func (e *dropEdge) Abort() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.state == edgeAborted {
+		//nothing to do, already aborted
+		return
+	}
+	e.state = edgeAborted
+	e.buf = nil
+	e.cond.Broadcast()
+}
+
+func (e *dropEdge) Type() pipeline.EdgeType {
+	return e.typ
+}
@@ -0,0 +1,207 @@
+package edge_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+var backpressureGroup = edge.GroupInfo{ID: models.GroupID("cpu")}
+
+func newBackpressureTestPoint(i int) edge.PointMessage {
+	return edge.NewPointMessage("cpu", "db", "rp", models.Dimensions{}, models.Fields{"value": float64(i)}, nil, time.Unix(int64(i), 0))
+}
+
+// describeBackpressure formats the messages emitted by e until it is closed.
+func describeBackpressure(e edge.Edge) []interface{} {
+	var got []interface{}
+	for m, ok := e.Emit(); ok; m, ok = e.Emit() {
+		switch m := m.(type) {
+		case edge.PointMessage:
+			got = append(got, m.Fields()["value"])
+		default:
+			got = append(got, m.Type())
+		}
+	}
+	return got
+}
+
+func TestBackpressureEdge_Drop(t *testing.T) {
+	testCases := []struct {
+		name        string
+		policy      edge.BackpressurePolicy
+		msgs        []edge.Message
+		exp         []interface{}
+		expDropped  int64
+		expBlocking bool
+	}{
+		{
+			name:   "drop newest",
+			policy: edge.BackpressureDropNewest,
+			msgs: []edge.Message{
+				newBackpressureTestPoint(1),
+				newBackpressureTestPoint(2),
+				newBackpressureTestPoint(3),
+				newBackpressureTestPoint(4),
+				newBackpressureTestPoint(5),
+			},
+			exp:        []interface{}{1.0, 2.0},
+			expDropped: 3,
+		},
+		{
+			name:   "drop oldest",
+			policy: edge.BackpressureDropOldest,
+			msgs: []edge.Message{
+				newBackpressureTestPoint(1),
+				newBackpressureTestPoint(2),
+				newBackpressureTestPoint(3),
+				newBackpressureTestPoint(4),
+				newBackpressureTestPoint(5),
+			},
+			exp:        []interface{}{4.0, 5.0},
+			expDropped: 3,
+		},
+		{
+			name:   "drop oldest keeps barriers",
+			policy: edge.BackpressureDropOldest,
+			msgs: []edge.Message{
+				edge.NewBarrierMessage(backpressureGroup, time.Unix(0, 0)),
+				newBackpressureTestPoint(1),
+				newBackpressureTestPoint(2),
+				newBackpressureTestPoint(3),
+			},
+			exp:        []interface{}{edge.Barrier, 3.0},
+			expDropped: 2,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dropped := new(expvar.Int)
+			e := edge.NewBackpressureEdge(pipeline.StreamEdge, 2, tc.policy, dropped)
+			// Nothing consumes the edge while the messages are collected.
+			for _, m := range tc.msgs {
+				if err := e.Collect(m); err != nil {
+					t.Fatal(err)
+				}
+			}
+			e.Close()
+			if got := describeBackpressure(e); !reflect.DeepEqual(got, tc.exp) {
+				t.Errorf("unexpected messages got %v exp %v", got, tc.exp)
+			}
+			if got := dropped.IntValue(); got != tc.expDropped {
+				t.Errorf("unexpected dropped got %d exp %d", got, tc.expDropped)
+			}
+		})
+	}
+}
+
+func TestBackpressureEdge_Block(t *testing.T) {
+	testCases := []struct {
+		name   string
+		policy edge.BackpressurePolicy
+		// msgs fill the buffer
+		msgs []edge.Message
+		// blocked is collected while the buffer is full
+		blocked edge.Message
+		exp     []interface{}
+	}{
+		{
+			name:    "block",
+			policy:  edge.BackpressureBlock,
+			msgs:    []edge.Message{newBackpressureTestPoint(1), newBackpressureTestPoint(2)},
+			blocked: newBackpressureTestPoint(3),
+			exp:     []interface{}{1.0, 2.0, 3.0},
+		},
+		{
+			name:    "drop newest never drops barriers",
+			policy:  edge.BackpressureDropNewest,
+			msgs:    []edge.Message{newBackpressureTestPoint(1), newBackpressureTestPoint(2)},
+			blocked: edge.NewBarrierMessage(backpressureGroup, time.Unix(2, 0)),
+			exp:     []interface{}{1.0, 2.0, edge.Barrier},
+		},
+		{
+			name:    "drop oldest without buffered points",
+			policy:  edge.BackpressureDropOldest,
+			msgs:    []edge.Message{edge.NewBarrierMessage(backpressureGroup, time.Unix(1, 0)), edge.NewBarrierMessage(backpressureGroup, time.Unix(2, 0))},
+			blocked: newBackpressureTestPoint(3),
+			exp:     []interface{}{edge.Barrier, edge.Barrier, 3.0},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dropped := new(expvar.Int)
+			e := edge.NewBackpressureEdge(pipeline.StreamEdge, 2, tc.policy, dropped)
+			for _, m := range tc.msgs {
+				if err := e.Collect(m); err != nil {
+					t.Fatal(err)
+				}
+			}
+			collected := make(chan error, 1)
+			go func() {
+				collected <- e.Collect(tc.blocked)
+			}()
+			select {
+			case err := <-collected:
+				t.Fatalf("expected collect to block while the buffer is full, returned %v", err)
+			case <-time.After(50 * time.Millisecond):
+			}
+
+			// The consumer catches up.
+			var got []interface{}
+			m, _ := e.Emit()
+			got = append(got, describeBackpressure(singleMessageEdge(m))...)
+			select {
+			case err := <-collected:
+				if err != nil {
+					t.Fatal(err)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("timed out waiting for collect")
+			}
+			e.Close()
+			got = append(got, describeBackpressure(e)...)
+			if !reflect.DeepEqual(got, tc.exp) {
+				t.Errorf("unexpected messages got %v exp %v", got, tc.exp)
+			}
+			if got := dropped.IntValue(); got != 0 {
+				t.Errorf("unexpected dropped got %d exp 0", got)
+			}
+		})
+	}
+}
+
+func TestBackpressureEdge_Abort(t *testing.T) {
+	e := edge.NewBackpressureEdge(pipeline.StreamEdge, 1, edge.BackpressureDropNewest, new(expvar.Int))
+	if err := e.Collect(edge.NewBarrierMessage(backpressureGroup, time.Unix(1, 0))); err != nil {
+		t.Fatal(err)
+	}
+	collected := make(chan error, 1)
+	go func() {
+		collected <- e.Collect(edge.NewBarrierMessage(backpressureGroup, time.Unix(2, 0)))
+	}()
+	e.Abort()
+	select {
+	case err := <-collected:
+		if err != edge.ErrAborted {
+			t.Errorf("unexpected error got %v exp %v", err, edge.ErrAborted)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for collect")
+	}
+	if m, ok := e.Emit(); ok {
+		t.Errorf("unexpected message after abort: %v", m)
+	}
+}
+
+// singleMessageEdge returns a closed edge holding only m.
+func singleMessageEdge(m edge.Message) edge.Edge {
+	e := edge.NewChannelEdge(pipeline.StreamEdge, 1)
+	e.Collect(m)
+	e.Close()
+	return e
+}
@@ -1,6 +1,7 @@
 package edge_test
 
 import (
+	"fmt"
 	"reflect"
 	"testing"
 	"time"
@@ -113,6 +114,50 @@ func TestEdge_CollectBatch(t *testing.T) {
 var emittedMsg edge.Message
 var emittedOK bool
 
+func TestGroupedConsumer_DeleteGroup(t *testing.T) {
+	p0 := newShardTestPoint("serverA", 0)
+	p1 := newShardTestPoint("serverA", 1)
+	e := edge.NewChannelEdge(pipeline.StreamEdge, 3)
+	for _, m := range []edge.Message{p0, edge.NewDeleteGroupMessage(p0.GroupID()), p1} {
+		if err := e.Collect(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	e.Close()
+
+	var groups []*sequenceReceiver
+	g := &shardedGroups{}
+	consumer := edge.NewGroupedConsumer(e, groupsFunc(func(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+		r, err := g.NewGroup(group, first)
+		groups = append(groups, r.(*sequenceReceiver))
+		return r, err
+	}))
+	if err := consumer.Consume(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The deleted group is released, so the next point of the group starts a new group.
+	if got, exp := len(groups), 2; got != exp {
+		t.Fatalf("unexpected number of groups: got %d exp %d", got, exp)
+	}
+	if got, exp := fmt.Sprint(groups[0].seqs), "[0 delete]"; got != exp {
+		t.Errorf("unexpected messages of deleted group: got %s exp %s", got, exp)
+	}
+	if got, exp := fmt.Sprint(groups[1].seqs), "[1]"; got != exp {
+		t.Errorf("unexpected messages of new group: got %s exp %s", got, exp)
+	}
+	if got, exp := consumer.CardinalityVar().IntValue(), int64(1); got != exp {
+		t.Errorf("unexpected cardinality: got %d exp %d", got, exp)
+	}
+}
+
+// groupsFunc is a function that implements edge.GroupedReceiver.
+type groupsFunc func(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error)
+
+func (f groupsFunc) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	return f(group, first)
+}
+
 func BenchmarkCollectPoint(b *testing.B) {
 	e := edge.NewChannelEdge(pipeline.StreamEdge, defaultEdgeBufferSize)
 	b.ReportAllocs()
@@ -815,49 +815,17 @@ func ResultToBufferedBatches(res influxdb.Result, groupByName bool) ([]BufferedB
 		points := b.Points()
 
 		for _, v := range series.Values {
-			fields := make(models.Fields)
-			var t time.Time
-			for i, c := range series.Columns {
-				if c == "time" {
-					tStr, ok := v[i].(string)
-					if !ok {
-						return nil, fmt.Errorf("unexpected time value: %v", v[i])
-					}
-					var err error
-					t, err = time.Parse(time.RFC3339Nano, tStr)
-					if err != nil {
-						t, err = time.Parse(time.RFC3339, tStr)
-						if err != nil {
-							return nil, fmt.Errorf("unexpected time format: %v", err)
-						}
-					}
-				} else {
-					value := v[i]
-					if n, ok := value.(json.Number); ok {
-						f, err := n.Float64()
-						if err == nil {
-							value = f
-						}
-					}
-					if value == nil {
-						continue
-					}
-					fields[c] = value
-				}
+			bp, err := rowValuesToBatchPoint(series, v)
+			if err != nil {
+				return nil, err
 			}
-			if len(fields) > 0 {
-				if t.After(b.Begin().Time()) {
-					b.Begin().SetTime(t.UTC())
-				}
-				points = append(
-					points,
-					NewBatchPointMessage(
-						fields,
-						series.Tags,
-						t.UTC(),
-					),
-				)
+			if bp == nil {
+				continue
 			}
+			if bp.Time().After(b.Begin().Time()) {
+				b.Begin().SetTime(bp.Time())
+			}
+			points = append(points, bp)
 		}
 		b.Begin().SetSizeHint(len(points))
 		b.SetPoints(points)
@@ -866,12 +834,65 @@ func ResultToBufferedBatches(res influxdb.Result, groupByName bool) ([]BufferedB
 	return batches, nil
 }
 
+// rowValuesToBatchPoint converts the values of a row of a series into a batch point.
+// Returns nil if the row has no non null fields.
+func rowValuesToBatchPoint(series imodels.Row, v []interface{}) (BatchPointMessage, error) {
+	fields := make(models.Fields)
+	var t time.Time
+	for i, c := range series.Columns {
+		if c == "time" {
+			tStr, ok := v[i].(string)
+			if !ok {
+				return nil, fmt.Errorf("unexpected time value: %v", v[i])
+			}
+			var err error
+			t, err = time.Parse(time.RFC3339Nano, tStr)
+			if err != nil {
+				t, err = time.Parse(time.RFC3339, tStr)
+				if err != nil {
+					return nil, fmt.Errorf("unexpected time format: %v", err)
+				}
+			}
+		} else {
+			value := v[i]
+			if n, ok := value.(json.Number); ok {
+				f, err := n.Float64()
+				if err == nil {
+					value = f
+				}
+			}
+			if value == nil {
+				continue
+			}
+			fields[c] = value
+		}
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	return NewBatchPointMessage(
+		fields,
+		series.Tags,
+		t.UTC(),
+	), nil
+}
+
 type BatchPointMessages []BatchPointMessage
 
 func (l BatchPointMessages) Len() int               { return len(l) }
 func (l BatchPointMessages) Less(i int, j int) bool { return l[i].Time().Before(l[j].Time()) }
 func (l BatchPointMessages) Swap(i int, j int)      { l[i], l[j] = l[j], l[i] }
 
+// Reasons for emitting a barrier.
+const (
+	// BarrierReasonIdle indicates no data arrived for the idle duration.
+	BarrierReasonIdle = "idle"
+	// BarrierReasonPeriod indicates the barrier period elapsed.
+	BarrierReasonPeriod = "period"
+	// BarrierReasonDelete indicates the group is being deleted.
+	BarrierReasonDelete = "delete"
+)
+
 // BarrierMessage indicates that no data older than the barrier time will arrive.
 type BarrierMessage interface {
 	Message
@@ -881,10 +902,13 @@ type BarrierMessage interface {
 	DimensionGetter
 	TagGetter
 	TimeGetter
+	// Reason returns why the barrier was emitted, empty if unknown.
+	Reason() string
 }
 type barrierMessage struct {
-	group GroupInfo
-	time  time.Time
+	group  GroupInfo
+	time   time.Time
+	reason string
 }
 
 func NewBarrierMessage(group GroupInfo, time time.Time) BarrierMessage {
@@ -894,6 +918,15 @@ func NewBarrierMessage(group GroupInfo, time time.Time) BarrierMessage {
 	}
 }
 
+// NewBarrierMessageWithReason creates a barrier that records why it was emitted.
+func NewBarrierMessageWithReason(group GroupInfo, time time.Time, reason string) BarrierMessage {
+	return &barrierMessage{
+		group:  group,
+		time:   time,
+		reason: reason,
+	}
+}
+
 func (b *barrierMessage) ShallowCopy() BarrierMessage {
 	c := new(barrierMessage)
 	*c = *b
@@ -924,6 +957,9 @@ func (b *barrierMessage) GroupInfo() GroupInfo {
 func (b *barrierMessage) Time() time.Time {
 	return b.time
 }
+func (b *barrierMessage) Reason() string {
+	return b.reason
+}
 
 type DeleteGroupMessage interface {
 	Message
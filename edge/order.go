@@ -0,0 +1,69 @@
+package edge
+
+import (
+	"time"
+
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/models"
+)
+
+// orderCheckEdge is an edge that checks that the points of each group are emitted in time order.
+// Messages are passed on unchanged.
+type orderCheckEdge struct {
+	StatsEdge
+
+	violations *expvar.Int
+	report     func(group models.GroupID, previous, current time.Time)
+
+	// time of the last point of each group
+	last map[models.GroupID]time.Time
+	// group of the current batch
+	batch models.GroupID
+}
+
+// NewOrderCheckEdge returns an edge that emits the messages of e unchanged,
+// counting each point or batch point older than the previous point of its group in violations
+// and passing the times of both points to report.
+// Points with equal times are in order.
+func NewOrderCheckEdge(e StatsEdge, violations *expvar.Int, report func(group models.GroupID, previous, current time.Time)) StatsEdge {
+	return &orderCheckEdge{
+		StatsEdge:  e,
+		violations: violations,
+		report:     report,
+		last:       make(map[models.GroupID]time.Time),
+	}
+}
+
+func (e *orderCheckEdge) Emit() (Message, bool) {
+	m, ok := e.StatsEdge.Emit()
+	if !ok {
+		return m, ok
+	}
+	switch msg := m.(type) {
+	case PointMessage:
+		e.check(msg.GroupID(), msg.Time())
+	case BeginBatchMessage:
+		e.batch = msg.GroupID()
+	case BatchPointMessage:
+		e.check(e.batch, msg.Time())
+	case BufferedBatchMessage:
+		for _, bp := range msg.Points() {
+			e.check(msg.GroupID(), bp.Time())
+		}
+	case DeleteGroupMessage:
+		delete(e.last, msg.GroupID())
+	}
+	return m, ok
+}
+
+// check records the time t of a point of the group, reporting a violation if it is older than the previous point.
+func (e *orderCheckEdge) check(group models.GroupID, t time.Time) {
+	previous, ok := e.last[group]
+	if ok && t.Before(previous) {
+		e.violations.Add(1)
+		e.report(group, previous, t)
+		// The newest time stays the reference, so that a single late point is reported once.
+		return
+	}
+	e.last[group] = t
+}
@@ -0,0 +1,103 @@
+package edge_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+type orderViolation struct {
+	group             models.GroupID
+	previous, current int64
+}
+
+// checkOrder emits msgs through an order checking edge, returning the reported violations and their count.
+func checkOrder(t *testing.T, typ pipeline.EdgeType, msgs []edge.Message) ([]orderViolation, int64) {
+	e := edge.NewStatsEdge(edge.NewChannelEdge(typ, len(msgs)))
+	for _, m := range msgs {
+		if err := e.Collect(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	e.Close()
+
+	var got []orderViolation
+	violations := new(expvar.Int)
+	oe := edge.NewOrderCheckEdge(e, violations, func(group models.GroupID, previous, current time.Time) {
+		got = append(got, orderViolation{group: group, previous: previous.Unix(), current: current.Unix()})
+	})
+	emitted := 0
+	for m, ok := oe.Emit(); ok; m, ok = oe.Emit() {
+		if m != msgs[emitted] {
+			t.Errorf("message %d was modified: got %v exp %v", emitted, m, msgs[emitted])
+		}
+		emitted++
+	}
+	if emitted != len(msgs) {
+		t.Errorf("unexpected number of messages: got %d exp %d", emitted, len(msgs))
+	}
+	return got, violations.IntValue()
+}
+
+func TestOrderCheckEdge_Stream(t *testing.T) {
+	dims := models.Dimensions{TagNames: []string{"host"}}
+	point := func(host string, sec int64) edge.PointMessage {
+		return edge.NewPointMessage("cpu", "db", "rp", dims, models.Fields{"value": 1.0}, models.Tags{"host": host}, time.Unix(sec, 0))
+	}
+	a, b := point("a", 0).GroupID(), point("b", 0).GroupID()
+	msgs := []edge.Message{
+		point("a", 10),
+		point("b", 5),
+		// Equal times and the order of other groups are not violations.
+		point("a", 10),
+		point("a", 20),
+		point("a", 15),
+		// The newest time remains the reference after a violation.
+		point("a", 18),
+		point("b", 1),
+		edge.NewBarrierMessage(edge.GroupInfo{ID: a}, time.Unix(30, 0)),
+		// A deleted group starts over.
+		edge.NewDeleteGroupMessage(b),
+		point("b", 0),
+	}
+	got, count := checkOrder(t, pipeline.StreamEdge, msgs)
+	exp := []orderViolation{
+		{group: a, previous: 20, current: 15},
+		{group: a, previous: 20, current: 18},
+		{group: b, previous: 5, current: 1},
+	}
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected violations:\ngot %v\nexp %v", got, exp)
+	}
+	if count != int64(len(exp)) {
+		t.Errorf("unexpected violation count: got %d exp %d", count, len(exp))
+	}
+}
+
+func TestOrderCheckEdge_Batch(t *testing.T) {
+	group := models.GroupID("host=a")
+	batch := func(secs ...int64) []edge.Message {
+		msgs := []edge.Message{edge.NewBeginBatchMessage("cpu", models.Tags{"host": "a"}, false, time.Unix(secs[len(secs)-1], 0), len(secs))}
+		for _, sec := range secs {
+			msgs = append(msgs, edge.NewBatchPointMessage(models.Fields{"value": 1.0}, models.Tags{"host": "a"}, time.Unix(sec, 0)))
+		}
+		return append(msgs, edge.NewEndBatchMessage())
+	}
+	msgs := append(batch(1, 3, 2), batch(4, 2)...)
+	got, count := checkOrder(t, pipeline.BatchEdge, msgs)
+	exp := []orderViolation{
+		{group: group, previous: 3, current: 2},
+		{group: group, previous: 4, current: 2},
+	}
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected violations:\ngot %v\nexp %v", got, exp)
+	}
+	if count != int64(len(exp)) {
+		t.Errorf("unexpected violation count: got %d exp %d", count, len(exp))
+	}
+}
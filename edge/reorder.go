@@ -0,0 +1,145 @@
+package edge
+
+import (
+	"container/heap"
+	"time"
+
+	"github.com/influxdata/kapacitor/expvar"
+)
+
+// reorderEdge is a stream edge that emits points in time order.
+// Points are buffered until they are older than the newest point minus the lateness,
+// or until the buffer is full.
+// Points older than a point that has already been emitted are dropped.
+type reorderEdge struct {
+	StatsEdge
+
+	lateness time.Duration
+	size     int
+	dropped  *expvar.Int
+
+	buf    pointHeap
+	seq    int64
+	newest time.Time
+	// time of the last emitted message, older points are stragglers
+	last time.Time
+
+	// messages ready to be emitted before anything else
+	ready []Message
+	// whether the underlying edge has been closed
+	closed bool
+}
+
+// NewReorderEdge returns an edge that emits the points collected by the stream edge e in time order,
+// tolerating points that arrive up to lateness after newer points.
+// At most size points are buffered, when the buffer is full the oldest point is emitted regardless of the lateness.
+// Points that arrive after a newer point has been emitted are dropped and counted in dropped.
+//
+// Barriers and deletes are passed on in order, after all buffered points up to their time.
+func NewReorderEdge(e StatsEdge, lateness time.Duration, size int, dropped *expvar.Int) StatsEdge {
+	if size < 1 {
+		size = 1
+	}
+	return &reorderEdge{
+		StatsEdge: e,
+		lateness:  lateness,
+		size:      size,
+		dropped:   dropped,
+	}
+}
+
+func (e *reorderEdge) Emit() (Message, bool) {
+	for {
+		if len(e.ready) > 0 {
+			m := e.ready[0]
+			e.ready = e.ready[1:]
+			return m, true
+		}
+		if e.buf.Len() > 0 {
+			oldest := e.buf[0].p.Time()
+			if e.closed || e.buf.Len() >= e.size || !oldest.After(e.newest.Add(-e.lateness)) {
+				return e.pop(), true
+			}
+		}
+		if e.closed {
+			return nil, false
+		}
+
+		m, ok := e.StatsEdge.Emit()
+		if !ok {
+			e.closed = true
+			continue
+		}
+		switch msg := m.(type) {
+		case PointMessage:
+			t := msg.Time()
+			if t.Before(e.last) {
+				e.dropped.Add(1)
+				continue
+			}
+			heap.Push(&e.buf, reorderPoint{p: msg, seq: e.seq})
+			e.seq++
+			if t.After(e.newest) {
+				e.newest = t
+			}
+		case BarrierMessage:
+			e.flush(msg.Time())
+			if msg.Time().After(e.last) {
+				e.last = msg.Time()
+			}
+			e.ready = append(e.ready, msg)
+		case DeleteGroupMessage:
+			// Points of the group must be emitted before the group is deleted.
+			e.flush(e.newest)
+			e.ready = append(e.ready, msg)
+		default:
+			e.ready = append(e.ready, m)
+		}
+	}
+}
+
+// pop removes and returns the oldest buffered point.
+func (e *reorderEdge) pop() Message {
+	p := heap.Pop(&e.buf).(reorderPoint).p
+	if p.Time().After(e.last) {
+		e.last = p.Time()
+	}
+	return p
+}
+
+// flush moves all buffered points up to t to the ready messages.
+func (e *reorderEdge) flush(t time.Time) {
+	for e.buf.Len() > 0 && !e.buf[0].p.Time().After(t) {
+		e.ready = append(e.ready, e.pop())
+	}
+}
+
+type reorderPoint struct {
+	p PointMessage
+	// arrival order of points with equal times
+	seq int64
+}
+
+type pointHeap []reorderPoint
+
+func (h pointHeap) Len() int { return len(h) }
+func (h pointHeap) Less(i, j int) bool {
+	ti, tj := h[i].p.Time(), h[j].p.Time()
+	if ti.Equal(tj) {
+		return h[i].seq < h[j].seq
+	}
+	return ti.Before(tj)
+}
+func (h pointHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *pointHeap) Push(x interface{}) {
+	*h = append(*h, x.(reorderPoint))
+}
+
+func (h *pointHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
@@ -0,0 +1,156 @@
+package edge_test
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+// orderedReceiver fails if points are not received in time order.
+type orderedReceiver struct {
+	last  time.Time
+	times []time.Time
+	msgs  []edge.MessageType
+}
+
+func (r *orderedReceiver) BeginBatch(edge.BeginBatchMessage) error { return nil }
+func (r *orderedReceiver) BatchPoint(edge.BatchPointMessage) error { return nil }
+func (r *orderedReceiver) EndBatch(edge.EndBatchMessage) error     { return nil }
+func (r *orderedReceiver) Point(p edge.PointMessage) error {
+	if p.Time().Before(r.last) {
+		return fmt.Errorf("point at %v received after point at %v", p.Time(), r.last)
+	}
+	r.last = p.Time()
+	r.times = append(r.times, p.Time())
+	r.msgs = append(r.msgs, p.Type())
+	return nil
+}
+func (r *orderedReceiver) Barrier(b edge.BarrierMessage) error {
+	r.msgs = append(r.msgs, b.Type())
+	return nil
+}
+func (r *orderedReceiver) DeleteGroup(edge.DeleteGroupMessage) error { return nil }
+func (r *orderedReceiver) Done()                                     {}
+
+func newReorderTestPoint(t time.Time) edge.PointMessage {
+	return edge.NewPointMessage("cpu", "db", "rp", models.Dimensions{}, models.Fields{"value": 1.0}, nil, t)
+}
+
+func consumeReordered(t *testing.T, msgs []edge.Message, lateness time.Duration, size int) (*orderedReceiver, int64) {
+	e := edge.NewStatsEdge(edge.NewChannelEdge(pipeline.StreamEdge, len(msgs)))
+	for _, m := range msgs {
+		if err := e.Collect(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	e.Close()
+
+	dropped := new(expvar.Int)
+	r := new(orderedReceiver)
+	consumer := edge.NewConsumerWithReceiver(edge.NewReorderEdge(e, lateness, size, dropped), r)
+	if err := consumer.Consume(); err != nil {
+		t.Fatal(err)
+	}
+	return r, dropped.IntValue()
+}
+
+func TestReorderEdge_Shuffled(t *testing.T) {
+	t0 := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	const count = 100
+	msgs := make([]edge.Message, count)
+	for i := range msgs {
+		msgs[i] = newReorderTestPoint(t0.Add(time.Duration(i) * time.Second))
+	}
+	// Shuffle points within windows of 5 points, so no point is more than 5s late.
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < count; i += 5 {
+		window := msgs[i : i+5]
+		rnd.Shuffle(len(window), func(a, b int) { window[a], window[b] = window[b], window[a] })
+	}
+
+	r, dropped := consumeReordered(t, msgs, 5*time.Second, 100)
+	if dropped != 0 {
+		t.Errorf("unexpected dropped points: %d", dropped)
+	}
+	if got := len(r.times); got != count {
+		t.Fatalf("unexpected number of points: got %d exp %d", got, count)
+	}
+}
+
+func TestReorderEdge_DropStragglers(t *testing.T) {
+	t0 := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	msgs := []edge.Message{
+		newReorderTestPoint(t0.Add(1 * time.Second)),
+		newReorderTestPoint(t0.Add(5 * time.Second)),
+		// Emitted once this point arrives as 1s is older than 10s - 6s.
+		newReorderTestPoint(t0.Add(10 * time.Second)),
+		// Late but newer than the last emitted point.
+		newReorderTestPoint(t0.Add(4 * time.Second)),
+		// Older than an emitted point.
+		newReorderTestPoint(t0),
+	}
+	r, dropped := consumeReordered(t, msgs, 6*time.Second, 100)
+	if dropped != 1 {
+		t.Errorf("unexpected dropped points: got %d exp 1", dropped)
+	}
+	exp := []time.Time{t0.Add(1 * time.Second), t0.Add(4 * time.Second), t0.Add(5 * time.Second), t0.Add(10 * time.Second)}
+	if len(r.times) != len(exp) {
+		t.Fatalf("unexpected points: got %v exp %v", r.times, exp)
+	}
+	for i := range exp {
+		if !r.times[i].Equal(exp[i]) {
+			t.Errorf("unexpected point %d: got %v exp %v", i, r.times[i], exp[i])
+		}
+	}
+}
+
+func TestReorderEdge_BufferFull(t *testing.T) {
+	t0 := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	msgs := []edge.Message{
+		newReorderTestPoint(t0.Add(3 * time.Second)),
+		newReorderTestPoint(t0.Add(2 * time.Second)),
+		// The buffer is full, so 2s is emitted.
+		newReorderTestPoint(t0.Add(4 * time.Second)),
+		// Dropped as 2s was emitted.
+		newReorderTestPoint(t0.Add(1 * time.Second)),
+	}
+	r, dropped := consumeReordered(t, msgs, time.Hour, 2)
+	if dropped != 1 {
+		t.Errorf("unexpected dropped points: got %d exp 1", dropped)
+	}
+	if got := len(r.times); got != 3 {
+		t.Errorf("unexpected number of points: got %d exp 3", got)
+	}
+}
+
+func TestReorderEdge_Barrier(t *testing.T) {
+	t0 := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	group := newReorderTestPoint(t0).GroupInfo()
+	msgs := []edge.Message{
+		newReorderTestPoint(t0.Add(2 * time.Second)),
+		newReorderTestPoint(t0.Add(1 * time.Second)),
+		newReorderTestPoint(t0.Add(5 * time.Second)),
+		edge.NewBarrierMessage(group, t0.Add(3*time.Second)),
+		// Dropped as it is older than the barrier.
+		newReorderTestPoint(t0),
+	}
+	r, dropped := consumeReordered(t, msgs, time.Hour, 100)
+	if dropped != 1 {
+		t.Errorf("unexpected dropped points: got %d exp 1", dropped)
+	}
+	exp := []edge.MessageType{edge.Point, edge.Point, edge.Barrier, edge.Point}
+	if len(r.msgs) != len(exp) {
+		t.Fatalf("unexpected messages: got %v exp %v", r.msgs, exp)
+	}
+	for i := range exp {
+		if r.msgs[i] != exp[i] {
+			t.Errorf("unexpected message %d: got %v exp %v", i, r.msgs[i], exp[i])
+		}
+	}
+}
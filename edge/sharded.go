@@ -0,0 +1,225 @@
+package edge
+
+import (
+	"errors"
+	"hash/fnv"
+	"sync"
+
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/models"
+)
+
+// shardBufferSize is the number of messages buffered for each worker of a sharded grouped consumer.
+const shardBufferSize = 100
+
+type shardedGroupedConsumer struct {
+	consumer    Consumer
+	gr          GroupedReceiver
+	groups      map[models.GroupID]Receiver
+	cardinality *expvar.Int
+
+	shards []chan shardMessage
+	wg     sync.WaitGroup
+
+	// batchMu ensures a single batch is processed at a time,
+	// so that the messages of the batches forwarded by the receivers are not interleaved.
+	batchMu sync.Mutex
+
+	// current batch being buffered and its receiver
+	current Receiver
+	begin   BeginBatchMessage
+	points  []BatchPointMessage
+
+	mu  sync.Mutex
+	err error
+}
+
+// shardMessage is a message for the receiver of its group.
+type shardMessage struct {
+	r Receiver
+	m Message
+}
+
+// NewShardedGroupedConsumer creates a new grouped consumer for edge e and grouped receiver r
+// that processes the groups on a pool of worker goroutines.
+//
+// Each group is assigned to a worker by the hash of its ID, so the messages of a group,
+// including its barriers and batches, are processed in order while different groups are processed in parallel.
+// The groups are created by r on the goroutine of the consumer,
+// the receivers of different groups must be safe to use concurrently.
+// Batches are buffered and passed to the workers whole, and a single batch is processed at a time,
+// so that the messages of the batches forwarded by the receivers are not interleaved.
+func NewShardedGroupedConsumer(e Edge, r GroupedReceiver, workers int) GroupedConsumer {
+	c := &shardedGroupedConsumer{
+		gr:          r,
+		groups:      make(map[models.GroupID]Receiver),
+		cardinality: new(expvar.Int),
+		shards:      make([]chan shardMessage, workers),
+	}
+	for i := range c.shards {
+		c.shards[i] = make(chan shardMessage, shardBufferSize)
+	}
+	c.consumer = NewConsumerWithReceiver(e, c)
+	return c
+}
+
+func (c *shardedGroupedConsumer) Consume() error {
+	for _, shard := range c.shards {
+		c.wg.Add(1)
+		go c.work(shard)
+	}
+	// The workers are stopped by Done once the edge is consumed.
+	if err := c.consumer.Consume(); err != nil {
+		return err
+	}
+	return c.firstErr()
+}
+
+func (c *shardedGroupedConsumer) CardinalityVar() expvar.IntVar {
+	return c.cardinality
+}
+
+// work processes the messages of a shard until the shard is closed.
+func (c *shardedGroupedConsumer) work(shard <-chan shardMessage) {
+	defer c.wg.Done()
+	for sm := range shard {
+		if c.firstErr() != nil {
+			// Drain the shard after an error.
+			continue
+		}
+		if err := c.receive(sm); err != nil {
+			c.fail(err)
+		}
+	}
+}
+
+func (c *shardedGroupedConsumer) receive(sm shardMessage) error {
+	switch m := sm.m.(type) {
+	case BufferedBatchMessage:
+		c.batchMu.Lock()
+		defer c.batchMu.Unlock()
+		return receiveBufferedBatch(sm.r, m)
+	case PointMessage:
+		return sm.r.Point(m)
+	case BarrierMessage:
+		return sm.r.Barrier(m)
+	case DeleteGroupMessage:
+		return sm.r.DeleteGroup(m)
+	}
+	return nil
+}
+
+func (c *shardedGroupedConsumer) fail(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err == nil {
+		c.err = err
+	}
+}
+
+func (c *shardedGroupedConsumer) firstErr() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// dispatch passes a message to the worker of its group.
+// Returns the first error of the workers, if any, to stop consuming.
+func (c *shardedGroupedConsumer) dispatch(group models.GroupID, r Receiver, m Message) error {
+	if err := c.firstErr(); err != nil {
+		return err
+	}
+	h := fnv.New32a()
+	h.Write([]byte(group))
+	c.shards[h.Sum32()%uint32(len(c.shards))] <- shardMessage{r: r, m: m}
+	return nil
+}
+
+func (c *shardedGroupedConsumer) getOrCreateGroup(group GroupInfo, first PointMeta) (Receiver, error) {
+	r, ok := c.groups[group.ID]
+	if !ok {
+		c.cardinality.Add(1)
+		recv, err := c.gr.NewGroup(group, first)
+		if err != nil {
+			return nil, err
+		}
+		c.groups[group.ID] = recv
+		r = recv
+	}
+	return r, nil
+}
+
+func (c *shardedGroupedConsumer) BeginBatch(begin BeginBatchMessage) error {
+	r, err := c.getOrCreateGroup(begin.GroupInfo(), begin)
+	if err != nil {
+		return err
+	}
+	c.current = r
+	c.begin = begin
+	c.points = make([]BatchPointMessage, 0, begin.SizeHint())
+	return nil
+}
+
+func (c *shardedGroupedConsumer) BatchPoint(p BatchPointMessage) error {
+	if c.current == nil {
+		return errors.New("received batch point without batch")
+	}
+	c.points = append(c.points, p)
+	return nil
+}
+
+func (c *shardedGroupedConsumer) EndBatch(end EndBatchMessage) error {
+	if c.current == nil {
+		return errors.New("received end batch without batch")
+	}
+	r, batch := c.current, NewBufferedBatchMessage(c.begin, c.points, end)
+	c.current, c.begin, c.points = nil, nil, nil
+	return c.dispatch(batch.Begin().GroupID(), r, batch)
+}
+
+func (c *shardedGroupedConsumer) BufferedBatch(batch BufferedBatchMessage) error {
+	begin := batch.Begin()
+	r, err := c.getOrCreateGroup(begin.GroupInfo(), begin)
+	if err != nil {
+		return err
+	}
+	return c.dispatch(begin.GroupID(), r, batch)
+}
+
+func (c *shardedGroupedConsumer) Point(p PointMessage) error {
+	r, err := c.getOrCreateGroup(p.GroupInfo(), p)
+	if err != nil {
+		return err
+	}
+	return c.dispatch(p.GroupID(), r, p)
+}
+
+func (c *shardedGroupedConsumer) Barrier(b BarrierMessage) error {
+	r, err := c.getOrCreateGroup(b.GroupInfo(), b)
+	if err != nil {
+		return err
+	}
+	return c.dispatch(b.GroupID(), r, b)
+}
+
+func (c *shardedGroupedConsumer) DeleteGroup(d DeleteGroupMessage) error {
+	id := d.GroupID()
+	r, ok := c.groups[id]
+	if ok {
+		delete(c.groups, id)
+		c.cardinality.Add(-1)
+		return c.dispatch(id, r, d)
+	}
+	return nil
+}
+
+// Done stops the workers once they processed all messages.
+func (c *shardedGroupedConsumer) Done() {
+	for _, shard := range c.shards {
+		close(shard)
+	}
+	c.wg.Wait()
+	for _, r := range c.groups {
+		r.Done()
+	}
+}
@@ -0,0 +1,183 @@
+package edge_test
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+// shardedGroups creates a sequenceReceiver per group.
+type shardedGroups struct {
+	groups map[models.GroupID]*sequenceReceiver
+	// number of batches being processed, to check batches are processed one at a time
+	batches int32
+	// work is the number of iterations of busy work per point
+	work int
+	err  error
+}
+
+func (g *shardedGroups) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	r := &sequenceReceiver{g: g}
+	if g.groups != nil {
+		g.groups[group.ID] = r
+	}
+	return r, nil
+}
+
+// sequenceReceiver records the sequence numbers of the points and the barriers of a group.
+type sequenceReceiver struct {
+	g    *shardedGroups
+	seqs []string
+	sum  float64
+}
+
+func (r *sequenceReceiver) BeginBatch(edge.BeginBatchMessage) error {
+	if atomic.AddInt32(&r.g.batches, 1) != 1 {
+		return errors.New("batches processed concurrently")
+	}
+	r.seqs = append(r.seqs, "begin")
+	return nil
+}
+func (r *sequenceReceiver) BatchPoint(bp edge.BatchPointMessage) error {
+	r.seqs = append(r.seqs, fmt.Sprint(bp.Fields()["seq"]))
+	return nil
+}
+func (r *sequenceReceiver) EndBatch(edge.EndBatchMessage) error {
+	r.seqs = append(r.seqs, "end")
+	atomic.AddInt32(&r.g.batches, -1)
+	return nil
+}
+func (r *sequenceReceiver) Point(p edge.PointMessage) error {
+	if r.g.err != nil {
+		return r.g.err
+	}
+	for i := 0; i < r.g.work; i++ {
+		r.sum += math.Sqrt(float64(i))
+	}
+	r.seqs = append(r.seqs, fmt.Sprint(p.Fields()["seq"]))
+	return nil
+}
+func (r *sequenceReceiver) Barrier(b edge.BarrierMessage) error {
+	r.seqs = append(r.seqs, "barrier")
+	return nil
+}
+func (r *sequenceReceiver) DeleteGroup(edge.DeleteGroupMessage) error {
+	r.seqs = append(r.seqs, "delete")
+	return nil
+}
+func (r *sequenceReceiver) Done() {}
+
+var shardTestDims = models.Dimensions{TagNames: []string{"host"}}
+
+func newShardTestPoint(host string, seq int64) edge.PointMessage {
+	return edge.NewPointMessage("cpu", "db", "rp", shardTestDims, models.Fields{"seq": seq}, models.Tags{"host": host}, time.Unix(seq, 0))
+}
+
+func consumeSharded(t testing.TB, typ pipeline.EdgeType, msgs []edge.Message, g *shardedGroups, workers int) error {
+	e := edge.NewChannelEdge(typ, len(msgs))
+	for _, m := range msgs {
+		if err := e.Collect(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	e.Close()
+	return edge.NewShardedGroupedConsumer(e, g, workers).Consume()
+}
+
+func TestShardedGroupedConsumer_StreamOrder(t *testing.T) {
+	const hosts, points = 20, 100
+	var msgs []edge.Message
+	exp := make(map[models.GroupID][]string, hosts)
+	for seq := int64(0); seq < points; seq++ {
+		for h := 0; h < hosts; h++ {
+			p := newShardTestPoint(fmt.Sprintf("host%d", h), seq)
+			msgs = append(msgs, p)
+			exp[p.GroupID()] = append(exp[p.GroupID()], fmt.Sprint(seq))
+			if seq%10 == 9 {
+				msgs = append(msgs, edge.NewBarrierMessage(p.GroupInfo(), p.Time()))
+				exp[p.GroupID()] = append(exp[p.GroupID()], "barrier")
+			}
+		}
+	}
+	g := &shardedGroups{groups: make(map[models.GroupID]*sequenceReceiver)}
+	if err := consumeSharded(t, pipeline.StreamEdge, msgs, g, 4); err != nil {
+		t.Fatal(err)
+	}
+	if len(g.groups) != hosts {
+		t.Fatalf("unexpected number of groups: got %d exp %d", len(g.groups), hosts)
+	}
+	for group, r := range g.groups {
+		if got, exp := fmt.Sprint(r.seqs), fmt.Sprint(exp[group]); got != exp {
+			t.Errorf("unexpected order of group %s:\ngot %s\nexp %s", group, got, exp)
+		}
+	}
+}
+
+func TestShardedGroupedConsumer_BatchOrder(t *testing.T) {
+	const hosts, batches, points = 8, 10, 5
+	var msgs []edge.Message
+	exp := make(map[models.GroupID][]string, hosts)
+	for b := 0; b < batches; b++ {
+		for h := 0; h < hosts; h++ {
+			tags := models.Tags{"host": fmt.Sprintf("host%d", h)}
+			begin := edge.NewBeginBatchMessage("cpu", tags, false, time.Unix(int64(b), 0), points)
+			group := begin.GroupID()
+			msgs = append(msgs, begin)
+			exp[group] = append(exp[group], "begin")
+			for i := 0; i < points; i++ {
+				seq := int64(b*points + i)
+				msgs = append(msgs, edge.NewBatchPointMessage(models.Fields{"seq": seq}, tags, time.Unix(int64(b), 0)))
+				exp[group] = append(exp[group], fmt.Sprint(seq))
+			}
+			msgs = append(msgs, edge.NewEndBatchMessage())
+			exp[group] = append(exp[group], "end")
+		}
+	}
+	g := &shardedGroups{groups: make(map[models.GroupID]*sequenceReceiver)}
+	if err := consumeSharded(t, pipeline.BatchEdge, msgs, g, 4); err != nil {
+		t.Fatal(err)
+	}
+	for group, r := range g.groups {
+		if got, exp := fmt.Sprint(r.seqs), fmt.Sprint(exp[group]); got != exp {
+			t.Errorf("unexpected order of group %s:\ngot %s\nexp %s", group, got, exp)
+		}
+	}
+}
+
+func TestShardedGroupedConsumer_Error(t *testing.T) {
+	var msgs []edge.Message
+	for seq := int64(0); seq < 1000; seq++ {
+		msgs = append(msgs, newShardTestPoint(fmt.Sprintf("host%d", seq%10), seq))
+	}
+	g := &shardedGroups{err: errors.New("failed")}
+	if err := consumeSharded(t, pipeline.StreamEdge, msgs, g, 4); err == nil || err.Error() != "failed" {
+		t.Errorf("unexpected error: got %v exp failed", err)
+	}
+}
+
+// BenchmarkShardedGroupedConsumer shows the throughput of CPU heavy receivers scaling with the number of workers.
+func BenchmarkShardedGroupedConsumer(b *testing.B) {
+	var msgs []edge.Message
+	for seq := int64(0); seq < 100; seq++ {
+		for h := 0; h < 64; h++ {
+			msgs = append(msgs, newShardTestPoint(fmt.Sprintf("host%d", h), seq))
+		}
+	}
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers_%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				g := &shardedGroups{work: 1000}
+				if err := consumeSharded(b, pipeline.StreamEdge, msgs, g, workers); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,233 @@
+package edge
+
+import (
+	"bufio"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	imodels "github.com/influxdata/influxdb/models"
+	"github.com/influxdata/kapacitor/influxdb"
+	"github.com/influxdata/kapacitor/models"
+)
+
+func init() {
+	// Field values are encoded as interfaces, register the types not known to gob.
+	gob.Register(json.Number(""))
+}
+
+// spilledPoint is the encoded form of a BatchPointMessage.
+type spilledPoint struct {
+	Fields models.Fields
+	Tags   models.Tags
+	Time   time.Time
+}
+
+// SpillBatchBuffer buffers the points of a batch keeping at most a fixed number of points in memory,
+// additional points are spilled to a temporary file.
+// The buffered batch is streamed back on EndBatch.
+type SpillBatchBuffer struct {
+	maxInMemoryPoints int
+
+	begin  BeginBatchMessage
+	points []BatchPointMessage
+	count  int
+
+	file *os.File
+	w    *bufio.Writer
+	enc  *gob.Encoder
+}
+
+// NewSpillBatchBuffer creates a SpillBatchBuffer keeping at most maxInMemoryPoints points in memory.
+func NewSpillBatchBuffer(maxInMemoryPoints int) *SpillBatchBuffer {
+	return &SpillBatchBuffer{
+		maxInMemoryPoints: maxInMemoryPoints,
+	}
+}
+
+// BeginBatch starts buffering a new batch.
+// The begin message is not copied so that it can be modified until the batch ends.
+func (b *SpillBatchBuffer) BeginBatch(begin BeginBatchMessage) error {
+	if err := b.Close(); err != nil {
+		return err
+	}
+	b.begin = begin
+	b.points = b.points[:0]
+	b.count = 0
+	return nil
+}
+
+// BatchPoint buffers a point of the batch, spilling it to disk if the in memory limit is reached.
+func (b *SpillBatchBuffer) BatchPoint(bp BatchPointMessage) error {
+	b.count++
+	if len(b.points) < b.maxInMemoryPoints {
+		b.points = append(b.points, bp)
+		return nil
+	}
+	if b.file == nil {
+		f, err := ioutil.TempFile("", "kapacitor-batch-")
+		if err != nil {
+			return fmt.Errorf("failed to create batch spill file: %v", err)
+		}
+		b.file = f
+		b.w = bufio.NewWriter(f)
+		b.enc = gob.NewEncoder(b.w)
+	}
+	if err := b.enc.Encode(spilledPoint{
+		Fields: bp.Fields(),
+		Tags:   bp.Tags(),
+		Time:   bp.Time(),
+	}); err != nil {
+		return fmt.Errorf("failed to spill batch point: %v", err)
+	}
+	return nil
+}
+
+// Spilled reports whether any points of the current batch were spilled to disk.
+func (b *SpillBatchBuffer) Spilled() bool {
+	return b.file != nil
+}
+
+// EndBatch passes the begin message, all buffered points in order and the end message to f.
+// The spill file is removed once the batch has been streamed back.
+func (b *SpillBatchBuffer) EndBatch(end EndBatchMessage, f func(Message) error) error {
+	defer b.Close()
+	b.begin.SetSizeHint(b.count)
+	if err := f(b.begin); err != nil {
+		return err
+	}
+	for _, bp := range b.points {
+		if err := f(bp); err != nil {
+			return err
+		}
+	}
+	// Release the in memory points before reading back the spilled points.
+	for i := range b.points {
+		b.points[i] = nil
+	}
+	if b.file != nil {
+		if err := b.w.Flush(); err != nil {
+			return fmt.Errorf("failed to flush batch spill file: %v", err)
+		}
+		if _, err := b.file.Seek(0, 0); err != nil {
+			return fmt.Errorf("failed to read batch spill file: %v", err)
+		}
+		dec := gob.NewDecoder(bufio.NewReader(b.file))
+		for i := len(b.points); i < b.count; i++ {
+			var p spilledPoint
+			if err := dec.Decode(&p); err != nil {
+				return fmt.Errorf("failed to read spilled batch point: %v", err)
+			}
+			if err := f(NewBatchPointMessage(p.Fields, p.Tags, p.Time)); err != nil {
+				return err
+			}
+		}
+	}
+	return f(end)
+}
+
+// Close removes the spill file of the current batch if any.
+func (b *SpillBatchBuffer) Close() error {
+	if b.file == nil {
+		return nil
+	}
+	name := b.file.Name()
+	b.file.Close()
+	b.file = nil
+	b.w = nil
+	b.enc = nil
+	return os.Remove(name)
+}
+
+// ResultSpiller converts the series of query results into batches buffered by a SpillBatchBuffer.
+// Results can be passed one chunk of a chunked query response at a time,
+// consecutive series with the same name and tags are buffered as a single batch.
+// This way at most one chunk and the in memory points of the buffer are held in memory.
+type ResultSpiller struct {
+	groupByName bool
+	buf         *SpillBatchBuffer
+	f           func(Message) error
+
+	// begin message of the batch being buffered, nil if none
+	begin BeginBatchMessage
+	// name and tags of the series of the batch being buffered
+	series imodels.Row
+}
+
+// NewResultSpiller creates a ResultSpiller buffering batches with buf.
+// The messages of each batch are passed to f once all points of the batch have been buffered.
+func NewResultSpiller(groupByName bool, buf *SpillBatchBuffer, f func(Message) error) *ResultSpiller {
+	return &ResultSpiller{
+		groupByName: groupByName,
+		buf:         buf,
+		f:           f,
+	}
+}
+
+// Result buffers the series of a result.
+// The last series may continue in the next result, so its batch is only passed on
+// once a later result starts another series or Flush is called.
+// The batch being buffered is discarded if an error is returned.
+func (s *ResultSpiller) Result(res influxdb.Result) error {
+	if res.Err != "" {
+		s.Abort()
+		return errors.New(res.Err)
+	}
+	for i := range res.Series {
+		series := res.Series[i]
+		if s.begin == nil || !s.series.SameSeries(&series) {
+			if err := s.Flush(); err != nil {
+				return err
+			}
+			s.begin = NewBeginBatchMessage(
+				series.Name,
+				series.Tags,
+				s.groupByName,
+				time.Time{},
+				len(series.Values),
+			)
+			s.series = imodels.Row{Name: series.Name, Tags: series.Tags}
+			if err := s.buf.BeginBatch(s.begin); err != nil {
+				s.begin = nil
+				return err
+			}
+		}
+		for _, v := range series.Values {
+			bp, err := rowValuesToBatchPoint(series, v)
+			if err != nil {
+				s.Abort()
+				return err
+			}
+			if bp == nil {
+				continue
+			}
+			if bp.Time().After(s.begin.Time()) {
+				s.begin.SetTime(bp.Time())
+			}
+			if err := s.buf.BatchPoint(bp); err != nil {
+				s.Abort()
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Flush passes the messages of the batch being buffered, if any, to f.
+func (s *ResultSpiller) Flush() error {
+	if s.begin == nil {
+		return nil
+	}
+	s.begin = nil
+	return s.buf.EndBatch(NewEndBatchMessage(), s.f)
+}
+
+// Abort discards the batch being buffered, if any.
+func (s *ResultSpiller) Abort() error {
+	s.begin = nil
+	return s.buf.Close()
+}
@@ -0,0 +1,253 @@
+package edge_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+
+	imodels "github.com/influxdata/influxdb/models"
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/influxdb"
+	"github.com/influxdata/kapacitor/models"
+)
+
+func spillFiles(t *testing.T) []string {
+	files, err := filepath.Glob(filepath.Join(os.TempDir(), "kapacitor-batch-*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestResultSpiller(t *testing.T) {
+	before := spillFiles(t)
+	t0 := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	const size = 25
+	values := make([][]interface{}, size)
+	for i := range values {
+		values[i] = []interface{}{
+			t0.Add(time.Duration(i) * time.Second).Format(time.RFC3339Nano),
+			json.Number("1.5"),
+			int64(i),
+			"v",
+			i%2 == 0,
+		}
+	}
+	// A row without any non null field is skipped.
+	values = append(values, []interface{}{t0.Add(time.Hour).Format(time.RFC3339Nano), nil, nil, nil, nil})
+	res := influxdb.Result{
+		Series: []imodels.Row{{
+			Name:    "cpu",
+			Tags:    map[string]string{"host": "serverA"},
+			Columns: []string{"time", "mean", "count", "str", "bool"},
+			Values:  values,
+		}},
+	}
+	exp, err := edge.ResultToBufferedBatches(res, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := edge.NewSpillBatchBuffer(10)
+	var got []edge.Message
+	spilled := false
+	spiller := edge.NewResultSpiller(false, buf, func(m edge.Message) error {
+		if _, ok := m.(edge.EndBatchMessage); ok {
+			spilled = buf.Spilled()
+		}
+		got = append(got, m)
+		return nil
+	})
+	// Pass the result in chunks of a chunked response, the series continues from chunk to chunk.
+	for i := 0; i < len(values); i += 10 {
+		end := i + 10
+		if end > len(values) {
+			end = len(values)
+		}
+		chunk := res.Series[0]
+		chunk.Values = values[i:end]
+		if err := spiller.Result(influxdb.Result{Series: []imodels.Row{chunk}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := spiller.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if !spilled {
+		t.Error("expected points to be spilled")
+	}
+
+	if exp, got := size+2, len(got); got != exp {
+		t.Fatalf("unexpected number of messages: got %d exp %d", got, exp)
+	}
+	begin, ok := got[0].(edge.BeginBatchMessage)
+	if !ok {
+		t.Fatalf("expected begin batch message, got %T", got[0])
+	}
+	if got, exp := begin.GroupID(), exp[0].Begin().GroupID(); got != exp {
+		t.Errorf("unexpected group: got %s exp %s", got, exp)
+	}
+	if got, exp := begin.Time(), exp[0].Begin().Time(); !got.Equal(exp) {
+		t.Errorf("unexpected time: got %v exp %v", got, exp)
+	}
+	if got, exp := begin.SizeHint(), size; got != exp {
+		t.Errorf("unexpected size hint: got %d exp %d", got, exp)
+	}
+	for i, ep := range exp[0].Points() {
+		bp, ok := got[i+1].(edge.BatchPointMessage)
+		if !ok {
+			t.Fatalf("point %d: expected batch point message, got %T", i, got[i+1])
+		}
+		if !bp.Time().Equal(ep.Time()) {
+			t.Errorf("point %d: unexpected time: got %v exp %v", i, bp.Time(), ep.Time())
+		}
+		if !reflect.DeepEqual(bp.Fields(), ep.Fields()) {
+			t.Errorf("point %d: unexpected fields: got %v exp %v", i, bp.Fields(), ep.Fields())
+		}
+		if !reflect.DeepEqual(bp.Tags(), ep.Tags()) {
+			t.Errorf("point %d: unexpected tags: got %v exp %v", i, bp.Tags(), ep.Tags())
+		}
+	}
+	if _, ok := got[len(got)-1].(edge.EndBatchMessage); !ok {
+		t.Errorf("expected end batch message, got %T", got[len(got)-1])
+	}
+	if after := spillFiles(t); len(after) != len(before) {
+		t.Errorf("spill file was not removed: %v", after)
+	}
+}
+
+func TestSpillBatchBuffer_Close(t *testing.T) {
+	before := spillFiles(t)
+	buf := edge.NewSpillBatchBuffer(1)
+	t0 := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := buf.BeginBatch(edge.NewBeginBatchMessage("cpu", models.Tags{}, false, t0, 0)); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := buf.BatchPoint(edge.NewBatchPointMessage(models.Fields{"value": 1.0}, models.Tags{}, t0)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !buf.Spilled() {
+		t.Fatal("expected points to be spilled")
+	}
+	if len(spillFiles(t)) != len(before)+1 {
+		t.Fatal("expected spill file to exist")
+	}
+
+	// Closing an interrupted batch removes the spill file.
+	if err := buf.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if after := spillFiles(t); len(after) != len(before) {
+		t.Errorf("spill file was not removed: %v", after)
+	}
+}
+
+func TestResultSpiller_Series(t *testing.T) {
+	t0 := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	row := func(host string, values ...float64) imodels.Row {
+		r := imodels.Row{
+			Name:    "cpu",
+			Tags:    map[string]string{"host": host},
+			Columns: []string{"time", "value"},
+		}
+		for _, v := range values {
+			r.Values = append(r.Values, []interface{}{t0.Add(time.Duration(v) * time.Second).Format(time.RFC3339Nano), v})
+		}
+		return r
+	}
+	var got []string
+	spiller := edge.NewResultSpiller(false, edge.NewSpillBatchBuffer(1), func(m edge.Message) error {
+		switch m := m.(type) {
+		case edge.BeginBatchMessage:
+			got = append(got, "begin "+m.Tags()["host"])
+		case edge.BatchPointMessage:
+			got = append(got, fmt.Sprint(m.Fields()["value"]))
+		case edge.EndBatchMessage:
+			got = append(got, "end")
+		}
+		return nil
+	})
+	chunks := []influxdb.Result{
+		{Series: []imodels.Row{row("serverA", 1, 2)}},
+		{Series: []imodels.Row{row("serverA", 3), row("serverB", 4)}},
+		{Series: []imodels.Row{row("serverB", 5, 6)}},
+	}
+	for _, c := range chunks {
+		if err := spiller.Result(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := spiller.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	exp := []string{"begin serverA", "1", "2", "3", "end", "begin serverB", "4", "5", "6", "end"}
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected messages:\ngot %v\nexp %v", got, exp)
+	}
+}
+
+// TestResultSpiller_BoundedMemory checks that the memory used to spill a large batch
+// passed in chunks does not grow with the size of the batch.
+func TestResultSpiller_BoundedMemory(t *testing.T) {
+	const (
+		chunkSize = 1000
+		chunks    = 200
+		// A batch point takes well over 100 bytes in memory,
+		// so keeping all points in memory would take more than 20MB.
+		maxGrowth = 8 * 1024 * 1024
+	)
+	t0 := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	heap := func() uint64 {
+		runtime.GC()
+		var ms runtime.MemStats
+		runtime.ReadMemStats(&ms)
+		return ms.HeapAlloc
+	}
+
+	points := 0
+	spiller := edge.NewResultSpiller(false, edge.NewSpillBatchBuffer(chunkSize), func(m edge.Message) error {
+		if _, ok := m.(edge.BatchPointMessage); ok {
+			points++
+		}
+		return nil
+	})
+	base := heap()
+	var peak uint64
+	for c := 0; c < chunks; c++ {
+		values := make([][]interface{}, chunkSize)
+		for i := range values {
+			tm := t0.Add(time.Duration(c*chunkSize+i) * time.Second)
+			values[i] = []interface{}{tm.Format(time.RFC3339Nano), json.Number("1.5"), "some string value"}
+		}
+		res := influxdb.Result{Series: []imodels.Row{{
+			Name:    "cpu",
+			Tags:    map[string]string{"host": "serverA"},
+			Columns: []string{"time", "value", "str"},
+			Values:  values,
+		}}}
+		if err := spiller.Result(res); err != nil {
+			t.Fatal(err)
+		}
+		if c%20 == 19 {
+			if h := heap(); h > peak {
+				peak = h
+			}
+		}
+	}
+	if peak > base && peak-base > maxGrowth {
+		t.Errorf("memory grew with the size of the batch: grew by %d bytes, expected at most %d", peak-base, maxGrowth)
+	}
+	if err := spiller.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := points, chunkSize*chunks; got != exp {
+		t.Errorf("unexpected number of points: got %d exp %d", got, exp)
+	}
+}
@@ -2,9 +2,17 @@ package edge
 
 import "github.com/influxdata/kapacitor/timer"
 
+// PanicHandler handles the panics recovered while a receiver processes a message.
+type PanicHandler interface {
+	// RecoverPanic is called with the value of a recovered panic.
+	// The message is dropped, unless an error is returned the receiver continues with the next message.
+	RecoverPanic(v interface{}) error
+}
+
 type timedForwardReceiver struct {
-	timer timer.Timer
-	r     ForwardReceiver
+	timer  timer.Timer
+	r      ForwardReceiver
+	panics PanicHandler
 }
 type timedForwardBufferedReceiver struct {
 	timedForwardReceiver
@@ -12,68 +20,85 @@ type timedForwardBufferedReceiver struct {
 }
 
 // NewTimedForwardReceiver creates a forward receiver which times the time spent in r.
+// If t is also a PanicHandler the panics of r are recovered and passed to it.
 func NewTimedForwardReceiver(t timer.Timer, r ForwardReceiver) ForwardReceiver {
+	panics, _ := t.(PanicHandler)
 	b, ok := r.(ForwardBufferedReceiver)
 	if ok {
 		return &timedForwardBufferedReceiver{
 			timedForwardReceiver: timedForwardReceiver{
-				timer: t,
-				r:     r,
+				timer:  t,
+				r:      r,
+				panics: panics,
 			},
 			b: b,
 		}
 	}
 	return &timedForwardReceiver{
-		timer: t,
-		r:     r,
+		timer:  t,
+		r:      r,
+		panics: panics,
+	}
+}
+
+// stop stops the timer and recovers a panic of the receiver if there is a panic handler.
+// It must be deferred.
+func (tr *timedForwardReceiver) stop(m *Message, err *error) {
+	tr.timer.Stop()
+	if tr.panics == nil {
+		return
+	}
+	if v := recover(); v != nil {
+		*m = nil
+		*err = tr.panics.RecoverPanic(v)
 	}
 }
 
 func (tr *timedForwardReceiver) BeginBatch(begin BeginBatchMessage) (m Message, err error) {
 	tr.timer.Start()
+	defer tr.stop(&m, &err)
 	m, err = tr.r.BeginBatch(begin)
-	tr.timer.Stop()
 	return
 }
 
 func (tr *timedForwardReceiver) BatchPoint(bp BatchPointMessage) (m Message, err error) {
 	tr.timer.Start()
+	defer tr.stop(&m, &err)
 	m, err = tr.r.BatchPoint(bp)
-	tr.timer.Stop()
 	return
 }
 
 func (tr *timedForwardReceiver) EndBatch(end EndBatchMessage) (m Message, err error) {
 	tr.timer.Start()
+	defer tr.stop(&m, &err)
 	m, err = tr.r.EndBatch(end)
-	tr.timer.Stop()
 	return
 }
 
 func (tr *timedForwardBufferedReceiver) BufferedBatch(batch BufferedBatchMessage) (m Message, err error) {
 	tr.timer.Start()
+	defer tr.stop(&m, &err)
 	m, err = tr.b.BufferedBatch(batch)
-	tr.timer.Stop()
 	return
 }
 
 func (tr *timedForwardReceiver) Point(p PointMessage) (m Message, err error) {
 	tr.timer.Start()
+	defer tr.stop(&m, &err)
 	m, err = tr.r.Point(p)
-	tr.timer.Stop()
 	return
 }
 
 func (tr *timedForwardReceiver) Barrier(b BarrierMessage) (m Message, err error) {
 	tr.timer.Start()
+	defer tr.stop(&m, &err)
 	m, err = tr.r.Barrier(b)
-	tr.timer.Stop()
 	return
 }
 func (tr *timedForwardReceiver) DeleteGroup(d DeleteGroupMessage) (m Message, err error) {
 	tr.timer.Start()
+	defer tr.stop(&m, &err)
 	m, err = tr.r.DeleteGroup(d)
-	tr.timer.Stop()
 	return
 }
 
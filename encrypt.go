@@ -0,0 +1,234 @@
+package kapacitor
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/keyvalue"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+const (
+	statsEncryptErrors = "encrypt_errors"
+	statsDecryptErrors = "decrypt_errors"
+)
+
+var errEncryptionNotEnabled = errors.New("encryption is not enabled, configure keys in the [encryption] section")
+
+type cryptService interface {
+	Encrypt(plaintext []byte) (string, error)
+	Decrypt(ciphertext string) ([]byte, error)
+}
+
+// cryptFunc transforms the value of a single field.
+type cryptFunc func(field string, value interface{}) (interface{}, error)
+
+// cryptFields applies f to each of the named fields present in fields.
+// The fields are copied before they are modified.
+func cryptFields(fields models.Fields, names []string, f cryptFunc) (models.Fields, error) {
+	newFields := fields
+	copied := false
+	for _, name := range names {
+		value, ok := fields[name]
+		if !ok {
+			continue
+		}
+		v, err := f(name, value)
+		if err != nil {
+			return nil, err
+		}
+		if !copied {
+			newFields = newFields.Copy()
+			copied = true
+		}
+		newFields[name] = v
+	}
+	return newFields, nil
+}
+
+type EncryptNode struct {
+	node
+	e *pipeline.EncryptNode
+
+	service cryptService
+
+	encryptErrors *expvar.Int
+}
+
+// Create a new EncryptNode which encrypts the values of fields.
+func newEncryptNode(et *ExecutingTask, n *pipeline.EncryptNode, d NodeDiagnostic) (*EncryptNode, error) {
+	if et.tm.EncryptionService == nil || !et.tm.EncryptionService.Enabled() {
+		return nil, errEncryptionNotEnabled
+	}
+	en := &EncryptNode{
+		node:          node{Node: n, et: et, diag: d},
+		e:             n,
+		service:       et.tm.EncryptionService,
+		encryptErrors: new(expvar.Int),
+	}
+	en.node.runF = en.runEncrypt
+	return en, nil
+}
+
+func (n *EncryptNode) runEncrypt(snapshot []byte) error {
+	n.statMap.Set(statsEncryptErrors, n.encryptErrors)
+	consumer := edge.NewConsumerWithReceiver(
+		n.ins[0],
+		edge.NewReceiverFromForwardReceiverWithStats(
+			n.outs,
+			edge.NewTimedForwardReceiver(n.timer, n),
+		),
+	)
+	return consumer.Consume()
+}
+
+func (n *EncryptNode) encrypt(field string, value interface{}) (interface{}, error) {
+	s, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("field %q is not a string, got %T", field, value)
+	}
+	ciphertext, err := n.service.Encrypt([]byte(s))
+	if err != nil {
+		return nil, fmt.Errorf("field %q: %v", field, err)
+	}
+	return ciphertext, nil
+}
+
+// doEncrypt encrypts the fields of p, it reports false if p should be dropped.
+func (n *EncryptNode) doEncrypt(p edge.FieldsTagsTimeSetter) bool {
+	fields, err := cryptFields(p.Fields(), n.e.Fields, n.encrypt)
+	if err != nil {
+		n.encryptErrors.Add(1)
+		n.diag.Error("failed to encrypt field, dropping point", err, keyvalue.KV("time", p.Time().String()))
+		return false
+	}
+	p.SetFields(fields)
+	return true
+}
+
+func (n *EncryptNode) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	return begin, nil
+}
+
+func (n *EncryptNode) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	bp = bp.ShallowCopy()
+	if !n.doEncrypt(bp) {
+		return nil, nil
+	}
+	return bp, nil
+}
+
+func (n *EncryptNode) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (n *EncryptNode) Point(p edge.PointMessage) (edge.Message, error) {
+	p = p.ShallowCopy()
+	if !n.doEncrypt(p) {
+		return nil, nil
+	}
+	return p, nil
+}
+
+func (n *EncryptNode) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (n *EncryptNode) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (n *EncryptNode) Done() {}
+
+type DecryptNode struct {
+	node
+	d *pipeline.DecryptNode
+
+	service cryptService
+
+	decryptErrors *expvar.Int
+}
+
+// Create a new DecryptNode which decrypts the values of fields.
+func newDecryptNode(et *ExecutingTask, n *pipeline.DecryptNode, d NodeDiagnostic) (*DecryptNode, error) {
+	if et.tm.EncryptionService == nil || !et.tm.EncryptionService.Enabled() {
+		return nil, errEncryptionNotEnabled
+	}
+	dn := &DecryptNode{
+		node:          node{Node: n, et: et, diag: d},
+		d:             n,
+		service:       et.tm.EncryptionService,
+		decryptErrors: new(expvar.Int),
+	}
+	dn.node.runF = dn.runDecrypt
+	return dn, nil
+}
+
+func (n *DecryptNode) runDecrypt(snapshot []byte) error {
+	n.statMap.Set(statsDecryptErrors, n.decryptErrors)
+	consumer := edge.NewConsumerWithReceiver(
+		n.ins[0],
+		edge.NewReceiverFromForwardReceiverWithStats(
+			n.outs,
+			edge.NewTimedForwardReceiver(n.timer, n),
+		),
+	)
+	return consumer.Consume()
+}
+
+func (n *DecryptNode) decrypt(field string, value interface{}) (interface{}, error) {
+	s, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("field %q is not a string, got %T", field, value)
+	}
+	plaintext, err := n.service.Decrypt(s)
+	if err != nil {
+		return nil, fmt.Errorf("field %q: %v", field, err)
+	}
+	return string(plaintext), nil
+}
+
+// doDecrypt decrypts the fields of p, it reports false if p should be dropped.
+func (n *DecryptNode) doDecrypt(p edge.FieldsTagsTimeSetter) bool {
+	fields, err := cryptFields(p.Fields(), n.d.Fields, n.decrypt)
+	if err != nil {
+		n.decryptErrors.Add(1)
+		n.diag.Error("failed to decrypt field, dropping point", err, keyvalue.KV("time", p.Time().String()))
+		return false
+	}
+	p.SetFields(fields)
+	return true
+}
+
+func (n *DecryptNode) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	return begin, nil
+}
+
+func (n *DecryptNode) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	bp = bp.ShallowCopy()
+	if !n.doDecrypt(bp) {
+		return nil, nil
+	}
+	return bp, nil
+}
+
+func (n *DecryptNode) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (n *DecryptNode) Point(p edge.PointMessage) (edge.Message, error) {
+	p = p.ShallowCopy()
+	if !n.doDecrypt(p) {
+		return nil, nil
+	}
+	return p, nil
+}
+
+func (n *DecryptNode) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (n *DecryptNode) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (n *DecryptNode) Done() {}
@@ -63,10 +63,7 @@ func newEvalNode(et *ExecutingTask, n *pipeline.EvalNode, d NodeDiagnostic) (*Ev
 }
 
 func (n *EvalNode) runEval(snapshot []byte) error {
-	consumer := edge.NewGroupedConsumer(
-		n.ins[0],
-		n,
-	)
+	consumer := n.newGroupedConsumer(n)
 	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
 
 	return consumer.Consume()
@@ -178,9 +175,13 @@ func (n *EvalNode) eval(expressions []stateful.Expression, p edge.FieldsTagsTime
 type evalGroup struct {
 	n           *EvalNode
 	expressions []stateful.Expression
+
+	// name of the current batch
+	name string
 }
 
 func (g *evalGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	g.name = begin.Name()
 	begin = begin.ShallowCopy()
 	begin.SetSizeHint(0)
 	return begin, nil
@@ -188,7 +189,7 @@ func (g *evalGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, erro
 
 func (g *evalGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
 	bp = bp.ShallowCopy()
-	if g.doEval(bp) {
+	if g.doEval(g.name, bp) {
 		return bp, nil
 	}
 	return nil, nil
@@ -200,19 +201,20 @@ func (g *evalGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
 
 func (g *evalGroup) Point(p edge.PointMessage) (edge.Message, error) {
 	p = p.ShallowCopy()
-	if g.doEval(p) {
+	if g.doEval(p.Name(), p) {
 		return p, nil
 	}
 	return nil, nil
 }
 
-func (g *evalGroup) doEval(p edge.FieldsTagsTimeSetter) bool {
+func (g *evalGroup) doEval(name string, p edge.FieldsTagsTimeSetter) bool {
 	err := g.n.eval(g.expressions, p)
 	if err != nil {
 		if !g.n.e.QuietFlag {
 			g.n.diag.Error("error evaluating expression", err)
 		}
 		// Skip bad point
+		g.n.deadLetter(name, p, err)
 		return false
 	}
 	return true
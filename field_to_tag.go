@@ -0,0 +1,262 @@
+package kapacitor
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/keyvalue"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+type FieldToTagNode struct {
+	node
+	f *pipeline.FieldToTagNode
+}
+
+// Create a new FieldToTagNode which converts a field of each point to a tag.
+func newFieldToTagNode(et *ExecutingTask, n *pipeline.FieldToTagNode, d NodeDiagnostic) (*FieldToTagNode, error) {
+	fn := &FieldToTagNode{
+		node: node{Node: n, et: et, diag: d},
+		f:    n,
+	}
+	fn.node.runF = fn.runFieldToTag
+	return fn, nil
+}
+
+func (n *FieldToTagNode) runFieldToTag(snapshot []byte) error {
+	consumer := edge.NewConsumerWithReceiver(
+		n.ins[0],
+		edge.NewReceiverFromForwardReceiverWithStats(
+			n.outs,
+			edge.NewTimedForwardReceiver(n.timer, n),
+		),
+	)
+	return consumer.Consume()
+}
+
+func (n *FieldToTagNode) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	return begin, nil
+}
+
+func (n *FieldToTagNode) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	fields, tags, ok := n.convert(bp.Fields(), bp.Tags())
+	if !ok {
+		return bp, nil
+	}
+	bp = bp.ShallowCopy()
+	bp.SetFields(fields)
+	bp.SetTags(tags)
+	return bp, nil
+}
+
+func (n *FieldToTagNode) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (n *FieldToTagNode) Point(p edge.PointMessage) (edge.Message, error) {
+	fields, tags, ok := n.convert(p.Fields(), p.Tags())
+	if !ok {
+		return p, nil
+	}
+	p = p.ShallowCopy()
+	p.SetFields(fields)
+	dims := p.Dimensions()
+	if n.f.RegroupFlag && !isDimension(n.f.As, dims) {
+		tagNames := make([]string, len(dims.TagNames), len(dims.TagNames)+1)
+		copy(tagNames, dims.TagNames)
+		tagNames = append(tagNames, n.f.As)
+		sort.Strings(tagNames)
+		dims = models.Dimensions{
+			TagNames: tagNames,
+			ByName:   dims.ByName,
+		}
+	}
+	p.SetTagsAndDimensions(tags, dims)
+	return p, nil
+}
+
+func (n *FieldToTagNode) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (n *FieldToTagNode) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (n *FieldToTagNode) Done() {}
+
+// convert returns copies of fields and tags with the field converted to the tag.
+// Returns false if the field is missing or cannot be converted.
+func (n *FieldToTagNode) convert(fields models.Fields, tags models.Tags) (models.Fields, models.Tags, bool) {
+	v, ok := fields[n.f.Field]
+	if !ok {
+		return nil, nil, false
+	}
+	value, err := fieldTagValue(v)
+	if err != nil {
+		n.diag.Error("cannot convert field to tag", err,
+			keyvalue.KV("field", n.f.Field),
+		)
+		return nil, nil, false
+	}
+	if !n.f.KeepFlag {
+		fields = fields.Copy()
+		delete(fields, n.f.Field)
+	}
+	tags = tags.Copy()
+	tags[n.f.As] = value
+	return fields, tags, true
+}
+
+type TagToFieldNode struct {
+	node
+	t *pipeline.TagToFieldNode
+
+	// dimensions of the current batch
+	batchDims models.Dimensions
+}
+
+// Create a new TagToFieldNode which converts a tag of each point to a field.
+func newTagToFieldNode(et *ExecutingTask, n *pipeline.TagToFieldNode, d NodeDiagnostic) (*TagToFieldNode, error) {
+	tn := &TagToFieldNode{
+		node: node{Node: n, et: et, diag: d},
+		t:    n,
+	}
+	tn.node.runF = tn.runTagToField
+	return tn, nil
+}
+
+func (n *TagToFieldNode) runTagToField(snapshot []byte) error {
+	consumer := edge.NewConsumerWithReceiver(
+		n.ins[0],
+		edge.NewReceiverFromForwardReceiverWithStats(
+			n.outs,
+			edge.NewTimedForwardReceiver(n.timer, n),
+		),
+	)
+	return consumer.Consume()
+}
+
+func (n *TagToFieldNode) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	n.batchDims = begin.Dimensions()
+	if !n.removeTag(n.batchDims) || !isDimension(n.t.Tag, n.batchDims) {
+		return begin, nil
+	}
+	// The tag is a dimension removed from the group of the batch.
+	begin = begin.ShallowCopy()
+	tags := begin.Tags().Copy()
+	delete(tags, n.t.Tag)
+	begin.SetTagsAndDimensions(tags, n.removeDimension(n.batchDims))
+	return begin, nil
+}
+
+func (n *TagToFieldNode) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	fields, tags, ok := n.convert(bp.Fields(), bp.Tags(), n.batchDims)
+	if !ok {
+		return bp, nil
+	}
+	bp = bp.ShallowCopy()
+	bp.SetFields(fields)
+	bp.SetTags(tags)
+	return bp, nil
+}
+
+func (n *TagToFieldNode) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (n *TagToFieldNode) Point(p edge.PointMessage) (edge.Message, error) {
+	dims := p.Dimensions()
+	fields, tags, ok := n.convert(p.Fields(), p.Tags(), dims)
+	if !ok {
+		return p, nil
+	}
+	p = p.ShallowCopy()
+	p.SetFields(fields)
+	if n.removeTag(dims) && isDimension(n.t.Tag, dims) {
+		dims = n.removeDimension(dims)
+	}
+	p.SetTagsAndDimensions(tags, dims)
+	return p, nil
+}
+
+func (n *TagToFieldNode) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (n *TagToFieldNode) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (n *TagToFieldNode) Done() {}
+
+// removeTag reports whether the tag is removed from points with the dimensions dims.
+func (n *TagToFieldNode) removeTag(dims models.Dimensions) bool {
+	if n.t.KeepFlag {
+		return false
+	}
+	return n.t.RegroupFlag || !isDimension(n.t.Tag, dims)
+}
+
+func (n *TagToFieldNode) removeDimension(dims models.Dimensions) models.Dimensions {
+	tagNames := make([]string, 0, len(dims.TagNames))
+	for _, dim := range dims.TagNames {
+		if dim != n.t.Tag {
+			tagNames = append(tagNames, dim)
+		}
+	}
+	return models.Dimensions{
+		TagNames: tagNames,
+		ByName:   dims.ByName,
+	}
+}
+
+// convert returns copies of fields and tags with the tag converted to the field.
+// Returns false if the tag is missing or cannot be converted.
+func (n *TagToFieldNode) convert(fields models.Fields, tags models.Tags, dims models.Dimensions) (models.Fields, models.Tags, bool) {
+	v, ok := tags[n.t.Tag]
+	if !ok {
+		return nil, nil, false
+	}
+	var value interface{}
+	var err error
+	switch n.t.FieldType {
+	case pipeline.TagToFieldInt:
+		value, err = strconv.ParseInt(v, 10, 64)
+	case pipeline.TagToFieldFloat:
+		value, err = strconv.ParseFloat(v, 64)
+	case pipeline.TagToFieldBool:
+		value, err = strconv.ParseBool(v)
+	default:
+		value = v
+	}
+	if err != nil {
+		n.diag.Error("cannot convert tag to field", err,
+			keyvalue.KV("tag", n.t.Tag),
+			keyvalue.KV("type", n.t.FieldType),
+		)
+		return nil, nil, false
+	}
+	fields = fields.Copy()
+	fields[n.t.As] = value
+	if n.removeTag(dims) {
+		tags = tags.Copy()
+		delete(tags, n.t.Tag)
+	}
+	return fields, tags, true
+}
+
+// fieldTagValue converts a field value to a tag value.
+func fieldTagValue(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	default:
+		return "", fmt.Errorf("unsupported type %T", v)
+	}
+}
@@ -0,0 +1,182 @@
+package kapacitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/keyvalue"
+	"github.com/influxdata/kapacitor/models"
+)
+
+// groupState is the state of a group that is saved in the task snapshots
+// and restored when the task restarts.
+type groupState interface {
+	// snapshotState returns the encoded state of the group.
+	snapshotState() ([]byte, error)
+	// restoreState restores the state from the data returned by snapshotState.
+	// The state is left unchanged if an error is returned.
+	restoreState(data []byte) error
+}
+
+// groupStateSnapshot is the snapshot of the state of the groups of a node.
+type groupStateSnapshot struct {
+	// Version of the encoding of the state of the groups.
+	Version int                                `json:"version"`
+	Groups  map[models.GroupID]json.RawMessage `json:"groups"`
+}
+
+// groupStates keeps the state of the groups of a node for the task snapshots.
+// The receivers of the groups hold the lock while processing messages,
+// so that a snapshot never reads the state of a group while it changes.
+type groupStates struct {
+	// version of the encoding of the state of the groups.
+	// Snapshots of other versions are discarded, increment it when the encoding changes.
+	version int
+	diag    NodeDiagnostic
+
+	mu     sync.Mutex
+	groups map[models.GroupID]groupState
+	// restored is the state of the groups in the snapshot the node started from,
+	// the state of a group is restored when the group is created.
+	restored map[models.GroupID]json.RawMessage
+}
+
+func newGroupStates(version int, diag NodeDiagnostic) *groupStates {
+	return &groupStates{
+		version: version,
+		diag:    diag,
+		groups:  make(map[models.GroupID]groupState),
+	}
+}
+
+// load loads the state of the groups from the data returned by snapshot.
+// Data that cannot be decoded or of another version is discarded, the groups then start anew.
+func (s *groupStates) load(data []byte) {
+	if len(data) == 0 {
+		return
+	}
+	var snapshot groupStateSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		s.diag.Error("discarding group state snapshot", err)
+		return
+	}
+	if snapshot.Version != s.version {
+		s.diag.Error("discarding group state snapshot", fmt.Errorf("incompatible version %d, expected %d", snapshot.Version, s.version))
+		return
+	}
+	s.mu.Lock()
+	s.restored = snapshot.Groups
+	s.mu.Unlock()
+}
+
+// add keeps the state of a new group, first restoring it from the loaded snapshot.
+// The returned receiver forwards the messages of the group to r while holding the lock.
+func (s *groupStates) add(group models.GroupID, state groupState, r edge.ForwardReceiver) edge.ForwardReceiver {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if data, ok := s.restored[group]; ok {
+		delete(s.restored, group)
+		if err := state.restoreState(data); err != nil {
+			s.diag.Error("discarding group state", err, keyvalue.KV("group", string(group)))
+		}
+	}
+	s.groups[group] = state
+	gr := &groupStateReceiver{
+		s:     s,
+		group: group,
+		r:     r,
+	}
+	if b, ok := r.(edge.ForwardBufferedReceiver); ok {
+		return &groupStateBufferedReceiver{
+			groupStateReceiver: gr,
+			b:                  b,
+		}
+	}
+	return gr
+}
+
+// snapshot returns the encoded state of the groups.
+func (s *groupStates) snapshot() ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snapshot := groupStateSnapshot{
+		Version: s.version,
+		Groups:  make(map[models.GroupID]json.RawMessage, len(s.restored)+len(s.groups)),
+	}
+	// Restored groups that have not been created yet keep their state.
+	for group, data := range s.restored {
+		snapshot.Groups[group] = data
+	}
+	for group, state := range s.groups {
+		data, err := state.snapshotState()
+		if err != nil {
+			return nil, err
+		}
+		snapshot.Groups[group] = data
+	}
+	return json.Marshal(snapshot)
+}
+
+// groupStateReceiver forwards the messages of a group while holding the lock of the group states.
+type groupStateReceiver struct {
+	s     *groupStates
+	group models.GroupID
+	r     edge.ForwardReceiver
+}
+
+func (r *groupStateReceiver) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	return r.r.BeginBatch(begin)
+}
+
+func (r *groupStateReceiver) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	return r.r.BatchPoint(bp)
+}
+
+func (r *groupStateReceiver) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	return r.r.EndBatch(end)
+}
+
+func (r *groupStateReceiver) Point(p edge.PointMessage) (edge.Message, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	return r.r.Point(p)
+}
+
+func (r *groupStateReceiver) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	return r.r.Barrier(b)
+}
+
+// DeleteGroup drops the state of the deleted group from the snapshots.
+func (r *groupStateReceiver) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if d.GroupID() == r.group {
+		delete(r.s.groups, r.group)
+	}
+	return r.r.DeleteGroup(d)
+}
+
+func (r *groupStateReceiver) Done() {
+	r.r.Done()
+}
+
+type groupStateBufferedReceiver struct {
+	*groupStateReceiver
+	b edge.ForwardBufferedReceiver
+}
+
+func (r *groupStateBufferedReceiver) BufferedBatch(batch edge.BufferedBatchMessage) (edge.Message, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	return r.b.BufferedBatch(batch)
+}
@@ -0,0 +1,128 @@
+package kapacitor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/alert"
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+func TestGroupStates_Restore(t *testing.T) {
+	groupA := models.GroupID("host=A")
+	groupB := models.GroupID("host=B")
+	t0 := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	s := newGroupStates(1, &windowNodeDiagnostic{})
+	a := &stateCountTracker{}
+	ra := s.add(groupA, a, &stateTrackingGroup{})
+	b := &stateCountTracker{}
+	rb := s.add(groupB, b, &stateTrackingGroup{})
+	a.track(t0, true)
+	a.track(t0, true)
+	b.track(t0, true)
+	if _, err := rb.DeleteGroup(edge.NewDeleteGroupMessage(groupB)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ra.Barrier(edge.NewBarrierMessage(edge.GroupInfo{ID: groupA}, t0)); err != nil {
+		t.Fatal(err)
+	}
+	data, err := s.snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restored := newGroupStates(1, &windowNodeDiagnostic{})
+	restored.load(data)
+	a = &stateCountTracker{}
+	restored.add(groupA, a, &stateTrackingGroup{})
+	if got, exp := a.count, int64(2); got != exp {
+		t.Errorf("unexpected restored count: got %d exp %d", got, exp)
+	}
+	// The state of deleted groups is not persisted.
+	b = &stateCountTracker{}
+	restored.add(groupB, b, &stateTrackingGroup{})
+	if got, exp := b.count, int64(0); got != exp {
+		t.Errorf("unexpected count of deleted group: got %d exp %d", got, exp)
+	}
+
+	// Groups not created yet keep their state in the snapshots.
+	pending := newGroupStates(1, &windowNodeDiagnostic{})
+	pending.load(data)
+	data, err = pending.snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored = newGroupStates(1, &windowNodeDiagnostic{})
+	restored.load(data)
+	a = &stateCountTracker{}
+	restored.add(groupA, a, &stateTrackingGroup{})
+	if got, exp := a.count, int64(2); got != exp {
+		t.Errorf("unexpected count of pending group: got %d exp %d", got, exp)
+	}
+}
+
+func TestGroupStates_VersionMismatch(t *testing.T) {
+	group := models.GroupID("host=A")
+	s := newGroupStates(1, &windowNodeDiagnostic{})
+	sdt := &stateDurationTracker{sd: &pipeline.StateDurationNode{Unit: time.Second}}
+	s.add(group, sdt, &stateTrackingGroup{})
+	sdt.track(time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC), true)
+	data, err := s.snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, data := range [][]byte{data, []byte("not json")} {
+		restored := newGroupStates(2, &windowNodeDiagnostic{})
+		restored.load(data)
+		sdt = &stateDurationTracker{}
+		restored.add(group, sdt, &stateTrackingGroup{})
+		if !sdt.startTime.IsZero() {
+			t.Errorf("unexpected restored start time %v from %q", sdt.startTime, data)
+		}
+	}
+}
+
+func TestAlertState_RestoreState(t *testing.T) {
+	n := &AlertNode{a: &pipeline.AlertNode{AlertNodeData: &pipeline.AlertNodeData{}}}
+	t0 := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	state := &alertState{
+		n:              n,
+		history:        []alert.Level{alert.OK, alert.Critical, alert.Warning},
+		idx:            2,
+		flapping:       true,
+		changed:        true,
+		firstTriggered: t0,
+		lastTriggered:  t0.Add(time.Minute),
+	}
+	data, err := state.snapshotState()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restored := &alertState{n: n, history: make([]alert.Level, 3)}
+	if err := restored.restoreState(data); err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := restored.currentLevel(), alert.Warning; got != exp {
+		t.Errorf("unexpected level: got %v exp %v", got, exp)
+	}
+	if !restored.flapping || !restored.changed || restored.expired {
+		t.Errorf("unexpected flags: %+v", restored)
+	}
+	if !restored.firstTriggered.Equal(t0) || !restored.lastTriggered.Equal(t0.Add(time.Minute)) {
+		t.Errorf("unexpected trigger times: %v %v", restored.firstTriggered, restored.lastTriggered)
+	}
+
+	// A history of another size is not restored.
+	restored = &alertState{n: n, history: make([]alert.Level, 5)}
+	if err := restored.restoreState(data); err == nil {
+		t.Error("expected error restoring history of different size")
+	}
+	if restored.flapping || restored.idx != 0 {
+		t.Errorf("unexpected partially restored state: %+v", restored)
+	}
+}
@@ -0,0 +1,125 @@
+package kapacitor
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+type HeartbeatNode struct {
+	node
+	h *pipeline.HeartbeatNode
+
+	taskID string
+	// number of points received since the last heartbeat
+	points int64
+
+	heartbeats *expvar.Int
+
+	wg       sync.WaitGroup
+	stopC    chan struct{}
+	stopOnce sync.Once
+}
+
+// Create a new HeartbeatNode which emits a heartbeat point on an interval.
+func newHeartbeatNode(et *ExecutingTask, n *pipeline.HeartbeatNode, d NodeDiagnostic) (*HeartbeatNode, error) {
+	hn := &HeartbeatNode{
+		node:       node{Node: n, et: et, diag: d},
+		h:          n,
+		taskID:     et.Task.ID,
+		heartbeats: new(expvar.Int),
+		stopC:      make(chan struct{}),
+	}
+	hn.node.runF = hn.runHeartbeat
+	hn.node.stopF = hn.stopHeartbeat
+	return hn, nil
+}
+
+func (n *HeartbeatNode) runHeartbeat([]byte) error {
+	n.statMap.Set(statsHeartbeats, n.heartbeats)
+	n.startEmitter()
+	// The heartbeats must stop before the outputs are closed.
+	defer n.stopHeartbeat()
+	consumer := edge.NewConsumerWithReceiver(
+		n.ins[0],
+		n,
+	)
+	return consumer.Consume()
+}
+
+func (n *HeartbeatNode) startEmitter() {
+	n.wg.Add(1)
+	go n.emitter()
+}
+
+// stopHeartbeat stops emitting heartbeats, it is safe to call more than once.
+func (n *HeartbeatNode) stopHeartbeat() {
+	n.stopOnce.Do(func() {
+		close(n.stopC)
+		n.wg.Wait()
+	})
+}
+
+// emitter emits a heartbeat every interval until the node is stopped.
+func (n *HeartbeatNode) emitter() {
+	defer n.wg.Done()
+	ticker := time.NewTicker(n.h.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-n.stopC:
+			return
+		case now := <-ticker.C:
+			if err := n.emit(now); err != nil {
+				n.diag.Error("failed to emit heartbeat", err)
+				return
+			}
+		}
+	}
+}
+
+// emit a heartbeat point with the time now.
+func (n *HeartbeatNode) emit(now time.Time) error {
+	p := edge.NewPointMessage(
+		pipeline.HeartbeatMeasurement, "", "",
+		models.Dimensions{TagNames: []string{pipeline.HeartbeatTaskTag}},
+		models.Fields{pipeline.HeartbeatPointsField: atomic.SwapInt64(&n.points, 0)},
+		models.Tags{pipeline.HeartbeatTaskTag: n.taskID},
+		now.UTC(),
+	)
+	n.heartbeats.Add(1)
+	return edge.Forward(n.outs, p)
+}
+
+func (n *HeartbeatNode) BeginBatch(begin edge.BeginBatchMessage) error {
+	return nil
+}
+
+func (n *HeartbeatNode) BatchPoint(bp edge.BatchPointMessage) error {
+	atomic.AddInt64(&n.points, 1)
+	return nil
+}
+
+func (n *HeartbeatNode) EndBatch(end edge.EndBatchMessage) error {
+	return nil
+}
+
+func (n *HeartbeatNode) Point(p edge.PointMessage) error {
+	atomic.AddInt64(&n.points, 1)
+	return nil
+}
+
+func (n *HeartbeatNode) Barrier(b edge.BarrierMessage) error {
+	return nil
+}
+
+func (n *HeartbeatNode) DeleteGroup(d edge.DeleteGroupMessage) error {
+	return nil
+}
+
+func (n *HeartbeatNode) Done() {}
@@ -0,0 +1,116 @@
+// Package hll implements the HyperLogLog algorithm
+// for estimating the number of distinct values in a set using a fixed amount of memory.
+//
+// A sketch with precision p uses 2^p registers of one byte each,
+// the standard error of its estimates is 1.04/sqrt(2^p).
+package hll
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/bits"
+)
+
+const (
+	// MinPrecision is the smallest supported precision, using 16 registers.
+	MinPrecision = 4
+	// MaxPrecision is the largest supported precision, using 262144 registers.
+	MaxPrecision = 18
+	// DefaultPrecision uses 16384 registers with a standard error of about 0.8%.
+	DefaultPrecision = 14
+)
+
+// Sketch estimates the number of distinct values added to it.
+type Sketch struct {
+	p         uint
+	registers []uint8
+}
+
+// New creates an empty sketch with the given precision.
+func New(precision int) (*Sketch, error) {
+	if precision < MinPrecision || precision > MaxPrecision {
+		return nil, fmt.Errorf("precision must be between %d and %d, got %d", MinPrecision, MaxPrecision, precision)
+	}
+	return &Sketch{
+		p:         uint(precision),
+		registers: make([]uint8, 1<<uint(precision)),
+	}, nil
+}
+
+// StandardError returns the relative standard error of the estimates of a sketch with the given precision.
+func StandardError(precision int) float64 {
+	return 1.04 / math.Sqrt(float64(uint(1)<<uint(precision)))
+}
+
+// Add adds a value to the sketch.
+func (s *Sketch) Add(v []byte) {
+	x := hash(v)
+	i := x >> (64 - s.p)
+	// The remaining bits determine the rank,
+	// the guard bit bounds it by the number of remaining bits.
+	w := x<<s.p | 1<<(s.p-1)
+	rank := uint8(bits.LeadingZeros64(w) + 1)
+	if rank > s.registers[i] {
+		s.registers[i] = rank
+	}
+}
+
+// AddString adds a string value to the sketch.
+func (s *Sketch) AddString(v string) {
+	s.Add([]byte(v))
+}
+
+// Count returns the estimated number of distinct values added to the sketch.
+func (s *Sketch) Count() uint64 {
+	m := float64(len(s.registers))
+	sum := 0.0
+	zeros := 0
+	for _, r := range s.registers {
+		sum += 1 / float64(uint64(1)<<r)
+		if r == 0 {
+			zeros++
+		}
+	}
+	estimate := alpha(len(s.registers)) * m * m / sum
+	// Use linear counting for small cardinalities where the raw estimate is biased.
+	// With 64 bit hashes no correction is needed for large cardinalities.
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return uint64(estimate + 0.5)
+}
+
+// Reset empties the sketch, keeping its registers allocated.
+func (s *Sketch) Reset() {
+	for i := range s.registers {
+		s.registers[i] = 0
+	}
+}
+
+// alpha is the bias correction constant for m registers.
+func alpha(m int) float64 {
+	switch m {
+	case 16:
+		return 0.673
+	case 32:
+		return 0.697
+	case 64:
+		return 0.709
+	}
+	return 0.7213 / (1 + 1.079/float64(m))
+}
+
+// hash returns a 64 bit hash of v.
+// FNV-1a is finalized with the MurmurHash3 mixer, spreading its output over all bits as HyperLogLog requires.
+func hash(v []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(v)
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
@@ -0,0 +1,69 @@
+package hll_test
+
+import (
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/influxdata/kapacitor/hll"
+)
+
+func TestSketch_Count(t *testing.T) {
+	testCases := []struct {
+		precision int
+		distinct  int
+	}{
+		{precision: 4, distinct: 1000},
+		{precision: 10, distinct: 10},
+		{precision: 10, distinct: 5000},
+		{precision: 14, distinct: 1000},
+		{precision: 14, distinct: 100000},
+		{precision: 18, distinct: 500000},
+	}
+	for _, tc := range testCases {
+		t.Run(strconv.Itoa(tc.precision)+"/"+strconv.Itoa(tc.distinct), func(t *testing.T) {
+			s, err := hll.New(tc.precision)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < tc.distinct; i++ {
+				s.AddString("value" + strconv.Itoa(i))
+				// Duplicates do not change the estimate.
+				s.AddString("value" + strconv.Itoa(i/2))
+			}
+			got := float64(s.Count())
+			// Allow four standard errors, failing with a probability below 0.01%.
+			bound := 4 * hll.StandardError(tc.precision) * float64(tc.distinct)
+			if diff := math.Abs(got - float64(tc.distinct)); diff > bound {
+				t.Errorf("estimate %v of %d distinct values is off by %v, more than %v", got, tc.distinct, diff, bound)
+			}
+		})
+	}
+}
+
+func TestSketch_Empty(t *testing.T) {
+	s, err := hll.New(hll.DefaultPrecision)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Count(); got != 0 {
+		t.Errorf("unexpected count of empty sketch: %d", got)
+	}
+	s.AddString("a")
+	s.AddString("a")
+	if got := s.Count(); got != 1 {
+		t.Errorf("unexpected count of single value: %d", got)
+	}
+	s.Reset()
+	if got := s.Count(); got != 0 {
+		t.Errorf("unexpected count after reset: %d", got)
+	}
+}
+
+func TestNew_InvalidPrecision(t *testing.T) {
+	for _, p := range []int{hll.MinPrecision - 1, hll.MaxPrecision + 1} {
+		if _, err := hll.New(p); err == nil {
+			t.Errorf("expected error for precision %d", p)
+		}
+	}
+}
@@ -3,8 +3,10 @@ package kapacitor
 import (
 	"encoding/json"
 	"fmt"
+	"hash/fnv"
 	"net/http"
 	"path"
+	"strings"
 	"sync"
 
 	"github.com/influxdata/kapacitor/edge"
@@ -47,16 +49,25 @@ func (n *HTTPOutNode) runOut([]byte) error {
 		n.mu.RLock()
 		defer n.mu.RUnlock()
 
-		if b, err := json.Marshal(n.result); err != nil {
+		b, err := json.Marshal(n.result)
+		if err != nil {
 			httpd.HttpError(
 				w,
 				err.Error(),
 				true,
 				http.StatusInternalServerError,
 			)
-		} else {
-			_, _ = w.Write(b)
+			return
 		}
+		h := fnv.New64a()
+		_, _ = h.Write(b)
+		etag := fmt.Sprintf(`"%x"`, h.Sum64())
+		w.Header().Set("ETag", etag)
+		if etagMatch(req.Header.Get("If-None-Match"), etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_, _ = w.Write(b)
 	}
 
 	p := path.Join("/tasks/", n.et.Task.ID, n.c.Endpoint)
@@ -86,6 +97,18 @@ func (n *HTTPOutNode) runOut([]byte) error {
 	return consumer.Consume()
 }
 
+// etagMatch reports whether the If-None-Match header matches etag.
+// The comparison is weak, so the weakened ETag of a gzipped response matches as well.
+func etagMatch(ifNoneMatch, etag string) bool {
+	for _, t := range strings.Split(ifNoneMatch, ",") {
+		t = strings.TrimSpace(t)
+		if t == "*" || strings.TrimPrefix(t, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
 // Update the result structure with a row.
 func (n *HTTPOutNode) updateResultWithRow(idx int, row *models.Row) {
 	n.mu.Lock()
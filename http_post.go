@@ -40,7 +40,7 @@ func newHTTPPostNode(et *ExecutingTask, n *pipeline.HTTPPostNode, d NodeDiagnost
 
 	// Should only ever be 0 or 1 from validation of n
 	if len(n.URLs) == 1 {
-		e := httppost.NewEndpoint(n.URLs[0], nil, httppost.BasicAuth{}, nil, nil)
+		e := httppost.NewEndpoint(n.URLs[0], nil, httppost.BasicAuth{}, httppost.Signature{}, nil, nil)
 		hn.endpoint = e
 	}
 
@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -32,6 +33,25 @@ type Client interface {
 	Query(q Query) (*Response, error)
 }
 
+// FluxClient is a Client that can also query InfluxDB 2.x with Flux.
+type FluxClient interface {
+	Client
+
+	// QueryFlux makes a Flux query with the InfluxDB 2.x query API.
+	// The tables of the result are converted to series, see FluxQuery.
+	QueryFlux(q FluxQuery) (*Response, error)
+}
+
+// ChunkedClient is a Client that can also stream the response of a query in chunks.
+type ChunkedClient interface {
+	Client
+
+	// QueryChunked makes an InfluxDB Query on the database, requesting the response in chunks of at most chunkSize rows.
+	// Each chunk is passed to f as soon as it is decoded, the series of a chunk may continue in the next chunk.
+	// Errors of the response and errors returned by f end the query and are returned.
+	QueryChunked(q Query, chunkSize int, f func(Result) error) error
+}
+
 type ClientUpdater interface {
 	Client
 	Update(new Config) error
@@ -87,6 +107,8 @@ const (
 	NoAuthentication AuthenticationMethod = iota
 	UserAuthentication
 	BearerAuthentication
+	// TokenAuthentication authenticates with an InfluxDB 2.x API token.
+	TokenAuthentication
 )
 
 // Set of credentials depending on the authentication method
@@ -98,7 +120,7 @@ type Credentials struct {
 	Username string
 	Password string
 
-	// BearerAuthentication fields
+	// BearerAuthentication and TokenAuthentication fields
 
 	Token string
 }
@@ -219,6 +241,11 @@ func (c *HTTPClient) url() url.URL {
 	return urls[i]
 }
 
+// bodyDecoder decodes a response body that is not a single JSON document.
+type bodyDecoder interface {
+	decodeBody(r io.Reader) error
+}
+
 func (c *HTTPClient) do(req *http.Request, result interface{}, codes ...int) (*http.Response, error) {
 	// Get current config
 	config := c.loadConfig()
@@ -230,6 +257,8 @@ func (c *HTTPClient) do(req *http.Request, result interface{}, codes ...int) (*h
 		req.SetBasicAuth(cred.Username, cred.Password)
 	case BearerAuthentication:
 		req.Header.Set("Authorization", "Bearer "+cred.Token)
+	case TokenAuthentication:
+		req.Header.Set("Authorization", "Token "+cred.Token)
 	default:
 		return nil, errors.New("unknown authentication method set")
 	}
@@ -271,6 +300,9 @@ func (c *HTTPClient) do(req *http.Request, result interface{}, codes ...int) (*h
 		return nil, fmt.Errorf("invalid response: code %d: body: %s", resp.StatusCode, string(body))
 	}
 	if result != nil {
+		if bd, ok := result.(bodyDecoder); ok {
+			return resp, bd.decodeBody(resp.Body)
+		}
 		d := json.NewDecoder(resp.Body)
 		d.UseNumber()
 		err := d.Decode(result)
@@ -402,6 +434,55 @@ func (c *HTTPClient) Query(q Query) (*Response, error) {
 	return response, nil
 }
 
+// QueryChunked sends a command to the server and passes the Response to f one chunk at a time.
+func (c *HTTPClient) QueryChunked(q Query, chunkSize int, f func(Result) error) error {
+	u := c.url()
+	u.Path = "query"
+	v := url.Values{}
+	v.Set("q", q.Command)
+	v.Set("db", q.Database)
+	if q.Precision != "" {
+		v.Set("epoch", q.Precision)
+	}
+	v.Set("chunked", "true")
+	v.Set("chunk_size", strconv.Itoa(chunkSize))
+	u.RawQuery = v.Encode()
+
+	req, err := http.NewRequest("POST", u.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.do(req, chunkedResponse{f: f}, http.StatusOK)
+	return err
+}
+
+// chunkedResponse decodes a chunked response, a sequence of responses each holding a chunk of the results.
+type chunkedResponse struct {
+	f func(Result) error
+}
+
+func (r chunkedResponse) decodeBody(body io.Reader) error {
+	d := json.NewDecoder(body)
+	d.UseNumber()
+	for {
+		response := Response{}
+		if err := d.Decode(&response); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return errors.Wrap(err, "failed to decode JSON")
+		}
+		if err := response.Error(); err != nil {
+			return err
+		}
+		for _, res := range response.Results {
+			if err := r.f(res); err != nil {
+				return err
+			}
+		}
+	}
+}
+
 // BatchPoints is an interface into a batched grouping of points to write into
 // InfluxDB together. BatchPoints is NOT thread-safe, you must create a separate
 // batch for each goroutine.
@@ -4,9 +4,11 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"strings"
 	"sync"
 	"testing"
+	"time"
 )
 
 func TestClient_Query(t *testing.T) {
@@ -27,6 +29,68 @@ func TestClient_Query(t *testing.T) {
 	}
 }
 
+func TestClient_QueryChunked(t *testing.T) {
+	// received is closed once the first chunk has been passed on,
+	// the second chunk is only sent afterwards so the chunks must be passed on as they arrive.
+	received := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got, exp := r.URL.Query().Get("chunked"), "true"; got != exp {
+			t.Errorf("unexpected chunked parameter: got %s exp %s", got, exp)
+		}
+		if got, exp := r.URL.Query().Get("chunk_size"), "2"; got != exp {
+			t.Errorf("unexpected chunk_size parameter: got %s exp %s", got, exp)
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"results":[{"series":[{"name":"cpu","columns":["time","value"],"values":[["1970-01-01T00:00:00Z",1],["1970-01-01T00:00:01Z",2]]}]}]}` + "\n"))
+		w.(http.Flusher).Flush()
+		select {
+		case <-received:
+		case <-time.After(5 * time.Second):
+			t.Error("first chunk was not passed on before the response was complete")
+		}
+		w.Write([]byte(`{"results":[{"series":[{"name":"cpu","columns":["time","value"],"values":[["1970-01-01T00:00:02Z",3]]}]}]}` + "\n"))
+	}))
+	defer ts.Close()
+
+	c, _ := NewHTTPClient(Config{URLs: []string{ts.URL}})
+	var chunks []int
+	err := c.QueryChunked(Query{Command: "SELECT value FROM cpu"}, 2, func(res Result) error {
+		if len(chunks) == 0 {
+			close(received)
+		}
+		chunks = append(chunks, len(res.Series[0].Values))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := chunks, []int{2, 1}; !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected chunk sizes: got %v exp %v", got, exp)
+	}
+}
+
+func TestClient_QueryChunked_Error(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"results":[{"series":[{"name":"cpu","columns":["time","value"],"values":[["1970-01-01T00:00:00Z",1]]}]}]}` + "\n"))
+		w.Write([]byte(`{"results":[{"error":"max-select-point limit exceeded"}]}` + "\n"))
+	}))
+	defer ts.Close()
+
+	c, _ := NewHTTPClient(Config{URLs: []string{ts.URL}})
+	chunks := 0
+	err := c.QueryChunked(Query{Command: "SELECT value FROM cpu"}, 1, func(res Result) error {
+		chunks++
+		return nil
+	})
+	if err == nil || err.Error() != "max-select-point limit exceeded" {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if chunks != 1 {
+		t.Errorf("unexpected number of chunks: got %d exp 1", chunks)
+	}
+}
+
 func TestClient_BasicAuth(t *testing.T) {
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		u, p, ok := r.BasicAuth()
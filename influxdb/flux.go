@@ -0,0 +1,385 @@
+package influxdb
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	imodels "github.com/influxdata/influxdb/models"
+	"github.com/pkg/errors"
+)
+
+// FluxQuery defines a Flux query to send to an InfluxDB 2.x server.
+//
+// The tables of the result are converted to series:
+// the columns of the group key of a table are the tags of its series,
+// except for the _start, _stop, _field and _measurement columns, and the measurement is the name of the series.
+// Tables with the same measurement and tags are merged into a single series,
+// the fields of rows with the same time are merged into a single row.
+// The field of a row is the value of its _value column named by its _field column,
+// other columns that are not part of the group key are also fields.
+// The time of a row is its _time, or its _stop if the table has no _time column.
+// Each result of the query, as named by yield, is a separate Result.
+type FluxQuery struct {
+	Query string
+	Org   string
+}
+
+// Columns of a Flux table that are not tags or fields.
+var fluxReservedColumns = map[string]bool{
+	"":             true,
+	"result":       true,
+	"table":        true,
+	"_start":       true,
+	"_stop":        true,
+	"_time":        true,
+	"_measurement": true,
+	"_field":       true,
+	"_value":       true,
+}
+
+// QueryFlux sends a Flux query to the InfluxDB 2.x query API and returns the Response.
+func (c *HTTPClient) QueryFlux(q FluxQuery) (*Response, error) {
+	u := c.url()
+	u.Path = "api/v2/query"
+	v := url.Values{}
+	v.Set("org", q.Org)
+	u.RawQuery = v.Encode()
+
+	body, err := json.Marshal(struct {
+		Query   string `json:"query"`
+		Type    string `json:"type"`
+		Dialect struct {
+			Header         bool     `json:"header"`
+			Delimiter      string   `json:"delimiter"`
+			Annotations    []string `json:"annotations"`
+			DateTimeFormat string   `json:"dateTimeFormat"`
+		} `json:"dialect"`
+	}{
+		Query: q.Query,
+		Type:  "flux",
+		Dialect: struct {
+			Header         bool     `json:"header"`
+			Delimiter      string   `json:"delimiter"`
+			Annotations    []string `json:"annotations"`
+			DateTimeFormat string   `json:"dateTimeFormat"`
+		}{
+			Header:         true,
+			Delimiter:      ",",
+			Annotations:    []string{"datatype", "group", "default"},
+			DateTimeFormat: "RFC3339Nano",
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("POST", u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/csv")
+
+	result := &fluxResult{}
+	if _, err := c.do(req, result, http.StatusOK); err != nil {
+		return nil, err
+	}
+	if err := result.response.Error(); err != nil {
+		return nil, err
+	}
+	return result.response, nil
+}
+
+// fluxResult decodes the annotated CSV of a Flux query result into a Response.
+type fluxResult struct {
+	response *Response
+}
+
+func (r *fluxResult) decodeBody(body io.Reader) error {
+	response, err := DecodeFluxCSV(body)
+	if err != nil {
+		return errors.Wrap(err, "failed to decode flux result")
+	}
+	r.response = response
+	return nil
+}
+
+// fluxSeries is a series being built from the rows of Flux tables.
+type fluxSeries struct {
+	name   string
+	tags   map[string]string
+	times  []time.Time
+	fields map[time.Time]map[string]interface{}
+}
+
+// fluxResultSeries are the series of a result in order of appearance.
+type fluxResultSeries struct {
+	keys   []string
+	series map[string]*fluxSeries
+}
+
+// DecodeFluxCSV decodes the annotated CSV of a Flux query result into a Response,
+// see FluxQuery for how the tables are converted to series.
+func DecodeFluxCSV(r io.Reader) (*Response, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	var datatypes, groups, defaults, header []string
+	var resultNames []string
+	results := make(map[string]*fluxResultSeries)
+	for {
+		rec, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		// A new annotation starts the schema of the next tables.
+		switch rec[0] {
+		case "#datatype":
+			datatypes, header = rec, nil
+			continue
+		case "#group":
+			groups, header = rec, nil
+			continue
+		case "#default":
+			defaults, header = rec, nil
+			continue
+		}
+		if header == nil {
+			header = rec
+			continue
+		}
+
+		values := make(map[string]string, len(header))
+		var tags map[string]string
+		var fields map[string]interface{}
+		for i, c := range header {
+			if i >= len(rec) {
+				break
+			}
+			v := rec[i]
+			if v == "" && i < len(defaults) {
+				v = defaults[i]
+			}
+			values[c] = v
+			if fluxReservedColumns[c] {
+				continue
+			}
+			if i < len(groups) && groups[i] == "true" {
+				if tags == nil {
+					tags = make(map[string]string)
+				}
+				tags[c] = v
+				continue
+			}
+			value, err := fluxValue(v, datatypeAt(datatypes, i))
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid value of column %q", c)
+			}
+			if value != nil {
+				if fields == nil {
+					fields = make(map[string]interface{})
+				}
+				fields[c] = value
+			}
+		}
+		if msg, ok := values["error"]; ok && msg != "" {
+			return &Response{Results: []Result{{Err: msg}}}, nil
+		}
+		if field, ok := values["_field"]; ok {
+			i := indexOf(header, "_value")
+			if i >= 0 {
+				value, err := fluxValue(values["_value"], datatypeAt(datatypes, i))
+				if err != nil {
+					return nil, errors.Wrapf(err, "invalid value of field %q", field)
+				}
+				if value != nil {
+					if fields == nil {
+						fields = make(map[string]interface{})
+					}
+					fields[field] = value
+				}
+			}
+		}
+		tStr, ok := values["_time"]
+		if !ok {
+			tStr = values["_stop"]
+		}
+		t, err := time.Parse(time.RFC3339Nano, tStr)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid time of row")
+		}
+
+		resultName := values["result"]
+		rs, ok := results[resultName]
+		if !ok {
+			rs = &fluxResultSeries{series: make(map[string]*fluxSeries)}
+			results[resultName] = rs
+			resultNames = append(resultNames, resultName)
+		}
+		name := values["_measurement"]
+		key := seriesKey(name, tags)
+		s, ok := rs.series[key]
+		if !ok {
+			s = &fluxSeries{
+				name:   name,
+				tags:   tags,
+				fields: make(map[time.Time]map[string]interface{}),
+			}
+			rs.series[key] = s
+			rs.keys = append(rs.keys, key)
+		}
+		s.add(t, fields)
+	}
+
+	response := &Response{Results: make([]Result, len(resultNames))}
+	for i, resultName := range resultNames {
+		rs := results[resultName]
+		rows := make([]imodels.Row, len(rs.keys))
+		for j, key := range rs.keys {
+			rows[j] = rs.series[key].row()
+		}
+		response.Results[i].Series = rows
+	}
+	return response, nil
+}
+
+func (s *fluxSeries) add(t time.Time, fields map[string]interface{}) {
+	existing, ok := s.fields[t]
+	if !ok {
+		existing = make(map[string]interface{}, len(fields))
+		s.fields[t] = existing
+		s.times = append(s.times, t)
+	}
+	for k, v := range fields {
+		existing[k] = v
+	}
+}
+
+// row returns the series as a row with the columns time and the fields sorted by name, ordered by time.
+func (s *fluxSeries) row() imodels.Row {
+	names := make(map[string]bool)
+	for _, fields := range s.fields {
+		for k := range fields {
+			names[k] = true
+		}
+	}
+	columns := make([]string, 0, len(names)+1)
+	for k := range names {
+		columns = append(columns, k)
+	}
+	sort.Strings(columns)
+	columns = append([]string{"time"}, columns...)
+
+	sort.Slice(s.times, func(i, j int) bool { return s.times[i].Before(s.times[j]) })
+	values := make([][]interface{}, len(s.times))
+	for i, t := range s.times {
+		fields := s.fields[t]
+		row := make([]interface{}, len(columns))
+		row[0] = t.UTC().Format(time.RFC3339Nano)
+		for j, c := range columns[1:] {
+			row[j+1] = fields[c]
+		}
+		values[i] = row
+	}
+	return imodels.Row{
+		Name:    s.name,
+		Tags:    s.tags,
+		Columns: columns,
+		Values:  values,
+	}
+}
+
+// seriesKey returns a key identifying the series with the name and tags.
+func seriesKey(name string, tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b bytes.Buffer
+	b.WriteString(name)
+	for _, k := range keys {
+		b.WriteByte(',')
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(tags[k])
+	}
+	return b.String()
+}
+
+func datatypeAt(datatypes []string, i int) string {
+	if i < len(datatypes) {
+		return datatypes[i]
+	}
+	return "string"
+}
+
+func indexOf(columns []string, c string) int {
+	for i, col := range columns {
+		if col == c {
+			return i
+		}
+	}
+	return -1
+}
+
+// fluxValue converts a value of an annotated CSV to the type of its datatype, nil if the value is null.
+// Unsigned values beyond the range of int64 are converted to float64.
+// Time, duration and binary values are kept as strings.
+func fluxValue(v, datatype string) (interface{}, error) {
+	if v == "" {
+		return nil, nil
+	}
+	switch datatype {
+	case "double":
+		return strconv.ParseFloat(v, 64)
+	case "long":
+		return strconv.ParseInt(v, 10, 64)
+	case "unsignedLong":
+		u, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		if u > math.MaxInt64 {
+			return float64(u), nil
+		}
+		return int64(u), nil
+	case "boolean":
+		return strconv.ParseBool(v)
+	default:
+		return v, nil
+	}
+}
+
+// FluxString returns s as a Flux string literal.
+func FluxString(s string) string {
+	var b bytes.Buffer
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"', '\\', '$':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
@@ -0,0 +1,165 @@
+package influxdb
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	imodels "github.com/influxdata/influxdb/models"
+)
+
+func TestDecodeFluxCSV(t *testing.T) {
+	csv := `#datatype,string,long,dateTime:RFC3339,dateTime:RFC3339,dateTime:RFC3339,double,string,string,string
+#group,false,false,true,true,false,false,true,true,true
+#default,_result,,,,,,,,
+,result,table,_start,_stop,_time,_value,_field,_measurement,host
+,,0,2018-01-01T00:00:00Z,2018-01-01T00:01:00Z,2018-01-01T00:00:10Z,90.5,usage_idle,cpu,serverA
+,,0,2018-01-01T00:00:00Z,2018-01-01T00:01:00Z,2018-01-01T00:00:00Z,91,usage_idle,cpu,serverA
+,,1,2018-01-01T00:00:00Z,2018-01-01T00:01:00Z,2018-01-01T00:00:00Z,5.5,usage_user,cpu,serverA
+,,1,2018-01-01T00:00:00Z,2018-01-01T00:01:00Z,2018-01-01T00:00:10Z,,usage_user,cpu,serverA
+,,2,2018-01-01T00:00:00Z,2018-01-01T00:01:00Z,2018-01-01T00:00:00Z,80,usage_idle,cpu,serverB
+
+#datatype,string,long,dateTime:RFC3339,dateTime:RFC3339,string,long,boolean
+#group,false,false,true,true,true,false,false
+#default,counts,,,,,,
+,result,table,_start,_stop,_measurement,count,ok
+,,0,2018-01-01T00:00:00Z,2018-01-01T00:01:00Z,requests,42,true
+`
+	got, err := DecodeFluxCSV(strings.NewReader(csv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := &Response{
+		Results: []Result{
+			{
+				Series: []imodels.Row{
+					{
+						Name:    "cpu",
+						Tags:    map[string]string{"host": "serverA"},
+						Columns: []string{"time", "usage_idle", "usage_user"},
+						Values: [][]interface{}{
+							{"2018-01-01T00:00:00Z", 91.0, 5.5},
+							{"2018-01-01T00:00:10Z", 90.5, nil},
+						},
+					},
+					{
+						Name:    "cpu",
+						Tags:    map[string]string{"host": "serverB"},
+						Columns: []string{"time", "usage_idle"},
+						Values: [][]interface{}{
+							{"2018-01-01T00:00:00Z", 80.0},
+						},
+					},
+				},
+			},
+			{
+				Series: []imodels.Row{
+					{
+						Name:    "requests",
+						Columns: []string{"time", "count", "ok"},
+						Values: [][]interface{}{
+							{"2018-01-01T00:01:00Z", int64(42), true},
+						},
+					},
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected response:\ngot\n%#v\nexp\n%#v", got, exp)
+	}
+}
+
+func TestDecodeFluxCSV_Error(t *testing.T) {
+	csv := `#datatype,string,string
+#group,true,true
+#default,,
+,error,reference
+,failed to execute query: bucket not found,
+`
+	got, err := DecodeFluxCSV(strings.NewReader(csv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := "failed to execute query: bucket not found"; got.Error() == nil || got.Error().Error() != exp {
+		t.Errorf("unexpected error, expected %q, actual %v", exp, got.Error())
+	}
+}
+
+func TestClient_QueryFlux(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/query" {
+			t.Errorf("unexpected path, expected %q, actual %q", "/api/v2/query", r.URL.Path)
+		}
+		if org := r.URL.Query().Get("org"); org != "my-org" {
+			t.Errorf("unexpected org, expected %q, actual %q", "my-org", org)
+		}
+		if auth := r.Header.Get("Authorization"); auth != "Token secret" {
+			t.Errorf("unexpected authorization, expected %q, actual %q", "Token secret", auth)
+		}
+		var body struct {
+			Query string `json:"query"`
+			Type  string `json:"type"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+		if body.Type != "flux" {
+			t.Errorf("unexpected type, expected %q, actual %q", "flux", body.Type)
+		}
+		if exp := `from(bucket: "b")`; body.Query != exp {
+			t.Errorf("unexpected query, expected %q, actual %q", exp, body.Query)
+		}
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`#datatype,string,long,dateTime:RFC3339,double,string,string
+#group,false,false,false,false,true,true
+#default,_result,,,,,
+,result,table,_time,_value,_field,_measurement
+,,0,2018-01-01T00:00:00Z,1,value,m
+`))
+	}))
+	defer ts.Close()
+
+	config := Config{URLs: []string{ts.URL}, Credentials: Credentials{Method: TokenAuthentication, Token: "secret"}}
+	c, _ := NewHTTPClient(config)
+
+	got, err := c.QueryFlux(FluxQuery{Query: `from(bucket: "b")`, Org: "my-org"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := &Response{Results: []Result{{Series: []imodels.Row{{
+		Name:    "m",
+		Columns: []string{"time", "value"},
+		Values:  [][]interface{}{{"2018-01-01T00:00:00Z", 1.0}},
+	}}}}}
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected response:\ngot\n%#v\nexp\n%#v", got, exp)
+	}
+}
+
+func TestClient_QueryFlux_Error(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = ioutil.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"code":"invalid","message":"compilation failed"}`))
+	}))
+	defer ts.Close()
+
+	c, _ := NewHTTPClient(Config{URLs: []string{ts.URL}})
+	if _, err := c.QueryFlux(FluxQuery{Query: "bad", Org: "my-org"}); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestFluxString(t *testing.T) {
+	got := FluxString("a\"b\\c${d}\n")
+	if exp := `"a\"b\\c\${d}\n"`; got != exp {
+		t.Errorf("unexpected string, expected %s, actual %s", exp, got)
+	}
+}
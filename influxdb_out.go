@@ -2,6 +2,10 @@ package kapacitor
 
 import (
 	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
@@ -17,8 +21,13 @@ import (
 const (
 	statsInfluxDBPointsWritten = "points_written"
 	statsInfluxDBWriteErrors   = "write_errors"
+	statsInfluxDBActiveCluster = "active_cluster"
+	statsInfluxDBFailovers     = "failovers"
 )
 
+// Timeout for probing the preferred cluster while failed over.
+const failoverProbeTimeout = 5 * time.Second
+
 type InfluxDBOutNode struct {
 	node
 	i  *pipeline.InfluxDBOutNode
@@ -27,23 +36,71 @@ type InfluxDBOutNode struct {
 	pointsWritten *expvar.Int
 	writeErrors   *expvar.Int
 
+	// Only set when writing to multiple clusters
+	fc *failoverClient
+
+	// writeTags are the only tags written, if set.
+	writeTags map[string]bool
+	// dropTags are the tags dropped when writing.
+	dropTags map[string]bool
+
 	batchBuffer *edge.BatchBuffer
+
+	// walEntries are the entries left in the write-ahead log when the node was created,
+	// they are written again when the node runs.
+	walEntries []walEntry
 }
 
 func newInfluxDBOutNode(et *ExecutingTask, n *pipeline.InfluxDBOutNode, d NodeDiagnostic) (*InfluxDBOutNode, error) {
 	if et.tm.InfluxDBService == nil {
 		return nil, errors.New("no InfluxDB cluster configured cannot use the InfluxDBOutNode")
 	}
-	cli, err := et.tm.InfluxDBService.NewNamedClient(n.Cluster)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to get InfluxDB client")
-	}
 	in := &InfluxDBOutNode{
 		node:        node{Node: n, et: et, diag: d},
 		i:           n,
-		wb:          newWriteBuffer(int(n.Buffer), n.FlushInterval, cli),
 		batchBuffer: new(edge.BatchBuffer),
 	}
+	var w influxDBWriter
+	if len(n.ClusterNames) > 0 {
+		clients := make([]influxdb.Client, len(n.ClusterNames))
+		for i, name := range n.ClusterNames {
+			cli, err := et.tm.InfluxDBService.NewNamedClient(name)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to get InfluxDB client for cluster %q", name)
+			}
+			clients[i] = cli
+		}
+		in.fc = newFailoverClient(n.ClusterNames, clients, int(n.FailoverThreshold), n.ProbeInterval, int(n.FailoverBuffer))
+		in.fc.i = in
+		w = in.fc
+	} else {
+		cli, err := et.tm.InfluxDBService.NewNamedClient(n.Cluster)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get InfluxDB client")
+		}
+		w = cli
+	}
+	if len(n.WriteTagsOnlyList) > 0 {
+		in.writeTags = make(map[string]bool, len(n.WriteTagsOnlyList))
+		for _, t := range n.WriteTagsOnlyList {
+			in.writeTags[t] = true
+		}
+	}
+	if len(n.DropWriteTagsList) > 0 {
+		in.dropTags = make(map[string]bool, len(n.DropWriteTagsList))
+		for _, t := range n.DropWriteTagsList {
+			in.dropTags[t] = true
+		}
+	}
+	in.wb = newWriteBuffer(int(n.Buffer), n.FlushInterval, w)
+	if n.WalDir != "" {
+		wal, entries, err := openWriteAheadLog(filepath.Join(n.WalDir, et.Task.ID, n.Name()), n.WalMaxSize, n.WalFsync, d)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to open write-ahead log")
+		}
+		in.wb.wal = wal
+		in.walEntries = entries
+	}
 	in.node.runF = in.runOut
 	in.node.stopF = in.stopOut
 	in.wb.i = in
@@ -56,32 +113,34 @@ func (n *InfluxDBOutNode) runOut([]byte) error {
 
 	n.statMap.Set(statsInfluxDBPointsWritten, n.pointsWritten)
 	n.statMap.Set(statsInfluxDBWriteErrors, n.writeErrors)
+	if n.fc != nil {
+		n.statMap.Set(statsInfluxDBActiveCluster, n.fc.activeCluster)
+		n.statMap.Set(statsInfluxDBFailovers, n.fc.failovers)
+	}
 
 	// Start the write buffer
 	n.wb.start()
 
+	// Write the points left in the write-ahead log by a crash
+	n.wb.replay(n.walEntries)
+	n.walEntries = nil
+
 	// Create the database and retention policy
 	if n.i.CreateFlag {
-		err := func() error {
-			cli, err := n.et.tm.InfluxDBService.NewNamedClient(n.i.Cluster)
-			if err != nil {
-				return err
-			}
-			var createDb bytes.Buffer
-			createDb.WriteString("CREATE DATABASE ")
-			createDb.WriteString(influxql.QuoteIdent(n.i.Database))
-			if n.i.RetentionPolicy != "" {
-				createDb.WriteString(" WITH NAME ")
-				createDb.WriteString(influxql.QuoteIdent(n.i.RetentionPolicy))
+		for _, cluster := range n.clusters() {
+			if err := n.createDatabase(cluster); err != nil {
+				n.diag.Error("failed to create database", err, keyvalue.KV("database", n.i.Database), keyvalue.KV("cluster", cluster))
 			}
-			_, err = cli.Query(influxdb.Query{Command: createDb.String()})
-			if err != nil {
-				return err
+		}
+	}
+
+	// Create the database and retention policy if they do not exist.
+	// A failure stops the task, since writes would fail anyway.
+	if n.i.CreateTargetsFlag {
+		for _, cluster := range n.clusters() {
+			if err := n.createTargets(cluster); err != nil {
+				return errors.Wrapf(err, "failed to create targets on cluster %q", cluster)
 			}
-			return nil
-		}()
-		if err != nil {
-			n.diag.Error("failed to create database", err, keyvalue.KV("database", n.i.Database), keyvalue.KV("cluster", n.i.Cluster))
 		}
 	}
 
@@ -96,6 +155,105 @@ func (n *InfluxDBOutNode) runOut([]byte) error {
 	return consumer.Consume()
 }
 
+// clusters returns the names of all clusters written to.
+func (n *InfluxDBOutNode) clusters() []string {
+	if len(n.i.ClusterNames) > 0 {
+		return n.i.ClusterNames
+	}
+	return []string{n.i.Cluster}
+}
+
+func (n *InfluxDBOutNode) createTargets(cluster string) error {
+	cli, err := n.et.tm.InfluxDBService.NewNamedClient(cluster)
+	if err != nil {
+		return err
+	}
+	return createTargets(cli, n.i.Database, n.i.RetentionPolicy)
+}
+
+// createTargets creates the database and retention policy if they do not exist.
+// The retention policy is not created if rp is empty.
+func createTargets(cli influxdb.Client, db, rp string) error {
+	databases, err := showNames(cli, "SHOW DATABASES", "")
+	if err != nil {
+		return errors.Wrap(err, "failed to list databases")
+	}
+	if !databases[db] {
+		if err := createTarget(cli, "CREATE DATABASE "+influxql.QuoteIdent(db), "database", db); err != nil {
+			return err
+		}
+	}
+	if rp == "" {
+		return nil
+	}
+	rps, err := showNames(cli, "SHOW RETENTION POLICIES ON "+influxql.QuoteIdent(db), db)
+	if err != nil {
+		return errors.Wrapf(err, "failed to list retention policies on database %q", db)
+	}
+	if !rps[rp] {
+		q := fmt.Sprintf("CREATE RETENTION POLICY %s ON %s DURATION INF REPLICATION 1", influxql.QuoteIdent(rp), influxql.QuoteIdent(db))
+		if err := createTarget(cli, q, "retention policy", rp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func createTarget(cli influxdb.Client, q, kind, name string) error {
+	if _, err := cli.Query(influxdb.Query{Command: q}); err != nil {
+		if strings.Contains(err.Error(), "authoriz") {
+			return errors.Wrapf(err, "not permitted to create %s %q, the InfluxDB user requires admin privileges", kind, name)
+		}
+		return errors.Wrapf(err, "failed to create %s %q", kind, name)
+	}
+	return nil
+}
+
+// showNames runs a SHOW query and returns the set of values in its name column.
+func showNames(cli influxdb.Client, q, db string) (map[string]bool, error) {
+	resp, err := cli.Query(influxdb.Query{Command: q, Database: db})
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]bool)
+	for _, res := range resp.Results {
+		for _, row := range res.Series {
+			col := -1
+			for i, c := range row.Columns {
+				if c == "name" {
+					col = i
+					break
+				}
+			}
+			if col < 0 {
+				continue
+			}
+			for _, v := range row.Values {
+				if name, ok := v[col].(string); ok {
+					names[name] = true
+				}
+			}
+		}
+	}
+	return names, nil
+}
+
+func (n *InfluxDBOutNode) createDatabase(cluster string) error {
+	cli, err := n.et.tm.InfluxDBService.NewNamedClient(cluster)
+	if err != nil {
+		return err
+	}
+	var createDb bytes.Buffer
+	createDb.WriteString("CREATE DATABASE ")
+	createDb.WriteString(influxql.QuoteIdent(n.i.Database))
+	if n.i.RetentionPolicy != "" {
+		createDb.WriteString(" WITH NAME ")
+		createDb.WriteString(influxql.QuoteIdent(n.i.RetentionPolicy))
+	}
+	_, err = cli.Query(influxdb.Query{Command: createDb.String()})
+	return err
+}
+
 func (n *InfluxDBOutNode) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
 	return nil, n.batchBuffer.BeginBatch(begin)
 }
@@ -146,6 +304,11 @@ func (n *InfluxDBOutNode) Done() {}
 func (n *InfluxDBOutNode) stopOut() {
 	n.wb.flush()
 	n.wb.abort()
+	if n.wb.wal != nil {
+		if err := n.wb.wal.close(); err != nil {
+			n.diag.Error("failed to close write-ahead log", err)
+		}
+	}
 }
 
 func (n *InfluxDBOutNode) write(db, rp string, batch edge.BufferedBatchMessage) error {
@@ -162,21 +325,9 @@ func (n *InfluxDBOutNode) write(db, rp string, batch edge.BufferedBatchMessage)
 
 	points := make([]influxdb.Point, len(batch.Points()))
 	for j, p := range batch.Points() {
-		var tags map[string]string
-		if len(n.i.Tags) > 0 {
-			tags = make(map[string]string, len(p.Tags())+len(n.i.Tags))
-			for k, v := range p.Tags() {
-				tags[k] = v
-			}
-			for k, v := range n.i.Tags {
-				tags[k] = v
-			}
-		} else {
-			tags = p.Tags()
-		}
 		points[j] = influxdb.Point{
 			Name:   name,
-			Tags:   tags,
+			Tags:   n.pointTags(p.Tags()),
 			Fields: p.Fields(),
 			Time:   p.Time(),
 		}
@@ -191,6 +342,25 @@ func (n *InfluxDBOutNode) write(db, rp string, batch edge.BufferedBatchMessage)
 	return nil
 }
 
+// pointTags returns the tags of a written point.
+// The tags are copied before they are modified, the tags of the data are left unchanged.
+func (n *InfluxDBOutNode) pointTags(tags map[string]string) map[string]string {
+	if len(n.i.Tags) == 0 && n.writeTags == nil && n.dropTags == nil {
+		return tags
+	}
+	written := make(map[string]string, len(tags)+len(n.i.Tags))
+	for k, v := range tags {
+		if n.writeTags != nil && !n.writeTags[k] || n.dropTags[k] {
+			continue
+		}
+		written[k] = v
+	}
+	for k, v := range n.i.Tags {
+		written[k] = v
+	}
+	return written
+}
+
 type writeBuffer struct {
 	size          int
 	flushInterval time.Duration
@@ -203,7 +373,17 @@ type writeBuffer struct {
 
 	stopping chan struct{}
 	wg       sync.WaitGroup
-	cli      influxdb.Client
+	cli      influxDBWriter
+
+	// wal is the write-ahead log of the points, if any.
+	wal *writeAheadLog
+	// firstSeq is the sequence number of the first write-ahead log entry of each buffered batch.
+	firstSeq map[influxdb.BatchPointsConfig]uint64
+	// received is the sequence number of the last write-ahead log entry received.
+	received uint64
+	// retries are the batches of failed writes, oldest first.
+	// Failed writes are only retried with a write-ahead log, which keeps their entries until they are written.
+	retries []retryBatch
 
 	i *InfluxDBOutNode
 }
@@ -211,9 +391,23 @@ type writeBuffer struct {
 type queueEntry struct {
 	bpc    influxdb.BatchPointsConfig
 	points []influxdb.Point
+	// sequence number of the write-ahead log entry of the points, zero if they are not in the log.
+	seq uint64
 }
 
-func newWriteBuffer(size int, flushInterval time.Duration, cli influxdb.Client) *writeBuffer {
+// retryBatch is a batch of a failed write.
+type retryBatch struct {
+	bp influxdb.BatchPoints
+	// sequence numbers of the first and last write-ahead log entries the batch may contain.
+	first, last uint64
+}
+
+// influxDBWriter writes batches of points to InfluxDB.
+type influxDBWriter interface {
+	Write(bp influxdb.BatchPoints) error
+}
+
+func newWriteBuffer(size int, flushInterval time.Duration, cli influxDBWriter) *writeBuffer {
 	return &writeBuffer{
 		cli:           cli,
 		size:          size,
@@ -222,6 +416,7 @@ func newWriteBuffer(size int, flushInterval time.Duration, cli influxdb.Client)
 		flushed:       make(chan struct{}),
 		queue:         make(chan queueEntry),
 		buffer:        make(map[influxdb.BatchPointsConfig]influxdb.BatchPoints),
+		firstSeq:      make(map[influxdb.BatchPointsConfig]uint64),
 		stopping:      make(chan struct{}),
 	}
 }
@@ -231,6 +426,29 @@ func (w *writeBuffer) enqueue(bpc influxdb.BatchPointsConfig, points []influxdb.
 		bpc:    bpc,
 		points: points,
 	}
+	if w.wal != nil {
+		// The points are appended to the log before they are buffered.
+		seq, err := w.wal.append(bpc, points)
+		if err != nil {
+			w.i.diag.Error("failed to append points to write-ahead log", err)
+		}
+		qe.seq = seq
+	}
+	w.send(qe)
+}
+
+// replay queues the entries left in the write-ahead log, they keep their place in the log until written.
+func (w *writeBuffer) replay(entries []walEntry) {
+	for _, e := range entries {
+		w.send(queueEntry{
+			bpc:    e.bpc,
+			points: e.points,
+			seq:    e.seq,
+		})
+	}
+}
+
+func (w *writeBuffer) send(qe queueEntry) {
 	select {
 	case w.queue <- qe:
 	case <-w.stopping:
@@ -271,13 +489,16 @@ func (w *writeBuffer) run() {
 				w.buffer[qe.bpc] = bp
 			}
 			bp.AddPoints(qe.points)
+			if qe.seq > 0 {
+				w.received = qe.seq
+				if _, ok := w.firstSeq[qe.bpc]; !ok {
+					w.firstSeq[qe.bpc] = qe.seq
+				}
+			}
 			// Check if we hit buffer size
 			if len(bp.Points()) >= w.size {
-				err = w.write(bp)
-				if err != nil {
-					w.i.diag.Error("failed to write points to InfluxDB", err)
-				}
-				delete(w.buffer, qe.bpc)
+				w.writeBuffered(qe.bpc, bp)
+				w.truncateWAL()
 			}
 		case <-w.flushing:
 			// Explicit flush called
@@ -293,12 +514,71 @@ func (w *writeBuffer) run() {
 }
 
 func (w *writeBuffer) writeAll() {
+	w.retryFailed()
 	for bpc, bp := range w.buffer {
-		err := w.write(bp)
-		if err != nil {
-			w.i.diag.Error("failed to write points to InfluxDB", err)
+		w.writeBuffered(bpc, bp)
+	}
+	w.truncateWAL()
+}
+
+// writeBuffered writes a buffered batch and removes it from the buffer.
+// If the write fails and the batch is in the write-ahead log it is retried by later flushes.
+func (w *writeBuffer) writeBuffered(bpc influxdb.BatchPointsConfig, bp influxdb.BatchPoints) {
+	if err := w.write(bp); err != nil {
+		w.i.diag.Error("failed to write points to InfluxDB", err)
+		if seq, ok := w.firstSeq[bpc]; ok {
+			w.retries = append(w.retries, retryBatch{
+				bp:    bp,
+				first: seq,
+				last:  w.received,
+			})
+		}
+	}
+	delete(w.buffer, bpc)
+	delete(w.firstSeq, bpc)
+}
+
+// retryFailed writes the batches of failed writes again, oldest first, stopping at the first failure.
+// Batches whose entries have all been dropped from the full write-ahead log are dropped as well.
+func (w *writeBuffer) retryFailed() {
+	if len(w.retries) == 0 {
+		return
+	}
+	oldest := w.wal.oldest()
+	for len(w.retries) > 0 {
+		r := w.retries[0]
+		if r.last >= oldest {
+			if err := w.write(r.bp); err != nil {
+				w.i.diag.Error("failed to write points to InfluxDB", err)
+				return
+			}
+		}
+		w.retries[0] = retryBatch{}
+		w.retries = w.retries[1:]
+	}
+}
+
+// truncateWAL removes the entries of the points that have been written from the write-ahead log.
+// Entries are only removed up to the oldest failed write that has not been retried successfully,
+// so that the points of failed writes are written again if the node is restarted first.
+func (w *writeBuffer) truncateWAL() {
+	if w.wal == nil {
+		return
+	}
+	// Entries appended after the last received entry are still queued.
+	unconfirmed := w.received + 1
+	for _, seq := range w.firstSeq {
+		if seq < unconfirmed {
+			unconfirmed = seq
+		}
+	}
+	for _, r := range w.retries {
+		if r.first < unconfirmed {
+			unconfirmed = r.first
 		}
-		delete(w.buffer, bpc)
+	}
+	if err := w.wal.truncate(unconfirmed); err != nil {
+		w.i.diag.Error("failed to truncate write-ahead log", err)
 	}
 }
 
@@ -311,3 +591,135 @@ func (w *writeBuffer) write(bp influxdb.BatchPoints) error {
 	w.i.pointsWritten.Add(int64(len(bp.Points())))
 	return nil
 }
+
+// failoverClient writes to the first of several clusters that accepts writes.
+// Writes fail over to the next cluster after threshold consecutive failed writes,
+// while failed over the first cluster is probed every probeInterval and writes switch back once it responds.
+// Batches of failed writes are retained, up to bufferSize points, and retried before newer batches.
+//
+// A failoverClient is not safe for concurrent use, it is only used by the goroutine of the writeBuffer.
+type failoverClient struct {
+	names         []string
+	clients       []influxdb.Client
+	threshold     int
+	probeInterval time.Duration
+	bufferSize    int
+
+	active    int
+	failures  int
+	lastProbe time.Time
+
+	// Batches that have not been written yet, oldest first.
+	pending       []influxdb.BatchPoints
+	pendingPoints int
+
+	activeCluster *expvar.String
+	failovers     *expvar.Int
+
+	i *InfluxDBOutNode
+}
+
+func newFailoverClient(names []string, clients []influxdb.Client, threshold int, probeInterval time.Duration, bufferSize int) *failoverClient {
+	c := &failoverClient{
+		names:         names,
+		clients:       clients,
+		threshold:     threshold,
+		probeInterval: probeInterval,
+		bufferSize:    bufferSize,
+		activeCluster: &expvar.String{},
+		failovers:     &expvar.Int{},
+	}
+	c.activeCluster.Set(names[0])
+	return c
+}
+
+// Write writes any retained batches followed by bp to the active cluster.
+// The error of the last failed write is returned if bp could not be written to any cluster,
+// in which case bp is retained to be retried by later writes.
+// A retained batch that is written again keeps its place among the retained batches.
+func (c *failoverClient) Write(bp influxdb.BatchPoints) error {
+	c.probe()
+	if !c.retained(bp) {
+		c.pending = append(c.pending, bp)
+		c.pendingPoints += len(bp.Points())
+	}
+	for switches := 0; ; switches++ {
+		err := c.writePending(bp)
+		if err == nil {
+			c.failures = 0
+			return nil
+		}
+		c.failures++
+		if c.failures < c.threshold || switches == len(c.clients)-1 {
+			c.trimPending()
+			return err
+		}
+		c.failovers.Add(1)
+		c.switchTo((c.active + 1) % len(c.clients))
+	}
+}
+
+// writePending writes the retained batches in order to the active cluster, stopping at the first failure.
+func (c *failoverClient) writePending(current influxdb.BatchPoints) error {
+	for len(c.pending) > 0 {
+		p := c.pending[0]
+		if err := c.clients[c.active].Write(p); err != nil {
+			return err
+		}
+		// The current batch is accounted for by the write buffer.
+		if p != current {
+			c.i.pointsWritten.Add(int64(len(p.Points())))
+		}
+		c.pending[0] = nil
+		c.pending = c.pending[1:]
+		c.pendingPoints -= len(p.Points())
+	}
+	return nil
+}
+
+// retained reports whether bp is one of the retained batches.
+func (c *failoverClient) retained(bp influxdb.BatchPoints) bool {
+	for _, p := range c.pending {
+		if p == bp {
+			return true
+		}
+	}
+	return false
+}
+
+// trimPending drops the oldest retained batches until at most bufferSize points are retained.
+func (c *failoverClient) trimPending() {
+	dropped := 0
+	for len(c.pending) > 0 && c.pendingPoints > c.bufferSize {
+		n := len(c.pending[0].Points())
+		c.pending[0] = nil
+		c.pending = c.pending[1:]
+		c.pendingPoints -= n
+		dropped += n
+	}
+	if dropped > 0 {
+		c.i.diag.Error("dropped points of failed writes", fmt.Errorf("more than %d points retained", c.bufferSize), keyvalue.KV("points", fmt.Sprint(dropped)))
+	}
+}
+
+// probe switches back to the first cluster if it is not active and responds to a ping.
+func (c *failoverClient) probe() {
+	if c.active == 0 || time.Since(c.lastProbe) < c.probeInterval {
+		return
+	}
+	c.lastProbe = time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), failoverProbeTimeout)
+	defer cancel()
+	if _, _, err := c.clients[0].Ping(ctx); err != nil {
+		return
+	}
+	c.switchTo(0)
+}
+
+func (c *failoverClient) switchTo(i int) {
+	c.i.diag.SwitchingCluster(c.names[c.active], c.names[i])
+	c.active = i
+	c.failures = 0
+	c.lastProbe = time.Now()
+	c.activeCluster.Set(c.names[i])
+}
@@ -0,0 +1,198 @@
+package kapacitor
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/influxdata/kapacitor/influxdb"
+)
+
+// mockInfluxDB is an InfluxDB server recording the points written and the databases created.
+type mockInfluxDB struct {
+	*httptest.Server
+
+	mu sync.Mutex
+	// lines of line protocol written
+	lines []string
+
+	// retention policies of each database
+	databases map[string][]string
+	// queries received, excluding SHOW queries
+	queries []string
+	// whether the user lacks privileges to create databases and retention policies
+	denyCreate bool
+	// whether writes fail
+	failWrites bool
+}
+
+func newMockInfluxDB() *mockInfluxDB {
+	m := new(mockInfluxDB)
+	m.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		switch r.URL.Path {
+		case "/write":
+			if m.failWrites {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			data, _ := ioutil.ReadAll(r.Body)
+			m.lines = append(m.lines, strings.Split(string(bytes.TrimSpace(data)), "\n")...)
+		case "/query":
+			m.query(w, r.URL.Query().Get("q"))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	m.databases = make(map[string][]string)
+	return m
+}
+
+// query answers the statements used to create databases and retention policies.
+func (m *mockInfluxDB) query(w http.ResponseWriter, q string) {
+	var values [][]interface{}
+	switch {
+	case q == "SHOW DATABASES":
+		for db := range m.databases {
+			values = append(values, []interface{}{db})
+		}
+	case strings.HasPrefix(q, "SHOW RETENTION POLICIES ON "):
+		db := strings.TrimPrefix(q, "SHOW RETENTION POLICIES ON ")
+		for _, rp := range m.databases[db] {
+			values = append(values, []interface{}{rp, "0s"})
+		}
+	case strings.HasPrefix(q, "CREATE "):
+		m.queries = append(m.queries, q)
+		if m.denyCreate {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"error":"error authorizing query: writer not authorized to execute statement '` + q + `', requires admin privilege"}`))
+			return
+		}
+		if strings.HasPrefix(q, "CREATE DATABASE ") {
+			m.databases[strings.TrimPrefix(q, "CREATE DATABASE ")] = []string{"autogen"}
+		}
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"results": []interface{}{
+			map[string]interface{}{
+				"series": []interface{}{
+					map[string]interface{}{
+						"columns": []string{"name", "duration"},
+						"values":  values,
+					},
+				},
+			},
+		},
+	})
+}
+
+func (m *mockInfluxDB) Queries() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.queries
+}
+
+func (m *mockInfluxDB) SetFailWrites(fail bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.failWrites = fail
+}
+
+func (m *mockInfluxDB) Lines() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lines
+}
+
+func TestCreateTargets(t *testing.T) {
+	testCases := []struct {
+		name      string
+		databases map[string][]string
+		rp        string
+		exp       []string
+	}{
+		{
+			name: "absent",
+			rp:   "myrp",
+			exp: []string{
+				"CREATE DATABASE mydb",
+				"CREATE RETENTION POLICY myrp ON mydb DURATION INF REPLICATION 1",
+			},
+		},
+		{
+			name:      "retention policy absent",
+			databases: map[string][]string{"mydb": {"autogen"}},
+			rp:        "myrp",
+			exp: []string{
+				"CREATE RETENTION POLICY myrp ON mydb DURATION INF REPLICATION 1",
+			},
+		},
+		{
+			name: "no retention policy",
+			exp: []string{
+				"CREATE DATABASE mydb",
+			},
+		},
+		{
+			name:      "present",
+			databases: map[string][]string{"mydb": {"autogen", "myrp"}},
+			rp:        "myrp",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newMockInfluxDB()
+			defer s.Close()
+			for db, rps := range tc.databases {
+				s.databases[db] = rps
+			}
+			cli, err := influxdb.NewHTTPClient(influxdb.Config{URLs: []string{s.URL}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := createTargets(cli, "mydb", tc.rp); err != nil {
+				t.Fatal(err)
+			}
+			if got := s.Queries(); !reflect.DeepEqual(got, tc.exp) {
+				t.Errorf("unexpected queries:\ngot %v\nexp %v", got, tc.exp)
+			}
+		})
+	}
+}
+
+func TestCreateTargets_Denied(t *testing.T) {
+	s := newMockInfluxDB()
+	defer s.Close()
+	s.denyCreate = true
+	cli, err := influxdb.NewHTTPClient(influxdb.Config{URLs: []string{s.URL}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = createTargets(cli, "mydb", "myrp")
+	if err == nil {
+		t.Fatal("expected error creating database")
+	}
+	if !strings.Contains(err.Error(), "requires admin privileges") {
+		t.Errorf("unexpected error: %v", err)
+	}
+	// Nothing is attempted after the database cannot be created.
+	if got, exp := s.Queries(), []string{"CREATE DATABASE mydb"}; !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected queries:\ngot %v\nexp %v", got, exp)
+	}
+}
+
+// mockInfluxDBService returns clients of a mockInfluxDB.
+type mockInfluxDBService struct {
+	s *mockInfluxDB
+}
+
+func (s mockInfluxDBService) NewNamedClient(name string) (influxdb.Client, error) {
+	return influxdb.NewHTTPClient(influxdb.Config{URLs: []string{s.s.URL}})
+}
@@ -0,0 +1,391 @@
+package kapacitor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	dbmodels "github.com/influxdata/influxdb/models"
+	"github.com/influxdata/kapacitor/influxdb"
+	"github.com/influxdata/kapacitor/keyvalue"
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/pkg/errors"
+)
+
+const (
+	walSegmentExt = ".wal"
+	// walHeaderPrefix starts the header line of an entry.
+	walHeaderPrefix = '#'
+	// walSegments is the number of segments the maximum size of a log is split into,
+	// a segment is removed once all its entries are confirmed.
+	walSegments = 8
+	// walSyncInterval is the interval at which the log is synced with the interval fsync policy.
+	walSyncInterval = time.Second
+)
+
+// writeAheadLog keeps the points written by an InfluxDBOutNode on disk until their writes are confirmed,
+// so that the points can be written again after a crash.
+//
+// The log is a directory of segment files, named by the sequence number of the first entry they were created for.
+// An entry is a header line with the sequence number, the batch points config and the number of points,
+// followed by the points in line protocol with nanosecond precision.
+//
+// The log is safe for concurrent use, entries are appended by the node and confirmed by the write buffer.
+type writeAheadLog struct {
+	dir         string
+	maxSize     int64
+	segmentSize int64
+	fsync       string
+	diag        NodeDiagnostic
+
+	mu sync.Mutex
+	// closed segments, oldest first
+	segments []walSegment
+	// current segment entries are appended to
+	cur walSegment
+	f   *os.File
+	// rotateNext forces the rotation of the current segment before the next entry,
+	// so that an entry is never appended after a partially written entry.
+	rotateNext bool
+	// size is the total size of the segments
+	size     int64
+	nextSeq  uint64
+	lastSync time.Time
+
+	buf bytes.Buffer
+	enc *json.Encoder
+}
+
+type walSegment struct {
+	path string
+	// sequence numbers of the first and last entry, valid if the segment has entries
+	first, last uint64
+	entries     int
+	size        int64
+}
+
+// walEntryHeader is the header line of an entry.
+type walEntryHeader struct {
+	Seq              uint64 `json:"seq"`
+	Database         string `json:"database"`
+	RetentionPolicy  string `json:"retentionPolicy,omitempty"`
+	WriteConsistency string `json:"writeConsistency,omitempty"`
+	Precision        string `json:"precision,omitempty"`
+	Points           int    `json:"points"`
+}
+
+// walEntry is an entry read from the log.
+type walEntry struct {
+	seq    uint64
+	bpc    influxdb.BatchPointsConfig
+	points []influxdb.Point
+}
+
+// openWriteAheadLog opens the log in dir, creating it if it does not exist.
+// It returns the entries left in the log, oldest first, which have not been confirmed before the log was last closed.
+func openWriteAheadLog(dir string, maxSize int64, fsync string, diag NodeDiagnostic) (*writeAheadLog, []walEntry, error) {
+	if maxSize == 0 {
+		maxSize = pipeline.DefaultWalMaxSize
+	}
+	if fsync == "" {
+		fsync = pipeline.WalFsyncAlways
+	}
+	segmentSize := maxSize / walSegments
+	if segmentSize == 0 {
+		segmentSize = 1
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, nil, err
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "*"+walSegmentExt))
+	if err != nil {
+		return nil, nil, err
+	}
+	// The names of the segments sort in the order they were created.
+	sort.Strings(paths)
+
+	l := &writeAheadLog{
+		dir:         dir,
+		maxSize:     maxSize,
+		segmentSize: segmentSize,
+		fsync:       fsync,
+		diag:        diag,
+		nextSeq:     1,
+	}
+	l.enc = json.NewEncoder(&l.buf)
+	var entries []walEntry
+	for _, path := range paths {
+		segEntries, size, err := l.readSegment(path)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to read segment %q", path)
+		}
+		if len(segEntries) == 0 {
+			if err := os.Remove(path); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+		s := walSegment{
+			path:    path,
+			first:   segEntries[0].seq,
+			last:    segEntries[len(segEntries)-1].seq,
+			entries: len(segEntries),
+			size:    size,
+		}
+		l.segments = append(l.segments, s)
+		l.size += size
+		if s.last >= l.nextSeq {
+			l.nextSeq = s.last + 1
+		}
+		entries = append(entries, segEntries...)
+	}
+	if err := l.openSegment(); err != nil {
+		return nil, nil, err
+	}
+	return l, entries, nil
+}
+
+// readSegment reads the entries of a segment.
+// Incomplete entries, left by a crash while appending them, are discarded.
+func (l *writeAheadLog) readSegment(path string) ([]walEntry, int64, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	size := int64(len(data))
+	var (
+		entries   []walEntry
+		cur       *walEntry
+		count     int
+		discarded int
+	)
+	for len(data) > 0 {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			// The last line was not completely written.
+			break
+		}
+		line := data[:i]
+		data = data[i+1:]
+		if len(line) > 0 && line[0] == walHeaderPrefix {
+			if cur != nil {
+				discarded++
+			}
+			cur = nil
+			var h walEntryHeader
+			if err := json.Unmarshal(line[1:], &h); err != nil {
+				discarded++
+				continue
+			}
+			cur = &walEntry{
+				seq: h.Seq,
+				bpc: influxdb.BatchPointsConfig{
+					Database:         h.Database,
+					RetentionPolicy:  h.RetentionPolicy,
+					WriteConsistency: h.WriteConsistency,
+					Precision:        h.Precision,
+				},
+				points: make([]influxdb.Point, 0, h.Points),
+			}
+			count = h.Points
+		} else if cur != nil {
+			mps, err := dbmodels.ParsePointsWithPrecision(line, time.Time{}, "ns")
+			if err != nil || len(mps) != 1 {
+				discarded++
+				cur = nil
+				continue
+			}
+			mp := mps[0]
+			cur.points = append(cur.points, influxdb.Point{
+				Name:   mp.Name(),
+				Tags:   mp.Tags().Map(),
+				Fields: mp.Fields(),
+				Time:   mp.Time().UTC(),
+			})
+		}
+		if cur != nil && len(cur.points) == count {
+			entries = append(entries, *cur)
+			cur = nil
+		}
+	}
+	if cur != nil || len(data) > 0 {
+		discarded++
+	}
+	if discarded > 0 {
+		l.diag.Error("discarded incomplete entries of write-ahead log",
+			fmt.Errorf("%d incomplete entries", discarded),
+			keyvalue.KV("segment", path))
+	}
+	return entries, size, nil
+}
+
+// openSegment creates a new current segment.
+func (l *writeAheadLog) openSegment() error {
+	path := filepath.Join(l.dir, fmt.Sprintf("%020d%s", l.nextSeq, walSegmentExt))
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	l.f = f
+	l.cur = walSegment{path: path}
+	l.rotateNext = false
+	return nil
+}
+
+// rotate closes the current segment and creates a new one.
+func (l *writeAheadLog) rotate() error {
+	if err := l.f.Sync(); err != nil {
+		return err
+	}
+	if err := l.f.Close(); err != nil {
+		return err
+	}
+	if l.cur.entries > 0 {
+		l.segments = append(l.segments, l.cur)
+	} else {
+		l.size -= l.cur.size
+		if err := os.Remove(l.cur.path); err != nil {
+			return err
+		}
+	}
+	return l.openSegment()
+}
+
+// append appends an entry with the points to the log and returns its sequence number.
+// The entry is synced to disk according to the fsync policy of the log.
+// If the entry was appended but could not be synced both its sequence number and an error are returned.
+func (l *writeAheadLog) append(bpc influxdb.BatchPointsConfig, points []influxdb.Point) (uint64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.rotateNext || l.cur.size >= l.segmentSize {
+		if err := l.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	seq := l.nextSeq
+	l.buf.Reset()
+	l.buf.WriteByte(walHeaderPrefix)
+	// The encoder terminates the header with a newline.
+	if err := l.enc.Encode(walEntryHeader{
+		Seq:              seq,
+		Database:         bpc.Database,
+		RetentionPolicy:  bpc.RetentionPolicy,
+		WriteConsistency: bpc.WriteConsistency,
+		Precision:        bpc.Precision,
+		Points:           len(points),
+	}); err != nil {
+		return 0, err
+	}
+	for _, p := range points {
+		l.buf.Write(p.Bytes("ns"))
+		l.buf.WriteByte('\n')
+	}
+	n, err := l.f.Write(l.buf.Bytes())
+	l.cur.size += int64(n)
+	l.size += int64(n)
+	if err != nil {
+		l.rotateNext = true
+		return 0, err
+	}
+	l.nextSeq++
+	if l.cur.entries == 0 {
+		l.cur.first = seq
+	}
+	l.cur.last = seq
+	l.cur.entries++
+	l.dropOldest()
+	return seq, l.sync()
+}
+
+// sync syncs the current segment to disk according to the fsync policy.
+func (l *writeAheadLog) sync() error {
+	switch l.fsync {
+	case pipeline.WalFsyncNever:
+		return nil
+	case pipeline.WalFsyncInterval:
+		if time.Since(l.lastSync) < walSyncInterval {
+			return nil
+		}
+	}
+	l.lastSync = time.Now()
+	return l.f.Sync()
+}
+
+// dropOldest drops the oldest closed segments while the log exceeds its maximum size.
+func (l *writeAheadLog) dropOldest() {
+	for l.size > l.maxSize && len(l.segments) > 0 {
+		s := l.segments[0]
+		if err := os.Remove(s.path); err != nil {
+			l.diag.Error("failed to remove write-ahead log segment", err, keyvalue.KV("segment", s.path))
+			return
+		}
+		l.segments = l.segments[1:]
+		l.size -= s.size
+		l.diag.Error("dropped entries from write-ahead log",
+			fmt.Errorf("write-ahead log exceeds %d bytes", l.maxSize),
+			keyvalue.KV("entries", strconv.Itoa(s.entries)))
+	}
+}
+
+// oldest returns the sequence number of the oldest entry in the log,
+// or the sequence number of the next entry if the log is empty.
+func (l *writeAheadLog) oldest() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.segments) > 0 {
+		return l.segments[0].first
+	}
+	if l.cur.entries > 0 {
+		return l.cur.first
+	}
+	return l.nextSeq
+}
+
+// truncate removes the entries with a sequence number below unconfirmed from the log,
+// i.e. the entries whose writes are confirmed.
+// Only whole segments are removed, the current segment is emptied once all its entries are confirmed.
+func (l *writeAheadLog) truncate(unconfirmed uint64) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for len(l.segments) > 0 && l.segments[0].last < unconfirmed {
+		s := l.segments[0]
+		if err := os.Remove(s.path); err != nil {
+			return err
+		}
+		l.segments = l.segments[1:]
+		l.size -= s.size
+	}
+	if l.cur.entries == 0 || l.cur.last >= unconfirmed {
+		return nil
+	}
+	if err := l.f.Truncate(0); err != nil {
+		l.rotateNext = true
+		return err
+	}
+	l.size -= l.cur.size
+	l.cur = walSegment{path: l.cur.path}
+	return nil
+}
+
+// close closes the log, the current segment is removed if it has no entries.
+func (l *writeAheadLog) close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.f.Sync(); err != nil {
+		return err
+	}
+	if err := l.f.Close(); err != nil {
+		return err
+	}
+	if l.cur.entries == 0 {
+		return os.Remove(l.cur.path)
+	}
+	return nil
+}
@@ -0,0 +1,335 @@
+package kapacitor
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/influxdb"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+func TestWriteAheadLog_Recovery(t *testing.T) {
+	dir, err := ioutil.TempDir("", "influxdb_out_wal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Small segments so that each entry is in its own segment.
+	const maxSize = 400
+	l, entries, err := openWriteAheadLog(dir, maxSize, pipeline.WalFsyncAlways, &windowNodeDiagnostic{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("unexpected entries in new log: %v", entries)
+	}
+	bpc := influxdb.BatchPointsConfig{Database: "mydb", RetentionPolicy: "autogen"}
+	points := [][]influxdb.Point{
+		{{Name: "cpu", Tags: map[string]string{"host": "serverA"}, Fields: map[string]interface{}{"value": 1.0}, Time: time.Unix(1, 0).UTC()}},
+		{{Name: "cpu", Tags: map[string]string{"host": "serverB"}, Fields: map[string]interface{}{"count": int64(2)}, Time: time.Unix(2, 0).UTC()}},
+		{{Name: "cpu", Tags: map[string]string{"host": "serverC"}, Fields: map[string]interface{}{"state": "ok"}, Time: time.Unix(3, 0).UTC()}},
+	}
+	for i, ps := range points {
+		seq, err := l.append(bpc, ps)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, exp := seq, uint64(i+1); got != exp {
+			t.Fatalf("unexpected sequence number: got %d exp %d", got, exp)
+		}
+	}
+	// The write of the first entry is confirmed.
+	if err := l.truncate(2); err != nil {
+		t.Fatal(err)
+	}
+	// Crash while appending an entry with two points.
+	if _, err := l.f.Write([]byte("#{\"seq\":4,\"database\":\"mydb\",\"points\":2}\ncpu value=4 4000000000\n")); err != nil {
+		t.Fatal(err)
+	}
+	l.f.Close()
+
+	l, entries, err = openWriteAheadLog(dir, maxSize, pipeline.WalFsyncAlways, &windowNodeDiagnostic{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := []walEntry{
+		{seq: 2, bpc: bpc, points: points[1]},
+		{seq: 3, bpc: bpc, points: points[2]},
+	}
+	if !reflect.DeepEqual(entries, exp) {
+		t.Errorf("unexpected entries:\ngot %v\nexp %v", entries, exp)
+	}
+	seq, err := l.append(bpc, points[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := seq, uint64(4); got != exp {
+		t.Errorf("unexpected sequence number after recovery: got %d exp %d", got, exp)
+	}
+
+	// Once all writes are confirmed no segments are left.
+	if err := l.truncate(seq + 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.close(); err != nil {
+		t.Fatal(err)
+	}
+	segments, err := filepath.Glob(filepath.Join(dir, "*"+walSegmentExt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(segments) != 0 {
+		t.Errorf("unexpected segments left: %v", segments)
+	}
+}
+
+func TestInfluxDBOut_WalCrashRecovery(t *testing.T) {
+	dir, err := ioutil.TempDir("", "influxdb_out_wal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := newMockInfluxDB()
+	defer s.Close()
+	pn := &pipeline.InfluxDBOutNode{
+		Database:      "mydb",
+		Buffer:        1000,
+		FlushInterval: time.Hour,
+		Tags:          make(map[string]string),
+		WalDir:        dir,
+	}
+	newNode := func() *InfluxDBOutNode {
+		n, err := newInfluxDBOutNode(
+			&ExecutingTask{Task: &Task{ID: "task"}, tm: &TaskMaster{InfluxDBService: mockInfluxDBService{s: s}}},
+			pn,
+			&windowNodeDiagnostic{},
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		n.pointsWritten = new(expvar.Int)
+		n.writeErrors = new(expvar.Int)
+		return n
+	}
+
+	n := newNode()
+	if len(n.walEntries) != 0 {
+		t.Fatalf("unexpected entries in new log: %v", n.walEntries)
+	}
+	n.wb.start()
+	tags := models.Tags{"host": "serverA"}
+	for _, fields := range []models.Fields{{"value": 1.0}, {"count": int64(2)}} {
+		p := edge.NewPointMessage("cpu", "mydb", "autogen", models.Dimensions{}, fields, tags, time.Unix(0, 0))
+		if _, err := n.Point(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Crash before the buffered points are written.
+	n.wb.abort()
+	n.wb.wal.f.Close()
+	if got := s.Lines(); len(got) != 0 {
+		t.Fatalf("unexpected lines written before crash: %v", got)
+	}
+
+	// The restarted node writes the points left in the log.
+	n = newNode()
+	if got, exp := len(n.walEntries), 2; got != exp {
+		t.Fatalf("unexpected number of entries after crash: got %d exp %d", got, exp)
+	}
+	n.wb.start()
+	n.wb.replay(n.walEntries)
+	n.stopOut()
+
+	exp := []string{
+		"cpu,host=serverA value=1 0",
+		"cpu,host=serverA count=2i 0",
+	}
+	if got := s.Lines(); !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected lines written:\ngot %v\nexp %v", got, exp)
+	}
+	// The written points are removed from the log.
+	_, entries, err := openWriteAheadLog(filepath.Join(dir, "task", pn.Name()), 0, "", &windowNodeDiagnostic{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("unexpected entries after replay: %v", entries)
+	}
+}
+
+func TestInfluxDBOut_WalCrashRecovery_FailedWrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "influxdb_out_wal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := newMockInfluxDB()
+	defer s.Close()
+	pn := &pipeline.InfluxDBOutNode{
+		Database:      "mydb",
+		Buffer:        1000,
+		FlushInterval: time.Hour,
+		Tags:          make(map[string]string),
+		WalDir:        dir,
+	}
+	newNode := func() *InfluxDBOutNode {
+		n, err := newInfluxDBOutNode(
+			&ExecutingTask{Task: &Task{ID: "task"}, tm: &TaskMaster{InfluxDBService: mockInfluxDBService{s: s}}},
+			pn,
+			&windowNodeDiagnostic{},
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		n.pointsWritten = new(expvar.Int)
+		n.writeErrors = new(expvar.Int)
+		return n
+	}
+	tags := models.Tags{"host": "serverA"}
+	point := func(fields models.Fields) edge.PointMessage {
+		return edge.NewPointMessage("cpu", "mydb", "autogen", models.Dimensions{}, fields, tags, time.Unix(0, 0))
+	}
+
+	n := newNode()
+	n.wb.start()
+	// The write of the first point fails.
+	s.SetFailWrites(true)
+	if _, err := n.Point(point(models.Fields{"value": 1.0})); err != nil {
+		t.Fatal(err)
+	}
+	n.wb.flush()
+	if got, exp := n.writeErrors.IntValue(), int64(1); got != exp {
+		t.Fatalf("unexpected write errors: got %d exp %d", got, exp)
+	}
+	// The retry of the failed write fails as well.
+	if _, err := n.Point(point(models.Fields{"count": int64(2)})); err != nil {
+		t.Fatal(err)
+	}
+	n.wb.flush()
+	if got, exp := n.writeErrors.IntValue(), int64(3); got != exp {
+		t.Fatalf("unexpected write errors: got %d exp %d", got, exp)
+	}
+	// Crash before InfluxDB accepts writes again.
+	n.wb.abort()
+	n.wb.wal.f.Close()
+	s.SetFailWrites(false)
+	if got := s.Lines(); len(got) != 0 {
+		t.Fatalf("unexpected lines written before crash: %v", got)
+	}
+
+	// The restarted node writes the points of the failed writes.
+	n = newNode()
+	if got, exp := len(n.walEntries), 2; got != exp {
+		t.Fatalf("unexpected number of entries after crash: got %d exp %d", got, exp)
+	}
+	n.wb.start()
+	n.wb.replay(n.walEntries)
+	n.stopOut()
+
+	exp := []string{
+		"cpu,host=serverA value=1 0",
+		"cpu,host=serverA count=2i 0",
+	}
+	if got := s.Lines(); !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected lines written:\ngot %v\nexp %v", got, exp)
+	}
+	_, entries, err := openWriteAheadLog(filepath.Join(dir, "task", pn.Name()), 0, "", &windowNodeDiagnostic{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("unexpected entries after replay: %v", entries)
+	}
+}
+
+func TestInfluxDBOut_WalRetryFailedWrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "influxdb_out_wal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := newMockInfluxDB()
+	defer s.Close()
+	pn := &pipeline.InfluxDBOutNode{
+		Database:      "mydb",
+		Buffer:        1000,
+		FlushInterval: time.Hour,
+		Tags:          make(map[string]string),
+		WalDir:        dir,
+	}
+	n, err := newInfluxDBOutNode(
+		&ExecutingTask{Task: &Task{ID: "task"}, tm: &TaskMaster{InfluxDBService: mockInfluxDBService{s: s}}},
+		pn,
+		&windowNodeDiagnostic{},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.pointsWritten = new(expvar.Int)
+	n.writeErrors = new(expvar.Int)
+	tags := models.Tags{"host": "serverA"}
+	point := func(fields models.Fields) edge.PointMessage {
+		return edge.NewPointMessage("cpu", "mydb", "autogen", models.Dimensions{}, fields, tags, time.Unix(0, 0))
+	}
+	entries := func() int {
+		n.wb.wal.mu.Lock()
+		defer n.wb.wal.mu.Unlock()
+		e := n.wb.wal.cur.entries
+		for _, s := range n.wb.wal.segments {
+			e += s.entries
+		}
+		return e
+	}
+
+	n.wb.start()
+	defer n.stopOut()
+	// A transient failure keeps the entries of the failed write in the log.
+	s.SetFailWrites(true)
+	if _, err := n.Point(point(models.Fields{"value": 1.0})); err != nil {
+		t.Fatal(err)
+	}
+	n.wb.flush()
+	if got, exp := entries(), 1; got != exp {
+		t.Fatalf("unexpected number of entries after failed write: got %d exp %d", got, exp)
+	}
+
+	// The failed write is retried by the next flush, after which the log is truncated.
+	s.SetFailWrites(false)
+	if _, err := n.Point(point(models.Fields{"count": int64(2)})); err != nil {
+		t.Fatal(err)
+	}
+	n.wb.flush()
+	if got, exp := entries(), 0; got != exp {
+		t.Errorf("unexpected number of entries after retry: got %d exp %d", got, exp)
+	}
+	exp := []string{
+		"cpu,host=serverA value=1 0",
+		"cpu,host=serverA count=2i 0",
+	}
+	if got := s.Lines(); !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected lines written:\ngot %v\nexp %v", got, exp)
+	}
+
+	// Later writes keep the log empty.
+	if _, err := n.Point(point(models.Fields{"value": 3.0})); err != nil {
+		t.Fatal(err)
+	}
+	n.wb.flush()
+	if got, exp := entries(), 0; got != exp {
+		t.Errorf("unexpected number of entries after later write: got %d exp %d", got, exp)
+	}
+	if got, exp := n.writeErrors.IntValue(), int64(1); got != exp {
+		t.Errorf("unexpected write errors: got %d exp %d", got, exp)
+	}
+}
@@ -4,10 +4,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -16,6 +21,7 @@ import (
 	"github.com/influxdata/kapacitor/alert"
 	"github.com/influxdata/kapacitor/clock"
 	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/nats/natstest"
 	alertservice "github.com/influxdata/kapacitor/services/alert"
 	"github.com/influxdata/kapacitor/services/httppost"
 	"github.com/influxdata/kapacitor/services/storage/storagetest"
@@ -481,6 +487,1115 @@ batch
 
 	testBatcherWithOutput(t, "TestBatch_MovingAverage", script, 21*time.Second, er, false)
 }
+
+func TestBatch_RollingAverage(t *testing.T) {
+
+	var script = `
+batch
+	|query('''
+		SELECT "value"
+		FROM "telegraf"."default".cpu
+''')
+		.period(10s)
+		.every(10s)
+	|rollingAverage('value', 2)
+	|httpOut('TestBatch_RollingAverage')
+`
+
+	// The values are reset at the start of each batch,
+	// so only the second point of the batch has an average.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    nil,
+				Columns: []string{"time", "value"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 12, 0, time.UTC),
+						2.0,
+					},
+				},
+			},
+		},
+	}
+
+	testBatcherWithOutput(t, "TestBatch_RollingAverage", script, 21*time.Second, er, false)
+}
+
+func TestBatch_Normalize(t *testing.T) {
+
+	var script = `
+batch
+	|query('''
+		SELECT "value"
+		FROM "telegraf"."default".cpu
+''')
+		.period(10s)
+		.every(10s)
+	|normalize('value')
+		.size(10)
+	|httpOut('TestBatch_Normalize')
+`
+
+	// The values are reset at the start of each batch,
+	// so the first point of the batch is alone in the window.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    nil,
+				Columns: []string{"time", "value"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
+						0.5,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 12, 0, time.UTC),
+						1.0,
+					},
+				},
+			},
+		},
+	}
+
+	testBatcherWithOutput(t, "TestBatch_Normalize", script, 21*time.Second, er, false)
+}
+
+func TestBatch_NatsOut(t *testing.T) {
+	s, err := natstest.NewServer(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	var script = `
+batch
+	|query('''
+		SELECT "value"
+		FROM "telegraf"."default".cpu
+''')
+		.period(10s)
+		.every(10s)
+		.groupBy('host')
+	|natsOut('metrics.{{ .TaskName }}')
+		.url('` + s.URL + `')
+		.format('line')
+`
+
+	clock, et, replayErr, tm := testBatcher(t, "TestBatch_NatsOut", script)
+	defer tm.Close()
+	if err := fastForwardTask(clock, et, replayErr, tm, 15*time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	messages := s.Messages()
+	if got, exp := len(messages), 1; got != exp {
+		t.Fatalf("unexpected message count: got %d exp %d", got, exp)
+	}
+	if got, exp := messages[0].Subject, "metrics.TestBatch_NatsOut"; got != exp {
+		t.Errorf("unexpected subject: got %s exp %s", got, exp)
+	}
+	if got, exp := string(messages[0].Data), "cpu,host=serverA value=1 31536000000000000"; got != exp {
+		t.Errorf("unexpected data: got %q exp %q", got, exp)
+	}
+}
+
+func TestBatch_Trend(t *testing.T) {
+
+	var script = `
+batch
+	|query('''
+		SELECT "value"
+		FROM "telegraf"."default".cpu
+''')
+		.period(10s)
+		.every(10s)
+	|trend('value')
+	|httpOut('TestBatch_Trend')
+`
+
+	// The first batch has a single point and no trend,
+	// the trend of the second batch has the time of its last point.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    nil,
+				Columns: []string{"time", "intercept", "r2", "slope"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 12, 0, time.UTC),
+						0.0,
+						1.0,
+						-2.0,
+					},
+				},
+			},
+		},
+	}
+
+	testBatcherWithOutput(t, "TestBatch_Trend", script, 21*time.Second, er, false)
+}
+
+func TestBatch_Warmup(t *testing.T) {
+
+	var script = `
+batch
+	|query('''
+		SELECT "value"
+		FROM "telegraf"."default".cpu
+''')
+		.period(10s)
+		.every(10s)
+	|warmup()
+		.count(3)
+	|httpOut('TestBatch_Warmup')
+`
+
+	// The first two batches are suppressed as a whole,
+	// since fewer than 3 points had been seen when they began.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    nil,
+				Columns: []string{"time", "value"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 20, 0, time.UTC),
+						5.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 21, 0, time.UTC),
+						6.0,
+					},
+				},
+			},
+		},
+	}
+
+	clock, et, replayErr, tm := testBatcher(t, "TestBatch_Warmup", script)
+	defer tm.Close()
+	if err := fastForwardTask(clock, et, replayErr, tm, 31*time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := et.ExecutionStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := stats.NodeStats["warmup2"]["points_suppressed"], int64(4); got != exp {
+		t.Errorf("unexpected points_suppressed: got %v exp %v", got, exp)
+	}
+
+	output, err := et.GetOutput("TestBatch_Warmup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Get(output.Endpoint())
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := models.Result{}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if eq, msg := compareResults(er, result); !eq {
+		t.Error(msg)
+	}
+}
+
+func TestBatch_BatchSizeLimit(t *testing.T) {
+	var mu sync.Mutex
+	var values [][]float64
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result := models.Result{}
+		if err := json.NewDecoder(r.Body).Decode(&result); err != nil {
+			t.Fatal(err)
+		}
+		var batch []float64
+		for _, row := range result.Series {
+			for _, v := range row.Values {
+				batch = append(batch, v[1].(float64))
+			}
+		}
+		mu.Lock()
+		values = append(values, batch)
+		mu.Unlock()
+	}))
+	defer ts.Close()
+
+	var script = `
+batch
+	|query('''
+		SELECT "value"
+		FROM "telegraf"."default".cpu
+''')
+		.period(10s)
+		.every(10s)
+	|batchSizeLimit()
+		.maxPoints(2)
+	|httpPost('` + ts.URL + `')
+`
+
+	clock, et, replayErr, tm := testBatcher(t, "TestBatch_BatchSizeLimit", script)
+	defer tm.Close()
+	if err := fastForwardTask(clock, et, replayErr, tm, 21*time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	// The first batch is split, the second batch has at most two points and is passed on unchanged.
+	mu.Lock()
+	defer mu.Unlock()
+	if exp := [][]float64{{1, 2}, {3, 4}, {5}, {6, 7}}; !reflect.DeepEqual(values, exp) {
+		t.Errorf("unexpected batches:\ngot %v\nexp %v", values, exp)
+	}
+	stats, err := et.ExecutionStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := stats.NodeStats["batch_size_limit2"]["batches_split"], int64(1); got != exp {
+		t.Errorf("unexpected batches_split: got %v exp %v", got, exp)
+	}
+}
+
+func TestBatch_Sanitize(t *testing.T) {
+
+	// The values left NaN or infinite are replaced with -9 before they are output,
+	// since they cannot be encoded as JSON.
+	var script = `
+var data = batch
+	|query('''
+		SELECT "an", "ad", "bn", "bd", "c"
+		FROM "telegraf"."default".cpu
+''')
+		.period(10s)
+		.every(10s)
+	|eval(lambda: "an" / "ad", lambda: "bn" / "bd")
+		.as('a', 'b')
+		.keep('a', 'b', 'c')
+	|sanitize()
+		.action('route')
+
+data
+	|httpOut('valid')
+
+data
+	|branch('invalid')
+	|sanitize()
+		.action('replace')
+		.replaceWith(-9.0)
+	|httpOut('invalid')
+`
+
+	// The batch is split, the invalid branch receives a batch of the routed points.
+	row := func(values ...[]interface{}) models.Result {
+		return models.Result{
+			Series: models.Rows{{
+				Name:    "cpu",
+				Columns: []string{"time", "a", "b", "c"},
+				Values:  values,
+			}},
+		}
+	}
+	value := func(s int, a, b float64, c string) []interface{} {
+		return []interface{}{time.Date(1971, 1, 1, 0, 0, s, 0, time.UTC), a, b, c}
+	}
+	outputs := map[string]models.Result{
+		"valid": row(value(3, 1, 2, "NaN")),
+		"invalid": row(
+			value(0, -9, 1, "x"),
+			value(1, 1, -9, "x"),
+			value(2, -9, -9, "x"),
+		),
+	}
+
+	clock, et, replayErr, tm := testBatcher(t, "TestBatch_Sanitize", script)
+	defer tm.Close()
+
+	if err := fastForwardTask(clock, et, replayErr, tm, 15*time.Second); err != nil {
+		t.Error(err)
+	}
+
+	for name, er := range outputs {
+		output, err := et.GetOutput(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.Get(output.Endpoint())
+		if err != nil {
+			t.Fatal(err)
+		}
+		result := models.Result{}
+		err = json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if eq, msg := compareResults(er, result); !eq {
+			t.Errorf("unexpected output for branch %s: %s", name, msg)
+		}
+	}
+
+	stats, err := et.ExecutionStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := stats.NodeStats["sanitize3"]["sanitized_points"], int64(3); got != exp {
+		t.Errorf("unexpected sanitized_points: got %v exp %v", got, exp)
+	}
+}
+
+func TestBatch_Where_DeadLetter(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "TestBatch_Where_DeadLetter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	dlqPath := filepath.Join(tmpDir, "dead_letters.log")
+
+	var script = fmt.Sprintf(`
+batch
+	|query('''
+		SELECT *
+		FROM "telegraf"."default".cpu
+''')
+		.period(10s)
+		.every(10s)
+	|where(lambda: "value" > 10)
+		.deadLetter('file', '%s')
+	|httpOut('TestBatch_Where_DeadLetter')
+`, dlqPath)
+
+	// The point without a value cannot be evaluated and is sent to the dead letter queue.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    nil,
+				Columns: []string{"time", "value"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						42.0,
+					},
+				},
+			},
+		},
+	}
+
+	testBatcherWithOutput(t, "TestBatch_Where_DeadLetter", script, 15*time.Second, er, false)
+
+	data, err := ioutil.ReadFile(dlqPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var l kapacitor.DeadLetter
+	if err := json.Unmarshal(data, &l); err != nil {
+		t.Fatal(err)
+	}
+	if l.Task != "TestBatch_Where_DeadLetter" || l.Node != "where2" || l.Name != "cpu" {
+		t.Errorf("unexpected dead letter context: task %s node %s name %s", l.Task, l.Node, l.Name)
+	}
+	if !strings.Contains(l.Error, "value") {
+		t.Errorf("unexpected error %q", l.Error)
+	}
+	if got, exp := l.Fields, (models.Fields{"idle": 1.0}); !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected fields: got %v exp %v", got, exp)
+	}
+	if got, exp := l.PointTime, time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC); !got.Equal(exp) {
+		t.Errorf("unexpected point time: got %v exp %v", got, exp)
+	}
+}
+
+func TestBatch_CountDistinct(t *testing.T) {
+
+	var script = `
+batch
+	|query('''
+		SELECT "user"
+		FROM "telegraf"."default".requests
+''')
+		.period(10s)
+		.every(10s)
+	|countDistinct('user')
+		.as('users')
+	|httpOut('TestBatch_CountDistinct')
+`
+
+	// Each of the 100 distinct users is repeated three times.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "requests",
+				Tags:    nil,
+				Columns: []string{"time", "users"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						100.0,
+					},
+				},
+			},
+		},
+	}
+
+	testBatcherWithOutput(t, "TestBatch_CountDistinct", script, 15*time.Second, er, false)
+}
+
+func TestBatch_Correlate(t *testing.T) {
+
+	var script = `
+batch
+	|query('''
+		SELECT "x", "y"
+		FROM "telegraf"."default".correlation
+''')
+		.period(10s)
+		.every(10s)
+	|correlate('x', 'y', 10)
+		.as('r')
+	|where(lambda: isPresent("r"))
+	|httpOut('TestBatch_Correlate')
+`
+
+	// Each batch is correlated separately, the last batch decreases.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "correlation",
+				Tags:    nil,
+				Columns: []string{"time", "r", "x", "y"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+						-1.0,
+						1.0,
+						-1.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+						-1.0,
+						2.0,
+						-2.0,
+					},
+				},
+			},
+		},
+	}
+
+	testBatcherWithOutput(t, "TestBatch_Correlate", script, 25*time.Second, er, false)
+}
+
+func TestBatch_Correlate_PerWindow(t *testing.T) {
+
+	var script = `
+batch
+	|query('''
+		SELECT "x", "y"
+		FROM "telegraf"."default".correlation
+''')
+		.period(10s)
+		.every(10s)
+	|correlate('x', 'y', 10)
+		.as('r')
+		.perWindow()
+	|httpOut('TestBatch_Correlate_PerWindow')
+`
+
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "correlation",
+				Tags:    nil,
+				Columns: []string{"time", "r"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+						-1.0,
+					},
+				},
+			},
+		},
+	}
+
+	testBatcherWithOutput(t, "TestBatch_Correlate_PerWindow", script, 25*time.Second, er, false)
+}
+
+func TestBatch_TagToField_Regroup(t *testing.T) {
+
+	var script = `
+batch
+	|query('''
+		SELECT "value"
+		FROM "telegraf"."default".requests
+''')
+		.period(10s)
+		.every(10s)
+		.groupBy('region', 'status')
+	|tagToField('status')
+		.type('int')
+		.as('code')
+		.regroup()
+	|httpOut('TestBatch_TagToField_Regroup')
+`
+
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "requests",
+				Tags:    map[string]string{"region": "west"},
+				Columns: []string{"time", "code", "value"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						200.0,
+						1.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
+						200.0,
+						2.0,
+					},
+				},
+			},
+		},
+	}
+
+	testBatcherWithOutput(t, "TestBatch_TagToField_Regroup", script, 15*time.Second, er, false)
+}
+
+func TestBatch_MaxDelay(t *testing.T) {
+
+	var script = `
+batch
+	|query('''
+		SELECT "value"
+		FROM "telegraf"."default".cpu
+''')
+		.period(10s)
+		.every(10s)
+	|maxDelay()
+		.maxLag(1h)
+		.unit(24h)
+	|httpOut('TestBatch_MaxDelay')
+`
+
+	clock, et, replayErr, tm := testBatcher(t, "TestBatch_MaxDelay", script)
+	defer tm.Close()
+	if err := fastForwardTask(clock, et, replayErr, tm, 15*time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := et.GetOutput("TestBatch_MaxDelay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Get(output.Endpoint())
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := models.Result{}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	// The replayed points are decades old, the lag is given in days.
+	if len(result.Series) != 1 || len(result.Series[0].Values) != 2 {
+		t.Fatalf("unexpected result: %v", result)
+	}
+	row := result.Series[0]
+	if exp := []string{"time", "lag", "lag_exceeded", "value"}; !reflect.DeepEqual(row.Columns, exp) {
+		t.Fatalf("unexpected columns: got %v exp %v", row.Columns, exp)
+	}
+	for _, v := range row.Values {
+		if lag := v[1].(float64); lag < 365*40 {
+			t.Errorf("unexpected lag: %v", lag)
+		}
+		if exceeded := v[2].(bool); !exceeded {
+			t.Errorf("expected lag to exceed the max lag, got %v", v)
+		}
+	}
+
+	stats, err := et.ExecutionStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := stats.NodeStats["maxDelay2"]["lag_breaches"], int64(2); got != exp {
+		t.Errorf("unexpected lag_breaches: got %v exp %v", got, exp)
+	}
+}
+
+func TestBatch_DecodeBase64(t *testing.T) {
+
+	var script = `
+batch
+	|query('''
+		SELECT "payload", "value"
+		FROM "telegraf"."default".events
+''')
+		.period(10s)
+		.every(10s)
+	|decodeBase64('payload')
+	|httpOut('TestBatch_DecodeBase64')
+`
+
+	// Invalid base64 and values that are not strings are errors, points without the field are not.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "events",
+				Tags:    nil,
+				Columns: []string{"time", "payload", "value"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						"hello",
+						1.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+						"not base64!",
+						2.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+						1.0,
+						3.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC),
+						nil,
+						4.0,
+					},
+				},
+			},
+		},
+	}
+
+	clock, et, replayErr, tm := testBatcher(t, "TestBatch_DecodeBase64", script)
+	defer tm.Close()
+	if err := fastForwardTask(clock, et, replayErr, tm, 15*time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := et.GetOutput("TestBatch_DecodeBase64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Get(output.Endpoint())
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := models.Result{}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if eq, msg := compareResults(er, result); !eq {
+		t.Error(msg)
+	}
+
+	stats, err := et.ExecutionStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := stats.NodeStats["decodeBase642"]["decode_errors"], int64(2); got != exp {
+		t.Errorf("unexpected decode_errors: got %v exp %v", got, exp)
+	}
+}
+
+func TestBatch_ConcatFields(t *testing.T) {
+
+	var script = `
+batch
+	|query('''
+		SELECT "host", "value"
+		FROM "telegraf"."default".requests
+''')
+		.period(10s)
+		.every(10s)
+	|concatFields()
+		.concat('a', ':', "host", 'x')
+		.concat('b', ':', "a")
+		.missing('?')
+	|httpOut('TestBatch_ConcatFields')
+`
+
+	// Concatenations refer to the fields of the point, not to the fields set before them.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "requests",
+				Tags:    nil,
+				Columns: []string{"time", "a", "b", "host", "value"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						"server01:x",
+						"?",
+						"server01",
+						1.0,
+					},
+				},
+			},
+		},
+	}
+
+	testBatcherWithOutput(t, "TestBatch_ConcatFields", script, 15*time.Second, er, false)
+}
+
+func TestBatch_LagCompensate(t *testing.T) {
+
+	var script = `
+batch
+	|query('''
+		SELECT "value"
+		FROM "telegraf"."default".requests
+''')
+		.period(10s)
+		.every(10s)
+	|lagCompensate()
+	|httpOut('TestBatch_LagCompensate')
+`
+
+	clock, et, replayErr, tm := testBatcher(t, "TestBatch_LagCompensate", script)
+	defer tm.Close()
+	start := time.Now()
+	if err := fastForwardTask(clock, et, replayErr, tm, 15*time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := et.GetOutput("TestBatch_LagCompensate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Get(output.Endpoint())
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := models.Result{}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Series) != 1 || len(result.Series[0].Values) != 2 {
+		t.Fatalf("unexpected result: %v", result)
+	}
+	// The replayed batch is shifted to the wall clock time it was received,
+	// the points are shifted alike by the lag of the batch.
+	first := result.Series[0].Values[0][0].(time.Time)
+	last := result.Series[0].Values[1][0].(time.Time)
+	if got, exp := last.Sub(first), 5*time.Second; got != exp {
+		t.Errorf("unexpected time between the points: got %v exp %v", got, exp)
+	}
+	if last.Before(start.Add(-10*time.Second)) || last.After(time.Now()) {
+		t.Errorf("unexpected time of the last point: got %v exp about %v", last, start)
+	}
+}
+
+func TestBatch_TimeInState(t *testing.T) {
+	var mu sync.Mutex
+	var got []map[string]float64
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result := models.Result{}
+		if err := json.NewDecoder(r.Body).Decode(&result); err != nil {
+			t.Fatal(err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for _, row := range result.Series {
+			durations := make(map[string]float64)
+			for _, v := range row.Values {
+				durations[v[2].(string)] = v[1].(float64)
+			}
+			got = append(got, durations)
+		}
+	}))
+	defer ts.Close()
+
+	var script = `
+batch
+	|query('''
+		SELECT "status"
+		FROM "telegraf"."default".service
+''')
+		.period(1m)
+		.every(1m)
+	|timeInState('status')
+	|httpPost('` + ts.URL + `')
+`
+
+	clock, et, replayErr, tm := testBatcher(t, "TestBatch_TimeInState", script)
+	defer tm.Close()
+	if err := fastForwardTask(clock, et, replayErr, tm, 4*time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	// The point older than the latest point is ignored, the state at the end of a window
+	// carries over into the next window until its first point and numeric states are formatted as tags.
+	exp := []map[string]float64{
+		{"up": 45, "down": 15},
+		{"up": 30, "down": 30},
+		{"down": 30, "1": 30},
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected durations:\ngot %v\nexp %v", got, exp)
+	}
+}
+
+func TestBatch_DurationFormat(t *testing.T) {
+
+	var script = `
+batch
+	|query('''
+		SELECT "elapsed"
+		FROM "telegraf"."default".jobs
+''')
+		.period(10s)
+		.every(10s)
+	|humanize('elapsed', 'elapsed_human')
+		.precision('m')
+	|httpOut('TestBatch_DurationFormat')
+`
+
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "jobs",
+				Tags:    nil,
+				Columns: []string{"time", "elapsed", "elapsed_human"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						8103e9,
+						"2h15m",
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+						59e9,
+						"1m",
+					},
+				},
+			},
+		},
+	}
+
+	testBatcherWithOutput(t, "TestBatch_DurationFormat", script, 15*time.Second, er, false)
+}
+
+func TestBatch_TagValueMap_FromField(t *testing.T) {
+
+	var script = `
+batch
+	|query('''
+		SELECT "status", "value"
+		FROM "telegraf"."default".services
+''')
+		.period(10s)
+		.every(10s)
+	|tagValueMap('status')
+		.fromField()
+		.map('2', 'crit')
+		.as('label')
+	|httpOut('TestBatch_TagValueMap_FromField')
+`
+
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "services",
+				Tags:    nil,
+				Columns: []string{"time", "label", "status", "value"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						"crit",
+						2.0,
+						1.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+						nil,
+						3.0,
+						2.0,
+					},
+				},
+			},
+		},
+	}
+
+	testBatcherWithOutput(t, "TestBatch_TagValueMap_FromField", script, 15*time.Second, er, false)
+}
+
+func TestBatch_Deadband(t *testing.T) {
+	var mu sync.Mutex
+	var got []float64
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result := models.Result{}
+		if err := json.NewDecoder(r.Body).Decode(&result); err != nil {
+			t.Fatal(err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for _, row := range result.Series {
+			for _, v := range row.Values {
+				got = append(got, v[1].(float64))
+			}
+		}
+	}))
+	defer ts.Close()
+
+	var script = `
+batch
+	|query('''
+		SELECT "value"
+		FROM "telegraf"."default".valve
+''')
+		.period(10s)
+		.every(10s)
+	|deadband('value')
+		.absolute(1.0)
+	|httpPost('` + ts.URL + `')
+`
+
+	clock, et, replayErr, tm := testBatcher(t, "TestBatch_Deadband", script)
+	defer tm.Close()
+	if err := fastForwardTask(clock, et, replayErr, tm, 25*time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	// The last emitted value is kept across batches.
+	exp := []float64{1, 3, 4.5}
+	mu.Lock()
+	defer mu.Unlock()
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected values:\ngot %v\nexp %v", got, exp)
+	}
+}
+
+func TestBatch_UpDownCounter(t *testing.T) {
+
+	var script = `
+batch
+	|query('''
+		SELECT "delta"
+		FROM "telegraf"."default".connections
+''')
+		.period(10s)
+		.every(10s)
+	|upDownCounter('delta')
+	|httpOut('TestBatch_UpDownCounter')
+`
+
+	// The total continues from the previous batch.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "connections",
+				Tags:    nil,
+				Columns: []string{"time", "delta", "total"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
+						-1.0,
+						1.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 11, 0, time.UTC),
+						2.0,
+						3.0,
+					},
+				},
+			},
+		},
+	}
+
+	testBatcherWithOutput(t, "TestBatch_UpDownCounter", script, 25*time.Second, er, false)
+}
+
+func TestBatch_ValidateSchema(t *testing.T) {
+
+	var script = `
+batch
+	|query('''
+		SELECT "amount", "items"
+		FROM "telegraf"."default".orders
+''')
+		.period(10s)
+		.every(10s)
+		.groupBy('region')
+	|validateSchema()
+		.schema('{"type":"record","name":"orders","fields":[{"name":"region","type":"string"},{"name":"amount","type":"double"},{"name":"items","type":["null","int"]}]}')
+	|httpOut('TestBatch_ValidateSchema')
+`
+
+	// The point with float items is dropped.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "orders",
+				Tags:    models.Tags{"region": "eu"},
+				Columns: []string{"time", "amount"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						1.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+						2.0,
+					},
+				},
+			},
+		},
+	}
+
+	testBatcherWithOutput(t, "TestBatch_ValidateSchema", script, 15*time.Second, er, false)
+}
+
+func TestBatch_RegexReplace(t *testing.T) {
+
+	var script = `
+batch
+	|query('''
+		SELECT "path"
+		FROM "telegraf"."default".requests
+''')
+		.period(10s)
+		.every(10s)
+		.groupBy('host')
+	|regexReplace()
+		.field('path')
+		.tag('host')
+		.rule(/^(\w+)-(\d+)$/, '$1$2')
+	|httpOut('TestBatch_RegexReplace')
+`
+
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "requests",
+				Tags:    models.Tags{"host": "web01"},
+				Columns: []string{"time", "path"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						"api2",
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+						"api",
+					},
+				},
+			},
+		},
+	}
+
+	testBatcherWithOutput(t, "TestBatch_RegexReplace", script, 15*time.Second, er, false)
+}
+
 func TestBatch_CumulativeSum(t *testing.T) {
 
 	var script = `
@@ -948,6 +2063,49 @@ batch
 
 	testBatcherWithOutput(t, "TestBatch_Delete", script, 30*time.Second, er, false)
 }
+func TestBatch_Split(t *testing.T) {
+
+	var script = `
+var split = batch
+	|query('''
+		SELECT mean("value")
+		FROM "telegraf"."default".cpu_usage_idle
+		WHERE "cpu" = 'cpu-total'
+''')
+		.period(10s)
+		.every(10s)
+		.groupBy(time(2s))
+	|split()
+		.case('high', lambda: "mean" > 90)
+		.case('low', lambda: "mean" < 20)
+
+split
+	|branch('high')
+	|count('mean')
+	|httpOut('TestBatch_Split')
+
+split
+	|branch('low')
+	|count('mean')
+	|httpOut('low')
+`
+
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu_usage_idle",
+				Tags:    map[string]string{"cpu": "cpu-total"},
+				Columns: []string{"time", "count"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 8, 0, time.UTC),
+					3.0,
+				}},
+			},
+		},
+	}
+
+	testBatcherWithOutput(t, "TestBatch_Split", script, 30*time.Second, er, false)
+}
 func TestBatch_Delete_GroupBy(t *testing.T) {
 
 	var script = `
@@ -1256,6 +2414,85 @@ batch
 
 	testBatcherWithOutput(t, "TestBatch_SimpleMR", script, 30*time.Second, er, false)
 }
+func TestBatch_AlertBatchContext(t *testing.T) {
+	var script = `
+batch
+	|query('''
+		SELECT mean("value")
+		FROM "telegraf"."default".cpu_usage_idle
+		WHERE "host" = 'serverA' AND "cpu" != 'cpu-total'
+''')
+		.period(10s)
+		.every(10s)
+		.groupBy(time(2s), 'cpu')
+	|alert()
+		.batchContext('mean', 97.5)
+		.crit(lambda: "count_over" > 0)
+	|httpOut('TestBatch_SimpleMR')
+`
+
+	// Expect no result since no value is over the threshold.
+	er := models.Result{Series: models.Rows{}}
+
+	testBatcherWithOutput(t, "TestBatch_SimpleMR", script, 30*time.Second, er, false)
+
+	script = `
+batch
+	|query('''
+		SELECT mean("value")
+		FROM "telegraf"."default".cpu_usage_idle
+		WHERE "host" = 'serverA' AND "cpu" != 'cpu-total'
+''')
+		.period(10s)
+		.every(10s)
+		.groupBy(time(2s), 'cpu')
+	|alert()
+		.batchContext('mean', 90.0)
+		.crit(lambda: "percent_over" > 70.0 AND "count" == 5 AND "min" > 90.0)
+		.levelField('level')
+	|httpOut('TestBatch_SimpleMR')
+`
+
+	er = models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu_usage_idle",
+				Tags:    map[string]string{"cpu": "cpu1"},
+				Columns: []string{"time", "level", "mean"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 20, 0, time.UTC),
+						"CRITICAL",
+						96.49999999996908,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 22, 0, time.UTC),
+						"CRITICAL",
+						93.46464646468584,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 24, 0, time.UTC),
+						"CRITICAL",
+						95.00950095007724,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 26, 0, time.UTC),
+						"CRITICAL",
+						92.99999999998636,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 28, 0, time.UTC),
+						"CRITICAL",
+						90.99999999998545,
+					},
+				},
+			},
+		},
+	}
+
+	testBatcherWithOutput(t, "TestBatch_SimpleMR", script, 30*time.Second, er, false)
+}
+
 func TestBatch_AlertLevelField(t *testing.T) {
 
 	var script = `
@@ -2191,6 +3428,117 @@ cpu0
 	testBatcherWithOutput(t, "TestBatch_Join_Fill", script, 30*time.Second, er, false)
 }
 
+func TestBatch_Join_ByIndex_Pad(t *testing.T) {
+
+	var script = `
+var cpu0 = batch
+	|query('''
+		SELECT mean("value")
+		FROM "telegraf"."default".cpu_usage_idle
+		WHERE "cpu" = 'cpu0'
+''')
+		.period(10s)
+		.every(10s)
+		.groupBy(time(2s))
+
+var cpu1 = batch
+	|query('''
+		SELECT mean("value")
+		FROM "telegraf"."default".cpu_usage_idle
+		WHERE "cpu" = 'cpu1'
+''')
+		.period(10s)
+		.every(10s)
+		.groupBy(time(2s))
+
+cpu0
+	|join(cpu1)
+		.as('cpu0', 'cpu1')
+		.byIndex()
+		.lengthMismatch('pad')
+		.fill(0.0)
+	|eval(lambda: "cpu0.mean" + "cpu1.mean")
+		.as('cpu')
+	|sum('cpu')
+	|window()
+		.period(20s)
+		.every(20s)
+	|sum('sum')
+	|httpOut('TestBatch_Join_ByIndex')
+`
+
+	// The shorter second batch of cpu1 is padded with the fill value.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu_usage_idle",
+				Columns: []string{"time", "sum"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 28, 0, time.UTC),
+					918.0,
+				}},
+			},
+		},
+	}
+
+	testBatcherWithOutput(t, "TestBatch_Join_ByIndex", script, 30*time.Second, er, false)
+}
+
+func TestBatch_Join_ByIndex_Error(t *testing.T) {
+
+	var script = `
+var cpu0 = batch
+	|query('''
+		SELECT mean("value")
+		FROM "telegraf"."default".cpu_usage_idle
+		WHERE "cpu" = 'cpu0'
+''')
+		.period(10s)
+		.every(10s)
+		.groupBy(time(2s))
+
+var cpu1 = batch
+	|query('''
+		SELECT mean("value")
+		FROM "telegraf"."default".cpu_usage_idle
+		WHERE "cpu" = 'cpu1'
+''')
+		.period(10s)
+		.every(10s)
+		.groupBy(time(2s))
+
+cpu0
+	|join(cpu1)
+		.as('cpu0', 'cpu1')
+		.byIndex()
+		.lengthMismatch('error')
+	|eval(lambda: "cpu0.mean" + "cpu1.mean")
+		.as('cpu')
+	|sum('cpu')
+	|window()
+		.period(20s)
+		.every(20s)
+	|sum('sum')
+	|httpOut('TestBatch_Join_ByIndex')
+`
+
+	// The second batches have different lengths and are dropped, only the first batches are joined.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu_usage_idle",
+				Columns: []string{"time", "sum"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 28, 0, time.UTC),
+					396.0,
+				}},
+			},
+		},
+	}
+
+	testBatcherWithOutput(t, "TestBatch_Join_ByIndex", script, 30*time.Second, er, false)
+}
+
 func TestBatch_JoinOn(t *testing.T) {
 
 	var script = `
@@ -2626,6 +3974,68 @@ data
 	testBatcherWithOutput(t, "TestBatch_StateTracking", script, 8*time.Second, er, false)
 }
 
+func TestBatch_StateMachine(t *testing.T) {
+	var script = `
+batch
+	|query('SELECT usage FROM "telegraf"."default"."cpu"')
+		.period(4s)
+		.every(4s)
+		.groupBy('host')
+	|stateMachine('ok')
+		.states('ok', 'warning', 'critical')
+		.transition('ok', 'warning', lambda: "usage" > 80)
+		.transition('warning', 'critical', lambda: "usage" > 95)
+		.transition('warning', 'ok', lambda: "usage" <= 80)
+		.transition('critical', 'ok', lambda: "usage" <= 80)
+	|httpOut('TestBatch_StateMachine')
+`
+	// The state of each group carries over from its previous batch.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverA"},
+				Columns: []string{"time", "dwell_time", "from_state", "to_state"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC),
+						4.0,
+						"ok",
+						"warning",
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
+						1.0,
+						"warning",
+						"critical",
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 7, 0, time.UTC),
+						2.0,
+						"critical",
+						"ok",
+					},
+				},
+			},
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverB"},
+				Columns: []string{"time", "dwell_time", "from_state", "to_state"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC),
+						4.0,
+						"warning",
+						"ok",
+					},
+				},
+			},
+		},
+	}
+
+	testBatcherWithOutput(t, "TestBatch_StateMachine", script, 8*time.Second, er, true)
+}
+
 func TestBatch_HttpPost(t *testing.T) {
 	requestCount := int32(0)
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -64,6 +64,22 @@ m1
 		.tolerance(1s)
 	|eval(lambda: "m1.value" * "m2.value")
 		.as('value')
+`
+	joinPreAggM12Task = `
+var m1 = stream
+	|from()
+		.measurement('m1')
+var m2 = stream
+	|from()
+		.measurement('m2')
+
+m1
+	|join(m2)
+		.as('m1','m2')
+		.tolerance(1s)
+		.preAgg('mean')
+	|eval(lambda: "m1.value" * "m2.value")
+		.as('value')
 `
 )
 
@@ -163,6 +179,19 @@ func BenchmarkJoinTask_T100_P5000(b *testing.B) {
 	Bench(b, 100, 5000, 10000, joinM12Task, "dbname", "rpname", "m1", "m2")
 }
 
+//----------------------------
+// Join PreAgg Task Benchmarks
+
+// Few tasks, many points
+func BenchmarkJoinPreAggTask_T10_P50000(b *testing.B) {
+	Bench(b, 10, 50000, 100000, joinPreAggM12Task, "dbname", "rpname", "m1", "m2")
+}
+
+// Many tasks, many points
+func BenchmarkJoinPreAggTask_T100_P5000(b *testing.B) {
+	Bench(b, 100, 5000, 10000, joinPreAggM12Task, "dbname", "rpname", "m1", "m2")
+}
+
 // Generic Benchmark method
 func Bench(b *testing.B, tasksCount, pointCount, expectedProcessedCount int, tickScript, db, rp string, measurements ...string) {
 	// Setup HTTPD service
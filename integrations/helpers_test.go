@@ -1,11 +1,13 @@
 package integrations
 
 import (
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -13,6 +15,7 @@ import (
 	"github.com/influxdata/kapacitor/alert"
 	"github.com/influxdata/kapacitor/influxdb"
 	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/services/encryption"
 	"github.com/influxdata/kapacitor/services/httpd"
 	"github.com/influxdata/kapacitor/udf"
 	"github.com/influxdata/kapacitor/uuid"
@@ -48,6 +51,32 @@ func (m *MockInfluxDBService) NewNamedClient(name string) (influxdb.Client, erro
 	})
 }
 
+// MockInfluxDBClusters returns clients of the MockInfluxDBService of each cluster name.
+type MockInfluxDBClusters map[string]*MockInfluxDBService
+
+func (m MockInfluxDBClusters) NewNamedClient(name string) (influxdb.Client, error) {
+	s, ok := m[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown InfluxDB cluster %q", name)
+	}
+	return s.NewNamedClient(name)
+}
+
+// newEncryptionService returns an encryption service whose active key is key repeated to 32 bytes.
+func newEncryptionService(t *testing.T, key string) *encryption.Service {
+	s, err := encryption.NewService(encryption.Config{
+		ActiveKey: "k1",
+		Keys: []encryption.KeyConfig{{
+			ID:  "k1",
+			Key: base64.StdEncoding.EncodeToString([]byte(strings.Repeat(key, 32/len(key)))),
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
 func compareResultsMetainfo(exp, got models.Result) (bool, string) {
 	if (exp.Err == nil && got.Err != nil) || (exp.Err != nil && got.Err == nil) {
 		return false, fmt.Sprintf("unexpected error: exp %v got %v", exp.Err, got.Err)
@@ -158,6 +187,18 @@ func (ts taskStore) LoadSnapshot(name string) (*kapacitor.TaskSnapshot, error) {
 	return nil, errors.New("not implemented")
 }
 
+// snapshotTaskStore loads the same snapshot for every task,
+// the snapshot must have an entry for each node of the task to be used.
+type snapshotTaskStore struct {
+	taskStore
+	snapshot *kapacitor.TaskSnapshot
+}
+
+func (ts snapshotTaskStore) HasSnapshot(name string) bool { return true }
+func (ts snapshotTaskStore) LoadSnapshot(name string) (*kapacitor.TaskSnapshot, error) {
+	return ts.snapshot, nil
+}
+
 type deadman struct {
 	interval  time.Duration
 	threshold float64
@@ -2,12 +2,18 @@ package integrations
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"html"
 	"io/ioutil"
+	"math"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/mail"
@@ -15,6 +21,8 @@ import (
 	"path"
 	"path/filepath"
 	"reflect"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"text/template"
@@ -27,25 +35,31 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/influxdata/influxdb/client"
 	imodels "github.com/influxdata/influxdb/models"
+	"github.com/influxdata/influxdb/toml"
 	"github.com/influxdata/kapacitor"
 	"github.com/influxdata/kapacitor/alert"
+	"github.com/influxdata/kapacitor/avro/avrotest"
 	"github.com/influxdata/kapacitor/clock"
 	"github.com/influxdata/kapacitor/command"
 	"github.com/influxdata/kapacitor/command/commandtest"
 	"github.com/influxdata/kapacitor/edge"
 	"github.com/influxdata/kapacitor/keyvalue"
 	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/nats/natstest"
 	alertservice "github.com/influxdata/kapacitor/services/alert"
 	"github.com/influxdata/kapacitor/services/alert/alerttest"
 	"github.com/influxdata/kapacitor/services/alerta"
 	"github.com/influxdata/kapacitor/services/alerta/alertatest"
 	"github.com/influxdata/kapacitor/services/diagnostic"
+	"github.com/influxdata/kapacitor/services/encryption"
 	"github.com/influxdata/kapacitor/services/hipchat"
 	"github.com/influxdata/kapacitor/services/hipchat/hipchattest"
 	"github.com/influxdata/kapacitor/services/httppost"
 	"github.com/influxdata/kapacitor/services/httppost/httpposttest"
 	k8s "github.com/influxdata/kapacitor/services/k8s/client"
 	"github.com/influxdata/kapacitor/services/k8s/k8stest"
+	"github.com/influxdata/kapacitor/services/kafka"
+	"github.com/influxdata/kapacitor/services/kafka/kafkatest"
 	"github.com/influxdata/kapacitor/services/opsgenie"
 	"github.com/influxdata/kapacitor/services/opsgenie/opsgenietest"
 	"github.com/influxdata/kapacitor/services/opsgenie2"
@@ -662,6 +676,95 @@ stream
 	testStreamerWithOutput(t, "TestStream_MovingAverage", script, 16*time.Second, er, false, nil)
 }
 
+func TestStream_RollingAverage(t *testing.T) {
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+	|rollingAverage('value', 3)
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_RollingAverage')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    nil,
+				Columns: []string{"time", "value"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+						2.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC),
+						3.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC),
+						4.0,
+					},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_RollingAverage", script, 20*time.Second, er, false, nil)
+}
+
+func TestStream_RollingAverage_Weighted(t *testing.T) {
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+	|rollingAverage('value', 3)
+		.mode('weighted')
+		.minPoints(2)
+		.as('avg')
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_RollingAverage_Weighted')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    nil,
+				Columns: []string{"time", "avg", "value"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+						5.0 / 3,
+						2.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+						14.0 / 6,
+						3.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC),
+						20.0 / 6,
+						4.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC),
+						26.0 / 6,
+						5.0,
+					},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_RollingAverage_Weighted", script, 20*time.Second, er, false, nil)
+}
+
 func TestStream_CumulativeSum(t *testing.T) {
 	var script = `
 stream
@@ -853,6 +956,53 @@ stream
 	testStreamerWithOutput(t, "TestStream_Window", script, 13*time.Second, er, false, nil)
 }
 
+func TestStream_Window_PeriodOrCount(t *testing.T) {
+
+	var script = `
+stream
+	|from()
+		.database('dbname')
+		.retentionPolicy('rpname')
+		.measurement('cpu')
+		.where(lambda: "host" == 'serverA')
+	|window()
+		.periodOrCount(5s, 1000)
+	|httpOut('TestStream_Window')
+`
+
+	// The last window is flushed by its period when the point at 10s arrives.
+	nums := []float64{
+		95.8,
+		92.7,
+		96.0,
+		93.4,
+		95.3,
+	}
+
+	values := make([][]interface{}, len(nums))
+	for i, num := range nums {
+		values[i] = []interface{}{
+			time.Date(1971, 1, 1, 0, 0, 5+i, 0, time.UTC),
+			"serverA",
+			"idle",
+			num,
+		}
+	}
+
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    nil,
+				Columns: []string{"time", "host", "type", "value"},
+				Values:  values,
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Window", script, 13*time.Second, er, false, nil)
+}
+
 func TestStream_Window_Count(t *testing.T) {
 
 	var script = `
@@ -2981,6 +3131,85 @@ stream
 	testStreamerWithOutput(t, "TestStream_Eval_Missing", script, 2*time.Hour, er, false, nil)
 }
 
+func TestStream_Eval_DeadLetter(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "TestStream_Eval_DeadLetter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	dlqPath := filepath.Join(tmpDir, "dead_letters.log")
+
+	var script = fmt.Sprintf(`
+stream
+	|from()
+		.measurement('missing')
+	|eval(lambda: "or_not_to_be")
+		.as('that_is_the_question')
+		.deadLetter('file', '%s')
+	|httpOut('TestStream_Eval_Missing')
+`, dlqPath)
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "missing",
+				Tags:    map[string]string{"t": "t1"},
+				Columns: []string{"time", "that_is_the_question"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						float64(42),
+					},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Eval_Missing", script, 2*time.Hour, er, false, nil)
+
+	f, err := os.Open(dlqPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var got []kapacitor.DeadLetter
+	dec := json.NewDecoder(f)
+	for dec.More() {
+		var l kapacitor.DeadLetter
+		if err := dec.Decode(&l); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, l)
+	}
+	exp := []struct {
+		value float64
+		time  time.Time
+	}{
+		{value: 42, time: time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{value: 24, time: time.Date(1971, 1, 1, 1, 0, 0, 0, time.UTC)},
+		{value: 24, time: time.Date(1971, 1, 1, 1, 0, 0, 0, time.UTC)},
+	}
+	if len(got) != len(exp) {
+		t.Fatalf("unexpected number of dead letters: got %d exp %d", len(got), len(exp))
+	}
+	for i, l := range got {
+		if l.Task != "TestStream_Eval_Missing" || l.Node != "eval2" || l.Name != "missing" {
+			t.Errorf("%d: unexpected dead letter context: task %s node %s name %s", i, l.Task, l.Node, l.Name)
+		}
+		if !strings.Contains(l.Error, "or_not_to_be") {
+			t.Errorf("%d: unexpected error %q", i, l.Error)
+		}
+		if !reflect.DeepEqual(l.Tags, models.Tags{"t": "t1"}) {
+			t.Errorf("%d: unexpected tags %v", i, l.Tags)
+		}
+		if got := l.Fields["to_be"]; got != exp[i].value {
+			t.Errorf("%d: unexpected field value: got %v exp %v", i, got, exp[i].value)
+		}
+		if !l.PointTime.Equal(exp[i].time) {
+			t.Errorf("%d: unexpected point time: got %v exp %v", i, l.PointTime, exp[i].time)
+		}
+	}
+}
+
 func TestStream_Default(t *testing.T) {
 	var script = `
 stream
@@ -3140,60 +3369,6726 @@ stream
 	testStreamerWithOutput(t, "TestStream_Delete_GroupBy", script, 15*time.Second, er, true, nil)
 }
 
-func TestStream_AllMeasurements(t *testing.T) {
+func TestStream_DropTags(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.groupBy('host', 'type')
+	|dropTags('type', 'cluster', 'tmp_*')
+		.regex(/^debug_/)
+	|httpOut('TestStream_DropTags')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverA", "type": "idle"},
+				Columns: []string{"time", "value"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+					9.0,
+				}},
+			},
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverA", "type": "system"},
+				Columns: []string{"time", "value"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+					6.0,
+				}},
+			},
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverB", "type": "idle"},
+				Columns: []string{"time", "value"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+					3.0,
+				}},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_DropTags", script, 15*time.Second, er, true, nil)
+}
 
+func TestStream_DropTags_Regroup(t *testing.T) {
 	var script = `
 stream
 	|from()
+		.measurement('cpu')
+		.groupBy('host', 'type')
+	|dropTags('type', 'tmp_*')
+		.regroup()
 	|window()
-		.period(10s)
-		.every(10s)
-	|count('value')
-	|httpOut('TestStream_AllMeasurements')
+		.period(2s)
+		.every(2s)
+	|sum('value')
+		.as('value')
+	|httpOut('TestStream_DropTags_Regroup')
 `
 	er := models.Result{
 		Series: models.Rows{
 			{
 				Name:    "cpu",
-				Tags:    nil,
-				Columns: []string{"time", "count"},
+				Tags:    map[string]string{"host": "serverA"},
+				Columns: []string{"time", "value"},
 				Values: [][]interface{}{[]interface{}{
-					time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
-					23.0,
+					time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+					30.0,
+				}},
+			},
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverB"},
+				Columns: []string{"time", "value"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+					6.0,
 				}},
 			},
 		},
 	}
 
-	testStreamerWithOutput(t, "TestStream_AllMeasurements", script, 15*time.Second, er, false, nil)
+	testStreamerWithOutput(t, "TestStream_DropTags_Regroup", script, 15*time.Second, er, true, nil)
 }
 
-func TestStream_HttpPost(t *testing.T) {
-	requestCount := int32(0)
-	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		result := models.Result{}
-		dec := json.NewDecoder(r.Body)
-		err := dec.Decode(&result)
-		if err != nil {
-			t.Fatal(err)
-		}
-		atomic.AddInt32(&requestCount, 1)
-		rc := atomic.LoadInt32(&requestCount)
-
-		var er models.Result
-		switch rc {
-		case 1:
-			er = models.Result{
-				Series: models.Rows{
-					{
-						Name:    "cpu",
-						Tags:    map[string]string{"host": "serverA", "type": "idle"},
-						Columns: []string{"time", "value"},
-						Values: [][]interface{}{[]interface{}{
-							time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
-							97.1,
-						}},
-					},
+func TestStream_DropFields(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.groupBy('host')
+	|dropFields('raw', 'debug_*')
+		.regex(/_tmp$/)
+	|httpOut('TestStream_DropFields')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverA"},
+				Columns: []string{"time", "tmpusage", "value"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+					3.0,
+					9.0,
+				}},
+			},
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverB"},
+				Columns: []string{"time", "value"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+					6.0,
+				}},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_DropFields", script, 15*time.Second, er, true, nil)
+}
+
+func TestStream_Percentiles(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.groupBy('host')
+	|window()
+		.period(10s)
+		.every(10s)
+	|percentiles('value', 50.0, 75.0)
+	|httpOut('TestStream_Percentiles')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverA"},
+				Columns: []string{"time", "p50", "p75"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
+					5.5,
+					7.75,
+				}},
+			},
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverB"},
+				Columns: []string{"time", "p50", "p75"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
+					55.0,
+					77.5,
+				}},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Percentiles", script, 15*time.Second, er, true, nil)
+}
+
+func TestStream_Percentiles_Barrier(t *testing.T) {
+	clock := clock.New(time.Now().UTC().Add(-10 * time.Second))
+	clock.Set(time.Now().UTC())
+	requestCount := int32(0)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result := models.Result{}
+		if err := json.NewDecoder(r.Body).Decode(&result); err != nil {
+			t.Fatal(err)
+		}
+		atomic.AddInt32(&requestCount, 1)
+		// The barrier closing the window of the first percentiles node also closes the window of the second.
+		er := models.Result{
+			Series: models.Rows{
+				{
+					Name:    "cpu",
+					Columns: []string{"time", "median50"},
+					Values: [][]interface{}{{
+						clock.Zero().Add(5 * time.Second),
+						3.0,
+					}},
+				},
+			},
+		}
+		if eq, msg := compareResults(er, result); !eq {
+			t.Error(msg)
+		}
+	}))
+	defer ts.Close()
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+	|barrier()
+		.idle(1s)
+	|percentiles('value', 50.0)
+	|percentiles('p50', 50.0)
+		.as('median')
+	|httpPost('` + ts.URL + `')
+`
+
+	dataChannel := make(chan edge.PointMessage)
+	cleanupTest := testStreamerWithInputChannel(t, "TestStream_Percentiles_Barrier", script, dataChannel, clock, nil)
+	defer func() {
+		cleanupTest()
+		if rc := atomic.LoadInt32(&requestCount); rc != 1 {
+			t.Errorf("unexpected number of requests: got %d exp %d", rc, 1)
+		}
+	}()
+
+	for i := 0; i < 5; i++ {
+		dataChannel <- edge.NewPointMessage(
+			"cpu",
+			"dbname",
+			"rpname",
+			models.Dimensions{},
+			models.Fields{"value": float64(i + 1)},
+			models.Tags{},
+			clock.Zero().Add(time.Duration(i)*time.Second),
+		)
+	}
+	time.Sleep(1500 * time.Millisecond)
+	close(dataChannel)
+}
+
+func TestStream_Percentiles_MaxWindowSize(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.groupBy('host')
+	|window()
+		.period(10s)
+		.every(10s)
+	|percentiles('value', 50.0)
+		.maxWindowSize(5)
+	|httpOut('TestStream_Percentiles_MaxWindowSize')
+`
+	// The window of serverB exceeds the max window size and is dropped.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverA"},
+				Columns: []string{"time", "p50"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
+					3.0,
+				}},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Percentiles_MaxWindowSize", script, 15*time.Second, er, false, nil)
+}
+
+func TestStream_Percentiles_SampleOverflow(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.groupBy('host')
+	|window()
+		.period(10s)
+		.every(10s)
+	|percentiles('value', 50.0)
+		.maxWindowSize(3)
+		.sampleOverflow()
+	|httpOut('TestStream_Percentiles_SampleOverflow')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverA"},
+				Columns: []string{"time", "p50"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
+					7.0,
+				}},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Percentiles_SampleOverflow", script, 15*time.Second, er, false, nil)
+}
+
+func TestStream_ValidateTime_Drop(t *testing.T) {
+	now := time.Now().UTC()
+	clock := clock.New(now.Add(-3 * time.Hour))
+	clock.Set(now)
+
+	var mu sync.Mutex
+	var values []interface{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result := models.Result{}
+		if err := json.NewDecoder(r.Body).Decode(&result); err != nil {
+			t.Fatal(err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for _, s := range result.Series {
+			for _, v := range s.Values {
+				values = append(values, v[1])
+			}
+		}
+	}))
+	defer ts.Close()
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+	|validateTime()
+		.maxPast(1h)
+	|httpPost('` + ts.URL + `')
+`
+
+	dataChannel := make(chan edge.PointMessage)
+	cleanupTest := testStreamerWithInputChannel(t, "TestStream_ValidateTime_Drop", script, dataChannel, clock, nil)
+
+	// Only the point within an hour of the current time is kept.
+	for i, tm := range []time.Time{now.Add(-3 * time.Hour), now.Add(-2 * time.Hour), now} {
+		dataChannel <- edge.NewPointMessage(
+			"cpu",
+			"dbname",
+			"rpname",
+			models.Dimensions{},
+			models.Fields{"value": float64(i)},
+			models.Tags{},
+			tm,
+		)
+	}
+	close(dataChannel)
+	cleanupTest()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if exp := []interface{}{2.0}; !reflect.DeepEqual(values, exp) {
+		t.Errorf("unexpected values:\ngot %v\nexp %v", values, exp)
+	}
+}
+
+func TestStream_ValidateTime_Clamp(t *testing.T) {
+	start := time.Now().UTC()
+	clock := clock.New(start.Add(-3 * time.Hour))
+	clock.Set(start.Add(49 * time.Hour))
+
+	var mu sync.Mutex
+	var times []time.Time
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result := models.Result{}
+		if err := json.NewDecoder(r.Body).Decode(&result); err != nil {
+			t.Fatal(err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for _, s := range result.Series {
+			for _, v := range s.Values {
+				times = append(times, v[0].(time.Time))
+			}
+		}
+	}))
+	defer ts.Close()
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+	|validateTime()
+		.maxFuture(1h)
+		.maxPast(1h)
+		.action('clamp')
+	|httpPost('` + ts.URL + `')
+`
+
+	dataChannel := make(chan edge.PointMessage)
+	cleanupTest := testStreamerWithInputChannel(t, "TestStream_ValidateTime_Clamp", script, dataChannel, clock, nil)
+
+	for _, tm := range []time.Time{start.Add(-3 * time.Hour), start.Add(48 * time.Hour)} {
+		dataChannel <- edge.NewPointMessage(
+			"cpu",
+			"dbname",
+			"rpname",
+			models.Dimensions{},
+			models.Fields{"value": 1.0},
+			models.Tags{},
+			tm,
+		)
+	}
+	close(dataChannel)
+	cleanupTest()
+	end := time.Now().UTC()
+
+	// The times are set to the nearest bound relative to the time the points were processed.
+	mu.Lock()
+	defer mu.Unlock()
+	if got, exp := len(times), 2; got != exp {
+		t.Fatalf("unexpected number of points: got %d exp %d", got, exp)
+	}
+	for i, offset := range []time.Duration{-time.Hour, time.Hour} {
+		if times[i].Before(start.Add(offset)) || times[i].After(end.Add(offset)) {
+			t.Errorf("%d: unexpected time: got %v exp between %v and %v", i, times[i], start.Add(offset), end.Add(offset))
+		}
+	}
+}
+
+func TestStream_Diff(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.groupBy('case')
+	|diff('a', 'b')
+	|httpOut('TestStream_Diff')
+`
+	// The percent change is relative to the absolute value of the baseline,
+	// it is removed when the baseline is zero.
+	// Points where a field is missing or not numeric are dropped.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"case": "increase"},
+				Columns: []string{"time", "a", "abs_difference", "b", "difference", "percent_change"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+					15.0,
+					5.0,
+					10.0,
+					5.0,
+					50.0,
+				}},
+			},
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"case": "decrease"},
+				Columns: []string{"time", "a", "abs_difference", "b", "difference", "percent_change"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+					5.0,
+					5.0,
+					10.0,
+					-5.0,
+					-50.0,
+				}},
+			},
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"case": "negative_baseline"},
+				Columns: []string{"time", "a", "abs_difference", "b", "difference", "percent_change"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+					-5.0,
+					5.0,
+					-10.0,
+					5.0,
+					50.0,
+				}},
+			},
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"case": "negative_value"},
+				Columns: []string{"time", "a", "abs_difference", "b", "difference", "percent_change"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC),
+					-30.0,
+					40.0,
+					10.0,
+					-40.0,
+					-400.0,
+				}},
+			},
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"case": "zero_baseline"},
+				Columns: []string{"time", "a", "abs_difference", "b", "difference"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC),
+					3.0,
+					3.0,
+					0.0,
+					3.0,
+				}},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Diff", script, 10*time.Second, er, true, nil)
+}
+
+func TestStream_Diff_ZeroBaseline(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+	|diff('a', 'b')
+		.zeroBaseline(0.0)
+		.differenceAs('d')
+		.absoluteAs('abs')
+		.percentAs('pct')
+	|httpOut('TestStream_Diff_ZeroBaseline')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    nil,
+				Columns: []string{"time", "a", "abs", "b", "d", "pct"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+					-3.0,
+					3.0,
+					0.0,
+					-3.0,
+					0.0,
+				}},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Diff_ZeroBaseline", script, 5*time.Second, er, false, nil)
+}
+
+func TestStream_Schedule(t *testing.T) {
+	times := []time.Time{
+		// friday before DST start opening
+		time.Date(2018, 3, 9, 14, 0, 0, 0, time.UTC),
+		// friday before DST start before opening
+		time.Date(2018, 3, 9, 13, 30, 0, 0, time.UTC),
+		// monday after DST start opening
+		time.Date(2018, 3, 12, 13, 0, 0, 0, time.UTC),
+		// monday after DST start before opening
+		time.Date(2018, 3, 12, 12, 59, 0, 0, time.UTC),
+		// friday before DST end last minute
+		time.Date(2018, 11, 2, 20, 59, 0, 0, time.UTC),
+		// friday before DST end closing
+		time.Date(2018, 11, 2, 21, 0, 0, 0, time.UTC),
+		// saturday
+		time.Date(2018, 11, 3, 15, 0, 0, 0, time.UTC),
+		// monday after DST end before opening
+		time.Date(2018, 11, 5, 13, 0, 0, 0, time.UTC),
+		// monday after DST end opening
+		time.Date(2018, 11, 5, 14, 0, 0, 0, time.UTC),
+		// overnight window before midnight
+		time.Date(2018, 3, 12, 3, 30, 0, 0, time.UTC),
+		// overnight window after midnight
+		time.Date(2018, 3, 12, 5, 30, 0, 0, time.UTC),
+		// overnight window end
+		time.Date(2018, 3, 12, 6, 0, 0, 0, time.UTC),
+		// overnight window on other days
+		time.Date(2018, 3, 13, 5, 30, 0, 0, time.UTC),
+	}
+	clock := clock.New(times[0])
+	clock.Set(time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	var mu sync.Mutex
+	var kept []interface{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result := models.Result{}
+		if err := json.NewDecoder(r.Body).Decode(&result); err != nil {
+			t.Fatal(err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for _, s := range result.Series {
+			for _, v := range s.Values {
+				kept = append(kept, v[1])
+			}
+		}
+	}))
+	defer ts.Close()
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+	|schedule()
+		.window('Mon-Fri', '09:00', '17:00')
+		.window('Sun', '23:00', '02:00')
+		.timezone('America/New_York')
+	|httpPost('` + ts.URL + `')
+`
+
+	dataChannel := make(chan edge.PointMessage)
+	cleanupTest := testStreamerWithInputChannel(t, "TestStream_Schedule", script, dataChannel, clock, nil)
+	for i, tm := range times {
+		dataChannel <- edge.NewPointMessage(
+			"cpu",
+			"dbname",
+			"rpname",
+			models.Dimensions{},
+			models.Fields{"value": float64(i)},
+			models.Tags{},
+			tm,
+		)
+	}
+	close(dataChannel)
+	cleanupTest()
+
+	// The windows follow the daylight saving time transitions of New York.
+	mu.Lock()
+	defer mu.Unlock()
+	if exp := []interface{}{0.0, 2.0, 4.0, 8.0, 9.0, 10.0}; !reflect.DeepEqual(kept, exp) {
+		t.Errorf("unexpected points kept:\ngot %v\nexp %v", kept, exp)
+	}
+}
+
+func TestStream_Schedule_FlagAsWallClock(t *testing.T) {
+	now := time.Now().UTC()
+	clock := clock.New(now.Add(-48 * time.Hour))
+	clock.Set(now)
+
+	var mu sync.Mutex
+	var flags []interface{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result := models.Result{}
+		if err := json.NewDecoder(r.Body).Decode(&result); err != nil {
+			t.Fatal(err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for _, s := range result.Series {
+			for i, c := range s.Columns {
+				if c == "today" {
+					flags = append(flags, s.Values[0][i])
+				}
+			}
+		}
+	}))
+	defer ts.Close()
+
+	// The window is the current day, points of two days earlier are within it by the wall clock.
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+	|schedule()
+		.window('` + now.Weekday().String()[:3] + `', '00:00', '24:00')
+		.wallClock()
+		.flagAs('today')
+	|httpPost('` + ts.URL + `')
+`
+
+	dataChannel := make(chan edge.PointMessage)
+	cleanupTest := testStreamerWithInputChannel(t, "TestStream_Schedule_FlagAsWallClock", script, dataChannel, clock, nil)
+	dataChannel <- edge.NewPointMessage(
+		"cpu",
+		"dbname",
+		"rpname",
+		models.Dimensions{},
+		models.Fields{"value": 1.0},
+		models.Tags{},
+		now.Add(-48*time.Hour),
+	)
+	close(dataChannel)
+	cleanupTest()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if exp := []interface{}{true}; !reflect.DeepEqual(flags, exp) {
+		t.Errorf("unexpected flags:\ngot %v\nexp %v", flags, exp)
+	}
+}
+
+func TestStream_Normalize_MinMax(t *testing.T) {
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+	|normalize('value')
+		.size(3)
+		.as('scaled')
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_Normalize_MinMax')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    nil,
+				Columns: []string{"time", "scaled", "value"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						0.5,
+						1.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+						1.0,
+						5.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+						0.5,
+						3.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC),
+						0.0,
+						3.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC),
+						1.0,
+						9.0,
+					},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Normalize_MinMax", script, 20*time.Second, er, false, nil)
+}
+
+func TestStream_Normalize_MinMaxPeriod(t *testing.T) {
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+	|normalize('value')
+		.period(2s)
+		.as('scaled')
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_Normalize_MinMaxPeriod')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    nil,
+				Columns: []string{"time", "scaled", "value"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						0.5,
+						10.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+						0.0,
+						0.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+						1.0,
+						5.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC),
+						0.5,
+						5.0,
+					},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Normalize_MinMaxPeriod", script, 20*time.Second, er, false, nil)
+}
+
+func TestStream_Normalize_ZScore(t *testing.T) {
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+	|normalize('value')
+		.method('zscore')
+		.size(2)
+		.period(3s)
+		.as('scaled')
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_Normalize_ZScore')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    nil,
+				Columns: []string{"time", "scaled", "value"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						0.0,
+						0.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+						1.0,
+						2.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+						1.0,
+						4.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC),
+						-1.0,
+						0.0,
+					},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Normalize_ZScore", script, 20*time.Second, er, false, nil)
+}
+
+func TestStream_Retract(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.groupBy('host')
+	|retract()
+		.tag('deleted')
+	|httpOut('TestStream_Retract')
+`
+	// Points are passed through unchanged, retraction points are only emitted when a group is deleted.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverA"},
+				Columns: []string{"time", "value"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+					3.0,
+				}},
+			},
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverB"},
+				Columns: []string{"time", "value"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+					2.0,
+				}},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Retract", script, 5*time.Second, er, true, nil)
+}
+
+func TestStream_Retract_Replay(t *testing.T) {
+	var mu sync.Mutex
+	var results []models.Result
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result := models.Result{}
+		if err := json.NewDecoder(r.Body).Decode(&result); err != nil {
+			t.Fatal(err)
+		}
+		mu.Lock()
+		results = append(results, result)
+		mu.Unlock()
+	}))
+	defer ts.Close()
+
+	dir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.groupBy('host')
+	|streamReplay('` + filepath.Join(dir, "testdata", "TestStream_Retract_Replay.rec") + `')
+		.replay()
+	|retract()
+		.tag('deleted')
+	|httpPost('` + ts.URL + `')
+`
+
+	testStreamerNoOutput(t, "TestStream_Retract_Replay", script, 5*time.Second, nil)
+
+	// The recording deletes the group of serverA after its last point.
+	point := func(host string, sec int, value float64) models.Result {
+		return models.Result{
+			Series: models.Rows{
+				{
+					Name:    "cpu",
+					Tags:    map[string]string{"host": host},
+					Columns: []string{"time", "value"},
+					Values: [][]interface{}{[]interface{}{
+						time.Date(1971, 1, 1, 0, 0, sec, 0, time.UTC),
+						value,
+					}},
+				},
+			},
+		}
+	}
+	exp := []models.Result{
+		point("serverA", 1, 1.0),
+		point("serverB", 2, 2.0),
+		point("serverA", 3, 3.0),
+		{
+			Series: models.Rows{
+				{
+					Name:    "cpu",
+					Tags:    map[string]string{"host": "serverA", "deleted": "true"},
+					Columns: []string{"time", "_deleted"},
+					Values: [][]interface{}{[]interface{}{
+						time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC),
+						true,
+					}},
+				},
+			},
+		},
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if got, exp := len(results), len(exp); got != exp {
+		t.Fatalf("unexpected number of posts: got %d exp %d", got, exp)
+	}
+	for i := range exp {
+		if eq, msg := compareResults(exp[i], results[i]); !eq {
+			t.Errorf("unexpected post %d: %s", i, msg)
+		}
+	}
+}
+
+func TestStream_StreamReplay(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "TestStream_StreamReplay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	recPath := filepath.Join(tmpDir, "{{ .TaskName }}.rec")
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.groupBy('host')
+	|streamReplay('%s')
+		%s
+	|window()
+		.period(10s)
+		.every(10s)
+		.align()
+	|count('value')
+	|httpOut('%s')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverA"},
+				Columns: []string{"time", "count"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
+					3.0,
+				}},
+			},
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverB"},
+				Columns: []string{"time", "count"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
+					2.0,
+				}},
+			},
+		},
+	}
+
+	// Record the data of the task.
+	testStreamerWithOutput(t, "TestStream_StreamReplay", fmt.Sprintf(script, recPath, "", "TestStream_StreamReplay"), 15*time.Second, er, true, nil)
+
+	// Replay the recording with a task that receives no cpu data of its own,
+	// the recording has the name of the recording task.
+	replayPath := filepath.Join(tmpDir, "TestStream_StreamReplay.rec")
+	testStreamerWithOutput(t, "TestStream_StreamReplay_Replay", fmt.Sprintf(script, replayPath, ".replay()", "TestStream_StreamReplay_Replay"), 15*time.Second, er, true, nil)
+}
+
+func TestStream_NatsOut(t *testing.T) {
+	s, err := natstest.NewServer(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+	|natsOut('metrics.{{ .Name }}.{{ index .Tags "host" }}')
+		.url('` + s.URL + `')
+`
+
+	clock, et, replayErr, tm := testStreamer(t, "TestStream_NatsOut", script, nil)
+	defer tm.Close()
+	if err := fastForwardTask(clock, et, replayErr, tm, 5*time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	messages := s.Messages()
+	if got, exp := len(messages), 2; got != exp {
+		t.Fatalf("unexpected message count: got %d exp %d", got, exp)
+	}
+	for i, exp := range []string{"metrics.cpu.serverA", "metrics.cpu.serverB"} {
+		if got := messages[i].Subject; got != exp {
+			t.Errorf("unexpected subject: got %s exp %s", got, exp)
+		}
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(messages[0].Data, &got); err != nil {
+		t.Fatal(err)
+	}
+	exp := map[string]interface{}{
+		"name":            "cpu",
+		"database":        "dbname",
+		"retentionPolicy": "rpname",
+		"time":            "1971-01-01T00:00:00Z",
+		"tags":            map[string]interface{}{"host": "serverA"},
+		"fields":          map[string]interface{}{"value": 1.0},
+	}
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected message:\ngot %v\nexp %v", got, exp)
+	}
+}
+
+func TestStream_NatsOut_JetStream(t *testing.T) {
+	s, err := natstest.NewServer(map[string]string{"metrics.cpu": "METRICS"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	var script = `
+stream
+	|from()
+	|natsOut('metrics.{{ .Name }}')
+		.url('` + s.URL + `')
+		.jetStream()
+`
+
+	clock, et, replayErr, tm := testStreamer(t, "TestStream_NatsOut_JetStream", script, nil)
+	defer tm.Close()
+	if err := fastForwardTask(clock, et, replayErr, tm, 5*time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the cpu subject is stored in a stream, the mem message is not acknowledged.
+	stats, err := et.ExecutionStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := stats.NodeStats["nats_out2"]["published"], int64(1); got != exp {
+		t.Errorf("unexpected published: got %v exp %v", got, exp)
+	}
+	if got, exp := stats.NodeStats["nats_out2"]["ack_errors"], int64(1); got != exp {
+		t.Errorf("unexpected ack errors: got %v exp %v", got, exp)
+	}
+}
+
+func TestStream_NatsOut_InvalidSubject(t *testing.T) {
+	s, err := natstest.NewServer(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+	|natsOut('metrics.{{ index .Tags "host" }}')
+		.url('` + s.URL + `')
+	|httpOut('TestStream_NatsOut_InvalidSubject')
+`
+
+	// The point without a host tag cannot be published but is passed through.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    nil,
+				Columns: []string{"time", "value"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+					1.0,
+				}},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_NatsOut_InvalidSubject", script, 5*time.Second, er, false, nil)
+
+	if got := len(s.Messages()); got != 0 {
+		t.Errorf("expected no messages, got %d", got)
+	}
+}
+
+func TestStream_Trend(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.groupBy('host')
+	|trend('value')
+		.size(3)
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_Trend')
+`
+	// Nothing is emitted for the first point, the trend of each later point
+	// is computed over the last three points.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverA"},
+				Columns: []string{"time", "intercept", "r2", "slope"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+						3.0,
+						1.0,
+						2.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+						5.0,
+						1.0,
+						2.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC),
+						4.5,
+						0.25,
+						0.5,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC),
+						3.0,
+						1.0,
+						-1.0,
+					},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Trend", script, 20*time.Second, er, false, nil)
+}
+
+func TestStream_Trend_Barrier(t *testing.T) {
+	clock := clock.New(time.Now().UTC().Add(-10 * time.Second))
+	clock.Set(time.Now().UTC())
+	requestCount := int32(0)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result := models.Result{}
+		if err := json.NewDecoder(r.Body).Decode(&result); err != nil {
+			t.Fatal(err)
+		}
+		atomic.AddInt32(&requestCount, 1)
+		// The trend of the points received before the barrier has the time of the newest point.
+		er := models.Result{
+			Series: models.Rows{
+				{
+					Name:    "disk",
+					Columns: []string{"time", "intercept", "r2", "slope"},
+					Values: [][]interface{}{{
+						clock.Zero().Add(3 * time.Second),
+						30.0,
+						1.0,
+						10.0,
+					}},
+				},
+			},
+		}
+		if eq, msg := compareResults(er, result); !eq {
+			t.Error(msg)
+		}
+	}))
+	defer ts.Close()
+
+	var script = `
+stream
+	|from()
+		.measurement('disk')
+	|barrier()
+		.idle(1s)
+	|trend('value')
+	|httpPost('` + ts.URL + `')
+`
+
+	dataChannel := make(chan edge.PointMessage)
+	cleanupTest := testStreamerWithInputChannel(t, "TestStream_Trend_Barrier", script, dataChannel, clock, nil)
+	defer func() {
+		cleanupTest()
+		if rc := atomic.LoadInt32(&requestCount); rc != 1 {
+			t.Errorf("unexpected number of requests: got %d exp %d", rc, 1)
+		}
+	}()
+
+	for i := 0; i < 4; i++ {
+		dataChannel <- edge.NewPointMessage(
+			"disk",
+			"dbname",
+			"rpname",
+			models.Dimensions{},
+			models.Fields{"value": float64(10 * i)},
+			models.Tags{},
+			clock.Zero().Add(time.Duration(i)*time.Second),
+		)
+	}
+	// The second idle barrier has no points to compute a trend of.
+	time.Sleep(2500 * time.Millisecond)
+	close(dataChannel)
+}
+
+func TestStream_EncryptDecrypt(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('signups')
+	|encrypt('email', 'missing')
+	|where(lambda: strHasPrefix("email", 'k1:'))
+	|decrypt('email')
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_EncryptDecrypt')
+`
+	// The point whose email is not a string cannot be encrypted and is dropped.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "signups",
+				Tags:    nil,
+				Columns: []string{"time", "email", "value"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						"a@example.com",
+						1.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+						"b@example.com",
+						3.0,
+					},
+				},
+			},
+		},
+	}
+
+	tmInit := func(tm *kapacitor.TaskMaster) {
+		tm.EncryptionService = newEncryptionService(t, "a")
+	}
+
+	testStreamerWithOutput(t, "TestStream_EncryptDecrypt", script, 15*time.Second, er, false, tmInit)
+}
+
+func TestStream_Decrypt_WrongKey(t *testing.T) {
+	clock := clock.New(time.Now().UTC().Add(-10 * time.Second))
+	clock.Set(time.Now().UTC())
+	requestCount := int32(0)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result := models.Result{}
+		if err := json.NewDecoder(r.Body).Decode(&result); err != nil {
+			t.Fatal(err)
+		}
+		atomic.AddInt32(&requestCount, 1)
+		er := models.Result{
+			Series: models.Rows{
+				{
+					Name:    "signups",
+					Columns: []string{"time", "email"},
+					Values: [][]interface{}{{
+						clock.Zero().Add(time.Second),
+						"b@example.com",
+					}},
+				},
+			},
+		}
+		if eq, msg := compareResults(er, result); !eq {
+			t.Error(msg)
+		}
+	}))
+	defer ts.Close()
+
+	var script = `
+stream
+	|from()
+		.measurement('signups')
+	|decrypt('email')
+	|httpPost('` + ts.URL + `')
+`
+
+	tmInit := func(tm *kapacitor.TaskMaster) {
+		tm.EncryptionService = newEncryptionService(t, "b")
+	}
+
+	dataChannel := make(chan edge.PointMessage)
+	cleanupTest := testStreamerWithInputChannel(t, "TestStream_Decrypt_WrongKey", script, dataChannel, clock, tmInit)
+	defer func() {
+		cleanupTest()
+		if rc := atomic.LoadInt32(&requestCount); rc != 1 {
+			t.Errorf("unexpected number of requests: got %d exp %d", rc, 1)
+		}
+	}()
+
+	// The value encrypted with another key cannot be decrypted and is dropped.
+	for i, s := range []*encryption.Service{newEncryptionService(t, "a"), newEncryptionService(t, "b")} {
+		ciphertext, err := s.Encrypt([]byte(fmt.Sprintf("%c@example.com", 'a'+i)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		dataChannel <- edge.NewPointMessage(
+			"signups",
+			"dbname",
+			"rpname",
+			models.Dimensions{},
+			models.Fields{"email": ciphertext},
+			models.Tags{},
+			clock.Zero().Add(time.Duration(i)*time.Second),
+		)
+	}
+	time.Sleep(100 * time.Millisecond)
+	close(dataChannel)
+}
+
+func TestStream_Backfill(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.groupBy('host')
+	|backfill('SELECT value FROM "db"."rp"."cpu"')
+		.lookback(1h)
+	|window()
+		.period(20s)
+		.every(20s)
+	|httpOut('TestStream_Backfill')
+`
+	// The point written last before the task stopped is queried again and dropped,
+	// the late live point of serverB is within the backfilled range and dropped.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverA"},
+				Columns: []string{"time", "value"},
+				Values: [][]interface{}{
+					{
+						time.Date(1970, 12, 31, 23, 59, 54, 0, time.UTC),
+						4.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						10.0,
+					},
+				},
+			},
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverB"},
+				Columns: []string{"time", "value"},
+				Values: [][]interface{}{
+					{
+						time.Date(1970, 12, 31, 23, 59, 53, 0, time.UTC),
+						3.0,
+					},
+					{
+						time.Date(1970, 12, 31, 23, 59, 55, 0, time.UTC),
+						5.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+						11.0,
+					},
+				},
+			},
+		},
+	}
+
+	var queries []string
+	influxdb := NewMockInfluxDBService(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ping" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		queries = append(queries, r.URL.Query().Get("q"))
+		w.Write([]byte(`{"results":[{"series":[
+	{"name":"cpu","tags":{"host":"serverB"},"columns":["time","value"],"values":[["1970-12-31T23:59:53Z",3],["1970-12-31T23:59:55Z",5]]},
+	{"name":"cpu","tags":{"host":"serverA"},"columns":["time","value"],"values":[["1970-12-31T23:59:52Z",2],["1970-12-31T23:59:54Z",4]]}
+]}]}`))
+	}))
+	tmInit := func(tm *kapacitor.TaskMaster) {
+		tm.InfluxDBService = influxdb
+		// The task stopped after writing the point of serverA at 23:59:52.
+		tm.TaskStore = snapshotTaskStore{
+			snapshot: &kapacitor.TaskSnapshot{
+				NodeSnapshots: map[string][]byte{
+					"stream0":   nil,
+					"from1":     nil,
+					"backfill2": []byte(`{"lastWritten":"1970-12-31T23:59:52Z"}`),
+					"window3":   nil,
+					"http_out4": nil,
+				},
+			},
+		}
+	}
+
+	testStreamerWithOutput(t, "TestStream_Backfill", script, 25*time.Second, er, false, tmInit)
+
+	if exp := []string{
+		`SELECT value FROM db.rp.cpu WHERE time >= '1970-12-31T23:59:52Z' AND time < '1971-01-01T00:00:00Z' GROUP BY *`,
+	}; !reflect.DeepEqual(queries, exp) {
+		t.Errorf("unexpected queries:\ngot %v\nexp %v", queries, exp)
+	}
+}
+
+func TestStream_Backfill_Lookback(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+	|backfill('SELECT value FROM "db"."rp"."cpu"')
+		.lookback(1h)
+	|httpOut('TestStream_Backfill_Lookback')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    nil,
+				Columns: []string{"time", "value"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						1.0,
+					},
+				},
+			},
+		},
+	}
+
+	var queries []string
+	influxdb := NewMockInfluxDBService(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ping" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		queries = append(queries, r.URL.Query().Get("q"))
+		w.Write([]byte(`{"results":[{}]}`))
+	}))
+	tmInit := func(tm *kapacitor.TaskMaster) {
+		tm.InfluxDBService = influxdb
+		// The last written point is older than the lookback.
+		tm.TaskStore = snapshotTaskStore{
+			snapshot: &kapacitor.TaskSnapshot{
+				NodeSnapshots: map[string][]byte{
+					"stream0":   nil,
+					"from1":     nil,
+					"backfill2": []byte(`{"lastWritten":"1970-12-31T00:00:00Z"}`),
+					"http_out3": nil,
+				},
+			},
+		}
+	}
+
+	testStreamerWithOutput(t, "TestStream_Backfill_Lookback", script, 5*time.Second, er, false, tmInit)
+
+	if exp := []string{
+		`SELECT value FROM db.rp.cpu WHERE time >= '1970-12-31T23:00:00Z' AND time < '1971-01-01T00:00:00Z' GROUP BY *`,
+	}; !reflect.DeepEqual(queries, exp) {
+		t.Errorf("unexpected queries:\ngot %v\nexp %v", queries, exp)
+	}
+}
+
+func TestStream_CardinalityLimit(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('requests')
+		.groupBy(*)
+	|cardinalityLimit()
+		.maxCardinality(3)
+		.window(1m)
+		.topTag()
+	|window()
+		.period(5m)
+		.every(5m)
+	|httpOut('TestStream_CardinalityLimit')
+`
+	// Repeated groups do not count towards the limit,
+	// each window is counted from scratch and breaches at most once.
+	// The breach of the last window closes the window of the first two breaches.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "requests",
+				Tags:    nil,
+				Columns: []string{"time", "cardinality", "top_tag", "top_tag_cardinality"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 20, 0, time.UTC),
+						4.0,
+						"request_id",
+						3.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 2, 0, 0, time.UTC),
+						4.0,
+						"request_id",
+						4.0,
+					},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_CardinalityLimit", script, 7*time.Minute, er, false, nil)
+}
+
+func TestStream_Warmup_Count(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.groupBy('host')
+	|warmup()
+		.count(2)
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_Warmup_Count')
+`
+	// Each group warms up independently.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverA"},
+				Columns: []string{"time", "value"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+						3.0,
+					},
+				},
+			},
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverB"},
+				Columns: []string{"time", "value"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
+						6.0,
+					},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Warmup_Count", script, 20*time.Second, er, false, nil)
+}
+
+func TestStream_Warmup_Period(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+	|warmup()
+		.period(10s)
+		.count(100)
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_Warmup_Period')
+`
+	// The period is reached before the count.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    nil,
+				Columns: []string{"time", "value"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
+						4.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 15, 0, time.UTC),
+						5.0,
+					},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Warmup_Period", script, 30*time.Second, er, false, nil)
+}
+
+func TestStream_ThresholdLearn_Stddev(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+	|thresholdLearn('value')
+		.window(1h)
+		.refresh(5m)
+		.method('stddev')
+		.k(3.0)
+	|window()
+		.period(6h)
+		.every(6h)
+	|httpOut('TestStream_ThresholdLearn_Stddev')
+`
+	// A stationary series with mean 50 and standard deviation 2 over 6h, one value every 10s.
+	rows := testThresholdLearn(t, "TestStream_ThresholdLearn_Stddev", script, 8*time.Hour)
+
+	// Nothing is emitted during the first learning window.
+	if got, exp := len(rows), 5*360; got != exp {
+		t.Fatalf("unexpected number of points emitted: got %d exp %d", got, exp)
+	}
+	if got, exp := rows[0].time, time.Date(1971, 1, 1, 1, 0, 0, 0, time.UTC); !got.Equal(exp) {
+		t.Fatalf("unexpected time of first emitted point: got %v exp %v", got, exp)
+	}
+
+	// The bounds are close to mean ± 3 stddev and stable between refreshes.
+	prev := rows[0]
+	refreshes := 0
+	for _, b := range rows {
+		if math.Abs(b.lower-44) > 1 || math.Abs(b.upper-56) > 1 {
+			t.Fatalf("bounds at %v not near [44, 56]: got [%f, %f]", b.time, b.lower, b.upper)
+		}
+		if b.lower != prev.lower || b.upper != prev.upper {
+			refreshes++
+			if math.Abs(b.lower-prev.lower) > 0.5 || math.Abs(b.upper-prev.upper) > 0.5 {
+				t.Fatalf("bounds at %v changed by more than 0.5: got [%f, %f] previous [%f, %f]", b.time, b.lower, b.upper, prev.lower, prev.upper)
+			}
+			if d := b.time.Sub(prev.time); d < 5*time.Minute {
+				t.Fatalf("bounds at %v refreshed after %v", b.time, d)
+			}
+			prev = b
+		}
+	}
+	// The bounds are refreshed every 5m after the first hour, the last refresh is at 5h55m.
+	if got, exp := refreshes, 59; got != exp {
+		t.Errorf("unexpected number of refreshes: got %d exp %d", got, exp)
+	}
+}
+
+func TestStream_ThresholdLearn_Percentile(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+	|thresholdLearn('value')
+		.method('percentile')
+	|window()
+		.period(3h)
+		.every(3h)
+	|httpOut('TestStream_ThresholdLearn_Percentile')
+`
+	// A stationary sawtooth series cycling through 0 to 99 over 3h, one value every 10s.
+	// The string value at 2h0m5s cannot be learned from and is dropped.
+	rows := testThresholdLearn(t, "TestStream_ThresholdLearn_Percentile", script, 5*time.Hour)
+
+	if got, exp := len(rows), 2*360; got != exp {
+		t.Fatalf("unexpected number of points emitted: got %d exp %d", got, exp)
+	}
+	for _, b := range rows {
+		if math.Abs(b.lower-5) > 1 || math.Abs(b.upper-94) > 1 {
+			t.Fatalf("bounds at %v not near [5, 94]: got [%f, %f]", b.time, b.lower, b.upper)
+		}
+	}
+}
+
+type learnedBounds struct {
+	time         time.Time
+	lower, upper float64
+}
+
+// testThresholdLearn runs the script and returns the bounds of the points of its httpOut node.
+func testThresholdLearn(t *testing.T, name, script string, duration time.Duration) []learnedBounds {
+	clock, et, replayErr, tm := testStreamer(t, name, script, nil)
+	defer tm.Close()
+	if err := fastForwardTask(clock, et, replayErr, tm, duration); err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := et.GetOutput(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Get(output.Endpoint())
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := models.Result{}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := len(result.Series), 1; got != exp {
+		t.Fatalf("unexpected number of series: got %d exp %d", got, exp)
+	}
+	row := result.Series[0]
+	if exp := []string{"time", "lower", "upper", "value"}; !reflect.DeepEqual(row.Columns, exp) {
+		t.Fatalf("unexpected columns: got %v exp %v", row.Columns, exp)
+	}
+	bounds := make([]learnedBounds, len(row.Values))
+	for i, v := range row.Values {
+		bounds[i] = learnedBounds{
+			time:  v[0].(time.Time),
+			lower: v[1].(float64),
+			upper: v[2].(float64),
+		}
+	}
+	return bounds
+}
+
+func TestStream_Decimals(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+	|decimals()
+		.round('usage_idle', 2)
+		.round('usage_user', 1)
+		.round('count', 2)
+		.round('name', 2)
+		.round('ok', 2)
+		.truncate('load', 1)
+		.truncate('missing', 1)
+	|httpOut('TestStream_Decimals')
+`
+	// Halfway values are rounded to the nearest even decimal,
+	// non-float fields are left unchanged.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    nil,
+				Columns: []string{"time", "count", "load", "name", "ok", "other", "usage_idle", "usage_user"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						42.0,
+						1.9,
+						"serverA",
+						true,
+						0.123456,
+						97.05,
+						-1.2,
+					},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Decimals", script, 5*time.Second, er, false, nil)
+}
+
+func TestStream_Decimals_HalfUp(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+	|decimals()
+		.round('usage_user', 1)
+		.halfUp()
+	|httpOut('TestStream_Decimals_HalfUp')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    nil,
+				Columns: []string{"time", "usage_user"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						-1.3,
+					},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Decimals_HalfUp", script, 5*time.Second, er, false, nil)
+}
+
+func TestStream_JSONExtract(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('events')
+	|jsonExtract('payload')
+		.extract('$.user.id', 'user_id', 'int')
+		.extract('$.user.name', 'user', 'tag')
+		.extract('$.user.admin', 'admin', 'bool')
+		.extract('user.score', 'score', 'float')
+		.extract('$["request-id"]', 'request_id', 'string')
+		.extract('$.items[0].price', 'first_price', 'float')
+		.extract('$.items[-1].price', 'last_price', 'int')
+		.extract('$.items[1].sku', 'sku', 'tag')
+		.extract('$.tags', 'tags', 'string')
+		.extract('$.items[0]', 'first', 'string')
+		.extract('$.user.id', 'user_id_string', 'string')
+		// Missing paths
+		.extract('$.user.email', 'email', 'string')
+		.extract('$.items[5].price', 'price', 'float')
+		.extract('$.user.id.value', 'value', 'int')
+		.extract('$.user[0]', 'first_user', 'string')
+		.extract('$.nothing', 'nothing', 'string')
+		// Coercion errors
+		.extract('$.user.name', 'name', 'float')
+		.extract('$.items[0].price', 'int_price', 'int')
+		.extract('$.user', 'user_bool', 'bool')
+	|httpOut('TestStream_JSONExtract')
+`
+	payload := `{"user": {"id": 42, "name": "alice", "admin": true, "score": "9.5"}, "items": [{"sku": "a1", "price": 9.99}, {"sku": "b2", "price": 20}], "request-id": "r-1", "tags": ["x", "y"], "big": 9007199254740993, "nothing": null}`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "events",
+				Tags:    map[string]string{"host": "serverA", "sku": "b2", "user": "alice"},
+				Columns: []string{"time", "admin", "first", "first_price", "last_price", "payload", "request_id", "score", "tags", "user_id", "user_id_string"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						true,
+						`{"price":9.99,"sku":"a1"}`,
+						9.99,
+						20.0,
+						payload,
+						"r-1",
+						9.5,
+						`["x","y"]`,
+						42.0,
+						"42",
+					},
+				},
+			},
+		},
+	}
+
+	testJSONExtract(t, "TestStream_JSONExtract", script, 5*time.Second, er, 0, 3)
+}
+
+func TestStream_JSONExtract_Malformed(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('events')
+	|jsonExtract('payload')
+		.extract('$.user.id', 'user_id', 'int')
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_JSONExtract_Malformed')
+`
+	// Points whose payload is not a string containing valid JSON are passed on unchanged.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "events",
+				Tags:    nil,
+				Columns: []string{"time", "host", "payload", "user_id"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						"serverA",
+						`{"user": {"id": 42}}`,
+						42.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+						"serverA",
+						`{"user": {"id": 42}`,
+						nil,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+						"serverA",
+						`{"user": {"id": 42}} {}`,
+						nil,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC),
+						"serverA",
+						42.0,
+						nil,
+					},
+				},
+			},
+		},
+	}
+
+	testJSONExtract(t, "TestStream_JSONExtract_Malformed", script, 15*time.Second, er, 3, 0)
+}
+
+func TestStream_JSONExtract_DropMalformed(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('events')
+	|jsonExtract('payload')
+		.extract('$.user.id', 'user_id', 'int')
+		.dropMalformed()
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_JSONExtract_DropMalformed')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "events",
+				Tags:    nil,
+				Columns: []string{"time", "host", "payload", "user_id"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+						"serverA",
+						`{"user": {"id": 42}}`,
+						42.0,
+					},
+				},
+			},
+		},
+	}
+
+	testJSONExtract(t, "TestStream_JSONExtract_DropMalformed", script, 15*time.Second, er, 1, 0)
+}
+
+// testJSONExtract runs the script and checks the output of its httpOut node and the statistics of its jsonExtract node.
+func testJSONExtract(t *testing.T, name, script string, duration time.Duration, er models.Result, malformed, coercionErrors int64) {
+	clock, et, replayErr, tm := testStreamer(t, name, script, nil)
+	defer tm.Close()
+	if err := fastForwardTask(clock, et, replayErr, tm, duration); err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := et.GetOutput(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Get(output.Endpoint())
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := models.Result{}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if eq, msg := compareResults(er, result); !eq {
+		t.Error(msg)
+	}
+
+	stats, err := et.ExecutionStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := stats.NodeStats["json_extract2"]["malformed"], malformed; got != exp {
+		t.Errorf("unexpected malformed: got %v exp %v", got, exp)
+	}
+	if got, exp := stats.NodeStats["json_extract2"]["coercion_errors"], coercionErrors; got != exp {
+		t.Errorf("unexpected coercion_errors: got %v exp %v", got, exp)
+	}
+}
+
+func TestStream_Sanitize(t *testing.T) {
+	row := func(values ...[]interface{}) models.Result {
+		return models.Result{
+			Series: models.Rows{{
+				Name:    "cpu",
+				Columns: []string{"time", "a", "b", "c"},
+				Values:  values,
+			}},
+		}
+	}
+	value := func(s int, a, b float64, c string) []interface{} {
+		return []interface{}{time.Date(1971, 1, 1, 0, 0, s, 0, time.UTC), a, b, c}
+	}
+	testCases := []struct {
+		name       string
+		properties string
+		outputs    map[string]models.Result
+	}{
+		{
+			name:       "drop",
+			properties: ".action('drop')",
+			outputs: map[string]models.Result{
+				"valid": row(value(3, 1, 2, "NaN")),
+			},
+		},
+		{
+			name:       "replace",
+			properties: ".action('replace').replaceWith(-1.0)",
+			outputs: map[string]models.Result{
+				"valid": row(
+					value(0, -1, 1, "x"),
+					value(1, 1, -1, "x"),
+					value(2, -1, -1, "x"),
+					value(3, 1, 2, "NaN"),
+				),
+			},
+		},
+		{
+			name:       "route",
+			properties: ".action('route')",
+			outputs: map[string]models.Result{
+				"valid": row(value(3, 1, 2, "NaN")),
+				"invalid": row(
+					value(0, -9, 1, "x"),
+					value(1, 1, -9, "x"),
+					value(2, -9, -9, "x"),
+				),
+			},
+		},
+		{
+			name:       "fields",
+			properties: ".fields('b')",
+			outputs: map[string]models.Result{
+				"valid": row(
+					value(0, -9, 1, "x"),
+					value(3, 1, 2, "NaN"),
+				),
+			},
+		},
+		{
+			name:       "field actions",
+			properties: ".fieldAction('a', 'replace').fieldAction('b', 'route')",
+			outputs: map[string]models.Result{
+				"valid": row(
+					value(0, 0, 1, "x"),
+					value(3, 1, 2, "NaN"),
+				),
+				"invalid": row(
+					value(1, 1, -9, "x"),
+					value(2, 0, -9, "x"),
+				),
+			},
+		},
+		{
+			name:       "drop takes precedence",
+			properties: ".action('route').fieldAction('a', 'drop')",
+			outputs: map[string]models.Result{
+				"valid":   row(value(3, 1, 2, "NaN")),
+				"invalid": row(value(1, 1, -9, "x")),
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// The values left NaN or infinite are replaced with -9 before they are output,
+			// since they cannot be encoded as JSON.
+			script := `
+var data = stream
+	|from()
+		.measurement('cpu')
+	|eval(lambda: "an" / "ad", lambda: "bn" / "bd")
+		.as('a', 'b')
+		.keep('a', 'b', 'c')
+	|sanitize()
+		` + tc.properties + `
+
+data
+	|sanitize()
+		.action('replace')
+		.replaceWith(-9.0)
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('valid')
+`
+			if _, ok := tc.outputs["invalid"]; ok {
+				script += `
+data
+	|branch('invalid')
+	|sanitize()
+		.action('replace')
+		.replaceWith(-9.0)
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('invalid')
+`
+			}
+
+			clock, et, replayErr, tm := testStreamer(t, "TestStream_Sanitize", script, nil)
+			defer tm.Close()
+
+			if err := fastForwardTask(clock, et, replayErr, tm, 25*time.Second); err != nil {
+				t.Error(err)
+			}
+
+			for name, er := range tc.outputs {
+				output, err := et.GetOutput(name)
+				if err != nil {
+					t.Fatal(err)
+				}
+				resp, err := http.Get(output.Endpoint())
+				if err != nil {
+					t.Fatal(err)
+				}
+				result := models.Result{}
+				err = json.NewDecoder(resp.Body).Decode(&result)
+				resp.Body.Close()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if eq, msg := compareResults(er, result); !eq {
+					t.Errorf("unexpected output for branch %s: %s", name, msg)
+				}
+			}
+		})
+	}
+}
+
+func TestStream_CountDistinct_Interval(t *testing.T) {
+	type estimate struct {
+		time  time.Time
+		count float64
+	}
+	var mu sync.Mutex
+	var got []estimate
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result := models.Result{}
+		if err := json.NewDecoder(r.Body).Decode(&result); err != nil {
+			t.Fatal(err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for _, row := range result.Series {
+			for _, v := range row.Values {
+				got = append(got, estimate{time: v[0].(time.Time), count: v[1].(float64)})
+			}
+		}
+	}))
+	defer ts.Close()
+
+	var script = `
+stream
+	|from()
+		.measurement('requests')
+	|countDistinct('user')
+		.interval(1m)
+	|httpPost('` + ts.URL + `')
+`
+
+	clock, et, replayErr, tm := testStreamer(t, "TestStream_CountDistinct_Interval", script, nil)
+	defer tm.Close()
+	if err := fastForwardTask(clock, et, replayErr, tm, 8*time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	// The first window counts the string, integer and float values and the value of the tag
+	// of the point without the field, windows without values emit nothing.
+	exp := []estimate{
+		{time: time.Date(1971, 1, 1, 0, 1, 0, 0, time.UTC), count: 5},
+		{time: time.Date(1971, 1, 1, 0, 2, 0, 0, time.UTC), count: 1},
+		{time: time.Date(1971, 1, 1, 0, 6, 0, 0, time.UTC), count: 1},
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected estimates:\ngot %v\nexp %v", got, exp)
+	}
+}
+
+func TestStream_CountDistinct_Barrier(t *testing.T) {
+	clock := clock.New(time.Now().UTC().Add(-10 * time.Second))
+	clock.Set(time.Now().UTC())
+	requestCount := int32(0)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result := models.Result{}
+		if err := json.NewDecoder(r.Body).Decode(&result); err != nil {
+			t.Fatal(err)
+		}
+		atomic.AddInt32(&requestCount, 1)
+		// The barrier closing the window of the first countDistinct node also closes the window of the second.
+		er := models.Result{
+			Series: models.Rows{
+				{
+					Name:    "requests",
+					Columns: []string{"time", "estimates"},
+					Values: [][]interface{}{{
+						clock.Zero().Add(5 * time.Second),
+						1.0,
+					}},
+				},
+			},
+		}
+		if eq, msg := compareResults(er, result); !eq {
+			t.Error(msg)
+		}
+	}))
+	defer ts.Close()
+
+	var script = `
+stream
+	|from()
+		.measurement('requests')
+	|barrier()
+		.idle(1s)
+	|countDistinct('user')
+	|countDistinct('count_distinct')
+		.as('estimates')
+	|httpPost('` + ts.URL + `')
+`
+
+	dataChannel := make(chan edge.PointMessage)
+	cleanupTest := testStreamerWithInputChannel(t, "TestStream_CountDistinct_Barrier", script, dataChannel, clock, nil)
+	defer func() {
+		cleanupTest()
+		if rc := atomic.LoadInt32(&requestCount); rc != 1 {
+			t.Errorf("unexpected number of requests: got %d exp %d", rc, 1)
+		}
+	}()
+
+	for i := 0; i < 5; i++ {
+		dataChannel <- edge.NewPointMessage(
+			"requests",
+			"dbname",
+			"rpname",
+			models.Dimensions{},
+			models.Fields{"user": fmt.Sprintf("user%d", i%3)},
+			models.Tags{},
+			clock.Zero().Add(time.Duration(i)*time.Second),
+		)
+	}
+	time.Sleep(1500 * time.Millisecond)
+	close(dataChannel)
+}
+
+func TestStream_Correlate(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('correlation')
+		.groupBy('case')
+	|correlate('x', 'y', 5)
+		.as('r')
+	|httpPost('%s')
+`
+	// The coefficient is null until the second point and the point without y is dropped.
+	testCorrelate(t, "TestStream_Correlate", script, map[string][]interface{}{
+		"positive": {nil, 1.0, 1.0, 1.0},
+		"negative": {nil, -1.0, -1.0},
+		"partial":  {nil, 1.0, 0.9819805060619657, 0.7181848464596079, 0.7745966692414834},
+	})
+}
+
+func TestStream_Correlate_Rolling(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('correlation')
+		.groupBy('case')
+	|correlate('x', 'y', 3)
+		.as('r')
+	|httpPost('%s')
+`
+	// The coefficient is null while the values of y have no variance.
+	testCorrelate(t, "TestStream_Correlate_Rolling", script, map[string][]interface{}{
+		"rolling":     {nil, 1.0, 1.0, 0.8660254037844387, -0.8660254037844387, -1.0},
+		"no_variance": {nil, nil, nil, 0.8660254037844387},
+	})
+}
+
+func TestStream_Correlate_PerWindow(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('correlation')
+	|correlate('x', 'y', 3)
+		.as('r')
+		.perWindow()
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_Correlate_PerWindow')
+`
+	// The second window decreases, the third has no variance of y and emits nothing.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "correlation",
+				Tags:    nil,
+				Columns: []string{"time", "r"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+						1.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
+						-1.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 11, 0, time.UTC),
+						1.0,
+					},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Correlate_PerWindow", script, 15*time.Second, er, false, nil)
+}
+
+// testCorrelate runs the script, which posts to the URL in place of its %s verb,
+// and compares the coefficients of each case with the expected ones, nil if the coefficient is null.
+func testCorrelate(t *testing.T, name, script string, exp map[string][]interface{}) {
+	var mu sync.Mutex
+	got := make(map[string][]interface{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result := models.Result{}
+		if err := json.NewDecoder(r.Body).Decode(&result); err != nil {
+			t.Fatal(err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for _, row := range result.Series {
+			for _, v := range row.Values {
+				var c interface{}
+				for i, col := range row.Columns {
+					if col == "r" {
+						c = v[i]
+					}
+				}
+				got[row.Tags["case"]] = append(got[row.Tags["case"]], c)
+			}
+		}
+	}))
+	defer ts.Close()
+
+	clock, et, replayErr, tm := testStreamer(t, name, fmt.Sprintf(script, ts.URL), nil)
+	defer tm.Close()
+	if err := fastForwardTask(clock, et, replayErr, tm, 10*time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(got) != len(exp) {
+		t.Fatalf("unexpected cases: got %v exp %v", got, exp)
+	}
+	for c, rs := range exp {
+		if len(got[c]) != len(rs) {
+			t.Errorf("%s: unexpected coefficients: got %v exp %v", c, got[c], rs)
+			continue
+		}
+		for i, r := range rs {
+			if r == nil || got[c][i] == nil {
+				if r != got[c][i] {
+					t.Errorf("%s: unexpected coefficient %d: got %v exp %v", c, i, got[c][i], r)
+				}
+				continue
+			}
+			if math.Abs(got[c][i].(float64)-r.(float64)) > 1e-9 {
+				t.Errorf("%s: unexpected coefficient %d: got %v exp %v", c, i, got[c][i], r)
+			}
+		}
+	}
+}
+
+func TestStream_FieldToTag(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('requests')
+	|fieldToTag('host')
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_FieldToTag')
+`
+	// Values of any type are converted to tags, the point without the field is not modified.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "requests",
+				Tags:    nil,
+				Columns: []string{"time", "host", "value"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						"a",
+						1.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+						"-42",
+						1.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+						"0.1",
+						1.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC),
+						"1000000000000000000000",
+						1.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC),
+						"true",
+						1.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
+						nil,
+						1.0,
+					},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_FieldToTag", script, 15*time.Second, er, false, nil)
+}
+
+func TestStream_FieldToTag_Keep(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('requests')
+	|fieldToTag('request_id')
+		.as('id')
+		.keep()
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_FieldToTag_Keep')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "requests",
+				Tags:    nil,
+				Columns: []string{"time", "id", "request_id", "value"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						"r1",
+						"r1",
+						1.0,
+					},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_FieldToTag_Keep", script, 15*time.Second, er, false, nil)
+}
+
+func TestStream_FieldToTag_Regroup(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('requests')
+		.groupBy('region')
+	|fieldToTag('host')
+		.regroup()
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_FieldToTag_Regroup')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "requests",
+				Tags:    map[string]string{"host": "a", "region": "west"},
+				Columns: []string{"time", "value"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						1.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+						3.0,
+					},
+				},
+			},
+			{
+				Name:    "requests",
+				Tags:    map[string]string{"host": "b", "region": "west"},
+				Columns: []string{"time", "value"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+						2.0,
+					},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_FieldToTag_Regroup", script, 15*time.Second, er, true, nil)
+}
+
+func TestStream_TagToField(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('requests')
+	|tagToField('status')
+		.type('int')
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_TagToField')
+`
+	// The value that is not an integer is left as a tag and the point without the tag is not modified.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "requests",
+				Tags:    nil,
+				Columns: []string{"time", "status", "value"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						200.0,
+						1.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+						"OK",
+						1.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+						nil,
+						1.0,
+					},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_TagToField", script, 15*time.Second, er, false, nil)
+}
+
+func TestStream_TagToField_Types(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('requests')
+	|tagToField('a')
+		.type('float')
+	|tagToField('b')
+		.type('bool')
+	|tagToField('c')
+		.as('code')
+		.keep()
+	|httpOut('TestStream_TagToField_Types')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "requests",
+				Tags:    map[string]string{"c": "200"},
+				Columns: []string{"time", "a", "b", "code", "value"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						0.5,
+						true,
+						"200",
+						1.0,
+					},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_TagToField_Types", script, 5*time.Second, er, false, nil)
+}
+
+func TestStream_TagToField_Dimension(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('requests')
+		.groupBy('status')
+	|tagToField('status')
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_TagToField_Dimension')
+`
+	// Tags that are group by dimensions are kept.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "requests",
+				Tags:    map[string]string{"status": "200"},
+				Columns: []string{"time", "status", "value"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						"200",
+						1.0,
+					},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_TagToField_Dimension", script, 15*time.Second, er, false, nil)
+}
+
+func TestStream_TagToField_Regroup(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('requests')
+		.groupBy('region', 'status')
+	|tagToField('status')
+		.type('int')
+		.as('code')
+		.regroup()
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_TagToField_Regroup')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "requests",
+				Tags:    map[string]string{"region": "west"},
+				Columns: []string{"time", "code", "value"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						200.0,
+						1.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+						500.0,
+						2.0,
+					},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_TagToField_Regroup", script, 15*time.Second, er, false, nil)
+}
+
+func TestStream_StreamCompact(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('valves')
+	|streamCompact('state')
+	|httpPost('%s')
+`
+	// Changes of other fields are ignored, a missing field is a change.
+	testStreamCompact(t, "TestStream_StreamCompact", script, 10*time.Second, []int{0, 2, 4, 6}, 3, 0)
+}
+
+func TestStream_StreamCompact_AllFields(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('valves')
+	|streamCompact()
+	|httpPost('%s')
+`
+	// Added and removed fields are changes and values of different types differ.
+	testStreamCompact(t, "TestStream_StreamCompact_AllFields", script, 10*time.Second, []int{0, 2, 3, 4, 5}, 1, 0)
+}
+
+func TestStream_StreamCompact_MaxInterval(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('valves')
+	|streamCompact('state')
+		.maxInterval(10s)
+	|httpPost('%s')
+`
+	// The interval restarts with each emitted point.
+	testStreamCompact(t, "TestStream_StreamCompact_MaxInterval", script, 45*time.Second, []int{0, 10, 15, 40}, 3, 2)
+}
+
+// testStreamCompact runs the script, which posts to the URL in place of its %s verb,
+// and compares the seconds of the emitted points and the statistics of the streamCompact node.
+func testStreamCompact(t *testing.T, name, script string, duration time.Duration, exp []int, compacted, heartbeats int64) {
+	var mu sync.Mutex
+	var got []int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result := models.Result{}
+		if err := json.NewDecoder(r.Body).Decode(&result); err != nil {
+			t.Fatal(err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for _, row := range result.Series {
+			for _, v := range row.Values {
+				got = append(got, v[0].(time.Time).Second())
+			}
+		}
+	}))
+	defer ts.Close()
+
+	clock, et, replayErr, tm := testStreamer(t, name, fmt.Sprintf(script, ts.URL), nil)
+	defer tm.Close()
+	if err := fastForwardTask(clock, et, replayErr, tm, duration); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected emitted points: got %v exp %v", got, exp)
+	}
+	stats, err := et.ExecutionStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := stats.NodeStats["streamCompact2"]["points_compacted"], compacted; got != exp {
+		t.Errorf("unexpected points_compacted: got %v exp %v", got, exp)
+	}
+	if got, exp := stats.NodeStats["streamCompact2"]["heartbeats"], heartbeats; got != exp {
+		t.Errorf("unexpected heartbeats: got %v exp %v", got, exp)
+	}
+}
+
+func TestStream_Sessionize(t *testing.T) {
+	var got []string
+	ts := newSessionCollector(t, time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), &got)
+	defer ts.Close()
+
+	var script = `
+stream
+	|from()
+		.measurement('clicks')
+		.groupBy('user')
+	|sessionize()
+		.timeout(10m)
+	|httpPost('` + ts.URL + `')
+`
+
+	clock, et, replayErr, tm := testStreamer(t, "TestStream_Sessionize", script, nil)
+	defer tm.Close()
+	if err := fastForwardTask(clock, et, replayErr, tm, 60*time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	// A gap equal to the timeout continues the session, the sessions open when the task stops are not summarized.
+	exp := []string{
+		"point alice 1 0s",
+		"point bob 1 1m0s",
+		"point alice 1 5m0s",
+		"summary bob 1 start=1m0s end=1m0s count=1",
+		"point bob 2 15m0s",
+		"summary alice 1 start=0s end=5m0s count=2",
+		"point alice 2 20m0s",
+		"point alice 2 30m0s",
+		"summary alice 2 start=20m0s end=30m0s count=2",
+		"point alice 3 50m0s",
+	}
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected messages:\ngot\n%v\nexp\n%v", got, exp)
+	}
+	stats, err := et.ExecutionStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := stats.NodeStats["sessionize2"]["sessions"], int64(3); got != exp {
+		t.Errorf("unexpected sessions: got %v exp %v", got, exp)
+	}
+}
+
+func TestStream_Sessionize_IdleTimeout(t *testing.T) {
+	clock := clock.New(time.Now().UTC().Add(-10 * time.Second))
+	clock.Set(time.Now().UTC())
+
+	var got []string
+	ts := newSessionCollector(t, clock.Zero(), &got)
+	defer ts.Close()
+
+	var script = `
+stream
+	|from()
+		.measurement('clicks')
+		.groupBy('user')
+	|sessionize()
+		.timeout(500ms)
+	|httpPost('` + ts.URL + `')
+`
+
+	dataChannel := make(chan edge.PointMessage)
+	cleanupTest := testStreamerWithInputChannel(t, "TestStream_Sessionize_IdleTimeout", script, dataChannel, clock, nil)
+	defer func() {
+		cleanupTest()
+		// The session closes once no points arrive for the timeout according to the system clock.
+		exp := []string{
+			"point alice 1 0s",
+			"summary alice 1 start=0s end=0s count=1",
+		}
+		ts.mu.Lock()
+		defer ts.mu.Unlock()
+		if !reflect.DeepEqual(got, exp) {
+			t.Errorf("unexpected messages:\ngot\n%v\nexp\n%v", got, exp)
+		}
+	}()
+
+	dataChannel <- edge.NewPointMessage(
+		"clicks",
+		"dbname",
+		"rpname",
+		models.Dimensions{TagNames: []string{"user"}},
+		models.Fields{"value": 1.0},
+		models.Tags{"user": "alice"},
+		clock.Zero(),
+	)
+	time.Sleep(1500 * time.Millisecond)
+	close(dataChannel)
+}
+
+func TestStream_Sessionize_Barrier(t *testing.T) {
+	clock := clock.New(time.Now().UTC().Add(-10 * time.Second))
+	clock.Set(time.Now().UTC())
+
+	var got []string
+	ts := newSessionCollector(t, clock.Zero(), &got)
+	defer ts.Close()
+
+	var script = `
+stream
+	|from()
+		.measurement('clicks')
+		.groupBy('user')
+	|barrier()
+		.period(1s)
+	|sessionize()
+		.timeout(5s)
+	|httpPost('` + ts.URL + `')
+`
+
+	dataChannel := make(chan edge.PointMessage)
+	cleanupTest := testStreamerWithInputChannel(t, "TestStream_Sessionize_Barrier", script, dataChannel, clock, nil)
+	defer func() {
+		cleanupTest()
+		// The barrier at the current time is beyond the timeout of the session.
+		exp := []string{
+			"point alice 1 0s",
+			"point alice 1 1s",
+			"summary alice 1 start=0s end=1s count=2",
+		}
+		ts.mu.Lock()
+		defer ts.mu.Unlock()
+		if !reflect.DeepEqual(got, exp) {
+			t.Errorf("unexpected messages:\ngot\n%v\nexp\n%v", got, exp)
+		}
+	}()
+
+	for i := 0; i < 2; i++ {
+		dataChannel <- edge.NewPointMessage(
+			"clicks",
+			"dbname",
+			"rpname",
+			models.Dimensions{TagNames: []string{"user"}},
+			models.Fields{"value": 1.0},
+			models.Tags{"user": "alice"},
+			clock.Zero().Add(time.Duration(i)*time.Second),
+		)
+	}
+	time.Sleep(1500 * time.Millisecond)
+	close(dataChannel)
+}
+
+// sessionCollector collects the points and summaries posted by a sessionize node.
+type sessionCollector struct {
+	*httptest.Server
+	mu sync.Mutex
+}
+
+// newSessionCollector describes each posted point relative to t0 and appends it to got,
+// got must only be read with the lock of the collector held.
+func newSessionCollector(t *testing.T, t0 time.Time, got *[]string) *sessionCollector {
+	c := new(sessionCollector)
+	c.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result := models.Result{}
+		if err := json.NewDecoder(r.Body).Decode(&result); err != nil {
+			t.Fatal(err)
+		}
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		for _, row := range result.Series {
+			for _, v := range row.Values {
+				if row.Name != "session" {
+					*got = append(*got, fmt.Sprintf("point %s %s %v", row.Tags["user"], row.Tags["session_id"], v[0].(time.Time).Sub(t0)))
+					continue
+				}
+				fields := make(map[string]float64)
+				for i, c := range row.Columns[1:] {
+					fields[c] = v[i+1].(float64)
+				}
+				// The nanosecond times lose precision as JSON numbers.
+				at := func(ns float64) time.Duration {
+					return time.Unix(0, int64(ns)).Sub(t0).Round(time.Millisecond)
+				}
+				*got = append(*got, fmt.Sprintf("summary %s %s start=%v end=%v count=%v",
+					row.Tags["user"],
+					row.Tags["session_id"],
+					at(fields["start"]),
+					at(fields["end"]),
+					fields["count"],
+				))
+			}
+		}
+	}))
+	return c
+}
+
+func TestStream_DeriveTag(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+	|deriveTag('env')
+		.fromTag('host')
+		.regex(/^(?P<env>[a-z]+)-/)
+		.missing('unknown')
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_DeriveTag')
+`
+	// The host that does not match and the missing host are set to the missing value.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    nil,
+				Columns: []string{"time", "env", "host", "value"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						"prod",
+						"prod-web-1",
+						1.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+						"unknown",
+						"localhost",
+						2.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+						"unknown",
+						nil,
+						3.0,
+					},
+				},
+			},
+		},
+	}
+
+	testDeriveTag(t, "TestStream_DeriveTag", script, 15*time.Second, er, false, 2)
+}
+
+func TestStream_DeriveTag_Capture(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+	|deriveTag('env')
+		.fromField('hostname')
+		.regex(/^(?P<site>[a-z]+)-(?P<environment>[a-z]+)-/)
+		.capture('environment')
+	|deriveTag('class')
+		.fromField('code')
+		.regex(/^(?P<class>\d)/)
+	|httpOut('TestStream_DeriveTag_Capture')
+`
+	// Integer fields are matched as decimal strings.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"class": "5", "env": "dev"},
+				Columns: []string{"time", "code", "hostname", "value"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						503.0,
+						"ams-dev-db",
+						1.0,
+					},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_DeriveTag_Capture", script, 5*time.Second, er, false, nil)
+}
+
+func TestStream_DeriveTag_NoMatch(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+	|deriveTag('env')
+		.fromTag('host')
+		.regex(/^(?P<env>[a-z]+)-/)
+	|httpOut('TestStream_DeriveTag_NoMatch')
+`
+	// Without a missing value the existing tag is removed.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "localhost", "region": "west"},
+				Columns: []string{"time", "value"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						1.0,
+					},
+				},
+			},
+		},
+	}
+
+	testDeriveTag(t, "TestStream_DeriveTag_NoMatch", script, 5*time.Second, er, false, 1)
+}
+
+func TestStream_DeriveTag_NoMatch_Dimension(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.groupBy('env')
+	|deriveTag('env')
+		.fromTag('host')
+		.regex(/^(?P<env>[a-z]+)-/)
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_DeriveTag_NoMatch_Dimension')
+`
+	// Existing tags that are group by dimensions are kept.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"env": "prod"},
+				Columns: []string{"time", "host", "value"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						"localhost",
+						1.0,
+					},
+				},
+			},
+		},
+	}
+
+	testDeriveTag(t, "TestStream_DeriveTag_NoMatch_Dimension", script, 15*time.Second, er, false, 2)
+}
+
+func TestStream_DeriveTag_Regroup(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.groupBy('region')
+	|deriveTag('env')
+		.fromTag('host')
+		.regex(/^(?P<env>[a-z]+)-/)
+		.regroup()
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_DeriveTag_Regroup')
+`
+	// Points that do not match stay in their group.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"env": "prod", "region": "west"},
+				Columns: []string{"time", "host", "value"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						"prod-web-1",
+						1.0,
+					},
+				},
+			},
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"region": "west"},
+				Columns: []string{"time", "host", "value"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+						"localhost",
+						2.0,
+					},
+				},
+			},
+		},
+	}
+
+	testDeriveTag(t, "TestStream_DeriveTag_Regroup", script, 15*time.Second, er, true, 2)
+}
+
+func testDeriveTag(t *testing.T, name, script string, duration time.Duration, er models.Result, ignoreOrder bool, unmatched int64) {
+	clock, et, replayErr, tm := testStreamer(t, name, script, nil)
+	defer tm.Close()
+	if err := fastForwardTask(clock, et, replayErr, tm, duration); err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := et.GetOutput(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Get(output.Endpoint())
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := models.Result{}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	compare := compareResults
+	if ignoreOrder {
+		compare = compareResultsIgnoreSeriesOrder
+	}
+	if eq, msg := compare(er, result); !eq {
+		t.Error(msg)
+	}
+
+	stats, err := et.ExecutionStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := stats.NodeStats["deriveTag2"]["unmatched"], unmatched; got != exp {
+		t.Errorf("unexpected unmatched: got %v exp %v", got, exp)
+	}
+}
+
+func TestStream_MaxDelay(t *testing.T) {
+	var got []maxDelayLag
+	ts := newMaxDelayCollector(t, &got)
+	defer ts.Close()
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+	|maxDelay()
+		.maxLag(1m)
+	|httpPost('` + ts.URL + `')
+`
+
+	testMaxDelay(t, "TestStream_MaxDelay", script, []time.Duration{30 * time.Second, 5 * time.Minute}, func() {
+		exp := []maxDelayLag{
+			{lag: 30, exceeded: false},
+			{lag: 300, exceeded: true},
+		}
+		ts.mu.Lock()
+		defer ts.mu.Unlock()
+		compareMaxDelayLags(t, got, exp, 1)
+	})
+}
+
+func TestStream_MaxDelay_BreachesOnly(t *testing.T) {
+	var got []maxDelayLag
+	ts := newMaxDelayCollector(t, &got)
+	defer ts.Close()
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+	|maxDelay()
+		.maxLag(1s)
+		.unit(1ms)
+		.breachesOnly()
+	|httpPost('` + ts.URL + `')
+`
+
+	testMaxDelay(t, "TestStream_MaxDelay_BreachesOnly", script, []time.Duration{500 * time.Millisecond, 2 * time.Second, 0}, func() {
+		exp := []maxDelayLag{
+			{lag: 2000, exceeded: true},
+		}
+		ts.mu.Lock()
+		defer ts.mu.Unlock()
+		compareMaxDelayLags(t, got, exp, 500)
+	})
+}
+
+// maxDelayLag is the lag of a point and whether it exceeded the max lag.
+type maxDelayLag struct {
+	lag      float64
+	exceeded bool
+}
+
+// maxDelayCollector collects the lags posted by a maxDelay node.
+type maxDelayCollector struct {
+	*httptest.Server
+	mu sync.Mutex
+}
+
+// newMaxDelayCollector appends the lag of each posted point to got,
+// got must only be read with the lock of the collector held.
+func newMaxDelayCollector(t *testing.T, got *[]maxDelayLag) *maxDelayCollector {
+	c := new(maxDelayCollector)
+	c.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result := models.Result{}
+		if err := json.NewDecoder(r.Body).Decode(&result); err != nil {
+			t.Fatal(err)
+		}
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		for _, row := range result.Series {
+			for _, v := range row.Values {
+				var l maxDelayLag
+				for i, c := range row.Columns {
+					switch c {
+					case "lag":
+						l.lag = v[i].(float64)
+					case "lag_exceeded":
+						l.exceeded = v[i].(bool)
+					}
+				}
+				*got = append(*got, l)
+			}
+		}
+	}))
+	return c
+}
+
+// testMaxDelay sends points that are the given lags old when the task starts,
+// and calls check once the task has finished.
+func testMaxDelay(t *testing.T, name, script string, lags []time.Duration, check func()) {
+	// The lag is measured against the wall clock, so the points are sent in real time.
+	// The replay moves the points relative to the zero time of the clock, which is the time of the first point.
+	now := time.Now().UTC()
+	clock := clock.New(now.Add(-lags[0]))
+	clock.Set(now)
+
+	dataChannel := make(chan edge.PointMessage)
+	cleanupTest := testStreamerWithInputChannel(t, name, script, dataChannel, clock, nil)
+	defer func() {
+		cleanupTest()
+		check()
+	}()
+
+	for _, lag := range lags {
+		dataChannel <- edge.NewPointMessage(
+			"cpu",
+			"dbname",
+			"rpname",
+			models.Dimensions{},
+			models.Fields{"value": 1.0},
+			models.Tags{},
+			now.Add(-lag),
+		)
+	}
+	time.Sleep(100 * time.Millisecond)
+	close(dataChannel)
+}
+
+// compareMaxDelayLags compares the lags allowing for the time it takes to process the points,
+// the lags grow by at most slack.
+func compareMaxDelayLags(t *testing.T, got, exp []maxDelayLag, slack float64) {
+	if len(got) != len(exp) {
+		t.Fatalf("unexpected lags: got %v exp %v", got, exp)
+	}
+	for i := range exp {
+		if got[i].exceeded != exp[i].exceeded || got[i].lag < exp[i].lag || got[i].lag >= exp[i].lag+slack {
+			t.Errorf("unexpected lag %d: got %v exp %v", i, got[i], exp[i])
+		}
+	}
+}
+
+func TestStream_Base64(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('events')
+	|encodeBase64('payload')
+		.as('encoded')
+	|decodeBase64('encoded')
+		.as('decoded')
+	|httpOut('TestStream_Base64')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "events",
+				Tags:    nil,
+				Columns: []string{"time", "decoded", "encoded", "payload", "value"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						`{"user":"bob"}`,
+						"eyJ1c2VyIjoiYm9iIn0=",
+						`{"user":"bob"}`,
+						1.0,
+					},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Base64", script, 5*time.Second, er, false, nil)
+}
+
+func TestStream_BloomFilter(t *testing.T) {
+	var falsePositives, falseNegatives int32
+	counter := func(c *int32) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(c, 1)
+		}))
+	}
+	fp := counter(&falsePositives)
+	defer fp.Close()
+	fn := counter(&falseNegatives)
+	defer fn.Close()
+
+	var script = `
+var logins = stream
+	|from()
+		.measurement('logins')
+	|bloomFilter('user')
+		.expected(1000)
+		.falsePositiveRate(0.01)
+
+logins
+	|where(lambda: "pass" == 1 AND "seen")
+	|httpPost('` + fp.URL + `')
+
+logins
+	|where(lambda: "pass" == 2 AND !"seen")
+	|httpPost('` + fn.URL + `')
+`
+
+	clock := clock.New(time.Now().UTC().Add(-10 * time.Second))
+	clock.Set(time.Now().UTC())
+
+	dataChannel := make(chan edge.PointMessage)
+	cleanupTest := testStreamerWithInputChannel(t, "TestStream_BloomFilter", script, dataChannel, clock, nil)
+	defer func() {
+		cleanupTest()
+		// The rate of false positives among the first sightings stays below the configured rate,
+		// as the filter only fills up to the expected number of IDs, and seen users are never missed.
+		if got := atomic.LoadInt32(&falsePositives); got > 10 {
+			t.Errorf("unexpected number of false positives: got %d exp at most %d", got, 10)
+		}
+		if got := atomic.LoadInt32(&falseNegatives); got != 0 {
+			t.Errorf("unexpected number of false negatives: got %d exp %d", got, 0)
+		}
+	}()
+
+	for pass := 1; pass <= 2; pass++ {
+		for i := 0; i < 1000; i++ {
+			dataChannel <- edge.NewPointMessage(
+				"logins",
+				"dbname",
+				"rpname",
+				models.Dimensions{},
+				models.Fields{"user": fmt.Sprintf("user%d", i), "pass": int64(pass)},
+				models.Tags{},
+				clock.Zero(),
+			)
+		}
+	}
+	time.Sleep(100 * time.Millisecond)
+	close(dataChannel)
+}
+
+func TestStream_BloomFilter_Tag(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+	|bloomFilter('host')
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_BloomFilter_Tag')
+`
+	// The point without the ID passes through unchanged.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    nil,
+				Columns: []string{"time", "host", "seen", "value"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						"a",
+						false,
+						1.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+						"a",
+						true,
+						2.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+						nil,
+						nil,
+						3.0,
+					},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_BloomFilter_Tag", script, 15*time.Second, er, false, nil)
+}
+
+func TestStream_BloomFilter_Rotate(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('logins')
+	|bloomFilter('user')
+		.rotate(1h)
+	|httpPost('%s')
+`
+	// A user is remembered during the next period, b was seen during the period before the one of its last point,
+	// a was last seen two periods before and skipping a whole period forgets all users.
+	testBloomFilter(t, "TestStream_BloomFilter_Rotate", script, 7*time.Hour, []bool{false, true, true, false, true, false, false})
+}
+
+func TestStream_BloomFilter_Groups(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('logins')
+		.groupBy('region')
+	|bloomFilter('user')
+	|httpPost('%s')
+`
+	testBloomFilter(t, "TestStream_BloomFilter_Groups", script, 5*time.Second, []bool{false, false, true})
+}
+
+func TestStream_BloomFilter_Global(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('logins')
+		.groupBy('region')
+	|bloomFilter('user')
+		.global()
+	|httpPost('%s')
+`
+	testBloomFilter(t, "TestStream_BloomFilter_Global", script, 5*time.Second, []bool{false, true, true})
+}
+
+// testBloomFilter runs the script, which posts to the URL in place of its %s verb,
+// and compares the seen field of the posted points.
+func testBloomFilter(t *testing.T, name, script string, duration time.Duration, exp []bool) {
+	var mu sync.Mutex
+	var got []bool
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result := models.Result{}
+		if err := json.NewDecoder(r.Body).Decode(&result); err != nil {
+			t.Fatal(err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for _, row := range result.Series {
+			for _, v := range row.Values {
+				for i, c := range row.Columns {
+					if c == "seen" {
+						got = append(got, v[i].(bool))
+					}
+				}
+			}
+		}
+	}))
+	defer ts.Close()
+
+	clock, et, replayErr, tm := testStreamer(t, name, fmt.Sprintf(script, ts.URL), nil)
+	defer tm.Close()
+	if err := fastForwardTask(clock, et, replayErr, tm, duration); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected seen: got %v exp %v", got, exp)
+	}
+}
+
+func TestStream_ConcatFields(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('requests')
+	|concatFields()
+		.concat('mixed', '/', "region", "host", 'requests', "path")
+		.concat('types', ',', "status", "value", "ok")
+		.concat('missing', '-', "region", "zone", "status")
+		.concat('label', '', 'status=', "status")
+		.concat('single', '/', "region")
+		.concat('path', '', '/v1', "path")
+	|httpOut('TestStream_ConcatFields')
+`
+	// A field takes precedence over a tag with the same name, missing parts are empty
+	// and a concatenation may replace a field it refers to.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "requests",
+				Tags:    map[string]string{"host": "tag-host", "region": "us-west"},
+				Columns: []string{"time", "host", "label", "missing", "mixed", "ok", "path", "single", "status", "types", "value"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						"field-host",
+						"status=200",
+						"us-west--200",
+						"us-west/field-host/requests//api",
+						true,
+						"/v1/api",
+						"us-west",
+						200.0,
+						"200,1.5,true",
+						1.5,
+					},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_ConcatFields", script, 5*time.Second, er, false, nil)
+}
+
+func TestStream_ConcatFields_Precision(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('requests')
+	|concatFields()
+		.concat('key', ' ', "value", "status")
+		.concat('zone', '-', "zone", "region")
+		.missing('unknown')
+		.precision(3)
+	|httpOut('TestStream_ConcatFields_Precision')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "requests",
+				Tags:    map[string]string{"host": "tag-host", "region": "us-west"},
+				Columns: []string{"time", "host", "key", "ok", "path", "status", "value", "zone"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						"field-host",
+						"1.500 200",
+						true,
+						"/api",
+						200.0,
+						1.5,
+						"unknown-us-west",
+					},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_ConcatFields_Precision", script, 5*time.Second, er, false, nil)
+}
+
+func TestStream_LagCompensate(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('requests')
+	|lagCompensate()
+		.alpha(0.5)
+	|httpPost('%s')
+`
+	// The first lag is the initial estimate, each further lag moves the estimate half way towards it.
+	testLagCompensate(t, "TestStream_LagCompensate", script,
+		[]time.Duration{10 * time.Second, 30 * time.Second, 30 * time.Second},
+		[]time.Duration{10 * time.Second, 20 * time.Second, 25 * time.Second},
+	)
+}
+
+func TestStream_LagCompensate_Max(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('requests')
+	|lagCompensate()
+		.alpha(1.0)
+		.max(1m)
+	|httpPost('%s')
+`
+	testLagCompensate(t, "TestStream_LagCompensate_Max", script,
+		[]time.Duration{20 * time.Second, time.Hour},
+		[]time.Duration{20 * time.Second, time.Minute},
+	)
+}
+
+func TestStream_LagCompensate_NoShift(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('requests')
+	|lagCompensate()
+		.noShift()
+	|httpPost('%s')
+`
+	testLagCompensate(t, "TestStream_LagCompensate_NoShift", script,
+		[]time.Duration{5 * time.Second},
+		[]time.Duration{0},
+	)
+}
+
+// testLagCompensate sends points that are the given lags old when the task starts
+// to the script, which posts to the URL in place of its %s verb,
+// and compares the shifts of the posted points allowing for the time it takes to process the points.
+func testLagCompensate(t *testing.T, name, script string, lags, exp []time.Duration) {
+	var mu sync.Mutex
+	var got []time.Time
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result := models.Result{}
+		if err := json.NewDecoder(r.Body).Decode(&result); err != nil {
+			t.Fatal(err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for _, row := range result.Series {
+			for _, v := range row.Values {
+				got = append(got, v[0].(time.Time))
+			}
+		}
+	}))
+	defer ts.Close()
+
+	// The lag is measured against the wall clock, so the points are sent in real time.
+	// The replay moves the points relative to the zero time of the clock, which is the time of the first point.
+	now := time.Now().UTC()
+	clock := clock.New(now.Add(-lags[0]))
+	clock.Set(now)
+
+	dataChannel := make(chan edge.PointMessage)
+	cleanupTest := testStreamerWithInputChannel(t, name, fmt.Sprintf(script, ts.URL), dataChannel, clock, nil)
+	defer func() {
+		cleanupTest()
+		mu.Lock()
+		defer mu.Unlock()
+		if len(got) != len(exp) {
+			t.Fatalf("unexpected number of points: got %d exp %d", len(got), len(exp))
+		}
+		const slack = 500 * time.Millisecond
+		for i := range exp {
+			shift := got[i].Sub(now.Add(-lags[i]))
+			if shift < exp[i] || shift >= exp[i]+slack {
+				t.Errorf("unexpected shift of point %d: got %v exp %v", i, shift, exp[i])
+			}
+		}
+	}()
+
+	for _, lag := range lags {
+		dataChannel <- edge.NewPointMessage(
+			"requests",
+			"dbname",
+			"rpname",
+			models.Dimensions{},
+			models.Fields{"value": 1.0},
+			models.Tags{},
+			now.Add(-lag),
+		)
+	}
+	time.Sleep(100 * time.Millisecond)
+	close(dataChannel)
+}
+
+func TestStream_Clamp(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('sensors')
+	|clamp()
+		.field('temperature', -40.0, 85.0)
+		.field('count', 0.5, 10.5)
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_Clamp')
+`
+	// Integers are clamped to the nearest integers within the range, non-numeric values are left unchanged.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "sensors",
+				Tags:    nil,
+				Columns: []string{"time", "count", "temperature"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						3.0,
+						20.5,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+						nil,
+						85.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+						1.0,
+						-40.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC),
+						10.0,
+						85.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC),
+						true,
+						"hot",
+					},
+				},
+			},
+		},
+	}
+
+	testClamp(t, "TestStream_Clamp", "clamp2", script, er, 4, 0)
+}
+
+func TestStream_Clamp_NaN(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('sensors')
+	|eval(lambda: "num" / "den")
+		.as('temperature')
+		.keep('temperature', 'other')
+	|clamp()
+		.field('temperature', -40.0, 85.0)
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_Clamp_NaN')
+`
+	// Infinite values are clamped to the bound, NaN values have no nearest bound and are removed.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "sensors",
+				Tags:    nil,
+				Columns: []string{"time", "other", "temperature"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						1.0,
+						-40.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+						2.0,
+						nil,
+					},
+				},
+			},
+		},
+	}
+
+	testClamp(t, "TestStream_Clamp_NaN", "clamp3", script, er, 2, 0)
+}
+
+func TestStream_Clamp_Drop(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('sensors')
+	|clamp()
+		.field('temperature', -40.0, 85.0)
+		.action('drop')
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_Clamp_Drop')
+`
+	// Points without the field are kept.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "sensors",
+				Tags:    nil,
+				Columns: []string{"time", "id", "temperature"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						1.0,
+						20.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC),
+						4.0,
+						nil,
+					},
+				},
+			},
+		},
+	}
+
+	testClamp(t, "TestStream_Clamp_Drop", "clamp2", script, er, 2, 2)
+}
+
+func testClamp(t *testing.T, name, node, script string, er models.Result, clamped, dropped int64) {
+	clock, et, replayErr, tm := testStreamer(t, name, script, nil)
+	defer tm.Close()
+	if err := fastForwardTask(clock, et, replayErr, tm, 15*time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := et.GetOutput(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Get(output.Endpoint())
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := models.Result{}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if eq, msg := compareResults(er, result); !eq {
+		t.Error(msg)
+	}
+
+	stats, err := et.ExecutionStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := stats.NodeStats[node]["clamped"], clamped; got != exp {
+		t.Errorf("unexpected clamped: got %v exp %v", got, exp)
+	}
+	if got, exp := stats.NodeStats[node]["dropped"], dropped; got != exp {
+		t.Errorf("unexpected dropped: got %v exp %v", got, exp)
+	}
+}
+
+func TestStream_TimeInState(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('service')
+		.groupBy('service')
+	|window()
+		.period(1m)
+		.every(1m)
+	|timeInState('status')
+	|httpOut('TestStream_TimeInState')
+`
+	// The state is read from the tag since the points have no such field,
+	// the last state lasts until the end of the window.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "service",
+				Tags:    map[string]string{"service": "api"},
+				Columns: []string{"time", "duration", "status"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 1, 0, 0, time.UTC),
+						15.0,
+						"down",
+					},
+					{
+						time.Date(1971, 1, 1, 0, 1, 0, 0, time.UTC),
+						45.0,
+						"up",
+					},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_TimeInState", script, 70*time.Second, er, false, nil)
+}
+
+func TestStream_Mask(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('requests')
+	|mask()
+		.field('message')
+		.field('count')
+		.tag('user')
+		.pattern(/[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}/)
+		.pattern(/\b(?:\d[ -]?){12,15}\d\b/)
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_Mask')
+`
+	// Fields that are not strings, fields and tags that are not masked and values without matches are unchanged.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "requests",
+				Tags:    nil,
+				Columns: []string{"time", "contact", "count", "email", "host", "message", "user", "value"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						"jane.doe@example.com",
+						4111111111111111.0,
+						"jane.doe@example.com",
+						"a",
+						"paid by *** with ***.",
+						"***",
+						1.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+						nil,
+						nil,
+						nil,
+						nil,
+						"card ***",
+						nil,
+						nil,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+						nil,
+						nil,
+						nil,
+						nil,
+						"order 1234 shipped to user@localhost",
+						"jane",
+						nil,
+					},
+				},
+			},
+		},
+	}
+
+	testMask(t, "TestStream_Mask", script, er, 4)
+}
+
+func TestStream_Mask_Hash(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('requests')
+	|mask()
+		.field('message')
+		.pattern(/[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}/)
+		.hash()
+	|httpOut('TestStream_Mask_Hash')
+`
+	sum := sha256.Sum256([]byte("jane.doe@example.com"))
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "requests",
+				Tags:    nil,
+				Columns: []string{"time", "message"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						"from " + hex.EncodeToString(sum[:]),
+					},
+				},
+			},
+		},
+	}
+
+	testMask(t, "TestStream_Mask_Hash", script, er, 1)
+}
+
+func TestStream_Mask_OverlappingPatterns(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('requests')
+	|mask()
+		.field('message')
+		.pattern(/secret/)
+		.pattern(/cret-\d+/)
+		.pattern(/x*/)
+	|httpOut('TestStream_Mask_OverlappingPatterns')
+`
+	// Overlapping matches are masked once and empty matches are ignored.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "requests",
+				Tags:    nil,
+				Columns: []string{"time", "message"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						"a *** and a ***",
+					},
+				},
+			},
+		},
+	}
+
+	testMask(t, "TestStream_Mask_OverlappingPatterns", script, er, 2)
+}
+
+func testMask(t *testing.T, name, script string, er models.Result, redactions int64) {
+	clock, et, replayErr, tm := testStreamer(t, name, script, nil)
+	defer tm.Close()
+	if err := fastForwardTask(clock, et, replayErr, tm, 15*time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := et.GetOutput(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Get(output.Endpoint())
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := models.Result{}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if eq, msg := compareResults(er, result); !eq {
+		t.Error(msg)
+	}
+
+	stats, err := et.ExecutionStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := stats.NodeStats["mask2"]["redactions"], redactions; got != exp {
+		t.Errorf("unexpected redactions: got %v exp %v", got, exp)
+	}
+}
+
+func TestStream_Coalesce(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('sensors')
+	|coalesce('value', 'a', 'b', 'c')
+	|httpPost('%s')
+`
+	// The point without any of the fields is passed on unchanged and an existing field is replaced.
+	testCoalesce(t, "TestStream_Coalesce", "coalesce2", script, []interface{}{1.0, 2.0, "three", nil, 2.0}, 1)
+}
+
+func TestStream_Coalesce_Default(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('sensors')
+	|coalesce('value', 'a', 'b', 'c')
+		.default(0.0)
+	|httpPost('%s')
+`
+	testCoalesce(t, "TestStream_Coalesce_Default", "coalesce2", script, []interface{}{0.0}, 1)
+}
+
+func TestStream_Coalesce_DropMissing(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('sensors')
+	|coalesce('value', 'a', 'b', 'c')
+		.dropMissing()
+	|httpPost('%s')
+`
+	testCoalesce(t, "TestStream_Coalesce_DropMissing", "coalesce2", script, []interface{}{1.0}, 1)
+}
+
+func TestStream_Coalesce_NaN(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('sensors')
+	|eval(lambda: "an" / "ad")
+		.as('a')
+		.keep('a', 'c')
+	|coalesce('value', 'a', 'c')
+	|dropFields('a')
+	|httpPost('%s')
+`
+	// NaN values are skipped.
+	testCoalesce(t, "TestStream_Coalesce_NaN", "coalesce3", script, []interface{}{3.0}, 0)
+}
+
+func TestStream_Coalesce_Float(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('sensors')
+	|coalesce('value', 'a', 'b', 'c')
+		.type('float')
+		.default(-1)
+	|httpPost('%s')
+`
+	// Values that cannot be converted are skipped and the default value is converted as well.
+	testCoalesce(t, "TestStream_Coalesce_Float", "coalesce2", script, []interface{}{4.0, 6.0, -1.0}, 1)
+}
+
+func TestStream_Coalesce_Int(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('sensors')
+	|coalesce('value', 'a', 'b', 'c')
+		.type('int')
+	|httpPost('%s')
+`
+	// Floats with a fractional part cannot be converted to integers.
+	testCoalesce(t, "TestStream_Coalesce_Int", "coalesce2", script, []interface{}{4.0, 5.0}, 0)
+}
+
+func TestStream_Coalesce_String(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('sensors')
+	|coalesce('value', 'a', 'b', 'c')
+		.type('string')
+	|httpPost('%s')
+`
+	testCoalesce(t, "TestStream_Coalesce_String", "coalesce2", script, []interface{}{"2.5", "true"}, 0)
+}
+
+// testCoalesce runs the script, which posts to the URL in place of its %s verb,
+// and compares the value field of the posted points, nil if a point has no value field,
+// and the missing statistic of the coalesce node.
+func testCoalesce(t *testing.T, name, node, script string, exp []interface{}, missing int64) {
+	var mu sync.Mutex
+	got := []interface{}{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result := models.Result{}
+		if err := json.NewDecoder(r.Body).Decode(&result); err != nil {
+			t.Fatal(err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for _, row := range result.Series {
+			for _, v := range row.Values {
+				var value interface{}
+				for i, c := range row.Columns {
+					if c == "value" {
+						value = v[i]
+					}
+				}
+				got = append(got, value)
+			}
+		}
+	}))
+	defer ts.Close()
+
+	clock, et, replayErr, tm := testStreamer(t, name, fmt.Sprintf(script, ts.URL), nil)
+	defer tm.Close()
+	if err := fastForwardTask(clock, et, replayErr, tm, 10*time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected values: got %v exp %v", got, exp)
+	}
+	stats, err := et.ExecutionStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := stats.NodeStats[node]["missing"], missing; got != exp {
+		t.Errorf("unexpected missing: got %v exp %v", got, exp)
+	}
+}
+
+func TestStream_ReverseDNS(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('flows')
+	|reverseDNS('src_ip')
+		.as('src_host')
+	|httpPost('%s')
+`
+	r := newReverseDNSResolver()
+	send := func(emit func(models.Tags, models.Fields)) {
+		// The addresses are not cached yet, the points keep the addresses.
+		emit(models.Tags{"src_ip": "10.0.0.1"}, nil)
+		emit(models.Tags{"src_ip": "192.0.2.1"}, nil)
+		time.Sleep(50 * time.Millisecond)
+		emit(models.Tags{"src_ip": "10.0.0.1"}, nil)
+		// Failed lookups are cached.
+		emit(models.Tags{"src_ip": "192.0.2.1"}, nil)
+		// Values that are not addresses are not looked up.
+		emit(models.Tags{"src_ip": "not an address"}, nil)
+		// Points without the address are not modified.
+		emit(models.Tags{"host": "serverA"}, nil)
+	}
+	exp := []string{
+		"10.0.0.1",
+		"192.0.2.1",
+		"host-a.example.com",
+		"192.0.2.1",
+		"not an address",
+		"",
+	}
+	stats := map[string]int64{
+		"cache_hits":    2,
+		"cache_misses":  2,
+		"lookup_errors": 1,
+	}
+	testReverseDNS(t, "TestStream_ReverseDNS", script, "src_host", r, send, exp, stats)
+	if got, exp := r.callCount("10.0.0.1"), 1; got != exp {
+		t.Errorf("unexpected number of lookups: got %d exp %d", got, exp)
+	}
+	if got, exp := r.callCount("192.0.2.1"), 1; got != exp {
+		t.Errorf("unexpected number of failed lookups: got %d exp %d", got, exp)
+	}
+}
+
+func TestStream_ReverseDNS_FromField(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('flows')
+	|reverseDNS('src_ip')
+		.fromField()
+		.placeholder('unknown')
+	|httpPost('%s')
+`
+	r := newReverseDNSResolver()
+	send := func(emit func(models.Tags, models.Fields)) {
+		emit(nil, models.Fields{"src_ip": "10.0.0.2"})
+		emit(nil, models.Fields{"src_ip": "192.0.2.1"})
+		time.Sleep(50 * time.Millisecond)
+		emit(nil, models.Fields{"src_ip": "10.0.0.2"})
+		emit(nil, models.Fields{"src_ip": "192.0.2.1"})
+		// Fields that are not strings are not addresses.
+		emit(nil, models.Fields{"src_ip": 1.0})
+	}
+	exp := []string{
+		"unknown",
+		"unknown",
+		"host-b.example.com",
+		"unknown",
+		"",
+	}
+	testReverseDNS(t, "TestStream_ReverseDNS_FromField", script, "hostname", r, send, exp, nil)
+}
+
+func TestStream_ReverseDNS_CacheTTL(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('flows')
+	|reverseDNS('src_ip')
+		.cacheTTL(200ms)
+	|httpPost('%s')
+`
+	r := newReverseDNSResolver()
+	send := func(emit func(models.Tags, models.Fields)) {
+		emit(models.Tags{"src_ip": "10.0.0.1"}, nil)
+		time.Sleep(50 * time.Millisecond)
+		emit(models.Tags{"src_ip": "10.0.0.1"}, nil)
+		// The cached hostname expires after the TTL and the address is looked up again.
+		time.Sleep(250 * time.Millisecond)
+		emit(models.Tags{"src_ip": "10.0.0.1"}, nil)
+		time.Sleep(50 * time.Millisecond)
+		emit(models.Tags{"src_ip": "10.0.0.1"}, nil)
+	}
+	exp := []string{
+		"10.0.0.1",
+		"host-a.example.com",
+		"10.0.0.1",
+		"host-a.example.com",
+	}
+	stats := map[string]int64{
+		"cache_hits":   2,
+		"cache_misses": 2,
+	}
+	testReverseDNS(t, "TestStream_ReverseDNS_CacheTTL", script, "hostname", r, send, exp, stats)
+	if got, exp := r.callCount("10.0.0.1"), 2; got != exp {
+		t.Errorf("unexpected number of lookups: got %d exp %d", got, exp)
+	}
+}
+
+func TestStream_ReverseDNS_CacheSize(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('flows')
+	|reverseDNS('src_ip')
+		.cacheSize(1)
+	|httpPost('%s')
+`
+	r := newReverseDNSResolver()
+	send := func(emit func(models.Tags, models.Fields)) {
+		emit(models.Tags{"src_ip": "10.0.0.1"}, nil)
+		time.Sleep(50 * time.Millisecond)
+		emit(models.Tags{"src_ip": "10.0.0.2"}, nil)
+		time.Sleep(50 * time.Millisecond)
+		// The first address was evicted from the full cache.
+		emit(models.Tags{"src_ip": "10.0.0.1"}, nil)
+	}
+	exp := []string{
+		"10.0.0.1",
+		"10.0.0.2",
+		"10.0.0.1",
+	}
+	stats := map[string]int64{
+		"cache_hits":   0,
+		"cache_misses": 3,
+	}
+	testReverseDNS(t, "TestStream_ReverseDNS_CacheSize", script, "hostname", r, send, exp, stats)
+}
+
+func TestStream_ReverseDNS_Timeout(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('flows')
+	|reverseDNS('src_ip')
+		.timeout(10ms)
+	|httpPost('%s')
+`
+	r := newReverseDNSResolver()
+	r.block = make(chan struct{})
+	defer close(r.block)
+	send := func(emit func(models.Tags, models.Fields)) {
+		emit(models.Tags{"src_ip": "10.0.0.1"}, nil)
+		time.Sleep(50 * time.Millisecond)
+		// Lookups that timed out are retried.
+		emit(models.Tags{"src_ip": "10.0.0.1"}, nil)
+		time.Sleep(50 * time.Millisecond)
+	}
+	exp := []string{
+		"10.0.0.1",
+		"10.0.0.1",
+	}
+	stats := map[string]int64{
+		"cache_misses": 2,
+		"timeouts":     2,
+	}
+	testReverseDNS(t, "TestStream_ReverseDNS_Timeout", script, "hostname", r, send, exp, stats)
+	if got, exp := r.callCount("10.0.0.1"), 2; got != exp {
+		t.Errorf("unexpected number of lookups: got %d exp %d", got, exp)
+	}
+}
+
+func TestStream_ReverseDNS_Concurrency(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('flows')
+	|reverseDNS('src_ip')
+		.concurrency(1)
+	|httpPost('%s')
+`
+	r := newReverseDNSResolver()
+	r.block = make(chan struct{})
+	send := func(emit func(models.Tags, models.Fields)) {
+		// The first lookup occupies the only resolver.
+		emit(models.Tags{"src_ip": "10.0.0.1"}, nil)
+		// A pending address is not looked up again, and other addresses wait for a free resolver.
+		emit(models.Tags{"src_ip": "10.0.0.1"}, nil)
+		emit(models.Tags{"src_ip": "10.0.0.2"}, nil)
+		time.Sleep(50 * time.Millisecond)
+		close(r.block)
+		time.Sleep(50 * time.Millisecond)
+		// Once the resolver is free the second address is looked up.
+		emit(models.Tags{"src_ip": "10.0.0.2"}, nil)
+		time.Sleep(50 * time.Millisecond)
+		emit(models.Tags{"src_ip": "10.0.0.1"}, nil)
+		emit(models.Tags{"src_ip": "10.0.0.2"}, nil)
+	}
+	exp := []string{
+		"10.0.0.1",
+		"10.0.0.1",
+		"10.0.0.2",
+		"10.0.0.2",
+		"host-a.example.com",
+		"host-b.example.com",
+	}
+	testReverseDNS(t, "TestStream_ReverseDNS_Concurrency", script, "hostname", r, send, exp, nil)
+	if got, exp := r.callCount("10.0.0.1"), 1; got != exp {
+		t.Errorf("unexpected number of lookups of first address: got %d exp %d", got, exp)
+	}
+	if got, exp := r.callCount("10.0.0.2"), 1; got != exp {
+		t.Errorf("unexpected number of lookups of second address: got %d exp %d", got, exp)
+	}
+}
+
+// reverseDNSResolver resolves a fixed set of addresses and counts the lookups.
+type reverseDNSResolver struct {
+	mu    sync.Mutex
+	calls map[string]int
+	// block, if set, blocks lookups until it is closed or the context is done.
+	block chan struct{}
+}
+
+func newReverseDNSResolver() *reverseDNSResolver {
+	return &reverseDNSResolver{
+		calls: make(map[string]int),
+	}
+}
+
+func (r *reverseDNSResolver) LookupAddr(ctx context.Context, addr string) ([]string, error) {
+	r.mu.Lock()
+	r.calls[addr]++
+	block := r.block
+	r.mu.Unlock()
+	if block != nil {
+		select {
+		case <-block:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	switch addr {
+	case "10.0.0.1":
+		return []string{"host-a.example.com.", "alias.example.com."}, nil
+	case "10.0.0.2":
+		return []string{"host-b.example.com."}, nil
+	default:
+		return nil, &net.DNSError{Err: "no such host", Name: addr, IsNotFound: true}
+	}
+}
+
+func (r *reverseDNSResolver) callCount(addr string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.calls[addr]
+}
+
+// testReverseDNS runs the script in real time, since the addresses are resolved in the background,
+// and compares the hostname tags of the posted points, an empty hostname means the point has no hostname tag.
+func testReverseDNS(t *testing.T, name, script, as string, r *reverseDNSResolver, send func(emit func(models.Tags, models.Fields)), exp []string, stats map[string]int64) {
+	var mu sync.Mutex
+	var got []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		result := models.Result{}
+		if err := json.NewDecoder(req.Body).Decode(&result); err != nil {
+			t.Fatal(err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for _, row := range result.Series {
+			got = append(got, row.Tags[as])
+		}
+	}))
+	defer ts.Close()
+
+	var tm *kapacitor.TaskMaster
+	tmInit := func(m *kapacitor.TaskMaster) {
+		m.Resolver = r
+		tm = m
+	}
+	// The clock is ahead of the points so that they are replayed as soon as they are sent.
+	start := time.Now().UTC()
+	clock := clock.New(start)
+	clock.Set(start.Add(time.Hour))
+	dataChannel := make(chan edge.PointMessage)
+	cleanupTest := testStreamerWithInputChannel(t, name, fmt.Sprintf(script, ts.URL), dataChannel, clock, tmInit)
+
+	var i int
+	send(func(tags models.Tags, fields models.Fields) {
+		if fields == nil {
+			fields = models.Fields{"bytes": 1.0}
+		}
+		dataChannel <- edge.NewPointMessage(
+			"flows",
+			"dbname",
+			"rpname",
+			models.Dimensions{},
+			fields,
+			tags,
+			start.Add(time.Duration(i)*time.Millisecond),
+		)
+		i++
+	})
+	time.Sleep(100 * time.Millisecond)
+
+	es, err := tm.ExecutionStats(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for stat, exp := range stats {
+		if got := es.NodeStats["reverse_dns2"][stat]; got != exp {
+			t.Errorf("unexpected %s: got %v exp %v", stat, got, exp)
+		}
+	}
+	close(dataChannel)
+	cleanupTest()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected hostnames:\ngot %q\nexp %q", got, exp)
+	}
+}
+
+func TestStream_PanicLimit(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('requests')
+	|eval(lambda: "total" / "count")
+		.as('avg')
+		.keep()
+		.panicLimit(3, 1m)
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_PanicLimit')
+`
+	// The points with a zero count panic with an integer division by zero, the panics are recovered and the points are dropped.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "requests",
+				Tags:    nil,
+				Columns: []string{"time", "avg", "count", "total"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						5.0,
+						2.0,
+						10.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+						3.0,
+						3.0,
+						9.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
+						2.0,
+						4.0,
+						8.0,
+					},
+				},
+			},
+		},
+	}
+
+	clock, et, replayErr, tm := testStreamer(t, "TestStream_PanicLimit", script, nil)
+	defer tm.Close()
+
+	if err := fastForwardTask(clock, et, replayErr, tm, 15*time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := et.GetOutput("TestStream_PanicLimit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Get(output.Endpoint())
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := models.Result{}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if eq, msg := compareResults(er, result); !eq {
+		t.Error(msg)
+	}
+
+	stats, err := et.ExecutionStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := stats.NodeStats["eval2"]["panics_recovered"], int64(3); got != exp {
+		t.Errorf("unexpected panics_recovered: got %v exp %v", got, exp)
+	}
+}
+
+func TestStream_PanicLimit_Exceeded(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('requests')
+	|eval(lambda: "total" / "count")
+		.as('avg')
+		.panicLimit(2, 1m)
+	|httpOut('TestStream_PanicLimit_Exceeded')
+`
+	clock, et, replayErr, tm := testStreamer(t, "TestStream_PanicLimit_Exceeded", script, nil)
+	defer tm.Close()
+
+	// The third panic within a minute fails the task.
+	err := fastForwardTask(clock, et, replayErr, tm, 15*time.Second)
+	if exp := "too many panics, 3 panics within 1m0s, last panic: runtime error: integer divide by zero"; err == nil || !strings.Contains(err.Error(), exp) {
+		t.Errorf("unexpected error: got %v exp %q", err, exp)
+	}
+}
+
+func TestStream_DurationFormat(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('jobs')
+	|humanize('elapsed', 'elapsed_human')
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_DurationFormat')
+`
+	// Values that are not numbers are not formatted.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "jobs",
+				Tags:    nil,
+				Columns: []string{"time", "elapsed", "elapsed_human", "other"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						8103.6e9,
+						"2h15m4s",
+						1.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+						400e6,
+						"0s",
+						1.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+						8100e9,
+						"2h15m",
+						1.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC),
+						180001e9,
+						"2d2h1s",
+						1.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC),
+						0.0,
+						"0s",
+						1.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
+						-90e9,
+						"-1m30s",
+						1.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 6, 0, time.UTC),
+						"1h",
+						nil,
+						1.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 7, 0, time.UTC),
+						nil,
+						nil,
+						1.0,
+					},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_DurationFormat", script, 15*time.Second, er, false, nil)
+}
+
+func TestStream_DurationFormat_Precision(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('jobs')
+	|humanize('elapsed', 'elapsed_human')
+		.precision('ns')
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_DurationFormat_Precision')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "jobs",
+				Tags:    nil,
+				Columns: []string{"time", "elapsed", "elapsed_human"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						2030004.0,
+						"2ms30us4ns",
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+						1500e6,
+						"1s500ms",
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+						0.0,
+						"0ns",
+					},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_DurationFormat_Precision", script, 15*time.Second, er, false, nil)
+}
+
+func TestStream_DurationFormat_Unit(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('jobs')
+	|humanize('elapsed', 'elapsed_human')
+		.unit('s')
+		.precision('ms')
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_DurationFormat_Unit')
+`
+	// Durations out of range are not formatted.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "jobs",
+				Tags:    nil,
+				Columns: []string{"time", "elapsed", "elapsed_human"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						1.25,
+						"1s250ms",
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+						8103.0,
+						"2h15m3s",
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+						1e12,
+						nil,
+					},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_DurationFormat_Unit", script, 15*time.Second, er, false, nil)
+}
+
+func TestStream_TagValueMap(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('services')
+	|tagValueMap('status')
+		.map('0', 'ok')
+		.map('1', 'warn')
+		.map('2', 'crit')
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_TagValueMap')
+`
+	// Values that are not in the table and points without the tag are passed on unchanged.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "services",
+				Tags:    nil,
+				Columns: []string{"time", "host", "status", "value"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						"serverA",
+						"warn",
+						1.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+						"serverA",
+						"crit",
+						2.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+						"serverA",
+						"7",
+						3.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC),
+						"serverA",
+						nil,
+						4.0,
+					},
+				},
+			},
+		},
+	}
+
+	testTagValueMap(t, "TestStream_TagValueMap", script, er, 1)
+}
+
+func TestStream_TagValueMap_As(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('services')
+	|tagValueMap('status')
+		.map('0', 'ok')
+		.map('1', 'warn')
+		.map('2', 'crit')
+		.default('unknown')
+		.as('label')
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_TagValueMap_As')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "services",
+				Tags:    nil,
+				Columns: []string{"time", "host", "label", "status", "value"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						"serverA",
+						"crit",
+						"2",
+						1.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+						"serverA",
+						"unknown",
+						"7",
+						2.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+						"serverA",
+						nil,
+						nil,
+						3.0,
+					},
+				},
+			},
+		},
+	}
+
+	testTagValueMap(t, "TestStream_TagValueMap_As", script, er, 1)
+}
+
+func TestStream_TagValueMap_FromField(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('services')
+	|tagValueMap('status')
+		.fromField()
+		.map('0', 'ok')
+		.map('1', 'warn')
+		.map('2', 'crit')
+		.default('unknown')
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_TagValueMap_FromField')
+`
+	// Field values are compared by their string representation, so both the integer 0 and the float 2 are mapped.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "services",
+				Tags:    nil,
+				Columns: []string{"time", "status", "value"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						"ok",
+						1.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+						"crit",
+						2.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+						"unknown",
+						3.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC),
+						nil,
+						4.0,
+					},
+				},
+			},
+		},
+	}
+
+	testTagValueMap(t, "TestStream_TagValueMap_FromField", script, er, 1)
+}
+
+func testTagValueMap(t *testing.T, name, script string, er models.Result, unmapped int64) {
+	clock, et, replayErr, tm := testStreamer(t, name, script, nil)
+	defer tm.Close()
+	if err := fastForwardTask(clock, et, replayErr, tm, 15*time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := et.GetOutput(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Get(output.Endpoint())
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := models.Result{}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if eq, msg := compareResults(er, result); !eq {
+		t.Error(msg)
+	}
+
+	stats, err := et.ExecutionStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := stats.NodeStats["tag_value_map2"]["unmapped"], unmapped; got != exp {
+		t.Errorf("unexpected unmapped: got %v exp %v", got, exp)
+	}
+}
+
+func TestStream_AvroOut(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+	|avroOut('metrics')
+		.registry('%s')
+`
+	messages, r, stats := testAvroOut(t, "TestStream_AvroOut", script)
+	if got, exp := len(messages), 1; got != exp {
+		t.Fatalf("unexpected message count: got %d exp %d", got, exp)
+	}
+	versions := r.Versions("metrics-value")
+	if got, exp := len(versions), 1; got != exp {
+		t.Fatalf("unexpected version count: got %d exp %d", got, exp)
+	}
+	schema, _ := r.Schema(versions[0])
+	if exp := `{"type":"record","name":"cpu","fields":[{"name":"time","type":{"type":"long","logicalType":"timestamp-micros"}},{"name":"host","type":["null","string"],"default":null},{"name":"value","type":["null","double"],"default":null}]}`; schema != exp {
+		t.Errorf("unexpected schema:\ngot %s\nexp %s", schema, exp)
+	}
+
+	// magic byte, schema ID, time in microseconds, host in branch 1, value in branch 1
+	exp := []byte{0, 0, 0, 0, byte(versions[0])}
+	exp = append(exp, 0x80, 0x80, 0x9f, 0xc1, 0xd1, 0xab, 0x0e)
+	exp = append(exp, 0x02, 0x0e)
+	exp = append(exp, "serverA"...)
+	exp = append(exp, 0x02)
+	var value [8]byte
+	binary.LittleEndian.PutUint64(value[:], math.Float64bits(1.5))
+	exp = append(exp, value[:]...)
+	if !bytes.Equal(messages[0], exp) {
+		t.Errorf("unexpected message:\ngot % x\nexp % x", messages[0], exp)
+	}
+	if got, exp := stats["published"], int64(1); got != exp {
+		t.Errorf("unexpected published: got %v exp %v", got, exp)
+	}
+}
+
+func TestStream_AvroOut_SchemaEvolution(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+	|avroOut('metrics')
+		.registry('%s')
+		.subject('cpu')
+`
+	messages, r, _ := testAvroOut(t, "TestStream_AvroOut_SchemaEvolution", script)
+	if got, exp := len(messages), 3; got != exp {
+		t.Fatalf("unexpected message count: got %d exp %d", got, exp)
+	}
+	versions := r.Versions("cpu")
+	if got, exp := len(versions), 2; got != exp {
+		t.Fatalf("unexpected version count: got %d exp %d", got, exp)
+	}
+	for i, v := range []int32{versions[0], versions[0], versions[1]} {
+		if got := int32(binary.BigEndian.Uint32(messages[i][1:5])); got != v {
+			t.Errorf("unexpected schema ID of message %d: got %d exp %d", i, got, v)
+		}
+	}
+	// One registration per schema
+	if got, exp := r.Requests(), 2; got != exp {
+		t.Errorf("unexpected registry requests: got %d exp %d", got, exp)
+	}
+}
+
+func TestStream_AvroOut_Schema(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+	|avroOut('metrics')
+		.registry('%s')
+		.schema('{"type":"record","name":"cpu","fields":[{"name":"time","type":{"type":"long","logicalType":"timestamp-millis"}},{"name":"host","type":"string"},{"name":"value","type":"float"}]}')
+`
+	// The second point is missing the non-nullable value.
+	messages, r, stats := testAvroOut(t, "TestStream_AvroOut_Schema", script)
+	if got, exp := len(messages), 1; got != exp {
+		t.Fatalf("unexpected message count: got %d exp %d", got, exp)
+	}
+	versions := r.Versions("metrics-value")
+	if got, exp := len(versions), 1; got != exp {
+		t.Fatalf("unexpected version count: got %d exp %d", got, exp)
+	}
+
+	// magic byte, schema ID, time in milliseconds, host, value as float
+	exp := []byte{0, 0, 0, 0, byte(versions[0])}
+	exp = append(exp, 0x80, 0xb0, 0x89, 0xfb, 0xea, 0x01)
+	exp = append(exp, 0x02)
+	exp = append(exp, "a"...)
+	var value [4]byte
+	binary.LittleEndian.PutUint32(value[:], math.Float32bits(2))
+	exp = append(exp, value[:]...)
+	if !bytes.Equal(messages[0], exp) {
+		t.Errorf("unexpected message:\ngot % x\nexp % x", messages[0], exp)
+	}
+	if got, exp := stats["serialization_errors"], int64(1); got != exp {
+		t.Errorf("unexpected serialization_errors: got %v exp %v", got, exp)
+	}
+}
+
+func TestStream_AvroOut_RegistryError(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+	|avroOut('metrics')
+		.registry('%s')
+`
+	ks, r, tmInit := newAvroOutServers(t)
+	defer ks.Close()
+	defer r.Close()
+	r.SetError(http.StatusInternalServerError)
+
+	var tm *kapacitor.TaskMaster
+	clock := clock.New(time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC))
+	clock.Set(clock.Zero().Add(time.Hour))
+	dataChannel := make(chan edge.PointMessage)
+	cleanupTest := testStreamerWithInputChannel(t, "TestStream_AvroOut_RegistryError", fmt.Sprintf(script, r.URL), dataChannel, clock, func(m *kapacitor.TaskMaster) {
+		tmInit(m)
+		tm = m
+	})
+	point := edge.NewPointMessage(
+		"cpu",
+		"dbname",
+		"rpname",
+		models.Dimensions{},
+		models.Fields{"value": 1.0},
+		models.Tags{},
+		clock.Zero(),
+	)
+	dataChannel <- point
+	time.Sleep(50 * time.Millisecond)
+	// The failed registration is retried with the next point.
+	r.SetError(0)
+	dataChannel <- point
+	time.Sleep(50 * time.Millisecond)
+
+	es, err := tm.ExecutionStats("TestStream_AvroOut_RegistryError")
+	if err != nil {
+		t.Fatal(err)
+	}
+	close(dataChannel)
+	cleanupTest()
+
+	messages := avroOutMessages(t, ks)
+	if got, exp := len(messages), 1; got != exp {
+		t.Fatalf("unexpected message count: got %d exp %d", got, exp)
+	}
+	if got, exp := es.NodeStats["avro_out2"]["registry_errors"], int64(1); got != exp {
+		t.Errorf("unexpected registry_errors: got %v exp %v", got, exp)
+	}
+	if got, exp := es.NodeStats["avro_out2"]["published"], int64(1); got != exp {
+		t.Errorf("unexpected published: got %v exp %v", got, exp)
+	}
+}
+
+func TestStream_AvroOut_UnknownCluster(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+	|avroOut('metrics')
+		.cluster('missing')
+		.registry('http://localhost:8081')
+`
+	ks, r, tmInit := newAvroOutServers(t)
+	defer ks.Close()
+	defer r.Close()
+
+	tm, err := createTaskMaster()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmInit(tm)
+	tm.Open()
+	defer tm.Close()
+
+	task, err := tm.NewTask("TestStream_AvroOut_UnknownCluster", script, kapacitor.StreamTask, dbrps, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tm.StartTask(task); err == nil {
+		t.Error("expected error for unknown cluster")
+	} else if got, exp := err.Error(), `unknown kafka cluster "missing"`; !strings.Contains(got, exp) {
+		t.Errorf("unexpected error: got %s exp %s", got, exp)
+	}
+}
+
+// newAvroOutServers starts a Kafka server and a schema registry,
+// and returns the function that configures the Kafka cluster default of a task master.
+func newAvroOutServers(t *testing.T) (*kafkatest.Server, *avrotest.Registry, func(tm *kapacitor.TaskMaster)) {
+	ks, err := kafkatest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := avrotest.NewRegistry()
+	tmInit := func(tm *kapacitor.TaskMaster) {
+		c := kafka.NewConfig()
+		c.Enabled = true
+		c.ID = "default"
+		c.Brokers = []string{ks.Addr.String()}
+		c.BatchTimeout = toml.Duration(10 * time.Millisecond)
+		tm.KafkaService = kafka.NewService(kafka.Configs{c}, diagService.NewKafkaHandler())
+	}
+	return ks, r, tmInit
+}
+
+// avroOutMessages closes the Kafka server and returns the messages it received on the topic metrics.
+func avroOutMessages(t *testing.T, ks *kafkatest.Server) [][]byte {
+	ks.Close()
+	messages, err := ks.Messages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := make([][]byte, len(messages))
+	for i, m := range messages {
+		if m.Topic != "metrics" {
+			t.Errorf("unexpected topic: got %s exp metrics", m.Topic)
+		}
+		data[i] = []byte(m.Message)
+	}
+	return data
+}
+
+// testAvroOut replays the data of the test to the script with the URL of a schema registry,
+// and returns the published messages, the closed registry and the statistics of the avroOut node.
+func testAvroOut(t *testing.T, name, script string) ([][]byte, *avrotest.Registry, map[string]interface{}) {
+	ks, r, tmInit := newAvroOutServers(t)
+	defer ks.Close()
+	defer r.Close()
+
+	clock, et, replayErr, tm := testStreamer(t, name, fmt.Sprintf(script, r.URL), tmInit)
+	defer tm.Close()
+	if err := fastForwardTask(clock, et, replayErr, tm, 15*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	stats, err := et.ExecutionStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return avroOutMessages(t, ks), r, stats.NodeStats["avro_out2"]
+}
+
+func TestStream_Deadband(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('valve')
+	|deadband('value')
+		.absolute(0.5)
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_Deadband')
+`
+	// A value exactly on the edge of the deadband is suppressed.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "valve",
+				Tags:    nil,
+				Columns: []string{"time", "value"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						10.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC),
+						10.51,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 6, 0, time.UTC),
+						9.9,
+					},
+				},
+			},
+		},
+	}
+
+	testDeadband(t, "TestStream_Deadband", script, er, false, 4)
+}
+
+func TestStream_Deadband_Integers(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('valve')
+	|deadband('value')
+		.absolute(2.0)
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_Deadband_Integers')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "valve",
+				Tags:    nil,
+				Columns: []string{"time", "value"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						10.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+						13.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC),
+						7.0,
+					},
+				},
+			},
+		},
+	}
+
+	testDeadband(t, "TestStream_Deadband_Integers", script, er, false, 2)
+}
+
+func TestStream_Deadband_Percent(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('flow')
+		.groupBy('sensor')
+	|deadband('rate')
+		.percent(10.0)
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_Deadband_Percent')
+`
+	// The deadband is relative to the last emitted value of each group.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "flow",
+				Tags:    models.Tags{"sensor": "a"},
+				Columns: []string{"time", "rate"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						100.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+						111.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC),
+						99.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
+						-50.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 7, 0, time.UTC),
+						-56.0,
+					},
+				},
+			},
+			{
+				Name:    "flow",
+				Tags:    models.Tags{"sensor": "b"},
+				Columns: []string{"time", "rate"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						0.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+						0.1,
+					},
+				},
+			},
+		},
+	}
+
+	testDeadband(t, "TestStream_Deadband_Percent", script, er, true, 4)
+}
+
+func TestStream_Deadband_NonNumeric(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('valve')
+	|deadband('value')
+		.absolute(1.0)
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_Deadband_NonNumeric')
+`
+	// Points without a numeric value are dropped.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "valve",
+				Tags:    nil,
+				Columns: []string{"time", "value"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+						1.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC),
+						2.5,
+					},
+				},
+			},
+		},
+	}
+
+	testDeadband(t, "TestStream_Deadband_NonNumeric", script, er, false, 0)
+}
+
+func testDeadband(t *testing.T, name, script string, er models.Result, ignoreOrder bool, suppressed int64) {
+	clock, et, replayErr, tm := testStreamer(t, name, script, nil)
+	defer tm.Close()
+	if err := fastForwardTask(clock, et, replayErr, tm, 15*time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := et.GetOutput(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Get(output.Endpoint())
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := models.Result{}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	compare := compareResults
+	if ignoreOrder {
+		compare = compareResultsIgnoreSeriesOrder
+	}
+	if eq, msg := compare(er, result); !eq {
+		t.Error(msg)
+	}
+
+	stats, err := et.ExecutionStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := stats.NodeStats["deadband2"]["suppressed"], suppressed; got != exp {
+		t.Errorf("unexpected suppressed: got %v exp %v", got, exp)
+	}
+}
+
+func TestStream_UpDownCounter(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('connections')
+		.groupBy('server')
+	|upDownCounter('delta')
+		.as('open')
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_UpDownCounter')
+`
+	// A float delta turns the total into a float, points without a numeric delta are dropped.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "connections",
+				Tags:    models.Tags{"server": "a"},
+				Columns: []string{"time", "delta", "open"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						1.0,
+						1.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+						1.0,
+						2.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+						-1.0,
+						1.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC),
+						1.0,
+						2.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC),
+						-1.0,
+						1.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
+						-1.0,
+						0.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 6, 0, time.UTC),
+						-1.0,
+						-1.0,
+					},
+				},
+			},
+			{
+				Name:    "connections",
+				Tags:    models.Tags{"server": "b"},
+				Columns: []string{"time", "delta", "open"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						2.0,
+						2.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+						0.5,
+						2.5,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC),
+						-1.0,
+						1.5,
+					},
+				},
+			},
+		},
+	}
+
+	testUpDownCounter(t, "TestStream_UpDownCounter", script, er, true, 1)
+}
+
+func TestStream_UpDownCounter_Floor(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('connections')
+	|upDownCounter('delta')
+		.floor()
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_UpDownCounter_Floor')
+`
+	// Decrements beyond zero are ignored.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "connections",
+				Tags:    nil,
+				Columns: []string{"time", "delta", "total"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						1.0,
+						1.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+						-1.0,
+						0.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+						-1.0,
+						0.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC),
+						-2.0,
+						0.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC),
+						1.0,
+						1.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC),
+						1.0,
+						2.0,
+					},
+				},
+			},
+		},
+	}
+
+	testUpDownCounter(t, "TestStream_UpDownCounter_Floor", script, er, false, 0)
+}
+
+func TestStream_UpDownCounter_Every(t *testing.T) {
+	var mu sync.Mutex
+	var got []upDownCounterTotal
+	ts := httptest.NewServer(newUpDownCounterCollector(t, &mu, &got))
+	defer ts.Close()
+
+	var script = `
+stream
+	|from()
+		.measurement('connections')
+		.groupBy('server')
+	|upDownCounter('delta')
+		.as('open')
+		.every(1m)
+	|httpPost('%s')
+`
+	clock, et, replayErr, tm := testStreamer(t, "TestStream_UpDownCounter_Every", fmt.Sprintf(script, ts.URL), nil)
+	defer tm.Close()
+	if err := fastForwardTask(clock, et, replayErr, tm, 5*time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	// The points are consumed, a point is emitted at the last multiple of every before the first point past it.
+	exp := []upDownCounterTotal{
+		{Time: time.Date(1971, 1, 1, 0, 1, 0, 0, time.UTC), Total: 2},
+		{Time: time.Date(1971, 1, 1, 0, 2, 0, 0, time.UTC), Total: 2},
+		{Time: time.Date(1971, 1, 1, 0, 4, 0, 0, time.UTC), Total: 3},
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected totals:\ngot %v\nexp %v", got, exp)
+	}
+}
+
+func TestStream_UpDownCounter_ResetOnBarrier(t *testing.T) {
+	var mu sync.Mutex
+	var got []upDownCounterTotal
+	ts := httptest.NewServer(newUpDownCounterCollector(t, &mu, &got))
+	defer ts.Close()
+
+	var script = `
+stream
+	|from()
+		.measurement('connections')
+		.groupBy('server')
+	|barrier()
+		.idle(1s)
+	|upDownCounter('delta')
+		.as('open')
+		.every(1s)
+		.resetOnBarrier()
+	|httpPost('%s')
+`
+	// The barriers are emitted after a second without points, with the time of the last point plus a second.
+	t0 := time.Now().UTC().Truncate(time.Second)
+	clock := clock.New(t0)
+	clock.Set(t0.Add(time.Hour))
+	dataChannel := make(chan edge.PointMessage)
+	cleanupTest := testStreamerWithInputChannel(t, "TestStream_UpDownCounter_ResetOnBarrier", fmt.Sprintf(script, ts.URL), dataChannel, clock, nil)
+	point := func(offset time.Duration, delta int64) edge.PointMessage {
+		return edge.NewPointMessage(
+			"connections",
+			"dbname",
+			"rpname",
+			models.Dimensions{TagNames: []string{"server"}},
+			models.Fields{"delta": delta},
+			models.Tags{"server": "a"},
+			t0.Add(offset),
+		)
+	}
+	dataChannel <- point(0, 1)
+	dataChannel <- point(100*time.Millisecond, 1)
+	// The barrier emits the due total and resets it.
+	time.Sleep(1300 * time.Millisecond)
+	dataChannel <- point(2050*time.Millisecond, 1)
+	time.Sleep(100 * time.Millisecond)
+	close(dataChannel)
+	cleanupTest()
+
+	exp := []upDownCounterTotal{
+		{Time: t0.Add(time.Second), Total: 2},
+		{Time: t0.Add(2 * time.Second), Total: 0},
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected totals:\ngot %v\nexp %v", got, exp)
+	}
+}
+
+// upDownCounterTotal is a point emitted by an upDownCounter node with the every property.
+type upDownCounterTotal struct {
+	Time  time.Time
+	Total float64
+}
+
+// newUpDownCounterCollector returns a handler collecting the totals in the field open of the posted points.
+func newUpDownCounterCollector(t *testing.T, mu *sync.Mutex, got *[]upDownCounterTotal) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result := models.Result{}
+		if err := json.NewDecoder(r.Body).Decode(&result); err != nil {
+			t.Fatal(err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for _, row := range result.Series {
+			if len(row.Columns) != 2 || row.Columns[1] != "open" || row.Tags["server"] != "a" {
+				t.Errorf("unexpected row %v", row)
+			}
+			for _, v := range row.Values {
+				*got = append(*got, upDownCounterTotal{Time: v[0].(time.Time), Total: v[1].(float64)})
+			}
+		}
+	})
+}
+
+func testUpDownCounter(t *testing.T, name, script string, er models.Result, ignoreOrder bool, invalidDeltas int64) {
+	clock, et, replayErr, tm := testStreamer(t, name, script, nil)
+	defer tm.Close()
+	if err := fastForwardTask(clock, et, replayErr, tm, 15*time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := et.GetOutput(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Get(output.Endpoint())
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := models.Result{}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	compare := compareResults
+	if ignoreOrder {
+		compare = compareResultsIgnoreSeriesOrder
+	}
+	if eq, msg := compare(er, result); !eq {
+		t.Error(msg)
+	}
+
+	stats, err := et.ExecutionStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := stats.NodeStats["up_down_counter2"]["invalid_deltas"], invalidDeltas; got != exp {
+		t.Errorf("unexpected invalid_deltas: got %v exp %v", got, exp)
+	}
+}
+
+// validateSchemaOrders is the schema of the orders of the validateSchema tests.
+const validateSchemaOrders = `{"type":"record","name":"orders","fields":[{"name":"time","type":{"type":"long","logicalType":"timestamp-micros"}},{"name":"region","type":"string"},{"name":"amount","type":"double"},{"name":"items","type":["null","int"]}]}`
+
+func TestStream_ValidateSchema(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "TestStream_ValidateSchema")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	dlqPath := filepath.Join(tmpDir, "dead_letters.log")
+
+	var script = fmt.Sprintf(`
+stream
+	|from()
+		.measurement('orders')
+	|validateSchema()
+		.schema('%s')
+		.deadLetter('file', '%s')
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_ValidateSchema')
+`, validateSchemaOrders, dlqPath)
+	// The values of the record fields are the fields or tags of the points, the null items and the extra field conform.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "orders",
+				Tags:    nil,
+				Columns: []string{"time", "amount", "host", "items", "region"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						9.5,
+						"a",
+						2.0,
+						"eu",
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+						9.5,
+						nil,
+						nil,
+						"eu",
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+						9.5,
+						nil,
+						nil,
+						"eu",
+					},
+				},
+			},
+		},
+	}
+
+	testValidateSchema(t, "TestStream_ValidateSchema", script, er, map[string]int64{
+		"valid":           4,
+		"invalid":         2,
+		"missing_fields":  1,
+		"type_mismatches": 1,
+		"unknown_fields":  0,
+	}, nil)
+
+	f, err := os.Open(dlqPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var got []kapacitor.DeadLetter
+	dec := json.NewDecoder(f)
+	for dec.More() {
+		var l kapacitor.DeadLetter
+		if err := dec.Decode(&l); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, l)
+	}
+	exp := []struct {
+		field string
+		time  time.Time
+	}{
+		{field: "region", time: time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC)},
+		{field: "amount", time: time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC)},
+	}
+	if len(got) != len(exp) {
+		t.Fatalf("unexpected number of dead letters: got %d exp %d", len(got), len(exp))
+	}
+	for i, l := range got {
+		if l.Task != "TestStream_ValidateSchema" || l.Node != "validate_schema2" || l.Name != "orders" {
+			t.Errorf("%d: unexpected dead letter context: task %s node %s name %s", i, l.Task, l.Node, l.Name)
+		}
+		if !strings.Contains(l.Error, exp[i].field) {
+			t.Errorf("%d: unexpected error %q", i, l.Error)
+		}
+		if !l.PointTime.Equal(exp[i].time) {
+			t.Errorf("%d: unexpected point time: got %v exp %v", i, l.PointTime, exp[i].time)
+		}
+	}
+}
+
+func TestStream_ValidateSchema_Strict(t *testing.T) {
+	var script = fmt.Sprintf(`
+stream
+	|from()
+		.measurement('orders')
+	|validateSchema()
+		.schema('%s')
+		.strict()
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_ValidateSchema_Strict')
+`, validateSchemaOrders)
+	// Fields that are not in the schema are invalid, tags are not checked.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "orders",
+				Tags:    nil,
+				Columns: []string{"time", "amount", "host", "items", "region"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						9.5,
+						"a",
+						2.0,
+						"eu",
+					},
+				},
+			},
+		},
+	}
+
+	testValidateSchema(t, "TestStream_ValidateSchema_Strict", script, er, map[string]int64{
+		"valid":          2,
+		"invalid":        1,
+		"unknown_fields": 1,
+	}, nil)
+}
+
+func TestStream_ValidateSchema_Registry(t *testing.T) {
+	r := avrotest.NewRegistry()
+	defer r.Close()
+	r.Register("orders-value", `{"type":"record","name":"orders","fields":[{"name":"amount","type":"long"}]}`)
+	r.Register("orders-value", validateSchemaOrders)
+
+	var script = fmt.Sprintf(`
+stream
+	|from()
+		.measurement('orders')
+	|validateSchema()
+		.registry('%s')
+		.subject('orders-value')
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_ValidateSchema_Registry')
+`, r.URL)
+	// The point is only valid with the latest version of the schema.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "orders",
+				Tags:    nil,
+				Columns: []string{"time", "amount", "region"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						9.5,
+						"eu",
+					},
+				},
+			},
+		},
+	}
+
+	testValidateSchema(t, "TestStream_ValidateSchema_Registry", script, er, map[string]int64{
+		"valid":   2,
+		"invalid": 0,
+	}, nil)
+}
+
+func TestStream_ValidateSchema_UnknownSubject(t *testing.T) {
+	r := avrotest.NewRegistry()
+	defer r.Close()
+
+	var script = fmt.Sprintf(`
+stream
+	|from()
+		.measurement('orders')
+	|validateSchema()
+		.registry('%s')
+		.subject('orders-value')
+`, r.URL)
+
+	tm, err := createTaskMaster()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tm.Open()
+	defer tm.Close()
+
+	task, err := tm.NewTask("TestStream_ValidateSchema_UnknownSubject", script, kapacitor.StreamTask, dbrps, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tm.StartTask(task); err == nil {
+		t.Error("expected error for unknown subject")
+	}
+}
+
+func testValidateSchema(t *testing.T, name, script string, er models.Result, stats map[string]int64, tmInit func(tm *kapacitor.TaskMaster)) {
+	clock, et, replayErr, tm := testStreamer(t, name, script, tmInit)
+	defer tm.Close()
+	if err := fastForwardTask(clock, et, replayErr, tm, 15*time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := et.GetOutput(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Get(output.Endpoint())
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := models.Result{}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if eq, msg := compareResults(er, result); !eq {
+		t.Error(msg)
+	}
+
+	es, err := et.ExecutionStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for stat, exp := range stats {
+		if got := es.NodeStats["validate_schema2"][stat]; got != exp {
+			t.Errorf("unexpected %s: got %v exp %v", stat, got, exp)
+		}
+	}
+}
+
+func TestStream_MultiWindow(t *testing.T) {
+	var mu sync.Mutex
+	var got []multiWindowResult
+	ts := httptest.NewServer(newMultiWindowCollector(t, &mu, &got, "window", "mean"))
+	defer ts.Close()
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.groupBy('host')
+	|multiWindow('value', 1m, 5m)
+	|httpPost('%s')
+`
+	clock, et, replayErr, tm := testStreamer(t, "TestStream_MultiWindow", fmt.Sprintf(script, ts.URL), nil)
+	defer tm.Close()
+	if err := fastForwardTask(clock, et, replayErr, tm, 15*time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	// The point at 310 is late for the 1m window that was closed at 360, but within the current 5m window.
+	exp := []multiWindowResult{
+		{Time: time.Date(1971, 1, 1, 0, 1, 0, 0, time.UTC), Window: "1m", Value: 2},
+		{Time: time.Date(1971, 1, 1, 0, 2, 0, 0, time.UTC), Window: "1m", Value: 5},
+		{Time: time.Date(1971, 1, 1, 0, 3, 0, 0, time.UTC), Window: "1m", Value: 7},
+		{Time: time.Date(1971, 1, 1, 0, 5, 0, 0, time.UTC), Window: "1m", Value: 9},
+		{Time: time.Date(1971, 1, 1, 0, 5, 0, 0, time.UTC), Window: "5m", Value: 5},
+		{Time: time.Date(1971, 1, 1, 0, 6, 0, 0, time.UTC), Window: "1m", Value: 11},
+		{Time: time.Date(1971, 1, 1, 0, 7, 0, 0, time.UTC), Window: "1m", Value: 13},
+		{Time: time.Date(1971, 1, 1, 0, 10, 0, 0, time.UTC), Window: "5m", Value: 12},
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected windows:\ngot %v\nexp %v", got, exp)
+	}
+
+	es, err := et.ExecutionStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := es.NodeStats["multi_window2"]["late_points"], int64(1); got != exp {
+		t.Errorf("unexpected late_points: got %v exp %v", got, exp)
+	}
+}
+
+func TestStream_MultiWindow_Aggregates(t *testing.T) {
+	// The string value is ignored.
+	testCases := []struct {
+		aggregate string
+		exp       float64
+	}{
+		{aggregate: "count", exp: 4},
+		{aggregate: "sum", exp: 20},
+		{aggregate: "mean", exp: 5},
+		{aggregate: "min", exp: 2},
+		{aggregate: "max", exp: 9},
+		{aggregate: "first", exp: 4},
+		{aggregate: "last", exp: 5},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.aggregate, func(t *testing.T) {
+			var mu sync.Mutex
+			var got []multiWindowResult
+			ts := httptest.NewServer(newMultiWindowCollector(t, &mu, &got, "size", "agg"))
+			defer ts.Close()
+
+			var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.groupBy('host')
+	|multiWindow('value', 1h)
+		.aggregate('%s')
+		.as('agg')
+		.tag('size')
+	|httpPost('%s')
+`
+			clock, et, replayErr, tm := testStreamer(t, "TestStream_MultiWindow_Aggregates", fmt.Sprintf(script, tc.aggregate, ts.URL), nil)
+			defer tm.Close()
+			if err := fastForwardTask(clock, et, replayErr, tm, 2*time.Hour); err != nil {
+				t.Fatal(err)
+			}
+
+			exp := []multiWindowResult{{Time: time.Date(1971, 1, 1, 1, 0, 0, 0, time.UTC), Window: "1h", Value: tc.exp}}
+			mu.Lock()
+			defer mu.Unlock()
+			if !reflect.DeepEqual(got, exp) {
+				t.Errorf("unexpected windows:\ngot %v\nexp %v", got, exp)
+			}
+		})
+	}
+}
+
+type multiWindowResult struct {
+	Time   time.Time
+	Window string
+	Value  float64
+}
+
+// newMultiWindowCollector returns a handler collecting the windows of the host a posted by a multiWindow node.
+func newMultiWindowCollector(t *testing.T, mu *sync.Mutex, got *[]multiWindowResult, tag, field string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result := models.Result{}
+		if err := json.NewDecoder(r.Body).Decode(&result); err != nil {
+			t.Fatal(err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for _, row := range result.Series {
+			if row.Name != "cpu" || len(row.Tags) != 2 || row.Tags["host"] != "a" || len(row.Columns) != 2 || row.Columns[1] != field {
+				t.Errorf("unexpected row %v", row)
+			}
+			for _, v := range row.Values {
+				*got = append(*got, multiWindowResult{Time: v[0].(time.Time), Window: row.Tags[tag], Value: v[1].(float64)})
+			}
+		}
+	})
+}
+
+func TestStream_Join_PreAgg(t *testing.T) {
+	// Each window is joined once the points of both parents moved past it,
+	// the last window is joined when the task stops.
+	testCases := []struct {
+		name   string
+		preAgg string
+		// number of joined points
+		count int
+		exp   []joinPreAggResult
+	}{
+		{
+			// Without pre-aggregation the points of each second are joined.
+			name:   "raw",
+			preAgg: "",
+			count:  16,
+		},
+		{
+			name:   "first",
+			preAgg: "first",
+			count:  4,
+			exp: []joinPreAggResult{
+				{Time: time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), Errors: 0, Requests: 100, Status: "s0"},
+				{Time: time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC), Errors: 10, Requests: 110, Status: "s0"},
+				{Time: time.Date(1971, 1, 1, 0, 0, 20, 0, time.UTC), Errors: 20, Requests: 120, Status: "s0"},
+				{Time: time.Date(1971, 1, 1, 0, 0, 30, 0, time.UTC), Errors: 30, Requests: 130, Status: "s0"},
+			},
+		},
+		{
+			name:   "last",
+			preAgg: "last",
+			count:  4,
+			exp: []joinPreAggResult{
+				{Time: time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), Errors: 4, Requests: 104, Status: "s4"},
+				{Time: time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC), Errors: 14, Requests: 114, Status: "s4"},
+				{Time: time.Date(1971, 1, 1, 0, 0, 20, 0, time.UTC), Errors: 24, Requests: 124, Status: "s4"},
+				{Time: time.Date(1971, 1, 1, 0, 0, 30, 0, time.UTC), Errors: 30, Requests: 130, Status: "s0"},
+			},
+		},
+		{
+			// The string fields have the value of the last point.
+			name:   "mean",
+			preAgg: "mean",
+			count:  4,
+			exp: []joinPreAggResult{
+				{Time: time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), Errors: 2, Requests: 102, Status: "s4"},
+				{Time: time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC), Errors: 12, Requests: 112, Status: "s4"},
+				{Time: time.Date(1971, 1, 1, 0, 0, 20, 0, time.UTC), Errors: 22, Requests: 122, Status: "s4"},
+				{Time: time.Date(1971, 1, 1, 0, 0, 30, 0, time.UTC), Errors: 30, Requests: 130, Status: "s0"},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var got []joinPreAggResult
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				result := models.Result{}
+				if err := json.NewDecoder(r.Body).Decode(&result); err != nil {
+					t.Fatal(err)
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				for _, row := range result.Series {
+					exp := []string{"time", "errors.status", "errors.value", "requests.status", "requests.value"}
+					if !reflect.DeepEqual(row.Columns, exp) {
+						t.Errorf("unexpected columns: got %v exp %v", row.Columns, exp)
+						continue
+					}
+					for _, v := range row.Values {
+						if v[1] != v[3] {
+							t.Errorf("unexpected status: errors %v requests %v", v[1], v[3])
+						}
+						got = append(got, joinPreAggResult{
+							Time:     v[0].(time.Time),
+							Errors:   v[2].(float64),
+							Requests: v[4].(float64),
+							Status:   v[1].(string),
+						})
+					}
+				}
+			}))
+			defer ts.Close()
+
+			var script = `
+var errors = stream
+	|from()
+		.measurement('errors')
+
+var requests = stream
+	|from()
+		.measurement('requests')
+
+errors
+	|join(requests)
+		.as('errors', 'requests')
+		.tolerance(10s)
+		.preAgg('%s')
+	|httpPost('%s')
+`
+			clock, et, replayErr, tm := testStreamer(t, "TestStream_Join_PreAgg", fmt.Sprintf(script, tc.preAgg, ts.URL), nil)
+			defer tm.Close()
+			if err := fastForwardTask(clock, et, replayErr, tm, time.Minute); err != nil {
+				t.Fatal(err)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if len(got) != tc.count {
+				t.Fatalf("unexpected number of joined points: got %d exp %d", len(got), tc.count)
+			}
+			if tc.exp != nil && !reflect.DeepEqual(got, tc.exp) {
+				t.Errorf("unexpected joined points:\ngot %v\nexp %v", got, tc.exp)
+			}
+		})
+	}
+}
+
+type joinPreAggResult struct {
+	Time     time.Time
+	Errors   float64
+	Requests float64
+	Status   string
+}
+
+func TestStream_RegexReplace(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('requests')
+	|regexReplace()
+		.field('path')
+		.field('count')
+		.tag('host')
+		.rule(/\s+$/, '')
+		.rule(/^WEB/, 'web')
+		.rule(/^(?P<name>[a-z]+)\.example\.com$/, '${name}')
+		.rule(/^(\w+)-(\d+)$/, '$1$2')
+		.rule(/price/, '$$')
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_RegexReplace')
+`
+	// Each rule rewrites the result of the previous rules,
+	// values that are not strings and fields that are not rewritten are unchanged.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "requests",
+				Tags:    nil,
+				Columns: []string{"time", "count", "dc", "host", "other", "path", "value"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+						1.0,
+						"east",
+						"web",
+						"web-01",
+						"/$",
+						1.0,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC),
+						1.0,
+						nil,
+						"web01",
+						nil,
+						"/api/v1",
+						nil,
+					},
+					{
+						time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC),
+						2.0,
+						nil,
+						"db",
+						nil,
+						"/",
+						nil,
+					},
+				},
+			},
+		},
+	}
+
+	clock, et, replayErr, tm := testStreamer(t, "TestStream_RegexReplace", script, nil)
+	defer tm.Close()
+	if err := fastForwardTask(clock, et, replayErr, tm, 15*time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := et.GetOutput("TestStream_RegexReplace")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Get(output.Endpoint())
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := models.Result{}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if eq, msg := compareResults(er, result); !eq {
+		t.Error(msg)
+	}
+
+	es, err := et.ExecutionStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := es.NodeStats["regexReplace2"]["rewrites"], int64(3); got != exp {
+		t.Errorf("unexpected rewrites: got %v exp %v", got, exp)
+	}
+}
+
+func TestStream_RegexReplace_GroupByTag(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('requests')
+		.groupBy('host')
+	|regexReplace()
+		.tag('host')
+		.rule(/\s+$/, '')
+	|window()
+		.period(10s)
+		.every(10s)
+	|count('value')
+	|httpOut('TestStream_RegexReplace_GroupByTag')
+`
+	// Rewriting a tag the data is grouped by moves the point to the group of the rewritten value.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "requests",
+				Tags:    models.Tags{"host": "web"},
+				Columns: []string{"time", "count"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
+						2.0,
+					},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_RegexReplace_GroupByTag", script, 15*time.Second, er, false, nil)
+}
+
+func TestStream_RegexReplace_Regroup(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('requests')
+		.groupBy('dc')
+	|regexReplace()
+		.tag('host')
+		.rule(/\s+$/, '')
+		.rule(/^(\w+)\.example\.com$/, '$1')
+		.regroup()
+	|window()
+		.period(10s)
+		.every(10s)
+	|count('value')
+	|httpOut('TestStream_RegexReplace_Regroup')
+`
+	// All variants of a host are grouped together.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "requests",
+				Tags:    models.Tags{"dc": "east", "host": "web"},
+				Columns: []string{"time", "count"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
+						3.0,
+					},
+				},
+			},
+			{
+				Name:    "requests",
+				Tags:    models.Tags{"dc": "east", "host": "db"},
+				Columns: []string{"time", "count"},
+				Values: [][]interface{}{
+					{
+						time.Date(1971, 1, 1, 0, 0, 13, 0, time.UTC),
+						1.0,
+					},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_RegexReplace_Regroup", script, 15*time.Second, er, true, nil)
+}
+
+func TestStream_Heartbeat(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+	|heartbeat(10ms)
+	|cumulativeSum('points')
+	|httpOut('TestStream_Heartbeat')
+`
+	clock, et, replayErr, tm := testStreamer(t, "TestStream_Heartbeat", script, nil)
+	defer tm.Close()
+	clock.Set(clock.Zero().Add(5 * time.Second))
+	if err := <-replayErr; err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := et.GetOutput("TestStream_Heartbeat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The heartbeats are emitted on real time, wait until they have counted all the cpu points.
+	var result models.Result
+	timeout := time.After(10 * time.Second)
+	for {
+		resp, err := http.Get(output.Endpoint())
+		if err != nil {
+			t.Fatal(err)
+		}
+		result = models.Result{}
+		err = json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result.Series) == 1 && len(result.Series[0].Values) == 1 && result.Series[0].Values[0][1] == 3.0 {
+			break
+		}
+		select {
+		case <-timeout:
+			t.Fatalf("timed out waiting for the heartbeats to count the points, last result: %v", result)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	row := result.Series[0]
+	if got, exp := row.Name, "_heartbeat"; got != exp {
+		t.Errorf("unexpected heartbeat measurement: got %s exp %s", got, exp)
+	}
+	if got, exp := row.Tags, map[string]string{"task": "TestStream_Heartbeat"}; !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected heartbeat tags: got %v exp %v", got, exp)
+	}
+	if got, exp := row.Columns, []string{"time", "cumulativeSum"}; !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected heartbeat columns: got %v exp %v", got, exp)
+	}
+
+	tm.Drain()
+	et.StopStats()
+	if err := et.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	es, err := et.ExecutionStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := es.NodeStats["heartbeat2"]["heartbeats"].(int64); !ok || got < 1 {
+		t.Errorf("unexpected heartbeats: got %v exp at least 1", es.NodeStats["heartbeat2"]["heartbeats"])
+	}
+}
+
+func TestStream_StateMachine(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.groupBy('host')
+	|stateMachine('ok')
+		.states('ok', 'warning', 'critical')
+		.transition('ok', 'warning', lambda: "usage" > 80)
+		.transition('warning', 'critical', lambda: "usage" > 95)
+		.transition('warning', 'ok', lambda: "usage" <= 80)
+		.transition('critical', 'ok', lambda: "usage" <= 80)
+	|window()
+		.period(100s)
+		.every(100s)
+		.align()
+	|httpOut('TestStream_StateMachine')
+`
+	// Each group enters the initial state with its first point and moves through the states on its own,
+	// a group cannot move from ok to critical directly and points older than its last transition are ignored.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    models.Tags{"host": "serverA"},
+				Columns: []string{"time", "dwell_time", "from_state", "to_state"},
+				Values: [][]interface{}{
+					{time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC), 10.0, "ok", "warning"},
+					{time.Date(1971, 1, 1, 0, 0, 30, 0, time.UTC), 20.0, "warning", "critical"},
+					{time.Date(1971, 1, 1, 0, 1, 0, 0, time.UTC), 30.0, "critical", "ok"},
+				},
+			},
+			{
+				Name:    "cpu",
+				Tags:    models.Tags{"host": "serverB"},
+				Columns: []string{"time", "dwell_time", "from_state", "to_state"},
+				Values: [][]interface{}{
+					{time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC), 0.0, "ok", "warning"},
+					{time.Date(1971, 1, 1, 0, 0, 40, 0, time.UTC), 35.0, "warning", "ok"},
+				},
+			},
+		},
+	}
+
+	clock, et, replayErr, tm := testStreamer(t, "TestStream_StateMachine", script, nil)
+	defer tm.Close()
+	if err := fastForwardTask(clock, et, replayErr, tm, 105*time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := et.GetOutput("TestStream_StateMachine")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Get(output.Endpoint())
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := models.Result{}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if eq, msg := compareResults(er, result); !eq {
+		t.Error(msg)
+	}
+
+	es, err := et.ExecutionStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := es.NodeStats["state_machine2"]["transitions"], int64(7); got != exp {
+		t.Errorf("unexpected transitions: got %v exp %v", got, exp)
+	}
+}
+
+func TestStream_Quantize(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+	|quantize()
+		.interval(5s)
+		.mode('ceil')
+	|httpOut('TestStream_Quantize')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverA"},
+				Columns: []string{"time", "value"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 15, 0, time.UTC),
+					4.0,
+				}},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Quantize", script, 15*time.Second, er, false, nil)
+}
+
+func TestStream_Split(t *testing.T) {
+	var script = `
+var split = stream
+	|from()
+		.measurement('cpu')
+		.groupBy('host')
+	|split()
+		.case('high', lambda: "value" > 90)
+		.case('elevated', lambda: "value" > 80)
+		.case('low', lambda: "value" < 10)
+		.defaultBranch('normal')
+
+split
+	|branch('high')
+	|httpOut('high')
+
+split
+	|branch('elevated')
+	|httpOut('elevated')
+
+split
+	|branch('low')
+	|httpOut('low')
+
+split
+	|branch('normal')
+	|httpOut('normal')
+`
+	row := func(host string, value float64) *models.Row {
+		return &models.Row{
+			Name:    "cpu",
+			Tags:    map[string]string{"host": host},
+			Columns: []string{"time", "value"},
+			Values: [][]interface{}{[]interface{}{
+				time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+				value,
+			}},
+		}
+	}
+	outputs := map[string]models.Result{
+		"high":     {Series: models.Rows{row("serverA", 95)}},
+		"elevated": {Series: models.Rows{row("serverD", 85)}},
+		"low":      {Series: models.Rows{row("serverC", 5)}},
+		"normal":   {Series: models.Rows{row("serverB", 50)}},
+	}
+
+	clock, et, replayErr, tm := testStreamer(t, "TestStream_Split", script, nil)
+	defer tm.Close()
+
+	if err := fastForwardTask(clock, et, replayErr, tm, 15*time.Second); err != nil {
+		t.Error(err)
+	}
+
+	for name, er := range outputs {
+		output, err := et.GetOutput(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.Get(output.Endpoint())
+		if err != nil {
+			t.Fatal(err)
+		}
+		result := models.Result{}
+		err = json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if eq, msg := compareResultsIgnoreSeriesOrder(er, result); !eq {
+			t.Errorf("unexpected output for branch %s: %s", name, msg)
+		}
+	}
+}
+
+func TestStream_AllMeasurements(t *testing.T) {
+
+	var script = `
+stream
+	|from()
+	|window()
+		.period(10s)
+		.every(10s)
+	|count('value')
+	|httpOut('TestStream_AllMeasurements')
+`
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    nil,
+				Columns: []string{"time", "count"},
+				Values: [][]interface{}{[]interface{}{
+					time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC),
+					23.0,
+				}},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_AllMeasurements", script, 15*time.Second, er, false, nil)
+}
+
+func TestStream_HttpPost(t *testing.T) {
+	requestCount := int32(0)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result := models.Result{}
+		dec := json.NewDecoder(r.Body)
+		err := dec.Decode(&result)
+		if err != nil {
+			t.Fatal(err)
+		}
+		atomic.AddInt32(&requestCount, 1)
+		rc := atomic.LoadInt32(&requestCount)
+
+		var er models.Result
+		switch rc {
+		case 1:
+			er = models.Result{
+				Series: models.Rows{
+					{
+						Name:    "cpu",
+						Tags:    map[string]string{"host": "serverA", "type": "idle"},
+						Columns: []string{"time", "value"},
+						Values: [][]interface{}{[]interface{}{
+							time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+							97.1,
+						}},
+					},
 				},
 			}
 		case 2:
@@ -3668,7 +10563,100 @@ stream
 		},
 	}
 
-	testStreamerWithOutput(t, "TestStream_SimpleMR", script, 15*time.Second, er, false, nil)
+	testStreamerWithOutput(t, "TestStream_SimpleMR", script, 15*time.Second, er, false, nil)
+}
+
+func TestStream_HttpOutGzip(t *testing.T) {
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.where(lambda: "host" == 'serverA')
+	|window()
+		.period(10s)
+		.every(10s)
+	|count('value')
+	|httpOut('TestStream_HttpOutGzip')
+`
+	clock, et, replayErr, tm := testStreamer(t, "TestStream_SimpleMR", script, nil)
+	defer tm.Close()
+	if err := fastForwardTask(clock, et, replayErr, tm, 15*time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := et.GetOutput("TestStream_HttpOutGzip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	get := func(header http.Header) *http.Response {
+		req, err := http.NewRequest("GET", output.Endpoint(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header = header
+		// Do not let the client ask for and decompress gzip transparently.
+		client := &http.Client{Transport: &http.Transport{DisableCompression: true}}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	resp := get(http.Header{})
+	plain, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.Header.Get("Content-Encoding"); got != "" {
+		t.Errorf("unexpected Content-Encoding without Accept-Encoding: %q", got)
+	}
+	etag := resp.Header.Get("ETag")
+	if etag == "" || strings.HasPrefix(etag, "W/") {
+		t.Errorf("expected a strong ETag, got %q", etag)
+	}
+
+	resp = get(http.Header{"Accept-Encoding": []string{"gzip"}})
+	defer resp.Body.Close()
+	if got, exp := resp.Header.Get("Content-Encoding"), "gzip"; got != exp {
+		t.Errorf("unexpected Content-Encoding: got %q exp %q", got, exp)
+	}
+	if got, exp := resp.Header.Get("Vary"), "Accept-Encoding"; got != exp {
+		t.Errorf("unexpected Vary: got %q exp %q", got, exp)
+	}
+	gzipETag := resp.Header.Get("ETag")
+	if got, exp := gzipETag, "W/"+etag; got != exp {
+		t.Errorf("unexpected ETag: got %q exp %q", got, exp)
+	}
+	gz, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := ioutil.ReadAll(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(body, plain) {
+		t.Errorf("unexpected decompressed body:\ngot %s\nexp %s", body, plain)
+	}
+
+	// The ETag of either response revalidates the cached data.
+	for _, tag := range []string{etag, gzipETag} {
+		resp := get(http.Header{"Accept-Encoding": []string{"gzip"}, "If-None-Match": []string{tag}})
+		b, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, exp := resp.StatusCode, http.StatusNotModified; got != exp {
+			t.Errorf("%s: unexpected status: got %d exp %d", tag, got, exp)
+		}
+		if len(b) != 0 {
+			t.Errorf("%s: unexpected body: %q", tag, b)
+		}
+	}
 }
 
 func TestStream_BatchGroupBy(t *testing.T) {
@@ -8215,6 +15203,188 @@ stream
 	}
 }
 
+func TestStream_Alert_StagedRecovery(t *testing.T) {
+	var mu sync.Mutex
+	var levels []alert.Level
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ad := alert.Data{}
+		dec := json.NewDecoder(r.Body)
+		err := dec.Decode(&ad)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mu.Lock()
+		levels = append(levels, ad.Level)
+		mu.Unlock()
+	}))
+	defer ts.Close()
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.where(lambda: "host" == 'serverA')
+		.groupBy('host')
+	|alert()
+		.warn(lambda: "value" > 70)
+		.warnReset(lambda: "value" < 60)
+		.crit(lambda: "value" > 80)
+		.critReset(lambda: "value" < 75)
+		.stateChangesOnly()
+		.post('` + ts.URL + `')
+`
+
+	testStreamerNoOutput(t, "TestStream_Alert_StagedRecovery", script, 8*time.Second, nil)
+
+	// Recovering from CRITICAL steps down to WARNING,
+	// since the warning still holds when the critical alert resets.
+	mu.Lock()
+	defer mu.Unlock()
+	exp := []alert.Level{alert.Critical, alert.Warning, alert.OK}
+	if !reflect.DeepEqual(levels, exp) {
+		t.Errorf("unexpected alert levels:\ngot %v\nexp %v", levels, exp)
+	}
+}
+
+func TestStream_Alert_ValueHistory(t *testing.T) {
+	var mu sync.Mutex
+	var messages []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ad := alert.Data{}
+		dec := json.NewDecoder(r.Body)
+		err := dec.Decode(&ad)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mu.Lock()
+		messages = append(messages, ad.Message)
+		mu.Unlock()
+	}))
+	defer ts.Close()
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.groupBy('host')
+	|alert()
+		.message('{{ range .History }}{{ . }} {{ end }}')
+		.crit(lambda: TRUE)
+		.valueHistory('value', 2)
+		.post('` + ts.URL + `')
+`
+
+	testStreamerNoOutput(t, "TestStream_Alert_ValueHistory", script, 5*time.Second, nil)
+
+	// The history keeps the two most recent values, including the value of the alerting point.
+	mu.Lock()
+	defer mu.Unlock()
+	exp := []string{
+		"1 ",
+		"1 2 ",
+		"2 3 ",
+		"3 4 ",
+	}
+	if !reflect.DeepEqual(messages, exp) {
+		t.Errorf("unexpected alert messages:\ngot %v\nexp %v", messages, exp)
+	}
+}
+
+func TestStream_Alert_Roc(t *testing.T) {
+	var mu sync.Mutex
+	var events []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ad := alert.Data{}
+		dec := json.NewDecoder(r.Body)
+		err := dec.Decode(&ad)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mu.Lock()
+		events = append(events, ad.ID+" "+ad.Level.String()+" "+ad.Message)
+		mu.Unlock()
+	}))
+	defer ts.Close()
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.groupBy('host')
+	|alert()
+		.id('{{ index .Tags "host" }}')
+		.message('{{ index .Fields "roc" }}')
+		.warn(lambda: "roc" > 0.2)
+		.crit(lambda: "roc" > 0.5)
+		.roc('value', 2s)
+		.stateChangesOnly()
+		.post('` + ts.URL + `')
+`
+
+	testStreamerNoOutput(t, "TestStream_Alert_Roc", script, 10*time.Second, nil)
+
+	// The values of serverA are compared with the values two seconds earlier,
+	// the first two points have no reference value.
+	// The constant values of serverB never alert.
+	mu.Lock()
+	defer mu.Unlock()
+	exp := []string{
+		"serverA CRITICAL 0.6",
+		"serverA WARNING 0.25",
+		"serverA OK 0.058823529411764705",
+	}
+	if !reflect.DeepEqual(events, exp) {
+		t.Errorf("unexpected alert events:\ngot %v\nexp %v", events, exp)
+	}
+}
+
+func TestStream_Alert_RollingPercentiles(t *testing.T) {
+	var mu sync.Mutex
+	var events []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ad := alert.Data{}
+		dec := json.NewDecoder(r.Body)
+		err := dec.Decode(&ad)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mu.Lock()
+		events = append(events, ad.ID+" "+ad.Level.String()+" "+ad.Message)
+		mu.Unlock()
+	}))
+	defer ts.Close()
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.groupBy('host')
+	|alert()
+		.id('{{ index .Tags "host" }}')
+		.message('{{ index .Fields "value" }}')
+		.crit(lambda: "value" > "p99")
+		.rollingPercentiles('value', 200s, 99.0)
+		.stateChangesOnly()
+		.post('` + ts.URL + `')
+`
+
+	testStreamerNoOutput(t, "TestStream_Alert_RollingPercentiles", script, 110*time.Second, nil)
+
+	// The values of serverA cycle from 10 to 19, the spike at 50s is ignored
+	// because the window does not have the 100 values needed for the 99th percentile yet.
+	// The spike at 101s exceeds the 99th percentile of the previous values.
+	// The constant values of serverB never exceed their own 99th percentile.
+	mu.Lock()
+	defer mu.Unlock()
+	exp := []string{
+		"serverA CRITICAL 100",
+		"serverA OK 15",
+	}
+	if !reflect.DeepEqual(events, exp) {
+		t.Errorf("unexpected alert events:\ngot %v\nexp %v", events, exp)
+	}
+}
+
 func TestStream_AlertDuration(t *testing.T) {
 	requestCount := int32(0)
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -10271,6 +17441,44 @@ stream
 		t.Errorf("got %v exp %v", rc, 5)
 	}
 }
+func TestStream_Alert_IdTags(t *testing.T) {
+	var mu sync.Mutex
+	var got []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ad := alert.Data{}
+		dec := json.NewDecoder(r.Body)
+		if err := dec.Decode(&ad); err != nil {
+			t.Fatal(err)
+		}
+		mu.Lock()
+		got = append(got, ad.ID+" "+ad.Level.String())
+		mu.Unlock()
+	}))
+	defer ts.Close()
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.groupBy('host', 'label')
+	|alert()
+		.idTags('host')
+		.crit(lambda: "value" > 80)
+		.stateChangesOnly()
+		.post('` + ts.URL + `')
+`
+
+	testStreamerNoOutput(t, "TestStream_Alert_IdTags", script, 6*time.Second, nil)
+
+	// The label changes while the host is critical,
+	// the alert of the host recovers instead of staying critical under the previous label.
+	exp := []string{"cpu:host=serverA CRITICAL", "cpu:host=serverA OK"}
+	mu.Lock()
+	defer mu.Unlock()
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected alerts:\ngot %v\nexp %v", got, exp)
+	}
+}
+
 func TestStream_AlertStateChangesOnlyExpired(t *testing.T) {
 
 	requestCount := int32(0)
@@ -11445,6 +18653,160 @@ stream
 	}
 }
 
+func TestStream_InfluxDBOut_Clusters(t *testing.T) {
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+	|influxDBOut()
+		.clusters('primary', 'secondary')
+		.failoverThreshold(2)
+		.failoverBuffer(1)
+		.database('db')
+		.buffer(1)
+`
+
+	var mu sync.Mutex
+	primaryWrites := 0
+	secondaryWrites := 0
+	var values []interface{}
+
+	primary := NewMockInfluxDBService(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ping" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		mu.Lock()
+		primaryWrites++
+		mu.Unlock()
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	secondary := NewMockInfluxDBService(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ping" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		secondaryWrites++
+		// The first write to the secondary fails as well.
+		if secondaryWrites == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		points, err := imodels.ParsePointsWithPrecision(b, time.Unix(0, 0), r.URL.Query().Get("precision"))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		for _, p := range points {
+			values = append(values, p.Fields()["value"])
+		}
+		var data client.Response
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(data)
+	}))
+
+	tmInit := func(tm *kapacitor.TaskMaster) {
+		tm.InfluxDBService = MockInfluxDBClusters{
+			"primary":   primary,
+			"secondary": secondary,
+		}
+	}
+	testStreamerNoOutput(t, "TestStream_InfluxDBOut_Clusters", script, 10*time.Second, tmInit)
+
+	// Writes fail over to the secondary after the second failed write to the primary.
+	// The first point is dropped once more than one point of failed writes is retained,
+	// the remaining points are written to the secondary in order.
+	mu.Lock()
+	defer mu.Unlock()
+	if got, exp := primaryWrites, 2; got != exp {
+		t.Errorf("unexpected writes to primary: got %d exp %d", got, exp)
+	}
+	if exp := []interface{}{2.0, 3.0, 4.0, 5.0}; !reflect.DeepEqual(values, exp) {
+		t.Errorf("unexpected values written to secondary:\ngot %v\nexp %v", values, exp)
+	}
+}
+
+func TestStream_InfluxDBOut_WriteTagsOnly(t *testing.T) {
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+	|influxDBOut()
+		.database('db')
+		.writeTagsOnly('host')
+		.tag('kapacitor', 'true')
+		.buffer(1)
+`
+
+	testInfluxDBOutTags(t, "TestStream_InfluxDBOut_WriteTags", script, map[string]string{"host": "serverA", "kapacitor": "true"})
+}
+
+func TestStream_InfluxDBOut_DropWriteTags(t *testing.T) {
+
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+	|influxDBOut()
+		.database('db')
+		.dropWriteTags('request_id', 'session_id')
+		.buffer(1)
+`
+
+	testInfluxDBOutTags(t, "TestStream_InfluxDBOut_WriteTags", script, map[string]string{"host": "serverA"})
+}
+
+// testInfluxDBOutTags runs the script and checks the tags of the points written to InfluxDB.
+func testInfluxDBOutTags(t *testing.T, name, script string, exp map[string]string) {
+	t.Helper()
+	var mu sync.Mutex
+	var tags []map[string]string
+	influxdb := NewMockInfluxDBService(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ping" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		points, err := imodels.ParsePointsWithPrecision(b, time.Unix(0, 0), r.URL.Query().Get("precision"))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		mu.Lock()
+		for _, p := range points {
+			tags = append(tags, p.Tags().Map())
+		}
+		mu.Unlock()
+		var data client.Response
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(data)
+	}))
+
+	tmInit := func(tm *kapacitor.TaskMaster) {
+		tm.InfluxDBService = influxdb
+	}
+	testStreamerNoOutput(t, name, script, 10*time.Second, tmInit)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if exp := []map[string]string{exp}; !reflect.DeepEqual(tags, exp) {
+		t.Errorf("unexpected tags written:\ngot %v\nexp %v", tags, exp)
+	}
+}
+
 func TestStream_Selectors(t *testing.T) {
 
 	var script = `
@@ -11986,17 +19348,19 @@ stream
 			"emitted":             int64(90),
 		},
 		"alert2": map[string]interface{}{
-			"emitted":             int64(0),
-			"working_cardinality": int64(9),
-			"avg_exec_time_ns":    int64(0),
-			"errors":              int64(0),
-			"collected":           int64(90),
-			"warns_triggered":     int64(0),
-			"crits_triggered":     int64(0),
-			"alerts_triggered":    int64(0),
-			"alerts_inhibited":    int64(0),
-			"oks_triggered":       int64(0),
-			"infos_triggered":     int64(0),
+			"emitted":                int64(0),
+			"working_cardinality":    int64(9),
+			"avg_exec_time_ns":       int64(0),
+			"errors":                 int64(0),
+			"collected":              int64(90),
+			"warns_triggered":        int64(0),
+			"crits_triggered":        int64(0),
+			"alerts_triggered":       int64(0),
+			"alerts_inhibited":       int64(0),
+			"alerts_acknowledged":    int64(0),
+			"handler_events_dropped": int64(0),
+			"oks_triggered":          int64(0),
+			"infos_triggered":        int64(0),
 		},
 	}
 
@@ -12263,17 +19627,19 @@ stream
 			"emitted":             int64(27),
 		},
 		"alert6": map[string]interface{}{
-			"emitted":             int64(0),
-			"working_cardinality": int64(3),
-			"avg_exec_time_ns":    int64(0),
-			"errors":              int64(0),
-			"collected":           int64(27),
-			"warns_triggered":     int64(0),
-			"crits_triggered":     int64(0),
-			"alerts_triggered":    int64(0),
-			"alerts_inhibited":    int64(0),
-			"oks_triggered":       int64(0),
-			"infos_triggered":     int64(0),
+			"emitted":                int64(0),
+			"working_cardinality":    int64(3),
+			"avg_exec_time_ns":       int64(0),
+			"errors":                 int64(0),
+			"collected":              int64(27),
+			"warns_triggered":        int64(0),
+			"crits_triggered":        int64(0),
+			"alerts_triggered":       int64(0),
+			"alerts_inhibited":       int64(0),
+			"alerts_acknowledged":    int64(0),
+			"handler_events_dropped": int64(0),
+			"oks_triggered":          int64(0),
+			"infos_triggered":        int64(0),
 		},
 	}
 
@@ -314,6 +314,18 @@ func (g *joinGroup) Collect(src int, p timeMessage) error {
 		g.oldestTime = t
 	}
 
+	if g.n.j.PreAggregate != "" {
+		// A single set per tolerance window aggregates the points of each parent.
+		sets := g.sets[t]
+		if len(sets) == 0 {
+			sets = append(sets, g.newJoinset(t))
+			g.sets[t] = sets
+		}
+		sets[0].Aggregate(src, p, g.n.j.PreAggregate)
+		g.head[src] = t
+		return g.emitAggregated()
+	}
+
 	var set *joinset
 	sets := g.sets[t]
 	if len(sets) == 0 {
@@ -394,6 +406,21 @@ func (g *joinGroup) emit(onlyReadySets bool) error {
 	return nil
 }
 
+// emit the pre-aggregated sets of the tolerance windows all parents have moved past.
+func (g *joinGroup) emitAggregated() error {
+	for len(g.sets) > 0 {
+		for _, t := range g.head {
+			if !t.After(g.oldestTime) {
+				return nil
+			}
+		}
+		if err := g.emit(false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // emit sets until we have none left.
 func (g *joinGroup) emitAll() error {
 	var lastErr error
@@ -423,6 +450,20 @@ func (g *joinGroup) emitJoinedSet(set *joinset) error {
 			}
 		}
 	case pipeline.BatchEdge:
+		if g.n.j.ByIndexFlag {
+			b, err := set.JoinIntoBatchByIndex()
+			if err != nil {
+				// Drop the batches, later sets can still be joined.
+				g.n.diag.Error("failed to join batches by index", err)
+				return nil
+			}
+			if b != nil {
+				if err := edge.Forward(g.n.outs, b); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
 		b, err := set.JoinIntoBatch()
 		if err != nil {
 			return errors.Wrap(err, "failed to join into batch")
@@ -461,6 +502,9 @@ type joinset struct {
 
 	first int
 
+	// counts are the number of points averaged into each numeric field of the values, for the mean pre-aggregate.
+	counts []map[string]int
+
 	diag NodeDiagnostic
 }
 
@@ -509,6 +553,68 @@ func (js *joinset) Set(i int, v edge.Message) {
 	js.size++
 }
 
+// aggregate a point into the value of a given parent index.
+func (js *joinset) Aggregate(i int, v edge.Message, aggregate string) {
+	if !js.Has(i) {
+		js.Set(i, v)
+		return
+	}
+	switch aggregate {
+	case pipeline.JoinPreAggLast:
+		js.values[i] = v
+	case pipeline.JoinPreAggMean:
+		js.values[i] = js.mean(i, v.(edge.PointMessage))
+	}
+}
+
+// mean returns the value of a given parent index with the numeric fields averaged with the fields of p.
+func (js *joinset) mean(i int, p edge.PointMessage) edge.PointMessage {
+	current := js.values[i].(edge.PointMessage)
+	if js.counts == nil {
+		js.counts = make([]map[string]int, js.expected)
+	}
+	counts := js.counts[i]
+	if counts == nil {
+		counts = make(map[string]int)
+		for k, v := range current.Fields() {
+			if _, ok := joinFloat(v); ok {
+				counts[k] = 1
+			}
+		}
+		js.counts[i] = counts
+	}
+	fields := current.Fields().Copy()
+	for k, v := range p.Fields() {
+		f, ok := joinFloat(v)
+		if !ok {
+			fields[k] = v
+			delete(counts, k)
+			continue
+		}
+		mean, ok := joinFloat(fields[k])
+		if !ok || counts[k] == 0 {
+			fields[k] = f
+			counts[k] = 1
+			continue
+		}
+		counts[k]++
+		fields[k] = mean + (f-mean)/float64(counts[k])
+	}
+	np := current.ShallowCopy()
+	np.SetFields(fields)
+	return np
+}
+
+func joinFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	}
+	return 0, false
+}
+
 // a valid point in the set
 func (js *joinset) First() edge.Message {
 	return js.values[js.first]
@@ -670,6 +776,87 @@ BATCH_POINT:
 	), nil
 }
 
+// join all batches of the set into a single batch, joining the points by their position in the batches
+func (js *joinset) JoinIntoBatchByIndex() (edge.BufferedBatchMessage, error) {
+	first, ok := js.First().(edge.BufferedBatchMessage)
+	if !ok {
+		return nil, fmt.Errorf("unexpected type of first value %T", js.First())
+	}
+	// The value of the fields of missing batches and points.
+	var fillValue interface{}
+	if js.fill == influxql.NumberFill {
+		fillValue = js.fillValue
+	}
+	batches := make([]edge.BufferedBatchMessage, js.expected)
+	minLen, maxLen := -1, 0
+	for i, v := range js.values {
+		if v == nil {
+			if js.fill == influxql.NoFill {
+				// inner join no valid batch possible
+				return nil, nil
+			}
+			continue
+		}
+		b, ok := v.(edge.BufferedBatchMessage)
+		if !ok {
+			return nil, fmt.Errorf("unexpected type of batch value %T", v)
+		}
+		batches[i] = b
+		l := len(b.Points())
+		if minLen == -1 || l < minLen {
+			minLen = l
+		}
+		if l > maxLen {
+			maxLen = l
+		}
+	}
+	if minLen != maxLen && js.j.j.LengthMismatchHandling != pipeline.JoinLengthMismatchPad {
+		return nil, fmt.Errorf("batches of %s at %v have different lengths, shortest %d longest %d", js.name, js.time, minLen, maxLen)
+	}
+
+	newBegin := edge.NewBeginBatchMessage(
+		js.name,
+		first.Tags(),
+		first.Dimensions().ByName,
+		js.time,
+		maxLen,
+	)
+	newPoints := make([]edge.BatchPointMessage, maxLen)
+	for idx := range newPoints {
+		// The first point at the index provides the time and the field names of missing points.
+		var firstPoint edge.BatchPointMessage
+		for _, b := range batches {
+			if b != nil && idx < len(b.Points()) {
+				firstPoint = b.Points()[idx]
+				break
+			}
+		}
+		firstFields := firstPoint.Fields()
+		fields := make(models.Fields, js.expected*len(firstFields))
+		for i, b := range batches {
+			if b == nil || idx >= len(b.Points()) {
+				for k := range firstFields {
+					fields[js.prefixes[i]+js.delimiter+k] = fillValue
+				}
+				continue
+			}
+			for k, v := range b.Points()[idx].Fields() {
+				fields[js.prefixes[i]+js.delimiter+k] = v
+			}
+		}
+		newPoints[idx] = edge.NewBatchPointMessage(
+			fields,
+			newBegin.Tags(),
+			firstPoint.Time(),
+		)
+	}
+	return edge.NewBufferedBatchMessage(
+		newBegin,
+		newPoints,
+		edge.NewEndBatchMessage(),
+	), nil
+}
+
 type durationVar struct {
 	expvar.Int
 }
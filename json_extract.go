@@ -0,0 +1,333 @@
+package kapacitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/keyvalue"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/pkg/errors"
+)
+
+const (
+	statsMalformed      = "malformed"
+	statsCoercionErrors = "coercion_errors"
+)
+
+type JSONExtractNode struct {
+	node
+	j *pipeline.JSONExtractNode
+
+	paths []jsonPath
+
+	malformed      *expvar.Int
+	coercionErrors *expvar.Int
+}
+
+// Create a new JSONExtractNode which extracts values out of a field containing a JSON string.
+func newJSONExtractNode(et *ExecutingTask, n *pipeline.JSONExtractNode, d NodeDiagnostic) (*JSONExtractNode, error) {
+	paths := make([]jsonPath, len(n.Extractions))
+	for i, e := range n.Extractions {
+		p, err := parseJSONPath(e.Path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid path %q", e.Path)
+		}
+		paths[i] = p
+	}
+	jn := &JSONExtractNode{
+		node:           node{Node: n, et: et, diag: d},
+		j:              n,
+		paths:          paths,
+		malformed:      new(expvar.Int),
+		coercionErrors: new(expvar.Int),
+	}
+	jn.node.runF = jn.runJSONExtract
+	return jn, nil
+}
+
+func (n *JSONExtractNode) runJSONExtract(snapshot []byte) error {
+	n.statMap.Set(statsMalformed, n.malformed)
+	n.statMap.Set(statsCoercionErrors, n.coercionErrors)
+	consumer := edge.NewConsumerWithReceiver(
+		n.ins[0],
+		edge.NewReceiverFromForwardReceiverWithStats(
+			n.outs,
+			edge.NewTimedForwardReceiver(n.timer, n),
+		),
+	)
+	return consumer.Consume()
+}
+
+// extract sets the values extracted from the JSON field of p, it reports false if p should be dropped.
+func (n *JSONExtractNode) extract(p edge.FieldsTagsTimeSetter) bool {
+	doc, err := decodeJSONField(p.Fields(), n.j.Field)
+	if err != nil {
+		n.malformed.Add(1)
+		n.diag.Error("failed to decode JSON field", err, keyvalue.KV("time", p.Time().String()))
+		return !n.j.DropMalformedFlag
+	}
+	fields, tags := p.Fields(), p.Tags()
+	fieldsCopied, tagsCopied := false, false
+	for i, e := range n.j.Extractions {
+		v, ok := n.paths[i].lookup(doc)
+		if !ok || v == nil {
+			continue
+		}
+		value, err := coerceJSONValue(v, e.Type)
+		if err != nil {
+			n.coercionErrors.Add(1)
+			n.diag.Error("failed to convert extracted value", err, keyvalue.KV("path", e.Path))
+			continue
+		}
+		if e.Type == pipeline.JSONExtractTag {
+			if !tagsCopied {
+				tags = tags.Copy()
+				tagsCopied = true
+			}
+			tags[e.As] = value.(string)
+		} else {
+			if !fieldsCopied {
+				fields = fields.Copy()
+				fieldsCopied = true
+			}
+			fields[e.As] = value
+		}
+	}
+	p.SetFields(fields)
+	p.SetTags(tags)
+	return true
+}
+
+// decodeJSONField decodes the JSON string of the field.
+// Numbers are decoded as json.Number so that integers keep their precision.
+func decodeJSONField(fields models.Fields, field string) (interface{}, error) {
+	s, ok := fields[field].(string)
+	if !ok {
+		if _, exists := fields[field]; !exists {
+			return nil, fmt.Errorf("field %q is missing", field)
+		}
+		return nil, fmt.Errorf("field %q is not a string, got %T", field, fields[field])
+	}
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("field %q is not valid JSON: %v", field, err)
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("field %q is not valid JSON: unexpected data after the JSON value", field)
+	}
+	return doc, nil
+}
+
+// coerceJSONValue converts a decoded JSON value to the extraction type.
+func coerceJSONValue(v interface{}, typ string) (interface{}, error) {
+	switch typ {
+	case pipeline.JSONExtractFloat:
+		switch v := v.(type) {
+		case json.Number:
+			return v.Float64()
+		case string:
+			return strconv.ParseFloat(v, 64)
+		}
+	case pipeline.JSONExtractInt:
+		switch v := v.(type) {
+		case json.Number:
+			if i, err := v.Int64(); err == nil {
+				return i, nil
+			}
+			// Accept numbers such as 1e3 or 42.0 that are integers.
+			f, err := v.Float64()
+			if err != nil || f != math.Trunc(f) || math.Abs(f) > math.MaxInt64 {
+				return nil, fmt.Errorf("%s is not an integer", v)
+			}
+			return int64(f), nil
+		case string:
+			return strconv.ParseInt(v, 10, 64)
+		}
+	case pipeline.JSONExtractBool:
+		switch v := v.(type) {
+		case bool:
+			return v, nil
+		case string:
+			return strconv.ParseBool(v)
+		}
+	case pipeline.JSONExtractString, pipeline.JSONExtractTag:
+		switch v := v.(type) {
+		case string:
+			return v, nil
+		case json.Number:
+			return v.String(), nil
+		case bool:
+			return strconv.FormatBool(v), nil
+		default:
+			// Objects and arrays are extracted as their JSON.
+			b, err := json.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+			return string(b), nil
+		}
+	default:
+		return nil, fmt.Errorf("unknown type %q", typ)
+	}
+	return nil, fmt.Errorf("cannot convert %s to %s", jsonKind(v), typ)
+}
+
+// jsonKind returns the name of the JSON type of a decoded value.
+func jsonKind(v interface{}) string {
+	switch v.(type) {
+	case json.Number:
+		return "number"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+// jsonPathStep is an object key or an array index of a JSONPath.
+type jsonPathStep struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// jsonPath is a JSONPath expression selecting a single value.
+type jsonPath []jsonPathStep
+
+// parseJSONPath parses the JSONPath subset of object keys and array indexes,
+// i.e. $.a.b, $['a']["b"] and $.a[0].
+// The leading "$." is optional.
+func parseJSONPath(path string) (jsonPath, error) {
+	s := strings.TrimSpace(path)
+	if strings.HasPrefix(s, "$") {
+		s = s[1:]
+	} else if s != "" && s[0] != '.' && s[0] != '[' {
+		s = "." + s
+	}
+	var p jsonPath
+	for len(s) > 0 {
+		switch s[0] {
+		case '.':
+			end := strings.IndexAny(s[1:], ".[")
+			if end < 0 {
+				end = len(s) - 1
+			}
+			key := s[1 : end+1]
+			if key == "" {
+				return nil, errors.New("empty key")
+			}
+			if key == "*" {
+				return nil, errors.New("wildcards are not supported")
+			}
+			p = append(p, jsonPathStep{key: key})
+			s = s[end+1:]
+		case '[':
+			end := strings.IndexByte(s, ']')
+			if end < 0 {
+				return nil, errors.New("missing ]")
+			}
+			inner := s[1:end]
+			if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') {
+				quote := inner[0]
+				// A quoted key may contain a ], find the closing quote.
+				closing := strings.IndexByte(s[2:], quote)
+				if closing < 0 {
+					return nil, errors.New("missing closing quote")
+				}
+				end = closing + 3
+				if end >= len(s) || s[end] != ']' {
+					return nil, errors.New("missing ] after quoted key")
+				}
+				p = append(p, jsonPathStep{key: s[2 : end-1]})
+			} else {
+				if inner == "*" {
+					return nil, errors.New("wildcards are not supported")
+				}
+				i, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, fmt.Errorf("invalid array index %q", inner)
+				}
+				p = append(p, jsonPathStep{index: i, isIndex: true})
+			}
+			s = s[end+1:]
+		default:
+			return nil, fmt.Errorf("unexpected character %q", s[0])
+		}
+	}
+	return p, nil
+}
+
+// lookup returns the value selected by the path and whether it exists.
+func (p jsonPath) lookup(v interface{}) (interface{}, bool) {
+	for _, step := range p {
+		if step.isIndex {
+			a, ok := v.([]interface{})
+			if !ok {
+				return nil, false
+			}
+			i := step.index
+			if i < 0 {
+				i += len(a)
+			}
+			if i < 0 || i >= len(a) {
+				return nil, false
+			}
+			v = a[i]
+		} else {
+			o, ok := v.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			if v, ok = o[step.key]; !ok {
+				return nil, false
+			}
+		}
+	}
+	return v, true
+}
+
+func (n *JSONExtractNode) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	return begin, nil
+}
+
+func (n *JSONExtractNode) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	bp = bp.ShallowCopy()
+	if !n.extract(bp) {
+		return nil, nil
+	}
+	return bp, nil
+}
+
+func (n *JSONExtractNode) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (n *JSONExtractNode) Point(p edge.PointMessage) (edge.Message, error) {
+	p = p.ShallowCopy()
+	if !n.extract(p) {
+		return nil, nil
+	}
+	return p, nil
+}
+
+func (n *JSONExtractNode) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (n *JSONExtractNode) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (n *JSONExtractNode) Done() {}
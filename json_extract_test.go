@@ -0,0 +1,40 @@
+package kapacitor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseJSONPath(t *testing.T) {
+	testCases := []struct {
+		path    string
+		exp     jsonPath
+		wantErr bool
+	}{
+		{path: "$", exp: nil},
+		{path: "$.user.id", exp: jsonPath{{key: "user"}, {key: "id"}}},
+		{path: "user.id", exp: jsonPath{{key: "user"}, {key: "id"}}},
+		{path: "$['user'][\"id\"]", exp: jsonPath{{key: "user"}, {key: "id"}}},
+		{path: "$['a.b]c']", exp: jsonPath{{key: "a.b]c"}}},
+		{path: "$.items[1].price", exp: jsonPath{{key: "items"}, {index: 1, isIndex: true}, {key: "price"}}},
+		{path: "$.items[-1]", exp: jsonPath{{key: "items"}, {index: -1, isIndex: true}}},
+		{path: "$.items[*]", wantErr: true},
+		{path: "$.*", wantErr: true},
+		{path: "$.items[x]", wantErr: true},
+		{path: "$.items[0", wantErr: true},
+		{path: "$['user]", wantErr: true},
+		{path: "$..user", wantErr: true},
+		{path: "$user", wantErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			got, err := parseJSONPath(tc.path)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("parseJSONPath() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if !tc.wantErr && !reflect.DeepEqual(got, tc.exp) {
+				t.Errorf("unexpected path: got %v exp %v", got, tc.exp)
+			}
+		})
+	}
+}
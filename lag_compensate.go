@@ -0,0 +1,122 @@
+package kapacitor
+
+import (
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+const (
+	statsLag = "lag_ns"
+)
+
+type LagCompensateNode struct {
+	node
+	l *pipeline.LagCompensateNode
+
+	// now returns the wall clock time the data is received.
+	now func() time.Time
+
+	// lag is the estimated lag in nanoseconds, valid once estimated is set.
+	lag       float64
+	estimated bool
+	// batchShift is the shift of the points of the current batch.
+	batchShift time.Duration
+
+	lagVar *expvar.Int
+}
+
+// Create a new LagCompensateNode which shifts the time of the data forward by the measured processing lag.
+func newLagCompensateNode(et *ExecutingTask, n *pipeline.LagCompensateNode, d NodeDiagnostic) (*LagCompensateNode, error) {
+	ln := &LagCompensateNode{
+		node:   node{Node: n, et: et, diag: d},
+		l:      n,
+		now:    time.Now,
+		lagVar: new(expvar.Int),
+	}
+	ln.node.runF = ln.runLagCompensate
+	return ln, nil
+}
+
+func (n *LagCompensateNode) runLagCompensate([]byte) error {
+	n.statMap.Set(statsLag, n.lagVar)
+	consumer := edge.NewConsumerWithReceiver(
+		n.ins[0],
+		edge.NewReceiverFromForwardReceiverWithStats(
+			n.outs,
+			edge.NewTimedForwardReceiver(n.timer, n),
+		),
+	)
+	return consumer.Consume()
+}
+
+// measure updates the estimate with the lag of data with the time t
+// and returns the shift of the data.
+func (n *LagCompensateNode) measure(t time.Time) time.Duration {
+	lag := float64(n.now().Sub(t))
+	if n.estimated {
+		n.lag += n.l.Alpha * (lag - n.lag)
+	} else {
+		// The first lag is the initial estimate.
+		n.lag = lag
+		n.estimated = true
+	}
+	n.lagVar.Set(int64(n.lag))
+
+	if n.l.NoShiftFlag {
+		return 0
+	}
+	shift := time.Duration(n.lag)
+	if max := n.l.Max; max > 0 {
+		if shift > max {
+			shift = max
+		} else if shift < -max {
+			shift = -max
+		}
+	}
+	return shift
+}
+
+func (n *LagCompensateNode) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	// All points of a batch are shifted alike, by the lag of the batch.
+	n.batchShift = n.measure(begin.Time())
+	if n.batchShift == 0 {
+		return begin, nil
+	}
+	begin = begin.ShallowCopy()
+	begin.SetTime(begin.Time().Add(n.batchShift))
+	return begin, nil
+}
+
+func (n *LagCompensateNode) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	if n.batchShift == 0 {
+		return bp, nil
+	}
+	bp = bp.ShallowCopy()
+	bp.SetTime(bp.Time().Add(n.batchShift))
+	return bp, nil
+}
+
+func (n *LagCompensateNode) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (n *LagCompensateNode) Point(p edge.PointMessage) (edge.Message, error) {
+	shift := n.measure(p.Time())
+	if shift == 0 {
+		return p, nil
+	}
+	p = p.ShallowCopy()
+	p.SetTime(p.Time().Add(shift))
+	return p, nil
+}
+
+func (n *LagCompensateNode) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (n *LagCompensateNode) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (n *LagCompensateNode) Done() {}
@@ -0,0 +1,186 @@
+package kapacitor
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+const (
+	statsRedactions = "redactions"
+)
+
+type MaskNode struct {
+	node
+	m *pipeline.MaskNode
+
+	redactions *expvar.Int
+}
+
+// Create a new MaskNode which masks the parts of field and tag values that match patterns.
+func newMaskNode(et *ExecutingTask, n *pipeline.MaskNode, d NodeDiagnostic) (*MaskNode, error) {
+	mn := &MaskNode{
+		node:       node{Node: n, et: et, diag: d},
+		m:          n,
+		redactions: new(expvar.Int),
+	}
+	mn.node.runF = mn.runMask
+	return mn, nil
+}
+
+func (n *MaskNode) runMask([]byte) error {
+	n.statMap.Set(statsRedactions, n.redactions)
+	consumer := edge.NewConsumerWithReceiver(
+		n.ins[0],
+		edge.NewReceiverFromForwardReceiverWithStats(
+			n.outs,
+			edge.NewTimedForwardReceiver(n.timer, n),
+		),
+	)
+	return consumer.Consume()
+}
+
+// replacement returns the string that replaces match.
+func (n *MaskNode) replacement(match string) string {
+	if n.m.HashFlag {
+		sum := sha256.Sum256([]byte(match))
+		return hex.EncodeToString(sum[:])
+	}
+	return n.m.Replacement
+}
+
+// mask replaces the matches of all patterns in s and returns the number of replacements.
+// All patterns are matched against the original value and overlapping matches are replaced once,
+// so that a replacement is never matched by another pattern.
+func (n *MaskNode) mask(s string) (string, int) {
+	var spans [][]int
+	for _, r := range n.m.Patterns {
+		for _, loc := range r.FindAllStringIndex(s, -1) {
+			if loc[0] < loc[1] {
+				spans = append(spans, loc)
+			}
+		}
+	}
+	if len(spans) == 0 {
+		return s, 0
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i][0] < spans[j][0] })
+
+	var buf bytes.Buffer
+	last, count := 0, 0
+	for i := 0; i < len(spans); {
+		start, end := spans[i][0], spans[i][1]
+		for i++; i < len(spans) && spans[i][0] < end; i++ {
+			if spans[i][1] > end {
+				end = spans[i][1]
+			}
+		}
+		buf.WriteString(s[last:start])
+		buf.WriteString(n.replacement(s[start:end]))
+		last = end
+		count++
+	}
+	buf.WriteString(s[last:])
+	return buf.String(), count
+}
+
+// maskFields returns the fields with the string fields masked and the number of replacements.
+// The fields are copied before they are modified.
+func (n *MaskNode) maskFields(fields models.Fields) (models.Fields, int) {
+	newFields := fields
+	total := 0
+	for _, name := range n.m.Fields {
+		s, ok := fields[name].(string)
+		if !ok {
+			continue
+		}
+		masked, count := n.mask(s)
+		if count == 0 {
+			continue
+		}
+		if total == 0 {
+			newFields = newFields.Copy()
+		}
+		total += count
+		newFields[name] = masked
+	}
+	return newFields, total
+}
+
+// maskTags returns the tags with the tags masked and the number of replacements.
+// The tags are copied before they are modified.
+func (n *MaskNode) maskTags(tags models.Tags) (models.Tags, int) {
+	newTags := tags
+	total := 0
+	for _, name := range n.m.Tags {
+		s, ok := tags[name]
+		if !ok {
+			continue
+		}
+		masked, count := n.mask(s)
+		if count == 0 {
+			continue
+		}
+		if total == 0 {
+			newTags = newTags.Copy()
+		}
+		total += count
+		newTags[name] = masked
+	}
+	return newTags, total
+}
+
+// apply masks the fields and tags of p.
+func (n *MaskNode) apply(p edge.FieldsTagsTimeSetter) {
+	fields, fieldCount := n.maskFields(p.Fields())
+	if fieldCount > 0 {
+		p.SetFields(fields)
+	}
+	tags, tagCount := n.maskTags(p.Tags())
+	if tagCount > 0 {
+		p.SetTags(tags)
+	}
+	n.redactions.Add(int64(fieldCount + tagCount))
+}
+
+func (n *MaskNode) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	// The tags of a batch are the dimension tags of its points,
+	// so they are masked without counting them as redactions.
+	tags, count := n.maskTags(begin.Tags())
+	if count == 0 {
+		return begin, nil
+	}
+	begin = begin.ShallowCopy()
+	begin.SetTags(tags)
+	return begin, nil
+}
+
+func (n *MaskNode) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	bp = bp.ShallowCopy()
+	n.apply(bp)
+	return bp, nil
+}
+
+func (n *MaskNode) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (n *MaskNode) Point(p edge.PointMessage) (edge.Message, error) {
+	p = p.ShallowCopy()
+	n.apply(p)
+	return p, nil
+}
+
+func (n *MaskNode) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (n *MaskNode) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (n *MaskNode) Done() {}
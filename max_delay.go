@@ -0,0 +1,108 @@
+package kapacitor
+
+import (
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+const (
+	statsLagBreaches = "lag_breaches"
+)
+
+type MaxDelayNode struct {
+	node
+	m *pipeline.MaxDelayNode
+
+	// now returns the current time the lag is relative to
+	now func() time.Time
+
+	lagBreaches *expvar.Int
+}
+
+// Create a new MaxDelayNode which computes the lag of each point behind the current time.
+func newMaxDelayNode(et *ExecutingTask, n *pipeline.MaxDelayNode, d NodeDiagnostic) (*MaxDelayNode, error) {
+	mn := &MaxDelayNode{
+		node:        node{Node: n, et: et, diag: d},
+		m:           n,
+		now:         time.Now,
+		lagBreaches: new(expvar.Int),
+	}
+	mn.node.runF = mn.runMaxDelay
+	return mn, nil
+}
+
+func (n *MaxDelayNode) runMaxDelay([]byte) error {
+	n.statMap.Set(statsLagBreaches, n.lagBreaches)
+	consumer := edge.NewConsumerWithReceiver(
+		n.ins[0],
+		edge.NewReceiverFromForwardReceiverWithStats(
+			n.outs,
+			edge.NewTimedForwardReceiver(n.timer, n),
+		),
+	)
+	return consumer.Consume()
+}
+
+// lagFields returns a copy of fields with the lag of a point with time t,
+// and whether the point should be kept.
+func (n *MaxDelayNode) lagFields(fields models.Fields, t time.Time) (models.Fields, bool) {
+	lag := n.now().Sub(t)
+	exceeded := n.m.MaxLag > 0 && lag > n.m.MaxLag
+	if exceeded {
+		n.lagBreaches.Add(1)
+	}
+	if n.m.BreachesOnlyFlag && !exceeded {
+		return nil, false
+	}
+	fields = fields.Copy()
+	fields[n.m.As] = float64(lag) / float64(n.m.Unit)
+	if n.m.MaxLag > 0 {
+		fields[n.m.ExceededAs] = exceeded
+	}
+	return fields, true
+}
+
+func (n *MaxDelayNode) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	if n.m.BreachesOnlyFlag {
+		// Points may be dropped, so the size of the batch is not known.
+		begin = begin.ShallowCopy()
+		begin.SetSizeHint(0)
+	}
+	return begin, nil
+}
+
+func (n *MaxDelayNode) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	fields, ok := n.lagFields(bp.Fields(), bp.Time())
+	if !ok {
+		return nil, nil
+	}
+	bp = bp.ShallowCopy()
+	bp.SetFields(fields)
+	return bp, nil
+}
+
+func (n *MaxDelayNode) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (n *MaxDelayNode) Point(p edge.PointMessage) (edge.Message, error) {
+	fields, ok := n.lagFields(p.Fields(), p.Time())
+	if !ok {
+		return nil, nil
+	}
+	p = p.ShallowCopy()
+	p.SetFields(fields)
+	return p, nil
+}
+
+func (n *MaxDelayNode) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (n *MaxDelayNode) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (n *MaxDelayNode) Done() {}
@@ -0,0 +1,178 @@
+package kapacitor
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/keyvalue"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+type MovingAverageNode struct {
+	node
+	m *pipeline.MovingAverageNode
+}
+
+// Create a new MovingAverageNode which averages a field over the last points of each group.
+func newMovingAverageNode(et *ExecutingTask, n *pipeline.MovingAverageNode, d NodeDiagnostic) (*MovingAverageNode, error) {
+	mn := &MovingAverageNode{
+		node: node{Node: n, et: et, diag: d},
+		m:    n,
+	}
+	mn.node.runF = mn.runMovingAverage
+	return mn, nil
+}
+
+func (n *MovingAverageNode) runMovingAverage([]byte) error {
+	consumer := edge.NewGroupedConsumer(
+		n.ins[0],
+		n,
+	)
+	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
+	return consumer.Consume()
+}
+
+func (n *MovingAverageNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	return edge.NewReceiverFromForwardReceiverWithStats(
+		n.outs,
+		edge.NewTimedForwardReceiver(n.timer, n.newGroup()),
+	), nil
+}
+
+func (n *MovingAverageNode) newGroup() *movingAverageGroup {
+	return &movingAverageGroup{
+		n:      n,
+		values: newMovingAverageBuffer(int(n.m.Size)),
+	}
+}
+
+type movingAverageGroup struct {
+	n      *MovingAverageNode
+	values *movingAverageBuffer
+}
+
+func (g *movingAverageGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	begin = begin.ShallowCopy()
+	begin.SetSizeHint(0)
+	g.values.reset()
+	return begin, nil
+}
+
+func (g *movingAverageGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	np := bp.ShallowCopy()
+	if g.doMovingAverage(bp, np) {
+		return np, nil
+	}
+	return nil, nil
+}
+
+func (g *movingAverageGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (g *movingAverageGroup) Point(p edge.PointMessage) (edge.Message, error) {
+	np := p.ShallowCopy()
+	if g.doMovingAverage(p, np) {
+		return np, nil
+	}
+	return nil, nil
+}
+
+// doMovingAverage adds the value of p to the buffer and sets the average on n.
+// Returns whether n should be emitted.
+func (g *movingAverageGroup) doMovingAverage(p edge.FieldsTagsTimeGetter, n edge.FieldsTagsTimeSetter) bool {
+	value, ok := numToFloat(p.Fields()[g.n.m.Field])
+	if !ok {
+		g.n.diag.Error("cannot compute moving average",
+			errors.New("field is the wrong type"),
+			keyvalue.KV("field", g.n.m.Field),
+			keyvalue.KV("type", fmt.Sprintf("%T", p.Fields()[g.n.m.Field])),
+		)
+		return false
+	}
+	g.values.add(value)
+	if int64(g.values.len()) < g.n.m.MinPoints {
+		return false
+	}
+
+	var avg float64
+	if g.n.m.Mode == pipeline.MovingAverageWeighted {
+		avg = g.values.weightedAverage()
+	} else {
+		avg = g.values.average()
+	}
+	fields := n.Fields().Copy()
+	fields[g.n.m.As] = avg
+	n.SetFields(fields)
+	return true
+}
+
+func (g *movingAverageGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (g *movingAverageGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	// Release the buffer, the group is no longer referenced by the consumer.
+	g.values = nil
+	return d, nil
+}
+func (g *movingAverageGroup) Done() {}
+
+// movingAverageBuffer is a ring buffer of the most recent values.
+type movingAverageBuffer struct {
+	values []float64
+	// index of the oldest value
+	start int
+	count int
+}
+
+func newMovingAverageBuffer(size int) *movingAverageBuffer {
+	return &movingAverageBuffer{
+		values: make([]float64, size),
+	}
+}
+
+// add adds v to the buffer replacing the oldest value if the buffer is full.
+func (b *movingAverageBuffer) add(v float64) {
+	if b.count < len(b.values) {
+		b.values[(b.start+b.count)%len(b.values)] = v
+		b.count++
+		return
+	}
+	b.values[b.start] = v
+	b.start = (b.start + 1) % len(b.values)
+}
+
+func (b *movingAverageBuffer) len() int {
+	return b.count
+}
+
+func (b *movingAverageBuffer) reset() {
+	b.start = 0
+	b.count = 0
+}
+
+// at returns the i-th oldest value.
+func (b *movingAverageBuffer) at(i int) float64 {
+	return b.values[(b.start+i)%len(b.values)]
+}
+
+// average returns the mean of the values.
+func (b *movingAverageBuffer) average() float64 {
+	sum := 0.0
+	for i := 0; i < b.count; i++ {
+		sum += b.at(i)
+	}
+	return sum / float64(b.count)
+}
+
+// weightedAverage returns the linearly weighted mean of the values,
+// where the oldest value has weight 1 and the most recent value has the highest weight.
+func (b *movingAverageBuffer) weightedAverage() float64 {
+	sum := 0.0
+	for i := 0; i < b.count; i++ {
+		sum += float64(i+1) * b.at(i)
+	}
+	weights := float64(b.count * (b.count + 1) / 2)
+	return sum / weights
+}
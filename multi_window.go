@@ -0,0 +1,236 @@
+package kapacitor
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/influxdata/influxdb/influxql"
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/keyvalue"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+type MultiWindowNode struct {
+	node
+	m *pipeline.MultiWindowNode
+
+	latePoints *expvar.Int
+}
+
+// Create a new MultiWindowNode which aggregates a field over windows of several sizes at once.
+func newMultiWindowNode(et *ExecutingTask, n *pipeline.MultiWindowNode, d NodeDiagnostic) (*MultiWindowNode, error) {
+	mn := &MultiWindowNode{
+		node:       node{Node: n, et: et, diag: d},
+		m:          n,
+		latePoints: new(expvar.Int),
+	}
+	mn.node.runF = mn.runMultiWindow
+	return mn, nil
+}
+
+func (n *MultiWindowNode) runMultiWindow([]byte) error {
+	n.statMap.Set(statsLatePoints, n.latePoints)
+	consumer := edge.NewGroupedConsumer(
+		n.ins[0],
+		n,
+	)
+	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
+	return consumer.Consume()
+}
+
+func (n *MultiWindowNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	return edge.NewReceiverFromForwardReceiverWithStats(
+		n.outs,
+		edge.NewTimedForwardReceiver(n.timer, n.newGroup(group)),
+	), nil
+}
+
+func (n *MultiWindowNode) newGroup(group edge.GroupInfo) *multiWindowGroup {
+	tags := make(models.Tags, len(group.Tags)+1)
+	for k, v := range group.Tags {
+		tags[k] = v
+	}
+	dims := models.Dimensions{
+		ByName:   group.Dimensions.ByName,
+		TagNames: append([]string(nil), group.Dimensions.TagNames...),
+	}
+	if _, ok := group.Tags[n.m.Tag]; !ok {
+		dims.TagNames = append(dims.TagNames, n.m.Tag)
+		sort.Strings(dims.TagNames)
+	}
+	g := &multiWindowGroup{
+		n:       n,
+		tags:    tags,
+		dims:    dims,
+		windows: make([]*multiWindow, len(n.m.Sizes)),
+	}
+	for i, size := range n.m.Sizes {
+		g.windows[i] = &multiWindow{
+			size: size,
+			tag:  influxql.FormatDuration(size),
+		}
+	}
+	return g
+}
+
+type multiWindowGroup struct {
+	n *MultiWindowNode
+
+	// tags and dims are the tags and dimensions of the emitted points, without the window tag.
+	tags models.Tags
+	dims models.Dimensions
+	// name of the last point
+	name    string
+	windows []*multiWindow
+}
+
+// multiWindow is the current window of a size.
+type multiWindow struct {
+	size time.Duration
+	// tag is the value of the window tag.
+	tag string
+
+	// start of the current window, valid if count is greater than zero.
+	start time.Time
+	// closed is the end of the last closed window.
+	closed time.Time
+
+	count                      int64
+	sum, min, max, first, last float64
+}
+
+// add adds a value to the window, it reports false if the point is older than the window.
+func (w *multiWindow) add(t time.Time, v float64) bool {
+	if w.count == 0 {
+		if t.Before(w.closed) {
+			return false
+		}
+		w.start = t.Truncate(w.size)
+		w.sum, w.min, w.max, w.first = 0, v, v, v
+	} else if t.Before(w.start) {
+		return false
+	}
+	w.count++
+	w.sum += v
+	if v < w.min {
+		w.min = v
+	}
+	if v > w.max {
+		w.max = v
+	}
+	w.last = v
+	return true
+}
+
+// value returns the aggregate of the window.
+func (w *multiWindow) value(aggregate string) interface{} {
+	switch aggregate {
+	case pipeline.MultiWindowCount:
+		return w.count
+	case pipeline.MultiWindowSum:
+		return w.sum
+	case pipeline.MultiWindowMin:
+		return w.min
+	case pipeline.MultiWindowMax:
+		return w.max
+	case pipeline.MultiWindowFirst:
+		return w.first
+	case pipeline.MultiWindowLast:
+		return w.last
+	default:
+		return w.sum / float64(w.count)
+	}
+}
+
+// closeWindows closes the windows ending at or before t, returning a point with the aggregate of each.
+func (g *multiWindowGroup) closeWindows(t time.Time) []edge.Message {
+	var points []edge.Message
+	for _, w := range g.windows {
+		if w.count == 0 {
+			continue
+		}
+		end := w.start.Add(w.size)
+		if t.Before(end) {
+			continue
+		}
+		tags := make(models.Tags, len(g.tags)+1)
+		for k, v := range g.tags {
+			tags[k] = v
+		}
+		tags[g.n.m.Tag] = w.tag
+		points = append(points, edge.NewPointMessage(
+			g.name,
+			"",
+			"",
+			g.dims,
+			models.Fields{g.n.m.AsField(): w.value(g.n.m.Aggregate)},
+			tags,
+			end,
+		))
+		w.closed = end
+		w.count = 0
+	}
+	return points
+}
+
+func (g *multiWindowGroup) forward(points []edge.Message) error {
+	for _, p := range points {
+		if err := edge.Forward(g.n.outs, p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *multiWindowGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	return nil, nil
+}
+
+func (g *multiWindowGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	return nil, nil
+}
+
+func (g *multiWindowGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return nil, nil
+}
+
+func (g *multiWindowGroup) Point(p edge.PointMessage) (edge.Message, error) {
+	if err := g.forward(g.closeWindows(p.Time())); err != nil {
+		return nil, err
+	}
+	g.name = p.Name()
+	var value float64
+	switch v := p.Fields()[g.n.m.Field].(type) {
+	case float64:
+		value = v
+	case int64:
+		value = float64(v)
+	default:
+		g.n.diag.Error("invalid field in multiWindow",
+			fmt.Errorf("expected numeric field %s, got %T", g.n.m.Field, v),
+			keyvalue.KV("field", g.n.m.Field))
+		return nil, nil
+	}
+	for _, w := range g.windows {
+		if !w.add(p.Time(), value) {
+			g.n.latePoints.Add(1)
+		}
+	}
+	return nil, nil
+}
+
+func (g *multiWindowGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	if err := g.forward(g.closeWindows(b.Time())); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (g *multiWindowGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+
+func (g *multiWindowGroup) Done() {}
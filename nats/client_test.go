@@ -0,0 +1,140 @@
+package nats_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/nats"
+	"github.com/influxdata/kapacitor/nats/natstest"
+)
+
+func newClient(t *testing.T, url string) *nats.Client {
+	c := nats.NewConfig()
+	c.URL = url
+	c.Timeout = time.Second
+	c.ReconnectWait = 0
+	cli, err := nats.NewClient(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cli
+}
+
+func TestClient_Publish(t *testing.T) {
+	s, err := natstest.NewServer(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	cli := newClient(t, s.URL)
+	defer cli.Close()
+	if err := cli.Publish("metrics.cpu", []byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	if err := cli.Publish("metrics.mem", []byte("hello\r\nworld")); err != nil {
+		t.Fatal(err)
+	}
+	if err := cli.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	got := s.Messages()
+	if exp := 2; len(got) != exp {
+		t.Fatalf("unexpected message count got %d exp %d", len(got), exp)
+	}
+	if got[0].Subject != "metrics.cpu" || string(got[0].Data) != "hello" {
+		t.Errorf("unexpected first message %+v", got[0])
+	}
+	if got[1].Subject != "metrics.mem" || string(got[1].Data) != "hello\r\nworld" {
+		t.Errorf("unexpected second message %+v", got[1])
+	}
+}
+
+func TestClient_PublishAck(t *testing.T) {
+	s, err := natstest.NewServer(map[string]string{"metrics.cpu": "METRICS"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	cli := newClient(t, s.URL)
+	defer cli.Close()
+	for i := uint64(1); i <= 2; i++ {
+		ack, err := cli.PublishAck("metrics.cpu", []byte("hello"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ack.Stream != "METRICS" || ack.Sequence != i {
+			t.Errorf("unexpected ack got %+v", ack)
+		}
+	}
+
+	if _, err := cli.PublishAck("metrics.mem", []byte("hello")); err == nil {
+		t.Error("expected error publishing to subject without a stream")
+	} else if !strings.Contains(err.Error(), "no responders") {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestClient_Reconnect(t *testing.T) {
+	s, err := natstest.NewServer(map[string]string{"metrics.cpu": "METRICS"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	cli := newClient(t, s.URL)
+	defer cli.Close()
+	if _, err := cli.PublishAck("metrics.cpu", []byte("a")); err != nil {
+		t.Fatal(err)
+	}
+	s.DropConnections()
+
+	// The client notices the lost connection asynchronously, retry until it reconnects.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		_, err := cli.PublishAck("metrics.cpu", []byte("b"))
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("client did not reconnect: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestClient_Closed(t *testing.T) {
+	s, err := natstest.NewServer(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	cli := newClient(t, s.URL)
+	cli.Close()
+	if err := cli.Publish("metrics", nil); err != nats.ErrClosed {
+		t.Errorf("unexpected error got %v exp %v", err, nats.ErrClosed)
+	}
+}
+
+func TestValidSubject(t *testing.T) {
+	testCases := map[string]bool{
+		"metrics":           true,
+		"metrics.cpu.host1": true,
+		"":                  false,
+		"metrics..cpu":      false,
+		".metrics":          false,
+		"metrics.":          false,
+		"metrics.*":         false,
+		"metrics.>":         false,
+		"metrics cpu":       false,
+	}
+	for subject, exp := range testCases {
+		if got := nats.ValidSubject(subject); got != exp {
+			t.Errorf("unexpected result for %q got %v exp %v", subject, got, exp)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+// Package natstest provides an embedded NATS server for tests.
+// It implements enough of the protocol to accept publishes and
+// acknowledge messages published to JetStream streams.
+package natstest
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Message is a message published to the server.
+type Message struct {
+	Subject string
+	Reply   string
+	Data    []byte
+}
+
+// Server is an embedded NATS server.
+type Server struct {
+	URL string
+
+	l  net.Listener
+	wg sync.WaitGroup
+
+	mu       sync.Mutex
+	streams  map[string]string
+	sequence map[string]uint64
+	messages []Message
+	conns    map[net.Conn]bool
+	closed   bool
+}
+
+// NewServer starts a server listening on a random local port.
+// Messages published with a reply subject to any of the subjects in streams
+// are acknowledged as if stored in the mapped JetStream stream.
+func NewServer(streams map[string]string) (*Server, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		URL:      "nats://" + l.Addr().String(),
+		l:        l,
+		streams:  streams,
+		sequence: make(map[string]uint64),
+		conns:    make(map[net.Conn]bool),
+	}
+	s.wg.Add(1)
+	go s.accept()
+	return s, nil
+}
+
+// Messages returns all messages published to the server.
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	messages := make([]Message, len(s.messages))
+	copy(messages, s.messages)
+	return messages
+}
+
+// DropConnections closes all client connections, the server keeps accepting new ones.
+func (s *Server) DropConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+}
+
+// Close stops the server.
+func (s *Server) Close() {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.closed = true
+	s.l.Close()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+func (s *Server) accept() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.l.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			conn.Close()
+			return
+		}
+		s.conns[conn] = true
+		s.mu.Unlock()
+		s.wg.Add(1)
+		go s.serve(conn)
+	}
+}
+
+func (s *Server) serve(conn net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	fmt.Fprintf(w, "INFO {\"server_id\":\"natstest\",\"version\":\"2.0.0\",\"max_payload\":1048576,\"jetstream\":true}\r\n")
+	if w.Flush() != nil {
+		return
+	}
+	// Reply subjects are delivered through the subscription with the matching prefix.
+	subs := make(map[string]string)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		args := strings.Fields(line)
+		if len(args) == 0 {
+			continue
+		}
+		switch strings.ToUpper(args[0]) {
+		case "CONNECT":
+		case "PING":
+			w.WriteString("PONG\r\n")
+		case "PONG":
+		case "SUB":
+			// SUB <subject> [queue group] <sid>
+			if len(args) < 3 {
+				w.WriteString("-ERR 'Invalid Subscription'\r\n")
+				break
+			}
+			subs[strings.TrimSuffix(args[1], "*")] = args[len(args)-1]
+		case "PUB":
+			// PUB <subject> [reply-to] <#bytes>
+			if len(args) < 3 || len(args) > 4 {
+				w.WriteString("-ERR 'Unknown Protocol Operation'\r\n")
+				w.Flush()
+				return
+			}
+			size, err := strconv.Atoi(args[len(args)-1])
+			if err != nil || size < 0 {
+				w.WriteString("-ERR 'Unknown Protocol Operation'\r\n")
+				w.Flush()
+				return
+			}
+			data := make([]byte, size+2)
+			if _, err := io.ReadFull(r, data); err != nil {
+				return
+			}
+			m := Message{
+				Subject: args[1],
+				Data:    data[:size],
+			}
+			if len(args) == 4 {
+				m.Reply = args[2]
+			}
+			ack := s.publish(m)
+			if ack == nil || m.Reply == "" {
+				break
+			}
+			for prefix, sid := range subs {
+				if strings.HasPrefix(m.Reply, prefix) {
+					fmt.Fprintf(w, "MSG %s %s %d\r\n%s\r\n", m.Reply, sid, len(ack), ack)
+					break
+				}
+			}
+		default:
+			w.WriteString("-ERR 'Unknown Protocol Operation'\r\n")
+		}
+		if w.Flush() != nil {
+			return
+		}
+	}
+}
+
+// publish records the message and returns the JetStream acknowledgement if any.
+func (s *Server) publish(m Message) []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = append(s.messages, m)
+	stream, ok := s.streams[m.Subject]
+	if !ok {
+		if m.Reply == "" || len(s.streams) == 0 {
+			return nil
+		}
+		data, _ := json.Marshal(map[string]interface{}{
+			"error": map[string]interface{}{
+				"code":        503,
+				"description": "no responders available for request",
+			},
+		})
+		return data
+	}
+	s.sequence[stream]++
+	data, _ := json.Marshal(map[string]interface{}{
+		"stream": stream,
+		"seq":    s.sequence[stream],
+	})
+	return data
+}
@@ -0,0 +1,197 @@
+package kapacitor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"text/template"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/influxdb"
+	"github.com/influxdata/kapacitor/keyvalue"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/nats"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+const (
+	statsNatsPublished     = "published"
+	statsNatsPublishErrors = "publish_errors"
+	statsNatsAckErrors     = "ack_errors"
+)
+
+type NatsOutNode struct {
+	node
+	o *pipeline.NatsOutNode
+
+	subject *template.Template
+	client  *nats.Client
+	// name of the current batch
+	batchName string
+
+	published     *expvar.Int
+	publishErrors *expvar.Int
+	ackErrors     *expvar.Int
+}
+
+// Create a new NatsOutNode which publishes each point to a NATS subject.
+func newNatsOutNode(et *ExecutingTask, n *pipeline.NatsOutNode, d NodeDiagnostic) (*NatsOutNode, error) {
+	tmpl, err := template.New("subject").Parse(n.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("invalid subject template: %v", err)
+	}
+	c := nats.NewConfig()
+	c.URL = n.URL
+	c.Name = "kapacitor-" + et.Task.ID
+	c.Timeout = n.Timeout
+	client, err := nats.NewClient(c)
+	if err != nil {
+		return nil, err
+	}
+	nn := &NatsOutNode{
+		node:          node{Node: n, et: et, diag: d},
+		o:             n,
+		subject:       tmpl,
+		client:        client,
+		published:     new(expvar.Int),
+		publishErrors: new(expvar.Int),
+		ackErrors:     new(expvar.Int),
+	}
+	nn.node.runF = nn.runNatsOut
+	return nn, nil
+}
+
+func (n *NatsOutNode) runNatsOut([]byte) error {
+	n.statMap.Set(statsNatsPublished, n.published)
+	n.statMap.Set(statsNatsPublishErrors, n.publishErrors)
+	n.statMap.Set(statsNatsAckErrors, n.ackErrors)
+	defer n.client.Close()
+
+	consumer := edge.NewConsumerWithReceiver(
+		n.ins[0],
+		edge.NewReceiverFromForwardReceiverWithStats(
+			n.outs,
+			edge.NewTimedForwardReceiver(n.timer, n),
+		),
+	)
+	err := consumer.Consume()
+	// Wait for the server to receive the published messages before closing the connection.
+	if ferr := n.client.Flush(); ferr != nil {
+		n.diag.Error("failed to flush messages", ferr)
+	}
+	return err
+}
+
+// natsMessage is the JSON format of a published point.
+type natsMessage struct {
+	Name            string        `json:"name"`
+	Database        string        `json:"database,omitempty"`
+	RetentionPolicy string        `json:"retentionPolicy,omitempty"`
+	Time            time.Time     `json:"time"`
+	Tags            models.Tags   `json:"tags"`
+	Fields          models.Fields `json:"fields"`
+}
+
+// publish publishes a single point, errors are logged and the point is passed on regardless.
+func (n *NatsOutNode) publish(name, db, rp string, t time.Time, tags models.Tags, fields models.Fields) {
+	subject, err := n.renderSubject(name, tags)
+	if err != nil {
+		n.publishErrors.Add(1)
+		n.diag.Error("failed to render subject", err)
+		return
+	}
+	var data []byte
+	switch n.o.Format {
+	case pipeline.NatsOutFormatLine:
+		data = influxdb.Point{
+			Name:   name,
+			Tags:   tags,
+			Fields: fields,
+			Time:   t,
+		}.Bytes("ns")
+	default:
+		data, err = json.Marshal(natsMessage{
+			Name:            name,
+			Database:        db,
+			RetentionPolicy: rp,
+			Time:            t,
+			Tags:            tags,
+			Fields:          fields,
+		})
+		if err != nil {
+			n.publishErrors.Add(1)
+			n.diag.Error("failed to encode message", err)
+			return
+		}
+	}
+
+	if !n.o.UseJetStream {
+		if err := n.client.Publish(subject, data); err != nil {
+			n.publishErrors.Add(1)
+			n.diag.Error("failed to publish message", err, keyvalue.KV("subject", subject))
+			return
+		}
+		n.published.Add(1)
+		return
+	}
+	if _, err := n.client.PublishAck(subject, data); err != nil {
+		// Without an acknowledgement the message may or may not have reached the stream.
+		n.ackErrors.Add(1)
+		n.diag.Error("failed to publish message to JetStream", err, keyvalue.KV("subject", subject))
+		return
+	}
+	n.published.Add(1)
+}
+
+// renderSubject executes the subject template for a point.
+func (n *NatsOutNode) renderSubject(name string, tags models.Tags) (string, error) {
+	var buf bytes.Buffer
+	info := struct {
+		Name     string
+		TaskName string
+		Tags     models.Tags
+	}{
+		Name:     name,
+		TaskName: n.et.Task.ID,
+		Tags:     tags,
+	}
+	if err := n.subject.Execute(&buf, info); err != nil {
+		return "", err
+	}
+	subject := buf.String()
+	if !nats.ValidSubject(subject) {
+		return "", fmt.Errorf("invalid subject %q", subject)
+	}
+	return subject, nil
+}
+
+func (n *NatsOutNode) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	n.batchName = begin.Name()
+	return begin, nil
+}
+
+func (n *NatsOutNode) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	n.publish(n.batchName, "", "", bp.Time(), bp.Tags(), bp.Fields())
+	return bp, nil
+}
+
+func (n *NatsOutNode) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (n *NatsOutNode) Point(p edge.PointMessage) (edge.Message, error) {
+	n.publish(p.Name(), p.Database(), p.RetentionPolicy(), p.Time(), p.Tags(), p.Fields())
+	return p, nil
+}
+
+func (n *NatsOutNode) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+
+func (n *NatsOutNode) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+
+func (n *NatsOutNode) Done() {}
@@ -38,6 +38,9 @@ type NodeDiagnostic interface {
 	// QueryNode
 	StartingBatchQuery(q string)
 
+	// InfluxDBOutNode
+	SwitchingCluster(from, to string)
+
 	// LogNode
 	LogPointData(key, prefix string, data edge.PointMessage)
 	LogBatchData(key, prefix string, data edge.BufferedBatchMessage)
@@ -104,6 +107,15 @@ type Node interface {
 
 	incrementErrorCount()
 
+	// set the queue receiving the points the node fails to process
+	setDeadLetterQueue(q DeadLetterQueue)
+
+	// recover the panics of the node, failing it once more than limit panics are recovered within window
+	setPanicLimit(limit int64, window time.Duration)
+
+	// process the groups of the node on count worker goroutines
+	setWorkers(count int)
+
 	stats() map[string]interface{}
 }
 
@@ -129,6 +141,16 @@ type node struct {
 	quiet bool
 
 	nodeErrors *kexpvar.Int
+
+	dlq         DeadLetterQueue
+	deadLetters *kexpvar.Int
+
+	panicsRecovered *kexpvar.Int
+	// panics recovers the panics of the node, it wraps the timer of the node.
+	panics *panicCircuit
+
+	// number of worker goroutines processing the groups, see newGroupedConsumer
+	workers int
 }
 
 func (n *node) addParentEdge(e edge.StatsEdge) {
@@ -160,6 +182,58 @@ func (n *node) init(quiet bool) {
 	n.quiet = quiet
 }
 
+func (n *node) setWorkers(count int) {
+	n.workers = count
+	// The timer cannot time the workers concurrently.
+	n.setTimer(timer.NewNoOp())
+}
+
+// setTimer replaces the timer of the node, keeping the recovery of its panics.
+func (n *node) setTimer(t timer.Timer) {
+	if n.panics != nil {
+		n.panics.Timer = t
+		return
+	}
+	n.timer = t
+}
+
+// newGroupedConsumer creates a grouped consumer of the first parent edge of the node,
+// processing the groups on the workers of the node if it has more than one.
+func (n *node) newGroupedConsumer(r edge.GroupedReceiver) edge.GroupedConsumer {
+	if n.workers > 1 {
+		return edge.NewShardedGroupedConsumer(n.ins[0], r, n.workers)
+	}
+	return edge.NewGroupedConsumer(n.ins[0], r)
+}
+
+func (n *node) setDeadLetterQueue(q DeadLetterQueue) {
+	n.dlq = q
+	n.deadLetters = &kexpvar.Int{}
+	n.statMap.Set(statDeadLetters, n.deadLetters)
+}
+
+// deadLetter sends a point the node failed to process to the dead letter queue of the node, if any.
+func (n *node) deadLetter(name string, p edge.FieldsTagsTimeGetter, err error) {
+	if n.dlq == nil {
+		return
+	}
+	l := DeadLetter{
+		Time:      time.Now().UTC(),
+		Task:      n.et.Task.ID,
+		Node:      n.Name(),
+		Error:     err.Error(),
+		Name:      name,
+		Tags:      p.Tags(),
+		Fields:    p.Fields(),
+		PointTime: p.Time(),
+	}
+	if err := n.dlq.Add(l); err != nil {
+		n.diag.Error("failed to send point to dead letter queue", err)
+		return
+	}
+	n.deadLetters.Add(1)
+}
+
 func (n *node) start(snapshot []byte) {
 	go func() {
 		var err error
@@ -220,11 +294,19 @@ func (n *node) addChild(c Node) (edge.StatsEdge, error) {
 	n.children = append(n.children, c)
 
 	d := n.et.tm.diag.WithEdgeContext(n.et.Task.ID, n.Name(), c.Name())
-	edge := newEdge(n.et.Task.ID, n.Name(), c.Name(), n.Provides(), defaultEdgeBufferSize, d)
+	edge := newEdge(n.et.Task.ID, n.Name(), c.Name(), n.Provides(), defaultEdgeBufferSize, backpressurePolicy(c.InputBackpressure()), d)
 	if edge == nil {
 		return nil, fmt.Errorf("unknown edge type %s", n.Provides())
 	}
-	c.addParentEdge(edge)
+	in := edge
+	if lateness, size := c.ReorderWindow(); lateness > 0 {
+		in = newReorderEdge(in, lateness, int(size))
+	}
+	if c.IsOrderAsserted() {
+		// Check the order after reordering, as seen by the child.
+		in = newOrderCheckEdge(edge, in, d)
+	}
+	c.addParentEdge(in)
 	return edge, nil
 }
 
@@ -357,6 +439,8 @@ func (n *node) stats() map[string]interface{} {
 			stats[kv.Key] = v.IntValue()
 		case kexpvar.FloatVar:
 			stats[kv.Key] = v.FloatValue()
+		case kexpvar.StringVar:
+			stats[kv.Key] = v.StringValue()
 		default:
 			stats[kv.Key] = v.String()
 		}
@@ -0,0 +1,198 @@
+package kapacitor
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/keyvalue"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+type NormalizeNode struct {
+	node
+	m *pipeline.NormalizeNode
+}
+
+// Create a new NormalizeNode which scales a field relative to the recent values of each group.
+func newNormalizeNode(et *ExecutingTask, n *pipeline.NormalizeNode, d NodeDiagnostic) (*NormalizeNode, error) {
+	nn := &NormalizeNode{
+		node: node{Node: n, et: et, diag: d},
+		m:    n,
+	}
+	nn.node.runF = nn.runNormalize
+	return nn, nil
+}
+
+func (n *NormalizeNode) runNormalize([]byte) error {
+	consumer := edge.NewGroupedConsumer(
+		n.ins[0],
+		n,
+	)
+	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
+	return consumer.Consume()
+}
+
+func (n *NormalizeNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	return edge.NewReceiverFromForwardReceiverWithStats(
+		n.outs,
+		edge.NewTimedForwardReceiver(n.timer, n.newGroup()),
+	), nil
+}
+
+func (n *NormalizeNode) newGroup() *normalizeGroup {
+	return &normalizeGroup{
+		n:      n,
+		window: newNormalizeWindow(int(n.m.Size), n.m.Period),
+	}
+}
+
+type normalizeGroup struct {
+	n      *NormalizeNode
+	window *normalizeWindow
+}
+
+func (g *normalizeGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	begin = begin.ShallowCopy()
+	begin.SetSizeHint(0)
+	g.window.reset()
+	return begin, nil
+}
+
+func (g *normalizeGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	np := bp.ShallowCopy()
+	if g.doNormalize(bp, np) {
+		return np, nil
+	}
+	return nil, nil
+}
+
+func (g *normalizeGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (g *normalizeGroup) Point(p edge.PointMessage) (edge.Message, error) {
+	np := p.ShallowCopy()
+	if g.doNormalize(p, np) {
+		return np, nil
+	}
+	return nil, nil
+}
+
+// doNormalize adds the value of p to the window and sets the scaled value on n.
+// Returns whether n should be emitted.
+func (g *normalizeGroup) doNormalize(p edge.FieldsTagsTimeGetter, n edge.FieldsTagsTimeSetter) bool {
+	value, ok := numToFloat(p.Fields()[g.n.m.Field])
+	if !ok {
+		g.n.diag.Error("cannot normalize value",
+			errors.New("field is the wrong type"),
+			keyvalue.KV("field", g.n.m.Field),
+			keyvalue.KV("type", fmt.Sprintf("%T", p.Fields()[g.n.m.Field])),
+		)
+		return false
+	}
+	g.window.add(p.Time(), value)
+
+	var scaled float64
+	if g.n.m.Method == pipeline.NormalizeZScore {
+		scaled = g.window.zScore(value)
+	} else {
+		scaled = g.window.minMax(value)
+	}
+	fields := n.Fields().Copy()
+	fields[g.n.m.As] = scaled
+	n.SetFields(fields)
+	return true
+}
+
+func (g *normalizeGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (g *normalizeGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	// Release the window, the group is no longer referenced by the consumer.
+	g.window = nil
+	return d, nil
+}
+func (g *normalizeGroup) Done() {}
+
+// normalizeWindow holds the values of the last size points and/or the points within the last period.
+type normalizeWindow struct {
+	size   int
+	period time.Duration
+	times  []time.Time
+	values []float64
+}
+
+func newNormalizeWindow(size int, period time.Duration) *normalizeWindow {
+	return &normalizeWindow{
+		size:   size,
+		period: period,
+	}
+}
+
+// add adds v at time t to the window and removes the values that no longer fit in the window.
+func (w *normalizeWindow) add(t time.Time, v float64) {
+	w.times = append(w.times, t)
+	w.values = append(w.values, v)
+	start := 0
+	if w.size > 0 && len(w.values) > w.size {
+		start = len(w.values) - w.size
+	}
+	if w.period > 0 {
+		oldest := t.Add(-w.period)
+		for start < len(w.times)-1 && !w.times[start].After(oldest) {
+			start++
+		}
+	}
+	if start > 0 {
+		// Copy the remaining values so the arrays do not grow without bound.
+		w.times = append(w.times[:0], w.times[start:]...)
+		w.values = append(w.values[:0], w.values[start:]...)
+	}
+}
+
+func (w *normalizeWindow) reset() {
+	w.times = w.times[:0]
+	w.values = w.values[:0]
+}
+
+// minMax returns v scaled to [0,1] relative to the minimum and maximum of the window,
+// or 0.5 if all values are equal.
+func (w *normalizeWindow) minMax(v float64) float64 {
+	min, max := math.Inf(1), math.Inf(-1)
+	for _, x := range w.values {
+		min = math.Min(min, x)
+		max = math.Max(max, x)
+	}
+	if max == min {
+		return 0.5
+	}
+	return (v - min) / (max - min)
+}
+
+// zScore returns the number of standard deviations v is from the mean of the window,
+// or 0 if all values are equal.
+func (w *normalizeWindow) zScore(v float64) float64 {
+	mean, stddev := w.meanStddev()
+	if stddev == 0 {
+		return 0
+	}
+	return (v - mean) / stddev
+}
+
+// meanStddev returns the mean and population standard deviation of the window.
+func (w *normalizeWindow) meanStddev() (float64, float64) {
+	mean := 0.0
+	for _, x := range w.values {
+		mean += x
+	}
+	mean /= float64(len(w.values))
+	variance := 0.0
+	for _, x := range w.values {
+		variance += (x - mean) * (x - mean)
+	}
+	variance /= float64(len(w.values))
+	return mean, math.Sqrt(variance)
+}
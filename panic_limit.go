@@ -0,0 +1,67 @@
+package kapacitor
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+
+	kexpvar "github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/keyvalue"
+	"github.com/influxdata/kapacitor/timer"
+)
+
+const (
+	statPanicsRecovered = "panics_recovered"
+)
+
+// panicCircuit is the timer of a node that recovers the panics of the node while it processes a message.
+// It fails the node once more than limit panics are recovered within the window.
+type panicCircuit struct {
+	timer.Timer
+	diag   NodeDiagnostic
+	limit  int64
+	window time.Duration
+	now    func() time.Time
+
+	recovered *kexpvar.Int
+
+	mu sync.Mutex
+	// times of the panics recovered within the window, oldest first.
+	times []time.Time
+}
+
+func (n *node) setPanicLimit(limit int64, window time.Duration) {
+	n.panicsRecovered = &kexpvar.Int{}
+	n.statMap.Set(statPanicsRecovered, n.panicsRecovered)
+	n.panics = &panicCircuit{
+		Timer:     n.timer,
+		diag:      n.diag,
+		limit:     limit,
+		window:    window,
+		now:       time.Now,
+		recovered: n.panicsRecovered,
+	}
+	n.timer = n.panics
+}
+
+// RecoverPanic logs a recovered panic, or returns an error if there were too many panics within the window.
+func (c *panicCircuit) RecoverPanic(v interface{}) error {
+	trace := make([]byte, 4096)
+	trace = trace[:runtime.Stack(trace, false)]
+	c.recovered.Add(1)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	i := 0
+	for i < len(c.times) && !c.times[i].After(now.Add(-c.window)) {
+		i++
+	}
+	c.times = append(c.times[i:], now)
+	if int64(len(c.times)) > c.limit {
+		return fmt.Errorf("too many panics, %d panics within %v, last panic: %v", len(c.times), c.window, v)
+	}
+	c.diag.Error("recovered panic", fmt.Errorf("%v", v), keyvalue.KV("trace", string(trace)))
+	return nil
+}
@@ -0,0 +1,56 @@
+package kapacitor
+
+import (
+	"testing"
+	"time"
+
+	kexpvar "github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/timer"
+)
+
+func TestPanicCircuit_Window(t *testing.T) {
+	now := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := &panicCircuit{
+		Timer:     timer.NewNoOp(),
+		diag:      &windowNodeDiagnostic{},
+		limit:     2,
+		window:    time.Minute,
+		now:       func() time.Time { return now },
+		recovered: new(kexpvar.Int),
+	}
+	// The panics are spread out so that no more than two are within a minute.
+	for i, d := range []time.Duration{0, 30 * time.Second, 31 * time.Second, 30 * time.Second, 31 * time.Second} {
+		now = now.Add(d)
+		if err := c.RecoverPanic("malformed point"); err != nil {
+			t.Fatalf("unexpected error for panic %d: %v", i, err)
+		}
+	}
+	now = now.Add(time.Second)
+	if err := c.RecoverPanic("malformed point"); err == nil {
+		t.Error("expected an error for the third panic within a minute")
+	}
+	if got, exp := c.recovered.IntValue(), int64(6); got != exp {
+		t.Errorf("unexpected panics_recovered: got %d exp %d", got, exp)
+	}
+}
+
+func TestNode_PanicLimitWorkers(t *testing.T) {
+	// The panics are recovered whichever of the panic limit and the workers is set first.
+	for _, workersFirst := range []bool{false, true} {
+		n := &node{
+			diag:    &windowNodeDiagnostic{},
+			timer:   timer.NewNoOp(),
+			statMap: new(kexpvar.Map).Init(),
+		}
+		if workersFirst {
+			n.setWorkers(4)
+			n.setPanicLimit(10, time.Minute)
+		} else {
+			n.setPanicLimit(10, time.Minute)
+			n.setWorkers(4)
+		}
+		if _, ok := n.timer.(*panicCircuit); !ok {
+			t.Errorf("workers first %v: panics are not recovered, timer is %T", workersFirst, n.timer)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package kapacitor
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+const (
+	statsWindowsDropped = "windows_dropped"
+)
+
+type PercentilesNode struct {
+	node
+	p *pipeline.PercentilesNode
+
+	// names of the output field for each percentile
+	fieldNames []string
+
+	windowsDropped *expvar.Int
+}
+
+// Create a new PercentilesNode which computes exact percentiles over each window of data.
+func newPercentilesNode(et *ExecutingTask, n *pipeline.PercentilesNode, d NodeDiagnostic) (*PercentilesNode, error) {
+	pn := &PercentilesNode{
+		node:           node{Node: n, et: et, diag: d},
+		p:              n,
+		fieldNames:     make([]string, len(n.Ranks)),
+		windowsDropped: new(expvar.Int),
+	}
+	for i, p := range n.Ranks {
+		pn.fieldNames[i] = n.As + strings.Replace(strconv.FormatFloat(p, 'f', -1, 64), ".", "_", 1)
+	}
+	pn.node.runF = pn.runPercentiles
+	return pn, nil
+}
+
+func (n *PercentilesNode) runPercentiles([]byte) error {
+	n.statMap.Set(statsWindowsDropped, n.windowsDropped)
+	consumer := edge.NewGroupedConsumer(
+		n.ins[0],
+		n,
+	)
+	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
+	return consumer.Consume()
+}
+
+func (n *PercentilesNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	g := &percentilesGroup{
+		n:     n,
+		name:  first.Name(),
+		group: group,
+		rand:  rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	return edge.NewReceiverFromForwardReceiverWithStats(
+		n.outs,
+		edge.NewTimedForwardReceiver(n.timer, g),
+	), nil
+}
+
+type percentilesGroup struct {
+	n     *PercentilesNode
+	name  string
+	group edge.GroupInfo
+	rand  *rand.Rand
+
+	// time of the current batch
+	batchTime time.Time
+
+	// values of the current window
+	values []float64
+	// count of all values seen in the current window, including those not kept
+	count      int64
+	overflowed bool
+}
+
+func (g *percentilesGroup) add(fields models.Fields) {
+	v, ok := fields[g.n.p.Field]
+	if !ok {
+		return
+	}
+	var value float64
+	switch f := v.(type) {
+	case float64:
+		value = f
+	case int64:
+		value = float64(f)
+	default:
+		g.n.diag.Error("cannot compute percentiles", fmt.Errorf("field %q has unsupported type %T", g.n.p.Field, v))
+		return
+	}
+	g.count++
+	max := g.n.p.MaxWindowSize
+	switch {
+	case max == 0 || int64(len(g.values)) < max:
+		g.values = append(g.values, value)
+	case g.n.p.SampleOverflowFlag:
+		// Reservoir sampling keeps a uniform sample of all values seen.
+		if j := g.rand.Int63n(g.count); j < max {
+			g.values[j] = value
+		}
+	default:
+		g.overflowed = true
+	}
+}
+
+func (g *percentilesGroup) reset() {
+	g.values = g.values[:0]
+	g.count = 0
+	g.overflowed = false
+}
+
+// emit returns a point containing the percentiles of the current window and resets the window.
+// A nil message is returned if the window is empty or was dropped.
+func (g *percentilesGroup) emit(t time.Time) edge.Message {
+	defer g.reset()
+	if g.overflowed {
+		g.n.windowsDropped.Add(1)
+		g.n.diag.Error("dropping window", fmt.Errorf("window exceeds max window size of %d points", g.n.p.MaxWindowSize))
+		return nil
+	}
+	if len(g.values) == 0 {
+		return nil
+	}
+	sort.Float64s(g.values)
+	fields := make(models.Fields, len(g.n.p.Ranks))
+	for i, p := range g.n.p.Ranks {
+		fields[g.n.fieldNames[i]] = percentile(g.values, p)
+	}
+	return edge.NewPointMessage(
+		g.name, "", "",
+		g.group.Dimensions,
+		fields,
+		g.group.Tags,
+		t,
+	)
+}
+
+// percentile computes the p-th percentile of the sorted values,
+// interpolating linearly between the closest ranks.
+func percentile(sorted []float64, p float64) float64 {
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := math.Floor(rank)
+	i := int(lower)
+	if i+1 >= len(sorted) {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[i] + (rank-lower)*(sorted[i+1]-sorted[i])
+}
+
+func (g *percentilesGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	g.name = begin.Name()
+	g.batchTime = begin.Time()
+	g.reset()
+	return nil, nil
+}
+
+func (g *percentilesGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	g.add(bp.Fields())
+	return nil, nil
+}
+
+func (g *percentilesGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return g.emit(g.batchTime), nil
+}
+
+func (g *percentilesGroup) Point(p edge.PointMessage) (edge.Message, error) {
+	g.name = p.Name()
+	g.add(p.Fields())
+	return nil, nil
+}
+
+// Barrier closes the current window, forwarding the percentiles of the window if any before the barrier.
+func (g *percentilesGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	if m := g.emit(b.Time()); m != nil {
+		if err := edge.Forward(g.n.outs, m); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+func (g *percentilesGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	g.values = nil
+	g.count = 0
+	g.overflowed = false
+	return d, nil
+}
+
+func (g *percentilesGroup) Done() {}
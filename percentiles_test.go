@@ -0,0 +1,36 @@
+package kapacitor
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPercentile(t *testing.T) {
+	sequence := make([]float64, 100)
+	for i := range sequence {
+		sequence[i] = float64(i + 1)
+	}
+	testCases := []struct {
+		name       string
+		values     []float64
+		percentile float64
+		exp        float64
+	}{
+		{name: "single value", values: []float64{42}, percentile: 99, exp: 42},
+		{name: "minimum", values: []float64{15, 20, 35, 40, 50}, percentile: 0, exp: 15},
+		{name: "maximum", values: []float64{15, 20, 35, 40, 50}, percentile: 100, exp: 50},
+		{name: "median odd", values: []float64{15, 20, 35, 40, 50}, percentile: 50, exp: 35},
+		{name: "interpolated", values: []float64{15, 20, 35, 40, 50}, percentile: 40, exp: 29},
+		{name: "median even", values: []float64{1, 2, 3, 4}, percentile: 50, exp: 2.5},
+		{name: "sequence median", values: sequence, percentile: 50, exp: 50.5},
+		{name: "sequence p90", values: sequence, percentile: 90, exp: 90.1},
+		{name: "sequence p99", values: sequence, percentile: 99, exp: 99.01},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := percentile(tc.values, tc.percentile); math.Abs(got-tc.exp) > 1e-9 {
+				t.Errorf("unexpected percentile: got %v exp %v", got, tc.exp)
+			}
+		})
+	}
+}
@@ -23,6 +23,12 @@ const defaultMessageTmpl = "{{ .ID }} is {{ .Level }}"
 // Default template for constructing a details message.
 const defaultDetailsTmpl = "{{ json . }}"
 
+// Default number of events queued for a handler with limited concurrency.
+const DefaultHandlerQueueSize = 1000
+
+// Default interval between attempts to redeliver events.
+const defaultDurableRetryInterval = 30 * time.Second
+
 // AlertNode struct wraps the default AlertNodeData
 // tick:wraps:AlertNodeData
 type AlertNode struct{ *AlertNodeData }
@@ -55,6 +61,7 @@ type AlertNode struct{ *AlertNodeData }
 //    * Talk -- Post alert message to Talk client.
 //    * Telegram -- Post alert message to Telegram client.
 //    * MQTT -- Post alert message to MQTT.
+//    * OpenTelemetry -- Export alert as an OpenTelemetry log record.
 //
 // See below for more details on configuring each handler.
 //
@@ -117,6 +124,19 @@ type AlertNode struct{ *AlertNodeData }
 // The corresponding alert states are:
 //     INFO WARNING WARNING CRITICAL INFO INFO OK
 //
+// An active alert can be acknowledged via the HTTP API of the task,
+// which suppresses further events for the alert until it recovers.
+// Each alert node exposes the endpoint `/kapacitor/v1/tasks/<task_id>/<node_name>/ack`,
+// e.g. `/kapacitor/v1/tasks/cpu_alert/alert2/ack`.
+// A POST request to the endpoint acknowledges the alert of a group:
+//
+//     {"group": "host=serverA", "level": "CRITICAL"}
+//
+// The group is the group ID of the alert, `nil` if the data is not grouped.
+// The level is optional, if set the request fails unless the alert is at the given level.
+// An acknowledgement is cleared once the alert recovers, escalates above the acknowledged level
+// or the AckTimeout elapses, at which point events are sent again.
+//
 // Available Statistics:
 //
 //    * alerts_triggered -- Total number of alerts triggered
@@ -124,6 +144,8 @@ type AlertNode struct{ *AlertNodeData }
 //    * infos_triggered -- Number of Info alerts triggered
 //    * warns_triggered -- Number of Warn alerts triggered
 //    * crits_triggered -- Number of Crit alerts triggered
+//    * alerts_acknowledged -- Number of events suppressed because the alert was acknowledged
+//    * handler_events_dropped -- Number of events dropped because the queue of a handler with limited concurrency was full
 //
 type AlertNodeData struct {
 	chainnode
@@ -196,6 +218,9 @@ type AlertNodeData struct {
 	//    * Fields -- Map of fields. Use '{{ index .Fields "key" }}' to get a specific field value.
 	//    * Time -- The time of the point that triggered the event.
 	//    * Duration -- The duration of the alert.
+	//    * History -- The most recent values of the field set with the AlertNode.ValueHistory property,
+	//        oldest first and including the value of the point that triggered the event.
+	//        Empty unless the property is set.
 	//
 	// Example:
 	//   stream
@@ -269,6 +294,37 @@ type AlertNodeData struct {
 	// Default: 21
 	History int64 `json:"history"`
 
+	// Field whose most recent values are available to the message and details templates as History.
+	// tick:ignore
+	ValueHistoryField string `tick:"ValueHistory" json:"valueHistoryField"`
+	// Number of recent values kept for each group.
+	// tick:ignore
+	ValueHistoryCount int64 `json:"valueHistoryCount"`
+
+	// Field whose rate of change is available to the level expressions as "roc", see Roc.
+	// tick:ignore
+	RocField string `tick:"Roc" json:"rocField"`
+	// Lookback of the rate of change, zero compares with the previous point.
+	// tick:ignore
+	RocLookback time.Duration `json:"rocLookback"`
+
+	// Field whose rolling percentiles are available to the level expressions, see RollingPercentiles.
+	// tick:ignore
+	PercentilesField string `tick:"RollingPercentiles" json:"percentilesField,omitempty"`
+	// Window of the values of the rolling percentiles.
+	// tick:ignore
+	PercentilesWindow time.Duration `json:"percentilesWindow,omitempty"`
+	// Rolling percentiles to compute, between 0 and 100 exclusive.
+	// tick:ignore
+	PercentilesRanks []float64 `json:"percentiles,omitempty"`
+
+	// Field whose aggregates over each batch are evaluated by the level expressions, see BatchContext.
+	// tick:ignore
+	BatchContextField string `tick:"BatchContext" json:"batchContextField,omitempty"`
+	// Values of the batch context field greater than the threshold are counted as over.
+	// tick:ignore
+	BatchContextThreshold float64 `json:"batchContextThreshold,omitempty"`
+
 	// Optional tag key to use when tagging the data with the alert level.
 	LevelTag string `json:"levelTag"`
 	// Optional field key to add to the data, containing the alert level as a string.
@@ -302,10 +358,55 @@ type AlertNodeData struct {
 	// tick:ignore
 	StateChangesOnlyDuration time.Duration `json:"stateChangesOnlyDuration"`
 
+	// Maximum duration an acknowledgement suppresses events.
+	// If zero, acknowledgements only expire once the alert recovers or escalates.
+	AckTimeout time.Duration `json:"ackTimeout"`
+
+	// Interval at which a single summary of all alerting groups is sent to the handlers,
+	// instead of an event per group.
+	// If zero, events are sent for each group.
+	//
+	// The summary contains the latest event of each group that is alerting or has recovered since the last summary,
+	// its level is the highest level of the groups and its data contains a series per group.
+	// Recovered groups are included in one summary only.
+	// No summary is sent if no group is alerting.
+	//
+	// Example:
+	//    stream
+	//        |from()
+	//            .measurement('cpu')
+	//            .groupBy('host')
+	//        |alert()
+	//            .crit(lambda: "usage_idle" < 10)
+	//            .summaryInterval(5m)
+	//            .slack()
+	//
+	// The above example sends at most one Slack message every 5 minutes, listing all hosts that are critical.
+	SummaryInterval time.Duration `json:"summaryInterval"`
+
+	// Maximum age of events that failed to be delivered and are retried, see DurableRetry.
+	// tick:ignore
+	DurableRetryMaxAge time.Duration `tick:"DurableRetry" json:"durableRetryMaxAge"`
+	// Interval between attempts to redeliver events.
+	// tick:ignore
+	DurableRetryInterval time.Duration `json:"durableRetryInterval"`
+
+	// Whether the state of the groups is saved in the task snapshots and restored when the task restarts.
+	// tick:ignore
+	PersistStateFlag bool `tick:"PersistState" json:"persistState,omitempty"`
+
+	// Tags the identity of an alert is computed from instead of the group, see IdTags.
+	// tick:ignore
+	IDTags []string `tick:"IdTags" json:"idTags,omitempty"`
+
 	// Inhibitors
 	// tick:ignore
 	Inhibitors []Inhibitor `tick:"Inhibit" json:"inhibitors"`
 
+	// Concurrency limits of the handlers
+	// tick:ignore
+	HandlerLimits []HandlerLimit `tick:"HandlerConcurrency" json:"handlerLimits"`
+
 	// Post the JSON alert data to the specified URL.
 	// tick:ignore
 	HTTPPostHandlers []*AlertHTTPPostHandler `tick:"Post" json:"post"`
@@ -385,6 +486,10 @@ type AlertNodeData struct {
 	// Send alert to Kafka topic
 	// tick:ignore
 	KafkaHandlers []*KafkaHandler `tick:"Kafka" json:"kafka"`
+
+	// Send alert as OpenTelemetry log records
+	// tick:ignore
+	OpenTelemetryHandlers []*OpenTelemetryHandler `tick:"OpenTelemetry" json:"openTelemetry"`
 }
 
 func newAlertNode(wants EdgeType) *AlertNode {
@@ -458,9 +563,158 @@ func (n *AlertNodeData) validate() error {
 			return errors.Wrap(err, "invalid post")
 		}
 	}
+
+	if n.SummaryInterval < 0 {
+		return errors.New("summary interval cannot be negative")
+	}
+
+	if n.DurableRetryMaxAge < 0 {
+		return errors.New("durable retry max age cannot be negative")
+	}
+	if n.DurableRetryMaxAge > 0 && n.DurableRetryInterval <= 0 {
+		return errors.New("durable retry interval must be greater than 0")
+	}
+
+	if n.ValueHistoryField != "" && n.ValueHistoryCount <= 0 {
+		return errors.New("value history count must be greater than 0")
+	}
+
+	if n.RocLookback < 0 {
+		return errors.New("rate of change lookback cannot be negative")
+	}
+
+	if n.PercentilesField != "" {
+		if n.PercentilesWindow <= 0 {
+			return errors.New("rolling percentiles window must be greater than 0")
+		}
+		if len(n.PercentilesRanks) == 0 {
+			return errors.New("must provide at least one rolling percentile")
+		}
+		for _, p := range n.PercentilesRanks {
+			if p <= 0 || p >= 100 {
+				return fmt.Errorf("invalid rolling percentile %v, must be between 0 and 100 exclusive", p)
+			}
+		}
+	}
+
+	for _, t := range n.IDTags {
+		if t == "" {
+			return errors.New("id tags must not be empty")
+		}
+	}
+
+	if n.BatchContextField != "" {
+		if n.Wants() != BatchEdge {
+			return errors.New("batch context can only be used with batch data")
+		}
+		if n.AllFlag {
+			return errors.New("batch context cannot be used with all")
+		}
+		if n.RocField != "" {
+			return errors.New("batch context cannot be used with roc")
+		}
+		if n.PercentilesField != "" {
+			return errors.New("batch context cannot be used with rolling percentiles")
+		}
+	}
+
+	limited := make(map[string]bool, len(n.HandlerLimits))
+	for _, l := range n.HandlerLimits {
+		if err := l.validate(); err != nil {
+			return errors.Wrapf(err, "invalid handler concurrency for %q", l.Handler)
+		}
+		if limited[l.Handler] {
+			return fmt.Errorf("handler concurrency for %q set more than once", l.Handler)
+		}
+		limited[l.Handler] = true
+	}
+
+	for _, levels := range n.handlerLevels() {
+		for _, l := range levels {
+			if !alertLevels[strings.ToUpper(l)] {
+				return fmt.Errorf("invalid handler level %q, must be one of INFO, WARNING or CRITICAL", l)
+			}
+		}
+	}
 	return nil
 }
 
+// alertLevels are the levels handlers can be restricted to.
+var alertLevels = map[string]bool{
+	"INFO":     true,
+	"WARNING":  true,
+	"CRITICAL": true,
+}
+
+// handlerLevels returns the levels of each handler.
+func (n *AlertNodeData) handlerLevels() [][]string {
+	var levels [][]string
+	for _, h := range n.HTTPPostHandlers {
+		levels = append(levels, h.LevelsList)
+	}
+	for _, h := range n.TcpHandlers {
+		levels = append(levels, h.LevelsList)
+	}
+	for _, h := range n.EmailHandlers {
+		levels = append(levels, h.LevelsList)
+	}
+	for _, h := range n.ExecHandlers {
+		levels = append(levels, h.LevelsList)
+	}
+	for _, h := range n.LogHandlers {
+		levels = append(levels, h.LevelsList)
+	}
+	for _, h := range n.VictorOpsHandlers {
+		levels = append(levels, h.LevelsList)
+	}
+	for _, h := range n.PagerDutyHandlers {
+		levels = append(levels, h.LevelsList)
+	}
+	for _, h := range n.PagerDuty2Handlers {
+		levels = append(levels, h.LevelsList)
+	}
+	for _, h := range n.PushoverHandlers {
+		levels = append(levels, h.LevelsList)
+	}
+	for _, h := range n.SensuHandlers {
+		levels = append(levels, h.LevelsList)
+	}
+	for _, h := range n.SlackHandlers {
+		levels = append(levels, h.LevelsList)
+	}
+	for _, h := range n.TelegramHandlers {
+		levels = append(levels, h.LevelsList)
+	}
+	for _, h := range n.HipChatHandlers {
+		levels = append(levels, h.LevelsList)
+	}
+	for _, h := range n.AlertaHandlers {
+		levels = append(levels, h.LevelsList)
+	}
+	for _, h := range n.OpsGenieHandlers {
+		levels = append(levels, h.LevelsList)
+	}
+	for _, h := range n.OpsGenie2Handlers {
+		levels = append(levels, h.LevelsList)
+	}
+	for _, h := range n.TalkHandlers {
+		levels = append(levels, h.LevelsList)
+	}
+	for _, h := range n.MQTTHandlers {
+		levels = append(levels, h.LevelsList)
+	}
+	for _, h := range n.SNMPTrapHandlers {
+		levels = append(levels, h.LevelsList)
+	}
+	for _, h := range n.KafkaHandlers {
+		levels = append(levels, h.LevelsList)
+	}
+	for _, h := range n.OpenTelemetryHandlers {
+		levels = append(levels, h.LevelsList)
+	}
+	return levels
+}
+
 // Indicates an alert should trigger only if all points in a batch match the criteria.
 // Does not apply to stream alerts.
 // tick:property
@@ -546,6 +800,243 @@ func (n *AlertNodeData) Flapping(low, high float64) *AlertNodeData {
 	return n
 }
 
+// Keep the most recent count values of a field for each group,
+// and make them available to the message and details templates as History.
+// The values are ordered oldest first and include the value of the point that triggered the event.
+// Points without the field are not recorded.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('cpu')
+//            .groupBy('host')
+//        |alert()
+//            .crit(lambda: "usage_idle" < 10)
+//            .valueHistory('usage_idle', 10)
+//            .message('{{ .ID }} is {{ .Level }}, recent values: {{ range .History }}{{ . }} {{ end }}')
+//
+// tick:property
+func (n *AlertNodeData) ValueHistory(field string, count int64) *AlertNodeData {
+	n.ValueHistoryField = field
+	n.ValueHistoryCount = count
+	return n
+}
+
+// Compute the rate of change of a field for each group,
+// and make it available to the info, warn and crit expressions as "roc".
+// The rate of change is the relative change of the value from a reference value of the same group,
+// (value - reference) / |reference|, so 0.5 is an increase of 50% and -0.5 a decrease of 50%.
+//
+// Without a lookback the reference is the value of the previous point of the group.
+// With a lookback the reference is the most recent value at least lookback older than the point,
+// so the rate of change is computed over a fixed interval regardless of the rate of the points.
+// The values within the lookback are kept for each group.
+//
+// Points without a numeric value of the field are ignored.
+// Until a group has a reference value, or when the reference value is zero,
+// there is no rate of change and the points of the group do not change the level of the alert.
+// The rate of change is also added to the fields of the alert event, but not to the points passed on.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('requests')
+//            .groupBy('service')
+//        |window()
+//            .period(1m)
+//            .every(1m)
+//        |sum('value')
+//        |alert()
+//            .warn(lambda: "roc" > 0.2)
+//            .crit(lambda: "roc" > 0.5)
+//            .roc('sum', 10m)
+//
+// The above example warns when the number of requests per minute increased by more than 20%
+// compared to ten minutes earlier, and is critical when it increased by more than 50%.
+//
+// tick:property
+func (n *AlertNodeData) Roc(field string, lookback ...time.Duration) *AlertNodeData {
+	n.RocField = field
+	n.RocLookback = 0
+	if len(lookback) > 0 {
+		n.RocLookback = lookback[0]
+	}
+	return n
+}
+
+// Estimate rolling percentiles of the recent values of a field for each group,
+// and make them available to the info, warn and crit expressions as "p" followed by the percentile,
+// e.g. "p99" for the 99th percentile and "p99_9" for the 99.9th percentile.
+// This alerts when a value is unusual compared to the history of its own group, instead of on a static threshold.
+// Without percentiles the 50th, 90th, 95th and 99th percentiles are computed.
+//
+// The percentiles are those of the values of the group within the window before the point,
+// the value of the point itself is added to the history after the expressions are evaluated.
+// Until the window has enough values for the highest percentile to be below the maximum,
+// i.e. 100 values for the 99th percentile, the points of the group do not change the level of the alert.
+// Points without a numeric value of the field are ignored as well.
+//
+// The percentiles are estimated with a bounded streaming estimator:
+//
+//    * Each estimate is within 1% of the true percentile of the values, relative to its magnitude,
+//      and within the minimum and maximum of the values, so constant values never exceed their own percentiles.
+//    * The window is split into 10 panes and values expire a pane at a time,
+//      so the estimates cover between the window and 1.1 times the window of history.
+//    * Each pane counts the values in at most 2048 buckets for either sign,
+//      beyond which the values closest to zero lose accuracy.
+//
+// The state of a group is removed when the group is deleted.
+// The percentiles are also added to the fields of the alert event, but not to the points passed on.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('requests')
+//            .groupBy('service')
+//        |alert()
+//            .rollingPercentiles('latency', 1h, 95.0, 99.0)
+//            .warn(lambda: "latency" > "p95")
+//            .crit(lambda: "latency" > "p99")
+//
+// The above example warns when the latency of a service exceeds the 95th percentile of its latencies
+// in the last hour, and is critical when it exceeds the 99th percentile.
+//
+// tick:property
+func (n *AlertNodeData) RollingPercentiles(field string, window time.Duration, percentiles ...float64) *AlertNodeData {
+	n.PercentilesField = field
+	n.PercentilesWindow = window
+	n.PercentilesRanks = percentiles
+	if len(percentiles) == 0 {
+		n.PercentilesRanks = []float64{50, 90, 95, 99}
+	}
+	return n
+}
+
+// Evaluate the info, warn and crit expressions once per batch on aggregates of a field over the whole batch,
+// instead of on each point of the batch.
+// This alerts on properties of the batch as a whole, i.e. the share of points over a threshold.
+//
+// Available variables in the expressions:
+//
+//    * count -- number of points of the batch with a numeric value of the field
+//    * count_over -- number of values greater than the threshold
+//    * percent_over -- percentage of values greater than the threshold, from 0 to 100
+//    * min -- smallest value
+//    * max -- largest value
+//    * mean -- mean of the values
+//
+// The tags of the batch are available as well.
+// The variables are the fields of the alert event, which has the time of the batch.
+// Batches without numeric values of the field do not change the level of the alert.
+// Only applies to batch data, and cannot be used with the all, roc or rollingPercentiles properties.
+//
+// Example:
+//    batch
+//        |query('SELECT usage_user FROM "telegraf"."autogen"."cpu"')
+//            .period(5m)
+//            .every(5m)
+//            .groupBy('host')
+//        |alert()
+//            .batchContext('usage_user', 90.0)
+//            .warn(lambda: "percent_over" > 10.0)
+//            .crit(lambda: "percent_over" > 50.0 OR "max" > 99.0)
+//
+// The above example warns when more than 10% of the values of a host in the last five minutes
+// exceeded 90, and is critical when more than half of them did or any of them exceeded 99.
+//
+// tick:property
+func (n *AlertNodeData) BatchContext(field string, threshold float64) *AlertNodeData {
+	n.BatchContextField = field
+	n.BatchContextThreshold = threshold
+	return n
+}
+
+// Retry the events that handlers fail to deliver until they are delivered or older than maxAge.
+// Undelivered events are saved in the task snapshot and retried after Kapacitor restarts.
+// An optional interval sets the time between attempts.
+// Default interval: 30s
+//
+// Events are delivered at least once, an event may be delivered again if Kapacitor stops while retrying it.
+// Receivers can detect duplicates by the ID and time of the event.
+// Only the post and tcp handlers report failed deliveries, other handlers are not retried.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('cpu')
+//            .groupBy('host')
+//        |alert()
+//            .crit(lambda: "usage_idle" < 10)
+//            .durableRetry(1h)
+//            .post('http://example.com/alerts')
+//
+// tick:property
+func (n *AlertNodeData) DurableRetry(maxAge time.Duration, interval ...time.Duration) *AlertNodeData {
+	n.DurableRetryMaxAge = maxAge
+	n.DurableRetryInterval = defaultDurableRetryInterval
+	if len(interval) > 0 {
+		n.DurableRetryInterval = interval[0]
+	}
+	return n
+}
+
+// Save the state of the groups in the task snapshots and restore it when the task restarts.
+// The snapshots are taken every snapshot-interval of the [task] configuration.
+//
+// The current level of an alert is restored from its topic in any case,
+// the persisted state also keeps the history of levels used to detect flapping and state changes
+// and the time the alert was first triggered.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('cpu')
+//            .groupBy('host')
+//        |alert()
+//            .crit(lambda: "usage_idle" < 10)
+//            .flapping(0.25, 0.5)
+//            .persistState()
+//
+// tick:property
+func (n *AlertNodeData) PersistState() *AlertNodeData {
+	n.PersistStateFlag = true
+	return n
+}
+
+// Compute the identity of an alert from a stable subset of the group by tags instead of the whole group,
+// so that an alert tracks the same logical entity when incidental tags of its data change.
+//
+// By default each group is a separate alert, so a change of any group by tag,
+// e.g. a label added to a host, starts a new alert while the alert of the previous tag set
+// never receives data again and is never resolved.
+// With id tags, all groups with the same values of the id tags share the state of a single alert:
+// the level, history and flapping state carry over from one tag set to the next,
+// and the `Group` of the ID, message and details templates, of the event data and of acknowledgements
+// only consists of the id tags.
+//
+// The id tags should be group by tags, the identity of a group is computed from its first point.
+// Grouping itself is unchanged, the data still has the group by tags and stateful properties
+// of other nodes keep a state per group.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('cpu')
+//            .groupBy('host', 'label')
+//        |alert()
+//            .crit(lambda: "usage_idle" < 10)
+//            .idTags('host')
+//            .stateChangesOnly()
+//
+// The above example keeps a CRITICAL alert of a host active when its label changes,
+// and sends the recovery with the ID `cpu:host=<host>` once the host is no longer critical.
+//
+// tick:property
+func (n *AlertNodeData) IdTags(tags ...string) *AlertNodeData {
+	n.IDTags = append(n.IDTags, tags...)
+	return n
+}
+
 // Inhibit other alerts in a category.
 // The equal tags provides a list of tags that must be equal in order for an alert event to be inhibited.
 //
@@ -582,6 +1073,82 @@ func (n *AlertNodeData) Inhibit(category string, equalTags ...string) *AlertNode
 	return n
 }
 
+// Limit the number of concurrent calls to the handlers of the given kind, e.g. 'pagerDuty' or 'slack'.
+// Events are queued while the limit is reached and dropped once the queue is full.
+// The queue size defaults to DefaultHandlerQueueSize.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('cpu')
+//            .groupBy('host')
+//        |alert()
+//            .crit(lambda: "usage_idle" < 10.0)
+//            .pagerDuty()
+//            .handlerConcurrency('pagerDuty', 4, 100)
+//
+// At most 4 events are sent to PagerDuty at the same time and up to 100 events wait to be sent.
+//
+// tick:property
+func (n *AlertNodeData) HandlerConcurrency(handler string, concurrency int64, queueSize ...int64) *AlertNodeData {
+	l := HandlerLimit{
+		Handler:     handler,
+		Concurrency: concurrency,
+		QueueSize:   DefaultHandlerQueueSize,
+	}
+	if len(queueSize) > 0 {
+		l.QueueSize = queueSize[0]
+	}
+	n.HandlerLimits = append(n.HandlerLimits, l)
+	return n
+}
+
+// HandlerLimit represents the concurrency limit of a kind of alert handler
+// tick:ignore
+type HandlerLimit struct {
+	Handler     string `json:"handler"`
+	Concurrency int64  `json:"concurrency"`
+	QueueSize   int64  `json:"queueSize"`
+}
+
+// alertHandlerKinds are the names of the handler properties of an AlertNode.
+var alertHandlerKinds = map[string]bool{
+	"alerta":        true,
+	"email":         true,
+	"exec":          true,
+	"hipChat":       true,
+	"kafka":         true,
+	"log":           true,
+	"mqtt":          true,
+	"openTelemetry": true,
+	"opsGenie":      true,
+	"opsGenie2":     true,
+	"pagerDuty":     true,
+	"pagerDuty2":    true,
+	"post":          true,
+	"pushover":      true,
+	"sensu":         true,
+	"slack":         true,
+	"snmpTrap":      true,
+	"talk":          true,
+	"tcp":           true,
+	"telegram":      true,
+	"victorOps":     true,
+}
+
+func (l HandlerLimit) validate() error {
+	if !alertHandlerKinds[l.Handler] {
+		return errors.New("unknown handler")
+	}
+	if l.Concurrency <= 0 {
+		return errors.New("concurrency must be greater than 0")
+	}
+	if l.QueueSize <= 0 {
+		return errors.New("queue size must be greater than 0")
+	}
+	return nil
+}
+
 // Inhibitor represents a single alert inhibitor
 // tick:ignore
 type Inhibitor struct {
@@ -636,6 +1203,18 @@ type AlertHTTPPostHandler struct {
 
 	// Timeout for HTTP Post
 	Timeout time.Duration `json:"timeout"`
+
+	// Alert levels of which the handler is notified, all levels if empty.
+	// tick:ignore
+	LevelsList []string `tick:"Levels" json:"levels,omitempty"`
+}
+
+// Only notify the handler of alerts of the given levels, one of INFO, WARNING or CRITICAL.
+// The handler is notified of the recovery of each alert it was notified of.
+// tick:property
+func (h *AlertHTTPPostHandler) Levels(levels ...string) *AlertHTTPPostHandler {
+	h.LevelsList = levels
+	return h
 }
 
 // Set a header key and value on the post request.
@@ -692,6 +1271,18 @@ type TcpHandler struct {
 
 	// The endpoint address.
 	Address string `json:"address"`
+
+	// Alert levels of which the handler is notified, all levels if empty.
+	// tick:ignore
+	LevelsList []string `tick:"Levels" json:"levels,omitempty"`
+}
+
+// Only notify the handler of alerts of the given levels, one of INFO, WARNING or CRITICAL.
+// The handler is notified of the recovery of each alert it was notified of.
+// tick:property
+func (h *TcpHandler) Levels(levels ...string) *TcpHandler {
+	h.LevelsList = levels
+	return h
 }
 
 // Email the alert data.
@@ -700,6 +1291,8 @@ type TcpHandler struct {
 // The email subject is the AlertNode.Message property.
 // The email body is the AlertNode.Details property.
 // The emails are sent as HTML emails and so the body can contain html markup.
+// The body and text properties template the HTML body and a plain text alternative of the email,
+// the attachData property attaches the alert data as CSV and the toTag property takes the recipients from a tag.
 //
 // If the 'smtp' section in the configuration has the option: global = true
 // then all alerts are sent via email without the need to explicitly state it
@@ -757,6 +1350,50 @@ type EmailHandler struct {
 	// List of email recipients.
 	// tick:ignore
 	ToList []string `tick:"To" json:"to"`
+
+	// Name of a tag holding a comma separated list of recipients.
+	// When the tag is not empty its recipients receive the alert instead of the To addresses.
+	ToTag string `json:"toTag,omitempty"`
+
+	// Template of the HTML body of the email, if empty the body is the AlertNode.Details property.
+	// The template has access to the same data as the AlertNode.Message property.
+	Body string `json:"body,omitempty"`
+
+	// Template of a plain text alternative of the HTML body.
+	// If not empty the email is sent as multipart/alternative with both parts.
+	Text string `json:"text,omitempty"`
+
+	// Attach the data of the alert as a CSV file.
+	// tick:ignore
+	AttachDataFlag bool `tick:"AttachData" json:"attachData,omitempty"`
+
+	// Alert levels of which the handler is notified, all levels if empty.
+	// tick:ignore
+	LevelsList []string `tick:"Levels" json:"levels,omitempty"`
+}
+
+// Attach the points of the alert data as a CSV file named data.csv.
+// Each row has the time, the tags and the fields of a point.
+//
+// Example:
+//    |alert()
+//       .email('oncall@example.com')
+//         .text('{{ .ID }} is {{ .Level }}, value: {{ index .Fields "value" }}')
+//         .body('<h1>{{ .ID }}</h1><b>{{ .Level }}</b>')
+//         .attachData()
+//
+// tick:property
+func (h *EmailHandler) AttachData() *EmailHandler {
+	h.AttachDataFlag = true
+	return h
+}
+
+// Only notify the handler of alerts of the given levels, one of INFO, WARNING or CRITICAL.
+// The handler is notified of the recovery of each alert it was notified of.
+// tick:property
+func (h *EmailHandler) Levels(levels ...string) *EmailHandler {
+	h.LevelsList = levels
+	return h
 }
 
 // Define the To addresses for the email alert.
@@ -805,6 +1442,18 @@ type ExecHandler struct {
 	// The command to execute
 	// tick:ignore
 	Command []string `json:"command"`
+
+	// Alert levels of which the handler is notified, all levels if empty.
+	// tick:ignore
+	LevelsList []string `tick:"Levels" json:"levels,omitempty"`
+}
+
+// Only notify the handler of alerts of the given levels, one of INFO, WARNING or CRITICAL.
+// The handler is notified of the recovery of each alert it was notified of.
+// tick:property
+func (h *ExecHandler) Levels(levels ...string) *ExecHandler {
+	h.LevelsList = levels
+	return h
 }
 
 // Log JSON alert data to file. One event per line.
@@ -842,6 +1491,18 @@ type LogHandler struct {
 	// File's mode and permissions, default is 0600
 	// NOTE: The leading 0 is required to interpret the value as an octal integer.
 	Mode int64 `json:"mode"`
+
+	// Alert levels of which the handler is notified, all levels if empty.
+	// tick:ignore
+	LevelsList []string `tick:"Levels" json:"levels,omitempty"`
+}
+
+// Only notify the handler of alerts of the given levels, one of INFO, WARNING or CRITICAL.
+// The handler is notified of the recovery of each alert it was notified of.
+// tick:property
+func (h *LogHandler) Levels(levels ...string) *LogHandler {
+	h.LevelsList = levels
+	return h
 }
 
 // Send alert to VictorOps.
@@ -904,6 +1565,18 @@ type VictorOpsHandler struct {
 	// The routing key to use for the alert.
 	// Defaults to the value in the configuration if empty.
 	RoutingKey string `json:"routingKey"`
+
+	// Alert levels of which the handler is notified, all levels if empty.
+	// tick:ignore
+	LevelsList []string `tick:"Levels" json:"levels,omitempty"`
+}
+
+// Only notify the handler of alerts of the given levels, one of INFO, WARNING or CRITICAL.
+// The handler is notified of the recovery of each alert it was notified of.
+// tick:property
+func (h *VictorOpsHandler) Levels(levels ...string) *VictorOpsHandler {
+	h.LevelsList = levels
+	return h
 }
 
 // Send the alert to PagerDuty.
@@ -961,6 +1634,18 @@ type PagerDutyHandler struct {
 	// The service key to use for the alert.
 	// Defaults to the value in the configuration if empty.
 	ServiceKey string `json:"serviceKey"`
+
+	// Alert levels of which the handler is notified, all levels if empty.
+	// tick:ignore
+	LevelsList []string `tick:"Levels" json:"levels,omitempty"`
+}
+
+// Only notify the handler of alerts of the given levels, one of INFO, WARNING or CRITICAL.
+// The handler is notified of the recovery of each alert it was notified of.
+// tick:property
+func (h *PagerDutyHandler) Levels(levels ...string) *PagerDutyHandler {
+	h.LevelsList = levels
+	return h
 }
 
 // Send the alert to PagerDuty API v2.
@@ -1019,6 +1704,18 @@ type PagerDuty2Handler struct {
 	// The service key to use for the alert.
 	// Defaults to the value in the configuration if empty.
 	ServiceKey string `json:"serviceKey"`
+
+	// Alert levels of which the handler is notified, all levels if empty.
+	// tick:ignore
+	LevelsList []string `tick:"Levels" json:"levels,omitempty"`
+}
+
+// Only notify the handler of alerts of the given levels, one of INFO, WARNING or CRITICAL.
+// The handler is notified of the recovery of each alert it was notified of.
+// tick:property
+func (h *PagerDuty2Handler) Levels(levels ...string) *PagerDuty2Handler {
+	h.LevelsList = levels
+	return h
 }
 
 // Send the alert to HipChat.
@@ -1092,6 +1789,18 @@ type HipChatHandler struct {
 	// HipChat authentication token.
 	// If empty uses the token from the configuration.
 	Token string `json:"token"`
+
+	// Alert levels of which the handler is notified, all levels if empty.
+	// tick:ignore
+	LevelsList []string `tick:"Levels" json:"levels,omitempty"`
+}
+
+// Only notify the handler of alerts of the given levels, one of INFO, WARNING or CRITICAL.
+// The handler is notified of the recovery of each alert it was notified of.
+// tick:property
+func (h *HipChatHandler) Levels(levels ...string) *HipChatHandler {
+	h.LevelsList = levels
+	return h
 }
 
 // Send the alert to Alerta.
@@ -1183,6 +1892,18 @@ type AlertaHandler struct {
 	// Alerta timeout.
 	// Default: 24h
 	Timeout time.Duration `json:"timeout"`
+
+	// Alert levels of which the handler is notified, all levels if empty.
+	// tick:ignore
+	LevelsList []string `tick:"Levels" json:"levels,omitempty"`
+}
+
+// Only notify the handler of alerts of the given levels, one of INFO, WARNING or CRITICAL.
+// The handler is notified of the recovery of each alert it was notified of.
+// tick:property
+func (h *AlertaHandler) Levels(levels ...string) *AlertaHandler {
+	h.LevelsList = levels
+	return h
 }
 
 // List of effected services.
@@ -1228,6 +1949,18 @@ type MQTTHandler struct {
 	// Retained indicates whether this alert should be delivered to
 	// clients that were not connected to the broker at the time of the alert.
 	Retained bool `json:"retained"`
+
+	// Alert levels of which the handler is notified, all levels if empty.
+	// tick:ignore
+	LevelsList []string `tick:"Levels" json:"levels,omitempty"`
+}
+
+// Only notify the handler of alerts of the given levels, one of INFO, WARNING or CRITICAL.
+// The handler is notified of the recovery of each alert it was notified of.
+// tick:property
+func (h *MQTTHandler) Levels(levels ...string) *MQTTHandler {
+	h.LevelsList = levels
+	return h
 }
 
 // Send the alert to Sensu.
@@ -1275,6 +2008,18 @@ type SensuHandler struct {
 	// If empty uses the handler list from the configuration
 	// tick:ignore
 	HandlersList []string `tick:"Handlers" json:"handlers"`
+
+	// Alert levels of which the handler is notified, all levels if empty.
+	// tick:ignore
+	LevelsList []string `tick:"Levels" json:"levels,omitempty"`
+}
+
+// Only notify the handler of alerts of the given levels, one of INFO, WARNING or CRITICAL.
+// The handler is notified of the recovery of each alert it was notified of.
+// tick:property
+func (h *SensuHandler) Levels(levels ...string) *SensuHandler {
+	h.LevelsList = levels
+	return h
 }
 
 // List of effected services.
@@ -1351,6 +2096,18 @@ type PushoverHandler struct {
 	// The name of one of the sounds supported by the device clients to override
 	// the user's default sound choice
 	Sound string `json:"sound"`
+
+	// Alert levels of which the handler is notified, all levels if empty.
+	// tick:ignore
+	LevelsList []string `tick:"Levels" json:"levels,omitempty"`
+}
+
+// Only notify the handler of alerts of the given levels, one of INFO, WARNING or CRITICAL.
+// The handler is notified of the recovery of each alert it was notified of.
+// tick:property
+func (h *PushoverHandler) Levels(levels ...string) *PushoverHandler {
+	h.LevelsList = levels
+	return h
 }
 
 // Send the alert to Slack.
@@ -1448,6 +2205,18 @@ type SlackHandler struct {
 	// IconEmoji is an emoji name surrounded in ':' characters.
 	// The emoji image will replace the normal user icon for the slack bot.
 	IconEmoji string `json:"iconEmoji"`
+
+	// Alert levels of which the handler is notified, all levels if empty.
+	// tick:ignore
+	LevelsList []string `tick:"Levels" json:"levels,omitempty"`
+}
+
+// Only notify the handler of alerts of the given levels, one of INFO, WARNING or CRITICAL.
+// The handler is notified of the recovery of each alert it was notified of.
+// tick:property
+func (h *SlackHandler) Levels(levels ...string) *SlackHandler {
+	h.LevelsList = levels
+	return h
 }
 
 // Send the alert to Telegram.
@@ -1526,6 +2295,18 @@ type TelegramHandler struct {
 	// If empty uses the disable-notification from the configuration.
 	// tick:ignore
 	IsDisableNotification bool `tick:"DisableNotification" json:"disableNotification"`
+
+	// Alert levels of which the handler is notified, all levels if empty.
+	// tick:ignore
+	LevelsList []string `tick:"Levels" json:"levels,omitempty"`
+}
+
+// Only notify the handler of alerts of the given levels, one of INFO, WARNING or CRITICAL.
+// The handler is notified of the recovery of each alert it was notified of.
+// tick:property
+func (h *TelegramHandler) Levels(levels ...string) *TelegramHandler {
+	h.LevelsList = levels
+	return h
 }
 
 // Disables the Notification. If empty defaults to the configuration.
@@ -1607,6 +2388,18 @@ type OpsGenieHandler struct {
 	// OpsGenie Recipients.
 	// tick:ignore
 	RecipientsList []string `tick:"Recipients" json:"recipients"`
+
+	// Alert levels of which the handler is notified, all levels if empty.
+	// tick:ignore
+	LevelsList []string `tick:"Levels" json:"levels,omitempty"`
+}
+
+// Only notify the handler of alerts of the given levels, one of INFO, WARNING or CRITICAL.
+// The handler is notified of the recovery of each alert it was notified of.
+// tick:property
+func (h *OpsGenieHandler) Levels(levels ...string) *OpsGenieHandler {
+	h.LevelsList = levels
+	return h
 }
 
 // The list of teams to be alerted. If empty defaults to the teams from the configuration.
@@ -1688,6 +2481,18 @@ type OpsGenie2Handler struct {
 	// OpsGenie2 Recipients.
 	// tick:ignore
 	RecipientsList []string `tick:"Recipients" json:"recipients"`
+
+	// Alert levels of which the handler is notified, all levels if empty.
+	// tick:ignore
+	LevelsList []string `tick:"Levels" json:"levels,omitempty"`
+}
+
+// Only notify the handler of alerts of the given levels, one of INFO, WARNING or CRITICAL.
+// The handler is notified of the recovery of each alert it was notified of.
+// tick:property
+func (h *OpsGenie2Handler) Levels(levels ...string) *OpsGenie2Handler {
+	h.LevelsList = levels
+	return h
 }
 
 // The list of teams to be alerted. If empty defaults to the teams from the configuration.
@@ -1740,6 +2545,18 @@ func (n *AlertNodeData) Talk() *TalkHandler {
 // tick:embedded:AlertNode.Talk
 type TalkHandler struct {
 	*AlertNodeData `json:"-"`
+
+	// Alert levels of which the handler is notified, all levels if empty.
+	// tick:ignore
+	LevelsList []string `tick:"Levels" json:"levels,omitempty"`
+}
+
+// Only notify the handler of alerts of the given levels, one of INFO, WARNING or CRITICAL.
+// The handler is notified of the recovery of each alert it was notified of.
+// tick:property
+func (h *TalkHandler) Levels(levels ...string) *TalkHandler {
+	h.LevelsList = levels
+	return h
 }
 
 // Send the alert using SNMP traps.
@@ -1781,6 +2598,18 @@ type SNMPTrapHandler struct {
 	// List of trap data.
 	// tick:ignore
 	DataList []SNMPData `tick:"Data" json:"data"`
+
+	// Alert levels of which the handler is notified, all levels if empty.
+	// tick:ignore
+	LevelsList []string `tick:"Levels" json:"levels,omitempty"`
+}
+
+// Only notify the handler of alerts of the given levels, one of INFO, WARNING or CRITICAL.
+// The handler is notified of the recovery of each alert it was notified of.
+// tick:property
+func (h *SNMPTrapHandler) Levels(levels ...string) *SNMPTrapHandler {
+	h.LevelsList = levels
+	return h
 }
 
 // tick:ignore
@@ -1877,4 +2706,59 @@ type KafkaHandler struct {
 	// Template used to construct the message body
 	// If empty the alert data in JSON is sent as the message body.
 	Template string `json:"template"`
+
+	// Alert levels of which the handler is notified, all levels if empty.
+	// tick:ignore
+	LevelsList []string `tick:"Levels" json:"levels,omitempty"`
+}
+
+// Only notify the handler of alerts of the given levels, one of INFO, WARNING or CRITICAL.
+// The handler is notified of the recovery of each alert it was notified of.
+// tick:property
+func (h *KafkaHandler) Levels(levels ...string) *KafkaHandler {
+	h.LevelsList = levels
+	return h
+}
+
+// Send the alert as an OpenTelemetry log record to an OTLP collector.
+// The alert message is the body of the record, the level maps to its severity
+// and the tags of the alert data are its attributes.
+//
+// Example:
+//    [opentelemetry]
+//      enabled = true
+//      url = "http://localhost:4318/v1/logs"
+//      service-name = "kapacitor"
+//
+// Example:
+//    stream
+//         |alert()
+//             .openTelemetry()
+//
+// Records are exported in batches, see the [opentelemetry] configuration section.
+//
+// tick:property
+func (n *AlertNodeData) OpenTelemetry() *OpenTelemetryHandler {
+	ot := &OpenTelemetryHandler{
+		AlertNodeData: n,
+	}
+	n.OpenTelemetryHandlers = append(n.OpenTelemetryHandlers, ot)
+	return ot
+}
+
+// tick:embedded:AlertNode.OpenTelemetry
+type OpenTelemetryHandler struct {
+	*AlertNodeData `json:"-"`
+
+	// Alert levels of which the handler is notified, all levels if empty.
+	// tick:ignore
+	LevelsList []string `tick:"Levels" json:"levels,omitempty"`
+}
+
+// Only notify the handler of alerts of the given levels, one of INFO, WARNING or CRITICAL.
+// The handler is notified of the recovery of each alert it was notified of.
+// tick:property
+func (h *OpenTelemetryHandler) Levels(levels ...string) *OpenTelemetryHandler {
+	h.LevelsList = levels
+	return h
 }
@@ -1,7 +1,9 @@
 package pipeline
 
 import (
+	"reflect"
 	"testing"
+	"time"
 )
 
 func TestAlertNode_MarshalJSON(t *testing.T) {
@@ -41,6 +43,10 @@ func TestAlertNode_MarshalJSON(t *testing.T) {
     "flapLow": 0,
     "flapHigh": 0,
     "history": 0,
+    "valueHistoryField": "",
+    "valueHistoryCount": 0,
+    "rocField": "",
+    "rocLookback": 0,
     "levelTag": "",
     "levelField": "",
     "messageField": "",
@@ -51,7 +57,12 @@ func TestAlertNode_MarshalJSON(t *testing.T) {
     "noRecoveries": false,
     "stateChangesOnly": false,
     "stateChangesOnlyDuration": 0,
+    "ackTimeout": 0,
+    "summaryInterval": 0,
+    "durableRetryMaxAge": 0,
+    "durableRetryInterval": 0,
     "inhibitors": null,
+    "handlerLimits": null,
     "post": [
         {
             "url": "http://howdy.local",
@@ -79,7 +90,8 @@ func TestAlertNode_MarshalJSON(t *testing.T) {
     "talk": null,
     "mqtt": null,
     "snmpTrap": null,
-    "kafka": null
+    "kafka": null,
+    "openTelemetry": null
 }`,
 		},
 	}
@@ -89,3 +101,214 @@ func TestAlertNode_MarshalJSON(t *testing.T) {
 		})
 	}
 }
+
+func TestAlertNode_ValidateHandlerConcurrency(t *testing.T) {
+	tests := []struct {
+		name    string
+		limits  []HandlerLimit
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			limits: []HandlerLimit{
+				{Handler: "pagerDuty", Concurrency: 4, QueueSize: 100},
+				{Handler: "slack", Concurrency: 1, QueueSize: 1},
+			},
+		},
+		{
+			name:    "unknown handler",
+			limits:  []HandlerLimit{{Handler: "carrierPigeon", Concurrency: 4, QueueSize: 100}},
+			wantErr: true,
+		},
+		{
+			name:    "zero concurrency",
+			limits:  []HandlerLimit{{Handler: "pagerDuty", Concurrency: 0, QueueSize: 100}},
+			wantErr: true,
+		},
+		{
+			name:    "zero queue size",
+			limits:  []HandlerLimit{{Handler: "pagerDuty", Concurrency: 4, QueueSize: 0}},
+			wantErr: true,
+		},
+		{
+			name: "duplicate handler",
+			limits: []HandlerLimit{
+				{Handler: "pagerDuty", Concurrency: 4, QueueSize: 100},
+				{Handler: "pagerDuty", Concurrency: 2, QueueSize: 100},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := &AlertNodeData{HandlerLimits: tt.limits}
+			if err := n.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAlertNode_ValidateHandlerLevels(t *testing.T) {
+	tests := []struct {
+		name    string
+		levels  []string
+		wantErr bool
+	}{
+		{
+			name:   "valid",
+			levels: []string{"WARNING", "critical"},
+		},
+		{
+			name:   "all levels",
+			levels: nil,
+		},
+		{
+			name:    "OK",
+			levels:  []string{"OK"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown level",
+			levels:  []string{"WARNING", "SEVERE"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := newAlertNode(StreamEdge)
+			n.Slack()
+			n.PagerDuty().Levels(tt.levels...)
+			if err := n.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAlertNode_ValidateSummaryInterval(t *testing.T) {
+	n := &AlertNodeData{SummaryInterval: time.Minute}
+	if err := n.validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	n.SummaryInterval = -time.Minute
+	if err := n.validate(); err == nil {
+		t.Error("expected error for negative summary interval")
+	}
+}
+
+func TestAlertNode_ValidateDurableRetry(t *testing.T) {
+	n := &AlertNodeData{}
+	n.DurableRetry(time.Hour)
+	if err := n.validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if got, exp := n.DurableRetryInterval, 30*time.Second; got != exp {
+		t.Errorf("unexpected default interval: got %v exp %v", got, exp)
+	}
+	n.DurableRetry(-time.Hour)
+	if err := n.validate(); err == nil {
+		t.Error("expected error for negative max age")
+	}
+	n.DurableRetry(time.Hour, 0)
+	if err := n.validate(); err == nil {
+		t.Error("expected error for zero interval")
+	}
+}
+
+func TestAlertNode_ValidateValueHistory(t *testing.T) {
+	n := &AlertNodeData{}
+	n.ValueHistory("value", 10)
+	if err := n.validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	n.ValueHistory("value", 0)
+	if err := n.validate(); err == nil {
+		t.Error("expected error for zero value history count")
+	}
+}
+
+func TestAlertNode_ValidateIdTags(t *testing.T) {
+	n := &AlertNodeData{}
+	n.IdTags("host")
+	if err := n.validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	n.IdTags("")
+	if err := n.validate(); err == nil {
+		t.Error("expected error for empty id tag")
+	}
+}
+
+func TestAlertNode_ValidateRoc(t *testing.T) {
+	n := &AlertNodeData{}
+	n.Roc("value")
+	if err := n.validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	n.Roc("value", time.Minute)
+	if err := n.validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	n.Roc("value", -time.Minute)
+	if err := n.validate(); err == nil {
+		t.Error("expected error for negative lookback")
+	}
+}
+
+func TestAlertNode_ValidateRollingPercentiles(t *testing.T) {
+	n := &AlertNodeData{}
+	n.RollingPercentiles("value", time.Hour)
+	if err := n.validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if exp := []float64{50, 90, 95, 99}; !reflect.DeepEqual(n.PercentilesRanks, exp) {
+		t.Errorf("unexpected default percentiles: got %v exp %v", n.PercentilesRanks, exp)
+	}
+	n.RollingPercentiles("value", time.Hour, 99.9)
+	if err := n.validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	n.RollingPercentiles("value", 0, 99)
+	if err := n.validate(); err == nil {
+		t.Error("expected error for zero window")
+	}
+	n.RollingPercentiles("value", time.Hour, 100)
+	if err := n.validate(); err == nil {
+		t.Error("expected error for percentile 100")
+	}
+	n.RollingPercentiles("value", time.Hour, 0)
+	if err := n.validate(); err == nil {
+		t.Error("expected error for percentile 0")
+	}
+
+	b := newAlertNode(BatchEdge)
+	b.BatchContext("value", 90).RollingPercentiles("value", time.Hour)
+	if err := b.validate(); err == nil {
+		t.Error("expected error for batch context with rolling percentiles")
+	}
+}
+
+func TestAlertNode_ValidateBatchContext(t *testing.T) {
+	n := newAlertNode(BatchEdge)
+	n.BatchContext("value", 90)
+	if err := n.validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	n.All()
+	if err := n.validate(); err == nil {
+		t.Error("expected error for batch context with all")
+	}
+
+	n = newAlertNode(BatchEdge)
+	n.BatchContext("value", 90).Roc("value")
+	if err := n.validate(); err == nil {
+		t.Error("expected error for batch context with roc")
+	}
+
+	n = newAlertNode(StreamEdge)
+	n.BatchContext("value", 90)
+	if err := n.validate(); err == nil {
+		t.Error("expected error for batch context on stream data")
+	}
+}
@@ -0,0 +1,166 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/influxdata/influxdb/influxql"
+	"github.com/influxdata/kapacitor/avro"
+)
+
+// Publish each point to a Kafka topic as an Avro record in the Confluent wire format,
+// with the schema registered in a Confluent schema registry.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('cpu')
+//        |avroOut('metrics')
+//            .cluster('datalake')
+//            .registry('http://schema-registry.example.com:8081')
+//
+// The above example publishes each cpu point to the topic `metrics` of the Kafka cluster `datalake`,
+// see the kafka configuration, with a schema generated from the point.
+//
+// Without a schema, a schema is generated from the tags and fields of each point:
+// a record with the name of the measurement, a `time` field with the time of the point
+// as a long with the logical type timestamp-micros, and a nullable field for each tag and field.
+// Names are sanitized to valid Avro names, replacing invalid characters with underscores.
+// When the tags or fields of the points change, the generated schema is registered as a new version of the subject.
+// Since all tags and fields are nullable with a null default, the versions are compatible
+// under the default BACKWARD compatibility of the registry.
+//
+// With a schema, every point is encoded with that schema. The values of the record fields are the fields of the point,
+// or else its tags, and the `time` field is the time of the point, in the unit of its logical type or else nanoseconds.
+// Only record schemas with fields of the primitive types null, boolean, int, long, float, double and string,
+// and unions of them, are supported. Points without a value for a field that is not nullable are dropped.
+//
+// Each schema is registered, or looked up if it already is registered, under the subject once,
+// and each message starts with a zero byte and the ID of its schema as a 4 byte big endian integer.
+// Messages are written one at a time, waiting up to the batch-timeout of the Kafka cluster for each.
+// Points that fail to be serialized or published are logged and dropped.
+// All data is passed through unchanged.
+//
+// Available Statistics:
+//
+//    * published -- number of messages published
+//    * serialization_errors -- number of points that could not be encoded with their schema
+//    * registry_errors -- number of points whose schema could not be registered
+//    * publish_errors -- number of messages that failed to publish
+//
+type AvroOutNode struct {
+	chainnode `json:"-"`
+
+	// The Kafka topic.
+	// tick:ignore
+	Topic string `json:"topic"`
+
+	// The ID of the Kafka cluster, see the kafka configuration.
+	// Default: default
+	Cluster string `json:"cluster"`
+
+	// URL of the schema registry.
+	// User and password may be given in the URL for basic authentication.
+	// tick:ignore
+	RegistryURL string `tick:"Registry" json:"registry"`
+
+	// The subject of the schemas in the registry.
+	// Default is the topic followed by -value.
+	Subject string `json:"subject"`
+
+	// The Avro schema of the records as JSON.
+	// Default is to generate the schema from the points.
+	Schema string `json:"schema"`
+
+	// Timeout of the requests to the schema registry.
+	// Default: 5s
+	Timeout time.Duration `json:"timeout"`
+}
+
+func newAvroOutNode(wants EdgeType, topic string) *AvroOutNode {
+	return &AvroOutNode{
+		chainnode: newBasicChainNode("avro_out", wants, wants),
+		Topic:     topic,
+		Cluster:   "default",
+		Timeout:   5 * time.Second,
+	}
+}
+
+// MarshalJSON converts AvroOutNode to JSON
+// tick:ignore
+func (n *AvroOutNode) MarshalJSON() ([]byte, error) {
+	type Alias AvroOutNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Timeout string `json:"timeout"`
+	}{
+		TypeOf: TypeOf{
+			Type: "avroOut",
+			ID:   n.ID(),
+		},
+		Alias:   (*Alias)(n),
+		Timeout: influxql.FormatDuration(n.Timeout),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an AvroOutNode
+// tick:ignore
+func (n *AvroOutNode) UnmarshalJSON(data []byte) error {
+	type Alias AvroOutNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Timeout string `json:"timeout"`
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "avroOut" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as AvroOutNode", raw.ID, raw.Type)
+	}
+	n.Timeout, err = influxql.ParseDuration(raw.Timeout)
+	if err != nil {
+		return err
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+// URL of the schema registry.
+// tick:property
+func (n *AvroOutNode) Registry(url string) *AvroOutNode {
+	n.RegistryURL = url
+	return n
+}
+
+func (n *AvroOutNode) validate() error {
+	if n.Topic == "" {
+		return errors.New("must provide a topic")
+	}
+	if n.Cluster == "" {
+		return errors.New("must provide a cluster")
+	}
+	if n.RegistryURL == "" {
+		return errors.New("must provide a registry url")
+	}
+	if _, err := url.Parse(n.RegistryURL); err != nil {
+		return fmt.Errorf("invalid registry url: %v", err)
+	}
+	if n.Schema != "" {
+		if _, err := avro.ParseSchema(n.Schema); err != nil {
+			return err
+		}
+	}
+	if n.Timeout <= 0 {
+		return errors.New("timeout must be greater than 0")
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package pipeline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAvroOutNode_MarshalJSON(t *testing.T) {
+	o := newAvroOutNode(StreamEdge, "metrics")
+	o.Cluster = "datalake"
+	o.Registry("http://localhost:8081")
+	o.Subject = "cpu-value"
+	o.Timeout = 10 * time.Second
+	MarshalTestHelper(t, o, false, `{"typeOf":"avroOut","id":"0","topic":"metrics","cluster":"datalake","registry":"http://localhost:8081","subject":"cpu-value","schema":"","timeout":"10s"}`)
+}
+
+func TestAvroOutNode_UnmarshalJSON(t *testing.T) {
+	input := `{"typeOf":"avroOut","id":"0","topic":"metrics","cluster":"default","registry":"http://localhost:8081","subject":"","schema":"","timeout":"5s"}`
+	want := &AvroOutNode{
+		Topic:       "metrics",
+		Cluster:     "default",
+		RegistryURL: "http://localhost:8081",
+		Timeout:     5 * time.Second,
+	}
+	UnmarshalJSONTestHelper(t, []byte(input), &AvroOutNode{}, false, want)
+}
+
+func TestAvroOutNode_Validate(t *testing.T) {
+	newNode := func(f func(o *AvroOutNode)) *AvroOutNode {
+		o := newAvroOutNode(StreamEdge, "metrics")
+		o.Registry("http://localhost:8081")
+		f(o)
+		return o
+	}
+	tests := []struct {
+		name    string
+		node    *AvroOutNode
+		wantErr bool
+	}{
+		{
+			name: "defaults",
+			node: newNode(func(*AvroOutNode) {}),
+		},
+		{
+			name: "schema",
+			node: newNode(func(o *AvroOutNode) {
+				o.Schema = `{"type":"record","name":"cpu","fields":[{"name":"time","type":"long"},{"name":"value","type":["null","double"]}]}`
+			}),
+		},
+		{
+			name:    "empty topic",
+			node:    newNode(func(o *AvroOutNode) { o.Topic = "" }),
+			wantErr: true,
+		},
+		{
+			name:    "empty cluster",
+			node:    newNode(func(o *AvroOutNode) { o.Cluster = "" }),
+			wantErr: true,
+		},
+		{
+			name:    "empty registry",
+			node:    newNode(func(o *AvroOutNode) { o.RegistryURL = "" }),
+			wantErr: true,
+		},
+		{
+			name:    "invalid schema",
+			node:    newNode(func(o *AvroOutNode) { o.Schema = `{"type":"enum","name":"cpu"}` }),
+			wantErr: true,
+		},
+		{
+			name:    "zero timeout",
+			node:    newNode(func(o *AvroOutNode) { o.Timeout = 0 }),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.node.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
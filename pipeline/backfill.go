@@ -0,0 +1,132 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxdb/influxql"
+)
+
+// Fill the gap in a stream since the task last ran by querying InfluxDB.
+// When the first point arrives after the task starts, the node queries InfluxDB
+// for the data between the last point it emitted and the first point,
+// emits the queried points in time order and then continues with the live stream.
+//
+// The query must not contain a time condition, the time range is added by the node.
+// The query is always grouped by all tags, so that the queried points carry
+// the same tags as the live points, and the queried points are grouped
+// by the same dimensions as the live stream.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('cpu')
+//            .groupBy('host')
+//        |backfill('SELECT usage_idle FROM "telegraf"."autogen"."cpu"')
+//            .lookback(6h)
+//        |window()
+//            .period(1h)
+//            .every(1h)
+//        |mean('usage_idle')
+//        |influxDBOut()
+//            .database('telegraf')
+//            .measurement('cpu_1h')
+//
+// The above example fills in up to 6h of cpu data missed while the task was not running.
+//
+// The time of the last emitted point is saved in the task snapshot,
+// so it is only known if snapshots are enabled for the task.
+// Otherwise, and if the last point is older than the lookback,
+// the gap is assumed to start one lookback before the first point.
+//
+// Live points older than the first live point are dropped,
+// since their time range has already been backfilled.
+//
+// Available Statistics:
+//
+//    * points_backfilled -- number of points queried and emitted to fill the gap
+//    * points_deduplicated -- number of live points dropped because they fall within the backfilled range
+//
+type BackfillNode struct {
+	chainnode `json:"-"`
+
+	// The query text
+	// tick:ignore
+	QueryStr string `json:"query"`
+
+	// The maximum duration to backfill.
+	// Default: 1h
+	Lookback time.Duration `json:"lookback"`
+
+	// The name of the InfluxDB instance to query.
+	// If empty the configured default will be used.
+	Cluster string `json:"cluster"`
+}
+
+func newBackfillNode(wants EdgeType, query string) *BackfillNode {
+	return &BackfillNode{
+		chainnode: newBasicChainNode("backfill", wants, wants),
+		QueryStr:  query,
+		Lookback:  time.Hour,
+	}
+}
+
+// MarshalJSON converts BackfillNode to JSON
+// tick:ignore
+func (n *BackfillNode) MarshalJSON() ([]byte, error) {
+	type Alias BackfillNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Lookback string `json:"lookback"`
+	}{
+		TypeOf: TypeOf{
+			Type: "backfill",
+			ID:   n.ID(),
+		},
+		Alias:    (*Alias)(n),
+		Lookback: influxql.FormatDuration(n.Lookback),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an BackfillNode
+// tick:ignore
+func (n *BackfillNode) UnmarshalJSON(data []byte) error {
+	type Alias BackfillNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Lookback string `json:"lookback"`
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "backfill" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as BackfillNode", raw.ID, raw.Type)
+	}
+	n.Lookback, err = influxql.ParseDuration(raw.Lookback)
+	if err != nil {
+		return err
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+func (n *BackfillNode) validate() error {
+	if n.Wants() != StreamEdge {
+		return errors.New("backfill can only be used on stream data")
+	}
+	if n.QueryStr == "" {
+		return errors.New("must provide a query")
+	}
+	if n.Lookback <= 0 {
+		return errors.New("lookback must be greater than 0")
+	}
+	return nil
+}
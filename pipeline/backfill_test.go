@@ -0,0 +1,62 @@
+package pipeline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackfillNode_MarshalJSON(t *testing.T) {
+	b := newBackfillNode(StreamEdge, `SELECT value FROM "db"."rp"."cpu"`)
+	b.Lookback = 6 * time.Hour
+	b.Cluster = "mycluster"
+	MarshalTestHelper(t, b, false, `{"typeOf":"backfill","id":"0","query":"SELECT value FROM \"db\".\"rp\".\"cpu\"","cluster":"mycluster","lookback":"6h"}`)
+}
+
+func TestBackfillNode_UnmarshalJSON(t *testing.T) {
+	input := `{"typeOf":"backfill","id":"0","query":"SELECT value FROM \"db\".\"rp\".\"cpu\"","lookback":"6h","cluster":"mycluster"}`
+	want := &BackfillNode{
+		QueryStr: `SELECT value FROM "db"."rp"."cpu"`,
+		Lookback: 6 * time.Hour,
+		Cluster:  "mycluster",
+	}
+	UnmarshalJSONTestHelper(t, []byte(input), &BackfillNode{}, false, want)
+}
+
+func TestBackfillNode_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		node    *BackfillNode
+		wantErr bool
+	}{
+		{
+			name: "default",
+			node: newBackfillNode(StreamEdge, `SELECT value FROM "db"."rp"."cpu"`),
+		},
+		{
+			name:    "batch",
+			node:    newBackfillNode(BatchEdge, `SELECT value FROM "db"."rp"."cpu"`),
+			wantErr: true,
+		},
+		{
+			name:    "no query",
+			node:    newBackfillNode(StreamEdge, ""),
+			wantErr: true,
+		},
+		{
+			name: "zero lookback",
+			node: func() *BackfillNode {
+				b := newBackfillNode(StreamEdge, `SELECT value FROM "db"."rp"."cpu"`)
+				b.Lookback = 0
+				return b
+			}(),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.node.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
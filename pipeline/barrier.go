@@ -9,6 +9,19 @@ import (
 	"github.com/influxdata/influxdb/influxql"
 )
 
+// The name of the branch of a BarrierNode receiving the barriers when the barrierOutput property is set.
+const BarrierBranch = "barrier"
+
+// Marker points emitted by a BarrierNode when the markers property is set.
+const (
+	// The measurement of the marker points.
+	BarrierMarkerMeasurement = "_barrier"
+	// The tag with the reason of the barrier, i.e. idle, period or delete.
+	BarrierMarkerReasonTag = "reason"
+	// The field with the ID of the group of the barrier.
+	BarrierMarkerGroupField = "group"
+)
+
 // A BarrierNode will emit a barrier with the current time, according to the system
 // clock.  Since the BarrierNode emits based on system time, it allows pipelines to be
 // forced in the absence of data traffic.  The barrier emitted will be based on either
@@ -16,6 +29,11 @@ import (
 // clock.  Any messages received after an emitted barrier that is older than the last
 // emitted barrier will be dropped.
 //
+// On batch data barriers are only emitted between batches, never while a batch is in progress.
+// The idle time is measured from the end of the last batch and a single idle barrier
+// with the time of that batch is emitted, so downstream nodes such as joins are not
+// left waiting for a batch that will not arrive.
+//
 // Example:
 //    stream
 //        |barrier().idle(5s)
@@ -26,6 +44,50 @@ import (
 //        //Post the top 10 results over the last 10s updated every 5s.
 //        |httpPost('http://example.com/api/top10')
 //
+// The period of periodic barriers can be changed while the task runs via the HTTP API of the task.
+// Each periodic barrier node exposes the endpoint `/kapacitor/v1/tasks/<task_id>/<node_name>/period`,
+// e.g. `/kapacitor/v1/tasks/cpu_top10/barrier2/period`.
+// A GET request returns the current period and a POST request sets a new period:
+//
+//     {"period": "30s"}
+//
+// The next barrier of each group is emitted one new period after its last barrier.
+// The period must be at least 10ms. A changed period is not saved, the task uses the period
+// of the TICKscript when it is restarted.
+//
+// With the barrierOutput property the barriers are sent to a separate output instead of inline with the data.
+// The barriers, both emitted by the node and received from its parent, are sent only to the `barrier` branch,
+// selected with the `branch` chaining method, and all other children receive only the data.
+// This allows a branch to be driven by the cadence of the barriers alone.
+//
+// Example:
+//    var data = stream
+//        |from()
+//            .measurement('cpu')
+//        |barrier()
+//            .period(10s)
+//            .barrierOutput()
+//
+//    data
+//        |window()
+//            .period(1m)
+//            .every(1m)
+//        |mean('usage_idle')
+//
+//    data
+//        |branch('barrier')
+//        |httpOut('clock')
+//
+// By default each group has a goroutine and a timer of its own emitting its barriers.
+// With hundreds of thousands of groups these take up significant memory and scheduler time,
+// the sharedTimer property schedules the barriers of all groups from a single goroutine instead.
+//
+// Barriers are not visible in the output of a task. To debug their timing, i.e. in a replay,
+// the markers property emits a marker point before each barrier, with the time of the barrier,
+// the measurement `_barrier`, the tags of the group and a `reason` tag with the reason of the barrier:
+// idle, period or delete. The `group` field of a marker point is the ID of the group.
+// The marker points go wherever the barriers go, they are data for the children of the node.
+//
 type BarrierNode struct {
 	chainnode
 
@@ -37,6 +99,26 @@ type BarrierNode struct {
 	// clock rather than message time.
 	// Must be greater than zero.
 	Period time.Duration `json:"period"`
+
+	// Only emit a periodic barrier if data has been received since the last barrier.
+	// tick:ignore
+	SkipEmptyFlag bool `tick:"SkipEmpty" json:"skipEmpty"`
+
+	// Send the barriers only to the barrier branch instead of all children.
+	// tick:ignore
+	BarrierOutputFlag bool `tick:"BarrierOutput" json:"barrierOutput"`
+
+	// Schedule the barriers of all groups from a single goroutine.
+	// tick:ignore
+	SharedTimerFlag bool `tick:"SharedTimer" json:"sharedTimer"`
+
+	// Emit a marker point before each barrier.
+	// tick:ignore
+	MarkersFlag bool `tick:"Markers" json:"markers"`
+
+	// Emit the first periodic barrier of a group right away instead of after one period.
+	// tick:ignore
+	EmitImmediatelyFlag bool `tick:"EmitImmediately" json:"emitImmediately"`
 }
 
 func newBarrierNode(wants EdgeType) *BarrierNode {
@@ -56,10 +138,91 @@ func (b *BarrierNode) validate() error {
 	if b.Period <= 0 && b.Idle == 0 {
 		return errors.New("period must be greater than zero")
 	}
+	if b.SkipEmptyFlag && b.Period == 0 {
+		return errors.New("skipEmpty can only be used with period")
+	}
+	if b.EmitImmediatelyFlag {
+		if b.Period == 0 {
+			return errors.New("emitImmediately can only be used with period")
+		}
+		if b.Provides() != StreamEdge {
+			return errors.New("emitImmediately can only be used with stream data")
+		}
+	}
+	hasBranch := false
+	for _, c := range b.Children() {
+		br, ok := c.(*SplitBranchNode)
+		if !ok {
+			continue
+		}
+		if br.BranchName != BarrierBranch {
+			return fmt.Errorf("unknown barrier branch %q, the only branch is %q", br.BranchName, BarrierBranch)
+		}
+		if !b.BarrierOutputFlag {
+			return errors.New("the barrier branch requires barrierOutput")
+		}
+		hasBranch = true
+	}
+	if b.BarrierOutputFlag && !hasBranch {
+		return errors.New("barrierOutput requires a barrier branch")
+	}
 
 	return nil
 }
 
+// Only emit a periodic barrier for a group if at least one point
+// has been received for the group since the last barrier.
+// This avoids emitting empty windows downstream for idle groups.
+// Can only be used with period.
+// tick:property
+func (b *BarrierNode) SkipEmpty() *BarrierNode {
+	b.SkipEmptyFlag = true
+	return b
+}
+
+// Send the barriers to a separate output, the `barrier` branch,
+// instead of inline with the data to all children.
+// tick:property
+func (b *BarrierNode) BarrierOutput() *BarrierNode {
+	b.BarrierOutputFlag = true
+	return b
+}
+
+// Schedule the barriers of all groups with a single timer
+// instead of a goroutine and a timer per group.
+// Use it for a high number of groups, the barriers are emitted the same way.
+// tick:property
+func (b *BarrierNode) SharedTimer() *BarrierNode {
+	b.SharedTimerFlag = true
+	return b
+}
+
+// Emit a marker point of measurement `_barrier` before each barrier, to make the barriers visible for debugging.
+// tick:property
+func (b *BarrierNode) Markers() *BarrierNode {
+	b.MarkersFlag = true
+	return b
+}
+
+// Emit the first periodic barrier of each group as soon as the group starts,
+// i.e. on its first point, instead of one period later.
+// The first barrier has the time of the first point, so that the point is not dropped,
+// and the following barriers are emitted every period after it.
+// Can only be used with period on stream data.
+// tick:property
+func (b *BarrierNode) EmitImmediately() *BarrierNode {
+	b.EmitImmediatelyFlag = true
+	return b
+}
+
+// Select the branch receiving the barriers, the only branch is `barrier`.
+// Requires the barrierOutput property.
+func (b *BarrierNode) Branch(name string) *SplitBranchNode {
+	br := newSplitBranchNode(b.Provides(), name)
+	b.linkChild(br)
+	return br
+}
+
 // MarshalJSON converts BarrierNode to JSON
 // tick:ignore
 func (n *BarrierNode) MarshalJSON() ([]byte, error) {
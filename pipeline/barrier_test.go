@@ -3,12 +3,19 @@ package pipeline
 import (
 	"testing"
 	"time"
+
+	"github.com/influxdata/kapacitor/tick/stateful"
 )
 
 func TestBarrierNode_MarshalJSON(t *testing.T) {
 	type fields struct {
-		Period time.Duration
-		Idle   time.Duration
+		Period          time.Duration
+		Idle            time.Duration
+		SkipEmpty       bool
+		BarrierOutput   bool
+		SharedTimer     bool
+		Markers         bool
+		EmitImmediately bool
 	}
 	tests := []struct {
 		name    string
@@ -22,14 +29,54 @@ func TestBarrierNode_MarshalJSON(t *testing.T) {
 				Period: time.Hour,
 				Idle:   time.Minute,
 			},
-			want: `{"typeOf":"barrier","id":"0","period":"1h","idle":"1m"}`,
+			want: `{"typeOf":"barrier","id":"0","skipEmpty":false,"barrierOutput":false,"sharedTimer":false,"markers":false,"emitImmediately":false,"period":"1h","idle":"1m"}`,
 		},
 		{
 			name: "only period ",
 			fields: fields{
 				Period: time.Hour,
 			},
-			want: `{"typeOf":"barrier","id":"0","period":"1h","idle":"0s"}`,
+			want: `{"typeOf":"barrier","id":"0","skipEmpty":false,"barrierOutput":false,"sharedTimer":false,"markers":false,"emitImmediately":false,"period":"1h","idle":"0s"}`,
+		},
+		{
+			name: "period with skip empty",
+			fields: fields{
+				Period:    time.Hour,
+				SkipEmpty: true,
+			},
+			want: `{"typeOf":"barrier","id":"0","skipEmpty":true,"barrierOutput":false,"sharedTimer":false,"markers":false,"emitImmediately":false,"period":"1h","idle":"0s"}`,
+		},
+		{
+			name: "period with barrier output",
+			fields: fields{
+				Period:        time.Hour,
+				BarrierOutput: true,
+			},
+			want: `{"typeOf":"barrier","id":"0","skipEmpty":false,"barrierOutput":true,"sharedTimer":false,"markers":false,"emitImmediately":false,"period":"1h","idle":"0s"}`,
+		},
+		{
+			name: "idle with shared timer",
+			fields: fields{
+				Idle:        time.Minute,
+				SharedTimer: true,
+			},
+			want: `{"typeOf":"barrier","id":"0","skipEmpty":false,"barrierOutput":false,"sharedTimer":true,"markers":false,"emitImmediately":false,"period":"0s","idle":"1m"}`,
+		},
+		{
+			name: "idle with markers",
+			fields: fields{
+				Idle:    time.Minute,
+				Markers: true,
+			},
+			want: `{"typeOf":"barrier","id":"0","skipEmpty":false,"barrierOutput":false,"sharedTimer":false,"markers":true,"emitImmediately":false,"period":"0s","idle":"1m"}`,
+		},
+		{
+			name: "period with emit immediately",
+			fields: fields{
+				Period:          time.Hour,
+				EmitImmediately: true,
+			},
+			want: `{"typeOf":"barrier","id":"0","skipEmpty":false,"barrierOutput":false,"sharedTimer":false,"markers":false,"emitImmediately":true,"period":"1h","idle":"0s"}`,
 		},
 	}
 	for _, tt := range tests {
@@ -37,7 +84,131 @@ func TestBarrierNode_MarshalJSON(t *testing.T) {
 			b := newBarrierNode(StreamEdge)
 			b.Period = tt.fields.Period
 			b.Idle = tt.fields.Idle
+			b.SkipEmptyFlag = tt.fields.SkipEmpty
+			b.BarrierOutputFlag = tt.fields.BarrierOutput
+			b.SharedTimerFlag = tt.fields.SharedTimer
+			b.MarkersFlag = tt.fields.Markers
+			b.EmitImmediatelyFlag = tt.fields.EmitImmediately
 			MarshalTestHelper(t, b, tt.wantErr, tt.want)
 		})
 	}
 }
+
+func TestBarrierNode_ValidateBarrierOutput(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  string
+		wantErr string
+	}{
+		{
+			name: "barrier output",
+			script: `
+var b = stream
+	|from()
+	|barrier()
+		.period(10s)
+		.barrierOutput()
+b
+	|log()
+b
+	|branch('barrier')
+	|log()
+`,
+		},
+		{
+			name: "unknown branch",
+			script: `
+stream
+	|from()
+	|barrier()
+		.period(10s)
+		.barrierOutput()
+	|branch('data')
+`,
+			wantErr: `unknown barrier branch "data", the only branch is "barrier"`,
+		},
+		{
+			name: "branch without barrier output",
+			script: `
+stream
+	|from()
+	|barrier()
+		.period(10s)
+	|branch('barrier')
+`,
+			wantErr: "the barrier branch requires barrierOutput",
+		},
+		{
+			name: "barrier output without branch",
+			script: `
+stream
+	|from()
+	|barrier()
+		.period(10s)
+		.barrierOutput()
+	|log()
+`,
+			wantErr: "barrierOutput requires a barrier branch",
+		},
+		{
+			name: "emit immediately without period",
+			script: `
+stream
+	|from()
+	|barrier()
+		.idle(10s)
+		.emitImmediately()
+	|log()
+`,
+			wantErr: "emitImmediately can only be used with period",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := CreatePipeline(tt.script, StreamEdge, stateful.NewScope(), deadman{}, nil)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected error %q", tt.wantErr)
+			}
+			if err.Error() != tt.wantErr {
+				t.Errorf("unexpected error got %q want %q", err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBarrierNode_ValidateEmitImmediately(t *testing.T) {
+	tests := []struct {
+		name    string
+		edge    EdgeType
+		period  time.Duration
+		wantErr bool
+	}{
+		{
+			name:   "stream with period",
+			edge:   StreamEdge,
+			period: time.Second,
+		},
+		{
+			name:    "batch with period",
+			edge:    BatchEdge,
+			period:  time.Second,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBarrierNode(tt.edge)
+			b.Period = tt.period
+			b.EmitImmediately()
+			if err := b.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Encodes the value of a string field with standard base64 encoding.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('events')
+//        |encodeBase64('payload')
+//            .as('payload_b64')
+//
+// The above example sets the field `payload_b64` to the base64 encoding of the `payload` field.
+//
+// By default the field is replaced with its encoding.
+// Points that do not have the field are passed through unchanged,
+// as are points whose field is not a string.
+//
+// Available Statistics:
+//
+//    * encode_errors -- number of points whose field could not be encoded.
+//
+type EncodeBase64Node struct {
+	chainnode `json:"-"`
+
+	// The field to encode.
+	// tick:ignore
+	Field string `json:"field"`
+
+	// The name of the encoded field.
+	// Default is the name of the field.
+	As string `json:"as"`
+}
+
+func newEncodeBase64Node(e EdgeType, field string) *EncodeBase64Node {
+	return &EncodeBase64Node{
+		chainnode: newBasicChainNode("encodeBase64", e, e),
+		Field:     field,
+		As:        field,
+	}
+}
+
+// MarshalJSON converts EncodeBase64Node to JSON
+// tick:ignore
+func (n *EncodeBase64Node) MarshalJSON() ([]byte, error) {
+	type Alias EncodeBase64Node
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		TypeOf: TypeOf{
+			Type: "encodeBase64",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an EncodeBase64Node
+// tick:ignore
+func (n *EncodeBase64Node) UnmarshalJSON(data []byte) error {
+	type Alias EncodeBase64Node
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "encodeBase64" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as EncodeBase64Node", raw.ID, raw.Type)
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+func (n *EncodeBase64Node) validate() error {
+	return validateBase64Fields(n.Field, n.As)
+}
+
+// Decodes the value of a string field that holds standard base64 encoded data.
+// The decoded bytes are stored as a string field, which may hold binary data.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('events')
+//        |decodeBase64('payload')
+//        |jsonExtract('payload')
+//            .extract('$.user.name', 'user', 'string')
+//
+// The above example decodes the `payload` field and extracts the user name from the decoded JSON document.
+//
+// By default the field is replaced with its decoded value.
+// Points that do not have the field are passed through unchanged,
+// as are points whose field is not a string or is not valid base64.
+//
+// Available Statistics:
+//
+//    * decode_errors -- number of points whose field could not be decoded.
+//
+type DecodeBase64Node struct {
+	chainnode `json:"-"`
+
+	// The field to decode.
+	// tick:ignore
+	Field string `json:"field"`
+
+	// The name of the decoded field.
+	// Default is the name of the field.
+	As string `json:"as"`
+}
+
+func newDecodeBase64Node(e EdgeType, field string) *DecodeBase64Node {
+	return &DecodeBase64Node{
+		chainnode: newBasicChainNode("decodeBase64", e, e),
+		Field:     field,
+		As:        field,
+	}
+}
+
+// MarshalJSON converts DecodeBase64Node to JSON
+// tick:ignore
+func (n *DecodeBase64Node) MarshalJSON() ([]byte, error) {
+	type Alias DecodeBase64Node
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		TypeOf: TypeOf{
+			Type: "decodeBase64",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an DecodeBase64Node
+// tick:ignore
+func (n *DecodeBase64Node) UnmarshalJSON(data []byte) error {
+	type Alias DecodeBase64Node
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "decodeBase64" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as DecodeBase64Node", raw.ID, raw.Type)
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+func (n *DecodeBase64Node) validate() error {
+	return validateBase64Fields(n.Field, n.As)
+}
+
+func validateBase64Fields(field, as string) error {
+	if field == "" {
+		return errors.New("must provide a field")
+	}
+	if as == "" {
+		return errors.New("must provide a name for the resulting field")
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package pipeline
+
+import (
+	"testing"
+)
+
+func TestEncodeBase64Node_MarshalJSON(t *testing.T) {
+	e := newEncodeBase64Node(StreamEdge, "payload")
+	e.As = "payload_b64"
+	MarshalTestHelper(t, e, false, `{"typeOf":"encodeBase64","id":"0","field":"payload","as":"payload_b64"}`)
+}
+
+func TestEncodeBase64Node_UnmarshalJSON(t *testing.T) {
+	input := `{"typeOf":"encodeBase64","id":"0","field":"payload","as":"payload_b64"}`
+	want := &EncodeBase64Node{
+		Field: "payload",
+		As:    "payload_b64",
+	}
+	UnmarshalJSONTestHelper(t, []byte(input), &EncodeBase64Node{}, false, want)
+}
+
+func TestDecodeBase64Node_MarshalJSON(t *testing.T) {
+	d := newDecodeBase64Node(StreamEdge, "payload")
+	MarshalTestHelper(t, d, false, `{"typeOf":"decodeBase64","id":"0","field":"payload","as":"payload"}`)
+}
+
+func TestDecodeBase64Node_UnmarshalJSON(t *testing.T) {
+	input := `{"typeOf":"decodeBase64","id":"0","field":"payload","as":"raw"}`
+	want := &DecodeBase64Node{
+		Field: "payload",
+		As:    "raw",
+	}
+	UnmarshalJSONTestHelper(t, []byte(input), &DecodeBase64Node{}, false, want)
+}
+
+func TestBase64Nodes_Validate(t *testing.T) {
+	noAs := newDecodeBase64Node(StreamEdge, "payload")
+	noAs.As = ""
+	tests := []struct {
+		name    string
+		node    interface{ validate() error }
+		wantErr bool
+	}{
+		{
+			name: "encode",
+			node: newEncodeBase64Node(StreamEdge, "payload"),
+		},
+		{
+			name: "decode",
+			node: newDecodeBase64Node(BatchEdge, "payload"),
+		},
+		{
+			name:    "no field",
+			node:    newEncodeBase64Node(StreamEdge, ""),
+			wantErr: true,
+		},
+		{
+			name:    "no as",
+			node:    noAs,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.node.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
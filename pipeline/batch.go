@@ -3,6 +3,7 @@ package pipeline
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"reflect"
 	"time"
@@ -87,6 +88,11 @@ func (n *BatchNode) UnmarshalJSON(data []byte) error {
 // The time conditions are added dynamically according to the period, offset and schedule.
 // The `GROUP BY` clause is added dynamically according to the dimensions
 // passed to the `groupBy` method.
+//
+// Subqueries in the `FROM` clause must not contain a time condition either,
+// they are queried with the same time range as the query.
+// Subqueries may contain a `GROUP BY time` clause, its interval must evenly divide
+// the time interval passed to the `groupBy` method so that batches align with the data of the subqueries.
 func (b *BatchNode) Query(q string) *QueryNode {
 	n := newQueryNode()
 	n.QueryStr = q
@@ -159,6 +165,10 @@ type QueryNode struct {
 	// tick:ignore
 	AlignGroupFlag bool `tick:"AlignGroup" json:"alignGroup"`
 
+	// Skip the first query if its window started before the task started
+	// tick:ignore
+	SkipFirstFlag bool `tick:"SkipFirst" json:"skipFirst"`
+
 	// The list of dimensions for the group-by clause.
 	//tick:ignore
 	Dimensions []interface{} `tick:"GroupBy" json:"groupBy"`
@@ -180,6 +190,55 @@ type QueryNode struct {
 	// The name of a configured InfluxDB cluster.
 	// If empty the default cluster will be used.
 	Cluster string `json:"cluster"`
+
+	// Whether the query is a Flux query made with the InfluxDB 2.x query API.
+	// tick:ignore
+	FluxFlag bool `tick:"Flux" json:"flux"`
+
+	// The organization of a Flux query.
+	Org string `json:"org"`
+
+	// The bucket of a Flux query, available to the query as v.bucket.
+	Bucket string `json:"bucket"`
+
+	// The maximum number of points of a batch to keep in memory.
+	// Additional points of the same batch are spilled to a temporary file
+	// and read back once the batch is complete.
+	// The response of an InfluxQL query is requested in chunks of this many points,
+	// which are spilled as they are received, so the response is never held in memory as a whole.
+	// The response of a Flux query is held in memory until its points are spilled,
+	// so for Flux queries this only avoids keeping a second copy of the points.
+	// If zero all points are kept in memory.
+	MaxInMemoryPoints int64 `json:"maxInMemoryPoints"`
+
+	// Split the window of each query into chunks of this length, queried one after the other,
+	// to bound the size of each query and of its response, e.g. for a long backfill.
+	// The points of all chunks are assembled into a single batch per group for the whole window,
+	// so the batches are the same as without chunking.
+	//
+	// Each chunk is queried on its own, so aggregates are computed per chunk.
+	// Chunking is meant for queries of raw points, or of aggregates grouped by a time interval
+	// that evenly divides the chunk interval.
+	// If zero the window is queried at once.
+	//
+	// Example:
+	//    batch
+	//        |query('SELECT "value" FROM "telegraf"."autogen"."cpu"')
+	//            .period(30d)
+	//            .every(30d)
+	//            .chunkInterval(1d)
+	//            .overlap(1m)
+	//
+	// The above example queries the 30 day window one day at a time,
+	// each chunk also querying the last minute of the previous day.
+	ChunkInterval time.Duration `json:"chunkInterval"`
+
+	// Extend each chunk, except the first, this far back into the previous chunk,
+	// to catch points written at the boundary of the chunks while they were queried.
+	// Points of the overlap with the same time and tags as a point of the previous chunk are dropped,
+	// so each point is in the batch of its group once.
+	// Must be less than the chunk interval.
+	Overlap time.Duration `json:"overlap"`
 }
 
 func newQueryNode() *QueryNode {
@@ -196,9 +255,11 @@ func (n *QueryNode) MarshalJSON() ([]byte, error) {
 	var raw = &struct {
 		TypeOf
 		*Alias
-		Period string `json:"period"`
-		Every  string `json:"every"`
-		Offset string `json:"offset"`
+		Period        string `json:"period"`
+		Every         string `json:"every"`
+		Offset        string `json:"offset"`
+		ChunkInterval string `json:"chunkInterval,omitempty"`
+		Overlap       string `json:"overlap,omitempty"`
 	}{
 		TypeOf: TypeOf{
 			Type: "query",
@@ -209,6 +270,12 @@ func (n *QueryNode) MarshalJSON() ([]byte, error) {
 		Every:  influxql.FormatDuration(n.Every),
 		Offset: influxql.FormatDuration(n.Offset),
 	}
+	if n.ChunkInterval != 0 {
+		raw.ChunkInterval = influxql.FormatDuration(n.ChunkInterval)
+	}
+	if n.Overlap != 0 {
+		raw.Overlap = influxql.FormatDuration(n.Overlap)
+	}
 	return json.Marshal(raw)
 }
 
@@ -219,9 +286,11 @@ func (n *QueryNode) UnmarshalJSON(data []byte) error {
 	var raw = &struct {
 		TypeOf
 		*Alias
-		Period string `json:"period"`
-		Every  string `json:"every"`
-		Offset string `json:"offset"`
+		Period        string `json:"period"`
+		Every         string `json:"every"`
+		Offset        string `json:"offset"`
+		ChunkInterval string `json:"chunkInterval"`
+		Overlap       string `json:"overlap"`
 	}{
 		Alias: (*Alias)(n),
 	}
@@ -248,10 +317,58 @@ func (n *QueryNode) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
+	if raw.ChunkInterval != "" {
+		n.ChunkInterval, err = influxql.ParseDuration(raw.ChunkInterval)
+		if err != nil {
+			return err
+		}
+	}
+
+	if raw.Overlap != "" {
+		n.Overlap, err = influxql.ParseDuration(raw.Overlap)
+		if err != nil {
+			return err
+		}
+	}
+
 	n.setID(raw.ID)
 	return nil
 }
 
+func (n *QueryNode) validate() error {
+	if n.MaxInMemoryPoints < 0 {
+		return errors.New("maxInMemoryPoints cannot be negative")
+	}
+	if n.ChunkInterval < 0 {
+		return errors.New("chunkInterval cannot be negative")
+	}
+	if n.Overlap < 0 {
+		return errors.New("overlap cannot be negative")
+	}
+	if n.Overlap > 0 {
+		if n.ChunkInterval == 0 {
+			return errors.New("overlap can only be used with chunkInterval")
+		}
+		if n.Overlap >= n.ChunkInterval {
+			return errors.New("overlap must be less than chunkInterval")
+		}
+	}
+	if n.ChunkInterval > 0 && n.MaxInMemoryPoints > 0 {
+		return errors.New("maxInMemoryPoints cannot be used with chunkInterval")
+	}
+	if n.FluxFlag {
+		if n.Org == "" {
+			return errors.New("must provide an org for a flux query")
+		}
+		if len(n.Dimensions) > 0 || n.Fill != nil || n.AlignGroupFlag {
+			return errors.New("groupBy, fill and alignGroup cannot be used with a flux query")
+		}
+	} else if n.Org != "" || n.Bucket != "" {
+		return errors.New("org and bucket can only be used with a flux query")
+	}
+	return nil
+}
+
 //tick:ignore
 func (n *QueryNode) ChainMethods() map[string]reflect.Value {
 	return map[string]reflect.Value{
@@ -349,3 +466,72 @@ func (b *QueryNode) AlignGroup() *QueryNode {
 	b.AlignGroupFlag = true
 	return b
 }
+
+// Skip the first query if its window started before the task started.
+//
+// When a task starts in the middle of an interval, the window of its first query
+// only partially covers the time the data was collected while the task was running,
+// and aggregates of the first batch may be skewed.
+// With SkipFirst that query is not executed and the first batch is that of the next query.
+// If the window of the first query started after the task started, it is complete and not skipped.
+// The window and the start time of the task are both shifted by QueryNode.Offset.
+//
+// Example:
+//    batch
+//        |query('SELECT mean("usage_idle") FROM "telegraf"."autogen"."cpu"')
+//            .period(5m)
+//            .every(5m)
+//            .align()
+//            .skipFirst()
+//
+// If the above task starts at 12:03, the query of 12:00 to 12:05 is skipped and the first batch is that of 12:05 to 12:10.
+// tick:property
+func (b *QueryNode) SkipFirst() *QueryNode {
+	b.SkipFirstFlag = true
+	return b
+}
+
+// Query InfluxDB 2.x with Flux instead of InfluxQL.
+//
+// The query is made with the InfluxDB 2.x query API, authenticating with the token of the InfluxDB cluster,
+// see the token option of the [[influxdb]] configuration.
+// The org property is required, the bucket property is optional.
+//
+// Kapacitor does not modify a Flux query, instead it defines the option v
+// whose properties the query uses to select its data:
+//
+//    * v.timeRangeStart -- The start time of the query window.
+//    * v.timeRangeStop -- The stop time of the query window.
+//    * v.bucket -- The bucket property.
+//
+// Example:
+//    batch
+//        |query('''
+//            from(bucket: v.bucket)
+//                |> range(start: v.timeRangeStart, stop: v.timeRangeStop)
+//                |> filter(fn: (r) => r._measurement == "cpu" and r._field == "usage_idle")
+//                |> group(columns: ["host"])
+//                |> mean()
+//        ''')
+//            .flux()
+//            .org('my-org')
+//            .bucket('telegraf')
+//            .period(5m)
+//            .every(5m)
+//
+// Each table of the result is a batch, the columns of the group key of the table are the tags of the batch,
+// except for the _start, _stop, _field and _measurement columns.
+// The measurement is the name of the batch.
+// Tables with the same measurement and tags, for example the tables of different fields, are merged into a single batch,
+// the fields of rows with the same time are merged into a single point.
+// The field of a row is the value of its _value column named by its _field column,
+// other columns that are not part of the group key, such as the columns of a pivoted table, are also fields.
+// The time of every batch is the stop time of the query.
+//
+// The groupBy, fill and alignGroup properties only apply to InfluxQL and cannot be used with Flux.
+// Flux queries cannot be replayed from recorded queries.
+// tick:property
+func (b *QueryNode) Flux() *QueryNode {
+	b.FluxFlag = true
+	return b
+}
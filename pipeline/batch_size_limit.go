@@ -0,0 +1,94 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Split batches with more than a maximum number of points into multiple smaller batches.
+// Use this node to cap the size of writes, i.e. when batches written by an InfluxDBOutNode
+// exceed the HTTP body limit of InfluxDB, without changing the query of the batch.
+//
+// Example:
+//    batch
+//        |query('SELECT usage_idle FROM "telegraf"."autogen"."cpu"')
+//            .period(1h)
+//            .every(1h)
+//            .groupBy(*)
+//        |batchSizeLimit()
+//            .maxPoints(1000)
+//        |influxDBOut()
+//            .database('telegraf')
+//            .measurement('cpu_copy')
+//
+// Each smaller batch has the name, group, tags and time of the original batch
+// and the points of the original batch in the same order.
+// Batches with at most the maximum number of points are passed on unchanged.
+//
+// Available Statistics:
+//
+//    * batches_split -- number of batches that were split into smaller batches
+//
+type BatchSizeLimitNode struct {
+	chainnode `json:"-"`
+
+	// The maximum number of points of a batch.
+	// Default: 5000
+	MaxPoints int64 `json:"maxPoints"`
+}
+
+func newBatchSizeLimitNode(wants EdgeType) *BatchSizeLimitNode {
+	return &BatchSizeLimitNode{
+		chainnode: newBasicChainNode("batch_size_limit", wants, wants),
+		MaxPoints: 5000,
+	}
+}
+
+// MarshalJSON converts BatchSizeLimitNode to JSON
+// tick:ignore
+func (n *BatchSizeLimitNode) MarshalJSON() ([]byte, error) {
+	type Alias BatchSizeLimitNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		TypeOf: TypeOf{
+			Type: "batchSizeLimit",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an BatchSizeLimitNode
+// tick:ignore
+func (n *BatchSizeLimitNode) UnmarshalJSON(data []byte) error {
+	type Alias BatchSizeLimitNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "batchSizeLimit" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as BatchSizeLimitNode", raw.ID, raw.Type)
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+func (n *BatchSizeLimitNode) validate() error {
+	if n.Wants() != BatchEdge {
+		return errors.New("batchSizeLimit can only be used on batch data")
+	}
+	if n.MaxPoints <= 0 {
+		return errors.New("maxPoints must be greater than 0")
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package pipeline
+
+import (
+	"testing"
+)
+
+func TestBatchSizeLimitNode_MarshalJSON(t *testing.T) {
+	b := newBatchSizeLimitNode(BatchEdge)
+	b.MaxPoints = 1000
+	MarshalTestHelper(t, b, false, `{"typeOf":"batchSizeLimit","id":"0","maxPoints":1000}`)
+}
+
+func TestBatchSizeLimitNode_UnmarshalJSON(t *testing.T) {
+	input := `{"typeOf":"batchSizeLimit","id":"0","maxPoints":1000}`
+	want := &BatchSizeLimitNode{
+		MaxPoints: 1000,
+	}
+	UnmarshalJSONTestHelper(t, []byte(input), &BatchSizeLimitNode{}, false, want)
+}
+
+func TestBatchSizeLimitNode_Validate(t *testing.T) {
+	tests := []struct {
+		name      string
+		wants     EdgeType
+		maxPoints int64
+		wantErr   bool
+	}{
+		{
+			name:      "batch",
+			wants:     BatchEdge,
+			maxPoints: 1000,
+		},
+		{
+			name:      "stream",
+			wants:     StreamEdge,
+			maxPoints: 1000,
+			wantErr:   true,
+		},
+		{
+			name:      "zero max points",
+			wants:     BatchEdge,
+			maxPoints: 0,
+			wantErr:   true,
+		},
+		{
+			name:      "negative max points",
+			wants:     BatchEdge,
+			maxPoints: -1,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBatchSizeLimitNode(tt.wants)
+			b.MaxPoints = tt.maxPoints
+			if err := b.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
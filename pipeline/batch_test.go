@@ -1 +1,117 @@
 package pipeline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQueryNode_ValidateFlux(t *testing.T) {
+	flux := func(org string) *QueryNode {
+		n := newQueryNode()
+		n.QueryStr = `from(bucket: v.bucket)`
+		n.Flux()
+		n.Org = org
+		return n
+	}
+	tests := []struct {
+		name    string
+		node    *QueryNode
+		wantErr bool
+	}{
+		{
+			name: "flux",
+			node: flux("my-org"),
+		},
+		{
+			name:    "no org",
+			node:    flux(""),
+			wantErr: true,
+		},
+		{
+			name:    "group by",
+			node:    flux("my-org").GroupBy("host"),
+			wantErr: true,
+		},
+		{
+			name:    "align group",
+			node:    flux("my-org").AlignGroup(),
+			wantErr: true,
+		},
+		{
+			name: "org without flux",
+			node: func() *QueryNode {
+				n := newQueryNode()
+				n.Org = "my-org"
+				return n
+			}(),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.node.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestQueryNode_ValidateChunkInterval(t *testing.T) {
+	chunked := func(interval, overlap time.Duration) *QueryNode {
+		n := newQueryNode()
+		n.QueryStr = `SELECT mean("value") FROM "cpu"`
+		n.ChunkInterval = interval
+		n.Overlap = overlap
+		return n
+	}
+	tests := []struct {
+		name    string
+		node    *QueryNode
+		wantErr bool
+	}{
+		{
+			name: "chunk interval",
+			node: chunked(time.Hour, 0),
+		},
+		{
+			name: "chunk interval with overlap",
+			node: chunked(time.Hour, time.Minute),
+		},
+		{
+			name:    "negative chunk interval",
+			node:    chunked(-time.Hour, 0),
+			wantErr: true,
+		},
+		{
+			name:    "negative overlap",
+			node:    chunked(time.Hour, -time.Minute),
+			wantErr: true,
+		},
+		{
+			name:    "overlap without chunk interval",
+			node:    chunked(0, time.Minute),
+			wantErr: true,
+		},
+		{
+			name:    "overlap not less than chunk interval",
+			node:    chunked(time.Hour, time.Hour),
+			wantErr: true,
+		},
+		{
+			name: "max in memory points with chunk interval",
+			node: func() *QueryNode {
+				n := chunked(time.Hour, 0)
+				n.MaxInMemoryPoints = 1000
+				return n
+			}(),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.node.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxdb/influxql"
+)
+
+const (
+	defaultBloomFilterAs                = "seen"
+	defaultBloomFilterExpected          = 100000
+	defaultBloomFilterFalsePositiveRate = 0.01
+)
+
+// A BloomFilterNode marks the points whose ID was probably seen before.
+// Instead of storing every ID, each group keeps a Bloom filter of a fixed size,
+// making membership tests over long horizons with many IDs cheap.
+//
+// The ID of a point is the value of the field with the given name,
+// or of the tag with that name if the point has no such field.
+// The node sets a boolean field on each point with an ID,
+// true if the ID was probably seen before, and then adds the ID to the filter.
+// Points without an ID pass through unchanged.
+//
+// A Bloom filter never misses an ID it has seen, but may report an ID as seen that was not.
+// The filter is sized so that the probability of such a false positive is about the falsePositiveRate property
+// once the expected number of IDs has been added, the probability grows beyond it as more IDs are added.
+// A filter for 100000 IDs with a rate of 1% uses about 117KB.
+//
+// By default each group has its own filter, which is released when the group is deleted.
+// The global property shares a single filter between all groups.
+//
+// The rotate property limits the horizon of the filter:
+// IDs are remembered for at least one and at most two rotation periods, based on the time of the points.
+// A rotating filter uses twice the memory, each half sized by the expected and falsePositiveRate properties.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('logins')
+//        |bloomFilter('user')
+//            .expected(1000000)
+//            .falsePositiveRate(0.001)
+//            .rotate(24h)
+//            .as('returning')
+//            .global()
+//
+// The above example marks logins by users that logged in during the last one to two days.
+//
+// Available Statistics:
+//
+//    * probably_seen -- number of points whose ID was probably seen before
+//
+type BloomFilterNode struct {
+	chainnode `json:"-"`
+
+	// The field or tag containing the ID.
+	// tick:ignore
+	Field string `json:"field"`
+
+	// The name of the boolean field set on the points.
+	// Default: seen
+	As string `json:"as"`
+
+	// The expected number of distinct IDs, sizing the filter.
+	// Default: 100000
+	Expected int64 `json:"expected"`
+
+	// The probability of an unseen ID being reported as seen once the expected number of IDs was added.
+	// Default: 0.01
+	FalsePositiveRate float64 `json:"falsePositiveRate"`
+
+	// The period after which the filter forgets the IDs.
+	// If zero, IDs are never forgotten.
+	Rotate time.Duration `json:"rotate"`
+
+	// Whether all groups share a single filter.
+	// tick:ignore
+	GlobalFlag bool `tick:"Global" json:"global"`
+}
+
+func newBloomFilterNode(wants EdgeType, field string) *BloomFilterNode {
+	return &BloomFilterNode{
+		chainnode:         newBasicChainNode("bloomFilter", wants, wants),
+		Field:             field,
+		As:                defaultBloomFilterAs,
+		Expected:          defaultBloomFilterExpected,
+		FalsePositiveRate: defaultBloomFilterFalsePositiveRate,
+	}
+}
+
+// MarshalJSON converts BloomFilterNode to JSON
+// tick:ignore
+func (n *BloomFilterNode) MarshalJSON() ([]byte, error) {
+	type Alias BloomFilterNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Rotate string `json:"rotate"`
+	}{
+		TypeOf: TypeOf{
+			Type: "bloomFilter",
+			ID:   n.ID(),
+		},
+		Alias:  (*Alias)(n),
+		Rotate: influxql.FormatDuration(n.Rotate),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an BloomFilterNode
+// tick:ignore
+func (n *BloomFilterNode) UnmarshalJSON(data []byte) error {
+	type Alias BloomFilterNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Rotate string `json:"rotate"`
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "bloomFilter" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as BloomFilterNode", raw.ID, raw.Type)
+	}
+	n.Rotate, err = influxql.ParseDuration(raw.Rotate)
+	if err != nil {
+		return err
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+// Share a single filter between all groups,
+// marking IDs seen before in any group.
+// tick:property
+func (n *BloomFilterNode) Global() *BloomFilterNode {
+	n.GlobalFlag = true
+	return n
+}
+
+func (n *BloomFilterNode) validate() error {
+	if n.Field == "" {
+		return errors.New("must provide a field or tag containing the ID")
+	}
+	if n.As == "" {
+		return errors.New("as cannot be empty")
+	}
+	if n.Expected <= 0 {
+		return errors.New("expected must be greater than zero")
+	}
+	if n.FalsePositiveRate <= 0 || n.FalsePositiveRate >= 1 {
+		return fmt.Errorf("falsePositiveRate must be between 0 and 1 exclusive, got %v", n.FalsePositiveRate)
+	}
+	if n.Rotate < 0 {
+		return errors.New("rotate cannot be negative")
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package pipeline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBloomFilterNode_MarshalJSON(t *testing.T) {
+	b := newBloomFilterNode(StreamEdge, "user")
+	b.As = "returning"
+	b.Expected = 1000
+	b.FalsePositiveRate = 0.001
+	b.Rotate = 24 * time.Hour
+	b.Global()
+	MarshalTestHelper(t, b, false, `{"typeOf":"bloomFilter","id":"0","field":"user","as":"returning","expected":1000,"falsePositiveRate":0.001,"global":true,"rotate":"1d"}`)
+}
+
+func TestBloomFilterNode_UnmarshalJSON(t *testing.T) {
+	input := `{"typeOf":"bloomFilter","id":"0","field":"user","as":"returning","expected":1000,"falsePositiveRate":0.001,"global":true,"rotate":"1d"}`
+	want := &BloomFilterNode{
+		Field:             "user",
+		As:                "returning",
+		Expected:          1000,
+		FalsePositiveRate: 0.001,
+		Rotate:            24 * time.Hour,
+		GlobalFlag:        true,
+	}
+	UnmarshalJSONTestHelper(t, []byte(input), &BloomFilterNode{}, false, want)
+}
+
+func TestBloomFilterNode_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		bloom   func(b *BloomFilterNode)
+		wantErr bool
+	}{
+		{
+			name:  "defaults",
+			bloom: func(b *BloomFilterNode) {},
+		},
+		{
+			name:  "rotate",
+			bloom: func(b *BloomFilterNode) { b.Rotate = time.Hour },
+		},
+		{
+			name:    "no field",
+			bloom:   func(b *BloomFilterNode) { b.Field = "" },
+			wantErr: true,
+		},
+		{
+			name:    "empty as",
+			bloom:   func(b *BloomFilterNode) { b.As = "" },
+			wantErr: true,
+		},
+		{
+			name:    "zero expected",
+			bloom:   func(b *BloomFilterNode) { b.Expected = 0 },
+			wantErr: true,
+		},
+		{
+			name:    "zero false positive rate",
+			bloom:   func(b *BloomFilterNode) { b.FalsePositiveRate = 0 },
+			wantErr: true,
+		},
+		{
+			name:    "false positive rate of one",
+			bloom:   func(b *BloomFilterNode) { b.FalsePositiveRate = 1 },
+			wantErr: true,
+		},
+		{
+			name:    "negative rotate",
+			bloom:   func(b *BloomFilterNode) { b.Rotate = -time.Hour },
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBloomFilterNode(StreamEdge, "user")
+			tt.bloom(b)
+			if err := b.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
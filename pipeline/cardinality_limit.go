@@ -0,0 +1,135 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/influxdata/influxdb/influxql"
+)
+
+// Emit a point when the number of distinct groups seen within a window exceeds a limit.
+// Accidental high cardinality tags can degrade the whole system,
+// use this node to alert on them before they do.
+//
+// The number of distinct groups is counted over consecutive windows of the configured duration,
+// aligned to the times of the data.
+// Use a `groupBy(*)` before this node to count distinct series.
+// At most one point is emitted per window, as soon as the limit is exceeded.
+// The data itself is not passed on.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('requests')
+//            .groupBy(*)
+//        |cardinalityLimit()
+//            .maxCardinality(10000)
+//            .window(1m)
+//            .topTag()
+//        |alert()
+//            .crit(lambda: TRUE)
+//            .message('{{ index .Fields "cardinality" }} series in requests, tag {{ index .Fields "top_tag" }} has the most values')
+//
+// The emitted point has the measurement name of the point that exceeded the limit and these fields:
+//
+//    * cardinality -- the number of distinct groups in the window
+//    * top_tag -- the tag key with the most distinct values in the window, only if topTag is set
+//    * top_tag_cardinality -- the number of distinct values of the top tag, only if topTag is set
+//
+// Available Statistics:
+//
+//    * breaches -- number of windows in which the limit was exceeded
+//
+type CardinalityLimitNode struct {
+	chainnode `json:"-"`
+
+	// The maximum number of distinct groups allowed within a window.
+	MaxCardinality int64 `json:"maxCardinality"`
+
+	// The duration of the window within which distinct groups are counted.
+	// Default: 1m
+	Window time.Duration `json:"window"`
+
+	// Whether to name the tag key with the most distinct values.
+	// tick:ignore
+	TopTagFlag bool `tick:"TopTag" json:"topTag"`
+}
+
+func newCardinalityLimitNode(wants EdgeType) *CardinalityLimitNode {
+	return &CardinalityLimitNode{
+		chainnode: newBasicChainNode("cardinality_limit", wants, StreamEdge),
+		Window:    time.Minute,
+	}
+}
+
+// MarshalJSON converts CardinalityLimitNode to JSON
+// tick:ignore
+func (n *CardinalityLimitNode) MarshalJSON() ([]byte, error) {
+	type Alias CardinalityLimitNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Window string `json:"window"`
+	}{
+		TypeOf: TypeOf{
+			Type: "cardinalityLimit",
+			ID:   n.ID(),
+		},
+		Alias:  (*Alias)(n),
+		Window: influxql.FormatDuration(n.Window),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an CardinalityLimitNode
+// tick:ignore
+func (n *CardinalityLimitNode) UnmarshalJSON(data []byte) error {
+	type Alias CardinalityLimitNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Window string `json:"window"`
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "cardinalityLimit" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as CardinalityLimitNode", raw.ID, raw.Type)
+	}
+	n.Window, err = influxql.ParseDuration(raw.Window)
+	if err != nil {
+		return err
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+//tick:ignore
+func (n *CardinalityLimitNode) ChainMethods() map[string]reflect.Value {
+	return map[string]reflect.Value{
+		"Window": reflect.ValueOf(n.chainnode.Window),
+	}
+}
+
+func (n *CardinalityLimitNode) validate() error {
+	if n.MaxCardinality <= 0 {
+		return errors.New("maxCardinality must be greater than 0")
+	}
+	if n.Window <= 0 {
+		return errors.New("window must be greater than 0")
+	}
+	return nil
+}
+
+// Name the tag key with the most distinct values within the window in the emitted point.
+// tick:property
+func (n *CardinalityLimitNode) TopTag() *CardinalityLimitNode {
+	n.TopTagFlag = true
+	return n
+}
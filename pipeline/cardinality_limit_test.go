@@ -0,0 +1,59 @@
+package pipeline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCardinalityLimitNode_MarshalJSON(t *testing.T) {
+	c := newCardinalityLimitNode(StreamEdge)
+	c.MaxCardinality = 1000
+	c.Window = 5 * time.Minute
+	c.TopTag()
+	MarshalTestHelper(t, c, false, `{"typeOf":"cardinalityLimit","id":"0","maxCardinality":1000,"topTag":true,"window":"5m"}`)
+}
+
+func TestCardinalityLimitNode_UnmarshalJSON(t *testing.T) {
+	input := `{"typeOf":"cardinalityLimit","id":"0","maxCardinality":1000,"topTag":true,"window":"5m"}`
+	want := &CardinalityLimitNode{
+		MaxCardinality: 1000,
+		Window:         5 * time.Minute,
+		TopTagFlag:     true,
+	}
+	UnmarshalJSONTestHelper(t, []byte(input), &CardinalityLimitNode{}, false, want)
+}
+
+func TestCardinalityLimitNode_Validate(t *testing.T) {
+	tests := []struct {
+		name           string
+		maxCardinality int64
+		window         time.Duration
+		wantErr        bool
+	}{
+		{
+			name:           "valid",
+			maxCardinality: 10,
+			window:         time.Minute,
+		},
+		{
+			name:    "missing maxCardinality",
+			window:  time.Minute,
+			wantErr: true,
+		},
+		{
+			name:           "zero window",
+			maxCardinality: 10,
+			wantErr:        true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newCardinalityLimitNode(StreamEdge)
+			c.MaxCardinality = tt.maxCardinality
+			c.Window = tt.window
+			if err := c.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Actions of a ClampNode for field values out of their range.
+const (
+	ClampClamp = "clamp"
+	ClampDrop  = "drop"
+)
+
+// Bound numeric fields into ranges, so that impossible readings,
+// i.e. spikes of a faulty sensor, do not disturb scaling and alerts.
+//
+// Each field is given its own range with the field property.
+// A point with a value out of the range of its field is handled with one of these actions:
+//
+//    * clamp -- replace the value with the nearest bound of the range and pass on the point, the default.
+//    * drop -- drop the point.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('sensors')
+//        |clamp()
+//            .field('temperature', -40.0, 85.0)
+//            .field('humidity', 0.0, 100.0)
+//        |alert()
+//            .crit(lambda: "temperature" > 60.0)
+//
+// The above example bounds the temperature between -40 and 85 and the humidity between 0 and 100.
+//
+// Integer fields are clamped to the nearest integers within the range and remain integers.
+// NaN values are out of any range, since they have no nearest bound they are removed from the point
+// by the clamp action. Non-numeric and missing fields are left unchanged.
+//
+// Available Statistics:
+//
+//    * clamped -- number of field values out of their range
+//    * dropped -- number of points dropped because of values out of range
+//
+type ClampNode struct {
+	chainnode `json:"-"`
+
+	// The range of each field.
+	// tick:ignore
+	Ranges map[string]*ClampRange `tick:"Field" json:"fields"`
+
+	// What to do with points with values out of range, one of clamp or drop.
+	// Default: clamp
+	Action string `json:"action"`
+}
+
+// ClampRange is the range of values of a field of a ClampNode, including its bounds.
+type ClampRange struct {
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+}
+
+func newClampNode(wants EdgeType) *ClampNode {
+	return &ClampNode{
+		chainnode: newBasicChainNode("clamp", wants, wants),
+		Ranges:    make(map[string]*ClampRange),
+		Action:    ClampClamp,
+	}
+}
+
+// MarshalJSON converts ClampNode to JSON
+// tick:ignore
+func (n *ClampNode) MarshalJSON() ([]byte, error) {
+	type Alias ClampNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		TypeOf: TypeOf{
+			Type: "clamp",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an ClampNode
+// tick:ignore
+func (n *ClampNode) UnmarshalJSON(data []byte) error {
+	type Alias ClampNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "clamp" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as ClampNode", raw.ID, raw.Type)
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+// Bound a field into the range from min to max, including both bounds.
+// tick:property
+func (n *ClampNode) Field(field string, min, max float64) *ClampNode {
+	n.Ranges[field] = &ClampRange{
+		Min: min,
+		Max: max,
+	}
+	return n
+}
+
+func (n *ClampNode) validate() error {
+	if len(n.Ranges) == 0 {
+		return errors.New("must clamp at least one field")
+	}
+	for field, r := range n.Ranges {
+		if r == nil {
+			return fmt.Errorf("missing range of field %q", field)
+		}
+		if math.IsNaN(r.Min) || math.IsNaN(r.Max) {
+			return fmt.Errorf("bounds of field %q must be numbers", field)
+		}
+		if r.Min > r.Max {
+			return fmt.Errorf("min of field %q must not be greater than max", field)
+		}
+	}
+	switch n.Action {
+	case ClampClamp, ClampDrop:
+	default:
+		return fmt.Errorf("invalid action %q, must be one of %s or %s", n.Action, ClampClamp, ClampDrop)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package pipeline
+
+import (
+	"math"
+	"testing"
+)
+
+func TestClampNode_MarshalJSON(t *testing.T) {
+	c := newClampNode(StreamEdge)
+	c.Field("temperature", -40, 85)
+	c.Action = ClampDrop
+	MarshalTestHelper(t, c, false, `{"typeOf":"clamp","id":"0","fields":{"temperature":{"min":-40,"max":85}},"action":"drop"}`)
+}
+
+func TestClampNode_UnmarshalJSON(t *testing.T) {
+	input := `{"typeOf":"clamp","id":"0","fields":{"humidity":{"min":0,"max":100}},"action":"clamp"}`
+	want := &ClampNode{
+		Ranges: map[string]*ClampRange{
+			"humidity": {Min: 0, Max: 100},
+		},
+		Action: ClampClamp,
+	}
+	UnmarshalJSONTestHelper(t, []byte(input), &ClampNode{}, false, want)
+}
+
+func TestClampNode_Validate(t *testing.T) {
+	newNode := func(min, max float64, action string) *ClampNode {
+		c := newClampNode(StreamEdge)
+		c.Field("value", min, max)
+		c.Action = action
+		return c
+	}
+	tests := []struct {
+		name    string
+		node    *ClampNode
+		wantErr bool
+	}{
+		{
+			name: "range",
+			node: newNode(0, 100, ClampClamp),
+		},
+		{
+			name: "single value",
+			node: newNode(1, 1, ClampDrop),
+		},
+		{
+			name:    "no fields",
+			node:    newClampNode(StreamEdge),
+			wantErr: true,
+		},
+		{
+			name:    "min greater than max",
+			node:    newNode(100, 0, ClampClamp),
+			wantErr: true,
+		},
+		{
+			name:    "NaN bound",
+			node:    newNode(math.NaN(), 0, ClampClamp),
+			wantErr: true,
+		},
+		{
+			name:    "invalid action",
+			node:    newNode(0, 100, "replace"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.node.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// The types a CoalesceNode can convert values to.
+const (
+	CoalesceAny    = ""
+	CoalesceString = "string"
+	CoalesceInt    = "int"
+	CoalesceFloat  = "float"
+	CoalesceBool   = "bool"
+)
+
+// Sets a field to the value of the first of a list of fields that has a value, like COALESCE in SQL.
+// This merges a value that is stored in different fields depending on its source,
+// e.g. because the fields were renamed between versions of the source.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('sensors')
+//        |coalesce('temperature', 'temp_c', 'temperature_v2', 'temp')
+//            .type('float')
+//            .default(0.0)
+//
+// The above example sets the field `temperature` to the value of `temp_c`, or if the point does not have it,
+// to the value of `temperature_v2` and so on. Points without any of the fields get the default value `0.0`.
+//
+// Fields that are missing or NaN are skipped.
+// The type property converts the values to a type, so that the field has the same type for all points
+// regardless of the field its value came from. Integers and floats are converted to each other,
+// where only floats without a fractional part can be converted to integers,
+// and integers, floats and booleans can be converted to strings.
+// Values that cannot be converted are skipped as well.
+//
+// If none of the fields has a value, the point is passed on unchanged,
+// unless the default property is set, in which case the field is set to the default value,
+// or the dropMissing property is set, in which case the point is dropped.
+// The default value is converted to the type like any other value.
+//
+// Available Statistics:
+//
+//    * missing -- number of points without a value in any of the fields.
+//
+type CoalesceNode struct {
+	chainnode `json:"-"`
+
+	// The name of the field to set.
+	// tick:ignore
+	As string `json:"as"`
+
+	// The fields to take the value from, in order of priority.
+	// tick:ignore
+	Fields []string `json:"fields"`
+
+	// The type of the field, one of string, int, float or bool.
+	// Default is the type of the value.
+	FieldType string `tick:"Type" json:"fieldType"`
+
+	// The value of the field if none of the fields has a value.
+	// tick:ignore
+	DefaultValue interface{} `tick:"Default" json:"default"`
+
+	// Whether to drop points if none of the fields has a value.
+	// tick:ignore
+	DropMissingFlag bool `tick:"DropMissing" json:"dropMissing"`
+}
+
+func newCoalesceNode(e EdgeType, as string, fields []string) *CoalesceNode {
+	return &CoalesceNode{
+		chainnode: newBasicChainNode("coalesce", e, e),
+		As:        as,
+		Fields:    fields,
+	}
+}
+
+// MarshalJSON converts CoalesceNode to JSON
+// tick:ignore
+func (n *CoalesceNode) MarshalJSON() ([]byte, error) {
+	type Alias CoalesceNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		TypeOf: TypeOf{
+			Type: "coalesce",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an CoalesceNode
+// tick:ignore
+func (n *CoalesceNode) UnmarshalJSON(data []byte) error {
+	type Alias CoalesceNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "coalesce" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as CoalesceNode", raw.ID, raw.Type)
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+// Set the type of the field, one of string, int, float or bool.
+// tick:property
+func (n *CoalesceNode) Type(typ string) *CoalesceNode {
+	n.FieldType = typ
+	return n
+}
+
+// Set the field to the value if none of the fields has a value.
+// tick:property
+func (n *CoalesceNode) Default(value interface{}) *CoalesceNode {
+	n.DefaultValue = value
+	return n
+}
+
+// Drop points if none of the fields has a value.
+// tick:property
+func (n *CoalesceNode) DropMissing() *CoalesceNode {
+	n.DropMissingFlag = true
+	return n
+}
+
+func (n *CoalesceNode) validate() error {
+	if n.As == "" {
+		return errors.New("must provide a name for the field")
+	}
+	if len(n.Fields) == 0 {
+		return errors.New("must provide at least one field")
+	}
+	for _, f := range n.Fields {
+		if f == "" {
+			return errors.New("field names must not be empty")
+		}
+	}
+	switch n.FieldType {
+	case CoalesceAny, CoalesceString, CoalesceInt, CoalesceFloat, CoalesceBool:
+	default:
+		return fmt.Errorf("invalid type %q, must be one of %s, %s, %s or %s", n.FieldType, CoalesceString, CoalesceInt, CoalesceFloat, CoalesceBool)
+	}
+	if n.DefaultValue != nil {
+		if n.DropMissingFlag {
+			return errors.New("cannot set a default and drop missing values")
+		}
+		if err := validateCoalesceDefault(n.DefaultValue, n.FieldType); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateCoalesceDefault(v interface{}, typ string) error {
+	switch v := v.(type) {
+	case float64:
+		if math.IsNaN(v) {
+			return errors.New("default cannot be NaN")
+		}
+		switch typ {
+		case CoalesceAny, CoalesceString, CoalesceFloat:
+			return nil
+		case CoalesceInt:
+			if v == math.Trunc(v) && v >= math.MinInt64 && v < math.MaxInt64 {
+				return nil
+			}
+		}
+	case int64:
+		switch typ {
+		case CoalesceAny, CoalesceString, CoalesceInt, CoalesceFloat:
+			return nil
+		}
+	case string:
+		switch typ {
+		case CoalesceAny, CoalesceString:
+			return nil
+		}
+	case bool:
+		switch typ {
+		case CoalesceAny, CoalesceString, CoalesceBool:
+			return nil
+		}
+	default:
+		return fmt.Errorf("unsupported default type %T", v)
+	}
+	return fmt.Errorf("default %v cannot be converted to type %s", v, typ)
+}
@@ -0,0 +1,100 @@
+package pipeline
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCoalesceNode_MarshalJSON(t *testing.T) {
+	c := newCoalesceNode(StreamEdge, "value", []string{"a", "b"})
+	c.Type(CoalesceFloat).Default(0.0)
+	MarshalTestHelper(t, c, false, `{"typeOf":"coalesce","id":"0","as":"value","fields":["a","b"],"fieldType":"float","default":0,"dropMissing":false}`)
+}
+
+func TestCoalesceNode_UnmarshalJSON(t *testing.T) {
+	input := `{"typeOf":"coalesce","id":"0","as":"value","fields":["a","b"],"fieldType":"","default":null,"dropMissing":true}`
+	want := &CoalesceNode{
+		As:              "value",
+		Fields:          []string{"a", "b"},
+		DropMissingFlag: true,
+	}
+	UnmarshalJSONTestHelper(t, []byte(input), &CoalesceNode{}, false, want)
+}
+
+func TestCoalesceNode_Validate(t *testing.T) {
+	newNode := func(typ string, def interface{}) *CoalesceNode {
+		c := newCoalesceNode(StreamEdge, "value", []string{"a", "b"})
+		c.FieldType = typ
+		c.DefaultValue = def
+		return c
+	}
+	tests := []struct {
+		name    string
+		node    *CoalesceNode
+		wantErr bool
+	}{
+		{
+			name: "fields",
+			node: newNode(CoalesceAny, nil),
+		},
+		{
+			name: "int default for float",
+			node: newNode(CoalesceFloat, int64(0)),
+		},
+		{
+			name: "whole float default for int",
+			node: newNode(CoalesceInt, 1.0),
+		},
+		{
+			name: "bool default for string",
+			node: newNode(CoalesceString, false),
+		},
+		{
+			name:    "no fields",
+			node:    newCoalesceNode(StreamEdge, "value", nil),
+			wantErr: true,
+		},
+		{
+			name:    "empty field",
+			node:    newCoalesceNode(StreamEdge, "value", []string{"a", ""}),
+			wantErr: true,
+		},
+		{
+			name:    "no name",
+			node:    newCoalesceNode(StreamEdge, "", []string{"a"}),
+			wantErr: true,
+		},
+		{
+			name:    "invalid type",
+			node:    newNode("time", nil),
+			wantErr: true,
+		},
+		{
+			name:    "fractional default for int",
+			node:    newNode(CoalesceInt, 1.5),
+			wantErr: true,
+		},
+		{
+			name:    "string default for float",
+			node:    newNode(CoalesceFloat, "0"),
+			wantErr: true,
+		},
+		{
+			name:    "NaN default",
+			node:    newNode(CoalesceAny, math.NaN()),
+			wantErr: true,
+		},
+		{
+			name:    "default and drop missing",
+			node:    newNode(CoalesceAny, 0.0).DropMissing(),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.node.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// Concatenate fields, tags and literal strings into string fields.
+// Use this node to build composite keys for outputs,
+// it is simpler than building the string in an eval lambda.
+//
+// Each part of a concatenation is either a reference to a field or tag, in double quotes,
+// or a literal string, in single quotes.
+// A reference resolves to the field with its name, or to the tag with its name if the point has no such field.
+// The parts are joined with the separator.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('requests')
+//        |concatFields()
+//            .concat('key', '/', "region", "host", 'requests')
+//            .concat('label', ' ', 'status', "status_code")
+//            .missing('unknown')
+//
+// The above example sets the field `key` to e.g. `us-west/server01/requests`
+// and the field `label` to e.g. `status 200`.
+//
+// Parts whose field or tag is missing are rendered as the missing property, by default the empty string.
+// Integer and boolean fields are formatted in the usual way,
+// float fields with the number of decimals of the precision property, by default with as few as needed.
+type ConcatFieldsNode struct {
+	chainnode `json:"-"`
+
+	// The concatenations of the node.
+	// tick:ignore
+	Concats []Concat `tick:"Concat" json:"concats"`
+
+	// The string rendered for a missing field or tag.
+	// Default: empty string
+	Missing string `json:"missing"`
+
+	// The number of decimals of float fields, -1 for as few as needed.
+	// Default: -1
+	Precision int64 `json:"precision"`
+}
+
+// Concat is a string field built by concatenating parts.
+type Concat struct {
+	// The name of the field.
+	As string `json:"as"`
+	// The string between parts.
+	Separator string `json:"separator"`
+	// The parts in order.
+	Parts []ConcatPart `json:"parts"`
+}
+
+// ConcatPart is a part of a Concat, either a reference to a field or tag or a literal string.
+type ConcatPart struct {
+	// Whether the value is the name of a field or tag rather than a literal string.
+	Reference bool `json:"reference"`
+	// The name of the field or tag, or the literal string.
+	Value string `json:"value"`
+}
+
+func newConcatFieldsNode(e EdgeType) *ConcatFieldsNode {
+	return &ConcatFieldsNode{
+		chainnode: newBasicChainNode("concatFields", e, e),
+		Precision: -1,
+	}
+}
+
+// MarshalJSON converts ConcatFieldsNode to JSON
+// tick:ignore
+func (n *ConcatFieldsNode) MarshalJSON() ([]byte, error) {
+	type Alias ConcatFieldsNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		TypeOf: TypeOf{
+			Type: "concatFields",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an ConcatFieldsNode
+// tick:ignore
+func (n *ConcatFieldsNode) UnmarshalJSON(data []byte) error {
+	type Alias ConcatFieldsNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "concatFields" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as ConcatFieldsNode", raw.ID, raw.Type)
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+// Concatenate the parts, separated by the separator, into the string field as.
+// Each part is a reference to a field or tag, such as "host", or a literal string, such as 'host'.
+// tick:property
+func (n *ConcatFieldsNode) Concat(as, separator string, parts ...interface{}) *ConcatFieldsNode {
+	c := Concat{
+		As:        as,
+		Separator: separator,
+		Parts:     make([]ConcatPart, len(parts)),
+	}
+	for i, p := range parts {
+		switch p := p.(type) {
+		case string:
+			c.Parts[i] = ConcatPart{Value: p}
+		case *ast.ReferenceNode:
+			c.Parts[i] = ConcatPart{Reference: true, Value: p.Reference}
+		default:
+			panic(fmt.Sprintf("invalid concat part of type %T, must be a reference or a string", p))
+		}
+	}
+	n.Concats = append(n.Concats, c)
+	return n
+}
+
+func (n *ConcatFieldsNode) validate() error {
+	if len(n.Concats) == 0 {
+		return errors.New("must concatenate at least one field")
+	}
+	names := make(map[string]bool, len(n.Concats))
+	for _, c := range n.Concats {
+		if c.As == "" {
+			return errors.New("the name of a concatenated field cannot be empty")
+		}
+		if names[c.As] {
+			return fmt.Errorf("field %q is concatenated more than once", c.As)
+		}
+		names[c.As] = true
+		if len(c.Parts) == 0 {
+			return fmt.Errorf("concatenated field %q must have at least one part", c.As)
+		}
+		for _, p := range c.Parts {
+			if p.Reference && p.Value == "" {
+				return fmt.Errorf("concatenated field %q references an empty name", c.As)
+			}
+		}
+	}
+	if n.Precision < -1 {
+		return fmt.Errorf("precision must be -1 or greater, got %d", n.Precision)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package pipeline
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+func TestConcatFieldsNode_MarshalJSON(t *testing.T) {
+	c := newConcatFieldsNode(StreamEdge)
+	c.Concat("key", "/", &ast.ReferenceNode{Reference: "host"}, "requests")
+	c.Missing = "unknown"
+	c.Precision = 2
+	MarshalTestHelper(t, c, false, `{"typeOf":"concatFields","id":"0","concats":[{"as":"key","separator":"/","parts":[{"reference":true,"value":"host"},{"reference":false,"value":"requests"}]}],"missing":"unknown","precision":2}`)
+}
+
+func TestConcatFieldsNode_UnmarshalJSON(t *testing.T) {
+	input := `{"typeOf":"concatFields","id":"0","concats":[{"as":"key","separator":"/","parts":[{"reference":true,"value":"host"},{"reference":false,"value":"requests"}]}],"missing":"unknown","precision":2}`
+	want := &ConcatFieldsNode{
+		Concats: []Concat{
+			{
+				As:        "key",
+				Separator: "/",
+				Parts: []ConcatPart{
+					{Reference: true, Value: "host"},
+					{Value: "requests"},
+				},
+			},
+		},
+		Missing:   "unknown",
+		Precision: 2,
+	}
+	UnmarshalJSONTestHelper(t, []byte(input), &ConcatFieldsNode{}, false, want)
+}
+
+func TestConcatFieldsNode_Concat(t *testing.T) {
+	c := newConcatFieldsNode(StreamEdge)
+	c.Concat("key", "", "a", &ast.ReferenceNode{Reference: "host"})
+	exp := []Concat{
+		{
+			As: "key",
+			Parts: []ConcatPart{
+				{Value: "a"},
+				{Reference: true, Value: "host"},
+			},
+		},
+	}
+	if !reflect.DeepEqual(c.Concats, exp) {
+		t.Errorf("unexpected concats: got %v exp %v", c.Concats, exp)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for invalid part")
+		}
+	}()
+	c.Concat("other", "", int64(1))
+}
+
+func TestConcatFieldsNode_Validate(t *testing.T) {
+	host := &ast.ReferenceNode{Reference: "host"}
+	tests := []struct {
+		name    string
+		concat  func(c *ConcatFieldsNode)
+		wantErr bool
+	}{
+		{
+			name:   "concat",
+			concat: func(c *ConcatFieldsNode) { c.Concat("key", "/", host, "requests") },
+		},
+		{
+			name:    "no concat",
+			concat:  func(c *ConcatFieldsNode) {},
+			wantErr: true,
+		},
+		{
+			name:    "empty as",
+			concat:  func(c *ConcatFieldsNode) { c.Concat("", "/", host) },
+			wantErr: true,
+		},
+		{
+			name:    "no parts",
+			concat:  func(c *ConcatFieldsNode) { c.Concat("key", "/") },
+			wantErr: true,
+		},
+		{
+			name:    "empty reference",
+			concat:  func(c *ConcatFieldsNode) { c.Concat("key", "/", &ast.ReferenceNode{}) },
+			wantErr: true,
+		},
+		{
+			name: "duplicate field",
+			concat: func(c *ConcatFieldsNode) {
+				c.Concat("key", "/", host)
+				c.Concat("key", "-", host)
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid precision",
+			concat: func(c *ConcatFieldsNode) {
+				c.Concat("key", "/", host)
+				c.Precision = -2
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newConcatFieldsNode(StreamEdge)
+			tt.concat(c)
+			if err := c.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+const defaultCorrelateAs = "correlation"
+
+// Compute the rolling Pearson correlation coefficient between two fields over the last N points of each group.
+// A coefficient close to 1 or -1 indicates the fields increase together or in opposite directions,
+// a coefficient close to 0 indicates no linear relationship.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('requests')
+//            .groupBy('service')
+//        |correlate('latency', 'queue_depth', 100)
+//            .as('latency_queue_correlation')
+//
+// The above example computes the correlation between the latency and the queue depth of each service
+// over the last 100 points, and sets it as a field of each point.
+//
+// With the perWindow property a single point is emitted per window instead:
+// at the end of each batch for a batch edge, or every N points for a stream edge.
+// The emitted point has the time of the batch or of the last point of the window,
+// the group tags and the correlation field only.
+//
+// The coefficient is null until a group has received two points,
+// or if either field has no variance over the window, for example if its value is constant.
+// A null coefficient leaves the field of the point unset, and no point is emitted for a window with a null coefficient.
+// Points without numeric values for both fields are dropped.
+//
+// The values of each group are reset at the start of each batch.
+type CorrelateNode struct {
+	chainnode `json:"-"`
+
+	// The first field to correlate.
+	// tick:ignore
+	X string `json:"x"`
+
+	// The second field to correlate.
+	// tick:ignore
+	Y string `json:"y"`
+
+	// The number of points the correlation is computed over.
+	// tick:ignore
+	Size int64 `json:"size"`
+
+	// The name of the field of the coefficient.
+	// Default: correlation
+	As string `json:"as"`
+
+	// Whether to emit a single point per window instead of setting the coefficient on each point.
+	// tick:ignore
+	PerWindowFlag bool `tick:"PerWindow" json:"perWindow"`
+}
+
+func newCorrelateNode(wants EdgeType, x, y string, size int64) *CorrelateNode {
+	return &CorrelateNode{
+		chainnode: newBasicChainNode("correlate", wants, wants),
+		X:         x,
+		Y:         y,
+		Size:      size,
+		As:        defaultCorrelateAs,
+	}
+}
+
+// MarshalJSON converts CorrelateNode to JSON
+// tick:ignore
+func (n *CorrelateNode) MarshalJSON() ([]byte, error) {
+	type Alias CorrelateNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		TypeOf: TypeOf{
+			Type: "correlate",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an CorrelateNode
+// tick:ignore
+func (n *CorrelateNode) UnmarshalJSON(data []byte) error {
+	type Alias CorrelateNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "correlate" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as CorrelateNode", raw.ID, raw.Type)
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+// Emit a single point with the coefficient per window,
+// at the end of each batch or every N points of a stream.
+// tick:property
+func (n *CorrelateNode) PerWindow() *CorrelateNode {
+	n.PerWindowFlag = true
+	return n
+}
+
+func (n *CorrelateNode) validate() error {
+	if n.X == "" || n.Y == "" {
+		return errors.New("must provide two fields to correlate")
+	}
+	if n.X == n.Y {
+		return errors.New("cannot correlate a field with itself")
+	}
+	if n.Size < 2 {
+		return errors.New("number of points to correlate must be at least 2")
+	}
+	if n.As == "" {
+		return errors.New("must provide a name for the correlation field")
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package pipeline
+
+import (
+	"testing"
+)
+
+func TestCorrelateNode_MarshalJSON(t *testing.T) {
+	c := newCorrelateNode(StreamEdge, "latency", "depth", 100)
+	c.As = "r"
+	c.PerWindow()
+	MarshalTestHelper(t, c, false, `{"typeOf":"correlate","id":"0","x":"latency","y":"depth","size":100,"as":"r","perWindow":true}`)
+}
+
+func TestCorrelateNode_UnmarshalJSON(t *testing.T) {
+	input := `{"typeOf":"correlate","id":"0","x":"latency","y":"depth","size":100,"as":"correlation","perWindow":false}`
+	want := &CorrelateNode{
+		X:    "latency",
+		Y:    "depth",
+		Size: 100,
+		As:   "correlation",
+	}
+	UnmarshalJSONTestHelper(t, []byte(input), &CorrelateNode{}, false, want)
+}
+
+func TestCorrelateNode_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		node    *CorrelateNode
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			node: newCorrelateNode(StreamEdge, "x", "y", 10),
+		},
+		{
+			name:    "no field",
+			node:    newCorrelateNode(StreamEdge, "x", "", 10),
+			wantErr: true,
+		},
+		{
+			name:    "same field",
+			node:    newCorrelateNode(StreamEdge, "x", "x", 10),
+			wantErr: true,
+		},
+		{
+			name:    "single point",
+			node:    newCorrelateNode(StreamEdge, "x", "y", 1),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.node.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
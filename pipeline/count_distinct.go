@@ -0,0 +1,137 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxdb/influxql"
+)
+
+const (
+	defaultCountDistinctAs        = "count_distinct"
+	defaultCountDistinctPrecision = 14
+)
+
+// A CountDistinctNode estimates the number of distinct values of a field or tag per group and window.
+// Instead of storing every value, each group keeps a HyperLogLog sketch of a fixed size,
+// making approximate distinct counts of high cardinality values far cheaper than exact counts.
+//
+// The counted values are those of the field with the given name,
+// or of the tag with that name if the point has no such field.
+//
+// A window closes, emitting the estimate and resetting the sketch:
+//
+//    * at the end of each batch for a batch edge.
+//    * on each barrier for a stream edge, see BarrierNode.
+//    * every interval for a stream edge if the interval property is set,
+//      windows are aligned to multiples of the interval and the emitted point has the time of the end of its window.
+//
+// The precision property sets the size of the sketch to 2^precision bytes,
+// the standard error of the estimate is 1.04/sqrt(2^precision).
+// The default precision of 14 uses 16KB per group with a standard error of about 0.8%.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('requests')
+//            .groupBy('service')
+//        |countDistinct('user')
+//            .interval(1m)
+//            .as('users')
+//
+// The above example emits the estimated number of distinct users of each service every minute.
+//
+type CountDistinctNode struct {
+	chainnode `json:"-"`
+
+	// The field or tag to count the distinct values of.
+	// tick:ignore
+	Field string `json:"field"`
+
+	// The name of the field containing the estimate.
+	// Default: count_distinct
+	As string `json:"as"`
+
+	// The precision of the HyperLogLog sketches, between 4 and 18.
+	// Default: 14
+	Precision int64 `json:"precision"`
+
+	// The interval at which windows of a stream are closed.
+	// If zero, windows are closed by barriers only.
+	Interval time.Duration `json:"interval"`
+}
+
+func newCountDistinctNode(wants EdgeType, field string) *CountDistinctNode {
+	return &CountDistinctNode{
+		chainnode: newBasicChainNode("countDistinct", wants, StreamEdge),
+		Field:     field,
+		As:        defaultCountDistinctAs,
+		Precision: defaultCountDistinctPrecision,
+	}
+}
+
+// MarshalJSON converts CountDistinctNode to JSON
+// tick:ignore
+func (n *CountDistinctNode) MarshalJSON() ([]byte, error) {
+	type Alias CountDistinctNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Interval string `json:"interval"`
+	}{
+		TypeOf: TypeOf{
+			Type: "countDistinct",
+			ID:   n.ID(),
+		},
+		Alias:    (*Alias)(n),
+		Interval: influxql.FormatDuration(n.Interval),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an CountDistinctNode
+// tick:ignore
+func (n *CountDistinctNode) UnmarshalJSON(data []byte) error {
+	type Alias CountDistinctNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Interval string `json:"interval"`
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "countDistinct" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as CountDistinctNode", raw.ID, raw.Type)
+	}
+	n.Interval, err = influxql.ParseDuration(raw.Interval)
+	if err != nil {
+		return err
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+func (n *CountDistinctNode) validate() error {
+	if n.Field == "" {
+		return errors.New("must provide a field to count the distinct values of")
+	}
+	if n.As == "" {
+		return errors.New("as cannot be empty")
+	}
+	if n.Precision < 4 || n.Precision > 18 {
+		return fmt.Errorf("precision must be between 4 and 18, got %d", n.Precision)
+	}
+	if n.Interval < 0 {
+		return errors.New("interval cannot be negative")
+	}
+	if n.Interval > 0 && n.Wants() != StreamEdge {
+		return errors.New("interval can only be used with a stream edge")
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package pipeline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCountDistinctNode_MarshalJSON(t *testing.T) {
+	c := newCountDistinctNode(StreamEdge, "user")
+	c.As = "users"
+	c.Precision = 12
+	c.Interval = time.Minute
+	MarshalTestHelper(t, c, false, `{"typeOf":"countDistinct","id":"0","field":"user","as":"users","precision":12,"interval":"1m"}`)
+}
+
+func TestCountDistinctNode_UnmarshalJSON(t *testing.T) {
+	input := `{"typeOf":"countDistinct","id":"0","field":"user","as":"users","precision":12,"interval":"1m"}`
+	want := &CountDistinctNode{
+		Field:     "user",
+		As:        "users",
+		Precision: 12,
+		Interval:  time.Minute,
+	}
+	UnmarshalJSONTestHelper(t, []byte(input), &CountDistinctNode{}, false, want)
+}
+
+func TestCountDistinctNode_Validate(t *testing.T) {
+	tests := []struct {
+		name      string
+		wants     EdgeType
+		field     string
+		as        string
+		precision int64
+		interval  time.Duration
+		wantErr   bool
+	}{
+		{
+			name:      "stream",
+			wants:     StreamEdge,
+			field:     "user",
+			as:        "users",
+			precision: 14,
+			interval:  time.Minute,
+		},
+		{
+			name:      "batch",
+			wants:     BatchEdge,
+			field:     "user",
+			as:        "users",
+			precision: 4,
+		},
+		{
+			name:      "no field",
+			wants:     StreamEdge,
+			as:        "users",
+			precision: 14,
+			wantErr:   true,
+		},
+		{
+			name:      "empty as",
+			wants:     StreamEdge,
+			field:     "user",
+			precision: 14,
+			wantErr:   true,
+		},
+		{
+			name:      "precision too small",
+			wants:     StreamEdge,
+			field:     "user",
+			as:        "users",
+			precision: 3,
+			wantErr:   true,
+		},
+		{
+			name:      "precision too large",
+			wants:     StreamEdge,
+			field:     "user",
+			as:        "users",
+			precision: 19,
+			wantErr:   true,
+		},
+		{
+			name:      "negative interval",
+			wants:     StreamEdge,
+			field:     "user",
+			as:        "users",
+			precision: 14,
+			interval:  -time.Minute,
+			wantErr:   true,
+		},
+		{
+			name:      "interval on batch edge",
+			wants:     BatchEdge,
+			field:     "user",
+			as:        "users",
+			precision: 14,
+			interval:  time.Minute,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newCountDistinctNode(tt.wants, tt.field)
+			c.As = tt.as
+			c.Precision = tt.precision
+			c.Interval = tt.interval
+			if err := c.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
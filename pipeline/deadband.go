@@ -0,0 +1,121 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Suppress small fluctuations of a field, emitting a point only when the value of the field
+// moved beyond a deadband around the value of the last emitted point.
+// This reduces the write volume of noisy but stable signals, like the metrics of control loops.
+//
+// The deadband is either absolute, with the `absolute` property,
+// or relative to the last emitted value, with the `percent` property.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('valve')
+//            .groupBy('valve_id')
+//        |deadband('position')
+//            .absolute(0.5)
+//        |influxDBOut()
+//            .database('control')
+//
+// The above example writes a point of each valve only when its position changed
+// by more than 0.5 since the last written point.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('flow')
+//        |deadband('rate')
+//            .percent(2.0)
+//
+// The above example emits a point only when the rate changed by more than 2% of the last emitted rate.
+//
+// The first point of each group is always emitted.
+// A value exactly on the edge of the deadband is suppressed.
+// Points without a numeric value of the field are dropped.
+// The last emitted value is tracked per group, and forgotten when the group is deleted.
+//
+// Available Statistics:
+//
+//    * suppressed -- number of points suppressed within the deadband
+//
+type DeadbandNode struct {
+	chainnode `json:"-"`
+
+	// The field of the values.
+	// tick:ignore
+	Field string `json:"field"`
+
+	// The width of the deadband on either side of the last emitted value.
+	Absolute float64 `json:"absolute"`
+
+	// The width of the deadband on either side of the last emitted value,
+	// as a percentage of the last emitted value.
+	Percent float64 `json:"percent"`
+}
+
+func newDeadbandNode(wants EdgeType, field string) *DeadbandNode {
+	return &DeadbandNode{
+		chainnode: newBasicChainNode("deadband", wants, wants),
+		Field:     field,
+	}
+}
+
+// MarshalJSON converts DeadbandNode to JSON
+// tick:ignore
+func (n *DeadbandNode) MarshalJSON() ([]byte, error) {
+	type Alias DeadbandNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		TypeOf: TypeOf{
+			Type: "deadband",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to a DeadbandNode
+// tick:ignore
+func (n *DeadbandNode) UnmarshalJSON(data []byte) error {
+	type Alias DeadbandNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "deadband" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as DeadbandNode", raw.ID, raw.Type)
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+func (n *DeadbandNode) validate() error {
+	if n.Field == "" {
+		return errors.New("must provide a field")
+	}
+	if n.Absolute < 0 {
+		return errors.New("absolute deadband cannot be negative")
+	}
+	if n.Percent < 0 {
+		return errors.New("percent deadband cannot be negative")
+	}
+	if (n.Absolute == 0) == (n.Percent == 0) {
+		return errors.New("must provide exactly one of absolute or percent")
+	}
+	return nil
+}
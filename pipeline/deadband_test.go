@@ -0,0 +1,48 @@
+package pipeline
+
+import (
+	"testing"
+)
+
+func TestDeadbandNode_MarshalJSON(t *testing.T) {
+	d := newDeadbandNode(StreamEdge, "position")
+	d.Absolute = 0.5
+	MarshalTestHelper(t, d, false, `{"typeOf":"deadband","id":"0","field":"position","absolute":0.5,"percent":0}`)
+}
+
+func TestDeadbandNode_UnmarshalJSON(t *testing.T) {
+	input := `{"typeOf":"deadband","id":"0","field":"rate","absolute":0,"percent":2}`
+	want := &DeadbandNode{
+		Field:   "rate",
+		Percent: 2,
+	}
+	UnmarshalJSONTestHelper(t, []byte(input), &DeadbandNode{}, false, want)
+}
+
+func TestDeadbandNode_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		field    string
+		absolute float64
+		percent  float64
+		wantErr  bool
+	}{
+		{name: "absolute", field: "value", absolute: 0.5},
+		{name: "percent", field: "value", percent: 2},
+		{name: "missing field", absolute: 0.5, wantErr: true},
+		{name: "missing deadband", field: "value", wantErr: true},
+		{name: "both deadbands", field: "value", absolute: 0.5, percent: 2, wantErr: true},
+		{name: "negative absolute", field: "value", absolute: -1, wantErr: true},
+		{name: "negative percent", field: "value", percent: -1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newDeadbandNode(StreamEdge, tt.field)
+			d.Absolute = tt.absolute
+			d.Percent = tt.percent
+			if err := d.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Round or truncate float fields to a fixed number of decimals.
+// Use this node to clean up noisy float values before they are written,
+// it reduces their storage and improves their readability.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('cpu')
+//        |decimals()
+//            .round('usage_idle', 2)
+//            .round('usage_user', 2)
+//            .truncate('load', 1)
+//        |influxDBOut()
+//            .database('telegraf')
+//            .measurement('cpu_clean')
+//
+// The above example rounds the fields `usage_idle` and `usage_user` to two decimals
+// and truncates the field `load` to one decimal.
+//
+// Halfway values are rounded to the nearest even decimal by default,
+// so that rounding does not bias sums and means, i.e. 0.125 is rounded to 0.12.
+// Use the halfUp property to round halfway values away from zero instead, i.e. 0.125 is rounded to 0.13
+// and -0.125 to -0.13.
+//
+// Integer fields already have no decimals and are left unchanged, as are non-numeric and missing fields.
+type DecimalsNode struct {
+	chainnode `json:"-"`
+
+	// The number of decimals to round each field to.
+	// tick:ignore
+	RoundFields map[string]int64 `tick:"Round" json:"round"`
+
+	// The number of decimals to truncate each field to.
+	// tick:ignore
+	TruncateFields map[string]int64 `tick:"Truncate" json:"truncate"`
+
+	// Whether to round halfway values away from zero instead of to even.
+	// tick:ignore
+	HalfUpFlag bool `tick:"HalfUp" json:"halfUp"`
+}
+
+func newDecimalsNode(e EdgeType) *DecimalsNode {
+	return &DecimalsNode{
+		chainnode:      newBasicChainNode("decimals", e, e),
+		RoundFields:    make(map[string]int64),
+		TruncateFields: make(map[string]int64),
+	}
+}
+
+// MarshalJSON converts DecimalsNode to JSON
+// tick:ignore
+func (n *DecimalsNode) MarshalJSON() ([]byte, error) {
+	type Alias DecimalsNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		TypeOf: TypeOf{
+			Type: "decimals",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an DecimalsNode
+// tick:ignore
+func (n *DecimalsNode) UnmarshalJSON(data []byte) error {
+	type Alias DecimalsNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "decimals" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as DecimalsNode", raw.ID, raw.Type)
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+// Round a field to a number of decimals.
+// tick:property
+func (n *DecimalsNode) Round(field string, decimals int64) *DecimalsNode {
+	n.RoundFields[field] = decimals
+	return n
+}
+
+// Truncate a field to a number of decimals, dropping the remaining decimals.
+// tick:property
+func (n *DecimalsNode) Truncate(field string, decimals int64) *DecimalsNode {
+	n.TruncateFields[field] = decimals
+	return n
+}
+
+// Round halfway values away from zero instead of to the nearest even decimal.
+// tick:property
+func (n *DecimalsNode) HalfUp() *DecimalsNode {
+	n.HalfUpFlag = true
+	return n
+}
+
+func (n *DecimalsNode) validate() error {
+	if len(n.RoundFields) == 0 && len(n.TruncateFields) == 0 {
+		return errors.New("must round or truncate at least one field")
+	}
+	for field, decimals := range n.RoundFields {
+		if decimals < 0 {
+			return fmt.Errorf("decimals of field %q must not be negative", field)
+		}
+		if _, ok := n.TruncateFields[field]; ok {
+			return fmt.Errorf("field %q cannot be both rounded and truncated", field)
+		}
+	}
+	for field, decimals := range n.TruncateFields {
+		if decimals < 0 {
+			return fmt.Errorf("decimals of field %q must not be negative", field)
+		}
+	}
+	return nil
+}
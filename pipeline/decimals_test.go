@@ -0,0 +1,74 @@
+package pipeline
+
+import (
+	"testing"
+)
+
+func TestDecimalsNode_MarshalJSON(t *testing.T) {
+	d := newDecimalsNode(StreamEdge)
+	d.Round("usage_idle", 2).Truncate("load", 1).HalfUp()
+	MarshalTestHelper(t, d, false, `{"typeOf":"decimals","id":"0","round":{"usage_idle":2},"truncate":{"load":1},"halfUp":true}`)
+}
+
+func TestDecimalsNode_UnmarshalJSON(t *testing.T) {
+	input := `{"typeOf":"decimals","id":"0","round":{"usage_idle":2},"truncate":{"load":1},"halfUp":true}`
+	want := &DecimalsNode{
+		RoundFields:    map[string]int64{"usage_idle": 2},
+		TruncateFields: map[string]int64{"load": 1},
+		HalfUpFlag:     true,
+	}
+	UnmarshalJSONTestHelper(t, []byte(input), &DecimalsNode{}, false, want)
+}
+
+func TestDecimalsNode_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		round    map[string]int64
+		truncate map[string]int64
+		wantErr  bool
+	}{
+		{
+			name:  "round",
+			round: map[string]int64{"value": 2},
+		},
+		{
+			name:     "round and truncate",
+			round:    map[string]int64{"value": 0},
+			truncate: map[string]int64{"other": 3},
+		},
+		{
+			name:    "no fields",
+			wantErr: true,
+		},
+		{
+			name:    "negative round decimals",
+			round:   map[string]int64{"value": -1},
+			wantErr: true,
+		},
+		{
+			name:     "negative truncate decimals",
+			truncate: map[string]int64{"value": -1},
+			wantErr:  true,
+		},
+		{
+			name:     "rounded and truncated",
+			round:    map[string]int64{"value": 1},
+			truncate: map[string]int64{"value": 1},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newDecimalsNode(StreamEdge)
+			for field, decimals := range tt.round {
+				d.Round(field, decimals)
+			}
+			for field, decimals := range tt.truncate {
+				d.Truncate(field, decimals)
+			}
+			if err := d.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// Derives a tag of each point from a named capture group of a regular expression
+// matched against another tag or field.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('cpu')
+//        |deriveTag('env')
+//            .fromTag('host')
+//            .regex(/^(?P<env>[a-z]+)-/)
+//            .missing('unknown')
+//
+// The above example sets the tag `env` to `prod` for the host `prod-web-1`,
+// and to `unknown` for hosts that do not match the regular expression.
+//
+// The value of the capture group named by the capture property is used, by default the group named after the tag.
+// Field values are converted to strings the same as for the FieldToTagNode.
+// If the source is missing or does not match, the tag is set to the missing value.
+// Without a missing value the tag is not set, and an existing tag with the same name is removed
+// unless it is a group by dimension.
+//
+// Deriving a tag does not change the group of a point.
+// For streams, use the regroup property to add the tag to the group by dimensions.
+//
+// Available Statistics:
+//
+//    * unmatched -- number of points whose source was missing or did not match the regular expression.
+//
+type DeriveTagNode struct {
+	chainnode `json:"-"`
+
+	// The name of the derived tag.
+	// tick:ignore
+	Tag string `json:"tag"`
+
+	// The tag to match the regular expression against.
+	// Mutually exclusive with FromField.
+	FromTag string `json:"fromTag"`
+
+	// The field to match the regular expression against.
+	// Mutually exclusive with FromTag.
+	FromField string `json:"fromField"`
+
+	// The regular expression, must contain the capture group.
+	// tick:ignore
+	Pattern *regexp.Regexp `tick:"Regex" json:"-"`
+
+	// The name of the capture group whose value is used for the tag.
+	// Default is the name of the tag.
+	Capture string `json:"capture"`
+
+	// The value of the tag for points whose source is missing or does not match.
+	// If empty the tag is not set.
+	Missing string `json:"missing"`
+
+	// Whether to add the tag to the group by dimensions.
+	// tick:ignore
+	RegroupFlag bool `tick:"Regroup" json:"regroup"`
+}
+
+func newDeriveTagNode(e EdgeType, tag string) *DeriveTagNode {
+	return &DeriveTagNode{
+		chainnode: newBasicChainNode("deriveTag", e, e),
+		Tag:       tag,
+		Capture:   tag,
+	}
+}
+
+// MarshalJSON converts DeriveTagNode to JSON
+// tick:ignore
+func (n *DeriveTagNode) MarshalJSON() ([]byte, error) {
+	type Alias DeriveTagNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Regex string `json:"regex"`
+	}{
+		TypeOf: TypeOf{
+			Type: "deriveTag",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+	}
+	if n.Pattern != nil {
+		raw.Regex = n.Pattern.String()
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an DeriveTagNode
+// tick:ignore
+func (n *DeriveTagNode) UnmarshalJSON(data []byte) error {
+	type Alias DeriveTagNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Regex string `json:"regex"`
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "deriveTag" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as DeriveTagNode", raw.ID, raw.Type)
+	}
+	if raw.Regex != "" {
+		n.Pattern, err = regexp.Compile(raw.Regex)
+		if err != nil {
+			return err
+		}
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+// The regular expression matched against the source tag or field.
+// tick:property
+func (n *DeriveTagNode) Regex(r *regexp.Regexp) *DeriveTagNode {
+	n.Pattern = r
+	return n
+}
+
+// Add the tag to the group by dimensions and regroup the data.
+// Only applies to streams.
+// tick:property
+func (n *DeriveTagNode) Regroup() *DeriveTagNode {
+	n.RegroupFlag = true
+	return n
+}
+
+func (n *DeriveTagNode) validate() error {
+	if n.Tag == "" {
+		return errors.New("must provide a name for the tag")
+	}
+	if n.FromTag == "" && n.FromField == "" {
+		return errors.New("must provide one of fromTag or fromField")
+	}
+	if n.FromTag != "" && n.FromField != "" {
+		return errors.New("cannot use both fromTag and fromField")
+	}
+	if n.Pattern == nil {
+		return errors.New("must provide a regex")
+	}
+	if n.Capture == "" {
+		return errors.New("must provide a capture group")
+	}
+	if n.Pattern.SubexpIndex(n.Capture) < 0 {
+		return fmt.Errorf("regex %q has no capture group named %q", n.Pattern.String(), n.Capture)
+	}
+	if n.RegroupFlag && n.Provides() != StreamEdge {
+		return errors.New("regroup can only be used with a stream edge")
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package pipeline
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestDeriveTagNode_MarshalJSON(t *testing.T) {
+	d := newDeriveTagNode(StreamEdge, "env")
+	d.FromTag = "host"
+	d.Regex(regexp.MustCompile(`^(?P<env>[a-z]+)-`))
+	d.Missing = "unknown"
+	d.Regroup()
+	MarshalTestHelper(t, d, false, `{"typeOf":"deriveTag","id":"0","tag":"env","fromTag":"host","fromField":"","capture":"env","missing":"unknown","regroup":true,"regex":"^(?P\u003cenv\u003e[a-z]+)-"}`)
+}
+
+func TestDeriveTagNode_UnmarshalJSON(t *testing.T) {
+	input := `{"typeOf":"deriveTag","id":"0","tag":"dc","fromTag":"","fromField":"hostname","capture":"datacenter","missing":"","regroup":false,"regex":"\\.(?P<datacenter>\\w+)$"}`
+	want := &DeriveTagNode{
+		Tag:       "dc",
+		FromField: "hostname",
+		Pattern:   regexp.MustCompile(`\.(?P<datacenter>\w+)$`),
+		Capture:   "datacenter",
+	}
+	UnmarshalJSONTestHelper(t, []byte(input), &DeriveTagNode{}, false, want)
+}
+
+func TestDeriveTagNode_Validate(t *testing.T) {
+	newNode := func(e EdgeType, fromTag, fromField, regex string) *DeriveTagNode {
+		d := newDeriveTagNode(e, "env")
+		d.FromTag = fromTag
+		d.FromField = fromField
+		if regex != "" {
+			d.Regex(regexp.MustCompile(regex))
+		}
+		return d
+	}
+	otherCapture := newNode(StreamEdge, "host", "", `^(?P<environment>\w+)-`)
+	otherCapture.Capture = "environment"
+	tests := []struct {
+		name    string
+		node    *DeriveTagNode
+		wantErr bool
+	}{
+		{
+			name: "valid tag",
+			node: newNode(StreamEdge, "host", "", `^(?P<env>\w+)-`),
+		},
+		{
+			name: "valid field",
+			node: newNode(BatchEdge, "", "hostname", `^(?P<env>\w+)-`),
+		},
+		{
+			name: "valid capture",
+			node: otherCapture,
+		},
+		{
+			name:    "no source",
+			node:    newNode(StreamEdge, "", "", `^(?P<env>\w+)-`),
+			wantErr: true,
+		},
+		{
+			name:    "both sources",
+			node:    newNode(StreamEdge, "host", "hostname", `^(?P<env>\w+)-`),
+			wantErr: true,
+		},
+		{
+			name:    "no regex",
+			node:    newNode(StreamEdge, "host", "", ""),
+			wantErr: true,
+		},
+		{
+			name:    "missing capture group",
+			node:    newNode(StreamEdge, "host", "", `^(\w+)-`),
+			wantErr: true,
+		},
+		{
+			name:    "regroup batch",
+			node:    newNode(BatchEdge, "host", "", `^(?P<env>\w+)-`).Regroup(),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.node.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
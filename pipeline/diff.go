@@ -0,0 +1,149 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Compute the difference between two fields of each point.
+// This is most useful after a join to compare two series.
+//
+// Example:
+//    var today = stream
+//        |from()
+//            .measurement('requests')
+//    var lastWeek = stream
+//        |from()
+//            .measurement('requests')
+//        |shift(1w)
+//    today
+//        |join(lastWeek)
+//            .as('today', 'last_week')
+//        |diff('today.value', 'last_week.value')
+//            .percentAs('change')
+//
+// The above example compares the number of requests with the number of requests a week earlier.
+//
+// Three fields are added to each point:
+//
+//    * difference -- the value of the field minus the value of the baseline field.
+//    * absolute difference -- the absolute value of the difference.
+//    * percent change -- the difference as a percentage of the absolute value of the baseline field,
+//                        i.e. positive when the field is greater than the baseline field even if the baseline is negative.
+//
+// The percent change is undefined if the baseline is zero, in which case the percent change field is not set.
+// Use the zeroBaseline property to set it to a sentinel value instead.
+//
+// Points where either field is missing or not numeric are dropped.
+type DiffNode struct {
+	chainnode `json:"-"`
+
+	// The field to compare.
+	// tick:ignore
+	Field string `json:"field"`
+
+	// The field to compare against.
+	// tick:ignore
+	Baseline string `json:"baseline"`
+
+	// The name of the difference field.
+	// Default: difference
+	DifferenceAs string `json:"differenceAs"`
+
+	// The name of the absolute difference field.
+	// Default: abs_difference
+	AbsoluteAs string `json:"absoluteAs"`
+
+	// The name of the percent change field.
+	// Default: percent_change
+	PercentAs string `json:"percentAs"`
+
+	// Whether to set the percent change to ZeroBaselineValue when the baseline is zero.
+	// tick:ignore
+	UseZeroBaseline bool `tick:"ZeroBaseline" json:"zeroBaseline"`
+
+	// The percent change when the baseline is zero.
+	// tick:ignore
+	ZeroBaselineValue float64 `json:"zeroBaselineValue"`
+}
+
+func newDiffNode(wants EdgeType, field, baseline string) *DiffNode {
+	return &DiffNode{
+		chainnode:    newBasicChainNode("diff", wants, wants),
+		Field:        field,
+		Baseline:     baseline,
+		DifferenceAs: "difference",
+		AbsoluteAs:   "abs_difference",
+		PercentAs:    "percent_change",
+	}
+}
+
+// MarshalJSON converts DiffNode to JSON
+// tick:ignore
+func (n *DiffNode) MarshalJSON() ([]byte, error) {
+	type Alias DiffNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		TypeOf: TypeOf{
+			Type: "diff",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an DiffNode
+// tick:ignore
+func (n *DiffNode) UnmarshalJSON(data []byte) error {
+	type Alias DiffNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "diff" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as DiffNode", raw.ID, raw.Type)
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+// Set the percent change to value instead of leaving it unset when the baseline is zero.
+//
+// Example:
+//    |diff('today.value', 'last_week.value')
+//        .zeroBaseline(0.0)
+//
+// tick:property
+func (n *DiffNode) ZeroBaseline(value float64) *DiffNode {
+	n.UseZeroBaseline = true
+	n.ZeroBaselineValue = value
+	return n
+}
+
+func (n *DiffNode) validate() error {
+	if n.Field == "" || n.Baseline == "" {
+		return errors.New("must provide the field and the baseline field to compare")
+	}
+	names := []string{n.DifferenceAs, n.AbsoluteAs, n.PercentAs}
+	for i, name := range names {
+		if name == "" {
+			return errors.New("must provide names for the difference, absolute difference and percent change fields")
+		}
+		for _, other := range names[i+1:] {
+			if name == other {
+				return fmt.Errorf("cannot use the same name %q for multiple fields", name)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package pipeline
+
+import (
+	"testing"
+)
+
+func TestDiffNode_MarshalJSON(t *testing.T) {
+	d := newDiffNode(StreamEdge, "today.value", "last_week.value")
+	d.PercentAs = "change"
+	d.ZeroBaseline(-1)
+	MarshalTestHelper(t, d, false, `{"typeOf":"diff","id":"0","field":"today.value","baseline":"last_week.value","differenceAs":"difference","absoluteAs":"abs_difference","percentAs":"change","zeroBaseline":true,"zeroBaselineValue":-1}`)
+}
+
+func TestDiffNode_UnmarshalJSON(t *testing.T) {
+	input := `{"typeOf":"diff","id":"0","field":"a","baseline":"b","differenceAs":"d","absoluteAs":"abs","percentAs":"pct","zeroBaseline":false,"zeroBaselineValue":0}`
+	want := &DiffNode{
+		Field:        "a",
+		Baseline:     "b",
+		DifferenceAs: "d",
+		AbsoluteAs:   "abs",
+		PercentAs:    "pct",
+	}
+	UnmarshalJSONTestHelper(t, []byte(input), &DiffNode{}, false, want)
+}
+
+func TestDiffNode_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(d *DiffNode)
+		wantErr bool
+	}{
+		{
+			name:  "valid",
+			setup: func(d *DiffNode) {},
+		},
+		{
+			name:    "no baseline",
+			setup:   func(d *DiffNode) { d.Baseline = "" },
+			wantErr: true,
+		},
+		{
+			name:    "empty name",
+			setup:   func(d *DiffNode) { d.AbsoluteAs = "" },
+			wantErr: true,
+		},
+		{
+			name:    "duplicate names",
+			setup:   func(d *DiffNode) { d.PercentAs = d.DifferenceAs },
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newDiffNode(StreamEdge, "a", "b")
+			tt.setup(d)
+			if err := d.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
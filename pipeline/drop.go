@@ -0,0 +1,263 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+)
+
+// Drops tags from data points.
+// Tags are selected by name, where each name may be a glob pattern
+// following the syntax of Go's path.Match, i.e. `*`, `?` and `[...]` are supported.
+// Tags can additionally be selected with regular expressions via the regex property.
+//
+// Example:
+//    stream
+//        |dropTags('host', 'tmp_*')
+//            .regex(/^debug_/)
+//
+// The above example removes the tag `host`, any tag beginning with `tmp_`
+// and any tag matching the regular expression `^debug_` from each point.
+//
+// Dropping a tag that is also a group by dimension changes the group of the point.
+// By default tags that are group by dimensions are never dropped, so that the
+// grouping of the data is preserved.
+// Use the regroup property to also drop matching dimension tags,
+// in which case the dimension is removed from the grouping and the
+// group of each point is recomputed from the remaining dimensions.
+//
+// Example:
+//    stream
+//        |groupBy('host', 'cpu')
+//        |dropTags('cpu')
+//            .regroup()
+//
+// The above example drops the `cpu` tag and regroups the data by `host` only.
+//
+// Available Statistics:
+//
+//    * tags_dropped -- number of tags that were dropped.
+//
+type DropTagsNode struct {
+	chainnode `json:"-"`
+
+	// Set of tag names or glob patterns to drop.
+	// tick:ignore
+	Tags []string `json:"tags"`
+
+	// Set of regular expressions matching tag names to drop.
+	// tick:ignore
+	Regexes []*regexp.Regexp `tick:"Regex" json:"-"`
+
+	// Whether to drop matching group by dimensions and regroup the data.
+	// tick:ignore
+	RegroupFlag bool `tick:"Regroup" json:"regroup"`
+}
+
+func newDropTagsNode(e EdgeType, tags []string) *DropTagsNode {
+	return &DropTagsNode{
+		chainnode: newBasicChainNode("dropTags", e, e),
+		Tags:      tags,
+	}
+}
+
+// MarshalJSON converts DropTagsNode to JSON
+// tick:ignore
+func (n *DropTagsNode) MarshalJSON() ([]byte, error) {
+	type Alias DropTagsNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Regexes []string `json:"regexes"`
+	}{
+		TypeOf: TypeOf{
+			Type: "dropTags",
+			ID:   n.ID(),
+		},
+		Alias:   (*Alias)(n),
+		Regexes: regexStrings(n.Regexes),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an DropTagsNode
+// tick:ignore
+func (n *DropTagsNode) UnmarshalJSON(data []byte) error {
+	type Alias DropTagsNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Regexes []string `json:"regexes"`
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "dropTags" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as DropTagsNode", raw.ID, raw.Type)
+	}
+	n.Regexes, err = compileRegexes(raw.Regexes)
+	if err != nil {
+		return err
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+func (n *DropTagsNode) validate() error {
+	return validateDropPatterns(n.Tags, n.Regexes)
+}
+
+// Drop tags whose names match the regular expression.
+// tick:property
+func (n *DropTagsNode) Regex(r *regexp.Regexp) *DropTagsNode {
+	n.Regexes = append(n.Regexes, r)
+	return n
+}
+
+// If set, tags that are group by dimensions are dropped as well
+// and the data is regrouped by the remaining dimensions.
+// tick:property
+func (n *DropTagsNode) Regroup() *DropTagsNode {
+	n.RegroupFlag = true
+	return n
+}
+
+// Drops fields from data points.
+// Fields are selected by name, where each name may be a glob pattern
+// following the syntax of Go's path.Match, i.e. `*`, `?` and `[...]` are supported.
+// Fields can additionally be selected with regular expressions via the regex property.
+//
+// Example:
+//    stream
+//        |dropFields('raw', 'debug_*')
+//            .regex(/_tmp$/)
+//
+// The above example removes the field `raw`, any field beginning with `debug_`
+// and any field ending in `_tmp` from each point.
+//
+// Available Statistics:
+//
+//    * fields_dropped -- number of fields that were dropped.
+//
+type DropFieldsNode struct {
+	chainnode `json:"-"`
+
+	// Set of field names or glob patterns to drop.
+	// tick:ignore
+	Fields []string `json:"fields"`
+
+	// Set of regular expressions matching field names to drop.
+	// tick:ignore
+	Regexes []*regexp.Regexp `tick:"Regex" json:"-"`
+}
+
+func newDropFieldsNode(e EdgeType, fields []string) *DropFieldsNode {
+	return &DropFieldsNode{
+		chainnode: newBasicChainNode("dropFields", e, e),
+		Fields:    fields,
+	}
+}
+
+// MarshalJSON converts DropFieldsNode to JSON
+// tick:ignore
+func (n *DropFieldsNode) MarshalJSON() ([]byte, error) {
+	type Alias DropFieldsNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Regexes []string `json:"regexes"`
+	}{
+		TypeOf: TypeOf{
+			Type: "dropFields",
+			ID:   n.ID(),
+		},
+		Alias:   (*Alias)(n),
+		Regexes: regexStrings(n.Regexes),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an DropFieldsNode
+// tick:ignore
+func (n *DropFieldsNode) UnmarshalJSON(data []byte) error {
+	type Alias DropFieldsNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Regexes []string `json:"regexes"`
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "dropFields" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as DropFieldsNode", raw.ID, raw.Type)
+	}
+	n.Regexes, err = compileRegexes(raw.Regexes)
+	if err != nil {
+		return err
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+func (n *DropFieldsNode) validate() error {
+	return validateDropPatterns(n.Fields, n.Regexes)
+}
+
+// Drop fields whose names match the regular expression.
+// tick:property
+func (n *DropFieldsNode) Regex(r *regexp.Regexp) *DropFieldsNode {
+	n.Regexes = append(n.Regexes, r)
+	return n
+}
+
+func validateDropPatterns(patterns []string, regexes []*regexp.Regexp) error {
+	if len(patterns) == 0 && len(regexes) == 0 {
+		return errors.New("must provide at least one name or regex to drop")
+	}
+	for _, p := range patterns {
+		if p == "" {
+			return errors.New("names to drop cannot be the empty string")
+		}
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("invalid glob pattern %q: %v", p, err)
+		}
+	}
+	for _, r := range regexes {
+		if r == nil {
+			return errors.New("regex cannot be nil")
+		}
+	}
+	return nil
+}
+
+func regexStrings(regexes []*regexp.Regexp) []string {
+	strs := make([]string, len(regexes))
+	for i, r := range regexes {
+		strs[i] = r.String()
+	}
+	return strs
+}
+
+func compileRegexes(strs []string) ([]*regexp.Regexp, error) {
+	if len(strs) == 0 {
+		return nil, nil
+	}
+	regexes := make([]*regexp.Regexp, len(strs))
+	for i, s := range strs {
+		r, err := regexp.Compile(s)
+		if err != nil {
+			return nil, err
+		}
+		regexes[i] = r
+	}
+	return regexes, nil
+}
@@ -0,0 +1,68 @@
+package pipeline
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestDropTagsNode_MarshalJSON(t *testing.T) {
+	d := newDropTagsNode(StreamEdge, []string{"host", "tmp_*"})
+	d.Regex(regexp.MustCompile("^debug_"))
+	d.Regroup()
+	MarshalTestHelper(t, d, false, `{"typeOf":"dropTags","id":"0","tags":["host","tmp_*"],"regroup":true,"regexes":["^debug_"]}`)
+}
+
+func TestDropTagsNode_UnmarshalJSON(t *testing.T) {
+	input := `{"typeOf":"dropTags","id":"0","tags":["host","tmp_*"],"regroup":true,"regexes":["^debug_"]}`
+	want := &DropTagsNode{
+		Tags:        []string{"host", "tmp_*"},
+		Regexes:     []*regexp.Regexp{regexp.MustCompile("^debug_")},
+		RegroupFlag: true,
+	}
+	UnmarshalJSONTestHelper(t, []byte(input), &DropTagsNode{}, false, want)
+}
+
+func TestDropFieldsNode_MarshalJSON(t *testing.T) {
+	d := newDropFieldsNode(StreamEdge, []string{"raw"})
+	d.Regex(regexp.MustCompile("_tmp$"))
+	MarshalTestHelper(t, d, false, `{"typeOf":"dropFields","id":"0","fields":["raw"],"regexes":["_tmp$"]}`)
+}
+
+func TestDropNodes_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		node    interface{ validate() error }
+		wantErr bool
+	}{
+		{
+			name: "glob",
+			node: newDropTagsNode(StreamEdge, []string{"tmp_*", "host?"}),
+		},
+		{
+			name: "regex only",
+			node: newDropFieldsNode(StreamEdge, nil).Regex(regexp.MustCompile("x")),
+		},
+		{
+			name:    "nothing to drop",
+			node:    newDropFieldsNode(StreamEdge, nil),
+			wantErr: true,
+		},
+		{
+			name:    "empty name",
+			node:    newDropTagsNode(StreamEdge, []string{""}),
+			wantErr: true,
+		},
+		{
+			name:    "bad glob",
+			node:    newDropTagsNode(StreamEdge, []string{"tmp_["}),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.node.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// The units of a DurationFormatNode.
+const (
+	DurationNanoseconds  = "ns"
+	DurationMicroseconds = "us"
+	DurationMilliseconds = "ms"
+	DurationSeconds      = "s"
+	DurationMinutes      = "m"
+	DurationHours        = "h"
+	DurationDays         = "d"
+)
+
+// DurationFormatUnit returns the duration of a unit of a DurationFormatNode.
+// Returns false if the unit is unknown.
+func DurationFormatUnit(unit string) (time.Duration, bool) {
+	switch unit {
+	case DurationNanoseconds:
+		return time.Nanosecond, true
+	case DurationMicroseconds:
+		return time.Microsecond, true
+	case DurationMilliseconds:
+		return time.Millisecond, true
+	case DurationSeconds:
+		return time.Second, true
+	case DurationMinutes:
+		return time.Minute, true
+	case DurationHours:
+		return time.Hour, true
+	case DurationDays:
+		return 24 * time.Hour, true
+	}
+	return 0, false
+}
+
+// Formats a numeric duration field as a human readable string field, e.g. `2h15m`,
+// for use in the templates of alert messages.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('jobs')
+//        |humanize('elapsed', 'elapsed_human')
+//            .unit('ms')
+//        |alert()
+//            .warn(lambda: "elapsed" > 3600000)
+//            .message('job {{ index .Tags "job" }} running for {{ index .Fields "elapsed_human" }}')
+//
+// The above example formats the field `elapsed`, a number of milliseconds, e.g. `8103000` as `2h15m3s`.
+//
+// The unit is the unit of the numeric value, one of ns, us, ms, s, m, h or d, nanoseconds by default.
+// Both integer and float values are formatted.
+// The precision is the smallest unit of the formatted duration, one of the same units, seconds by default.
+// The duration is rounded to the precision and units that are zero are left out,
+// so that a precision of m formats `2h15m3s` as `2h15m`.
+// A zero duration is formatted as zero of the precision, e.g. `0s`.
+//
+// Points without the field, or whose field is not a number, are passed on unchanged.
+type DurationFormatNode struct {
+	chainnode `json:"-"`
+
+	// The name of the field with the duration.
+	// tick:ignore
+	Field string `json:"field"`
+
+	// The name of the field with the formatted duration.
+	// tick:ignore
+	As string `json:"as"`
+
+	// The unit of the duration, one of ns, us, ms, s, m, h or d.
+	// Default: ns
+	Unit string `json:"unit"`
+
+	// The smallest unit of the formatted duration, one of ns, us, ms, s, m, h or d.
+	// Default: s
+	Precision string `json:"precision"`
+}
+
+func newDurationFormatNode(e EdgeType, field, as string) *DurationFormatNode {
+	return &DurationFormatNode{
+		chainnode: newBasicChainNode("humanize", e, e),
+		Field:     field,
+		As:        as,
+		Unit:      DurationNanoseconds,
+		Precision: DurationSeconds,
+	}
+}
+
+// MarshalJSON converts DurationFormatNode to JSON
+// tick:ignore
+func (n *DurationFormatNode) MarshalJSON() ([]byte, error) {
+	type Alias DurationFormatNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		TypeOf: TypeOf{
+			Type: "humanize",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an DurationFormatNode
+// tick:ignore
+func (n *DurationFormatNode) UnmarshalJSON(data []byte) error {
+	type Alias DurationFormatNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "humanize" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as DurationFormatNode", raw.ID, raw.Type)
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+func (n *DurationFormatNode) validate() error {
+	if n.Field == "" {
+		return errors.New("must provide a field with the duration")
+	}
+	if n.As == "" {
+		return errors.New("must provide a name for the formatted field")
+	}
+	if _, ok := DurationFormatUnit(n.Unit); !ok {
+		return fmt.Errorf("invalid unit %q, must be one of ns, us, ms, s, m, h or d", n.Unit)
+	}
+	if _, ok := DurationFormatUnit(n.Precision); !ok {
+		return fmt.Errorf("invalid precision %q, must be one of ns, us, ms, s, m, h or d", n.Precision)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package pipeline
+
+import "testing"
+
+func TestDurationFormatNode_MarshalJSON(t *testing.T) {
+	d := newDurationFormatNode(StreamEdge, "elapsed", "elapsed_human")
+	d.Unit = DurationMilliseconds
+	MarshalTestHelper(t, d, false, `{"typeOf":"humanize","id":"0","field":"elapsed","as":"elapsed_human","unit":"ms","precision":"s"}`)
+}
+
+func TestDurationFormatNode_UnmarshalJSON(t *testing.T) {
+	input := `{"typeOf":"humanize","id":"0","field":"elapsed","as":"elapsed_human","unit":"s","precision":"m"}`
+	want := &DurationFormatNode{
+		Field:     "elapsed",
+		As:        "elapsed_human",
+		Unit:      DurationSeconds,
+		Precision: DurationMinutes,
+	}
+	UnmarshalJSONTestHelper(t, []byte(input), &DurationFormatNode{}, false, want)
+}
+
+func TestDurationFormatNode_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		node    func(d *DurationFormatNode)
+		wantErr bool
+	}{
+		{
+			name: "defaults",
+			node: func(d *DurationFormatNode) {},
+		},
+		{
+			name:    "no field",
+			node:    func(d *DurationFormatNode) { d.Field = "" },
+			wantErr: true,
+		},
+		{
+			name:    "no as",
+			node:    func(d *DurationFormatNode) { d.As = "" },
+			wantErr: true,
+		},
+		{
+			name:    "invalid unit",
+			node:    func(d *DurationFormatNode) { d.Unit = "w" },
+			wantErr: true,
+		},
+		{
+			name:    "invalid precision",
+			node:    func(d *DurationFormatNode) { d.Precision = "1s" },
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newDurationFormatNode(StreamEdge, "elapsed", "elapsed_human")
+			tt.node(d)
+			if err := d.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
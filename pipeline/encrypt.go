@@ -0,0 +1,182 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Encrypts the values of fields.
+// Each value is encrypted with AES-GCM and replaced with its base64 ciphertext,
+// which is prefixed with the ID of the key that was used.
+//
+// Keys are configured in the `[encryption]` section of the Kapacitor configuration,
+// either directly or as a reference to an environment variable.
+// Key material can never be specified in a TICKscript.
+// Values are always encrypted with the configured active key.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('signups')
+//        |encrypt('email', 'ssn')
+//        |influxDBOut()
+//            .database('audit')
+//
+// The above example encrypts the `email` and `ssn` fields before writing the points to InfluxDB.
+//
+// Only string fields can be encrypted.
+// Points that do not have a field are passed through unchanged,
+// points whose field cannot be encrypted are dropped.
+//
+// Available Statistics:
+//
+//    * encrypt_errors -- number of points dropped because a field could not be encrypted.
+//
+type EncryptNode struct {
+	chainnode `json:"-"`
+
+	// Names of the fields to encrypt.
+	// tick:ignore
+	Fields []string `json:"fields"`
+}
+
+func newEncryptNode(e EdgeType, fields []string) *EncryptNode {
+	return &EncryptNode{
+		chainnode: newBasicChainNode("encrypt", e, e),
+		Fields:    fields,
+	}
+}
+
+// MarshalJSON converts EncryptNode to JSON
+// tick:ignore
+func (n *EncryptNode) MarshalJSON() ([]byte, error) {
+	type Alias EncryptNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		TypeOf: TypeOf{
+			Type: "encrypt",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an EncryptNode
+// tick:ignore
+func (n *EncryptNode) UnmarshalJSON(data []byte) error {
+	type Alias EncryptNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "encrypt" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as EncryptNode", raw.ID, raw.Type)
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+func (n *EncryptNode) validate() error {
+	return validateCryptFields(n.Fields)
+}
+
+// Decrypts the values of fields that were encrypted by an encrypt node.
+// Each value is decrypted with the key whose ID prefixes the ciphertext,
+// so values encrypted before a key rotation can be decrypted
+// as long as the previous key is still configured.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('signups')
+//        |decrypt('email')
+//        |httpOut('signups')
+//
+// The above example decrypts the `email` field of each point.
+//
+// Points that do not have a field are passed through unchanged.
+// Points whose field cannot be decrypted, for example because the key is unknown
+// or the ciphertext was encrypted with a different key, are dropped.
+//
+// Available Statistics:
+//
+//    * decrypt_errors -- number of points dropped because a field could not be decrypted.
+//
+type DecryptNode struct {
+	chainnode `json:"-"`
+
+	// Names of the fields to decrypt.
+	// tick:ignore
+	Fields []string `json:"fields"`
+}
+
+func newDecryptNode(e EdgeType, fields []string) *DecryptNode {
+	return &DecryptNode{
+		chainnode: newBasicChainNode("decrypt", e, e),
+		Fields:    fields,
+	}
+}
+
+// MarshalJSON converts DecryptNode to JSON
+// tick:ignore
+func (n *DecryptNode) MarshalJSON() ([]byte, error) {
+	type Alias DecryptNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		TypeOf: TypeOf{
+			Type: "decrypt",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an DecryptNode
+// tick:ignore
+func (n *DecryptNode) UnmarshalJSON(data []byte) error {
+	type Alias DecryptNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "decrypt" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as DecryptNode", raw.ID, raw.Type)
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+func (n *DecryptNode) validate() error {
+	return validateCryptFields(n.Fields)
+}
+
+func validateCryptFields(fields []string) error {
+	if len(fields) == 0 {
+		return errors.New("must provide at least one field")
+	}
+	for _, f := range fields {
+		if f == "" {
+			return errors.New("field names must not be empty")
+		}
+	}
+	return nil
+}
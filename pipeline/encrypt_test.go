@@ -0,0 +1,65 @@
+package pipeline
+
+import (
+	"testing"
+)
+
+func TestEncryptNode_MarshalJSON(t *testing.T) {
+	e := newEncryptNode(StreamEdge, []string{"email", "ssn"})
+	MarshalTestHelper(t, e, false, `{"typeOf":"encrypt","id":"0","fields":["email","ssn"]}`)
+}
+
+func TestEncryptNode_UnmarshalJSON(t *testing.T) {
+	input := `{"typeOf":"encrypt","id":"0","fields":["email","ssn"]}`
+	want := &EncryptNode{
+		Fields: []string{"email", "ssn"},
+	}
+	UnmarshalJSONTestHelper(t, []byte(input), &EncryptNode{}, false, want)
+}
+
+func TestDecryptNode_MarshalJSON(t *testing.T) {
+	d := newDecryptNode(StreamEdge, []string{"email"})
+	MarshalTestHelper(t, d, false, `{"typeOf":"decrypt","id":"0","fields":["email"]}`)
+}
+
+func TestDecryptNode_UnmarshalJSON(t *testing.T) {
+	input := `{"typeOf":"decrypt","id":"0","fields":["email"]}`
+	want := &DecryptNode{
+		Fields: []string{"email"},
+	}
+	UnmarshalJSONTestHelper(t, []byte(input), &DecryptNode{}, false, want)
+}
+
+func TestCryptNodes_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		node    interface{ validate() error }
+		wantErr bool
+	}{
+		{
+			name: "encrypt",
+			node: newEncryptNode(StreamEdge, []string{"email"}),
+		},
+		{
+			name: "decrypt",
+			node: newDecryptNode(BatchEdge, []string{"email", "ssn"}),
+		},
+		{
+			name:    "no fields",
+			node:    newEncryptNode(StreamEdge, nil),
+			wantErr: true,
+		},
+		{
+			name:    "empty name",
+			node:    newDecryptNode(StreamEdge, []string{""}),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.node.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,271 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// The types a TagToFieldNode can convert tag values to.
+const (
+	TagToFieldString = "string"
+	TagToFieldInt    = "int"
+	TagToFieldFloat  = "float"
+	TagToFieldBool   = "bool"
+)
+
+// Converts a field of each point to a tag.
+// The value of the field is converted to a string, the field is removed unless the keep property is set.
+// Points without the field are not modified, an existing tag with the same name is replaced.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('requests')
+//        |fieldToTag('request_id')
+//            .as('id')
+//
+// The above example moves the value of the field `request_id` to the tag `id`.
+//
+// Integers and booleans are converted exactly.
+// Floats are converted to the shortest decimal representation that parses back to the same value,
+// which may differ from the value that was written, e.g. 0.1 + 0.2 is converted to `0.30000000000000004`.
+// Values that are meant to be equal but differ by rounding errors produce different tags,
+// round floats before converting them if they are used as identifiers.
+//
+// Converting a field to a tag does not change the group of a point.
+// For streams, use the regroup property to add the tag to the group by dimensions,
+// grouping the data by the value of the field.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('requests')
+//            .groupBy('host')
+//        |fieldToTag('tenant')
+//            .regroup()
+//
+// The above example groups the data by `host` and `tenant`.
+type FieldToTagNode struct {
+	chainnode `json:"-"`
+
+	// The field to convert.
+	// tick:ignore
+	Field string `json:"field"`
+
+	// The name of the tag.
+	// Default is the name of the field.
+	As string `json:"as"`
+
+	// Whether to keep the field.
+	// tick:ignore
+	KeepFlag bool `tick:"Keep" json:"keep"`
+
+	// Whether to add the tag to the group by dimensions.
+	// tick:ignore
+	RegroupFlag bool `tick:"Regroup" json:"regroup"`
+}
+
+func newFieldToTagNode(e EdgeType, field string) *FieldToTagNode {
+	return &FieldToTagNode{
+		chainnode: newBasicChainNode("fieldToTag", e, e),
+		Field:     field,
+		As:        field,
+	}
+}
+
+// MarshalJSON converts FieldToTagNode to JSON
+// tick:ignore
+func (n *FieldToTagNode) MarshalJSON() ([]byte, error) {
+	type Alias FieldToTagNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		TypeOf: TypeOf{
+			Type: "fieldToTag",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an FieldToTagNode
+// tick:ignore
+func (n *FieldToTagNode) UnmarshalJSON(data []byte) error {
+	type Alias FieldToTagNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "fieldToTag" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as FieldToTagNode", raw.ID, raw.Type)
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+// Keep the field, copying its value to the tag instead of moving it.
+// tick:property
+func (n *FieldToTagNode) Keep() *FieldToTagNode {
+	n.KeepFlag = true
+	return n
+}
+
+// Add the tag to the group by dimensions and regroup the data.
+// Only applies to streams.
+// tick:property
+func (n *FieldToTagNode) Regroup() *FieldToTagNode {
+	n.RegroupFlag = true
+	return n
+}
+
+func (n *FieldToTagNode) validate() error {
+	if n.Field == "" {
+		return errors.New("must provide a field to convert")
+	}
+	if n.As == "" {
+		return errors.New("must provide a name for the tag")
+	}
+	if n.RegroupFlag && n.Provides() != StreamEdge {
+		return errors.New("regroup can only be used with a stream edge")
+	}
+	return nil
+}
+
+// Converts a tag of each point to a field.
+// The tag is removed unless the keep property is set.
+// Points without the tag are not modified, an existing field with the same name is replaced.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('requests')
+//        |tagToField('status')
+//            .type('int')
+//            .as('status_code')
+//
+// The above example moves the value of the tag `status` to the integer field `status_code`.
+//
+// The type property sets the type of the field, one of string, int, float or bool.
+// Points whose tag cannot be converted to the type are not modified.
+//
+// By default tags that are group by dimensions are kept, so that the grouping of the data is preserved.
+// Use the regroup property to also remove them, in which case the dimension is removed from the grouping,
+// the same as for the DropTagsNode.
+type TagToFieldNode struct {
+	chainnode `json:"-"`
+
+	// The tag to convert.
+	// tick:ignore
+	Tag string `json:"tag"`
+
+	// The name of the field.
+	// Default is the name of the tag.
+	As string `json:"as"`
+
+	// The type of the field, one of string, int, float or bool.
+	// Default: string
+	FieldType string `tick:"Type" json:"fieldType"`
+
+	// Whether to keep the tag.
+	// tick:ignore
+	KeepFlag bool `tick:"Keep" json:"keep"`
+
+	// Whether to remove the tag if it is a group by dimension and regroup the data.
+	// tick:ignore
+	RegroupFlag bool `tick:"Regroup" json:"regroup"`
+}
+
+func newTagToFieldNode(e EdgeType, tag string) *TagToFieldNode {
+	return &TagToFieldNode{
+		chainnode: newBasicChainNode("tagToField", e, e),
+		Tag:       tag,
+		As:        tag,
+		FieldType: TagToFieldString,
+	}
+}
+
+// MarshalJSON converts TagToFieldNode to JSON
+// tick:ignore
+func (n *TagToFieldNode) MarshalJSON() ([]byte, error) {
+	type Alias TagToFieldNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		TypeOf: TypeOf{
+			Type: "tagToField",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an TagToFieldNode
+// tick:ignore
+func (n *TagToFieldNode) UnmarshalJSON(data []byte) error {
+	type Alias TagToFieldNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "tagToField" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as TagToFieldNode", raw.ID, raw.Type)
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+// Set the type of the field, one of string, int, float or bool.
+// tick:property
+func (n *TagToFieldNode) Type(typ string) *TagToFieldNode {
+	n.FieldType = typ
+	return n
+}
+
+// Keep the tag, copying its value to the field instead of moving it.
+// tick:property
+func (n *TagToFieldNode) Keep() *TagToFieldNode {
+	n.KeepFlag = true
+	return n
+}
+
+// Remove the tag even if it is a group by dimension and regroup the data by the remaining dimensions.
+// tick:property
+func (n *TagToFieldNode) Regroup() *TagToFieldNode {
+	n.RegroupFlag = true
+	return n
+}
+
+func (n *TagToFieldNode) validate() error {
+	if n.Tag == "" {
+		return errors.New("must provide a tag to convert")
+	}
+	if n.As == "" {
+		return errors.New("must provide a name for the field")
+	}
+	switch n.FieldType {
+	case TagToFieldString, TagToFieldInt, TagToFieldFloat, TagToFieldBool:
+	default:
+		return fmt.Errorf("invalid type %q, must be one of %s, %s, %s or %s", n.FieldType, TagToFieldString, TagToFieldInt, TagToFieldFloat, TagToFieldBool)
+	}
+	if n.KeepFlag && n.RegroupFlag {
+		return errors.New("cannot regroup when keeping the tag")
+	}
+	return nil
+}
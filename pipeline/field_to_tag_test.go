@@ -0,0 +1,108 @@
+package pipeline
+
+import (
+	"testing"
+)
+
+func TestFieldToTagNode_MarshalJSON(t *testing.T) {
+	f := newFieldToTagNode(StreamEdge, "host")
+	f.As = "hostname"
+	f.Regroup()
+	MarshalTestHelper(t, f, false, `{"typeOf":"fieldToTag","id":"0","field":"host","as":"hostname","keep":false,"regroup":true}`)
+}
+
+func TestFieldToTagNode_UnmarshalJSON(t *testing.T) {
+	input := `{"typeOf":"fieldToTag","id":"0","field":"host","as":"host","keep":true,"regroup":false}`
+	want := &FieldToTagNode{
+		Field:    "host",
+		As:       "host",
+		KeepFlag: true,
+	}
+	UnmarshalJSONTestHelper(t, []byte(input), &FieldToTagNode{}, false, want)
+}
+
+func TestFieldToTagNode_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		node    *FieldToTagNode
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			node: newFieldToTagNode(StreamEdge, "host"),
+		},
+		{
+			name:    "no field",
+			node:    newFieldToTagNode(StreamEdge, ""),
+			wantErr: true,
+		},
+		{
+			name:    "regroup batch",
+			node:    newFieldToTagNode(BatchEdge, "host").Regroup(),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.node.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTagToFieldNode_MarshalJSON(t *testing.T) {
+	n := newTagToFieldNode(StreamEdge, "status")
+	n.As = "status_code"
+	n.FieldType = TagToFieldInt
+	n.Keep()
+	MarshalTestHelper(t, n, false, `{"typeOf":"tagToField","id":"0","tag":"status","as":"status_code","fieldType":"int","keep":true,"regroup":false}`)
+}
+
+func TestTagToFieldNode_UnmarshalJSON(t *testing.T) {
+	input := `{"typeOf":"tagToField","id":"0","tag":"status","as":"status","fieldType":"float","keep":false,"regroup":true}`
+	want := &TagToFieldNode{
+		Tag:         "status",
+		As:          "status",
+		FieldType:   TagToFieldFloat,
+		RegroupFlag: true,
+	}
+	UnmarshalJSONTestHelper(t, []byte(input), &TagToFieldNode{}, false, want)
+}
+
+func TestTagToFieldNode_Validate(t *testing.T) {
+	invalidType := newTagToFieldNode(StreamEdge, "status")
+	invalidType.FieldType = "integer"
+	tests := []struct {
+		name    string
+		node    *TagToFieldNode
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			node: newTagToFieldNode(BatchEdge, "status").Regroup(),
+		},
+		{
+			name:    "no tag",
+			node:    newTagToFieldNode(StreamEdge, ""),
+			wantErr: true,
+		},
+		{
+			name:    "invalid type",
+			node:    invalidType,
+			wantErr: true,
+		},
+		{
+			name:    "keep and regroup",
+			node:    newTagToFieldNode(StreamEdge, "status").Keep().Regroup(),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.node.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxdb/influxql"
+)
+
+// Heartbeat points emitted by a HeartbeatNode.
+const (
+	// The measurement of the heartbeat points.
+	HeartbeatMeasurement = "_heartbeat"
+	// The tag with the ID of the task.
+	HeartbeatTaskTag = "task"
+	// The field with the number of points received since the previous heartbeat.
+	HeartbeatPointsField = "points"
+)
+
+// A HeartbeatNode emits a heartbeat point on a fixed interval of real time,
+// regardless of whether any data is flowing through the task.
+// An external watchdog can then alert when the heartbeats of a task stop,
+// i.e. when the task is stopped, disabled or stuck.
+//
+// Each heartbeat point has the measurement `_heartbeat`, the tag `task` with the ID of the task
+// and the current time according to the system clock.
+// The field `points` is the number of points the node received from its parent since the previous heartbeat,
+// so the heartbeats also tell whether data reaches the task.
+// The heartbeat points are grouped by the `task` tag.
+//
+// The node only emits the heartbeats, the data it receives is not forwarded.
+// Branch the data before the heartbeat node to keep processing it.
+//
+// Example:
+//    var data = stream
+//        |from()
+//            .measurement('cpu')
+//    data
+//        |heartbeat(10s)
+//        |influxDBOut()
+//            .database('monitoring')
+//            .retentionPolicy('autogen')
+//    data
+//        |alert()
+//            ...
+//
+// The above example writes a heartbeat of the task every 10 seconds,
+// a deadman alert on the `_heartbeat` measurement then detects that the task is no longer running.
+//
+// Available Statistics:
+//
+//    * heartbeats -- number of heartbeat points emitted.
+//
+type HeartbeatNode struct {
+	chainnode `json:"-"`
+
+	// The interval of the heartbeats.
+	// tick:ignore
+	Interval time.Duration `json:"interval"`
+}
+
+func newHeartbeatNode(wants EdgeType, interval time.Duration) *HeartbeatNode {
+	return &HeartbeatNode{
+		chainnode: newBasicChainNode("heartbeat", wants, StreamEdge),
+		Interval:  interval,
+	}
+}
+
+// MarshalJSON converts HeartbeatNode to JSON
+// tick:ignore
+func (n *HeartbeatNode) MarshalJSON() ([]byte, error) {
+	type Alias HeartbeatNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Interval string `json:"interval"`
+	}{
+		TypeOf: TypeOf{
+			Type: "heartbeat",
+			ID:   n.ID(),
+		},
+		Alias:    (*Alias)(n),
+		Interval: influxql.FormatDuration(n.Interval),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an HeartbeatNode
+// tick:ignore
+func (n *HeartbeatNode) UnmarshalJSON(data []byte) error {
+	type Alias HeartbeatNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Interval string `json:"interval"`
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "heartbeat" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as HeartbeatNode", raw.ID, raw.Type)
+	}
+	n.Interval, err = influxql.ParseDuration(raw.Interval)
+	if err != nil {
+		return err
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+func (n *HeartbeatNode) validate() error {
+	if n.Interval <= 0 {
+		return errors.New("heartbeat interval must be greater than 0")
+	}
+	return nil
+}
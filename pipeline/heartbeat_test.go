@@ -0,0 +1,28 @@
+package pipeline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHeartbeatNode_MarshalJSON(t *testing.T) {
+	h := newHeartbeatNode(StreamEdge, 10*time.Second)
+	MarshalTestHelper(t, h, false, `{"typeOf":"heartbeat","id":"0","interval":"10s"}`)
+}
+
+func TestHeartbeatNode_UnmarshalJSON(t *testing.T) {
+	input := `{"typeOf":"heartbeat","id":"0","interval":"1m"}`
+	want := &HeartbeatNode{
+		Interval: time.Minute,
+	}
+	UnmarshalJSONTestHelper(t, []byte(input), &HeartbeatNode{}, false, want)
+}
+
+func TestHeartbeatNode_Validate(t *testing.T) {
+	if err := newHeartbeatNode(StreamEdge, 0).validate(); err == nil {
+		t.Error("expected error for zero interval")
+	}
+	if err := newHeartbeatNode(BatchEdge, time.Second).validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -11,6 +11,9 @@ import (
 // The endpoint is the relative path from the API endpoint of the running task.
 // For example if the task endpoint is at `/kapacitor/v1/tasks/<task_id>` and endpoint is
 // `top10`, then the data can be requested from `/kapacitor/v1/tasks/<task_id>/top10`.
+// The response is gzipped if the request has the header `Accept-Encoding: gzip`.
+// The response has an ETag of the cached data, a request with a matching `If-None-Match` header
+// gets a `304 Not Modified` response. The ETag of a gzipped response is weak.
 //
 // Example:
 //    stream
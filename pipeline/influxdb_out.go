@@ -2,6 +2,7 @@ package pipeline
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -10,6 +11,20 @@ import (
 
 const DefaultBufferSize = 1000
 const DefaultFlushInterval = time.Second * 10
+const DefaultFailoverThreshold = 3
+const DefaultProbeInterval = time.Second * 30
+const DefaultFailoverBuffer = 10000
+const DefaultWalMaxSize = 100 * 1024 * 1024
+
+// Fsync policies of the write-ahead log of an InfluxDBOutNode.
+const (
+	// Sync every entry to disk before it is written.
+	WalFsyncAlways = "always"
+	// Sync the log to disk at most once a second.
+	WalFsyncInterval = "interval"
+	// Leave syncing the log to disk to the operating system.
+	WalFsyncNever = "never"
+)
 
 // Writes the data to InfluxDB as it is received.
 //
@@ -27,10 +42,62 @@ const DefaultFlushInterval = time.Second * 10
 //            .tag('kapacitor', 'true')
 //            .tag('version', '0.2')
 //
+// Writes can fail over between multiple InfluxDB instances using the clusters property.
+// Writes go to the first cluster in the list, once failoverThreshold consecutive writes
+// have failed they go to the next cluster instead.
+// While not writing to the first cluster it is probed every probeInterval
+// and writes switch back to it once it is available again.
+// Writes that failed are retried on the next write, retaining at most failoverBuffer points,
+// so that no data is lost while failing over.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('requests')
+//        |influxDBOut()
+//            .clusters('primary', 'secondary')
+//            .database('mydb')
+//            .retentionPolicy('myrp')
+//
+// High cardinality tags can be dropped just before writing, so that they do not create
+// series in InfluxDB while remaining available to the rest of the pipeline.
+// Either list the tags to write with writeTagsOnly or the tags to drop with dropWriteTags.
+// Tags are never dropped from the data passed on by this node, only from the written points.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('requests')
+//            .groupBy('request_id')
+//        |influxDBOut()
+//            .database('mydb')
+//            .dropWriteTags('request_id', 'session_id')
+//
+// Points are lost if Kapacitor crashes before they are written, since they are buffered in memory.
+// Set walDir to keep a write-ahead log of the points on disk, the points are appended to the log
+// before they are buffered and the log is truncated once they have been written.
+// When the task starts the points left in the log by a crash are written again,
+// so every point is written at least once.
+// Failed writes are retried at every flush interval and the log is not truncated past them until they succeed,
+// so if the task is restarted first their points, along with any points logged after them, are written again.
+// The log still never exceeds walMaxSize, once full its oldest entries are dropped.
+// The log of each node is kept in the directory `<walDir>/<task>/<node>`.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('requests')
+//        |influxDBOut()
+//            .database('mydb')
+//            .walDir('/var/lib/kapacitor/wal')
+//            .walFsync('interval')
+//
 // Available Statistics:
 //
 //    * points_written -- number of points written to InfluxDB
 //    * write_errors -- number of errors attempting to write to InfluxDB
+//    * active_cluster -- name of the cluster currently written to, only present when using clusters
+//    * failovers -- number of times writes switched to another cluster, only present when using clusters
 //
 type InfluxDBOutNode struct {
 	node `json:"-"`
@@ -60,6 +127,39 @@ type InfluxDBOutNode struct {
 	// Create the specified database and retention policy
 	// tick:ignore
 	CreateFlag bool `tick:"Create" json:"create"`
+	// Create the specified database and retention policy if they do not exist
+	// tick:ignore
+	CreateTargetsFlag bool `tick:"CreateTargets" json:"createTargets"`
+	// The only tags written, all other tags are dropped when writing.
+	// tick:ignore
+	WriteTagsOnlyList []string `tick:"WriteTagsOnly" json:"writeTagsOnly"`
+	// Tags dropped when writing.
+	// tick:ignore
+	DropWriteTagsList []string `tick:"DropWriteTags" json:"dropWriteTags"`
+
+	// The names of the InfluxDB instances to write to in order of preference.
+	// tick:ignore
+	ClusterNames []string `tick:"Clusters" json:"clusters"`
+	// Number of consecutive write failures after which writes fail over to the next cluster.
+	// Default: 3
+	FailoverThreshold int64 `json:"failoverThreshold"`
+	// Interval at which the first cluster is probed while writing to another cluster.
+	// Default: 30s
+	ProbeInterval time.Duration `json:"probeInterval"`
+	// Maximum number of points of failed writes to retain for retrying.
+	// Default: 10000
+	FailoverBuffer int64 `json:"failoverBuffer"`
+
+	// The directory of the write-ahead log.
+	// If empty no write-ahead log is kept.
+	WalDir string `json:"walDir,omitempty"`
+	// Maximum size in bytes of the write-ahead log.
+	// Once exceeded the oldest entries are dropped from the log, even if they have not been written yet.
+	// If zero, DefaultWalMaxSize is used.
+	WalMaxSize int64 `json:"walMaxSize,omitempty"`
+	// When the write-ahead log is synced to disk, one of always, interval or never.
+	// If empty, always is used.
+	WalFsync string `json:"walFsync,omitempty"`
 }
 
 func newInfluxDBOutNode(wants EdgeType) *InfluxDBOutNode {
@@ -69,9 +169,12 @@ func newInfluxDBOutNode(wants EdgeType) *InfluxDBOutNode {
 			wants:    wants,
 			provides: NoEdge,
 		},
-		Tags:          make(map[string]string),
-		Buffer:        DefaultBufferSize,
-		FlushInterval: DefaultFlushInterval,
+		Tags:              make(map[string]string),
+		Buffer:            DefaultBufferSize,
+		FlushInterval:     DefaultFlushInterval,
+		FailoverThreshold: DefaultFailoverThreshold,
+		ProbeInterval:     DefaultProbeInterval,
+		FailoverBuffer:    DefaultFailoverBuffer,
 	}
 }
 
@@ -83,6 +186,7 @@ func (n *InfluxDBOutNode) MarshalJSON() ([]byte, error) {
 		TypeOf
 		*Alias
 		FlushInterval string `json:"flushInterval"`
+		ProbeInterval string `json:"probeInterval"`
 	}{
 		TypeOf: TypeOf{
 			Type: "influxdbOut",
@@ -90,6 +194,7 @@ func (n *InfluxDBOutNode) MarshalJSON() ([]byte, error) {
 		},
 		Alias:         (*Alias)(n),
 		FlushInterval: influxql.FormatDuration(n.FlushInterval),
+		ProbeInterval: influxql.FormatDuration(n.ProbeInterval),
 	}
 	return json.Marshal(raw)
 }
@@ -102,6 +207,7 @@ func (n *InfluxDBOutNode) UnmarshalJSON(data []byte) error {
 		TypeOf
 		*Alias
 		FlushInterval string `json:"flushInterval"`
+		ProbeInterval string `json:"probeInterval"`
 	}{
 		Alias: (*Alias)(n),
 	}
@@ -116,6 +222,13 @@ func (n *InfluxDBOutNode) UnmarshalJSON(data []byte) error {
 	if err != nil {
 		return err
 	}
+	// The probe interval is absent from nodes created before clusters were supported.
+	if raw.ProbeInterval != "" {
+		n.ProbeInterval, err = influxql.ParseDuration(raw.ProbeInterval)
+		if err != nil {
+			return err
+		}
+	}
 	n.setID(raw.ID)
 	return nil
 }
@@ -142,3 +255,103 @@ func (i *InfluxDBOutNode) Create() *InfluxDBOutNode {
 	i.CreateFlag = true
 	return i
 }
+
+// CreateTargets indicates that the database and retention policy
+// will be created when the task is started, if they do not exist.
+// Unlike create, the existence of the database and retention policy is checked first
+// and the task fails to start if they cannot be created,
+// for example because the InfluxDB user lacks admin privileges.
+//
+// The following statements are issued as needed:
+//
+//    CREATE DATABASE "<database>"
+//    CREATE RETENTION POLICY "<retentionPolicy>" ON "<database>" DURATION INF REPLICATION 1
+//
+// The retention policy is created with an infinite duration, alter it
+// in InfluxDB if a different duration is needed.
+//
+// tick:property
+func (i *InfluxDBOutNode) CreateTargets() *InfluxDBOutNode {
+	i.CreateTargetsFlag = true
+	return i
+}
+
+// WriteTagsOnly sets the only tags written to InfluxDB, all other tags are dropped from the written points.
+// The static tags set with the tag property are always written.
+// Cannot be used together with the dropWriteTags property.
+//
+// tick:property
+func (i *InfluxDBOutNode) WriteTagsOnly(tags ...string) *InfluxDBOutNode {
+	i.WriteTagsOnlyList = tags
+	return i
+}
+
+// DropWriteTags sets tags that are dropped from the points written to InfluxDB.
+// The static tags set with the tag property are always written.
+// Cannot be used together with the writeTagsOnly property.
+//
+// tick:property
+func (i *InfluxDBOutNode) DropWriteTags(tags ...string) *InfluxDBOutNode {
+	i.DropWriteTagsList = tags
+	return i
+}
+
+// Clusters sets the names of the InfluxDB instances to write to in order of preference.
+// Writes fail over to the next cluster when writing to the current cluster fails.
+// Cannot be used together with the cluster property.
+//
+// tick:property
+func (i *InfluxDBOutNode) Clusters(clusters ...string) *InfluxDBOutNode {
+	i.ClusterNames = clusters
+	return i
+}
+
+func (i *InfluxDBOutNode) validate() error {
+	if i.CreateTargetsFlag {
+		if i.CreateFlag {
+			return errors.New("cannot use both create and createTargets")
+		}
+		if i.Database == "" {
+			return errors.New("must specify a database to use createTargets")
+		}
+	}
+	if len(i.WriteTagsOnlyList) > 0 && len(i.DropWriteTagsList) > 0 {
+		return errors.New("cannot use both writeTagsOnly and dropWriteTags")
+	}
+	if i.WalMaxSize < 0 {
+		return errors.New("walMaxSize cannot be negative")
+	}
+	if i.WalDir == "" && (i.WalMaxSize != 0 || i.WalFsync != "") {
+		return errors.New("walMaxSize and walFsync can only be used with walDir")
+	}
+	if i.WalDir != "" {
+		switch i.WalFsync {
+		case "", WalFsyncAlways, WalFsyncInterval, WalFsyncNever:
+		default:
+			return fmt.Errorf("invalid walFsync %q, must be one of %q, %q or %q", i.WalFsync, WalFsyncAlways, WalFsyncInterval, WalFsyncNever)
+		}
+	}
+	if len(i.ClusterNames) == 0 {
+		return nil
+	}
+	if i.Cluster != "" {
+		return errors.New("cannot use both cluster and clusters")
+	}
+	seen := make(map[string]bool, len(i.ClusterNames))
+	for _, c := range i.ClusterNames {
+		if seen[c] {
+			return fmt.Errorf("duplicate cluster %q", c)
+		}
+		seen[c] = true
+	}
+	if i.FailoverThreshold < 1 {
+		return errors.New("failoverThreshold must be at least 1")
+	}
+	if i.ProbeInterval <= 0 {
+		return errors.New("probeInterval must be greater than zero")
+	}
+	if i.FailoverBuffer < 0 {
+		return errors.New("failoverBuffer cannot be negative")
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package pipeline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInfluxDBOutNode_UnmarshalJSON(t *testing.T) {
+	input := `{"typeOf":"influxdbOut","id":"0","database":"mydb","buffer":1000,"flushInterval":"10s","clusters":["primary","secondary"],"failoverThreshold":5,"failoverBuffer":100,"probeInterval":"1m","dropWriteTags":["request_id"]}`
+	want := &InfluxDBOutNode{
+		Database:          "mydb",
+		Buffer:            1000,
+		FlushInterval:     10 * time.Second,
+		ClusterNames:      []string{"primary", "secondary"},
+		FailoverThreshold: 5,
+		ProbeInterval:     time.Minute,
+		FailoverBuffer:    100,
+		DropWriteTagsList: []string{"request_id"},
+	}
+	UnmarshalJSONTestHelper(t, []byte(input), &InfluxDBOutNode{}, false, want)
+}
+
+func TestInfluxDBOutNode_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(i *InfluxDBOutNode)
+		wantErr bool
+	}{
+		{
+			name:  "single cluster",
+			setup: func(i *InfluxDBOutNode) { i.Cluster = "primary" },
+		},
+		{
+			name:  "clusters",
+			setup: func(i *InfluxDBOutNode) { i.Clusters("primary", "secondary") },
+		},
+		{
+			name: "cluster and clusters",
+			setup: func(i *InfluxDBOutNode) {
+				i.Cluster = "primary"
+				i.Clusters("primary", "secondary")
+			},
+			wantErr: true,
+		},
+		{
+			name:    "duplicate clusters",
+			setup:   func(i *InfluxDBOutNode) { i.Clusters("primary", "primary") },
+			wantErr: true,
+		},
+		{
+			name: "no failover threshold",
+			setup: func(i *InfluxDBOutNode) {
+				i.Clusters("primary", "secondary")
+				i.FailoverThreshold = 0
+			},
+			wantErr: true,
+		},
+		{
+			name: "no probe interval",
+			setup: func(i *InfluxDBOutNode) {
+				i.Clusters("primary", "secondary")
+				i.ProbeInterval = 0
+			},
+			wantErr: true,
+		},
+		{
+			name: "create targets",
+			setup: func(i *InfluxDBOutNode) {
+				i.Database = "mydb"
+				i.CreateTargets()
+			},
+		},
+		{
+			name:    "create targets without database",
+			setup:   func(i *InfluxDBOutNode) { i.CreateTargets() },
+			wantErr: true,
+		},
+		{
+			name: "create and create targets",
+			setup: func(i *InfluxDBOutNode) {
+				i.Database = "mydb"
+				i.Create()
+				i.CreateTargets()
+			},
+			wantErr: true,
+		},
+		{
+			name:  "write tags only",
+			setup: func(i *InfluxDBOutNode) { i.WriteTagsOnly("host") },
+		},
+		{
+			name:  "drop write tags",
+			setup: func(i *InfluxDBOutNode) { i.DropWriteTags("request_id") },
+		},
+		{
+			name: "write tags only and drop write tags",
+			setup: func(i *InfluxDBOutNode) {
+				i.WriteTagsOnly("host")
+				i.DropWriteTags("request_id")
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative failover buffer",
+			setup: func(i *InfluxDBOutNode) {
+				i.Clusters("primary", "secondary")
+				i.FailoverBuffer = -1
+			},
+			wantErr: true,
+		},
+		{
+			name: "wal",
+			setup: func(i *InfluxDBOutNode) {
+				i.WalDir = "/var/lib/kapacitor/wal"
+				i.WalMaxSize = 1024
+				i.WalFsync = WalFsyncInterval
+			},
+		},
+		{
+			name:  "wal defaults",
+			setup: func(i *InfluxDBOutNode) { i.WalDir = "/var/lib/kapacitor/wal" },
+		},
+		{
+			name: "negative wal max size",
+			setup: func(i *InfluxDBOutNode) {
+				i.WalDir = "/var/lib/kapacitor/wal"
+				i.WalMaxSize = -1
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid wal fsync",
+			setup: func(i *InfluxDBOutNode) {
+				i.WalDir = "/var/lib/kapacitor/wal"
+				i.WalFsync = "sometimes"
+			},
+			wantErr: true,
+		},
+		{
+			name:    "wal fsync without wal dir",
+			setup:   func(i *InfluxDBOutNode) { i.WalFsync = WalFsyncNever },
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := newInfluxDBOutNode(StreamEdge)
+			tt.setup(i)
+			if err := i.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
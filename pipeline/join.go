@@ -13,6 +13,19 @@ const (
 	defaultJoinDelimiter = "."
 )
 
+// Aggregates of the points of a parent within a tolerance window, see JoinNode.PreAgg.
+const (
+	JoinPreAggFirst = "first"
+	JoinPreAggLast  = "last"
+	JoinPreAggMean  = "mean"
+)
+
+// Handling of batches of different lengths joined by index, see JoinNode.LengthMismatch.
+const (
+	JoinLengthMismatchError = "error"
+	JoinLengthMismatchPad   = "pad"
+)
+
 // Joins the data from any number of nodes.
 // As each data point is received from a parent node it is paired
 // with the next data points from the other parent nodes with a
@@ -124,6 +137,18 @@ type JoinNode struct {
 	//        |where(lambda: "maintlock.mode")
 	//        |...
 	Fill interface{} `json:"fill"`
+
+	// The aggregate of the points of each parent within a tolerance window.
+	// tick:ignore
+	PreAggregate string `tick:"PreAgg" json:"preAgg,omitempty"`
+
+	// Whether to join the points of batches by position instead of by time.
+	// tick:ignore
+	ByIndexFlag bool `tick:"ByIndex" json:"byIndex,omitempty"`
+
+	// How batches of different lengths are joined by index.
+	// tick:ignore
+	LengthMismatchHandling string `tick:"LengthMismatch" json:"lengthMismatch,omitempty"`
 }
 
 func newJoinNode(e EdgeType, parents []Node) *JoinNode {
@@ -232,6 +257,96 @@ func (j *JoinNode) On(dims ...string) *JoinNode {
 	return j
 }
 
+// Aggregate the points of each parent within a tolerance window into a single point before joining,
+// so that each parent contributes exactly one point to each tolerance window.
+//
+// Without pre-aggregation, the points of a parent falling into the same tolerance window are joined
+// in the order they arrive, one joined point per point of the busiest parent,
+// and the points without a counterpart are dropped or filled.
+// With pre-aggregation, a single point is emitted per tolerance window and group,
+// joining the aggregates of the parents.
+// Since a tolerance window is only complete once all parents have moved past it,
+// joined points are emitted once data for a later tolerance window arrived from every parent.
+//
+// The aggregate is one of:
+//
+//   - first - keep the first point of the window.
+//   - last - keep the last point of the window.
+//   - mean - the mean of each numeric field over the points of the window with the field,
+//     other fields have the value of the last point with the field.
+//
+// Only applies to stream data.
+//
+// Example:
+//    var errors = stream
+//        |from()
+//            .measurement('errors')
+//    var requests = stream
+//        |from()
+//            .measurement('requests')
+//    errors
+//        |join(requests)
+//            .as('errors', 'requests')
+//            .tolerance(10s)
+//            .preAgg('mean')
+//
+// The above example joins the mean of the errors and of the requests over each 10 second window.
+//
+// tick:property
+func (j *JoinNode) PreAgg(aggregate string) *JoinNode {
+	j.PreAggregate = aggregate
+	return j
+}
+
+// Join the points of batches by position instead of by time,
+// the i-th point of each batch is joined with the i-th point of the other batches.
+//
+// Joining by index is useful for aligned batches of equal length whose points do not share timestamps,
+// e.g. the predicted and actual values of a series, or the results of sorting or ranking nodes.
+// The batches themselves are still matched by time within the tolerance.
+// Each joined point has the time of the point of the first parent with a point at that position.
+//
+// If the batches have different lengths the batches are dropped and an error is logged,
+// see the lengthMismatch property to pad the shorter batches instead.
+// Missing batches are filled according to the fill property.
+//
+// Only applies to batch data.
+//
+// Example:
+//    var predicted = batch
+//        |query('SELECT value FROM "db"."rp"."predicted"')
+//            .period(1h)
+//            .every(1h)
+//    var actual = batch
+//        |query('SELECT value FROM "db"."rp"."actual"')
+//            .period(1h)
+//            .every(1h)
+//    predicted
+//        |join(actual)
+//            .as('predicted', 'actual')
+//            .byIndex()
+//        |eval(lambda: "actual.value" - "predicted.value")
+//            .as('error')
+//
+// tick:property
+func (j *JoinNode) ByIndex() *JoinNode {
+	j.ByIndexFlag = true
+	return j
+}
+
+// How batches of different lengths are joined by index, see the byIndex property.
+// Options are:
+//
+//   - error - (default) drop the batches and log an error.
+//   - pad - join the points of the longer batches with the fill value in place of the missing points,
+//     or null if no numerical fill value is set.
+//
+// tick:property
+func (j *JoinNode) LengthMismatch(handling string) *JoinNode {
+	j.LengthMismatchHandling = handling
+	return j
+}
+
 // Validate that the as() specification is consistent with the number of join arms.
 func (j *JoinNode) validate() error {
 	if len(j.Names) == 0 {
@@ -258,5 +373,28 @@ func (j *JoinNode) validate() error {
 		names[name] = true
 	}
 
+	switch j.PreAggregate {
+	case "":
+	case JoinPreAggFirst, JoinPreAggLast, JoinPreAggMean:
+		if j.Wants() != StreamEdge {
+			return fmt.Errorf("join.preAgg() is only supported with stream data")
+		}
+	default:
+		return fmt.Errorf("invalid pre-aggregate %q, must be one of first, last or mean", j.PreAggregate)
+	}
+
+	if j.ByIndexFlag && j.Wants() != BatchEdge {
+		return fmt.Errorf("join.byIndex() is only supported with batch data")
+	}
+	switch j.LengthMismatchHandling {
+	case "":
+	case JoinLengthMismatchError, JoinLengthMismatchPad:
+		if !j.ByIndexFlag {
+			return fmt.Errorf("join.lengthMismatch() requires join.byIndex()")
+		}
+	default:
+		return fmt.Errorf("invalid length mismatch handling %q, must be one of error or pad", j.LengthMismatchHandling)
+	}
+
 	return nil
 }
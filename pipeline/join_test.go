@@ -0,0 +1,67 @@
+package pipeline
+
+import "testing"
+
+func TestJoinNode_MarshalJSON_PreAgg(t *testing.T) {
+	j := newJoinNode(StreamEdge, nil)
+	j.As("errors", "requests").PreAgg(JoinPreAggLast)
+	MarshalTestHelper(t, j, false, `{"typeOf":"join","id":"0","as":["errors","requests"],"on":null,"delimiter":".","streamName":"","fill":null,"preAgg":"last","tolerance":"0s"}`)
+}
+
+func TestJoinNode_ValidatePreAgg(t *testing.T) {
+	tests := []struct {
+		name    string
+		wants   EdgeType
+		preAgg  string
+		wantErr bool
+	}{
+		{name: "none", wants: BatchEdge},
+		{name: "first", wants: StreamEdge, preAgg: JoinPreAggFirst},
+		{name: "last", wants: StreamEdge, preAgg: JoinPreAggLast},
+		{name: "mean", wants: StreamEdge, preAgg: JoinPreAggMean},
+		{name: "invalid", wants: StreamEdge, preAgg: "max", wantErr: true},
+		{name: "batch", wants: BatchEdge, preAgg: JoinPreAggMean, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s1, s2 := newStreamNode(), newStreamNode()
+			CreatePipelineSources(s1, s2)
+			j := s1.From().Join(s2.From())
+			j.As("a", "b").PreAgg(tt.preAgg)
+			j.wants = tt.wants
+			if err := j.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestJoinNode_ValidateByIndex(t *testing.T) {
+	tests := []struct {
+		name     string
+		wants    EdgeType
+		byIndex  bool
+		mismatch string
+		wantErr  bool
+	}{
+		{name: "batch", wants: BatchEdge, byIndex: true},
+		{name: "error", wants: BatchEdge, byIndex: true, mismatch: JoinLengthMismatchError},
+		{name: "pad", wants: BatchEdge, byIndex: true, mismatch: JoinLengthMismatchPad},
+		{name: "stream", wants: StreamEdge, byIndex: true, wantErr: true},
+		{name: "invalid mismatch", wants: BatchEdge, byIndex: true, mismatch: "truncate", wantErr: true},
+		{name: "mismatch without byIndex", wants: BatchEdge, mismatch: JoinLengthMismatchPad, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s1, s2 := newStreamNode(), newStreamNode()
+			CreatePipelineSources(s1, s2)
+			j := s1.From().Join(s2.From())
+			j.As("a", "b").LengthMismatch(tt.mismatch)
+			j.ByIndexFlag = tt.byIndex
+			j.wants = tt.wants
+			if err := j.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
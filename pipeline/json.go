@@ -183,8 +183,55 @@ func init() {
 		"stats":             func(parent chainnodeAlias) Node { return parent.Stats(0) },
 		"stateDuration":     func(parent chainnodeAlias) Node { return parent.StateDuration(nil) },
 		"stateCount":        func(parent chainnodeAlias) Node { return parent.StateCount(nil) },
+		"stateMachine":      func(parent chainnodeAlias) Node { return parent.StateMachine("") },
+		"streamReplay":      func(parent chainnodeAlias) Node { return parent.StreamReplay("") },
+		"streamCompact":     func(parent chainnodeAlias) Node { return parent.StreamCompact() },
+		"sessionize":        func(parent chainnodeAlias) Node { return parent.Sessionize() },
 		"shift":             func(parent chainnodeAlias) Node { return parent.Shift(0) },
 		"sideload":          func(parent chainnodeAlias) Node { return parent.Sideload() },
+		"split":             func(parent chainnodeAlias) Node { return parent.Split() },
+		"percentiles":       func(parent chainnodeAlias) Node { return parent.Percentiles("") },
+		"quantize":          func(parent chainnodeAlias) Node { return parent.Quantize() },
+		"validateTime":      func(parent chainnodeAlias) Node { return parent.ValidateTime() },
+		"maxDelay":          func(parent chainnodeAlias) Node { return parent.MaxDelay() },
+		"rollingAverage":    func(parent chainnodeAlias) Node { return parent.RollingAverage("", 0) },
+		"correlate":         func(parent chainnodeAlias) Node { return parent.Correlate("", "", 0) },
+		"diff":              func(parent chainnodeAlias) Node { return parent.Diff("", "") },
+		"schedule":          func(parent chainnodeAlias) Node { return parent.Schedule() },
+		"normalize":         func(parent chainnodeAlias) Node { return parent.Normalize("") },
+		"retract":           func(parent chainnodeAlias) Node { return parent.Retract() },
+		"natsOut":           func(parent chainnodeAlias) Node { return parent.NatsOut("") },
+		"trend":             func(parent chainnodeAlias) Node { return parent.Trend("") },
+		"encrypt":           func(parent chainnodeAlias) Node { return parent.Encrypt() },
+		"decrypt":           func(parent chainnodeAlias) Node { return parent.Decrypt() },
+		"encodeBase64":      func(parent chainnodeAlias) Node { return parent.EncodeBase64("") },
+		"decodeBase64":      func(parent chainnodeAlias) Node { return parent.DecodeBase64("") },
+		"backfill":          func(parent chainnodeAlias) Node { return parent.Backfill("") },
+		"cardinalityLimit":  func(parent chainnodeAlias) Node { return parent.CardinalityLimit() },
+		"warmup":            func(parent chainnodeAlias) Node { return parent.Warmup() },
+		"thresholdLearn":    func(parent chainnodeAlias) Node { return parent.ThresholdLearn("") },
+		"decimals":          func(parent chainnodeAlias) Node { return parent.Decimals() },
+		"jsonExtract":       func(parent chainnodeAlias) Node { return parent.JsonExtract("") },
+		"batchSizeLimit":    func(parent chainnodeAlias) Node { return parent.BatchSizeLimit() },
+		"sanitize":          func(parent chainnodeAlias) Node { return parent.Sanitize() },
+		"countDistinct":     func(parent chainnodeAlias) Node { return parent.CountDistinct("") },
+		"bloomFilter":       func(parent chainnodeAlias) Node { return parent.BloomFilter("") },
+		"concatFields":      func(parent chainnodeAlias) Node { return parent.ConcatFields() },
+		"lagCompensate":     func(parent chainnodeAlias) Node { return parent.LagCompensate() },
+		"clamp":             func(parent chainnodeAlias) Node { return parent.Clamp() },
+		"timeInState":       func(parent chainnodeAlias) Node { return parent.TimeInState("") },
+		"mask":              func(parent chainnodeAlias) Node { return parent.Mask() },
+		"coalesce":          func(parent chainnodeAlias) Node { return parent.Coalesce("") },
+		"reverseDNS":        func(parent chainnodeAlias) Node { return parent.ReverseDNS("") },
+		"humanize":          func(parent chainnodeAlias) Node { return parent.Humanize("", "") },
+		"tagValueMap":       func(parent chainnodeAlias) Node { return parent.TagValueMap("") },
+		"avroOut":           func(parent chainnodeAlias) Node { return parent.AvroOut("") },
+		"deadband":          func(parent chainnodeAlias) Node { return parent.Deadband("") },
+		"upDownCounter":     func(parent chainnodeAlias) Node { return parent.UpDownCounter("") },
+		"validateSchema":    func(parent chainnodeAlias) Node { return parent.ValidateSchema() },
+		"multiWindow":       func(parent chainnodeAlias) Node { return parent.MultiWindow("") },
+		"regexReplace":      func(parent chainnodeAlias) Node { return parent.RegexReplace() },
+		"heartbeat":         func(parent chainnodeAlias) Node { return parent.Heartbeat(0) },
 		"sample":            func(parent chainnodeAlias) Node { return parent.Sample(0) },
 		"log":               func(parent chainnodeAlias) Node { return parent.Log() },
 		"kapacitorLoopback": func(parent chainnodeAlias) Node { return parent.KapacitorLoopback() },
@@ -197,6 +244,11 @@ func init() {
 		"derivative":        func(parent chainnodeAlias) Node { return parent.Derivative("") },
 		"changeDetect":      func(parent chainnodeAlias) Node { return parent.ChangeDetect("") },
 		"delete":            func(parent chainnodeAlias) Node { return parent.Delete() },
+		"dropTags":          func(parent chainnodeAlias) Node { return parent.DropTags() },
+		"fieldToTag":        func(parent chainnodeAlias) Node { return parent.FieldToTag("") },
+		"tagToField":        func(parent chainnodeAlias) Node { return parent.TagToField("") },
+		"deriveTag":         func(parent chainnodeAlias) Node { return parent.DeriveTag("") },
+		"dropFields":        func(parent chainnodeAlias) Node { return parent.DropFields() },
 		"default":           func(parent chainnodeAlias) Node { return parent.Default() },
 		"combine":           func(parent chainnodeAlias) Node { return parent.Combine(nil) },
 		"alert":             func(parent chainnodeAlias) Node { return parent.Alert() },
@@ -237,6 +289,7 @@ func init() {
 		"where":   unmarshalWhere,
 		"groupBy": unmarshalGroupby,
 		"udf":     unmarshalUDF,
+		"branch":  unmarshalBranch,
 	}
 }
 
@@ -422,6 +475,19 @@ func unmarshalWhere(data []byte, parents []Node, typ TypeOf) (Node, error) {
 	return child, err
 }
 
+func unmarshalBranch(data []byte, parents []Node, typ TypeOf) (Node, error) {
+	if len(parents) != 1 {
+		return nil, fmt.Errorf("expected one parent for node %d but found %d", typ.ID, len(parents))
+	}
+	parent, ok := parents[0].(chainNodeAliasBranch)
+	if !ok {
+		return nil, fmt.Errorf("parent node of branch must be split, sanitize, barrier or window but is %T", parents[0])
+	}
+	child := parent.Branch("")
+	err := json.Unmarshal(data, child)
+	return child, err
+}
+
 func unmarshalGroupby(data []byte, parents []Node, typ TypeOf) (Node, error) {
 	if len(parents) != 1 {
 		return nil, fmt.Errorf("expected one parent for node %d but found %d", typ.ID, len(parents))
@@ -512,58 +578,110 @@ func isChainNode(node Node) (chainnodeAlias, bool) {
 // chainnodeAlias is used to check for the presence of a chain node
 type chainnodeAlias interface {
 	Alert() *AlertNode
+	AvroOut(string) *AvroOutNode
+	Backfill(string) *BackfillNode
+	BatchSizeLimit() *BatchSizeLimitNode
+	BloomFilter(string) *BloomFilterNode
 	Bottom(int64, string, ...string) *InfluxQLNode
+	CardinalityLimit() *CardinalityLimitNode
 	Children() []Node
+	Clamp() *ClampNode
+	Coalesce(string, ...string) *CoalesceNode
 	Combine(...*ast.LambdaNode) *CombineNode
+	ConcatFields() *ConcatFieldsNode
+	Correlate(string, string, int64) *CorrelateNode
 	Count(string) *InfluxQLNode
+	CountDistinct(string) *CountDistinctNode
 	CumulativeSum(string) *InfluxQLNode
+	Deadband(string) *DeadbandNode
 	Deadman(float64, time.Duration, ...*ast.LambdaNode) *AlertNode
+	Decimals() *DecimalsNode
+	DecodeBase64(string) *DecodeBase64Node
+	Decrypt(...string) *DecryptNode
 	Default() *DefaultNode
 	Delete() *DeleteNode
 	Derivative(string) *DerivativeNode
+	DeriveTag(string) *DeriveTagNode
 	ChangeDetect(string) *ChangeDetectNode
 	Desc() string
+	Diff(string, string) *DiffNode
 	Difference(string) *InfluxQLNode
 	Distinct(string) *InfluxQLNode
+	DropFields(...string) *DropFieldsNode
+	DropTags(...string) *DropTagsNode
 	Elapsed(string, time.Duration) *InfluxQLNode
+	EncodeBase64(string) *EncodeBase64Node
+	Encrypt(...string) *EncryptNode
 	Eval(...*ast.LambdaNode) *EvalNode
+	FieldToTag(string) *FieldToTagNode
 	First(string) *InfluxQLNode
 	Flatten() *FlattenNode
+	Heartbeat(time.Duration) *HeartbeatNode
 	HoltWinters(string, int64, int64, time.Duration) *InfluxQLNode
 	HoltWintersWithFit(string, int64, int64, time.Duration) *InfluxQLNode
 	HttpOut(string) *HTTPOutNode
 	HttpPost(...string) *HTTPPostNode
+	Humanize(string, string) *DurationFormatNode
 	ID() ID
 	InfluxDBOut() *InfluxDBOutNode
 	Join(...Node) *JoinNode
+	JsonExtract(string) *JSONExtractNode
 	K8sAutoscale() *K8sAutoscaleNode
 	KapacitorLoopback() *KapacitorLoopbackNode
+	LagCompensate() *LagCompensateNode
 	Last(string) *InfluxQLNode
 	Log() *LogNode
+	Mask() *MaskNode
 	Max(string) *InfluxQLNode
+	MaxDelay() *MaxDelayNode
 	Mean(string) *InfluxQLNode
 	Median(string) *InfluxQLNode
 	Min(string) *InfluxQLNode
 	Mode(string) *InfluxQLNode
 	MovingAverage(string, int64) *InfluxQLNode
+	MultiWindow(string, ...time.Duration) *MultiWindowNode
 	Name() string
+	NatsOut(string) *NatsOutNode
+	Normalize(string) *NormalizeNode
 	Parents() []Node
 	Percentile(string, float64) *InfluxQLNode
+	Percentiles(string, ...float64) *PercentilesNode
 	Provides() EdgeType
+	Quantize() *QuantizeNode
+	RegexReplace() *RegexReplaceNode
+	Retract() *RetractNode
+	ReverseDNS(string) *ReverseDNSNode
+	RollingAverage(string, int64) *MovingAverageNode
 	Sample(interface{}) *SampleNode
+	Sanitize() *SanitizeNode
+	Schedule() *ScheduleNode
+	Sessionize() *SessionizeNode
 	SetName(string)
 	Shift(time.Duration) *ShiftNode
 	Sideload() *SideloadNode
 	Spread(string) *InfluxQLNode
+	Split() *SplitNode
 	StateCount(*ast.LambdaNode) *StateCountNode
 	StateDuration(*ast.LambdaNode) *StateDurationNode
+	StateMachine(string) *StateMachineNode
 	Stats(time.Duration) *StatsNode
 	Stddev(string) *InfluxQLNode
+	StreamCompact(...string) *StreamCompactNode
+	StreamReplay(string) *StreamReplayNode
 	Sum(string) *InfluxQLNode
 	SwarmAutoscale() *SwarmAutoscaleNode
+	TagToField(string) *TagToFieldNode
+	TagValueMap(string) *TagValueMapNode
+	ThresholdLearn(string) *ThresholdLearnNode
+	TimeInState(string) *TimeInStateNode
 	Top(int64, string, ...string) *InfluxQLNode
+	Trend(string) *TrendNode
 	Union(...Node) *UnionNode
+	UpDownCounter(string) *UpDownCounterNode
+	ValidateSchema() *ValidateSchemaNode
+	ValidateTime() *ValidateTimeNode
 	Wants() EdgeType
+	Warmup() *WarmupNode
 	Window() *WindowNode
 	addParent(Node)
 	dot(*bytes.Buffer)
@@ -584,6 +702,11 @@ type chainNodeAliasWhere interface {
 	Where(*ast.LambdaNode) *WhereNode
 }
 
+// chainNodeAliasBranch exists because SplitNode, SanitizeNode, BarrierNode and WindowNode have branches
+type chainNodeAliasBranch interface {
+	Branch(string) *SplitBranchNode
+}
+
 // chainNodeAliasGroupBy exists because FromNode, BatchNode and chainnodes have different GroupBy()
 type chainNodeAliasGroupBy interface {
 	GroupBy(...interface{}) *GroupByNode
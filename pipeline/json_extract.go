@@ -0,0 +1,167 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// The types values can be extracted as by a JSONExtractNode.
+const (
+	JSONExtractFloat  = "float"
+	JSONExtractInt    = "int"
+	JSONExtractString = "string"
+	JSONExtractBool   = "bool"
+	// JSONExtractTag extracts the value as a tag instead of a field.
+	JSONExtractTag = "tag"
+)
+
+// Extract values out of a field containing a JSON string into fields and tags.
+// Each extraction selects a value with a JSONPath expression, converts it to the given type
+// and stores it as a field, or as a tag if the type is `tag`.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('events')
+//        |jsonExtract('payload')
+//            .extract('$.user.id', 'user_id', 'int')
+//            .extract('$.user.name', 'user', 'tag')
+//            .extract('$.items[0].price', 'first_price', 'float')
+//            .extract('$["request-id"]', 'request_id', 'string')
+//        |influxDBOut()
+//            .database('events')
+//            .measurement('events_extracted')
+//
+// The JSONPath expressions select a single value, they consist of the root `$` followed by
+// object keys, either as `.key` or `['key']`, and array indexes, `[0]`.
+// Negative array indexes count from the end of the array, i.e. `[-1]` is the last element.
+// The leading `$.` may be omitted, i.e. `user.id` is the same as `$.user.id`.
+//
+// Values are converted to the extraction type where possible,
+// i.e. the JSON string "42" is extracted as the integer 42 and the number 42 as the string "42".
+// Objects and arrays can only be extracted as strings, they are extracted as their JSON.
+// Values that are missing or null are not extracted.
+//
+// Points whose field is not a string containing valid JSON are passed on unchanged,
+// use dropMalformed to drop them instead.
+//
+// Available Statistics:
+//
+//    * malformed -- number of points whose field is not a string containing valid JSON
+//    * coercion_errors -- number of extracted values that could not be converted to their type
+//
+type JSONExtractNode struct {
+	chainnode `json:"-"`
+
+	// The name of the field containing the JSON string.
+	// tick:ignore
+	Field string `json:"field"`
+
+	// The values to extract.
+	// tick:ignore
+	Extractions []*JSONExtraction `tick:"Extract" json:"extractions"`
+
+	// Whether to drop points whose field is not valid JSON.
+	// tick:ignore
+	DropMalformedFlag bool `tick:"DropMalformed" json:"dropMalformed"`
+}
+
+// JSONExtraction is a value extracted from JSON by a JSONExtractNode.
+// tick:ignore
+type JSONExtraction struct {
+	// The JSONPath expression selecting the value.
+	Path string `json:"path"`
+	// The name of the field or tag the value is stored as.
+	As string `json:"as"`
+	// The type the value is converted to.
+	Type string `json:"type"`
+}
+
+func newJSONExtractNode(wants EdgeType, field string) *JSONExtractNode {
+	return &JSONExtractNode{
+		chainnode: newBasicChainNode("json_extract", wants, wants),
+		Field:     field,
+	}
+}
+
+// MarshalJSON converts JSONExtractNode to JSON
+// tick:ignore
+func (n *JSONExtractNode) MarshalJSON() ([]byte, error) {
+	type Alias JSONExtractNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		TypeOf: TypeOf{
+			Type: "jsonExtract",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an JSONExtractNode
+// tick:ignore
+func (n *JSONExtractNode) UnmarshalJSON(data []byte) error {
+	type Alias JSONExtractNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "jsonExtract" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as JSONExtractNode", raw.ID, raw.Type)
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+// Extract the value selected by the JSONPath expression as a field or tag.
+// The type is one of `float`, `int`, `string`, `bool` or `tag`.
+// tick:property
+func (n *JSONExtractNode) Extract(path, as, typ string) *JSONExtractNode {
+	n.Extractions = append(n.Extractions, &JSONExtraction{
+		Path: path,
+		As:   as,
+		Type: typ,
+	})
+	return n
+}
+
+// Drop points whose field is not a string containing valid JSON,
+// instead of passing them on unchanged.
+// tick:property
+func (n *JSONExtractNode) DropMalformed() *JSONExtractNode {
+	n.DropMalformedFlag = true
+	return n
+}
+
+func (n *JSONExtractNode) validate() error {
+	if n.Field == "" {
+		return errors.New("must provide the field containing the JSON")
+	}
+	if len(n.Extractions) == 0 {
+		return errors.New("must extract at least one value")
+	}
+	for _, e := range n.Extractions {
+		if e.Path == "" {
+			return errors.New("must provide the path of each extraction")
+		}
+		if e.As == "" {
+			return fmt.Errorf("must provide the name of the value extracted from %q", e.Path)
+		}
+		switch e.Type {
+		case JSONExtractFloat, JSONExtractInt, JSONExtractString, JSONExtractBool, JSONExtractTag:
+		default:
+			return fmt.Errorf("invalid type %q of the value extracted from %q, must be one of float, int, string, bool or tag", e.Type, e.Path)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package pipeline
+
+import (
+	"testing"
+)
+
+func TestJSONExtractNode_MarshalJSON(t *testing.T) {
+	j := newJSONExtractNode(StreamEdge, "payload")
+	j.Extract("$.user.id", "user_id", "int").
+		Extract("$.user.name", "user", "tag").
+		DropMalformed()
+	MarshalTestHelper(t, j, false, `{"typeOf":"jsonExtract","id":"0","field":"payload","extractions":[{"path":"$.user.id","as":"user_id","type":"int"},{"path":"$.user.name","as":"user","type":"tag"}],"dropMalformed":true}`)
+}
+
+func TestJSONExtractNode_UnmarshalJSON(t *testing.T) {
+	input := `{"typeOf":"jsonExtract","id":"0","field":"payload","extractions":[{"path":"$.user.id","as":"user_id","type":"int"},{"path":"$.user.name","as":"user","type":"tag"}],"dropMalformed":true}`
+	want := &JSONExtractNode{
+		Field: "payload",
+		Extractions: []*JSONExtraction{
+			{Path: "$.user.id", As: "user_id", Type: "int"},
+			{Path: "$.user.name", As: "user", Type: "tag"},
+		},
+		DropMalformedFlag: true,
+	}
+	UnmarshalJSONTestHelper(t, []byte(input), &JSONExtractNode{}, false, want)
+}
+
+func TestJSONExtractNode_Validate(t *testing.T) {
+	tests := []struct {
+		name        string
+		field       string
+		extractions []*JSONExtraction
+		wantErr     bool
+	}{
+		{
+			name:  "all types",
+			field: "payload",
+			extractions: []*JSONExtraction{
+				{Path: "$.a", As: "a", Type: "float"},
+				{Path: "$.b", As: "b", Type: "int"},
+				{Path: "$.c", As: "c", Type: "string"},
+				{Path: "$.d", As: "d", Type: "bool"},
+				{Path: "$.e", As: "e", Type: "tag"},
+			},
+		},
+		{
+			name: "no field",
+			extractions: []*JSONExtraction{
+				{Path: "$.a", As: "a", Type: "float"},
+			},
+			wantErr: true,
+		},
+		{
+			name:    "no extractions",
+			field:   "payload",
+			wantErr: true,
+		},
+		{
+			name:  "no path",
+			field: "payload",
+			extractions: []*JSONExtraction{
+				{As: "a", Type: "float"},
+			},
+			wantErr: true,
+		},
+		{
+			name:  "no name",
+			field: "payload",
+			extractions: []*JSONExtraction{
+				{Path: "$.a", Type: "float"},
+			},
+			wantErr: true,
+		},
+		{
+			name:  "unknown type",
+			field: "payload",
+			extractions: []*JSONExtraction{
+				{Path: "$.a", As: "a", Type: "duration"},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j := newJSONExtractNode(StreamEdge, tt.field)
+			for _, e := range tt.extractions {
+				j.Extract(e.Path, e.As, e.Type)
+			}
+			if err := j.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -54,8 +54,17 @@ func TestPipeline_MarshalJSON(t *testing.T) {
             "fillPeriod": false,
             "periodCount": 0,
             "everyCount": 0,
+            "flushCount": 0,
+            "watermark": false,
+            "lateOutput": false,
+            "fillLast": false,
+            "fillLastMax": 0,
+            "trigger": null,
+            "triggerRealign": false,
             "period": "10s",
-            "every": "1s"
+            "every": "1s",
+            "flushPeriod": "0s",
+            "lateness": "0s"
         }
     ],
     "edges": [
@@ -220,6 +229,10 @@ func TestPipeline_MarshalJSON(t *testing.T) {
             "flapLow": 0,
             "flapHigh": 0,
             "history": 21,
+            "valueHistoryField": "",
+            "valueHistoryCount": 0,
+            "rocField": "",
+            "rocLookback": 0,
             "levelTag": "level",
             "levelField": "",
             "messageField": "message",
@@ -230,7 +243,12 @@ func TestPipeline_MarshalJSON(t *testing.T) {
             "noRecoveries": false,
             "stateChangesOnly": true,
             "stateChangesOnlyDuration": 0,
+            "ackTimeout": 0,
+            "summaryInterval": 0,
+            "durableRetryMaxAge": 0,
+            "durableRetryInterval": 0,
             "inhibitors": null,
+            "handlerLimits": null,
             "post": [
                 {
                     "url": "http://howdy.local",
@@ -258,7 +276,8 @@ func TestPipeline_MarshalJSON(t *testing.T) {
             "talk": null,
             "mqtt": null,
             "snmpTrap": null,
-            "kafka": null
+            "kafka": null,
+            "openTelemetry": null
         },
         {
             "typeOf": "httpOut",
@@ -280,7 +299,14 @@ func TestPipeline_MarshalJSON(t *testing.T) {
                 "triggerType": "threshold"
             },
             "create": true,
-            "flushInterval": "10s"
+            "createTargets": false,
+            "writeTagsOnly": null,
+            "dropWriteTags": null,
+            "clusters": null,
+            "failoverThreshold": 3,
+            "failoverBuffer": 10000,
+            "flushInterval": "10s",
+            "probeInterval": "30s"
         }
     ],
     "edges": [
@@ -1298,24 +1324,32 @@ var _ Node = &MockNode{}
 type MockNode struct {
 }
 
-func (m *MockNode) Parents() []Node              { return nil }
-func (m *MockNode) Children() []Node             { return nil }
-func (m *MockNode) addParent(p Node)             {}
-func (m *MockNode) linkChild(c Node)             {}
-func (m *MockNode) Desc() string                 { return "" }
-func (m *MockNode) Name() string                 { return "" }
-func (m *MockNode) SetName(string)               {}
-func (m *MockNode) ID() ID                       { return 0 }
-func (m *MockNode) setID(ID)                     {}
-func (m *MockNode) Wants() EdgeType              { return StreamEdge }
-func (m *MockNode) Provides() EdgeType           { return StreamEdge }
-func (m *MockNode) validate() error              { return nil }
-func (m *MockNode) tMark() bool                  { return true }
-func (m *MockNode) setTMark(b bool)              {}
-func (m *MockNode) pMark() bool                  { return true }
-func (m *MockNode) setPMark(b bool)              {}
-func (m *MockNode) setPipeline(*Pipeline)        {}
-func (m *MockNode) pipeline() *Pipeline          { return nil }
-func (m *MockNode) dot(buf *bytes.Buffer)        {}
-func (m *MockNode) MarshalJSON() ([]byte, error) { return nil, nil }
-func (m *MockNode) IsQuiet() bool                { return false }
+func (m *MockNode) Parents() []Node                        { return nil }
+func (m *MockNode) Children() []Node                       { return nil }
+func (m *MockNode) addParent(p Node)                       {}
+func (m *MockNode) linkChild(c Node)                       {}
+func (m *MockNode) Desc() string                           { return "" }
+func (m *MockNode) Name() string                           { return "" }
+func (m *MockNode) SetName(string)                         {}
+func (m *MockNode) ID() ID                                 { return 0 }
+func (m *MockNode) setID(ID)                               {}
+func (m *MockNode) Wants() EdgeType                        { return StreamEdge }
+func (m *MockNode) Provides() EdgeType                     { return StreamEdge }
+func (m *MockNode) validate() error                        { return nil }
+func (m *MockNode) tMark() bool                            { return true }
+func (m *MockNode) setTMark(b bool)                        {}
+func (m *MockNode) pMark() bool                            { return true }
+func (m *MockNode) setPMark(b bool)                        {}
+func (m *MockNode) setPipeline(*Pipeline)                  {}
+func (m *MockNode) pipeline() *Pipeline                    { return nil }
+func (m *MockNode) dot(buf *bytes.Buffer)                  {}
+func (m *MockNode) MarshalJSON() ([]byte, error)           { return nil, nil }
+func (m *MockNode) IsQuiet() bool                          { return false }
+func (m *MockNode) ReorderWindow() (time.Duration, int64)  { return 0, 0 }
+func (m *MockNode) validateReorder() error                 { return nil }
+func (m *MockNode) DeadLetterQueue() (string, string)      { return "", "" }
+func (m *MockNode) InputBackpressure() string              { return "" }
+func (m *MockNode) validateBackpressure() error            { return nil }
+func (m *MockNode) IsOrderAsserted() bool                  { return false }
+func (m *MockNode) PanicThreshold() (int64, time.Duration) { return 0, 0 }
+func (m *MockNode) WorkerPool() int64                      { return 0 }
@@ -0,0 +1,123 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxdb/influxql"
+)
+
+const defaultLagCompensateAlpha = 0.1
+
+// A LagCompensateNode shifts the time of the data forward by the measured processing lag.
+// The lag is the difference between the wall clock time the data is received and its time.
+// Unlike the shift node, whose shift is fixed, the node keeps a rolling estimate of the lag
+// and shifts the data by the current estimate, so that data sets with a varying lag line up.
+//
+// The estimate is an exponentially weighted moving average of the lag of every point or batch.
+// The alpha property is the weight of the newest lag, higher values follow changes in the lag faster
+// and lower values smooth out the jitter of the lag.
+// The max property bounds the shift in both directions.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('requests')
+//        |lagCompensate()
+//            .alpha(0.2)
+//            .max(5m)
+//
+// Shift the requests forward by the estimated lag, by at most 5m.
+//
+// The noShift property only estimates the lag, leaving the data unchanged,
+// so the lag can be monitored before compensating it.
+//
+// Available Statistics:
+//
+//    * lag_ns -- current estimate of the lag in nanoseconds
+//
+type LagCompensateNode struct {
+	chainnode `json:"-"`
+
+	// The weight of the newest lag in the estimate, between 0 exclusive and 1 inclusive.
+	// Default: 0.1
+	Alpha float64 `json:"alpha"`
+
+	// The largest shift in both directions, if zero the shift is unbounded.
+	Max time.Duration `json:"max"`
+
+	// Whether only the lag is estimated without shifting the data.
+	// tick:ignore
+	NoShiftFlag bool `tick:"NoShift" json:"noShift"`
+}
+
+func newLagCompensateNode(wants EdgeType) *LagCompensateNode {
+	return &LagCompensateNode{
+		chainnode: newBasicChainNode("lagCompensate", wants, wants),
+		Alpha:     defaultLagCompensateAlpha,
+	}
+}
+
+// MarshalJSON converts LagCompensateNode to JSON
+// tick:ignore
+func (n *LagCompensateNode) MarshalJSON() ([]byte, error) {
+	type Alias LagCompensateNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Max string `json:"max"`
+	}{
+		TypeOf: TypeOf{
+			Type: "lagCompensate",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+		Max:   influxql.FormatDuration(n.Max),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an LagCompensateNode
+// tick:ignore
+func (n *LagCompensateNode) UnmarshalJSON(data []byte) error {
+	type Alias LagCompensateNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Max string `json:"max"`
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "lagCompensate" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as LagCompensateNode", raw.ID, raw.Type)
+	}
+	n.Max, err = influxql.ParseDuration(raw.Max)
+	if err != nil {
+		return err
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+// Only estimate the lag, without shifting the data.
+// The estimate is available as the lag_ns statistic.
+// tick:property
+func (n *LagCompensateNode) NoShift() *LagCompensateNode {
+	n.NoShiftFlag = true
+	return n
+}
+
+func (n *LagCompensateNode) validate() error {
+	if n.Alpha <= 0 || n.Alpha > 1 {
+		return fmt.Errorf("alpha must be between 0 exclusive and 1 inclusive, got %v", n.Alpha)
+	}
+	if n.Max < 0 {
+		return fmt.Errorf("max cannot be negative, got %s", influxql.FormatDuration(n.Max))
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package pipeline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLagCompensateNode_MarshalJSON(t *testing.T) {
+	l := newLagCompensateNode(StreamEdge)
+	l.Alpha = 0.2
+	l.Max = 5 * time.Minute
+	l.NoShift()
+	MarshalTestHelper(t, l, false, `{"typeOf":"lagCompensate","id":"0","alpha":0.2,"noShift":true,"max":"5m"}`)
+}
+
+func TestLagCompensateNode_UnmarshalJSON(t *testing.T) {
+	input := `{"typeOf":"lagCompensate","id":"0","alpha":0.2,"noShift":true,"max":"5m"}`
+	want := &LagCompensateNode{
+		Alpha:       0.2,
+		Max:         5 * time.Minute,
+		NoShiftFlag: true,
+	}
+	UnmarshalJSONTestHelper(t, []byte(input), &LagCompensateNode{}, false, want)
+}
+
+func TestLagCompensateNode_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		lag     func(l *LagCompensateNode)
+		wantErr bool
+	}{
+		{
+			name: "defaults",
+			lag:  func(l *LagCompensateNode) {},
+		},
+		{
+			name: "alpha one",
+			lag:  func(l *LagCompensateNode) { l.Alpha = 1 },
+		},
+		{
+			name:    "zero alpha",
+			lag:     func(l *LagCompensateNode) { l.Alpha = 0 },
+			wantErr: true,
+		},
+		{
+			name:    "alpha above one",
+			lag:     func(l *LagCompensateNode) { l.Alpha = 1.5 },
+			wantErr: true,
+		},
+		{
+			name:    "negative max",
+			lag:     func(l *LagCompensateNode) { l.Max = -time.Second },
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newLagCompensateNode(StreamEdge)
+			tt.lag(l)
+			if err := l.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
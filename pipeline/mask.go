@@ -0,0 +1,169 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+const defaultMaskReplacement = "***"
+
+// Masks the parts of field and tag values that match regular expressions,
+// i.e. to redact email addresses or credit card numbers before data is written to logs or other outputs.
+// Masking is irreversible, use an encrypt node if the original values must be recoverable.
+//
+// Each match of each pattern in the given string fields and tags is replaced with the replacement,
+// or with the hex encoded SHA-256 hash of the match if the hash property is set.
+// Hashes allow to correlate equal values without revealing them, note however that values
+// with few possible values, like credit card numbers, can be recovered from their unsalted hash.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('requests')
+//        |mask()
+//            .field('message')
+//            .tag('user')
+//            .pattern(/[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}/)
+//            .pattern(/\b(?:\d[ -]?){12,15}\d\b/)
+//        |log()
+//
+// The above example replaces email addresses and credit card numbers
+// in the `message` field and the `user` tag with `***`.
+//
+// Fields that are not strings and values that do not match any pattern are passed through unchanged.
+// Masking a tag that is a group by dimension changes the group of the point.
+//
+// Available Statistics:
+//
+//    * redactions -- number of matches that were masked.
+//
+type MaskNode struct {
+	chainnode `json:"-"`
+
+	// Set of fields to mask
+	// tick:ignore
+	Fields []string `tick:"Field" json:"fields"`
+
+	// Set of tags to mask
+	// tick:ignore
+	Tags []string `tick:"Tag" json:"tags"`
+
+	// Set of regular expressions matching the parts of values to mask.
+	// tick:ignore
+	Patterns []*regexp.Regexp `tick:"Pattern" json:"-"`
+
+	// The string that replaces each match.
+	// Default: ***
+	Replacement string `json:"replacement"`
+
+	// Whether to replace each match with its SHA-256 hash instead of the replacement.
+	// tick:ignore
+	HashFlag bool `tick:"Hash" json:"hash"`
+}
+
+func newMaskNode(e EdgeType) *MaskNode {
+	return &MaskNode{
+		chainnode:   newBasicChainNode("mask", e, e),
+		Replacement: defaultMaskReplacement,
+	}
+}
+
+// MarshalJSON converts MaskNode to JSON
+// tick:ignore
+func (n *MaskNode) MarshalJSON() ([]byte, error) {
+	type Alias MaskNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Patterns []string `json:"patterns"`
+	}{
+		TypeOf: TypeOf{
+			Type: "mask",
+			ID:   n.ID(),
+		},
+		Alias:    (*Alias)(n),
+		Patterns: regexStrings(n.Patterns),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an MaskNode
+// tick:ignore
+func (n *MaskNode) UnmarshalJSON(data []byte) error {
+	type Alias MaskNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Patterns []string `json:"patterns"`
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "mask" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as MaskNode", raw.ID, raw.Type)
+	}
+	n.Patterns, err = compileRegexes(raw.Patterns)
+	if err != nil {
+		return err
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+func (n *MaskNode) validate() error {
+	if len(n.Fields) == 0 && len(n.Tags) == 0 {
+		return errors.New("must provide at least one field or tag to mask")
+	}
+	for _, f := range n.Fields {
+		if f == "" {
+			return errors.New("field names must not be empty")
+		}
+	}
+	for _, t := range n.Tags {
+		if t == "" {
+			return errors.New("tag names must not be empty")
+		}
+	}
+	if len(n.Patterns) == 0 {
+		return errors.New("must provide at least one pattern")
+	}
+	for _, p := range n.Patterns {
+		if p == nil {
+			return errors.New("pattern cannot be nil")
+		}
+	}
+	return nil
+}
+
+// Mask a field.
+// tick:property
+func (n *MaskNode) Field(name string) *MaskNode {
+	n.Fields = append(n.Fields, name)
+	return n
+}
+
+// Mask a tag.
+// tick:property
+func (n *MaskNode) Tag(name string) *MaskNode {
+	n.Tags = append(n.Tags, name)
+	return n
+}
+
+// Mask the parts of values that match the regular expression.
+// tick:property
+func (n *MaskNode) Pattern(r *regexp.Regexp) *MaskNode {
+	n.Patterns = append(n.Patterns, r)
+	return n
+}
+
+// If set, each match is replaced with its hex encoded SHA-256 hash instead of the replacement.
+// tick:property
+func (n *MaskNode) Hash() *MaskNode {
+	n.HashFlag = true
+	return n
+}
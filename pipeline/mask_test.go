@@ -0,0 +1,72 @@
+package pipeline
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestMaskNode_MarshalJSON(t *testing.T) {
+	m := newMaskNode(StreamEdge)
+	m.Field("message").Tag("user").Pattern(regexp.MustCompile(`\d{16}`)).Hash()
+	MarshalTestHelper(t, m, false, `{"typeOf":"mask","id":"0","fields":["message"],"tags":["user"],"replacement":"***","hash":true,"patterns":["\\d{16}"]}`)
+}
+
+func TestMaskNode_UnmarshalJSON(t *testing.T) {
+	input := `{"typeOf":"mask","id":"0","fields":["message"],"tags":null,"replacement":"[redacted]","hash":false,"patterns":["@example\\.com$"]}`
+	want := &MaskNode{
+		Fields:      []string{"message"},
+		Patterns:    []*regexp.Regexp{regexp.MustCompile(`@example\.com$`)},
+		Replacement: "[redacted]",
+	}
+	UnmarshalJSONTestHelper(t, []byte(input), &MaskNode{}, false, want)
+}
+
+func TestMaskNode_Validate(t *testing.T) {
+	pattern := regexp.MustCompile(`\d{16}`)
+	tests := []struct {
+		name    string
+		node    *MaskNode
+		wantErr bool
+	}{
+		{
+			name: "field",
+			node: newMaskNode(StreamEdge).Field("message").Pattern(pattern),
+		},
+		{
+			name: "tag",
+			node: newMaskNode(StreamEdge).Tag("user").Pattern(pattern),
+		},
+		{
+			name:    "no fields or tags",
+			node:    newMaskNode(StreamEdge).Pattern(pattern),
+			wantErr: true,
+		},
+		{
+			name:    "empty field",
+			node:    newMaskNode(StreamEdge).Field("").Pattern(pattern),
+			wantErr: true,
+		},
+		{
+			name:    "empty tag",
+			node:    newMaskNode(StreamEdge).Tag("").Pattern(pattern),
+			wantErr: true,
+		},
+		{
+			name:    "no patterns",
+			node:    newMaskNode(StreamEdge).Field("message"),
+			wantErr: true,
+		},
+		{
+			name:    "nil pattern",
+			node:    newMaskNode(StreamEdge).Field("message").Pattern(nil),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.node.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxdb/influxql"
+)
+
+// A MaxDelayNode computes the lag of each point, i.e. the difference between the time it is processed
+// and the time of the point, and flags points whose lag exceeds a maximum.
+// It detects ingestion delays upstream of Kapacitor, which a deadman does not catch as long as data keeps arriving.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('cpu')
+//        |maxDelay()
+//            .maxLag(5m)
+//            .breachesOnly()
+//        |alert()
+//            .crit(lambda: "lag_exceeded")
+//
+// The above example alerts when points of the cpu measurement arrive more than 5 minutes after their time.
+//
+// The lag is added to each point as a float field in multiples of the unit.
+// If maxLag is set, a boolean field reports whether the lag exceeds it,
+// and the breachesOnly property drops all points whose lag does not exceed it.
+//
+// The lag is measured against the wall clock of the Kapacitor host,
+// so this node should not be used when replaying recordings of old data.
+//
+// Available Statistics:
+//
+//    * lag_breaches -- number of points whose lag exceeded maxLag
+//
+type MaxDelayNode struct {
+	chainnode `json:"-"`
+
+	// The maximum lag of a point.
+	// If zero points are not checked against a maximum.
+	MaxLag time.Duration `json:"maxLag"`
+
+	// The name of the lag field.
+	// Default: lag
+	As string `json:"as"`
+
+	// The unit of the lag field.
+	// Default: 1s
+	Unit time.Duration `json:"unit"`
+
+	// The name of the boolean field reporting whether the lag exceeds maxLag.
+	// Default: lag_exceeded
+	ExceededAs string `json:"exceededAs"`
+
+	// Whether to drop points whose lag does not exceed maxLag.
+	// tick:ignore
+	BreachesOnlyFlag bool `tick:"BreachesOnly" json:"breachesOnly"`
+}
+
+func newMaxDelayNode(wants EdgeType) *MaxDelayNode {
+	return &MaxDelayNode{
+		chainnode:  newBasicChainNode("maxDelay", wants, wants),
+		As:         "lag",
+		Unit:       time.Second,
+		ExceededAs: "lag_exceeded",
+	}
+}
+
+// MarshalJSON converts MaxDelayNode to JSON
+// tick:ignore
+func (n *MaxDelayNode) MarshalJSON() ([]byte, error) {
+	type Alias MaxDelayNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		MaxLag string `json:"maxLag"`
+		Unit   string `json:"unit"`
+	}{
+		TypeOf: TypeOf{
+			Type: "maxDelay",
+			ID:   n.ID(),
+		},
+		Alias:  (*Alias)(n),
+		MaxLag: influxql.FormatDuration(n.MaxLag),
+		Unit:   influxql.FormatDuration(n.Unit),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an MaxDelayNode
+// tick:ignore
+func (n *MaxDelayNode) UnmarshalJSON(data []byte) error {
+	type Alias MaxDelayNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		MaxLag string `json:"maxLag"`
+		Unit   string `json:"unit"`
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "maxDelay" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as MaxDelayNode", raw.ID, raw.Type)
+	}
+	n.MaxLag, err = influxql.ParseDuration(raw.MaxLag)
+	if err != nil {
+		return err
+	}
+	n.Unit, err = influxql.ParseDuration(raw.Unit)
+	if err != nil {
+		return err
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+// Only forward points whose lag exceeds maxLag.
+// tick:property
+func (n *MaxDelayNode) BreachesOnly() *MaxDelayNode {
+	n.BreachesOnlyFlag = true
+	return n
+}
+
+func (n *MaxDelayNode) validate() error {
+	if n.MaxLag < 0 {
+		return errors.New("maxLag cannot be negative")
+	}
+	if n.As == "" {
+		return errors.New("must provide a name for the lag field")
+	}
+	if n.Unit <= 0 {
+		return errors.New("unit must be greater than zero")
+	}
+	if n.MaxLag > 0 && n.ExceededAs == "" {
+		return errors.New("must provide a name for the exceeded field")
+	}
+	if n.BreachesOnlyFlag && n.MaxLag == 0 {
+		return errors.New("breachesOnly requires maxLag")
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package pipeline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMaxDelayNode_MarshalJSON(t *testing.T) {
+	m := newMaxDelayNode(StreamEdge)
+	m.MaxLag = 5 * time.Minute
+	m.BreachesOnly()
+	MarshalTestHelper(t, m, false, `{"typeOf":"maxDelay","id":"0","as":"lag","exceededAs":"lag_exceeded","breachesOnly":true,"maxLag":"5m","unit":"1s"}`)
+}
+
+func TestMaxDelayNode_UnmarshalJSON(t *testing.T) {
+	input := `{"typeOf":"maxDelay","id":"0","as":"delay","exceededAs":"late","breachesOnly":false,"maxLag":"1m","unit":"1ms"}`
+	want := &MaxDelayNode{
+		MaxLag:     time.Minute,
+		As:         "delay",
+		Unit:       time.Millisecond,
+		ExceededAs: "late",
+	}
+	UnmarshalJSONTestHelper(t, []byte(input), &MaxDelayNode{}, false, want)
+}
+
+func TestMaxDelayNode_Validate(t *testing.T) {
+	newNode := func(maxLag time.Duration, breachesOnly bool) *MaxDelayNode {
+		m := newMaxDelayNode(StreamEdge)
+		m.MaxLag = maxLag
+		m.BreachesOnlyFlag = breachesOnly
+		return m
+	}
+	noUnit := newNode(time.Minute, false)
+	noUnit.Unit = 0
+	noExceeded := newNode(time.Minute, false)
+	noExceeded.ExceededAs = ""
+	tests := []struct {
+		name    string
+		node    *MaxDelayNode
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			node: newNode(time.Minute, true),
+		},
+		{
+			name: "lag only",
+			node: newNode(0, false),
+		},
+		{
+			name:    "negative maxLag",
+			node:    newNode(-time.Minute, false),
+			wantErr: true,
+		},
+		{
+			name:    "no unit",
+			node:    noUnit,
+			wantErr: true,
+		},
+		{
+			name:    "no exceeded field",
+			node:    noExceeded,
+			wantErr: true,
+		},
+		{
+			name:    "breachesOnly without maxLag",
+			node:    newNode(0, true),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.node.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+const (
+	MovingAverageSimple   = "simple"
+	MovingAverageWeighted = "weighted"
+)
+
+// Compute the moving average of a field over the last N points of each group.
+// Unlike the InfluxQL movingAverage function, which operates on the points of a single batch,
+// the rollingAverage node keeps the last N values of each group across points of a stream.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('cpu')
+//            .groupBy('host')
+//        |rollingAverage('usage_idle', 10)
+//            .mode('weighted')
+//            .as('usage_idle_avg')
+//
+// The above example computes the linearly weighted average of the last 10 values of the usage_idle field of each host.
+//
+// The mode determines how the values are averaged:
+//
+//    * simple -- all values have the same weight.
+//    * weighted -- values are linearly weighted, the oldest value has weight 1 and the most recent value has weight N.
+//
+// Nothing is emitted for a group until it has received minPoints values,
+// by default only once N values have been received.
+// Setting minPoints lower emits the average of the values received so far during the warm-up.
+//
+// The values of each group are reset at the start of each batch.
+type MovingAverageNode struct {
+	chainnode `json:"-"`
+
+	// The field to average.
+	// tick:ignore
+	Field string `json:"field"`
+
+	// The number of points to average.
+	// tick:ignore
+	Size int64 `json:"size"`
+
+	// How the values are averaged, one of simple or weighted.
+	// Default: simple
+	Mode string `json:"mode"`
+
+	// The name of the field of the average.
+	// Default is the name of the averaged field.
+	As string `json:"as"`
+
+	// The minimum number of values a group must have received before an average is emitted.
+	// Default is the number of points to average.
+	MinPoints int64 `json:"minPoints"`
+}
+
+func newMovingAverageNode(wants EdgeType, field string, size int64) *MovingAverageNode {
+	return &MovingAverageNode{
+		chainnode: newBasicChainNode("rolling_average", wants, wants),
+		Field:     field,
+		Size:      size,
+		Mode:      MovingAverageSimple,
+		As:        field,
+		MinPoints: size,
+	}
+}
+
+// MarshalJSON converts MovingAverageNode to JSON
+// tick:ignore
+func (n *MovingAverageNode) MarshalJSON() ([]byte, error) {
+	type Alias MovingAverageNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		TypeOf: TypeOf{
+			Type: "rollingAverage",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an MovingAverageNode
+// tick:ignore
+func (n *MovingAverageNode) UnmarshalJSON(data []byte) error {
+	type Alias MovingAverageNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "rollingAverage" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as MovingAverageNode", raw.ID, raw.Type)
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+func (n *MovingAverageNode) validate() error {
+	if n.Field == "" {
+		return errors.New("must provide a field to average")
+	}
+	if n.Size < 1 {
+		return errors.New("number of points to average must be at least 1")
+	}
+	switch n.Mode {
+	case MovingAverageSimple, MovingAverageWeighted:
+	default:
+		return fmt.Errorf("invalid mode %q, must be one of %s or %s", n.Mode, MovingAverageSimple, MovingAverageWeighted)
+	}
+	if n.As == "" {
+		return errors.New("must provide a name for the average field")
+	}
+	if n.MinPoints < 1 || n.MinPoints > n.Size {
+		return fmt.Errorf("minPoints must be between 1 and %d", n.Size)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package pipeline
+
+import (
+	"testing"
+)
+
+func TestMovingAverageNode_MarshalJSON(t *testing.T) {
+	m := newMovingAverageNode(StreamEdge, "value", 10)
+	m.Mode = MovingAverageWeighted
+	m.As = "avg"
+	m.MinPoints = 5
+	MarshalTestHelper(t, m, false, `{"typeOf":"rollingAverage","id":"0","field":"value","size":10,"mode":"weighted","as":"avg","minPoints":5}`)
+}
+
+func TestMovingAverageNode_UnmarshalJSON(t *testing.T) {
+	input := `{"typeOf":"rollingAverage","id":"0","field":"value","size":10,"mode":"simple","as":"value","minPoints":10}`
+	want := &MovingAverageNode{
+		Field:     "value",
+		Size:      10,
+		Mode:      MovingAverageSimple,
+		As:        "value",
+		MinPoints: 10,
+	}
+	UnmarshalJSONTestHelper(t, []byte(input), &MovingAverageNode{}, false, want)
+}
+
+func TestMovingAverageNode_Validate(t *testing.T) {
+	newNode := func(field string, size int64, mode string, minPoints int64) *MovingAverageNode {
+		m := newMovingAverageNode(StreamEdge, field, size)
+		m.Mode = mode
+		m.MinPoints = minPoints
+		return m
+	}
+	tests := []struct {
+		name    string
+		node    *MovingAverageNode
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			node: newNode("value", 10, MovingAverageSimple, 10),
+		},
+		{
+			name: "partial",
+			node: newNode("value", 10, MovingAverageWeighted, 1),
+		},
+		{
+			name:    "no field",
+			node:    newNode("", 10, MovingAverageSimple, 10),
+			wantErr: true,
+		},
+		{
+			name:    "no size",
+			node:    newNode("value", 0, MovingAverageSimple, 0),
+			wantErr: true,
+		},
+		{
+			name:    "invalid mode",
+			node:    newNode("value", 10, "exponential", 10),
+			wantErr: true,
+		},
+		{
+			name:    "no minPoints",
+			node:    newNode("value", 10, MovingAverageSimple, 0),
+			wantErr: true,
+		},
+		{
+			name:    "minPoints greater than size",
+			node:    newNode("value", 10, MovingAverageSimple, 11),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.node.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
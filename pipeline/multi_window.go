@@ -0,0 +1,193 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxdb/influxql"
+)
+
+// Aggregates of a MultiWindowNode.
+const (
+	MultiWindowCount = "count"
+	MultiWindowSum   = "sum"
+	MultiWindowMean  = "mean"
+	MultiWindowMin   = "min"
+	MultiWindowMax   = "max"
+	MultiWindowFirst = "first"
+	MultiWindowLast  = "last"
+)
+
+const defaultMultiWindowTag = "window"
+
+// A MultiWindowNode aggregates a field over windows of several sizes at once,
+// sharing a single pass over the data between all windows.
+// The windows of each size are aligned to the clock, i.e. the 5m windows start at multiples of five minutes since the epoch.
+//
+// When a window closes the node emits a point per group with the aggregate of the window,
+// tagged with the size of the window.
+// The point has the time of the end of the window, the name of the measurement of the data
+// and the tags of the group, and is grouped by the dimensions of the group and the window tag.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('cpu')
+//            .groupBy('host')
+//        |multiWindow('usage_user', 1m, 5m, 1h)
+//            .aggregate('mean')
+//        |influxDBOut()
+//            .database('dashboards')
+//            .measurement('cpu_usage')
+//
+// The above example writes the mean of the `usage_user` field of each host over each minute,
+// each five minutes and each hour, in the field `mean` with the tag `window` set to `1m`, `5m` and `1h`.
+//
+// The aggregate is one of:
+//
+//    * count -- number of values
+//    * sum -- sum of the values
+//    * mean -- mean of the values
+//    * min -- smallest value
+//    * max -- largest value
+//    * first -- first value by time
+//    * last -- last value by time
+//
+// Windows are closed by the first point or barrier at or after their end, see BarrierNode.
+// Windows without values emit nothing, and points older than the current window of a size
+// are not counted in that window.
+// Only numeric values are aggregated, points without a numeric value of the field are ignored and an error is logged.
+// Only applies to stream data.
+//
+// Available Statistics:
+//
+//    * late_points -- number of times a point was older than the current window of a size
+//
+type MultiWindowNode struct {
+	chainnode `json:"-"`
+
+	// The field to aggregate.
+	// tick:ignore
+	Field string `json:"field"`
+
+	// The sizes of the windows.
+	// tick:ignore
+	Sizes []time.Duration `json:"-"`
+
+	// The aggregate of each window.
+	// Default: mean
+	Aggregate string `json:"aggregate"`
+
+	// The name of the field of the aggregate.
+	// Default is the name of the aggregate.
+	As string `json:"as"`
+
+	// The name of the tag with the size of the window.
+	// Default: window
+	Tag string `json:"tag"`
+}
+
+func newMultiWindowNode(wants EdgeType, field string, sizes []time.Duration) *MultiWindowNode {
+	return &MultiWindowNode{
+		chainnode: newBasicChainNode("multi_window", wants, StreamEdge),
+		Field:     field,
+		Sizes:     sizes,
+		Aggregate: MultiWindowMean,
+		Tag:       defaultMultiWindowTag,
+	}
+}
+
+// MarshalJSON converts MultiWindowNode to JSON
+// tick:ignore
+func (n *MultiWindowNode) MarshalJSON() ([]byte, error) {
+	type Alias MultiWindowNode
+	sizes := make([]string, len(n.Sizes))
+	for i, s := range n.Sizes {
+		sizes[i] = influxql.FormatDuration(s)
+	}
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Sizes []string `json:"sizes"`
+	}{
+		TypeOf: TypeOf{
+			Type: "multiWindow",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+		Sizes: sizes,
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to a MultiWindowNode
+// tick:ignore
+func (n *MultiWindowNode) UnmarshalJSON(data []byte) error {
+	type Alias MultiWindowNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Sizes []string `json:"sizes"`
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "multiWindow" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as MultiWindowNode", raw.ID, raw.Type)
+	}
+	n.Sizes = nil
+	for _, s := range raw.Sizes {
+		d, err := influxql.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		n.Sizes = append(n.Sizes, d)
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+// AsField returns the name of the field of the aggregate.
+// tick:ignore
+func (n *MultiWindowNode) AsField() string {
+	if n.As != "" {
+		return n.As
+	}
+	return n.Aggregate
+}
+
+func (n *MultiWindowNode) validate() error {
+	if n.Wants() != StreamEdge {
+		return errors.New("multiWindow only supports stream data")
+	}
+	if n.Field == "" {
+		return errors.New("must provide a field")
+	}
+	if len(n.Sizes) == 0 {
+		return errors.New("must provide at least one window size")
+	}
+	seen := make(map[time.Duration]bool, len(n.Sizes))
+	for _, s := range n.Sizes {
+		if s <= 0 {
+			return fmt.Errorf("window size must be greater than 0, got %s", influxql.FormatDuration(s))
+		}
+		if seen[s] {
+			return fmt.Errorf("duplicate window size %s", influxql.FormatDuration(s))
+		}
+		seen[s] = true
+	}
+	switch n.Aggregate {
+	case MultiWindowCount, MultiWindowSum, MultiWindowMean, MultiWindowMin, MultiWindowMax, MultiWindowFirst, MultiWindowLast:
+	default:
+		return fmt.Errorf("invalid aggregate %q, must be one of count, sum, mean, min, max, first or last", n.Aggregate)
+	}
+	if n.Tag == "" {
+		return errors.New("must provide a tag for the window size")
+	}
+	return nil
+}
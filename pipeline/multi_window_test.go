@@ -0,0 +1,83 @@
+package pipeline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMultiWindowNode_MarshalJSON(t *testing.T) {
+	m := newMultiWindowNode(StreamEdge, "usage_user", []time.Duration{time.Minute, 5 * time.Minute, time.Hour})
+	m.As = "avg"
+	MarshalTestHelper(t, m, false, `{"typeOf":"multiWindow","id":"0","field":"usage_user","aggregate":"mean","as":"avg","tag":"window","sizes":["1m","5m","1h"]}`)
+}
+
+func TestMultiWindowNode_UnmarshalJSON(t *testing.T) {
+	input := `{"typeOf":"multiWindow","id":"0","field":"usage_user","aggregate":"sum","as":"","tag":"window","sizes":["1m","1h"]}`
+	want := &MultiWindowNode{
+		Field:     "usage_user",
+		Sizes:     []time.Duration{time.Minute, time.Hour},
+		Aggregate: "sum",
+		Tag:       "window",
+	}
+	UnmarshalJSONTestHelper(t, []byte(input), &MultiWindowNode{}, false, want)
+}
+
+func TestMultiWindowNode_Validate(t *testing.T) {
+	newNode := func(wants EdgeType, f func(m *MultiWindowNode)) *MultiWindowNode {
+		m := newMultiWindowNode(wants, "value", []time.Duration{time.Minute, time.Hour})
+		f(m)
+		return m
+	}
+	tests := []struct {
+		name    string
+		node    *MultiWindowNode
+		wantErr bool
+	}{
+		{
+			name: "defaults",
+			node: newNode(StreamEdge, func(*MultiWindowNode) {}),
+		},
+		{
+			name:    "batch",
+			node:    newNode(BatchEdge, func(*MultiWindowNode) {}),
+			wantErr: true,
+		},
+		{
+			name:    "empty field",
+			node:    newNode(StreamEdge, func(m *MultiWindowNode) { m.Field = "" }),
+			wantErr: true,
+		},
+		{
+			name:    "no sizes",
+			node:    newNode(StreamEdge, func(m *MultiWindowNode) { m.Sizes = nil }),
+			wantErr: true,
+		},
+		{
+			name:    "zero size",
+			node:    newNode(StreamEdge, func(m *MultiWindowNode) { m.Sizes = []time.Duration{0} }),
+			wantErr: true,
+		},
+		{
+			name:    "duplicate size",
+			node:    newNode(StreamEdge, func(m *MultiWindowNode) { m.Sizes = []time.Duration{time.Minute, time.Minute} }),
+			wantErr: true,
+		},
+		{
+			name:    "invalid aggregate",
+			node:    newNode(StreamEdge, func(m *MultiWindowNode) { m.Aggregate = "median" }),
+			wantErr: true,
+		},
+		{
+			name:    "empty tag",
+			node:    newNode(StreamEdge, func(m *MultiWindowNode) { m.Tag = "" }),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.node.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
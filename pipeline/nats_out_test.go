@@ -0,0 +1,75 @@
+package pipeline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNatsOutNode_MarshalJSON(t *testing.T) {
+	o := newNatsOutNode(StreamEdge, "metrics.{{ .Name }}")
+	o.Format = NatsOutFormatLine
+	o.UseJetStream = true
+	o.Timeout = 10 * time.Second
+	MarshalTestHelper(t, o, false, `{"typeOf":"natsOut","id":"0","subject":"metrics.{{ .Name }}","url":"nats://localhost:4222","format":"line","jetStream":true,"timeout":"10s"}`)
+}
+
+func TestNatsOutNode_UnmarshalJSON(t *testing.T) {
+	input := `{"typeOf":"natsOut","id":"0","subject":"metrics","url":"nats://localhost:4222","format":"json","jetStream":false,"timeout":"5s"}`
+	want := &NatsOutNode{
+		Subject: "metrics",
+		URL:     "nats://localhost:4222",
+		Format:  NatsOutFormatJSON,
+		Timeout: 5 * time.Second,
+	}
+	UnmarshalJSONTestHelper(t, []byte(input), &NatsOutNode{}, false, want)
+}
+
+func TestNatsOutNode_Validate(t *testing.T) {
+	newNode := func(f func(o *NatsOutNode)) *NatsOutNode {
+		o := newNatsOutNode(StreamEdge, "metrics.{{ .Name }}")
+		f(o)
+		return o
+	}
+	tests := []struct {
+		name    string
+		node    *NatsOutNode
+		wantErr bool
+	}{
+		{
+			name: "defaults",
+			node: newNode(func(*NatsOutNode) {}),
+		},
+		{
+			name:    "empty subject",
+			node:    newNode(func(o *NatsOutNode) { o.Subject = "" }),
+			wantErr: true,
+		},
+		{
+			name:    "invalid subject template",
+			node:    newNode(func(o *NatsOutNode) { o.Subject = "metrics.{{ .Name" }),
+			wantErr: true,
+		},
+		{
+			name:    "empty url",
+			node:    newNode(func(o *NatsOutNode) { o.URL = "" }),
+			wantErr: true,
+		},
+		{
+			name:    "invalid format",
+			node:    newNode(func(o *NatsOutNode) { o.Format = "csv" }),
+			wantErr: true,
+		},
+		{
+			name:    "zero timeout",
+			node:    newNode(func(o *NatsOutNode) { o.Timeout = 0 }),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.node.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package pipeline
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -66,8 +67,35 @@ type Node interface {
 	// IsQuiet reports whether the node should suppress all errors during evaluation.
 	IsQuiet() bool
 
+	// ReorderWindow returns the lateness and size of the reorder buffer of the input of the node.
+	// A zero lateness means the input is not reordered.
+	ReorderWindow() (time.Duration, int64)
+
 	// Check that the definition of the node is consistent
 	validate() error
+	// Check that the reorder buffer of the node is valid
+	validateReorder() error
+
+	// DeadLetterQueue returns the sink and target of the dead letter queue of the node.
+	// An empty sink means points the node fails to process are dropped.
+	DeadLetterQueue() (sink, target string)
+
+	// InputBackpressure returns the backpressure policy of the edges from the parents of the node.
+	// An empty policy means the edges block.
+	InputBackpressure() string
+	// Check that the backpressure policy of the node is valid
+	validateBackpressure() error
+
+	// IsOrderAsserted reports whether the order of the points arriving at the node is checked.
+	IsOrderAsserted() bool
+
+	// PanicThreshold returns the number of panics the node recovers within the window before the task fails.
+	// A zero count means panics are not recovered.
+	PanicThreshold() (count int64, window time.Duration)
+
+	// WorkerPool returns the number of worker goroutines processing the groups of the node.
+	// A count of zero or one means the groups are processed on a single goroutine.
+	WorkerPool() int64
 
 	// Helper methods for walking DAG
 	tMark() bool
@@ -95,6 +123,30 @@ type node struct {
 
 	// tick:ignore
 	QuietFlag bool `tick:"Quiet" json:"quiet,omitempty"`
+
+	// tick:ignore
+	ReorderLateness time.Duration `tick:"Reorder" json:"reorderLateness,omitempty"`
+	// tick:ignore
+	ReorderSize int64 `tick:"Reorder" json:"reorderSize,omitempty"`
+
+	// tick:ignore
+	DeadLetterSink string `tick:"DeadLetter" json:"deadLetterSink,omitempty"`
+	// tick:ignore
+	DeadLetterTarget string `tick:"DeadLetter" json:"deadLetterTarget,omitempty"`
+
+	// tick:ignore
+	BackpressurePolicy string `tick:"Backpressure" json:"backpressure,omitempty"`
+
+	// tick:ignore
+	AssertOrderFlag bool `tick:"AssertOrder" json:"assertOrder,omitempty"`
+
+	// tick:ignore
+	PanicLimitCount int64 `tick:"PanicLimit" json:"panicLimitCount,omitempty"`
+	// tick:ignore
+	PanicLimitWindow time.Duration `tick:"PanicLimit" json:"panicLimitWindow,omitempty"`
+
+	// tick:ignore
+	WorkerCount int64 `tick:"Workers" json:"workers,omitempty"`
 }
 
 // tick:ignore
@@ -108,6 +160,324 @@ func (n *node) Quiet() {
 	n.QuietFlag = true
 }
 
+// Default maximum number of points in a reorder buffer.
+const DefaultReorderSize = 1000
+
+// Reorder the points arriving at this node by time before processing them,
+// tolerating points that arrive up to lateness after newer points.
+// Points are buffered until they are older than the newest point minus the lateness.
+// An optional size limits the number of buffered points, default 1000.
+// When the buffer is full the oldest point is processed regardless of the lateness.
+// Points older than a point that has already been processed are dropped,
+// see the reorder_dropped statistic of the edge.
+//
+// Barriers and group deletes flush the buffered points up to their time.
+// As buffered points are only released by newer data,
+// use a barrier node upstream to flush points when the data stops.
+//
+// Only nodes that want a stream edge can reorder their input.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('cpu')
+//        |derivative('value')
+//            .reorder(10s)
+//
+// tick:property
+func (n *node) Reorder(lateness time.Duration, size ...int64) {
+	n.ReorderLateness = lateness
+	n.ReorderSize = DefaultReorderSize
+	if len(size) > 0 {
+		n.ReorderSize = size[0]
+	}
+}
+
+// tick:ignore
+func (n *node) ReorderWindow() (time.Duration, int64) {
+	return n.ReorderLateness, n.ReorderSize
+}
+
+func (n *node) validateReorder() error {
+	if n.ReorderLateness == 0 && n.ReorderSize == 0 {
+		return nil
+	}
+	if n.wants != StreamEdge {
+		return fmt.Errorf("cannot reorder the input of %s, only stream edges can be reordered", n.Name())
+	}
+	if n.ReorderLateness <= 0 {
+		return errors.New("reorder lateness must be greater than 0")
+	}
+	if n.ReorderSize <= 0 {
+		return errors.New("reorder size must be greater than 0")
+	}
+	return nil
+}
+
+// Sinks of a dead letter queue.
+const (
+	DeadLetterFile     = "file"
+	DeadLetterInfluxDB = "influxdb"
+	DeadLetterTopic    = "topic"
+)
+
+// Send the points this node fails to process to a dead letter queue instead of dropping them,
+// annotated with the task, the node and the error.
+// The queue is shared by all nodes of the task with the same sink and target.
+//
+// The sink is one of:
+//
+//    * file -- append the points as JSON lines to the file at target.
+//    * influxdb -- write the points to InfluxDB, target is of the form database.retentionPolicy.measurement.
+//    * topic -- publish the points as alert events to the topic target.
+//
+// Points are routed to the dead letter queue by the eval and where nodes
+// when evaluating an expression fails, and by the validateSchema node when they do not conform to the schema.
+// Only the eval, where and validateSchema nodes can have a dead letter queue.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('requests')
+//        |eval(lambda: "errors" / "total")
+//            .as('error_rate')
+//            .deadLetter('file', '/var/lib/kapacitor/dead_letters.log')
+//
+// tick:property
+func (n *node) DeadLetter(sink, target string) {
+	n.DeadLetterSink = sink
+	n.DeadLetterTarget = target
+}
+
+// tick:ignore
+func (n *node) DeadLetterQueue() (string, string) {
+	return n.DeadLetterSink, n.DeadLetterTarget
+}
+
+// validateDeadLetter checks that the dead letter queue of the node n is valid and that n can route points to it.
+func validateDeadLetter(n Node) error {
+	sink, target := n.DeadLetterQueue()
+	if sink == "" && target == "" {
+		return nil
+	}
+	if target == "" {
+		return errors.New("dead letter target must not be empty")
+	}
+	switch sink {
+	case DeadLetterFile, DeadLetterTopic:
+	case DeadLetterInfluxDB:
+		if _, _, _, err := SplitDeadLetterMeasurement(target); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("invalid dead letter sink %q, must be one of file, influxdb or topic", sink)
+	}
+	switch n.(type) {
+	case *EvalNode, *WhereNode, *ValidateSchemaNode:
+	default:
+		return fmt.Errorf("cannot use deadLetter with %s, only eval, where and validateSchema nodes can have a dead letter queue", n.Name())
+	}
+	return nil
+}
+
+// SplitDeadLetterMeasurement splits the target of an influxdb dead letter queue
+// into its database, retention policy and measurement.
+func SplitDeadLetterMeasurement(target string) (db, rp, measurement string, err error) {
+	parts := strings.SplitN(target, ".", 3)
+	if len(parts) != 3 || parts[0] == "" || parts[2] == "" {
+		return "", "", "", fmt.Errorf("invalid dead letter target %q, must be of the form database.retentionPolicy.measurement", target)
+	}
+	return parts[0], parts[1], parts[2], nil
+}
+
+// Backpressure policies of the edges between nodes.
+const (
+	BackpressureBlock      = "block"
+	BackpressureDropNewest = "dropNewest"
+	BackpressureDropOldest = "dropOldest"
+)
+
+// Set what happens to the points sent to this node while it falls behind
+// and the buffer of the edge from its parent is full.
+//
+// The policy is one of:
+//
+//    * block -- the parent waits until the buffer has room, no points are lost. The default.
+//    * dropNewest -- the point sent by the parent is dropped.
+//    * dropOldest -- the oldest buffered point is dropped to make room for the point sent by the parent.
+//
+// Dropping points keeps a slow node from holding up the other children of its parent,
+// for best-effort outputs that can tolerate missing data.
+// Barriers and group deletes are never dropped.
+// Dropped points are counted in the backpressure_dropped statistic of the edge.
+//
+// Only nodes that want a stream edge can drop points.
+//
+// Example:
+//    var data = stream
+//        |from()
+//            .measurement('cpu')
+//
+//    data
+//        |influxDBOut()
+//            .database('archive')
+//
+//    data
+//        |httpPost('http://example.com/metrics')
+//            .backpressure('dropOldest')
+//
+// The above example never loses points written to InfluxDB,
+// while a slow HTTP endpoint only loses the points it could not keep up with.
+//
+// tick:property
+func (n *node) Backpressure(policy string) {
+	n.BackpressurePolicy = policy
+}
+
+// tick:ignore
+func (n *node) InputBackpressure() string {
+	return n.BackpressurePolicy
+}
+
+func (n *node) validateBackpressure() error {
+	switch n.BackpressurePolicy {
+	case "", BackpressureBlock:
+		return nil
+	case BackpressureDropNewest, BackpressureDropOldest:
+	default:
+		return fmt.Errorf("invalid backpressure policy %q, must be one of block, dropNewest or dropOldest", n.BackpressurePolicy)
+	}
+	if n.wants != StreamEdge {
+		return fmt.Errorf("cannot drop the input of %s, only stream edges can drop points", n.Name())
+	}
+	return nil
+}
+
+// Check that the points arriving at this node are in time order, for debugging.
+// A point or batch point older than the previous point of its group is logged with both times
+// and counted in the order_violations statistic of the edge.
+// The data is passed on unchanged.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('cpu')
+//        |derivative('value')
+//            .assertOrder()
+//
+// tick:property
+func (n *node) AssertOrder() {
+	n.AssertOrderFlag = true
+}
+
+// tick:ignore
+func (n *node) IsOrderAsserted() bool {
+	return n.AssertOrderFlag
+}
+
+// Recover the panics of this node while it processes a message instead of crashing,
+// and fail the task once more than count panics are recovered within window.
+// The message that caused a panic is dropped and the panic is logged with its stack trace.
+// A node that panics on all of its data, e.g. on malformed points, thus fails the task
+// with a clear error instead of silently dropping everything.
+//
+// Recovered panics are counted in the panics_recovered statistic of the node.
+// Panics are recovered by the nodes that process each message on its own, such as eval and where.
+// The panic limit cannot be used with the stream, batch, query, combine, flatten, groupBy, heartbeat, join,
+// kapacitorLoopback, noOp, sanitize, split, stats, udf and union nodes.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('requests')
+//        |eval(lambda: "errors" / "total")
+//            .as('error_rate')
+//            .panicLimit(10, 1m)
+//
+// The above example fails the task if eval panics more than 10 times within a minute.
+//
+// tick:property
+func (n *node) PanicLimit(count int64, window time.Duration) {
+	n.PanicLimitCount = count
+	n.PanicLimitWindow = window
+}
+
+// tick:ignore
+func (n *node) PanicThreshold() (int64, time.Duration) {
+	return n.PanicLimitCount, n.PanicLimitWindow
+}
+
+// validatePanicLimit checks that the panic limit of the node n is valid and that n recovers its panics.
+func validatePanicLimit(n Node) error {
+	count, window := n.PanicThreshold()
+	if count == 0 && window == 0 {
+		return nil
+	}
+	if count <= 0 {
+		return errors.New("panic limit count must be greater than 0")
+	}
+	if window <= 0 {
+		return errors.New("panic limit window must be greater than 0")
+	}
+	switch n.(type) {
+	case *StreamNode, *BatchNode, *QueryNode, *CombineNode, *FlattenNode, *GroupByNode, *HeartbeatNode, *JoinNode,
+		*KapacitorLoopbackNode, *NoOpNode, *SanitizeNode, *SplitNode, *SplitBranchNode, *StatsNode, *UDFNode, *UnionNode:
+		return fmt.Errorf("cannot use panicLimit with %s, the node does not recover its panics", n.Name())
+	}
+	return nil
+}
+
+// Process the groups of this node in parallel on a pool of count worker goroutines,
+// to use several cores for a CPU heavy node, e.g. an eval with complex expressions.
+//
+// Each group is assigned to a worker by the hash of its group ID,
+// so the points, batches and barriers of a group are processed in order,
+// while different groups are processed in parallel.
+// The order of the points of different groups in the output of the node is not preserved.
+// Batches are processed one at a time, so that the batches in the output are not interleaved,
+// and only benefit from the workers when the processing of a batch is short.
+// The avg_exec_time_ns statistic is not measured for a node with workers.
+//
+// Only the eval and where nodes can have workers.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('requests')
+//            .groupBy('host')
+//        |eval(lambda: sigma("latency"), lambda: "errors" / "total")
+//            .as('latency_sigma', 'error_rate')
+//            .workers(4)
+//
+// The above example evaluates the expressions for up to four hosts at the same time.
+//
+// tick:property
+func (n *node) Workers(count int64) {
+	n.WorkerCount = count
+}
+
+// tick:ignore
+func (n *node) WorkerPool() int64 {
+	return n.WorkerCount
+}
+
+// validateWorkers checks that the node n can have its worker pool.
+func validateWorkers(n Node) error {
+	count := n.WorkerPool()
+	if count == 0 {
+		return nil
+	}
+	if count < 0 {
+		return errors.New("worker count must be greater than 0")
+	}
+	switch n.(type) {
+	case *EvalNode, *WhereNode:
+	default:
+		return fmt.Errorf("cannot use workers with %s, only eval and where nodes can have workers", n.Name())
+	}
+	return nil
+}
+
 // tick:ignore
 func (n *node) Desc() string {
 	return n.desc
@@ -494,6 +864,364 @@ func (n *chainnode) Delete() *DeleteNode {
 	return s
 }
 
+// Create a node that routes each point to one of several named branches.
+func (n *chainnode) Split() *SplitNode {
+	s := newSplitNode(n.Provides())
+	n.linkChild(s)
+	return s
+}
+
+// Create a node that drops tags matching the given names or glob patterns.
+func (n *chainnode) DropTags(tags ...string) *DropTagsNode {
+	s := newDropTagsNode(n.Provides(), tags)
+	n.linkChild(s)
+	return s
+}
+
+// Create a node that drops fields matching the given names or glob patterns.
+func (n *chainnode) DropFields(fields ...string) *DropFieldsNode {
+	s := newDropFieldsNode(n.Provides(), fields)
+	n.linkChild(s)
+	return s
+}
+
+// Create a node that converts a field to a tag.
+func (n *chainnode) FieldToTag(field string) *FieldToTagNode {
+	f := newFieldToTagNode(n.Provides(), field)
+	n.linkChild(f)
+	return f
+}
+
+// Create a node that derives a tag from a regular expression capture group on another tag or field.
+func (n *chainnode) DeriveTag(tag string) *DeriveTagNode {
+	d := newDeriveTagNode(n.Provides(), tag)
+	n.linkChild(d)
+	return d
+}
+
+// Create a node that converts a tag to a field.
+func (n *chainnode) TagToField(tag string) *TagToFieldNode {
+	t := newTagToFieldNode(n.Provides(), tag)
+	n.linkChild(t)
+	return t
+}
+
+// Create a node that computes exact percentiles of a field over each window of data.
+func (n *chainnode) Percentiles(field string, percentiles ...float64) *PercentilesNode {
+	p := newPercentilesNode(n.Provides(), field, percentiles)
+	n.linkChild(p)
+	return p
+}
+
+// Create a node that estimates the number of distinct values of a field or tag per window using HyperLogLog.
+func (n *chainnode) CountDistinct(field string) *CountDistinctNode {
+	c := newCountDistinctNode(n.Provides(), field)
+	n.linkChild(c)
+	return c
+}
+
+// Create a node that marks points whose ID was probably seen before using a Bloom filter.
+func (n *chainnode) BloomFilter(field string) *BloomFilterNode {
+	b := newBloomFilterNode(n.Provides(), field)
+	n.linkChild(b)
+	return b
+}
+
+// Create a node that concatenates fields, tags and literal strings into string fields.
+func (n *chainnode) ConcatFields() *ConcatFieldsNode {
+	c := newConcatFieldsNode(n.Provides())
+	n.linkChild(c)
+	return c
+}
+
+// Create a node that shifts the time of the data forward by the measured processing lag.
+func (n *chainnode) LagCompensate() *LagCompensateNode {
+	l := newLagCompensateNode(n.Provides())
+	n.linkChild(l)
+	return l
+}
+
+// Create a node that bounds numeric fields into ranges.
+func (n *chainnode) Clamp() *ClampNode {
+	c := newClampNode(n.Provides())
+	n.linkChild(c)
+	return c
+}
+
+// Create a node that computes the time spent in each value of a state field per window.
+func (n *chainnode) TimeInState(field string) *TimeInStateNode {
+	t := newTimeInStateNode(n.Provides(), field)
+	n.linkChild(t)
+	return t
+}
+
+// Create a node that masks the parts of field and tag values that match regular expressions.
+func (n *chainnode) Mask() *MaskNode {
+	m := newMaskNode(n.Provides())
+	n.linkChild(m)
+	return m
+}
+
+// Create a node that sets a field to the value of the first of the fields that has a value.
+func (n *chainnode) Coalesce(as string, fields ...string) *CoalesceNode {
+	c := newCoalesceNode(n.Provides(), as, fields)
+	n.linkChild(c)
+	return c
+}
+
+// Create a node that resolves the IP addresses of a tag to hostnames.
+func (n *chainnode) ReverseDNS(tag string) *ReverseDNSNode {
+	r := newReverseDNSNode(n.Provides(), tag)
+	n.linkChild(r)
+	return r
+}
+
+// Create a node that formats a numeric duration field as a human readable string field.
+func (n *chainnode) Humanize(field, as string) *DurationFormatNode {
+	d := newDurationFormatNode(n.Provides(), field, as)
+	n.linkChild(d)
+	return d
+}
+
+// Create a node that maps the values of a tag through a lookup table.
+func (n *chainnode) TagValueMap(tag string) *TagValueMapNode {
+	m := newTagValueMapNode(n.Provides(), tag)
+	n.linkChild(m)
+	return m
+}
+
+// Create a node that publishes each point to a Kafka topic as an Avro record,
+// with the schema registered in a schema registry.
+func (n *chainnode) AvroOut(topic string) *AvroOutNode {
+	a := newAvroOutNode(n.Provides(), topic)
+	n.linkChild(a)
+	return a
+}
+
+// Create a node that emits a point only when the field moved beyond a deadband around the last emitted value.
+func (n *chainnode) Deadband(field string) *DeadbandNode {
+	d := newDeadbandNode(n.Provides(), field)
+	n.linkChild(d)
+	return d
+}
+
+// Create a node that maintains a running total per group of a field of increments and decrements.
+func (n *chainnode) UpDownCounter(field string) *UpDownCounterNode {
+	c := newUpDownCounterNode(n.Provides(), field)
+	n.linkChild(c)
+	return c
+}
+
+// Create a node that drops the points that do not conform to an Avro schema.
+func (n *chainnode) ValidateSchema() *ValidateSchemaNode {
+	v := newValidateSchemaNode(n.Provides())
+	n.linkChild(v)
+	return v
+}
+
+// Create a node that aggregates a field over windows of several sizes at once.
+func (n *chainnode) MultiWindow(field string, sizes ...time.Duration) *MultiWindowNode {
+	m := newMultiWindowNode(n.Provides(), field, sizes)
+	n.linkChild(m)
+	return m
+}
+
+// Create a node that rewrites field and tag values with regular expression find and replace rules.
+func (n *chainnode) RegexReplace() *RegexReplaceNode {
+	r := newRegexReplaceNode(n.Provides())
+	n.linkChild(r)
+	return r
+}
+
+// Create a node that emits a heartbeat point on an interval regardless of the data.
+func (n *chainnode) Heartbeat(interval time.Duration) *HeartbeatNode {
+	h := newHeartbeatNode(n.Provides(), interval)
+	n.linkChild(h)
+	return h
+}
+
+// Create a node that snaps the time of each point to a multiple of an interval.
+func (n *chainnode) Quantize() *QuantizeNode {
+	q := newQuantizeNode(n.Provides())
+	n.linkChild(q)
+	return q
+}
+
+// Create a node that records the data it receives to a file for later replay.
+func (n *chainnode) StreamReplay(path string) *StreamReplayNode {
+	s := newStreamReplayNode(n.Provides(), path)
+	n.linkChild(s)
+	return s
+}
+
+// Create a node that emits the points of each group only when their fields change.
+func (n *chainnode) StreamCompact(fields ...string) *StreamCompactNode {
+	s := newStreamCompactNode(n.Provides(), fields)
+	n.linkChild(s)
+	return s
+}
+
+// Create a node that splits the points of each group into sessions separated by gaps of inactivity.
+func (n *chainnode) Sessionize() *SessionizeNode {
+	s := newSessionizeNode(n.Provides())
+	n.linkChild(s)
+	return s
+}
+
+// Create a node that computes the difference between two fields of each point.
+func (n *chainnode) Diff(field, baseline string) *DiffNode {
+	d := newDiffNode(n.Provides(), field, baseline)
+	n.linkChild(d)
+	return d
+}
+
+// Create a node that computes the moving average of a field over the last size points of each group.
+func (n *chainnode) RollingAverage(field string, size int64) *MovingAverageNode {
+	m := newMovingAverageNode(n.Provides(), field, size)
+	n.linkChild(m)
+	return m
+}
+
+// Create a node that computes the rolling correlation coefficient between two fields over the last size points of each group.
+func (n *chainnode) Correlate(x, y string, size int64) *CorrelateNode {
+	c := newCorrelateNode(n.Provides(), x, y, size)
+	n.linkChild(c)
+	return c
+}
+
+// Create a node that passes only points within recurring time-of-day windows.
+func (n *chainnode) Schedule() *ScheduleNode {
+	s := newScheduleNode(n.Provides())
+	n.linkChild(s)
+	return s
+}
+
+// Create a node that scales a field of each group relative to its values in a rolling window.
+func (n *chainnode) Normalize(field string) *NormalizeNode {
+	m := newNormalizeNode(n.Provides(), field)
+	n.linkChild(m)
+	return m
+}
+
+// Create a node that emits a retraction point when a group is deleted.
+func (n *chainnode) Retract() *RetractNode {
+	r := newRetractNode(n.Provides())
+	n.linkChild(r)
+	return r
+}
+
+// Create a node that computes the linear regression trend of a field of each group.
+func (n *chainnode) Trend(field string) *TrendNode {
+	t := newTrendNode(n.Provides(), field)
+	n.linkChild(t)
+	return t
+}
+
+// Create a node that publishes each point to a NATS subject.
+func (n *chainnode) NatsOut(subject string) *NatsOutNode {
+	o := newNatsOutNode(n.Provides(), subject)
+	n.linkChild(o)
+	return o
+}
+
+// Create a node that encrypts the values of fields.
+func (n *chainnode) Encrypt(fields ...string) *EncryptNode {
+	e := newEncryptNode(n.Provides(), fields)
+	n.linkChild(e)
+	return e
+}
+
+// Create a node that decrypts the values of fields encrypted by an encrypt node.
+func (n *chainnode) Decrypt(fields ...string) *DecryptNode {
+	d := newDecryptNode(n.Provides(), fields)
+	n.linkChild(d)
+	return d
+}
+
+// Create a node that encodes the value of a field with base64.
+func (n *chainnode) EncodeBase64(field string) *EncodeBase64Node {
+	e := newEncodeBase64Node(n.Provides(), field)
+	n.linkChild(e)
+	return e
+}
+
+// Create a node that decodes the base64 value of a field.
+func (n *chainnode) DecodeBase64(field string) *DecodeBase64Node {
+	d := newDecodeBase64Node(n.Provides(), field)
+	n.linkChild(d)
+	return d
+}
+
+// Create a node that fills the gap in a stream since the task last ran by querying InfluxDB.
+func (n *chainnode) Backfill(query string) *BackfillNode {
+	b := newBackfillNode(n.Provides(), query)
+	n.linkChild(b)
+	return b
+}
+
+// Create a node that emits a point when the number of distinct groups within a window exceeds a limit.
+func (n *chainnode) CardinalityLimit() *CardinalityLimitNode {
+	c := newCardinalityLimitNode(n.Provides())
+	n.linkChild(c)
+	return c
+}
+
+// Create a node that suppresses output of each group until it has warmed up.
+func (n *chainnode) Warmup() *WarmupNode {
+	w := newWarmupNode(n.Provides())
+	n.linkChild(w)
+	return w
+}
+
+// Create a node that learns lower and upper bounds of a field of each group.
+func (n *chainnode) ThresholdLearn(field string) *ThresholdLearnNode {
+	t := newThresholdLearnNode(n.Provides(), field)
+	n.linkChild(t)
+	return t
+}
+
+// Create a node that rounds or truncates float fields to a fixed number of decimals.
+func (n *chainnode) Decimals() *DecimalsNode {
+	d := newDecimalsNode(n.Provides())
+	n.linkChild(d)
+	return d
+}
+
+// Create a node that extracts values out of a field containing a JSON string.
+func (n *chainnode) JsonExtract(field string) *JSONExtractNode {
+	j := newJSONExtractNode(n.Provides(), field)
+	n.linkChild(j)
+	return j
+}
+
+// Create a node that splits batches with more than a maximum number of points into smaller batches.
+func (n *chainnode) BatchSizeLimit() *BatchSizeLimitNode {
+	b := newBatchSizeLimitNode(n.Provides())
+	n.linkChild(b)
+	return b
+}
+
+// Create a node that drops, replaces or routes NaN and infinite field values.
+func (n *chainnode) Sanitize() *SanitizeNode {
+	s := newSanitizeNode(n.Provides())
+	n.linkChild(s)
+	return s
+}
+
+// Create a node that rejects points with timestamps too far in the future or past.
+func (n *chainnode) ValidateTime() *ValidateTimeNode {
+	v := newValidateTimeNode(n.Provides())
+	n.linkChild(v)
+	return v
+}
+
+// Create a node that computes the lag of each point behind the current time.
+func (n *chainnode) MaxDelay() *MaxDelayNode {
+	m := newMaxDelayNode(n.Provides())
+	n.linkChild(m)
+	return m
+}
+
 // Create a node that can trigger autoscale events for a kubernetes cluster.
 func (n *chainnode) K8sAutoscale() *K8sAutoscaleNode {
 	k := newK8sAutoscaleNode(n.Provides())
@@ -529,6 +1257,13 @@ func (n *chainnode) StateCount(expression *ast.LambdaNode) *StateCountNode {
 	return sc
 }
 
+// Create a node that tracks the state of each group through a graph of declared states and transitions.
+func (n *chainnode) StateMachine(initial string) *StateMachineNode {
+	sm := newStateMachineNode(n.provides, initial)
+	n.linkChild(sm)
+	return sm
+}
+
 // Create a node that can load data from external sources
 func (n *chainnode) Sideload() *SideloadNode {
 	s := newSideloadNode(n.provides)
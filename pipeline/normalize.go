@@ -0,0 +1,142 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxdb/influxql"
+)
+
+const (
+	NormalizeMinMax = "minmax"
+	NormalizeZScore = "zscore"
+)
+
+// Scale a field of each group relative to its recent values.
+// The values are kept in a rolling window of the last size points and/or the points within the last period.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('cpu')
+//            .groupBy('host')
+//        |normalize('usage_idle')
+//            .method('zscore')
+//            .period(1h)
+//            .as('usage_idle_score')
+//
+// The above example computes the z-score of the usage_idle field of each host relative to its values over the last hour.
+//
+// The method determines how the value is scaled:
+//
+//    * minmax -- the value is scaled to [0,1], the minimum of the window is 0 and the maximum is 1.
+//    * zscore -- the value is scaled to zero mean and unit variance, using the mean and standard deviation of the window.
+//
+// The window includes the point being scaled.
+// If all values in the window are equal the value cannot be scaled,
+// in which case minmax emits 0.5 and zscore emits 0.
+//
+// The values of each group are reset at the start of each batch.
+type NormalizeNode struct {
+	chainnode `json:"-"`
+
+	// The field to scale.
+	// tick:ignore
+	Field string `json:"field"`
+
+	// How the value is scaled, one of minmax or zscore.
+	// Default: minmax
+	Method string `json:"method"`
+
+	// The maximum number of points in the window.
+	// If zero the number of points is not limited.
+	Size int64 `json:"size"`
+
+	// The maximum age of the points in the window relative to the newest point.
+	// If zero the age of the points is not limited.
+	Period time.Duration `json:"period"`
+
+	// The name of the field of the scaled value.
+	// Default is the name of the scaled field.
+	As string `json:"as"`
+}
+
+func newNormalizeNode(wants EdgeType, field string) *NormalizeNode {
+	return &NormalizeNode{
+		chainnode: newBasicChainNode("normalize", wants, wants),
+		Field:     field,
+		Method:    NormalizeMinMax,
+		As:        field,
+	}
+}
+
+// MarshalJSON converts NormalizeNode to JSON
+// tick:ignore
+func (n *NormalizeNode) MarshalJSON() ([]byte, error) {
+	type Alias NormalizeNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Period string `json:"period"`
+	}{
+		TypeOf: TypeOf{
+			Type: "normalize",
+			ID:   n.ID(),
+		},
+		Alias:  (*Alias)(n),
+		Period: influxql.FormatDuration(n.Period),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an NormalizeNode
+// tick:ignore
+func (n *NormalizeNode) UnmarshalJSON(data []byte) error {
+	type Alias NormalizeNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Period string `json:"period"`
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "normalize" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as NormalizeNode", raw.ID, raw.Type)
+	}
+	n.Period, err = influxql.ParseDuration(raw.Period)
+	if err != nil {
+		return err
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+func (n *NormalizeNode) validate() error {
+	if n.Field == "" {
+		return errors.New("must provide a field to normalize")
+	}
+	switch n.Method {
+	case NormalizeMinMax, NormalizeZScore:
+	default:
+		return fmt.Errorf("invalid method %q, must be one of %s or %s", n.Method, NormalizeMinMax, NormalizeZScore)
+	}
+	if n.Size < 0 {
+		return errors.New("size cannot be negative")
+	}
+	if n.Period < 0 {
+		return errors.New("period cannot be negative")
+	}
+	if n.Size == 0 && n.Period == 0 {
+		return errors.New("must provide a size or period for the window")
+	}
+	if n.As == "" {
+		return errors.New("must provide a name for the normalized field")
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package pipeline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNormalizeNode_MarshalJSON(t *testing.T) {
+	m := newNormalizeNode(StreamEdge, "value")
+	m.Method = NormalizeZScore
+	m.Size = 100
+	m.Period = time.Hour
+	m.As = "score"
+	MarshalTestHelper(t, m, false, `{"typeOf":"normalize","id":"0","field":"value","method":"zscore","size":100,"as":"score","period":"1h"}`)
+}
+
+func TestNormalizeNode_UnmarshalJSON(t *testing.T) {
+	input := `{"typeOf":"normalize","id":"0","field":"value","method":"minmax","size":0,"as":"value","period":"10m"}`
+	want := &NormalizeNode{
+		Field:  "value",
+		Method: NormalizeMinMax,
+		Period: 10 * time.Minute,
+		As:     "value",
+	}
+	UnmarshalJSONTestHelper(t, []byte(input), &NormalizeNode{}, false, want)
+}
+
+func TestNormalizeNode_Validate(t *testing.T) {
+	newNode := func(field, method string, size int64, period time.Duration) *NormalizeNode {
+		m := newNormalizeNode(StreamEdge, field)
+		m.Method = method
+		m.Size = size
+		m.Period = period
+		return m
+	}
+	tests := []struct {
+		name    string
+		node    *NormalizeNode
+		wantErr bool
+	}{
+		{
+			name: "size",
+			node: newNode("value", NormalizeMinMax, 10, 0),
+		},
+		{
+			name: "period",
+			node: newNode("value", NormalizeZScore, 0, time.Hour),
+		},
+		{
+			name:    "no field",
+			node:    newNode("", NormalizeMinMax, 10, 0),
+			wantErr: true,
+		},
+		{
+			name:    "invalid method",
+			node:    newNode("value", "log", 10, 0),
+			wantErr: true,
+		},
+		{
+			name:    "no window",
+			node:    newNode("value", NormalizeMinMax, 0, 0),
+			wantErr: true,
+		},
+		{
+			name:    "negative size",
+			node:    newNode("value", NormalizeMinMax, -1, time.Hour),
+			wantErr: true,
+		},
+		{
+			name:    "negative period",
+			node:    newNode("value", NormalizeMinMax, 10, -time.Hour),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.node.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+const defaultPercentilesAs = "p"
+
+// A PercentilesNode computes exact percentiles of a field over each window of data.
+// All points of a window are buffered and sorted once the window closes.
+// Percentiles are computed using linear interpolation between the closest ranks.
+//
+// For a batch edge each batch is a window.
+// For a stream edge a window is closed by each barrier, see BarrierNode.
+//
+// The node emits a single point per window and group containing a field
+// for each requested percentile.
+// Fields are named using the `as` prefix followed by the percentile,
+// where any decimal point is replaced with an underscore,
+// i.e. the 50th and 99.9th percentiles are named `p50` and `p99_9` by default.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('requests')
+//        |window()
+//            .period(1m)
+//            .every(1m)
+//        |percentiles('latency', 50.0, 90.0, 99.0)
+//            .maxWindowSize(100000)
+//
+// The above example emits the exact 50th, 90th and 99th percentiles of the `latency` field
+// for each one minute window in the fields `p50`, `p90` and `p99`.
+//
+// Since every point of a window must be buffered, the memory used by the node
+// can be bounded with the maxWindowSize property.
+// By default windows exceeding the maximum size are dropped and an error is logged.
+// Use the sampleOverflow property to instead keep a uniform random sample
+// of maxWindowSize points, in which case the percentiles are no longer exact.
+//
+// Available Statistics:
+//
+//    * windows_dropped -- number of windows dropped because they exceeded the maximum window size.
+//
+type PercentilesNode struct {
+	chainnode `json:"-"`
+
+	// The field to compute percentiles of.
+	// tick:ignore
+	Field string `json:"field"`
+
+	// The percentiles to compute, each between 0 and 100.
+	// tick:ignore
+	Ranks []float64 `json:"percentiles"`
+
+	// The prefix for the names of the percentile fields.
+	// Default: p
+	As string `json:"as"`
+
+	// The maximum number of points buffered for a window.
+	// If zero the size of windows is unbounded.
+	MaxWindowSize int64 `json:"maxWindowSize"`
+
+	// Whether to sample windows exceeding the maximum window size instead of dropping them.
+	// tick:ignore
+	SampleOverflowFlag bool `tick:"SampleOverflow" json:"sampleOverflow"`
+}
+
+func newPercentilesNode(wants EdgeType, field string, percentiles []float64) *PercentilesNode {
+	return &PercentilesNode{
+		chainnode: newBasicChainNode("percentiles", wants, StreamEdge),
+		Field:     field,
+		Ranks:     percentiles,
+		As:        defaultPercentilesAs,
+	}
+}
+
+// MarshalJSON converts PercentilesNode to JSON
+// tick:ignore
+func (n *PercentilesNode) MarshalJSON() ([]byte, error) {
+	type Alias PercentilesNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		TypeOf: TypeOf{
+			Type: "percentiles",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an PercentilesNode
+// tick:ignore
+func (n *PercentilesNode) UnmarshalJSON(data []byte) error {
+	type Alias PercentilesNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "percentiles" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as PercentilesNode", raw.ID, raw.Type)
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+// If set, windows exceeding the maximum window size are sampled
+// using reservoir sampling instead of being dropped.
+// Requires maxWindowSize to be set.
+// tick:property
+func (n *PercentilesNode) SampleOverflow() *PercentilesNode {
+	n.SampleOverflowFlag = true
+	return n
+}
+
+func (n *PercentilesNode) validate() error {
+	if n.Field == "" {
+		return errors.New("must provide a field to compute percentiles of")
+	}
+	if len(n.Ranks) == 0 {
+		return errors.New("must provide at least one percentile")
+	}
+	seen := make(map[float64]bool, len(n.Ranks))
+	for _, p := range n.Ranks {
+		if p < 0 || p > 100 {
+			return fmt.Errorf("percentile must be between 0 and 100, got %v", p)
+		}
+		if seen[p] {
+			return fmt.Errorf("duplicate percentile %v", p)
+		}
+		seen[p] = true
+	}
+	if n.As == "" {
+		return errors.New("as cannot be empty")
+	}
+	if n.MaxWindowSize < 0 {
+		return errors.New("maxWindowSize cannot be negative")
+	}
+	if n.SampleOverflowFlag && n.MaxWindowSize == 0 {
+		return errors.New("sampleOverflow requires maxWindowSize to be set")
+	}
+	return nil
+}
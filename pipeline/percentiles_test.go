@@ -0,0 +1,69 @@
+package pipeline
+
+import (
+	"testing"
+)
+
+func TestPercentilesNode_MarshalJSON(t *testing.T) {
+	p := newPercentilesNode(BatchEdge, "latency", []float64{50, 99.9})
+	p.MaxWindowSize = 1000
+	p.SampleOverflow()
+	MarshalTestHelper(t, p, false, `{"typeOf":"percentiles","id":"0","field":"latency","percentiles":[50,99.9],"as":"p","maxWindowSize":1000,"sampleOverflow":true}`)
+}
+
+func TestPercentilesNode_UnmarshalJSON(t *testing.T) {
+	input := `{"typeOf":"percentiles","id":"0","field":"latency","percentiles":[50,99.9],"as":"p","maxWindowSize":1000,"sampleOverflow":true}`
+	want := &PercentilesNode{
+		Field:              "latency",
+		Ranks:              []float64{50, 99.9},
+		As:                 "p",
+		MaxWindowSize:      1000,
+		SampleOverflowFlag: true,
+	}
+	UnmarshalJSONTestHelper(t, []byte(input), &PercentilesNode{}, false, want)
+}
+
+func TestPercentilesNode_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		node    *PercentilesNode
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			node: newPercentilesNode(BatchEdge, "value", []float64{0, 50, 100}),
+		},
+		{
+			name:    "no field",
+			node:    newPercentilesNode(BatchEdge, "", []float64{50}),
+			wantErr: true,
+		},
+		{
+			name:    "no percentiles",
+			node:    newPercentilesNode(BatchEdge, "value", nil),
+			wantErr: true,
+		},
+		{
+			name:    "out of range",
+			node:    newPercentilesNode(BatchEdge, "value", []float64{100.1}),
+			wantErr: true,
+		},
+		{
+			name:    "duplicate",
+			node:    newPercentilesNode(BatchEdge, "value", []float64{50, 50}),
+			wantErr: true,
+		},
+		{
+			name:    "sample without max window size",
+			node:    newPercentilesNode(BatchEdge, "value", []float64{50}).SampleOverflow(),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.node.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -64,6 +64,21 @@ func CreatePipelineSources(srcs ...Node) *Pipeline {
 func Validate(p *Pipeline) error {
 	return p.Walk(
 		func(n Node) error {
+			if err := n.validateReorder(); err != nil {
+				return err
+			}
+			if err := validateDeadLetter(n); err != nil {
+				return err
+			}
+			if err := n.validateBackpressure(); err != nil {
+				return err
+			}
+			if err := validatePanicLimit(n); err != nil {
+				return err
+			}
+			if err := validateWorkers(n); err != nil {
+				return err
+			}
 			return n.validate()
 		})
 }
@@ -134,3 +134,361 @@ func UnmarshalJSONTestHelper(t *testing.T, input []byte, node interface{}, wantE
 		t.Errorf("UnmarshalJSON() =\ngot:\n%#+v\nwant:\n%#+v\n", node, want)
 	}
 }
+
+func TestTICK_To_Pipeline_Reorder(t *testing.T) {
+	tests := []struct {
+		name         string
+		script       string
+		edge         EdgeType
+		wantLateness time.Duration
+		wantSize     int64
+		wantErr      bool
+	}{
+		{
+			name:         "default size",
+			script:       `stream|from()|derivative('value').reorder(10s)`,
+			edge:         StreamEdge,
+			wantLateness: 10 * time.Second,
+			wantSize:     DefaultReorderSize,
+		},
+		{
+			name:         "size",
+			script:       `stream|from()|derivative('value').reorder(1m, 50)`,
+			edge:         StreamEdge,
+			wantLateness: time.Minute,
+			wantSize:     50,
+		},
+		{
+			name:    "zero size",
+			script:  `stream|from()|derivative('value').reorder(1m, 0)`,
+			edge:    StreamEdge,
+			wantErr: true,
+		},
+		{
+			name:    "negative lateness",
+			script:  `stream|from()|derivative('value').reorder(-1m)`,
+			edge:    StreamEdge,
+			wantErr: true,
+		},
+		{
+			name:    "batch edge",
+			script:  `batch|query('SELECT value FROM cpu')|derivative('value').reorder(10s)`,
+			edge:    BatchEdge,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := CreatePipeline(tt.script, tt.edge, stateful.NewScope(), deadman{}, nil)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			d := p.sources[0].Children()[0].Children()[0]
+			lateness, size := d.ReorderWindow()
+			if lateness != tt.wantLateness || size != tt.wantSize {
+				t.Errorf("unexpected reorder window: got %v, %d exp %v, %d", lateness, size, tt.wantLateness, tt.wantSize)
+			}
+		})
+	}
+}
+
+func TestTICK_To_Pipeline_DeadLetter(t *testing.T) {
+	tests := []struct {
+		name       string
+		script     string
+		wantSink   string
+		wantTarget string
+		wantErr    bool
+	}{
+		{
+			name:       "file",
+			script:     `stream|from()|eval(lambda: "value" * 2.0).as('value').deadLetter('file', '/tmp/dead_letters.log')`,
+			wantSink:   DeadLetterFile,
+			wantTarget: "/tmp/dead_letters.log",
+		},
+		{
+			name:       "influxdb",
+			script:     `stream|from()|eval(lambda: "value" * 2.0).as('value').deadLetter('influxdb', 'mydb.autogen.dead_letters')`,
+			wantSink:   DeadLetterInfluxDB,
+			wantTarget: "mydb.autogen.dead_letters",
+		},
+		{
+			name:       "influxdb default retention policy",
+			script:     `stream|from()|eval(lambda: "value" * 2.0).as('value').deadLetter('influxdb', 'mydb..dead_letters')`,
+			wantSink:   DeadLetterInfluxDB,
+			wantTarget: "mydb..dead_letters",
+		},
+		{
+			name:       "topic",
+			script:     `stream|from()|eval(lambda: "value" * 2.0).as('value').deadLetter('topic', 'dead_letters')`,
+			wantSink:   DeadLetterTopic,
+			wantTarget: "dead_letters",
+		},
+		{
+			name:       "where",
+			script:     `stream|from()|where(lambda: "value" > 2.0).deadLetter('topic', 'dead_letters')`,
+			wantSink:   DeadLetterTopic,
+			wantTarget: "dead_letters",
+		},
+		{
+			name:    "unsupported node",
+			script:  `stream|from()|httpOut('cpu').deadLetter('topic', 'dead_letters')`,
+			wantErr: true,
+		},
+		{
+			name:    "unknown sink",
+			script:  `stream|from()|eval(lambda: "value" * 2.0).as('value').deadLetter('kafka', 'dead_letters')`,
+			wantErr: true,
+		},
+		{
+			name:    "empty target",
+			script:  `stream|from()|eval(lambda: "value" * 2.0).as('value').deadLetter('file', '')`,
+			wantErr: true,
+		},
+		{
+			name:    "influxdb without database",
+			script:  `stream|from()|eval(lambda: "value" * 2.0).as('value').deadLetter('influxdb', 'dead_letters')`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := CreatePipeline(tt.script, StreamEdge, stateful.NewScope(), deadman{}, nil)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			e := p.sources[0].Children()[0].Children()[0]
+			sink, target := e.DeadLetterQueue()
+			if sink != tt.wantSink || target != tt.wantTarget {
+				t.Errorf("unexpected dead letter queue: got %s, %s exp %s, %s", sink, target, tt.wantSink, tt.wantTarget)
+			}
+		})
+	}
+}
+
+func TestTICK_To_Pipeline_Backpressure(t *testing.T) {
+	tests := []struct {
+		name       string
+		script     string
+		edge       EdgeType
+		wantPolicy string
+		wantErr    bool
+	}{
+		{
+			name:   "default",
+			script: `stream|from()|httpOut('cpu')`,
+			edge:   StreamEdge,
+		},
+		{
+			name:       "block",
+			script:     `stream|from()|httpOut('cpu').backpressure('block')`,
+			edge:       StreamEdge,
+			wantPolicy: BackpressureBlock,
+		},
+		{
+			name:       "drop newest",
+			script:     `stream|from()|httpOut('cpu').backpressure('dropNewest')`,
+			edge:       StreamEdge,
+			wantPolicy: BackpressureDropNewest,
+		},
+		{
+			name:       "drop oldest",
+			script:     `stream|from()|httpOut('cpu').backpressure('dropOldest')`,
+			edge:       StreamEdge,
+			wantPolicy: BackpressureDropOldest,
+		},
+		{
+			name:    "invalid policy",
+			script:  `stream|from()|httpOut('cpu').backpressure('drop')`,
+			edge:    StreamEdge,
+			wantErr: true,
+		},
+		{
+			name:       "batch edge blocks",
+			script:     `batch|query('SELECT value FROM cpu')|httpOut('cpu').backpressure('block')`,
+			edge:       BatchEdge,
+			wantPolicy: BackpressureBlock,
+		},
+		{
+			name:    "batch edge drops",
+			script:  `batch|query('SELECT value FROM cpu')|httpOut('cpu').backpressure('dropNewest')`,
+			edge:    BatchEdge,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := CreatePipeline(tt.script, tt.edge, stateful.NewScope(), deadman{}, nil)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			h := p.sources[0].Children()[0].Children()[0]
+			if got := h.InputBackpressure(); got != tt.wantPolicy {
+				t.Errorf("unexpected backpressure policy: got %q exp %q", got, tt.wantPolicy)
+			}
+		})
+	}
+}
+
+func TestTICK_To_Pipeline_AssertOrder(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		edge   EdgeType
+		want   bool
+	}{
+		{
+			name:   "default",
+			script: `stream|from()|httpOut('cpu')`,
+			edge:   StreamEdge,
+		},
+		{
+			name:   "stream",
+			script: `stream|from()|httpOut('cpu').assertOrder()`,
+			edge:   StreamEdge,
+			want:   true,
+		},
+		{
+			name:   "batch",
+			script: `batch|query('SELECT value FROM cpu')|httpOut('cpu').assertOrder()`,
+			edge:   BatchEdge,
+			want:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := CreatePipeline(tt.script, tt.edge, stateful.NewScope(), deadman{}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			h := p.sources[0].Children()[0].Children()[0]
+			if got := h.IsOrderAsserted(); got != tt.want {
+				t.Errorf("unexpected order assertion: got %v exp %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTICK_To_Pipeline_PanicLimit(t *testing.T) {
+	tests := []struct {
+		name       string
+		script     string
+		wantCount  int64
+		wantWindow time.Duration
+		wantErr    bool
+	}{
+		{
+			name:   "default",
+			script: `stream|from()|httpOut('cpu')`,
+		},
+		{
+			name:       "panic limit",
+			script:     `stream|from()|httpOut('cpu').panicLimit(10, 1m)`,
+			wantCount:  10,
+			wantWindow: time.Minute,
+		},
+		{
+			name:    "zero count",
+			script:  `stream|from()|httpOut('cpu').panicLimit(0, 1m)`,
+			wantErr: true,
+		},
+		{
+			name:    "zero window",
+			script:  `stream|from()|httpOut('cpu').panicLimit(10, 0s)`,
+			wantErr: true,
+		},
+		{
+			name:    "unsupported node",
+			script:  `stream|from()|groupBy('host').panicLimit(10, 1m)`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := CreatePipeline(tt.script, StreamEdge, stateful.NewScope(), deadman{}, nil)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			h := p.sources[0].Children()[0].Children()[0]
+			if count, window := h.PanicThreshold(); count != tt.wantCount || window != tt.wantWindow {
+				t.Errorf("unexpected panic limit: got %d, %v exp %d, %v", count, window, tt.wantCount, tt.wantWindow)
+			}
+		})
+	}
+}
+
+func TestTICK_To_Pipeline_Workers(t *testing.T) {
+	tests := []struct {
+		name        string
+		script      string
+		wantWorkers int64
+		wantErr     bool
+	}{
+		{
+			name:   "default",
+			script: `stream|from()|eval(lambda: "value" * 2.0).as('double')`,
+		},
+		{
+			name:        "eval",
+			script:      `stream|from()|eval(lambda: "value" * 2.0).as('double').workers(4)`,
+			wantWorkers: 4,
+		},
+		{
+			name:        "where",
+			script:      `stream|from()|where(lambda: "value" > 2.0).workers(2)`,
+			wantWorkers: 2,
+		},
+		{
+			name:    "negative",
+			script:  `stream|from()|eval(lambda: "value" * 2.0).as('double').workers(-1)`,
+			wantErr: true,
+		},
+		{
+			name:    "unsupported node",
+			script:  `stream|from()|httpOut('cpu').workers(4)`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := CreatePipeline(tt.script, StreamEdge, stateful.NewScope(), deadman{}, nil)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			n := p.sources[0].Children()[0].Children()[0]
+			if got := n.WorkerPool(); got != tt.wantWorkers {
+				t.Errorf("unexpected workers: got %d exp %d", got, tt.wantWorkers)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxdb/influxql"
+)
+
+const (
+	QuantizeFloor = "floor"
+	QuantizeRound = "round"
+	QuantizeCeil  = "ceil"
+
+	QuantizeKeep  = "keep"
+	QuantizeFirst = "first"
+	QuantizeLast  = "last"
+	QuantizeMean  = "mean"
+)
+
+// Quantize snaps the time of each point to a multiple of an interval.
+// This is useful for joining series whose timestamps are close but not equal.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('cpu')
+//        |quantize()
+//            .interval(10s)
+//            .mode('floor')
+//
+// The above example snaps the time of each point down to the previous multiple of 10s.
+//
+// The mode determines how times are snapped to the grid:
+//
+//    * floor -- snap to the previous multiple of the interval.
+//    * round -- snap to the nearest multiple of the interval, halfway times are rounded up.
+//    * ceil -- snap to the next multiple of the interval.
+//
+// Multiple points of a batch with the same tags may snap to the same time.
+// The onCollision property determines how such points are handled:
+//
+//    * keep -- keep all points.
+//    * first -- keep only the first point.
+//    * last -- keep only the last point.
+//    * mean -- replace the points with a single point where each numeric field is the mean of the fields of the points.
+//              Non numeric fields are taken from the last point.
+//
+// Collisions are only handled within batches, points of a stream are always kept.
+type QuantizeNode struct {
+	chainnode `json:"-"`
+
+	// The interval of the grid to snap times to.
+	// Must be greater than zero.
+	Interval time.Duration `json:"interval"`
+
+	// How times are snapped to the grid, one of floor, round or ceil.
+	// Default: round
+	Mode string `json:"mode"`
+
+	// How points of a batch snapping to the same time are handled, one of keep, first, last or mean.
+	// Default: keep
+	OnCollision string `json:"onCollision"`
+}
+
+func newQuantizeNode(wants EdgeType) *QuantizeNode {
+	return &QuantizeNode{
+		chainnode:   newBasicChainNode("quantize", wants, wants),
+		Mode:        QuantizeRound,
+		OnCollision: QuantizeKeep,
+	}
+}
+
+// MarshalJSON converts QuantizeNode to JSON
+// tick:ignore
+func (n *QuantizeNode) MarshalJSON() ([]byte, error) {
+	type Alias QuantizeNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Interval string `json:"interval"`
+	}{
+		TypeOf: TypeOf{
+			Type: "quantize",
+			ID:   n.ID(),
+		},
+		Alias:    (*Alias)(n),
+		Interval: influxql.FormatDuration(n.Interval),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an QuantizeNode
+// tick:ignore
+func (n *QuantizeNode) UnmarshalJSON(data []byte) error {
+	type Alias QuantizeNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Interval string `json:"interval"`
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "quantize" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as QuantizeNode", raw.ID, raw.Type)
+	}
+	n.Interval, err = influxql.ParseDuration(raw.Interval)
+	if err != nil {
+		return err
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+func (n *QuantizeNode) validate() error {
+	if n.Interval <= 0 {
+		return errors.New("interval must be greater than zero")
+	}
+	switch n.Mode {
+	case QuantizeFloor, QuantizeRound, QuantizeCeil:
+	default:
+		return fmt.Errorf("invalid mode %q, must be one of %s, %s or %s", n.Mode, QuantizeFloor, QuantizeRound, QuantizeCeil)
+	}
+	switch n.OnCollision {
+	case QuantizeKeep, QuantizeFirst, QuantizeLast, QuantizeMean:
+	default:
+		return fmt.Errorf("invalid onCollision %q, must be one of %s, %s, %s or %s", n.OnCollision, QuantizeKeep, QuantizeFirst, QuantizeLast, QuantizeMean)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package pipeline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQuantizeNode_MarshalJSON(t *testing.T) {
+	q := newQuantizeNode(BatchEdge)
+	q.Interval = 10 * time.Second
+	q.Mode = QuantizeFloor
+	q.OnCollision = QuantizeMean
+	MarshalTestHelper(t, q, false, `{"typeOf":"quantize","id":"0","mode":"floor","onCollision":"mean","interval":"10s"}`)
+}
+
+func TestQuantizeNode_UnmarshalJSON(t *testing.T) {
+	input := `{"typeOf":"quantize","id":"0","mode":"ceil","onCollision":"last","interval":"1m"}`
+	want := &QuantizeNode{
+		Interval:    time.Minute,
+		Mode:        QuantizeCeil,
+		OnCollision: QuantizeLast,
+	}
+	UnmarshalJSONTestHelper(t, []byte(input), &QuantizeNode{}, false, want)
+}
+
+func TestQuantizeNode_Validate(t *testing.T) {
+	newNode := func(interval time.Duration, mode, onCollision string) *QuantizeNode {
+		q := newQuantizeNode(StreamEdge)
+		q.Interval = interval
+		q.Mode = mode
+		q.OnCollision = onCollision
+		return q
+	}
+	tests := []struct {
+		name    string
+		node    *QuantizeNode
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			node: newNode(time.Second, QuantizeRound, QuantizeKeep),
+		},
+		{
+			name:    "no interval",
+			node:    newNode(0, QuantizeRound, QuantizeKeep),
+			wantErr: true,
+		},
+		{
+			name:    "negative interval",
+			node:    newNode(-time.Second, QuantizeRound, QuantizeKeep),
+			wantErr: true,
+		},
+		{
+			name:    "invalid mode",
+			node:    newNode(time.Second, "truncate", QuantizeKeep),
+			wantErr: true,
+		},
+		{
+			name:    "invalid onCollision",
+			node:    newNode(time.Second, QuantizeFloor, "max"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.node.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
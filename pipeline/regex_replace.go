@@ -0,0 +1,205 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// Rewrites string field and tag values with regular expression find and replace rules,
+// i.e. to normalize inconsistent values before they are grouped or written.
+//
+// Each rule replaces all matches of its regular expression with its replacement.
+// The replacement may reference capture groups of the match as `$1` or `${name}`, use `$$` for a literal `$`.
+// The rules are applied in order, each rule rewriting the result of the previous rules.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('requests')
+//        |regexReplace()
+//            .field('path')
+//            .tag('host')
+//            .rule(/\s+$/, '')
+//            .rule(/^(?P<name>[a-z]+)\.example\.com$/, '${name}')
+//            .rule(/^(\w+)-(\d+)$/, '$1$2')
+//
+// The above example strips trailing whitespace from the `path` field and the `host` tag,
+// then rewrites hosts like `web.example.com` to `web` and `web-01` to `web01`.
+//
+// Fields that are not strings and missing fields and tags are passed through unchanged.
+// The group of a point is computed from the values of its group by dimensions,
+// so rewriting a tag the data is grouped by moves the point to the group of the rewritten value.
+// For streams, use the regroup property to also group by the rewritten tags.
+//
+// Available Statistics:
+//
+//    * rewrites -- number of field and tag values changed by the rules.
+//
+type RegexReplaceNode struct {
+	chainnode `json:"-"`
+
+	// Set of fields to rewrite
+	// tick:ignore
+	Fields []string `tick:"Field" json:"fields"`
+
+	// Set of tags to rewrite
+	// tick:ignore
+	Tags []string `tick:"Tag" json:"tags"`
+
+	// The find and replace rules, applied in order.
+	// tick:ignore
+	Rules []RegexReplaceRule `tick:"Rule" json:"rules"`
+
+	// Whether to add the rewritten tags to the group by dimensions.
+	// tick:ignore
+	RegroupFlag bool `tick:"Regroup" json:"regroup"`
+}
+
+// RegexReplaceRule replaces the matches of a regular expression.
+type RegexReplaceRule struct {
+	// The regular expression matching the parts of values to replace.
+	Pattern *regexp.Regexp `json:"-"`
+	// The replacement of each match, may reference capture groups.
+	Replacement string `json:"replacement"`
+}
+
+// MarshalJSON converts RegexReplaceRule to JSON
+func (r RegexReplaceRule) MarshalJSON() ([]byte, error) {
+	raw := struct {
+		Regex       string `json:"regex"`
+		Replacement string `json:"replacement"`
+	}{
+		Replacement: r.Replacement,
+	}
+	if r.Pattern != nil {
+		raw.Regex = r.Pattern.String()
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to a RegexReplaceRule
+func (r *RegexReplaceRule) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Regex       string `json:"regex"`
+		Replacement string `json:"replacement"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	pattern, err := regexp.Compile(raw.Regex)
+	if err != nil {
+		return err
+	}
+	r.Pattern = pattern
+	r.Replacement = raw.Replacement
+	return nil
+}
+
+func newRegexReplaceNode(e EdgeType) *RegexReplaceNode {
+	return &RegexReplaceNode{
+		chainnode: newBasicChainNode("regexReplace", e, e),
+	}
+}
+
+// MarshalJSON converts RegexReplaceNode to JSON
+// tick:ignore
+func (n *RegexReplaceNode) MarshalJSON() ([]byte, error) {
+	type Alias RegexReplaceNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		TypeOf: TypeOf{
+			Type: "regexReplace",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an RegexReplaceNode
+// tick:ignore
+func (n *RegexReplaceNode) UnmarshalJSON(data []byte) error {
+	type Alias RegexReplaceNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "regexReplace" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as RegexReplaceNode", raw.ID, raw.Type)
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+func (n *RegexReplaceNode) validate() error {
+	if len(n.Fields) == 0 && len(n.Tags) == 0 {
+		return errors.New("must provide at least one field or tag to rewrite")
+	}
+	for _, f := range n.Fields {
+		if f == "" {
+			return errors.New("field names must not be empty")
+		}
+	}
+	for _, t := range n.Tags {
+		if t == "" {
+			return errors.New("tag names must not be empty")
+		}
+	}
+	if len(n.Rules) == 0 {
+		return errors.New("must provide at least one rule")
+	}
+	for _, r := range n.Rules {
+		if r.Pattern == nil {
+			return errors.New("rule pattern cannot be nil")
+		}
+	}
+	if n.RegroupFlag {
+		if len(n.Tags) == 0 {
+			return errors.New("regroup requires at least one tag to rewrite")
+		}
+		if n.Provides() != StreamEdge {
+			return errors.New("regroup can only be used with a stream edge")
+		}
+	}
+	return nil
+}
+
+// Rewrite a field.
+// tick:property
+func (n *RegexReplaceNode) Field(name string) *RegexReplaceNode {
+	n.Fields = append(n.Fields, name)
+	return n
+}
+
+// Rewrite a tag.
+// tick:property
+func (n *RegexReplaceNode) Tag(name string) *RegexReplaceNode {
+	n.Tags = append(n.Tags, name)
+	return n
+}
+
+// Replace the matches of the regular expression with the replacement,
+// after the rules that were added before.
+// tick:property
+func (n *RegexReplaceNode) Rule(r *regexp.Regexp, replacement string) *RegexReplaceNode {
+	n.Rules = append(n.Rules, RegexReplaceRule{Pattern: r, Replacement: replacement})
+	return n
+}
+
+// Add the rewritten tags to the group by dimensions and regroup the data.
+// Only applies to streams.
+// tick:property
+func (n *RegexReplaceNode) Regroup() *RegexReplaceNode {
+	n.RegroupFlag = true
+	return n
+}
@@ -0,0 +1,76 @@
+package pipeline
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestRegexReplaceNode_MarshalJSON(t *testing.T) {
+	r := newRegexReplaceNode(StreamEdge)
+	r.Field("path").Tag("host").Rule(regexp.MustCompile(`^(\w+)-(\d+)$`), "$1$2").Regroup()
+	MarshalTestHelper(t, r, false, `{"typeOf":"regexReplace","id":"0","fields":["path"],"tags":["host"],"rules":[{"regex":"^(\\w+)-(\\d+)$","replacement":"$1$2"}],"regroup":true}`)
+}
+
+func TestRegexReplaceNode_UnmarshalJSON(t *testing.T) {
+	input := `{"typeOf":"regexReplace","id":"0","fields":["path"],"tags":null,"rules":[{"regex":"\\s+$","replacement":""}],"regroup":false}`
+	want := &RegexReplaceNode{
+		Fields: []string{"path"},
+		Rules:  []RegexReplaceRule{{Pattern: regexp.MustCompile(`\s+$`)}},
+	}
+	UnmarshalJSONTestHelper(t, []byte(input), &RegexReplaceNode{}, false, want)
+}
+
+func TestRegexReplaceNode_Validate(t *testing.T) {
+	pattern := regexp.MustCompile(`\s+$`)
+	tests := []struct {
+		name    string
+		node    *RegexReplaceNode
+		wantErr bool
+	}{
+		{
+			name: "field",
+			node: newRegexReplaceNode(StreamEdge).Field("path").Rule(pattern, ""),
+		},
+		{
+			name: "tag regroup",
+			node: newRegexReplaceNode(StreamEdge).Tag("host").Rule(pattern, "").Regroup(),
+		},
+		{
+			name:    "no fields or tags",
+			node:    newRegexReplaceNode(StreamEdge).Rule(pattern, ""),
+			wantErr: true,
+		},
+		{
+			name:    "empty tag",
+			node:    newRegexReplaceNode(StreamEdge).Tag("").Rule(pattern, ""),
+			wantErr: true,
+		},
+		{
+			name:    "no rules",
+			node:    newRegexReplaceNode(StreamEdge).Field("path"),
+			wantErr: true,
+		},
+		{
+			name:    "nil pattern",
+			node:    newRegexReplaceNode(StreamEdge).Field("path").Rule(nil, ""),
+			wantErr: true,
+		},
+		{
+			name:    "regroup without tags",
+			node:    newRegexReplaceNode(StreamEdge).Field("path").Rule(pattern, "").Regroup(),
+			wantErr: true,
+		},
+		{
+			name:    "regroup batch",
+			node:    newRegexReplaceNode(BatchEdge).Tag("host").Rule(pattern, "").Regroup(),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.node.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
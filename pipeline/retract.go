@@ -0,0 +1,100 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Emit a retraction point when a group is deleted,
+// so that downstream systems know the series is gone and can delete or expire it.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('cpu')
+//            .groupBy('host')
+//        |retract()
+//            .tag('deleted')
+//        |influxDBOut()
+//            .database('inventory')
+//            .measurement('hosts')
+//
+// The above example writes a point tagged with deleted=true for each host whose group is deleted.
+//
+// All data is passed through unchanged.
+// When a group is deleted a retraction point is emitted before the delete is forwarded.
+// The retraction point has the measurement and tags of the group and the time of the last point of the group.
+// The tag and the field of the retraction point are both set to true, either may be disabled by setting its name to empty.
+// On a batch edge the retraction point is emitted as a batch with a single point.
+//
+// Nothing is emitted for a group that has not received any points.
+//
+// Available Statistics:
+//
+//    * retractions -- number of retraction points emitted
+//
+type RetractNode struct {
+	chainnode `json:"-"`
+
+	// The name of the tag set to true on retraction points.
+	// Default: _deleted
+	Tag string `json:"tag"`
+
+	// The name of the field set to true on retraction points.
+	// Default: _deleted
+	Field string `json:"field"`
+}
+
+func newRetractNode(wants EdgeType) *RetractNode {
+	return &RetractNode{
+		chainnode: newBasicChainNode("retract", wants, wants),
+		Tag:       "_deleted",
+		Field:     "_deleted",
+	}
+}
+
+// MarshalJSON converts RetractNode to JSON
+// tick:ignore
+func (n *RetractNode) MarshalJSON() ([]byte, error) {
+	type Alias RetractNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		TypeOf: TypeOf{
+			Type: "retract",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an RetractNode
+// tick:ignore
+func (n *RetractNode) UnmarshalJSON(data []byte) error {
+	type Alias RetractNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "retract" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as RetractNode", raw.ID, raw.Type)
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+func (n *RetractNode) validate() error {
+	if n.Tag == "" && n.Field == "" {
+		return errors.New("must provide a tag or field to mark retraction points")
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package pipeline
+
+import (
+	"testing"
+)
+
+func TestRetractNode_MarshalJSON(t *testing.T) {
+	r := newRetractNode(StreamEdge)
+	r.Tag = "deleted"
+	r.Field = ""
+	MarshalTestHelper(t, r, false, `{"typeOf":"retract","id":"0","tag":"deleted","field":""}`)
+}
+
+func TestRetractNode_UnmarshalJSON(t *testing.T) {
+	input := `{"typeOf":"retract","id":"0","tag":"_deleted","field":"_deleted"}`
+	want := &RetractNode{
+		Tag:   "_deleted",
+		Field: "_deleted",
+	}
+	UnmarshalJSONTestHelper(t, []byte(input), &RetractNode{}, false, want)
+}
+
+func TestRetractNode_Validate(t *testing.T) {
+	newNode := func(tag, field string) *RetractNode {
+		r := newRetractNode(StreamEdge)
+		r.Tag = tag
+		r.Field = field
+		return r
+	}
+	tests := []struct {
+		name    string
+		node    *RetractNode
+		wantErr bool
+	}{
+		{
+			name: "defaults",
+			node: newRetractNode(StreamEdge),
+		},
+		{
+			name: "only tag",
+			node: newNode("deleted", ""),
+		},
+		{
+			name: "only field",
+			node: newNode("", "deleted"),
+		},
+		{
+			name:    "neither",
+			node:    newNode("", ""),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.node.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
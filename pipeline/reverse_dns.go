@@ -0,0 +1,179 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxdb/influxql"
+)
+
+const (
+	defaultReverseDNSAs          = "hostname"
+	defaultReverseDNSCacheTTL    = time.Hour
+	defaultReverseDNSCacheSize   = 10000
+	defaultReverseDNSTimeout     = time.Second
+	defaultReverseDNSConcurrency = 10
+)
+
+// Resolves the IP addresses of a tag to hostnames using reverse DNS lookups
+// and adds the hostnames as a tag.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('flows')
+//        |reverseDNS('src_ip')
+//            .as('src_host')
+//            .cacheTTL(10m)
+//
+// The above example adds the tag `src_host` with the hostname of the address in the tag `src_ip`.
+//
+// Lookups never stall the pipeline: the results are cached, and when the hostname of an address
+// is not in the cache yet, the point is emitted right away while the address is resolved in the background.
+// At most concurrency lookups run at the same time, addresses are not looked up while all resolvers are busy.
+// Points whose address cannot be resolved, or has not been resolved yet, get the placeholder as hostname,
+// or the address itself if the placeholder is empty.
+// Failed lookups are cached like successful lookups, lookups that time out are retried with the next point.
+//
+// Use the fromField property to read the addresses from a field instead of a tag.
+// Points without the tag or field are passed on unchanged.
+//
+// Available Statistics:
+//
+//    * cache_hits -- number of addresses that were found in the cache.
+//    * cache_misses -- number of addresses that were not in the cache.
+//    * timeouts -- number of lookups that timed out.
+//    * lookup_errors -- number of lookups that failed.
+//
+type ReverseDNSNode struct {
+	chainnode `json:"-"`
+
+	// The tag, or field, with the IP address.
+	// tick:ignore
+	Tag string `json:"tag"`
+
+	// Whether to read the IP address from a field with the name instead of a tag.
+	// tick:ignore
+	FromFieldFlag bool `tick:"FromField" json:"fromField"`
+
+	// The name of the tag with the hostname.
+	// Default: hostname
+	As string `json:"as"`
+
+	// The hostname of addresses that cannot be resolved, empty uses the address.
+	Placeholder string `json:"placeholder"`
+
+	// How long the result of a lookup is cached.
+	// Default: 1h
+	CacheTTL time.Duration `json:"cacheTTL"`
+
+	// The maximum number of cached addresses.
+	// Default: 10000
+	CacheSize int64 `json:"cacheSize"`
+
+	// How long a lookup may take.
+	// Default: 1s
+	Timeout time.Duration `json:"timeout"`
+
+	// The maximum number of concurrent lookups.
+	// Default: 10
+	Concurrency int64 `json:"concurrency"`
+}
+
+func newReverseDNSNode(e EdgeType, tag string) *ReverseDNSNode {
+	return &ReverseDNSNode{
+		chainnode:   newBasicChainNode("reverse_dns", e, e),
+		Tag:         tag,
+		As:          defaultReverseDNSAs,
+		CacheTTL:    defaultReverseDNSCacheTTL,
+		CacheSize:   defaultReverseDNSCacheSize,
+		Timeout:     defaultReverseDNSTimeout,
+		Concurrency: defaultReverseDNSConcurrency,
+	}
+}
+
+// MarshalJSON converts ReverseDNSNode to JSON
+// tick:ignore
+func (n *ReverseDNSNode) MarshalJSON() ([]byte, error) {
+	type Alias ReverseDNSNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		CacheTTL string `json:"cacheTTL"`
+		Timeout  string `json:"timeout"`
+	}{
+		TypeOf: TypeOf{
+			Type: "reverseDNS",
+			ID:   n.ID(),
+		},
+		Alias:    (*Alias)(n),
+		CacheTTL: influxql.FormatDuration(n.CacheTTL),
+		Timeout:  influxql.FormatDuration(n.Timeout),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an ReverseDNSNode
+// tick:ignore
+func (n *ReverseDNSNode) UnmarshalJSON(data []byte) error {
+	type Alias ReverseDNSNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		CacheTTL string `json:"cacheTTL"`
+		Timeout  string `json:"timeout"`
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "reverseDNS" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as ReverseDNSNode", raw.ID, raw.Type)
+	}
+	n.CacheTTL, err = influxql.ParseDuration(raw.CacheTTL)
+	if err != nil {
+		return err
+	}
+	n.Timeout, err = influxql.ParseDuration(raw.Timeout)
+	if err != nil {
+		return err
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+// Read the IP address from the field with the name instead of the tag.
+// tick:property
+func (n *ReverseDNSNode) FromField() *ReverseDNSNode {
+	n.FromFieldFlag = true
+	return n
+}
+
+func (n *ReverseDNSNode) validate() error {
+	if n.Tag == "" {
+		return errors.New("must provide a tag with the IP address")
+	}
+	if n.As == "" {
+		return errors.New("as cannot be empty")
+	}
+	if n.As == n.Tag && !n.FromFieldFlag {
+		return fmt.Errorf("as cannot be the tag with the IP address %q", n.Tag)
+	}
+	if n.CacheTTL <= 0 {
+		return errors.New("cacheTTL must be greater than zero")
+	}
+	if n.CacheSize <= 0 {
+		return errors.New("cacheSize must be greater than zero")
+	}
+	if n.Timeout <= 0 {
+		return errors.New("timeout must be greater than zero")
+	}
+	if n.Concurrency <= 0 {
+		return errors.New("concurrency must be greater than zero")
+	}
+	return nil
+}
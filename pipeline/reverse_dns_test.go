@@ -0,0 +1,88 @@
+package pipeline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReverseDNSNode_MarshalJSON(t *testing.T) {
+	r := newReverseDNSNode(StreamEdge, "src_ip")
+	r.As = "src_host"
+	r.Placeholder = "unknown"
+	MarshalTestHelper(t, r, false, `{"typeOf":"reverseDNS","id":"0","tag":"src_ip","fromField":false,"as":"src_host","placeholder":"unknown","cacheSize":10000,"concurrency":10,"cacheTTL":"1h","timeout":"1s"}`)
+}
+
+func TestReverseDNSNode_UnmarshalJSON(t *testing.T) {
+	input := `{"typeOf":"reverseDNS","id":"0","tag":"addr","fromField":true,"as":"hostname","placeholder":"","cacheSize":100,"concurrency":2,"cacheTTL":"10m","timeout":"500ms"}`
+	want := &ReverseDNSNode{
+		Tag:           "addr",
+		FromFieldFlag: true,
+		As:            "hostname",
+		CacheSize:     100,
+		Concurrency:   2,
+		CacheTTL:      10 * time.Minute,
+		Timeout:       500 * time.Millisecond,
+	}
+	UnmarshalJSONTestHelper(t, []byte(input), &ReverseDNSNode{}, false, want)
+}
+
+func TestReverseDNSNode_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		node    func(r *ReverseDNSNode)
+		wantErr bool
+	}{
+		{
+			name: "defaults",
+			node: func(r *ReverseDNSNode) {},
+		},
+		{
+			name:    "no tag",
+			node:    func(r *ReverseDNSNode) { r.Tag = "" },
+			wantErr: true,
+		},
+		{
+			name:    "empty as",
+			node:    func(r *ReverseDNSNode) { r.As = "" },
+			wantErr: true,
+		},
+		{
+			name:    "as is the tag",
+			node:    func(r *ReverseDNSNode) { r.As = "ip" },
+			wantErr: true,
+		},
+		{
+			name: "as is the field",
+			node: func(r *ReverseDNSNode) { r.As = "ip"; r.FromField() },
+		},
+		{
+			name:    "zero TTL",
+			node:    func(r *ReverseDNSNode) { r.CacheTTL = 0 },
+			wantErr: true,
+		},
+		{
+			name:    "zero cache size",
+			node:    func(r *ReverseDNSNode) { r.CacheSize = 0 },
+			wantErr: true,
+		},
+		{
+			name:    "zero timeout",
+			node:    func(r *ReverseDNSNode) { r.Timeout = 0 },
+			wantErr: true,
+		},
+		{
+			name:    "zero concurrency",
+			node:    func(r *ReverseDNSNode) { r.Concurrency = 0 },
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newReverseDNSNode(StreamEdge, "ip")
+			tt.node(r)
+			if err := r.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Actions of a SanitizeNode for fields with NaN or infinite values.
+const (
+	SanitizeDrop    = "drop"
+	SanitizeReplace = "replace"
+	SanitizeRoute   = "route"
+)
+
+// The name of the branch of a SanitizeNode receiving routed points.
+const SanitizeInvalidBranch = "invalid"
+
+// Detect NaN and infinite values in float fields and handle them before they reach
+// aggregates and alerts, which would otherwise need to handle them all.
+//
+// Each float field is checked, unless the checked fields are set with the fields property.
+// A point with NaN or infinite values is handled with one of these actions:
+//
+//    * drop -- drop the point, the default.
+//    * replace -- replace the values with the value of the replaceWith property and pass on the point.
+//    * route -- send the point to the `invalid` branch instead of the other children of the node.
+//
+// The action can be set per field with the fieldAction property.
+// If the fields of a point call for different actions, dropping takes precedence over routing,
+// values are replaced before a point is routed.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('requests')
+//        |eval(lambda: "errors" / "total")
+//            .as('error_rate')
+//            .keep()
+//        |sanitize()
+//            .action('replace')
+//            .replaceWith(0.0)
+//        |alert()
+//            .crit(lambda: "error_rate" > 0.1)
+//
+// The above example replaces the NaN error rate produced when no requests were made with 0.
+//
+// Routed points are selected with the `branch` chaining method,
+// all other children of the node receive only the valid points.
+//
+// Example:
+//    var clean = stream
+//        |from()
+//            .measurement('sensors')
+//        |sanitize()
+//            .action('route')
+//
+//    clean
+//        |mean('temperature')
+//        |influxDBOut()
+//            .database('sensors')
+//
+//    clean
+//        |branch('invalid')
+//        |influxDBOut()
+//            .database('sensors_invalid')
+//
+// Batches are split so that the invalid branch receives a batch containing only the routed points.
+//
+// Available Statistics:
+//
+//    * sanitized_points -- number of points with NaN or infinite values
+//
+type SanitizeNode struct {
+	chainnode `json:"-"`
+
+	// The action for fields with NaN or infinite values, one of drop, replace or route.
+	// Default: drop
+	Action string `json:"action"`
+
+	// The value replacing NaN and infinite values when the action is replace.
+	// Default: 0.0
+	ReplaceWith float64 `json:"replaceWith"`
+
+	// The fields to check, if empty all float fields are checked.
+	// tick:ignore
+	FieldsList []string `tick:"Fields" json:"fields"`
+
+	// The actions of individual fields.
+	// tick:ignore
+	FieldActions map[string]string `tick:"FieldAction" json:"fieldActions"`
+}
+
+func newSanitizeNode(e EdgeType) *SanitizeNode {
+	return &SanitizeNode{
+		chainnode:    newBasicChainNode("sanitize", e, e),
+		Action:       SanitizeDrop,
+		FieldActions: make(map[string]string),
+	}
+}
+
+// MarshalJSON converts SanitizeNode to JSON
+// tick:ignore
+func (n *SanitizeNode) MarshalJSON() ([]byte, error) {
+	type Alias SanitizeNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		TypeOf: TypeOf{
+			Type: "sanitize",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an SanitizeNode
+// tick:ignore
+func (n *SanitizeNode) UnmarshalJSON(data []byte) error {
+	type Alias SanitizeNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "sanitize" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as SanitizeNode", raw.ID, raw.Type)
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+// Check only the given fields.
+// tick:property
+func (n *SanitizeNode) Fields(fields ...string) *SanitizeNode {
+	n.FieldsList = fields
+	return n
+}
+
+// Set the action for NaN or infinite values of a single field,
+// one of drop, replace or route.
+// tick:property
+func (n *SanitizeNode) FieldAction(field, action string) *SanitizeNode {
+	n.FieldActions[field] = action
+	return n
+}
+
+// Select the branch receiving the routed points, the only branch is `invalid`.
+func (n *SanitizeNode) Branch(name string) *SplitBranchNode {
+	b := newSplitBranchNode(n.Provides(), name)
+	n.linkChild(b)
+	return b
+}
+
+// routes reports whether any field is routed.
+func (n *SanitizeNode) routes() bool {
+	if n.Action == SanitizeRoute {
+		return true
+	}
+	for _, a := range n.FieldActions {
+		if a == SanitizeRoute {
+			return true
+		}
+	}
+	return false
+}
+
+func validSanitizeAction(action string) bool {
+	switch action {
+	case SanitizeDrop, SanitizeReplace, SanitizeRoute:
+		return true
+	}
+	return false
+}
+
+func (n *SanitizeNode) validate() error {
+	if !validSanitizeAction(n.Action) {
+		return fmt.Errorf("invalid action %q, must be one of drop, replace or route", n.Action)
+	}
+	for field, action := range n.FieldActions {
+		if !validSanitizeAction(action) {
+			return fmt.Errorf("invalid action %q of field %q, must be one of drop, replace or route", action, field)
+		}
+	}
+	for _, c := range n.Children() {
+		b, ok := c.(*SplitBranchNode)
+		if !ok {
+			continue
+		}
+		if b.BranchName != SanitizeInvalidBranch {
+			return fmt.Errorf("unknown sanitize branch %q, the only branch is %q", b.BranchName, SanitizeInvalidBranch)
+		}
+		if !n.routes() {
+			return errors.New("the invalid branch requires the route action")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/influxdata/kapacitor/tick/stateful"
+)
+
+func TestSanitizeNode_MarshalJSON(t *testing.T) {
+	s := newSanitizeNode(StreamEdge)
+	s.Action = SanitizeReplace
+	s.ReplaceWith = -1
+	s.Fields("value")
+	s.FieldAction("value", SanitizeRoute)
+	MarshalTestHelper(t, s, false, `{"typeOf":"sanitize","id":"0","action":"replace","replaceWith":-1,"fields":["value"],"fieldActions":{"value":"route"}}`)
+}
+
+func TestSanitizeNode_UnmarshalJSON(t *testing.T) {
+	input := `{"typeOf":"sanitize","id":"0","action":"replace","replaceWith":-1,"fields":["value"],"fieldActions":{"value":"route"}}`
+	want := &SanitizeNode{
+		Action:       SanitizeReplace,
+		ReplaceWith:  -1,
+		FieldsList:   []string{"value"},
+		FieldActions: map[string]string{"value": SanitizeRoute},
+	}
+	UnmarshalJSONTestHelper(t, []byte(input), &SanitizeNode{}, false, want)
+}
+
+func TestSanitizeNode_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  string
+		wantErr string
+	}{
+		{
+			name: "drop",
+			script: `
+stream
+	|from()
+	|sanitize()
+`,
+		},
+		{
+			name: "route",
+			script: `
+var s = stream
+	|from()
+	|sanitize()
+		.action('route')
+s
+	|log()
+s
+	|branch('invalid')
+`,
+		},
+		{
+			name: "routed field",
+			script: `
+stream
+	|from()
+	|sanitize()
+		.fieldAction('value', 'route')
+	|branch('invalid')
+`,
+		},
+		{
+			name: "invalid action",
+			script: `
+stream
+	|from()
+	|sanitize()
+		.action('ignore')
+`,
+			wantErr: `invalid action "ignore", must be one of drop, replace or route`,
+		},
+		{
+			name: "invalid field action",
+			script: `
+stream
+	|from()
+	|sanitize()
+		.fieldAction('value', 'ignore')
+`,
+			wantErr: `invalid action "ignore" of field "value", must be one of drop, replace or route`,
+		},
+		{
+			name: "unknown branch",
+			script: `
+stream
+	|from()
+	|sanitize()
+		.action('route')
+	|branch('nan')
+`,
+			wantErr: `unknown sanitize branch "nan", the only branch is "invalid"`,
+		},
+		{
+			name: "branch without route",
+			script: `
+stream
+	|from()
+	|sanitize()
+		.action('replace')
+	|branch('invalid')
+`,
+			wantErr: "the invalid branch requires the route action",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := CreatePipeline(tt.script, StreamEdge, stateful.NewScope(), deadman{}, nil)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected error %q", tt.wantErr)
+			}
+			if err.Error() != tt.wantErr {
+				t.Errorf("unexpected error got %q want %q", err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSanitizeNode_JSONRoundTrip(t *testing.T) {
+	script := `
+var s = stream
+	|from()
+	|sanitize()
+		.action('route')
+s
+	|log()
+s
+	|branch('invalid')
+	|log()
+`
+	p, err := CreatePipeline(script, StreamEdge, stateful.NewScope(), deadman{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := &Pipeline{}
+	if err := got.Unmarshal(want); err != nil {
+		t.Fatal(err)
+	}
+	if err := Validate(got); err != nil {
+		t.Fatal(err)
+	}
+	gotJSON, err := json.Marshal(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(sortedPipelineJSON(t, gotJSON), sortedPipelineJSON(t, want)) {
+		t.Errorf("unexpected JSON after round trip\ngot:\n%s\nwant:\n%s", gotJSON, want)
+	}
+}
@@ -0,0 +1,242 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A ScheduleNode passes only points whose time falls within recurring time-of-day windows,
+// for example to only process data during business hours.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('requests')
+//        |schedule()
+//            .window('Mon-Fri', '09:00', '17:00')
+//            .window('Sat', '10:00', '14:00')
+//            .timezone('America/New_York')
+//        |alert()
+//            .crit(lambda: "errors" > 10)
+//
+// The above example only alerts on requests during the opening hours in New York.
+//
+// Windows are evaluated in the local time of the timezone, so they follow daylight saving time transitions.
+// A window whose end is before its start spans midnight and belongs to the day it starts on,
+// e.g. `.window('Fri', '22:00', '06:00')` includes Saturday 05:00.
+//
+// By default the time of each point is used so that replaying data is deterministic.
+// Use the wallClock property to use the current time instead.
+//
+// Points outside the windows are dropped unless the flagAs property is set,
+// in which case all points are passed with a boolean field indicating whether they are within the windows.
+// Use a split node to route points based on this field.
+//
+// Available Statistics:
+//
+//    * points_outside -- number of points outside the windows
+//
+type ScheduleNode struct {
+	chainnode `json:"-"`
+
+	// The recurring windows in which points are passed.
+	// tick:ignore
+	Windows []*ScheduleWindow `tick:"Window" json:"windows"`
+
+	// The name of the timezone of the windows, e.g. 'America/New_York'.
+	// Default: UTC
+	Timezone string `json:"timezone"`
+
+	// Whether to evaluate the windows against the current time instead of the time of the points.
+	// tick:ignore
+	WallClockFlag bool `tick:"WallClock" json:"wallClock"`
+
+	// The name of a boolean field set on all points indicating whether they are within the windows.
+	// If empty, points outside the windows are dropped.
+	FlagAs string `json:"flagAs"`
+}
+
+// ScheduleWindow is a time-of-day window on a set of days of the week.
+// tick:ignore
+type ScheduleWindow struct {
+	// The days of the week, e.g. 'Mon-Fri', 'Sat,Sun' or '*'.
+	Days string `json:"days"`
+	// The start of the window as HH:MM or HH:MM:SS.
+	Start string `json:"start"`
+	// The end of the window as HH:MM or HH:MM:SS, exclusive.
+	End string `json:"end"`
+}
+
+func newScheduleNode(wants EdgeType) *ScheduleNode {
+	return &ScheduleNode{
+		chainnode: newBasicChainNode("schedule", wants, wants),
+		Timezone:  "UTC",
+	}
+}
+
+// MarshalJSON converts ScheduleNode to JSON
+// tick:ignore
+func (n *ScheduleNode) MarshalJSON() ([]byte, error) {
+	type Alias ScheduleNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		TypeOf: TypeOf{
+			Type: "schedule",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an ScheduleNode
+// tick:ignore
+func (n *ScheduleNode) UnmarshalJSON(data []byte) error {
+	type Alias ScheduleNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "schedule" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as ScheduleNode", raw.ID, raw.Type)
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+// Add a window from start to end on the given days of the week.
+// Days are given as a comma separated list of names or ranges of names, e.g. 'Mon-Fri' or 'Mon,Wed,Fri', or '*' for all days.
+// Times are given as HH:MM or HH:MM:SS, the end is exclusive and may be '24:00'.
+// tick:property
+func (n *ScheduleNode) Window(days, start, end string) *ScheduleNode {
+	n.Windows = append(n.Windows, &ScheduleWindow{
+		Days:  days,
+		Start: start,
+		End:   end,
+	})
+	return n
+}
+
+// Evaluate the windows against the current time instead of the time of the points.
+// tick:property
+func (n *ScheduleNode) WallClock() *ScheduleNode {
+	n.WallClockFlag = true
+	return n
+}
+
+func (n *ScheduleNode) validate() error {
+	if len(n.Windows) == 0 {
+		return errors.New("must provide at least one window")
+	}
+	for _, w := range n.Windows {
+		if _, _, _, err := w.Parse(); err != nil {
+			return err
+		}
+	}
+	if _, err := time.LoadLocation(n.Timezone); err != nil {
+		return fmt.Errorf("invalid timezone %q: %v", n.Timezone, err)
+	}
+	return nil
+}
+
+var scheduleDays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// Parse returns the days of the window indexed by time.Weekday
+// and the start and end of the window as durations since midnight.
+// tick:ignore
+func (w *ScheduleWindow) Parse() (days [7]bool, start, end time.Duration, err error) {
+	days, err = parseScheduleDays(w.Days)
+	if err != nil {
+		return
+	}
+	start, err = parseScheduleClock(w.Start)
+	if err != nil {
+		return
+	}
+	end, err = parseScheduleClock(w.End)
+	if err != nil {
+		return
+	}
+	if start == end {
+		err = fmt.Errorf("window %s-%s is empty", w.Start, w.End)
+	}
+	return
+}
+
+func parseScheduleDays(s string) (days [7]bool, err error) {
+	if strings.TrimSpace(s) == "*" {
+		for i := range days {
+			days[i] = true
+		}
+		return days, nil
+	}
+	day := func(name string) (time.Weekday, error) {
+		d, ok := scheduleDays[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return 0, fmt.Errorf("invalid day %q, must be one of Mon, Tue, Wed, Thu, Fri, Sat or Sun", strings.TrimSpace(name))
+		}
+		return d, nil
+	}
+	for _, part := range strings.Split(s, ",") {
+		bounds := strings.SplitN(part, "-", 2)
+		first, err := day(bounds[0])
+		if err != nil {
+			return days, err
+		}
+		last := first
+		if len(bounds) == 2 {
+			if last, err = day(bounds[1]); err != nil {
+				return days, err
+			}
+		}
+		// Ranges may wrap around the end of the week, e.g. Fri-Mon.
+		for d := first; ; d = (d + 1) % 7 {
+			days[d] = true
+			if d == last {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+func parseScheduleClock(s string) (time.Duration, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 2 && len(parts) != 3 {
+		return 0, fmt.Errorf("invalid time %q, must be HH:MM or HH:MM:SS", s)
+	}
+	limits := []int{24, 59, 59}
+	units := []time.Duration{time.Hour, time.Minute, time.Second}
+	var d time.Duration
+	for i, p := range parts {
+		v, err := strconv.Atoi(p)
+		if err != nil || len(p) != 2 || v < 0 || v > limits[i] {
+			return 0, fmt.Errorf("invalid time %q, must be HH:MM or HH:MM:SS", s)
+		}
+		d += time.Duration(v) * units[i]
+	}
+	if d > 24*time.Hour {
+		return 0, fmt.Errorf("invalid time %q, must not be after 24:00", s)
+	}
+	return d, nil
+}
@@ -0,0 +1,108 @@
+package pipeline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduleNode_MarshalJSON(t *testing.T) {
+	s := newScheduleNode(StreamEdge)
+	s.Window("Mon-Fri", "09:00", "17:00")
+	s.Timezone = "Europe/Berlin"
+	s.WallClock()
+	s.FlagAs = "open"
+	MarshalTestHelper(t, s, false, `{"typeOf":"schedule","id":"0","windows":[{"days":"Mon-Fri","start":"09:00","end":"17:00"}],"timezone":"Europe/Berlin","wallClock":true,"flagAs":"open"}`)
+}
+
+func TestScheduleNode_UnmarshalJSON(t *testing.T) {
+	input := `{"typeOf":"schedule","id":"0","windows":[{"days":"Sat,Sun","start":"22:00","end":"06:00"}],"timezone":"UTC","wallClock":false,"flagAs":""}`
+	want := &ScheduleNode{
+		Windows:  []*ScheduleWindow{{Days: "Sat,Sun", Start: "22:00", End: "06:00"}},
+		Timezone: "UTC",
+	}
+	UnmarshalJSONTestHelper(t, []byte(input), &ScheduleNode{}, false, want)
+}
+
+func TestScheduleWindow_Parse(t *testing.T) {
+	w := &ScheduleWindow{Days: "Fri-Mon, wed", Start: "22:30", End: "06:00:30"}
+	days, start, end, err := w.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expDays := [7]bool{
+		time.Sunday:    true,
+		time.Monday:    true,
+		time.Wednesday: true,
+		time.Friday:    true,
+		time.Saturday:  true,
+	}
+	if days != expDays {
+		t.Errorf("unexpected days: got %v exp %v", days, expDays)
+	}
+	if exp := 22*time.Hour + 30*time.Minute; start != exp {
+		t.Errorf("unexpected start: got %v exp %v", start, exp)
+	}
+	if exp := 6*time.Hour + 30*time.Second; end != exp {
+		t.Errorf("unexpected end: got %v exp %v", end, exp)
+	}
+}
+
+func TestScheduleNode_Validate(t *testing.T) {
+	newNode := func(days, start, end, timezone string) *ScheduleNode {
+		s := newScheduleNode(StreamEdge)
+		s.Window(days, start, end)
+		s.Timezone = timezone
+		return s
+	}
+	tests := []struct {
+		name    string
+		node    *ScheduleNode
+		wantErr bool
+	}{
+		{
+			name: "business hours",
+			node: newNode("Mon-Fri", "09:00", "17:00", "America/New_York"),
+		},
+		{
+			name: "all day",
+			node: newNode("*", "00:00", "24:00", "UTC"),
+		},
+		{
+			name:    "no windows",
+			node:    newScheduleNode(StreamEdge),
+			wantErr: true,
+		},
+		{
+			name:    "invalid day",
+			node:    newNode("Mon-Funday", "09:00", "17:00", "UTC"),
+			wantErr: true,
+		},
+		{
+			name:    "invalid start",
+			node:    newNode("Mon", "9am", "17:00", "UTC"),
+			wantErr: true,
+		},
+		{
+			name:    "end after midnight",
+			node:    newNode("Mon", "09:00", "24:30", "UTC"),
+			wantErr: true,
+		},
+		{
+			name:    "empty window",
+			node:    newNode("Mon", "09:00", "09:00", "UTC"),
+			wantErr: true,
+		},
+		{
+			name:    "invalid timezone",
+			node:    newNode("Mon", "09:00", "17:00", "Mars/Olympus_Mons"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.node.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
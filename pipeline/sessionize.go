@@ -0,0 +1,129 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxdb/influxql"
+)
+
+// A SessionizeNode splits the points of each group into sessions separated by gaps of inactivity.
+// A new session starts when the time since the previous point of the group exceeds the timeout.
+//
+// Each point is tagged with the ID of its session, the IDs of the sessions of a group are increasing integers starting at 1.
+// When a session closes a summary point is emitted with the fields:
+//
+//    * start -- the time of the first point of the session, in nanoseconds since the epoch
+//    * end -- the time of the last point of the session, in nanoseconds since the epoch
+//    * count -- the number of points of the session
+//
+// The summary point has the time of the last point of the session, the tags of the group and the session ID tag.
+// Its name is set with the summaryName property, so that it can be told apart from the points of the session.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('clicks')
+//            .groupBy('user')
+//        |sessionize()
+//            .timeout(30m)
+//        |influxDBOut()
+//            .database('analytics')
+//
+// The above example tags the clicks of each user with the session they belong to,
+// a session ends after 30 minutes without clicks, and writes a `session` point summarizing each session.
+//
+// A session closes when a point or barrier of the group arrives more than the timeout after the last point of the session,
+// when the group has received no points for the timeout according to the system clock, or when the group is deleted.
+// The sessions that are open when the task stops are not summarized.
+//
+// Available Statistics:
+//
+//    * sessions -- number of sessions closed
+//
+type SessionizeNode struct {
+	chainnode `json:"-"`
+
+	// The maximum gap between the points of a session.
+	// Must be greater than zero.
+	Timeout time.Duration `json:"timeout"`
+
+	// The name of the session ID tag.
+	// Default: session_id
+	Tag string `json:"tag"`
+
+	// The name of the summary points.
+	// Default: session
+	SummaryName string `json:"summaryName"`
+}
+
+func newSessionizeNode(wants EdgeType) *SessionizeNode {
+	return &SessionizeNode{
+		chainnode:   newBasicChainNode("sessionize", wants, wants),
+		Tag:         "session_id",
+		SummaryName: "session",
+	}
+}
+
+// MarshalJSON converts SessionizeNode to JSON
+// tick:ignore
+func (n *SessionizeNode) MarshalJSON() ([]byte, error) {
+	type Alias SessionizeNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Timeout string `json:"timeout"`
+	}{
+		TypeOf: TypeOf{
+			Type: "sessionize",
+			ID:   n.ID(),
+		},
+		Alias:   (*Alias)(n),
+		Timeout: influxql.FormatDuration(n.Timeout),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an SessionizeNode
+// tick:ignore
+func (n *SessionizeNode) UnmarshalJSON(data []byte) error {
+	type Alias SessionizeNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Timeout string `json:"timeout"`
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "sessionize" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as SessionizeNode", raw.ID, raw.Type)
+	}
+	n.Timeout, err = influxql.ParseDuration(raw.Timeout)
+	if err != nil {
+		return err
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+func (n *SessionizeNode) validate() error {
+	if n.Wants() != StreamEdge {
+		return errors.New("sessionize can only be used on stream data")
+	}
+	if n.Timeout <= 0 {
+		return errors.New("timeout must be greater than zero")
+	}
+	if n.Tag == "" {
+		return errors.New("must provide a session ID tag")
+	}
+	if n.SummaryName == "" {
+		return errors.New("must provide a summary name")
+	}
+	return nil
+}
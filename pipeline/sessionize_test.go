@@ -0,0 +1,74 @@
+package pipeline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSessionizeNode_MarshalJSON(t *testing.T) {
+	s := newSessionizeNode(StreamEdge)
+	s.Timeout = 30 * time.Minute
+	MarshalTestHelper(t, s, false, `{"typeOf":"sessionize","id":"0","tag":"session_id","summaryName":"session","timeout":"30m"}`)
+}
+
+func TestSessionizeNode_UnmarshalJSON(t *testing.T) {
+	input := `{"typeOf":"sessionize","id":"0","tag":"sid","summaryName":"visits","timeout":"1h"}`
+	want := &SessionizeNode{
+		Timeout:     time.Hour,
+		Tag:         "sid",
+		SummaryName: "visits",
+	}
+	UnmarshalJSONTestHelper(t, []byte(input), &SessionizeNode{}, false, want)
+}
+
+func TestSessionizeNode_Validate(t *testing.T) {
+	newNode := func(e EdgeType, timeout time.Duration, tag, summaryName string) *SessionizeNode {
+		s := newSessionizeNode(e)
+		s.Timeout = timeout
+		s.Tag = tag
+		s.SummaryName = summaryName
+		return s
+	}
+	tests := []struct {
+		name    string
+		node    *SessionizeNode
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			node: newNode(StreamEdge, time.Minute, "session_id", "session"),
+		},
+		{
+			name:    "missing timeout",
+			node:    newNode(StreamEdge, 0, "session_id", "session"),
+			wantErr: true,
+		},
+		{
+			name:    "negative timeout",
+			node:    newNode(StreamEdge, -time.Minute, "session_id", "session"),
+			wantErr: true,
+		},
+		{
+			name:    "empty tag",
+			node:    newNode(StreamEdge, time.Minute, "", "session"),
+			wantErr: true,
+		},
+		{
+			name:    "empty summary name",
+			node:    newNode(StreamEdge, time.Minute, "session_id", ""),
+			wantErr: true,
+		},
+		{
+			name:    "batch",
+			node:    newNode(BatchEdge, time.Minute, "session_id", "session"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.node.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,241 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// A SplitNode routes each point to exactly one of several named branches.
+// Cases are evaluated in the order they are defined and each point is sent to the
+// branch of the first case whose predicate evaluates to true.
+// Points that match no case are sent to the default branch, if defined, and dropped otherwise.
+//
+// Branches are selected from the split node using the `branch` chaining method.
+// Only branch nodes may be children of a SplitNode.
+//
+// Example:
+//    var split = stream
+//        |from()
+//            .measurement('cpu')
+//        |split()
+//            .case('critical', lambda: "usage_idle" < 10)
+//            .case('warning', lambda: "usage_idle" < 30)
+//            .defaultBranch('normal')
+//
+//    split
+//        |branch('critical')
+//        |alert()
+//            .crit(lambda: TRUE)
+//
+//    split
+//        |branch('normal')
+//        |influxDBOut()
+//            .database('normal_cpu')
+//
+// The above example sends points with less than 10% idle CPU to the `critical` branch,
+// points with less than 30% idle CPU to the `warning` branch
+// and all remaining points to the `normal` branch.
+// Since nothing consumes the `warning` branch, those points are dropped.
+//
+// Batches are split so that each branch receives a batch containing only the points routed to it.
+//
+// Available Statistics:
+//
+//    * unmatched -- number of points that matched no case and had no default branch.
+//
+type SplitNode struct {
+	chainnode `json:"-"`
+
+	// The ordered list of cases.
+	// tick:ignore
+	Cases []*SplitCase `tick:"Case" json:"cases"`
+
+	// The name of the branch that receives points not matching any case.
+	// If empty, points not matching any case are dropped.
+	DefaultBranch string `json:"defaultBranch"`
+}
+
+// SplitCase maps a predicate to a named branch.
+// tick:ignore
+type SplitCase struct {
+	Name   string          `json:"name"`
+	Lambda *ast.LambdaNode `json:"lambda"`
+}
+
+func newSplitNode(e EdgeType) *SplitNode {
+	return &SplitNode{
+		chainnode: newBasicChainNode("split", e, e),
+	}
+}
+
+// MarshalJSON converts SplitNode to JSON
+// tick:ignore
+func (n *SplitNode) MarshalJSON() ([]byte, error) {
+	type Alias SplitNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		TypeOf: TypeOf{
+			Type: "split",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an SplitNode
+// tick:ignore
+func (n *SplitNode) UnmarshalJSON(data []byte) error {
+	type Alias SplitNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "split" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as SplitNode", raw.ID, raw.Type)
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+// Add a case routing points for which the lambda evaluates to true to the named branch.
+// Cases are evaluated in the order they are added.
+// tick:property
+func (n *SplitNode) Case(name string, lambda *ast.LambdaNode) *SplitNode {
+	n.Cases = append(n.Cases, &SplitCase{
+		Name:   name,
+		Lambda: lambda,
+	})
+	return n
+}
+
+// Select the named branch of the split.
+// The branch receives all points routed to it by the split node.
+func (n *SplitNode) Branch(name string) *SplitBranchNode {
+	b := newSplitBranchNode(n.Provides(), name)
+	n.linkChild(b)
+	return b
+}
+
+// hasBranch reports whether name is the name of a case or the default branch.
+func (n *SplitNode) hasBranch(name string) bool {
+	if name == "" {
+		return false
+	}
+	if name == n.DefaultBranch {
+		return true
+	}
+	for _, c := range n.Cases {
+		if c.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *SplitNode) validate() error {
+	if len(n.Cases) == 0 {
+		return errors.New("split must have at least one case")
+	}
+	names := make(map[string]bool, len(n.Cases))
+	for _, c := range n.Cases {
+		if c.Name == "" {
+			return errors.New("split case names cannot be empty")
+		}
+		if c.Lambda == nil {
+			return fmt.Errorf("split case %q must have a lambda expression", c.Name)
+		}
+		if names[c.Name] {
+			return fmt.Errorf("duplicate split case %q", c.Name)
+		}
+		names[c.Name] = true
+	}
+	if names[n.DefaultBranch] {
+		return fmt.Errorf("default branch %q cannot also be a case", n.DefaultBranch)
+	}
+	for _, c := range n.Children() {
+		switch b := c.(type) {
+		case *SplitBranchNode:
+			if !n.hasBranch(b.BranchName) {
+				return fmt.Errorf("unknown split branch %q", b.BranchName)
+			}
+		case *NoOpNode:
+		default:
+			return fmt.Errorf("children of split must be branches, got %s", c.Name())
+		}
+	}
+	return nil
+}
+
+// A SplitBranchNode receives the points routed to a single named branch of a SplitNode or SanitizeNode,
+// the barriers of a BarrierNode or the late points of a WindowNode.
+// See SplitNode, SanitizeNode, BarrierNode and WindowNode for details.
+//
+// Example:
+//    split
+//        |branch('critical')
+//        |httpOut('critical')
+//
+type SplitBranchNode struct {
+	chainnode `json:"-"`
+
+	// The name of the branch.
+	// tick:ignore
+	BranchName string `json:"branch"`
+}
+
+func newSplitBranchNode(e EdgeType, name string) *SplitBranchNode {
+	return &SplitBranchNode{
+		chainnode:  newBasicChainNode("branch", e, e),
+		BranchName: name,
+	}
+}
+
+// MarshalJSON converts SplitBranchNode to JSON
+// tick:ignore
+func (n *SplitBranchNode) MarshalJSON() ([]byte, error) {
+	type Alias SplitBranchNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		TypeOf: TypeOf{
+			Type: "branch",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an SplitBranchNode
+// tick:ignore
+func (n *SplitBranchNode) UnmarshalJSON(data []byte) error {
+	type Alias SplitBranchNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "branch" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as SplitBranchNode", raw.ID, raw.Type)
+	}
+	n.setID(raw.ID)
+	return nil
+}
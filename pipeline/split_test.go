@@ -0,0 +1,158 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/influxdata/kapacitor/tick/stateful"
+)
+
+func TestSplitNode_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  string
+		wantErr string
+	}{
+		{
+			name: "valid",
+			script: `
+var s = stream
+	|from()
+	|split()
+		.case('a', lambda: "value" > 1)
+		.defaultBranch('b')
+s
+	|branch('a')
+s
+	|branch('b')
+`,
+		},
+		{
+			name: "no cases",
+			script: `
+stream
+	|from()
+	|split()
+`,
+			wantErr: "split must have at least one case",
+		},
+		{
+			name: "duplicate case",
+			script: `
+stream
+	|from()
+	|split()
+		.case('a', lambda: "value" > 1)
+		.case('a', lambda: "value" > 2)
+`,
+			wantErr: `duplicate split case "a"`,
+		},
+		{
+			name: "default is case",
+			script: `
+stream
+	|from()
+	|split()
+		.case('a', lambda: "value" > 1)
+		.defaultBranch('a')
+`,
+			wantErr: `default branch "a" cannot also be a case`,
+		},
+		{
+			name: "unknown branch",
+			script: `
+stream
+	|from()
+	|split()
+		.case('a', lambda: "value" > 1)
+	|branch('b')
+`,
+			wantErr: `unknown split branch "b"`,
+		},
+		{
+			name: "non branch child",
+			script: `
+stream
+	|from()
+	|split()
+		.case('a', lambda: "value" > 1)
+	|log()
+`,
+			wantErr: "children of split must be branches, got log3",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := CreatePipeline(tt.script, StreamEdge, stateful.NewScope(), deadman{}, nil)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected error %q", tt.wantErr)
+			}
+			if err.Error() != tt.wantErr {
+				t.Errorf("unexpected error got %q want %q", err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSplitNode_JSONRoundTrip(t *testing.T) {
+	script := `
+var s = stream
+	|from()
+	|split()
+		.case('a', lambda: "value" > 1)
+		.defaultBranch('b')
+s
+	|branch('a')
+	|log()
+s
+	|branch('b')
+`
+	p, err := CreatePipeline(script, StreamEdge, stateful.NewScope(), deadman{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := &Pipeline{}
+	if err := got.Unmarshal(want); err != nil {
+		t.Fatal(err)
+	}
+	if err := Validate(got); err != nil {
+		t.Fatal(err)
+	}
+	gotJSON, err := json.Marshal(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The order of sibling nodes is not preserved, so compare the nodes and edges regardless of their order.
+	if !reflect.DeepEqual(sortedPipelineJSON(t, gotJSON), sortedPipelineJSON(t, want)) {
+		t.Errorf("unexpected JSON after round trip\ngot:\n%s\nwant:\n%s", gotJSON, want)
+	}
+}
+
+func sortedPipelineJSON(t *testing.T, data []byte) []string {
+	var raw JSONPipeline
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatal(err)
+	}
+	var sorted []string
+	for _, n := range raw.Nodes {
+		sorted = append(sorted, string(n))
+	}
+	for _, e := range raw.Edges {
+		sorted = append(sorted, fmt.Sprintf("%d->%d", e.Parent, e.Child))
+	}
+	sort.Strings(sorted)
+	return sorted
+}
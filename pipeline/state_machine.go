@@ -0,0 +1,228 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxdb/influxql"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// Fields of the transition points emitted by a StateMachineNode.
+const (
+	// The field with the state the group left.
+	StateMachineFromField = "from_state"
+	// The field with the state the group entered.
+	StateMachineToField = "to_state"
+	// The field with the time the group spent in the state it left.
+	StateMachineDwellField = "dwell_time"
+)
+
+// A StateMachineNode tracks the state of each group through an explicit graph of states and transitions.
+// It generalizes StateCountNode and StateDurationNode to any number of states,
+// for workflows that cannot be expressed with the levels of an AlertNode.
+//
+// The states are declared with the states property and the allowed transitions with the transition property.
+// A transition leaves a state for another state once its lambda expression, the entry condition of the other state,
+// evaluates to true for a point of the group.
+// Only the transitions declared from the current state of a group are evaluated, in the order they are declared,
+// and the first that matches is taken. A point can cause at most one transition.
+// Transitions that are not declared are never taken, e.g. a group in the state `ok` in the example below
+// cannot move to `critical` directly, even if the usage exceeds 95%.
+//
+// Each group starts in the initial state when its first point arrives.
+// On each transition a point is emitted with the time of the point that caused the transition,
+// the tags of that point and the fields `from_state`, `to_state` and `dwell_time`,
+// the time the group spent in the state it left in the unit of the node.
+// The data itself is not forwarded, points that cause no transition are dropped.
+// The transitions of a batch are emitted as a batch, batches without transitions are dropped.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('cpu')
+//            .groupBy('host')
+//        |stateMachine('ok')
+//            .states('ok', 'warning', 'critical')
+//            .transition('ok', 'warning', lambda: "usage" > 80)
+//            .transition('warning', 'critical', lambda: "usage" > 95)
+//            .transition('warning', 'ok', lambda: "usage" <= 80)
+//            .transition('critical', 'ok', lambda: "usage" <= 80)
+//            .unit(1m)
+//        |influxDBOut()
+//            .database('workflows')
+//            .measurement('cpu_transitions')
+//
+// The above example records every change of the state of each host,
+// e.g. a point with the fields from_state=warning, to_state=critical and dwell_time=12
+// when a host exceeds 95% usage after 12 minutes of warnings.
+//
+// The state of a group carries over across batches, points older than the last transition of their group are ignored.
+// The state of a group is dropped when the group is deleted,
+// and lost when the task restarts unless the persistState property is set.
+// A persisted state that is no longer declared is discarded when the task restarts.
+//
+// Available Statistics:
+//
+//    * transitions -- number of transitions taken.
+//
+type StateMachineNode struct {
+	chainnode `json:"-"`
+
+	// The state of the new groups.
+	// tick:ignore
+	InitialState string `json:"initialState"`
+
+	// The declared states.
+	// tick:ignore
+	DeclaredStates []string `tick:"States" json:"states"`
+
+	// The ordered list of transitions.
+	// tick:ignore
+	Transitions []*StateMachineTransition `tick:"Transition" json:"transitions"`
+
+	// The time unit of the dwell time.
+	// Default: 1s
+	Unit time.Duration `json:"unit"`
+
+	// Whether the state of the groups is saved in the task snapshots and restored when the task restarts.
+	// tick:ignore
+	PersistStateFlag bool `tick:"PersistState" json:"persistState,omitempty"`
+}
+
+// StateMachineTransition is a transition from a state to another state taken when its lambda evaluates to true.
+// tick:ignore
+type StateMachineTransition struct {
+	From   string          `json:"from"`
+	To     string          `json:"to"`
+	Lambda *ast.LambdaNode `json:"lambda"`
+}
+
+func newStateMachineNode(wants EdgeType, initial string) *StateMachineNode {
+	return &StateMachineNode{
+		chainnode:    newBasicChainNode("state_machine", wants, wants),
+		InitialState: initial,
+		Unit:         time.Second,
+	}
+}
+
+// MarshalJSON converts StateMachineNode to JSON
+// tick:ignore
+func (n *StateMachineNode) MarshalJSON() ([]byte, error) {
+	type Alias StateMachineNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Unit string `json:"unit"`
+	}{
+		TypeOf: TypeOf{
+			Type: "stateMachine",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+		Unit:  influxql.FormatDuration(n.Unit),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an StateMachineNode
+// tick:ignore
+func (n *StateMachineNode) UnmarshalJSON(data []byte) error {
+	type Alias StateMachineNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Unit string `json:"unit"`
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "stateMachine" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as StateMachineNode", raw.ID, raw.Type)
+	}
+	n.Unit, err = influxql.ParseDuration(raw.Unit)
+	if err != nil {
+		return err
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+// Declare the states of the state machine.
+// tick:property
+func (n *StateMachineNode) States(states ...string) *StateMachineNode {
+	n.DeclaredStates = append(n.DeclaredStates, states...)
+	return n
+}
+
+// Allow a transition from a state to another state, taken when the lambda evaluates to true.
+// The transitions from a state are evaluated in the order they are declared.
+// tick:property
+func (n *StateMachineNode) Transition(from, to string, lambda *ast.LambdaNode) *StateMachineNode {
+	n.Transitions = append(n.Transitions, &StateMachineTransition{
+		From:   from,
+		To:     to,
+		Lambda: lambda,
+	})
+	return n
+}
+
+// Save the state of the groups in the task snapshots, taken every snapshot-interval of the [task] configuration,
+// and restore it when the task restarts, so that the groups continue in the state they were in.
+// tick:property
+func (n *StateMachineNode) PersistState() *StateMachineNode {
+	n.PersistStateFlag = true
+	return n
+}
+
+func (n *StateMachineNode) validate() error {
+	if len(n.DeclaredStates) == 0 {
+		return errors.New("stateMachine must declare its states")
+	}
+	declared := make(map[string]bool, len(n.DeclaredStates))
+	for _, s := range n.DeclaredStates {
+		if s == "" {
+			return errors.New("state names cannot be empty")
+		}
+		if declared[s] {
+			return fmt.Errorf("duplicate state %q", s)
+		}
+		declared[s] = true
+	}
+	if !declared[n.InitialState] {
+		return fmt.Errorf("initial state %q is not a declared state", n.InitialState)
+	}
+	if len(n.Transitions) == 0 {
+		return errors.New("stateMachine must have at least one transition")
+	}
+	type key struct{ from, to string }
+	transitions := make(map[key]bool, len(n.Transitions))
+	for _, t := range n.Transitions {
+		if !declared[t.From] {
+			return fmt.Errorf("transition from undeclared state %q", t.From)
+		}
+		if !declared[t.To] {
+			return fmt.Errorf("transition to undeclared state %q", t.To)
+		}
+		if t.From == t.To {
+			return fmt.Errorf("transition from state %q to itself", t.From)
+		}
+		if t.Lambda == nil {
+			return fmt.Errorf("transition from %q to %q must have a lambda expression", t.From, t.To)
+		}
+		k := key{from: t.From, to: t.To}
+		if transitions[k] {
+			return fmt.Errorf("duplicate transition from %q to %q", t.From, t.To)
+		}
+		transitions[k] = true
+	}
+	if n.Unit <= 0 {
+		return errors.New("unit must be greater than zero")
+	}
+	return nil
+}
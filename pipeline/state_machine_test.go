@@ -0,0 +1,143 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/influxdata/kapacitor/tick/stateful"
+)
+
+func TestStateMachineNode_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  string
+		wantErr string
+	}{
+		{
+			name: "valid",
+			script: `
+stream
+	|from()
+	|stateMachine('ok')
+		.states('ok', 'warning')
+		.transition('ok', 'warning', lambda: "usage" > 80)
+		.transition('warning', 'ok', lambda: "usage" <= 80)
+`,
+		},
+		{
+			name: "no states",
+			script: `
+stream
+	|from()
+	|stateMachine('ok')
+		.transition('ok', 'warning', lambda: "usage" > 80)
+`,
+			wantErr: "stateMachine must declare its states",
+		},
+		{
+			name: "duplicate state",
+			script: `
+stream
+	|from()
+	|stateMachine('ok')
+		.states('ok', 'warning', 'ok')
+		.transition('ok', 'warning', lambda: "usage" > 80)
+`,
+			wantErr: `duplicate state "ok"`,
+		},
+		{
+			name: "undeclared initial state",
+			script: `
+stream
+	|from()
+	|stateMachine('unknown')
+		.states('ok', 'warning')
+		.transition('ok', 'warning', lambda: "usage" > 80)
+`,
+			wantErr: `initial state "unknown" is not a declared state`,
+		},
+		{
+			name: "no transitions",
+			script: `
+stream
+	|from()
+	|stateMachine('ok')
+		.states('ok', 'warning')
+`,
+			wantErr: "stateMachine must have at least one transition",
+		},
+		{
+			name: "transition from undeclared state",
+			script: `
+stream
+	|from()
+	|stateMachine('ok')
+		.states('ok', 'warning')
+		.transition('critical', 'ok', lambda: "usage" <= 80)
+`,
+			wantErr: `transition from undeclared state "critical"`,
+		},
+		{
+			name: "transition to undeclared state",
+			script: `
+stream
+	|from()
+	|stateMachine('ok')
+		.states('ok', 'warning')
+		.transition('ok', 'critical', lambda: "usage" > 95)
+`,
+			wantErr: `transition to undeclared state "critical"`,
+		},
+		{
+			name: "transition to itself",
+			script: `
+stream
+	|from()
+	|stateMachine('ok')
+		.states('ok', 'warning')
+		.transition('ok', 'ok', lambda: "usage" > 80)
+`,
+			wantErr: `transition from state "ok" to itself`,
+		},
+		{
+			name: "duplicate transition",
+			script: `
+stream
+	|from()
+	|stateMachine('ok')
+		.states('ok', 'warning')
+		.transition('ok', 'warning', lambda: "usage" > 80)
+		.transition('ok', 'warning', lambda: "usage" > 90)
+`,
+			wantErr: `duplicate transition from "ok" to "warning"`,
+		},
+		{
+			name: "zero unit",
+			script: `
+stream
+	|from()
+	|stateMachine('ok')
+		.states('ok', 'warning')
+		.transition('ok', 'warning', lambda: "usage" > 80)
+		.unit(0s)
+`,
+			wantErr: "unit must be greater than zero",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := CreatePipeline(tt.script, StreamEdge, stateful.NewScope(), deadman{}, nil)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected error %q", tt.wantErr)
+			}
+			if err.Error() != tt.wantErr {
+				t.Errorf("unexpected error got %q want %q", err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
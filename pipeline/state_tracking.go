@@ -36,6 +36,8 @@ import (
 //
 // Note that as the first point in the given state has no previous point, its
 // state duration will be 0.
+//
+// The state of each group is lost when the task restarts unless the persistState property is set.
 type StateDurationNode struct {
 	chainnode `json:"-"`
 
@@ -50,6 +52,10 @@ type StateDurationNode struct {
 	// The time unit of the resulting duration value.
 	// Default: 1s.
 	Unit time.Duration `json:"unit"`
+
+	// Whether the state of the groups is saved in the task snapshots and restored when the task restarts.
+	// tick:ignore
+	PersistStateFlag bool `tick:"PersistState" json:"persistState,omitempty"`
 }
 
 func newStateDurationNode(wants EdgeType, predicate *ast.LambdaNode) *StateDurationNode {
@@ -106,6 +112,14 @@ func (n *StateDurationNode) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// Save the state of the groups in the task snapshots, taken every snapshot-interval of the [task] configuration,
+// and restore it when the task restarts, so that the durations continue where they were.
+// tick:property
+func (n *StateDurationNode) PersistState() *StateDurationNode {
+	n.PersistStateFlag = true
+	return n
+}
+
 // Compute the number of consecutive points in a given state.
 // The state is defined via a lambda expression. For each consecutive point for
 // which the expression evaluates as true, the state count will be incremented
@@ -128,6 +142,8 @@ func (n *StateDurationNode) UnmarshalJSON(data []byte) error {
 //             .warn(lambda: "state_count" >= 1)
 //             // Critical after 5 points
 //             .crit(lambda: "state_count" >= 5)
+//
+// The state of each group is lost when the task restarts unless the persistState property is set.
 type StateCountNode struct {
 	chainnode `json:"-"`
 
@@ -138,6 +154,10 @@ type StateCountNode struct {
 	// The new name of the resulting duration field.
 	// Default: 'state_count'
 	As string `json:"as"`
+
+	// Whether the state of the groups is saved in the task snapshots and restored when the task restarts.
+	// tick:ignore
+	PersistStateFlag bool `tick:"PersistState" json:"persistState,omitempty"`
 }
 
 func newStateCountNode(wants EdgeType, predicate *ast.LambdaNode) *StateCountNode {
@@ -185,3 +205,11 @@ func (n *StateCountNode) UnmarshalJSON(data []byte) error {
 	n.setID(raw.ID)
 	return nil
 }
+
+// Save the state of the groups in the task snapshots, taken every snapshot-interval of the [task] configuration,
+// and restore it when the task restarts, so that the counts continue where they were.
+// tick:property
+func (n *StateCountNode) PersistState() *StateCountNode {
+	n.PersistStateFlag = true
+	return n
+}
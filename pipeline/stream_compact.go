@@ -0,0 +1,121 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxdb/influxql"
+)
+
+// A StreamCompactNode coalesces consecutive identical points of each group,
+// emitting a point only when its fields differ from the previously emitted point of the group.
+// This reduces the data of state streams whose values are sent regularly but rarely change.
+//
+// The compared fields are the fields given as arguments, or all fields of the points if none are given.
+// A field missing from one point but present in the other counts as a change.
+//
+// The maxInterval property emits a heartbeat, an unchanged point is emitted
+// if it is at least maxInterval later than the previously emitted point of the group,
+// so that downstream systems know the series is still alive.
+// Since the heartbeat is an incoming point, no heartbeat is emitted while a group receives no points.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('valves')
+//            .groupBy('valve')
+//        |streamCompact('state')
+//            .maxInterval(5m)
+//        |influxDBOut()
+//            .database('plant')
+//
+// The above example writes the state of each valve when it changes and at least every 5 minutes.
+//
+// The first point of each group is always emitted.
+// The last emitted point of a group is forgotten when the group is deleted.
+//
+// Available Statistics:
+//
+//    * points_compacted -- number of points not emitted since they were unchanged
+//    * heartbeats -- number of unchanged points emitted because of the max interval
+//
+type StreamCompactNode struct {
+	chainnode `json:"-"`
+
+	// The fields to compare, all fields if empty.
+	// tick:ignore
+	Fields []string `json:"fields"`
+
+	// The maximum interval between emitted points of a group.
+	// If zero, unchanged points are never emitted.
+	MaxInterval time.Duration `json:"maxInterval"`
+}
+
+func newStreamCompactNode(wants EdgeType, fields []string) *StreamCompactNode {
+	return &StreamCompactNode{
+		chainnode: newBasicChainNode("streamCompact", wants, wants),
+		Fields:    fields,
+	}
+}
+
+// MarshalJSON converts StreamCompactNode to JSON
+// tick:ignore
+func (n *StreamCompactNode) MarshalJSON() ([]byte, error) {
+	type Alias StreamCompactNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		MaxInterval string `json:"maxInterval"`
+	}{
+		TypeOf: TypeOf{
+			Type: "streamCompact",
+			ID:   n.ID(),
+		},
+		Alias:       (*Alias)(n),
+		MaxInterval: influxql.FormatDuration(n.MaxInterval),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an StreamCompactNode
+// tick:ignore
+func (n *StreamCompactNode) UnmarshalJSON(data []byte) error {
+	type Alias StreamCompactNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		MaxInterval string `json:"maxInterval"`
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "streamCompact" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as StreamCompactNode", raw.ID, raw.Type)
+	}
+	n.MaxInterval, err = influxql.ParseDuration(raw.MaxInterval)
+	if err != nil {
+		return err
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+func (n *StreamCompactNode) validate() error {
+	if n.Wants() != StreamEdge {
+		return errors.New("streamCompact can only be used on stream data")
+	}
+	for _, f := range n.Fields {
+		if f == "" {
+			return errors.New("field names cannot be empty")
+		}
+	}
+	if n.MaxInterval < 0 {
+		return errors.New("max interval cannot be negative")
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package pipeline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStreamCompactNode_MarshalJSON(t *testing.T) {
+	s := newStreamCompactNode(StreamEdge, []string{"state", "mode"})
+	s.MaxInterval = 5 * time.Minute
+	MarshalTestHelper(t, s, false, `{"typeOf":"streamCompact","id":"0","fields":["state","mode"],"maxInterval":"5m"}`)
+}
+
+func TestStreamCompactNode_UnmarshalJSON(t *testing.T) {
+	input := `{"typeOf":"streamCompact","id":"0","fields":["state"],"maxInterval":"1m"}`
+	want := &StreamCompactNode{
+		Fields:      []string{"state"},
+		MaxInterval: time.Minute,
+	}
+	UnmarshalJSONTestHelper(t, []byte(input), &StreamCompactNode{}, false, want)
+}
+
+func TestStreamCompactNode_Validate(t *testing.T) {
+	negative := newStreamCompactNode(StreamEdge, nil)
+	negative.MaxInterval = -time.Minute
+	tests := []struct {
+		name    string
+		node    *StreamCompactNode
+		wantErr bool
+	}{
+		{
+			name: "all fields",
+			node: newStreamCompactNode(StreamEdge, nil),
+		},
+		{
+			name: "fields",
+			node: newStreamCompactNode(StreamEdge, []string{"state"}),
+		},
+		{
+			name:    "empty field",
+			node:    newStreamCompactNode(StreamEdge, []string{""}),
+			wantErr: true,
+		},
+		{
+			name:    "negative max interval",
+			node:    negative,
+			wantErr: true,
+		},
+		{
+			name:    "batch",
+			node:    newStreamCompactNode(BatchEdge, nil),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.node.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"text/template"
+)
+
+// A StreamReplayNode records all data it receives to a file while passing it through unchanged.
+// The recording can later be replayed to deterministically reproduce the data seen by the nodes
+// following it, which is useful when reproducing incidents.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('requests')
+//            .groupBy('host')
+//        |streamReplay('/var/lib/kapacitor/replays/{{ .TaskName }}-{{ .NodeName }}.rec')
+//            .maxSize(104857600)
+//            .rotations(5)
+//        |window()
+//            .period(1m)
+//            .every(1m)
+//
+// The above example records the grouped requests stream to a file that is rotated once it exceeds 100MB,
+// keeping the five most recent rotated files.
+//
+// The path is a template with access to the `TaskName` and `NodeName`.
+// Rotated files are named by appending `.1`, `.2`, ... to the path, where `.1` is the most recent.
+//
+// The recording is a text file, each line is either a point in line protocol with nanosecond precision
+// or a control record prefixed with `#` followed by a JSON object.
+// Control records capture everything that is not a point, i.e. the database, retention policy and group
+// of stream points, the boundaries of batches, barriers and deleted groups.
+// Since barriers are recorded, replaying the file reproduces the windowing of the original data.
+//
+// A recording is replayed by running the task with the replay property set on the node.
+// The node then emits the messages of the recording, including its rotated files oldest first,
+// to its children before passing on the data it receives, which is not recorded.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('requests')
+//            .groupBy('host')
+//        |streamReplay('/var/lib/kapacitor/replays/{{ .TaskName }}-{{ .NodeName }}.rec')
+//            .replay()
+//        |window()
+//            .period(1m)
+//            .every(1m)
+//
+// The above example replays the recording of the first example to the window node.
+type StreamReplayNode struct {
+	chainnode `json:"-"`
+
+	// The path template of the recording file.
+	// tick:ignore
+	Path string `json:"path"`
+
+	// The size in bytes after which the recording file is rotated.
+	// If zero the file is never rotated.
+	MaxSize int64 `json:"maxSize"`
+
+	// The number of rotated files to keep.
+	// If zero the recording is discarded when the file is rotated.
+	Rotations int64 `json:"rotations"`
+
+	// Replay the recording instead of recording.
+	// tick:ignore
+	ReplayFlag bool `tick:"Replay" json:"replay"`
+}
+
+func newStreamReplayNode(wants EdgeType, path string) *StreamReplayNode {
+	return &StreamReplayNode{
+		chainnode: newBasicChainNode("stream_replay", wants, wants),
+		Path:      path,
+	}
+}
+
+// MarshalJSON converts StreamReplayNode to JSON
+// tick:ignore
+func (n *StreamReplayNode) MarshalJSON() ([]byte, error) {
+	type Alias StreamReplayNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		TypeOf: TypeOf{
+			Type: "streamReplay",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an StreamReplayNode
+// tick:ignore
+func (n *StreamReplayNode) UnmarshalJSON(data []byte) error {
+	type Alias StreamReplayNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "streamReplay" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as StreamReplayNode", raw.ID, raw.Type)
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+// Replay the recording to the children of the node instead of recording the data.
+// tick:property
+func (n *StreamReplayNode) Replay() *StreamReplayNode {
+	n.ReplayFlag = true
+	return n
+}
+
+func (n *StreamReplayNode) validate() error {
+	if n.Path == "" {
+		return errors.New("must provide a path for the recording")
+	}
+	if _, err := template.New("path").Parse(n.Path); err != nil {
+		return fmt.Errorf("invalid path template: %v", err)
+	}
+	if n.MaxSize < 0 {
+		return errors.New("maxSize cannot be negative")
+	}
+	if n.Rotations < 0 {
+		return errors.New("rotations cannot be negative")
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package pipeline
+
+import (
+	"testing"
+)
+
+func TestStreamReplayNode_MarshalJSON(t *testing.T) {
+	s := newStreamReplayNode(StreamEdge, "/tmp/{{ .TaskName }}.rec")
+	s.MaxSize = 1024
+	s.Rotations = 3
+	MarshalTestHelper(t, s, false, `{"typeOf":"streamReplay","id":"0","path":"/tmp/{{ .TaskName }}.rec","maxSize":1024,"rotations":3,"replay":false}`)
+}
+
+func TestStreamReplayNode_UnmarshalJSON(t *testing.T) {
+	input := `{"typeOf":"streamReplay","id":"0","path":"/tmp/{{ .TaskName }}.rec","maxSize":1024,"rotations":3,"replay":true}`
+	want := &StreamReplayNode{
+		Path:       "/tmp/{{ .TaskName }}.rec",
+		MaxSize:    1024,
+		Rotations:  3,
+		ReplayFlag: true,
+	}
+	UnmarshalJSONTestHelper(t, []byte(input), &StreamReplayNode{}, false, want)
+}
+
+func TestStreamReplayNode_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		node    *StreamReplayNode
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			node: newStreamReplayNode(StreamEdge, "/tmp/{{ .TaskName }}.rec"),
+		},
+		{
+			name:    "no path",
+			node:    newStreamReplayNode(StreamEdge, ""),
+			wantErr: true,
+		},
+		{
+			name:    "invalid template",
+			node:    newStreamReplayNode(StreamEdge, "/tmp/{{ .TaskName"),
+			wantErr: true,
+		},
+		{
+			name: "negative max size",
+			node: &StreamReplayNode{
+				Path:    "/tmp/replay.rec",
+				MaxSize: -1,
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative rotations",
+			node: &StreamReplayNode{
+				Path:      "/tmp/replay.rec",
+				Rotations: -1,
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.node.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
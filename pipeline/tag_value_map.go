@@ -0,0 +1,141 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Maps the values of a tag through a lookup table, e.g. to translate codes to readable labels.
+// This keeps the mapping declarative instead of nesting `if` calls in an eval node.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('services')
+//        |tagValueMap('status')
+//            .fromField()
+//            .map('0', 'ok')
+//            .map('1', 'warn')
+//            .map('2', 'crit')
+//            .default('unknown')
+//            .as('status_label')
+//
+// The above example sets the field `status_label` to `ok`, `warn` or `crit` depending on the code in the field `status`,
+// or to `unknown` if the code is not in the table.
+//
+// The value is read from a tag, or from a field with the fromField property,
+// and the label is written to a tag or field respectively.
+// Field values are compared by their string representation, so the integer 1 matches the key '1'.
+// Without the as property the label replaces the value in the same tag or field.
+// Replacing a tag the data is grouped by does not regroup the data, map to a new tag and group by it instead.
+//
+// Values that are not in the table get the default label if it is set, otherwise the point is passed on unchanged.
+// Points without the tag or field are passed on unchanged.
+//
+// Available Statistics:
+//
+//    * unmapped -- number of values that were not in the table.
+//
+type TagValueMapNode struct {
+	chainnode `json:"-"`
+
+	// The tag, or field, with the value to map.
+	// tick:ignore
+	Tag string `json:"tag"`
+
+	// Whether to read the value from a field with the name instead of a tag.
+	// tick:ignore
+	FromFieldFlag bool `tick:"FromField" json:"fromField"`
+
+	// The name of the tag, or field, with the label.
+	// Default is to replace the value.
+	As string `json:"as"`
+
+	// The labels of the values.
+	// tick:ignore
+	Mappings map[string]string `tick:"Map" json:"mappings"`
+
+	// The label of values that are not in the table.
+	// tick:ignore
+	DefaultLabel *string `tick:"Default" json:"default"`
+}
+
+func newTagValueMapNode(e EdgeType, tag string) *TagValueMapNode {
+	return &TagValueMapNode{
+		chainnode: newBasicChainNode("tag_value_map", e, e),
+		Tag:       tag,
+	}
+}
+
+// MarshalJSON converts TagValueMapNode to JSON
+// tick:ignore
+func (n *TagValueMapNode) MarshalJSON() ([]byte, error) {
+	type Alias TagValueMapNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		TypeOf: TypeOf{
+			Type: "tagValueMap",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an TagValueMapNode
+// tick:ignore
+func (n *TagValueMapNode) UnmarshalJSON(data []byte) error {
+	type Alias TagValueMapNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "tagValueMap" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as TagValueMapNode", raw.ID, raw.Type)
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+// Read the value from the field with the name instead of the tag.
+// tick:property
+func (n *TagValueMapNode) FromField() *TagValueMapNode {
+	n.FromFieldFlag = true
+	return n
+}
+
+// Map a value to a label.
+// tick:property
+func (n *TagValueMapNode) Map(value, label string) *TagValueMapNode {
+	if n.Mappings == nil {
+		n.Mappings = make(map[string]string)
+	}
+	n.Mappings[value] = label
+	return n
+}
+
+// Set the label of values that are not in the table.
+// tick:property
+func (n *TagValueMapNode) Default(label string) *TagValueMapNode {
+	n.DefaultLabel = &label
+	return n
+}
+
+func (n *TagValueMapNode) validate() error {
+	if n.Tag == "" {
+		return errors.New("must provide a tag or field with the value to map")
+	}
+	if len(n.Mappings) == 0 {
+		return errors.New("must provide at least one mapping")
+	}
+	return nil
+}
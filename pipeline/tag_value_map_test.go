@@ -0,0 +1,55 @@
+package pipeline
+
+import "testing"
+
+func TestTagValueMapNode_MarshalJSON(t *testing.T) {
+	m := newTagValueMapNode(StreamEdge, "status")
+	m.FromField().Map("0", "ok").Map("1", "warn").Default("unknown")
+	m.As = "label"
+	MarshalTestHelper(t, m, false, `{"typeOf":"tagValueMap","id":"0","tag":"status","fromField":true,"as":"label","mappings":{"0":"ok","1":"warn"},"default":"unknown"}`)
+}
+
+func TestTagValueMapNode_UnmarshalJSON(t *testing.T) {
+	input := `{"typeOf":"tagValueMap","id":"0","tag":"status","fromField":false,"as":"","mappings":{"0":"ok"},"default":null}`
+	want := &TagValueMapNode{
+		Tag:      "status",
+		Mappings: map[string]string{"0": "ok"},
+	}
+	UnmarshalJSONTestHelper(t, []byte(input), &TagValueMapNode{}, false, want)
+}
+
+func TestTagValueMapNode_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		node    func(m *TagValueMapNode)
+		wantErr bool
+	}{
+		{
+			name: "mapping",
+			node: func(m *TagValueMapNode) { m.Map("0", "ok") },
+		},
+		{
+			name: "empty label",
+			node: func(m *TagValueMapNode) { m.Map("0", "").Default("") },
+		},
+		{
+			name:    "no name",
+			node:    func(m *TagValueMapNode) { m.Map("0", "ok").Tag = "" },
+			wantErr: true,
+		},
+		{
+			name:    "no mappings",
+			node:    func(m *TagValueMapNode) { m.Default("unknown") },
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTagValueMapNode(StreamEdge, "status")
+			tt.node(m)
+			if err := m.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/influxdata/influxdb/influxql"
+)
+
+const (
+	ThresholdLearnStddev     = "stddev"
+	ThresholdLearnPercentile = "percentile"
+)
+
+// Learn lower and upper bounds of a field of each group from its recent values.
+// Setting alert thresholds by hand does not scale to many series,
+// use this node to learn a baseline for each series instead.
+//
+// The bounds are added as fields to each point, so that a downstream alert can compare against them.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('cpu')
+//            .groupBy('host')
+//        |thresholdLearn('usage_user')
+//            .window(1d)
+//            .refresh(1h)
+//            .method('stddev')
+//            .k(3.0)
+//        |alert()
+//            .crit(lambda: "usage_user" > "upper" OR "usage_user" < "lower")
+//
+// The above example alerts when the CPU usage of a host deviates by more than
+// three standard deviations from its mean over the last day.
+//
+// The method determines how the bounds are computed from the values within the learning window:
+//
+//    * stddev -- the bounds are the mean minus and plus k standard deviations.
+//    * percentile -- the bounds are the lowerPercentile and upperPercentile percentiles.
+//
+// The first bounds of a group are learned once it has seen data spanning the learning window,
+// points are not emitted before then.
+// The bounds are recomputed every refresh interval, measured by the time of the data.
+//
+// Batch data is treated as a stream of points, the values are kept across batches.
+type ThresholdLearnNode struct {
+	chainnode `json:"-"`
+
+	// The field to learn the bounds of.
+	// tick:ignore
+	Field string `json:"field"`
+
+	// How the bounds are computed, one of stddev or percentile.
+	// Default: stddev
+	Method string `json:"method"`
+
+	// The duration of the values the bounds are learned from.
+	// Default: 1h
+	Window time.Duration `json:"window"`
+
+	// How often the bounds are recomputed.
+	// Default: 5m
+	Refresh time.Duration `json:"refresh"`
+
+	// The number of standard deviations of the bounds from the mean, for the stddev method.
+	// Default: 3.0
+	K float64 `json:"k"`
+
+	// The percentile of the lower bound, for the percentile method.
+	// Default: 5.0
+	LowerPercentile float64 `json:"lowerPercentile"`
+
+	// The percentile of the upper bound, for the percentile method.
+	// Default: 95.0
+	UpperPercentile float64 `json:"upperPercentile"`
+
+	// The name of the lower bound field.
+	// Default: lower
+	LowerAs string `json:"lowerAs"`
+
+	// The name of the upper bound field.
+	// Default: upper
+	UpperAs string `json:"upperAs"`
+}
+
+func newThresholdLearnNode(wants EdgeType, field string) *ThresholdLearnNode {
+	return &ThresholdLearnNode{
+		chainnode:       newBasicChainNode("threshold_learn", wants, wants),
+		Field:           field,
+		Method:          ThresholdLearnStddev,
+		Window:          time.Hour,
+		Refresh:         5 * time.Minute,
+		K:               3,
+		LowerPercentile: 5,
+		UpperPercentile: 95,
+		LowerAs:         "lower",
+		UpperAs:         "upper",
+	}
+}
+
+// MarshalJSON converts ThresholdLearnNode to JSON
+// tick:ignore
+func (n *ThresholdLearnNode) MarshalJSON() ([]byte, error) {
+	type Alias ThresholdLearnNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Window  string `json:"window"`
+		Refresh string `json:"refresh"`
+	}{
+		TypeOf: TypeOf{
+			Type: "thresholdLearn",
+			ID:   n.ID(),
+		},
+		Alias:   (*Alias)(n),
+		Window:  influxql.FormatDuration(n.Window),
+		Refresh: influxql.FormatDuration(n.Refresh),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an ThresholdLearnNode
+// tick:ignore
+func (n *ThresholdLearnNode) UnmarshalJSON(data []byte) error {
+	type Alias ThresholdLearnNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Window  string `json:"window"`
+		Refresh string `json:"refresh"`
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "thresholdLearn" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as ThresholdLearnNode", raw.ID, raw.Type)
+	}
+	n.Window, err = influxql.ParseDuration(raw.Window)
+	if err != nil {
+		return err
+	}
+	n.Refresh, err = influxql.ParseDuration(raw.Refresh)
+	if err != nil {
+		return err
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+//tick:ignore
+func (n *ThresholdLearnNode) ChainMethods() map[string]reflect.Value {
+	return map[string]reflect.Value{
+		"Window": reflect.ValueOf(n.chainnode.Window),
+	}
+}
+
+func (n *ThresholdLearnNode) validate() error {
+	if n.Field == "" {
+		return errors.New("must provide a field to learn the bounds of")
+	}
+	switch n.Method {
+	case ThresholdLearnStddev:
+		if n.K < 0 {
+			return errors.New("k cannot be negative")
+		}
+	case ThresholdLearnPercentile:
+		if n.LowerPercentile < 0 || n.LowerPercentile > 100 {
+			return fmt.Errorf("invalid lowerPercentile %v, must be in [0,100]", n.LowerPercentile)
+		}
+		if n.UpperPercentile < 0 || n.UpperPercentile > 100 {
+			return fmt.Errorf("invalid upperPercentile %v, must be in [0,100]", n.UpperPercentile)
+		}
+		if n.LowerPercentile > n.UpperPercentile {
+			return errors.New("lowerPercentile cannot be greater than upperPercentile")
+		}
+	default:
+		return fmt.Errorf("invalid method %q, must be one of %s or %s", n.Method, ThresholdLearnStddev, ThresholdLearnPercentile)
+	}
+	if n.Window <= 0 {
+		return errors.New("window must be greater than 0")
+	}
+	if n.Refresh <= 0 {
+		return errors.New("refresh must be greater than 0")
+	}
+	if n.LowerAs == "" || n.UpperAs == "" {
+		return errors.New("must provide names for the lower and upper bound fields")
+	}
+	if n.LowerAs == n.UpperAs {
+		return errors.New("lowerAs and upperAs must be different")
+	}
+	return nil
+}
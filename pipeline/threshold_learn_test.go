@@ -0,0 +1,107 @@
+package pipeline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestThresholdLearnNode_MarshalJSON(t *testing.T) {
+	n := newThresholdLearnNode(StreamEdge, "usage")
+	n.Method = ThresholdLearnPercentile
+	n.Window = 24 * time.Hour
+	n.Refresh = time.Hour
+	n.UpperPercentile = 99
+	MarshalTestHelper(t, n, false, `{"typeOf":"thresholdLearn","id":"0","field":"usage","method":"percentile","k":3,"lowerPercentile":5,"upperPercentile":99,"lowerAs":"lower","upperAs":"upper","window":"1d","refresh":"1h"}`)
+}
+
+func TestThresholdLearnNode_UnmarshalJSON(t *testing.T) {
+	input := `{"typeOf":"thresholdLearn","id":"0","field":"usage","method":"stddev","k":2.5,"lowerPercentile":5,"upperPercentile":95,"lowerAs":"lo","upperAs":"hi","window":"1h","refresh":"5m"}`
+	want := &ThresholdLearnNode{
+		Field:           "usage",
+		Method:          ThresholdLearnStddev,
+		Window:          time.Hour,
+		Refresh:         5 * time.Minute,
+		K:               2.5,
+		LowerPercentile: 5,
+		UpperPercentile: 95,
+		LowerAs:         "lo",
+		UpperAs:         "hi",
+	}
+	UnmarshalJSONTestHelper(t, []byte(input), &ThresholdLearnNode{}, false, want)
+}
+
+func TestThresholdLearnNode_Validate(t *testing.T) {
+	newNode := func(f func(n *ThresholdLearnNode)) *ThresholdLearnNode {
+		n := newThresholdLearnNode(StreamEdge, "usage")
+		f(n)
+		return n
+	}
+	tests := []struct {
+		name    string
+		node    *ThresholdLearnNode
+		wantErr bool
+	}{
+		{
+			name: "stddev",
+			node: newNode(func(*ThresholdLearnNode) {}),
+		},
+		{
+			name: "percentile",
+			node: newNode(func(n *ThresholdLearnNode) { n.Method = ThresholdLearnPercentile }),
+		},
+		{
+			name:    "missing field",
+			node:    newNode(func(n *ThresholdLearnNode) { n.Field = "" }),
+			wantErr: true,
+		},
+		{
+			name:    "invalid method",
+			node:    newNode(func(n *ThresholdLearnNode) { n.Method = "median" }),
+			wantErr: true,
+		},
+		{
+			name:    "negative k",
+			node:    newNode(func(n *ThresholdLearnNode) { n.K = -1 }),
+			wantErr: true,
+		},
+		{
+			name: "percentile out of range",
+			node: newNode(func(n *ThresholdLearnNode) {
+				n.Method = ThresholdLearnPercentile
+				n.UpperPercentile = 101
+			}),
+			wantErr: true,
+		},
+		{
+			name: "lower percentile above upper",
+			node: newNode(func(n *ThresholdLearnNode) {
+				n.Method = ThresholdLearnPercentile
+				n.LowerPercentile = 90
+				n.UpperPercentile = 10
+			}),
+			wantErr: true,
+		},
+		{
+			name:    "zero window",
+			node:    newNode(func(n *ThresholdLearnNode) { n.Window = 0 }),
+			wantErr: true,
+		},
+		{
+			name:    "zero refresh",
+			node:    newNode(func(n *ThresholdLearnNode) { n.Refresh = 0 }),
+			wantErr: true,
+		},
+		{
+			name:    "duplicate field names",
+			node:    newNode(func(n *ThresholdLearnNode) { n.UpperAs = "lower" }),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.node.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -41,6 +41,8 @@ func (n *AlertNode) Build(a *pipeline.AlertNode) (ast.Node, error) {
 		Dot("durationField", a.DurationField).
 		Dot("idTag", a.IdTag).
 		Dot("idField", a.IdField).
+		Dot("ackTimeout", a.AckTimeout).
+		Dot("summaryInterval", a.SummaryInterval).
 		DotIf("all", a.AllFlag).
 		DotIf("noRecoveries", a.NoRecoveriesFlag)
 
@@ -53,6 +55,10 @@ func (n *AlertNode) Build(a *pipeline.AlertNode) (ast.Node, error) {
 		n.Dot("inhibit", args...)
 	}
 
+	for _, l := range a.HandlerLimits {
+		n.Dot("handlerConcurrency", l.Handler, l.Concurrency, l.QueueSize)
+	}
+
 	if a.IsStateChangesOnly {
 		if a.StateChangesOnlyDuration == 0 {
 			n.Dot("stateChangesOnly")
@@ -65,6 +71,39 @@ func (n *AlertNode) Build(a *pipeline.AlertNode) (ast.Node, error) {
 		n.DotZeroValueOK("flapping", a.FlapLow, a.FlapHigh)
 	}
 
+	if a.DurableRetryMaxAge > 0 {
+		n.Dot("durableRetry", a.DurableRetryMaxAge, a.DurableRetryInterval)
+	}
+	n.DotIf("persistState", a.PersistStateFlag)
+
+	if len(a.IDTags) > 0 {
+		n.Dot("idTags", args(a.IDTags)...)
+	}
+
+	if a.ValueHistoryField != "" {
+		n.Dot("valueHistory", a.ValueHistoryField, a.ValueHistoryCount)
+	}
+
+	if a.RocField != "" {
+		if a.RocLookback == 0 {
+			n.Dot("roc", a.RocField)
+		} else {
+			n.Dot("roc", a.RocField, a.RocLookback)
+		}
+	}
+
+	if a.PercentilesField != "" {
+		percentiles := []interface{}{a.PercentilesField, a.PercentilesWindow}
+		for _, percentile := range a.PercentilesRanks {
+			percentiles = append(percentiles, percentile)
+		}
+		n.Dot("rollingPercentiles", percentiles...)
+	}
+
+	if a.BatchContextField != "" {
+		n.DotZeroValueOK("batchContext", a.BatchContextField, a.BatchContextThreshold)
+	}
+
 	for _, h := range a.HTTPPostHandlers {
 		n.DotRemoveZeroValue("post", h.URL).
 			Dot("endpoint", h.Endpoint).
@@ -79,10 +118,12 @@ func (n *AlertNode) Build(a *pipeline.AlertNode) (ast.Node, error) {
 		for _, k := range headers {
 			n.Dot("header", k, h.Headers[k])
 		}
+		n.DotNotEmpty("levels", args(h.LevelsList)...)
 	}
 
 	for _, h := range a.TcpHandlers {
-		n.DotRemoveZeroValue("tcp", h.Address)
+		n.DotRemoveZeroValue("tcp", h.Address).
+			DotNotEmpty("levels", args(h.LevelsList)...)
 	}
 
 	for _, h := range a.EmailHandlers {
@@ -90,10 +131,16 @@ func (n *AlertNode) Build(a *pipeline.AlertNode) (ast.Node, error) {
 		for _, to := range h.ToList {
 			n.Dot("to", to)
 		}
+		n.Dot("toTag", h.ToTag).
+			Dot("body", h.Body).
+			Dot("text", h.Text).
+			DotIf("attachData", h.AttachDataFlag).
+			DotNotEmpty("levels", args(h.LevelsList)...)
 	}
 
 	for _, h := range a.ExecHandlers {
-		n.DotRemoveZeroValue("exec", args(h.Command)...)
+		n.DotRemoveZeroValue("exec", args(h.Command)...).
+			DotNotEmpty("levels", args(h.LevelsList)...)
 	}
 
 	for _, h := range a.LogHandlers {
@@ -106,21 +153,25 @@ func (n *AlertNode) Build(a *pipeline.AlertNode) (ast.Node, error) {
 			}
 			n.Dot("mode", mode)
 		}
+		n.DotNotEmpty("levels", args(h.LevelsList)...)
 	}
 
 	for _, h := range a.VictorOpsHandlers {
 		n.Dot("victorOps").
-			Dot("routingKey", h.RoutingKey)
+			Dot("routingKey", h.RoutingKey).
+			DotNotEmpty("levels", args(h.LevelsList)...)
 	}
 
 	for _, h := range a.PagerDutyHandlers {
 		n.Dot("pagerDuty").
-			Dot("serviceKey", h.ServiceKey)
+			Dot("serviceKey", h.ServiceKey).
+			DotNotEmpty("levels", args(h.LevelsList)...)
 	}
 
 	for _, h := range a.PagerDuty2Handlers {
 		n.Dot("pagerDuty2").
-			Dot("serviceKey", h.ServiceKey)
+			Dot("serviceKey", h.ServiceKey).
+			DotNotEmpty("levels", args(h.LevelsList)...)
 	}
 
 	for _, h := range a.PushoverHandlers {
@@ -130,13 +181,15 @@ func (n *AlertNode) Build(a *pipeline.AlertNode) (ast.Node, error) {
 			Dot("title", h.Title).
 			Dot("uRL", h.URL).
 			Dot("uRLTitle", h.URLTitle).
-			Dot("sound", h.Sound)
+			Dot("sound", h.Sound).
+			DotNotEmpty("levels", args(h.LevelsList)...)
 	}
 
 	for _, h := range a.SensuHandlers {
 		n.Dot("sensu").
 			Dot("source", h.Source).
-			Dot("handlers", args(h.HandlersList)...)
+			Dot("handlers", args(h.HandlersList)...).
+			DotNotEmpty("levels", args(h.LevelsList)...)
 	}
 
 	for _, h := range a.SlackHandlers {
@@ -144,7 +197,8 @@ func (n *AlertNode) Build(a *pipeline.AlertNode) (ast.Node, error) {
 			Dot("workspace", h.Workspace).
 			Dot("channel", h.Channel).
 			Dot("username", h.Username).
-			Dot("iconEmoji", h.IconEmoji)
+			Dot("iconEmoji", h.IconEmoji).
+			DotNotEmpty("levels", args(h.LevelsList)...)
 	}
 
 	for _, h := range a.TelegramHandlers {
@@ -152,20 +206,28 @@ func (n *AlertNode) Build(a *pipeline.AlertNode) (ast.Node, error) {
 			Dot("chatId", h.ChatId).
 			Dot("parseMode", h.ParseMode).
 			DotIf("disableWebPagePreview", h.IsDisableWebPagePreview).
-			DotIf("disableNotification", h.IsDisableNotification)
+			DotIf("disableNotification", h.IsDisableNotification).
+			DotNotEmpty("levels", args(h.LevelsList)...)
 	}
 
 	for _, h := range a.HipChatHandlers {
 		n.Dot("hipChat").
 			Dot("room", h.Room).
-			Dot("token", h.Token)
+			Dot("token", h.Token).
+			DotNotEmpty("levels", args(h.LevelsList)...)
 	}
 
 	for _, h := range a.KafkaHandlers {
 		n.Dot("kafka").
 			Dot("cluster", h.Cluster).
 			Dot("kafkaTopic", h.KafkaTopic).
-			Dot("template", h.Template)
+			Dot("template", h.Template).
+			DotNotEmpty("levels", args(h.LevelsList)...)
+	}
+
+	for _, h := range a.OpenTelemetryHandlers {
+		n.Dot("openTelemetry").
+			DotNotEmpty("levels", args(h.LevelsList)...)
 	}
 
 	for _, h := range a.AlertaHandlers {
@@ -178,29 +240,34 @@ func (n *AlertNode) Build(a *pipeline.AlertNode) (ast.Node, error) {
 			Dot("value", h.Value).
 			Dot("origin", h.Origin).
 			Dot("services", args(h.Service)...).
-			Dot("timeout", h.Timeout)
+			Dot("timeout", h.Timeout).
+			DotNotEmpty("levels", args(h.LevelsList)...)
 	}
 
 	for _, h := range a.OpsGenieHandlers {
 		n.Dot("opsGenie").
 			Dot("teams", args(h.TeamsList)...).
-			Dot("recipients", args(h.RecipientsList)...)
+			Dot("recipients", args(h.RecipientsList)...).
+			DotNotEmpty("levels", args(h.LevelsList)...)
 	}
 	for _, h := range a.OpsGenie2Handlers {
 		n.Dot("opsGenie2").
 			Dot("teams", args(h.TeamsList)...).
-			Dot("recipients", args(h.RecipientsList)...)
+			Dot("recipients", args(h.RecipientsList)...).
+			DotNotEmpty("levels", args(h.LevelsList)...)
 	}
 
-	for _ = range a.TalkHandlers {
-		n.Dot("talk")
+	for _, h := range a.TalkHandlers {
+		n.Dot("talk").
+			DotNotEmpty("levels", args(h.LevelsList)...)
 	}
 
 	for _, h := range a.MQTTHandlers {
 		n.DotRemoveZeroValue("mqtt", h.Topic).
 			Dot("brokerName", h.BrokerName).
 			Dot("qos", h.Qos).
-			Dot("retained", h.Retained)
+			Dot("retained", h.Retained).
+			DotNotEmpty("levels", args(h.LevelsList)...)
 	}
 
 	for _, h := range a.SNMPTrapHandlers {
@@ -208,6 +275,7 @@ func (n *AlertNode) Build(a *pipeline.AlertNode) (ast.Node, error) {
 		for _, d := range h.DataList {
 			n.Dot("data", d.Oid, d.Type, d.Value)
 		}
+		n.DotNotEmpty("levels", args(h.LevelsList)...)
 	}
 
 	return n.prev, n.err
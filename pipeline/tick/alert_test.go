@@ -46,6 +46,8 @@ func TestAlert(t *testing.T) {
 	alert.DurationField = "1000000"
 	alert.IdTag = "idTag"
 	alert.IdField = "idField"
+	alert.AckTimeout = 30 * time.Minute
+	alert.SummaryInterval = 5 * time.Minute
 	alert.All().NoRecoveries().StateChangesOnly(time.Hour)
 	alert.Inhibitors = []pipeline.Inhibitor{{Category: "other", EqualTags: []string{"t1", "t2"}}}
 
@@ -69,6 +71,8 @@ func TestAlert(t *testing.T) {
         .durationField('1000000')
         .idTag('idTag')
         .idField('idField')
+        .ackTimeout(30m)
+        .summaryInterval(5m)
         .all()
         .noRecoveries()
         .inhibit('other', 't1', 't2')
@@ -240,6 +244,31 @@ func TestAlertEmail(t *testing.T) {
 	PipelineTickTestHelper(t, pipe, want)
 }
 
+func TestAlertEmailMultipart(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	handler := from.Alert().Email("oncall@example.com")
+	handler.ToTag = "owners"
+	handler.Body = "<h1>{{ .ID }}</h1>"
+	handler.Text = "{{ .ID }} is {{ .Level }}"
+	handler.AttachData()
+
+	want := `stream
+    |from()
+    |alert()
+        .id('{{ .Name }}:{{ .Group }}')
+        .message('{{ .ID }} is {{ .Level }}')
+        .details('{{ json . }}')
+        .history(21)
+        .email()
+        .to('oncall@example.com')
+        .toTag('owners')
+        .body('<h1>{{ .ID }}</h1>')
+        .text('{{ .ID }} is {{ .Level }}')
+        .attachData()
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
 func TestAlertExec(t *testing.T) {
 	pipe, _, from := StreamFrom()
 	from.Alert().Exec("send", "-watch", "-verbose") // maybe I should rewrite mh in go?
@@ -310,6 +339,46 @@ func TestAlertPagerDuty(t *testing.T) {
 	PipelineTickTestHelper(t, pipe, want)
 }
 
+func TestAlertHandlerConcurrency(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	alert := from.Alert().HandlerConcurrency("pagerDuty", 4, 100).HandlerConcurrency("slack", 2)
+	alert.PagerDuty()
+
+	want := `stream
+    |from()
+    |alert()
+        .id('{{ .Name }}:{{ .Group }}')
+        .message('{{ .ID }} is {{ .Level }}')
+        .details('{{ json . }}')
+        .history(21)
+        .handlerConcurrency('pagerDuty', 4, 100)
+        .handlerConcurrency('slack', 2, 1000)
+        .pagerDuty()
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
+func TestAlertHandlerLevels(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	alert := from.Alert()
+	alert.Slack().Levels("WARNING", "CRITICAL")
+	alert.PagerDuty().Levels("CRITICAL")
+
+	want := `stream
+    |from()
+    |alert()
+        .id('{{ .Name }}:{{ .Group }}')
+        .message('{{ .ID }} is {{ .Level }}')
+        .details('{{ json . }}')
+        .history(21)
+        .pagerDuty()
+        .levels('CRITICAL')
+        .slack()
+        .levels('WARNING', 'CRITICAL')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
 func TestAlertPagerDuty2(t *testing.T) {
 	pipe, _, from := StreamFrom()
 	handler := from.Alert().PagerDuty2()
@@ -555,6 +624,22 @@ func TestAlertTalk(t *testing.T) {
 	PipelineTickTestHelper(t, pipe, want)
 }
 
+func TestAlertOpenTelemetry(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	from.Alert().OpenTelemetry()
+
+	want := `stream
+    |from()
+    |alert()
+        .id('{{ .Name }}:{{ .Group }}')
+        .message('{{ .ID }} is {{ .Level }}')
+        .details('{{ json . }}')
+        .history(21)
+        .openTelemetry()
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
 func TestAlertMQTT(t *testing.T) {
 	pipe, _, from := StreamFrom()
 	handler := from.Alert().Mqtt("mattel")
@@ -617,3 +702,142 @@ func TestAlertSNMP(t *testing.T) {
 `
 	PipelineTickTestHelper(t, pipe, want)
 }
+
+func TestAlertDurableRetry(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	alert := from.Alert()
+	alert.DurableRetry(time.Hour, 10*time.Second)
+
+	want := `stream
+    |from()
+    |alert()
+        .id('{{ .Name }}:{{ .Group }}')
+        .message('{{ .ID }} is {{ .Level }}')
+        .details('{{ json . }}')
+        .history(21)
+        .durableRetry(1h, 10s)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
+func TestAlertPersistState(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	alert := from.Alert()
+	alert.PersistState()
+
+	want := `stream
+    |from()
+    |alert()
+        .id('{{ .Name }}:{{ .Group }}')
+        .message('{{ .ID }} is {{ .Level }}')
+        .details('{{ json . }}')
+        .history(21)
+        .persistState()
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
+func TestAlertIdTags(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	alert := from.Alert()
+	alert.IdTags("host", "region")
+
+	want := `stream
+    |from()
+    |alert()
+        .id('{{ .Name }}:{{ .Group }}')
+        .message('{{ .ID }} is {{ .Level }}')
+        .details('{{ json . }}')
+        .history(21)
+        .idTags('host', 'region')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
+func TestAlertValueHistory(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	alert := from.Alert()
+	alert.ValueHistory("value", 10)
+
+	want := `stream
+    |from()
+    |alert()
+        .id('{{ .Name }}:{{ .Group }}')
+        .message('{{ .ID }} is {{ .Level }}')
+        .details('{{ json . }}')
+        .history(21)
+        .valueHistory('value', 10)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
+func TestAlertRoc(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	alert := from.Alert()
+	alert.Roc("value", 10*time.Minute)
+
+	want := `stream
+    |from()
+    |alert()
+        .id('{{ .Name }}:{{ .Group }}')
+        .message('{{ .ID }} is {{ .Level }}')
+        .details('{{ json . }}')
+        .history(21)
+        .roc('value', 10m)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
+func TestAlertBatchContext(t *testing.T) {
+	pipe, _, query := BatchQuery("select cpu_usage from cpu")
+	alert := query.Alert()
+	alert.BatchContext("cpu_usage", 90)
+
+	want := `batch
+    |query('select cpu_usage from cpu')
+    |alert()
+        .id('{{ .Name }}:{{ .Group }}')
+        .message('{{ .ID }} is {{ .Level }}')
+        .details('{{ json . }}')
+        .history(21)
+        .batchContext('cpu_usage', 90.0)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
+func TestAlertRollingPercentiles(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	alert := from.Alert()
+	alert.RollingPercentiles("latency", time.Hour, 95, 99.9)
+	alert.Percentiles("latency", 50)
+
+	want := `stream
+    |from()
+    |alert()
+        .id('{{ .Name }}:{{ .Group }}')
+        .message('{{ .ID }} is {{ .Level }}')
+        .details('{{ json . }}')
+        .history(21)
+        .rollingPercentiles('latency', 1h, 95.0, 99.9)
+    |percentiles('latency', 50.0)
+        .as('p')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
+func TestAlertRocPreviousPoint(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	alert := from.Alert()
+	alert.Roc("value")
+
+	want := `stream
+    |from()
+    |alert()
+        .id('{{ .Name }}:{{ .Group }}')
+        .message('{{ .ID }} is {{ .Level }}')
+        .details('{{ json . }}')
+        .history(21)
+        .roc('value')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -40,12 +40,113 @@ func (a *AST) Build(p *pipeline.Pipeline) error {
 			return nil
 		}
 
+		function, err = a.reorder(node, function)
+		if err != nil {
+			a.err = err
+			return err
+		}
+
+		function, err = a.deadLetter(node, function)
+		if err != nil {
+			a.err = err
+			return err
+		}
+
+		function, err = a.backpressure(node, function)
+		if err != nil {
+			a.err = err
+			return err
+		}
+
+		function, err = a.assertOrder(node, function)
+		if err != nil {
+			a.err = err
+			return err
+		}
+
+		function, err = a.panicLimit(node, function)
+		if err != nil {
+			a.err = err
+			return err
+		}
+
+		function, err = a.workers(node, function)
+		if err != nil {
+			a.err = err
+			return err
+		}
+
 		a.Link(node, function)
 		return nil
 	})
 	return a.err
 }
 
+// reorder adds the reorder property shared by all nodes to the function of the node.
+func (a *AST) reorder(node pipeline.Node, function ast.Node) (ast.Node, error) {
+	lateness, size := node.ReorderWindow()
+	if lateness == 0 {
+		return function, nil
+	}
+	f := &Function{prev: function}
+	f.Dot("reorder", lateness, size)
+	return f.prev, f.err
+}
+
+// deadLetter adds the deadLetter property shared by all nodes to the function of the node.
+func (a *AST) deadLetter(node pipeline.Node, function ast.Node) (ast.Node, error) {
+	sink, target := node.DeadLetterQueue()
+	if sink == "" {
+		return function, nil
+	}
+	f := &Function{prev: function}
+	f.Dot("deadLetter", sink, target)
+	return f.prev, f.err
+}
+
+// backpressure adds the backpressure property shared by all nodes to the function of the node.
+func (a *AST) backpressure(node pipeline.Node, function ast.Node) (ast.Node, error) {
+	policy := node.InputBackpressure()
+	if policy == "" {
+		return function, nil
+	}
+	f := &Function{prev: function}
+	f.Dot("backpressure", policy)
+	return f.prev, f.err
+}
+
+// assertOrder adds the assertOrder property shared by all nodes to the function of the node.
+func (a *AST) assertOrder(node pipeline.Node, function ast.Node) (ast.Node, error) {
+	if !node.IsOrderAsserted() {
+		return function, nil
+	}
+	f := &Function{prev: function}
+	f.Dot("assertOrder")
+	return f.prev, f.err
+}
+
+// panicLimit adds the panicLimit property shared by all nodes to the function of the node.
+func (a *AST) panicLimit(node pipeline.Node, function ast.Node) (ast.Node, error) {
+	count, window := node.PanicThreshold()
+	if count == 0 {
+		return function, nil
+	}
+	f := &Function{prev: function}
+	f.Dot("panicLimit", count, window)
+	return f.prev, f.err
+}
+
+// workers adds the workers property shared by all nodes to the function of the node.
+func (a *AST) workers(node pipeline.Node, function ast.Node) (ast.Node, error) {
+	count := node.WorkerPool()
+	if count == 0 {
+		return function, nil
+	}
+	f := &Function{prev: function}
+	f.Dot("workers", count)
+	return f.prev, f.err
+}
+
 // Link inspects the pipeline node to determine if it
 // should become a variable, or, be considered "complete."
 func (a *AST) Link(node pipeline.Node, function ast.Node) {
@@ -96,6 +197,16 @@ func (a *AST) Create(n pipeline.Node, parents []ast.Node) (ast.Node, error) {
 		return NewDefault(parents).Build(node)
 	case *pipeline.DeleteNode:
 		return NewDelete(parents).Build(node)
+	case *pipeline.DropTagsNode:
+		return NewDropTags(parents).Build(node)
+	case *pipeline.DropFieldsNode:
+		return NewDropFields(parents).Build(node)
+	case *pipeline.FieldToTagNode:
+		return NewFieldToTag(parents).Build(node)
+	case *pipeline.TagToFieldNode:
+		return NewTagToField(parents).Build(node)
+	case *pipeline.DeriveTagNode:
+		return NewDeriveTag(parents).Build(node)
 	case *pipeline.DerivativeNode:
 		return NewDerivative(parents).Build(node)
 	case *pipeline.ChangeDetectNode:
@@ -124,6 +235,96 @@ func (a *AST) Create(n pipeline.Node, parents []ast.Node) (ast.Node, error) {
 		return NewKapacitorLoopbackNode(parents).Build(node)
 	case *pipeline.LogNode:
 		return NewLog(parents).Build(node)
+	case *pipeline.PercentilesNode:
+		return NewPercentiles(parents).Build(node)
+	case *pipeline.StreamReplayNode:
+		return NewStreamReplay(parents).Build(node)
+	case *pipeline.StreamCompactNode:
+		return NewStreamCompact(parents).Build(node)
+	case *pipeline.SessionizeNode:
+		return NewSessionize(parents).Build(node)
+	case *pipeline.QuantizeNode:
+		return NewQuantize(parents).Build(node)
+	case *pipeline.ValidateTimeNode:
+		return NewValidateTime(parents).Build(node)
+	case *pipeline.MaxDelayNode:
+		return NewMaxDelay(parents).Build(node)
+	case *pipeline.MovingAverageNode:
+		return NewMovingAverage(parents).Build(node)
+	case *pipeline.CorrelateNode:
+		return NewCorrelate(parents).Build(node)
+	case *pipeline.DiffNode:
+		return NewDiff(parents).Build(node)
+	case *pipeline.ScheduleNode:
+		return NewSchedule(parents).Build(node)
+	case *pipeline.NormalizeNode:
+		return NewNormalize(parents).Build(node)
+	case *pipeline.RetractNode:
+		return NewRetract(parents).Build(node)
+	case *pipeline.NatsOutNode:
+		return NewNatsOut(parents).Build(node)
+	case *pipeline.TrendNode:
+		return NewTrend(parents).Build(node)
+	case *pipeline.EncryptNode:
+		return NewEncrypt(parents).Build(node)
+	case *pipeline.DecryptNode:
+		return NewDecrypt(parents).Build(node)
+	case *pipeline.EncodeBase64Node:
+		return NewEncodeBase64(parents).Build(node)
+	case *pipeline.DecodeBase64Node:
+		return NewDecodeBase64(parents).Build(node)
+	case *pipeline.BackfillNode:
+		return NewBackfill(parents).Build(node)
+	case *pipeline.CardinalityLimitNode:
+		return NewCardinalityLimit(parents).Build(node)
+	case *pipeline.WarmupNode:
+		return NewWarmup(parents).Build(node)
+	case *pipeline.ThresholdLearnNode:
+		return NewThresholdLearn(parents).Build(node)
+	case *pipeline.DecimalsNode:
+		return NewDecimals(parents).Build(node)
+	case *pipeline.JSONExtractNode:
+		return NewJSONExtract(parents).Build(node)
+	case *pipeline.BatchSizeLimitNode:
+		return NewBatchSizeLimit(parents).Build(node)
+	case *pipeline.SanitizeNode:
+		return NewSanitize(parents).Build(node)
+	case *pipeline.CountDistinctNode:
+		return NewCountDistinct(parents).Build(node)
+	case *pipeline.BloomFilterNode:
+		return NewBloomFilter(parents).Build(node)
+	case *pipeline.ConcatFieldsNode:
+		return NewConcatFields(parents).Build(node)
+	case *pipeline.LagCompensateNode:
+		return NewLagCompensate(parents).Build(node)
+	case *pipeline.ClampNode:
+		return NewClamp(parents).Build(node)
+	case *pipeline.TimeInStateNode:
+		return NewTimeInState(parents).Build(node)
+	case *pipeline.MaskNode:
+		return NewMask(parents).Build(node)
+	case *pipeline.CoalesceNode:
+		return NewCoalesce(parents).Build(node)
+	case *pipeline.ReverseDNSNode:
+		return NewReverseDNS(parents).Build(node)
+	case *pipeline.DurationFormatNode:
+		return NewDurationFormat(parents).Build(node)
+	case *pipeline.TagValueMapNode:
+		return NewTagValueMap(parents).Build(node)
+	case *pipeline.AvroOutNode:
+		return NewAvroOut(parents).Build(node)
+	case *pipeline.DeadbandNode:
+		return NewDeadband(parents).Build(node)
+	case *pipeline.UpDownCounterNode:
+		return NewUpDownCounter(parents).Build(node)
+	case *pipeline.ValidateSchemaNode:
+		return NewValidateSchema(parents).Build(node)
+	case *pipeline.MultiWindowNode:
+		return NewMultiWindow(parents).Build(node)
+	case *pipeline.RegexReplaceNode:
+		return NewRegexReplace(parents).Build(node)
+	case *pipeline.HeartbeatNode:
+		return NewHeartbeat(parents).Build(node)
 	case *pipeline.QueryNode:
 		return NewQuery(parents).Build(node)
 	case *pipeline.SampleNode:
@@ -132,10 +333,16 @@ func (a *AST) Create(n pipeline.Node, parents []ast.Node) (ast.Node, error) {
 		return NewShift(parents).Build(node)
 	case *pipeline.SideloadNode:
 		return NewSideload(parents).Build(node)
+	case *pipeline.SplitNode:
+		return NewSplit(parents).Build(node)
+	case *pipeline.SplitBranchNode:
+		return NewSplitBranch(parents).Build(node)
 	case *pipeline.StateCountNode:
 		return NewStateCount(parents).Build(node)
 	case *pipeline.StateDurationNode:
 		return NewStateDuration(parents).Build(node)
+	case *pipeline.StateMachineNode:
+		return NewStateMachine(parents).Build(node)
 	case *pipeline.SwarmAutoscaleNode:
 		return NewSwarmAutoscale(parents).Build(node)
 	case *pipeline.UDFNode:
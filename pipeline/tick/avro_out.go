@@ -0,0 +1,31 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// AvroOutNode converts the AvroOut pipeline node into the TICKScript AST
+type AvroOutNode struct {
+	Function
+}
+
+// NewAvroOut creates an AvroOut function builder
+func NewAvroOut(parents []ast.Node) *AvroOutNode {
+	return &AvroOutNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates an AvroOut ast.Node
+func (n *AvroOutNode) Build(o *pipeline.AvroOutNode) (ast.Node, error) {
+	n.Pipe("avroOut", o.Topic).
+		Dot("cluster", o.Cluster).
+		Dot("registry", o.RegistryURL).
+		Dot("subject", o.Subject).
+		Dot("schema", o.Schema).
+		Dot("timeout", o.Timeout)
+	return n.prev, n.err
+}
@@ -0,0 +1,27 @@
+package tick_test
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAvroOut(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	o := from.AvroOut("metrics")
+	o.Cluster = "datalake"
+	o.Registry("http://registry.example.com:8081")
+	o.Subject = "cpu-value"
+	o.Schema = `{"type":"record","name":"cpu","fields":[{"name":"value","type":"double"}]}`
+	o.Timeout = 10 * time.Second
+
+	want := `stream
+    |from()
+    |avroOut('metrics')
+        .cluster('datalake')
+        .registry('http://registry.example.com:8081')
+        .subject('cpu-value')
+        .schema('{"type":"record","name":"cpu","fields":[{"name":"value","type":"double"}]}')
+        .timeout(10s)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
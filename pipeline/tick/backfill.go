@@ -0,0 +1,28 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// BackfillNode converts the Backfill pipeline node into the TICKScript AST
+type BackfillNode struct {
+	Function
+}
+
+// NewBackfill creates a Backfill function builder
+func NewBackfill(parents []ast.Node) *BackfillNode {
+	return &BackfillNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a Backfill ast.Node
+func (n *BackfillNode) Build(b *pipeline.BackfillNode) (ast.Node, error) {
+	n.Pipe("backfill", b.QueryStr).
+		Dot("lookback", b.Lookback).
+		Dot("cluster", b.Cluster)
+	return n.prev, n.err
+}
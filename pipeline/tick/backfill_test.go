@@ -0,0 +1,21 @@
+package tick_test
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackfill(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	b := from.Backfill(`SELECT value FROM "db"."rp"."cpu"`)
+	b.Lookback = 6 * time.Hour
+	b.Cluster = "mycluster"
+
+	want := `stream
+    |from()
+    |backfill('SELECT value FROM "db"."rp"."cpu"')
+        .lookback(6h)
+        .cluster('mycluster')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -23,6 +23,11 @@ func NewBarrierNode(parents []ast.Node) *BarrierNode {
 func (n *BarrierNode) Build(b *pipeline.BarrierNode) (ast.Node, error) {
 	n.Pipe("barrier").
 		Dot("idle", b.Idle).
-		Dot("period", b.Period)
+		Dot("period", b.Period).
+		DotIf("skipEmpty", b.SkipEmptyFlag).
+		DotIf("barrierOutput", b.BarrierOutputFlag).
+		DotIf("sharedTimer", b.SharedTimerFlag).
+		DotIf("markers", b.MarkersFlag).
+		DotIf("emitImmediately", b.EmitImmediatelyFlag)
 	return n.prev, n.err
 }
@@ -9,8 +9,12 @@ import (
 
 func TestBarrierNode(t *testing.T) {
 	type args struct {
-		idle   time.Duration
-		period time.Duration
+		idle        time.Duration
+		period      time.Duration
+		skipEmpty   bool
+		sharedTimer bool
+		markers     bool
+		immediate   bool
 	}
 	tests := []struct {
 		name string
@@ -37,6 +41,58 @@ func TestBarrierNode(t *testing.T) {
     |from()
     |barrier()
         .period(1s)
+`,
+		},
+		{
+			name: "barrier with period and skip empty",
+			args: args{
+				period:    time.Second,
+				skipEmpty: true,
+			},
+			want: `stream
+    |from()
+    |barrier()
+        .period(1s)
+        .skipEmpty()
+`,
+		},
+		{
+			name: "barrier with idle and shared timer",
+			args: args{
+				idle:        time.Second,
+				sharedTimer: true,
+			},
+			want: `stream
+    |from()
+    |barrier()
+        .idle(1s)
+        .sharedTimer()
+`,
+		},
+		{
+			name: "barrier with period and markers",
+			args: args{
+				period:  time.Second,
+				markers: true,
+			},
+			want: `stream
+    |from()
+    |barrier()
+        .period(1s)
+        .markers()
+`,
+		},
+		{
+			name: "barrier with period and emit immediately",
+			args: args{
+				period:    time.Second,
+				immediate: true,
+			},
+			want: `stream
+    |from()
+    |barrier()
+        .period(1s)
+        .emitImmediately()
 `,
 		},
 	}
@@ -48,6 +104,10 @@ func TestBarrierNode(t *testing.T) {
 			b := stream.From().Barrier()
 			b.Idle = tt.args.idle
 			b.Period = tt.args.period
+			b.SkipEmptyFlag = tt.args.skipEmpty
+			b.SharedTimerFlag = tt.args.sharedTimer
+			b.MarkersFlag = tt.args.markers
+			b.EmitImmediatelyFlag = tt.args.immediate
 
 			got, err := PipelineTick(pipe)
 			if err != nil {
@@ -59,3 +119,31 @@ func TestBarrierNode(t *testing.T) {
 		})
 	}
 }
+
+func TestBarrierOutput(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	b := from.Barrier()
+	b.Period = time.Second
+	b.BarrierOutput()
+	w := b.Window()
+	w.Period = time.Minute
+	w.Every = time.Minute
+	b.Branch("barrier").HttpOut("clock")
+
+	want := `var barrier2 = stream
+    |from()
+    |barrier()
+        .period(1s)
+        .barrierOutput()
+
+barrier2
+    |branch('barrier')
+    |httpOut('clock')
+
+barrier2
+    |window()
+        .period(1m)
+        .every(1m)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -0,0 +1,48 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// EncodeBase64Node converts the EncodeBase64 pipeline node into the TICKScript AST
+type EncodeBase64Node struct {
+	Function
+}
+
+// NewEncodeBase64 creates an EncodeBase64 function builder
+func NewEncodeBase64(parents []ast.Node) *EncodeBase64Node {
+	return &EncodeBase64Node{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates an EncodeBase64 ast.Node
+func (n *EncodeBase64Node) Build(e *pipeline.EncodeBase64Node) (ast.Node, error) {
+	n.Pipe("encodeBase64", e.Field).
+		Dot("as", e.As)
+	return n.prev, n.err
+}
+
+// DecodeBase64Node converts the DecodeBase64 pipeline node into the TICKScript AST
+type DecodeBase64Node struct {
+	Function
+}
+
+// NewDecodeBase64 creates a DecodeBase64 function builder
+func NewDecodeBase64(parents []ast.Node) *DecodeBase64Node {
+	return &DecodeBase64Node{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a DecodeBase64 ast.Node
+func (n *DecodeBase64Node) Build(d *pipeline.DecodeBase64Node) (ast.Node, error) {
+	n.Pipe("decodeBase64", d.Field).
+		Dot("as", d.As)
+	return n.prev, n.err
+}
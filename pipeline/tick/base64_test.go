@@ -0,0 +1,30 @@
+package tick_test
+
+import (
+	"testing"
+)
+
+func TestEncodeBase64(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	e := from.EncodeBase64("payload")
+	e.As = "payload_b64"
+
+	want := `stream
+    |from()
+    |encodeBase64('payload')
+        .as('payload_b64')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
+func TestDecodeBase64(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	from.DecodeBase64("payload")
+
+	want := `stream
+    |from()
+    |decodeBase64('payload')
+        .as('payload')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -0,0 +1,27 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// BatchSizeLimitNode converts the BatchSizeLimit pipeline node into the TICKScript AST
+type BatchSizeLimitNode struct {
+	Function
+}
+
+// NewBatchSizeLimit creates a BatchSizeLimit function builder
+func NewBatchSizeLimit(parents []ast.Node) *BatchSizeLimitNode {
+	return &BatchSizeLimitNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a BatchSizeLimit ast.Node
+func (n *BatchSizeLimitNode) Build(b *pipeline.BatchSizeLimitNode) (ast.Node, error) {
+	n.Pipe("batchSizeLimit").
+		Dot("maxPoints", b.MaxPoints)
+	return n.prev, n.err
+}
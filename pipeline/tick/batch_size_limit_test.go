@@ -0,0 +1,18 @@
+package tick_test
+
+import (
+	"testing"
+)
+
+func TestBatchSizeLimit(t *testing.T) {
+	pipe, _, query := BatchQuery("select cpu_usage from cpu")
+	b := query.BatchSizeLimit()
+	b.MaxPoints = 1000
+
+	want := `batch
+    |query('select cpu_usage from cpu')
+    |batchSizeLimit()
+        .maxPoints(1000)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -0,0 +1,31 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// BloomFilterNode converts the BloomFilter pipeline node into the TICKScript AST
+type BloomFilterNode struct {
+	Function
+}
+
+// NewBloomFilter creates a BloomFilter function builder
+func NewBloomFilter(parents []ast.Node) *BloomFilterNode {
+	return &BloomFilterNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a BloomFilter ast.Node
+func (n *BloomFilterNode) Build(b *pipeline.BloomFilterNode) (ast.Node, error) {
+	n.Pipe("bloomFilter", b.Field).
+		Dot("as", b.As).
+		Dot("expected", b.Expected).
+		Dot("falsePositiveRate", b.FalsePositiveRate).
+		Dot("rotate", b.Rotate).
+		DotIf("global", b.GlobalFlag)
+	return n.prev, n.err
+}
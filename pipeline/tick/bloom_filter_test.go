@@ -0,0 +1,27 @@
+package tick_test
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBloomFilter(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	b := from.BloomFilter("user")
+	b.As = "returning"
+	b.Expected = 1000
+	b.FalsePositiveRate = 0.001
+	b.Rotate = 24 * time.Hour
+	b.Global()
+
+	want := `stream
+    |from()
+    |bloomFilter('user')
+        .as('returning')
+        .expected(1000)
+        .falsePositiveRate(0.001)
+        .rotate(1d)
+        .global()
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
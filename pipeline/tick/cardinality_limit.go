@@ -0,0 +1,29 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// CardinalityLimitNode converts the CardinalityLimit pipeline node into the TICKScript AST
+type CardinalityLimitNode struct {
+	Function
+}
+
+// NewCardinalityLimit creates a CardinalityLimit function builder
+func NewCardinalityLimit(parents []ast.Node) *CardinalityLimitNode {
+	return &CardinalityLimitNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a CardinalityLimit ast.Node
+func (n *CardinalityLimitNode) Build(c *pipeline.CardinalityLimitNode) (ast.Node, error) {
+	n.Pipe("cardinalityLimit").
+		Dot("maxCardinality", c.MaxCardinality).
+		Dot("window", c.Window).
+		DotIf("topTag", c.TopTagFlag)
+	return n.prev, n.err
+}
@@ -0,0 +1,23 @@
+package tick_test
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCardinalityLimit(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	c := from.CardinalityLimit()
+	c.MaxCardinality = 1000
+	c.Window = 5 * time.Minute
+	c.TopTag()
+
+	want := `stream
+    |from()
+    |cardinalityLimit()
+        .maxCardinality(1000)
+        .window(5m)
+        .topTag()
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -0,0 +1,37 @@
+package tick
+
+import (
+	"sort"
+
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// ClampNode converts the Clamp pipeline node into the TICKScript AST
+type ClampNode struct {
+	Function
+}
+
+// NewClamp creates a Clamp function builder
+func NewClamp(parents []ast.Node) *ClampNode {
+	return &ClampNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a Clamp ast.Node
+func (n *ClampNode) Build(c *pipeline.ClampNode) (ast.Node, error) {
+	n.Pipe("clamp")
+	var fields []string
+	for k := range c.Ranges {
+		fields = append(fields, k)
+	}
+	sort.Strings(fields)
+	for _, k := range fields {
+		n.DotZeroValueOK("field", k, c.Ranges[k].Min, c.Ranges[k].Max)
+	}
+	n.Dot("action", c.Action)
+	return n.prev, n.err
+}
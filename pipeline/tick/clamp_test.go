@@ -0,0 +1,22 @@
+package tick_test
+
+import (
+	"testing"
+)
+
+func TestClamp(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	c := from.Clamp()
+	c.Field("temperature", -40, 85.5)
+	c.Field("humidity", 0, 100)
+	c.Action = "drop"
+
+	want := `stream
+    |from()
+    |clamp()
+        .field('humidity', 0.0, 100.0)
+        .field('temperature', -40.0, 85.5)
+        .action('drop')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -0,0 +1,32 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// CoalesceNode converts the Coalesce pipeline node into the TICKScript AST
+type CoalesceNode struct {
+	Function
+}
+
+// NewCoalesce creates a Coalesce function builder
+func NewCoalesce(parents []ast.Node) *CoalesceNode {
+	return &CoalesceNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a Coalesce ast.Node
+func (n *CoalesceNode) Build(c *pipeline.CoalesceNode) (ast.Node, error) {
+	pipeArgs := append([]interface{}{c.As}, args(c.Fields)...)
+	n.Pipe("coalesce", pipeArgs...).
+		Dot("type", c.FieldType)
+	if c.DefaultValue != nil {
+		n.DotZeroValueOK("default", c.DefaultValue)
+	}
+	n.DotIf("dropMissing", c.DropMissingFlag)
+	return n.prev, n.err
+}
@@ -0,0 +1,33 @@
+package tick_test
+
+import (
+	"testing"
+)
+
+func TestCoalesce(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	from.Coalesce("temperature", "temp_c", "temperature_v2").
+		Type("float").
+		Default(0.0)
+
+	want := `stream
+    |from()
+    |coalesce('temperature', 'temp_c', 'temperature_v2')
+        .type('float')
+        .default(0.0)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
+func TestCoalesceDropMissing(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	from.Coalesce("host", "hostname", "host_name").
+		DropMissing()
+
+	want := `stream
+    |from()
+    |coalesce('host', 'hostname', 'host_name')
+        .dropMissing()
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -0,0 +1,40 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// ConcatFieldsNode converts the ConcatFields pipeline node into the TICKScript AST
+type ConcatFieldsNode struct {
+	Function
+}
+
+// NewConcatFields creates a ConcatFields function builder
+func NewConcatFields(parents []ast.Node) *ConcatFieldsNode {
+	return &ConcatFieldsNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a ConcatFields ast.Node
+func (n *ConcatFieldsNode) Build(c *pipeline.ConcatFieldsNode) (ast.Node, error) {
+	n.Pipe("concatFields")
+	for _, concat := range c.Concats {
+		a := []interface{}{concat.As, concat.Separator}
+		for _, p := range concat.Parts {
+			if p.Reference {
+				a = append(a, &ast.ReferenceNode{Reference: p.Value})
+			} else {
+				a = append(a, p.Value)
+			}
+		}
+		// An empty separator or literal is not omitted.
+		n.DotZeroValueOK("concat", a...)
+	}
+	n.Dot("missing", c.Missing).
+		DotZeroValueOK("precision", c.Precision)
+	return n.prev, n.err
+}
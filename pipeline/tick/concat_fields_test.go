@@ -0,0 +1,25 @@
+package tick_test
+
+import (
+	"testing"
+
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+func TestConcatFields(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	c := from.ConcatFields()
+	c.Concat("key", "/", &ast.ReferenceNode{Reference: "region"}, &ast.ReferenceNode{Reference: "host"}, "requests")
+	c.Concat("label", "", "", &ast.ReferenceNode{Reference: "status"})
+	c.Missing = "unknown"
+
+	want := `stream
+    |from()
+    |concatFields()
+        .concat('key', '/', "region", "host", 'requests')
+        .concat('label', '', '', "status")
+        .missing('unknown')
+        .precision(-1)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -0,0 +1,28 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// CorrelateNode converts the Correlate pipeline node into the TICKScript AST
+type CorrelateNode struct {
+	Function
+}
+
+// NewCorrelate creates a Correlate function builder
+func NewCorrelate(parents []ast.Node) *CorrelateNode {
+	return &CorrelateNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a Correlate ast.Node
+func (n *CorrelateNode) Build(c *pipeline.CorrelateNode) (ast.Node, error) {
+	n.Pipe("correlate", c.X, c.Y, c.Size).
+		Dot("as", c.As).
+		DotIf("perWindow", c.PerWindowFlag)
+	return n.prev, n.err
+}
@@ -0,0 +1,20 @@
+package tick_test
+
+import (
+	"testing"
+)
+
+func TestCorrelate(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	c := from.Correlate("latency", "depth", 100)
+	c.As = "r"
+	c.PerWindow()
+
+	want := `stream
+    |from()
+    |correlate('latency', 'depth', 100)
+        .as('r')
+        .perWindow()
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -0,0 +1,29 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// CountDistinctNode converts the CountDistinct pipeline node into the TICKScript AST
+type CountDistinctNode struct {
+	Function
+}
+
+// NewCountDistinct creates a CountDistinct function builder
+func NewCountDistinct(parents []ast.Node) *CountDistinctNode {
+	return &CountDistinctNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a CountDistinct ast.Node
+func (n *CountDistinctNode) Build(c *pipeline.CountDistinctNode) (ast.Node, error) {
+	n.Pipe("countDistinct", c.Field).
+		Dot("as", c.As).
+		Dot("precision", c.Precision).
+		Dot("interval", c.Interval)
+	return n.prev, n.err
+}
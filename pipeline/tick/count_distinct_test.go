@@ -0,0 +1,23 @@
+package tick_test
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCountDistinct(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	c := from.CountDistinct("user")
+	c.As = "users"
+	c.Precision = 12
+	c.Interval = time.Minute
+
+	want := `stream
+    |from()
+    |countDistinct('user')
+        .as('users')
+        .precision(12)
+        .interval(1m)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
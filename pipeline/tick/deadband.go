@@ -0,0 +1,28 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// DeadbandNode converts the Deadband pipeline node into the TICKScript AST
+type DeadbandNode struct {
+	Function
+}
+
+// NewDeadband creates a Deadband function builder
+func NewDeadband(parents []ast.Node) *DeadbandNode {
+	return &DeadbandNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a Deadband ast.Node
+func (n *DeadbandNode) Build(d *pipeline.DeadbandNode) (ast.Node, error) {
+	n.Pipe("deadband", d.Field).
+		Dot("absolute", d.Absolute).
+		Dot("percent", d.Percent)
+	return n.prev, n.err
+}
@@ -0,0 +1,31 @@
+package tick_test
+
+import (
+	"testing"
+)
+
+func TestDeadband(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	d := from.Deadband("position")
+	d.Absolute = 0.5
+
+	want := `stream
+    |from()
+    |deadband('position')
+        .absolute(0.5)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
+func TestDeadbandPercent(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	d := from.Deadband("rate")
+	d.Percent = 2
+
+	want := `stream
+    |from()
+    |deadband('rate')
+        .percent(2.0)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -0,0 +1,46 @@
+package tick
+
+import (
+	"sort"
+
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// DecimalsNode converts the Decimals pipeline node into the TICKScript AST
+type DecimalsNode struct {
+	Function
+}
+
+// NewDecimals creates a Decimals function builder
+func NewDecimals(parents []ast.Node) *DecimalsNode {
+	return &DecimalsNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a Decimals ast.Node
+func (n *DecimalsNode) Build(d *pipeline.DecimalsNode) (ast.Node, error) {
+	n.Pipe("decimals")
+	var roundKeys []string
+	for k := range d.RoundFields {
+		roundKeys = append(roundKeys, k)
+	}
+	sort.Strings(roundKeys)
+	for _, k := range roundKeys {
+		n.Dot("round", k, d.RoundFields[k])
+	}
+
+	var truncateKeys []string
+	for k := range d.TruncateFields {
+		truncateKeys = append(truncateKeys, k)
+	}
+	sort.Strings(truncateKeys)
+	for _, k := range truncateKeys {
+		n.Dot("truncate", k, d.TruncateFields[k])
+	}
+	n.DotIf("halfUp", d.HalfUpFlag)
+	return n.prev, n.err
+}
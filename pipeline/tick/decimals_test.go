@@ -0,0 +1,24 @@
+package tick_test
+
+import (
+	"testing"
+)
+
+func TestDecimals(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	from.Decimals().
+		Round("usage_user", 1).
+		Round("usage_idle", 2).
+		Truncate("load", 1).
+		HalfUp()
+
+	want := `stream
+    |from()
+    |decimals()
+        .round('usage_idle', 2)
+        .round('usage_user', 1)
+        .truncate('load', 1)
+        .halfUp()
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
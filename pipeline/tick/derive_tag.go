@@ -0,0 +1,40 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// DeriveTagNode converts the DeriveTag pipeline node into the TICKScript AST
+type DeriveTagNode struct {
+	Function
+}
+
+// NewDeriveTag creates a DeriveTag function builder
+func NewDeriveTag(parents []ast.Node) *DeriveTagNode {
+	return &DeriveTagNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a DeriveTag ast.Node
+func (n *DeriveTagNode) Build(d *pipeline.DeriveTagNode) (ast.Node, error) {
+	n.Pipe("deriveTag", d.Tag)
+	if d.FromTag != "" {
+		n.Dot("fromTag", d.FromTag)
+	}
+	if d.FromField != "" {
+		n.Dot("fromField", d.FromField)
+	}
+	if d.Pattern != nil {
+		n.Dot("regex", regex(d.Pattern))
+	}
+	n.Dot("capture", d.Capture)
+	if d.Missing != "" {
+		n.Dot("missing", d.Missing)
+	}
+	n.DotIf("regroup", d.RegroupFlag)
+	return n.prev, n.err
+}
@@ -0,0 +1,43 @@
+package tick_test
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestDeriveTag(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	d := from.DeriveTag("env")
+	d.FromTag = "host"
+	d.Regex(regexp.MustCompile(`^(?P<env>[a-z]+)/`))
+	d.Missing = "unknown"
+	d.Regroup()
+
+	want := `stream
+    |from()
+    |deriveTag('env')
+        .fromTag('host')
+        .regex(/^(?P<env>[a-z]+)\//)
+        .capture('env')
+        .missing('unknown')
+        .regroup()
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
+func TestDeriveTag_FromField(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	d := from.DeriveTag("dc")
+	d.FromField = "hostname"
+	d.Regex(regexp.MustCompile(`\.(?P<datacenter>\w+)$`))
+	d.Capture = "datacenter"
+
+	want := `stream
+    |from()
+    |deriveTag('dc')
+        .fromField('hostname')
+        .regex(/\.(?P<datacenter>\w+)$/)
+        .capture('datacenter')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -0,0 +1,32 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// DiffNode converts the Diff pipeline node into the TICKScript AST
+type DiffNode struct {
+	Function
+}
+
+// NewDiff creates a Diff function builder
+func NewDiff(parents []ast.Node) *DiffNode {
+	return &DiffNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a Diff ast.Node
+func (n *DiffNode) Build(d *pipeline.DiffNode) (ast.Node, error) {
+	n.Pipe("diff", d.Field, d.Baseline).
+		Dot("differenceAs", d.DifferenceAs).
+		Dot("absoluteAs", d.AbsoluteAs).
+		Dot("percentAs", d.PercentAs)
+	if d.UseZeroBaseline {
+		n.DotZeroValueOK("zeroBaseline", d.ZeroBaselineValue)
+	}
+	return n.prev, n.err
+}
@@ -0,0 +1,22 @@
+package tick_test
+
+import (
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	d := from.Diff("today.value", "last_week.value")
+	d.PercentAs = "change"
+	d.ZeroBaseline(0)
+
+	want := `stream
+    |from()
+    |diff('today.value', 'last_week.value')
+        .differenceAs('difference')
+        .absoluteAs('abs_difference')
+        .percentAs('change')
+        .zeroBaseline(0.0)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -0,0 +1,53 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// DropTagsNode converts the DropTags pipeline node into the TICKScript AST
+type DropTagsNode struct {
+	Function
+}
+
+// NewDropTags creates a DropTags function builder
+func NewDropTags(parents []ast.Node) *DropTagsNode {
+	return &DropTagsNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a DropTags ast.Node
+func (n *DropTagsNode) Build(d *pipeline.DropTagsNode) (ast.Node, error) {
+	n.Pipe("dropTags", args(d.Tags)...)
+	for _, r := range d.Regexes {
+		n.Dot("regex", regex(r))
+	}
+	n.DotIf("regroup", d.RegroupFlag)
+	return n.prev, n.err
+}
+
+// DropFieldsNode converts the DropFields pipeline node into the TICKScript AST
+type DropFieldsNode struct {
+	Function
+}
+
+// NewDropFields creates a DropFields function builder
+func NewDropFields(parents []ast.Node) *DropFieldsNode {
+	return &DropFieldsNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a DropFields ast.Node
+func (n *DropFieldsNode) Build(d *pipeline.DropFieldsNode) (ast.Node, error) {
+	n.Pipe("dropFields", args(d.Fields)...)
+	for _, r := range d.Regexes {
+		n.Dot("regex", regex(r))
+	}
+	return n.prev, n.err
+}
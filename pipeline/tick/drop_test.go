@@ -0,0 +1,34 @@
+package tick_test
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestDropTags(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	drop := from.DropTags("host", "tmp_*")
+	drop.Regex(regexp.MustCompile("^debug_"))
+	drop.Regroup()
+
+	want := `stream
+    |from()
+    |dropTags('host', 'tmp_*')
+        .regex(/^debug_/)
+        .regroup()
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
+func TestDropFields(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	drop := from.DropFields("raw", "debug_*")
+	drop.Regex(regexp.MustCompile("_tmp$"))
+
+	want := `stream
+    |from()
+    |dropFields('raw', 'debug_*')
+        .regex(/_tmp$/)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
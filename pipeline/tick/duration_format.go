@@ -0,0 +1,28 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// DurationFormatNode converts the DurationFormat pipeline node into the TICKScript AST
+type DurationFormatNode struct {
+	Function
+}
+
+// NewDurationFormat creates a DurationFormat function builder
+func NewDurationFormat(parents []ast.Node) *DurationFormatNode {
+	return &DurationFormatNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a DurationFormat ast.Node
+func (n *DurationFormatNode) Build(d *pipeline.DurationFormatNode) (ast.Node, error) {
+	n.Pipe("humanize", d.Field, d.As).
+		Dot("unit", d.Unit).
+		Dot("precision", d.Precision)
+	return n.prev, n.err
+}
@@ -0,0 +1,20 @@
+package tick_test
+
+import (
+	"testing"
+)
+
+func TestDurationFormat(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	d := from.Humanize("elapsed", "elapsed_human")
+	d.Unit = "ms"
+	d.Precision = "m"
+
+	want := `stream
+    |from()
+    |humanize('elapsed', 'elapsed_human')
+        .unit('ms')
+        .precision('m')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -0,0 +1,46 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// EncryptNode converts the Encrypt pipeline node into the TICKScript AST
+type EncryptNode struct {
+	Function
+}
+
+// NewEncrypt creates an Encrypt function builder
+func NewEncrypt(parents []ast.Node) *EncryptNode {
+	return &EncryptNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates an Encrypt ast.Node
+func (n *EncryptNode) Build(e *pipeline.EncryptNode) (ast.Node, error) {
+	n.Pipe("encrypt", args(e.Fields)...)
+	return n.prev, n.err
+}
+
+// DecryptNode converts the Decrypt pipeline node into the TICKScript AST
+type DecryptNode struct {
+	Function
+}
+
+// NewDecrypt creates a Decrypt function builder
+func NewDecrypt(parents []ast.Node) *DecryptNode {
+	return &DecryptNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a Decrypt ast.Node
+func (n *DecryptNode) Build(d *pipeline.DecryptNode) (ast.Node, error) {
+	n.Pipe("decrypt", args(d.Fields)...)
+	return n.prev, n.err
+}
@@ -0,0 +1,27 @@
+package tick_test
+
+import (
+	"testing"
+)
+
+func TestEncrypt(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	from.Encrypt("email", "ssn")
+
+	want := `stream
+    |from()
+    |encrypt('email', 'ssn')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
+func TestDecrypt(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	from.Decrypt("email")
+
+	want := `stream
+    |from()
+    |decrypt('email')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -0,0 +1,53 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// FieldToTagNode converts the FieldToTag pipeline node into the TICKScript AST
+type FieldToTagNode struct {
+	Function
+}
+
+// NewFieldToTag creates a FieldToTag function builder
+func NewFieldToTag(parents []ast.Node) *FieldToTagNode {
+	return &FieldToTagNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a FieldToTag ast.Node
+func (n *FieldToTagNode) Build(f *pipeline.FieldToTagNode) (ast.Node, error) {
+	n.Pipe("fieldToTag", f.Field).
+		Dot("as", f.As).
+		DotIf("keep", f.KeepFlag).
+		DotIf("regroup", f.RegroupFlag)
+	return n.prev, n.err
+}
+
+// TagToFieldNode converts the TagToField pipeline node into the TICKScript AST
+type TagToFieldNode struct {
+	Function
+}
+
+// NewTagToField creates a TagToField function builder
+func NewTagToField(parents []ast.Node) *TagToFieldNode {
+	return &TagToFieldNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a TagToField ast.Node
+func (n *TagToFieldNode) Build(t *pipeline.TagToFieldNode) (ast.Node, error) {
+	n.Pipe("tagToField", t.Tag).
+		Dot("as", t.As).
+		Dot("type", t.FieldType).
+		DotIf("keep", t.KeepFlag).
+		DotIf("regroup", t.RegroupFlag)
+	return n.prev, n.err
+}
@@ -0,0 +1,37 @@
+package tick_test
+
+import (
+	"testing"
+)
+
+func TestFieldToTag(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	f := from.FieldToTag("host")
+	f.As = "hostname"
+	f.Keep()
+	f.Regroup()
+
+	want := `stream
+    |from()
+    |fieldToTag('host')
+        .as('hostname')
+        .keep()
+        .regroup()
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
+func TestTagToField(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	n := from.TagToField("status")
+	n.As = "status_code"
+	n.Type("int")
+
+	want := `stream
+    |from()
+    |tagToField('status')
+        .as('status_code')
+        .type('int')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
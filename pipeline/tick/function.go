@@ -2,6 +2,8 @@ package tick
 
 import (
 	"fmt"
+	"regexp"
+	"strings"
 
 	"github.com/influxdata/kapacitor/tick/ast"
 )
@@ -40,6 +42,29 @@ func (f *Function) Pipe(name string, args ...interface{}) *Function {
 	return f
 }
 
+// PipeZeroValueOK produces an ast.FunctionNode within a Pipe Chain.
+// All function arguments that evaluate to the zero value are kept.
+// Assumes one parent exists.
+func (f *Function) PipeZeroValueOK(name string, args ...interface{}) *Function {
+	if f.err != nil {
+		return f
+	}
+
+	if len(f.Parents) == 0 {
+		f.err = fmt.Errorf("Parent required for function creation")
+		return f
+	}
+
+	fn, err := FuncWithZero(name, args...)
+	if err != nil {
+		f.err = err
+		return f
+	}
+
+	f.prev = Pipe(f.Parents[0], fn)
+	return f
+}
+
 // At produces an ast.FunctionNode within an At Chain.  May return
 // the parent node if all args evaluate to the zero value.
 // Assumes there is only one At called per Function.
@@ -335,6 +360,14 @@ func largs(a []*ast.LambdaNode) []interface{} {
 	return r
 }
 
+// regex produces an ast.RegexNode whose literal form can be formatted back into TICKscript.
+func regex(r *regexp.Regexp) *ast.RegexNode {
+	return &ast.RegexNode{
+		Regex:   r,
+		Literal: strings.Replace(r.String(), "/", `\/`, -1),
+	}
+}
+
 var _ ast.Position = &NullPosition{}
 
 // NullPosition is a NOOP to satisfy the tick AST package
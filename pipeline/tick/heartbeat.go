@@ -0,0 +1,26 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// HeartbeatNode converts the Heartbeat pipeline node into the TICKScript AST
+type HeartbeatNode struct {
+	Function
+}
+
+// NewHeartbeat creates a Heartbeat function builder
+func NewHeartbeat(parents []ast.Node) *HeartbeatNode {
+	return &HeartbeatNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a Heartbeat ast.Node
+func (n *HeartbeatNode) Build(h *pipeline.HeartbeatNode) (ast.Node, error) {
+	n.Pipe("heartbeat", h.Interval)
+	return n.prev, n.err
+}
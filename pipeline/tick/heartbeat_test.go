@@ -0,0 +1,17 @@
+package tick_test
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHeartbeat(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	from.Heartbeat(10 * time.Second)
+
+	want := `stream
+    |from()
+    |heartbeat(10s)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
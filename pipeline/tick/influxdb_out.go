@@ -32,7 +32,40 @@ func (n *InfluxDBOutNode) Build(db *pipeline.InfluxDBOutNode) (ast.Node, error)
 		Dot("precision", db.Precision).
 		Dot("buffer", db.Buffer).
 		Dot("flushInterval", db.FlushInterval).
-		DotIf("create", db.CreateFlag)
+		DotIf("create", db.CreateFlag).
+		DotIf("createTargets", db.CreateTargetsFlag)
+
+	if len(db.ClusterNames) > 0 {
+		args := make([]interface{}, len(db.ClusterNames))
+		for i, c := range db.ClusterNames {
+			args[i] = c
+		}
+		n.Dot("clusters", args...).
+			Dot("failoverThreshold", db.FailoverThreshold).
+			Dot("probeInterval", db.ProbeInterval).
+			Dot("failoverBuffer", db.FailoverBuffer)
+	}
+
+	if db.WalDir != "" {
+		n.Dot("walDir", db.WalDir).
+			Dot("walMaxSize", db.WalMaxSize).
+			Dot("walFsync", db.WalFsync)
+	}
+
+	if len(db.WriteTagsOnlyList) > 0 {
+		args := make([]interface{}, len(db.WriteTagsOnlyList))
+		for i, t := range db.WriteTagsOnlyList {
+			args[i] = t
+		}
+		n.Dot("writeTagsOnly", args...)
+	}
+	if len(db.DropWriteTagsList) > 0 {
+		args := make([]interface{}, len(db.DropWriteTagsList))
+		for i, t := range db.DropWriteTagsList {
+			args[i] = t
+		}
+		n.Dot("dropWriteTags", args...)
+	}
 
 	var tags []string
 	for k := range db.Tags {
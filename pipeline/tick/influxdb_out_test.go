@@ -35,3 +35,81 @@ func TestInfluxDBOut(t *testing.T) {
 `
 	PipelineTickTestHelper(t, pipe, want)
 }
+
+func TestInfluxDBOutClusters(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	influx := from.InfluxDBOut()
+	influx.Database = "mydb"
+	influx.Clusters("primary", "secondary")
+	influx.ProbeInterval = time.Minute
+
+	want := `stream
+    |from()
+    |influxDBOut()
+        .database('mydb')
+        .buffer(1000)
+        .flushInterval(10s)
+        .clusters('primary', 'secondary')
+        .failoverThreshold(3)
+        .probeInterval(1m)
+        .failoverBuffer(10000)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
+func TestInfluxDBOutWal(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	influx := from.InfluxDBOut()
+	influx.Database = "mydb"
+	influx.WalDir = "/var/lib/kapacitor/wal"
+	influx.WalMaxSize = 1048576
+	influx.WalFsync = "interval"
+
+	want := `stream
+    |from()
+    |influxDBOut()
+        .database('mydb')
+        .buffer(1000)
+        .flushInterval(10s)
+        .walDir('/var/lib/kapacitor/wal')
+        .walMaxSize(1048576)
+        .walFsync('interval')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
+func TestInfluxDBOutCreateTargets(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	influx := from.InfluxDBOut()
+	influx.Database = "mydb"
+	influx.RetentionPolicy = "myrp"
+	influx.CreateTargets()
+
+	want := `stream
+    |from()
+    |influxDBOut()
+        .database('mydb')
+        .retentionPolicy('myrp')
+        .buffer(1000)
+        .flushInterval(10s)
+        .createTargets()
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
+func TestInfluxDBOutWriteTags(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	influx := from.InfluxDBOut()
+	influx.Database = "mydb"
+	influx.DropWriteTags("request_id", "session_id")
+
+	want := `stream
+    |from()
+    |influxDBOut()
+        .database('mydb')
+        .buffer(1000)
+        .flushInterval(10s)
+        .dropWriteTags('request_id', 'session_id')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -31,6 +31,9 @@ func (n *JoinNode) Build(j *pipeline.JoinNode) (ast.Node, error) {
 		Dot("delimiter", j.Delimiter).
 		Dot("streamName", j.StreamName).
 		Dot("tolerance", j.Tolerance).
-		DotNotNil("fill", j.Fill)
+		DotNotNil("fill", j.Fill).
+		Dot("preAgg", j.PreAggregate).
+		DotIf("byIndex", j.ByIndexFlag).
+		Dot("lengthMismatch", j.LengthMismatchHandling)
 	return n.prev, n.err
 }
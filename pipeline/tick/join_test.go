@@ -43,3 +43,61 @@ stream
 `
 	PipelineTickTestHelper(t, pipe, want)
 }
+
+func TestJoin_PreAgg(t *testing.T) {
+	stream1 := &pipeline.StreamNode{}
+	stream2 := &pipeline.StreamNode{}
+	pipe := pipeline.CreatePipelineSources(stream1, stream2)
+
+	from1 := stream1.From()
+	from1.Measurement = "errors"
+
+	from2 := stream2.From()
+	from2.Measurement = "requests"
+
+	join := from1.Join(from2)
+	join.As("errors", "requests").PreAgg("mean")
+	join.Tolerance = 10 * time.Second
+
+	want := `var from3 = stream
+    |from()
+        .measurement('requests')
+
+stream
+    |from()
+        .measurement('errors')
+    |join(from3)
+        .as('errors', 'requests')
+        .on()
+        .delimiter('.')
+        .tolerance(10s)
+        .preAgg('mean')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
+func TestJoin_ByIndex(t *testing.T) {
+	batch1 := &pipeline.BatchNode{}
+	batch2 := &pipeline.BatchNode{}
+	pipe := pipeline.CreatePipelineSources(batch1, batch2)
+
+	query1 := batch1.Query("select predicted from forecast")
+	query2 := batch2.Query("select actual from usage")
+
+	join := query1.Join(query2)
+	join.As("predicted", "actual").ByIndex().LengthMismatch("pad")
+
+	want := `var query3 = batch
+    |query('select actual from usage')
+
+batch
+    |query('select predicted from forecast')
+    |join(query3)
+        .as('predicted', 'actual')
+        .on()
+        .delimiter('.')
+        .byIndex()
+        .lengthMismatch('pad')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -0,0 +1,30 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// JSONExtractNode converts the JSONExtract pipeline node into the TICKScript AST
+type JSONExtractNode struct {
+	Function
+}
+
+// NewJSONExtract creates a JSONExtract function builder
+func NewJSONExtract(parents []ast.Node) *JSONExtractNode {
+	return &JSONExtractNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a JSONExtract ast.Node
+func (n *JSONExtractNode) Build(j *pipeline.JSONExtractNode) (ast.Node, error) {
+	n.Pipe("jsonExtract", j.Field)
+	for _, e := range j.Extractions {
+		n.Dot("extract", e.Path, e.As, e.Type)
+	}
+	n.DotIf("dropMalformed", j.DropMalformedFlag)
+	return n.prev, n.err
+}
@@ -0,0 +1,22 @@
+package tick_test
+
+import (
+	"testing"
+)
+
+func TestJSONExtract(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	from.JsonExtract("payload").
+		Extract("$.user.id", "user_id", "int").
+		Extract("$['request-id']", "request_id", "tag").
+		DropMalformed()
+
+	want := `stream
+    |from()
+    |jsonExtract('payload')
+        .extract('$.user.id', 'user_id', 'int')
+        .extract('$[\'request-id\']', 'request_id', 'tag')
+        .dropMalformed()
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
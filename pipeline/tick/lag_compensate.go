@@ -0,0 +1,29 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// LagCompensateNode converts the LagCompensateNode pipeline node into the TICKScript AST
+type LagCompensateNode struct {
+	Function
+}
+
+// NewLagCompensate creates a LagCompensateNode function builder
+func NewLagCompensate(parents []ast.Node) *LagCompensateNode {
+	return &LagCompensateNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a LagCompensate ast.Node
+func (n *LagCompensateNode) Build(l *pipeline.LagCompensateNode) (ast.Node, error) {
+	n.Pipe("lagCompensate").
+		Dot("alpha", l.Alpha).
+		Dot("max", l.Max).
+		DotIf("noShift", l.NoShiftFlag)
+	return n.prev, n.err
+}
@@ -0,0 +1,23 @@
+package tick_test
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLagCompensate(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	l := from.LagCompensate()
+	l.Alpha = 0.2
+	l.Max = 5 * time.Minute
+	l.NoShift()
+
+	want := `stream
+    |from()
+    |lagCompensate()
+        .alpha(0.2)
+        .max(5m)
+        .noShift()
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -0,0 +1,37 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// MaskNode converts the Mask pipeline node into the TICKScript AST
+type MaskNode struct {
+	Function
+}
+
+// NewMask creates a Mask function builder
+func NewMask(parents []ast.Node) *MaskNode {
+	return &MaskNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a Mask ast.Node
+func (n *MaskNode) Build(m *pipeline.MaskNode) (ast.Node, error) {
+	n.Pipe("mask")
+	for _, f := range m.Fields {
+		n.Dot("field", f)
+	}
+	for _, t := range m.Tags {
+		n.Dot("tag", t)
+	}
+	for _, r := range m.Patterns {
+		n.Dot("pattern", regex(r))
+	}
+	n.Dot("replacement", m.Replacement).
+		DotIf("hash", m.HashFlag)
+	return n.prev, n.err
+}
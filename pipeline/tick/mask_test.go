@@ -0,0 +1,28 @@
+package tick_test
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestMask(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	m := from.Mask()
+	m.Field("message")
+	m.Tag("user")
+	m.Pattern(regexp.MustCompile(`[a-z]+@example\.com`))
+	m.Pattern(regexp.MustCompile(`\d{16}`))
+	m.Hash()
+
+	want := `stream
+    |from()
+    |mask()
+        .field('message')
+        .tag('user')
+        .pattern(/[a-z]+@example\.com/)
+        .pattern(/\d{16}/)
+        .replacement('***')
+        .hash()
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -0,0 +1,31 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// MaxDelayNode converts the MaxDelay pipeline node into the TICKScript AST
+type MaxDelayNode struct {
+	Function
+}
+
+// NewMaxDelay creates a MaxDelay function builder
+func NewMaxDelay(parents []ast.Node) *MaxDelayNode {
+	return &MaxDelayNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a MaxDelay ast.Node
+func (n *MaxDelayNode) Build(m *pipeline.MaxDelayNode) (ast.Node, error) {
+	n.Pipe("maxDelay").
+		Dot("maxLag", m.MaxLag).
+		Dot("as", m.As).
+		Dot("unit", m.Unit).
+		Dot("exceededAs", m.ExceededAs).
+		DotIf("breachesOnly", m.BreachesOnlyFlag)
+	return n.prev, n.err
+}
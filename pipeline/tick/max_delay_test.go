@@ -0,0 +1,26 @@
+package tick_test
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMaxDelay(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	m := from.MaxDelay()
+	m.MaxLag = 5 * time.Minute
+	m.As = "delay"
+	m.Unit = time.Millisecond
+	m.BreachesOnly()
+
+	want := `stream
+    |from()
+    |maxDelay()
+        .maxLag(5m)
+        .as('delay')
+        .unit(1ms)
+        .exceededAs('lag_exceeded')
+        .breachesOnly()
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
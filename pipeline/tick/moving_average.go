@@ -0,0 +1,29 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// MovingAverageNode converts the MovingAverage pipeline node into the TICKScript AST
+type MovingAverageNode struct {
+	Function
+}
+
+// NewMovingAverage creates a MovingAverage function builder
+func NewMovingAverage(parents []ast.Node) *MovingAverageNode {
+	return &MovingAverageNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a MovingAverage ast.Node
+func (n *MovingAverageNode) Build(m *pipeline.MovingAverageNode) (ast.Node, error) {
+	n.Pipe("rollingAverage", m.Field, m.Size).
+		Dot("mode", m.Mode).
+		Dot("as", m.As).
+		Dot("minPoints", m.MinPoints)
+	return n.prev, n.err
+}
@@ -0,0 +1,22 @@
+package tick_test
+
+import (
+	"testing"
+)
+
+func TestRollingAverage(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	m := from.RollingAverage("value", 10)
+	m.Mode = "weighted"
+	m.As = "avg"
+	m.MinPoints = 5
+
+	want := `stream
+    |from()
+    |rollingAverage('value', 10)
+        .mode('weighted')
+        .as('avg')
+        .minPoints(5)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
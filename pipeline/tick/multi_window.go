@@ -0,0 +1,33 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// MultiWindowNode converts the MultiWindow pipeline node into the TICKScript AST
+type MultiWindowNode struct {
+	Function
+}
+
+// NewMultiWindow creates a MultiWindow function builder
+func NewMultiWindow(parents []ast.Node) *MultiWindowNode {
+	return &MultiWindowNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a MultiWindow ast.Node
+func (n *MultiWindowNode) Build(m *pipeline.MultiWindowNode) (ast.Node, error) {
+	args := []interface{}{m.Field}
+	for _, size := range m.Sizes {
+		args = append(args, size)
+	}
+	n.Pipe("multiWindow", args...).
+		Dot("aggregate", m.Aggregate).
+		Dot("as", m.As).
+		Dot("tag", m.Tag)
+	return n.prev, n.err
+}
@@ -0,0 +1,23 @@
+package tick_test
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMultiWindow(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	m := from.MultiWindow("usage_user", time.Minute, 5*time.Minute, time.Hour)
+	m.Aggregate = "max"
+	m.As = "peak"
+	m.Tag = "size"
+
+	want := `stream
+    |from()
+    |multiWindow('usage_user', 1m, 5m, 1h)
+        .aggregate('max')
+        .as('peak')
+        .tag('size')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -0,0 +1,30 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// NatsOutNode converts the NatsOut pipeline node into the TICKScript AST
+type NatsOutNode struct {
+	Function
+}
+
+// NewNatsOut creates a NatsOut function builder
+func NewNatsOut(parents []ast.Node) *NatsOutNode {
+	return &NatsOutNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a NatsOut ast.Node
+func (n *NatsOutNode) Build(o *pipeline.NatsOutNode) (ast.Node, error) {
+	n.Pipe("natsOut", o.Subject).
+		Dot("url", o.URL).
+		Dot("format", o.Format).
+		DotIf("jetStream", o.UseJetStream).
+		Dot("timeout", o.Timeout)
+	return n.prev, n.err
+}
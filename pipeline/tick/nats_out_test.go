@@ -0,0 +1,25 @@
+package tick_test
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNatsOut(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	o := from.NatsOut("metrics.{{ .Name }}")
+	o.URL = "nats://nats.example.com:4222"
+	o.Format = "line"
+	o.JetStream()
+	o.Timeout = 10 * time.Second
+
+	want := `stream
+    |from()
+    |natsOut('metrics.{{ .Name }}')
+        .url('nats://nats.example.com:4222')
+        .format('line')
+        .jetStream()
+        .timeout(10s)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
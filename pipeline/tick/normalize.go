@@ -0,0 +1,30 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// NormalizeNode converts the Normalize pipeline node into the TICKScript AST
+type NormalizeNode struct {
+	Function
+}
+
+// NewNormalize creates a Normalize function builder
+func NewNormalize(parents []ast.Node) *NormalizeNode {
+	return &NormalizeNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a Normalize ast.Node
+func (n *NormalizeNode) Build(m *pipeline.NormalizeNode) (ast.Node, error) {
+	n.Pipe("normalize", m.Field).
+		Dot("method", m.Method).
+		Dot("size", m.Size).
+		Dot("period", m.Period).
+		Dot("as", m.As)
+	return n.prev, n.err
+}
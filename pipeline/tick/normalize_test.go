@@ -0,0 +1,25 @@
+package tick_test
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNormalize(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	m := from.Normalize("value")
+	m.Method = "zscore"
+	m.Size = 100
+	m.Period = time.Hour
+	m.As = "score"
+
+	want := `stream
+    |from()
+    |normalize('value')
+        .method('zscore')
+        .size(100)
+        .period(1h)
+        .as('score')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -0,0 +1,33 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// PercentilesNode converts the Percentiles pipeline node into the TICKScript AST
+type PercentilesNode struct {
+	Function
+}
+
+// NewPercentiles creates a Percentiles function builder
+func NewPercentiles(parents []ast.Node) *PercentilesNode {
+	return &PercentilesNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a Percentiles ast.Node
+func (n *PercentilesNode) Build(p *pipeline.PercentilesNode) (ast.Node, error) {
+	args := []interface{}{p.Field}
+	for _, percentile := range p.Ranks {
+		args = append(args, percentile)
+	}
+	n.PipeZeroValueOK("percentiles", args...).
+		Dot("as", p.As).
+		Dot("maxWindowSize", p.MaxWindowSize).
+		DotIf("sampleOverflow", p.SampleOverflowFlag)
+	return n.prev, n.err
+}
@@ -0,0 +1,29 @@
+package tick_test
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPercentiles(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	w := from.Window()
+	w.Period = 10 * time.Second
+	w.Every = 10 * time.Second
+	p := w.Percentiles("latency", 0.0, 50.0, 99.9)
+	p.As = "latency_p"
+	p.MaxWindowSize = 1000
+	p.SampleOverflow()
+
+	want := `stream
+    |from()
+    |window()
+        .period(10s)
+        .every(10s)
+    |percentiles('latency', 0.0, 50.0, 99.9)
+        .as('latency_p')
+        .maxWindowSize(1000)
+        .sampleOverflow()
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
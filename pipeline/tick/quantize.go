@@ -0,0 +1,29 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// QuantizeNode converts the Quantize pipeline node into the TICKScript AST
+type QuantizeNode struct {
+	Function
+}
+
+// NewQuantize creates a Quantize function builder
+func NewQuantize(parents []ast.Node) *QuantizeNode {
+	return &QuantizeNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a Quantize ast.Node
+func (n *QuantizeNode) Build(q *pipeline.QuantizeNode) (ast.Node, error) {
+	n.Pipe("quantize").
+		Dot("interval", q.Interval).
+		Dot("mode", q.Mode).
+		Dot("onCollision", q.OnCollision)
+	return n.prev, n.err
+}
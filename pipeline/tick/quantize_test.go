@@ -0,0 +1,23 @@
+package tick_test
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQuantize(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	q := from.Quantize()
+	q.Interval = 10 * time.Second
+	q.Mode = "floor"
+	q.OnCollision = "mean"
+
+	want := `stream
+    |from()
+    |quantize()
+        .interval(10s)
+        .mode('floor')
+        .onCollision('mean')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -28,10 +28,17 @@ func (n *QueryNode) Build(q *pipeline.QueryNode) (ast.Node, error) {
 		Dot("cron", q.Cron).
 		Dot("offset", q.Offset).
 		DotIf("alignGroup", q.AlignGroupFlag).
+		DotIf("skipFirst", q.SkipFirstFlag).
 		Dot("groupBy", q.Dimensions).
 		DotIf("groupByMeasurement", q.GroupByMeasurementFlag).
 		DotNotNil("fill", q.Fill).
-		Dot("cluster", q.Cluster)
+		Dot("cluster", q.Cluster).
+		DotIf("flux", q.FluxFlag).
+		Dot("org", q.Org).
+		Dot("bucket", q.Bucket).
+		Dot("maxInMemoryPoints", q.MaxInMemoryPoints).
+		Dot("chunkInterval", q.ChunkInterval).
+		Dot("overlap", q.Overlap)
 
 	return n.prev, n.err
 }
@@ -13,10 +13,12 @@ func TestQuery(t *testing.T) {
 	query.AlignFlag = true
 	query.Offset = time.Hour
 	query.AlignGroupFlag = true
+	query.SkipFirstFlag = true
 	query.Dimensions = []interface{}{"host", "region"}
 	query.GroupByMeasurementFlag = true
 	query.Fill = "linear"
 	query.Cluster = "mycluster"
+	query.MaxInMemoryPoints = 10000
 
 	want := `batch
     |query('select cpu_usage from cpu')
@@ -25,10 +27,50 @@ func TestQuery(t *testing.T) {
         .align()
         .offset(1h)
         .alignGroup()
+        .skipFirst()
         .groupBy(['host', 'region'])
         .groupByMeasurement()
         .fill('linear')
         .cluster('mycluster')
+        .maxInMemoryPoints(10000)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
+func TestQueryChunkInterval(t *testing.T) {
+	pipe, _, query := BatchQuery("select cpu_usage from cpu")
+
+	query.Period = 24 * time.Hour
+	query.Every = 24 * time.Hour
+	query.ChunkInterval = time.Hour
+	query.Overlap = time.Minute
+
+	want := `batch
+    |query('select cpu_usage from cpu')
+        .period(1d)
+        .every(1d)
+        .chunkInterval(1h)
+        .overlap(1m)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
+func TestQueryFlux(t *testing.T) {
+	pipe, _, query := BatchQuery(`from(bucket: v.bucket) |> range(start: v.timeRangeStart, stop: v.timeRangeStop)`)
+
+	query.Period = time.Minute
+	query.Every = time.Minute
+	query.FluxFlag = true
+	query.Org = "my-org"
+	query.Bucket = "telegraf"
+
+	want := `batch
+    |query('from(bucket: v.bucket) |> range(start: v.timeRangeStart, stop: v.timeRangeStop)')
+        .period(1m)
+        .every(1m)
+        .flux()
+        .org('my-org')
+        .bucket('telegraf')
 `
 	PipelineTickTestHelper(t, pipe, want)
 }
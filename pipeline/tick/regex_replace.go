@@ -0,0 +1,36 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// RegexReplaceNode converts the RegexReplace pipeline node into the TICKScript AST
+type RegexReplaceNode struct {
+	Function
+}
+
+// NewRegexReplace creates a RegexReplace function builder
+func NewRegexReplace(parents []ast.Node) *RegexReplaceNode {
+	return &RegexReplaceNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a RegexReplace ast.Node
+func (n *RegexReplaceNode) Build(r *pipeline.RegexReplaceNode) (ast.Node, error) {
+	n.Pipe("regexReplace")
+	for _, f := range r.Fields {
+		n.Dot("field", f)
+	}
+	for _, t := range r.Tags {
+		n.Dot("tag", t)
+	}
+	for _, rule := range r.Rules {
+		n.DotZeroValueOK("rule", regex(rule.Pattern), rule.Replacement)
+	}
+	n.DotIf("regroup", r.RegroupFlag)
+	return n.prev, n.err
+}
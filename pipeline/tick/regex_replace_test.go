@@ -0,0 +1,27 @@
+package tick_test
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestRegexReplace(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	r := from.RegexReplace()
+	r.Field("path")
+	r.Tag("host")
+	r.Rule(regexp.MustCompile(`\s+$`), "")
+	r.Rule(regexp.MustCompile(`^(\w+)-(\d+)$`), "$1$2")
+	r.Regroup()
+
+	want := `stream
+    |from()
+    |regexReplace()
+        .field('path')
+        .tag('host')
+        .rule(/\s+$/, '')
+        .rule(/^(\w+)-(\d+)$/, '$1$2')
+        .regroup()
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -0,0 +1,28 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// RetractNode converts the Retract pipeline node into the TICKScript AST
+type RetractNode struct {
+	Function
+}
+
+// NewRetract creates a Retract function builder
+func NewRetract(parents []ast.Node) *RetractNode {
+	return &RetractNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a Retract ast.Node
+func (n *RetractNode) Build(r *pipeline.RetractNode) (ast.Node, error) {
+	n.Pipe("retract").
+		DotZeroValueOK("tag", r.Tag).
+		DotZeroValueOK("field", r.Field)
+	return n.prev, n.err
+}
@@ -0,0 +1,20 @@
+package tick_test
+
+import (
+	"testing"
+)
+
+func TestRetract(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	r := from.Retract()
+	r.Tag = "deleted"
+	r.Field = ""
+
+	want := `stream
+    |from()
+    |retract()
+        .tag('deleted')
+        .field('')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -0,0 +1,33 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// ReverseDNSNode converts the ReverseDNS pipeline node into the TICKScript AST
+type ReverseDNSNode struct {
+	Function
+}
+
+// NewReverseDNS creates a ReverseDNS function builder
+func NewReverseDNS(parents []ast.Node) *ReverseDNSNode {
+	return &ReverseDNSNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a ReverseDNS ast.Node
+func (n *ReverseDNSNode) Build(r *pipeline.ReverseDNSNode) (ast.Node, error) {
+	n.Pipe("reverseDNS", r.Tag).
+		DotIf("fromField", r.FromFieldFlag).
+		Dot("as", r.As).
+		Dot("placeholder", r.Placeholder).
+		Dot("cacheTTL", r.CacheTTL).
+		Dot("cacheSize", r.CacheSize).
+		Dot("timeout", r.Timeout).
+		Dot("concurrency", r.Concurrency)
+	return n.prev, n.err
+}
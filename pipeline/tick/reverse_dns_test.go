@@ -0,0 +1,44 @@
+package tick_test
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReverseDNS(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	r := from.ReverseDNS("src_ip")
+	r.As = "src_host"
+	r.Placeholder = "unknown"
+	r.CacheTTL = 10 * time.Minute
+	r.Concurrency = 4
+
+	want := `stream
+    |from()
+    |reverseDNS('src_ip')
+        .as('src_host')
+        .placeholder('unknown')
+        .cacheTTL(10m)
+        .cacheSize(10000)
+        .timeout(1s)
+        .concurrency(4)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
+func TestReverseDNSFromField(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	from.ReverseDNS("addr").FromField()
+
+	want := `stream
+    |from()
+    |reverseDNS('addr')
+        .fromField()
+        .as('hostname')
+        .cacheTTL(1h)
+        .cacheSize(10000)
+        .timeout(1s)
+        .concurrency(10)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
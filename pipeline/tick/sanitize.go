@@ -0,0 +1,47 @@
+package tick
+
+import (
+	"sort"
+
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// SanitizeNode converts the Sanitize pipeline node into the TICKScript AST
+type SanitizeNode struct {
+	Function
+}
+
+// NewSanitize creates a Sanitize function builder
+func NewSanitize(parents []ast.Node) *SanitizeNode {
+	return &SanitizeNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a Sanitize ast.Node
+func (n *SanitizeNode) Build(s *pipeline.SanitizeNode) (ast.Node, error) {
+	n.Pipe("sanitize").
+		Dot("action", s.Action).
+		Dot("replaceWith", s.ReplaceWith)
+
+	if len(s.FieldsList) > 0 {
+		args := make([]interface{}, len(s.FieldsList))
+		for i, f := range s.FieldsList {
+			args[i] = f
+		}
+		n.Dot("fields", args...)
+	}
+
+	var fields []string
+	for k := range s.FieldActions {
+		fields = append(fields, k)
+	}
+	sort.Strings(fields)
+	for _, k := range fields {
+		n.Dot("fieldAction", k, s.FieldActions[k])
+	}
+	return n.prev, n.err
+}
@@ -0,0 +1,46 @@
+package tick_test
+
+import (
+	"testing"
+)
+
+func TestSanitize(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	s := from.Sanitize()
+	s.Action = "replace"
+	s.ReplaceWith = -1
+	s.Fields("value", "errors")
+	s.FieldAction("errors", "drop")
+
+	want := `stream
+    |from()
+    |sanitize()
+        .action('replace')
+        .replaceWith(-1.0)
+        .fields('value', 'errors')
+        .fieldAction('errors', 'drop')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
+func TestSanitizeBranch(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	s := from.Sanitize()
+	s.Action = "route"
+	s.HttpOut("valid")
+	s.Branch("invalid").HttpOut("invalid")
+
+	want := `var sanitize2 = stream
+    |from()
+    |sanitize()
+        .action('route')
+
+sanitize2
+    |branch('invalid')
+    |httpOut('invalid')
+
+sanitize2
+    |httpOut('valid')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -0,0 +1,32 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// ScheduleNode converts the Schedule pipeline node into the TICKScript AST
+type ScheduleNode struct {
+	Function
+}
+
+// NewSchedule creates a Schedule function builder
+func NewSchedule(parents []ast.Node) *ScheduleNode {
+	return &ScheduleNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a Schedule ast.Node
+func (n *ScheduleNode) Build(s *pipeline.ScheduleNode) (ast.Node, error) {
+	n.Pipe("schedule")
+	for _, w := range s.Windows {
+		n.Dot("window", w.Days, w.Start, w.End)
+	}
+	n.Dot("timezone", s.Timezone).
+		DotIf("wallClock", s.WallClockFlag).
+		Dot("flagAs", s.FlagAs)
+	return n.prev, n.err
+}
@@ -0,0 +1,26 @@
+package tick_test
+
+import (
+	"testing"
+)
+
+func TestSchedule(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	s := from.Schedule()
+	s.Window("Mon-Fri", "09:00", "17:00")
+	s.Window("Sat", "10:00", "14:00")
+	s.Timezone = "America/New_York"
+	s.WallClock()
+	s.FlagAs = "open"
+
+	want := `stream
+    |from()
+    |schedule()
+        .window('Mon-Fri', '09:00', '17:00')
+        .window('Sat', '10:00', '14:00')
+        .timezone('America/New_York')
+        .wallClock()
+        .flagAs('open')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -0,0 +1,29 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// SessionizeNode converts the Sessionize pipeline node into the TICKScript AST
+type SessionizeNode struct {
+	Function
+}
+
+// NewSessionize creates a Sessionize function builder
+func NewSessionize(parents []ast.Node) *SessionizeNode {
+	return &SessionizeNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a Sessionize ast.Node
+func (n *SessionizeNode) Build(s *pipeline.SessionizeNode) (ast.Node, error) {
+	n.Pipe("sessionize").
+		Dot("timeout", s.Timeout).
+		Dot("tag", s.Tag).
+		Dot("summaryName", s.SummaryName)
+	return n.prev, n.err
+}
@@ -0,0 +1,23 @@
+package tick_test
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSessionize(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	s := from.Sessionize()
+	s.Timeout = 30 * time.Minute
+	s.Tag = "sid"
+	s.SummaryName = "visits"
+
+	want := `stream
+    |from()
+    |sessionize()
+        .timeout(30m)
+        .tag('sid')
+        .summaryName('visits')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -0,0 +1,50 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// SplitNode converts the Split pipeline node into the TICKScript AST
+type SplitNode struct {
+	Function
+}
+
+// NewSplit creates a Split function builder
+func NewSplit(parents []ast.Node) *SplitNode {
+	return &SplitNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a Split ast.Node
+func (n *SplitNode) Build(s *pipeline.SplitNode) (ast.Node, error) {
+	n.Pipe("split")
+	for _, c := range s.Cases {
+		n.Dot("case", c.Name, c.Lambda)
+	}
+	n.Dot("defaultBranch", s.DefaultBranch)
+	return n.prev, n.err
+}
+
+// SplitBranchNode converts the SplitBranch pipeline node into the TICKScript AST
+type SplitBranchNode struct {
+	Function
+}
+
+// NewSplitBranch creates a SplitBranch function builder
+func NewSplitBranch(parents []ast.Node) *SplitBranchNode {
+	return &SplitBranchNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a SplitBranch ast.Node
+func (n *SplitBranchNode) Build(b *pipeline.SplitBranchNode) (ast.Node, error) {
+	n.Pipe("branch", b.BranchName)
+	return n.prev, n.err
+}
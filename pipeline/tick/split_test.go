@@ -0,0 +1,44 @@
+package tick_test
+
+import (
+	"testing"
+
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+func TestSplit(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	split := from.Split()
+	split.Case("high", &ast.LambdaNode{
+		Expression: &ast.BinaryNode{
+			Operator: ast.TokenGreater,
+			Left: &ast.ReferenceNode{
+				Reference: "value",
+			},
+			Right: &ast.NumberNode{
+				IsInt: true,
+				Int64: 90,
+				Base:  10,
+			},
+		},
+	})
+	split.DefaultBranch = "normal"
+	split.Branch("high").HttpOut("high")
+	split.Branch("normal").HttpOut("normal")
+
+	want := `var split2 = stream
+    |from()
+    |split()
+        .case('high', lambda: "value" > 90)
+        .defaultBranch('normal')
+
+split2
+    |branch('normal')
+    |httpOut('normal')
+
+split2
+    |branch('high')
+    |httpOut('high')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -0,0 +1,32 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// StateMachineNode converts the StateMachine pipeline node into the TICKScript AST
+type StateMachineNode struct {
+	Function
+}
+
+// NewStateMachine creates a StateMachine function builder
+func NewStateMachine(parents []ast.Node) *StateMachineNode {
+	return &StateMachineNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a StateMachine ast.Node
+func (n *StateMachineNode) Build(s *pipeline.StateMachineNode) (ast.Node, error) {
+	n.Pipe("stateMachine", s.InitialState).
+		Dot("states", args(s.DeclaredStates)...)
+	for _, t := range s.Transitions {
+		n.Dot("transition", t.From, t.To, t.Lambda)
+	}
+	n.Dot("unit", s.Unit).
+		DotIf("persistState", s.PersistStateFlag)
+	return n.prev, n.err
+}
@@ -0,0 +1,46 @@
+package tick_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+func TestStateMachine(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	usage := func(op ast.TokenType, value int64) *ast.LambdaNode {
+		return &ast.LambdaNode{
+			Expression: &ast.BinaryNode{
+				Operator: op,
+				Left: &ast.ReferenceNode{
+					Reference: "usage",
+				},
+				Right: &ast.NumberNode{
+					IsInt: true,
+					Int64: value,
+					Base:  10,
+				},
+			},
+		}
+	}
+	sm := from.StateMachine("ok")
+	sm.States("ok", "warning", "critical").
+		Transition("ok", "warning", usage(ast.TokenGreater, 80)).
+		Transition("warning", "critical", usage(ast.TokenGreater, 95)).
+		Transition("warning", "ok", usage(ast.TokenLessEqual, 80)).
+		PersistState()
+	sm.Unit = time.Minute
+
+	want := `stream
+    |from()
+    |stateMachine('ok')
+        .states('ok', 'warning', 'critical')
+        .transition('ok', 'warning', lambda: "usage" > 80)
+        .transition('warning', 'critical', lambda: "usage" > 95)
+        .transition('warning', 'ok', lambda: "usage" <= 80)
+        .unit(1m)
+        .persistState()
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
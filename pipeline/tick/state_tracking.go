@@ -23,7 +23,8 @@ func NewStateDuration(parents []ast.Node) *StateDurationNode {
 func (n *StateDurationNode) Build(s *pipeline.StateDurationNode) (ast.Node, error) {
 	n.Pipe("stateDuration", s.Lambda).
 		Dot("as", s.As).
-		Dot("unit", s.Unit)
+		Dot("unit", s.Unit).
+		DotIf("persistState", s.PersistStateFlag)
 
 	return n.prev, n.err
 }
@@ -45,7 +46,8 @@ func NewStateCount(parents []ast.Node) *StateCountNode {
 // Build creates a StateCountNode ast.Node
 func (n *StateCountNode) Build(s *pipeline.StateCountNode) (ast.Node, error) {
 	n.Pipe("stateCount", s.Lambda).
-		Dot("as", s.As)
+		Dot("as", s.As).
+		DotIf("persistState", s.PersistStateFlag)
 
 	return n.prev, n.err
 }
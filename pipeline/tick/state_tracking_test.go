@@ -24,12 +24,14 @@ func TestStateDuration(t *testing.T) {
 	sd := from.StateDuration(lambda)
 	sd.As = "AKA"
 	sd.Unit = time.Minute
+	sd.PersistState()
 
 	want := `stream
     |from()
     |stateDuration(lambda: "cpu" != 'cpu-total')
         .as('AKA')
         .unit(1m)
+        .persistState()
 `
 	PipelineTickTestHelper(t, pipe, want)
 }
@@ -50,11 +52,13 @@ func TestStateCount(t *testing.T) {
 
 	sd := from.StateCount(lambda)
 	sd.As = "AKA"
+	sd.PersistState()
 
 	want := `stream
     |from()
     |stateCount(lambda: "cpu" != 'cpu-total')
         .as('AKA')
+        .persistState()
 `
 	PipelineTickTestHelper(t, pipe, want)
 }
@@ -0,0 +1,27 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// StreamCompactNode converts the StreamCompact pipeline node into the TICKScript AST
+type StreamCompactNode struct {
+	Function
+}
+
+// NewStreamCompact creates a StreamCompact function builder
+func NewStreamCompact(parents []ast.Node) *StreamCompactNode {
+	return &StreamCompactNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a StreamCompact ast.Node
+func (n *StreamCompactNode) Build(s *pipeline.StreamCompactNode) (ast.Node, error) {
+	n.Pipe("streamCompact", args(s.Fields)...).
+		Dot("maxInterval", s.MaxInterval)
+	return n.prev, n.err
+}
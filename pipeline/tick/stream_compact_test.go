@@ -0,0 +1,19 @@
+package tick_test
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStreamCompact(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	s := from.StreamCompact("state", "mode")
+	s.MaxInterval = 5 * time.Minute
+
+	want := `stream
+    |from()
+    |streamCompact('state', 'mode')
+        .maxInterval(5m)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
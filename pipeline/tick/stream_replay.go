@@ -0,0 +1,29 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// StreamReplayNode converts the StreamReplay pipeline node into the TICKScript AST
+type StreamReplayNode struct {
+	Function
+}
+
+// NewStreamReplay creates a StreamReplay function builder
+func NewStreamReplay(parents []ast.Node) *StreamReplayNode {
+	return &StreamReplayNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a StreamReplay ast.Node
+func (n *StreamReplayNode) Build(s *pipeline.StreamReplayNode) (ast.Node, error) {
+	n.Pipe("streamReplay", s.Path).
+		Dot("maxSize", s.MaxSize).
+		Dot("rotations", s.Rotations).
+		DotIf("replay", s.ReplayFlag)
+	return n.prev, n.err
+}
@@ -0,0 +1,32 @@
+package tick_test
+
+import (
+	"testing"
+)
+
+func TestStreamReplay(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	s := from.StreamReplay("/tmp/{{ .TaskName }}.rec")
+	s.MaxSize = 1024
+	s.Rotations = 3
+
+	want := `stream
+    |from()
+    |streamReplay('/tmp/{{ .TaskName }}.rec')
+        .maxSize(1024)
+        .rotations(3)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
+func TestStreamReplayReplay(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	from.StreamReplay("/tmp/{{ .TaskName }}.rec").Replay()
+
+	want := `stream
+    |from()
+    |streamReplay('/tmp/{{ .TaskName }}.rec')
+        .replay()
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -0,0 +1,43 @@
+package tick
+
+import (
+	"sort"
+
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// TagValueMapNode converts the TagValueMap pipeline node into the TICKScript AST
+type TagValueMapNode struct {
+	Function
+}
+
+// NewTagValueMap creates a TagValueMap function builder
+func NewTagValueMap(parents []ast.Node) *TagValueMapNode {
+	return &TagValueMapNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a TagValueMap ast.Node
+func (n *TagValueMapNode) Build(m *pipeline.TagValueMapNode) (ast.Node, error) {
+	n.Pipe("tagValueMap", m.Tag).
+		DotIf("fromField", m.FromFieldFlag)
+
+	var values []string
+	for v := range m.Mappings {
+		values = append(values, v)
+	}
+	sort.Strings(values)
+	for _, v := range values {
+		n.DotZeroValueOK("map", v, m.Mappings[v])
+	}
+
+	if m.DefaultLabel != nil {
+		n.DotZeroValueOK("default", *m.DefaultLabel)
+	}
+	n.Dot("as", m.As)
+	return n.prev, n.err
+}
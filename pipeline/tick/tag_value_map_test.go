@@ -0,0 +1,38 @@
+package tick_test
+
+import "testing"
+
+func TestTagValueMap(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	m := from.TagValueMap("status").
+		Map("2", "crit").
+		Map("0", "ok").
+		Map("1", "warn").
+		Default("unknown")
+	m.As = "status_label"
+
+	want := `stream
+    |from()
+    |tagValueMap('status')
+        .map('0', 'ok')
+        .map('1', 'warn')
+        .map('2', 'crit')
+        .default('unknown')
+        .as('status_label')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
+func TestTagValueMapFromField(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	from.TagValueMap("status").FromField().Map("0", "").Default("")
+
+	want := `stream
+    |from()
+    |tagValueMap('status')
+        .fromField()
+        .map('0', '')
+        .default('')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -0,0 +1,34 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// ThresholdLearnNode converts the ThresholdLearn pipeline node into the TICKScript AST
+type ThresholdLearnNode struct {
+	Function
+}
+
+// NewThresholdLearn creates a ThresholdLearn function builder
+func NewThresholdLearn(parents []ast.Node) *ThresholdLearnNode {
+	return &ThresholdLearnNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a ThresholdLearn ast.Node
+func (n *ThresholdLearnNode) Build(t *pipeline.ThresholdLearnNode) (ast.Node, error) {
+	n.Pipe("thresholdLearn", t.Field).
+		Dot("method", t.Method).
+		Dot("window", t.Window).
+		Dot("refresh", t.Refresh).
+		Dot("k", t.K).
+		Dot("lowerPercentile", t.LowerPercentile).
+		Dot("upperPercentile", t.UpperPercentile).
+		Dot("lowerAs", t.LowerAs).
+		Dot("upperAs", t.UpperAs)
+	return n.prev, n.err
+}
@@ -0,0 +1,30 @@
+package tick_test
+
+import (
+	"testing"
+	"time"
+)
+
+func TestThresholdLearn(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	n := from.ThresholdLearn("usage")
+	n.Method = "percentile"
+	n.Window = 24 * time.Hour
+	n.Refresh = time.Hour
+	n.LowerPercentile = 1
+	n.UpperPercentile = 99
+
+	want := `stream
+    |from()
+    |thresholdLearn('usage')
+        .method('percentile')
+        .window(1d)
+        .refresh(1h)
+        .k(3.0)
+        .lowerPercentile(1.0)
+        .upperPercentile(99.0)
+        .lowerAs('lower')
+        .upperAs('upper')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
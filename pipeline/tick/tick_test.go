@@ -10,6 +10,7 @@ import (
 
 	"github.com/influxdata/kapacitor/pipeline"
 	"github.com/influxdata/kapacitor/pipeline/tick"
+	"github.com/influxdata/kapacitor/tick/ast"
 	"github.com/influxdata/kapacitor/tick/stateful"
 )
 
@@ -151,3 +152,102 @@ func (d deadman) Threshold() float64      { return d.threshold }
 func (d deadman) Id() string              { return d.id }
 func (d deadman) Message() string         { return d.message }
 func (d deadman) Global() bool            { return d.global }
+
+func TestReorder(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	d := from.Derivative("value")
+	d.Reorder(10*time.Second, 100)
+
+	want := `stream
+    |from()
+    |derivative('value')
+        .as('value')
+        .unit(1s)
+        .reorder(10s, 100)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
+func TestDeadLetter(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	e := from.Eval(&ast.LambdaNode{
+		Expression: &ast.ReferenceNode{
+			Reference: "value",
+		},
+	})
+	e.As("value")
+	e.DeadLetter("topic", "dead_letters")
+
+	want := `stream
+    |from()
+    |eval(lambda: "value")
+        .as('value')
+        .tags()
+        .deadLetter('topic', 'dead_letters')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
+func TestBackpressure(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	h := from.HttpOut("cpu")
+	h.Backpressure("dropOldest")
+
+	want := `stream
+    |from()
+    |httpOut('cpu')
+        .backpressure('dropOldest')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
+func TestAssertOrder(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	d := from.Derivative("value")
+	d.AssertOrder()
+
+	want := `stream
+    |from()
+    |derivative('value')
+        .as('value')
+        .unit(1s)
+        .assertOrder()
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
+func TestPanicLimit(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	h := from.HttpOut("cpu")
+	h.PanicLimit(10, time.Minute)
+
+	want := `stream
+    |from()
+    |httpOut('cpu')
+        .panicLimit(10, 1m)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
+func TestWorkers(t *testing.T) {
+	pipe, _, query := BatchQuery("select cpu_usage from cpu")
+	w := query.Where(&ast.LambdaNode{
+		Expression: &ast.BinaryNode{
+			Left: &ast.ReferenceNode{
+				Reference: "host",
+			},
+			Right: &ast.StringNode{
+				Literal: "serverA",
+			},
+			Operator: ast.TokenEqual,
+		},
+	})
+	w.Workers(4)
+
+	want := `batch
+    |query('select cpu_usage from cpu')
+    |where(lambda: "host" == 'serverA')
+        .workers(4)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
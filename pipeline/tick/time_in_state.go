@@ -0,0 +1,28 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// TimeInStateNode converts the TimeInState pipeline node into the TICKScript AST
+type TimeInStateNode struct {
+	Function
+}
+
+// NewTimeInState creates a TimeInState function builder
+func NewTimeInState(parents []ast.Node) *TimeInStateNode {
+	return &TimeInStateNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a TimeInState ast.Node
+func (n *TimeInStateNode) Build(t *pipeline.TimeInStateNode) (ast.Node, error) {
+	n.Pipe("timeInState", t.Field).
+		Dot("as", t.As).
+		Dot("unit", t.Unit)
+	return n.prev, n.err
+}
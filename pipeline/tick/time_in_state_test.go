@@ -0,0 +1,21 @@
+package tick_test
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeInState(t *testing.T) {
+	pipe, _, query := BatchQuery("select status from service")
+	s := query.TimeInState("status")
+	s.As = "minutes"
+	s.Unit = time.Minute
+
+	want := `batch
+    |query('select status from service')
+    |timeInState('status')
+        .as('minutes')
+        .unit(1m)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -0,0 +1,32 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// TrendNode converts the Trend pipeline node into the TICKScript AST
+type TrendNode struct {
+	Function
+}
+
+// NewTrend creates a Trend function builder
+func NewTrend(parents []ast.Node) *TrendNode {
+	return &TrendNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a Trend ast.Node
+func (n *TrendNode) Build(t *pipeline.TrendNode) (ast.Node, error) {
+	n.Pipe("trend", t.Field).
+		Dot("size", t.Size).
+		Dot("period", t.Period).
+		Dot("unit", t.Unit).
+		Dot("slopeAs", t.SlopeAs).
+		Dot("interceptAs", t.InterceptAs).
+		Dot("r2As", t.R2As)
+	return n.prev, n.err
+}
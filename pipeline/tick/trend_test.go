@@ -0,0 +1,27 @@
+package tick_test
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTrend(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	n := from.Trend("used")
+	n.Size = 100
+	n.Period = 6 * time.Hour
+	n.Unit = time.Hour
+	n.SlopeAs = "rate"
+
+	want := `stream
+    |from()
+    |trend('used')
+        .size(100)
+        .period(6h)
+        .unit(1h)
+        .slopeAs('rate')
+        .interceptAs('intercept')
+        .r2As('r2')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -0,0 +1,31 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// UpDownCounterNode converts the UpDownCounter pipeline node into the TICKScript AST
+type UpDownCounterNode struct {
+	Function
+}
+
+// NewUpDownCounter creates an UpDownCounter function builder
+func NewUpDownCounter(parents []ast.Node) *UpDownCounterNode {
+	return &UpDownCounterNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates an UpDownCounter ast.Node
+func (n *UpDownCounterNode) Build(c *pipeline.UpDownCounterNode) (ast.Node, error) {
+	n.Pipe("upDownCounter", c.Field).
+		Dot("as", c.As).
+		Dot("every", c.Every).
+		DotIf("floor", c.FloorFlag).
+		DotIf("resetOnBarrier", c.ResetOnBarrierFlag).
+		DotIf("persistState", c.PersistStateFlag)
+	return n.prev, n.err
+}
@@ -0,0 +1,25 @@
+package tick_test
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUpDownCounter(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	c := from.UpDownCounter("delta")
+	c.As = "open"
+	c.Every = time.Minute
+	c.Floor().ResetOnBarrier().PersistState()
+
+	want := `stream
+    |from()
+    |upDownCounter('delta')
+        .as('open')
+        .every(1m)
+        .floor()
+        .resetOnBarrier()
+        .persistState()
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
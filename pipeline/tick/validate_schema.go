@@ -0,0 +1,31 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// ValidateSchemaNode converts the ValidateSchema pipeline node into the TICKScript AST
+type ValidateSchemaNode struct {
+	Function
+}
+
+// NewValidateSchema creates a ValidateSchema function builder
+func NewValidateSchema(parents []ast.Node) *ValidateSchemaNode {
+	return &ValidateSchemaNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a ValidateSchema ast.Node
+func (n *ValidateSchemaNode) Build(v *pipeline.ValidateSchemaNode) (ast.Node, error) {
+	n.Pipe("validateSchema").
+		Dot("registry", v.RegistryURL).
+		Dot("subject", v.Subject).
+		Dot("schema", v.Schema).
+		Dot("timeout", v.Timeout).
+		DotIf("strict", v.StrictFlag)
+	return n.prev, n.err
+}
@@ -0,0 +1,39 @@
+package tick_test
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidateSchema(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	v := from.ValidateSchema()
+	v.Registry("http://registry.example.com:8081")
+	v.Subject = "orders-value"
+	v.Timeout = 10 * time.Second
+	v.Strict()
+
+	want := `stream
+    |from()
+    |validateSchema()
+        .registry('http://registry.example.com:8081')
+        .subject('orders-value')
+        .timeout(10s)
+        .strict()
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
+func TestValidateSchema_Schema(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	v := from.ValidateSchema()
+	v.Schema = `{"type":"record","name":"orders","fields":[{"name":"amount","type":"double"}]}`
+
+	want := `stream
+    |from()
+    |validateSchema()
+        .schema('{"type":"record","name":"orders","fields":[{"name":"amount","type":"double"}]}')
+        .timeout(5s)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -0,0 +1,29 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// ValidateTimeNode converts the ValidateTime pipeline node into the TICKScript AST
+type ValidateTimeNode struct {
+	Function
+}
+
+// NewValidateTime creates a ValidateTime function builder
+func NewValidateTime(parents []ast.Node) *ValidateTimeNode {
+	return &ValidateTimeNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a ValidateTime ast.Node
+func (n *ValidateTimeNode) Build(v *pipeline.ValidateTimeNode) (ast.Node, error) {
+	n.Pipe("validateTime").
+		Dot("maxFuture", v.MaxFuture).
+		Dot("maxPast", v.MaxPast).
+		Dot("action", v.Action)
+	return n.prev, n.err
+}
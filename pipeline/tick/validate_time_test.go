@@ -0,0 +1,23 @@
+package tick_test
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidateTime(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	v := from.ValidateTime()
+	v.MaxFuture = time.Hour
+	v.MaxPast = 24 * time.Hour
+	v.Action = "clamp"
+
+	want := `stream
+    |from()
+    |validateTime()
+        .maxFuture(1h)
+        .maxPast(1d)
+        .action('clamp')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -0,0 +1,28 @@
+package tick
+
+import (
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// WarmupNode converts the Warmup pipeline node into the TICKScript AST
+type WarmupNode struct {
+	Function
+}
+
+// NewWarmup creates a Warmup function builder
+func NewWarmup(parents []ast.Node) *WarmupNode {
+	return &WarmupNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a Warmup ast.Node
+func (n *WarmupNode) Build(w *pipeline.WarmupNode) (ast.Node, error) {
+	n.Pipe("warmup").
+		Dot("period", w.Period).
+		Dot("count", w.Count)
+	return n.prev, n.err
+}
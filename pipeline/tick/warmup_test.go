@@ -0,0 +1,21 @@
+package tick_test
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWarmup(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	w := from.Warmup()
+	w.Period = 5 * time.Minute
+	w.Count = 10
+
+	want := `stream
+    |from()
+    |warmup()
+        .period(5m)
+        .count(10)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
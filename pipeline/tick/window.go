@@ -28,5 +28,16 @@ func (n *WindowNode) Build(w *pipeline.WindowNode) (ast.Node, error) {
 		Dot("everyCount", w.EveryCount).
 		DotIf("align", w.AlignFlag).
 		DotIf("fillPeriod", w.FillPeriodFlag)
+	if w.FlushPeriod != 0 {
+		n.Dot("periodOrCount", w.FlushPeriod, w.FlushCount)
+	}
+	if w.WatermarkFlag {
+		n.Dot("watermark", w.Lateness)
+	}
+	n.DotIf("lateOutput", w.LateOutputFlag).
+		DotIf("fillLast", w.FillLastFlag).
+		Dot("fillLastMax", w.FillLastMax).
+		Dot("trigger", w.Trigger).
+		DotIf("triggerRealign", w.TriggerRealignFlag)
 	return n.prev, n.err
 }
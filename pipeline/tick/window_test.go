@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
 )
 
 func TestWindowNode(t *testing.T) {
@@ -15,6 +16,10 @@ func TestWindowNode(t *testing.T) {
 		fillPeriod  bool
 		periodCount int64
 		everyCount  int64
+		flushPeriod time.Duration
+		flushCount  int64
+		watermark   bool
+		lateness    time.Duration
 	}
 	tests := []struct {
 		name string
@@ -50,6 +55,34 @@ func TestWindowNode(t *testing.T) {
     |window()
         .periodCount(10)
         .everyCount(15)
+`,
+		},
+		{
+			name: "window with period or count",
+			args: args{
+				flushPeriod: time.Minute,
+				flushCount:  100,
+			},
+			want: `stream
+    |from()
+    |window()
+        .periodOrCount(1m, 100)
+`,
+		},
+		{
+			name: "window with watermark",
+			args: args{
+				period:    time.Minute,
+				every:     time.Minute,
+				watermark: true,
+				lateness:  30 * time.Second,
+			},
+			want: `stream
+    |from()
+    |window()
+        .period(1m)
+        .every(1m)
+        .watermark(30s)
 `,
 		},
 	}
@@ -65,6 +98,10 @@ func TestWindowNode(t *testing.T) {
 			w.FillPeriodFlag = tt.args.fillPeriod
 			w.PeriodCount = tt.args.periodCount
 			w.EveryCount = tt.args.everyCount
+			w.FlushPeriod = tt.args.flushPeriod
+			w.FlushCount = tt.args.flushCount
+			w.WatermarkFlag = tt.args.watermark
+			w.Lateness = tt.args.lateness
 
 			got, err := PipelineTick(pipe)
 			if err != nil {
@@ -76,3 +113,63 @@ func TestWindowNode(t *testing.T) {
 		})
 	}
 }
+
+func TestWindowNodeLateOutput(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	w := from.Window()
+	w.Period = time.Minute
+	w.Every = time.Minute
+	w.Watermark(30 * time.Second).LateOutput()
+	w.Branch(pipeline.WindowLateBranch).Log()
+	want := `stream
+    |from()
+    |window()
+        .period(1m)
+        .every(1m)
+        .watermark(30s)
+        .lateOutput()
+    |branch('late')
+    |log()
+        .level('INFO')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
+func TestWindowNodeFillLast(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	w := from.Window()
+	w.Period = time.Minute
+	w.Every = time.Minute
+	w.FillLast().FillLastMax = 10
+	want := `stream
+    |from()
+    |window()
+        .period(1m)
+        .every(1m)
+        .fillLast()
+        .fillLastMax(10)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
+func TestWindowNodeTrigger(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	w := from.Window()
+	w.Period = time.Minute
+	w.Every = time.Minute
+	w.Trigger = &ast.LambdaNode{
+		Expression: &ast.ReferenceNode{
+			Reference: "end_of_transaction",
+		},
+	}
+	w.TriggerRealign()
+	want := `stream
+    |from()
+    |window()
+        .period(1m)
+        .every(1m)
+        .trigger(lambda: "end_of_transaction")
+        .triggerRealign()
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
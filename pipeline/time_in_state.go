@@ -0,0 +1,138 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxdb/influxql"
+)
+
+const defaultTimeInStateAs = "duration"
+
+// A TimeInStateNode computes how long each group spent in each value of a state field per window,
+// i.e. the time a service was up and down for availability reports.
+//
+// The state of a group is the value of the field with the given name,
+// or of the tag with that name if the point has no such field.
+// A state lasts from the time of its point until the time of the next point of the group,
+// and the last state of a window lasts until the end of the window.
+// The state at the end of a window carries over into the next window,
+// until the first point of the next window. So the durations of a window always add up to the time
+// covered since the previous window, except before the first point of the first window of a group,
+// which has no known state.
+//
+// At the end of each window a batch with one point per state of the window is emitted.
+// The points have the time of the window, a tag with the name of the state field and the state as value,
+// and a field with the duration spent in the state.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('service')
+//            .groupBy('service')
+//        |window()
+//            .period(1h)
+//            .every(1h)
+//        |timeInState('status')
+//            .unit(1m)
+//        |influxDBOut()
+//            .database('reports')
+//            .measurement('availability')
+//
+// The above example emits the number of minutes each service spent in each of its statuses every hour,
+// e.g. a point with the tag status=up and the field duration=57 and a point with status=down and duration=3.
+//
+// Windows must not overlap, i.e. the period of a WindowNode must equal its every property.
+// Points older than the latest point of their group are ignored.
+// The state of a group is dropped when the group is deleted.
+//
+type TimeInStateNode struct {
+	chainnode `json:"-"`
+
+	// The field or tag with the state.
+	// tick:ignore
+	Field string `json:"field"`
+
+	// The name of the field containing the duration spent in a state.
+	// Default: duration
+	As string `json:"as"`
+
+	// The time unit of the durations.
+	// Default: 1s
+	Unit time.Duration `json:"unit"`
+}
+
+func newTimeInStateNode(wants EdgeType, field string) *TimeInStateNode {
+	return &TimeInStateNode{
+		chainnode: newBasicChainNode("time_in_state", wants, wants),
+		Field:     field,
+		As:        defaultTimeInStateAs,
+		Unit:      time.Second,
+	}
+}
+
+// MarshalJSON converts TimeInStateNode to JSON
+// tick:ignore
+func (n *TimeInStateNode) MarshalJSON() ([]byte, error) {
+	type Alias TimeInStateNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Unit string `json:"unit"`
+	}{
+		TypeOf: TypeOf{
+			Type: "timeInState",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+		Unit:  influxql.FormatDuration(n.Unit),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an TimeInStateNode
+// tick:ignore
+func (n *TimeInStateNode) UnmarshalJSON(data []byte) error {
+	type Alias TimeInStateNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Unit string `json:"unit"`
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "timeInState" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as TimeInStateNode", raw.ID, raw.Type)
+	}
+	n.Unit, err = influxql.ParseDuration(raw.Unit)
+	if err != nil {
+		return err
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+func (n *TimeInStateNode) validate() error {
+	if n.Wants() != BatchEdge {
+		return errors.New("timeInState can only be used with batch data, i.e. after a window")
+	}
+	if n.Field == "" {
+		return errors.New("must provide a state field")
+	}
+	if n.As == "" {
+		return errors.New("as cannot be empty")
+	}
+	if n.As == n.Field {
+		return fmt.Errorf("as cannot be the state field %q", n.Field)
+	}
+	if n.Unit <= 0 {
+		return errors.New("unit must be greater than zero")
+	}
+	return nil
+}
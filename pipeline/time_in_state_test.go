@@ -0,0 +1,68 @@
+package pipeline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeInStateNode_MarshalJSON(t *testing.T) {
+	n := newTimeInStateNode(BatchEdge, "status")
+	n.Unit = time.Minute
+	MarshalTestHelper(t, n, false, `{"typeOf":"timeInState","id":"0","field":"status","as":"duration","unit":"1m"}`)
+}
+
+func TestTimeInStateNode_UnmarshalJSON(t *testing.T) {
+	input := `{"typeOf":"timeInState","id":"0","field":"status","as":"uptime","unit":"1s"}`
+	want := &TimeInStateNode{
+		Field: "status",
+		As:    "uptime",
+		Unit:  time.Second,
+	}
+	UnmarshalJSONTestHelper(t, []byte(input), &TimeInStateNode{}, false, want)
+}
+
+func TestTimeInStateNode_Validate(t *testing.T) {
+	newNode := func(wants EdgeType, field, as string, unit time.Duration) *TimeInStateNode {
+		n := newTimeInStateNode(wants, field)
+		n.As = as
+		n.Unit = unit
+		return n
+	}
+	tests := []struct {
+		name    string
+		node    *TimeInStateNode
+		wantErr bool
+	}{
+		{
+			name: "batch",
+			node: newNode(BatchEdge, "status", "duration", time.Second),
+		},
+		{
+			name:    "stream",
+			node:    newNode(StreamEdge, "status", "duration", time.Second),
+			wantErr: true,
+		},
+		{
+			name:    "no field",
+			node:    newNode(BatchEdge, "", "duration", time.Second),
+			wantErr: true,
+		},
+		{
+			name:    "as state field",
+			node:    newNode(BatchEdge, "status", "status", time.Second),
+			wantErr: true,
+		},
+		{
+			name:    "zero unit",
+			node:    newNode(BatchEdge, "status", "duration", 0),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.node.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxdb/influxql"
+)
+
+// Compute the trend of a field of each group using least-squares linear regression.
+// The trend is more robust to noisy data than the derivative of consecutive points.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('disk')
+//            .groupBy('host', 'path')
+//        |trend('used')
+//            .period(6h)
+//            .unit(1h)
+//        |alert()
+//            .warn(lambda: "slope" > 1000000000)
+//
+// The above example computes how many bytes of disk space are used per hour, over the last 6 hours.
+//
+// The points used for the regression are:
+//
+//    * the points of each batch for batch data.
+//    * the points within the last size points and/or the last period for stream data, if size or period are set.
+//      A result is emitted for each point.
+//    * the points received since the last barrier for stream data otherwise.
+//      A result is emitted for each barrier.
+//
+// Each result is a point with the slope, intercept and R² of the regression,
+// the time of the result is the time of the newest point.
+// The slope is the change of the field per unit of time.
+// The intercept is the value of the regression line at the time of the result.
+// R² is the coefficient of determination of the regression, 1 if the regression fits the points perfectly.
+//
+// Nothing is emitted for fewer than two points or if all points have the same time.
+type TrendNode struct {
+	chainnode `json:"-"`
+
+	// The field to compute the trend of.
+	// tick:ignore
+	Field string `json:"field"`
+
+	// The maximum number of points used for a stream regression.
+	// If zero the number of points is not limited.
+	Size int64 `json:"size"`
+
+	// The maximum age of the points used for a stream regression, relative to the newest point.
+	// If zero the age of the points is not limited.
+	Period time.Duration `json:"period"`
+
+	// The time unit of the slope.
+	// Default: 1s
+	Unit time.Duration `json:"unit"`
+
+	// The name of the slope field.
+	// Default: slope
+	SlopeAs string `json:"slopeAs"`
+
+	// The name of the intercept field.
+	// Default: intercept
+	InterceptAs string `json:"interceptAs"`
+
+	// The name of the R² field.
+	// Default: r2
+	R2As string `json:"r2As"`
+}
+
+func newTrendNode(wants EdgeType, field string) *TrendNode {
+	return &TrendNode{
+		chainnode:   newBasicChainNode("trend", wants, wants),
+		Field:       field,
+		Unit:        time.Second,
+		SlopeAs:     "slope",
+		InterceptAs: "intercept",
+		R2As:        "r2",
+	}
+}
+
+// MarshalJSON converts TrendNode to JSON
+// tick:ignore
+func (n *TrendNode) MarshalJSON() ([]byte, error) {
+	type Alias TrendNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Period string `json:"period"`
+		Unit   string `json:"unit"`
+	}{
+		TypeOf: TypeOf{
+			Type: "trend",
+			ID:   n.ID(),
+		},
+		Alias:  (*Alias)(n),
+		Period: influxql.FormatDuration(n.Period),
+		Unit:   influxql.FormatDuration(n.Unit),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an TrendNode
+// tick:ignore
+func (n *TrendNode) UnmarshalJSON(data []byte) error {
+	type Alias TrendNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Period string `json:"period"`
+		Unit   string `json:"unit"`
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "trend" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as TrendNode", raw.ID, raw.Type)
+	}
+	n.Period, err = influxql.ParseDuration(raw.Period)
+	if err != nil {
+		return err
+	}
+	n.Unit, err = influxql.ParseDuration(raw.Unit)
+	if err != nil {
+		return err
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+func (n *TrendNode) validate() error {
+	if n.Field == "" {
+		return errors.New("must provide a field to compute the trend of")
+	}
+	if n.Size < 0 {
+		return errors.New("size cannot be negative")
+	}
+	if n.Size == 1 {
+		return errors.New("size must be at least 2 to compute a trend")
+	}
+	if n.Period < 0 {
+		return errors.New("period cannot be negative")
+	}
+	if n.Wants() == BatchEdge && (n.Size != 0 || n.Period != 0) {
+		return errors.New("size and period only apply to stream data, batch trends use the points of each batch")
+	}
+	if n.Unit <= 0 {
+		return errors.New("unit must be greater than 0")
+	}
+	if n.SlopeAs == "" || n.InterceptAs == "" || n.R2As == "" {
+		return errors.New("must provide names for the slope, intercept and r2 fields")
+	}
+	if n.SlopeAs == n.InterceptAs || n.SlopeAs == n.R2As || n.InterceptAs == n.R2As {
+		return errors.New("the slope, intercept and r2 fields must have different names")
+	}
+	return nil
+}
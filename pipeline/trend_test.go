@@ -0,0 +1,90 @@
+package pipeline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTrendNode_MarshalJSON(t *testing.T) {
+	n := newTrendNode(StreamEdge, "used")
+	n.Period = 6 * time.Hour
+	n.Unit = time.Hour
+	n.SlopeAs = "rate"
+	MarshalTestHelper(t, n, false, `{"typeOf":"trend","id":"0","field":"used","size":0,"slopeAs":"rate","interceptAs":"intercept","r2As":"r2","period":"6h","unit":"1h"}`)
+}
+
+func TestTrendNode_UnmarshalJSON(t *testing.T) {
+	input := `{"typeOf":"trend","id":"0","field":"used","size":10,"slopeAs":"slope","interceptAs":"intercept","r2As":"r2","period":"0s","unit":"1s"}`
+	want := &TrendNode{
+		Field:       "used",
+		Size:        10,
+		Unit:        time.Second,
+		SlopeAs:     "slope",
+		InterceptAs: "intercept",
+		R2As:        "r2",
+	}
+	UnmarshalJSONTestHelper(t, []byte(input), &TrendNode{}, false, want)
+}
+
+func TestTrendNode_Validate(t *testing.T) {
+	newNode := func(wants EdgeType, f func(n *TrendNode)) *TrendNode {
+		n := newTrendNode(wants, "used")
+		f(n)
+		return n
+	}
+	tests := []struct {
+		name    string
+		node    *TrendNode
+		wantErr bool
+	}{
+		{
+			name: "barrier",
+			node: newNode(StreamEdge, func(*TrendNode) {}),
+		},
+		{
+			name: "rolling",
+			node: newNode(StreamEdge, func(n *TrendNode) { n.Size = 10; n.Period = time.Minute }),
+		},
+		{
+			name: "batch",
+			node: newNode(BatchEdge, func(*TrendNode) {}),
+		},
+		{
+			name:    "batch with size",
+			node:    newNode(BatchEdge, func(n *TrendNode) { n.Size = 10 }),
+			wantErr: true,
+		},
+		{
+			name:    "missing field",
+			node:    newNode(StreamEdge, func(n *TrendNode) { n.Field = "" }),
+			wantErr: true,
+		},
+		{
+			name:    "size of one",
+			node:    newNode(StreamEdge, func(n *TrendNode) { n.Size = 1 }),
+			wantErr: true,
+		},
+		{
+			name:    "negative period",
+			node:    newNode(StreamEdge, func(n *TrendNode) { n.Period = -time.Minute }),
+			wantErr: true,
+		},
+		{
+			name:    "zero unit",
+			node:    newNode(StreamEdge, func(n *TrendNode) { n.Unit = 0 }),
+			wantErr: true,
+		},
+		{
+			name:    "duplicate field names",
+			node:    newNode(StreamEdge, func(n *TrendNode) { n.R2As = "slope" }),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.node.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
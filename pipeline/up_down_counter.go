@@ -0,0 +1,172 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxdb/influxql"
+)
+
+// Maintain a running total per group of a field of increments and decrements,
+// i.e. to turn +1/-1 events into a gauge of the number of open connections.
+//
+// By default each point is emitted with the running total added as a field.
+// With the `every` property the points are consumed, and a point with the running total
+// is emitted per group at each multiple of every in the time of the data.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('connections')
+//            .groupBy('server')
+//        |upDownCounter('delta')
+//            .as('open')
+//            .floor()
+//        |influxDBOut()
+//            .database('gauges')
+//            .measurement('open_connections')
+//
+// The above example writes the number of open connections of each server with each event,
+// never letting the number drop below zero if a close event arrives without its open event.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('connections')
+//            .groupBy('server')
+//        |upDownCounter('delta')
+//            .every(1m)
+//
+// The above example emits the running total of each server once a minute.
+//
+// A total of integer deltas is an integer, a float delta turns it into a float.
+// Points without a numeric value of the field are dropped.
+// The totals of batches continue from the previous batch, and with the `every` property only stream data is supported.
+//
+// The total of each group is dropped when the group is deleted,
+// and lost when the task restarts unless the persistState property is set.
+//
+// Available Statistics:
+//
+//    * invalid_deltas -- number of points dropped because of a missing or non-numeric field
+//
+type UpDownCounterNode struct {
+	chainnode `json:"-"`
+
+	// The field of the increments and decrements.
+	// tick:ignore
+	Field string `json:"field"`
+
+	// The name of the field of the running total.
+	// Default: total
+	As string `json:"as"`
+
+	// How often the running total is emitted, zero emits it with every point.
+	Every time.Duration `json:"every"`
+
+	// Whether the running total is kept from dropping below zero.
+	// tick:ignore
+	FloorFlag bool `tick:"Floor" json:"floor"`
+
+	// Whether the running total is reset to zero by barriers.
+	// tick:ignore
+	ResetOnBarrierFlag bool `tick:"ResetOnBarrier" json:"resetOnBarrier"`
+
+	// Whether the totals of the groups are saved in the task snapshots and restored when the task restarts.
+	// tick:ignore
+	PersistStateFlag bool `tick:"PersistState" json:"persistState,omitempty"`
+}
+
+func newUpDownCounterNode(wants EdgeType, field string) *UpDownCounterNode {
+	return &UpDownCounterNode{
+		chainnode: newBasicChainNode("up_down_counter", wants, wants),
+		Field:     field,
+		As:        "total",
+	}
+}
+
+// MarshalJSON converts UpDownCounterNode to JSON
+// tick:ignore
+func (n *UpDownCounterNode) MarshalJSON() ([]byte, error) {
+	type Alias UpDownCounterNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Every string `json:"every"`
+	}{
+		TypeOf: TypeOf{
+			Type: "upDownCounter",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+		Every: influxql.FormatDuration(n.Every),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an UpDownCounterNode
+// tick:ignore
+func (n *UpDownCounterNode) UnmarshalJSON(data []byte) error {
+	type Alias UpDownCounterNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Every string `json:"every"`
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "upDownCounter" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as UpDownCounterNode", raw.ID, raw.Type)
+	}
+	n.Every, err = influxql.ParseDuration(raw.Every)
+	if err != nil {
+		return err
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+// Keep the running total from dropping below zero,
+// decrements beyond zero are ignored.
+// tick:property
+func (n *UpDownCounterNode) Floor() *UpDownCounterNode {
+	n.FloorFlag = true
+	return n
+}
+
+// Reset the running total to zero with each barrier, after emitting it with the every property.
+// tick:property
+func (n *UpDownCounterNode) ResetOnBarrier() *UpDownCounterNode {
+	n.ResetOnBarrierFlag = true
+	return n
+}
+
+// Save the totals of the groups in the task snapshots, taken every snapshot-interval of the [task] configuration,
+// and restore them when the task restarts, so that the gauges continue where they were.
+// tick:property
+func (n *UpDownCounterNode) PersistState() *UpDownCounterNode {
+	n.PersistStateFlag = true
+	return n
+}
+
+func (n *UpDownCounterNode) validate() error {
+	if n.Field == "" {
+		return errors.New("must provide a field")
+	}
+	if n.As == "" {
+		return errors.New("must provide a name for the total")
+	}
+	if n.Every < 0 {
+		return errors.New("every cannot be negative")
+	}
+	if n.Every > 0 && n.Provides() != StreamEdge {
+		return errors.New("every is only supported with stream data")
+	}
+	return nil
+}
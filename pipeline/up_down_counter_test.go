@@ -0,0 +1,83 @@
+package pipeline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUpDownCounterNode_MarshalJSON(t *testing.T) {
+	c := newUpDownCounterNode(StreamEdge, "delta")
+	c.Every = time.Minute
+	c.Floor().PersistState()
+	MarshalTestHelper(t, c, false, `{"typeOf":"upDownCounter","id":"0","field":"delta","as":"total","floor":true,"resetOnBarrier":false,"persistState":true,"every":"1m"}`)
+}
+
+func TestUpDownCounterNode_UnmarshalJSON(t *testing.T) {
+	input := `{"typeOf":"upDownCounter","id":"0","field":"delta","as":"open","every":"0s","floor":false,"resetOnBarrier":true}`
+	want := &UpDownCounterNode{
+		Field:              "delta",
+		As:                 "open",
+		ResetOnBarrierFlag: true,
+	}
+	UnmarshalJSONTestHelper(t, []byte(input), &UpDownCounterNode{}, false, want)
+}
+
+func TestUpDownCounterNode_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		node    *UpDownCounterNode
+		wantErr bool
+	}{
+		{
+			name: "defaults",
+			node: newUpDownCounterNode(StreamEdge, "delta"),
+		},
+		{
+			name: "every",
+			node: func() *UpDownCounterNode {
+				c := newUpDownCounterNode(StreamEdge, "delta")
+				c.Every = time.Minute
+				return c
+			}(),
+		},
+		{
+			name:    "missing field",
+			node:    newUpDownCounterNode(StreamEdge, ""),
+			wantErr: true,
+		},
+		{
+			name: "missing as",
+			node: func() *UpDownCounterNode {
+				c := newUpDownCounterNode(StreamEdge, "delta")
+				c.As = ""
+				return c
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "negative every",
+			node: func() *UpDownCounterNode {
+				c := newUpDownCounterNode(StreamEdge, "delta")
+				c.Every = -time.Minute
+				return c
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "every with batch data",
+			node: func() *UpDownCounterNode {
+				c := newUpDownCounterNode(BatchEdge, "delta")
+				c.Every = time.Minute
+				return c
+			}(),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.node.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
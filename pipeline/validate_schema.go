@@ -0,0 +1,164 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/influxdata/influxdb/influxql"
+	"github.com/influxdata/kapacitor/avro"
+)
+
+// A ValidateSchemaNode validates each point against an Avro record schema
+// and drops the points that do not conform to it.
+// The schema is either given inline or is the latest version of a subject in a Confluent schema registry.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('orders')
+//        |validateSchema()
+//            .registry('http://schema-registry.example.com:8081')
+//            .subject('orders-value')
+//            .deadLetter('influxdb', 'quarantine.autogen.orders')
+//        |influxDBOut()
+//            .database('orders')
+//
+// The above example passes on the orders that conform to the latest schema of the subject `orders-value`
+// and writes the others, annotated with the reason, to the measurement `quarantine.autogen.orders`.
+//
+// A point conforms to the schema if it can be encoded with the schema like the avroOut node does:
+// the value of each record field is the field of the point, or else its tag, with the name of the record field,
+// and the `time` field is the time of the point.
+// Each record field that is not nullable must have a value, and each value must match one of the types of its record field.
+// With strict, the point must not have fields that are not in the schema.
+// Points that do not conform are sent to the dead letter queue of the node, if any, and dropped.
+//
+// The latest schema of the subject is fetched from the registry when the task starts;
+// the task fails to start if the registry cannot be reached.
+// Only Avro schemas of records with fields of primitive types, and unions of them, are supported.
+//
+// Available Statistics:
+//
+//    * valid -- number of points that conform to the schema
+//    * invalid -- number of points that do not conform to the schema
+//    * missing_fields -- number of points without a value for a field that is not nullable
+//    * type_mismatches -- number of points with a value that does not match the type of its field
+//    * unknown_fields -- number of points with fields that are not in the schema, with strict
+//
+type ValidateSchemaNode struct {
+	chainnode `json:"-"`
+
+	// URL of the schema registry.
+	// User and password may be given in the URL for basic authentication.
+	// tick:ignore
+	RegistryURL string `tick:"Registry" json:"registry"`
+
+	// The subject of the schema in the registry.
+	Subject string `json:"subject"`
+
+	// The Avro schema of the points as JSON, instead of a schema from the registry.
+	Schema string `json:"schema"`
+
+	// Timeout of the requests to the schema registry.
+	// Default: 5s
+	Timeout time.Duration `json:"timeout"`
+
+	// Whether points with fields that are not in the schema are invalid.
+	// tick:ignore
+	StrictFlag bool `tick:"Strict" json:"strict"`
+}
+
+func newValidateSchemaNode(wants EdgeType) *ValidateSchemaNode {
+	return &ValidateSchemaNode{
+		chainnode: newBasicChainNode("validate_schema", wants, wants),
+		Timeout:   5 * time.Second,
+	}
+}
+
+// MarshalJSON converts ValidateSchemaNode to JSON
+// tick:ignore
+func (n *ValidateSchemaNode) MarshalJSON() ([]byte, error) {
+	type Alias ValidateSchemaNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Timeout string `json:"timeout"`
+	}{
+		TypeOf: TypeOf{
+			Type: "validateSchema",
+			ID:   n.ID(),
+		},
+		Alias:   (*Alias)(n),
+		Timeout: influxql.FormatDuration(n.Timeout),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to a ValidateSchemaNode
+// tick:ignore
+func (n *ValidateSchemaNode) UnmarshalJSON(data []byte) error {
+	type Alias ValidateSchemaNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Timeout string `json:"timeout"`
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "validateSchema" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as ValidateSchemaNode", raw.ID, raw.Type)
+	}
+	n.Timeout, err = influxql.ParseDuration(raw.Timeout)
+	if err != nil {
+		return err
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+// URL of the schema registry.
+// tick:property
+func (n *ValidateSchemaNode) Registry(url string) *ValidateSchemaNode {
+	n.RegistryURL = url
+	return n
+}
+
+// Treat points with fields that are not in the schema as invalid.
+// Tags are not checked.
+// tick:property
+func (n *ValidateSchemaNode) Strict() *ValidateSchemaNode {
+	n.StrictFlag = true
+	return n
+}
+
+func (n *ValidateSchemaNode) validate() error {
+	if n.Schema != "" {
+		if n.RegistryURL != "" || n.Subject != "" {
+			return errors.New("must not provide both a schema and a registry")
+		}
+		if _, err := avro.ParseSchema(n.Schema); err != nil {
+			return err
+		}
+		return nil
+	}
+	if n.RegistryURL == "" {
+		return errors.New("must provide a schema or a registry url")
+	}
+	if _, err := url.Parse(n.RegistryURL); err != nil {
+		return fmt.Errorf("invalid registry url: %v", err)
+	}
+	if n.Subject == "" {
+		return errors.New("must provide a subject")
+	}
+	if n.Timeout <= 0 {
+		return errors.New("timeout must be greater than 0")
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package pipeline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidateSchemaNode_MarshalJSON(t *testing.T) {
+	v := newValidateSchemaNode(StreamEdge)
+	v.Registry("http://localhost:8081")
+	v.Subject = "orders-value"
+	v.Timeout = 10 * time.Second
+	v.Strict()
+	MarshalTestHelper(t, v, false, `{"typeOf":"validateSchema","id":"0","registry":"http://localhost:8081","subject":"orders-value","schema":"","strict":true,"timeout":"10s"}`)
+}
+
+func TestValidateSchemaNode_UnmarshalJSON(t *testing.T) {
+	input := `{"typeOf":"validateSchema","id":"0","registry":"","subject":"","schema":"{\"type\":\"record\",\"name\":\"orders\",\"fields\":[]}","timeout":"5s","strict":false}`
+	want := &ValidateSchemaNode{
+		Schema:  `{"type":"record","name":"orders","fields":[]}`,
+		Timeout: 5 * time.Second,
+	}
+	UnmarshalJSONTestHelper(t, []byte(input), &ValidateSchemaNode{}, false, want)
+}
+
+func TestValidateSchemaNode_Validate(t *testing.T) {
+	const schema = `{"type":"record","name":"orders","fields":[{"name":"amount","type":"double"}]}`
+	newNode := func(f func(v *ValidateSchemaNode)) *ValidateSchemaNode {
+		v := newValidateSchemaNode(StreamEdge)
+		f(v)
+		return v
+	}
+	tests := []struct {
+		name    string
+		node    *ValidateSchemaNode
+		wantErr bool
+	}{
+		{
+			name: "registry",
+			node: newNode(func(v *ValidateSchemaNode) {
+				v.Registry("http://localhost:8081")
+				v.Subject = "orders-value"
+			}),
+		},
+		{
+			name: "schema",
+			node: newNode(func(v *ValidateSchemaNode) { v.Schema = schema }),
+		},
+		{
+			name:    "neither",
+			node:    newNode(func(*ValidateSchemaNode) {}),
+			wantErr: true,
+		},
+		{
+			name: "both",
+			node: newNode(func(v *ValidateSchemaNode) {
+				v.Schema = schema
+				v.Registry("http://localhost:8081")
+			}),
+			wantErr: true,
+		},
+		{
+			name:    "empty subject",
+			node:    newNode(func(v *ValidateSchemaNode) { v.Registry("http://localhost:8081") }),
+			wantErr: true,
+		},
+		{
+			name:    "invalid schema",
+			node:    newNode(func(v *ValidateSchemaNode) { v.Schema = `{"type":"enum","name":"orders"}` }),
+			wantErr: true,
+		},
+		{
+			name: "zero timeout",
+			node: newNode(func(v *ValidateSchemaNode) {
+				v.Registry("http://localhost:8081")
+				v.Subject = "orders-value"
+				v.Timeout = 0
+			}),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.node.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxdb/influxql"
+)
+
+const (
+	ValidateTimeDrop  = "drop"
+	ValidateTimeClamp = "clamp"
+)
+
+// A ValidateTimeNode rejects points with implausible timestamps,
+// i.e. timestamps too far in the future or past relative to the current time.
+// Such points typically come from clients with bad clocks and can disturb windows and retention.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('cpu')
+//        |validateTime()
+//            .maxFuture(1h)
+//            .maxPast(7d)
+//        |window()
+//            .period(1m)
+//            .every(1m)
+//
+// The above example drops all points more than an hour in the future or more than a week in the past.
+//
+// The action determines what happens with points outside the bounds:
+//
+//    * drop -- drop the point.
+//    * clamp -- set the time of the point to the nearest bound.
+//
+// Bounds are checked against the wall clock of the Kapacitor host,
+// so this node should not be used when replaying recordings of old data.
+//
+// Available Statistics:
+//
+//    * bad_timestamps -- number of points with timestamps outside the bounds
+//
+type ValidateTimeNode struct {
+	chainnode `json:"-"`
+
+	// The maximum duration the time of a point may be ahead of the current time.
+	// If zero the time of points is not checked against a future bound.
+	MaxFuture time.Duration `json:"maxFuture"`
+
+	// The maximum duration the time of a point may be behind the current time.
+	// If zero the time of points is not checked against a past bound.
+	MaxPast time.Duration `json:"maxPast"`
+
+	// What to do with points outside the bounds, one of drop or clamp.
+	// Default: drop
+	Action string `json:"action"`
+}
+
+func newValidateTimeNode(wants EdgeType) *ValidateTimeNode {
+	return &ValidateTimeNode{
+		chainnode: newBasicChainNode("validate_time", wants, wants),
+		Action:    ValidateTimeDrop,
+	}
+}
+
+// MarshalJSON converts ValidateTimeNode to JSON
+// tick:ignore
+func (n *ValidateTimeNode) MarshalJSON() ([]byte, error) {
+	type Alias ValidateTimeNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		MaxFuture string `json:"maxFuture"`
+		MaxPast   string `json:"maxPast"`
+	}{
+		TypeOf: TypeOf{
+			Type: "validateTime",
+			ID:   n.ID(),
+		},
+		Alias:     (*Alias)(n),
+		MaxFuture: influxql.FormatDuration(n.MaxFuture),
+		MaxPast:   influxql.FormatDuration(n.MaxPast),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an ValidateTimeNode
+// tick:ignore
+func (n *ValidateTimeNode) UnmarshalJSON(data []byte) error {
+	type Alias ValidateTimeNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		MaxFuture string `json:"maxFuture"`
+		MaxPast   string `json:"maxPast"`
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "validateTime" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as ValidateTimeNode", raw.ID, raw.Type)
+	}
+	n.MaxFuture, err = influxql.ParseDuration(raw.MaxFuture)
+	if err != nil {
+		return err
+	}
+	n.MaxPast, err = influxql.ParseDuration(raw.MaxPast)
+	if err != nil {
+		return err
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+func (n *ValidateTimeNode) validate() error {
+	if n.MaxFuture < 0 {
+		return errors.New("maxFuture cannot be negative")
+	}
+	if n.MaxPast < 0 {
+		return errors.New("maxPast cannot be negative")
+	}
+	if n.MaxFuture == 0 && n.MaxPast == 0 {
+		return errors.New("must provide at least one of maxFuture or maxPast")
+	}
+	switch n.Action {
+	case ValidateTimeDrop, ValidateTimeClamp:
+	default:
+		return fmt.Errorf("invalid action %q, must be one of %s or %s", n.Action, ValidateTimeDrop, ValidateTimeClamp)
+	}
+	return nil
+}
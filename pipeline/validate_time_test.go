@@ -0,0 +1,78 @@
+package pipeline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidateTimeNode_MarshalJSON(t *testing.T) {
+	v := newValidateTimeNode(StreamEdge)
+	v.MaxFuture = time.Hour
+	v.MaxPast = 24 * time.Hour
+	v.Action = ValidateTimeClamp
+	MarshalTestHelper(t, v, false, `{"typeOf":"validateTime","id":"0","action":"clamp","maxFuture":"1h","maxPast":"1d"}`)
+}
+
+func TestValidateTimeNode_UnmarshalJSON(t *testing.T) {
+	input := `{"typeOf":"validateTime","id":"0","action":"drop","maxFuture":"10m","maxPast":"0s"}`
+	want := &ValidateTimeNode{
+		MaxFuture: 10 * time.Minute,
+		Action:    ValidateTimeDrop,
+	}
+	UnmarshalJSONTestHelper(t, []byte(input), &ValidateTimeNode{}, false, want)
+}
+
+func TestValidateTimeNode_Validate(t *testing.T) {
+	newNode := func(maxFuture, maxPast time.Duration, action string) *ValidateTimeNode {
+		v := newValidateTimeNode(StreamEdge)
+		v.MaxFuture = maxFuture
+		v.MaxPast = maxPast
+		v.Action = action
+		return v
+	}
+	tests := []struct {
+		name    string
+		node    *ValidateTimeNode
+		wantErr bool
+	}{
+		{
+			name: "both bounds",
+			node: newNode(time.Hour, time.Hour, ValidateTimeDrop),
+		},
+		{
+			name: "only future",
+			node: newNode(time.Hour, 0, ValidateTimeClamp),
+		},
+		{
+			name: "only past",
+			node: newNode(0, time.Hour, ValidateTimeDrop),
+		},
+		{
+			name:    "no bounds",
+			node:    newNode(0, 0, ValidateTimeDrop),
+			wantErr: true,
+		},
+		{
+			name:    "negative maxFuture",
+			node:    newNode(-time.Hour, time.Hour, ValidateTimeDrop),
+			wantErr: true,
+		},
+		{
+			name:    "negative maxPast",
+			node:    newNode(time.Hour, -time.Hour, ValidateTimeDrop),
+			wantErr: true,
+		},
+		{
+			name:    "invalid action",
+			node:    newNode(time.Hour, time.Hour, "ignore"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.node.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
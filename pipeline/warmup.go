@@ -0,0 +1,113 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxdb/influxql"
+)
+
+// Suppress the output of the parent node until each group has warmed up.
+// Stateful nodes, like moving averages and percentiles, emit unreliable values
+// until they have seen enough data, which can cause spurious alerts after a task starts.
+//
+// A group has warmed up once it has seen data spanning the period,
+// measured from the time of its first point, or once it has seen count points.
+// If both are set, whichever is reached first ends the warm-up.
+// Each group warms up independently, a new group starts its own warm-up.
+//
+// Batches are passed or suppressed as a whole, depending on whether the group
+// had warmed up when the batch began.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('cpu')
+//            .groupBy('host')
+//        |movingAverage('usage_idle', 10)
+//        |warmup()
+//            .count(10)
+//        |alert()
+//            .crit(lambda: "movingAverage" < 10)
+//
+// The above example only alerts on moving averages computed from 10 points.
+//
+// Available Statistics:
+//
+//    * points_suppressed -- number of points suppressed during warm-up
+//
+type WarmupNode struct {
+	chainnode `json:"-"`
+
+	// The duration of data a group must see before its output is emitted.
+	Period time.Duration `json:"period"`
+
+	// The number of points a group must see before its output is emitted.
+	Count int64 `json:"count"`
+}
+
+func newWarmupNode(wants EdgeType) *WarmupNode {
+	return &WarmupNode{
+		chainnode: newBasicChainNode("warmup", wants, wants),
+	}
+}
+
+// MarshalJSON converts WarmupNode to JSON
+// tick:ignore
+func (n *WarmupNode) MarshalJSON() ([]byte, error) {
+	type Alias WarmupNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Period string `json:"period"`
+	}{
+		TypeOf: TypeOf{
+			Type: "warmup",
+			ID:   n.ID(),
+		},
+		Alias:  (*Alias)(n),
+		Period: influxql.FormatDuration(n.Period),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an WarmupNode
+// tick:ignore
+func (n *WarmupNode) UnmarshalJSON(data []byte) error {
+	type Alias WarmupNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Period string `json:"period"`
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "warmup" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as WarmupNode", raw.ID, raw.Type)
+	}
+	n.Period, err = influxql.ParseDuration(raw.Period)
+	if err != nil {
+		return err
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+func (n *WarmupNode) validate() error {
+	if n.Period < 0 {
+		return errors.New("period must not be negative")
+	}
+	if n.Count < 0 {
+		return errors.New("count must not be negative")
+	}
+	if n.Period == 0 && n.Count == 0 {
+		return errors.New("must set period or count")
+	}
+	return nil
+}
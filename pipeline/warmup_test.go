@@ -0,0 +1,71 @@
+package pipeline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWarmupNode_MarshalJSON(t *testing.T) {
+	w := newWarmupNode(StreamEdge)
+	w.Period = 5 * time.Minute
+	w.Count = 10
+	MarshalTestHelper(t, w, false, `{"typeOf":"warmup","id":"0","count":10,"period":"5m"}`)
+}
+
+func TestWarmupNode_UnmarshalJSON(t *testing.T) {
+	input := `{"typeOf":"warmup","id":"0","count":10,"period":"5m"}`
+	want := &WarmupNode{
+		Period: 5 * time.Minute,
+		Count:  10,
+	}
+	UnmarshalJSONTestHelper(t, []byte(input), &WarmupNode{}, false, want)
+}
+
+func TestWarmupNode_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		period  time.Duration
+		count   int64
+		wantErr bool
+	}{
+		{
+			name:   "period",
+			period: time.Minute,
+		},
+		{
+			name:  "count",
+			count: 10,
+		},
+		{
+			name:   "period and count",
+			period: time.Minute,
+			count:  10,
+		},
+		{
+			name:    "neither",
+			wantErr: true,
+		},
+		{
+			name:    "negative period",
+			period:  -time.Minute,
+			count:   10,
+			wantErr: true,
+		},
+		{
+			name:    "negative count",
+			period:  time.Minute,
+			count:   -1,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := newWarmupNode(StreamEdge)
+			w.Period = tt.period
+			w.Count = tt.count
+			if err := w.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
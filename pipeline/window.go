@@ -7,8 +7,12 @@ import (
 	"time"
 
 	"github.com/influxdata/influxdb/influxql"
+	"github.com/influxdata/kapacitor/tick/ast"
 )
 
+// WindowLateBranch is the name of the branch of a WindowNode receiving late points.
+const WindowLateBranch = "late"
+
 // A `window` node caches data within a moving time range.
 // The `period` property of `window` defines the time range covered by `window`.
 //
@@ -31,6 +35,48 @@ import (
 // new data and `5 minutes` of the previous period's data.
 //
 // NOTE: Because no `align` property is defined, the `window` edge is defined relative to the first data point.
+//
+// Instead of a period or a count, the `periodOrCount` property flushes the window
+// as soon as it contains a number of points or a period of time has elapsed, whichever comes first.
+//
+// Example:
+//    stream
+//        |window()
+//            .periodOrCount(1m, 1000)
+//        |httpOut('recent')
+//
+// This example emits at most `1000` points at a time, and the points of the last `1 minute` if fewer arrived.
+//
+// The `watermark` property closes windows based on the time of the data instead of the arrival of the next point,
+// so that points arriving out of order are included in the windows they belong to.
+//
+// Example:
+//    stream
+//        |window()
+//            .period(1m)
+//            .every(1m)
+//            .watermark(30s)
+//            .lateOutput()
+//
+// This example emits each minute of data once a point at least `30 seconds` past the end of the minute arrived,
+// see the `watermark` and `lateOutput` properties.
+//
+// The `trigger` property flushes the window as soon as a point satisfies a lambda expression,
+// in addition to the emits on the `every` schedule.
+//
+// Example:
+//    stream
+//        |window()
+//            .period(1m)
+//            .every(1m)
+//            .trigger(lambda: "end_of_transaction")
+//
+// This example emits the points of each minute, and the points of a transaction as soon as its last point arrived.
+//
+// Available Statistics:
+//
+//    * late_points -- number of points that arrived after their windows were closed, only with watermark
+//
 type WindowNode struct {
 	chainnode `json:"-"`
 	// The period, or length in time, of the window.
@@ -50,6 +96,39 @@ type WindowNode struct {
 	// EveryCount determines how often the window is emitted based on the count of points.
 	// A value of 1 means that every new point will emit the window.
 	EveryCount int64 `json:"everyCount"`
+
+	// Period after which the window is flushed, unless it reached the FlushCount first.
+	// tick:ignore
+	FlushPeriod time.Duration `json:"flushPeriod" tick:"PeriodOrCount"`
+	// Number of points after which the window is flushed, unless the FlushPeriod elapsed first.
+	// tick:ignore
+	FlushCount int64 `json:"flushCount"`
+
+	// Whether to close windows based on the watermark.
+	// tick:ignore
+	WatermarkFlag bool `json:"watermark" tick:"Watermark"`
+	// How late points may arrive relative to the maximum time seen.
+	// tick:ignore
+	Lateness time.Duration `json:"lateness"`
+	// Whether to send late points to the late branch instead of dropping them.
+	// tick:ignore
+	LateOutputFlag bool `json:"lateOutput" tick:"LateOutput"`
+
+	// Whether to emit the last point of the previous window when a window is empty.
+	// tick:ignore
+	FillLastFlag bool `json:"fillLast" tick:"FillLast"`
+	// Maximum number of consecutive empty windows to fill, zero means no limit.
+	// Requires the fillLast property.
+	FillLastMax int64 `json:"fillLastMax"`
+
+	// Lambda expression flushing the window when a point satisfies it.
+	// The window is flushed with the point, as a batch with the time of the point,
+	// and the flushed points are not part of later windows.
+	// Requires period and every and cannot be combined with the count, periodOrCount or watermark properties.
+	Trigger *ast.LambdaNode `json:"trigger"`
+	// Whether to schedule the next emit one every after a trigger flush.
+	// tick:ignore
+	TriggerRealignFlag bool `json:"triggerRealign" tick:"TriggerRealign"`
 }
 
 func newWindowNode() *WindowNode {
@@ -65,16 +144,20 @@ func (n *WindowNode) MarshalJSON() ([]byte, error) {
 	var raw = &struct {
 		TypeOf
 		*Alias
-		Period string `json:"period"`
-		Every  string `json:"every"`
+		Period      string `json:"period"`
+		Every       string `json:"every"`
+		FlushPeriod string `json:"flushPeriod"`
+		Lateness    string `json:"lateness"`
 	}{
 		TypeOf: TypeOf{
 			Type: "window",
 			ID:   n.ID(),
 		},
-		Alias:  (*Alias)(n),
-		Period: influxql.FormatDuration(n.Period),
-		Every:  influxql.FormatDuration(n.Every),
+		Alias:       (*Alias)(n),
+		Period:      influxql.FormatDuration(n.Period),
+		Every:       influxql.FormatDuration(n.Every),
+		FlushPeriod: influxql.FormatDuration(n.FlushPeriod),
+		Lateness:    influxql.FormatDuration(n.Lateness),
 	}
 	return json.Marshal(raw)
 }
@@ -86,8 +169,10 @@ func (n *WindowNode) UnmarshalJSON(data []byte) error {
 	var raw = &struct {
 		TypeOf
 		*Alias
-		Period string `json:"period"`
-		Every  string `json:"every"`
+		Period      string `json:"period"`
+		Every       string `json:"every"`
+		FlushPeriod string `json:"flushPeriod"`
+		Lateness    string `json:"lateness"`
 	}{
 		Alias: (*Alias)(n),
 	}
@@ -109,6 +194,22 @@ func (n *WindowNode) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
+	// The flush period is absent from pipelines created before it existed.
+	if raw.FlushPeriod != "" {
+		n.FlushPeriod, err = influxql.ParseDuration(raw.FlushPeriod)
+		if err != nil {
+			return err
+		}
+	}
+
+	// The lateness is absent from pipelines created before it existed.
+	if raw.Lateness != "" {
+		n.Lateness, err = influxql.ParseDuration(raw.Lateness)
+		if err != nil {
+			return err
+		}
+	}
+
 	n.setID(raw.ID)
 	return nil
 }
@@ -133,7 +234,177 @@ func (w *WindowNode) FillPeriod() *WindowNode {
 	return w
 }
 
+// PeriodOrCount flushes the window when it contains count points or when period has elapsed
+// since its first point, whichever comes first.
+// Both are reset when the window is flushed, the windows do not overlap.
+//
+// A point whose time is exactly the end of the period is not part of the window,
+// it flushes the window and becomes the first point of the next one.
+// A window flushed by its period has the time of the end of the period,
+// a window flushed by its count has the time of its last point.
+//
+// PeriodOrCount cannot be combined with the other window properties.
+// tick:property
+func (w *WindowNode) PeriodOrCount(period time.Duration, count int64) *WindowNode {
+	w.FlushPeriod = period
+	w.FlushCount = count
+	return w
+}
+
+// Watermark closes each window once the watermark, the maximum time of the points seen minus the lateness,
+// passes the end of the window.
+// Points that arrive out of order within the lateness are included in their windows.
+//
+// The windows are aligned with every, i.e. they start at multiples of every and last for the period.
+// Each window is emitted once with all its points in time order, windows without points are not emitted.
+// A point is late if all windows it belongs to have been closed, late points are dropped
+// unless the `lateOutput` property is set.
+//
+// Watermark requires both period and every to be set.
+// tick:property
+func (w *WindowNode) Watermark(lateness time.Duration) *WindowNode {
+	w.WatermarkFlag = true
+	w.Lateness = lateness
+	return w
+}
+
+// Send late points to the `late` branch instead of dropping them.
+// Each late point is emitted as a batch of its own, with the time of the point.
+// Requires the watermark property.
+//
+// Example:
+//    var w = stream
+//        |window()
+//            .period(1m)
+//            .every(1m)
+//            .watermark(30s)
+//            .lateOutput()
+//
+//    w
+//        |branch('late')
+//        |log()
+//
+// tick:property
+func (w *WindowNode) LateOutput() *WindowNode {
+	w.LateOutputFlag = true
+	return w
+}
+
+// FillLast emits the last point of the previous window when a window is empty,
+// so that step-function metrics like gauges stay continuous while a series is quiet.
+// The point is emitted with the time of the empty window, its fields and tags are unchanged.
+// Windows are filled until a window contains points again,
+// or until `fillLastMax` consecutive empty windows have been filled if it is greater than zero.
+//
+// Example:
+//    stream
+//        |window()
+//            .period(1m)
+//            .every(1m)
+//            .fillLast()
+//            .fillLastMax(10)
+//
+// This example emits the last known point for up to ten minutes after a series went quiet.
+//
+// Windows are only closed when points or barriers of their group arrive,
+// use a barrier node to close the windows of quiet groups.
+// FillLast requires a period and cannot be combined with the count, periodOrCount or watermark properties.
+// tick:property
+func (w *WindowNode) FillLast() *WindowNode {
+	w.FillLastFlag = true
+	return w
+}
+
+// TriggerRealign schedules the next emit one `every` after each flush by the trigger,
+// instead of continuing with the unchanged schedule.
+// Requires the trigger property and cannot be combined with align.
+// tick:property
+func (w *WindowNode) TriggerRealign() *WindowNode {
+	w.TriggerRealignFlag = true
+	return w
+}
+
+// Select the branch receiving the late points, the only branch is `late`.
+// Requires the lateOutput property.
+func (w *WindowNode) Branch(name string) *SplitBranchNode {
+	br := newSplitBranchNode(w.Provides(), name)
+	w.linkChild(br)
+	return br
+}
+
 func (w *WindowNode) validate() error {
+	if w.WatermarkFlag {
+		if w.Period <= 0 || w.Every <= 0 {
+			return errors.New("watermark requires period and every to be greater than zero")
+		}
+		if w.Lateness < 0 {
+			return errors.New("watermark lateness cannot be negative")
+		}
+		if w.PeriodCount != 0 || w.EveryCount != 0 || w.FillPeriodFlag {
+			return errors.New("cannot combine watermark with count or fillPeriod window properties")
+		}
+	}
+	if w.LateOutputFlag && !w.WatermarkFlag {
+		return errors.New("lateOutput requires watermark")
+	}
+	hasBranch := false
+	for _, c := range w.Children() {
+		br, ok := c.(*SplitBranchNode)
+		if !ok {
+			continue
+		}
+		if br.BranchName != WindowLateBranch {
+			return fmt.Errorf("unknown window branch %q, the only branch is %q", br.BranchName, WindowLateBranch)
+		}
+		if !w.LateOutputFlag {
+			return errors.New("the late branch requires lateOutput")
+		}
+		hasBranch = true
+	}
+	if w.LateOutputFlag && !hasBranch {
+		return errors.New("lateOutput requires a late branch")
+	}
+	if w.FlushPeriod != 0 || w.FlushCount != 0 {
+		if w.FlushPeriod <= 0 {
+			return errors.New("periodOrCount period must be greater than zero")
+		}
+		if w.FlushCount <= 0 {
+			return errors.New("periodOrCount count must be greater than zero")
+		}
+		if w.Period != 0 || w.Every != 0 || w.PeriodCount != 0 || w.EveryCount != 0 || w.AlignFlag || w.FillPeriodFlag {
+			return errors.New("cannot combine periodOrCount with other window properties")
+		}
+	}
+	if w.FillLastFlag {
+		if w.Period <= 0 {
+			return errors.New("fillLast requires period to be greater than zero")
+		}
+		if w.WatermarkFlag || w.FlushPeriod != 0 || w.PeriodCount != 0 {
+			return errors.New("cannot combine fillLast with count, periodOrCount or watermark window properties")
+		}
+	}
+	if w.FillLastMax < 0 {
+		return errors.New("fillLastMax cannot be negative")
+	}
+	if w.FillLastMax != 0 && !w.FillLastFlag {
+		return errors.New("fillLastMax requires fillLast")
+	}
+	if w.Trigger != nil {
+		if w.Period <= 0 || w.Every <= 0 {
+			return errors.New("trigger requires period and every to be greater than zero")
+		}
+		if w.WatermarkFlag || w.FlushPeriod != 0 || w.PeriodCount != 0 {
+			return errors.New("cannot combine trigger with count, periodOrCount or watermark window properties")
+		}
+	}
+	if w.TriggerRealignFlag {
+		if w.Trigger == nil {
+			return errors.New("triggerRealign requires trigger")
+		}
+		if w.AlignFlag {
+			return errors.New("cannot combine triggerRealign with align")
+		}
+	}
 	if w.PeriodCount != 0 && w.Period != 0 {
 		return errors.New("cannot specify both period and periodCount")
 	}
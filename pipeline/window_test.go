@@ -5,6 +5,9 @@ import (
 	"reflect"
 	"testing"
 	"time"
+
+	"github.com/influxdata/kapacitor/tick/ast"
+	"github.com/influxdata/kapacitor/tick/stateful"
 )
 
 func TestWindowNode_MarshalJSON(t *testing.T) {
@@ -32,7 +35,7 @@ func TestWindowNode_MarshalJSON(t *testing.T) {
 				PeriodCount:    1,
 				EveryCount:     2,
 			},
-			want: `{"typeOf":"window","id":"0","align":true,"fillPeriod":true,"periodCount":1,"everyCount":2,"period":"1h","every":"1m"}`,
+			want: `{"typeOf":"window","id":"0","align":true,"fillPeriod":true,"periodCount":1,"everyCount":2,"flushCount":0,"watermark":false,"lateOutput":false,"fillLast":false,"fillLastMax":0,"trigger":null,"triggerRealign":false,"period":"1h","every":"1m","flushPeriod":"0s","lateness":"0s"}`,
 		},
 		{
 			name: "only period and every",
@@ -40,7 +43,7 @@ func TestWindowNode_MarshalJSON(t *testing.T) {
 				Period: time.Hour,
 				Every:  time.Minute,
 			},
-			want: `{"typeOf":"window","id":"0","align":false,"fillPeriod":false,"periodCount":0,"everyCount":0,"period":"1h","every":"1m"}`,
+			want: `{"typeOf":"window","id":"0","align":false,"fillPeriod":false,"periodCount":0,"everyCount":0,"flushCount":0,"watermark":false,"lateOutput":false,"fillLast":false,"fillLastMax":0,"trigger":null,"triggerRealign":false,"period":"1h","every":"1m","flushPeriod":"0s","lateness":"0s"}`,
 		},
 	}
 	for _, tt := range tests {
@@ -97,6 +100,25 @@ func TestWindowNode_UnmarshalJSON(t *testing.T) {
 				Every:  time.Minute,
 			},
 		},
+		{
+			name:  "period or count",
+			input: `{"typeOf":"window","id":"0","period":"0s","every":"0s","flushPeriod":"1m","flushCount":100}`,
+			want: &WindowNode{
+				FlushPeriod: time.Minute,
+				FlushCount:  100,
+			},
+		},
+		{
+			name:  "watermark",
+			input: `{"typeOf":"window","id":"0","period":"1m","every":"1m","watermark":true,"lateness":"30s","lateOutput":true}`,
+			want: &WindowNode{
+				Period:         time.Minute,
+				Every:          time.Minute,
+				WatermarkFlag:  true,
+				Lateness:       30 * time.Second,
+				LateOutputFlag: true,
+			},
+		},
 		{
 			name:    "invalid data",
 			input:   `{"typeOf":"window","id":"0", "period": "invalid"}`,
@@ -131,3 +153,348 @@ func TestWindowNode_UnmarshalJSON(t *testing.T) {
 	}
 
 }
+
+func TestWindowNode_ValidatePeriodOrCount(t *testing.T) {
+	tests := []struct {
+		name    string
+		window  func(w *WindowNode)
+		wantErr bool
+	}{
+		{
+			name:   "period or count",
+			window: func(w *WindowNode) { w.PeriodOrCount(time.Minute, 100) },
+		},
+		{
+			name:    "zero period",
+			window:  func(w *WindowNode) { w.PeriodOrCount(0, 100) },
+			wantErr: true,
+		},
+		{
+			name:    "zero count",
+			window:  func(w *WindowNode) { w.PeriodOrCount(time.Minute, 0) },
+			wantErr: true,
+		},
+		{
+			name: "combined with period",
+			window: func(w *WindowNode) {
+				w.PeriodOrCount(time.Minute, 100)
+				w.Period = time.Minute
+			},
+			wantErr: true,
+		},
+		{
+			name: "combined with align",
+			window: func(w *WindowNode) {
+				w.PeriodOrCount(time.Minute, 100)
+				w.Align()
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := newWindowNode()
+			tt.window(w)
+			if err := w.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("WindowNode.validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWindowNode_ValidateWatermark(t *testing.T) {
+	tests := []struct {
+		name    string
+		window  func(w *WindowNode)
+		wantErr bool
+	}{
+		{
+			name: "watermark",
+			window: func(w *WindowNode) {
+				w.Period = time.Minute
+				w.Every = time.Minute
+				w.Watermark(30 * time.Second)
+			},
+		},
+		{
+			name: "missing every",
+			window: func(w *WindowNode) {
+				w.Period = time.Minute
+				w.Watermark(30 * time.Second)
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative lateness",
+			window: func(w *WindowNode) {
+				w.Period = time.Minute
+				w.Every = time.Minute
+				w.Watermark(-time.Second)
+			},
+			wantErr: true,
+		},
+		{
+			name: "combined with fillPeriod",
+			window: func(w *WindowNode) {
+				w.Period = time.Minute
+				w.Every = time.Minute
+				w.Watermark(0).FillPeriod()
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := newWindowNode()
+			tt.window(w)
+			if err := w.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("WindowNode.validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWindowNode_ValidateFillLast(t *testing.T) {
+	tests := []struct {
+		name    string
+		window  func(w *WindowNode)
+		wantErr bool
+	}{
+		{
+			name: "fillLast",
+			window: func(w *WindowNode) {
+				w.Period = time.Minute
+				w.Every = time.Minute
+				w.FillLast()
+			},
+		},
+		{
+			name: "fillLast with max",
+			window: func(w *WindowNode) {
+				w.Period = time.Minute
+				w.Every = time.Minute
+				w.FillLast().FillLastMax = 3
+			},
+		},
+		{
+			name: "negative max",
+			window: func(w *WindowNode) {
+				w.Period = time.Minute
+				w.Every = time.Minute
+				w.FillLast().FillLastMax = -1
+			},
+			wantErr: true,
+		},
+		{
+			name: "max without fillLast",
+			window: func(w *WindowNode) {
+				w.Period = time.Minute
+				w.Every = time.Minute
+				w.FillLastMax = 3
+			},
+			wantErr: true,
+		},
+		{
+			name: "count window",
+			window: func(w *WindowNode) {
+				w.PeriodCount = 10
+				w.EveryCount = 10
+				w.FillLast()
+			},
+			wantErr: true,
+		},
+		{
+			name: "combined with watermark",
+			window: func(w *WindowNode) {
+				w.Period = time.Minute
+				w.Every = time.Minute
+				w.Watermark(0).FillLast()
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := newWindowNode()
+			tt.window(w)
+			if err := w.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("WindowNode.validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWindowNode_ValidateTrigger(t *testing.T) {
+	trigger := &ast.LambdaNode{Expression: &ast.ReferenceNode{Reference: "end"}}
+	tests := []struct {
+		name    string
+		window  func(w *WindowNode)
+		wantErr bool
+	}{
+		{
+			name: "trigger",
+			window: func(w *WindowNode) {
+				w.Period = time.Minute
+				w.Every = time.Minute
+				w.Trigger = trigger
+			},
+		},
+		{
+			name: "trigger with realign",
+			window: func(w *WindowNode) {
+				w.Period = time.Minute
+				w.Every = time.Minute
+				w.Trigger = trigger
+				w.TriggerRealign()
+			},
+		},
+		{
+			name: "missing every",
+			window: func(w *WindowNode) {
+				w.Period = time.Minute
+				w.Trigger = trigger
+			},
+			wantErr: true,
+		},
+		{
+			name: "count window",
+			window: func(w *WindowNode) {
+				w.PeriodCount = 10
+				w.EveryCount = 10
+				w.Trigger = trigger
+			},
+			wantErr: true,
+		},
+		{
+			name: "combined with watermark",
+			window: func(w *WindowNode) {
+				w.Period = time.Minute
+				w.Every = time.Minute
+				w.Watermark(0)
+				w.Trigger = trigger
+			},
+			wantErr: true,
+		},
+		{
+			name: "realign without trigger",
+			window: func(w *WindowNode) {
+				w.Period = time.Minute
+				w.Every = time.Minute
+				w.TriggerRealign()
+			},
+			wantErr: true,
+		},
+		{
+			name: "realign with align",
+			window: func(w *WindowNode) {
+				w.Period = time.Minute
+				w.Every = time.Minute
+				w.Trigger = trigger
+				w.Align().TriggerRealign()
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := newWindowNode()
+			tt.window(w)
+			if err := w.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("WindowNode.validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWindowNode_ValidateLateBranch(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  string
+		wantErr string
+	}{
+		{
+			name: "late branch",
+			script: `
+stream
+	|from()
+	|window()
+		.period(1m)
+		.every(1m)
+		.watermark(30s)
+		.lateOutput()
+	|branch('late')
+`,
+		},
+		{
+			name: "unknown branch",
+			script: `
+stream
+	|from()
+	|window()
+		.period(1m)
+		.every(1m)
+		.watermark(30s)
+		.lateOutput()
+	|branch('data')
+`,
+			wantErr: `unknown window branch "data", the only branch is "late"`,
+		},
+		{
+			name: "branch without late output",
+			script: `
+stream
+	|from()
+	|window()
+		.period(1m)
+		.every(1m)
+		.watermark(30s)
+	|branch('late')
+`,
+			wantErr: "the late branch requires lateOutput",
+		},
+		{
+			name: "late output without branch",
+			script: `
+stream
+	|from()
+	|window()
+		.period(1m)
+		.every(1m)
+		.watermark(30s)
+		.lateOutput()
+	|log()
+`,
+			wantErr: "lateOutput requires a late branch",
+		},
+		{
+			name: "late output without watermark",
+			script: `
+stream
+	|from()
+	|window()
+		.period(1m)
+		.every(1m)
+		.lateOutput()
+	|branch('late')
+`,
+			wantErr: "lateOutput requires watermark",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := CreatePipeline(tt.script, StreamEdge, stateful.NewScope(), deadman{}, nil)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected error %q", tt.wantErr)
+			}
+			if err.Error() != tt.wantErr {
+				t.Errorf("unexpected error got %q want %q", err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
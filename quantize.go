@@ -0,0 +1,192 @@
+package kapacitor
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+type QuantizeNode struct {
+	node
+	q *pipeline.QuantizeNode
+
+	batchBuffer *edge.BatchBuffer
+}
+
+// Create a new QuantizeNode which snaps the time of points and batches to a grid.
+func newQuantizeNode(et *ExecutingTask, n *pipeline.QuantizeNode, d NodeDiagnostic) (*QuantizeNode, error) {
+	qn := &QuantizeNode{
+		node:        node{Node: n, et: et, diag: d},
+		q:           n,
+		batchBuffer: new(edge.BatchBuffer),
+	}
+	qn.node.runF = qn.runQuantize
+	return qn, nil
+}
+
+func (n *QuantizeNode) runQuantize([]byte) error {
+	consumer := edge.NewConsumerWithReceiver(
+		n.ins[0],
+		edge.NewReceiverFromForwardReceiverWithStats(
+			n.outs,
+			edge.NewTimedForwardReceiver(n.timer, n),
+		),
+	)
+	return consumer.Consume()
+}
+
+// quantize snaps t to a multiple of interval since the Unix epoch according to mode.
+func quantize(t time.Time, interval time.Duration, mode string) time.Time {
+	ns := t.UnixNano()
+	i := int64(interval)
+	r := ns % i
+	if r < 0 {
+		r += i
+	}
+	floor := ns - r
+	switch mode {
+	case pipeline.QuantizeFloor:
+		ns = floor
+	case pipeline.QuantizeCeil:
+		if r != 0 {
+			ns = floor + i
+		}
+	default:
+		if r >= i-r {
+			ns = floor + i
+		} else {
+			ns = floor
+		}
+	}
+	return time.Unix(0, ns).UTC()
+}
+
+func (n *QuantizeNode) doQuantize(t edge.TimeSetter) {
+	t.SetTime(quantize(t.Time(), n.q.Interval, n.q.Mode))
+}
+
+func (n *QuantizeNode) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	begin = begin.ShallowCopy()
+	n.doQuantize(begin)
+	if n.q.OnCollision == pipeline.QuantizeKeep {
+		return begin, nil
+	}
+	return nil, n.batchBuffer.BeginBatch(begin)
+}
+
+func (n *QuantizeNode) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	bp = bp.ShallowCopy()
+	n.doQuantize(bp)
+	if n.q.OnCollision == pipeline.QuantizeKeep {
+		return bp, nil
+	}
+	return nil, n.batchBuffer.BatchPoint(bp)
+}
+
+func (n *QuantizeNode) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	if n.q.OnCollision == pipeline.QuantizeKeep {
+		return end, nil
+	}
+	batch := n.batchBuffer.BufferedBatchMessage(end)
+	points := resolveCollisions(batch.Points(), n.q.OnCollision)
+	batch.SetPoints(points)
+	batch.Begin().SetSizeHint(len(points))
+	return batch, nil
+}
+
+// resolveCollisions merges points with the same time and tags according to onCollision.
+// The order of the first occurrence of each time and tags is preserved.
+func resolveCollisions(points []edge.BatchPointMessage, onCollision string) []edge.BatchPointMessage {
+	type collision struct {
+		point edge.BatchPointMessage
+		// sums and counts of numeric fields, only used for the mean
+		sums   map[string]float64
+		counts map[string]int
+	}
+	collisions := make(map[string]*collision, len(points))
+	keys := make([]string, 0, len(points))
+	for _, p := range points {
+		key := collisionKey(p.Time(), p.Tags())
+		c, ok := collisions[key]
+		if !ok {
+			c = &collision{point: p}
+			collisions[key] = c
+			keys = append(keys, key)
+			if onCollision != pipeline.QuantizeMean {
+				continue
+			}
+			c.sums = make(map[string]float64, len(p.Fields()))
+			c.counts = make(map[string]int, len(p.Fields()))
+		}
+		switch onCollision {
+		case pipeline.QuantizeFirst:
+		case pipeline.QuantizeLast:
+			c.point = p
+		case pipeline.QuantizeMean:
+			c.point = p
+			for name, value := range p.Fields() {
+				switch v := value.(type) {
+				case float64:
+					c.sums[name] += v
+					c.counts[name]++
+				case int64:
+					c.sums[name] += float64(v)
+					c.counts[name]++
+				}
+			}
+		}
+	}
+	resolved := make([]edge.BatchPointMessage, len(keys))
+	for i, key := range keys {
+		c := collisions[key]
+		if onCollision == pipeline.QuantizeMean {
+			// Non numeric fields are taken from the last point.
+			fields := c.point.Fields().Copy()
+			for name, sum := range c.sums {
+				fields[name] = sum / float64(c.counts[name])
+			}
+			p := c.point.ShallowCopy()
+			p.SetFields(fields)
+			c.point = p
+		}
+		resolved[i] = c.point
+	}
+	return resolved
+}
+
+// collisionKey returns a key uniquely identifying a time and set of tags.
+func collisionKey(t time.Time, tags models.Tags) string {
+	names := make([]string, 0, len(tags))
+	for name := range tags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b bytes.Buffer
+	b.WriteString(strconv.FormatInt(t.UnixNano(), 10))
+	for _, name := range names {
+		b.WriteByte(',')
+		b.WriteString(name)
+		b.WriteByte('=')
+		b.WriteString(tags[name])
+	}
+	return b.String()
+}
+
+func (n *QuantizeNode) Point(p edge.PointMessage) (edge.Message, error) {
+	p = p.ShallowCopy()
+	n.doQuantize(p)
+	return p, nil
+}
+
+func (n *QuantizeNode) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (n *QuantizeNode) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (n *QuantizeNode) Done() {}
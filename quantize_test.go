@@ -0,0 +1,101 @@
+package kapacitor
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+func TestQuantize(t *testing.T) {
+	at := func(s string) time.Time {
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			panic(err)
+		}
+		return t
+	}
+	testCases := []struct {
+		t    string
+		mode string
+		exp  string
+	}{
+		{t: "2018-01-01T00:00:04Z", mode: pipeline.QuantizeFloor, exp: "2018-01-01T00:00:00Z"},
+		{t: "2018-01-01T00:00:04Z", mode: pipeline.QuantizeRound, exp: "2018-01-01T00:00:00Z"},
+		{t: "2018-01-01T00:00:04Z", mode: pipeline.QuantizeCeil, exp: "2018-01-01T00:00:10Z"},
+		{t: "2018-01-01T00:00:05Z", mode: pipeline.QuantizeRound, exp: "2018-01-01T00:00:10Z"},
+		{t: "2018-01-01T00:00:06Z", mode: pipeline.QuantizeFloor, exp: "2018-01-01T00:00:00Z"},
+		{t: "2018-01-01T00:00:10Z", mode: pipeline.QuantizeFloor, exp: "2018-01-01T00:00:10Z"},
+		{t: "2018-01-01T00:00:10Z", mode: pipeline.QuantizeRound, exp: "2018-01-01T00:00:10Z"},
+		{t: "2018-01-01T00:00:10Z", mode: pipeline.QuantizeCeil, exp: "2018-01-01T00:00:10Z"},
+		{t: "1969-12-31T23:59:56Z", mode: pipeline.QuantizeFloor, exp: "1969-12-31T23:59:50Z"},
+		{t: "1969-12-31T23:59:56Z", mode: pipeline.QuantizeRound, exp: "1970-01-01T00:00:00Z"},
+		{t: "1969-12-31T23:59:54Z", mode: pipeline.QuantizeCeil, exp: "1970-01-01T00:00:00Z"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.mode+" "+tc.t, func(t *testing.T) {
+			if got, exp := quantize(at(tc.t), 10*time.Second, tc.mode), at(tc.exp); !got.Equal(exp) {
+				t.Errorf("unexpected time: got %v exp %v", got, exp)
+			}
+		})
+	}
+}
+
+func TestResolveCollisions(t *testing.T) {
+	t0 := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	t1 := t0.Add(10 * time.Second)
+	point := func(tm time.Time, host string, value interface{}, state string) edge.BatchPointMessage {
+		return edge.NewBatchPointMessage(
+			models.Fields{"value": value, "state": state},
+			models.Tags{"host": host},
+			tm,
+		)
+	}
+	points := []edge.BatchPointMessage{
+		point(t0, "A", 1.0, "a"),
+		point(t0, "B", 10.0, "b"),
+		point(t0, "A", int64(2), "c"),
+		point(t1, "A", 4.0, "d"),
+		point(t0, "A", 6.0, "e"),
+	}
+	testCases := []struct {
+		onCollision string
+		exp         []edge.BatchPointMessage
+	}{
+		{
+			onCollision: pipeline.QuantizeFirst,
+			exp: []edge.BatchPointMessage{
+				point(t0, "A", 1.0, "a"),
+				point(t0, "B", 10.0, "b"),
+				point(t1, "A", 4.0, "d"),
+			},
+		},
+		{
+			onCollision: pipeline.QuantizeLast,
+			exp: []edge.BatchPointMessage{
+				point(t0, "A", 6.0, "e"),
+				point(t0, "B", 10.0, "b"),
+				point(t1, "A", 4.0, "d"),
+			},
+		},
+		{
+			onCollision: pipeline.QuantizeMean,
+			exp: []edge.BatchPointMessage{
+				point(t0, "A", 3.0, "e"),
+				point(t0, "B", 10.0, "b"),
+				point(t1, "A", 4.0, "d"),
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.onCollision, func(t *testing.T) {
+			got := resolveCollisions(points, tc.onCollision)
+			if !reflect.DeepEqual(got, tc.exp) {
+				t.Errorf("unexpected points:\ngot %v\nexp %v", got, tc.exp)
+			}
+		})
+	}
+}
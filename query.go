@@ -1,7 +1,9 @@
 package kapacitor
 
 import (
+	"bytes"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/influxdata/influxdb/influxql"
@@ -16,10 +18,35 @@ type Query struct {
 	groupByOffsetDL *influxql.DurationLiteral
 	stmt            *influxql.SelectStatement
 	alignGroup      bool
+
+	// The subqueries of the FROM clause.
+	// The i-th subquery is represented by a placeholder measurement in stmt,
+	// see subqueryPlaceholder.
+	subqueries []*Query
 }
 
 func NewQuery(queryString string) (*Query, error) {
+	return newQuery(queryString, false)
+}
+
+func newQuery(queryString string, subquery bool) (*Query, error) {
 	query := &Query{}
+	// The InfluxQL parser does not support subqueries,
+	// so they are parsed separately and replaced with placeholder measurements.
+	queryString, subqueries, err := extractSubqueries(queryString)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse InfluxQL query")
+	}
+	for _, s := range subqueries {
+		sq, err := newQuery(s, true)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid subquery")
+		}
+		query.subqueries = append(query.subqueries, sq)
+	}
+	if subquery {
+		queryString = addParseTimeRange(queryString)
+	}
 	// Parse and validate query
 	q, err := influxql.ParseQuery(queryString)
 	if err != nil {
@@ -33,6 +60,20 @@ func NewQuery(queryString string) (*Query, error) {
 	if !ok {
 		return nil, fmt.Errorf("query is not a select statement %q", q)
 	}
+	if subquery {
+		// Remove the time range added for parsing,
+		// the time range of subqueries is set from the outer query.
+		cond, _ := query.stmt.Condition.(*influxql.BinaryExpr)
+		query.stmt.Condition = nil
+		// Keep the parentheses so that the condition binds as written once the time range is added.
+		if p, ok := cond.RHS.(*influxql.ParenExpr); ok {
+			query.stmt.Condition = p
+		}
+		if hasTimeCondition(query.stmt.Condition) {
+			return nil, errors.New("subquery must not contain a time condition")
+		}
+		query.findGroupByTime()
+	}
 
 	// Add in time condition nodes
 	query.startTL = &influxql.TimeLiteral{}
@@ -71,16 +112,24 @@ func NewQuery(queryString string) (*Query, error) {
 
 // Return the db rp pairs of the query
 func (q *Query) DBRPs() ([]DBRP, error) {
-	dbrps := make([]DBRP, len(q.stmt.Sources))
-	for i, s := range q.stmt.Sources {
+	dbrps := make([]DBRP, 0, len(q.stmt.Sources))
+	for _, s := range q.stmt.Sources {
 		m, ok := s.(*influxql.Measurement)
 		if !ok {
 			return nil, fmt.Errorf("unknown query source %T", s)
 		}
-		dbrps[i] = DBRP{
+		if sq := q.subquery(m); sq != nil {
+			d, err := sq.DBRPs()
+			if err != nil {
+				return nil, err
+			}
+			dbrps = append(dbrps, d...)
+			continue
+		}
+		dbrps = append(dbrps, DBRP{
 			Database:        m.Database,
 			RetentionPolicy: m.RetentionPolicy,
-		}
+		})
 	}
 	return dbrps, nil
 }
@@ -101,11 +150,17 @@ func (q *Query) SetStartTime(s time.Time) {
 	if q.alignGroup && q.groupByTimeDL != nil && q.groupByOffsetDL != nil {
 		q.groupByOffsetDL.Val = s.Sub(time.Unix(0, 0)) % q.groupByTimeDL.Val
 	}
+	for _, sq := range q.subqueries {
+		sq.SetStartTime(s)
+	}
 }
 
 // Set the stop time of the query
 func (q *Query) SetStopTime(s time.Time) {
 	q.stopTL.Val = s
+	for _, sq := range q.subqueries {
+		sq.SetStopTime(s)
+	}
 }
 
 // Deep clone this query
@@ -114,6 +169,13 @@ func (q *Query) Clone() (*Query, error) {
 		stmt:       q.stmt.Clone(),
 		alignGroup: q.alignGroup,
 	}
+	for _, sq := range q.subqueries {
+		c, err := sq.Clone()
+		if err != nil {
+			return nil, err
+		}
+		n.subqueries = append(n.subqueries, c)
+	}
 	// Find the start/stop time literals
 	var err error
 	influxql.WalkFunc(n.stmt.Condition, func(qlNode influxql.Node) {
@@ -245,6 +307,17 @@ func (q *Query) Dimensions(dims []interface{}) error {
 		}
 	}
 
+	// Each window of the outer query must consist of whole windows of the subqueries,
+	// otherwise the boundaries of the batches do not align with the data of the subqueries.
+	if q.groupByTimeDL != nil {
+		for _, sq := range q.subqueries {
+			if sq.groupByTimeDL != nil && q.groupByTimeDL.Val%sq.groupByTimeDL.Val != 0 {
+				return fmt.Errorf("groupBy time %v must be a multiple of the subquery GROUP BY time %v",
+					q.groupByTimeDL.Val, sq.groupByTimeDL.Val)
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -254,6 +327,9 @@ func (q *Query) IsGroupedByTime() bool {
 
 func (q *Query) AlignGroup() {
 	q.alignGroup = true
+	for _, sq := range q.subqueries {
+		sq.AlignGroup()
+	}
 }
 
 func (q *Query) Fill(option influxql.FillOption, value interface{}) {
@@ -262,7 +338,195 @@ func (q *Query) Fill(option influxql.FillOption, value interface{}) {
 }
 
 func (q *Query) String() string {
-	return q.stmt.String()
+	s := q.stmt.String()
+	for i, sq := range q.subqueries {
+		s = strings.Replace(s, subqueryPlaceholder(i), "("+sq.String()+")", 1)
+	}
+	return s
+}
+
+// subqueryPlaceholder returns the name of the measurement standing in for the i-th subquery.
+func subqueryPlaceholder(i int) string {
+	return fmt.Sprintf("kapacitor_subquery_%d_", i)
+}
+
+// subquery returns the subquery the measurement m stands in for or nil if m is not a placeholder.
+func (q *Query) subquery(m *influxql.Measurement) *Query {
+	if m.Database != "" || m.RetentionPolicy != "" {
+		return nil
+	}
+	for i, sq := range q.subqueries {
+		if m.Name == subqueryPlaceholder(i) {
+			return sq
+		}
+	}
+	return nil
+}
+
+// findGroupByTime finds the GROUP BY time dimension of the statement,
+// so that it can be aligned with the outer query.
+func (q *Query) findGroupByTime() {
+	for _, d := range q.stmt.Dimensions {
+		cn, ok := d.Expr.(*influxql.Call)
+		if !ok || cn.Name != "time" || len(cn.Args) == 0 {
+			continue
+		}
+		dl, ok := cn.Args[0].(*influxql.DurationLiteral)
+		if !ok {
+			continue
+		}
+		if len(cn.Args) == 1 {
+			cn.Args = append(cn.Args, &influxql.DurationLiteral{})
+		}
+		q.groupByTimeDL = dl
+		q.groupByOffsetDL, _ = cn.Args[1].(*influxql.DurationLiteral)
+	}
+}
+
+// hasTimeCondition reports whether the condition references time.
+func hasTimeCondition(cond influxql.Expr) bool {
+	found := false
+	influxql.WalkFunc(cond, func(qlNode influxql.Node) {
+		if vr, ok := qlNode.(*influxql.VarRef); ok && strings.EqualFold(vr.Val, "time") {
+			found = true
+		}
+	})
+	return found
+}
+
+// extractSubqueries replaces the subqueries in the FROM clause of the query with placeholder measurements.
+// It returns the resulting query and the subqueries in order of their placeholders.
+func extractSubqueries(query string) (string, []string, error) {
+	text := newQueryText(query)
+	var (
+		buf        bytes.Buffer
+		subqueries []string
+		inFrom     bool
+		prev       influxql.Token
+		// Position from which the query still needs to be copied
+		copyPos influxql.Pos
+	)
+	s := influxql.NewScanner(strings.NewReader(text.String()))
+	for {
+		tok, pos, _ := s.Scan()
+		switch tok {
+		case influxql.EOF:
+			if len(subqueries) == 0 {
+				return query, nil, nil
+			}
+			buf.WriteString(text.slice(copyPos, text.end()))
+			return buf.String(), subqueries, nil
+		case influxql.WS:
+			continue
+		case influxql.FROM:
+			inFrom = true
+		case influxql.WHERE, influxql.GROUP, influxql.ORDER, influxql.LIMIT, influxql.OFFSET, influxql.SLIMIT, influxql.SOFFSET:
+			inFrom = false
+		case influxql.LPAREN:
+			if !inFrom || (prev != influxql.FROM && prev != influxql.COMMA) {
+				break
+			}
+			// Find the matching closing parenthesis
+			depth := 1
+			var end influxql.Pos
+			for depth > 0 {
+				t, p, _ := s.Scan()
+				switch t {
+				case influxql.LPAREN:
+					depth++
+				case influxql.RPAREN:
+					depth--
+					end = p
+				case influxql.EOF:
+					return "", nil, errors.New("unterminated subquery")
+				}
+			}
+			buf.WriteString(text.slice(copyPos, pos))
+			buf.WriteString(subqueryPlaceholder(len(subqueries)))
+			subqueries = append(subqueries, text.slice(influxql.Pos{Line: pos.Line, Char: pos.Char + 1}, end))
+			copyPos = influxql.Pos{Line: end.Line, Char: end.Char + 1}
+			tok = influxql.RPAREN
+		}
+		prev = tok
+	}
+}
+
+// addParseTimeRange adds a time range to the WHERE clause of the query wrapping any existing condition in parentheses.
+// InfluxQL requires a time range to parse aggregates with a GROUP BY time,
+// subqueries get their time range from the outer query so they are parsed with this placeholder time range instead.
+func addParseTimeRange(query string) string {
+	const timeRange = "time >= 0 AND time < 0"
+	text := newQueryText(query)
+	var (
+		where *influxql.Pos
+		end   = text.end()
+		depth int
+	)
+	s := influxql.NewScanner(strings.NewReader(text.String()))
+scan:
+	for {
+		tok, pos, _ := s.Scan()
+		switch tok {
+		case influxql.EOF:
+			break scan
+		case influxql.LPAREN:
+			depth++
+		case influxql.RPAREN:
+			depth--
+		case influxql.WHERE:
+			if depth == 0 && where == nil {
+				where = &influxql.Pos{Line: pos.Line, Char: pos.Char + len("WHERE")}
+			}
+		case influxql.GROUP, influxql.ORDER, influxql.LIMIT, influxql.OFFSET, influxql.SLIMIT, influxql.SOFFSET:
+			if depth == 0 {
+				end = pos
+				break scan
+			}
+		}
+	}
+	if where == nil {
+		return text.slice(influxql.Pos{}, end) + " WHERE " + timeRange + " " + text.slice(end, text.end())
+	}
+	return text.slice(influxql.Pos{}, *where) + " " + timeRange + " AND (" + text.slice(*where, end) + ") " + text.slice(end, text.end())
+}
+
+// queryText provides the text of a query by the positions of the InfluxQL scanner,
+// which are in lines and characters.
+type queryText [][]rune
+
+func newQueryText(query string) queryText {
+	lines := strings.Split(strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(query), "\n")
+	t := make(queryText, len(lines))
+	for i, l := range lines {
+		t[i] = []rune(l)
+	}
+	return t
+}
+
+func (t queryText) String() string {
+	return t.slice(influxql.Pos{}, t.end())
+}
+
+// end returns the position after the last character.
+func (t queryText) end() influxql.Pos {
+	last := len(t) - 1
+	return influxql.Pos{Line: last, Char: len(t[last])}
+}
+
+// slice returns the text from the position from up to but not including the position to.
+func (t queryText) slice(from, to influxql.Pos) string {
+	var lines []string
+	for l := from.Line; l <= to.Line; l++ {
+		start, end := 0, len(t[l])
+		if l == from.Line {
+			start = from.Char
+		}
+		if l == to.Line {
+			end = to.Char
+		}
+		lines = append(lines, string(t[l][start:end]))
+	}
+	return strings.Join(lines, "\n")
 }
 
 type TimeDimension struct {
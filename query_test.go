@@ -2,6 +2,7 @@ package kapacitor_test
 
 import (
 	"fmt"
+	"reflect"
 	"testing"
 	"time"
 
@@ -15,6 +16,7 @@ func TestQuery_Clone(t *testing.T) {
 		"SELECT mean(usage) FROM telegraf.autogen.cpu WHERE host = 'serverA' AND dc = 'slc'",
 		"SELECT mean(usage) FROM telegraf.autogen.cpu WHERE host = 'serverA' AND dc = 'slc' OR product = 'login'",
 		"SELECT mean(usage) FROM telegraf.autogen.cpu WHERE host = 'serverA' AND (dc = 'slc' OR product = 'login')",
+		"SELECT max(mean) FROM (SELECT mean(usage) FROM telegraf.autogen.cpu WHERE host = 'serverA' GROUP BY time(1m), host)",
 	}
 
 	equal := func(q0, q1 *kapacitor.Query) error {
@@ -165,3 +167,115 @@ func TestQuery_IsGroupedByTime(t *testing.T) {
 		t.Error("expected query to not be grouped by time")
 	}
 }
+
+func TestQuery_Subquery(t *testing.T) {
+	start := time.Date(2018, 1, 1, 0, 0, 30, 0, time.UTC)
+	stop := start.Add(time.Hour)
+	testCases := []struct {
+		name     string
+		query    string
+		dims     []interface{}
+		align    bool
+		exp      string
+		expDBRPs []kapacitor.DBRP
+	}{
+		{
+			name: "nested aggregate",
+			query: `SELECT max("mean") FROM (
+    SELECT mean("usage") FROM "telegraf"."autogen"."cpu"
+    WHERE "host" = 'serverA' OR "host" = 'serverB'
+    GROUP BY time(1m), "host"
+) WHERE "mean" > 0`,
+			dims: []interface{}{10 * time.Minute},
+			exp: "SELECT max(mean) FROM (SELECT mean(usage) FROM telegraf.autogen.cpu WHERE (host = 'serverA' OR host = 'serverB') AND time >= '2018-01-01T00:00:30Z' AND time < '2018-01-01T01:00:30Z' GROUP BY time(1m, 0s), host)" +
+				" WHERE mean > 0 AND time >= '2018-01-01T00:00:30Z' AND time < '2018-01-01T01:00:30Z' GROUP BY time(10m, 0s)",
+			expDBRPs: []kapacitor.DBRP{{Database: "telegraf", RetentionPolicy: "autogen"}},
+		},
+		{
+			name:  "aligned groups",
+			query: "SELECT max(mean) FROM (SELECT mean(usage) FROM telegraf.autogen.cpu GROUP BY time(1m))",
+			dims:  []interface{}{10 * time.Minute, "host"},
+			align: true,
+			exp: "SELECT max(mean) FROM (SELECT mean(usage) FROM telegraf.autogen.cpu WHERE time >= '2018-01-01T00:00:30Z' AND time < '2018-01-01T01:00:30Z' GROUP BY time(1m, 30s))" +
+				" WHERE time >= '2018-01-01T00:00:30Z' AND time < '2018-01-01T01:00:30Z' GROUP BY time(10m, 30s), host",
+			expDBRPs: []kapacitor.DBRP{{Database: "telegraf", RetentionPolicy: "autogen"}},
+		},
+		{
+			name:  "nested subqueries and measurements",
+			query: "SELECT max(m) FROM (SELECT mean(usage) AS m FROM (SELECT usage FROM telegraf.autogen.cpu) GROUP BY time(5m)), telegraf.longterm.mem",
+			dims:  []interface{}{time.Hour},
+			exp: "SELECT max(m) FROM (SELECT mean(usage) AS m FROM (SELECT usage FROM telegraf.autogen.cpu WHERE time >= '2018-01-01T00:00:30Z' AND time < '2018-01-01T01:00:30Z') WHERE time >= '2018-01-01T00:00:30Z' AND time < '2018-01-01T01:00:30Z' GROUP BY time(5m, 0s)), telegraf.longterm.mem" +
+				" WHERE time >= '2018-01-01T00:00:30Z' AND time < '2018-01-01T01:00:30Z' GROUP BY time(1h, 0s)",
+			expDBRPs: []kapacitor.DBRP{
+				{Database: "telegraf", RetentionPolicy: "autogen"},
+				{Database: "telegraf", RetentionPolicy: "longterm"},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := kapacitor.NewQuery(tc.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := q.Dimensions(tc.dims); err != nil {
+				t.Fatal(err)
+			}
+			if tc.align {
+				q.AlignGroup()
+			}
+			q.SetStartTime(start)
+			q.SetStopTime(stop)
+			if got := q.String(); got != tc.exp {
+				t.Errorf("unexpected query:\ngot  %s\nexp  %s", got, tc.exp)
+			}
+			dbrps, err := q.DBRPs()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(dbrps, tc.expDBRPs) {
+				t.Errorf("unexpected DBRPs: got %v exp %v", dbrps, tc.expDBRPs)
+			}
+		})
+	}
+}
+
+func TestQuery_SubqueryErrors(t *testing.T) {
+	testCases := []struct {
+		name  string
+		query string
+		dims  []interface{}
+		exp   string
+	}{
+		{
+			name:  "time condition",
+			query: "SELECT max(mean) FROM (SELECT mean(usage) FROM cpu WHERE time > now() - 1h GROUP BY time(1m))",
+			exp:   "invalid subquery: subquery must not contain a time condition",
+		},
+		{
+			name:  "unterminated",
+			query: "SELECT max(mean) FROM (SELECT mean(usage) FROM cpu",
+			exp:   "failed to parse InfluxQL query: unterminated subquery",
+		},
+		{
+			name:  "misaligned groups",
+			query: "SELECT max(mean) FROM (SELECT mean(usage) FROM cpu GROUP BY time(7m))",
+			dims:  []interface{}{10 * time.Minute},
+			exp:   "groupBy time 10m0s must be a multiple of the subquery GROUP BY time 7m0s",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := kapacitor.NewQuery(tc.query)
+			if err == nil {
+				err = q.Dimensions(tc.dims)
+			}
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if got := err.Error(); got != tc.exp {
+				t.Errorf("unexpected error: got %q exp %q", got, tc.exp)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package kapacitor
+
+import (
+	"sort"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+const (
+	statsRewrites = "rewrites"
+)
+
+type RegexReplaceNode struct {
+	node
+	r *pipeline.RegexReplaceNode
+
+	rewrites *expvar.Int
+}
+
+// Create a new RegexReplaceNode which rewrites field and tag values with find and replace rules.
+func newRegexReplaceNode(et *ExecutingTask, n *pipeline.RegexReplaceNode, d NodeDiagnostic) (*RegexReplaceNode, error) {
+	rn := &RegexReplaceNode{
+		node:     node{Node: n, et: et, diag: d},
+		r:        n,
+		rewrites: new(expvar.Int),
+	}
+	rn.node.runF = rn.runRegexReplace
+	return rn, nil
+}
+
+func (n *RegexReplaceNode) runRegexReplace([]byte) error {
+	n.statMap.Set(statsRewrites, n.rewrites)
+	consumer := edge.NewConsumerWithReceiver(
+		n.ins[0],
+		edge.NewReceiverFromForwardReceiverWithStats(
+			n.outs,
+			edge.NewTimedForwardReceiver(n.timer, n),
+		),
+	)
+	return consumer.Consume()
+}
+
+// rewrite applies the rules in order to s.
+func (n *RegexReplaceNode) rewrite(s string) string {
+	for _, r := range n.r.Rules {
+		s = r.Pattern.ReplaceAllString(s, r.Replacement)
+	}
+	return s
+}
+
+// rewriteFields returns the fields with the string fields rewritten and the number of changed values.
+// The fields are copied before they are modified.
+func (n *RegexReplaceNode) rewriteFields(fields models.Fields) (models.Fields, int) {
+	newFields := fields
+	count := 0
+	for _, name := range n.r.Fields {
+		s, ok := fields[name].(string)
+		if !ok {
+			continue
+		}
+		rewritten := n.rewrite(s)
+		if rewritten == s {
+			continue
+		}
+		if count == 0 {
+			newFields = newFields.Copy()
+		}
+		count++
+		newFields[name] = rewritten
+	}
+	return newFields, count
+}
+
+// rewriteTags returns the tags with the tags rewritten and the number of changed values.
+// The tags are copied before they are modified.
+func (n *RegexReplaceNode) rewriteTags(tags models.Tags) (models.Tags, int) {
+	newTags := tags
+	count := 0
+	for _, name := range n.r.Tags {
+		s, ok := tags[name]
+		if !ok {
+			continue
+		}
+		rewritten := n.rewrite(s)
+		if rewritten == s {
+			continue
+		}
+		if count == 0 {
+			newTags = newTags.Copy()
+		}
+		count++
+		newTags[name] = rewritten
+	}
+	return newTags, count
+}
+
+// regroup returns the dimensions with the rewritten tags of the point added.
+// Returns false if the dimensions are unchanged.
+func (n *RegexReplaceNode) regroup(tags models.Tags, dims models.Dimensions) (models.Dimensions, bool) {
+	var added []string
+	for _, name := range n.r.Tags {
+		if _, ok := tags[name]; ok && !isDimension(name, dims) {
+			added = append(added, name)
+		}
+	}
+	if len(added) == 0 {
+		return dims, false
+	}
+	tagNames := make([]string, len(dims.TagNames), len(dims.TagNames)+len(added))
+	copy(tagNames, dims.TagNames)
+	tagNames = append(tagNames, added...)
+	sort.Strings(tagNames)
+	return models.Dimensions{
+		TagNames: tagNames,
+		ByName:   dims.ByName,
+	}, true
+}
+
+func (n *RegexReplaceNode) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	// The tags of a batch are the dimension tags of its points,
+	// so they are rewritten without counting them as rewrites.
+	tags, count := n.rewriteTags(begin.Tags())
+	if count == 0 {
+		return begin, nil
+	}
+	begin = begin.ShallowCopy()
+	begin.SetTags(tags)
+	return begin, nil
+}
+
+func (n *RegexReplaceNode) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	fields, fieldCount := n.rewriteFields(bp.Fields())
+	tags, tagCount := n.rewriteTags(bp.Tags())
+	if fieldCount+tagCount == 0 {
+		return bp, nil
+	}
+	bp = bp.ShallowCopy()
+	if fieldCount > 0 {
+		bp.SetFields(fields)
+	}
+	if tagCount > 0 {
+		bp.SetTags(tags)
+	}
+	n.rewrites.Add(int64(fieldCount + tagCount))
+	return bp, nil
+}
+
+func (n *RegexReplaceNode) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (n *RegexReplaceNode) Point(p edge.PointMessage) (edge.Message, error) {
+	fields, fieldCount := n.rewriteFields(p.Fields())
+	tags, tagCount := n.rewriteTags(p.Tags())
+	dims, regroup := p.Dimensions(), false
+	if n.r.RegroupFlag {
+		dims, regroup = n.regroup(tags, dims)
+	}
+	if fieldCount+tagCount == 0 && !regroup {
+		return p, nil
+	}
+	p = p.ShallowCopy()
+	if fieldCount > 0 {
+		p.SetFields(fields)
+	}
+	if tagCount > 0 || regroup {
+		p.SetTagsAndDimensions(tags, dims)
+	}
+	n.rewrites.Add(int64(fieldCount + tagCount))
+	return p, nil
+}
+
+func (n *RegexReplaceNode) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (n *RegexReplaceNode) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (n *RegexReplaceNode) Done() {}
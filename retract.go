@@ -0,0 +1,140 @@
+package kapacitor
+
+import (
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+const (
+	statsRetractions = "retractions"
+)
+
+type RetractNode struct {
+	node
+	r *pipeline.RetractNode
+
+	retractions *expvar.Int
+}
+
+// Create a new RetractNode which emits a retraction point when a group is deleted.
+func newRetractNode(et *ExecutingTask, n *pipeline.RetractNode, d NodeDiagnostic) (*RetractNode, error) {
+	rn := &RetractNode{
+		node:        node{Node: n, et: et, diag: d},
+		r:           n,
+		retractions: new(expvar.Int),
+	}
+	rn.node.runF = rn.runRetract
+	return rn, nil
+}
+
+func (n *RetractNode) runRetract([]byte) error {
+	n.statMap.Set(statsRetractions, n.retractions)
+	consumer := edge.NewGroupedConsumer(
+		n.ins[0],
+		n,
+	)
+	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
+	return consumer.Consume()
+}
+
+func (n *RetractNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	return edge.NewReceiverFromForwardReceiverWithStats(
+		n.outs,
+		edge.NewTimedForwardReceiver(n.timer, n.newGroup(group)),
+	), nil
+}
+
+func (n *RetractNode) newGroup(group edge.GroupInfo) *retractGroup {
+	return &retractGroup{
+		n:     n,
+		group: group,
+	}
+}
+
+type retractGroup struct {
+	n     *RetractNode
+	group edge.GroupInfo
+
+	// seen reports whether the group has received any points.
+	seen bool
+	// batch reports whether the group received batches.
+	batch bool
+
+	name            string
+	database        string
+	retentionPolicy string
+	time            time.Time
+}
+
+func (g *retractGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	g.batch = true
+	g.name = begin.Name()
+	return begin, nil
+}
+
+func (g *retractGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	g.seen = true
+	g.time = bp.Time()
+	return bp, nil
+}
+
+func (g *retractGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (g *retractGroup) Point(p edge.PointMessage) (edge.Message, error) {
+	g.seen = true
+	g.name = p.Name()
+	g.database = p.Database()
+	g.retentionPolicy = p.RetentionPolicy()
+	g.time = p.Time()
+	return p, nil
+}
+
+func (g *retractGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+
+func (g *retractGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	if g.seen {
+		if err := edge.Forward(g.n.outs, g.retraction()); err != nil {
+			return nil, err
+		}
+		g.n.retractions.Add(1)
+	}
+	return d, nil
+}
+
+func (g *retractGroup) Done() {}
+
+// retraction returns the retraction message of the group.
+func (g *retractGroup) retraction() edge.Message {
+	tags := g.group.Tags.Copy()
+	if g.n.r.Tag != "" {
+		tags[g.n.r.Tag] = "true"
+	}
+	fields := models.Fields{}
+	if g.n.r.Field != "" {
+		fields[g.n.r.Field] = true
+	}
+	if !g.batch {
+		return edge.NewPointMessage(
+			g.name, g.database, g.retentionPolicy,
+			g.group.Dimensions,
+			fields,
+			tags,
+			g.time,
+		)
+	}
+	begin := edge.NewBeginBatchMessage(g.name, g.group.Tags, g.group.Dimensions.ByName, g.time, 1)
+	begin.SetTagsAndDimensions(g.group.Tags, g.group.Dimensions)
+	return edge.NewBufferedBatchMessage(
+		begin,
+		[]edge.BatchPointMessage{edge.NewBatchPointMessage(fields, tags, g.time)},
+		edge.NewEndBatchMessage(),
+	)
+}
@@ -0,0 +1,136 @@
+package kapacitor
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+func newTestRetractNode(t *testing.T, et pipeline.EdgeType, tag, field string) (*RetractNode, edge.Edge) {
+	t.Helper()
+	n, err := newRetractNode(&ExecutingTask{}, &pipeline.RetractNode{Tag: tag, Field: field}, &windowNodeDiagnostic{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := edge.NewChannelEdge(et, 10)
+	n.outs = []edge.StatsEdge{edge.NewStatsEdge(out)}
+	return n, out
+}
+
+func TestRetractGroup_Point(t *testing.T) {
+	n, out := newTestRetractNode(t, pipeline.StreamEdge, "_deleted", "_deleted")
+	dims := models.Dimensions{TagNames: []string{"host"}}
+	tags := models.Tags{"host": "serverA"}
+	t0 := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	p := edge.NewPointMessage("cpu", "db", "rp", dims, models.Fields{"value": 1.0}, tags, t0)
+	g := n.newGroup(p.GroupInfo())
+
+	if m, err := g.Point(p); err != nil {
+		t.Fatal(err)
+	} else if m != p {
+		t.Errorf("expected point to be passed through unchanged")
+	}
+	d := edge.NewDeleteGroupMessage(p.GroupID())
+	if m, err := g.DeleteGroup(d); err != nil {
+		t.Fatal(err)
+	} else if m != d {
+		t.Errorf("expected delete group to be forwarded")
+	}
+	out.Close()
+
+	m, ok := out.Emit()
+	if !ok {
+		t.Fatal("expected retraction to be emitted")
+	}
+	r, ok := m.(edge.PointMessage)
+	if !ok {
+		t.Fatalf("unexpected retraction message type %T", m)
+	}
+	if got, exp := r.Name(), "cpu"; got != exp {
+		t.Errorf("unexpected name: got %s exp %s", got, exp)
+	}
+	if got, exp := r.Database(), "db"; got != exp {
+		t.Errorf("unexpected database: got %s exp %s", got, exp)
+	}
+	if got, exp := r.Tags(), (models.Tags{"host": "serverA", "_deleted": "true"}); !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected tags: got %v exp %v", got, exp)
+	}
+	if got, exp := r.Fields(), (models.Fields{"_deleted": true}); !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected fields: got %v exp %v", got, exp)
+	}
+	if got, exp := r.GroupID(), p.GroupID(); got != exp {
+		t.Errorf("unexpected group: got %s exp %s", got, exp)
+	}
+	if !r.Time().Equal(t0) {
+		t.Errorf("unexpected time: got %v exp %v", r.Time(), t0)
+	}
+	if _, ok := out.Emit(); ok {
+		t.Error("expected a single retraction")
+	}
+	if got, exp := n.retractions.IntValue(), int64(1); got != exp {
+		t.Errorf("unexpected retractions: got %d exp %d", got, exp)
+	}
+}
+
+func TestRetractGroup_Batch(t *testing.T) {
+	n, out := newTestRetractNode(t, pipeline.BatchEdge, "", "gone")
+	t0 := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	tags := models.Tags{"host": "serverA"}
+	begin := edge.NewBeginBatchMessage("cpu", tags, false, t0.Add(time.Minute), 1)
+	g := n.newGroup(begin.GroupInfo())
+
+	if _, err := g.BeginBatch(begin); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.BatchPoint(edge.NewBatchPointMessage(models.Fields{"value": 1.0}, tags, t0)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.EndBatch(edge.NewEndBatchMessage()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.DeleteGroup(edge.NewDeleteGroupMessage(begin.GroupID())); err != nil {
+		t.Fatal(err)
+	}
+	out.Close()
+
+	m, ok := out.Emit()
+	if !ok {
+		t.Fatal("expected retraction to be emitted")
+	}
+	b, ok := m.(edge.BufferedBatchMessage)
+	if !ok {
+		t.Fatalf("unexpected retraction message type %T", m)
+	}
+	if got, exp := b.Begin().GroupID(), begin.GroupID(); got != exp {
+		t.Errorf("unexpected group: got %s exp %s", got, exp)
+	}
+	if got := len(b.Points()); got != 1 {
+		t.Fatalf("unexpected number of points: got %d exp 1", got)
+	}
+	bp := b.Points()[0]
+	if got, exp := bp.Tags(), tags; !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected tags: got %v exp %v", got, exp)
+	}
+	if got, exp := bp.Fields(), (models.Fields{"gone": true}); !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected fields: got %v exp %v", got, exp)
+	}
+	if !bp.Time().Equal(t0) {
+		t.Errorf("unexpected time: got %v exp %v", bp.Time(), t0)
+	}
+}
+
+func TestRetractGroup_NoPoints(t *testing.T) {
+	n, out := newTestRetractNode(t, pipeline.StreamEdge, "_deleted", "_deleted")
+	g := n.newGroup(edge.GroupInfo{ID: "cpu"})
+	if _, err := g.DeleteGroup(edge.NewDeleteGroupMessage("cpu")); err != nil {
+		t.Fatal(err)
+	}
+	out.Close()
+	if m, ok := out.Emit(); ok {
+		t.Errorf("unexpected retraction for group without points: %v", m)
+	}
+}
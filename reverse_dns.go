@@ -0,0 +1,246 @@
+package kapacitor
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/keyvalue"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+const (
+	statsCacheHits    = "cache_hits"
+	statsCacheMisses  = "cache_misses"
+	statsTimeouts     = "timeouts"
+	statsLookupErrors = "lookup_errors"
+)
+
+// reverseDNSEntry is a cached lookup result.
+type reverseDNSEntry struct {
+	host    string
+	ok      bool
+	expires time.Time
+}
+
+type ReverseDNSNode struct {
+	node
+	r *pipeline.ReverseDNSNode
+
+	mu    sync.Mutex
+	cache map[string]reverseDNSEntry
+	// pending contains the addresses that are being looked up.
+	pending map[string]bool
+
+	// slots limits the number of concurrent lookups.
+	slots  chan struct{}
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	cacheHits    *expvar.Int
+	cacheMisses  *expvar.Int
+	timeouts     *expvar.Int
+	lookupErrors *expvar.Int
+}
+
+// Create a new ReverseDNSNode which resolves IP addresses to hostnames.
+func newReverseDNSNode(et *ExecutingTask, n *pipeline.ReverseDNSNode, d NodeDiagnostic) (*ReverseDNSNode, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	rn := &ReverseDNSNode{
+		node:         node{Node: n, et: et, diag: d},
+		r:            n,
+		cache:        make(map[string]reverseDNSEntry),
+		pending:      make(map[string]bool),
+		slots:        make(chan struct{}, n.Concurrency),
+		ctx:          ctx,
+		cancel:       cancel,
+		cacheHits:    new(expvar.Int),
+		cacheMisses:  new(expvar.Int),
+		timeouts:     new(expvar.Int),
+		lookupErrors: new(expvar.Int),
+	}
+	rn.node.runF = rn.runReverseDNS
+	rn.node.stopF = rn.stopReverseDNS
+	return rn, nil
+}
+
+func (n *ReverseDNSNode) runReverseDNS([]byte) error {
+	n.statMap.Set(statsCacheHits, n.cacheHits)
+	n.statMap.Set(statsCacheMisses, n.cacheMisses)
+	n.statMap.Set(statsTimeouts, n.timeouts)
+	n.statMap.Set(statsLookupErrors, n.lookupErrors)
+	consumer := edge.NewConsumerWithReceiver(
+		n.ins[0],
+		edge.NewReceiverFromForwardReceiverWithStats(
+			n.outs,
+			edge.NewTimedForwardReceiver(n.timer, n),
+		),
+	)
+	return consumer.Consume()
+}
+
+func (n *ReverseDNSNode) stopReverseDNS() {
+	n.cancel()
+	n.wg.Wait()
+}
+
+// hostname returns the cached hostname of addr, or the fallback if it is not cached.
+// The address is looked up in the background if it is not cached.
+func (n *ReverseDNSNode) hostname(addr string) string {
+	fallback := n.r.Placeholder
+	if fallback == "" {
+		fallback = addr
+	}
+	if net.ParseIP(addr) == nil {
+		return fallback
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if e, ok := n.cache[addr]; ok && time.Now().Before(e.expires) {
+		n.cacheHits.Add(1)
+		if !e.ok {
+			return fallback
+		}
+		return e.host
+	}
+	n.cacheMisses.Add(1)
+	if n.pending[addr] {
+		return fallback
+	}
+	select {
+	case n.slots <- struct{}{}:
+	default:
+		// All resolvers are busy, the address is looked up with a later point.
+		return fallback
+	}
+	n.pending[addr] = true
+	n.wg.Add(1)
+	go n.lookup(addr)
+	return fallback
+}
+
+// lookup resolves addr and caches the result.
+func (n *ReverseDNSNode) lookup(addr string) {
+	defer n.wg.Done()
+	defer func() { <-n.slots }()
+
+	ctx, cancel := context.WithTimeout(n.ctx, n.r.Timeout)
+	names, err := n.et.tm.Resolver.LookupAddr(ctx, addr)
+	timedOut := ctx.Err() == context.DeadlineExceeded
+	cancel()
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	delete(n.pending, addr)
+	var e reverseDNSEntry
+	switch {
+	case timedOut:
+		// Retry with the next point.
+		n.timeouts.Add(1)
+		return
+	case n.ctx.Err() != nil:
+		// The node is stopping.
+		return
+	case err != nil || len(names) == 0:
+		n.lookupErrors.Add(1)
+		if err != nil && !isNotFound(err) {
+			n.diag.Error("failed to resolve address", err, keyvalue.KV("address", addr))
+		}
+	default:
+		e.host = strings.TrimSuffix(names[0], ".")
+		e.ok = true
+	}
+	e.expires = time.Now().Add(n.r.CacheTTL)
+	n.store(addr, e)
+}
+
+// isNotFound reports whether err means that the address has no name.
+func isNotFound(err error) bool {
+	dnsErr, ok := err.(*net.DNSError)
+	return ok && dnsErr.IsNotFound
+}
+
+// store caches e, evicting expired entries or else an arbitrary entry if the cache is full.
+// n.mu must be held.
+func (n *ReverseDNSNode) store(addr string, e reverseDNSEntry) {
+	if _, ok := n.cache[addr]; !ok && int64(len(n.cache)) >= n.r.CacheSize {
+		now := time.Now()
+		for a, c := range n.cache {
+			if !now.Before(c.expires) {
+				delete(n.cache, a)
+			}
+		}
+		for a := range n.cache {
+			if int64(len(n.cache)) < n.r.CacheSize {
+				break
+			}
+			delete(n.cache, a)
+		}
+	}
+	n.cache[addr] = e
+}
+
+// resolve returns the tags with the hostname tag set.
+// Returns false if the point has no address.
+func (n *ReverseDNSNode) resolve(fields models.Fields, tags models.Tags) (models.Tags, bool) {
+	var addr string
+	if n.r.FromFieldFlag {
+		s, ok := fields[n.r.Tag].(string)
+		if !ok {
+			return nil, false
+		}
+		addr = s
+	} else {
+		v, ok := tags[n.r.Tag]
+		if !ok {
+			return nil, false
+		}
+		addr = v
+	}
+	tags = tags.Copy()
+	tags[n.r.As] = n.hostname(addr)
+	return tags, true
+}
+
+func (n *ReverseDNSNode) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	return begin, nil
+}
+
+func (n *ReverseDNSNode) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	tags, ok := n.resolve(bp.Fields(), bp.Tags())
+	if !ok {
+		return bp, nil
+	}
+	bp = bp.ShallowCopy()
+	bp.SetTags(tags)
+	return bp, nil
+}
+
+func (n *ReverseDNSNode) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (n *ReverseDNSNode) Point(p edge.PointMessage) (edge.Message, error) {
+	tags, ok := n.resolve(p.Fields(), p.Tags())
+	if !ok {
+		return p, nil
+	}
+	p = p.ShallowCopy()
+	p.SetTags(tags)
+	return p, nil
+}
+
+func (n *ReverseDNSNode) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (n *ReverseDNSNode) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (n *ReverseDNSNode) Done() {}
@@ -0,0 +1,189 @@
+package kapacitor
+
+import (
+	"math"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+const (
+	statsSanitizedPoints = "sanitized_points"
+)
+
+// sanitizeResult is the outcome of sanitizing a point.
+type sanitizeResult int
+
+const (
+	sanitizeKeep sanitizeResult = iota
+	sanitizeRoute
+	sanitizeDrop
+)
+
+type SanitizeNode struct {
+	node
+	s *pipeline.SanitizeNode
+
+	// validOuts receive the valid points, invalidOuts the routed points.
+	validOuts   []edge.StatsEdge
+	invalidOuts []edge.StatsEdge
+
+	// points of the current batch
+	begin   edge.BeginBatchMessage
+	valid   []edge.BatchPointMessage
+	invalid []edge.BatchPointMessage
+
+	sanitizedPoints *expvar.Int
+}
+
+// Create a new SanitizeNode which drops, replaces or routes NaN and infinite field values.
+func newSanitizeNode(et *ExecutingTask, n *pipeline.SanitizeNode, d NodeDiagnostic) (*SanitizeNode, error) {
+	sn := &SanitizeNode{
+		node:            node{Node: n, et: et, diag: d},
+		s:               n,
+		sanitizedPoints: new(expvar.Int),
+	}
+	sn.node.runF = sn.runSanitize
+	return sn, nil
+}
+
+func (n *SanitizeNode) runSanitize([]byte) error {
+	n.statMap.Set(statsSanitizedPoints, n.sanitizedPoints)
+	n.mapOuts()
+	consumer := edge.NewConsumerWithReceiver(n.ins[0], n)
+	return consumer.Consume()
+}
+
+// mapOuts separates the output edges of the invalid branch from the other output edges.
+func (n *SanitizeNode) mapOuts() {
+	branches := make(map[pipeline.ID]bool)
+	for _, c := range n.s.Children() {
+		if _, ok := c.(*pipeline.SplitBranchNode); ok {
+			branches[c.ID()] = true
+		}
+	}
+	n.validOuts, n.invalidOuts = nil, nil
+	for i, child := range n.children {
+		if branches[child.ID()] {
+			n.invalidOuts = append(n.invalidOuts, n.outs[i])
+		} else {
+			n.validOuts = append(n.validOuts, n.outs[i])
+		}
+	}
+}
+
+// fieldAction returns the action for NaN or infinite values of a field.
+func (n *SanitizeNode) fieldAction(field string) string {
+	if a, ok := n.s.FieldActions[field]; ok {
+		return a
+	}
+	return n.s.Action
+}
+
+// sanitize checks the fields of p, replacing values in place if needed.
+func (n *SanitizeNode) sanitize(p edge.FieldsTagsTimeSetter) sanitizeResult {
+	fields := p.Fields()
+	checked := n.s.FieldsList
+	if len(checked) == 0 {
+		checked = make([]string, 0, len(fields))
+		for f := range fields {
+			checked = append(checked, f)
+		}
+	}
+	result := sanitizeKeep
+	sanitized := false
+	var replaced models.Fields
+	for _, f := range checked {
+		v, ok := fields[f].(float64)
+		if !ok || !(math.IsNaN(v) || math.IsInf(v, 0)) {
+			continue
+		}
+		sanitized = true
+		switch n.fieldAction(f) {
+		case pipeline.SanitizeDrop:
+			result = sanitizeDrop
+		case pipeline.SanitizeRoute:
+			if result < sanitizeRoute {
+				result = sanitizeRoute
+			}
+		case pipeline.SanitizeReplace:
+			if replaced == nil {
+				replaced = fields.Copy()
+			}
+			replaced[f] = n.s.ReplaceWith
+		}
+	}
+	if sanitized {
+		n.sanitizedPoints.Add(1)
+	}
+	if replaced != nil && result != sanitizeDrop {
+		p.SetFields(replaced)
+	}
+	return result
+}
+
+func (n *SanitizeNode) BeginBatch(begin edge.BeginBatchMessage) error {
+	n.begin = begin.ShallowCopy()
+	n.valid = n.valid[:0]
+	n.invalid = n.invalid[:0]
+	return nil
+}
+
+func (n *SanitizeNode) BatchPoint(bp edge.BatchPointMessage) error {
+	n.timer.Start()
+	defer n.timer.Stop()
+	bp = bp.ShallowCopy()
+	switch n.sanitize(bp) {
+	case sanitizeKeep:
+		n.valid = append(n.valid, bp)
+	case sanitizeRoute:
+		n.invalid = append(n.invalid, bp)
+	}
+	return nil
+}
+
+// EndBatch emits the valid points and the routed points each as their own batch.
+func (n *SanitizeNode) EndBatch(end edge.EndBatchMessage) error {
+	begin := n.begin.ShallowCopy()
+	begin.SetSizeHint(len(n.valid))
+	valid := make([]edge.BatchPointMessage, len(n.valid))
+	copy(valid, n.valid)
+	if err := edge.Forward(n.validOuts, edge.NewBufferedBatchMessage(begin, valid, end)); err != nil {
+		return err
+	}
+	if len(n.invalidOuts) > 0 && len(n.invalid) > 0 {
+		begin := n.begin.ShallowCopy()
+		begin.SetSizeHint(len(n.invalid))
+		invalid := make([]edge.BatchPointMessage, len(n.invalid))
+		copy(invalid, n.invalid)
+		if err := edge.Forward(n.invalidOuts, edge.NewBufferedBatchMessage(begin, invalid, end)); err != nil {
+			return err
+		}
+	}
+	n.begin = nil
+	return nil
+}
+
+func (n *SanitizeNode) Point(p edge.PointMessage) error {
+	n.timer.Start()
+	p = p.ShallowCopy()
+	result := n.sanitize(p)
+	n.timer.Stop()
+	switch result {
+	case sanitizeKeep:
+		return edge.Forward(n.validOuts, p)
+	case sanitizeRoute:
+		return edge.Forward(n.invalidOuts, p)
+	}
+	return nil
+}
+
+func (n *SanitizeNode) Barrier(b edge.BarrierMessage) error {
+	return edge.Forward(n.outs, b)
+}
+func (n *SanitizeNode) DeleteGroup(d edge.DeleteGroupMessage) error {
+	return edge.Forward(n.outs, d)
+}
+func (n *SanitizeNode) Done() {}
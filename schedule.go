@@ -0,0 +1,148 @@
+package kapacitor
+
+import (
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+const (
+	statsPointsOutside = "points_outside"
+)
+
+// scheduleWindow is a parsed pipeline.ScheduleWindow.
+type scheduleWindow struct {
+	days       [7]bool
+	start, end time.Duration
+}
+
+// contains reports whether the local weekday and time of day fall within the window.
+func (w scheduleWindow) contains(day time.Weekday, clock time.Duration) bool {
+	if w.start < w.end {
+		return w.days[day] && clock >= w.start && clock < w.end
+	}
+	// The window spans midnight, the days refer to the day the window starts on.
+	return (w.days[day] && clock >= w.start) || (w.days[(day+6)%7] && clock < w.end)
+}
+
+type ScheduleNode struct {
+	node
+	s *pipeline.ScheduleNode
+
+	windows  []scheduleWindow
+	location *time.Location
+
+	// now returns the current time used in wall clock mode
+	now func() time.Time
+
+	pointsOutside *expvar.Int
+}
+
+// Create a new ScheduleNode which passes only points within recurring time-of-day windows.
+func newScheduleNode(et *ExecutingTask, n *pipeline.ScheduleNode, d NodeDiagnostic) (*ScheduleNode, error) {
+	location, err := time.LoadLocation(n.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	windows := make([]scheduleWindow, len(n.Windows))
+	for i, w := range n.Windows {
+		days, start, end, err := w.Parse()
+		if err != nil {
+			return nil, err
+		}
+		windows[i] = scheduleWindow{days: days, start: start, end: end}
+	}
+	sn := &ScheduleNode{
+		node:          node{Node: n, et: et, diag: d},
+		s:             n,
+		windows:       windows,
+		location:      location,
+		now:           time.Now,
+		pointsOutside: new(expvar.Int),
+	}
+	sn.node.runF = sn.runSchedule
+	return sn, nil
+}
+
+func (n *ScheduleNode) runSchedule([]byte) error {
+	n.statMap.Set(statsPointsOutside, n.pointsOutside)
+	consumer := edge.NewConsumerWithReceiver(
+		n.ins[0],
+		edge.NewReceiverFromForwardReceiverWithStats(
+			n.outs,
+			edge.NewTimedForwardReceiver(n.timer, n),
+		),
+	)
+	return consumer.Consume()
+}
+
+// inSchedule reports whether t falls within any of the windows.
+func (n *ScheduleNode) inSchedule(t time.Time) bool {
+	t = t.In(n.location)
+	hour, min, sec := t.Clock()
+	clock := time.Duration(hour)*time.Hour + time.Duration(min)*time.Minute + time.Duration(sec)*time.Second + time.Duration(t.Nanosecond())
+	for _, w := range n.windows {
+		if w.contains(t.Weekday(), clock) {
+			return true
+		}
+	}
+	return false
+}
+
+// check reports whether a point with time t should be kept and sets the flag field if configured.
+func (n *ScheduleNode) check(p edge.FieldsTagsTimeGetter, s edge.FieldSetter) bool {
+	t := p.Time()
+	if n.s.WallClockFlag {
+		t = n.now()
+	}
+	in := n.inSchedule(t)
+	if !in {
+		n.pointsOutside.Add(1)
+	}
+	if n.s.FlagAs == "" {
+		return in
+	}
+	fields := p.Fields().Copy()
+	fields[n.s.FlagAs] = in
+	s.SetFields(fields)
+	return true
+}
+
+func (n *ScheduleNode) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	if n.s.FlagAs == "" {
+		// Points may be dropped, so the size of the batch is not known.
+		begin = begin.ShallowCopy()
+		begin.SetSizeHint(0)
+	}
+	return begin, nil
+}
+
+func (n *ScheduleNode) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	np := bp.ShallowCopy()
+	if n.check(bp, np) {
+		return np, nil
+	}
+	return nil, nil
+}
+
+func (n *ScheduleNode) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (n *ScheduleNode) Point(p edge.PointMessage) (edge.Message, error) {
+	np := p.ShallowCopy()
+	if n.check(p, np) {
+		return np, nil
+	}
+	return nil, nil
+}
+
+func (n *ScheduleNode) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (n *ScheduleNode) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (n *ScheduleNode) Done() {}
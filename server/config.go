@@ -20,6 +20,7 @@ import (
 	"github.com/influxdata/kapacitor/services/diagnostic"
 	"github.com/influxdata/kapacitor/services/dns"
 	"github.com/influxdata/kapacitor/services/ec2"
+	"github.com/influxdata/kapacitor/services/encryption"
 	"github.com/influxdata/kapacitor/services/file_discovery"
 	"github.com/influxdata/kapacitor/services/gce"
 	"github.com/influxdata/kapacitor/services/hipchat"
@@ -32,10 +33,13 @@ import (
 	"github.com/influxdata/kapacitor/services/marathon"
 	"github.com/influxdata/kapacitor/services/mqtt"
 	"github.com/influxdata/kapacitor/services/nerve"
+	"github.com/influxdata/kapacitor/services/opentelemetry"
 	"github.com/influxdata/kapacitor/services/opsgenie"
 	"github.com/influxdata/kapacitor/services/opsgenie2"
 	"github.com/influxdata/kapacitor/services/pagerduty"
 	"github.com/influxdata/kapacitor/services/pagerduty2"
+	"github.com/influxdata/kapacitor/services/pbline"
+	"github.com/influxdata/kapacitor/services/pointlimit"
 	"github.com/influxdata/kapacitor/services/pushover"
 	"github.com/influxdata/kapacitor/services/replay"
 	"github.com/influxdata/kapacitor/services/reporting"
@@ -79,25 +83,30 @@ type Config struct {
 	Collectd collectd.Config   `toml:"collectd"`
 	OpenTSDB opentsdb.Config   `toml:"opentsdb"`
 	UDP      []udp.Config      `toml:"udp"`
+	PBLine   []pbline.Config   `toml:"pbline"`
+
+	// Size limits of the points written by the inputs
+	PointLimits pointlimit.Configs `toml:"point-limit"`
 
 	// Alert handlers
-	Alerta     alerta.Config     `toml:"alerta" override:"alerta"`
-	HipChat    hipchat.Config    `toml:"hipchat" override:"hipchat"`
-	Kafka      kafka.Configs     `toml:"kafka" override:"kafka,element-key=id"`
-	MQTT       mqtt.Configs      `toml:"mqtt" override:"mqtt,element-key=name"`
-	OpsGenie   opsgenie.Config   `toml:"opsgenie" override:"opsgenie"`
-	OpsGenie2  opsgenie2.Config  `toml:"opsgenie2" override:"opsgenie2"`
-	PagerDuty  pagerduty.Config  `toml:"pagerduty" override:"pagerduty"`
-	PagerDuty2 pagerduty2.Config `toml:"pagerduty2" override:"pagerduty2"`
-	Pushover   pushover.Config   `toml:"pushover" override:"pushover"`
-	HTTPPost   httppost.Configs  `toml:"httppost" override:"httppost,element-key=endpoint"`
-	SMTP       smtp.Config       `toml:"smtp" override:"smtp"`
-	SNMPTrap   snmptrap.Config   `toml:"snmptrap" override:"snmptrap"`
-	Sensu      sensu.Config      `toml:"sensu" override:"sensu"`
-	Slack      slack.Configs     `toml:"slack" override:"slack,element-key=workspace"`
-	Talk       talk.Config       `toml:"talk" override:"talk"`
-	Telegram   telegram.Config   `toml:"telegram" override:"telegram"`
-	VictorOps  victorops.Config  `toml:"victorops" override:"victorops"`
+	Alerta        alerta.Config        `toml:"alerta" override:"alerta"`
+	HipChat       hipchat.Config       `toml:"hipchat" override:"hipchat"`
+	Kafka         kafka.Configs        `toml:"kafka" override:"kafka,element-key=id"`
+	MQTT          mqtt.Configs         `toml:"mqtt" override:"mqtt,element-key=name"`
+	OpenTelemetry opentelemetry.Config `toml:"opentelemetry" override:"opentelemetry"`
+	OpsGenie      opsgenie.Config      `toml:"opsgenie" override:"opsgenie"`
+	OpsGenie2     opsgenie2.Config     `toml:"opsgenie2" override:"opsgenie2"`
+	PagerDuty     pagerduty.Config     `toml:"pagerduty" override:"pagerduty"`
+	PagerDuty2    pagerduty2.Config    `toml:"pagerduty2" override:"pagerduty2"`
+	Pushover      pushover.Config      `toml:"pushover" override:"pushover"`
+	HTTPPost      httppost.Configs     `toml:"httppost" override:"httppost,element-key=endpoint"`
+	SMTP          smtp.Config          `toml:"smtp" override:"smtp"`
+	SNMPTrap      snmptrap.Config      `toml:"snmptrap" override:"snmptrap"`
+	Sensu         sensu.Config         `toml:"sensu" override:"sensu"`
+	Slack         slack.Configs        `toml:"slack" override:"slack,element-key=workspace"`
+	Talk          talk.Config          `toml:"talk" override:"talk"`
+	Telegram      telegram.Config      `toml:"telegram" override:"telegram"`
+	VictorOps     victorops.Config     `toml:"victorops" override:"victorops"`
 
 	// Discovery for scraping
 	Scraper         []scraper.Config          `toml:"scraper" override:"scraper,element-key=name"`
@@ -117,10 +126,11 @@ type Config struct {
 	Kubernetes k8s.Configs   `toml:"kubernetes" override:"kubernetes,element-key=id" env-config:"implicit-index"`
 	Swarm      swarm.Configs `toml:"swarm" override:"swarm,element-key=id"`
 
-	Reporting reporting.Config `toml:"reporting"`
-	Stats     stats.Config     `toml:"stats"`
-	UDF       udf.Config       `toml:"udf"`
-	Deadman   deadman.Config   `toml:"deadman"`
+	Reporting  reporting.Config  `toml:"reporting"`
+	Stats      stats.Config      `toml:"stats"`
+	UDF        udf.Config        `toml:"udf"`
+	Deadman    deadman.Config    `toml:"deadman"`
+	Encryption encryption.Config `toml:"encryption"`
 
 	Hostname               string `toml:"hostname"`
 	DataDir                string `toml:"data_dir"`
@@ -152,6 +162,7 @@ func NewConfig() *Config {
 	c.HipChat = hipchat.NewConfig()
 	c.Kafka = kafka.Configs{kafka.NewConfig()}
 	c.MQTT = mqtt.Configs{mqtt.NewConfig()}
+	c.OpenTelemetry = opentelemetry.NewConfig()
 	c.OpsGenie = opsgenie.NewConfig()
 	c.OpsGenie2 = opsgenie2.NewConfig()
 	c.PagerDuty = pagerduty.NewConfig()
@@ -170,6 +181,7 @@ func NewConfig() *Config {
 	c.Stats = stats.NewConfig()
 	c.UDF = udf.NewConfig()
 	c.Deadman = deadman.NewConfig()
+	c.Encryption = encryption.NewConfig()
 	c.Load = load.NewConfig()
 
 	return c
@@ -222,6 +234,9 @@ func (c *Config) Validate() error {
 	if err := c.Load.Validate(); err != nil {
 		return err
 	}
+	if err := c.Encryption.Validate(); err != nil {
+		return errors.Wrap(err, "encryption")
+	}
 	// Validate the set of InfluxDB configs.
 	// All names should be unique.
 	names := make(map[string]bool, len(c.InfluxDB))
@@ -258,6 +273,14 @@ func (c *Config) Validate() error {
 			return errors.Wrap(err, "graphite")
 		}
 	}
+	for _, p := range c.PBLine {
+		if err := p.Validate(); err != nil {
+			return errors.Wrap(err, "pbline")
+		}
+	}
+	if err := c.PointLimits.Validate(); err != nil {
+		return errors.Wrap(err, "point-limit")
+	}
 
 	// Validate alert handlers
 	if err := c.Alerta.Validate(); err != nil {
@@ -272,6 +295,9 @@ func (c *Config) Validate() error {
 	if err := c.MQTT.Validate(); err != nil {
 		return errors.Wrap(err, "mqtt")
 	}
+	if err := c.OpenTelemetry.Validate(); err != nil {
+		return errors.Wrap(err, "opentelemetry")
+	}
 	if err := c.OpsGenie.Validate(); err != nil {
 		return errors.Wrap(err, "opsgenie")
 	}
@@ -6,8 +6,10 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"reflect"
 	"runtime"
 	"runtime/pprof"
+	"sort"
 	"sync"
 
 	"github.com/influxdata/influxdb/influxql"
@@ -30,6 +32,7 @@ import (
 	"github.com/influxdata/kapacitor/services/diagnostic"
 	"github.com/influxdata/kapacitor/services/dns"
 	"github.com/influxdata/kapacitor/services/ec2"
+	"github.com/influxdata/kapacitor/services/encryption"
 	"github.com/influxdata/kapacitor/services/file_discovery"
 	"github.com/influxdata/kapacitor/services/gce"
 	"github.com/influxdata/kapacitor/services/hipchat"
@@ -43,10 +46,13 @@ import (
 	"github.com/influxdata/kapacitor/services/mqtt"
 	"github.com/influxdata/kapacitor/services/nerve"
 	"github.com/influxdata/kapacitor/services/noauth"
+	"github.com/influxdata/kapacitor/services/opentelemetry"
 	"github.com/influxdata/kapacitor/services/opsgenie"
 	"github.com/influxdata/kapacitor/services/opsgenie2"
 	"github.com/influxdata/kapacitor/services/pagerduty"
 	"github.com/influxdata/kapacitor/services/pagerduty2"
+	"github.com/influxdata/kapacitor/services/pbline"
+	"github.com/influxdata/kapacitor/services/pointlimit"
 	"github.com/influxdata/kapacitor/services/pushover"
 	"github.com/influxdata/kapacitor/services/replay"
 	"github.com/influxdata/kapacitor/services/reporting"
@@ -126,6 +132,8 @@ type Server struct {
 
 	ScraperService *scraper.Service
 
+	PointLimitService *pointlimit.Service
+
 	MetaClient    *kapacitor.NoopMetaClient
 	QueryExecutor *Queryexecutor
 
@@ -205,6 +213,7 @@ func New(c *Config, buildInfo BuildInfo, diagService *diagnostic.Service) (*Serv
 	}
 
 	// Append Kapacitor services.
+	s.appendPointLimitService()
 	s.initHTTPDService()
 	s.appendStorageService()
 	s.appendAuthService()
@@ -218,6 +227,9 @@ func New(c *Config, buildInfo BuildInfo, diagService *diagnostic.Service) (*Serv
 	// Append all dynamic services after the config override and tester services.
 	s.appendUDFService()
 	s.appendDeadmanService()
+	if err := s.appendEncryptionService(); err != nil {
+		return nil, errors.Wrap(err, "encryption service")
+	}
 
 	if err := s.appendInfluxDBService(); err != nil {
 		return nil, errors.Wrap(err, "influxdb service")
@@ -234,6 +246,9 @@ func New(c *Config, buildInfo BuildInfo, diagService *diagnostic.Service) (*Serv
 	if err := s.appendMQTTService(); err != nil {
 		return nil, errors.Wrap(err, "mqtt service")
 	}
+	if err := s.appendOpenTelemetryService(); err != nil {
+		return nil, errors.Wrap(err, "opentelemetry service")
+	}
 	s.appendOpsGenieService()
 	s.appendOpsGenie2Service()
 	s.appendPagerDutyService()
@@ -266,6 +281,7 @@ func New(c *Config, buildInfo BuildInfo, diagService *diagnostic.Service) (*Serv
 		return nil, errors.Wrap(err, "collectd service")
 	}
 	s.appendUDPServices()
+	s.appendPBLineServices()
 	if err := s.appendOpenTSDBService(); err != nil {
 		return nil, errors.Wrap(err, "opentsdb service")
 	}
@@ -435,7 +451,7 @@ func (s *Server) appendInfluxDBService() error {
 	srv.ClusterIDWaiter = w
 
 	srv.HTTPDService = s.HTTPDService
-	srv.PointsWriter = s.TaskMaster
+	srv.PointsWriter = s.PointLimitService.PointsWriter(pointlimit.SourceSubscriptions, s.TaskMaster)
 	srv.AuthService = s.AuthService
 	srv.ClientCreator = iclient.ClientCreator{}
 
@@ -446,12 +462,20 @@ func (s *Server) appendInfluxDBService() error {
 	return nil
 }
 
+func (s *Server) appendPointLimitService() {
+	d := s.DiagService.NewPointLimitHandler()
+	srv := pointlimit.NewService(s.config.PointLimits, d)
+
+	s.PointLimitService = srv
+	s.AppendService("pointlimit", srv)
+}
+
 func (s *Server) initHTTPDService() {
 	d := s.DiagService.NewHTTPDHandler()
 	srv := httpd.NewService(s.config.HTTP, s.hostname, d)
 
 	srv.LocalHandler.PointsWriter = s.TaskMaster
-	srv.Handler.PointsWriter = s.TaskMaster
+	srv.Handler.PointsWriter = s.PointLimitService.PointsWriter(pointlimit.SourceHTTP, s.TaskMaster)
 
 	srv.LocalHandler.DiagService = s.DiagService
 	srv.Handler.DiagService = s.DiagService
@@ -539,6 +563,16 @@ func (s *Server) appendEC2Service() error {
 	s.AppendService("ec2", srv)
 	return nil
 }
+func (s *Server) appendEncryptionService() error {
+	srv, err := encryption.NewService(s.config.Encryption)
+	if err != nil {
+		return err
+	}
+	s.TaskMaster.EncryptionService = srv
+	s.AppendService("encryption", srv)
+	return nil
+}
+
 func (s *Server) appendDeadmanService() {
 	d := s.DiagService.NewDeadmanHandler()
 	srv := deadman.NewService(s.config.Deadman, d)
@@ -580,6 +614,22 @@ func (s *Server) appendMQTTService() error {
 	return nil
 }
 
+func (s *Server) appendOpenTelemetryService() error {
+	c := s.config.OpenTelemetry
+	d := s.DiagService.NewOpenTelemetryHandler()
+	srv, err := opentelemetry.NewService(c, d)
+	if err != nil {
+		return err
+	}
+
+	s.TaskMaster.OpenTelemetryService = srv
+	s.AlertService.OpenTelemetryService = srv
+
+	s.SetDynamicService("opentelemetry", srv)
+	s.AppendService("opentelemetry", srv)
+	return nil
+}
+
 func (s *Server) appendOpsGenieService() {
 	c := s.config.OpsGenie
 	d := s.DiagService.NewOpsGenieHandler()
@@ -782,7 +832,7 @@ func (s *Server) appendCollectdService() error {
 	srv.SetLogOutput(w)
 
 	srv.MetaClient = s.MetaClient
-	srv.PointsWriter = s.TaskMaster
+	srv.PointsWriter = s.PointLimitService.PointsWriter(pointlimit.SourceCollectd, s.TaskMaster)
 	s.AppendService("collectd", srv)
 
 	return nil
@@ -803,7 +853,7 @@ func (s *Server) appendOpenTSDBService() error {
 	}
 	srv.SetLogOutput(w)
 
-	srv.PointsWriter = s.TaskMaster
+	srv.PointsWriter = s.PointLimitService.PointsWriter(pointlimit.SourceOpenTSDB, s.TaskMaster)
 	srv.MetaClient = s.MetaClient
 	s.AppendService("opentsdb", srv)
 	return nil
@@ -824,7 +874,7 @@ func (s *Server) appendGraphiteServices() error {
 		}
 		srv.SetLogOutput(w)
 
-		srv.PointsWriter = s.TaskMaster
+		srv.PointsWriter = s.PointLimitService.PointsWriter(pointlimit.SourceGraphite, s.TaskMaster)
 		srv.MetaClient = s.MetaClient
 		s.AppendService(fmt.Sprintf("graphite%d", i), srv)
 	}
@@ -838,11 +888,23 @@ func (s *Server) appendUDPServices() {
 		}
 		d := s.DiagService.NewUDPHandler()
 		srv := udp.NewService(c, d)
-		srv.PointsWriter = s.TaskMaster
+		srv.PointsWriter = s.PointLimitService.PointsWriter(pointlimit.SourceUDP, s.TaskMaster)
 		s.AppendService(fmt.Sprintf("udp%d", i), srv)
 	}
 }
 
+func (s *Server) appendPBLineServices() {
+	for i, c := range s.config.PBLine {
+		if !c.Enabled {
+			continue
+		}
+		d := s.DiagService.NewPBLineHandler()
+		srv := pbline.NewService(c, d)
+		srv.PointsWriter = s.PointLimitService.PointsWriter(pointlimit.SourcePBLine, s.TaskMaster)
+		s.AppendService(fmt.Sprintf("pbline%d", i), srv)
+	}
+}
+
 func (s *Server) appendStatsService() {
 	c := s.config.Stats
 	if c.Enabled {
@@ -1143,6 +1205,58 @@ func (s *Server) Reload() {
 	}
 }
 
+// ReloadConfig applies the configuration of all dynamic services from c to the running services.
+// Configuration overrides still take precedence over c, as they do on startup.
+// Running tasks are not restarted, instead their alert handlers use the new configuration,
+// i.e. rotated credentials, for all subsequent alerts.
+// Only the sections of c that can be overridden are applied,
+// and only the services whose section differs from the current configuration are updated.
+// No service is updated if c has a section for an unknown service.
+func (s *Server) ReloadConfig(c *Config) error {
+	current, err := s.configSections(s.ConfigOverrideService.BaseConfig().(*Config))
+	if err != nil {
+		return errors.Wrap(err, "failed to reload configuration")
+	}
+	configs, err := s.configSections(c)
+	if err != nil {
+		return errors.Wrap(err, "failed to reload configuration")
+	}
+	var changed []string
+	for service, newConfig := range configs {
+		if _, ok := s.DynamicServices[service]; !ok {
+			return fmt.Errorf("found configuration for unknown service %q", service)
+		}
+		if !reflect.DeepEqual(newConfig, current[service]) {
+			changed = append(changed, service)
+		}
+	}
+	sort.Strings(changed)
+
+	s.ConfigOverrideService.SetConfig(c)
+	for _, service := range changed {
+		// Send updates via the same channel as the config override service,
+		// so that they are never applied concurrently with updates made via the API.
+		errC := make(chan error, 1)
+		s.configUpdates <- config.ConfigUpdate{
+			Name:      service,
+			NewConfig: configs[service],
+			ErrC:      errC,
+		}
+		if err := <-errC; err != nil {
+			return errors.Wrapf(err, "failed to update configuration for service %s", service)
+		}
+	}
+	return nil
+}
+
+// configSections returns the values of the sections of c, with the config overrides applied if they are enabled.
+func (s *Server) configSections(c *Config) (map[string][]interface{}, error) {
+	if !c.SkipConfigOverrides && c.ConfigOverride.Enabled {
+		return s.ConfigOverrideService.ConfigOf(c)
+	}
+	return config.Sections(c)
+}
+
 func (s *Server) SetClusterID(clusterID uuid.UUID) error {
 	s.clusterIDMu.Lock()
 	defer s.clusterIDMu.Unlock()
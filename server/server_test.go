@@ -1,6 +1,7 @@
 package server_test
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -8,6 +9,7 @@ import (
 	"io/ioutil"
 	"math/rand"
 	"net/http"
+	"net/http/httptest"
 	"net/mail"
 	"net/url"
 	"os"
@@ -19,11 +21,13 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/davecgh/go-spew/spew"
 	"github.com/dgrijalva/jwt-go"
+	"github.com/golang/protobuf/proto"
 	"github.com/google/go-cmp/cmp"
 	iclient "github.com/influxdata/influxdb/client/v2"
 	"github.com/influxdata/influxdb/influxql"
@@ -52,6 +56,7 @@ import (
 	"github.com/influxdata/kapacitor/services/pagerduty/pagerdutytest"
 	"github.com/influxdata/kapacitor/services/pagerduty2"
 	"github.com/influxdata/kapacitor/services/pagerduty2/pagerduty2test"
+	"github.com/influxdata/kapacitor/services/pbline"
 	"github.com/influxdata/kapacitor/services/pushover/pushovertest"
 	"github.com/influxdata/kapacitor/services/sensu/sensutest"
 	"github.com/influxdata/kapacitor/services/slack"
@@ -1992,6 +1997,80 @@ test value=1 0000000011
 	}
 }
 
+func TestServer_WriteProtobuf(t *testing.T) {
+	s, cli := OpenDefaultServer()
+	defer s.Close()
+
+	id := "testWriteProtobuf"
+	tick := `stream
+    |from()
+        .measurement('test')
+    |window()
+        .period(10s)
+        .every(10s)
+    |sum('value')
+    |httpOut('sum')
+`
+	if _, err := cli.CreateTask(client.CreateTaskOptions{
+		ID:         id,
+		Type:       client.StreamTask,
+		DBRPs:      []client.DBRP{{Database: "mydb", RetentionPolicy: "myrp"}},
+		TICKscript: tick,
+		Status:     client.Enabled,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// point encodes a length delimited protobuf point with a single integer field.
+	point := func(name string, value int64, t time.Time) []byte {
+		field := new(proto.Buffer)
+		field.EncodeVarint(1<<3 | 2)
+		field.EncodeStringBytes("value")
+		field.EncodeVarint(3<<3 | 0)
+		field.EncodeVarint(uint64(value))
+		p := new(proto.Buffer)
+		if name != "" {
+			p.EncodeVarint(1<<3 | 2)
+			p.EncodeStringBytes(name)
+		}
+		p.EncodeVarint(2<<3 | 0)
+		p.EncodeVarint(uint64(t.UnixNano()))
+		p.EncodeVarint(4<<3 | 2)
+		p.EncodeRawBytes(field.Bytes())
+		b := new(proto.Buffer)
+		b.EncodeRawBytes(p.Bytes())
+		return b.Bytes()
+	}
+	write := func(body []byte) int {
+		resp, err := http.Post(s.URL()+"/write?db=mydb&rp=myrp", pbline.ContentType, bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// A malformed point fails the whole request.
+	if code := write(append(point("test", 100, time.Unix(1, 0)), point("", 100, time.Unix(2, 0))...)); code != http.StatusBadRequest {
+		t.Errorf("unexpected status code for malformed point: got %d exp %d", code, http.StatusBadRequest)
+	}
+
+	// A zero time is replaced with the time the point is received, so start at 1s.
+	var body []byte
+	for i := 1; i <= 11; i++ {
+		body = append(body, point("test", int64(i), time.Unix(int64(i), 0))...)
+	}
+	if code := write(body); code != http.StatusNoContent {
+		t.Fatalf("unexpected status code: got %d exp %d", code, http.StatusNoContent)
+	}
+
+	endpoint := fmt.Sprintf("%s/tasks/%s/sum", s.URL(), id)
+	exp := `{"series":[{"name":"test","columns":["time","sum"],"values":[["1970-01-01T00:00:11Z",55]]}]}`
+	if err := s.HTTPGetRetry(endpoint, exp, 100, time.Millisecond*5); err != nil {
+		t.Error(err)
+	}
+}
+
 func TestServer_StreamTask_NoRP(t *testing.T) {
 	conf := NewConfig()
 	conf.DefaultRetentionPolicy = "myrp"
@@ -3173,6 +3252,213 @@ func TestServer_BatchTask(t *testing.T) {
 		}
 	}
 }
+func TestServer_BatchTask_SkipFirst(t *testing.T) {
+	c := NewConfig()
+	c.InfluxDB[0].Enabled = true
+	startTimeC := make(chan time.Time, 10)
+
+	db := NewInfluxDB(func(q string) *iclient.Response {
+		stmt, err := influxql.ParseStatement(q)
+		if err != nil {
+			return &iclient.Response{Err: err.Error()}
+		}
+		slct, ok := stmt.(*influxql.SelectStatement)
+		if !ok {
+			return nil
+		}
+		cond, ok := slct.Condition.(*influxql.BinaryExpr)
+		if !ok {
+			return &iclient.Response{Err: "expected select condition to be binary expression"}
+		}
+		startTimeExpr, ok := cond.LHS.(*influxql.BinaryExpr)
+		if !ok {
+			return &iclient.Response{Err: "expected select condition lhs to be binary expression"}
+		}
+		startTL, ok := startTimeExpr.RHS.(*influxql.StringLiteral)
+		if !ok {
+			return &iclient.Response{Err: "expected select condition lhs to be string literal"}
+		}
+		startTime, err := time.Parse(time.RFC3339Nano, startTL.Val)
+		if err != nil {
+			return &iclient.Response{Err: err.Error()}
+		}
+		select {
+		case startTimeC <- startTime:
+		default:
+		}
+		return &iclient.Response{
+			Results: []iclient.Result{{
+				Series: []imodels.Row{{
+					Name:    "cpu",
+					Columns: []string{"time", "value"},
+					Values:  [][]interface{}{},
+				}},
+			}},
+		}
+	})
+	c.InfluxDB[0].URLs = []string{db.URL()}
+	s := OpenServer(c)
+	defer s.Close()
+	cli := Client(s)
+
+	// The task starts in the middle of an aligned interval,
+	// so the window of the first query started before the task.
+	tick := `batch
+    |query('SELECT value from mydb.myrp.cpu')
+        .period(50ms)
+        .every(50ms)
+        .align()
+        .skipFirst()
+    |httpOut('values')
+`
+
+	task, err := cli.CreateTask(client.CreateTaskOptions{
+		ID:   "testBatchTaskSkipFirst",
+		Type: client.BatchTask,
+		DBRPs: []client.DBRP{{
+			Database:        "mydb",
+			RetentionPolicy: "myrp",
+		}},
+		TICKscript: tick,
+		Status:     client.Disabled,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	enabled := time.Now()
+	if _, err := cli.UpdateTask(task.Link, client.UpdateTaskOptions{
+		Status: client.Enabled,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	defer cli.UpdateTask(task.Link, client.UpdateTaskOptions{
+		Status: client.Disabled,
+	})
+
+	select {
+	case <-time.After(time.Second):
+		t.Fatal("timedout waiting for query")
+	case startTime := <-startTimeC:
+		if startTime.Before(enabled) {
+			t.Errorf("expected partial first window to be skipped, got query starting at %v before the task was enabled at %v", startTime, enabled)
+		}
+	}
+}
+
+func TestServer_BatchTask_Flux(t *testing.T) {
+	stopTimeC := make(chan time.Time, 1)
+	count := 0
+	db := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/query" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if org := r.URL.Query().Get("org"); org != "my-org" {
+			http.Error(w, fmt.Sprintf("unexpected org %q", org), http.StatusBadRequest)
+			return
+		}
+		if auth := r.Header.Get("Authorization"); auth != "Token my-token" {
+			http.Error(w, fmt.Sprintf("unexpected authorization %q", auth), http.StatusUnauthorized)
+			return
+		}
+		var body struct {
+			Query string `json:"query"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var start, stop, bucket string
+		if _, err := fmt.Sscanf(body.Query, "option v = {timeRangeStart: %s timeRangeStop: %s bucket: %s", &start, &stop, &bucket); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if bucket != `"telegraf"}` {
+			http.Error(w, fmt.Sprintf("unexpected bucket %q", bucket), http.StatusBadRequest)
+			return
+		}
+		stopTime, err := time.Parse(time.RFC3339Nano, strings.TrimSuffix(stop, ","))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		count++
+		if count > 1 {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		stopTimeC <- stopTime
+		pointTime := stopTime.Add(-time.Millisecond).Format(time.RFC3339Nano)
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, `#datatype,string,long,dateTime:RFC3339,double,string,string,string
+#group,false,false,false,false,true,true,true
+#default,_result,,,,,,
+,result,table,_time,_value,_field,_measurement,host
+,,0,%[1]s,90,usage_idle,cpu,serverA
+,,1,%[1]s,5,usage_user,cpu,serverA
+,,2,%[1]s,80,usage_idle,cpu,serverB
+,,3,%[1]s,15,usage_user,cpu,serverB
+`, pointTime)
+	}))
+	defer db.Close()
+
+	c := NewConfig()
+	c.InfluxDB[0].Enabled = true
+	c.InfluxDB[0].DisableSubscriptions = true
+	c.InfluxDB[0].Token = "my-token"
+	c.InfluxDB[0].URLs = []string{db.URL}
+	s := OpenServer(c)
+	defer s.Close()
+	cli := Client(s)
+
+	id := "testBatchTaskFlux"
+	tick := `batch
+    |query('''
+        from(bucket: v.bucket)
+            |> range(start: v.timeRangeStart, stop: v.timeRangeStop)
+            |> filter(fn: (r) => r._measurement == "cpu")
+    ''')
+        .flux()
+        .org('my-org')
+        .bucket('telegraf')
+        .period(5ms)
+        .every(5ms)
+        .align()
+    |httpOut('cpu')
+`
+
+	task, err := cli.CreateTask(client.CreateTaskOptions{
+		ID:   id,
+		Type: client.BatchTask,
+		DBRPs: []client.DBRP{{
+			Database:        "telegraf",
+			RetentionPolicy: "autogen",
+		}},
+		TICKscript: tick,
+		Status:     client.Enabled,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.UpdateTask(task.Link, client.UpdateTaskOptions{
+		Status: client.Disabled,
+	})
+
+	endpoint := fmt.Sprintf("%s/tasks/%s/cpu", s.URL(), id)
+
+	select {
+	case <-time.After(time.Second):
+		t.Fatal("timedout waiting for query")
+	case stopTime := <-stopTimeC:
+		pointTime := stopTime.Add(-time.Millisecond).Local().Format(time.RFC3339Nano)
+		exp := fmt.Sprintf(`{"series":[{"name":"cpu","tags":{"host":"serverA"},"columns":["time","usage_idle","usage_user"],"values":[["%[1]s",90,5]]},{"name":"cpu","tags":{"host":"serverB"},"columns":["time","usage_idle","usage_user"],"values":[["%[1]s",80,15]]}]}`, pointTime)
+		if err := s.HTTPGetRetry(endpoint, exp, 100, time.Millisecond*5); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
 func TestServer_BatchTask_InfluxDBConfigUpdate(t *testing.T) {
 	c := NewConfig()
 	c.InfluxDB[0].Enabled = true
@@ -6289,6 +6575,7 @@ func TestServer_UpdateConfig(t *testing.T) {
 						"subscriptions":               nil,
 						"subscriptions-sync-interval": "1m0s",
 						"timeout":                     "0s",
+						"token":                       false,
 						"udp-bind":                    "",
 						"udp-buffer":                  float64(1e3),
 						"udp-read-buffer":             float64(0),
@@ -6297,6 +6584,7 @@ func TestServer_UpdateConfig(t *testing.T) {
 					},
 					Redacted: []string{
 						"password",
+						"token",
 					},
 				}},
 			},
@@ -6321,6 +6609,7 @@ func TestServer_UpdateConfig(t *testing.T) {
 					"subscriptions":               nil,
 					"subscriptions-sync-interval": "1m0s",
 					"timeout":                     "0s",
+					"token":                       false,
 					"udp-bind":                    "",
 					"udp-buffer":                  float64(1e3),
 					"udp-read-buffer":             float64(0),
@@ -6329,6 +6618,7 @@ func TestServer_UpdateConfig(t *testing.T) {
 				},
 				Redacted: []string{
 					"password",
+					"token",
 				},
 			},
 			updates: []updateAction{
@@ -6363,6 +6653,7 @@ func TestServer_UpdateConfig(t *testing.T) {
 								"subscriptions":               nil,
 								"subscriptions-sync-interval": "1m0s",
 								"timeout":                     "0s",
+								"token":                       false,
 								"udp-bind":                    "",
 								"udp-buffer":                  float64(1e3),
 								"udp-read-buffer":             float64(0),
@@ -6371,6 +6662,7 @@ func TestServer_UpdateConfig(t *testing.T) {
 							},
 							Redacted: []string{
 								"password",
+								"token",
 							},
 						}},
 					},
@@ -6395,6 +6687,7 @@ func TestServer_UpdateConfig(t *testing.T) {
 							"subscriptions":               nil,
 							"subscriptions-sync-interval": "1m0s",
 							"timeout":                     "0s",
+							"token":                       false,
 							"udp-bind":                    "",
 							"udp-buffer":                  float64(1e3),
 							"udp-read-buffer":             float64(0),
@@ -6403,6 +6696,7 @@ func TestServer_UpdateConfig(t *testing.T) {
 						},
 						Redacted: []string{
 							"password",
+							"token",
 						},
 					},
 				},
@@ -6438,6 +6732,7 @@ func TestServer_UpdateConfig(t *testing.T) {
 								"subscriptions":               map[string]interface{}{"_internal": []interface{}{"monitor"}},
 								"subscriptions-sync-interval": "1m0s",
 								"timeout":                     "0s",
+								"token":                       false,
 								"udp-bind":                    "",
 								"udp-buffer":                  float64(1e3),
 								"udp-read-buffer":             float64(0),
@@ -6446,6 +6741,7 @@ func TestServer_UpdateConfig(t *testing.T) {
 							},
 							Redacted: []string{
 								"password",
+								"token",
 							},
 						}},
 					},
@@ -6470,6 +6766,7 @@ func TestServer_UpdateConfig(t *testing.T) {
 							"subscriptions":               map[string]interface{}{"_internal": []interface{}{"monitor"}},
 							"subscriptions-sync-interval": "1m0s",
 							"timeout":                     "0s",
+							"token":                       false,
 							"udp-bind":                    "",
 							"udp-buffer":                  float64(1e3),
 							"udp-read-buffer":             float64(0),
@@ -6478,6 +6775,7 @@ func TestServer_UpdateConfig(t *testing.T) {
 						},
 						Redacted: []string{
 							"password",
+							"token",
 						},
 					},
 				},
@@ -6509,6 +6807,7 @@ func TestServer_UpdateConfig(t *testing.T) {
 								"subscriptions":               map[string]interface{}{"_internal": []interface{}{"monitor"}},
 								"subscriptions-sync-interval": "1m0s",
 								"timeout":                     "0s",
+								"token":                       false,
 								"udp-bind":                    "",
 								"udp-buffer":                  float64(1e3),
 								"udp-read-buffer":             float64(0),
@@ -6517,6 +6816,7 @@ func TestServer_UpdateConfig(t *testing.T) {
 							},
 							Redacted: []string{
 								"password",
+								"token",
 							},
 						}},
 					},
@@ -6541,6 +6841,7 @@ func TestServer_UpdateConfig(t *testing.T) {
 							"subscriptions":               map[string]interface{}{"_internal": []interface{}{"monitor"}},
 							"subscriptions-sync-interval": "1m0s",
 							"timeout":                     "0s",
+							"token":                       false,
 							"udp-bind":                    "",
 							"udp-buffer":                  float64(1e3),
 							"udp-read-buffer":             float64(0),
@@ -6549,6 +6850,7 @@ func TestServer_UpdateConfig(t *testing.T) {
 						},
 						Redacted: []string{
 							"password",
+							"token",
 						},
 					},
 				},
@@ -6584,6 +6886,7 @@ func TestServer_UpdateConfig(t *testing.T) {
 									"subscriptions":               map[string]interface{}{"_internal": []interface{}{"monitor"}},
 									"subscriptions-sync-interval": "1m0s",
 									"timeout":                     "0s",
+									"token":                       false,
 									"udp-bind":                    "",
 									"udp-buffer":                  float64(1e3),
 									"udp-read-buffer":             float64(0),
@@ -6592,6 +6895,7 @@ func TestServer_UpdateConfig(t *testing.T) {
 								},
 								Redacted: []string{
 									"password",
+									"token",
 								},
 							},
 							{
@@ -6615,6 +6919,7 @@ func TestServer_UpdateConfig(t *testing.T) {
 									"subscriptions":               nil,
 									"subscriptions-sync-interval": "1m0s",
 									"timeout":                     "0s",
+									"token":                       false,
 									"udp-bind":                    "",
 									"udp-buffer":                  float64(1e3),
 									"udp-read-buffer":             float64(0),
@@ -6623,6 +6928,7 @@ func TestServer_UpdateConfig(t *testing.T) {
 								},
 								Redacted: []string{
 									"password",
+									"token",
 								},
 							},
 						},
@@ -6648,6 +6954,7 @@ func TestServer_UpdateConfig(t *testing.T) {
 							"subscription-mode":           "cluster",
 							"subscriptions-sync-interval": "1m0s",
 							"timeout":                     "0s",
+							"token":                       false,
 							"udp-bind":                    "",
 							"udp-buffer":                  float64(1e3),
 							"udp-read-buffer":             float64(0),
@@ -6656,6 +6963,7 @@ func TestServer_UpdateConfig(t *testing.T) {
 						},
 						Redacted: []string{
 							"password",
+							"token",
 						},
 					},
 				},
@@ -6773,6 +7081,7 @@ func TestServer_UpdateConfig(t *testing.T) {
 								"testing": "works",
 							},
 							"basic-auth":          false,
+							"signature":           false,
 							"alert-template":      "",
 							"alert-template-file": "",
 							"row-template":        "",
@@ -6780,6 +7089,7 @@ func TestServer_UpdateConfig(t *testing.T) {
 						},
 						Redacted: []string{
 							"basic-auth",
+							"signature",
 						}},
 				},
 			},
@@ -6792,6 +7102,7 @@ func TestServer_UpdateConfig(t *testing.T) {
 						"testing": "works",
 					},
 					"basic-auth":          false,
+					"signature":           false,
 					"alert-template":      "",
 					"alert-template-file": "",
 					"row-template":        "",
@@ -6799,6 +7110,7 @@ func TestServer_UpdateConfig(t *testing.T) {
 				},
 				Redacted: []string{
 					"basic-auth",
+					"signature",
 				},
 			},
 			updates: []updateAction{
@@ -6826,6 +7138,7 @@ func TestServer_UpdateConfig(t *testing.T) {
 									"testing": "more",
 								},
 								"basic-auth":          true,
+								"signature":           false,
 								"alert-template":      "",
 								"alert-template-file": "",
 								"row-template":        "",
@@ -6833,6 +7146,7 @@ func TestServer_UpdateConfig(t *testing.T) {
 							},
 							Redacted: []string{
 								"basic-auth",
+								"signature",
 							},
 						}},
 					},
@@ -6845,6 +7159,7 @@ func TestServer_UpdateConfig(t *testing.T) {
 								"testing": "more",
 							},
 							"basic-auth":          true,
+							"signature":           false,
 							"alert-template":      "",
 							"alert-template-file": "",
 							"row-template":        "",
@@ -6852,6 +7167,7 @@ func TestServer_UpdateConfig(t *testing.T) {
 						},
 						Redacted: []string{
 							"basic-auth",
+							"signature",
 						},
 					},
 				},
@@ -7296,6 +7612,8 @@ func TestServer_UpdateConfig(t *testing.T) {
 				Elements: []client.ConfigElement{{
 					Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/opsgenie2/"},
 					Options: map[string]interface{}{
+						"batch-size":      float64(100),
+						"batch-window":    "0s",
 						"api-key":         false,
 						"enabled":         false,
 						"global":          false,
@@ -7312,6 +7630,8 @@ func TestServer_UpdateConfig(t *testing.T) {
 			expDefaultElement: client.ConfigElement{
 				Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/opsgenie2/"},
 				Options: map[string]interface{}{
+					"batch-size":      float64(100),
+					"batch-window":    "0s",
 					"api-key":         false,
 					"enabled":         false,
 					"global":          false,
@@ -7338,6 +7658,8 @@ func TestServer_UpdateConfig(t *testing.T) {
 						Elements: []client.ConfigElement{{
 							Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/opsgenie2/"},
 							Options: map[string]interface{}{
+								"batch-size":      float64(100),
+								"batch-window":    "0s",
 								"api-key":         true,
 								"enabled":         false,
 								"global":          true,
@@ -7354,6 +7676,8 @@ func TestServer_UpdateConfig(t *testing.T) {
 					expElement: client.ConfigElement{
 						Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/opsgenie2/"},
 						Options: map[string]interface{}{
+							"batch-size":      float64(100),
+							"batch-window":    "0s",
 							"api-key":         true,
 							"enabled":         false,
 							"global":          true,
@@ -7449,10 +7773,12 @@ func TestServer_UpdateConfig(t *testing.T) {
 				Elements: []client.ConfigElement{{
 					Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/pagerduty2/"},
 					Options: map[string]interface{}{
-						"enabled":     false,
-						"global":      false,
-						"routing-key": true,
-						"url":         pagerduty2.DefaultPagerDuty2APIURL,
+						"batch-size":   float64(100),
+						"batch-window": "0s",
+						"enabled":      false,
+						"global":       false,
+						"routing-key":  true,
+						"url":          pagerduty2.DefaultPagerDuty2APIURL,
 					},
 					Redacted: []string{
 						"routing-key",
@@ -7462,10 +7788,12 @@ func TestServer_UpdateConfig(t *testing.T) {
 			expDefaultElement: client.ConfigElement{
 				Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/pagerduty2/"},
 				Options: map[string]interface{}{
-					"enabled":     false,
-					"global":      false,
-					"routing-key": true,
-					"url":         pagerduty2.DefaultPagerDuty2APIURL,
+					"batch-size":   float64(100),
+					"batch-window": "0s",
+					"enabled":      false,
+					"global":       false,
+					"routing-key":  true,
+					"url":          pagerduty2.DefaultPagerDuty2APIURL,
 				},
 				Redacted: []string{
 					"routing-key",
@@ -7484,10 +7812,12 @@ func TestServer_UpdateConfig(t *testing.T) {
 						Elements: []client.ConfigElement{{
 							Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/pagerduty2/"},
 							Options: map[string]interface{}{
-								"enabled":     true,
-								"global":      false,
-								"routing-key": false,
-								"url":         pagerduty2.DefaultPagerDuty2APIURL,
+								"batch-size":   float64(100),
+								"batch-window": "0s",
+								"enabled":      true,
+								"global":       false,
+								"routing-key":  false,
+								"url":          pagerduty2.DefaultPagerDuty2APIURL,
 							},
 							Redacted: []string{
 								"routing-key",
@@ -7497,10 +7827,12 @@ func TestServer_UpdateConfig(t *testing.T) {
 					expElement: client.ConfigElement{
 						Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/pagerduty2/"},
 						Options: map[string]interface{}{
-							"enabled":     true,
-							"global":      false,
-							"routing-key": false,
-							"url":         pagerduty2.DefaultPagerDuty2APIURL,
+							"batch-size":   float64(100),
+							"batch-window": "0s",
+							"enabled":      true,
+							"global":       false,
+							"routing-key":  false,
+							"url":          pagerduty2.DefaultPagerDuty2APIURL,
 						},
 						Redacted: []string{
 							"routing-key",
@@ -8321,6 +8653,93 @@ func TestServer_UpdateConfig(t *testing.T) {
 				},
 			},
 		},
+		{
+			section: "opentelemetry",
+			expDefaultSection: client.ConfigSection{
+				Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/opentelemetry"},
+				Elements: []client.ConfigElement{{
+					Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/opentelemetry/"},
+					Options: map[string]interface{}{
+						"enabled":              false,
+						"url":                  "http://localhost:4318/v1/logs",
+						"headers":              nil,
+						"service-name":         "kapacitor",
+						"batch-size":           float64(100),
+						"flush-interval":       "1s",
+						"queue-size":           float64(10000),
+						"timeout":              "10s",
+						"ssl-ca":               "",
+						"ssl-cert":             "",
+						"ssl-key":              "",
+						"insecure-skip-verify": false,
+					},
+				}},
+			},
+			expDefaultElement: client.ConfigElement{
+				Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/opentelemetry/"},
+				Options: map[string]interface{}{
+					"enabled":              false,
+					"url":                  "http://localhost:4318/v1/logs",
+					"headers":              nil,
+					"service-name":         "kapacitor",
+					"batch-size":           float64(100),
+					"flush-interval":       "1s",
+					"queue-size":           float64(10000),
+					"timeout":              "10s",
+					"ssl-ca":               "",
+					"ssl-cert":             "",
+					"ssl-key":              "",
+					"insecure-skip-verify": false,
+				},
+			},
+			updates: []updateAction{
+				{
+					updateAction: client.ConfigUpdateAction{
+						Set: map[string]interface{}{
+							"enabled": true,
+							"url":     "http://collector.example.com:4318/v1/logs",
+						},
+					},
+					expSection: client.ConfigSection{
+						Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/opentelemetry"},
+						Elements: []client.ConfigElement{{
+							Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/opentelemetry/"},
+							Options: map[string]interface{}{
+								"enabled":              true,
+								"url":                  "http://collector.example.com:4318/v1/logs",
+								"headers":              nil,
+								"service-name":         "kapacitor",
+								"batch-size":           float64(100),
+								"flush-interval":       "1s",
+								"queue-size":           float64(10000),
+								"timeout":              "10s",
+								"ssl-ca":               "",
+								"ssl-cert":             "",
+								"ssl-key":              "",
+								"insecure-skip-verify": false,
+							},
+						}},
+					},
+					expElement: client.ConfigElement{
+						Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/opentelemetry/"},
+						Options: map[string]interface{}{
+							"enabled":              true,
+							"url":                  "http://collector.example.com:4318/v1/logs",
+							"headers":              nil,
+							"service-name":         "kapacitor",
+							"batch-size":           float64(100),
+							"flush-interval":       "1s",
+							"queue-size":           float64(10000),
+							"timeout":              "10s",
+							"ssl-ca":               "",
+							"ssl-cert":             "",
+							"ssl-key":              "",
+							"insecure-skip-verify": false,
+						},
+					},
+				},
+			},
+		},
 		{
 			section: "telegram",
 			setDefaults: func(c *server.Config) {
@@ -8744,6 +9163,16 @@ func TestServer_ListServiceTests(t *testing.T) {
 				},
 			},
 			{
+				Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/service-tests/opentelemetry"},
+				Name: "opentelemetry",
+				Options: client.ServiceTestOptions{
+					"message": "test opentelemetry message",
+					"level":   "CRITICAL",
+					"tags": map[string]interface{}{
+						"host": "serverA",
+					},
+				},
+			},			{
 				Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/service-tests/opsgenie"},
 				Name: "opsgenie",
 				Options: client.ServiceTestOptions{
@@ -10513,23 +10942,31 @@ stream
 	}
 }
 
-func TestServer_Alert_Aggregate(t *testing.T) {
-	// Setup test TCP server
-	ts, err := alerttest.NewTCPServer()
-	if err != nil {
-		t.Fatal(err)
-	}
+func TestServer_Alert_DurableRetry(t *testing.T) {
+	var mu sync.Mutex
+	fail := true
+	var attempts int
+	var delivered []alert.Data
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ad := alert.Data{}
+		if err := json.NewDecoder(r.Body).Decode(&ad); err != nil {
+			t.Error(err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		attempts++
+		if fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		delivered = append(delivered, ad)
+	}))
 	defer ts.Close()
 
-	// Create default config
-	c := NewConfig()
-	s := OpenServer(c)
-	cli := Client(s)
+	s, cli := OpenDefaultServer()
 	defer s.Close()
 
-	aggTopic := "agg"
-
-	// Create task for alert
+	// Retry hourly so the event is only redelivered when the task restarts.
 	tick := `
 stream
 	|from()
@@ -10537,13 +10974,12 @@ stream
 	|alert()
 		.id('id')
 		.message('message')
-		.details('details')
 		.crit(lambda: "value" > 1.0)
-		.topic('` + aggTopic + `')
+		.durableRetry(24h, 1h)
+		.post('` + ts.URL + `')
 `
-
 	if _, err := cli.CreateTask(client.CreateTaskOptions{
-		ID:   "agg_task",
+		ID:   "testDurableRetry",
 		Type: client.StreamTask,
 		DBRPs: []client.DBRP{{
 			Database:        "mydb",
@@ -10555,13 +10991,249 @@ stream
 		t.Fatal(err)
 	}
 
-	// Create tpc handler on tcp topic
-	tcpTopic := "tcp"
-	if _, err := cli.CreateTopicHandler(cli.TopicHandlersLink(tcpTopic), client.TopicHandlerOptions{
-		ID:   "tcp_handler",
-		Kind: "tcp",
-		Options: map[string]interface{}{
-			"address": ts.Addr,
+	point := "alert value=2 0000000000"
+	v := url.Values{}
+	v.Add("precision", "s")
+	s.MustWrite("mydb", "myrp", point, v)
+
+	// Wait for the undelivered event to be saved in the task snapshot.
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		if s.TaskStore.HasSnapshot("testDurableRetry") {
+			snapshot, err := s.TaskStore.LoadSnapshot("testDurableRetry")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(snapshot.NodeSnapshots["alert2"]) > 0 {
+				break
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the undelivered event to be saved")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	mu.Lock()
+	fail = false
+	mu.Unlock()
+
+	s.Restart()
+
+	deadline = time.Now().Add(10 * time.Second)
+	for {
+		mu.Lock()
+		n := len(delivered)
+		mu.Unlock()
+		if n > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the event to be redelivered")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if attempts < 2 {
+		t.Errorf("expected a failed attempt before the redelivery, got %d attempts", attempts)
+	}
+	if len(delivered) != 1 {
+		t.Fatalf("unexpected number of delivered events: %d", len(delivered))
+	}
+	if got := delivered[0]; got.ID != "id" || got.Level != alert.Critical || !got.Time.Equal(time.Unix(0, 0)) {
+		t.Errorf("unexpected redelivered event: %+v", got)
+	}
+}
+
+func TestServer_StateDuration_PersistState(t *testing.T) {
+	c := NewConfig()
+	c.Task.SnapshotInterval = toml.Duration(100 * time.Millisecond)
+	s := OpenServer(c)
+	cli := Client(s)
+	defer s.Close()
+
+	id := "testPersistState"
+	tick := `stream
+    |from()
+        .measurement('state')
+    |stateDuration(lambda: "value" > 1.0)
+        .unit(1s)
+        .persistState()
+    |httpOut('state')
+`
+	if _, err := cli.CreateTask(client.CreateTaskOptions{
+		ID:   id,
+		Type: client.StreamTask,
+		DBRPs: []client.DBRP{{
+			Database:        "mydb",
+			RetentionPolicy: "myrp",
+		}},
+		TICKscript: tick,
+		Status:     client.Enabled,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	v := url.Values{}
+	v.Add("precision", "s")
+	s.MustWrite("mydb", "myrp", "state value=2 0000000000\nstate value=2 0000000010\n", v)
+
+	endpoint := fmt.Sprintf("%s/tasks/%s/state", s.URL(), id)
+	exp := `{"series":[{"name":"state","columns":["time","state_duration","value"],"values":[["1970-01-01T00:00:10Z",10,2]]}]}`
+	if err := s.HTTPGetRetry(endpoint, exp, 100, time.Millisecond*5); err != nil {
+		t.Fatal(err)
+	}
+
+	// Wait for the state of the group to be saved in the task snapshot.
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		if s.TaskStore.HasSnapshot(id) {
+			snapshot, err := s.TaskStore.LoadSnapshot(id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Contains(string(snapshot.NodeSnapshots["state_duration2"]), "1970-01-01T00:00:00Z") {
+				break
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the state to be saved")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	s.Restart()
+	// Connections kept alive to the stopped server are closed.
+	http.DefaultTransport.(*http.Transport).CloseIdleConnections()
+
+	// The duration continues from the state before the restart.
+	s.MustWrite("mydb", "myrp", "state value=2 0000000030\n", v)
+	exp = `{"series":[{"name":"state","columns":["time","state_duration","value"],"values":[["1970-01-01T00:00:30Z",30,2]]}]}`
+	if err := s.HTTPGetRetry(endpoint, exp, 100, time.Millisecond*5); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestServer_UpDownCounter_PersistState(t *testing.T) {
+	c := NewConfig()
+	c.Task.SnapshotInterval = toml.Duration(100 * time.Millisecond)
+	s := OpenServer(c)
+	cli := Client(s)
+	defer s.Close()
+
+	id := "testUpDownCounterPersistState"
+	tick := `stream
+    |from()
+        .measurement('connections')
+        .groupBy('server')
+    |upDownCounter('delta')
+        .persistState()
+    |httpOut('total')
+`
+	if _, err := cli.CreateTask(client.CreateTaskOptions{
+		ID:   id,
+		Type: client.StreamTask,
+		DBRPs: []client.DBRP{{
+			Database:        "mydb",
+			RetentionPolicy: "myrp",
+		}},
+		TICKscript: tick,
+		Status:     client.Enabled,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	v := url.Values{}
+	v.Add("precision", "s")
+	s.MustWrite("mydb", "myrp", "connections,server=a delta=1 0000000000\nconnections,server=a delta=1.5 0000000001\nconnections,server=a delta=-0.5 0000000002\n", v)
+
+	endpoint := fmt.Sprintf("%s/tasks/%s/total", s.URL(), id)
+	exp := `{"series":[{"name":"connections","tags":{"server":"a"},"columns":["time","delta","total"],"values":[["1970-01-01T00:00:02Z",-0.5,2]]}]}`
+	if err := s.HTTPGetRetry(endpoint, exp, 100, time.Millisecond*5); err != nil {
+		t.Fatal(err)
+	}
+
+	// Wait for the total of the group to be saved in the task snapshot.
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		if s.TaskStore.HasSnapshot(id) {
+			snapshot, err := s.TaskStore.LoadSnapshot(id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Contains(string(snapshot.NodeSnapshots["up_down_counter2"]), "server=a") {
+				break
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the total to be saved")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	s.Restart()
+	// Connections kept alive to the stopped server are closed.
+	http.DefaultTransport.(*http.Transport).CloseIdleConnections()
+
+	// The total continues from the total before the restart.
+	s.MustWrite("mydb", "myrp", "connections,server=a delta=1 0000000003\n", v)
+	exp = `{"series":[{"name":"connections","tags":{"server":"a"},"columns":["time","delta","total"],"values":[["1970-01-01T00:00:03Z",1,3]]}]}`
+	if err := s.HTTPGetRetry(endpoint, exp, 100, time.Millisecond*5); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestServer_Alert_Aggregate(t *testing.T) {
+	// Setup test TCP server
+	ts, err := alerttest.NewTCPServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Close()
+
+	// Create default config
+	c := NewConfig()
+	s := OpenServer(c)
+	cli := Client(s)
+	defer s.Close()
+
+	aggTopic := "agg"
+
+	// Create task for alert
+	tick := `
+stream
+	|from()
+		.measurement('alert')
+	|alert()
+		.id('id')
+		.message('message')
+		.details('details')
+		.crit(lambda: "value" > 1.0)
+		.topic('` + aggTopic + `')
+`
+
+	if _, err := cli.CreateTask(client.CreateTaskOptions{
+		ID:   "agg_task",
+		Type: client.StreamTask,
+		DBRPs: []client.DBRP{{
+			Database:        "mydb",
+			RetentionPolicy: "myrp",
+		}},
+		TICKscript: tick,
+		Status:     client.Enabled,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Create tpc handler on tcp topic
+	tcpTopic := "tcp"
+	if _, err := cli.CreateTopicHandler(cli.TopicHandlersLink(tcpTopic), client.TopicHandlerOptions{
+		ID:   "tcp_handler",
+		Kind: "tcp",
+		Options: map[string]interface{}{
+			"address": ts.Addr,
 		},
 	}); err != nil {
 		t.Fatal(err)
@@ -11937,6 +12609,332 @@ stream
 	}
 }
 
+func TestServer_ReloadConfig_AlertHandlerCredentials(t *testing.T) {
+	// Create default config
+	c := NewConfig()
+
+	// Configure slack with the old credentials
+	ts := slacktest.NewServer()
+	defer ts.Close()
+	c.Slack[0].Enabled = true
+	c.Slack[0].URL = ts.URL + "/old/token"
+
+	s := OpenServer(c)
+	cli := Client(s)
+	closed := false
+	defer func() {
+		if !closed {
+			s.Close()
+		}
+	}()
+
+	if _, err := cli.CreateTopicHandler(cli.TopicHandlersLink("test"), client.TopicHandlerOptions{
+		ID:   "testReloadConfig-Slack",
+		Kind: "slack",
+		Options: map[string]interface{}{
+			"channel": "#test",
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	tick := `
+stream
+	|from()
+		.measurement('alert')
+	|alert()
+		.topic('test')
+		.id('id')
+		.message('message')
+		.crit(lambda: TRUE)
+`
+
+	if _, err := cli.CreateTask(client.CreateTaskOptions{
+		ID:   "testReloadConfig",
+		Type: client.StreamTask,
+		DBRPs: []client.DBRP{{
+			Database:        "mydb",
+			RetentionPolicy: "myrp",
+		}},
+		TICKscript: tick,
+		Status:     client.Enabled,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	v := url.Values{}
+	v.Add("precision", "s")
+	s.MustWrite("mydb", "myrp", "alert value=1 0000000000", v)
+
+	// Wait for the first alert to be sent with the old credentials
+	timeout := time.After(10 * time.Second)
+	for len(ts.Requests()) == 0 {
+		select {
+		case <-timeout:
+			t.Fatal("timed out waiting for first alert")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	// Rotate the credentials without restarting the task
+	newConfig := *c
+	newConfig.Slack = slack.Configs{c.Slack[0]}
+	newConfig.Slack[0].URL = ts.URL + "/new/token"
+	if err := s.Server.ReloadConfig(&newConfig); err != nil {
+		t.Fatal(err)
+	}
+
+	s.MustWrite("mydb", "myrp", "alert value=1 0000000001", v)
+
+	// Close the entire server to ensure all data is processed
+	s.Close()
+	closed = true
+
+	ts.Close()
+	var got []string
+	for _, r := range ts.Requests() {
+		got = append(got, r.URL)
+	}
+	exp := []string{"/old/token", "/new/token"}
+	if !reflect.DeepEqual(exp, got) {
+		t.Errorf("unexpected slack request URLs:\nexp\n%v\ngot\n%v\n", exp, got)
+	}
+}
+
+// updateCounter counts the updates of a dynamic service.
+type updateCounter struct {
+	server.Updater
+
+	mu      sync.Mutex
+	updates int
+}
+
+func (u *updateCounter) Update(c []interface{}) error {
+	u.mu.Lock()
+	u.updates++
+	u.mu.Unlock()
+	return u.Updater.Update(c)
+}
+
+func (u *updateCounter) Updates() int {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.updates
+}
+
+func TestServer_ReloadConfig_Changed(t *testing.T) {
+	c := NewConfig()
+	s := OpenServer(c)
+	defer s.Close()
+
+	slackUpdates := &updateCounter{Updater: s.Server.DynamicServices["slack"]}
+	s.Server.DynamicServices["slack"] = slackUpdates
+	smtpUpdates := &updateCounter{Updater: s.Server.DynamicServices["smtp"]}
+	s.Server.DynamicServices["smtp"] = smtpUpdates
+
+	// Only the changed section is applied.
+	newConfig := *c
+	newConfig.Slack = slack.Configs{c.Slack[0]}
+	newConfig.Slack[0].Channel = "#reloaded"
+	if err := s.Server.ReloadConfig(&newConfig); err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := slackUpdates.Updates(), 1; got != exp {
+		t.Errorf("unexpected slack updates: got %d exp %d", got, exp)
+	}
+	if got, exp := smtpUpdates.Updates(), 0; got != exp {
+		t.Errorf("unexpected smtp updates: got %d exp %d", got, exp)
+	}
+
+	// Reloading the same configuration updates nothing.
+	if err := s.Server.ReloadConfig(&newConfig); err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := slackUpdates.Updates(), 1; got != exp {
+		t.Errorf("unexpected slack updates after reloading the same configuration: got %d exp %d", got, exp)
+	}
+
+	// No service is updated if a section belongs to an unknown service.
+	delete(s.Server.DynamicServices, "smtp")
+	newConfig.Slack = slack.Configs{c.Slack[0]}
+	newConfig.Slack[0].Channel = "#unknown"
+	if err := s.Server.ReloadConfig(&newConfig); err == nil {
+		t.Error("expected error for unknown service")
+	}
+	if got, exp := slackUpdates.Updates(), 1; got != exp {
+		t.Errorf("unexpected slack updates after failed reload: got %d exp %d", got, exp)
+	}
+}
+
+func TestServer_AlertAck(t *testing.T) {
+	var mu sync.Mutex
+	var levels []alert.Level
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ad := alert.Data{}
+		if err := json.NewDecoder(r.Body).Decode(&ad); err != nil {
+			t.Error(err)
+		}
+		mu.Lock()
+		levels = append(levels, ad.Level)
+		mu.Unlock()
+	}))
+	defer ts.Close()
+	received := func() []alert.Level {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]alert.Level(nil), levels...)
+	}
+
+	s, cli := OpenDefaultServer()
+	closed := false
+	defer func() {
+		if !closed {
+			s.Close()
+		}
+	}()
+
+	id := "testAlertAck"
+	tick := fmt.Sprintf(`
+stream
+	|from()
+		.measurement('cpu')
+		.groupBy('host')
+	|alert()
+		.id('id')
+		.crit(lambda: "value" > 10)
+		.post('%s')
+`, ts.URL)
+
+	if _, err := cli.CreateTask(client.CreateTaskOptions{
+		ID:   id,
+		Type: client.StreamTask,
+		DBRPs: []client.DBRP{{
+			Database:        "mydb",
+			RetentionPolicy: "myrp",
+		}},
+		TICKscript: tick,
+		Status:     client.Enabled,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	v := url.Values{}
+	v.Add("precision", "s")
+	s.MustWrite("mydb", "myrp", "cpu,host=serverA value=20 0000000000", v)
+
+	// Wait for the critical alert to be sent
+	timeout := time.After(10 * time.Second)
+	for len(received()) == 0 {
+		select {
+		case <-timeout:
+			t.Fatal("timed out waiting for critical alert")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	ack := func(body string) int {
+		resp, err := http.Post(s.URL()+"/tasks/"+id+"/alert2/ack", "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if got, exp := ack(`{"group":"host=serverB"}`), http.StatusNotFound; got != exp {
+		t.Errorf("unexpected status acknowledging inactive alert: got %d exp %d", got, exp)
+	}
+	if got, exp := ack(`{"group":"host=serverA","level":"WARNING"}`), http.StatusConflict; got != exp {
+		t.Errorf("unexpected status acknowledging alert at wrong level: got %d exp %d", got, exp)
+	}
+	if got, exp := ack(`{"group":"host=serverA","level":"CRITICAL"}`), http.StatusNoContent; got != exp {
+		t.Fatalf("unexpected status acknowledging alert: got %d exp %d", got, exp)
+	}
+
+	// The acknowledged alert is suppressed, the recovery is sent.
+	s.MustWrite("mydb", "myrp", "cpu,host=serverA value=30 0000000001", v)
+	s.MustWrite("mydb", "myrp", "cpu,host=serverA value=5 0000000002", v)
+
+	// Close the entire server to ensure all data is processed
+	s.Close()
+	closed = true
+
+	exp := []alert.Level{alert.Critical, alert.OK}
+	if got := received(); !reflect.DeepEqual(exp, got) {
+		t.Errorf("unexpected alert levels:\nexp\n%v\ngot\n%v\n", exp, got)
+	}
+}
+
+func TestServer_BarrierPeriod(t *testing.T) {
+	s, cli := OpenDefaultServer()
+	defer s.Close()
+
+	id := "testBarrierPeriod"
+	tick := `
+stream
+	|from()
+		.measurement('cpu')
+	|barrier()
+		.period(1h)
+	|log()
+`
+	if _, err := cli.CreateTask(client.CreateTaskOptions{
+		ID:   id,
+		Type: client.StreamTask,
+		DBRPs: []client.DBRP{{
+			Database:        "mydb",
+			RetentionPolicy: "myrp",
+		}},
+		TICKscript: tick,
+		Status:     client.Enabled,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	u := s.URL() + "/tasks/" + id + "/barrier2/period"
+	get := func() string {
+		resp, err := http.Get(u)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("unexpected status getting period: %d", resp.StatusCode)
+		}
+		b, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+	set := func(body string) int {
+		resp, err := http.Post(u, "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if got, exp := get(), `{"period":"1h"}`; got != exp {
+		t.Errorf("unexpected period: got %s exp %s", got, exp)
+	}
+	if got, exp := set(`{"period":"30s"}`), http.StatusNoContent; got != exp {
+		t.Fatalf("unexpected status setting period: got %d exp %d", got, exp)
+	}
+	if got, exp := get(), `{"period":"30s"}`; got != exp {
+		t.Errorf("unexpected period: got %s exp %s", got, exp)
+	}
+	if got, exp := set(`{"period":"1ms"}`), http.StatusBadRequest; got != exp {
+		t.Errorf("unexpected status setting too small period: got %d exp %d", got, exp)
+	}
+	if got, exp := set(`{"period":"soon"}`), http.StatusBadRequest; got != exp {
+		t.Errorf("unexpected status setting invalid period: got %d exp %d", got, exp)
+	}
+	if got, exp := get(), `{"period":"30s"}`; got != exp {
+		t.Errorf("unexpected period: got %s exp %s", got, exp)
+	}
+}
+
 func TestServer_AlertHandler_MultipleHandlers(t *testing.T) {
 	resultJSON := `{"series":[{"name":"alert","columns":["time","value"],"values":[["1970-01-01T00:00:00Z",1]]}]}`
 
@@ -155,6 +155,12 @@ func NewTCPHandler(c TCPHandlerConfig, d HandlerDiagnostic) alert.Handler {
 }
 
 func (h *tcpHandler) Handle(event alert.Event) {
+	// Failures are logged by Deliver.
+	_ = h.Deliver(event)
+}
+
+// Deliver writes the alert data to the address, returning an error if it could not be written.
+func (h *tcpHandler) Deliver(event alert.Event) error {
 	buf := h.bp.Get()
 	defer h.bp.Put(buf)
 	ad := event.AlertData()
@@ -162,18 +168,22 @@ func (h *tcpHandler) Handle(event alert.Event) {
 	err := json.NewEncoder(buf).Encode(ad)
 	if err != nil {
 		h.diag.Error("failed to marshal alert data json", err)
-		return
+		return err
 	}
 
 	conn, err := net.Dial("tcp", h.addr)
 	if err != nil {
 		h.diag.Error("tcp handler failed to connect", err, keyvalue.KV("address", h.addr))
-		return
+		return err
 	}
 	defer conn.Close()
 
 	buf.WriteByte('\n')
-	conn.Write(buf.Bytes())
+	if _, err := conn.Write(buf.Bytes()); err != nil {
+		h.diag.Error("tcp handler failed to write", err, keyvalue.KV("address", h.addr))
+		return err
+	}
+	return nil
 }
 
 type AggregateHandlerConfig struct {
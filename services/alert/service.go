@@ -89,6 +89,9 @@ type Service struct {
 	KafkaService interface {
 		Handler(kafka.HandlerConfig, ...keyvalue.T) (alert.Handler, error)
 	}
+	OpenTelemetryService interface {
+		Handler(...keyvalue.T) alert.Handler
+	}
 	MQTTService interface {
 		Handler(mqtt.HandlerConfig, ...keyvalue.T) alert.Handler
 	}
@@ -117,7 +120,7 @@ type Service struct {
 		Handler(slack.HandlerConfig, ...keyvalue.T) alert.Handler
 	}
 	SMTPService interface {
-		Handler(smtp.HandlerConfig, ...keyvalue.T) alert.Handler
+		Handler(smtp.HandlerConfig, ...keyvalue.T) (alert.Handler, error)
 	}
 	SNMPTrapService interface {
 		Handler(snmptrap.HandlerConfig, ...keyvalue.T) (alert.Handler, error)
@@ -822,6 +825,9 @@ func (s *Service) createHandlerFromSpec(spec HandlerSpec) (handler, error) {
 		}
 		h = s.MQTTService.Handler(c, ctx...)
 		h = newExternalHandler(h)
+	case "opentelemetry":
+		h = s.OpenTelemetryService.Handler(ctx...)
+		h = newExternalHandler(h)
 	case "opsgenie":
 		c := opsgenie.HandlerConfig{}
 		err = decodeOptions(spec.Options, &c)
@@ -905,7 +911,10 @@ func (s *Service) createHandlerFromSpec(spec HandlerSpec) (handler, error) {
 		if err != nil {
 			return handler{}, err
 		}
-		h = s.SMTPService.Handler(c, ctx...)
+		h, err = s.SMTPService.Handler(c, ctx...)
+		if err != nil {
+			return handler{}, err
+		}
 		h = newExternalHandler(h)
 	case "snmptrap":
 		c := snmptrap.HandlerConfig{}
@@ -7,6 +7,7 @@ import (
 	"path"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	client "github.com/influxdata/kapacitor/client/v1"
@@ -37,11 +38,14 @@ type ConfigUpdate struct {
 
 type Service struct {
 	enabled bool
-	config  interface{}
 	diag    Diagnostic
 	updates chan<- ConfigUpdate
 	routes  []httpd.Route
 
+	// The configuration onto which overrides are applied.
+	mu     sync.RWMutex
+	config interface{}
+
 	// Cached map of section name to element key name
 	elementKeys map[string]string
 
@@ -83,7 +87,7 @@ func (s *Service) Open() error {
 	s.StorageService.Register(overridesAPIName, s.overrides)
 
 	// Cache element keys
-	if elementKeys, err := override.ElementKeys(s.config); err != nil {
+	if elementKeys, err := override.ElementKeys(s.baseConfig()); err != nil {
 		return errors.Wrap(err, "failed to determine the element keys")
 	} else {
 		s.elementKeys = elementKeys
@@ -237,7 +241,7 @@ func (s *Service) handleUpdateSection(w http.ResponseWriter, r *http.Request) {
 
 	// Apply overrides to config object
 	os := convertOverrides(overrides)
-	newConfig, err := override.OverrideConfig(s.config, os)
+	newConfig, err := override.OverrideConfig(s.baseConfig(), os)
 	if err != nil {
 		httpd.HttpError(w, err.Error(), true, http.StatusBadRequest)
 		return
@@ -489,7 +493,7 @@ func (s *Service) getConfig(section string) (client.ConfigSections, error) {
 		return client.ConfigSections{}, errors.Wrap(err, "failed to retrieve config overrides")
 	}
 	os := convertOverrides(overrides)
-	sections, err := override.OverrideConfig(s.config, os)
+	sections, err := override.OverrideConfig(s.baseConfig(), os)
 	if err != nil {
 		return client.ConfigSections{}, errors.Wrap(err, "failed to apply configuration overrides")
 	}
@@ -521,20 +525,52 @@ func (s *Service) getConfig(section string) (client.ConfigSections, error) {
 }
 
 func (s *Service) Config() (map[string][]interface{}, error) {
+	return s.ConfigOf(s.baseConfig())
+}
+
+// ConfigOf returns the values of all sections of config with the current overrides applied.
+func (s *Service) ConfigOf(config interface{}) (map[string][]interface{}, error) {
 	overrides, err := s.overrides.List("")
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to retrieve config overrides")
 	}
-	os := convertOverrides(overrides)
-	sections, err := override.OverrideConfig(s.config, os)
+	return sectionValues(config, convertOverrides(overrides))
+}
+
+// SetConfig replaces the configuration onto which overrides are applied,
+// i.e. after the configuration file has been reloaded.
+func (s *Service) SetConfig(config interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.config = config
+}
+
+// BaseConfig returns the configuration onto which overrides are applied.
+func (s *Service) BaseConfig() interface{} {
+	return s.baseConfig()
+}
+
+func (s *Service) baseConfig() interface{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.config
+}
+
+// Sections returns the values of all sections of the configuration object that can be overridden.
+func Sections(config interface{}) (map[string][]interface{}, error) {
+	return sectionValues(config, nil)
+}
+
+func sectionValues(config interface{}, os []override.Override) (map[string][]interface{}, error) {
+	sections, err := override.OverrideConfig(config, os)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to apply configuration overrides")
 	}
-	config := make(map[string][]interface{}, len(sections))
+	values := make(map[string][]interface{}, len(sections))
 	for name, sectionList := range sections {
 		for _, section := range sectionList {
-			config[name] = append(config[name], section.Value())
+			values[name] = append(values[name], section.Value())
 		}
 	}
-	return config, nil
+	return values, nil
 }
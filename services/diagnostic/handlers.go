@@ -24,6 +24,7 @@ import (
 	"github.com/influxdata/kapacitor/services/k8s"
 	"github.com/influxdata/kapacitor/services/kafka"
 	"github.com/influxdata/kapacitor/services/mqtt"
+	"github.com/influxdata/kapacitor/services/opentelemetry"
 	"github.com/influxdata/kapacitor/services/opsgenie"
 	"github.com/influxdata/kapacitor/services/opsgenie2"
 	"github.com/influxdata/kapacitor/services/pagerduty"
@@ -239,6 +240,10 @@ func (h *KapacitorHandler) ClosingEdge(collected int64, emitted int64) {
 	h.l.Debug("closing edge", Int64("collected", collected), Int64("emitted", emitted))
 }
 
+func (h *KapacitorHandler) OutOfOrder(group models.GroupID, previous, current time.Time) {
+	h.l.Error("point out of order", String("group", string(group)), Time("previous", previous), Time("current", current))
+}
+
 func (h *KapacitorHandler) Error(msg string, err error, ctx ...keyvalue.T) {
 	Err(h.l, msg, err, ctx)
 }
@@ -264,6 +269,10 @@ func (h *KapacitorHandler) StartingBatchQuery(q string) {
 	h.l.Debug("starting next batch query", String("query", q))
 }
 
+func (h *KapacitorHandler) SwitchingCluster(from, to string) {
+	h.l.Info("switching InfluxDB cluster", String("from", from), String("to", to))
+}
+
 func TagPairs(tags models.Tags) []Field {
 	ts := []Field{}
 	for k, v := range tags {
@@ -877,6 +886,24 @@ func (h *TalkHandler) WithContext(ctx ...keyvalue.T) talk.Diagnostic {
 	}
 }
 
+// OpenTelemetry handler
+
+type OpenTelemetryHandler struct {
+	l Logger
+}
+
+func (h *OpenTelemetryHandler) Error(msg string, err error) {
+	h.l.Error(msg, Error(err))
+}
+
+func (h *OpenTelemetryHandler) WithContext(ctx ...keyvalue.T) opentelemetry.Diagnostic {
+	fields := logFieldsFromContext(ctx)
+
+	return &OpenTelemetryHandler{
+		l: h.l.With(fields...),
+	}
+}
+
 // Config handler
 
 type ConfigOverrideHandler struct {
@@ -1005,6 +1032,41 @@ func (h *UDPHandler) ClosedService() {
 	h.l.Info("closed service")
 }
 
+// PBLine handler
+
+type PBLineHandler struct {
+	l Logger
+}
+
+func (h *PBLineHandler) Error(msg string, err error, ctx ...keyvalue.T) {
+	Err(h.l, msg, err, ctx)
+}
+
+func (h *PBLineHandler) StartedListening(addr string) {
+	h.l.Info("started listening on TCP", String("address", addr))
+}
+
+func (h *PBLineHandler) ClosedService() {
+	h.l.Info("closed service")
+}
+
+// PointLimit handler
+
+type PointLimitHandler struct {
+	l Logger
+}
+
+func (h *PointLimitHandler) RejectedPoints(source, database, retentionPolicy, reason, sample string, rejected int64) {
+	h.l.Error("rejected oversized points",
+		String("source", source),
+		String("database", database),
+		String("retention_policy", retentionPolicy),
+		String("reason", reason),
+		String("sample", sample),
+		Int64("rejected", rejected),
+	)
+}
+
 // InfluxDB handler
 
 type InfluxDBHandler struct {
@@ -279,6 +279,12 @@ func (s *Service) NewTalkHandler() *TalkHandler {
 	}
 }
 
+func (s *Service) NewOpenTelemetryHandler() *OpenTelemetryHandler {
+	return &OpenTelemetryHandler{
+		l: s.Logger.With(String("service", "opentelemetry")),
+	}
+}
+
 func (s *Service) NewConfigOverrideHandler() *ConfigOverrideHandler {
 	return &ConfigOverrideHandler{
 		l: s.Logger.With(String("service", "config-override")),
@@ -342,6 +348,18 @@ func (s *Service) NewUDPHandler() *UDPHandler {
 	}
 }
 
+func (s *Service) NewPBLineHandler() *PBLineHandler {
+	return &PBLineHandler{
+		l: s.Logger.With(String("service", "pbline")),
+	}
+}
+
+func (s *Service) NewPointLimitHandler() *PointLimitHandler {
+	return &PointLimitHandler{
+		l: s.Logger.With(String("service", "pointlimit")),
+	}
+}
+
 func (s *Service) NewInfluxDBHandler() *InfluxDBHandler {
 	return &InfluxDBHandler{
 		l: s.Logger.With(String("service", "influxdb")),
@@ -0,0 +1,99 @@
+package encryption
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+type Config struct {
+	// ActiveKey is the ID of the key used to encrypt new values.
+	// All configured keys can be used to decrypt values.
+	ActiveKey string      `toml:"active-key"`
+	Keys      []KeyConfig `toml:"keys"`
+}
+
+type KeyConfig struct {
+	// ID of the key, it is stored with each ciphertext.
+	ID string `toml:"id"`
+	// Base64 encoded key of 16, 24 or 32 bytes.
+	Key string `toml:"key"`
+	// Name of an environment variable that holds the base64 encoded key.
+	KeyEnv string `toml:"key-env"`
+}
+
+func NewConfig() Config {
+	return Config{}
+}
+
+// Enabled reports whether any keys have been configured.
+func (c Config) Enabled() bool {
+	return len(c.Keys) > 0
+}
+
+func (c Config) Validate() error {
+	if !c.Enabled() {
+		if c.ActiveKey != "" {
+			return fmt.Errorf("active-key %q is not a configured key", c.ActiveKey)
+		}
+		return nil
+	}
+	ids := make(map[string]bool, len(c.Keys))
+	for _, k := range c.Keys {
+		if err := k.Validate(); err != nil {
+			return err
+		}
+		if ids[k.ID] {
+			return fmt.Errorf("duplicate key id %q", k.ID)
+		}
+		ids[k.ID] = true
+	}
+	if c.ActiveKey == "" {
+		return errors.New("must specify active-key when keys are configured")
+	}
+	if !ids[c.ActiveKey] {
+		return fmt.Errorf("active-key %q is not a configured key", c.ActiveKey)
+	}
+	return nil
+}
+
+func (k KeyConfig) Validate() error {
+	if k.ID == "" {
+		return errors.New("must specify key id")
+	}
+	if strings.Contains(k.ID, idSeparator) {
+		return fmt.Errorf("key id %q must not contain %q", k.ID, idSeparator)
+	}
+	_, err := k.Bytes()
+	return err
+}
+
+// Bytes returns the decoded key, reading it from the environment if configured.
+func (k KeyConfig) Bytes() ([]byte, error) {
+	if k.Key != "" && k.KeyEnv != "" {
+		return nil, fmt.Errorf("key %q: must specify only one of key or key-env", k.ID)
+	}
+	encoded := k.Key
+	if k.KeyEnv != "" {
+		v, ok := os.LookupEnv(k.KeyEnv)
+		if !ok || v == "" {
+			return nil, fmt.Errorf("key %q: environment variable %s is not set", k.ID, k.KeyEnv)
+		}
+		encoded = v
+	}
+	if encoded == "" {
+		return nil, fmt.Errorf("key %q: must specify one of key or key-env", k.ID)
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("key %q: invalid base64 encoding: %v", k.ID, err)
+	}
+	switch len(key) {
+	case 16, 24, 32:
+	default:
+		return nil, fmt.Errorf("key %q: must be 16, 24 or 32 bytes, got %d", k.ID, len(key))
+	}
+	return key, nil
+}
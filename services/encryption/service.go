@@ -0,0 +1,109 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// idSeparator separates the key ID from the encoded ciphertext.
+const idSeparator = ":"
+
+var (
+	ErrNotEnabled        = errors.New("encryption is not enabled, no keys are configured")
+	ErrInvalidCiphertext = errors.New("invalid ciphertext")
+)
+
+// Service encrypts and decrypts values with AES-GCM using the configured keys.
+//
+// Ciphertexts have the form <key id>:<base64 nonce and sealed data>,
+// so that values encrypted with a previous key can still be decrypted
+// after the active key has been rotated.
+type Service struct {
+	active string
+	aeads  map[string]cipher.AEAD
+}
+
+func NewService(c Config) (*Service, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	s := &Service{
+		active: c.ActiveKey,
+		aeads:  make(map[string]cipher.AEAD, len(c.Keys)),
+	}
+	for _, k := range c.Keys {
+		key, err := k.Bytes()
+		if err != nil {
+			return nil, err
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %v", k.ID, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %v", k.ID, err)
+		}
+		s.aeads[k.ID] = aead
+	}
+	return s, nil
+}
+
+func (s *Service) Open() error {
+	return nil
+}
+
+func (s *Service) Close() error {
+	return nil
+}
+
+// Enabled reports whether any keys are configured.
+func (s *Service) Enabled() bool {
+	return len(s.aeads) > 0
+}
+
+// Encrypt seals the plaintext with the active key.
+func (s *Service) Encrypt(plaintext []byte) (string, error) {
+	aead, ok := s.aeads[s.active]
+	if !ok {
+		return "", ErrNotEnabled
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, plaintext, []byte(s.active))
+	return s.active + idSeparator + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a ciphertext produced by Encrypt using the key it was encrypted with.
+func (s *Service) Decrypt(ciphertext string) ([]byte, error) {
+	if !s.Enabled() {
+		return nil, ErrNotEnabled
+	}
+	i := strings.Index(ciphertext, idSeparator)
+	if i < 0 {
+		return nil, ErrInvalidCiphertext
+	}
+	id := ciphertext[:i]
+	aead, ok := s.aeads[id]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", id)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(ciphertext[i+1:])
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return nil, ErrInvalidCiphertext
+	}
+	nonce := sealed[:aead.NonceSize()]
+	plaintext, err := aead.Open(nil, nonce, sealed[aead.NonceSize():], []byte(id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt with key %q: %v", id, err)
+	}
+	return plaintext, nil
+}
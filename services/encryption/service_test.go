@@ -0,0 +1,290 @@
+package encryption_test
+
+import (
+	"encoding/base64"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/influxdata/kapacitor/services/encryption"
+)
+
+func key(b byte, n int) string {
+	return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(b), n)))
+}
+
+func newService(t *testing.T, c encryption.Config) *encryption.Service {
+	t.Helper()
+	s, err := encryption.NewService(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestService_RoundTrip(t *testing.T) {
+	s := newService(t, encryption.Config{
+		ActiveKey: "k1",
+		Keys: []encryption.KeyConfig{
+			{ID: "k1", Key: key('a', 32)},
+		},
+	})
+	ciphertext, err := s.Encrypt([]byte("123-45-6789"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(ciphertext, "k1:") {
+		t.Errorf("expected ciphertext to be tagged with key id, got %q", ciphertext)
+	}
+	if strings.Contains(ciphertext, "123-45-6789") {
+		t.Errorf("ciphertext contains plaintext: %q", ciphertext)
+	}
+	other, err := s.Encrypt([]byte("123-45-6789"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other == ciphertext {
+		t.Error("expected unique ciphertexts for the same plaintext")
+	}
+	plaintext, err := s.Decrypt(ciphertext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := string(plaintext), "123-45-6789"; got != exp {
+		t.Errorf("unexpected plaintext got %q exp %q", got, exp)
+	}
+}
+
+func TestService_KeyRotation(t *testing.T) {
+	old := newService(t, encryption.Config{
+		ActiveKey: "k1",
+		Keys: []encryption.KeyConfig{
+			{ID: "k1", Key: key('a', 16)},
+		},
+	})
+	ciphertext, err := old.Encrypt([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rotated := newService(t, encryption.Config{
+		ActiveKey: "k2",
+		Keys: []encryption.KeyConfig{
+			{ID: "k1", Key: key('a', 16)},
+			{ID: "k2", Key: key('b', 32)},
+		},
+	})
+	plaintext, err := rotated.Decrypt(ciphertext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := string(plaintext), "secret"; got != exp {
+		t.Errorf("unexpected plaintext got %q exp %q", got, exp)
+	}
+	ciphertext, err = rotated.Encrypt([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(ciphertext, "k2:") {
+		t.Errorf("expected ciphertext to use the active key, got %q", ciphertext)
+	}
+}
+
+func TestService_WrongKey(t *testing.T) {
+	s := newService(t, encryption.Config{
+		ActiveKey: "k1",
+		Keys: []encryption.KeyConfig{
+			{ID: "k1", Key: key('a', 32)},
+		},
+	})
+	ciphertext, err := s.Encrypt([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wrong := newService(t, encryption.Config{
+		ActiveKey: "k1",
+		Keys: []encryption.KeyConfig{
+			{ID: "k1", Key: key('b', 32)},
+		},
+	})
+	unknown := newService(t, encryption.Config{
+		ActiveKey: "k2",
+		Keys: []encryption.KeyConfig{
+			{ID: "k2", Key: key('a', 32)},
+		},
+	})
+	testCases := []struct {
+		name       string
+		s          *encryption.Service
+		ciphertext string
+		err        string
+	}{
+		{
+			name:       "wrong key",
+			s:          wrong,
+			ciphertext: ciphertext,
+			err:        `failed to decrypt with key "k1": cipher: message authentication failed`,
+		},
+		{
+			name:       "unknown key id",
+			s:          unknown,
+			ciphertext: ciphertext,
+			err:        `unknown key id "k1"`,
+		},
+		{
+			name:       "tampered key id",
+			s:          unknown,
+			ciphertext: "k2" + strings.TrimPrefix(ciphertext, "k1"),
+			err:        `failed to decrypt with key "k2": cipher: message authentication failed`,
+		},
+		{
+			name:       "missing key id",
+			s:          s,
+			ciphertext: "secret",
+			err:        "invalid ciphertext",
+		},
+		{
+			name:       "invalid base64",
+			s:          s,
+			ciphertext: "k1:not base64",
+			err:        "invalid ciphertext",
+		},
+		{
+			name:       "short",
+			s:          s,
+			ciphertext: "k1:AAAA",
+			err:        "invalid ciphertext",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := tc.s.Decrypt(tc.ciphertext)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if got := err.Error(); got != tc.err {
+				t.Errorf("unexpected error got %q exp %q", got, tc.err)
+			}
+		})
+	}
+}
+
+func TestService_NotEnabled(t *testing.T) {
+	s := newService(t, encryption.NewConfig())
+	if s.Enabled() {
+		t.Error("expected service to be disabled")
+	}
+	if _, err := s.Encrypt([]byte("secret")); err != encryption.ErrNotEnabled {
+		t.Errorf("unexpected error got %v exp %v", err, encryption.ErrNotEnabled)
+	}
+	if _, err := s.Decrypt("k1:AAAA"); err != encryption.ErrNotEnabled {
+		t.Errorf("unexpected error got %v exp %v", err, encryption.ErrNotEnabled)
+	}
+}
+
+func TestConfig_KeyEnv(t *testing.T) {
+	os.Setenv("KAPACITOR_TEST_ENCRYPTION_KEY", key('c', 24))
+	defer os.Unsetenv("KAPACITOR_TEST_ENCRYPTION_KEY")
+	s := newService(t, encryption.Config{
+		ActiveKey: "env",
+		Keys: []encryption.KeyConfig{
+			{ID: "env", KeyEnv: "KAPACITOR_TEST_ENCRYPTION_KEY"},
+		},
+	})
+	ciphertext, err := s.Encrypt([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Decrypt(ciphertext); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	testCases := []struct {
+		name string
+		c    encryption.Config
+		err  string
+	}{
+		{
+			name: "disabled",
+			c:    encryption.NewConfig(),
+		},
+		{
+			name: "missing active key",
+			c: encryption.Config{
+				Keys: []encryption.KeyConfig{{ID: "k1", Key: key('a', 16)}},
+			},
+			err: "must specify active-key when keys are configured",
+		},
+		{
+			name: "unknown active key",
+			c: encryption.Config{
+				ActiveKey: "k2",
+				Keys:      []encryption.KeyConfig{{ID: "k1", Key: key('a', 16)}},
+			},
+			err: `active-key "k2" is not a configured key`,
+		},
+		{
+			name: "duplicate id",
+			c: encryption.Config{
+				ActiveKey: "k1",
+				Keys: []encryption.KeyConfig{
+					{ID: "k1", Key: key('a', 16)},
+					{ID: "k1", Key: key('b', 16)},
+				},
+			},
+			err: `duplicate key id "k1"`,
+		},
+		{
+			name: "invalid id",
+			c: encryption.Config{
+				ActiveKey: "k:1",
+				Keys:      []encryption.KeyConfig{{ID: "k:1", Key: key('a', 16)}},
+			},
+			err: `key id "k:1" must not contain ":"`,
+		},
+		{
+			name: "bad key length",
+			c: encryption.Config{
+				ActiveKey: "k1",
+				Keys:      []encryption.KeyConfig{{ID: "k1", Key: key('a', 10)}},
+			},
+			err: `key "k1": must be 16, 24 or 32 bytes, got 10`,
+		},
+		{
+			name: "key and key-env",
+			c: encryption.Config{
+				ActiveKey: "k1",
+				Keys:      []encryption.KeyConfig{{ID: "k1", Key: key('a', 16), KeyEnv: "KEY"}},
+			},
+			err: `key "k1": must specify only one of key or key-env`,
+		},
+		{
+			name: "missing env",
+			c: encryption.Config{
+				ActiveKey: "k1",
+				Keys:      []encryption.KeyConfig{{ID: "k1", KeyEnv: "KAPACITOR_TEST_ENCRYPTION_MISSING"}},
+			},
+			err: `key "k1": environment variable KAPACITOR_TEST_ENCRYPTION_MISSING is not set`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.c.Validate()
+			if tc.err == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if got := err.Error(); got != tc.err {
+				t.Errorf("unexpected error got %q exp %q", got, tc.err)
+			}
+		})
+	}
+}
@@ -26,6 +26,12 @@ type Config struct {
 	ShutdownTimeout  toml.Duration `toml:"shutdown-timeout"`
 	SharedSecret     string        `toml:"shared-secret"`
 
+	// WriteLimits limit and authenticate the writes to databases.
+	WriteLimits []WriteLimitConfig `toml:"write-limit"`
+
+	// HeaderTags tag the written points with the values of request headers.
+	HeaderTags []HeaderTagConfig `toml:"header-tag"`
+
 	// Enable gzipped encoding
 	// NOTE: this is ignored in toml since it is only consumed by the tests
 	GZIP bool `toml:"-"`
@@ -54,6 +60,12 @@ func (c Config) Validate() error {
 	} else if pn > 65535 || pn < 0 {
 		return fmt.Errorf("invalid http bind address port %d: out of range", pn)
 	}
+	if err := validateWriteLimits(c.WriteLimits, c.AuthEnabled); err != nil {
+		return errors.Wrap(err, "invalid http write-limit")
+	}
+	if err := validateHeaderTags(c.HeaderTags); err != nil {
+		return errors.Wrap(err, "invalid http header-tag")
+	}
 
 	return nil
 }
@@ -1,6 +1,7 @@
 package httpd
 
 import (
+	"bytes"
 	"compress/gzip"
 	"encoding/json"
 	"errors"
@@ -11,6 +12,7 @@ import (
 	"net/http"
 	"net/http/pprof"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
@@ -20,6 +22,7 @@ import (
 	"github.com/influxdata/influxdb/uuid"
 	"github.com/influxdata/kapacitor/auth"
 	"github.com/influxdata/kapacitor/client/v1"
+	"github.com/influxdata/kapacitor/services/pbline"
 )
 
 // statistics gathered by the httpd package.
@@ -30,7 +33,10 @@ const (
 	statWriteRequestBytesReceived = "write_req_bytes"     // Sum of all bytes in write requests
 	statPointsWrittenOK           = "points_written_ok"   // Number of points written OK
 	statPointsWrittenFail         = "points_written_fail" // Number of points that failed to be written
+	statPointsParseFail           = "points_parse_fail"   // Number of protobuf points that failed to be decoded
 	statAuthFail                  = "auth_fail"           // Number of requests that failed to authenticate
+	statWriteRateLimited          = "write_rate_limited"  // Number of write requests rejected by a rate limit
+	statWriteUnauthorized         = "write_unauthorized"  // Number of write requests rejected by a write limit token or signature
 )
 
 const (
@@ -93,6 +99,9 @@ type Handler struct {
 	// Log every HTTP access.
 	loggingEnabled bool
 
+	writeLimits writeLimiters
+	headerTags  headerTags
+
 	statMap *expvar.Map
 }
 
@@ -242,6 +251,18 @@ func NewHandler(
 	return h
 }
 
+// SetWriteLimits configures the rate limits and authentication of writes.
+// It must be called before the handler serves requests.
+func (h *Handler) SetWriteLimits(limits []WriteLimitConfig) {
+	h.writeLimits = newWriteLimiters(limits)
+}
+
+// SetHeaderTags configures the tags set on the written points from request headers.
+// It must be called before the handler serves requests.
+func (h *Handler) SetHeaderTags(tags []HeaderTagConfig) {
+	h.headerTags = tags
+}
+
 func (h *Handler) AddRoutes(routes []Route) error {
 	for _, r := range routes {
 		err := h.AddRoute(r)
@@ -432,6 +453,15 @@ func (h *Handler) servePing(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) serveWrite(w http.ResponseWriter, r *http.Request, user auth.User) {
 	h.statMap.Add(statWriteRequest, 1)
 
+	limiter := h.writeLimits.get(r.FormValue("db"), r.FormValue("rp"))
+	if limiter != nil {
+		if err := limiter.checkToken(r); err != nil {
+			h.statMap.Add(statWriteUnauthorized, 1)
+			h.writeError(w, influxql.Result{Err: err}, http.StatusUnauthorized)
+			return
+		}
+	}
+
 	// Handle gzip decoding of the body
 	body := r.Body
 	if r.Header.Get("Content-encoding") == "gzip" {
@@ -456,7 +486,25 @@ func (h *Handler) serveWrite(w http.ResponseWriter, r *http.Request, user auth.U
 	if h.writeTrace {
 		h.diag.WriteBodyReceived(string(b))
 	}
+	if limiter != nil {
+		if err := limiter.checkSignature(r, b); err != nil {
+			h.statMap.Add(statWriteUnauthorized, 1)
+			h.writeError(w, influxql.Result{Err: err}, http.StatusUnauthorized)
+			return
+		}
+		// Only authenticated writes take a token, so unauthenticated
+		// requests cannot use up the rate budget of legitimate clients.
+		if !limiter.allow() {
+			h.statMap.Add(statWriteRateLimited, 1)
+			h.writeError(w, influxql.Result{Err: errors.New("write rate limit exceeded")}, http.StatusTooManyRequests)
+			return
+		}
+	}
 
+	if r.Header.Get("Content-Type") == pbline.ContentType {
+		h.serveWriteProtobuf(w, r, b, user)
+		return
+	}
 	h.serveWriteLine(w, r, b, user)
 }
 
@@ -477,6 +525,37 @@ func (h *Handler) serveWriteLine(w http.ResponseWriter, r *http.Request, body []
 		return
 	}
 
+	h.writePoints(w, r, points, user)
+}
+
+// serveWriteProtobuf receives incoming series data as length delimited protobuf points and writes it to the database.
+// A malformed point fails the whole request.
+func (h *Handler) serveWriteProtobuf(w http.ResponseWriter, r *http.Request, body []byte, user auth.User) {
+	d := pbline.NewDecoder(bytes.NewReader(body), 0)
+	now := time.Now().UTC()
+	var points []models.Point
+	for {
+		p, err := d.Decode(now)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			if pbline.IsMalformed(err) {
+				h.statMap.Add(statPointsParseFail, 1)
+			}
+			h.writeError(w, influxql.Result{Err: err}, http.StatusBadRequest)
+			return
+		}
+		points = append(points, p)
+	}
+	if len(points) == 0 {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	h.writePoints(w, r, points, user)
+}
+
+// writePoints writes the points to the database of the request.
+func (h *Handler) writePoints(w http.ResponseWriter, r *http.Request, points []models.Point, user auth.User) {
 	database := r.FormValue("db")
 	if database == "" {
 		h.writeError(w, influxql.Result{Err: fmt.Errorf("database is required")}, http.StatusBadRequest)
@@ -492,6 +571,8 @@ func (h *Handler) serveWriteLine(w http.ResponseWriter, r *http.Request, body []
 		return
 	}
 
+	h.headerTags.apply(r, points)
+
 	// Write points.
 	if err := h.PointsWriter.WritePoints(
 		database,
@@ -793,30 +874,72 @@ func parseCredentials(r *http.Request) (credentials, error) {
 }
 
 type gzipResponseWriter struct {
-	io.Writer
 	http.ResponseWriter
+	gz          *gzip.Writer
+	wroteHeader bool
+	noBody      bool
+}
+
+func (w *gzipResponseWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		h := w.Header()
+		h.Del("Content-Length")
+		if code == http.StatusNotModified || code == http.StatusNoContent {
+			w.noBody = true
+			h.Del("Content-Encoding")
+		}
+		// A strong ETag identifies the exact bytes of the uncompressed body,
+		// so it is weakened for the gzipped body.
+		if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			h.Set("ETag", "W/"+etag)
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
 }
 
-func (w gzipResponseWriter) Write(b []byte) (int, error) {
-	return w.Writer.Write(b)
+func (w *gzipResponseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.gz.Write(b)
 }
 
-func (w gzipResponseWriter) Flush() {
-	w.Writer.(*gzip.Writer).Flush()
+func (w *gzipResponseWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	w.gz.Flush()
+}
+
+// gzipWriterPool pools the writers of the gzipped responses.
+var gzipWriterPool = sync.Pool{
+	New: func() interface{} { return gzip.NewWriter(ioutil.Discard) },
 }
 
 // determines if the client can accept compressed responses, and encodes accordingly
 func gzipFilter(inner http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The response depends on the Accept-Encoding header, caches must not serve one for the other.
+		w.Header().Add("Vary", "Accept-Encoding")
 		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
 			inner.ServeHTTP(w, r)
 			return
 		}
 		w.Header().Set("Content-Encoding", "gzip")
-		gz := gzip.NewWriter(w)
-		defer gz.Close()
-		gzw := gzipResponseWriter{Writer: gz, ResponseWriter: w}
+		gz := gzipWriterPool.Get().(*gzip.Writer)
+		defer gzipWriterPool.Put(gz)
+		gz.Reset(w)
+		gzw := &gzipResponseWriter{ResponseWriter: w, gz: gz}
 		inner.ServeHTTP(gzw, r)
+		if !gzw.wroteHeader {
+			gzw.WriteHeader(http.StatusOK)
+		}
+		if gzw.noBody {
+			// Responses without a body must not get the gzip header and footer.
+			gz.Reset(ioutil.Discard)
+		}
+		gz.Close()
 	})
 }
 
@@ -1,6 +1,11 @@
 package httpd
 
 import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/influxdata/kapacitor/auth"
@@ -103,3 +108,76 @@ func Test_RequiredPrilegeForHTTPMethod(t *testing.T) {
 		}
 	}
 }
+
+func Test_GzipFilter(t *testing.T) {
+	body := []byte(`{"series":[{"name":"cpu","columns":["time","value"],"values":[["2018-01-01T00:00:00Z",1]]}]}`)
+	h := gzipFilter(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"body"`)
+		if r.Header.Get("If-None-Match") != "" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_, _ = w.Write(body)
+	}))
+
+	plain := httptest.NewRecorder()
+	h.ServeHTTP(plain, httptest.NewRequest("GET", "/", nil))
+	if got := plain.Header().Get("Content-Encoding"); got != "" {
+		t.Errorf("unexpected Content-Encoding without Accept-Encoding: %q", got)
+	}
+	if got, exp := plain.Header().Get("Vary"), "Accept-Encoding"; got != exp {
+		t.Errorf("unexpected Vary: got %q exp %q", got, exp)
+	}
+	if got, exp := plain.Header().Get("ETag"), `"body"`; got != exp {
+		t.Errorf("unexpected ETag: got %q exp %q", got, exp)
+	}
+	if !bytes.Equal(plain.Body.Bytes(), body) {
+		t.Errorf("unexpected body:\ngot %s\nexp %s", plain.Body.Bytes(), body)
+	}
+
+	// Serve several times to reuse the pooled writers.
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Accept-Encoding", "gzip, deflate")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		if got, exp := rec.Header().Get("Content-Encoding"), "gzip"; got != exp {
+			t.Errorf("%d: unexpected Content-Encoding: got %q exp %q", i, got, exp)
+		}
+		if got, exp := rec.Header().Get("Vary"), "Accept-Encoding"; got != exp {
+			t.Errorf("%d: unexpected Vary: got %q exp %q", i, got, exp)
+		}
+		// The strong ETag of the uncompressed body is weakened.
+		if got, exp := rec.Header().Get("ETag"), `W/"body"`; got != exp {
+			t.Errorf("%d: unexpected ETag: got %q exp %q", i, got, exp)
+		}
+		gz, err := gzip.NewReader(rec.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := ioutil.ReadAll(gz)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, body) {
+			t.Errorf("%d: unexpected decompressed body:\ngot %s\nexp %s", i, got, body)
+		}
+	}
+
+	// A response without a body is not gzipped.
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	req.Header.Set("If-None-Match", `W/"body"`)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if got, exp := rec.Code, http.StatusNotModified; got != exp {
+		t.Errorf("unexpected status: got %d exp %d", got, exp)
+	}
+	if got := rec.Header().Get("Content-Encoding"); got != "" {
+		t.Errorf("unexpected Content-Encoding of a response without a body: %q", got)
+	}
+	if rec.Body.Len() != 0 {
+		t.Errorf("unexpected body of a response without a body: %q", rec.Body.Bytes())
+	}
+}
@@ -0,0 +1,80 @@
+package httpd
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/influxdata/influxdb/models"
+	"github.com/pkg/errors"
+)
+
+// HeaderTagConfig tags the points written to the write endpoint with the value of a request header,
+// i.e. to tag the points with the tenant authenticated by a proxy.
+//
+// The value of the header replaces the value of the tag sent by the client.
+// If the header is missing or empty the tag is set to the default,
+// or the points are left unchanged if there is no default.
+type HeaderTagConfig struct {
+	// Header is the name of the request header.
+	Header string `toml:"header"`
+	// Tag is the key of the tag.
+	Tag string `toml:"tag"`
+	// Default is the value of the tag if the header is missing.
+	Default string `toml:"default"`
+}
+
+func (c HeaderTagConfig) Validate() error {
+	if c.Header == "" {
+		return errors.New("header-tag must specify a header")
+	}
+	if c.Tag == "" {
+		return fmt.Errorf("header-tag for header %q must specify a tag", c.Header)
+	}
+	return nil
+}
+
+func validateHeaderTags(tags []HeaderTagConfig) error {
+	seen := make(map[string]bool, len(tags))
+	for _, c := range tags {
+		if err := c.Validate(); err != nil {
+			return err
+		}
+		if seen[c.Tag] {
+			return fmt.Errorf("duplicate header-tag for tag %q", c.Tag)
+		}
+		seen[c.Tag] = true
+	}
+	return nil
+}
+
+// headerTags sets the tags of points from the headers of requests.
+type headerTags []HeaderTagConfig
+
+// apply sets the tags of the points from the headers of the request.
+func (h headerTags) apply(r *http.Request, points []models.Point) {
+	if len(h) == 0 {
+		return
+	}
+	values := make(map[string]string, len(h))
+	for _, c := range h {
+		value := r.Header.Get(c.Header)
+		if value == "" {
+			value = c.Default
+		}
+		if value != "" {
+			values[c.Tag] = value
+		}
+	}
+	if len(values) == 0 {
+		return
+	}
+	for _, p := range points {
+		tags := p.Tags()
+		for k, v := range values {
+			// Tags.Set does not replace existing values, delete them first.
+			tags.Delete([]byte(k))
+			tags.SetString(k, v)
+		}
+		p.SetTags(tags)
+	}
+}
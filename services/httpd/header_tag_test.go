@@ -0,0 +1,119 @@
+package httpd_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/influxdata/influxdb/models"
+	"github.com/influxdata/kapacitor/services/httpd"
+	"github.com/influxdata/kapacitor/services/httpd/httpdtest"
+)
+
+type recordingPointsWriter struct {
+	points []models.Point
+}
+
+func (w *recordingPointsWriter) WritePoints(database, retentionPolicy string, consistencyLevel models.ConsistencyLevel, points []models.Point) error {
+	w.points = append(w.points, points...)
+	return nil
+}
+
+func TestHeaderTags(t *testing.T) {
+	testCases := []struct {
+		name    string
+		headers map[string]string
+		body    string
+		exp     []string
+	}{
+		{
+			name:    "headers",
+			headers: map[string]string{"X-Tenant-ID": "acme", "X-Region": "eu"},
+			body:    "cpu,host=a value=1 0\ncpu,host=b value=2 0",
+			exp: []string{
+				"cpu,host=a,region=eu,tenant=acme value=1 0",
+				"cpu,host=b,region=eu,tenant=acme value=2 0",
+			},
+		},
+		{
+			name:    "header replaces tag of the client",
+			headers: map[string]string{"x-tenant-id": "acme"},
+			body:    "cpu,host=a,tenant=other value=1 0",
+			exp:     []string{"cpu,host=a,region=unknown,tenant=acme value=1 0"},
+		},
+		{
+			name: "missing headers",
+			body: "cpu,host=a value=1 0",
+			exp:  []string{"cpu,host=a,region=unknown value=1 0"},
+		},
+		{
+			name:    "empty header",
+			headers: map[string]string{"X-Tenant-ID": ""},
+			body:    "cpu,host=a,tenant=other value=1 0",
+			exp:     []string{"cpu,host=a,region=unknown,tenant=other value=1 0"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := httpdtest.NewServer(false)
+			defer s.Close()
+			pw := new(recordingPointsWriter)
+			s.Handler.PointsWriter = pw
+			s.Handler.SetHeaderTags([]httpd.HeaderTagConfig{
+				{Header: "X-Tenant-ID", Tag: "tenant"},
+				{Header: "X-Region", Tag: "region", Default: "unknown"},
+			})
+
+			if got, exp := write(t, s, "db=db", []byte(tc.body), tc.headers), http.StatusNoContent; got != exp {
+				t.Fatalf("unexpected status: got %d exp %d", got, exp)
+			}
+			if got, exp := len(pw.points), len(tc.exp); got != exp {
+				t.Fatalf("unexpected number of points: got %d exp %d", got, exp)
+			}
+			for i, p := range pw.points {
+				if got := p.String(); got != tc.exp[i] {
+					t.Errorf("unexpected point %d: got %s exp %s", i, got, tc.exp[i])
+				}
+			}
+		})
+	}
+}
+
+func TestHeaderTags_Validate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		tags    []httpd.HeaderTagConfig
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			tags: []httpd.HeaderTagConfig{{Header: "X-Tenant-ID", Tag: "tenant", Default: "none"}},
+		},
+		{
+			name:    "missing header",
+			tags:    []httpd.HeaderTagConfig{{Tag: "tenant"}},
+			wantErr: true,
+		},
+		{
+			name:    "missing tag",
+			tags:    []httpd.HeaderTagConfig{{Header: "X-Tenant-ID"}},
+			wantErr: true,
+		},
+		{
+			name: "duplicate tag",
+			tags: []httpd.HeaderTagConfig{
+				{Header: "X-Tenant-ID", Tag: "tenant"},
+				{Header: "X-Tenant", Tag: "tenant"},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := httpd.NewConfig()
+			c.HeaderTags = tc.tags
+			if err := c.Validate(); (err != nil) != tc.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
@@ -135,6 +135,8 @@ func NewService(c Config, hostname string, d Diagnostic) *Service {
 	if s.key == "" {
 		s.key = s.cert
 	}
+	s.Handler.SetWriteLimits(c.WriteLimits)
+	s.Handler.SetHeaderTags(c.HeaderTags)
 
 	return s
 }
@@ -0,0 +1,236 @@
+package httpd
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/toml"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultWriteSignatureHeader is the header containing the signature of a write request if none is configured.
+	DefaultWriteSignatureHeader = "X-Signature"
+	// DefaultWriteSignatureMaxAge is the default maximum age of the timestamp of a signature.
+	DefaultWriteSignatureMaxAge = toml.Duration(5 * time.Minute)
+)
+
+// WriteLimitConfig configures the rate limit and authentication of writes
+// to a database and optionally a retention policy, i.e. the source of the tasks reading from it.
+//
+// Signed requests use the same signature as the httpPost signature,
+// the header value has the form:
+//
+//    t=<timestamp>,v1=<hex encoded HMAC-SHA256 of "<timestamp>.<body>">
+//
+// Requests whose timestamp differs from the current time by more than the maximum age are rejected.
+type WriteLimitConfig struct {
+	Database string `toml:"database"`
+	// RetentionPolicy limits the writes to the retention policy, empty applies to all retention policies of the database.
+	RetentionPolicy string `toml:"retention-policy"`
+
+	// RateLimit is the maximum number of write requests per second, zero means no limit.
+	RateLimit float64 `toml:"rate-limit"`
+	// Burst is the number of requests that may exceed the rate limit at once.
+	// Defaults to the rate limit rounded up.
+	Burst int `toml:"burst"`
+
+	// Token required as bearer token in the Authorization header.
+	Token string `toml:"token"`
+
+	// HMACSecret is the secret the request body must be signed with.
+	HMACSecret      string        `toml:"hmac-secret"`
+	SignatureHeader string        `toml:"signature-header"`
+	SignatureMaxAge toml.Duration `toml:"signature-max-age"`
+}
+
+func (c WriteLimitConfig) Validate() error {
+	if c.Database == "" {
+		return errors.New("write-limit must specify a database")
+	}
+	if c.RateLimit < 0 || math.IsNaN(c.RateLimit) || math.IsInf(c.RateLimit, 0) {
+		return fmt.Errorf("write-limit rate-limit must be a positive number, got %v", c.RateLimit)
+	}
+	if c.Burst < 0 {
+		return fmt.Errorf("write-limit burst cannot be negative, got %d", c.Burst)
+	}
+	if c.Burst != 0 && c.RateLimit == 0 {
+		return errors.New("write-limit burst requires rate-limit")
+	}
+	if c.SignatureMaxAge < 0 {
+		return errors.New("write-limit signature-max-age cannot be negative")
+	}
+	if (c.SignatureHeader != "" || c.SignatureMaxAge != 0) && c.HMACSecret == "" {
+		return errors.New("write-limit must set hmac-secret when signature-header or signature-max-age is set")
+	}
+	return nil
+}
+
+func validateWriteLimits(limits []WriteLimitConfig, authEnabled bool) error {
+	type key struct{ db, rp string }
+	seen := make(map[key]bool, len(limits))
+	for _, c := range limits {
+		if err := c.Validate(); err != nil {
+			return err
+		}
+		if c.Token != "" && authEnabled {
+			return fmt.Errorf("write-limit for database %q cannot set a token when auth-enabled is set", c.Database)
+		}
+		k := key{db: c.Database, rp: c.RetentionPolicy}
+		if seen[k] {
+			return fmt.Errorf("duplicate write-limit for database %q and retention policy %q", c.Database, c.RetentionPolicy)
+		}
+		seen[k] = true
+	}
+	return nil
+}
+
+// writeLimiter enforces a WriteLimitConfig.
+type writeLimiter struct {
+	c WriteLimitConfig
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+	burst  float64
+
+	now func() time.Time
+}
+
+func newWriteLimiter(c WriteLimitConfig) *writeLimiter {
+	burst := float64(c.Burst)
+	if burst == 0 {
+		burst = math.Max(1, math.Ceil(c.RateLimit))
+	}
+	if c.SignatureHeader == "" {
+		c.SignatureHeader = DefaultWriteSignatureHeader
+	}
+	if c.SignatureMaxAge == 0 {
+		c.SignatureMaxAge = DefaultWriteSignatureMaxAge
+	}
+	return &writeLimiter{
+		c:      c,
+		tokens: burst,
+		burst:  burst,
+		now:    time.Now,
+	}
+}
+
+// allow reports whether a request is within the rate limit and takes a token if it is.
+func (l *writeLimiter) allow() bool {
+	if l.c.RateLimit == 0 {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	if !l.last.IsZero() && now.After(l.last) {
+		l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.c.RateLimit)
+	}
+	l.last = now
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+// checkToken checks the bearer token of a request.
+func (l *writeLimiter) checkToken(r *http.Request) error {
+	if l.c.Token == "" {
+		return nil
+	}
+	const prefix = "Bearer "
+	h := r.Header.Get("Authorization")
+	if !strings.HasPrefix(h, prefix) || subtle.ConstantTimeCompare([]byte(h[len(prefix):]), []byte(l.c.Token)) != 1 {
+		return errors.New("invalid or missing bearer token")
+	}
+	return nil
+}
+
+// checkSignature verifies the signature of a request with the body,
+// the signature has the form t=<timestamp>,v1=<hex HMAC-SHA256>.
+func (l *writeLimiter) checkSignature(r *http.Request, body []byte) error {
+	if l.c.HMACSecret == "" {
+		return nil
+	}
+	sig := r.Header.Get(l.c.SignatureHeader)
+	if sig == "" {
+		return errors.New("missing signature")
+	}
+	var ts, v1 string
+	for _, part := range strings.Split(sig, ",") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return errors.New("malformed signature")
+		}
+		switch kv[0] {
+		case "t":
+			ts = kv[1]
+		case "v1":
+			v1 = kv[1]
+		}
+	}
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return errors.New("malformed signature timestamp")
+	}
+	age := l.now().Sub(time.Unix(sec, 0))
+	if age < 0 {
+		age = -age
+	}
+	if age > time.Duration(l.c.SignatureMaxAge) {
+		return errors.New("signature timestamp is too old")
+	}
+	got, err := hex.DecodeString(v1)
+	if err != nil {
+		return errors.New("malformed signature")
+	}
+	mac := hmac.New(sha256.New, []byte(l.c.HMACSecret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+	if !hmac.Equal(got, mac.Sum(nil)) {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
+
+// writeLimiters holds the write limiters by database and retention policy.
+type writeLimiters map[string]map[string]*writeLimiter
+
+func newWriteLimiters(limits []WriteLimitConfig) writeLimiters {
+	if len(limits) == 0 {
+		return nil
+	}
+	ls := make(writeLimiters)
+	for _, c := range limits {
+		if ls[c.Database] == nil {
+			ls[c.Database] = make(map[string]*writeLimiter)
+		}
+		ls[c.Database][c.RetentionPolicy] = newWriteLimiter(c)
+	}
+	return ls
+}
+
+// get returns the limiter of the retention policy, or else of the whole database.
+// Returns nil if the writes are not limited.
+func (ls writeLimiters) get(database, retentionPolicy string) *writeLimiter {
+	rps := ls[database]
+	if rps == nil {
+		return nil
+	}
+	if l, ok := rps[retentionPolicy]; ok {
+		return l
+	}
+	return rps[""]
+}
@@ -0,0 +1,257 @@
+package httpd_test
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/models"
+	"github.com/influxdata/kapacitor/services/httpd"
+	"github.com/influxdata/kapacitor/services/httpd/httpdtest"
+	"github.com/influxdata/kapacitor/services/httppost"
+)
+
+type pointsWriter struct {
+	writes int
+}
+
+func (w *pointsWriter) WritePoints(database, retentionPolicy string, consistencyLevel models.ConsistencyLevel, points []models.Point) error {
+	w.writes++
+	return nil
+}
+
+func newWriteLimitServer(t *testing.T, limits ...httpd.WriteLimitConfig) (*httpdtest.Server, *pointsWriter) {
+	t.Helper()
+	s := httpdtest.NewServer(false)
+	pw := new(pointsWriter)
+	s.Handler.PointsWriter = pw
+	s.Handler.SetWriteLimits(limits)
+	return s, pw
+}
+
+func write(t *testing.T, s *httpdtest.Server, query string, body []byte, headers map[string]string) int {
+	t.Helper()
+	req, err := http.NewRequest("POST", s.Server.URL+httpd.BasePath+"/write?"+query, bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestWriteLimit_RateLimit(t *testing.T) {
+	s, pw := newWriteLimitServer(t, httpd.WriteLimitConfig{
+		Database:  "db",
+		RateLimit: 0.001,
+		Burst:     2,
+	})
+	defer s.Close()
+	body := []byte("cpu value=1")
+
+	for i, exp := range []int{http.StatusNoContent, http.StatusNoContent, http.StatusTooManyRequests} {
+		if got := write(t, s, "db=db&rp=rp", body, nil); got != exp {
+			t.Errorf("unexpected status of write %d: got %d exp %d", i, got, exp)
+		}
+	}
+	// Other databases are not limited.
+	if got, exp := write(t, s, "db=other", body, nil), http.StatusNoContent; got != exp {
+		t.Errorf("unexpected status of write to other database: got %d exp %d", got, exp)
+	}
+	if got, exp := pw.writes, 3; got != exp {
+		t.Errorf("unexpected number of writes: got %d exp %d", got, exp)
+	}
+}
+
+func TestWriteLimit_RetentionPolicy(t *testing.T) {
+	s, pw := newWriteLimitServer(t,
+		httpd.WriteLimitConfig{
+			Database:  "db",
+			RateLimit: 0.001,
+		},
+		httpd.WriteLimitConfig{
+			Database:        "db",
+			RetentionPolicy: "open",
+		},
+	)
+	defer s.Close()
+	body := []byte("cpu value=1")
+
+	for i := 0; i < 3; i++ {
+		if got, exp := write(t, s, "db=db&rp=open", body, nil), http.StatusNoContent; got != exp {
+			t.Errorf("unexpected status of write %d to open retention policy: got %d exp %d", i, got, exp)
+		}
+	}
+	for i, exp := range []int{http.StatusNoContent, http.StatusTooManyRequests} {
+		if got := write(t, s, "db=db&rp=autogen", body, nil); got != exp {
+			t.Errorf("unexpected status of write %d: got %d exp %d", i, got, exp)
+		}
+	}
+	if got, exp := pw.writes, 4; got != exp {
+		t.Errorf("unexpected number of writes: got %d exp %d", got, exp)
+	}
+}
+
+func TestWriteLimit_Token(t *testing.T) {
+	s, pw := newWriteLimitServer(t, httpd.WriteLimitConfig{
+		Database: "db",
+		Token:    "secret-token",
+	})
+	defer s.Close()
+	body := []byte("cpu value=1")
+
+	testCases := []struct {
+		name          string
+		authorization string
+		exp           int
+	}{
+		{name: "valid token", authorization: "Bearer secret-token", exp: http.StatusNoContent},
+		{name: "missing token", exp: http.StatusUnauthorized},
+		{name: "wrong token", authorization: "Bearer other-token", exp: http.StatusUnauthorized},
+		{name: "wrong scheme", authorization: "Basic secret-token", exp: http.StatusUnauthorized},
+	}
+	for _, tc := range testCases {
+		var headers map[string]string
+		if tc.authorization != "" {
+			headers = map[string]string{"Authorization": tc.authorization}
+		}
+		if got := write(t, s, "db=db", body, headers); got != tc.exp {
+			t.Errorf("%s: unexpected status: got %d exp %d", tc.name, got, tc.exp)
+		}
+	}
+	if got, exp := pw.writes, 1; got != exp {
+		t.Errorf("unexpected number of writes: got %d exp %d", got, exp)
+	}
+}
+
+func TestWriteLimit_Signature(t *testing.T) {
+	secret := []byte("hmac-secret")
+	s, pw := newWriteLimitServer(t, httpd.WriteLimitConfig{
+		Database:   "db",
+		HMACSecret: string(secret),
+	})
+	defer s.Close()
+	body := []byte("cpu value=1")
+	now := time.Now()
+
+	testCases := []struct {
+		name      string
+		signature string
+		exp       int
+	}{
+		{name: "valid signature", signature: httppost.Sign(secret, now, body), exp: http.StatusNoContent},
+		{name: "missing signature", exp: http.StatusUnauthorized},
+		{name: "wrong secret", signature: httppost.Sign([]byte("other"), now, body), exp: http.StatusUnauthorized},
+		{name: "other body", signature: httppost.Sign(secret, now, []byte("cpu value=2")), exp: http.StatusUnauthorized},
+		{name: "expired timestamp", signature: httppost.Sign(secret, now.Add(-time.Hour), body), exp: http.StatusUnauthorized},
+		{name: "malformed", signature: "v1=abc", exp: http.StatusUnauthorized},
+	}
+	for _, tc := range testCases {
+		var headers map[string]string
+		if tc.signature != "" {
+			headers = map[string]string{httpd.DefaultWriteSignatureHeader: tc.signature}
+		}
+		if got := write(t, s, "db=db", body, headers); got != tc.exp {
+			t.Errorf("%s: unexpected status: got %d exp %d", tc.name, got, tc.exp)
+		}
+	}
+	if got, exp := pw.writes, 1; got != exp {
+		t.Errorf("unexpected number of writes: got %d exp %d", got, exp)
+	}
+}
+
+func TestWriteLimit_RateLimitSignature(t *testing.T) {
+	secret := []byte("hmac-secret")
+	s, pw := newWriteLimitServer(t, httpd.WriteLimitConfig{
+		Database:   "db",
+		HMACSecret: string(secret),
+		RateLimit:  0.001,
+		Burst:      1,
+	})
+	defer s.Close()
+	body := []byte("cpu value=1")
+
+	// Unsigned and badly signed writes must not use up the rate budget.
+	for i := 0; i < 3; i++ {
+		if got, exp := write(t, s, "db=db", body, nil), http.StatusUnauthorized; got != exp {
+			t.Errorf("unexpected status of unsigned write %d: got %d exp %d", i, got, exp)
+		}
+		headers := map[string]string{httpd.DefaultWriteSignatureHeader: httppost.Sign([]byte("other"), time.Now(), body)}
+		if got, exp := write(t, s, "db=db", body, headers), http.StatusUnauthorized; got != exp {
+			t.Errorf("unexpected status of badly signed write %d: got %d exp %d", i, got, exp)
+		}
+	}
+	for i, exp := range []int{http.StatusNoContent, http.StatusTooManyRequests} {
+		headers := map[string]string{httpd.DefaultWriteSignatureHeader: httppost.Sign(secret, time.Now(), body)}
+		if got := write(t, s, "db=db", body, headers); got != exp {
+			t.Errorf("unexpected status of signed write %d: got %d exp %d", i, got, exp)
+		}
+	}
+	if got, exp := pw.writes, 1; got != exp {
+		t.Errorf("unexpected number of writes: got %d exp %d", got, exp)
+	}
+}
+
+func TestWriteLimitConfig_Validate(t *testing.T) {
+	testCases := []struct {
+		name        string
+		limits      []httpd.WriteLimitConfig
+		authEnabled bool
+		wantErr     bool
+	}{
+		{
+			name:   "rate limit",
+			limits: []httpd.WriteLimitConfig{{Database: "db", RateLimit: 10, Burst: 20}},
+		},
+		{
+			name:   "database and retention policy",
+			limits: []httpd.WriteLimitConfig{{Database: "db", Token: "t"}, {Database: "db", RetentionPolicy: "rp", HMACSecret: "s"}},
+		},
+		{
+			name:    "missing database",
+			limits:  []httpd.WriteLimitConfig{{RateLimit: 10}},
+			wantErr: true,
+		},
+		{
+			name:    "negative rate limit",
+			limits:  []httpd.WriteLimitConfig{{Database: "db", RateLimit: -1}},
+			wantErr: true,
+		},
+		{
+			name:    "burst without rate limit",
+			limits:  []httpd.WriteLimitConfig{{Database: "db", Burst: 10}},
+			wantErr: true,
+		},
+		{
+			name:    "signature header without secret",
+			limits:  []httpd.WriteLimitConfig{{Database: "db", SignatureHeader: "X-Sig"}},
+			wantErr: true,
+		},
+		{
+			name:        "token with auth enabled",
+			limits:      []httpd.WriteLimitConfig{{Database: "db", Token: "t"}},
+			authEnabled: true,
+			wantErr:     true,
+		},
+		{
+			name:    "duplicate",
+			limits:  []httpd.WriteLimitConfig{{Database: "db", RateLimit: 1}, {Database: "db", RateLimit: 2}},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		c := httpd.NewConfig()
+		c.AuthEnabled = tc.authEnabled
+		c.WriteLimits = tc.limits
+		if err := c.Validate(); (err != nil) != tc.wantErr {
+			t.Errorf("%s: Validate() error = %v, wantErr %v", tc.name, err, tc.wantErr)
+		}
+	}
+}
@@ -35,6 +35,7 @@ type Config struct {
 	URL               string            `toml:"url" override:"url"`
 	Headers           map[string]string `toml:"headers" override:"headers"`
 	BasicAuth         BasicAuth         `toml:"basic-auth" override:"basic-auth,redact"`
+	Signature         Signature         `toml:"signature" override:"signature,redact"`
 	AlertTemplate     string            `toml:"alert-template" override:"alert-template"`
 	AlertTemplateFile string            `toml:"alert-template-file" override:"alert-template-file"`
 	RowTemplate       string            `toml:"row-template" override:"row-template"`
@@ -63,6 +64,10 @@ func (c Config) Validate() error {
 		return errors.Wrapf(err, "invalid URL %q", c.URL)
 	}
 
+	if err := c.Signature.validate(); err != nil {
+		return err
+	}
+
 	if c.AlertTemplate != "" && c.AlertTemplateFile != "" {
 		return errors.New("must specify only one of alert-template and alert-template-file")
 	}
@@ -140,7 +145,7 @@ func (cs Configs) index() (map[string]*Endpoint, error) {
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to get row-template for endpoint %q", c.Endpoint)
 		}
-		m[c.Endpoint] = NewEndpoint(c.URL, c.Headers, c.BasicAuth, at, rt)
+		m[c.Endpoint] = NewEndpoint(c.URL, c.Headers, c.BasicAuth, c.Signature, at, rt)
 	}
 
 	return m, nil
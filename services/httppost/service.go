@@ -30,16 +30,18 @@ type Endpoint struct {
 	url           string
 	headers       map[string]string
 	auth          BasicAuth
+	signature     Signature
 	alertTemplate *template.Template
 	rowTemplate   *template.Template
 	closed        bool
 }
 
-func NewEndpoint(url string, headers map[string]string, auth BasicAuth, at, rt *template.Template) *Endpoint {
+func NewEndpoint(url string, headers map[string]string, auth BasicAuth, sig Signature, at, rt *template.Template) *Endpoint {
 	return &Endpoint{
 		url:           url,
 		headers:       headers,
 		auth:          auth,
+		signature:     sig,
 		alertTemplate: at,
 		rowTemplate:   rt,
 	}
@@ -57,6 +59,7 @@ func (e *Endpoint) Update(c Config) error {
 	e.url = c.URL
 	e.headers = c.Headers
 	e.auth = c.BasicAuth
+	e.signature = c.Signature
 	at, err := c.getAlertTemplate()
 	if err != nil {
 		return err
@@ -89,6 +92,16 @@ func (e *Endpoint) NewHTTPRequest(body io.Reader) (req *http.Request, err error)
 		return nil, errors.New("endpoint was closed")
 	}
 
+	var signature string
+	if e.signature.enabled() {
+		data, err := ioutil.ReadAll(body)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read body for signing")
+		}
+		signature = Sign([]byte(e.signature.Secret), time.Now(), data)
+		body = bytes.NewReader(data)
+	}
+
 	req, err = http.NewRequest("POST", e.url, body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create POST request: %v", err)
@@ -102,6 +115,10 @@ func (e *Endpoint) NewHTTPRequest(body io.Reader) (req *http.Request, err error)
 		req.Header.Add(k, v)
 	}
 
+	if signature != "" {
+		req.Header.Set(e.signature.header(), signature)
+	}
+
 	return req, nil
 }
 
@@ -151,7 +168,7 @@ func (s *Service) Update(newConfigs []interface{}) error {
 				if err != nil {
 					return errors.Wrapf(err, "failed to get row template for endpoint %q", c.Endpoint)
 				}
-				s.endpoints[c.Endpoint] = NewEndpoint(c.URL, c.Headers, c.BasicAuth, at, rt)
+				s.endpoints[c.Endpoint] = NewEndpoint(c.URL, c.Headers, c.BasicAuth, c.Signature, at, rt)
 				continue
 			}
 			if err := e.Update(c); err != nil {
@@ -258,7 +275,7 @@ type handler struct {
 func (s *Service) Handler(c HandlerConfig, ctx ...keyvalue.T) alert.Handler {
 	e, ok := s.Endpoint(c.Endpoint)
 	if !ok {
-		e = NewEndpoint(c.URL, nil, BasicAuth{}, nil, nil)
+		e = NewEndpoint(c.URL, nil, BasicAuth{}, Signature{}, nil, nil)
 	}
 	return &handler{
 		s:               s,
@@ -284,6 +301,12 @@ func (h *handler) NewHTTPRequest(body io.Reader) (req *http.Request, err error)
 }
 
 func (h *handler) Handle(event alert.Event) {
+	// Failures are logged by Deliver.
+	_ = h.Deliver(event)
+}
+
+// Deliver posts the alert data, returning an error if the alert was not accepted by the endpoint.
+func (h *handler) Deliver(event alert.Event) error {
 	var err error
 
 	// Construct the body of the HTTP request
@@ -295,13 +318,13 @@ func (h *handler) Handle(event alert.Event) {
 		err := h.endpoint.AlertTemplate().Execute(body, ad)
 		if err != nil {
 			h.diag.Error("failed to execute alert template", err)
-			return
+			return err
 		}
 	} else {
 		err = json.NewEncoder(body).Encode(ad)
 		if err != nil {
 			h.diag.Error("failed to marshal alert data json", err)
-			return
+			return err
 		}
 		contentType = "application/json"
 	}
@@ -309,7 +332,7 @@ func (h *handler) Handle(event alert.Event) {
 	req, err := h.NewHTTPRequest(body)
 	if err != nil {
 		h.diag.Error("failed to create HTTP request", err)
-		return
+		return err
 	}
 
 	if contentType != "" {
@@ -327,7 +350,7 @@ func (h *handler) Handle(event alert.Event) {
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		h.diag.Error("failed to POST alert data", err)
-		return
+		return err
 	}
 	defer resp.Body.Close()
 
@@ -344,6 +367,7 @@ func (h *handler) Handle(event alert.Event) {
 			err = errors.New("unknown error, use .captureResponse() to capture the HTTP response")
 		}
 		h.diag.Error("POST returned non 2xx status code", err, keyvalue.KV("code", strconv.Itoa(resp.StatusCode)))
+		return err
 	}
-
+	return nil
 }
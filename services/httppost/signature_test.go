@@ -0,0 +1,101 @@
+package httppost_test
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/services/httppost"
+)
+
+func TestSign(t *testing.T) {
+	// Computed independently with:
+	//    python3 -c 'import hmac,hashlib; print(hmac.new(b"secret", b"1500000000.{\"id\":\"alert\"}", hashlib.sha256).hexdigest())'
+	exp := "t=1500000000,v1=d87b955f6668e53e0ea9d0b03607f7c057a615d49191fce70cbec5d7dd36585d"
+	got := httppost.Sign([]byte("secret"), time.Unix(1500000000, 0), []byte(`{"id":"alert"}`))
+	if got != exp {
+		t.Errorf("unexpected signature:\ngot %s\nexp %s", got, exp)
+	}
+}
+
+// verify checks the signature header value the way a webhook receiver would.
+func verify(secret, header string, body []byte) bool {
+	parts := strings.Split(header, ",")
+	if len(parts) != 2 || !strings.HasPrefix(parts[0], "t=") || !strings.HasPrefix(parts[1], "v1=") {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strings.TrimPrefix(parts[0], "t=") + "."))
+	mac.Write(body)
+	sig, err := hex.DecodeString(strings.TrimPrefix(parts[1], "v1="))
+	if err != nil {
+		return false
+	}
+	return hmac.Equal(sig, mac.Sum(nil))
+}
+
+func TestEndpoint_Signature(t *testing.T) {
+	testCases := []struct {
+		signature httppost.Signature
+		header    string
+	}{
+		{
+			signature: httppost.Signature{Secret: "secret"},
+			header:    httppost.DefaultSignatureHeader,
+		},
+		{
+			signature: httppost.Signature{Secret: "secret", Header: "X-Hub-Signature"},
+			header:    "X-Hub-Signature",
+		},
+	}
+	for _, tc := range testCases {
+		e := httppost.NewEndpoint("http://example.com", nil, httppost.BasicAuth{}, tc.signature, nil, nil)
+		body := []byte(`{"id":"alert"}`)
+		req, err := e.NewHTTPRequest(bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, body) {
+			t.Errorf("unexpected body: got %q exp %q", got, body)
+		}
+		header := req.Header.Get(tc.header)
+		if !verify(tc.signature.Secret, header, body) {
+			t.Errorf("signature %q in header %s does not verify", header, tc.header)
+		}
+		if verify("other", header, body) {
+			t.Errorf("signature %q verified with the wrong secret", header)
+		}
+	}
+}
+
+func TestEndpoint_NoSignature(t *testing.T) {
+	e := httppost.NewEndpoint("http://example.com", nil, httppost.BasicAuth{}, httppost.Signature{}, nil, nil)
+	req, err := e.NewHTTPRequest(bytes.NewReader([]byte("body")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h := req.Header.Get(httppost.DefaultSignatureHeader); h != "" {
+		t.Errorf("unexpected signature header %q", h)
+	}
+}
+
+func TestConfig_Validate_Signature(t *testing.T) {
+	c := httppost.NewConfig()
+	c.Signature = httppost.Signature{Header: "X-Signature"}
+	if err := c.Validate(); err == nil {
+		t.Error("expected error for signature header without secret")
+	}
+	c.Signature.Secret = "secret"
+	if err := c.Validate(); err != nil {
+		t.Error(err)
+	}
+}
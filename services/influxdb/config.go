@@ -36,6 +36,8 @@ type Config struct {
 	URLs     []string `toml:"urls" override:"urls"`
 	Username string   `toml:"username" override:"username"`
 	Password string   `toml:"password" override:"password,redact"`
+	// InfluxDB 2.x API token, used instead of the username and password.
+	Token string `toml:"token" override:"token,redact"`
 	// Path to CA file
 	SSLCA string `toml:"ssl-ca" override:"ssl-ca"`
 	// Path to host cert file
@@ -107,6 +109,9 @@ func (c Config) Validate() error {
 	if len(c.URLs) == 0 {
 		return errors.New("must specify at least one InfluxDB URL")
 	}
+	if c.Token != "" && c.Username != "" {
+		return errors.New("cannot specify both a username and a token")
+	}
 	for _, u := range c.URLs {
 		_, err := url.Parse(u)
 		if err != nil {
@@ -513,6 +513,12 @@ func httpConfig(c Config) (influxdb.Config, error) {
 			Password: c.Password,
 		}
 	}
+	if c.Token != "" {
+		credentials = influxdb.Credentials{
+			Method: influxdb.TokenAuthentication,
+			Token:  c.Token,
+		}
+	}
 	return influxdb.Config{
 		URLs:        c.URLs,
 		Timeout:     time.Duration(c.Timeout),
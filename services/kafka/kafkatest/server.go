@@ -260,6 +260,10 @@ func readStr(buf []byte) (string, int) {
 }
 func readByteArray(buf []byte) ([]byte, int) {
 	n := int(int32(binary.BigEndian.Uint32(buf[:4])))
+	// A length of -1 is a null array
+	if n < 0 {
+		return nil, 4
+	}
 	return buf[4 : 4+n], n + 4
 }
 
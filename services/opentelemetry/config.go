@@ -0,0 +1,99 @@
+package opentelemetry
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/influxdata/influxdb/toml"
+	"github.com/influxdata/kapacitor/tlsconfig"
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultURL           = "http://localhost:4318/v1/logs"
+	DefaultServiceName   = "kapacitor"
+	DefaultBatchSize     = 100
+	DefaultFlushInterval = time.Second
+	DefaultQueueSize     = 10000
+	DefaultTimeout       = 10 * time.Second
+)
+
+type Config struct {
+	// Whether OpenTelemetry integration is enabled.
+	Enabled bool `toml:"enabled" override:"enabled"`
+	// The OTLP/HTTP logs endpoint of the collector.
+	URL string `toml:"url" override:"url"`
+	// Headers added to each export request, e.g. for authentication.
+	Headers map[string]string `toml:"headers" override:"headers"`
+	// The service.name resource attribute of the log records.
+	ServiceName string `toml:"service-name" override:"service-name"`
+	// The maximum number of log records sent in one export request.
+	BatchSize int `toml:"batch-size" override:"batch-size"`
+	// The interval at which buffered log records are exported.
+	FlushInterval toml.Duration `toml:"flush-interval" override:"flush-interval"`
+	// The maximum number of log records buffered, the oldest records are dropped beyond it.
+	QueueSize int `toml:"queue-size" override:"queue-size"`
+	// Timeout of an export request.
+	Timeout toml.Duration `toml:"timeout" override:"timeout"`
+	// Path to CA file
+	SSLCA string `toml:"ssl-ca" override:"ssl-ca"`
+	// Path to host cert file
+	SSLCert string `toml:"ssl-cert" override:"ssl-cert"`
+	// Path to cert key file
+	SSLKey string `toml:"ssl-key" override:"ssl-key"`
+	// Use SSL but skip chain & host verification
+	InsecureSkipVerify bool `toml:"insecure-skip-verify" override:"insecure-skip-verify"`
+}
+
+func NewConfig() Config {
+	return Config{
+		URL:           DefaultURL,
+		ServiceName:   DefaultServiceName,
+		BatchSize:     DefaultBatchSize,
+		FlushInterval: toml.Duration(DefaultFlushInterval),
+		QueueSize:     DefaultQueueSize,
+		Timeout:       toml.Duration(DefaultTimeout),
+	}
+}
+
+func (c Config) Validate() error {
+	// The flush loop runs even when the service is disabled, so its settings are always validated.
+	if c.BatchSize <= 0 {
+		return errors.New("batch-size must be greater than 0")
+	}
+	if c.FlushInterval <= 0 {
+		return errors.New("flush-interval must be greater than 0")
+	}
+	if c.QueueSize < c.BatchSize {
+		return errors.New("queue-size must not be less than batch-size")
+	}
+	if c.Timeout < 0 {
+		return errors.New("timeout must not be negative")
+	}
+	if !c.Enabled {
+		return nil
+	}
+	if c.URL == "" {
+		return errors.New("must specify url")
+	}
+	if _, err := url.Parse(c.URL); err != nil {
+		return errors.Wrapf(err, "invalid url %q", c.URL)
+	}
+	return nil
+}
+
+// httpClient creates the client used to export log records.
+func (c Config) httpClient() (*http.Client, error) {
+	tlsConfig, err := tlsconfig.Create(c.SSLCA, c.SSLCert, c.SSLKey, c.InsecureSkipVerify)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{
+		Timeout: time.Duration(c.Timeout),
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsConfig,
+		},
+	}, nil
+}
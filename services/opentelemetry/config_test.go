@@ -0,0 +1,60 @@
+package opentelemetry
+
+import (
+	"testing"
+
+	"github.com/influxdata/influxdb/toml"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		enabled bool
+		modify  func(c *Config)
+		err     string
+	}{
+		{name: "default", modify: func(c *Config) {}},
+		{name: "enabled", enabled: true, modify: func(c *Config) {}},
+		{name: "enabled without url", enabled: true, modify: func(c *Config) { c.URL = "" }, err: "must specify url"},
+		{name: "disabled without url", modify: func(c *Config) { c.URL = "" }},
+		// The flush loop runs even when the service is disabled.
+		{name: "disabled zero flush-interval", modify: func(c *Config) { c.FlushInterval = toml.Duration(0) }, err: "flush-interval must be greater than 0"},
+		{name: "disabled zero batch-size", modify: func(c *Config) { c.BatchSize = 0 }, err: "batch-size must be greater than 0"},
+		{name: "disabled small queue-size", modify: func(c *Config) { c.QueueSize = c.BatchSize - 1 }, err: "queue-size must not be less than batch-size"},
+		{name: "enabled zero flush-interval", enabled: true, modify: func(c *Config) { c.FlushInterval = toml.Duration(0) }, err: "flush-interval must be greater than 0"},
+	}
+	for _, tc := range testCases {
+		c := NewConfig()
+		c.Enabled = tc.enabled
+		tc.modify(&c)
+		err := c.Validate()
+		if tc.err == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", tc.name, err)
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("%s: expected error %q", tc.name, tc.err)
+		} else if got := err.Error(); got != tc.err {
+			t.Errorf("%s: unexpected error: got %q exp %q", tc.name, got, tc.err)
+		}
+	}
+}
+
+func TestService_UpdateInvalid(t *testing.T) {
+	s := newTestService(t, "http://localhost")
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	c := NewConfig()
+	c.FlushInterval = toml.Duration(0)
+	if err := s.Update([]interface{}{c}); err == nil {
+		t.Fatal("expected error updating with zero flush-interval")
+	}
+	if got := s.config().FlushInterval; got <= 0 {
+		t.Errorf("invalid flush-interval applied: %v", got)
+	}
+}
@@ -0,0 +1,159 @@
+package opentelemetry
+
+import (
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/influxdata/kapacitor/alert"
+)
+
+// Severity numbers of OTLP log records.
+const (
+	SeverityInfo  = 9
+	SeverityWarn  = 13
+	SeverityFatal = 21
+)
+
+// Severity returns the OTLP severity number and text of an alert level.
+// OK is reported as INFO, since a recovery is informational.
+func Severity(l alert.Level) (int32, string) {
+	switch l {
+	case alert.Info:
+		return SeverityInfo, l.String()
+	case alert.Warning:
+		return SeverityWarn, l.String()
+	case alert.Critical:
+		return SeverityFatal, l.String()
+	default:
+		return SeverityInfo, l.String()
+	}
+}
+
+type attribute struct {
+	Key   string
+	Value string
+}
+
+type logRecord struct {
+	Time           time.Time
+	ObservedTime   time.Time
+	SeverityNumber int32
+	SeverityText   string
+	Body           string
+	Attributes     []attribute
+}
+
+// Field numbers of the OTLP messages, see
+// https://github.com/open-telemetry/opentelemetry-proto/blob/main/opentelemetry/proto/logs/v1/logs.proto
+const (
+	fieldExportResourceLogs = 1
+
+	fieldResourceLogsResource  = 1
+	fieldResourceLogsScopeLogs = 2
+
+	fieldResourceAttributes = 1
+
+	fieldScopeLogsScope      = 1
+	fieldScopeLogsLogRecords = 2
+
+	fieldScopeName = 1
+
+	fieldLogRecordTime           = 1
+	fieldLogRecordSeverityNumber = 2
+	fieldLogRecordSeverityText   = 3
+	fieldLogRecordBody           = 5
+	fieldLogRecordAttributes     = 6
+	fieldLogRecordObservedTime   = 11
+
+	fieldKeyValueKey   = 1
+	fieldKeyValueValue = 2
+
+	fieldAnyValueString = 1
+)
+
+// Protobuf wire types
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+)
+
+// message encodes the fields of a protobuf message.
+type message struct {
+	proto.Buffer
+}
+
+func (m *message) tag(field, wire int) {
+	m.EncodeVarint(uint64(field)<<3 | uint64(wire))
+}
+
+func (m *message) writeString(field int, s string) {
+	m.tag(field, wireBytes)
+	m.EncodeStringBytes(s)
+}
+
+func (m *message) writeMessage(field int, sub *message) {
+	m.tag(field, wireBytes)
+	m.EncodeRawBytes(sub.Bytes())
+}
+
+func (m *message) writeVarint(field int, v uint64) {
+	m.tag(field, wireVarint)
+	m.EncodeVarint(v)
+}
+
+func (m *message) writeFixed64(field int, v uint64) {
+	m.tag(field, wireFixed64)
+	m.EncodeFixed64(v)
+}
+
+func (m *message) writeAttribute(field int, a attribute) {
+	value := new(message)
+	value.writeString(fieldAnyValueString, a.Value)
+	kv := new(message)
+	kv.writeString(fieldKeyValueKey, a.Key)
+	kv.writeMessage(fieldKeyValueValue, value)
+	m.writeMessage(field, kv)
+}
+
+func unixNano(t time.Time) uint64 {
+	if t.IsZero() {
+		return 0
+	}
+	return uint64(t.UnixNano())
+}
+
+// marshalLogs encodes the records as an OTLP ExportLogsServiceRequest
+// with a single resource and instrumentation scope.
+func marshalLogs(serviceName string, records []logRecord) []byte {
+	resource := new(message)
+	resource.writeAttribute(fieldResourceAttributes, attribute{Key: "service.name", Value: serviceName})
+
+	scope := new(message)
+	scope.writeString(fieldScopeName, "kapacitor")
+
+	scopeLogs := new(message)
+	scopeLogs.writeMessage(fieldScopeLogsScope, scope)
+	for _, r := range records {
+		record := new(message)
+		record.writeFixed64(fieldLogRecordTime, unixNano(r.Time))
+		record.writeVarint(fieldLogRecordSeverityNumber, uint64(r.SeverityNumber))
+		record.writeString(fieldLogRecordSeverityText, r.SeverityText)
+		body := new(message)
+		body.writeString(fieldAnyValueString, r.Body)
+		record.writeMessage(fieldLogRecordBody, body)
+		for _, a := range r.Attributes {
+			record.writeAttribute(fieldLogRecordAttributes, a)
+		}
+		record.writeFixed64(fieldLogRecordObservedTime, unixNano(r.ObservedTime))
+		scopeLogs.writeMessage(fieldScopeLogsLogRecords, record)
+	}
+
+	resourceLogs := new(message)
+	resourceLogs.writeMessage(fieldResourceLogsResource, resource)
+	resourceLogs.writeMessage(fieldResourceLogsScopeLogs, scopeLogs)
+
+	req := new(message)
+	req.writeMessage(fieldExportResourceLogs, resourceLogs)
+	return req.Bytes()
+}
@@ -0,0 +1,287 @@
+package opentelemetry
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/influxdata/kapacitor/alert"
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/keyvalue"
+	"github.com/influxdata/kapacitor/server/vars"
+	"github.com/pkg/errors"
+)
+
+const (
+	statRecordsExported = "records_exported"
+	statRecordsDropped  = "records_dropped"
+	statExportErrors    = "export_errors"
+)
+
+type Diagnostic interface {
+	WithContext(ctx ...keyvalue.T) Diagnostic
+	Error(msg string, err error)
+}
+
+type Service struct {
+	configValue atomic.Value
+	clientValue atomic.Value
+	diag        Diagnostic
+
+	mu      sync.Mutex
+	records []logRecord
+
+	flushing chan struct{}
+	updated  chan struct{}
+	closing  chan struct{}
+	wg       sync.WaitGroup
+
+	statKey         string
+	recordsExported *expvar.Int
+	recordsDropped  *expvar.Int
+	exportErrors    *expvar.Int
+}
+
+func NewService(c Config, d Diagnostic) (*Service, error) {
+	client, err := c.httpClient()
+	if err != nil {
+		return nil, err
+	}
+	s := &Service{
+		diag:            d,
+		flushing:        make(chan struct{}, 1),
+		updated:         make(chan struct{}, 1),
+		recordsExported: new(expvar.Int),
+		recordsDropped:  new(expvar.Int),
+		exportErrors:    new(expvar.Int),
+	}
+	s.configValue.Store(c)
+	s.clientValue.Store(client)
+	return s, nil
+}
+
+func (s *Service) Open() error {
+	var statMap *expvar.Map
+	s.statKey, statMap = vars.NewStatistic("opentelemetry", nil)
+	statMap.Set(statRecordsExported, s.recordsExported)
+	statMap.Set(statRecordsDropped, s.recordsDropped)
+	statMap.Set(statExportErrors, s.exportErrors)
+
+	s.closing = make(chan struct{})
+	s.wg.Add(1)
+	go s.run()
+	return nil
+}
+
+func (s *Service) Close() error {
+	if s.closing != nil {
+		close(s.closing)
+		s.wg.Wait()
+		s.closing = nil
+	}
+	// Export whatever is still buffered.
+	s.flush()
+	vars.DeleteStatistic(s.statKey)
+	return nil
+}
+
+func (s *Service) config() Config {
+	return s.configValue.Load().(Config)
+}
+
+func (s *Service) client() *http.Client {
+	return s.clientValue.Load().(*http.Client)
+}
+
+func (s *Service) Update(newConfig []interface{}) error {
+	if l := len(newConfig); l != 1 {
+		return fmt.Errorf("expected only one new config object, got %d", l)
+	}
+	c, ok := newConfig[0].(Config)
+	if !ok {
+		return fmt.Errorf("expected config object to be of type %T, got %T", c, newConfig[0])
+	}
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	client, err := c.httpClient()
+	if err != nil {
+		return err
+	}
+	s.configValue.Store(c)
+	s.clientValue.Store(client)
+	// Let the flush loop pick up the new flush interval.
+	select {
+	case s.updated <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// run exports the buffered records every flush interval and whenever a full batch is buffered.
+func (s *Service) run() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(time.Duration(s.config().FlushInterval))
+	defer func() { ticker.Stop() }()
+	for {
+		select {
+		case <-s.closing:
+			return
+		case <-s.updated:
+			ticker.Stop()
+			ticker = time.NewTicker(time.Duration(s.config().FlushInterval))
+		case <-s.flushing:
+			s.flush()
+		case <-ticker.C:
+			s.flush()
+		}
+	}
+}
+
+// enqueue buffers the record until the next flush.
+func (s *Service) enqueue(r logRecord) {
+	c := s.config()
+	s.mu.Lock()
+	if len(s.records) >= c.QueueSize {
+		// Drop the oldest record, the collector is not keeping up.
+		s.records = s.records[1:]
+		s.recordsDropped.Add(1)
+	}
+	s.records = append(s.records, r)
+	full := len(s.records) >= c.BatchSize
+	s.mu.Unlock()
+	if full {
+		select {
+		case s.flushing <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// flush exports all buffered records in batches.
+func (s *Service) flush() {
+	c := s.config()
+	s.mu.Lock()
+	records := s.records
+	s.records = nil
+	s.mu.Unlock()
+	for len(records) > 0 {
+		n := c.BatchSize
+		if n > len(records) {
+			n = len(records)
+		}
+		if err := s.export(c, records[:n]); err != nil {
+			s.exportErrors.Add(1)
+			s.recordsDropped.Add(int64(n))
+			s.diag.Error("failed to export log records", err)
+		} else {
+			s.recordsExported.Add(int64(n))
+		}
+		records = records[n:]
+	}
+}
+
+// export sends the records to the collector as an OTLP/HTTP protobuf request.
+func (s *Service) export(c Config, records []logRecord) error {
+	if !c.Enabled {
+		return errors.New("service is not enabled")
+	}
+	req, err := http.NewRequest("POST", c.URL, bytes.NewReader(marshalLogs(c.ServiceName, records)))
+	if err != nil {
+		return err
+	}
+	for k, v := range c.Headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	resp, err := s.client().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("collector returned status code %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+	return nil
+}
+
+type testOptions struct {
+	Message string            `json:"message"`
+	Level   alert.Level       `json:"level"`
+	Tags    map[string]string `json:"tags"`
+}
+
+func (s *Service) TestOptions() interface{} {
+	return &testOptions{
+		Message: "test opentelemetry message",
+		Level:   alert.Critical,
+		Tags:    map[string]string{"host": "serverA"},
+	}
+}
+
+func (s *Service) Test(options interface{}) error {
+	o, ok := options.(*testOptions)
+	if !ok {
+		return fmt.Errorf("unexpected options type %T", options)
+	}
+	now := time.Now()
+	r := newLogRecord("testID", o.Message, o.Level, o.Tags, now, now)
+	return s.export(s.config(), []logRecord{r})
+}
+
+// newLogRecord creates the log record of an alert.
+// The tags are added as attributes in key order, followed by the alert ID.
+func newLogRecord(id, message string, level alert.Level, tags map[string]string, t, observed time.Time) logRecord {
+	severity, text := Severity(level)
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	attributes := make([]attribute, 0, len(tags)+1)
+	for _, k := range keys {
+		attributes = append(attributes, attribute{Key: k, Value: tags[k]})
+	}
+	attributes = append(attributes, attribute{Key: "alert.id", Value: id})
+	return logRecord{
+		Time:           t,
+		ObservedTime:   observed,
+		SeverityNumber: severity,
+		SeverityText:   text,
+		Body:           message,
+		Attributes:     attributes,
+	}
+}
+
+type handler struct {
+	s    *Service
+	diag Diagnostic
+}
+
+func (s *Service) Handler(ctx ...keyvalue.T) alert.Handler {
+	return &handler{
+		s:    s,
+		diag: s.diag.WithContext(ctx...),
+	}
+}
+
+func (h *handler) Handle(event alert.Event) {
+	if !h.s.config().Enabled {
+		h.diag.Error("failed to send event to OpenTelemetry", errors.New("service is not enabled"))
+		return
+	}
+	h.s.enqueue(newLogRecord(
+		event.State.ID,
+		event.State.Message,
+		event.State.Level,
+		event.Data.Tags,
+		event.State.Time,
+		time.Now(),
+	))
+}
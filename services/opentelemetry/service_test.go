@@ -0,0 +1,298 @@
+package opentelemetry
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/influxdata/influxdb/toml"
+	"github.com/influxdata/kapacitor/alert"
+	"github.com/influxdata/kapacitor/keyvalue"
+)
+
+type diag struct{}
+
+func (d diag) WithContext(ctx ...keyvalue.T) Diagnostic { return d }
+func (diag) Error(msg string, err error)                {}
+
+// fields are the decoded fields of a protobuf message by field number.
+type fields map[uint64][]interface{}
+
+// decode decodes the fields of a protobuf message without knowing its schema.
+// Length delimited fields are returned as bytes.
+func decode(t *testing.T, b []byte) fields {
+	t.Helper()
+	f := make(fields)
+	buf := proto.NewBuffer(b)
+	for len(buf.Bytes()) > 0 && !eof(buf) {
+		key, err := buf.DecodeVarint()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var v interface{}
+		switch key & 7 {
+		case wireVarint:
+			v, err = buf.DecodeVarint()
+		case wireFixed64:
+			v, err = buf.DecodeFixed64()
+		case wireBytes:
+			v, err = buf.DecodeRawBytes(true)
+		default:
+			t.Fatalf("unexpected wire type %d", key&7)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		f[key>>3] = append(f[key>>3], v)
+	}
+	return f
+}
+
+// eof reports whether the buffer has been read entirely.
+func eof(buf *proto.Buffer) bool {
+	// DecodeVarint on an empty remainder fails, peek by copying the buffer.
+	c := *buf
+	_, err := c.DecodeVarint()
+	return err != nil
+}
+
+func (f fields) message(t *testing.T, field uint64) fields {
+	t.Helper()
+	if len(f[field]) != 1 {
+		t.Fatalf("expected one field %d, got %d", field, len(f[field]))
+	}
+	return decode(t, f[field][0].([]byte))
+}
+
+func (f fields) messages(t *testing.T, field uint64) []fields {
+	t.Helper()
+	var ms []fields
+	for _, v := range f[field] {
+		ms = append(ms, decode(t, v.([]byte)))
+	}
+	return ms
+}
+
+func (f fields) string(t *testing.T, field uint64) string {
+	t.Helper()
+	if len(f[field]) != 1 {
+		t.Fatalf("expected one field %d, got %d", field, len(f[field]))
+	}
+	return string(f[field][0].([]byte))
+}
+
+// attributes decodes repeated KeyValue fields with string values.
+func (f fields) attributes(t *testing.T, field uint64) []attribute {
+	t.Helper()
+	var as []attribute
+	for _, kv := range f.messages(t, field) {
+		as = append(as, attribute{
+			Key:   kv.string(t, fieldKeyValueKey),
+			Value: kv.message(t, fieldKeyValueValue).string(t, fieldAnyValueString),
+		})
+	}
+	return as
+}
+
+type collector struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	requests    [][]byte
+	contentType string
+	status      int
+}
+
+func newCollector() *collector {
+	c := &collector{status: http.StatusOK}
+	c.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.requests = append(c.requests, body)
+		c.contentType = r.Header.Get("Content-Type")
+		w.WriteHeader(c.status)
+	}))
+	return c
+}
+
+func (c *collector) Requests() [][]byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.requests
+}
+
+func newTestService(t *testing.T, url string) *Service {
+	c := NewConfig()
+	c.Enabled = true
+	c.URL = url
+	c.BatchSize = 2
+	c.FlushInterval = toml.Duration(time.Hour)
+	s, err := NewService(c, diag{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestService_Payload(t *testing.T) {
+	c := newCollector()
+	defer c.Close()
+	s := newTestService(t, c.URL)
+
+	levels := []struct {
+		level    alert.Level
+		severity uint64
+		text     string
+	}{
+		{alert.OK, SeverityInfo, "OK"},
+		{alert.Info, SeverityInfo, "INFO"},
+		{alert.Warning, SeverityWarn, "WARNING"},
+		{alert.Critical, SeverityFatal, "CRITICAL"},
+	}
+	t0 := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	h := s.Handler()
+	for _, l := range levels {
+		h.Handle(alert.Event{
+			State: alert.EventState{
+				ID:      "cpu:host=serverA",
+				Message: "cpu is " + l.text,
+				Time:    t0,
+				Level:   l.level,
+			},
+			Data: alert.EventData{
+				Tags: map[string]string{"host": "serverA", "cpu": "total"},
+			},
+		})
+	}
+	s.flush()
+
+	reqs := c.Requests()
+	// Four records in batches of two.
+	if got, exp := len(reqs), 2; got != exp {
+		t.Fatalf("unexpected number of requests: got %d exp %d", got, exp)
+	}
+	if got, exp := c.contentType, "application/x-protobuf"; got != exp {
+		t.Errorf("unexpected content type: got %s exp %s", got, exp)
+	}
+
+	var records []fields
+	for _, req := range reqs {
+		resourceLogs := decode(t, req).messages(t, fieldExportResourceLogs)
+		if len(resourceLogs) != 1 {
+			t.Fatalf("expected one resource logs, got %d", len(resourceLogs))
+		}
+		resource := resourceLogs[0].message(t, fieldResourceLogsResource)
+		if got, exp := resource.attributes(t, fieldResourceAttributes), []attribute{{Key: "service.name", Value: "kapacitor"}}; !reflect.DeepEqual(got, exp) {
+			t.Errorf("unexpected resource attributes: got %v exp %v", got, exp)
+		}
+		scopeLogs := resourceLogs[0].message(t, fieldResourceLogsScopeLogs)
+		if got, exp := scopeLogs.message(t, fieldScopeLogsScope).string(t, fieldScopeName), "kapacitor"; got != exp {
+			t.Errorf("unexpected scope name: got %s exp %s", got, exp)
+		}
+		records = append(records, scopeLogs.messages(t, fieldScopeLogsLogRecords)...)
+	}
+	if got, exp := len(records), len(levels); got != exp {
+		t.Fatalf("unexpected number of log records: got %d exp %d", got, exp)
+	}
+	for i, l := range levels {
+		r := records[i]
+		if got, exp := r[fieldLogRecordTime][0], uint64(t0.UnixNano()); got != exp {
+			t.Errorf("%s: unexpected time: got %v exp %v", l.text, got, exp)
+		}
+		if got := r[fieldLogRecordObservedTime][0].(uint64); got == 0 {
+			t.Errorf("%s: missing observed time", l.text)
+		}
+		if got, exp := r[fieldLogRecordSeverityNumber][0], l.severity; got != exp {
+			t.Errorf("%s: unexpected severity number: got %v exp %v", l.text, got, exp)
+		}
+		if got, exp := r.string(t, fieldLogRecordSeverityText), l.text; got != exp {
+			t.Errorf("%s: unexpected severity text: got %s exp %s", l.text, got, exp)
+		}
+		if got, exp := r.message(t, fieldLogRecordBody).string(t, fieldAnyValueString), "cpu is "+l.text; got != exp {
+			t.Errorf("%s: unexpected body: got %s exp %s", l.text, got, exp)
+		}
+		expAttributes := []attribute{
+			{Key: "cpu", Value: "total"},
+			{Key: "host", Value: "serverA"},
+			{Key: "alert.id", Value: "cpu:host=serverA"},
+		}
+		if got := r.attributes(t, fieldLogRecordAttributes); !reflect.DeepEqual(got, expAttributes) {
+			t.Errorf("%s: unexpected attributes: got %v exp %v", l.text, got, expAttributes)
+		}
+	}
+	if got, exp := s.recordsExported.IntValue(), int64(4); got != exp {
+		t.Errorf("unexpected records_exported: got %d exp %d", got, exp)
+	}
+}
+
+func TestService_ExportErrors(t *testing.T) {
+	c := newCollector()
+	defer c.Close()
+	c.status = http.StatusServiceUnavailable
+	s := newTestService(t, c.URL)
+
+	h := s.Handler()
+	for i := 0; i < 3; i++ {
+		h.Handle(alert.Event{State: alert.EventState{ID: "id", Level: alert.Critical}})
+	}
+	s.flush()
+
+	if got, exp := s.exportErrors.IntValue(), int64(2); got != exp {
+		t.Errorf("unexpected export_errors: got %d exp %d", got, exp)
+	}
+	if got, exp := s.recordsDropped.IntValue(), int64(3); got != exp {
+		t.Errorf("unexpected records_dropped: got %d exp %d", got, exp)
+	}
+	if got, exp := s.recordsExported.IntValue(), int64(0); got != exp {
+		t.Errorf("unexpected records_exported: got %d exp %d", got, exp)
+	}
+}
+
+func TestService_FlushOnBatchSize(t *testing.T) {
+	c := newCollector()
+	defer c.Close()
+	s := newTestService(t, c.URL)
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	h := s.Handler()
+	// A full batch is exported without waiting for the flush interval.
+	for i := 0; i < 2; i++ {
+		h.Handle(alert.Event{State: alert.EventState{ID: "id", Level: alert.Warning}})
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for len(c.Requests()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the batch to be exported")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestService_QueueSize(t *testing.T) {
+	s := newTestService(t, "http://localhost")
+	c := s.config()
+	c.QueueSize = 2
+	s.configValue.Store(c)
+
+	for i := 0; i < 3; i++ {
+		s.enqueue(logRecord{Body: string('a' + rune(i))})
+	}
+	if got, exp := len(s.records), 2; got != exp {
+		t.Fatalf("unexpected number of buffered records: got %d exp %d", got, exp)
+	}
+	if got, exp := s.records[0].Body, "b"; got != exp {
+		t.Errorf("expected oldest record to be dropped, got first record %q", got)
+	}
+	if got, exp := s.recordsDropped.IntValue(), int64(1); got != exp {
+		t.Errorf("unexpected records_dropped: got %d exp %d", got, exp)
+	}
+}
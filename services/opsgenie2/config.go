@@ -3,11 +3,13 @@ package opsgenie2
 import (
 	"net/url"
 
+	"github.com/influxdata/influxdb/toml"
 	"github.com/pkg/errors"
 )
 
 const DefaultOpsGenieAPIURL = "https://api.opsgenie.com/v2/alerts"
 const DefaultOpsGenieRecoveryAction = "notes"
+const DefaultBatchSize = 100
 
 type Config struct {
 	// Whether to enable OpsGenie integration.
@@ -26,12 +28,19 @@ type Config struct {
 	RecoveryAction string `toml:"recovery_action" override:"recovery_action"`
 	// Whether every alert should automatically go to OpsGenie.
 	Global bool `toml:"global" override:"global"`
+	// How long events are accumulated before they are sent, zero sends every event immediately.
+	// The Alert API has no bulk endpoint, batched events are still sent with one request each,
+	// but at most BatchSize events, and at most one event of each alert, are sent per window.
+	BatchWindow toml.Duration `toml:"batch-window" override:"batch-window"`
+	// The maximum number of events sent per batch window.
+	BatchSize int `toml:"batch-size" override:"batch-size"`
 }
 
 func NewConfig() Config {
 	return Config{
 		URL:            DefaultOpsGenieAPIURL,
 		RecoveryAction: DefaultOpsGenieRecoveryAction,
+		BatchSize:      DefaultBatchSize,
 	}
 }
 
@@ -49,5 +58,11 @@ func (c Config) Validate() error {
 	if c.Enabled && c.APIKey == "" {
 		return errors.New("api-key cannot be empty")
 	}
+	if c.BatchWindow < 0 {
+		return errors.New("batch-window cannot be negative")
+	}
+	if c.BatchWindow > 0 && c.BatchSize <= 0 {
+		return errors.New("batch-size must be greater than zero when batch-window is set")
+	}
 	return nil
 }
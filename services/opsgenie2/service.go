@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"net/url"
 	"path"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -27,6 +28,10 @@ type Diagnostic interface {
 type Service struct {
 	configValue atomic.Value
 	diag        Diagnostic
+
+	// mu guards batcher, which is nil unless batching is enabled.
+	mu      sync.Mutex
+	batcher *alert.Batcher
 }
 
 func NewService(c Config, d Diagnostic) *Service {
@@ -38,13 +43,36 @@ func NewService(c Config, d Diagnostic) *Service {
 }
 
 func (s *Service) Open() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.batcher == nil {
+		s.batcher = s.newBatcher(s.config())
+	}
 	return nil
 }
 
 func (s *Service) Close() error {
+	s.mu.Lock()
+	b := s.batcher
+	s.batcher = nil
+	s.mu.Unlock()
+	// The waiting events are sent without holding the lock, so that handlers are not blocked meanwhile.
+	if b != nil {
+		b.Close()
+	}
 	return nil
 }
 
+// newBatcher creates and opens a batcher if batching is enabled, otherwise it returns nil.
+func (s *Service) newBatcher(c Config) *alert.Batcher {
+	if c.BatchWindow <= 0 {
+		return nil
+	}
+	b := alert.NewBatcher(s.sendBatch, time.Duration(c.BatchWindow), c.BatchSize)
+	b.Open()
+	return b
+}
+
 func (s *Service) config() Config {
 	return s.configValue.Load().(Config)
 }
@@ -53,14 +81,51 @@ func (s *Service) Update(newConfig []interface{}) error {
 	if l := len(newConfig); l != 1 {
 		return fmt.Errorf("expected only one new config object, got %d", l)
 	}
-	if c, ok := newConfig[0].(Config); !ok {
+	c, ok := newConfig[0].(Config)
+	if !ok {
 		return fmt.Errorf("expected config object to be of type %T, got %T", c, newConfig[0])
-	} else {
-		s.configValue.Store(c)
+	}
+	old := s.config()
+	s.configValue.Store(c)
+	if old.BatchWindow != c.BatchWindow || old.BatchSize != c.BatchSize {
+		s.mu.Lock()
+		b := s.batcher
+		s.batcher = s.newBatcher(c)
+		s.mu.Unlock()
+		if b != nil {
+			b.Close()
+		}
 	}
 	return nil
 }
 
+// enqueue adds the event to the batcher, returns false if batching is disabled or the batcher is full.
+func (s *Service) enqueue(e alert.BatchEvent) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.batcher == nil {
+		return false
+	}
+	if !s.batcher.Add(e) {
+		s.diag.Error("failed to batch event, sending it immediately", fmt.Errorf("more than %d events waiting", alert.BatchQueueSize))
+		return false
+	}
+	return true
+}
+
+// sendBatch sends the events of a batch one at a time, since the Alert API has no bulk endpoint.
+func (s *Service) sendBatch(batch []alert.BatchEvent) []alert.BatchEvent {
+	var failed []alert.BatchEvent
+	for _, e := range batch {
+		h := e.Data.(*handler)
+		if err := h.send(e.Event); err != nil {
+			h.diag.Error("failed to send event to OpsGenie", err)
+			failed = append(failed, e)
+		}
+	}
+	return failed
+}
+
 func (s *Service) Global() bool {
 	c := s.config()
 	return c.Global
@@ -268,7 +333,20 @@ func (s *Service) Handler(c HandlerConfig, ctx ...keyvalue.T) alert.Handler {
 }
 
 func (h *handler) Handle(event alert.Event) {
-	if err := h.s.Alert(
+	if h.s.enqueue(alert.BatchEvent{
+		Key:   event.State.ID,
+		Event: event,
+		Data:  h,
+	}) {
+		return
+	}
+	if err := h.send(event); err != nil {
+		h.diag.Error("failed to send event to OpsGenie", err)
+	}
+}
+
+func (h *handler) send(event alert.Event) error {
+	return h.s.Alert(
 		h.c.TeamsList,
 		h.c.RecipientsList,
 		event.State.Level,
@@ -276,7 +354,5 @@ func (h *handler) Handle(event alert.Event) {
 		event.State.ID,
 		event.State.Time,
 		event.Data.Result,
-	); err != nil {
-		h.diag.Error("failed to send event to OpsGenie", err)
-	}
+	)
 }
@@ -3,11 +3,16 @@ package pagerduty2
 import (
 	"net/url"
 
+	"github.com/influxdata/influxdb/toml"
 	"github.com/pkg/errors"
 )
 
-// DefaultPagerDuty2APIURL is the default URL for the v2 API
-const DefaultPagerDuty2APIURL = "https://events.pagerduty.com/v2/enqueue"
+const (
+	// DefaultPagerDuty2APIURL is the default URL for the v2 API
+	DefaultPagerDuty2APIURL = "https://events.pagerduty.com/v2/enqueue"
+	// DefaultBatchSize is the default maximum number of events sent per batch window.
+	DefaultBatchSize = 100
+)
 
 // Config is the default struct for the PagerDuty v2 plugin
 type Config struct {
@@ -19,12 +24,19 @@ type Config struct {
 	RoutingKey string `toml:"routing-key" override:"routing-key,redact"`
 	// Whether every alert should automatically go to PagerDuty
 	Global bool `toml:"global" override:"global"`
+	// How long events are accumulated before they are sent, zero sends every event immediately.
+	// The Events v2 API has no bulk endpoint, batched events are still sent with one request each,
+	// but at most BatchSize events, and at most one event of each alert, are sent per window.
+	BatchWindow toml.Duration `toml:"batch-window" override:"batch-window"`
+	// The maximum number of events sent per batch window.
+	BatchSize int `toml:"batch-size" override:"batch-size"`
 }
 
 // NewConfig returns a new instance of the primary config struct for PagerDuty
 func NewConfig() Config {
 	return Config{
-		URL: DefaultPagerDuty2APIURL,
+		URL:       DefaultPagerDuty2APIURL,
+		BatchSize: DefaultBatchSize,
 	}
 }
 
@@ -36,5 +48,11 @@ func (c Config) Validate() error {
 	if _, err := url.Parse(c.URL); err != nil {
 		return errors.Wrapf(err, "invalid URL %q", c.URL)
 	}
+	if c.BatchWindow < 0 {
+		return errors.New("batch-window cannot be negative")
+	}
+	if c.BatchWindow > 0 && c.BatchSize <= 0 {
+		return errors.New("batch-size must be greater than zero when batch-window is set")
+	}
 	return nil
 }
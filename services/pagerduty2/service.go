@@ -8,6 +8,7 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -108,6 +109,10 @@ type Service struct {
 		URL() string
 	}
 	diag Diagnostic
+
+	// mu guards batcher, which is nil unless batching is enabled.
+	mu      sync.Mutex
+	batcher *alert.Batcher
 }
 
 // NewService returns a newly instantiated Service
@@ -121,14 +126,37 @@ func NewService(c Config, d Diagnostic) *Service {
 
 // Open is a bound method of the Service struct
 func (s *Service) Open() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.batcher == nil {
+		s.batcher = s.newBatcher(s.config())
+	}
 	return nil
 }
 
 // Close is a bound method of the Service struct
 func (s *Service) Close() error {
+	s.mu.Lock()
+	b := s.batcher
+	s.batcher = nil
+	s.mu.Unlock()
+	// The waiting events are sent without holding the lock, so that handlers are not blocked meanwhile.
+	if b != nil {
+		b.Close()
+	}
 	return nil
 }
 
+// newBatcher creates and opens a batcher if batching is enabled, otherwise it returns nil.
+func (s *Service) newBatcher(c Config) *alert.Batcher {
+	if c.BatchWindow <= 0 {
+		return nil
+	}
+	b := alert.NewBatcher(s.sendBatch, time.Duration(c.BatchWindow), c.BatchSize)
+	b.Open()
+	return b
+}
+
 func (s *Service) config() Config {
 	return s.configValue.Load().(Config)
 }
@@ -144,10 +172,47 @@ func (s *Service) Update(newConfig []interface{}) error {
 		return fmt.Errorf("expected config object to be of type %T, got %T", c, newConfig[0])
 	}
 
+	old := s.config()
 	s.configValue.Store(c)
+	if old.BatchWindow != c.BatchWindow || old.BatchSize != c.BatchSize {
+		s.mu.Lock()
+		b := s.batcher
+		s.batcher = s.newBatcher(c)
+		s.mu.Unlock()
+		if b != nil {
+			b.Close()
+		}
+	}
 	return nil
 }
 
+// enqueue adds the event to the batcher, returns false if batching is disabled or the batcher is full.
+func (s *Service) enqueue(e alert.BatchEvent) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.batcher == nil {
+		return false
+	}
+	if !s.batcher.Add(e) {
+		s.diag.Error("failed to batch event, sending it immediately", fmt.Errorf("more than %d events waiting", alert.BatchQueueSize))
+		return false
+	}
+	return true
+}
+
+// sendBatch sends the events of a batch one at a time, since the Events v2 API has no bulk endpoint.
+func (s *Service) sendBatch(batch []alert.BatchEvent) []alert.BatchEvent {
+	var failed []alert.BatchEvent
+	for _, e := range batch {
+		h := e.Data.(*handler)
+		if err := h.send(e.Event); err != nil {
+			h.diag.Error("failed to send event to PagerDuty", err)
+			failed = append(failed, e)
+		}
+	}
+	return failed
+}
+
 // Global is a bound method of the Service struct, returns whether the Service configuration is global
 func (s *Service) Global() bool {
 	c := s.config()
@@ -357,14 +422,25 @@ func (s *Service) Handler(c HandlerConfig, ctx ...keyvalue.T) alert.Handler {
 
 // Handle is a bound method to the handler that processes a given alert
 func (h *handler) Handle(event alert.Event) {
-	if err := h.s.Alert(
+	if h.s.enqueue(alert.BatchEvent{
+		Key:   h.c.RoutingKey + "\n" + event.State.ID,
+		Event: event,
+		Data:  h,
+	}) {
+		return
+	}
+	if err := h.send(event); err != nil {
+		h.diag.Error("failed to send event to PagerDuty", err)
+	}
+}
+
+func (h *handler) send(event alert.Event) error {
+	return h.s.Alert(
 		h.c.RoutingKey,
 		event.State.ID,
 		event.State.Message,
 		event.State.Level,
 		event.State.Time,
 		event.Data,
-	); err != nil {
-		h.diag.Error("failed to send event to PagerDuty", err)
-	}
+	)
 }
@@ -0,0 +1,53 @@
+package pbline
+
+import "github.com/pkg/errors"
+
+const (
+	// DefaultBatchSize is the maximum number of points written at once.
+	DefaultBatchSize = 1000
+)
+
+type Config struct {
+	Enabled     bool   `toml:"enabled"`
+	BindAddress string `toml:"bind-address"`
+
+	Database        string `toml:"database"`
+	RetentionPolicy string `toml:"retention-policy"`
+
+	// Maximum size in bytes of a single Point message.
+	MaxMessageSize int `toml:"max-message-size"`
+	// Maximum number of points written at once.
+	BatchSize int `toml:"batch-size"`
+}
+
+// WithDefaults takes the given config and returns a new config with any required
+// default values set.
+func (c *Config) WithDefaults() *Config {
+	d := *c
+	if d.MaxMessageSize == 0 {
+		d.MaxMessageSize = DefaultMaxMessageSize
+	}
+	if d.BatchSize == 0 {
+		d.BatchSize = DefaultBatchSize
+	}
+	return &d
+}
+
+func (c Config) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.BindAddress == "" {
+		return errors.New("must specify bind-address")
+	}
+	if c.Database == "" {
+		return errors.New("must specify database")
+	}
+	if c.MaxMessageSize < 0 {
+		return errors.New("max-message-size cannot be negative")
+	}
+	if c.BatchSize < 0 {
+		return errors.New("batch-size cannot be negative")
+	}
+	return nil
+}
@@ -0,0 +1,391 @@
+// Package pbline decodes points encoded as protobuf messages.
+//
+// Each point is encoded as a Point message of the following schema:
+//
+//    message Point {
+//        string measurement = 1;
+//        // Unix time in nanoseconds, if zero the time the point is received is used.
+//        int64 time = 2;
+//        repeated Tag tags = 3;
+//        repeated Field fields = 4;
+//    }
+//
+//    message Tag {
+//        string key = 1;
+//        string value = 2;
+//    }
+//
+//    message Field {
+//        string key = 1;
+//        oneof value {
+//            double float_value = 2;
+//            int64 int_value = 3;
+//            string string_value = 4;
+//            bool bool_value = 5;
+//        }
+//    }
+//
+// A stream of points is framed by prefixing each Point message with its length encoded as a varint,
+// the framing used by the delimited protobuf readers and writers of most languages.
+// Unknown fields are skipped.
+package pbline
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/influxdata/influxdb/models"
+)
+
+// ContentType is the HTTP content type of a stream of length delimited points.
+const ContentType = "application/x-protobuf"
+
+// DefaultMaxMessageSize is the default maximum size in bytes of a single Point message.
+const DefaultMaxMessageSize = 1 << 20
+
+// Field numbers of the messages.
+const (
+	fieldPointMeasurement = 1
+	fieldPointTime        = 2
+	fieldPointTags        = 3
+	fieldPointFields      = 4
+
+	fieldTagKey   = 1
+	fieldTagValue = 2
+
+	fieldFieldKey         = 1
+	fieldFieldFloatValue  = 2
+	fieldFieldIntValue    = 3
+	fieldFieldStringValue = 4
+	fieldFieldBoolValue   = 5
+)
+
+// Protobuf wire types
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// MalformedError is returned for a Point message that cannot be decoded.
+// Since the message is framed, the stream can still be read after a MalformedError.
+type MalformedError struct {
+	Err error
+}
+
+func (e *MalformedError) Error() string {
+	return "malformed point: " + e.Err.Error()
+}
+
+// IsMalformed reports whether err is a MalformedError.
+func IsMalformed(err error) bool {
+	_, ok := err.(*MalformedError)
+	return ok
+}
+
+// Unmarshal decodes a single Point message.
+// Points without a time are given the time now.
+func Unmarshal(b []byte, now time.Time) (models.Point, error) {
+	p, err := unmarshalPoint(b, now)
+	if err != nil {
+		return nil, &MalformedError{Err: err}
+	}
+	return p, nil
+}
+
+func unmarshalPoint(b []byte, now time.Time) (models.Point, error) {
+	var (
+		name   string
+		t      int64
+		tags   = make(map[string]string)
+		fields = make(models.Fields)
+	)
+	r := reader{buf: b}
+	for !r.done() {
+		field, wire, err := r.tag()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case field == fieldPointMeasurement && wire == wireBytes:
+			if name, err = r.string(); err != nil {
+				return nil, err
+			}
+		case field == fieldPointTime && wire == wireVarint:
+			v, err := r.varint()
+			if err != nil {
+				return nil, err
+			}
+			t = int64(v)
+		case field == fieldPointTags && wire == wireBytes:
+			b, err := r.bytes()
+			if err != nil {
+				return nil, err
+			}
+			k, v, err := unmarshalTag(b)
+			if err != nil {
+				return nil, err
+			}
+			tags[k] = v
+		case field == fieldPointFields && wire == wireBytes:
+			b, err := r.bytes()
+			if err != nil {
+				return nil, err
+			}
+			k, v, err := unmarshalField(b)
+			if err != nil {
+				return nil, err
+			}
+			fields[k] = v
+		default:
+			if err := r.skip(wire); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if name == "" {
+		return nil, errors.New("missing measurement")
+	}
+	if len(fields) == 0 {
+		return nil, errors.New("point has no fields")
+	}
+	pt := now
+	if t != 0 {
+		pt = time.Unix(0, t).UTC()
+	}
+	return models.NewPoint(name, models.NewTags(tags), fields, pt)
+}
+
+func unmarshalTag(b []byte) (string, string, error) {
+	var key, value string
+	r := reader{buf: b}
+	for !r.done() {
+		field, wire, err := r.tag()
+		if err != nil {
+			return "", "", err
+		}
+		switch {
+		case field == fieldTagKey && wire == wireBytes:
+			if key, err = r.string(); err != nil {
+				return "", "", err
+			}
+		case field == fieldTagValue && wire == wireBytes:
+			if value, err = r.string(); err != nil {
+				return "", "", err
+			}
+		default:
+			if err := r.skip(wire); err != nil {
+				return "", "", err
+			}
+		}
+	}
+	if key == "" {
+		return "", "", errors.New("missing tag key")
+	}
+	return key, value, nil
+}
+
+func unmarshalField(b []byte) (string, interface{}, error) {
+	var (
+		key   string
+		value interface{}
+	)
+	r := reader{buf: b}
+	for !r.done() {
+		field, wire, err := r.tag()
+		if err != nil {
+			return "", nil, err
+		}
+		switch {
+		case field == fieldFieldKey && wire == wireBytes:
+			if key, err = r.string(); err != nil {
+				return "", nil, err
+			}
+		case field == fieldFieldFloatValue && wire == wireFixed64:
+			v, err := r.fixed64()
+			if err != nil {
+				return "", nil, err
+			}
+			value = math.Float64frombits(v)
+		case field == fieldFieldIntValue && wire == wireVarint:
+			v, err := r.varint()
+			if err != nil {
+				return "", nil, err
+			}
+			value = int64(v)
+		case field == fieldFieldStringValue && wire == wireBytes:
+			v, err := r.string()
+			if err != nil {
+				return "", nil, err
+			}
+			value = v
+		case field == fieldFieldBoolValue && wire == wireVarint:
+			v, err := r.varint()
+			if err != nil {
+				return "", nil, err
+			}
+			value = v != 0
+		default:
+			if err := r.skip(wire); err != nil {
+				return "", nil, err
+			}
+		}
+	}
+	if key == "" {
+		return "", nil, errors.New("missing field key")
+	}
+	if value == nil {
+		return "", nil, fmt.Errorf("field %q has no value", key)
+	}
+	return key, value, nil
+}
+
+// reader reads the fields of an encoded protobuf message.
+type reader struct {
+	buf []byte
+}
+
+func (r *reader) done() bool {
+	return len(r.buf) == 0
+}
+
+func (r *reader) varint() (uint64, error) {
+	v, n := proto.DecodeVarint(r.buf)
+	if n == 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	r.buf = r.buf[n:]
+	return v, nil
+}
+
+func (r *reader) tag() (int, int, error) {
+	v, err := r.varint()
+	if err != nil {
+		return 0, 0, err
+	}
+	return int(v >> 3), int(v & 7), nil
+}
+
+func (r *reader) fixed64() (uint64, error) {
+	if len(r.buf) < 8 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	var v uint64
+	for i := 7; i >= 0; i-- {
+		v = v<<8 | uint64(r.buf[i])
+	}
+	r.buf = r.buf[8:]
+	return v, nil
+}
+
+func (r *reader) bytes() ([]byte, error) {
+	l, err := r.varint()
+	if err != nil {
+		return nil, err
+	}
+	if l > uint64(len(r.buf)) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	b := r.buf[:l]
+	r.buf = r.buf[l:]
+	return b, nil
+}
+
+func (r *reader) string() (string, error) {
+	b, err := r.bytes()
+	return string(b), err
+}
+
+// skip skips the value of an unknown field.
+func (r *reader) skip(wire int) error {
+	var err error
+	switch wire {
+	case wireVarint:
+		_, err = r.varint()
+	case wireFixed64:
+		_, err = r.fixed64()
+	case wireBytes:
+		_, err = r.bytes()
+	case wireFixed32:
+		if len(r.buf) < 4 {
+			return io.ErrUnexpectedEOF
+		}
+		r.buf = r.buf[4:]
+	default:
+		return fmt.Errorf("unsupported wire type %d", wire)
+	}
+	return err
+}
+
+// Decoder reads length delimited Point messages from a stream.
+type Decoder struct {
+	r              *bufio.Reader
+	maxMessageSize int
+	buf            []byte
+}
+
+// NewDecoder returns a Decoder reading from r.
+// Messages larger than maxMessageSize bytes are rejected, if zero DefaultMaxMessageSize is used.
+func NewDecoder(r io.Reader, maxMessageSize int) *Decoder {
+	if maxMessageSize <= 0 {
+		maxMessageSize = DefaultMaxMessageSize
+	}
+	return &Decoder{
+		r:              bufio.NewReader(r),
+		maxMessageSize: maxMessageSize,
+	}
+}
+
+// Decode reads the next point from the stream.
+// It returns io.EOF at the end of the stream and a MalformedError if the message cannot be decoded,
+// any other error means the stream cannot be read any further.
+func (d *Decoder) Decode(now time.Time) (models.Point, error) {
+	l, err := d.readLength()
+	if err != nil {
+		return nil, err
+	}
+	if l > uint64(d.maxMessageSize) {
+		return nil, fmt.Errorf("message of %d bytes exceeds the maximum size of %d bytes", l, d.maxMessageSize)
+	}
+	if uint64(cap(d.buf)) < l {
+		d.buf = make([]byte, l)
+	}
+	d.buf = d.buf[:l]
+	if _, err := io.ReadFull(d.r, d.buf); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return Unmarshal(d.buf, now)
+}
+
+// Buffered reports whether data of the next message has already been read from the stream.
+func (d *Decoder) Buffered() bool {
+	return d.r.Buffered() > 0
+}
+
+// readLength reads the varint length prefix of a message.
+func (d *Decoder) readLength() (uint64, error) {
+	var l uint64
+	for shift := uint(0); shift < 64; shift += 7 {
+		c, err := d.r.ReadByte()
+		if err != nil {
+			if err == io.EOF && shift > 0 {
+				err = io.ErrUnexpectedEOF
+			}
+			return 0, err
+		}
+		l |= uint64(c&0x7F) << shift
+		if c < 0x80 {
+			return l, nil
+		}
+	}
+	return 0, errors.New("invalid message length")
+}
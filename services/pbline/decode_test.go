@@ -0,0 +1,245 @@
+package pbline_test
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/influxdata/influxdb/models"
+	"github.com/influxdata/kapacitor/services/pbline"
+)
+
+var now = time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// message encodes the fields of a protobuf message.
+type message struct {
+	proto.Buffer
+}
+
+func (m *message) tag(field, wire int) *message {
+	m.EncodeVarint(uint64(field)<<3 | uint64(wire))
+	return m
+}
+
+func (m *message) str(field int, s string) *message {
+	m.tag(field, 2).EncodeStringBytes(s)
+	return m
+}
+
+func (m *message) msg(field int, sub *message) *message {
+	m.tag(field, 2).EncodeRawBytes(sub.Bytes())
+	return m
+}
+
+func (m *message) varint(field int, v uint64) *message {
+	m.tag(field, 0).EncodeVarint(v)
+	return m
+}
+
+func (m *message) fixed64(field int, v uint64) *message {
+	m.tag(field, 1).EncodeFixed64(v)
+	return m
+}
+
+func tag(k, v string) *message {
+	return new(message).str(1, k).str(2, v)
+}
+
+// delimited frames the messages with their length.
+func delimited(msgs ...*message) []byte {
+	b := new(proto.Buffer)
+	for _, m := range msgs {
+		b.EncodeRawBytes(m.Bytes())
+	}
+	return b.Bytes()
+}
+
+func mustPoint(t *testing.T, name string, tags map[string]string, fields models.Fields, tm time.Time) models.Point {
+	p, err := models.NewPoint(name, models.NewTags(tags), fields, tm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestUnmarshal(t *testing.T) {
+	// Point{measurement: "cpu", time: 1, fields: [{key: "value", int_value: 42}]}
+	known := []byte{
+		0x0a, 0x03, 'c', 'p', 'u',
+		0x10, 0x01,
+		0x22, 0x09, 0x0a, 0x05, 'v', 'a', 'l', 'u', 'e', 0x18, 0x2a,
+	}
+	p, err := pbline.Unmarshal(known, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := p.String(), mustPoint(t, "cpu", nil, models.Fields{"value": int64(42)}, time.Unix(0, 1)).String(); got != exp {
+		t.Errorf("unexpected point:\ngot %s\nexp %s", got, exp)
+	}
+}
+
+func TestUnmarshal_FieldTypes(t *testing.T) {
+	tm := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	m := new(message).
+		str(1, "system").
+		varint(2, uint64(tm.UnixNano())).
+		msg(3, tag("host", "serverA")).
+		msg(3, tag("region", "us-west")).
+		msg(4, new(message).str(1, "load").fixed64(2, math.Float64bits(1.5))).
+		msg(4, new(message).str(1, "procs").varint(3, 120)).
+		msg(4, new(message).str(1, "delta").varint(3, uint64(-7&(1<<64-1)))).
+		msg(4, new(message).str(1, "status").str(4, "running")).
+		msg(4, new(message).str(1, "healthy").varint(5, 1)).
+		msg(4, new(message).str(1, "degraded").varint(5, 0)).
+		// Unknown fields are skipped.
+		varint(15, 1)
+	p, err := pbline.Unmarshal(m.Bytes(), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := string(p.Name()), "system"; got != exp {
+		t.Errorf("unexpected name: got %s exp %s", got, exp)
+	}
+	if got, exp := p.Time(), tm; !got.Equal(exp) {
+		t.Errorf("unexpected time: got %v exp %v", got, exp)
+	}
+	if got, exp := p.Tags().Map(), map[string]string{"host": "serverA", "region": "us-west"}; !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected tags:\ngot %v\nexp %v", got, exp)
+	}
+	fields := p.Fields()
+	expFields := models.Fields{
+		"load":     1.5,
+		"procs":    int64(120),
+		"delta":    int64(-7),
+		"status":   "running",
+		"healthy":  true,
+		"degraded": false,
+	}
+	if !reflect.DeepEqual(fields, expFields) {
+		t.Errorf("unexpected fields:\ngot %v\nexp %v", fields, expFields)
+	}
+}
+
+func TestUnmarshal_DefaultTime(t *testing.T) {
+	m := new(message).
+		str(1, "cpu").
+		msg(4, new(message).str(1, "value").fixed64(2, math.Float64bits(1)))
+	p, err := pbline.Unmarshal(m.Bytes(), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := p.Time(); !got.Equal(now) {
+		t.Errorf("unexpected time: got %v exp %v", got, now)
+	}
+}
+
+func TestUnmarshal_Malformed(t *testing.T) {
+	value := new(message).str(1, "value").fixed64(2, math.Float64bits(1))
+	testCases := []struct {
+		name string
+		data []byte
+	}{
+		{
+			name: "truncated",
+			data: []byte{0x0a, 0x05, 'c', 'p'},
+		},
+		{
+			name: "no measurement",
+			data: new(message).msg(4, value).Bytes(),
+		},
+		{
+			name: "no fields",
+			data: new(message).str(1, "cpu").Bytes(),
+		},
+		{
+			name: "field without value",
+			data: new(message).str(1, "cpu").msg(4, new(message).str(1, "value")).Bytes(),
+		},
+		{
+			name: "field without key",
+			data: new(message).str(1, "cpu").msg(4, new(message).varint(3, 1)).Bytes(),
+		},
+		{
+			name: "tag without key",
+			data: new(message).str(1, "cpu").msg(3, new(message).str(2, "serverA")).msg(4, value).Bytes(),
+		},
+		{
+			name: "invalid wire type",
+			data: append(new(message).str(1, "cpu").msg(4, value).Bytes(), 0x7f),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := pbline.Unmarshal(tc.data, now)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !pbline.IsMalformed(err) {
+				t.Errorf("expected malformed error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	cpu := new(message).
+		str(1, "cpu").
+		varint(2, 1).
+		msg(3, tag("host", "serverA")).
+		msg(4, new(message).str(1, "value").fixed64(2, math.Float64bits(0.5)))
+	malformed := new(message).str(1, "mem")
+	mem := new(message).
+		str(1, "mem").
+		varint(2, 2).
+		msg(4, new(message).str(1, "free").varint(3, 1024))
+
+	d := pbline.NewDecoder(bytes.NewReader(delimited(cpu, malformed, mem)), 0)
+	exp := []models.Point{
+		mustPoint(t, "cpu", map[string]string{"host": "serverA"}, models.Fields{"value": 0.5}, time.Unix(0, 1)),
+		nil,
+		mustPoint(t, "mem", nil, models.Fields{"free": int64(1024)}, time.Unix(0, 2)),
+	}
+	for i, e := range exp {
+		p, err := d.Decode(now)
+		if e == nil {
+			if !pbline.IsMalformed(err) {
+				t.Fatalf("%d: expected malformed error, got %v", i, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%d: %v", i, err)
+		}
+		if got, exp := p.String(), e.String(); got != exp {
+			t.Errorf("%d: unexpected point:\ngot %s\nexp %s", i, got, exp)
+		}
+	}
+	if _, err := d.Decode(now); err != io.EOF {
+		t.Errorf("expected EOF, got %v", err)
+	}
+}
+
+func TestDecoder_Framing(t *testing.T) {
+	cpu := new(message).
+		str(1, "cpu").
+		msg(4, new(message).str(1, "value").fixed64(2, math.Float64bits(0.5)))
+	data := delimited(cpu)
+
+	t.Run("truncated", func(t *testing.T) {
+		d := pbline.NewDecoder(bytes.NewReader(data[:len(data)-1]), 0)
+		if _, err := d.Decode(now); err != io.ErrUnexpectedEOF {
+			t.Errorf("expected unexpected EOF, got %v", err)
+		}
+	})
+	t.Run("too large", func(t *testing.T) {
+		d := pbline.NewDecoder(bytes.NewReader(data), 4)
+		_, err := d.Decode(now)
+		if err == nil || pbline.IsMalformed(err) {
+			t.Errorf("expected framing error, got %v", err)
+		}
+	})
+}
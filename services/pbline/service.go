@@ -0,0 +1,175 @@
+package pbline
+
+import (
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/models"
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/keyvalue"
+	"github.com/influxdata/kapacitor/server/vars"
+)
+
+// statistics gathered by the pbline package.
+const (
+	statPointsReceived    = "points_rx"
+	statPointsParseFail   = "points_parse_fail"
+	statReadFail          = "read_fail"
+	statPointsTransmitted = "points_tx"
+	statTransmitFail      = "tx_fail"
+)
+
+type Diagnostic interface {
+	Error(msg string, err error, ctx ...keyvalue.T)
+	StartedListening(addr string)
+	ClosedService()
+}
+
+// Service listens for TCP connections
+// streaming length delimited protobuf points.
+type Service struct {
+	ln     net.Listener
+	wg     sync.WaitGroup
+	mu     sync.Mutex
+	conns  map[net.Conn]bool
+	config Config
+
+	PointsWriter interface {
+		WritePoints(database, retentionPolicy string, consistencyLevel models.ConsistencyLevel, points []models.Point) error
+	}
+
+	Diag    Diagnostic
+	statMap *expvar.Map
+	statKey string
+}
+
+func NewService(c Config, diag Diagnostic) *Service {
+	return &Service{
+		config: *c.WithDefaults(),
+		conns:  make(map[net.Conn]bool),
+		Diag:   diag,
+	}
+}
+
+func (s *Service) Open() (err error) {
+	if s.config.BindAddress == "" {
+		return errors.New("bind address has to be specified in config")
+	}
+	if s.config.Database == "" {
+		return errors.New("database has to be specified in config")
+	}
+
+	s.ln, err = net.Listen("tcp", s.config.BindAddress)
+	if err != nil {
+		s.Diag.Error("failed to set up TCP listener at address", err, keyvalue.KV("address", s.config.BindAddress))
+		return err
+	}
+
+	tags := map[string]string{"bind": s.ln.Addr().String()}
+	s.statKey, s.statMap = vars.NewStatistic("pbline", tags)
+
+	s.Diag.StartedListening(s.ln.Addr().String())
+
+	s.wg.Add(1)
+	go s.serve()
+	return nil
+}
+
+func (s *Service) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			if !strings.Contains(err.Error(), "use of closed network connection") {
+				s.Diag.Error("failed to accept connection", err)
+			}
+			return
+		}
+		s.mu.Lock()
+		s.conns[conn] = true
+		s.mu.Unlock()
+		s.wg.Add(1)
+		go s.handleConn(conn)
+	}
+}
+
+// handleConn decodes the points of a connection,
+// writing them once a batch is full or no more data is buffered.
+func (s *Service) handleConn(conn net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	d := NewDecoder(conn, s.config.MaxMessageSize)
+	points := make([]models.Point, 0, s.config.BatchSize)
+	for {
+		p, err := d.Decode(time.Now().UTC())
+		if err != nil {
+			if IsMalformed(err) {
+				s.statMap.Add(statPointsParseFail, 1)
+				s.Diag.Error("failed to parse point", err)
+				continue
+			}
+			s.writePoints(points)
+			if err != io.EOF && !strings.Contains(err.Error(), "use of closed network connection") {
+				s.statMap.Add(statReadFail, 1)
+				s.Diag.Error("failed to read points", err, keyvalue.KV("remote", conn.RemoteAddr().String()))
+			}
+			return
+		}
+		s.statMap.Add(statPointsReceived, 1)
+		points = append(points, p)
+		if len(points) >= s.config.BatchSize || !d.Buffered() {
+			s.writePoints(points)
+			points = points[:0]
+		}
+	}
+}
+
+func (s *Service) writePoints(points []models.Point) {
+	if len(points) == 0 {
+		return
+	}
+	if err := s.PointsWriter.WritePoints(
+		s.config.Database,
+		s.config.RetentionPolicy,
+		models.ConsistencyLevelAll,
+		points,
+	); err == nil {
+		s.statMap.Add(statPointsTransmitted, int64(len(points)))
+	} else {
+		s.Diag.Error("failed to write points to database", err, keyvalue.KV("database", s.config.Database))
+		s.statMap.Add(statTransmitFail, 1)
+	}
+}
+
+func (s *Service) Close() error {
+	if s.ln == nil {
+		return errors.New("Service already closed")
+	}
+	vars.DeleteStatistic(s.statKey)
+
+	s.ln.Close()
+	s.mu.Lock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+	s.ln = nil
+
+	s.Diag.ClosedService()
+	return nil
+}
+
+func (s *Service) Addr() net.Addr {
+	return s.ln.Addr()
+}
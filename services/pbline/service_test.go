@@ -0,0 +1,108 @@
+package pbline_test
+
+import (
+	"io/ioutil"
+	"math"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/models"
+	"github.com/influxdata/kapacitor/server/vars"
+	"github.com/influxdata/kapacitor/services/diagnostic"
+	"github.com/influxdata/kapacitor/services/pbline"
+)
+
+var diagService *diagnostic.Service
+
+func init() {
+	diagService = diagnostic.NewService(diagnostic.NewConfig(), ioutil.Discard, ioutil.Discard)
+	diagService.Open()
+}
+
+type pointsWriter struct {
+	mu     sync.Mutex
+	db     string
+	points []models.Point
+}
+
+func (w *pointsWriter) WritePoints(database, retentionPolicy string, consistencyLevel models.ConsistencyLevel, points []models.Point) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.db = database
+	w.points = append(w.points, points...)
+	return nil
+}
+
+func (w *pointsWriter) Points() []models.Point {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.points
+}
+
+func TestService(t *testing.T) {
+	c := pbline.Config{
+		Enabled:     true,
+		BindAddress: "127.0.0.1:0",
+		Database:    "mydb",
+	}
+	s := pbline.NewService(c, diagService.NewPBLineHandler())
+	w := new(pointsWriter)
+	s.PointsWriter = w
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	conn, err := net.Dial("tcp", s.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cpu := new(message).
+		str(1, "cpu").
+		varint(2, 1).
+		msg(3, tag("host", "serverA")).
+		msg(4, new(message).str(1, "value").fixed64(2, math.Float64bits(0.5)))
+	malformed := new(message).str(1, "cpu")
+	if _, err := conn.Write(delimited(cpu, malformed, cpu)); err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+
+	timeout := time.After(5 * time.Second)
+	for len(w.Points()) < 2 {
+		select {
+		case <-timeout:
+			t.Fatalf("timed out waiting for points, got %d", len(w.Points()))
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	exp := mustPoint(t, "cpu", map[string]string{"host": "serverA"}, models.Fields{"value": 0.5}, time.Unix(0, 1)).String()
+	for _, p := range w.Points() {
+		if got := p.String(); got != exp {
+			t.Errorf("unexpected point:\ngot %s\nexp %s", got, exp)
+		}
+	}
+	if w.db != "mydb" {
+		t.Errorf("unexpected database %q", w.db)
+	}
+
+	stats, err := vars.GetStatsData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, st := range stats {
+		if st.Name != "pbline" || st.Tags["bind"] != s.Addr().String() {
+			continue
+		}
+		if got, exp := st.Values["points_parse_fail"], int64(1); got != exp {
+			t.Errorf("unexpected points_parse_fail: got %v exp %v", got, exp)
+		}
+		if got, exp := st.Values["points_rx"], int64(2); got != exp {
+			t.Errorf("unexpected points_rx: got %v exp %v", got, exp)
+		}
+		return
+	}
+	t.Error("no pbline statistics found")
+}
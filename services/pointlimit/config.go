@@ -0,0 +1,111 @@
+package pointlimit
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxdb/toml"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultSampleInterval is the minimum time between logged samples of rejected points.
+	DefaultSampleInterval = toml.Duration(time.Minute)
+)
+
+// Sources of written points that can be limited.
+const (
+	SourceHTTP          = "http"
+	SourceUDP           = "udp"
+	SourcePBLine        = "pbline"
+	SourceCollectd      = "collectd"
+	SourceOpenTSDB      = "opentsdb"
+	SourceGraphite      = "graphite"
+	SourceSubscriptions = "subscriptions"
+)
+
+var sources = []string{
+	SourceHTTP,
+	SourceUDP,
+	SourcePBLine,
+	SourceCollectd,
+	SourceOpenTSDB,
+	SourceGraphite,
+	SourceSubscriptions,
+}
+
+// Config limits the size of the points written by a source,
+// points exceeding any of the limits are rejected before they reach any task.
+type Config struct {
+	// Source is the input the limits apply to,
+	// one of http, udp, pbline, collectd, opentsdb, graphite or subscriptions.
+	// Empty applies to all sources without their own limits.
+	Source string `toml:"source"`
+
+	// MaxPointSize is the maximum size in bytes of a point in line protocol, zero means no limit.
+	MaxPointSize int `toml:"max-point-size"`
+	// MaxFieldLength is the maximum length in bytes of a field key or string field value, zero means no limit.
+	MaxFieldLength int `toml:"max-field-length"`
+	// MaxTagLength is the maximum length in bytes of a tag key or value, zero means no limit.
+	MaxTagLength int `toml:"max-tag-length"`
+
+	// SampleInterval is the minimum time between logged samples of rejected points.
+	SampleInterval toml.Duration `toml:"sample-interval"`
+}
+
+// WithDefaults takes the given config and returns a new config with any required
+// default values set.
+func (c Config) WithDefaults() Config {
+	if c.SampleInterval == 0 {
+		c.SampleInterval = DefaultSampleInterval
+	}
+	return c
+}
+
+func (c Config) Validate() error {
+	if c.Source != "" && !validSource(c.Source) {
+		return fmt.Errorf("unknown source %q, must be one of %v", c.Source, sources)
+	}
+	if c.MaxPointSize < 0 {
+		return errors.New("max-point-size cannot be negative")
+	}
+	if c.MaxFieldLength < 0 {
+		return errors.New("max-field-length cannot be negative")
+	}
+	if c.MaxTagLength < 0 {
+		return errors.New("max-tag-length cannot be negative")
+	}
+	if c.MaxPointSize == 0 && c.MaxFieldLength == 0 && c.MaxTagLength == 0 {
+		return errors.New("must set at least one of max-point-size, max-field-length or max-tag-length")
+	}
+	if c.SampleInterval < 0 {
+		return errors.New("sample-interval cannot be negative")
+	}
+	return nil
+}
+
+func validSource(source string) bool {
+	for _, s := range sources {
+		if s == source {
+			return true
+		}
+	}
+	return false
+}
+
+// Configs is the set of point limits, at most one per source.
+type Configs []Config
+
+func (cs Configs) Validate() error {
+	seen := make(map[string]bool, len(cs))
+	for _, c := range cs {
+		if err := c.Validate(); err != nil {
+			return err
+		}
+		if seen[c.Source] {
+			return fmt.Errorf("duplicate point-limit for source %q", c.Source)
+		}
+		seen[c.Source] = true
+	}
+	return nil
+}
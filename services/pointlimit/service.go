@@ -0,0 +1,200 @@
+// Package pointlimit rejects oversized points written by the input sources,
+// protecting the tasks from pathological inputs like megabyte long string values.
+package pointlimit
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/models"
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/server/vars"
+)
+
+// statistics gathered by the pointlimit package.
+const (
+	statPointsRejected = "points_rejected"
+)
+
+// maxSampleLength is the maximum length of the logged sample of a rejected point.
+const maxSampleLength = 256
+
+type Diagnostic interface {
+	// RejectedPoints logs a sample of the rejected points and the number of points rejected since the last sample.
+	RejectedPoints(source, database, retentionPolicy, reason, sample string, rejected int64)
+}
+
+type PointsWriter interface {
+	WritePoints(database, retentionPolicy string, consistencyLevel models.ConsistencyLevel, points []models.Point) error
+}
+
+// Service limits the size of the points written by the sources.
+type Service struct {
+	configs map[string]Config
+	diag    Diagnostic
+
+	mu       sync.Mutex
+	limiters map[string]*limiter
+}
+
+func NewService(c Configs, d Diagnostic) *Service {
+	configs := make(map[string]Config, len(c))
+	for _, config := range c {
+		configs[config.Source] = config.WithDefaults()
+	}
+	return &Service{
+		configs:  configs,
+		diag:     d,
+		limiters: make(map[string]*limiter),
+	}
+}
+
+func (s *Service) Open() error {
+	return nil
+}
+
+func (s *Service) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, l := range s.limiters {
+		vars.DeleteStatistic(l.statKey)
+	}
+	s.limiters = make(map[string]*limiter)
+	return nil
+}
+
+// PointsWriter returns a PointsWriter that writes the points of the source to w,
+// dropping the points that exceed the limits of the source.
+// If the source has no limits w is returned.
+func (s *Service) PointsWriter(source string, w PointsWriter) PointsWriter {
+	c, ok := s.configs[source]
+	if !ok {
+		c, ok = s.configs[""]
+	}
+	if !ok {
+		return w
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l, ok := s.limiters[source]
+	if !ok {
+		l = newLimiter(source, c, s.diag)
+		s.limiters[source] = l
+	}
+	return &limitedWriter{l: l, w: w}
+}
+
+// limiter checks the points of a source and samples the rejected points.
+type limiter struct {
+	source string
+	c      Config
+	diag   Diagnostic
+
+	statKey string
+	statMap *expvar.Map
+
+	mu         sync.Mutex
+	lastSample time.Time
+	unsampled  int64
+
+	now func() time.Time
+}
+
+func newLimiter(source string, c Config, d Diagnostic) *limiter {
+	statKey, statMap := vars.NewStatistic("point_limit", map[string]string{"source": source})
+	return &limiter{
+		source:  source,
+		c:       c,
+		diag:    d,
+		statKey: statKey,
+		statMap: statMap,
+		now:     time.Now,
+	}
+}
+
+// check returns the reason the point exceeds the limits, or an empty string if it does not.
+func (l *limiter) check(p models.Point) string {
+	if l.c.MaxTagLength > 0 {
+		for _, t := range p.Tags() {
+			if len(t.Key) > l.c.MaxTagLength {
+				return fmt.Sprintf("tag key longer than %d bytes", l.c.MaxTagLength)
+			}
+			if len(t.Value) > l.c.MaxTagLength {
+				return fmt.Sprintf("value of tag %q longer than %d bytes", t.Key, l.c.MaxTagLength)
+			}
+		}
+	}
+	if l.c.MaxFieldLength > 0 {
+		iter := p.FieldIterator()
+		for iter.Next() {
+			if len(iter.FieldKey()) > l.c.MaxFieldLength {
+				return fmt.Sprintf("field key longer than %d bytes", l.c.MaxFieldLength)
+			}
+			if iter.Type() == models.String && len(iter.StringValue()) > l.c.MaxFieldLength {
+				return fmt.Sprintf("value of field %q longer than %d bytes", iter.FieldKey(), l.c.MaxFieldLength)
+			}
+		}
+	}
+	if l.c.MaxPointSize > 0 {
+		if size := p.StringSize(); size > l.c.MaxPointSize {
+			return fmt.Sprintf("point size %d exceeds %d bytes", size, l.c.MaxPointSize)
+		}
+	}
+	return ""
+}
+
+// reject counts a rejected point and logs it if no point was sampled within the sample interval.
+func (l *limiter) reject(database, retentionPolicy, reason string, p models.Point) {
+	l.statMap.Add(statPointsRejected, 1)
+
+	l.mu.Lock()
+	l.unsampled++
+	now := l.now()
+	if !l.lastSample.IsZero() && now.Sub(l.lastSample) < time.Duration(l.c.SampleInterval) {
+		l.mu.Unlock()
+		return
+	}
+	rejected := l.unsampled
+	l.unsampled = 0
+	l.lastSample = now
+	l.mu.Unlock()
+
+	sample := p.String()
+	if len(sample) > maxSampleLength {
+		sample = sample[:maxSampleLength] + "..."
+	}
+	l.diag.RejectedPoints(l.source, database, retentionPolicy, reason, sample, rejected)
+}
+
+// limitedWriter writes the points within the limits of a limiter.
+type limitedWriter struct {
+	l *limiter
+	w PointsWriter
+}
+
+func (w *limitedWriter) WritePoints(database, retentionPolicy string, consistencyLevel models.ConsistencyLevel, points []models.Point) error {
+	// The points are only copied once a point is rejected.
+	var accepted []models.Point
+	for i, p := range points {
+		reason := w.l.check(p)
+		if reason == "" {
+			if accepted != nil {
+				accepted = append(accepted, p)
+			}
+			continue
+		}
+		if accepted == nil {
+			accepted = make([]models.Point, i, len(points))
+			copy(accepted, points[:i])
+		}
+		w.l.reject(database, retentionPolicy, reason, p)
+	}
+	if accepted != nil {
+		points = accepted
+	}
+	if len(points) == 0 {
+		return nil
+	}
+	return w.w.WritePoints(database, retentionPolicy, consistencyLevel, points)
+}
@@ -0,0 +1,212 @@
+package pointlimit_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/influxdata/influxdb/models"
+	"github.com/influxdata/kapacitor/services/pointlimit"
+)
+
+type pointsWriter struct {
+	points []models.Point
+}
+
+func (w *pointsWriter) WritePoints(database, retentionPolicy string, consistencyLevel models.ConsistencyLevel, points []models.Point) error {
+	w.points = append(w.points, points...)
+	return nil
+}
+
+type rejection struct {
+	source, reason, sample string
+	rejected               int64
+}
+
+type diag struct {
+	rejections []rejection
+}
+
+func (d *diag) RejectedPoints(source, database, retentionPolicy, reason, sample string, rejected int64) {
+	d.rejections = append(d.rejections, rejection{source: source, reason: reason, sample: sample, rejected: rejected})
+}
+
+func parsePoints(t *testing.T, lines ...string) []models.Point {
+	t.Helper()
+	points, err := models.ParsePointsString(strings.Join(lines, "\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return points
+}
+
+func TestService_PointsWriter(t *testing.T) {
+	long := strings.Repeat("x", 100)
+	testCases := []struct {
+		name   string
+		config pointlimit.Config
+		lines  []string
+		// indexes of the written points
+		exp    []int
+		reason string
+	}{
+		{
+			name:   "field value",
+			config: pointlimit.Config{Source: pointlimit.SourceHTTP, MaxFieldLength: 50},
+			lines: []string{
+				`cpu,host=a value=1,msg="ok" 0`,
+				`cpu,host=a value=1,msg="` + long + `" 0`,
+				`cpu,host=b value=2 0`,
+			},
+			exp:    []int{0, 2},
+			reason: `value of field "msg" longer than 50 bytes`,
+		},
+		{
+			name:   "numeric fields are not limited",
+			config: pointlimit.Config{Source: pointlimit.SourceHTTP, MaxFieldLength: 5},
+			lines:  []string{`cpu value=123456789 0`},
+			exp:    []int{0},
+		},
+		{
+			name:   "field key",
+			config: pointlimit.Config{Source: pointlimit.SourceHTTP, MaxFieldLength: 50},
+			lines:  []string{`cpu ` + long + `=1 0`},
+			reason: "field key longer than 50 bytes",
+		},
+		{
+			name:   "tag value",
+			config: pointlimit.Config{Source: pointlimit.SourceHTTP, MaxTagLength: 50},
+			lines: []string{
+				`cpu,host=` + long + ` value=1 0`,
+				`cpu,host=a value=1 0`,
+			},
+			exp:    []int{1},
+			reason: `value of tag "host" longer than 50 bytes`,
+		},
+		{
+			name:   "point size",
+			config: pointlimit.Config{Source: pointlimit.SourceHTTP, MaxPointSize: 64},
+			lines: []string{
+				`cpu,host=a value=1 0`,
+				`cpu,host=a msg="` + long + `" 0`,
+			},
+			exp:    []int{0},
+			reason: "exceeds 64 bytes",
+		},
+		{
+			name:   "default limit",
+			config: pointlimit.Config{MaxFieldLength: 50},
+			lines:  []string{`cpu msg="` + long + `" 0`},
+			reason: `value of field "msg" longer than 50 bytes`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := new(diag)
+			s := pointlimit.NewService(pointlimit.Configs{tc.config}, d)
+			defer s.Close()
+			pw := new(pointsWriter)
+			w := s.PointsWriter(pointlimit.SourceHTTP, pw)
+
+			points := parsePoints(t, tc.lines...)
+			if err := w.WritePoints("db", "rp", models.ConsistencyLevelAny, points); err != nil {
+				t.Fatal(err)
+			}
+			if got, exp := len(pw.points), len(tc.exp); got != exp {
+				t.Fatalf("unexpected number of written points: got %d exp %d", got, exp)
+			}
+			for i, j := range tc.exp {
+				if pw.points[i] != points[j] {
+					t.Errorf("unexpected written point %d: got %s exp %s", i, pw.points[i], points[j])
+				}
+			}
+			rejected := len(points) - len(tc.exp)
+			if rejected == 0 {
+				if len(d.rejections) != 0 {
+					t.Errorf("unexpected rejections: %v", d.rejections)
+				}
+				return
+			}
+			if len(d.rejections) != 1 {
+				t.Fatalf("expected one sample of the rejected points, got %v", d.rejections)
+			}
+			r := d.rejections[0]
+			if r.source != pointlimit.SourceHTTP || r.rejected != 1 || !strings.Contains(r.reason, tc.reason) {
+				t.Errorf("unexpected rejection: got %+v exp reason %q", r, tc.reason)
+			}
+		})
+	}
+}
+
+func TestService_SampleRejectedPoints(t *testing.T) {
+	d := new(diag)
+	s := pointlimit.NewService(pointlimit.Configs{{Source: pointlimit.SourceUDP, MaxFieldLength: 1000}}, d)
+	defer s.Close()
+	pw := new(pointsWriter)
+	w := s.PointsWriter(pointlimit.SourceUDP, pw)
+
+	huge := `cpu msg="` + strings.Repeat("x", 1<<20) + `" 0`
+	for i := 0; i < 3; i++ {
+		if err := w.WritePoints("db", "rp", models.ConsistencyLevelAny, parsePoints(t, huge, huge)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(pw.points) != 0 {
+		t.Errorf("expected all points to be rejected, got %d", len(pw.points))
+	}
+	// Only the first rejection is logged within the sample interval.
+	if len(d.rejections) != 1 {
+		t.Fatalf("unexpected number of samples: got %d exp 1", len(d.rejections))
+	}
+	if got, max := len(d.rejections[0].sample), 260; got > max {
+		t.Errorf("sample is not truncated: got %d bytes exp at most %d", got, max)
+	}
+}
+
+func TestService_PointsWriterWithoutLimits(t *testing.T) {
+	s := pointlimit.NewService(pointlimit.Configs{{Source: pointlimit.SourceUDP, MaxPointSize: 10}}, new(diag))
+	defer s.Close()
+	pw := new(pointsWriter)
+	if w := s.PointsWriter(pointlimit.SourceHTTP, pw); w != pw {
+		t.Errorf("expected points writer of source without limits to be unchanged, got %T", w)
+	}
+}
+
+func TestConfigs_Validate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		configs pointlimit.Configs
+		wantErr bool
+	}{
+		{
+			name:    "valid",
+			configs: pointlimit.Configs{{Source: "http", MaxPointSize: 1024}, {MaxFieldLength: 64}},
+		},
+		{
+			name:    "unknown source",
+			configs: pointlimit.Configs{{Source: "kafka", MaxPointSize: 1024}},
+			wantErr: true,
+		},
+		{
+			name:    "no limits",
+			configs: pointlimit.Configs{{Source: "http"}},
+			wantErr: true,
+		},
+		{
+			name:    "negative limit",
+			configs: pointlimit.Configs{{Source: "http", MaxTagLength: -1}},
+			wantErr: true,
+		},
+		{
+			name:    "duplicate source",
+			configs: pointlimit.Configs{{Source: "udp", MaxPointSize: 1}, {Source: "udp", MaxTagLength: 1}},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.configs.Validate(); (err != nil) != tc.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
@@ -61,6 +61,13 @@ func (w *Workspace) Client() *http.Client {
 	return w.client
 }
 
+// snapshot returns the config and client of the workspace as a consistent pair.
+func (w *Workspace) snapshot() (Config, *http.Client) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.config, w.client
+}
+
 func (w *Workspace) Update(c Config) error {
 	tlsConfig, err := tlsconfig.Create(c.SSLCA, c.SSLCert, c.SSLKey, c.InsecureSkipVerify)
 	if err != nil {
@@ -74,6 +81,8 @@ func (w *Workspace) Update(c Config) error {
 		},
 	}
 
+	w.mu.Lock()
+	defer w.mu.Unlock()
 	w.client = cl
 	w.config = c
 
@@ -154,14 +163,6 @@ func (s *Service) defaultConfig() Config {
 	return conf
 }
 
-func (s *Service) client(wid string) (*http.Client, error) {
-	w, err := s.workspace(wid)
-	if err != nil {
-		return &http.Client{}, err
-	}
-	return w.Client(), nil
-}
-
 func (s *Service) Update(newConfigs []interface{}) error {
 
 	s.mu.Lock()
@@ -240,12 +241,15 @@ func (s *Service) Test(options interface{}) error {
 }
 
 func (s *Service) Alert(workspace, channel, message, username, iconEmoji string, level alert.Level) error {
-	url, post, err := s.preparePost(workspace, channel, message, username, iconEmoji, level)
+	w, err := s.workspace(workspace)
 	if err != nil {
 		return err
 	}
+	// Use a single snapshot of the workspace for the entire request,
+	// so that a concurrent configuration update cannot mix old and new settings.
+	c, client := w.snapshot()
 
-	client, err := s.client(workspace)
+	url, post, err := s.preparePost(c, channel, message, username, iconEmoji, level)
 	if err != nil {
 		return err
 	}
@@ -272,11 +276,7 @@ func (s *Service) Alert(workspace, channel, message, username, iconEmoji string,
 	return nil
 }
 
-func (s *Service) preparePost(workspace, channel, message, username, iconEmoji string, level alert.Level) (string, io.Reader, error) {
-	c, err := s.config(workspace)
-	if err != nil {
-		return "", nil, err
-	}
+func (s *Service) preparePost(c Config, channel, message, username, iconEmoji string, level alert.Level) (string, io.Reader, error) {
 	if !c.Enabled {
 		return "", nil, errors.New("service is not enabled")
 	}
@@ -316,7 +316,7 @@ func (s *Service) preparePost(workspace, channel, message, username, iconEmoji s
 
 	var post bytes.Buffer
 	enc := json.NewEncoder(&post)
-	err = enc.Encode(postData)
+	err := enc.Encode(postData)
 	if err != nil {
 		return "", nil, err
 	}
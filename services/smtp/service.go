@@ -1,15 +1,23 @@
 package smtp
 
 import (
+	"bytes"
 	"crypto/tls"
+	"encoding/csv"
 	"errors"
 	"fmt"
+	html "html/template"
+	"io"
+	"sort"
+	"strings"
 	"sync"
 	"sync/atomic"
+	text "text/template"
 	"time"
 
 	"github.com/influxdata/kapacitor/alert"
 	"github.com/influxdata/kapacitor/keyvalue"
+	"github.com/influxdata/kapacitor/models"
 
 	"gopkg.in/gomail.v2"
 )
@@ -176,8 +184,36 @@ func (s *Service) runMailer() {
 	}
 }
 
+// Mail is an HTML email with an optional plain text alternative and attachments.
+type Mail struct {
+	// List of recipients, if empty the recipients from the configuration are used.
+	To      []string
+	Subject string
+	// HTML body of the email.
+	Body string
+	// Plain text alternative of the body.
+	// If not empty the email is sent as multipart/alternative.
+	Text        string
+	Attachments []Attachment
+}
+
+// Attachment is a file attached to an email.
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
 func (s *Service) SendMail(to []string, subject, body string) error {
-	m, err := s.prepareMessge(to, subject, body)
+	return s.Send(Mail{
+		To:      to,
+		Subject: subject,
+		Body:    body,
+	})
+}
+
+func (s *Service) Send(mail Mail) error {
+	m, err := s.prepareMessge(mail)
 	if err != nil {
 		return err
 	}
@@ -185,11 +221,12 @@ func (s *Service) SendMail(to []string, subject, body string) error {
 	return nil
 }
 
-func (s *Service) prepareMessge(to []string, subject, body string) (*gomail.Message, error) {
+func (s *Service) prepareMessge(mail Mail) (*gomail.Message, error) {
 	c := s.config()
 	if !c.Enabled {
 		return nil, errors.New("service is not enabled")
 	}
+	to := mail.To
 	if len(to) == 0 {
 		to = c.To
 	}
@@ -199,8 +236,25 @@ func (s *Service) prepareMessge(to []string, subject, body string) (*gomail.Mess
 	m := gomail.NewMessage()
 	m.SetHeader("From", c.From)
 	m.SetHeader("To", to...)
-	m.SetHeader("Subject", subject)
-	m.SetBody("text/html", body)
+	m.SetHeader("Subject", mail.Subject)
+	if mail.Text != "" {
+		// The last alternative is the preferred one.
+		m.SetBody("text/plain", mail.Text)
+		m.AddAlternative("text/html", mail.Body)
+	} else {
+		m.SetBody("text/html", mail.Body)
+	}
+	for _, a := range mail.Attachments {
+		data := a.Data
+		m.Attach(
+			a.Filename,
+			gomail.SetHeader(map[string][]string{"Content-Type": {a.ContentType}}),
+			gomail.SetCopyFunc(func(w io.Writer) error {
+				_, err := w.Write(data)
+				return err
+			}),
+		)
+	}
 	return m, nil
 }
 
@@ -231,31 +285,193 @@ func (s *Service) Test(options interface{}) error {
 	)
 }
 
+// DataFilename is the name of the file the alert data is attached as.
+const DataFilename = "data.csv"
+
 type HandlerConfig struct {
 	// List of email recipients.
 	To []string `mapstructure:"to"`
+
+	// Name of a tag holding a comma separated list of recipients.
+	// When the tag of the alert data is not empty its recipients are used instead of To.
+	ToTag string `mapstructure:"to-tag"`
+
+	// Template of the HTML body, if empty the details of the alert are the body.
+	// The template is executed with the alert template data.
+	Body string `mapstructure:"body"`
+
+	// Template of the plain text alternative of the body.
+	// If not empty the email is sent as multipart/alternative with a plain text and an HTML part.
+	Text string `mapstructure:"text"`
+
+	// Attach the data of the alert as a CSV file.
+	AttachData bool `mapstructure:"attach-data"`
 }
 
 type handler struct {
 	s    *Service
 	c    HandlerConfig
 	diag Diagnostic
+
+	bodyTmpl *html.Template
+	textTmpl *text.Template
 }
 
-func (s *Service) Handler(c HandlerConfig, ctx ...keyvalue.T) alert.Handler {
-	return &handler{
+func (s *Service) Handler(c HandlerConfig, ctx ...keyvalue.T) (alert.Handler, error) {
+	h := &handler{
 		s:    s,
 		c:    c,
 		diag: s.diag.WithContext(ctx...),
 	}
+	if c.Body != "" {
+		t, err := html.New("body").Parse(c.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse body template: %v", err)
+		}
+		h.bodyTmpl = t
+	}
+	if c.Text != "" {
+		t, err := text.New("text").Parse(c.Text)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse text template: %v", err)
+		}
+		h.textTmpl = t
+	}
+	return h, nil
 }
 
 func (h *handler) Handle(event alert.Event) {
-	if err := h.s.SendMail(
-		h.c.To,
-		event.State.Message,
-		event.State.Details,
-	); err != nil {
+	mail, err := h.prepareMail(event)
+	if err != nil {
+		h.diag.Error("failed to prepare email", err)
+		return
+	}
+	if err := h.s.Send(mail); err != nil {
 		h.diag.Error("failed to send email", err)
 	}
 }
+
+func (h *handler) prepareMail(event alert.Event) (Mail, error) {
+	mail := Mail{
+		To:      h.c.To,
+		Subject: event.State.Message,
+		Body:    event.State.Details,
+	}
+	if h.c.ToTag != "" {
+		if to := splitRecipients(event.Data.Tags[h.c.ToTag]); len(to) > 0 {
+			mail.To = to
+		}
+	}
+	td := event.TemplateData()
+	var buf bytes.Buffer
+	if h.bodyTmpl != nil {
+		if err := h.bodyTmpl.Execute(&buf, td); err != nil {
+			return Mail{}, fmt.Errorf("failed to execute body template: %v", err)
+		}
+		mail.Body = buf.String()
+		buf.Reset()
+	}
+	if h.textTmpl != nil {
+		if err := h.textTmpl.Execute(&buf, td); err != nil {
+			return Mail{}, fmt.Errorf("failed to execute text template: %v", err)
+		}
+		mail.Text = buf.String()
+		buf.Reset()
+	}
+	if h.c.AttachData {
+		data, err := dataCSV(event.Data.Result)
+		if err != nil {
+			return Mail{}, err
+		}
+		mail.Attachments = append(mail.Attachments, Attachment{
+			Filename:    DataFilename,
+			ContentType: "text/csv",
+			Data:        data,
+		})
+	}
+	return mail, nil
+}
+
+// splitRecipients splits a comma separated list of recipients, ignoring empty entries.
+func splitRecipients(list string) []string {
+	var to []string
+	for _, r := range strings.Split(list, ",") {
+		if r = strings.TrimSpace(r); r != "" {
+			to = append(to, r)
+		}
+	}
+	return to
+}
+
+// dataCSV renders the points of the result as CSV.
+// Each row has the time, the tags and the fields of a point.
+// A header row precedes the points of each series with columns different from the previous series.
+func dataCSV(result models.Result) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	var header []string
+	for _, s := range result.Series {
+		tagKeys := make([]string, 0, len(s.Tags))
+		for k := range s.Tags {
+			tagKeys = append(tagKeys, k)
+		}
+		sort.Strings(tagKeys)
+
+		// The first column is the time.
+		columns := s.Columns
+		if len(columns) > 0 {
+			columns = columns[1:]
+		}
+		h := append(append([]string{"time"}, tagKeys...), columns...)
+		if !equalStrings(h, header) {
+			header = h
+			if err := w.Write(header); err != nil {
+				return nil, err
+			}
+		}
+		for _, values := range s.Values {
+			if len(values) == 0 {
+				continue
+			}
+			row := make([]string, 0, len(header))
+			row = append(row, csvValue(values[0]))
+			for _, k := range tagKeys {
+				row = append(row, s.Tags[k])
+			}
+			for _, v := range values[1:] {
+				row = append(row, csvValue(v))
+			}
+			if err := w.Write(row); err != nil {
+				return nil, err
+			}
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func csvValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
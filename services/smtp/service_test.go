@@ -0,0 +1,206 @@
+package smtp
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/alert"
+	"github.com/influxdata/kapacitor/keyvalue"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/services/smtp/smtptest"
+)
+
+type diag struct{}
+
+func (d diag) WithContext(ctx ...keyvalue.T) Diagnostic { return d }
+func (diag) Error(msg string, err error)                {}
+
+// part is a decoded part of a MIME message.
+type part struct {
+	contentType string
+	filename    string
+	body        string
+}
+
+// readParts returns the decoded parts of a multipart body.
+func readParts(t *testing.T, contentType, body string) []part {
+	t.Helper()
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		t.Fatalf("unexpected content type %q, expected multipart", mediaType)
+	}
+	var parts []part
+	r := multipart.NewReader(strings.NewReader(body), params["boundary"])
+	for {
+		p, err := r.NextPart()
+		if err != nil {
+			break
+		}
+		data, err := ioutil.ReadAll(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.Header.Get("Content-Transfer-Encoding") == "base64" {
+			data, err = base64.StdEncoding.DecodeString(strings.Replace(string(data), "\r\n", "", -1))
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		parts = append(parts, part{
+			contentType: p.Header.Get("Content-Type"),
+			filename:    p.FileName(),
+			body:        string(data),
+		})
+	}
+	return parts
+}
+
+func TestHandler_Multipart(t *testing.T) {
+	ts, err := smtptest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Close()
+	s := NewService(Config{
+		Enabled: true,
+		Host:    ts.Host,
+		Port:    ts.Port,
+		From:    "kapacitor@example.com",
+	}, diag{})
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	h, err := s.Handler(HandlerConfig{
+		To:         []string{"oncall@example.com"},
+		ToTag:      "owners",
+		Body:       `<h1>{{ .ID }}</h1><p>{{ index .Tags "host" }}</p>`,
+		Text:       `{{ .ID }} is {{ .Level }}`,
+		AttachData: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t0 := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	h.Handle(alert.Event{
+		State: alert.EventState{
+			ID:      "cpu:host=<a>",
+			Message: "cpu:host=<a> is CRITICAL",
+			Level:   alert.Critical,
+		},
+		Data: alert.EventData{
+			Tags: map[string]string{
+				"host":   "<a>",
+				"owners": "alice@example.com, bob@example.com,",
+			},
+			Result: models.Result{
+				Series: models.Rows{{
+					Name:    "cpu",
+					Tags:    map[string]string{"host": "<a>", "cpu": "0"},
+					Columns: []string{"time", "value"},
+					Values: [][]interface{}{
+						{t0, 90.5},
+						{t0.Add(time.Second), nil},
+					},
+				}},
+			},
+		},
+	})
+
+	s.Close()
+	ts.Close()
+	if errs := ts.Errors(); len(errs) != 0 {
+		t.Fatalf("unexpected smtp server errors: %v", errs)
+	}
+	msgs := ts.SentMessages()
+	if got, exp := len(msgs), 1; got != exp {
+		t.Fatalf("unexpected number of messages sent: got %d exp %d", got, exp)
+	}
+	m := msgs[0]
+	if got, exp := m.Header.Get("To"), "alice@example.com, bob@example.com"; got != exp {
+		t.Errorf("unexpected recipients from tag: got %q exp %q", got, exp)
+	}
+
+	// The alternative bodies and the attachment are parts of a mixed message.
+	mixed := readParts(t, m.Header.Get("Content-Type"), m.Body)
+	if got, exp := len(mixed), 2; got != exp {
+		t.Fatalf("unexpected number of parts: got %d exp %d", got, exp)
+	}
+	alternatives := readParts(t, mixed[0].contentType, mixed[0].body)
+	if got, exp := len(alternatives), 2; got != exp {
+		t.Fatalf("unexpected number of alternatives: got %d exp %d", got, exp)
+	}
+	expAlternatives := []part{
+		{contentType: "text/plain; charset=UTF-8", body: "cpu:host=<a> is CRITICAL"},
+		{contentType: "text/html; charset=UTF-8", body: "<h1>cpu:host=&lt;a&gt;</h1><p>&lt;a&gt;</p>"},
+	}
+	for i, exp := range expAlternatives {
+		if got := alternatives[i]; got != exp {
+			t.Errorf("unexpected alternative %d:\ngot\n%+v\nexp\n%+v", i, got, exp)
+		}
+	}
+
+	expAttachment := part{
+		contentType: "text/csv",
+		filename:    DataFilename,
+		body: "time,cpu,host,value\n" +
+			"2017-01-01T00:00:00Z,0,<a>,90.5\n" +
+			"2017-01-01T00:00:01Z,0,<a>,\n",
+	}
+	if got := mixed[1]; got != expAttachment {
+		t.Errorf("unexpected attachment:\ngot\n%+v\nexp\n%+v", got, expAttachment)
+	}
+}
+
+func TestHandler_HTMLOnly(t *testing.T) {
+	s := NewService(Config{
+		Enabled: true,
+		From:    "kapacitor@example.com",
+		To:      []string{"oncall@example.com"},
+	}, diag{})
+	h, err := s.Handler(HandlerConfig{ToTag: "owners"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mail, err := h.(*handler).prepareMail(alert.Event{
+		State: alert.EventState{
+			Message: "subject",
+			Details: "<b>details</b>",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := s.prepareMessge(mail)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := m.GetHeader("To"), []string{"oncall@example.com"}; len(got) != 1 || got[0] != exp[0] {
+		t.Errorf("unexpected recipients without tag: got %v exp %v", got, exp)
+	}
+	if len(mail.Attachments) != 0 || mail.Text != "" {
+		t.Errorf("unexpected multipart mail %+v", mail)
+	}
+	if got, exp := mail.Body, "<b>details</b>"; got != exp {
+		t.Errorf("unexpected body: got %q exp %q", got, exp)
+	}
+}
+
+func TestService_Handler_InvalidTemplate(t *testing.T) {
+	s := NewService(Config{}, diag{})
+	if _, err := s.Handler(HandlerConfig{Body: "{{ .ID "}); err == nil {
+		t.Error("expected error for invalid body template")
+	}
+	if _, err := s.Handler(HandlerConfig{Text: "{{ .ID "}); err == nil {
+		t.Error("expected error for invalid text template")
+	}
+}
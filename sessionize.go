@@ -0,0 +1,205 @@
+package kapacitor
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+const (
+	statsSessions = "sessions"
+)
+
+type SessionizeNode struct {
+	node
+	s *pipeline.SessionizeNode
+
+	sessions *expvar.Int
+}
+
+// Create a new SessionizeNode which splits the points of each group into sessions separated by gaps of inactivity.
+func newSessionizeNode(et *ExecutingTask, n *pipeline.SessionizeNode, d NodeDiagnostic) (*SessionizeNode, error) {
+	sn := &SessionizeNode{
+		node:     node{Node: n, et: et, diag: d},
+		s:        n,
+		sessions: new(expvar.Int),
+	}
+	sn.node.runF = sn.runSessionize
+	return sn, nil
+}
+
+func (n *SessionizeNode) runSessionize([]byte) error {
+	n.statMap.Set(statsSessions, n.sessions)
+	consumer := edge.NewGroupedConsumer(
+		n.ins[0],
+		n,
+	)
+	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
+	return consumer.Consume()
+}
+
+func (n *SessionizeNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	return edge.NewReceiverFromForwardReceiverWithStats(
+		n.outs,
+		edge.NewTimedForwardReceiver(n.timer, n.newGroup(group)),
+	), nil
+}
+
+func (n *SessionizeNode) newGroup(group edge.GroupInfo) *sessionizeGroup {
+	return &sessionizeGroup{
+		n:     n,
+		group: group,
+	}
+}
+
+type sessionizeGroup struct {
+	n     *SessionizeNode
+	group edge.GroupInfo
+
+	// mu protects the session from the idle timer.
+	mu sync.Mutex
+	// stopped indicates no more summaries are emitted, the group was deleted or the node is done.
+	stopped bool
+	idle    *time.Timer
+
+	// ID of the current session, zero before the first session.
+	id   int64
+	open bool
+	// database and retention policy of the points of the session
+	database   string
+	retention  string
+	start, end time.Time
+	count      int64
+}
+
+func (g *sessionizeGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	return begin, nil
+}
+
+func (g *sessionizeGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	return bp, nil
+}
+
+func (g *sessionizeGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+// Point adds the point to the current session, first closing the session if the point is beyond its timeout,
+// and tags the point with the ID of its session.
+func (g *sessionizeGroup) Point(p edge.PointMessage) (edge.Message, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	t := p.Time()
+	if g.open && t.Sub(g.end) > g.n.s.Timeout {
+		if err := g.closeSession(); err != nil {
+			return nil, err
+		}
+	}
+	if !g.open {
+		g.id++
+		g.open = true
+		g.database = p.Database()
+		g.retention = p.RetentionPolicy()
+		g.start = t
+		g.end = t
+		g.count = 0
+	}
+	if t.After(g.end) {
+		g.end = t
+	}
+	g.count++
+	g.resetIdle()
+
+	p = p.ShallowCopy()
+	tags := p.Tags().Copy()
+	tags[g.n.s.Tag] = strconv.FormatInt(g.id, 10)
+	p.SetTags(tags)
+	return p, nil
+}
+
+// Barrier closes the current session if the barrier is beyond its timeout.
+func (g *sessionizeGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.open && b.Time().Sub(g.end) > g.n.s.Timeout {
+		if err := g.closeSession(); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// DeleteGroup closes the current session before the group is deleted.
+func (g *sessionizeGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.stop()
+	if g.open {
+		if err := g.closeSession(); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+func (g *sessionizeGroup) Done() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.stop()
+}
+
+// stop stops the idle timer, must be called with the lock held.
+func (g *sessionizeGroup) stop() {
+	g.stopped = true
+	if g.idle != nil {
+		g.idle.Stop()
+	}
+}
+
+// resetIdle restarts the idle timer, must be called with the lock held.
+func (g *sessionizeGroup) resetIdle() {
+	if g.idle == nil {
+		g.idle = time.AfterFunc(g.n.s.Timeout, g.idleTimeout)
+		return
+	}
+	g.idle.Reset(g.n.s.Timeout)
+}
+
+// idleTimeout closes the current session once the group has received no points for the timeout.
+func (g *sessionizeGroup) idleTimeout() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.stopped || !g.open {
+		return
+	}
+	if err := g.closeSession(); err != nil {
+		g.n.diag.Error("failed to emit session summary", err)
+	}
+}
+
+// closeSession emits the summary of the current session, must be called with the lock held.
+func (g *sessionizeGroup) closeSession() error {
+	g.open = false
+	g.n.sessions.Add(1)
+	tags := g.group.Tags.Copy()
+	tags[g.n.s.Tag] = strconv.FormatInt(g.id, 10)
+	summary := edge.NewPointMessage(
+		g.n.s.SummaryName,
+		g.database,
+		g.retention,
+		g.group.Dimensions,
+		models.Fields{
+			"start": g.start.UnixNano(),
+			"end":   g.end.UnixNano(),
+			"count": g.count,
+		},
+		tags,
+		g.end,
+	)
+	return edge.Forward(g.n.outs, summary)
+}
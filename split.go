@@ -0,0 +1,201 @@
+package kapacitor
+
+import (
+	"fmt"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+	"github.com/influxdata/kapacitor/tick/stateful"
+)
+
+const (
+	statsSplitUnmatched = "unmatched"
+)
+
+type SplitNode struct {
+	node
+	s *pipeline.SplitNode
+
+	expressions []stateful.Expression
+	scopePools  []stateful.ScopePool
+
+	// branchOuts maps each case index to its output edges.
+	// The last entry contains the output edges of the default branch.
+	branchOuts [][]edge.StatsEdge
+
+	unmatched *expvar.Int
+}
+
+// Create a new SplitNode which routes each point to the first branch whose predicate matches.
+func newSplitNode(et *ExecutingTask, n *pipeline.SplitNode, d NodeDiagnostic) (*SplitNode, error) {
+	sn := &SplitNode{
+		node:        node{Node: n, et: et, diag: d},
+		s:           n,
+		expressions: make([]stateful.Expression, len(n.Cases)),
+		scopePools:  make([]stateful.ScopePool, len(n.Cases)),
+		unmatched:   new(expvar.Int),
+	}
+	for i, c := range n.Cases {
+		expr, err := stateful.NewExpression(c.Lambda.Expression)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to compile expression for split case %q: %v", c.Name, err)
+		}
+		sn.expressions[i] = expr
+		sn.scopePools[i] = stateful.NewScopePool(ast.FindReferenceVariables(c.Lambda.Expression))
+	}
+	sn.node.runF = sn.runSplit
+	return sn, nil
+}
+
+func (n *SplitNode) runSplit([]byte) error {
+	n.statMap.Set(statsSplitUnmatched, n.unmatched)
+	n.branchOuts = n.mapBranchOuts()
+	consumer := edge.NewGroupedConsumer(
+		n.ins[0],
+		n,
+	)
+	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
+	return consumer.Consume()
+}
+
+// mapBranchOuts determines the output edges of each branch using the branch names of the children.
+func (n *SplitNode) mapBranchOuts() [][]edge.StatsEdge {
+	indexes := make(map[string]int, len(n.s.Cases)+1)
+	for i, c := range n.s.Cases {
+		indexes[c.Name] = i
+	}
+	if n.s.DefaultBranch != "" {
+		indexes[n.s.DefaultBranch] = len(n.s.Cases)
+	}
+	names := make(map[pipeline.ID]string)
+	for _, c := range n.s.Children() {
+		if b, ok := c.(*pipeline.SplitBranchNode); ok {
+			names[b.ID()] = b.BranchName
+		}
+	}
+	branchOuts := make([][]edge.StatsEdge, len(n.s.Cases)+1)
+	for i, child := range n.children {
+		name, ok := names[child.ID()]
+		if !ok {
+			continue
+		}
+		if idx, ok := indexes[name]; ok {
+			branchOuts[idx] = append(branchOuts[idx], n.outs[i])
+		}
+	}
+	return branchOuts
+}
+
+func (n *SplitNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	g := &splitGroup{
+		n:           n,
+		expressions: make([]stateful.Expression, len(n.expressions)),
+	}
+	for i, expr := range n.expressions {
+		g.expressions[i] = expr.CopyReset()
+	}
+	return g, nil
+}
+
+type splitGroup struct {
+	n           *SplitNode
+	expressions []stateful.Expression
+
+	begin  edge.BeginBatchMessage
+	points [][]edge.BatchPointMessage
+}
+
+// route returns the index of the branch for p, or -1 if the point should be dropped.
+func (g *splitGroup) route(p edge.FieldsTagsTimeGetter) int {
+	for i, expr := range g.expressions {
+		pass, err := EvalPredicate(expr, g.n.scopePools[i], p)
+		if err != nil {
+			g.n.diag.Error("error while evaluating expression", err)
+			continue
+		}
+		if pass {
+			return i
+		}
+	}
+	if g.n.s.DefaultBranch != "" {
+		return len(g.expressions)
+	}
+	g.n.unmatched.Add(1)
+	return -1
+}
+
+func (g *splitGroup) BeginBatch(begin edge.BeginBatchMessage) error {
+	g.n.timer.Start()
+	defer g.n.timer.Stop()
+	g.begin = begin.ShallowCopy()
+	g.points = make([][]edge.BatchPointMessage, len(g.n.branchOuts))
+	return nil
+}
+
+func (g *splitGroup) BatchPoint(bp edge.BatchPointMessage) error {
+	g.n.timer.Start()
+	defer g.n.timer.Stop()
+	if i := g.route(bp); i >= 0 {
+		g.points[i] = append(g.points[i], bp)
+	}
+	return nil
+}
+
+// EndBatch emits a batch to each branch containing only the points routed to that branch.
+func (g *splitGroup) EndBatch(end edge.EndBatchMessage) error {
+	for i, outs := range g.n.branchOuts {
+		if len(outs) == 0 {
+			continue
+		}
+		begin := g.begin.ShallowCopy()
+		begin.SetSizeHint(len(g.points[i]))
+		if err := edge.Forward(outs, edge.NewBufferedBatchMessage(begin, g.points[i], end)); err != nil {
+			return err
+		}
+	}
+	g.begin = nil
+	g.points = nil
+	return nil
+}
+
+func (g *splitGroup) Point(p edge.PointMessage) error {
+	g.n.timer.Start()
+	i := g.route(p)
+	g.n.timer.Stop()
+	if i < 0 {
+		return nil
+	}
+	return edge.Forward(g.n.branchOuts[i], p)
+}
+
+func (g *splitGroup) Barrier(b edge.BarrierMessage) error {
+	return edge.Forward(g.n.outs, b)
+}
+func (g *splitGroup) DeleteGroup(d edge.DeleteGroupMessage) error {
+	return edge.Forward(g.n.outs, d)
+}
+func (g *splitGroup) Done() {}
+
+type SplitBranchNode struct {
+	node
+}
+
+// Create a new SplitBranchNode which passes along all data routed to its branch.
+func newSplitBranchNode(et *ExecutingTask, n *pipeline.SplitBranchNode, d NodeDiagnostic) (*SplitBranchNode, error) {
+	bn := &SplitBranchNode{
+		node: node{Node: n, et: et, diag: d},
+	}
+	bn.node.runF = bn.runSplitBranch
+	return bn, nil
+}
+
+func (n *SplitBranchNode) runSplitBranch([]byte) error {
+	for m, ok := n.ins[0].Emit(); ok; m, ok = n.ins[0].Emit() {
+		if err := edge.Forward(n.outs, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
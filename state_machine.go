@@ -0,0 +1,242 @@
+package kapacitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+	"github.com/influxdata/kapacitor/tick/stateful"
+)
+
+const (
+	statsTransitions = "transitions"
+)
+
+// stateMachineGroupStateVersion is the version of the encoding of stateMachineGroupState.
+const stateMachineGroupStateVersion = 1
+
+type StateMachineNode struct {
+	node
+	s *pipeline.StateMachineNode
+
+	expressions []stateful.Expression
+	scopePools  []stateful.ScopePool
+	// fromState maps each state to the indexes of the transitions from it, in the order they are declared.
+	fromState map[string][]int
+
+	transitions *expvar.Int
+
+	// groupStates keeps the state of the groups for the task snapshots, if it is persisted.
+	groupStates *groupStates
+}
+
+// Create a new StateMachineNode which tracks the state of each group through the declared transitions.
+func newStateMachineNode(et *ExecutingTask, n *pipeline.StateMachineNode, d NodeDiagnostic) (*StateMachineNode, error) {
+	sn := &StateMachineNode{
+		node:        node{Node: n, et: et, diag: d},
+		s:           n,
+		expressions: make([]stateful.Expression, len(n.Transitions)),
+		scopePools:  make([]stateful.ScopePool, len(n.Transitions)),
+		fromState:   make(map[string][]int, len(n.DeclaredStates)),
+		transitions: new(expvar.Int),
+	}
+	for i, t := range n.Transitions {
+		expr, err := stateful.NewExpression(t.Lambda.Expression)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to compile expression for transition from %q to %q: %v", t.From, t.To, err)
+		}
+		sn.expressions[i] = expr
+		sn.scopePools[i] = stateful.NewScopePool(ast.FindReferenceVariables(t.Lambda.Expression))
+		sn.fromState[t.From] = append(sn.fromState[t.From], i)
+	}
+	if n.PersistStateFlag {
+		sn.groupStates = newGroupStates(stateMachineGroupStateVersion, d)
+	}
+	sn.node.runF = sn.runStateMachine
+	return sn, nil
+}
+
+func (n *StateMachineNode) runStateMachine(snapshot []byte) error {
+	n.statMap.Set(statsTransitions, n.transitions)
+	if n.groupStates != nil {
+		n.groupStates.load(snapshot)
+	}
+	consumer := edge.NewGroupedConsumer(
+		n.ins[0],
+		n,
+	)
+	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
+	return consumer.Consume()
+}
+
+// snapshot returns the state of the groups if it is persisted.
+func (n *StateMachineNode) snapshot() ([]byte, error) {
+	if n.groupStates == nil {
+		return nil, nil
+	}
+	return n.groupStates.snapshot()
+}
+
+func (n *StateMachineNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	g := n.newGroup()
+	var r edge.ForwardReceiver = g
+	if n.groupStates != nil {
+		r = n.groupStates.add(group.ID, g, g)
+	}
+	return edge.NewReceiverFromForwardReceiverWithStats(
+		n.outs,
+		edge.NewTimedForwardReceiver(n.timer, r),
+	), nil
+}
+
+func (n *StateMachineNode) newGroup() *stateMachineGroup {
+	g := &stateMachineGroup{
+		n:           n,
+		expressions: make([]stateful.Expression, len(n.expressions)),
+	}
+	for i, expr := range n.expressions {
+		g.expressions[i] = expr.CopyReset()
+	}
+	return g
+}
+
+type stateMachineGroup struct {
+	n           *StateMachineNode
+	expressions []stateful.Expression
+
+	// state is the current state of the group, empty until the first point of the group.
+	state string
+	// entered is the time the group entered the current state.
+	entered time.Time
+
+	// begin of the current batch and the transitions of the batch
+	begin  edge.BeginBatchMessage
+	points []edge.BatchPointMessage
+}
+
+// step moves the group to the state of the first transition from the current state matching p.
+// It returns the fields of the transition point, or nil if p causes no transition.
+func (g *stateMachineGroup) step(p edge.FieldsTagsTimeGetter) models.Fields {
+	t := p.Time()
+	if g.state == "" {
+		g.state = g.n.s.InitialState
+		g.entered = t
+	}
+	if t.Before(g.entered) {
+		return nil
+	}
+	for _, i := range g.n.fromState[g.state] {
+		pass, err := EvalPredicate(g.expressions[i], g.n.scopePools[i], p)
+		if err != nil {
+			g.n.diag.Error("error while evaluating expression", err)
+			continue
+		}
+		if !pass {
+			continue
+		}
+		fields := models.Fields{
+			pipeline.StateMachineFromField:  g.state,
+			pipeline.StateMachineToField:    g.n.s.Transitions[i].To,
+			pipeline.StateMachineDwellField: float64(t.Sub(g.entered)) / float64(g.n.s.Unit),
+		}
+		g.state = g.n.s.Transitions[i].To
+		g.entered = t
+		g.n.transitions.Add(1)
+		return fields
+	}
+	return nil
+}
+
+func (g *stateMachineGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	g.begin = begin
+	g.points = nil
+	return nil, nil
+}
+
+func (g *stateMachineGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	if fields := g.step(bp); fields != nil {
+		g.points = append(g.points, edge.NewBatchPointMessage(fields, bp.Tags(), bp.Time()))
+	}
+	return nil, nil
+}
+
+// EndBatch emits the transitions of the batch as a batch.
+func (g *stateMachineGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	points := g.points
+	g.points = nil
+	if len(points) == 0 {
+		return nil, nil
+	}
+	begin := g.begin.ShallowCopy()
+	begin.SetSizeHint(len(points))
+	return edge.NewBufferedBatchMessage(begin, points, end), nil
+}
+
+func (g *stateMachineGroup) Point(p edge.PointMessage) (edge.Message, error) {
+	fields := g.step(p)
+	if fields == nil {
+		return nil, nil
+	}
+	return edge.NewPointMessage(
+		p.Name(), p.Database(), p.RetentionPolicy(),
+		p.Dimensions(),
+		fields,
+		p.Tags(),
+		p.Time(),
+	), nil
+}
+
+func (g *stateMachineGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+
+func (g *stateMachineGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	g.state = ""
+	g.entered = time.Time{}
+	g.points = nil
+	return d, nil
+}
+
+func (g *stateMachineGroup) Done() {}
+
+// stateMachineGroupState is the persisted state of a state machine group.
+type stateMachineGroupState struct {
+	State   string    `json:"state"`
+	Entered time.Time `json:"entered"`
+}
+
+func (g *stateMachineGroup) snapshotState() ([]byte, error) {
+	return json.Marshal(stateMachineGroupState{
+		State:   g.state,
+		Entered: g.entered,
+	})
+}
+
+// restoreState restores the state of the group, states that are no longer declared are rejected.
+func (g *stateMachineGroup) restoreState(data []byte) error {
+	var s stateMachineGroupState
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if s.State != "" && !g.n.isDeclared(s.State) {
+		return fmt.Errorf("state %q is not declared", s.State)
+	}
+	g.state = s.State
+	g.entered = s.Entered
+	return nil
+}
+
+// isDeclared reports whether state is a declared state.
+func (n *StateMachineNode) isDeclared(state string) bool {
+	for _, s := range n.s.DeclaredStates {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
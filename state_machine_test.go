@@ -0,0 +1,66 @@
+package kapacitor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+)
+
+// usageLambda returns the lambda comparing the usage field with value.
+func usageLambda(op ast.TokenType, value float64) *ast.LambdaNode {
+	return &ast.LambdaNode{
+		Expression: &ast.BinaryNode{
+			Operator: op,
+			Left:     &ast.ReferenceNode{Reference: "usage"},
+			Right:    &ast.NumberNode{IsFloat: true, Float64: value},
+		},
+	}
+}
+
+func newTestStateMachineNode(t *testing.T) *StateMachineNode {
+	s := &pipeline.StateMachineNode{
+		InitialState: "ok",
+		Unit:         time.Second,
+	}
+	s.States("ok", "warning", "critical").
+		Transition("ok", "warning", usageLambda(ast.TokenGreater, 80)).
+		Transition("warning", "critical", usageLambda(ast.TokenGreater, 95)).
+		Transition("warning", "ok", usageLambda(ast.TokenLessEqual, 80)).
+		Transition("critical", "ok", usageLambda(ast.TokenLessEqual, 80))
+	n, err := newStateMachineNode(&ExecutingTask{Task: &Task{ID: "task"}}, s, &windowNodeDiagnostic{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestStateMachine_RestoreState(t *testing.T) {
+	entered := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	n := newTestStateMachineNode(t)
+	g := n.newGroup()
+	g.state = "warning"
+	g.entered = entered
+	data, err := g.snapshotState()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restored := n.newGroup()
+	if err := restored.restoreState(data); err != nil {
+		t.Fatal(err)
+	}
+	if restored.state != "warning" || !restored.entered.Equal(entered) {
+		t.Errorf("unexpected restored state: got %s entered %v", restored.state, restored.entered)
+	}
+
+	// A state that is no longer declared is rejected.
+	undeclared := n.newGroup()
+	if err := undeclared.restoreState([]byte(`{"state":"unknown","entered":"2018-01-01T00:00:00Z"}`)); err == nil {
+		t.Error("expected error restoring an undeclared state")
+	}
+	if undeclared.state != "" {
+		t.Errorf("unexpected state after rejected restore: %s", undeclared.state)
+	}
+}
@@ -1,6 +1,7 @@
 package kapacitor
 
 import (
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -10,9 +11,13 @@ import (
 	"github.com/influxdata/kapacitor/tick/stateful"
 )
 
+// stateTrackingGroupStateVersion is the version of the encoding of the state of the trackers.
+const stateTrackingGroupStateVersion = 1
+
 type stateTracker interface {
 	track(t time.Time, inState bool) interface{}
 	reset()
+	groupState
 }
 
 type stateTrackingGroup struct {
@@ -29,9 +34,15 @@ type StateTrackingNode struct {
 	scopePool stateful.ScopePool
 
 	newTracker func() stateTracker
+
+	// groupStates keeps the state of the groups for the task snapshots, if it is persisted.
+	groupStates *groupStates
 }
 
-func (n *StateTrackingNode) runStateTracking(_ []byte) error {
+func (n *StateTrackingNode) runStateTracking(snapshot []byte) error {
+	if n.groupStates != nil {
+		n.groupStates.load(snapshot)
+	}
 	consumer := edge.NewGroupedConsumer(
 		n.ins[0],
 		n,
@@ -40,10 +51,23 @@ func (n *StateTrackingNode) runStateTracking(_ []byte) error {
 	return consumer.Consume()
 }
 
+// snapshot returns the state of the groups if it is persisted.
+func (n *StateTrackingNode) snapshot() ([]byte, error) {
+	if n.groupStates == nil {
+		return nil, nil
+	}
+	return n.groupStates.snapshot()
+}
+
 func (n *StateTrackingNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	g := n.newGroup()
+	var r edge.ForwardReceiver = g
+	if n.groupStates != nil {
+		r = n.groupStates.add(group.ID, g.tracker, g)
+	}
 	return edge.NewReceiverFromForwardReceiverWithStats(
 		n.outs,
-		edge.NewTimedForwardReceiver(n.timer, n.newGroup()),
+		edge.NewTimedForwardReceiver(n.timer, r),
 	), nil
 }
 
@@ -130,6 +154,14 @@ func (sdt *stateDurationTracker) track(t time.Time, inState bool) interface{} {
 	return float64(t.Sub(sdt.startTime)) / float64(sdt.sd.Unit)
 }
 
+func (sdt *stateDurationTracker) snapshotState() ([]byte, error) {
+	return json.Marshal(sdt.startTime)
+}
+
+func (sdt *stateDurationTracker) restoreState(data []byte) error {
+	return json.Unmarshal(data, &sdt.startTime)
+}
+
 func newStateDurationNode(et *ExecutingTask, sd *pipeline.StateDurationNode, d NodeDiagnostic) (*StateTrackingNode, error) {
 	if sd.Lambda == nil {
 		return nil, fmt.Errorf("nil expression passed to StateDurationNode")
@@ -146,6 +178,9 @@ func newStateDurationNode(et *ExecutingTask, sd *pipeline.StateDurationNode, d N
 		expr:       expr,
 		scopePool:  stateful.NewScopePool(ast.FindReferenceVariables(sd.Lambda.Expression)),
 	}
+	if sd.PersistStateFlag {
+		n.groupStates = newGroupStates(stateTrackingGroupStateVersion, d)
+	}
 	n.node.runF = n.runStateTracking
 	return n, nil
 }
@@ -168,6 +203,14 @@ func (sct *stateCountTracker) track(t time.Time, inState bool) interface{} {
 	return sct.count
 }
 
+func (sct *stateCountTracker) snapshotState() ([]byte, error) {
+	return json.Marshal(sct.count)
+}
+
+func (sct *stateCountTracker) restoreState(data []byte) error {
+	return json.Unmarshal(data, &sct.count)
+}
+
 func newStateCountNode(et *ExecutingTask, sc *pipeline.StateCountNode, d NodeDiagnostic) (*StateTrackingNode, error) {
 	if sc.Lambda == nil {
 		return nil, fmt.Errorf("nil expression passed to StateCountNode")
@@ -184,6 +227,9 @@ func newStateCountNode(et *ExecutingTask, sc *pipeline.StateCountNode, d NodeDia
 		expr:       expr,
 		scopePool:  stateful.NewScopePool(ast.FindReferenceVariables(sc.Lambda.Expression)),
 	}
+	if sc.PersistStateFlag {
+		n.groupStates = newGroupStates(stateTrackingGroupStateVersion, d)
+	}
 	n.node.runF = n.runStateTracking
 	return n, nil
 }
@@ -0,0 +1,124 @@
+package kapacitor
+
+import (
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+const (
+	statsPointsCompacted = "points_compacted"
+	statsHeartbeats      = "heartbeats"
+)
+
+type StreamCompactNode struct {
+	node
+	s *pipeline.StreamCompactNode
+
+	compacted  *expvar.Int
+	heartbeats *expvar.Int
+}
+
+// Create a new StreamCompactNode which emits the points of each group only when their fields change.
+func newStreamCompactNode(et *ExecutingTask, n *pipeline.StreamCompactNode, d NodeDiagnostic) (*StreamCompactNode, error) {
+	sn := &StreamCompactNode{
+		node:       node{Node: n, et: et, diag: d},
+		s:          n,
+		compacted:  new(expvar.Int),
+		heartbeats: new(expvar.Int),
+	}
+	sn.node.runF = sn.runStreamCompact
+	return sn, nil
+}
+
+func (n *StreamCompactNode) runStreamCompact([]byte) error {
+	n.statMap.Set(statsPointsCompacted, n.compacted)
+	n.statMap.Set(statsHeartbeats, n.heartbeats)
+	consumer := edge.NewGroupedConsumer(
+		n.ins[0],
+		n,
+	)
+	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
+	return consumer.Consume()
+}
+
+func (n *StreamCompactNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	return edge.NewReceiverFromForwardReceiverWithStats(
+		n.outs,
+		edge.NewTimedForwardReceiver(n.timer, n.newGroup()),
+	), nil
+}
+
+func (n *StreamCompactNode) newGroup() *streamCompactGroup {
+	return &streamCompactGroup{
+		n: n,
+	}
+}
+
+// changed reports whether the compared fields of curr differ from those of prev.
+func (n *StreamCompactNode) changed(prev, curr models.Fields) bool {
+	if len(n.s.Fields) == 0 {
+		if len(prev) != len(curr) {
+			return true
+		}
+		for k, v := range curr {
+			if pv, ok := prev[k]; !ok || pv != v {
+				return true
+			}
+		}
+		return false
+	}
+	for _, f := range n.s.Fields {
+		pv, pok := prev[f]
+		cv, cok := curr[f]
+		if pok != cok || pv != cv {
+			return true
+		}
+	}
+	return false
+}
+
+type streamCompactGroup struct {
+	n *StreamCompactNode
+	// last emitted point of the group, nil if none
+	last edge.PointMessage
+}
+
+func (g *streamCompactGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	return begin, nil
+}
+
+func (g *streamCompactGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	return bp, nil
+}
+
+func (g *streamCompactGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+// Point emits the point if it changed from the last emitted point,
+// or if the max interval has elapsed since the last emitted point.
+func (g *streamCompactGroup) Point(p edge.PointMessage) (edge.Message, error) {
+	if g.last != nil && !g.n.changed(g.last.Fields(), p.Fields()) {
+		maxInterval := g.n.s.MaxInterval
+		if maxInterval <= 0 || p.Time().Sub(g.last.Time()) < maxInterval {
+			g.n.compacted.Add(1)
+			return nil, nil
+		}
+		g.n.heartbeats.Add(1)
+	}
+	g.last = p
+	return p, nil
+}
+
+func (g *streamCompactGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+
+func (g *streamCompactGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	g.last = nil
+	return d, nil
+}
+
+func (g *streamCompactGroup) Done() {}
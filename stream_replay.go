@@ -0,0 +1,527 @@
+package kapacitor
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"text/template"
+	"time"
+
+	dbmodels "github.com/influxdata/influxdb/models"
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+type StreamReplayNode struct {
+	node
+	s *pipeline.StreamReplayNode
+
+	path string
+
+	mu     sync.Mutex
+	writer *streamReplayWriter
+	// name of the current batch
+	batchName string
+}
+
+// Create a new StreamReplayNode which records all data it receives to a file, or replays such a recording.
+func newStreamReplayNode(et *ExecutingTask, n *pipeline.StreamReplayNode, d NodeDiagnostic) (*StreamReplayNode, error) {
+	tmpl, err := template.New("path").Parse(n.Path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid path template: %v", err)
+	}
+	var path bytes.Buffer
+	info := struct {
+		TaskName string
+		NodeName string
+	}{
+		TaskName: et.Task.ID,
+		NodeName: n.Name(),
+	}
+	if err := tmpl.Execute(&path, info); err != nil {
+		return nil, fmt.Errorf("failed to execute path template: %v", err)
+	}
+	sn := &StreamReplayNode{
+		node: node{Node: n, et: et, diag: d},
+		s:    n,
+		path: path.String(),
+	}
+	sn.node.runF = sn.runStreamReplay
+	sn.node.stopF = sn.stopStreamReplay
+	return sn, nil
+}
+
+func (n *StreamReplayNode) runStreamReplay([]byte) error {
+	if n.s.ReplayFlag {
+		if err := n.replay(); err != nil {
+			return err
+		}
+	} else {
+		if err := os.MkdirAll(filepath.Dir(n.path), 0755); err != nil {
+			return err
+		}
+		w, err := newStreamReplayWriter(n.path, n.s.MaxSize, n.s.Rotations)
+		if err != nil {
+			return err
+		}
+		n.mu.Lock()
+		n.writer = w
+		n.mu.Unlock()
+		defer n.stopStreamReplay()
+	}
+
+	consumer := edge.NewConsumerWithReceiver(
+		n.ins[0],
+		edge.NewReceiverFromForwardReceiverWithStats(
+			n.outs,
+			edge.NewTimedForwardReceiver(n.timer, n),
+		),
+	)
+	return consumer.Consume()
+}
+
+// replay emits the messages of the recording to the children of the node.
+func (n *StreamReplayNode) replay() error {
+	r, err := OpenStreamReplay(n.path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	dec := NewStreamReplayDecoder(r)
+	for {
+		m, err := dec.Decode()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := edge.Forward(n.outs, m); err != nil {
+			return err
+		}
+	}
+}
+
+// stopStreamReplay flushes and closes the recording.
+func (n *StreamReplayNode) stopStreamReplay() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.writer == nil {
+		return
+	}
+	if err := n.writer.Close(); err != nil {
+		n.diag.Error("failed to close recording", err)
+	}
+	n.writer = nil
+}
+
+// record writes to the recording, errors are logged and the data is passed on regardless.
+func (n *StreamReplayNode) record(f func(w *streamReplayWriter) error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.writer == nil {
+		return
+	}
+	if err := f(n.writer); err != nil {
+		n.diag.Error("failed to record data", err)
+	}
+}
+
+func (n *StreamReplayNode) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	n.batchName = begin.Name()
+	n.record(func(w *streamReplayWriter) error { return w.WriteBeginBatch(begin) })
+	return begin, nil
+}
+
+func (n *StreamReplayNode) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	n.record(func(w *streamReplayWriter) error { return w.WriteBatchPoint(n.batchName, bp) })
+	return bp, nil
+}
+
+func (n *StreamReplayNode) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	n.record(func(w *streamReplayWriter) error { return w.WriteEndBatch(end) })
+	return end, nil
+}
+
+func (n *StreamReplayNode) Point(p edge.PointMessage) (edge.Message, error) {
+	n.record(func(w *streamReplayWriter) error { return w.WritePoint(p) })
+	return p, nil
+}
+
+func (n *StreamReplayNode) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	n.record(func(w *streamReplayWriter) error { return w.WriteBarrier(b) })
+	return b, nil
+}
+
+func (n *StreamReplayNode) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	n.record(func(w *streamReplayWriter) error { return w.WriteDeleteGroup(d) })
+	return d, nil
+}
+
+func (n *StreamReplayNode) Done() {}
+
+const (
+	streamReplayRecordPrefix = '#'
+
+	streamReplayStream      = "stream"
+	streamReplayBeginBatch  = "beginBatch"
+	streamReplayEndBatch    = "endBatch"
+	streamReplayBarrier     = "barrier"
+	streamReplayDeleteGroup = "deleteGroup"
+)
+
+// streamReplayRecord is a control record of a recording.
+// Stream records set the database, retention policy and dimensions of all subsequent stream points.
+type streamReplayRecord struct {
+	Type            string         `json:"type"`
+	Name            string         `json:"name,omitempty"`
+	Database        string         `json:"database,omitempty"`
+	RetentionPolicy string         `json:"retentionPolicy,omitempty"`
+	Group           models.GroupID `json:"group,omitempty"`
+	Tags            models.Tags    `json:"tags,omitempty"`
+	TagNames        []string       `json:"tagNames,omitempty"`
+	ByName          bool           `json:"byName,omitempty"`
+	Time            *time.Time     `json:"time,omitempty"`
+	SizeHint        int            `json:"sizeHint,omitempty"`
+}
+
+func (r streamReplayRecord) dimensions() models.Dimensions {
+	return models.Dimensions{
+		ByName:   r.ByName,
+		TagNames: r.TagNames,
+	}
+}
+
+// streamReplayWriter writes a recording to a file, rotating the file once it exceeds its maximum size.
+// Files are only rotated between batches so that each file can be replayed on its own.
+type streamReplayWriter struct {
+	path      string
+	maxSize   int64
+	rotations int64
+
+	f    *os.File
+	w    *bufio.Writer
+	size int64
+
+	buf bytes.Buffer
+	enc *json.Encoder
+
+	// stream is the last stream record written to the current file
+	stream *streamReplayRecord
+}
+
+func newStreamReplayWriter(path string, maxSize, rotations int64) (*streamReplayWriter, error) {
+	w := &streamReplayWriter{
+		path:      path,
+		maxSize:   maxSize,
+		rotations: rotations,
+	}
+	w.enc = json.NewEncoder(&w.buf)
+	if err := w.open(os.O_APPEND); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *streamReplayWriter) open(flag int) error {
+	f, err := os.OpenFile(w.path, os.O_WRONLY|os.O_CREATE|flag, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	w.f = f
+	w.w = bufio.NewWriter(f)
+	w.size = info.Size()
+	w.stream = nil
+	return nil
+}
+
+// rotate moves the current file to path.1, shifting older files up to the number of rotations.
+func (w *streamReplayWriter) rotate() error {
+	if err := w.w.Flush(); err != nil {
+		return err
+	}
+	if err := w.f.Close(); err != nil {
+		return err
+	}
+	if w.rotations > 0 {
+		for i := w.rotations - 1; i > 0; i-- {
+			src := w.path + "." + strconv.FormatInt(i, 10)
+			dst := w.path + "." + strconv.FormatInt(i+1, 10)
+			if err := os.Rename(src, dst); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		if err := os.Rename(w.path, w.path+".1"); err != nil {
+			return err
+		}
+	}
+	return w.open(os.O_TRUNC)
+}
+
+// startMessage rotates the file if needed, must be called before writing any message outside of a batch.
+func (w *streamReplayWriter) startMessage() error {
+	if w.maxSize > 0 && w.size >= w.maxSize {
+		return w.rotate()
+	}
+	return nil
+}
+
+func (w *streamReplayWriter) writeRecord(r *streamReplayRecord) error {
+	w.buf.Reset()
+	w.buf.WriteByte(streamReplayRecordPrefix)
+	// The encoder terminates the record with a newline.
+	if err := w.enc.Encode(r); err != nil {
+		return err
+	}
+	return w.flushBuf()
+}
+
+func (w *streamReplayWriter) writeLine(name string, tags models.Tags, fields models.Fields, t time.Time) error {
+	p, err := dbmodels.NewPoint(name, dbmodels.NewTags(tags), dbmodels.Fields(fields), t)
+	if err != nil {
+		return err
+	}
+	w.buf.Reset()
+	w.buf.Write(p.AppendString(nil))
+	w.buf.WriteByte('\n')
+	return w.flushBuf()
+}
+
+func (w *streamReplayWriter) flushBuf() error {
+	n, err := w.w.Write(w.buf.Bytes())
+	w.size += int64(n)
+	return err
+}
+
+func (w *streamReplayWriter) WritePoint(p edge.PointMessage) error {
+	if err := w.startMessage(); err != nil {
+		return err
+	}
+	dims := p.Dimensions()
+	if s := w.stream; s == nil || s.Database != p.Database() || s.RetentionPolicy != p.RetentionPolicy() || !s.dimensions().Equal(dims) {
+		s = &streamReplayRecord{
+			Type:            streamReplayStream,
+			Database:        p.Database(),
+			RetentionPolicy: p.RetentionPolicy(),
+			TagNames:        dims.TagNames,
+			ByName:          dims.ByName,
+		}
+		if err := w.writeRecord(s); err != nil {
+			return err
+		}
+		w.stream = s
+	}
+	return w.writeLine(p.Name(), p.Tags(), p.Fields(), p.Time())
+}
+
+func (w *streamReplayWriter) WriteBeginBatch(begin edge.BeginBatchMessage) error {
+	if err := w.startMessage(); err != nil {
+		return err
+	}
+	dims := begin.Dimensions()
+	t := begin.Time()
+	return w.writeRecord(&streamReplayRecord{
+		Type:     streamReplayBeginBatch,
+		Name:     begin.Name(),
+		Tags:     begin.Tags(),
+		TagNames: dims.TagNames,
+		ByName:   dims.ByName,
+		Time:     &t,
+		SizeHint: begin.SizeHint(),
+	})
+}
+
+func (w *streamReplayWriter) WriteBatchPoint(name string, bp edge.BatchPointMessage) error {
+	return w.writeLine(name, bp.Tags(), bp.Fields(), bp.Time())
+}
+
+func (w *streamReplayWriter) WriteEndBatch(edge.EndBatchMessage) error {
+	return w.writeRecord(&streamReplayRecord{
+		Type: streamReplayEndBatch,
+	})
+}
+
+func (w *streamReplayWriter) WriteBarrier(b edge.BarrierMessage) error {
+	if err := w.startMessage(); err != nil {
+		return err
+	}
+	group := b.GroupInfo()
+	t := b.Time()
+	return w.writeRecord(&streamReplayRecord{
+		Type:     streamReplayBarrier,
+		Group:    group.ID,
+		Tags:     group.Tags,
+		TagNames: group.Dimensions.TagNames,
+		ByName:   group.Dimensions.ByName,
+		Time:     &t,
+	})
+}
+
+func (w *streamReplayWriter) WriteDeleteGroup(d edge.DeleteGroupMessage) error {
+	if err := w.startMessage(); err != nil {
+		return err
+	}
+	return w.writeRecord(&streamReplayRecord{
+		Type:  streamReplayDeleteGroup,
+		Group: d.GroupID(),
+	})
+}
+
+// Close flushes any buffered data and closes the file.
+func (w *streamReplayWriter) Close() error {
+	if err := w.w.Flush(); err != nil {
+		w.f.Close()
+		return err
+	}
+	return w.f.Close()
+}
+
+// StreamReplayDecoder decodes the messages of a recording made by a StreamReplayNode.
+type StreamReplayDecoder struct {
+	in *bufio.Scanner
+
+	stream  streamReplayRecord
+	inBatch bool
+	// name of the current batch
+	batchName string
+}
+
+func NewStreamReplayDecoder(r io.Reader) *StreamReplayDecoder {
+	in := bufio.NewScanner(r)
+	in.Buffer(nil, 16*1024*1024)
+	return &StreamReplayDecoder{
+		in: in,
+	}
+}
+
+// Decode returns the next message of the recording.
+// Stream records are consumed and never returned.
+// Returns io.EOF once the recording is exhausted.
+func (d *StreamReplayDecoder) Decode() (edge.Message, error) {
+	for d.in.Scan() {
+		line := d.in.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		if line[0] != streamReplayRecordPrefix {
+			return d.decodePoint(line)
+		}
+		r := streamReplayRecord{}
+		if err := json.Unmarshal(line[1:], &r); err != nil {
+			return nil, fmt.Errorf("invalid recording control record: %v", err)
+		}
+		switch r.Type {
+		case streamReplayStream:
+			d.stream = r
+		case streamReplayBeginBatch:
+			if r.Time == nil {
+				return nil, errors.New("invalid recording, begin batch record is missing its time")
+			}
+			d.inBatch = true
+			d.batchName = r.Name
+			begin := edge.NewBeginBatchMessage(r.Name, r.Tags, r.ByName, r.Time.UTC(), r.SizeHint)
+			begin.SetTagsAndDimensions(r.Tags, r.dimensions())
+			return begin, nil
+		case streamReplayEndBatch:
+			d.inBatch = false
+			return edge.NewEndBatchMessage(), nil
+		case streamReplayBarrier:
+			if r.Time == nil {
+				return nil, errors.New("invalid recording, barrier record is missing its time")
+			}
+			return edge.NewBarrierMessage(edge.GroupInfo{
+				ID:         r.Group,
+				Tags:       r.Tags,
+				Dimensions: r.dimensions(),
+			}, r.Time.UTC()), nil
+		case streamReplayDeleteGroup:
+			return edge.NewDeleteGroupMessage(r.Group), nil
+		default:
+			return nil, fmt.Errorf("invalid recording, unknown control record type %q", r.Type)
+		}
+	}
+	if err := d.in.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+func (d *StreamReplayDecoder) decodePoint(line []byte) (edge.Message, error) {
+	mps, err := dbmodels.ParsePointsWithPrecision(line, time.Time{}, "ns")
+	if err != nil {
+		return nil, err
+	}
+	mp := mps[0]
+	fields := models.Fields(mp.Fields())
+	tags := models.Tags(mp.Tags().Map())
+	if d.inBatch {
+		return edge.NewBatchPointMessage(fields, tags, mp.Time().UTC()), nil
+	}
+	return edge.NewPointMessage(
+		mp.Name(),
+		d.stream.Database,
+		d.stream.RetentionPolicy,
+		d.stream.dimensions(),
+		fields,
+		tags,
+		mp.Time().UTC(),
+	), nil
+}
+
+// OpenStreamReplay opens a recording including all of its rotated files, oldest first.
+func OpenStreamReplay(path string) (io.ReadCloser, error) {
+	paths := []string{path}
+	for i := 1; ; i++ {
+		p := path + "." + strconv.Itoa(i)
+		if _, err := os.Stat(p); err != nil {
+			if os.IsNotExist(err) {
+				break
+			}
+			return nil, err
+		}
+		paths = append([]string{p}, paths...)
+	}
+	files := make(multiFileCloser, 0, len(paths))
+	readers := make([]io.Reader, 0, len(paths))
+	for _, p := range paths {
+		f, err := os.Open(p)
+		if err != nil {
+			files.Close()
+			return nil, err
+		}
+		files = append(files, f)
+		readers = append(readers, f)
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{
+		Reader: io.MultiReader(readers...),
+		Closer: files,
+	}, nil
+}
+
+type multiFileCloser []*os.File
+
+func (m multiFileCloser) Close() error {
+	var firstErr error
+	for _, f := range m {
+		if err := f.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
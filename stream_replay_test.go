@@ -0,0 +1,158 @@
+package kapacitor
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+func streamReplayTestDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "stream_replay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func writeStreamReplay(w *streamReplayWriter, msgs []edge.Message) error {
+	var batchName string
+	for _, m := range msgs {
+		var err error
+		switch m := m.(type) {
+		case edge.PointMessage:
+			err = w.WritePoint(m)
+		case edge.BeginBatchMessage:
+			batchName = m.Name()
+			err = w.WriteBeginBatch(m)
+		case edge.BatchPointMessage:
+			err = w.WriteBatchPoint(batchName, m)
+		case edge.EndBatchMessage:
+			err = w.WriteEndBatch(m)
+		case edge.BarrierMessage:
+			err = w.WriteBarrier(m)
+		case edge.DeleteGroupMessage:
+			err = w.WriteDeleteGroup(m)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return w.Close()
+}
+
+// readStreamReplay replays the recording with a StreamReplayNode and returns the messages emitted by the node.
+func readStreamReplay(t *testing.T, path string) []edge.Message {
+	n, err := newStreamReplayNode(
+		&ExecutingTask{Task: &Task{ID: "task"}},
+		&pipeline.StreamReplayNode{Path: path, ReplayFlag: true},
+		&windowNodeDiagnostic{},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := edge.NewChannelEdge(pipeline.StreamEdge, 100)
+	n.outs = []edge.StatsEdge{edge.NewStatsEdge(out)}
+	if err := n.replay(); err != nil {
+		t.Fatal(err)
+	}
+	out.Close()
+	var msgs []edge.Message
+	for m, ok := out.Emit(); ok; m, ok = out.Emit() {
+		msgs = append(msgs, m)
+	}
+	return msgs
+}
+
+func TestStreamReplay_RoundTrip(t *testing.T) {
+	dir := streamReplayTestDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "replay.rec")
+
+	t0 := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	dims := models.Dimensions{TagNames: []string{"host"}}
+	point := func(db, host string, value float64, tm time.Time) edge.PointMessage {
+		return edge.NewPointMessage(
+			"cpu", db, "autogen",
+			dims,
+			models.Fields{"value": value, "count": int64(2), "state": "ok", "enabled": true},
+			models.Tags{"host": host, "region": "west"},
+			tm,
+		)
+	}
+	groupA := point("telegraf", "serverA", 0, t0).GroupInfo()
+	begin := edge.NewBeginBatchMessage("mem", models.Tags{"host": "serverA"}, false, t0.Add(2*time.Second), 2)
+	begin.SetTagsAndDimensions(models.Tags{"host": "serverA"}, dims)
+	msgs := []edge.Message{
+		point("telegraf", "serverA", 1, t0),
+		point("telegraf", "serverB", 2, t0),
+		edge.NewBarrierMessage(groupA, t0.Add(time.Second)),
+		point("other", "serverA", 3, t0.Add(time.Second)),
+		edge.NewDeleteGroupMessage(groupA.ID),
+		begin,
+		edge.NewBatchPointMessage(models.Fields{"used": 10.5}, models.Tags{"host": "serverA"}, t0.Add(time.Second)),
+		edge.NewBatchPointMessage(models.Fields{"used": 11.5}, models.Tags{"host": "serverA"}, t0.Add(2*time.Second)),
+		edge.NewEndBatchMessage(),
+		point("other", "serverB", 4, t0.Add(3*time.Second)),
+	}
+
+	w, err := newStreamReplayWriter(path, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writeStreamReplay(w, msgs); err != nil {
+		t.Fatal(err)
+	}
+
+	got := readStreamReplay(t, path)
+	if !reflect.DeepEqual(got, msgs) {
+		t.Errorf("unexpected replayed messages:\ngot %v\nexp %v", got, msgs)
+	}
+}
+
+func TestStreamReplay_Rotation(t *testing.T) {
+	dir := streamReplayTestDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "replay.rec")
+
+	t0 := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	var msgs []edge.Message
+	for i := 0; i < 5; i++ {
+		msgs = append(msgs, edge.NewPointMessage(
+			"cpu", "telegraf", "autogen",
+			models.Dimensions{},
+			models.Fields{"value": float64(i)},
+			models.Tags{},
+			t0.Add(time.Duration(i)*time.Second),
+		))
+	}
+
+	// Rotate after every point, keeping two rotated files.
+	w, err := newStreamReplayWriter(path, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writeStreamReplay(w, msgs); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range []string{path, path + ".1", path + ".2"} {
+		if _, err := os.Stat(p); err != nil {
+			t.Errorf("expected recording file %s: %v", p, err)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("expected recording file %s.3 to not exist", path)
+	}
+
+	got := readStreamReplay(t, path)
+	if exp := msgs[2:]; !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected replayed messages:\ngot %v\nexp %v", got, exp)
+	}
+}
@@ -0,0 +1,145 @@
+package kapacitor
+
+import (
+	"strconv"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+const (
+	statsUnmapped = "unmapped"
+)
+
+type TagValueMapNode struct {
+	node
+	m *pipeline.TagValueMapNode
+
+	as       string
+	unmapped *expvar.Int
+}
+
+// Create a new TagValueMapNode which maps the values of a tag or field through a lookup table.
+func newTagValueMapNode(et *ExecutingTask, n *pipeline.TagValueMapNode, d NodeDiagnostic) (*TagValueMapNode, error) {
+	mn := &TagValueMapNode{
+		node:     node{Node: n, et: et, diag: d},
+		m:        n,
+		as:       n.As,
+		unmapped: new(expvar.Int),
+	}
+	if mn.as == "" {
+		mn.as = n.Tag
+	}
+	mn.node.runF = mn.runTagValueMap
+	return mn, nil
+}
+
+func (n *TagValueMapNode) runTagValueMap([]byte) error {
+	n.statMap.Set(statsUnmapped, n.unmapped)
+	consumer := edge.NewConsumerWithReceiver(
+		n.ins[0],
+		edge.NewReceiverFromForwardReceiverWithStats(
+			n.outs,
+			edge.NewTimedForwardReceiver(n.timer, n),
+		),
+	)
+	return consumer.Consume()
+}
+
+// label returns the label of a value.
+// Returns false if the value is not in the table and there is no default label.
+func (n *TagValueMapNode) label(value string) (string, bool) {
+	if l, ok := n.m.Mappings[value]; ok {
+		return l, true
+	}
+	n.unmapped.Add(1)
+	if n.m.DefaultLabel == nil {
+		return "", false
+	}
+	return *n.m.DefaultLabel, true
+}
+
+// tagValueMapKey returns the string representation of a field value.
+func tagValueMapKey(v interface{}) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(v), true
+	default:
+		return "", false
+	}
+}
+
+// mapValue returns the fields and tags with the label set.
+// Returns false if the point is passed on unchanged.
+func (n *TagValueMapNode) mapValue(fields models.Fields, tags models.Tags) (models.Fields, models.Tags, bool) {
+	if n.m.FromFieldFlag {
+		value, ok := tagValueMapKey(fields[n.m.Tag])
+		if !ok {
+			return nil, nil, false
+		}
+		l, ok := n.label(value)
+		if !ok {
+			return nil, nil, false
+		}
+		fields = fields.Copy()
+		fields[n.as] = l
+		return fields, tags, true
+	}
+	value, ok := tags[n.m.Tag]
+	if !ok {
+		return nil, nil, false
+	}
+	l, ok := n.label(value)
+	if !ok {
+		return nil, nil, false
+	}
+	tags = tags.Copy()
+	tags[n.as] = l
+	return fields, tags, true
+}
+
+func (n *TagValueMapNode) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	return begin, nil
+}
+
+func (n *TagValueMapNode) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	fields, tags, ok := n.mapValue(bp.Fields(), bp.Tags())
+	if !ok {
+		return bp, nil
+	}
+	bp = bp.ShallowCopy()
+	bp.SetFields(fields)
+	bp.SetTags(tags)
+	return bp, nil
+}
+
+func (n *TagValueMapNode) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (n *TagValueMapNode) Point(p edge.PointMessage) (edge.Message, error) {
+	fields, tags, ok := n.mapValue(p.Fields(), p.Tags())
+	if !ok {
+		return p, nil
+	}
+	p = p.ShallowCopy()
+	p.SetFields(fields)
+	p.SetTags(tags)
+	return p, nil
+}
+
+func (n *TagValueMapNode) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (n *TagValueMapNode) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (n *TagValueMapNode) Done() {}
@@ -116,6 +116,9 @@ type ExecutingTask struct {
 	wg       sync.WaitGroup
 	diag     TaskDiagnostic
 
+	// dead letter queues by sink and target
+	deadLetters map[string]DeadLetterQueue
+
 	// Mutex for throughput var
 	tmu        sync.RWMutex
 	throughput float64
@@ -133,6 +136,7 @@ func NewExecutingTask(tm *TaskMaster, t *Task) (*ExecutingTask, error) {
 	}
 	err := et.link()
 	if err != nil {
+		et.closeDeadLetterQueues()
 		return nil, err
 	}
 	return et, nil
@@ -243,6 +247,7 @@ func (et *ExecutingTask) stop() (err error) {
 		return nil
 	})
 	et.wg.Wait()
+	et.closeDeadLetterQueues()
 	return
 }
 
@@ -501,6 +506,16 @@ func (et *ExecutingTask) createNode(p pipeline.Node, d NodeDiagnostic) (n Node,
 		n, err = newDefaultNode(et, t, d)
 	case *pipeline.DeleteNode:
 		n, err = newDeleteNode(et, t, d)
+	case *pipeline.DropTagsNode:
+		n, err = newDropTagsNode(et, t, d)
+	case *pipeline.DropFieldsNode:
+		n, err = newDropFieldsNode(et, t, d)
+	case *pipeline.FieldToTagNode:
+		n, err = newFieldToTagNode(et, t, d)
+	case *pipeline.TagToFieldNode:
+		n, err = newTagToFieldNode(et, t, d)
+	case *pipeline.DeriveTagNode:
+		n, err = newDeriveTagNode(et, t, d)
 	case *pipeline.CombineNode:
 		n, err = newCombineNode(et, t, d)
 	case *pipeline.K8sAutoscaleNode:
@@ -513,15 +528,124 @@ func (et *ExecutingTask) createNode(p pipeline.Node, d NodeDiagnostic) (n Node,
 		n, err = newStateDurationNode(et, t, d)
 	case *pipeline.StateCountNode:
 		n, err = newStateCountNode(et, t, d)
+	case *pipeline.StateMachineNode:
+		n, err = newStateMachineNode(et, t, d)
 	case *pipeline.SideloadNode:
 		n, err = newSideloadNode(et, t, d)
+	case *pipeline.SplitNode:
+		n, err = newSplitNode(et, t, d)
+	case *pipeline.SplitBranchNode:
+		n, err = newSplitBranchNode(et, t, d)
 	case *pipeline.BarrierNode:
 		n, err = newBarrierNode(et, t, d)
+	case *pipeline.PercentilesNode:
+		n, err = newPercentilesNode(et, t, d)
+	case *pipeline.QuantizeNode:
+		n, err = newQuantizeNode(et, t, d)
+	case *pipeline.StreamReplayNode:
+		n, err = newStreamReplayNode(et, t, d)
+	case *pipeline.StreamCompactNode:
+		n, err = newStreamCompactNode(et, t, d)
+	case *pipeline.SessionizeNode:
+		n, err = newSessionizeNode(et, t, d)
+	case *pipeline.ValidateTimeNode:
+		n, err = newValidateTimeNode(et, t, d)
+	case *pipeline.MaxDelayNode:
+		n, err = newMaxDelayNode(et, t, d)
+	case *pipeline.MovingAverageNode:
+		n, err = newMovingAverageNode(et, t, d)
+	case *pipeline.CorrelateNode:
+		n, err = newCorrelateNode(et, t, d)
+	case *pipeline.DiffNode:
+		n, err = newDiffNode(et, t, d)
+	case *pipeline.ScheduleNode:
+		n, err = newScheduleNode(et, t, d)
+	case *pipeline.NormalizeNode:
+		n, err = newNormalizeNode(et, t, d)
+	case *pipeline.RetractNode:
+		n, err = newRetractNode(et, t, d)
+	case *pipeline.NatsOutNode:
+		n, err = newNatsOutNode(et, t, d)
+	case *pipeline.TrendNode:
+		n, err = newTrendNode(et, t, d)
+	case *pipeline.EncryptNode:
+		n, err = newEncryptNode(et, t, d)
+	case *pipeline.DecryptNode:
+		n, err = newDecryptNode(et, t, d)
+	case *pipeline.EncodeBase64Node:
+		n, err = newEncodeBase64Node(et, t, d)
+	case *pipeline.DecodeBase64Node:
+		n, err = newDecodeBase64Node(et, t, d)
+	case *pipeline.BackfillNode:
+		n, err = newBackfillNode(et, t, d)
+	case *pipeline.CardinalityLimitNode:
+		n, err = newCardinalityLimitNode(et, t, d)
+	case *pipeline.WarmupNode:
+		n, err = newWarmupNode(et, t, d)
+	case *pipeline.ThresholdLearnNode:
+		n, err = newThresholdLearnNode(et, t, d)
+	case *pipeline.DecimalsNode:
+		n, err = newDecimalsNode(et, t, d)
+	case *pipeline.JSONExtractNode:
+		n, err = newJSONExtractNode(et, t, d)
+	case *pipeline.BatchSizeLimitNode:
+		n, err = newBatchSizeLimitNode(et, t, d)
+	case *pipeline.SanitizeNode:
+		n, err = newSanitizeNode(et, t, d)
+	case *pipeline.CountDistinctNode:
+		n, err = newCountDistinctNode(et, t, d)
+	case *pipeline.BloomFilterNode:
+		n, err = newBloomFilterNode(et, t, d)
+	case *pipeline.ConcatFieldsNode:
+		n, err = newConcatFieldsNode(et, t, d)
+	case *pipeline.LagCompensateNode:
+		n, err = newLagCompensateNode(et, t, d)
+	case *pipeline.ClampNode:
+		n, err = newClampNode(et, t, d)
+	case *pipeline.TimeInStateNode:
+		n, err = newTimeInStateNode(et, t, d)
+	case *pipeline.MaskNode:
+		n, err = newMaskNode(et, t, d)
+	case *pipeline.CoalesceNode:
+		n, err = newCoalesceNode(et, t, d)
+	case *pipeline.ReverseDNSNode:
+		n, err = newReverseDNSNode(et, t, d)
+	case *pipeline.DurationFormatNode:
+		n, err = newDurationFormatNode(et, t, d)
+	case *pipeline.TagValueMapNode:
+		n, err = newTagValueMapNode(et, t, d)
+	case *pipeline.AvroOutNode:
+		n, err = newAvroOutNode(et, t, d)
+	case *pipeline.DeadbandNode:
+		n, err = newDeadbandNode(et, t, d)
+	case *pipeline.UpDownCounterNode:
+		n, err = newUpDownCounterNode(et, t, d)
+	case *pipeline.ValidateSchemaNode:
+		n, err = newValidateSchemaNode(et, t, d)
+	case *pipeline.MultiWindowNode:
+		n, err = newMultiWindowNode(et, t, d)
+	case *pipeline.RegexReplaceNode:
+		n, err = newRegexReplaceNode(et, t, d)
+	case *pipeline.HeartbeatNode:
+		n, err = newHeartbeatNode(et, t, d)
 	default:
 		return nil, fmt.Errorf("unknown pipeline node type %T", p)
 	}
 	if err == nil && n != nil {
 		n.init(p.IsQuiet())
+		if sink, target := p.DeadLetterQueue(); sink != "" {
+			q, err := et.deadLetterQueue(sink, target)
+			if err != nil {
+				return nil, err
+			}
+			n.setDeadLetterQueue(q)
+		}
+		if workers := p.WorkerPool(); workers > 1 {
+			n.setWorkers(int(workers))
+		}
+		if limit, window := p.PanicThreshold(); limit > 0 {
+			n.setPanicLimit(limit, window)
+		}
 	}
 	return n, err
 }
@@ -548,6 +672,21 @@ func (et *ExecutingTask) Snapshot() (*TaskSnapshot, error) {
 	return snapshot, nil
 }
 
+// saveSnapshot saves a snapshot of the task immediately,
+// for nodes whose state must not be lost between the periodic snapshots.
+func (et *ExecutingTask) saveSnapshot() error {
+	snapshot, err := et.Snapshot()
+	if err != nil {
+		et.diag.Error("failed to snapshot task", err)
+		return err
+	}
+	if err := et.tm.TaskStore.SaveSnapshot(et.Task.ID, snapshot); err != nil {
+		et.diag.Error("failed to save task snapshot", err)
+		return err
+	}
+	return nil
+}
+
 func (et *ExecutingTask) runSnapshotter() {
 	defer et.wg.Done()
 	// Wait random duration to splay snapshot events across interval
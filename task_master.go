@@ -1,9 +1,11 @@
 package kapacitor
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log"
+	"net"
 	"sync"
 	"time"
 
@@ -116,7 +118,7 @@ type TaskMaster struct {
 	SMTPService interface {
 		Global() bool
 		StateChangesOnly() bool
-		Handler(smtp.HandlerConfig, ...keyvalue.T) alert.Handler
+		Handler(smtp.HandlerConfig, ...keyvalue.T) (alert.Handler, error)
 	}
 	MQTTService interface {
 		Handler(mqtt.HandlerConfig, ...keyvalue.T) alert.Handler
@@ -169,6 +171,7 @@ type TaskMaster struct {
 	}
 	KafkaService interface {
 		Handler(kafka.HandlerConfig, ...keyvalue.T) (alert.Handler, error)
+		Cluster(id string) (*kafka.Cluster, bool)
 	}
 	AlertaService interface {
 		DefaultHandlerConfig() alerta.HandlerConfig
@@ -180,6 +183,9 @@ type TaskMaster struct {
 	TalkService interface {
 		Handler(...keyvalue.T) alert.Handler
 	}
+	OpenTelemetryService interface {
+		Handler(...keyvalue.T) alert.Handler
+	}
 	TimingService interface {
 		NewTimer(timer.Setter) timer.Timer
 	}
@@ -197,6 +203,17 @@ type TaskMaster struct {
 		Source(dir string) (sideload.Source, error)
 	}
 
+	EncryptionService interface {
+		Enabled() bool
+		Encrypt(plaintext []byte) (string, error)
+		Decrypt(ciphertext string) ([]byte, error)
+	}
+
+	// Resolver looks up the names of IP addresses, it is implemented by *net.Resolver.
+	Resolver interface {
+		LookupAddr(ctx context.Context, addr string) ([]string, error)
+	}
+
 	Commander command.Commander
 
 	DefaultRetentionPolicy string
@@ -257,6 +274,7 @@ func NewTaskMaster(id string, info vars.Infoer, d Diagnostic) *TaskMaster {
 
 		closed:        true,
 		TimingService: noOpTimingService{},
+		Resolver:      net.DefaultResolver,
 	}
 }
 
@@ -286,10 +304,13 @@ func (tm *TaskMaster) New(id string) *TaskMaster {
 	n.AlertaService = tm.AlertaService
 	n.SensuService = tm.SensuService
 	n.TalkService = tm.TalkService
+	n.OpenTelemetryService = tm.OpenTelemetryService
 	n.TimingService = tm.TimingService
 	n.K8sService = tm.K8sService
 	n.Commander = tm.Commander
 	n.SideloadService = tm.SideloadService
+	n.EncryptionService = tm.EncryptionService
+	n.Resolver = tm.Resolver
 	return n
 }
 
@@ -503,7 +524,7 @@ func (tm *TaskMaster) StartTask(t *Task) (*ExecutingTask, error) {
 		ins = make([]edge.StatsEdge, count)
 		for i := 0; i < count; i++ {
 			d := tm.diag.WithEdgeContext(t.ID, "batch", fmt.Sprintf("batch%d", i))
-			in := newEdge(t.ID, "batch", fmt.Sprintf("batch%d", i), pipeline.BatchEdge, defaultEdgeBufferSize, d)
+			in := newEdge(t.ID, "batch", fmt.Sprintf("batch%d", i), pipeline.BatchEdge, defaultEdgeBufferSize, edge.BackpressureBlock, d)
 			ins[i] = in
 			tm.batches[t.ID] = append(tm.batches[t.ID], &batchCollector{edge: in})
 		}
@@ -627,7 +648,7 @@ func (tm *TaskMaster) stream(name string) (StreamCollector, error) {
 		return nil, ErrTaskMasterClosed
 	}
 	d := tm.diag.WithEdgeContext(fmt.Sprintf("task_master:%s", tm.id), name, "stream")
-	in := newEdge(fmt.Sprintf("task_master:%s", tm.id), name, "stream", pipeline.StreamEdge, defaultEdgeBufferSize, d)
+	in := newEdge(fmt.Sprintf("task_master:%s", tm.id), name, "stream", pipeline.StreamEdge, defaultEdgeBufferSize, edge.BackpressureBlock, d)
 	se := &streamEdge{edge: in}
 	tm.wg.Add(1)
 	go func() {
@@ -807,7 +828,7 @@ func (tm *TaskMaster) newFork(taskName string, dbrps []DBRP, measurements []stri
 	}
 
 	d := tm.diag.WithEdgeContext(taskName, "stream", "stream0")
-	e := newEdge(taskName, "stream", "stream0", pipeline.StreamEdge, defaultEdgeBufferSize, d)
+	e := newEdge(taskName, "stream", "stream0", pipeline.StreamEdge, defaultEdgeBufferSize, edge.BackpressureBlock, d)
 
 	for _, key := range forkKeys(dbrps, measurements) {
 		tm.taskToForkKeys[taskName] = append(tm.taskToForkKeys[taskName], key)
@@ -0,0 +1,152 @@
+package kapacitor
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/keyvalue"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+type ThresholdLearnNode struct {
+	node
+	t *pipeline.ThresholdLearnNode
+}
+
+// Create a new ThresholdLearnNode which learns lower and upper bounds of a field of each group.
+func newThresholdLearnNode(et *ExecutingTask, n *pipeline.ThresholdLearnNode, d NodeDiagnostic) (*ThresholdLearnNode, error) {
+	tn := &ThresholdLearnNode{
+		node: node{Node: n, et: et, diag: d},
+		t:    n,
+	}
+	tn.node.runF = tn.runThresholdLearn
+	return tn, nil
+}
+
+func (n *ThresholdLearnNode) runThresholdLearn([]byte) error {
+	consumer := edge.NewGroupedConsumer(
+		n.ins[0],
+		n,
+	)
+	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
+	return consumer.Consume()
+}
+
+func (n *ThresholdLearnNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	return edge.NewReceiverFromForwardReceiverWithStats(
+		n.outs,
+		edge.NewTimedForwardReceiver(n.timer, n.newGroup()),
+	), nil
+}
+
+func (n *ThresholdLearnNode) newGroup() *thresholdLearnGroup {
+	return &thresholdLearnGroup{
+		n:      n,
+		window: newNormalizeWindow(0, n.t.Window),
+	}
+}
+
+type thresholdLearnGroup struct {
+	n      *ThresholdLearnNode
+	window *normalizeWindow
+
+	// time of the first point of the group
+	first time.Time
+	// time the bounds were last computed, zero until the first bounds are learned
+	refreshed time.Time
+
+	lower, upper float64
+}
+
+func (g *thresholdLearnGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	begin = begin.ShallowCopy()
+	begin.SetSizeHint(0)
+	return begin, nil
+}
+
+func (g *thresholdLearnGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	fields, ok := g.learn(bp)
+	if !ok {
+		return nil, nil
+	}
+	bp = bp.ShallowCopy()
+	bp.SetFields(fields)
+	return bp, nil
+}
+
+func (g *thresholdLearnGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (g *thresholdLearnGroup) Point(p edge.PointMessage) (edge.Message, error) {
+	fields, ok := g.learn(p)
+	if !ok {
+		return nil, nil
+	}
+	p = p.ShallowCopy()
+	p.SetFields(fields)
+	return p, nil
+}
+
+// learn adds the value of p to the window and refreshes the bounds if they are due.
+// It returns the fields of p with the bounds added, or false if p should be dropped.
+func (g *thresholdLearnGroup) learn(p edge.FieldsTagsTimeGetter) (models.Fields, bool) {
+	value, ok := numToFloat(p.Fields()[g.n.t.Field])
+	if !ok {
+		g.n.diag.Error("cannot learn threshold",
+			errors.New("field is the wrong type"),
+			keyvalue.KV("field", g.n.t.Field),
+			keyvalue.KV("type", fmt.Sprintf("%T", p.Fields()[g.n.t.Field])),
+		)
+		return nil, false
+	}
+	t := p.Time()
+	if g.first.IsZero() {
+		g.first = t
+	}
+	g.window.add(t, value)
+	if g.refreshed.IsZero() {
+		// Still learning the first bounds.
+		if t.Sub(g.first) < g.n.t.Window {
+			return nil, false
+		}
+		g.refresh(t)
+	} else if t.Sub(g.refreshed) >= g.n.t.Refresh {
+		g.refresh(t)
+	}
+	fields := p.Fields().Copy()
+	fields[g.n.t.LowerAs] = g.lower
+	fields[g.n.t.UpperAs] = g.upper
+	return fields, true
+}
+
+// refresh computes the bounds from the values of the window.
+func (g *thresholdLearnGroup) refresh(t time.Time) {
+	g.refreshed = t
+	switch g.n.t.Method {
+	case pipeline.ThresholdLearnStddev:
+		mean, stddev := g.window.meanStddev()
+		g.lower = mean - g.n.t.K*stddev
+		g.upper = mean + g.n.t.K*stddev
+	case pipeline.ThresholdLearnPercentile:
+		sorted := make([]float64, len(g.window.values))
+		copy(sorted, g.window.values)
+		sort.Float64s(sorted)
+		g.lower = percentile(sorted, g.n.t.LowerPercentile)
+		g.upper = percentile(sorted, g.n.t.UpperPercentile)
+	}
+}
+
+func (g *thresholdLearnGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (g *thresholdLearnGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	// Release the window, the group is no longer referenced by the consumer.
+	g.window = nil
+	return d, nil
+}
+func (g *thresholdLearnGroup) Done() {}
@@ -0,0 +1,173 @@
+package kapacitor
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+type TimeInStateNode struct {
+	node
+	t *pipeline.TimeInStateNode
+}
+
+// Create a new TimeInStateNode which computes the time spent in each value of a state field per window.
+func newTimeInStateNode(et *ExecutingTask, n *pipeline.TimeInStateNode, d NodeDiagnostic) (*TimeInStateNode, error) {
+	tn := &TimeInStateNode{
+		node: node{Node: n, et: et, diag: d},
+		t:    n,
+	}
+	tn.node.runF = tn.runTimeInState
+	return tn, nil
+}
+
+func (n *TimeInStateNode) runTimeInState([]byte) error {
+	consumer := edge.NewGroupedConsumer(
+		n.ins[0],
+		n,
+	)
+	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
+	return consumer.Consume()
+}
+
+func (n *TimeInStateNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	return edge.NewReceiverFromForwardReceiverWithStats(
+		n.outs,
+		edge.NewTimedForwardReceiver(n.timer, &timeInStateGroup{n: n}),
+	), nil
+}
+
+type timeInStateGroup struct {
+	n *TimeInStateNode
+
+	// begin of the current window
+	begin edge.BeginBatchMessage
+	// durations of the states of the current window
+	durations map[string]time.Duration
+
+	// state is the last state of the group, valid if hasState is set.
+	state    string
+	hasState bool
+	// stateT is the time up to which the time in the last state has been accounted for.
+	stateT time.Time
+}
+
+// stateValue returns the value of the field with the given name as a string,
+// or the value of the tag with that name if p has no such field.
+// Returns false if p has neither.
+func stateValue(p edge.FieldsTagsTimeGetter, name string) (string, bool, error) {
+	if v, ok := p.Fields()[name]; ok {
+		switch v := v.(type) {
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64), true, nil
+		case int64:
+			return strconv.FormatInt(v, 10), true, nil
+		case string:
+			return v, true, nil
+		case bool:
+			return strconv.FormatBool(v), true, nil
+		default:
+			return "", false, fmt.Errorf("field %q has unsupported type %T", name, v)
+		}
+	}
+	v, ok := p.Tags()[name]
+	return v, ok, nil
+}
+
+// advance accounts for the time in the last state up to t.
+func (g *timeInStateGroup) advance(t time.Time) {
+	if !t.After(g.stateT) {
+		return
+	}
+	if g.hasState {
+		g.durations[g.state] += t.Sub(g.stateT)
+	}
+	g.stateT = t
+}
+
+func (g *timeInStateGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	g.begin = begin
+	g.durations = make(map[string]time.Duration)
+	if g.hasState {
+		// The state carried over from the previous window is part of the window.
+		g.durations[g.state] += 0
+	}
+	return nil, nil
+}
+
+func (g *timeInStateGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	if bp.Time().Before(g.stateT) {
+		return nil, nil
+	}
+	state, ok, err := stateValue(bp, g.n.t.Field)
+	if err != nil {
+		g.n.diag.Error("cannot determine state", err)
+		return nil, nil
+	}
+	if !ok {
+		return nil, nil
+	}
+	g.advance(bp.Time())
+	g.state = state
+	g.hasState = true
+	g.stateT = bp.Time()
+	g.durations[state] += 0
+	return nil, nil
+}
+
+// EndBatch closes the window, the last state lasts until the end of the window.
+// It returns a batch with a point per state of the window.
+func (g *timeInStateGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	t := g.begin.Time()
+	g.advance(t)
+	if len(g.durations) == 0 {
+		return nil, nil
+	}
+	states := make([]string, 0, len(g.durations))
+	for state := range g.durations {
+		states = append(states, state)
+	}
+	sort.Strings(states)
+
+	tags := g.begin.Tags()
+	points := make([]edge.BatchPointMessage, len(states))
+	for i, state := range states {
+		pointTags := make(models.Tags, len(tags)+1)
+		for k, v := range tags {
+			pointTags[k] = v
+		}
+		pointTags[g.n.t.Field] = state
+		points[i] = edge.NewBatchPointMessage(
+			models.Fields{g.n.t.As: float64(g.durations[state]) / float64(g.n.t.Unit)},
+			pointTags,
+			t,
+		)
+	}
+	begin := g.begin.ShallowCopy()
+	begin.SetSizeHint(len(points))
+	g.durations = nil
+	return edge.NewBufferedBatchMessage(begin, points, end), nil
+}
+
+func (g *timeInStateGroup) Point(p edge.PointMessage) (edge.Message, error) {
+	return p, nil
+}
+
+func (g *timeInStateGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+
+func (g *timeInStateGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	g.durations = nil
+	g.state = ""
+	g.hasState = false
+	g.stateT = time.Time{}
+	return d, nil
+}
+
+func (g *timeInStateGroup) Done() {}
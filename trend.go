@@ -0,0 +1,199 @@
+package kapacitor
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/keyvalue"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+type TrendNode struct {
+	node
+	t *pipeline.TrendNode
+}
+
+// Create a new TrendNode which computes the linear regression of a field of each group.
+func newTrendNode(et *ExecutingTask, n *pipeline.TrendNode, d NodeDiagnostic) (*TrendNode, error) {
+	tn := &TrendNode{
+		node: node{Node: n, et: et, diag: d},
+		t:    n,
+	}
+	tn.node.runF = tn.runTrend
+	return tn, nil
+}
+
+func (n *TrendNode) runTrend([]byte) error {
+	consumer := edge.NewGroupedConsumer(
+		n.ins[0],
+		n,
+	)
+	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
+	return consumer.Consume()
+}
+
+func (n *TrendNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	return edge.NewReceiverFromForwardReceiverWithStats(
+		n.outs,
+		edge.NewTimedForwardReceiver(n.timer, n.newGroup()),
+	), nil
+}
+
+func (n *TrendNode) newGroup() *trendGroup {
+	return &trendGroup{
+		n:      n,
+		window: newNormalizeWindow(int(n.t.Size), n.t.Period),
+	}
+}
+
+type trendGroup struct {
+	n      *TrendNode
+	window *normalizeWindow
+
+	begin edge.BeginBatchMessage
+	// newest point of the stream window, results copy its metadata
+	last edge.PointMessage
+}
+
+func (g *trendGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	g.begin = begin.ShallowCopy()
+	g.begin.SetSizeHint(1)
+	g.window.reset()
+	return nil, nil
+}
+
+func (g *trendGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	g.add(bp)
+	return nil, nil
+}
+
+func (g *trendGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	fields, t, ok := g.trend()
+	if !ok {
+		return nil, nil
+	}
+	return edge.NewBufferedBatchMessage(
+		g.begin,
+		[]edge.BatchPointMessage{edge.NewBatchPointMessage(fields, g.begin.Tags(), t)},
+		end,
+	), nil
+}
+
+func (g *trendGroup) Point(p edge.PointMessage) (edge.Message, error) {
+	if !g.add(p) {
+		return nil, nil
+	}
+	g.last = p
+	// Without a size or period the trend is emitted on barriers.
+	if g.n.t.Size == 0 && g.n.t.Period == 0 {
+		return nil, nil
+	}
+	return g.result()
+}
+
+// result returns a point with the trend of the window, or nil if there is no trend.
+func (g *trendGroup) result() (edge.Message, error) {
+	fields, t, ok := g.trend()
+	if !ok {
+		return nil, nil
+	}
+	np := g.last.ShallowCopy()
+	np.SetFields(fields)
+	np.SetTime(t)
+	return np, nil
+}
+
+// add adds the value of p to the window and reports whether it was added.
+func (g *trendGroup) add(p edge.FieldsTagsTimeGetter) bool {
+	value, ok := numToFloat(p.Fields()[g.n.t.Field])
+	if !ok {
+		g.n.diag.Error("cannot compute trend",
+			errors.New("field is the wrong type"),
+			keyvalue.KV("field", g.n.t.Field),
+			keyvalue.KV("type", fmt.Sprintf("%T", p.Fields()[g.n.t.Field])),
+		)
+		return false
+	}
+	g.window.add(p.Time(), value)
+	return true
+}
+
+// trend returns the fields of the regression of the window and the time of the result.
+func (g *trendGroup) trend() (models.Fields, time.Time, bool) {
+	if len(g.window.times) < 2 {
+		return nil, time.Time{}, false
+	}
+	t := g.window.times[len(g.window.times)-1]
+	slope, intercept, r2, ok := linearRegression(g.window.times, g.window.values, t, g.n.t.Unit)
+	if !ok {
+		return nil, time.Time{}, false
+	}
+	return models.Fields{
+		g.n.t.SlopeAs:     slope,
+		g.n.t.InterceptAs: intercept,
+		g.n.t.R2As:        r2,
+	}, t, true
+}
+
+func (g *trendGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	if g.n.t.Size == 0 && g.n.t.Period == 0 && g.last != nil {
+		m, err := g.result()
+		if err != nil {
+			return nil, err
+		}
+		if m != nil {
+			if err := edge.Forward(g.n.outs, m); err != nil {
+				return nil, err
+			}
+		}
+		g.window.reset()
+		g.last = nil
+	}
+	return b, nil
+}
+func (g *trendGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	// Release the window, the group is no longer referenced by the consumer.
+	g.window = nil
+	g.last = nil
+	return d, nil
+}
+func (g *trendGroup) Done() {}
+
+// linearRegression fits a line to the values at times using least squares.
+// Times are measured in units relative to ref, so the intercept is the value of the line at ref.
+// Returns false if the times are all equal.
+func linearRegression(times []time.Time, values []float64, ref time.Time, unit time.Duration) (slope, intercept, r2 float64, ok bool) {
+	n := float64(len(values))
+	xs := make([]float64, len(times))
+	var meanX, meanY float64
+	for i, t := range times {
+		xs[i] = float64(t.Sub(ref)) / float64(unit)
+		meanX += xs[i]
+		meanY += values[i]
+	}
+	meanX /= n
+	meanY /= n
+
+	var sxx, sxy, syy float64
+	for i, x := range xs {
+		dx, dy := x-meanX, values[i]-meanY
+		sxx += dx * dx
+		sxy += dx * dy
+		syy += dy * dy
+	}
+	if sxx == 0 {
+		return 0, 0, 0, false
+	}
+	slope = sxy / sxx
+	intercept = meanY - slope*meanX
+	if syy == 0 {
+		// The values are constant, which the line fits perfectly.
+		r2 = 1
+	} else {
+		r2 = sxy * sxy / (sxx * syy)
+	}
+	return slope, intercept, r2, true
+}
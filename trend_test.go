@@ -0,0 +1,53 @@
+package kapacitor
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestLinearRegression(t *testing.T) {
+	t0 := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	// value = 10 + 2 * seconds, with noise
+	rnd := rand.New(rand.NewSource(1))
+	const count = 100
+	times := make([]time.Time, count)
+	values := make([]float64, count)
+	for i := range times {
+		times[i] = t0.Add(time.Duration(i) * time.Second)
+		values[i] = 10 + 2*float64(i) + rnd.NormFloat64()
+	}
+	ref := times[count-1]
+	slope, intercept, r2, ok := linearRegression(times, values, ref, time.Second)
+	if !ok {
+		t.Fatal("expected regression")
+	}
+	if math.Abs(slope-2) > 0.05 {
+		t.Errorf("unexpected slope: got %v exp about 2", slope)
+	}
+	if exp := 10 + 2*float64(count-1); math.Abs(intercept-exp) > 1 {
+		t.Errorf("unexpected intercept: got %v exp about %v", intercept, exp)
+	}
+	if r2 < 0.99 || r2 > 1 {
+		t.Errorf("unexpected r2: got %v exp about 1", r2)
+	}
+
+	// The slope is per unit.
+	slope, _, _, _ = linearRegression(times, values, ref, time.Minute)
+	if math.Abs(slope-120) > 3 {
+		t.Errorf("unexpected slope per minute: got %v exp about 120", slope)
+	}
+
+	// Pure noise has no trend.
+	for i := range values {
+		values[i] = rnd.NormFloat64()
+	}
+	if _, _, r2, _ := linearRegression(times, values, ref, time.Second); r2 > 0.1 {
+		t.Errorf("unexpected r2 for noise: got %v exp about 0", r2)
+	}
+
+	if _, _, _, ok := linearRegression([]time.Time{t0, t0}, []float64{1, 2}, t0, time.Second); ok {
+		t.Error("expected no regression for equal times")
+	}
+}
@@ -0,0 +1,264 @@
+package kapacitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/keyvalue"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+const (
+	statsInvalidDeltas = "invalid_deltas"
+)
+
+// upDownCounterGroupStateVersion is the version of the encoding of the state of the counters.
+const upDownCounterGroupStateVersion = 1
+
+type UpDownCounterNode struct {
+	node
+	c *pipeline.UpDownCounterNode
+
+	invalidDeltas *expvar.Int
+
+	// groupStates keeps the totals of the groups for the task snapshots, if they are persisted.
+	groupStates *groupStates
+}
+
+// Create a new UpDownCounterNode which maintains a running total per group of a field of deltas.
+func newUpDownCounterNode(et *ExecutingTask, n *pipeline.UpDownCounterNode, d NodeDiagnostic) (*UpDownCounterNode, error) {
+	cn := &UpDownCounterNode{
+		node:          node{Node: n, et: et, diag: d},
+		c:             n,
+		invalidDeltas: new(expvar.Int),
+	}
+	if n.PersistStateFlag {
+		cn.groupStates = newGroupStates(upDownCounterGroupStateVersion, d)
+	}
+	cn.node.runF = cn.runUpDownCounter
+	return cn, nil
+}
+
+func (n *UpDownCounterNode) runUpDownCounter(snapshot []byte) error {
+	n.statMap.Set(statsInvalidDeltas, n.invalidDeltas)
+	if n.groupStates != nil {
+		n.groupStates.load(snapshot)
+	}
+	consumer := edge.NewGroupedConsumer(
+		n.ins[0],
+		n,
+	)
+	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
+	return consumer.Consume()
+}
+
+// snapshot returns the totals of the groups if they are persisted.
+func (n *UpDownCounterNode) snapshot() ([]byte, error) {
+	if n.groupStates == nil {
+		return nil, nil
+	}
+	return n.groupStates.snapshot()
+}
+
+func (n *UpDownCounterNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	g := n.newGroup(group)
+	var r edge.ForwardReceiver = g
+	if n.groupStates != nil {
+		r = n.groupStates.add(group.ID, g, g)
+	}
+	return edge.NewReceiverFromForwardReceiverWithStats(
+		n.outs,
+		edge.NewTimedForwardReceiver(n.timer, r),
+	), nil
+}
+
+func (n *UpDownCounterNode) newGroup(group edge.GroupInfo) *upDownCounterGroup {
+	return &upDownCounterGroup{
+		n:     n,
+		group: group,
+	}
+}
+
+// upDownTotal is a running total, an integer until a float is added to it.
+type upDownTotal struct {
+	Int     int64   `json:"int,omitempty"`
+	Float   float64 `json:"float,omitempty"`
+	IsFloat bool    `json:"isFloat,omitempty"`
+}
+
+// add adds a delta to the total, returns false if the delta is not numeric.
+func (t *upDownTotal) add(delta interface{}, floor bool) bool {
+	switch d := delta.(type) {
+	case int64:
+		if t.IsFloat {
+			t.Float += float64(d)
+		} else {
+			t.Int += d
+		}
+	case float64:
+		if !t.IsFloat {
+			t.Float = float64(t.Int)
+			t.Int = 0
+			t.IsFloat = true
+		}
+		t.Float += d
+	default:
+		return false
+	}
+	if floor {
+		if t.Int < 0 {
+			t.Int = 0
+		}
+		if t.Float < 0 {
+			t.Float = 0
+		}
+	}
+	return true
+}
+
+func (t upDownTotal) value() interface{} {
+	if t.IsFloat {
+		return t.Float
+	}
+	return t.Int
+}
+
+type upDownCounterGroup struct {
+	n     *UpDownCounterNode
+	group edge.GroupInfo
+
+	total upDownTotal
+	// nextEmit is the time of the next emit with every, zero until the first point.
+	nextEmit time.Time
+
+	// name, database and retention policy of the last point, used for the emitted points.
+	name, db, rp string
+}
+
+// upDownCounterState is the state of a group in the task snapshots.
+type upDownCounterState struct {
+	Total    upDownTotal `json:"total"`
+	NextEmit time.Time   `json:"nextEmit"`
+}
+
+func (g *upDownCounterGroup) snapshotState() ([]byte, error) {
+	return json.Marshal(upDownCounterState{
+		Total:    g.total,
+		NextEmit: g.nextEmit,
+	})
+}
+
+func (g *upDownCounterGroup) restoreState(data []byte) error {
+	var s upDownCounterState
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	g.total = s.Total
+	g.nextEmit = s.NextEmit
+	return nil
+}
+
+func (g *upDownCounterGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	if s := begin.SizeHint(); s > 0 {
+		begin = begin.ShallowCopy()
+		begin.SetSizeHint(0)
+	}
+	return begin, nil
+}
+
+func (g *upDownCounterGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	if !g.add(bp) {
+		return nil, nil
+	}
+	bp = bp.ShallowCopy()
+	g.setTotal(bp)
+	return bp, nil
+}
+
+func (g *upDownCounterGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (g *upDownCounterGroup) Point(p edge.PointMessage) (edge.Message, error) {
+	if g.n.c.Every == 0 {
+		if !g.add(p) {
+			return nil, nil
+		}
+		p = p.ShallowCopy()
+		g.setTotal(p)
+		return p, nil
+	}
+
+	g.name, g.db, g.rp = p.Name(), p.Database(), p.RetentionPolicy()
+	msg := g.emit(p.Time())
+	g.add(p)
+	return msg, nil
+}
+
+// add adds the delta of the point to the total, returns false if the point has no numeric delta.
+func (g *upDownCounterGroup) add(p edge.FieldsTagsTimeGetter) bool {
+	delta := p.Fields()[g.n.c.Field]
+	if !g.total.add(delta, g.n.c.FloorFlag) {
+		g.n.invalidDeltas.Add(1)
+		g.n.diag.Error("invalid field in upDownCounter",
+			fmt.Errorf("expected numeric field %s, got %T", g.n.c.Field, delta),
+			keyvalue.KV("field", g.n.c.Field))
+		return false
+	}
+	return true
+}
+
+func (g *upDownCounterGroup) setTotal(p edge.FieldsTagsTimeSetter) {
+	fields := p.Fields().Copy()
+	fields[g.n.c.As] = g.total.value()
+	p.SetFields(fields)
+}
+
+// emit returns a point with the total if the time t reached the next emit,
+// with the time of the last multiple of every before t.
+// It returns nil if no point is due, the first call only schedules the next emit.
+func (g *upDownCounterGroup) emit(t time.Time) edge.Message {
+	every := g.n.c.Every
+	if g.nextEmit.IsZero() {
+		g.nextEmit = t.Truncate(every).Add(every)
+		return nil
+	}
+	if t.Before(g.nextEmit) {
+		return nil
+	}
+	emitTime := t.Truncate(every)
+	g.nextEmit = emitTime.Add(every)
+	return edge.NewPointMessage(
+		g.name, g.db, g.rp,
+		g.group.Dimensions,
+		models.Fields{g.n.c.As: g.total.value()},
+		g.group.Tags,
+		emitTime,
+	)
+}
+
+// Barrier emits the total if it is due with every, and resets the total with resetOnBarrier.
+func (g *upDownCounterGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	if g.n.c.Every != 0 && !g.nextEmit.IsZero() {
+		if msg := g.emit(b.Time()); msg != nil {
+			if err := edge.Forward(g.n.outs, msg); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if g.n.c.ResetOnBarrierFlag {
+		g.total = upDownTotal{}
+	}
+	return b, nil
+}
+
+func (g *upDownCounterGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	g.total = upDownTotal{}
+	g.nextEmit = time.Time{}
+	return d, nil
+}
+func (g *upDownCounterGroup) Done() {}
@@ -0,0 +1,154 @@
+package kapacitor
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/influxdata/kapacitor/avro"
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/keyvalue"
+	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+const (
+	statsValidateSchemaValid          = "valid"
+	statsValidateSchemaInvalid        = "invalid"
+	statsValidateSchemaMissingFields  = "missing_fields"
+	statsValidateSchemaTypeMismatches = "type_mismatches"
+	statsValidateSchemaUnknownFields  = "unknown_fields"
+)
+
+// reasonUnknownField is the reason for a field of a point that is not in the schema.
+const reasonUnknownField = "unknown_field"
+
+type ValidateSchemaNode struct {
+	node
+	v *pipeline.ValidateSchemaNode
+
+	schema *avro.Schema
+	// name of the current batch
+	batchName string
+
+	valid   *expvar.Int
+	invalid *expvar.Int
+	// reasons counts the invalid points by reason.
+	reasons map[string]*expvar.Int
+}
+
+// Create a new ValidateSchemaNode which drops the points that do not conform to a schema.
+func newValidateSchemaNode(et *ExecutingTask, n *pipeline.ValidateSchemaNode, d NodeDiagnostic) (*ValidateSchemaNode, error) {
+	var s *avro.Schema
+	var err error
+	if n.Schema != "" {
+		s, err = avro.ParseSchema(n.Schema)
+	} else {
+		s, _, err = avro.NewRegistry(n.RegistryURL, n.Timeout).Latest(n.Subject)
+	}
+	if err != nil {
+		return nil, err
+	}
+	vn := &ValidateSchemaNode{
+		node:    node{Node: n, et: et, diag: d},
+		v:       n,
+		schema:  s,
+		valid:   new(expvar.Int),
+		invalid: new(expvar.Int),
+		reasons: map[string]*expvar.Int{
+			avro.ReasonMissingField: new(expvar.Int),
+			avro.ReasonTypeMismatch: new(expvar.Int),
+			reasonUnknownField:      new(expvar.Int),
+		},
+	}
+	vn.node.runF = vn.runValidateSchema
+	return vn, nil
+}
+
+func (n *ValidateSchemaNode) runValidateSchema([]byte) error {
+	n.statMap.Set(statsValidateSchemaValid, n.valid)
+	n.statMap.Set(statsValidateSchemaInvalid, n.invalid)
+	n.statMap.Set(statsValidateSchemaMissingFields, n.reasons[avro.ReasonMissingField])
+	n.statMap.Set(statsValidateSchemaTypeMismatches, n.reasons[avro.ReasonTypeMismatch])
+	n.statMap.Set(statsValidateSchemaUnknownFields, n.reasons[reasonUnknownField])
+
+	consumer := edge.NewConsumerWithReceiver(
+		n.ins[0],
+		edge.NewReceiverFromForwardReceiverWithStats(
+			n.outs,
+			edge.NewTimedForwardReceiver(n.timer, n),
+		),
+	)
+	return consumer.Consume()
+}
+
+// conforms reports whether a point conforms to the schema,
+// points that do not are counted and sent to the dead letter queue.
+func (n *ValidateSchemaNode) conforms(name string, p edge.FieldsTagsTimeGetter) bool {
+	err := n.check(p.Time(), p.Tags(), p.Fields())
+	if err == nil {
+		n.valid.Add(1)
+		return true
+	}
+	n.invalid.Add(1)
+	if c, ok := n.reasons[err.Reason]; ok {
+		c.Add(1)
+	}
+	n.diag.Error("point does not conform to schema", err, keyvalue.KV("reason", err.Reason))
+	n.deadLetter(name, p, err)
+	return false
+}
+
+func (n *ValidateSchemaNode) check(t time.Time, tags models.Tags, fields models.Fields) *avro.ValidationError {
+	if n.v.StrictFlag {
+		for k := range fields {
+			if name := avro.Name(k); !n.schema.HasField(name) {
+				return &avro.ValidationError{
+					Field:  name,
+					Reason: reasonUnknownField,
+					Err:    fmt.Errorf("field %q is not in the schema", k),
+				}
+			}
+		}
+	}
+	if err := n.schema.Validate(avroValues(t, tags, fields)); err != nil {
+		if verr, ok := err.(*avro.ValidationError); ok {
+			return verr
+		}
+		return &avro.ValidationError{Reason: avro.ReasonTypeMismatch, Err: err}
+	}
+	return nil
+}
+
+func (n *ValidateSchemaNode) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	n.batchName = begin.Name()
+	return begin, nil
+}
+
+func (n *ValidateSchemaNode) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	if !n.conforms(n.batchName, bp) {
+		return nil, nil
+	}
+	return bp, nil
+}
+
+func (n *ValidateSchemaNode) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (n *ValidateSchemaNode) Point(p edge.PointMessage) (edge.Message, error) {
+	if !n.conforms(p.Name(), p) {
+		return nil, nil
+	}
+	return p, nil
+}
+
+func (n *ValidateSchemaNode) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+
+func (n *ValidateSchemaNode) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+
+func (n *ValidateSchemaNode) Done() {}
@@ -0,0 +1,118 @@
+package kapacitor
+
+import (
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+const (
+	statsBadTimestamps = "bad_timestamps"
+)
+
+type ValidateTimeNode struct {
+	node
+	v *pipeline.ValidateTimeNode
+
+	// now returns the current time the bounds are relative to
+	now func() time.Time
+
+	badTimestamps *expvar.Int
+}
+
+// Create a new ValidateTimeNode which rejects points with implausible timestamps.
+func newValidateTimeNode(et *ExecutingTask, n *pipeline.ValidateTimeNode, d NodeDiagnostic) (*ValidateTimeNode, error) {
+	vn := &ValidateTimeNode{
+		node:          node{Node: n, et: et, diag: d},
+		v:             n,
+		now:           time.Now,
+		badTimestamps: new(expvar.Int),
+	}
+	vn.node.runF = vn.runValidateTime
+	return vn, nil
+}
+
+func (n *ValidateTimeNode) runValidateTime([]byte) error {
+	n.statMap.Set(statsBadTimestamps, n.badTimestamps)
+	consumer := edge.NewConsumerWithReceiver(
+		n.ins[0],
+		edge.NewReceiverFromForwardReceiverWithStats(
+			n.outs,
+			edge.NewTimedForwardReceiver(n.timer, n),
+		),
+	)
+	return consumer.Consume()
+}
+
+// validateTime returns the time to use for a point with time t and whether the point should be kept.
+func validateTime(t, now time.Time, maxFuture, maxPast time.Duration, action string) (time.Time, bool) {
+	var bound time.Time
+	switch {
+	case maxFuture > 0 && t.After(now.Add(maxFuture)):
+		bound = now.Add(maxFuture)
+	case maxPast > 0 && t.Before(now.Add(-maxPast)):
+		bound = now.Add(-maxPast)
+	default:
+		return t, true
+	}
+	if action == pipeline.ValidateTimeClamp {
+		return bound, true
+	}
+	return t, false
+}
+
+// checkTime returns the time to use for a point with time t and whether the point should be kept.
+func (n *ValidateTimeNode) checkTime(t time.Time) (time.Time, bool) {
+	valid, ok := validateTime(t, n.now(), n.v.MaxFuture, n.v.MaxPast, n.v.Action)
+	if !ok || !valid.Equal(t) {
+		n.badTimestamps.Add(1)
+	}
+	return valid, ok
+}
+
+func (n *ValidateTimeNode) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	if n.v.Action == pipeline.ValidateTimeDrop {
+		// Points may be dropped, so the size of the batch is not known.
+		begin = begin.ShallowCopy()
+		begin.SetSizeHint(0)
+	}
+	return begin, nil
+}
+
+func (n *ValidateTimeNode) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	t, ok := n.checkTime(bp.Time())
+	if !ok {
+		return nil, nil
+	}
+	if !t.Equal(bp.Time()) {
+		bp = bp.ShallowCopy()
+		bp.SetTime(t)
+	}
+	return bp, nil
+}
+
+func (n *ValidateTimeNode) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (n *ValidateTimeNode) Point(p edge.PointMessage) (edge.Message, error) {
+	t, ok := n.checkTime(p.Time())
+	if !ok {
+		return nil, nil
+	}
+	if !t.Equal(p.Time()) {
+		p = p.ShallowCopy()
+		p.SetTime(t)
+	}
+	return p, nil
+}
+
+func (n *ValidateTimeNode) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (n *ValidateTimeNode) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (n *ValidateTimeNode) Done() {}
@@ -0,0 +1,100 @@
+package kapacitor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+func TestValidateTime(t *testing.T) {
+	now := time.Date(2018, 1, 1, 12, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name      string
+		t         time.Time
+		maxFuture time.Duration
+		maxPast   time.Duration
+		action    string
+		exp       time.Time
+		expOK     bool
+	}{
+		{
+			name:      "within bounds",
+			t:         now.Add(30 * time.Minute),
+			maxFuture: time.Hour,
+			maxPast:   time.Hour,
+			action:    pipeline.ValidateTimeDrop,
+			exp:       now.Add(30 * time.Minute),
+			expOK:     true,
+		},
+		{
+			name:      "on future bound",
+			t:         now.Add(time.Hour),
+			maxFuture: time.Hour,
+			action:    pipeline.ValidateTimeDrop,
+			exp:       now.Add(time.Hour),
+			expOK:     true,
+		},
+		{
+			name:      "future drop",
+			t:         time.Date(2286, 11, 20, 17, 46, 39, 0, time.UTC),
+			maxFuture: time.Hour,
+			maxPast:   time.Hour,
+			action:    pipeline.ValidateTimeDrop,
+			expOK:     false,
+		},
+		{
+			name:      "past drop",
+			t:         now.Add(-2 * time.Hour),
+			maxFuture: time.Hour,
+			maxPast:   time.Hour,
+			action:    pipeline.ValidateTimeDrop,
+			expOK:     false,
+		},
+		{
+			name:      "no past bound",
+			t:         now.Add(-24 * time.Hour),
+			maxFuture: time.Hour,
+			action:    pipeline.ValidateTimeDrop,
+			exp:       now.Add(-24 * time.Hour),
+			expOK:     true,
+		},
+		{
+			name:    "no future bound",
+			t:       now.Add(24 * time.Hour),
+			maxPast: time.Hour,
+			action:  pipeline.ValidateTimeDrop,
+			exp:     now.Add(24 * time.Hour),
+			expOK:   true,
+		},
+		{
+			name:      "future clamp",
+			t:         now.Add(2 * time.Hour),
+			maxFuture: time.Hour,
+			maxPast:   time.Hour,
+			action:    pipeline.ValidateTimeClamp,
+			exp:       now.Add(time.Hour),
+			expOK:     true,
+		},
+		{
+			name:      "past clamp",
+			t:         now.Add(-2 * time.Hour),
+			maxFuture: time.Hour,
+			maxPast:   time.Hour,
+			action:    pipeline.ValidateTimeClamp,
+			exp:       now.Add(-time.Hour),
+			expOK:     true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := validateTime(tc.t, now, tc.maxFuture, tc.maxPast, tc.action)
+			if ok != tc.expOK {
+				t.Fatalf("unexpected ok: got %v exp %v", ok, tc.expOK)
+			}
+			if ok && !got.Equal(tc.exp) {
+				t.Errorf("unexpected time: got %v exp %v", got, tc.exp)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package kapacitor
+
+import (
+	"time"
+
+	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/expvar"
+	"github.com/influxdata/kapacitor/pipeline"
+)
+
+const (
+	statsPointsSuppressed = "points_suppressed"
+)
+
+type WarmupNode struct {
+	node
+	w *pipeline.WarmupNode
+
+	pointsSuppressed *expvar.Int
+}
+
+// Create a new WarmupNode which suppresses output of each group until it has warmed up.
+func newWarmupNode(et *ExecutingTask, n *pipeline.WarmupNode, d NodeDiagnostic) (*WarmupNode, error) {
+	wn := &WarmupNode{
+		node:             node{Node: n, et: et, diag: d},
+		w:                n,
+		pointsSuppressed: new(expvar.Int),
+	}
+	wn.node.runF = wn.runWarmup
+	return wn, nil
+}
+
+func (n *WarmupNode) runWarmup([]byte) error {
+	n.statMap.Set(statsPointsSuppressed, n.pointsSuppressed)
+	consumer := edge.NewGroupedConsumer(
+		n.ins[0],
+		n,
+	)
+	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
+	return consumer.Consume()
+}
+
+func (n *WarmupNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	return edge.NewReceiverFromForwardReceiverWithStats(
+		n.outs,
+		edge.NewTimedForwardReceiver(n.timer, n.newGroup()),
+	), nil
+}
+
+func (n *WarmupNode) newGroup() *warmupGroup {
+	return &warmupGroup{
+		n: n,
+	}
+}
+
+type warmupGroup struct {
+	n *WarmupNode
+
+	first time.Time
+	count int64
+	warm  bool
+
+	// suppressBatch is whether the current batch is suppressed.
+	suppressBatch bool
+}
+
+// warmedUp reports whether the group has warmed up by time t.
+// Once warmed up a group stays warmed up.
+func (g *warmupGroup) warmedUp(t time.Time) bool {
+	if g.warm {
+		return true
+	}
+	if g.first.IsZero() {
+		g.first = t
+	}
+	g.warm = (g.n.w.Period > 0 && t.Sub(g.first) >= g.n.w.Period) ||
+		(g.n.w.Count > 0 && g.count >= g.n.w.Count)
+	return g.warm
+}
+
+func (g *warmupGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	g.suppressBatch = !g.warmedUp(begin.Time())
+	if g.suppressBatch {
+		return nil, nil
+	}
+	return begin, nil
+}
+
+func (g *warmupGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	g.count++
+	if g.suppressBatch {
+		g.n.pointsSuppressed.Add(1)
+		return nil, nil
+	}
+	return bp, nil
+}
+
+func (g *warmupGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	if g.suppressBatch {
+		return nil, nil
+	}
+	return end, nil
+}
+
+func (g *warmupGroup) Point(p edge.PointMessage) (edge.Message, error) {
+	warm := g.warmedUp(p.Time())
+	g.count++
+	if !warm {
+		g.n.pointsSuppressed.Add(1)
+		return nil, nil
+	}
+	return p, nil
+}
+
+func (g *warmupGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (g *warmupGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (g *warmupGroup) Done() {}
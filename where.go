@@ -41,10 +41,7 @@ func newWhereNode(et *ExecutingTask, n *pipeline.WhereNode, d NodeDiagnostic) (w
 }
 
 func (n *WhereNode) runWhere(snapshot []byte) error {
-	consumer := edge.NewGroupedConsumer(
-		n.ins[0],
-		n,
-	)
+	consumer := n.newGroupedConsumer(n)
 	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
 
 	return consumer.Consume()
@@ -67,16 +64,20 @@ func (n *WhereNode) newGroup() *whereGroup {
 type whereGroup struct {
 	n    *WhereNode
 	expr stateful.Expression
+
+	// name of the current batch
+	name string
 }
 
 func (g *whereGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	g.name = begin.Name()
 	begin = begin.ShallowCopy()
 	begin.SetSizeHint(0)
 	return begin, nil
 }
 
 func (g *whereGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
-	return g.doWhere(bp)
+	return g.doWhere(g.name, bp)
 }
 
 func (g *whereGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
@@ -84,13 +85,14 @@ func (g *whereGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
 }
 
 func (g *whereGroup) Point(p edge.PointMessage) (edge.Message, error) {
-	return g.doWhere(p)
+	return g.doWhere(p.Name(), p)
 }
 
-func (g *whereGroup) doWhere(p edge.FieldsTagsTimeGetterMessage) (edge.Message, error) {
+func (g *whereGroup) doWhere(name string, p edge.FieldsTagsTimeGetterMessage) (edge.Message, error) {
 	pass, err := EvalPredicate(g.expr, g.n.scopePool, p)
 	if err != nil {
 		g.n.diag.Error("error while evaluating expression", err)
+		g.n.deadLetter(name, p, err)
 		return nil, nil
 	}
 	if pass {
@@ -3,32 +3,62 @@ package kapacitor
 import (
 	"errors"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/expvar"
 	"github.com/influxdata/kapacitor/models"
 	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+	"github.com/influxdata/kapacitor/tick/stateful"
+)
+
+const (
+	statsLatePoints = "late_points"
 )
 
 type WindowNode struct {
 	node
 	w *pipeline.WindowNode
+
+	// dataOuts receive the windows, lateOuts the late points.
+	dataOuts []edge.StatsEdge
+	lateOuts []edge.StatsEdge
+
+	latePoints *expvar.Int
+
+	trigger          stateful.Expression
+	triggerScopePool stateful.ScopePool
 }
 
 // Create a new  WindowNode, which windows data for a period of time and emits the window.
 func newWindowNode(et *ExecutingTask, n *pipeline.WindowNode, d NodeDiagnostic) (*WindowNode, error) {
-	if n.Period == 0 && n.PeriodCount == 0 {
-		return nil, errors.New("window node must have either a non zero period or non zero period count")
+	if n.Period == 0 && n.PeriodCount == 0 && n.FlushPeriod == 0 {
+		return nil, errors.New("window node must have either a non zero period, non zero period count or periodOrCount")
 	}
 	wn := &WindowNode{
-		w:    n,
-		node: node{Node: n, et: et, diag: d},
+		w:          n,
+		node:       node{Node: n, et: et, diag: d},
+		latePoints: new(expvar.Int),
+	}
+	if n.Trigger != nil {
+		expr, err := stateful.NewExpression(n.Trigger.Expression)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to compile trigger expression: %v", err)
+		}
+		wn.trigger = expr
+		wn.triggerScopePool = stateful.NewScopePool(ast.FindReferenceVariables(n.Trigger.Expression))
 	}
 	wn.node.runF = wn.runWindow
 	return wn, nil
 }
 
 func (n *WindowNode) runWindow([]byte) (err error) {
+	n.mapOuts()
+	if n.w.WatermarkFlag {
+		n.statMap.Set(statsLatePoints, n.latePoints)
+	}
 	consumer := edge.NewGroupedConsumer(n.ins[0], n)
 	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
 	err = consumer.Consume()
@@ -41,18 +71,60 @@ func (n *WindowNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.
 		return nil, err
 	}
 	return edge.NewReceiverFromForwardReceiverWithStats(
-		n.outs,
+		n.dataOuts,
 		edge.NewTimedForwardReceiver(n.timer, r),
 	), nil
 }
 
+// mapOuts separates the output edges of the late branch from the other output edges.
+func (n *WindowNode) mapOuts() {
+	if !n.w.LateOutputFlag {
+		n.dataOuts, n.lateOuts = n.outs, nil
+		return
+	}
+	branches := make(map[pipeline.ID]bool)
+	for _, c := range n.w.Children() {
+		if _, ok := c.(*pipeline.SplitBranchNode); ok {
+			branches[c.ID()] = true
+		}
+	}
+	n.dataOuts, n.lateOuts = nil, nil
+	for i, child := range n.children {
+		if branches[child.ID()] {
+			n.lateOuts = append(n.lateOuts, n.outs[i])
+		} else {
+			n.dataOuts = append(n.dataOuts, n.outs[i])
+		}
+	}
+}
+
 func (n *WindowNode) DeleteGroup(group models.GroupID) {
 	// Nothing to do
 }
 
 func (n *WindowNode) newWindow(group edge.GroupInfo, first edge.PointMeta) (edge.ForwardReceiver, error) {
 	switch {
+	case n.w.WatermarkFlag:
+		return newWindowByWatermark(
+			first.Name(),
+			group,
+			n.w.Period,
+			n.w.Every,
+			n.w.Lateness,
+			n,
+		), nil
+	case n.w.FlushPeriod != 0:
+		return newWindowByPeriodOrCount(
+			first.Name(),
+			group,
+			n.w.FlushPeriod,
+			int(n.w.FlushCount),
+		), nil
 	case n.w.Period != 0:
+		var trigger stateful.Expression
+		if n.trigger != nil {
+			trigger = n.trigger.CopyReset()
+		}
 		return newWindowByTime(
 			first.Name(),
 			first.Time(),
@@ -61,6 +133,10 @@ func (n *WindowNode) newWindow(group edge.GroupInfo, first edge.PointMeta) (edge
 			n.w.Every,
 			n.w.AlignFlag,
 			n.w.FillPeriodFlag,
+			n.w.FillLastFlag,
+			int(n.w.FillLastMax),
+			trigger,
+			n,
 			n.diag,
 		), nil
 	case n.w.PeriodCount != 0:
@@ -91,6 +167,17 @@ type windowByTime struct {
 	period time.Duration
 	every  time.Duration
 
+	fillLast    bool
+	fillLastMax int
+	// last is the last point of the last window that was not empty.
+	last edge.BatchPointMessage
+	// filled is the number of consecutive empty windows that have been filled.
+	filled int
+
+	// trigger flushes the window when a point satisfies it, nil without a trigger.
+	trigger stateful.Expression
+	n       *WindowNode
+
 	diag NodeDiagnostic
 }
 
@@ -101,7 +188,11 @@ func newWindowByTime(
 	period,
 	every time.Duration,
 	align,
-	fillPeriod bool,
+	fillPeriod,
+	fillLast bool,
+	fillLastMax int,
+	trigger stateful.Expression,
+	n *WindowNode,
 	d NodeDiagnostic,
 
 ) *windowByTime {
@@ -125,15 +216,19 @@ func newWindowByTime(
 		}
 	}
 	return &windowByTime{
-		name:       name,
-		group:      group,
-		nextEmit:   nextEmit,
-		buf:        &windowTimeBuffer{diag: d},
-		align:      align,
-		fillPeriod: fillPeriod,
-		period:     period,
-		every:      every,
-		diag:       d,
+		name:        name,
+		group:       group,
+		nextEmit:    nextEmit,
+		buf:         &windowTimeBuffer{diag: d},
+		align:       align,
+		fillPeriod:  fillPeriod,
+		period:      period,
+		every:       every,
+		fillLast:    fillLast,
+		fillLastMax: fillLastMax,
+		trigger:     trigger,
+		n:           n,
+		diag:        d,
 	}
 }
 
@@ -221,13 +316,49 @@ func (w *windowByTime) Point(p edge.PointMessage) (msg edge.Message, err error)
 		// Insert point after.
 		w.buf.insert(p)
 	}
+	if w.trigger != nil && w.triggered(p) {
+		if msg != nil {
+			// The point closed the previous window as well, emit it before the flushed window.
+			if err := edge.Forward(w.n.dataOuts, msg); err != nil {
+				return nil, err
+			}
+		}
+		msg = w.flush(p.Time())
+	}
 	return
 }
 
+// triggered reports whether the point satisfies the trigger expression.
+// Errors are logged and do not flush the window.
+func (w *windowByTime) triggered(p edge.PointMessage) bool {
+	ok, err := EvalPredicate(w.trigger, w.n.triggerScopePool, p)
+	if err != nil {
+		w.diag.Error("error evaluating trigger expression", err)
+		return false
+	}
+	return ok
+}
+
+// flush returns the points of the current window up to and including the time t as a batch with the time t
+// and removes them from the window.
+// With triggerRealign the next emit is scheduled one every after t.
+func (w *windowByTime) flush(t time.Time) edge.BufferedBatchMessage {
+	w.buf.purge(w.nextEmit.Add(-1*w.period), true)
+	msg := w.batch(t)
+	w.buf.purge(t, false)
+	if w.n.w.TriggerRealignFlag {
+		w.nextEmit = t.Add(w.every)
+	}
+	return msg
+}
+
 // batch returns the current window buffer as a batch message.
 // TODO(nathanielc): A possible optimization could be to not buffer the data at all if we know that we do not have overlapping windows.
 func (w *windowByTime) batch(tmax time.Time) edge.BufferedBatchMessage {
 	points := w.buf.points()
+	if w.fillLast {
+		points = w.fill(points, tmax)
+	}
 	return edge.NewBufferedBatchMessage(
 		edge.NewBeginBatchMessage(
 			w.name,
@@ -241,6 +372,23 @@ func (w *windowByTime) batch(tmax time.Time) edge.BufferedBatchMessage {
 	)
 }
 
+// fill returns the last point of the previous window with the time tmax if points is empty,
+// unless the maximum number of consecutive empty windows has already been filled.
+func (w *windowByTime) fill(points []edge.BatchPointMessage, tmax time.Time) []edge.BatchPointMessage {
+	if len(points) > 0 {
+		w.last = points[len(points)-1]
+		w.filled = 0
+		return points
+	}
+	if w.last == nil || (w.fillLastMax > 0 && w.filled >= w.fillLastMax) {
+		return points
+	}
+	w.filled++
+	p := w.last.ShallowCopy()
+	p.SetTime(tmax)
+	return []edge.BatchPointMessage{p}
+}
+
 // implements a purpose built ring buffer for the window of points
 type windowTimeBuffer struct {
 	window []edge.PointMessage
@@ -300,7 +448,8 @@ func (b *windowTimeBuffer) purge(oldest time.Time, inclusive bool) {
 		return t.After(oldest)
 	}
 	l := len(b.window)
-	if l == 0 {
+	// An empty buffer has start equal to stop, which would be mistaken for a wrapped full buffer.
+	if l == 0 || b.size == 0 {
 		return
 	}
 	if b.start < b.stop {
@@ -468,3 +617,250 @@ func (w *windowByCount) points() []edge.BatchPointMessage {
 	}
 	return points
 }
+
+// windowByPeriodOrCount flushes the window when it contains count points
+// or when period has elapsed since its first point, whichever comes first.
+type windowByPeriodOrCount struct {
+	name  string
+	group edge.GroupInfo
+
+	period time.Duration
+	count  int
+
+	// start of the current window, zero if the window is empty
+	start  time.Time
+	points []edge.BatchPointMessage
+}
+
+func newWindowByPeriodOrCount(
+	name string,
+	group edge.GroupInfo,
+	period time.Duration,
+	count int,
+) *windowByPeriodOrCount {
+	return &windowByPeriodOrCount{
+		name:   name,
+		group:  group,
+		period: period,
+		count:  count,
+	}
+}
+
+func (w *windowByPeriodOrCount) BeginBatch(edge.BeginBatchMessage) (edge.Message, error) {
+	return nil, errors.New("window does not support batch data")
+}
+func (w *windowByPeriodOrCount) BatchPoint(edge.BatchPointMessage) (edge.Message, error) {
+	return nil, errors.New("window does not support batch data")
+}
+func (w *windowByPeriodOrCount) EndBatch(edge.EndBatchMessage) (edge.Message, error) {
+	return nil, errors.New("window does not support batch data")
+}
+
+// Barrier flushes the window if its period has elapsed, replacing the barrier.
+func (w *windowByPeriodOrCount) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	if w.expired(b.Time()) {
+		return w.flush(w.start.Add(w.period)), nil
+	}
+	return b, nil
+}
+func (w *windowByPeriodOrCount) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (w *windowByPeriodOrCount) Done() {}
+
+// Point adds the point to the window.
+// If the period of the window has elapsed the window is flushed first and the point starts the next window,
+// otherwise the window is flushed once the point brings it to count points.
+func (w *windowByPeriodOrCount) Point(p edge.PointMessage) (msg edge.Message, err error) {
+	if w.expired(p.Time()) {
+		msg = w.flush(w.start.Add(w.period))
+	}
+	if len(w.points) == 0 {
+		w.start = p.Time()
+	}
+	w.points = append(w.points, edge.BatchPointFromPoint(p))
+	if len(w.points) == w.count {
+		// A window flushed by its period above now only holds this point,
+		// it reaches its count here only if the count is one, in which case no window can expire.
+		msg = w.flush(p.Time())
+	}
+	return
+}
+
+// expired reports whether the window is not empty and its period has elapsed at time t.
+// The end of the period is excluded from the window.
+func (w *windowByPeriodOrCount) expired(t time.Time) bool {
+	return len(w.points) > 0 && !t.Before(w.start.Add(w.period))
+}
+
+// flush returns the points of the window as a batch with the time tmax and empties the window.
+func (w *windowByPeriodOrCount) flush(tmax time.Time) edge.BufferedBatchMessage {
+	points := w.points
+	// The flushed points are owned by the batch, the next window needs a new slice.
+	w.points = nil
+	return edge.NewBufferedBatchMessage(
+		edge.NewBeginBatchMessage(
+			w.name,
+			w.group.Tags,
+			w.group.Dimensions.ByName,
+			tmax,
+			len(points),
+		),
+		points,
+		edge.NewEndBatchMessage(),
+	)
+}
+
+// windowByWatermark emits the windows aligned with every once the watermark,
+// the maximum time seen minus the lateness, passes their end.
+// Points are buffered in time order, so points arriving out of order within the lateness are included in their windows.
+type windowByWatermark struct {
+	name  string
+	group edge.GroupInfo
+
+	period   time.Duration
+	every    time.Duration
+	lateness time.Duration
+
+	n *WindowNode
+
+	// maxTime is the maximum time of the points seen
+	maxTime time.Time
+	// closed is the time up to which the windows ending at or before it have been emitted
+	closed time.Time
+	// points are the buffered points sorted by time
+	points []edge.BatchPointMessage
+}
+
+func newWindowByWatermark(
+	name string,
+	group edge.GroupInfo,
+	period,
+	every,
+	lateness time.Duration,
+	n *WindowNode,
+) *windowByWatermark {
+	return &windowByWatermark{
+		name:     name,
+		group:    group,
+		period:   period,
+		every:    every,
+		lateness: lateness,
+		n:        n,
+	}
+}
+
+func (w *windowByWatermark) BeginBatch(edge.BeginBatchMessage) (edge.Message, error) {
+	return nil, errors.New("window does not support batch data")
+}
+func (w *windowByWatermark) BatchPoint(edge.BatchPointMessage) (edge.Message, error) {
+	return nil, errors.New("window does not support batch data")
+}
+func (w *windowByWatermark) EndBatch(edge.EndBatchMessage) (edge.Message, error) {
+	return nil, errors.New("window does not support batch data")
+}
+
+// Barrier advances the watermark to the time of the barrier and emits the windows it closes.
+func (w *windowByWatermark) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	if err := w.advance(b.Time()); err != nil {
+		return nil, err
+	}
+	if err := edge.Forward(w.n.lateOuts, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+func (w *windowByWatermark) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (w *windowByWatermark) Done() {}
+
+// Point buffers the point and emits the windows closed by the new watermark.
+// Points whose windows have all been emitted are late, they are dropped or sent to the late branch.
+func (w *windowByWatermark) Point(p edge.PointMessage) (edge.Message, error) {
+	t := p.Time()
+	if !t.Truncate(w.every).Add(w.period).After(w.closed) {
+		w.n.latePoints.Add(1)
+		if len(w.n.lateOuts) == 0 {
+			return nil, nil
+		}
+		return nil, edge.Forward(w.n.lateOuts, edge.NewBufferedBatchMessage(
+			edge.NewBeginBatchMessage(
+				w.name,
+				w.group.Tags,
+				w.group.Dimensions.ByName,
+				t,
+				1,
+			),
+			[]edge.BatchPointMessage{edge.BatchPointFromPoint(p)},
+			edge.NewEndBatchMessage(),
+		))
+	}
+	// Insert the point after the points with the same time to preserve the arrival order.
+	i := sort.Search(len(w.points), func(i int) bool {
+		return w.points[i].Time().After(t)
+	})
+	w.points = append(w.points, nil)
+	copy(w.points[i+1:], w.points[i:])
+	w.points[i] = edge.BatchPointFromPoint(p)
+	return nil, w.advance(t)
+}
+
+// advance updates the maximum time seen with t and emits the windows ending at or before the watermark.
+func (w *windowByWatermark) advance(t time.Time) error {
+	if t.After(w.maxTime) {
+		w.maxTime = t
+	}
+	watermark := w.maxTime.Add(-w.lateness)
+	for len(w.points) > 0 {
+		from := w.points[0].Time()
+		if w.closed.After(from) {
+			from = w.closed
+		}
+		end := w.endAfter(from)
+		if end.After(watermark) {
+			break
+		}
+		start := end.Add(-w.period)
+		var points []edge.BatchPointMessage
+		for _, bp := range w.points {
+			if !bp.Time().Before(end) {
+				break
+			}
+			if !bp.Time().Before(start) {
+				points = append(points, bp)
+			}
+		}
+		if len(points) > 0 {
+			if err := edge.Forward(w.n.dataOuts, edge.NewBufferedBatchMessage(
+				edge.NewBeginBatchMessage(
+					w.name,
+					w.group.Tags,
+					w.group.Dimensions.ByName,
+					end,
+					len(points),
+				),
+				points,
+				edge.NewEndBatchMessage(),
+			)); err != nil {
+				return err
+			}
+		}
+		w.closed = end
+		// Purge the points before the start of the next window.
+		next := start.Add(w.every)
+		i := sort.Search(len(w.points), func(i int) bool {
+			return !w.points[i].Time().Before(next)
+		})
+		w.points = w.points[i:]
+	}
+	if watermark.After(w.closed) {
+		w.closed = watermark
+	}
+	return nil
+}
+
+// endAfter returns the earliest end of a window after t.
+func (w *windowByWatermark) endAfter(t time.Time) time.Time {
+	return t.Add(-w.period).Truncate(w.every).Add(w.every + w.period)
+}
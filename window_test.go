@@ -1,13 +1,18 @@
 package kapacitor
 
 import (
+	"reflect"
 	"testing"
 	"time"
 
 	"github.com/influxdata/kapacitor/alert"
 	"github.com/influxdata/kapacitor/edge"
+	"github.com/influxdata/kapacitor/expvar"
 	"github.com/influxdata/kapacitor/keyvalue"
 	"github.com/influxdata/kapacitor/models"
+	"github.com/influxdata/kapacitor/pipeline"
+	"github.com/influxdata/kapacitor/tick/ast"
+	"github.com/influxdata/kapacitor/tick/stateful"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -21,6 +26,7 @@ func (d *windowNodeDiagnostic) AlertTriggered(level alert.Level, id string, mess
 }
 func (d *windowNodeDiagnostic) SettingReplicas(new int, old int, id string)                        {}
 func (d *windowNodeDiagnostic) StartingBatchQuery(q string)                                        {}
+func (d *windowNodeDiagnostic) SwitchingCluster(from, to string)                                   {}
 func (d *windowNodeDiagnostic) LogBatchData(level, prefix string, batch edge.BufferedBatchMessage) {}
 func (d *windowNodeDiagnostic) LogPointData(level, prefix string, point edge.PointMessage)         {}
 func (d *windowNodeDiagnostic) UDFLog(s string)                                                    {}
@@ -211,3 +217,381 @@ func TestWindowBufferByCount(t *testing.T) {
 		}
 	}
 }
+
+func TestWindowByPeriodOrCount(t *testing.T) {
+	w := newWindowByPeriodOrCount("test", edge.GroupInfo{}, 10*time.Second, 3)
+	point := func(sec int64) edge.PointMessage {
+		return edge.NewPointMessage(
+			"name", "db", "rp",
+			models.Dimensions{},
+			nil,
+			nil,
+			time.Unix(sec, 0).UTC(),
+		)
+	}
+	testCases := []struct {
+		name string
+		// time of the point or barrier
+		sec     int64
+		barrier bool
+		// expected window, nil if no window is emitted
+		expTime   *int64
+		expPoints []int64
+	}{
+		// Count first: three points within the period.
+		{name: "first", sec: 0},
+		{name: "second", sec: 1},
+		{name: "count reached", sec: 2, expTime: int64Ptr(2), expPoints: []int64{0, 1, 2}},
+		// Time first: the window started at 5s expires at 15s.
+		{name: "new window", sec: 5},
+		{name: "barrier before period", sec: 14, barrier: true},
+		// A point exactly at the end of the period starts the next window.
+		{name: "period boundary", sec: 15, expTime: int64Ptr(15), expPoints: []int64{5}},
+		{name: "after boundary", sec: 16},
+		// Count first again, the count was reset by the period flush.
+		{name: "count after period", sec: 17, expTime: int64Ptr(17), expPoints: []int64{15, 16, 17}},
+		// Time first through a barrier.
+		{name: "after count", sec: 30},
+		{name: "barrier after period", sec: 40, barrier: true, expTime: int64Ptr(40), expPoints: []int64{30}},
+		{name: "barrier on empty window", sec: 60, barrier: true},
+	}
+	for _, tc := range testCases {
+		var msg edge.Message
+		var err error
+		if tc.barrier {
+			msg, err = w.Barrier(edge.NewBarrierMessage(edge.GroupInfo{}, time.Unix(tc.sec, 0).UTC()))
+		} else {
+			msg, err = w.Point(point(tc.sec))
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if tc.expTime == nil {
+			if msg != nil && msg.Type() != edge.Barrier {
+				t.Errorf("%s: unexpected message %v", tc.name, msg)
+			}
+			continue
+		}
+		b, ok := msg.(edge.BufferedBatchMessage)
+		if !ok {
+			t.Errorf("%s: expected window, got %v", tc.name, msg)
+			continue
+		}
+		if got, exp := b.Begin().Time(), time.Unix(*tc.expTime, 0).UTC(); !got.Equal(exp) {
+			t.Errorf("%s: unexpected window time: got %v exp %v", tc.name, got, exp)
+		}
+		points := b.Points()
+		if got, exp := len(points), len(tc.expPoints); got != exp {
+			t.Errorf("%s: unexpected number of points: got %d exp %d", tc.name, got, exp)
+			continue
+		}
+		for i, p := range points {
+			if got, exp := p.Time(), time.Unix(tc.expPoints[i], 0).UTC(); !got.Equal(exp) {
+				t.Errorf("%s: unexpected point[%d] time: got %v exp %v", tc.name, i, got, exp)
+			}
+		}
+	}
+}
+
+func TestWindowByTime_FillLast(t *testing.T) {
+	t0 := time.Unix(0, 0).UTC()
+	w := newWindowByTime("test", t0, edge.GroupInfo{}, 10*time.Second, 10*time.Second, true, false, true, 2, nil, nil, &windowNodeDiagnostic{})
+	point := func(sec int64, value float64) edge.PointMessage {
+		return edge.NewPointMessage(
+			"name", "db", "rp",
+			models.Dimensions{},
+			models.Fields{"value": value},
+			models.Tags{"host": "a"},
+			time.Unix(sec, 0).UTC(),
+		)
+	}
+	testCases := []struct {
+		name string
+		// time of the point or barrier
+		sec     int64
+		barrier bool
+		// expected window, nil if no window is emitted
+		expTime *int64
+		// expected values of the points of the window
+		expValues []float64
+		// whether the window is filled with the last point of the previous window
+		expFilled bool
+	}{
+		{name: "first", sec: 1},
+		{name: "second", sec: 5},
+		{name: "window with points", sec: 10, barrier: true, expTime: int64Ptr(10), expValues: []float64{1, 5}},
+		// Empty windows are filled with the last point of the previous window.
+		{name: "first empty window", sec: 20, barrier: true, expTime: int64Ptr(20), expValues: []float64{5}, expFilled: true},
+		{name: "second empty window", sec: 30, barrier: true, expTime: int64Ptr(30), expValues: []float64{5}, expFilled: true},
+		// Filling stops after the maximum number of consecutive empty windows.
+		{name: "empty window after max", sec: 40, barrier: true, expTime: int64Ptr(40), expValues: []float64{}},
+		{name: "point closing empty window", sec: 55, expTime: int64Ptr(50), expValues: []float64{}},
+		// A window with points resets the count.
+		{name: "window after points", sec: 60, barrier: true, expTime: int64Ptr(60), expValues: []float64{55}},
+		{name: "empty window after points", sec: 70, barrier: true, expTime: int64Ptr(70), expValues: []float64{55}, expFilled: true},
+	}
+	for _, tc := range testCases {
+		var msg edge.Message
+		var err error
+		if tc.barrier {
+			msg, err = w.Barrier(edge.NewBarrierMessage(edge.GroupInfo{}, time.Unix(tc.sec, 0).UTC()))
+		} else {
+			msg, err = w.Point(point(tc.sec, float64(tc.sec)))
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if tc.expTime == nil {
+			if msg != nil {
+				t.Errorf("%s: unexpected message %v", tc.name, msg)
+			}
+			continue
+		}
+		b, ok := msg.(edge.BufferedBatchMessage)
+		if !ok {
+			t.Errorf("%s: expected window, got %v", tc.name, msg)
+			continue
+		}
+		expTime := time.Unix(*tc.expTime, 0).UTC()
+		if got := b.Begin().Time(); !got.Equal(expTime) {
+			t.Errorf("%s: unexpected window time: got %v exp %v", tc.name, got, expTime)
+		}
+		points := b.Points()
+		if got, exp := len(points), len(tc.expValues); got != exp {
+			t.Errorf("%s: unexpected number of points: got %d exp %d", tc.name, got, exp)
+			continue
+		}
+		for i, p := range points {
+			if got, exp := p.Fields()["value"], tc.expValues[i]; got != exp {
+				t.Errorf("%s: unexpected point[%d] value: got %v exp %v", tc.name, i, got, exp)
+			}
+			if got, exp := p.Tags()["host"], "a"; got != exp {
+				t.Errorf("%s: unexpected point[%d] host: got %v exp %v", tc.name, i, got, exp)
+			}
+			// A filled point has the time of the window.
+			if got := p.Time(); tc.expFilled && !got.Equal(expTime) {
+				t.Errorf("%s: unexpected filled point time: got %v exp %v", tc.name, got, expTime)
+			}
+		}
+	}
+}
+
+func TestWindowByWatermark(t *testing.T) {
+	point := func(sec int64) edge.PointMessage {
+		return edge.NewPointMessage(
+			"name", "db", "rp",
+			models.Dimensions{},
+			nil,
+			nil,
+			time.Unix(sec, 0).UTC(),
+		)
+	}
+	type window struct {
+		sec    int64
+		points []int64
+	}
+	testCases := []struct {
+		name       string
+		period     time.Duration
+		every      time.Duration
+		lateness   time.Duration
+		lateOutput bool
+		// times of the points, negative times are barriers
+		input   []int64
+		exp     []window
+		expLate []int64
+	}{
+		{
+			name:     "out of order with late point dropped",
+			period:   10 * time.Second,
+			every:    10 * time.Second,
+			lateness: 5 * time.Second,
+			input:    []int64{1, 8, 3, 12, 11, 16, 4, 25, -40},
+			exp: []window{
+				{sec: 10, points: []int64{1, 3, 8}},
+				{sec: 20, points: []int64{11, 12, 16}},
+				{sec: 30, points: []int64{25}},
+			},
+			expLate: []int64{4},
+		},
+		{
+			name:       "out of order with late output",
+			period:     10 * time.Second,
+			every:      10 * time.Second,
+			lateness:   5 * time.Second,
+			lateOutput: true,
+			input:      []int64{1, 8, 3, 12, 11, 16, 4, 9, 25},
+			exp: []window{
+				{sec: 10, points: []int64{1, 3, 8}},
+				{sec: 20, points: []int64{11, 12, 16}},
+			},
+			expLate: []int64{4, 9},
+		},
+		{
+			name:   "overlapping windows",
+			period: 10 * time.Second,
+			every:  5 * time.Second,
+			input:  []int64{1, 6, 12, 17, 3},
+			exp: []window{
+				{sec: 5, points: []int64{1}},
+				{sec: 10, points: []int64{1, 6}},
+				{sec: 15, points: []int64{6, 12}},
+			},
+			expLate: []int64{3},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			out := edge.NewChannelEdge(pipeline.BatchEdge, 100)
+			late := edge.NewChannelEdge(pipeline.BatchEdge, 100)
+			n := &WindowNode{
+				w:          &pipeline.WindowNode{LateOutputFlag: tc.lateOutput},
+				dataOuts:   []edge.StatsEdge{edge.NewStatsEdge(out)},
+				latePoints: new(expvar.Int),
+			}
+			if tc.lateOutput {
+				n.lateOuts = []edge.StatsEdge{edge.NewStatsEdge(late)}
+			}
+			w := newWindowByWatermark("test", edge.GroupInfo{}, tc.period, tc.every, tc.lateness, n)
+			for _, sec := range tc.input {
+				var err error
+				if sec < 0 {
+					_, err = w.Barrier(edge.NewBarrierMessage(edge.GroupInfo{}, time.Unix(-sec, 0).UTC()))
+				} else {
+					_, err = w.Point(point(sec))
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			out.Close()
+			late.Close()
+
+			var got []window
+			for m, ok := out.Emit(); ok; m, ok = out.Emit() {
+				b, ok := m.(edge.BufferedBatchMessage)
+				if !ok {
+					t.Fatalf("expected window, got %v", m)
+				}
+				w := window{sec: b.Begin().Time().Unix()}
+				for _, p := range b.Points() {
+					w.points = append(w.points, p.Time().Unix())
+				}
+				got = append(got, w)
+			}
+			if !reflect.DeepEqual(got, tc.exp) {
+				t.Errorf("unexpected windows:\ngot %v\nexp %v", got, tc.exp)
+			}
+
+			var gotLate []int64
+			for m, ok := late.Emit(); ok; m, ok = late.Emit() {
+				if m.Type() == edge.Barrier {
+					continue
+				}
+				b := m.(edge.BufferedBatchMessage)
+				if len(b.Points()) != 1 || !b.Begin().Time().Equal(b.Points()[0].Time()) {
+					t.Fatalf("unexpected late batch %v", b)
+				}
+				gotLate = append(gotLate, b.Begin().Time().Unix())
+			}
+			if tc.lateOutput && !reflect.DeepEqual(gotLate, tc.expLate) {
+				t.Errorf("unexpected late points: got %v exp %v", gotLate, tc.expLate)
+			}
+			if got, exp := n.latePoints.IntValue(), int64(len(tc.expLate)); got != exp {
+				t.Errorf("unexpected late_points: got %d exp %d", got, exp)
+			}
+		})
+	}
+}
+
+func TestWindowByTime_Trigger(t *testing.T) {
+	type window struct {
+		sec    int64
+		points []int64
+	}
+	testCases := []struct {
+		name    string
+		realign bool
+		// times of the points, negative times are the absolute times of trigger points
+		input []int64
+		exp   []window
+	}{
+		{
+			name:  "unchanged schedule",
+			input: []int64{0, -5, 7, 12, -15, 22, 31},
+			exp: []window{
+				{sec: 5, points: []int64{0, 5}},
+				{sec: 10, points: []int64{7}},
+				{sec: 15, points: []int64{12, 15}},
+				// The points of the period were flushed by the trigger.
+				{sec: 22},
+			},
+		},
+		{
+			name:    "realign",
+			realign: true,
+			input:   []int64{0, -5, 7, 12, -15, 22, 31},
+			exp: []window{
+				{sec: 5, points: []int64{0, 5}},
+				// The trigger point closes the realigned window before flushing itself.
+				{sec: 15, points: []int64{7, 12}},
+				{sec: 15, points: []int64{15}},
+				{sec: 25, points: []int64{22}},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			lambda := &ast.LambdaNode{Expression: &ast.ReferenceNode{Reference: "end"}}
+			expr, err := stateful.NewExpression(lambda.Expression)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out := edge.NewChannelEdge(pipeline.BatchEdge, 100)
+			n := &WindowNode{
+				w:                &pipeline.WindowNode{TriggerRealignFlag: tc.realign},
+				dataOuts:         []edge.StatsEdge{edge.NewStatsEdge(out)},
+				triggerScopePool: stateful.NewScopePool(ast.FindReferenceVariables(lambda.Expression)),
+			}
+			w := newWindowByTime("test", time.Unix(0, 0).UTC(), edge.GroupInfo{}, 10*time.Second, 10*time.Second, false, false, false, 0, expr, n, &windowNodeDiagnostic{})
+			for _, sec := range tc.input {
+				end := sec < 0
+				if end {
+					sec = -sec
+				}
+				p := edge.NewPointMessage(
+					"name", "db", "rp",
+					models.Dimensions{},
+					models.Fields{"end": end},
+					nil,
+					time.Unix(sec, 0).UTC(),
+				)
+				msg, err := w.Point(p)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if msg != nil {
+					if err := out.Collect(msg); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+			out.Close()
+
+			var got []window
+			for m, ok := out.Emit(); ok; m, ok = out.Emit() {
+				b := m.(edge.BufferedBatchMessage)
+				w := window{sec: b.Begin().Time().Unix()}
+				for _, p := range b.Points() {
+					w.points = append(w.points, p.Time().Unix())
+				}
+				got = append(got, w)
+			}
+			if !reflect.DeepEqual(got, tc.exp) {
+				t.Errorf("unexpected windows:\ngot %v\nexp %v", got, tc.exp)
+			}
+		})
+	}
+}
+
+func int64Ptr(v int64) *int64 { return &v }